@@ -0,0 +1,345 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/cor0nius/willitrain/internal/database"
+)
+
+// This file implements /api/airquality, which returns the air quality index and the PM2.5, PM10
+// and ozone concentrations at a location. The readings come from the Open-Meteo Air Quality API
+// and the OpenWeatherMap Air Pollution API, the two configured providers that publish them, and
+// go through the same pipeline as the current weather: Redis first, then the database, then the
+// providers, with a scheduler job refreshing the stored readings of every known location.
+//
+// The providers report the index on different scales, the European AQI and OWM's 1 to 5 scale,
+// so every reading is also mapped to a category shared by both.
+
+const (
+	// airQualityJobName is the scheduler job that refreshes the stored air quality readings.
+	airQualityJobName = "air quality"
+
+	// airQualityCacheTTL is how long stored readings are considered fresh. Both providers update
+	// their readings hourly.
+	airQualityCacheTTL      = 1 * time.Hour
+	redisAirQualityCacheTTL = 55 * time.Minute
+)
+
+// @Summary      Get air quality
+// @Description  Retrieves the air quality index and the PM2.5, PM10 and ozone concentrations for a specified
+// @Description  location, from the Open-Meteo Air Quality and OpenWeatherMap Air Pollution APIs. The index is
+// @Description  given on each provider's own scale and mapped to a common category.
+// @Description  The location can be identified by its name, or by latitude and longitude.
+// @Tags         weather
+// @Produce      json
+// @Param        city    query     string  false  "Location name to search for (e.g., 'London')"
+// @Param        lat     query     number  false  "Latitude for the location (e.g., 51.5074)"
+// @Param        lon     query     number  false  "Longitude for the location (e.g., -0.1278)"
+// @Success      200  {object}  AirQualityResponse
+// @Failure      400  {object}  ErrorResponse "Bad Request - Invalid location parameters"
+// @Failure      500  {object}  ErrorResponse "Internal Server Error - Failed to retrieve air quality data"
+// @Router       /api/v1/airquality [get]
+func (cfg *apiConfig) handlerAirQuality(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		cfg.respondWithError(w, http.StatusMethodNotAllowed, "Method Not Allowed", nil)
+		return
+	}
+
+	location, err := cfg.getLocationFromRequest(r)
+	if err != nil {
+		cfg.respondWithError(w, http.StatusBadRequest, "Error getting location data", err)
+		return
+	}
+	cfg.logger.Debug("air quality request", "city", location.CityName)
+
+	airQuality, err := cfg.getCachedOrFetchAirQuality(r.Context(), location)
+	if err != nil {
+		cfg.respondWithError(w, http.StatusInternalServerError, "Error getting air quality data", err)
+		return
+	}
+
+	cfg.respondWithJSON(w, http.StatusOK, airQualityResponse(location, airQuality, cfg.logger))
+}
+
+// airQualityResponse formats air quality readings for the API response, ordered by source, with
+// times in the location's timezone.
+func airQualityResponse(location Location, airQuality []AirQuality, logger *slog.Logger) AirQualityResponse {
+	loc, err := time.LoadLocation(location.Timezone)
+	if err != nil {
+		logger.Warn("could not load location timezone, falling back to UTC", "timezone", location.Timezone, "error", err)
+		loc = time.UTC
+	}
+
+	sort.Slice(airQuality, func(i, j int) bool {
+		return airQuality[i].SourceAPI < airQuality[j].SourceAPI
+	})
+
+	airQualityJSON := make([]AirQualityJSON, len(airQuality))
+	sources := make([]string, len(airQuality))
+	for i, a := range airQuality {
+		scale := airQualityScale(a.SourceAPI)
+		airQualityJSON[i] = AirQualityJSON{
+			SourceAPI: a.SourceAPI,
+			Timestamp: a.Timestamp.In(loc).Format("2006-01-02 15:04"),
+			AQI:       a.AQI,
+			AQIScale:  scale,
+			Category:  airQualityCategory(scale, a.AQI),
+			PM25:      a.PM25,
+			PM10:      a.PM10,
+			Ozone:     a.Ozone,
+		}
+		sources[i] = a.SourceAPI
+	}
+
+	return AirQualityResponse{
+		Location:    location,
+		AirQuality:  airQualityJSON,
+		Attribution: attributionForSources(sources),
+	}
+}
+
+// airQualityScale returns the index scale a source reports the AQI on.
+func airQualityScale(sourceAPI string) string {
+	if sourceAPI == "OpenWeatherMap API" {
+		return "owm"
+	}
+	return "european_aqi"
+}
+
+// airQualityCategory maps an index on the given scale to a common category. The European AQI
+// bands are 20 wide; OWM's 1 to 5 scale uses the same names up to "very_poor".
+func airQualityCategory(scale string, aqi int32) string {
+	categories := []string{"good", "fair", "moderate", "poor", "very_poor", "extremely_poor"}
+	if scale == "owm" {
+		if aqi < 1 || aqi > 5 {
+			return "unknown"
+		}
+		return categories[aqi-1]
+	}
+	if aqi < 0 {
+		return "unknown"
+	}
+	return categories[min((max(aqi, 1)-1)/20, int32(len(categories)-1))]
+}
+
+// getCachedOrFetchAirQuality is the air quality implementation of the generic getCachedOrFetch
+// helper. Only the enabled providers that publish air quality data are expected in the cache.
+func (cfg *apiConfig) getCachedOrFetchAirQuality(ctx context.Context, location Location) ([]AirQuality, error) {
+	return getCachedOrFetch(
+		cfg,
+		ctx,
+		location,
+		airQualityCacheKeyPrefix,
+		airQualityCacheTTL,
+		redisAirQualityCacheTTL,
+		cfg.dbQueries.GetAirQualityAtLocation,
+		cfg.requestAirQuality,
+		cfg.persistAirQuality,
+		databaseAirQualityToAirQuality,
+		func(d database.AirQuality) time.Time {
+			return d.UpdatedAt
+		},
+		func(items []AirQuality) bool {
+			return len(items) == cfg.airQualitySourceCount()
+		},
+	)
+}
+
+// airQualitySourceCount returns the number of enabled providers that publish air quality data.
+func (cfg *apiConfig) airQualitySourceCount() int {
+	count := 0
+	for _, id := range []string{"owm", "ometeo"} {
+		if cfg.sourceEnabled(id) {
+			count++
+		}
+	}
+	return count
+}
+
+// requestAirQuality fetches the air quality at a location from the Open-Meteo and OWM APIs.
+func (cfg *apiConfig) requestAirQuality(ctx context.Context, location Location, onLate func([]AirQuality), onOutcome func(providerFetchOutcome)) ([]AirQuality, error) {
+	urls := cfg.WrapForAirQuality(location)
+
+	providers := map[string]forecastProvider[AirQuality]{
+		"owmWrappedURL": {
+			parser:   ParseAirQualityOWM,
+			errorVal: AirQuality{SourceAPI: "OpenWeatherMap API"},
+		},
+		"ometeoWrappedURL": {
+			parser:   ParseAirQualityOMeteo,
+			errorVal: AirQuality{SourceAPI: "Open-Meteo API"},
+		},
+	}
+
+	var late func(AirQuality)
+	if onLate != nil {
+		late = func(a AirQuality) {
+			a.Location = location
+			onLate([]AirQuality{a})
+		}
+	}
+
+	results, tz, err := processForecastRequests(cfg, ctx, location, urls, providers, late, onOutcome)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.reconcileTimezone(ctx, location, tz)
+
+	for i := range results {
+		results[i].Location = location
+	}
+
+	return results, nil
+}
+
+// persistAirQuality saves air quality readings to the database, one row per location and source.
+func (cfg *apiConfig) persistAirQuality(ctx context.Context, airQualityData []AirQuality) {
+	for _, airQuality := range airQualityData {
+		cfg.upsertWeatherItem(ctx,
+			func() (any, error) {
+				return cfg.dbQueries.GetAirQualityAtLocationFromAPI(ctx, database.GetAirQualityAtLocationFromAPIParams{
+					LocationID: airQuality.Location.LocationID,
+					SourceApi:  airQuality.SourceAPI,
+				})
+			},
+			func() (any, error) {
+				return cfg.dbQueries.CreateAirQuality(ctx, airQualityToCreateAirQualityParams(airQuality))
+			},
+			func(existing any) (any, error) {
+				existingAirQuality, ok := existing.(database.AirQuality)
+				if !ok {
+					return nil, fmt.Errorf("unexpected type for existing item: %T", existing)
+				}
+				return cfg.dbQueries.UpdateAirQuality(ctx, airQualityToUpdateAirQualityParams(airQuality, existingAirQuality.ID))
+			},
+			map[string]string{
+				"location": airQuality.Location.CityName,
+				"api":      airQuality.SourceAPI,
+				"type":     "air quality",
+			},
+		)
+	}
+}
+
+// airQualityJob returns the scheduler job that refreshes the air quality of every location.
+func (s *Scheduler) airQualityJob(interval time.Duration) SchedulerJob {
+	return SchedulerJob{Name: airQualityJobName, Interval: interval, Run: s.runAirQualityJobs}
+}
+
+// runAirQualityJobs updates the air quality of each location.
+func (s *Scheduler) runAirQualityJobs(ctx context.Context) error {
+	return s.runUpdateForLocations(ctx, airQualityJobName, s.updateAirQuality)
+}
+
+// updateAirQuality deletes the stored air quality of a location and requests new readings,
+// saving the outcome of every provider as a scheduler run report.
+func (s *Scheduler) updateAirQuality(ctx context.Context, location Location) error {
+	if err := s.cfg.dbQueries.DeleteAirQualityAtLocation(ctx, location.LocationID); err != nil {
+		s.cfg.logger.Error("failed to delete air quality", "location", location.CityName, "error", err)
+		return err
+	}
+	runs := newSchedulerRunRecorder(airQualityJobName, location)
+	defer s.cfg.saveSchedulerRuns(ctx, runs)
+	airQuality, err := s.cfg.requestAirQuality(ctx, location, nil, runs.observe)
+	if err != nil {
+		s.cfg.logger.Error("failed to request air quality", "location", location.CityName, "error", err)
+		return err
+	}
+	s.cfg.persistAirQuality(ctx, airQuality)
+	s.cfg.logger.Debug("updated air quality", "location", location.CityName)
+	return nil
+}
+
+// ParseAirQualityOMeteo decodes the JSON response from the Open-Meteo Air Quality API and maps it to the internal AirQuality struct.
+func ParseAirQualityOMeteo(body io.Reader, logger *slog.Logger) (AirQuality, string, error) {
+	var response ResponseAirQualityOMeteo
+
+	if err := json.NewDecoder(body).Decode(&response); err != nil {
+		return AirQuality{SourceAPI: "Open-Meteo API"}, "", err
+	}
+	if response.Current.Time == 0 {
+		return AirQuality{SourceAPI: "Open-Meteo API"}, "", errors.New("empty or invalid response from API")
+	}
+
+	loc, err := time.LoadLocation(response.Timezone)
+	if err != nil {
+		logger.Warn("Failed to load timezone, using UTC as fallback", "error", err)
+		loc = time.UTC
+	}
+
+	airQuality := AirQuality{
+		SourceAPI: "Open-Meteo API",
+		Timestamp: time.Unix(response.Current.Time, 0).UTC().In(loc),
+		AQI:       int32(math.Round(response.Current.EuropeanAQI)),
+		PM25:      response.Current.PM25,
+		PM10:      response.Current.PM10,
+		Ozone:     response.Current.Ozone,
+	}
+
+	return airQuality, response.Timezone, nil
+}
+
+// ParseAirQualityOWM decodes the JSON response from the OpenWeatherMap Air Pollution API and maps it to the internal AirQuality struct.
+// The response carries no timezone.
+func ParseAirQualityOWM(body io.Reader, logger *slog.Logger) (AirQuality, string, error) {
+	var response ResponseAirQualityOWM
+
+	if err := json.NewDecoder(body).Decode(&response); err != nil {
+		return AirQuality{SourceAPI: "OpenWeatherMap API"}, "", err
+	}
+	if len(response.List) == 0 || response.List[0].Dt == 0 {
+		return AirQuality{SourceAPI: "OpenWeatherMap API"}, "", errors.New("empty or invalid response from API")
+	}
+
+	reading := response.List[0]
+	airQuality := AirQuality{
+		SourceAPI: "OpenWeatherMap API",
+		Timestamp: time.Unix(reading.Dt, 0).UTC(),
+		AQI:       reading.Main.AQI,
+		PM25:      reading.Components.PM25,
+		PM10:      reading.Components.PM10,
+		Ozone:     reading.Components.O3,
+	}
+
+	return airQuality, "", nil
+}
+
+// The following structs are used to unmarshal the JSON responses of the air quality APIs.
+type ResponseAirQualityOMeteo struct {
+	Current  CurrentAirQualityOMeteo `json:"current"`
+	Timezone string                  `json:"timezone"`
+}
+
+type CurrentAirQualityOMeteo struct {
+	Time        int64   `json:"time"`
+	EuropeanAQI float64 `json:"european_aqi"`
+	PM25        float64 `json:"pm2_5"`
+	PM10        float64 `json:"pm10"`
+	Ozone       float64 `json:"ozone"`
+}
+
+type ResponseAirQualityOWM struct {
+	List []AirQualityReadingOWM `json:"list"`
+}
+
+type AirQualityReadingOWM struct {
+	Dt   int64 `json:"dt"`
+	Main struct {
+		AQI int32 `json:"aqi"`
+	} `json:"main"`
+	Components struct {
+		PM25 float64 `json:"pm2_5"`
+		PM10 float64 `json:"pm10"`
+		O3   float64 `json:"o3"`
+	} `json:"components"`
+}
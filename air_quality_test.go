@@ -0,0 +1,300 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cor0nius/willitrain/internal/database"
+	"github.com/google/uuid"
+)
+
+func TestParseAirQuality(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	testCases := []struct {
+		name     string
+		file     string
+		parser   func(io.Reader, *slog.Logger) (AirQuality, string, error)
+		want     AirQuality
+		wantTZ   string
+		wantZone string
+	}{
+		{
+			name:   "Open-Meteo",
+			file:   "testdata/air_quality_ometeo.json",
+			parser: ParseAirQualityOMeteo,
+			want: AirQuality{
+				SourceAPI: "Open-Meteo API",
+				Timestamp: time.Unix(1754312400, 0),
+				AQI:       42,
+				PM25:      14.3,
+				PM10:      21.7,
+				Ozone:     88.0,
+			},
+			wantTZ:   "Europe/Warsaw",
+			wantZone: "Europe/Warsaw",
+		},
+		{
+			name:   "OpenWeatherMap",
+			file:   "testdata/air_quality_owm.json",
+			parser: ParseAirQualityOWM,
+			want: AirQuality{
+				SourceAPI: "OpenWeatherMap API",
+				Timestamp: time.Unix(1754312400, 0),
+				AQI:       2,
+				PM25:      11.2,
+				PM10:      16.45,
+				Ozone:     79.45,
+			},
+			wantZone: "UTC",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sampleJSON, err := testData.Open(tc.file)
+			if err != nil {
+				t.Fatalf("failed to open test data: %v", err)
+			}
+			defer sampleJSON.Close()
+
+			got, tz, err := tc.parser(sampleJSON, logger)
+			if err != nil {
+				t.Fatalf("parser failed with error: %v", err)
+			}
+			if tz != tc.wantTZ {
+				t.Errorf("expected timezone %q, got %q", tc.wantTZ, tz)
+			}
+			if got.Timestamp.Location().String() != tc.wantZone {
+				t.Errorf("expected timestamp in %s, got %s", tc.wantZone, got.Timestamp.Location())
+			}
+			if !got.Timestamp.Equal(tc.want.Timestamp) {
+				t.Errorf("expected timestamp %v, got %v", tc.want.Timestamp, got.Timestamp)
+			}
+			got.Timestamp = tc.want.Timestamp
+			if got != tc.want {
+				t.Errorf("got %+v, want %+v", got, tc.want)
+			}
+		})
+
+		t.Run(tc.name+" Invalid Response", func(t *testing.T) {
+			got, _, err := tc.parser(strings.NewReader(`{}`), logger)
+			if err == nil {
+				t.Error("expected an error for an empty response, got nil")
+			}
+			if got.SourceAPI != tc.want.SourceAPI {
+				t.Errorf("expected the error value to carry source %q, got %q", tc.want.SourceAPI, got.SourceAPI)
+			}
+			if _, _, err := tc.parser(strings.NewReader(`{"current":`), logger); err == nil {
+				t.Error("expected an error for malformed JSON, got nil")
+			}
+		})
+	}
+}
+
+func TestAirQualityCategory(t *testing.T) {
+	testCases := []struct {
+		scale string
+		aqi   int32
+		want  string
+	}{
+		{"european_aqi", 0, "good"},
+		{"european_aqi", 20, "good"},
+		{"european_aqi", 21, "fair"},
+		{"european_aqi", 42, "moderate"},
+		{"european_aqi", 80, "poor"},
+		{"european_aqi", 100, "very_poor"},
+		{"european_aqi", 180, "extremely_poor"},
+		{"european_aqi", -1, "unknown"},
+		{"owm", 1, "good"},
+		{"owm", 2, "fair"},
+		{"owm", 5, "very_poor"},
+		{"owm", 0, "unknown"},
+		{"owm", 6, "unknown"},
+	}
+
+	for _, tc := range testCases {
+		if got := airQualityCategory(tc.scale, tc.aqi); got != tc.want {
+			t.Errorf("airQualityCategory(%q, %d) = %q, want %q", tc.scale, tc.aqi, got, tc.want)
+		}
+	}
+}
+
+func TestHandlerAirQuality(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	fresh := []database.AirQuality{
+		{
+			ID:         uuid.New(),
+			LocationID: MockLocation.LocationID,
+			SourceApi:  "Open-Meteo API",
+			UpdatedAt:  now,
+			Aqi:        sql.NullInt32{Int32: 42, Valid: true},
+			Pm25Ugm3:   sql.NullFloat64{Float64: 14.3, Valid: true},
+			Pm10Ugm3:   sql.NullFloat64{Float64: 21.7, Valid: true},
+			OzoneUgm3:  sql.NullFloat64{Float64: 88, Valid: true},
+		},
+		{
+			ID:         uuid.New(),
+			LocationID: MockLocation.LocationID,
+			SourceApi:  "OpenWeatherMap API",
+			UpdatedAt:  now,
+			Aqi:        sql.NullInt32{Int32: 2, Valid: true},
+			Pm25Ugm3:   sql.NullFloat64{Float64: 11.2, Valid: true},
+			Pm10Ugm3:   sql.NullFloat64{Float64: 16.45, Valid: true},
+			OzoneUgm3:  sql.NullFloat64{Float64: 79.45, Valid: true},
+		},
+	}
+	stale := []database.AirQuality{fresh[0]}
+	stale[0].UpdatedAt = now.Add(-2 * time.Hour)
+
+	testCases := []struct {
+		name           string
+		method         string
+		setupMocks     func(cfg *testAPIConfig)
+		enabledSources map[string]bool
+		wantStatus     int
+		wantScales     map[string]string
+		wantCategories map[string]string
+		wantUpstream   []string
+		wantPersisted  int
+	}{
+		{
+			name: "Fetched From Providers",
+			setupMocks: func(cfg *testAPIConfig) {
+				cfg.mockDB.GetAirQualityAtLocationFunc = func(ctx context.Context, locationID uuid.UUID) ([]database.AirQuality, error) {
+					return stale, nil
+				}
+			},
+			wantStatus:     http.StatusOK,
+			wantScales:     map[string]string{"Open-Meteo API": "european_aqi", "OpenWeatherMap API": "owm"},
+			wantCategories: map[string]string{"Open-Meteo API": "moderate", "OpenWeatherMap API": "fair"},
+			wantUpstream:   []string{"ometeo", "owm"},
+			wantPersisted:  2,
+		},
+		{
+			name: "Fresh In Database",
+			setupMocks: func(cfg *testAPIConfig) {
+				cfg.mockDB.GetAirQualityAtLocationFunc = func(ctx context.Context, locationID uuid.UUID) ([]database.AirQuality, error) {
+					return fresh, nil
+				}
+			},
+			wantStatus:     http.StatusOK,
+			wantScales:     map[string]string{"Open-Meteo API": "european_aqi", "OpenWeatherMap API": "owm"},
+			wantCategories: map[string]string{"Open-Meteo API": "moderate", "OpenWeatherMap API": "fair"},
+		},
+		{
+			name: "Only Open-Meteo Enabled",
+			setupMocks: func(cfg *testAPIConfig) {
+				cfg.mockDB.GetAirQualityAtLocationFunc = func(ctx context.Context, locationID uuid.UUID) ([]database.AirQuality, error) {
+					return fresh, nil
+				}
+			},
+			enabledSources: map[string]bool{"ometeo": true},
+			wantStatus:     http.StatusOK,
+			wantScales:     map[string]string{"Open-Meteo API": "european_aqi"},
+			wantCategories: map[string]string{"Open-Meteo API": "moderate"},
+		},
+		{
+			name: "Database Error",
+			setupMocks: func(cfg *testAPIConfig) {
+				cfg.mockDB.GetAirQualityAtLocationFunc = func(ctx context.Context, locationID uuid.UUID) ([]database.AirQuality, error) {
+					return nil, errors.New("db down")
+				}
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name:       "Wrong Method",
+			method:     http.MethodPost,
+			setupMocks: func(cfg *testAPIConfig) {},
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var upstream []string
+			serve := func(name, file string) *httptest.Server {
+				return setupMockServer(func(w http.ResponseWriter, r *http.Request) {
+					mu.Lock()
+					upstream = append(upstream, name)
+					mu.Unlock()
+					data, err := testData.ReadFile(file)
+					if err != nil {
+						t.Errorf("failed to read test data: %v", err)
+					}
+					_, _ = w.Write(data)
+				})
+			}
+			ometeoServer := serve("ometeo", "testdata/air_quality_ometeo.json")
+			defer ometeoServer.Close()
+			owmServer := serve("owm", "testdata/air_quality_owm.json")
+			defer owmServer.Close()
+
+			testCfg := newTestAPIConfig(t)
+			testCfg.ometeoAirQualityURL = ometeoServer.URL + "/?"
+			testCfg.owmAirPollutionURL = owmServer.URL + "/?"
+			testCfg.enabledSources = tc.enabledSources
+			testCfg.mockDB.GetLocationByAliasFunc = func(ctx context.Context, alias string) (database.Location, error) {
+				return MockDBLocation, nil
+			}
+			testCfg.mockDB.GetAirQualityAtLocationFromAPIFunc = func(ctx context.Context, arg database.GetAirQualityAtLocationFromAPIParams) (database.AirQuality, error) {
+				return database.AirQuality{}, sql.ErrNoRows
+			}
+			tc.setupMocks(testCfg)
+
+			method := tc.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			req := httptest.NewRequest(method, "/api/v1/airquality?city=Wroclaw", nil)
+			rr := httptest.NewRecorder()
+
+			testCfg.apiConfig.handlerAirQuality(rr, req)
+
+			if status := rr.Code; status != tc.wantStatus {
+				t.Fatalf("handler returned wrong status code: got %v want %v, body %s", status, tc.wantStatus, rr.Body.String())
+			}
+			if got := testCfg.mockDB.Calls("CreateAirQuality"); got != tc.wantPersisted {
+				t.Errorf("expected %d persisted readings, got %d", tc.wantPersisted, got)
+			}
+			mu.Lock()
+			slices.Sort(upstream)
+			if strings.Join(upstream, ",") != strings.Join(tc.wantUpstream, ",") {
+				t.Errorf("expected upstream calls %v, got %v", tc.wantUpstream, upstream)
+			}
+			mu.Unlock()
+			if tc.wantStatus != http.StatusOK {
+				return
+			}
+
+			var response AirQualityResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if len(response.AirQuality) != len(tc.wantScales) {
+				t.Fatalf("expected %d readings, got %+v", len(tc.wantScales), response.AirQuality)
+			}
+			for _, a := range response.AirQuality {
+				if a.AQIScale != tc.wantScales[a.SourceAPI] || a.Category != tc.wantCategories[a.SourceAPI] {
+					t.Errorf("unexpected scale or category for %s: %+v", a.SourceAPI, a)
+				}
+			}
+			if len(response.Attribution) != len(tc.wantScales) {
+				t.Errorf("expected attribution for %d sources, got %+v", len(tc.wantScales), response.Attribution)
+			}
+		})
+	}
+}
@@ -0,0 +1,545 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/cor0nius/willitrain/internal/database"
+	"github.com/google/uuid"
+)
+
+// This file implements rain alerts. A subscriber, identified like a watchlist subscriber, registers
+// a rule for a location, such as "precipitation_chance > 60 within the next 12 hours", together
+// with a webhook URL. After every hourly forecast refresh the scheduler evaluates the rules of the
+// refreshed location against the consensus of all sources. When a rule starts to match, a webhook
+// call is queued; it fires once per episode and again only after the rule stopped matching in
+// between. Queued calls are sent by the alert delivery job, which retries failed calls with
+// exponential backoff.
+
+const (
+	alertDeliveryJobName  = "alert delivery"
+	alertDeliveryInterval = time.Minute
+
+	// alertDeliveryBatch is the number of deliveries sent per run at most. Claimed deliveries are
+	// leased for alertDeliveryLease, so a crashed worker's deliveries are retried after the lease.
+	alertDeliveryBatch = 50
+	alertDeliveryLease = 5 * time.Minute
+
+	// A failed delivery is retried after alertDeliveryBackoff, doubling with every attempt, and
+	// given up after alertDeliveryMaxAttempts attempts. Finished deliveries are kept for
+	// alertDeliveryRetention.
+	alertDeliveryMaxAttempts = 6
+	alertDeliveryBackoff     = time.Minute
+	alertDeliveryRetention   = 7 * 24 * time.Hour
+
+	defaultAlertWindowHours = 12
+	maxAlertWindowHours     = 24
+	maxAlertSubscriptions   = 20
+
+	// alertTriggeredEvent is the event name sent in every webhook payload.
+	alertTriggeredEvent = "alert.triggered"
+)
+
+// errAlertSubscriptionLimit is returned when a subscriber already has maxAlertSubscriptions rules.
+var errAlertSubscriptionLimit = fmt.Errorf("at most %d alerts per subscriber are allowed", maxAlertSubscriptions)
+
+// alertMetric is an hourly forecast field that alert rules can compare. The value of an hour is
+// the median of all sources, or their average if mean is set, as in the consensus forecast.
+type alertMetric struct {
+	unit   string
+	value  func(HourlyForecast) float64
+	mean   bool
+	spread spreadThresholds
+}
+
+// alertMetrics lists the fields that alert rules can compare, by name.
+var alertMetrics = map[string]alertMetric{
+	"precipitation_chance": {unit: "%", value: func(f HourlyForecast) float64 { return float64(f.PrecipitationChance) }, mean: true, spread: precipitationChanceSpread},
+	"precipitation":        {unit: "mm", value: func(f HourlyForecast) float64 { return f.Precipitation }, spread: precipitationSpread},
+	"temperature":          {unit: "°C", value: func(f HourlyForecast) float64 { return f.Temperature }, spread: temperatureSpread},
+	"wind_speed":           {unit: "km/h", value: func(f HourlyForecast) float64 { return f.WindSpeed }, spread: windSpeedSpread},
+	"humidity":             {unit: "%", value: func(f HourlyForecast) float64 { return float64(f.Humidity) }, spread: humiditySpread},
+}
+
+// alertOperators lists the comparisons that alert rules can use, by operator.
+var alertOperators = map[string]func(value, threshold float64) bool{
+	">":  func(v, t float64) bool { return v > t },
+	">=": func(v, t float64) bool { return v >= t },
+	"<":  func(v, t float64) bool { return v < t },
+	"<=": func(v, t float64) bool { return v <= t },
+}
+
+// AlertRuleRequest is the request body for creating an alert on /api/alerts.
+type AlertRuleRequest struct {
+	Metric      string   `json:"metric" example:"precipitation_chance"`
+	Operator    string   `json:"operator" example:">"`
+	Threshold   *float64 `json:"threshold" example:"60"`
+	WindowHours int      `json:"window_hours" example:"12"`
+	WebhookURL  string   `json:"webhook_url" example:"https://example.com/hooks/rain"`
+}
+
+// validate checks the rule and fills in the default window.
+func (req *AlertRuleRequest) validate() error {
+	if _, ok := alertMetrics[req.Metric]; !ok {
+		names := make([]string, 0, len(alertMetrics))
+		for name := range alertMetrics {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("metric must be one of %v", names)
+	}
+	if _, ok := alertOperators[req.Operator]; !ok {
+		return errors.New("operator must be one of >, >=, < or <=")
+	}
+	if req.Threshold == nil {
+		return errors.New("threshold is required")
+	}
+	if req.WindowHours == 0 {
+		req.WindowHours = defaultAlertWindowHours
+	}
+	if req.WindowHours < 1 || req.WindowHours > maxAlertWindowHours {
+		return fmt.Errorf("window_hours must be between 1 and %d", maxAlertWindowHours)
+	}
+	u, err := url.Parse(req.WebhookURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("webhook_url must be an absolute http or https URL")
+	}
+	if !isPublicWebhookHost(u.Hostname()) {
+		return errAlertWebhookAddress
+	}
+	return nil
+}
+
+// errAlertWebhookAddress is returned for webhooks on loopback, private, link-local or other
+// non-public addresses. Anyone can create an alert, so its webhook must not reach the server's
+// own network, such as the cloud metadata service.
+var errAlertWebhookAddress = errors.New("webhook_url must point to a public address")
+
+// nonPublicPrefixes lists the ranges that netip does not classify as private or link-local but
+// that are not reachable on the internet either: "this network" and the carrier-grade NAT range.
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+}
+
+// isPublicWebhookAddr reports whether webhooks may be sent to addr.
+func isPublicWebhookAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+	for _, prefix := range nonPublicPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// isPublicWebhookHost reports whether a webhook URL's host may be used. IP literals must be
+// public, and names reserved for local use are rejected. Other names are checked again once
+// resolved, when the webhook is sent.
+func isPublicWebhookHost(host string) bool {
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return isPublicWebhookAddr(addr)
+	}
+	name := strings.TrimSuffix(strings.ToLower(host), ".")
+	if name == "localhost" {
+		return false
+	}
+	for _, suffix := range []string{".localhost", ".local", ".internal"} {
+		if strings.HasSuffix(name, suffix) {
+			return false
+		}
+	}
+	return true
+}
+
+// newAlertWebhookClient returns the client that alert webhooks are sent with. It refuses to
+// connect to non-public addresses, checking the address a name resolved to at dial time so that
+// a name cannot be pointed at the server's network after validation. Redirects are not followed,
+// so they count as failed calls, and proxies are not used, so the checked address is the one
+// called.
+func newAlertWebhookClient(userAgent string) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if !isPublicWebhookAddr(addrPort.Addr()) {
+				return fmt.Errorf("%w: %s", errAlertWebhookAddress, addrPort.Addr())
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Timeout: 10 * time.Second,
+		Transport: &userAgentTransport{
+			wrapped:   transport,
+			userAgent: userAgent,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// handlerAlerts dispatches alert requests by method: GET lists the subscriber's alerts, POST
+// creates one and DELETE removes one.
+
+// @Summary      Manage rain alerts
+// @Description  GET lists the subscriber's alerts. POST creates an alert for the location given by city or
+// @Description  lat/lon: the webhook URL receives a POST when the rule starts to match the consensus hourly
+// @Description  forecast within the next window_hours hours. DELETE removes the alert given by id. The subscriber
+// @Description  is identified by the X-API-Key or X-Device-ID header.
+// @Tags         alerts
+// @Accept       json
+// @Produce      json
+// @Param        X-API-Key    header    string            false  "API key identifying the subscriber"
+// @Param        X-Device-ID  header    string            false  "Device ID identifying the subscriber"
+// @Param        city         query     string            false  "Location name (POST)"
+// @Param        lat          query     number            false  "Latitude of the location (POST)"
+// @Param        lon          query     number            false  "Longitude of the location (POST)"
+// @Param        id           query     string            false  "ID of the alert to remove (DELETE)"
+// @Param        request      body      AlertRuleRequest  false  "Alert rule and webhook URL (POST)"
+// @Success      200  {object}  AlertsResponse
+// @Success      201  {object}  AlertSubscriptionJSON
+// @Failure      400  {object}  ErrorResponse "Bad Request - Missing subscriber, invalid location or invalid rule"
+// @Failure      404  {object}  ErrorResponse "Not Found - Alert not found"
+// @Failure      409  {object}  ErrorResponse "Conflict - Too many alerts"
+// @Failure      500  {object}  ErrorResponse "Internal Server Error - Failed to access alerts"
+// @Router       /api/v1/alerts [get]
+// @Router       /api/v1/alerts [post]
+// @Router       /api/v1/alerts [delete]
+func (cfg *apiConfig) handlerAlerts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost && r.Method != http.MethodDelete {
+		cfg.respondWithError(w, http.StatusMethodNotAllowed, "Method Not Allowed", nil)
+		return
+	}
+
+	subscriberID, err := getSubscriberID(r)
+	if err != nil {
+		cfg.respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		cfg.listAlerts(w, r, subscriberID)
+	case http.MethodPost:
+		cfg.createAlert(w, r, subscriberID)
+	case http.MethodDelete:
+		cfg.deleteAlert(w, r, subscriberID)
+	}
+}
+
+func (cfg *apiConfig) listAlerts(w http.ResponseWriter, r *http.Request, subscriberID string) {
+	subscriptions, err := cfg.dbQueries.ListAlertSubscriptionsForSubscriber(r.Context(), subscriberID)
+	if err != nil {
+		cfg.respondWithError(w, http.StatusInternalServerError, "Failed to get alerts", err)
+		return
+	}
+
+	alerts := make([]AlertSubscriptionJSON, len(subscriptions))
+	for i, s := range subscriptions {
+		alerts[i] = alertSubscriptionToJSON(s)
+	}
+	cfg.respondWithJSON(w, http.StatusOK, AlertsResponse{Alerts: alerts})
+}
+
+func (cfg *apiConfig) createAlert(w http.ResponseWriter, r *http.Request, subscriberID string) {
+	var req AlertRuleRequest
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4<<10))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		cfg.respondWithError(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	if err := req.validate(); err != nil {
+		cfg.respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	count, err := cfg.dbQueries.CountAlertSubscriptionsForSubscriber(r.Context(), subscriberID)
+	if err != nil {
+		cfg.respondWithError(w, http.StatusInternalServerError, "Failed to create alert", err)
+		return
+	}
+	if count >= maxAlertSubscriptions {
+		cfg.respondWithError(w, http.StatusConflict, errAlertSubscriptionLimit.Error(), nil)
+		return
+	}
+
+	location, err := cfg.getLocationFromRequest(r)
+	if err != nil {
+		cfg.respondWithError(w, http.StatusBadRequest, "Error getting location data", err)
+		return
+	}
+
+	subscription, err := cfg.dbQueries.CreateAlertSubscription(r.Context(), database.CreateAlertSubscriptionParams{
+		ID:           uuid.New(),
+		SubscriberID: subscriberID,
+		LocationID:   location.LocationID,
+		Metric:       req.Metric,
+		Operator:     req.Operator,
+		Threshold:    *req.Threshold,
+		WindowHours:  int32(req.WindowHours),
+		WebhookUrl:   req.WebhookURL,
+		CreatedAt:    time.Now().UTC(),
+	})
+	if err != nil {
+		cfg.respondWithError(w, http.StatusInternalServerError, "Failed to create alert", err)
+		return
+	}
+	cfg.logger.Debug("alert created", "subscriber", subscriberID, "city", location.CityName, "metric", req.Metric)
+	cfg.respondWithJSON(w, http.StatusCreated, alertSubscriptionToJSON(subscription))
+}
+
+func (cfg *apiConfig) deleteAlert(w http.ResponseWriter, r *http.Request, subscriberID string) {
+	id, err := uuid.Parse(r.URL.Query().Get("id"))
+	if err != nil {
+		cfg.respondWithError(w, http.StatusBadRequest, "Invalid alert ID", err)
+		return
+	}
+
+	deleted, err := cfg.dbQueries.DeleteAlertSubscription(r.Context(), database.DeleteAlertSubscriptionParams{
+		ID:           id,
+		SubscriberID: subscriberID,
+	})
+	if err != nil {
+		cfg.respondWithError(w, http.StatusInternalServerError, "Failed to delete alert", err)
+		return
+	}
+	if deleted == 0 {
+		cfg.respondWithError(w, http.StatusNotFound, "Alert not found", nil)
+		return
+	}
+	cfg.respondWithJSON(w, http.StatusOK, map[string]string{"status": "alert deleted"})
+}
+
+// alertSubscriptionToJSON converts a stored alert rule to its API representation.
+func alertSubscriptionToJSON(s database.AlertSubscription) AlertSubscriptionJSON {
+	alert := AlertSubscriptionJSON{
+		ID:          s.ID.String(),
+		LocationID:  s.LocationID.String(),
+		Metric:      s.Metric,
+		Operator:    s.Operator,
+		Threshold:   s.Threshold,
+		Unit:        alertMetrics[s.Metric].unit,
+		WindowHours: int(s.WindowHours),
+		WebhookURL:  s.WebhookUrl,
+		CreatedAt:   s.CreatedAt.UTC().Format(time.RFC3339),
+		Triggered:   s.Triggered,
+	}
+	if s.LastTriggeredAt.Valid {
+		alert.LastTriggeredAt = s.LastTriggeredAt.Time.UTC().Format(time.RFC3339)
+	}
+	return alert
+}
+
+// alertHour is the consensus value of an alert metric for one forecast hour.
+type alertHour struct {
+	at    time.Time
+	value float64
+}
+
+// alertHours returns the consensus value of the metric for every forecast hour in [from, to),
+// in chronological order.
+func alertHours(forecast []HourlyForecast, metric alertMetric, from, to time.Time) []alertHour {
+	byTime := make(map[time.Time][]float64)
+	for _, f := range forecast {
+		if f.ForecastDateTime.Before(from) || !f.ForecastDateTime.Before(to) {
+			continue
+		}
+		byTime[f.ForecastDateTime] = append(byTime[f.ForecastDateTime], metric.value(f))
+	}
+
+	consensus := consensusMedian
+	if metric.mean {
+		consensus = consensusMean
+	}
+	hours := make([]alertHour, 0, len(byTime))
+	for t, values := range byTime {
+		hours = append(hours, alertHour{at: t, value: consensus(values, metric.spread).Value})
+	}
+	sort.Slice(hours, func(i, j int) bool { return hours[i].at.Before(hours[j].at) })
+	return hours
+}
+
+// evaluateAlerts checks the alert rules of a location against its freshly fetched hourly forecast.
+// A rule that starts to match queues a webhook delivery; a rule that stops matching is re-armed.
+// Errors are logged, since a failed evaluation must not fail the forecast update.
+func (cfg *apiConfig) evaluateAlerts(ctx context.Context, location Location, forecast []HourlyForecast, now time.Time) {
+	subscriptions, err := cfg.dbQueries.ListAlertSubscriptionsForLocation(ctx, location.LocationID)
+	if err != nil {
+		cfg.logger.Error("failed to list alerts", "location", location.CityName, "error", err)
+		return
+	}
+	if len(subscriptions) == 0 {
+		return
+	}
+
+	loc, err := time.LoadLocation(location.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	from := now.Truncate(time.Hour)
+
+	for _, s := range subscriptions {
+		metric, ok := alertMetrics[s.Metric]
+		compare, okOperator := alertOperators[s.Operator]
+		if !ok || !okOperator {
+			cfg.logger.Warn("skipping alert with unknown rule", "alert", s.ID, "metric", s.Metric, "operator", s.Operator)
+			continue
+		}
+
+		var matches []AlertMatchJSON
+		for _, h := range alertHours(forecast, metric, from, now.Add(time.Duration(s.WindowHours)*time.Hour)) {
+			if compare(h.value, s.Threshold) {
+				matches = append(matches, AlertMatchJSON{ForecastDateTime: h.at.In(loc).Format("2006-01-02 15:04"), Value: h.value})
+			}
+		}
+		matched := len(matches) > 0
+		if matched == s.Triggered {
+			continue
+		}
+
+		s.Triggered = matched
+		if matched {
+			s.LastTriggeredAt = sql.NullTime{Time: now.UTC(), Valid: true}
+			if err := cfg.queueAlertDelivery(ctx, s, location, matches, now); err != nil {
+				cfg.logger.Error("failed to queue alert delivery", "alert", s.ID, "error", err)
+				continue
+			}
+			alertsTriggered.WithLabelValues(s.Metric).Inc()
+		}
+		err := cfg.dbQueries.UpdateAlertSubscriptionState(ctx, database.UpdateAlertSubscriptionStateParams{
+			ID:              s.ID,
+			Triggered:       s.Triggered,
+			LastTriggeredAt: s.LastTriggeredAt,
+		})
+		if err != nil {
+			cfg.logger.Error("failed to update alert state", "alert", s.ID, "error", err)
+		}
+	}
+}
+
+// queueAlertDelivery stores the webhook call of a triggered alert for the delivery job.
+func (cfg *apiConfig) queueAlertDelivery(ctx context.Context, s database.AlertSubscription, location Location, matches []AlertMatchJSON, now time.Time) error {
+	deliveryID := uuid.New()
+	payload, err := json.Marshal(AlertWebhookPayload{
+		Event:       alertTriggeredEvent,
+		DeliveryID:  deliveryID.String(),
+		Alert:       alertSubscriptionToJSON(s),
+		Location:    location,
+		TriggeredAt: now.UTC().Format(time.RFC3339),
+		Matches:     matches,
+	})
+	if err != nil {
+		return fmt.Errorf("could not encode webhook payload: %w", err)
+	}
+	return cfg.dbQueries.CreateAlertDelivery(ctx, database.CreateAlertDeliveryParams{
+		ID:             deliveryID,
+		SubscriptionID: s.ID,
+		WebhookUrl:     s.WebhookUrl,
+		Payload:        payload,
+		CreatedAt:      now.UTC(),
+	})
+}
+
+// alertDeliveryJob returns the scheduler job that sends queued webhook calls.
+func (cfg *apiConfig) alertDeliveryJob() SchedulerJob {
+	return SchedulerJob{
+		Name:     alertDeliveryJobName,
+		Interval: alertDeliveryInterval,
+		Run: func(ctx context.Context) error {
+			return cfg.deliverAlerts(ctx, time.Now())
+		},
+	}
+}
+
+// deliverAlerts sends the webhook calls that are due and records their outcome. A failed call is
+// rescheduled with exponential backoff until it runs out of attempts. Finished deliveries older
+// than alertDeliveryRetention are removed.
+func (cfg *apiConfig) deliverAlerts(ctx context.Context, now time.Time) error {
+	deliveries, err := cfg.dbQueries.ClaimDueAlertDeliveries(ctx, database.ClaimDueAlertDeliveriesParams{
+		LeaseUntil: now.Add(alertDeliveryLease),
+		Now:        now,
+		RowLimit:   alertDeliveryBatch,
+	})
+	if err != nil {
+		return fmt.Errorf("could not claim alert deliveries: %w", err)
+	}
+
+	var failed int
+	for _, d := range deliveries {
+		attempt := database.RecordAlertDeliveryAttemptParams{ID: d.ID, Attempts: d.Attempts + 1}
+		outcome := "delivered"
+		if err := cfg.sendAlertWebhook(ctx, d); err != nil {
+			attempt.LastError = sql.NullString{String: err.Error(), Valid: true}
+			outcome = "failed"
+			if attempt.Attempts < alertDeliveryMaxAttempts {
+				backoff := alertDeliveryBackoff << (attempt.Attempts - 1)
+				attempt.NextAttemptAt = sql.NullTime{Time: now.Add(backoff), Valid: true}
+				outcome = "retried"
+			}
+			cfg.logger.Warn("alert webhook failed", "delivery", d.ID, "attempt", attempt.Attempts, "error", err)
+		} else {
+			attempt.DeliveredAt = sql.NullTime{Time: time.Now().UTC(), Valid: true}
+		}
+		alertDeliveries.WithLabelValues(outcome).Inc()
+		if err := cfg.dbQueries.RecordAlertDeliveryAttempt(ctx, attempt); err != nil {
+			cfg.logger.Error("failed to record alert delivery attempt", "delivery", d.ID, "error", err)
+			failed++
+		}
+	}
+
+	if _, err := cfg.dbQueries.DeleteAlertDeliveriesBefore(ctx, now.Add(-alertDeliveryRetention)); err != nil {
+		cfg.logger.Warn("could not prune alert deliveries", "error", err)
+	}
+	if failed > 0 {
+		return fmt.Errorf("could not record %d alert delivery attempts", failed)
+	}
+	return nil
+}
+
+// sendAlertWebhook posts a delivery's payload to its webhook URL. Any status other than 2xx
+// counts as a failure. The delivery ID is sent in the X-Willitrain-Delivery header, so that
+// receivers can discard duplicates.
+func (cfg *apiConfig) sendAlertWebhook(ctx context.Context, d database.AlertDelivery) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.WebhookUrl, bytes.NewReader(d.Payload))
+	if err != nil {
+		return fmt.Errorf("could not create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Willitrain-Delivery", d.ID.String())
+
+	resp, err := cfg.alertWebhookClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("could not call webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
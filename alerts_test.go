@@ -0,0 +1,498 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cor0nius/willitrain/internal/database"
+	"github.com/google/uuid"
+)
+
+func TestAlertRuleRequestValidate(t *testing.T) {
+	threshold := 60.0
+	valid := func() AlertRuleRequest {
+		return AlertRuleRequest{Metric: "precipitation_chance", Operator: ">", Threshold: &threshold, WebhookURL: "https://example.com/hook"}
+	}
+
+	testCases := []struct {
+		name    string
+		modify  func(req *AlertRuleRequest)
+		wantErr string
+	}{
+		{name: "valid with default window", modify: func(req *AlertRuleRequest) {}},
+		{name: "unknown metric", modify: func(req *AlertRuleRequest) { req.Metric = "snow" }, wantErr: "metric must be one of"},
+		{name: "unknown operator", modify: func(req *AlertRuleRequest) { req.Operator = "==" }, wantErr: "operator"},
+		{name: "missing threshold", modify: func(req *AlertRuleRequest) { req.Threshold = nil }, wantErr: "threshold is required"},
+		{name: "window too long", modify: func(req *AlertRuleRequest) { req.WindowHours = 48 }, wantErr: "window_hours"},
+		{name: "negative window", modify: func(req *AlertRuleRequest) { req.WindowHours = -1 }, wantErr: "window_hours"},
+		{name: "relative webhook", modify: func(req *AlertRuleRequest) { req.WebhookURL = "/hook" }, wantErr: "webhook_url"},
+		{name: "non-http webhook", modify: func(req *AlertRuleRequest) { req.WebhookURL = "ftp://example.com/hook" }, wantErr: "webhook_url"},
+		{name: "public IP webhook", modify: func(req *AlertRuleRequest) { req.WebhookURL = "http://93.184.216.34/hook" }},
+		{name: "loopback webhook", modify: func(req *AlertRuleRequest) { req.WebhookURL = "http://127.0.0.1:8080/hook" }, wantErr: "public address"},
+		{name: "localhost webhook", modify: func(req *AlertRuleRequest) { req.WebhookURL = "http://localhost/hook" }, wantErr: "public address"},
+		{name: "private webhook", modify: func(req *AlertRuleRequest) { req.WebhookURL = "http://10.0.0.5/hook" }, wantErr: "public address"},
+		{name: "metadata webhook", modify: func(req *AlertRuleRequest) { req.WebhookURL = "http://169.254.169.254/latest/meta-data" }, wantErr: "public address"},
+		{name: "metadata name webhook", modify: func(req *AlertRuleRequest) { req.WebhookURL = "http://metadata.google.internal/" }, wantErr: "public address"},
+		{name: "IPv6 loopback webhook", modify: func(req *AlertRuleRequest) { req.WebhookURL = "http://[::1]/hook" }, wantErr: "public address"},
+		{name: "IPv4-mapped private webhook", modify: func(req *AlertRuleRequest) { req.WebhookURL = "http://[::ffff:192.168.1.1]/hook" }, wantErr: "public address"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := valid()
+			tc.modify(&req)
+			err := req.validate()
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if req.WindowHours != defaultAlertWindowHours {
+					t.Errorf("WindowHours = %d, want %d", req.WindowHours, defaultAlertWindowHours)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestHandlerAlerts(t *testing.T) {
+	alertID := uuid.New()
+	createdAt := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	stored := database.AlertSubscription{
+		ID:          alertID,
+		LocationID:  MockLocation.LocationID,
+		Metric:      "precipitation_chance",
+		Operator:    ">",
+		Threshold:   60,
+		WindowHours: 12,
+		WebhookUrl:  "https://example.com/hook",
+		CreatedAt:   createdAt,
+	}
+	validBody := `{"metric":"precipitation_chance","operator":">","threshold":60,"webhook_url":"https://example.com/hook"}`
+
+	testCases := []struct {
+		name       string
+		method     string
+		target     string
+		body       string
+		deviceID   string
+		setupMocks func(cfg *testAPIConfig)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:     "List",
+			method:   http.MethodGet,
+			target:   "/api/alerts",
+			deviceID: "esp-1",
+			setupMocks: func(cfg *testAPIConfig) {
+				cfg.mockDB.ListAlertSubscriptionsForSubscriberFunc = func(ctx context.Context, subscriberID string) ([]database.AlertSubscription, error) {
+					if subscriberID != "device:esp-1" {
+						t.Errorf("unexpected subscriber: %s", subscriberID)
+					}
+					return []database.AlertSubscription{stored}, nil
+				}
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `{"alerts":[{"id":"` + alertID.String() + `","location_id":"` + MockLocation.LocationID.String() + `","metric":"precipitation_chance","operator":"\u003e","threshold":60,"unit":"%","window_hours":12,"webhook_url":"https://example.com/hook","created_at":"2025-06-01T12:00:00Z","triggered":false}]}`,
+		},
+		{
+			name:     "Create",
+			method:   http.MethodPost,
+			target:   "/api/alerts?city=Wroclaw",
+			body:     validBody,
+			deviceID: "esp-1",
+			setupMocks: func(cfg *testAPIConfig) {
+				cfg.mockDB.CountAlertSubscriptionsForSubscriberFunc = func(ctx context.Context, subscriberID string) (int64, error) {
+					return 0, nil
+				}
+				cfg.mockDB.GetLocationByAliasFunc = func(ctx context.Context, alias string) (database.Location, error) {
+					return MockDBLocation, nil
+				}
+				cfg.mockDB.CreateAlertSubscriptionFunc = func(ctx context.Context, arg database.CreateAlertSubscriptionParams) (database.AlertSubscription, error) {
+					if arg.SubscriberID != "device:esp-1" || arg.LocationID != MockLocation.LocationID || arg.WindowHours != defaultAlertWindowHours || arg.Threshold != 60 {
+						t.Errorf("unexpected alert: %+v", arg)
+					}
+					return stored, nil
+				}
+			},
+			wantStatus: http.StatusCreated,
+			wantBody:   `"id":"` + alertID.String() + `"`,
+		},
+		{
+			name:       "Create - Invalid Rule",
+			method:     http.MethodPost,
+			target:     "/api/alerts?city=Wroclaw",
+			body:       `{"metric":"precipitation_chance","operator":">","webhook_url":"https://example.com/hook"}`,
+			deviceID:   "esp-1",
+			setupMocks: func(cfg *testAPIConfig) {},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"threshold is required"}`,
+		},
+		{
+			name:       "Create - Unknown Field",
+			method:     http.MethodPost,
+			target:     "/api/alerts?city=Wroclaw",
+			body:       `{"metric":"precipitation_chance","operator":">","threshold":60,"webhook":"https://example.com/hook"}`,
+			deviceID:   "esp-1",
+			setupMocks: func(cfg *testAPIConfig) {},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"Invalid request body"}`,
+		},
+		{
+			name:     "Create - Limit Reached",
+			method:   http.MethodPost,
+			target:   "/api/alerts?city=Wroclaw",
+			body:     validBody,
+			deviceID: "esp-1",
+			setupMocks: func(cfg *testAPIConfig) {
+				cfg.mockDB.CountAlertSubscriptionsForSubscriberFunc = func(ctx context.Context, subscriberID string) (int64, error) {
+					return maxAlertSubscriptions, nil
+				}
+			},
+			wantStatus: http.StatusConflict,
+			wantBody:   `{"error":"at most 20 alerts per subscriber are allowed"}`,
+		},
+		{
+			name:     "Delete",
+			method:   http.MethodDelete,
+			target:   "/api/alerts?id=" + alertID.String(),
+			deviceID: "esp-1",
+			setupMocks: func(cfg *testAPIConfig) {
+				cfg.mockDB.DeleteAlertSubscriptionFunc = func(ctx context.Context, arg database.DeleteAlertSubscriptionParams) (int64, error) {
+					if arg.ID != alertID || arg.SubscriberID != "device:esp-1" {
+						t.Errorf("unexpected delete: %+v", arg)
+					}
+					return 1, nil
+				}
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `{"status":"alert deleted"}`,
+		},
+		{
+			name:     "Delete - Not Found",
+			method:   http.MethodDelete,
+			target:   "/api/alerts?id=" + alertID.String(),
+			deviceID: "esp-2",
+			setupMocks: func(cfg *testAPIConfig) {
+				cfg.mockDB.DeleteAlertSubscriptionFunc = func(ctx context.Context, arg database.DeleteAlertSubscriptionParams) (int64, error) {
+					return 0, nil
+				}
+			},
+			wantStatus: http.StatusNotFound,
+			wantBody:   `{"error":"Alert not found"}`,
+		},
+		{
+			name:       "Delete - Invalid ID",
+			method:     http.MethodDelete,
+			target:     "/api/alerts?id=abc",
+			deviceID:   "esp-1",
+			setupMocks: func(cfg *testAPIConfig) {},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"Invalid alert ID"}`,
+		},
+		{
+			name:       "Missing Subscriber",
+			method:     http.MethodGet,
+			target:     "/api/alerts",
+			setupMocks: func(cfg *testAPIConfig) {},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"either X-API-Key or X-Device-ID header is required"}`,
+		},
+		{
+			name:       "Wrong Method",
+			method:     http.MethodPut,
+			target:     "/api/alerts",
+			deviceID:   "esp-1",
+			setupMocks: func(cfg *testAPIConfig) {},
+			wantStatus: http.StatusMethodNotAllowed,
+			wantBody:   `{"error":"Method Not Allowed"}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testCfg := newTestAPIConfig(t)
+			tc.setupMocks(testCfg)
+
+			req := httptest.NewRequest(tc.method, tc.target, strings.NewReader(tc.body))
+			if tc.deviceID != "" {
+				req.Header.Set("X-Device-ID", tc.deviceID)
+			}
+			rr := httptest.NewRecorder()
+			testCfg.apiConfig.handlerAlerts(rr, req)
+
+			if rr.Code != tc.wantStatus {
+				t.Fatalf("status = %d, want %d; body: %s", rr.Code, tc.wantStatus, rr.Body.String())
+			}
+			if !strings.Contains(rr.Body.String(), tc.wantBody) {
+				t.Errorf("body = %s, want it to contain %s", rr.Body.String(), tc.wantBody)
+			}
+		})
+	}
+}
+
+func TestAlertHours(t *testing.T) {
+	start := time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC)
+	forecast := []HourlyForecast{
+		{SourceAPI: "a", ForecastDateTime: start, PrecipitationChance: 40, Temperature: 10},
+		{SourceAPI: "b", ForecastDateTime: start, PrecipitationChance: 80, Temperature: 14},
+		{SourceAPI: "c", ForecastDateTime: start, PrecipitationChance: 90, Temperature: 11},
+		{SourceAPI: "a", ForecastDateTime: start.Add(time.Hour), PrecipitationChance: 10, Temperature: 12},
+		{SourceAPI: "a", ForecastDateTime: start.Add(2 * time.Hour), PrecipitationChance: 100, Temperature: 13},
+	}
+
+	chance := alertHours(forecast, alertMetrics["precipitation_chance"], start, start.Add(2*time.Hour))
+	if len(chance) != 2 || !chance[0].at.Equal(start) || chance[0].value != 70 || chance[1].value != 10 {
+		t.Errorf("unexpected precipitation chance hours: %+v", chance)
+	}
+	temperature := alertHours(forecast, alertMetrics["temperature"], start, start.Add(time.Hour))
+	if len(temperature) != 1 || temperature[0].value != 11 {
+		t.Errorf("unexpected temperature hours: %+v", temperature)
+	}
+}
+
+func TestEvaluateAlerts(t *testing.T) {
+	now := time.Date(2025, 6, 1, 10, 30, 0, 0, time.UTC)
+	location := Location{LocationID: uuid.New(), CityName: "Wroclaw", Timezone: "Europe/Warsaw"}
+	forecast := []HourlyForecast{
+		{SourceAPI: "a", ForecastDateTime: now.Truncate(time.Hour).Add(3 * time.Hour), PrecipitationChance: 80},
+		{SourceAPI: "b", ForecastDateTime: now.Truncate(time.Hour).Add(3 * time.Hour), PrecipitationChance: 60},
+	}
+	rule := func(windowHours int32, triggered bool) database.AlertSubscription {
+		return database.AlertSubscription{
+			ID:          uuid.New(),
+			LocationID:  location.LocationID,
+			Metric:      "precipitation_chance",
+			Operator:    ">",
+			Threshold:   60,
+			WindowHours: windowHours,
+			WebhookUrl:  "https://example.com/hook",
+			Triggered:   triggered,
+		}
+	}
+
+	testCases := []struct {
+		name          string
+		subscription  database.AlertSubscription
+		wantDelivery  bool
+		wantUpdate    bool
+		wantTriggered bool
+	}{
+		{name: "starts matching", subscription: rule(12, false), wantDelivery: true, wantUpdate: true, wantTriggered: true},
+		{name: "still matching", subscription: rule(12, true)},
+		{name: "stops matching", subscription: rule(2, true), wantUpdate: true},
+		{name: "still not matching", subscription: rule(2, false)},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testCfg := newTestAPIConfig(t)
+			testCfg.mockDB.ListAlertSubscriptionsForLocationFunc = func(ctx context.Context, locationID uuid.UUID) ([]database.AlertSubscription, error) {
+				return []database.AlertSubscription{tc.subscription}, nil
+			}
+			var payload AlertWebhookPayload
+			testCfg.mockDB.CreateAlertDeliveryFunc = func(ctx context.Context, arg database.CreateAlertDeliveryParams) error {
+				if arg.SubscriptionID != tc.subscription.ID || arg.WebhookUrl != tc.subscription.WebhookUrl || !arg.CreatedAt.Equal(now) {
+					t.Errorf("unexpected delivery: %+v", arg)
+				}
+				if err := json.Unmarshal(arg.Payload, &payload); err != nil {
+					t.Errorf("invalid payload: %v", err)
+				}
+				return nil
+			}
+			var update database.UpdateAlertSubscriptionStateParams
+			testCfg.mockDB.UpdateAlertSubscriptionStateFunc = func(ctx context.Context, arg database.UpdateAlertSubscriptionStateParams) error {
+				update = arg
+				return nil
+			}
+
+			testCfg.apiConfig.evaluateAlerts(context.Background(), location, forecast, now)
+
+			if got := testCfg.mockDB.Calls("CreateAlertDelivery"); got != map[bool]int{false: 0, true: 1}[tc.wantDelivery] {
+				t.Errorf("CreateAlertDelivery called %d times", got)
+			}
+			if got := testCfg.mockDB.Calls("UpdateAlertSubscriptionState"); got != map[bool]int{false: 0, true: 1}[tc.wantUpdate] {
+				t.Fatalf("UpdateAlertSubscriptionState called %d times", got)
+			}
+			if tc.wantUpdate && update.Triggered != tc.wantTriggered {
+				t.Errorf("Triggered = %v, want %v", update.Triggered, tc.wantTriggered)
+			}
+			if tc.wantDelivery {
+				if payload.Event != alertTriggeredEvent || !payload.Alert.Triggered || payload.Alert.LastTriggeredAt != "2025-06-01T10:30:00Z" {
+					t.Errorf("unexpected payload: %+v", payload)
+				}
+				if len(payload.Matches) != 1 || payload.Matches[0].Value != 70 || payload.Matches[0].ForecastDateTime != "2025-06-01 15:00" {
+					t.Errorf("unexpected matches: %+v", payload.Matches)
+				}
+			}
+		})
+	}
+
+	t.Run("queue error keeps the rule armed", func(t *testing.T) {
+		testCfg := newTestAPIConfig(t)
+		testCfg.mockDB.ListAlertSubscriptionsForLocationFunc = func(ctx context.Context, locationID uuid.UUID) ([]database.AlertSubscription, error) {
+			return []database.AlertSubscription{rule(12, false)}, nil
+		}
+		testCfg.mockDB.CreateAlertDeliveryFunc = func(ctx context.Context, arg database.CreateAlertDeliveryParams) error {
+			return errors.New("db down")
+		}
+
+		testCfg.apiConfig.evaluateAlerts(context.Background(), location, forecast, now)
+
+		if got := testCfg.mockDB.Calls("UpdateAlertSubscriptionState"); got != 0 {
+			t.Errorf("UpdateAlertSubscriptionState called %d times, want 0", got)
+		}
+	})
+}
+
+func TestDeliverAlerts(t *testing.T) {
+	now := time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC)
+	var gotHeader, gotBody string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		gotHeader = r.Header.Get("X-Willitrain-Delivery")
+		body, _ := io.ReadAll(r.Body)
+		gotBody = string(body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	testCases := []struct {
+		name         string
+		path         string
+		attempts     int32
+		wantOutcome  func(t *testing.T, arg database.RecordAlertDeliveryAttemptParams)
+		wantJobError bool
+	}{
+		{
+			name: "delivered",
+			path: "/ok",
+			wantOutcome: func(t *testing.T, arg database.RecordAlertDeliveryAttemptParams) {
+				if arg.Attempts != 1 || !arg.DeliveredAt.Valid || arg.NextAttemptAt.Valid || arg.LastError.Valid {
+					t.Errorf("unexpected attempt: %+v", arg)
+				}
+			},
+		},
+		{
+			name:     "retried with backoff",
+			path:     "/fail",
+			attempts: 2,
+			wantOutcome: func(t *testing.T, arg database.RecordAlertDeliveryAttemptParams) {
+				if arg.Attempts != 3 || arg.DeliveredAt.Valid || !arg.LastError.Valid {
+					t.Errorf("unexpected attempt: %+v", arg)
+				}
+				if want := now.Add(4 * time.Minute); !arg.NextAttemptAt.Valid || !arg.NextAttemptAt.Time.Equal(want) {
+					t.Errorf("NextAttemptAt = %v, want %v", arg.NextAttemptAt, want)
+				}
+			},
+		},
+		{
+			name:     "given up",
+			path:     "/fail",
+			attempts: alertDeliveryMaxAttempts - 1,
+			wantOutcome: func(t *testing.T, arg database.RecordAlertDeliveryAttemptParams) {
+				if arg.Attempts != alertDeliveryMaxAttempts || arg.NextAttemptAt.Valid || arg.DeliveredAt.Valid {
+					t.Errorf("unexpected attempt: %+v", arg)
+				}
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testCfg := newTestAPIConfig(t)
+			delivery := database.AlertDelivery{
+				ID:         uuid.New(),
+				WebhookUrl: server.URL + tc.path,
+				Payload:    json.RawMessage(`{"event":"alert.triggered"}`),
+				Attempts:   tc.attempts,
+			}
+			testCfg.mockDB.ClaimDueAlertDeliveriesFunc = func(ctx context.Context, arg database.ClaimDueAlertDeliveriesParams) ([]database.AlertDelivery, error) {
+				if !arg.Now.Equal(now) || !arg.LeaseUntil.Equal(now.Add(alertDeliveryLease)) || arg.RowLimit != alertDeliveryBatch {
+					t.Errorf("unexpected claim: %+v", arg)
+				}
+				return []database.AlertDelivery{delivery}, nil
+			}
+			testCfg.mockDB.RecordAlertDeliveryAttemptFunc = func(ctx context.Context, arg database.RecordAlertDeliveryAttemptParams) error {
+				if arg.ID != delivery.ID {
+					t.Errorf("recorded attempt for %v, want %v", arg.ID, delivery.ID)
+				}
+				tc.wantOutcome(t, arg)
+				return nil
+			}
+			var prunedBefore time.Time
+			testCfg.mockDB.DeleteAlertDeliveriesBeforeFunc = func(ctx context.Context, createdAt time.Time) (int64, error) {
+				prunedBefore = createdAt
+				return 0, nil
+			}
+
+			if err := testCfg.apiConfig.deliverAlerts(context.Background(), now); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := testCfg.mockDB.Calls("RecordAlertDeliveryAttempt"); got != 1 {
+				t.Errorf("RecordAlertDeliveryAttempt called %d times, want 1", got)
+			}
+			if !prunedBefore.Equal(now.Add(-alertDeliveryRetention)) {
+				t.Errorf("pruned before %v, want %v", prunedBefore, now.Add(-alertDeliveryRetention))
+			}
+			if tc.path == "/ok" && (gotHeader != delivery.ID.String() || gotBody != string(delivery.Payload)) {
+				t.Errorf("webhook received header %q and body %q", gotHeader, gotBody)
+			}
+		})
+	}
+
+	t.Run("claim error", func(t *testing.T) {
+		testCfg := newTestAPIConfig(t)
+		testCfg.mockDB.ClaimDueAlertDeliveriesFunc = func(ctx context.Context, arg database.ClaimDueAlertDeliveriesParams) ([]database.AlertDelivery, error) {
+			return nil, sql.ErrConnDone
+		}
+		if err := testCfg.apiConfig.deliverAlerts(context.Background(), now); err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
+
+func TestAlertWebhookClient(t *testing.T) {
+	var called bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	_, err := newAlertWebhookClient(defaultUserAgent).Post(server.URL, "application/json", nil)
+	if !errors.Is(err, errAlertWebhookAddress) {
+		t.Errorf("got error %v, want %v", err, errAlertWebhookAddress)
+	}
+	if called {
+		t.Error("webhook on the loopback address was called")
+	}
+
+	t.Run("redirects are not followed", func(t *testing.T) {
+		redirect := httptest.NewServer(http.RedirectHandler(server.URL, http.StatusFound))
+		defer redirect.Close()
+		client := newAlertWebhookClient(defaultUserAgent)
+		// The dial check is replaced so that the local test server can be called.
+		client.Transport = redirect.Client().Transport
+		resp, err := client.Post(redirect.URL, "application/json", nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusFound || called {
+			t.Errorf("got status %d and called %v, want %d without calling the target", resp.StatusCode, called, http.StatusFound)
+		}
+	})
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/cor0nius/willitrain/internal/database"
+	"github.com/google/uuid"
+)
+
+// This file implements the administrative API for location aliases. Aliases map normalized
+// user input (e.g. "georgia") to a canonical location, and a wrong mapping silently sends every
+// matching request to the wrong place. Operators can list a location's aliases, point an alias
+// at a location (moving it away from wherever it resolved before) and remove an alias. Every
+// change is written to the log as an audit event.
+
+// handlerLocationAliases dispatches alias requests by method: GET lists the aliases of the
+// location, POST assigns an alias to it and DELETE removes one.
+
+// @Summary      Manage a location's aliases
+// @Description  GET lists the aliases that resolve to the location. POST points the given alias at the
+// @Description  location, moving it from any other location it resolved to. DELETE removes the alias.
+// @Description  Aliases are normalized the same way as city names in weather requests. Changes are audit-logged.
+// @Tags         admin
+// @Produce      json
+// @Param        id     path      string  true   "Location ID (UUID)"
+// @Param        alias  query     string  false  "Alias to add or remove (POST, DELETE)"
+// @Success      200  {object}  LocationAliasesResponse
+// @Success      201  {object}  LocationAliasJSON
+// @Failure      400  {object}  ErrorResponse "Bad Request - Invalid location ID or alias"
+// @Failure      404  {object}  ErrorResponse "Not Found - Location or alias does not exist"
+// @Failure      500  {object}  ErrorResponse "Internal Server Error - Failed to access aliases"
+// @Security     ApiKeyAuth
+// @Failure      401  {object}  ErrorResponse "Unauthorized - Missing API key"
+// @Failure      403  {object}  ErrorResponse "Forbidden - Invalid API key"
+// @Router       /admin/locations/{id}/aliases [get]
+// @Router       /admin/locations/{id}/aliases [post]
+// @Router       /admin/locations/{id}/aliases [delete]
+func (cfg *apiConfig) handlerLocationAliases(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost && r.Method != http.MethodDelete {
+		cfg.respondWithError(w, http.StatusMethodNotAllowed, "Method Not Allowed", nil)
+		return
+	}
+
+	locationID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		cfg.respondWithError(w, http.StatusBadRequest, "Invalid location ID", err)
+		return
+	}
+
+	dbLocation, err := cfg.dbQueries.GetLocationByID(r.Context(), locationID)
+	if err == sql.ErrNoRows {
+		cfg.respondWithError(w, http.StatusNotFound, "Location not found", nil)
+		return
+	}
+	if err != nil {
+		cfg.respondWithError(w, http.StatusInternalServerError, "Failed to get location", err)
+		return
+	}
+	location := databaseLocationToLocation(dbLocation)
+
+	switch r.Method {
+	case http.MethodGet:
+		cfg.listLocationAliases(w, r, location)
+	case http.MethodPost:
+		cfg.addLocationAlias(w, r, location)
+	case http.MethodDelete:
+		cfg.removeLocationAlias(w, r, location)
+	}
+}
+
+func (cfg *apiConfig) listLocationAliases(w http.ResponseWriter, r *http.Request, location Location) {
+	dbAliases, err := cfg.dbQueries.ListLocationAliases(r.Context(), location.LocationID)
+	if err != nil {
+		cfg.respondWithError(w, http.StatusInternalServerError, "Failed to get aliases", err)
+		return
+	}
+
+	aliases := make([]string, len(dbAliases))
+	for i, a := range dbAliases {
+		aliases[i] = a.Alias
+	}
+	cfg.respondWithJSON(w, http.StatusOK, LocationAliasesResponse{Location: location, Aliases: aliases})
+}
+
+func (cfg *apiConfig) addLocationAlias(w http.ResponseWriter, r *http.Request, location Location) {
+	alias, err := aliasFromRequest(r)
+	if err != nil {
+		cfg.respondWithError(w, http.StatusBadRequest, "Invalid alias", err)
+		return
+	}
+
+	ctx := r.Context()
+	var previousLocationID string
+	previous, err := cfg.dbQueries.GetLocationByAlias(ctx, alias)
+	switch {
+	case err == nil:
+		previousLocationID = previous.ID.String()
+	case err != sql.ErrNoRows:
+		cfg.respondWithError(w, http.StatusInternalServerError, "Failed to get alias", err)
+		return
+	}
+
+	dbAlias, err := cfg.dbQueries.UpsertLocationAlias(ctx, database.UpsertLocationAliasParams{
+		Alias:      alias,
+		LocationID: location.LocationID,
+	})
+	if err != nil {
+		cfg.respondWithError(w, http.StatusInternalServerError, "Failed to update alias", err)
+		return
+	}
+
+	cfg.logger.Info("audit: location alias assigned",
+		"alias", alias,
+		"location_id", location.LocationID,
+		"city", location.CityName,
+		"previous_location_id", previousLocationID,
+		"remote_addr", r.RemoteAddr,
+	)
+	cfg.respondWithJSON(w, http.StatusCreated, LocationAliasJSON{
+		Alias:      dbAlias.Alias,
+		LocationID: dbAlias.LocationID.String(),
+	})
+}
+
+func (cfg *apiConfig) removeLocationAlias(w http.ResponseWriter, r *http.Request, location Location) {
+	alias, err := aliasFromRequest(r)
+	if err != nil {
+		cfg.respondWithError(w, http.StatusBadRequest, "Invalid alias", err)
+		return
+	}
+
+	deleted, err := cfg.dbQueries.DeleteLocationAlias(r.Context(), database.DeleteLocationAliasParams{
+		Alias:      alias,
+		LocationID: location.LocationID,
+	})
+	if err != nil {
+		cfg.respondWithError(w, http.StatusInternalServerError, "Failed to delete alias", err)
+		return
+	}
+	if deleted == 0 {
+		cfg.respondWithError(w, http.StatusNotFound, "Alias not found", nil)
+		return
+	}
+
+	cfg.logger.Info("audit: location alias removed",
+		"alias", alias,
+		"location_id", location.LocationID,
+		"city", location.CityName,
+		"remote_addr", r.RemoteAddr,
+	)
+	cfg.respondWithJSON(w, http.StatusOK, map[string]string{"status": "alias removed"})
+}
+
+// aliasFromRequest reads the alias query parameter and normalizes it the same way city names
+// are normalized before alias lookups.
+func aliasFromRequest(r *http.Request) (string, error) {
+	raw := strings.TrimSpace(r.URL.Query().Get("alias"))
+	if raw == "" {
+		return "", errors.New("alias parameter is required")
+	}
+	return normalizeCityName(raw)
+}
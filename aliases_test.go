@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cor0nius/willitrain/internal/database"
+	"github.com/google/uuid"
+)
+
+func TestHandlerLocationAliases(t *testing.T) {
+	otherLocationID := uuid.New()
+	foundLocation := func(cfg *testAPIConfig) {
+		cfg.mockDB.GetLocationByIDFunc = func(ctx context.Context, id uuid.UUID) (database.Location, error) {
+			return MockDBLocation, nil
+		}
+	}
+
+	testCases := []struct {
+		name          string
+		requestMethod string
+		locationID    string
+		query         string
+		setupMocks    func(cfg *testAPIConfig)
+		wantStatus    int
+		wantBody      string
+		wantLog       string
+	}{
+		{
+			name:          "List",
+			requestMethod: http.MethodGet,
+			locationID:    MockLocation.LocationID.String(),
+			setupMocks: func(cfg *testAPIConfig) {
+				foundLocation(cfg)
+				cfg.mockDB.ListLocationAliasesFunc = func(ctx context.Context, locationID uuid.UUID) ([]database.LocationAlias, error) {
+					return []database.LocationAlias{
+						{Alias: "breslau", LocationID: locationID},
+						{Alias: "wroclaw", LocationID: locationID},
+					}, nil
+				}
+			},
+			wantStatus: http.StatusOK,
+			wantBody: `{"location":{"location_id":"` + MockLocation.LocationID.String() + `","city_name":"Wroclaw","latitude":51.1,"longitude":17.03,"country_code":"PL"},` +
+				`"aliases":["breslau","wroclaw"]}`,
+		},
+		{
+			name:          "Add Moves Existing Alias",
+			requestMethod: http.MethodPost,
+			locationID:    MockLocation.LocationID.String(),
+			query:         "?alias=Krak%C3%B3w",
+			setupMocks: func(cfg *testAPIConfig) {
+				foundLocation(cfg)
+				cfg.mockDB.GetLocationByAliasFunc = func(ctx context.Context, alias string) (database.Location, error) {
+					return database.Location{ID: otherLocationID}, nil
+				}
+				cfg.mockDB.UpsertLocationAliasFunc = func(ctx context.Context, arg database.UpsertLocationAliasParams) (database.LocationAlias, error) {
+					return database.LocationAlias{Alias: arg.Alias, LocationID: arg.LocationID}, nil
+				}
+			},
+			wantStatus: http.StatusCreated,
+			wantBody:   `{"alias":"krakow","location_id":"` + MockLocation.LocationID.String() + `"}`,
+			wantLog:    "previous_location_id=" + otherLocationID.String(),
+		},
+		{
+			name:          "Add Missing Alias",
+			requestMethod: http.MethodPost,
+			locationID:    MockLocation.LocationID.String(),
+			setupMocks:    foundLocation,
+			wantStatus:    http.StatusBadRequest,
+			wantBody:      `{"error":"Invalid alias"}`,
+		},
+		{
+			name:          "Delete",
+			requestMethod: http.MethodDelete,
+			locationID:    MockLocation.LocationID.String(),
+			query:         "?alias=breslau",
+			setupMocks: func(cfg *testAPIConfig) {
+				foundLocation(cfg)
+				cfg.mockDB.DeleteLocationAliasFunc = func(ctx context.Context, arg database.DeleteLocationAliasParams) (int64, error) {
+					return 1, nil
+				}
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `{"status":"alias removed"}`,
+			wantLog:    "audit: location alias removed",
+		},
+		{
+			name:          "Delete Unknown Alias",
+			requestMethod: http.MethodDelete,
+			locationID:    MockLocation.LocationID.String(),
+			query:         "?alias=georgia",
+			setupMocks: func(cfg *testAPIConfig) {
+				foundLocation(cfg)
+				cfg.mockDB.DeleteLocationAliasFunc = func(ctx context.Context, arg database.DeleteLocationAliasParams) (int64, error) {
+					return 0, nil
+				}
+			},
+			wantStatus: http.StatusNotFound,
+			wantBody:   `{"error":"Alias not found"}`,
+		},
+		{
+			name:          "Location Not Found",
+			requestMethod: http.MethodGet,
+			locationID:    MockLocation.LocationID.String(),
+			setupMocks: func(cfg *testAPIConfig) {
+				cfg.mockDB.GetLocationByIDFunc = func(ctx context.Context, id uuid.UUID) (database.Location, error) {
+					return database.Location{}, sql.ErrNoRows
+				}
+			},
+			wantStatus: http.StatusNotFound,
+			wantBody:   `{"error":"Location not found"}`,
+		},
+		{
+			name:          "Invalid Location ID",
+			requestMethod: http.MethodGet,
+			locationID:    "not-a-uuid",
+			setupMocks:    func(cfg *testAPIConfig) {},
+			wantStatus:    http.StatusBadRequest,
+			wantBody:      `{"error":"Invalid location ID"}`,
+		},
+		{
+			name:          "Wrong Method",
+			requestMethod: http.MethodPut,
+			locationID:    MockLocation.LocationID.String(),
+			setupMocks:    func(cfg *testAPIConfig) {},
+			wantStatus:    http.StatusMethodNotAllowed,
+			wantBody:      `{"error":"Method Not Allowed"}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testCfg := newTestAPIConfig(t)
+			var logBuf bytes.Buffer
+			testCfg.logger = slog.New(slog.NewTextHandler(&logBuf, nil))
+			tc.setupMocks(testCfg)
+
+			req := httptest.NewRequest(tc.requestMethod, "/admin/locations/"+tc.locationID+"/aliases"+tc.query, nil)
+			req.SetPathValue("id", tc.locationID)
+			rr := httptest.NewRecorder()
+
+			testCfg.apiConfig.handlerLocationAliases(rr, req)
+
+			if status := rr.Code; status != tc.wantStatus {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, tc.wantStatus)
+			}
+			if rr.Body.String() != tc.wantBody {
+				t.Errorf("handler returned unexpected body: got %v want %v", rr.Body.String(), tc.wantBody)
+			}
+			if tc.wantLog != "" && !strings.Contains(logBuf.String(), tc.wantLog) {
+				t.Errorf("expected log to contain %q, got %s", tc.wantLog, logBuf.String())
+			}
+		})
+	}
+}
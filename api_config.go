@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"io"
@@ -8,11 +9,12 @@ import (
 	"log/slog"
 	"net/http"
 	"os"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
-	_ "github.com/lib/pq"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -22,25 +24,89 @@ import (
 // functions, providing them with the necessary context to operate without relying on
 // global state. This design improves testability and clarifies dependencies.
 type apiConfig struct {
-	dbURL                    string
-	redisURL                 string
-	geocoder                 GeocodingService
-	gmpWeatherURL            string
-	owmWeatherURL            string
-	ometeoWeatherURL         string
-	gmpKey                   string
-	owmKey                   string
-	httpClient               *http.Client
-	schedulerCurrentInterval time.Duration
-	schedulerHourlyInterval  time.Duration
-	schedulerDailyInterval   time.Duration
-	port                     string
-	devMode                  bool
-	logger                   *slog.Logger
-	newDBClientFunc          func(driverName, dataSourceName string) (*sql.DB, error)
-	dbQueries                dbQuerier
-	newCacheClientFunc       func(opt *redis.Options) *redis.Client
-	cache                    Cache
+	dbURL                       string
+	dbDriver                    string
+	dbMaxConns                  int
+	dbMaxConnIdleTime           time.Duration
+	dbHealthCheckPeriod         time.Duration
+	migrateOnStart              bool
+	redisURL                    string
+	geocoder                    GeocodingService
+	geocoderProviders           []string
+	timezoner                   TimezoneService
+	gmpGeocodeURL               string
+	nominatimURL                string
+	gmpTimezoneURL              string
+	gmpWeatherURL               string
+	owmWeatherURL               string
+	owmLegacyWeatherURL         string
+	ometeoWeatherURL            string
+	ometeoArchiveURL            string
+	metnoWeatherURL             string
+	ometeoAirQualityURL         string
+	owmAirPollutionURL          string
+	userAgent                   string
+	gmpKey                      *secretValue
+	owmKey                      *secretValue
+	secrets                     *secretStore
+	secretsReloadInterval       time.Duration
+	httpClient                  *http.Client
+	alertWebhookClient          *http.Client
+	schedulerCurrentInterval    time.Duration
+	schedulerHourlyInterval     time.Duration
+	schedulerDailyInterval      time.Duration
+	schedulerAirQualityInterval time.Duration
+	schedulerConcurrency        int
+	schedulerJitter             time.Duration
+	schedulerDrainTimeout       time.Duration
+	schedulerJobTimeout         time.Duration
+	schedulerLocationTimeout    time.Duration
+	refreshPolicy               *locationRefreshPolicy
+	archiveHistory              bool
+	retentionHourlyHours        int
+	retentionDailyDays          int
+	port                        string
+	devMode                     bool
+	logLevel                    *slog.LevelVar
+	logSampler                  *logSampler
+	demoMode                    bool
+	logger                      *slog.Logger
+	newDBClientFunc             func(driverName, dataSourceName string) (*sql.DB, error)
+	db                          *sql.DB
+	dbQueries                   dbQuerier
+	newCacheClientFunc          func(opt *redis.Options) *redis.Client
+	cache                       Cache
+	cacheBackend                string
+	localCacheSize              int
+	localCacheTTL               time.Duration
+	usage                       *providerUsageTracker
+	providerPricing             map[string]float64
+	enabledSources              map[string]bool
+	owmVersion                  *owmVersionTracker
+	citySuggestions             map[string][]string
+	weatherStations             map[string]string
+	camelCaseAPIKeys            map[string]bool
+	adminAPIKeyHashes           map[string]bool
+	apiKeyDailyQuota            int64
+	mapTileURL                  string
+	mapAttribution              string
+	mapCache                    *mapDiskCache
+	defaultUnits                unitSystem
+	forecastDays                int
+	forecastHours               int
+	quota                       *providerQuotaPolicy
+	rateLimits                  *providerRateLimiter
+	providerWeights             map[string]float64
+	breakers                    *providerCircuitBreakers
+	notifier                    *opsNotifier
+	userJWTSecret               *secretValue
+	userSessionTTL              time.Duration
+	fetchMaxRetries             int
+	fetchRetryBase              time.Duration
+	inflight                    *flightGroup
+	latency                     *providerLatencyTracker
+	hedgePercentile             int
+	requestStats                *requestStatsRecorder
 }
 
 // getRequiredEnv provides a safe way to read a mandatory environment variable.
@@ -81,9 +147,71 @@ func getEnvAsInt(key string, fallback int, logger *slog.Logger) int {
 	return val
 }
 
+// getProviderPricing reads per-call provider prices from PROVIDER_COST_PER_CALL, a comma-separated
+// list of id=price pairs (e.g. "gmp=0.00015,owm=0"). Providers that are not listed keep the default
+// price from the registry, and malformed entries are logged and ignored.
+func getProviderPricing(logger *slog.Logger) map[string]float64 {
+	pricing := make(map[string]float64, len(weatherProviders))
+	for _, p := range weatherProviders {
+		pricing[p.ID] = p.DefaultCostPerCall
+	}
+
+	val := os.Getenv("PROVIDER_COST_PER_CALL")
+	if val == "" {
+		return pricing
+	}
+	for _, entry := range strings.Split(val, ",") {
+		id, priceStr, found := strings.Cut(strings.TrimSpace(entry), "=")
+		if !found {
+			logger.Warn("invalid provider pricing entry, ignoring", "entry", entry)
+			continue
+		}
+		if _, ok := providerByID(id); !ok {
+			logger.Warn("unknown provider in pricing configuration, ignoring", "provider", id)
+			continue
+		}
+		price, err := strconv.ParseFloat(priceStr, 64)
+		if err != nil || price < 0 {
+			logger.Warn("invalid provider price, using default", "provider", id, "value", priceStr)
+			continue
+		}
+		pricing[id] = price
+	}
+	return pricing
+}
+
+// getEnabledSources reads the providers to query from WEATHER_SOURCES, a comma-separated list of
+// provider IDs (e.g. "gmp,ometeo"). When the variable is unset every provider is enabled. Unknown
+// IDs are logged and ignored, and a list without any valid provider enables all of them so that a
+// typo cannot silently stop all data collection.
+func getEnabledSources(logger *slog.Logger) map[string]bool {
+	enabled := make(map[string]bool, len(weatherProviders))
+	val := os.Getenv("WEATHER_SOURCES")
+	if val != "" {
+		for _, id := range strings.Split(val, ",") {
+			id = strings.TrimSpace(id)
+			if _, ok := providerByID(id); !ok {
+				logger.Warn("unknown provider in WEATHER_SOURCES, ignoring", "provider", id)
+				continue
+			}
+			enabled[id] = true
+		}
+		if len(enabled) > 0 {
+			return enabled
+		}
+		logger.Warn("WEATHER_SOURCES lists no valid providers, enabling all", "value", val)
+	}
+
+	for _, p := range weatherProviders {
+		enabled[p.ID] = true
+	}
+	return enabled
+}
+
 // config is the application's configuration hub and initialization function.
 // It orchestrates the entire setup process by:
-//  1. Loading environment variables from a .env file for local development.
+//  1. Loading environment variables from a .env file for local development, and from the
+//     optional YAML file named by CONFIG_FILE for variables that are still unset.
 //  2. Establishing and verifying connections to the database (PostgreSQL) and cache (Redis).
 //  3. Initializing service clients for external APIs (e.g., geocoding).
 //  4. Assembling all runtime parameters and dependencies into a single, fully populated
@@ -95,40 +223,103 @@ func NewAPIConfig(output io.Writer) (*apiConfig, error) {
 		log.Println("could not load .env file, proceeding with environment variables")
 	}
 
+	// Secrets are read first, so that neither the config file nor demo mode fill in a setting
+	// that is loaded from a secret.
+	secrets, fromSecrets, secretsErr := loadSecrets(context.Background())
+
+	// The config file only fills in variables that are not set in the environment,
+	// so it has to be applied before anything reads the environment.
+	fromFile, configFileErr := applyConfigFile()
+	// Demo mode in turn only fills in what neither the environment nor the config file set.
+	demoMode, fromDemo, demoModeErr := applyDemoMode()
+
 	devModeStr := os.Getenv("DEV_MODE")
 	devMode, err := strconv.ParseBool(devModeStr)
 	if err != nil {
 		devMode = false
 	}
 
+	// The level is a LevelVar, so that it can be changed at runtime through /admin/loglevel.
+	logLevel := new(slog.LevelVar)
+	initialLevel, logLevelErr := getLogLevel(devMode)
+	logLevel.Set(initialLevel)
+
 	var logger *slog.Logger
 	if devMode {
-		logger = slog.New(slog.NewTextHandler(output, &slog.HandlerOptions{
-			Level: slog.LevelDebug,
-		}))
+		logger = slog.New(requestIDHandler{slog.NewTextHandler(output, &slog.HandlerOptions{
+			Level: logLevel,
+		})})
 	} else {
-		logger = slog.New(slog.NewJSONHandler(output, nil))
+		logger = slog.New(requestIDHandler{slog.NewJSONHandler(output, &slog.HandlerOptions{
+			Level: logLevel,
+		})})
 	}
 
 	cfg := &apiConfig{
-		logger: logger,
+		logger:   logger,
+		logLevel: logLevel,
+	}
+	if logLevelErr != nil {
+		logger.Warn("invalid LOG_LEVEL, using default", "level", initialLevel.String(), "error", logLevelErr)
 	}
 
-	dbURL, err := getRequiredEnv("DB_URL", logger)
-	if err != nil {
-		return cfg, err
+	if secretsErr != nil {
+		logger.Error("could not load secrets", "error", secretsErr)
+		return cfg, secretsErr
+	}
+	if len(fromSecrets) > 0 {
+		logger.Info("loaded settings from secrets", "keys", fromSecrets)
+	}
+	if configFileErr != nil {
+		logger.Error("could not load config file", "path", os.Getenv("CONFIG_FILE"), "error", configFileErr)
+		return cfg, configFileErr
+	}
+	if len(fromFile) > 0 {
+		logger.Info("loaded settings from config file", "path", os.Getenv("CONFIG_FILE"), "keys", fromFile)
+	}
+	if demoModeErr != nil {
+		logger.Error("invalid demo mode setting", "error", demoModeErr)
+		return cfg, demoModeErr
+	}
+	if len(fromDemo) > 0 {
+		logger.Info("applied demo mode defaults", "keys", fromDemo)
 	}
 
-	redisURL, err := getRequiredEnv("REDIS_URL", logger)
-	if err != nil {
+	// Every missing setting is reported at once, instead of one per attempt to start.
+	cacheBackend := getCacheBackend(logger)
+	if err := checkRequiredSettings(cacheBackend); err != nil {
+		logger.Error("invalid configuration", "error", err)
 		return cfg, err
 	}
 
-	gmpKey, err := getRequiredEnv("GMP_KEY", logger)
+	// DB_URL is the PostgreSQL connection string, or the database file with DB_DRIVER=sqlite.
+	dbDriver := getDBDriver(logger)
+	dbURL, err := getRequiredEnv("DB_URL", logger)
 	if err != nil {
 		return cfg, err
 	}
 
+	// Redis is only needed by the Redis cache backend.
+	var redisURL string
+	if cacheBackend == cacheBackendRedis {
+		redisURL, err = getRequiredEnv("REDIS_URL", logger)
+		if err != nil {
+			return cfg, err
+		}
+	}
+
+	// Without a Google Maps key the application still runs: the gmp weather source is disabled
+	// and, unless Nominatim is selected, geocoding is limited to the bundled city dataset.
+	gmpKey := os.Getenv("GMP_KEY")
+	geocoderProviders := getGeocoderProviders(logger)
+	if gmpKey == "" {
+		if !slices.Contains(geocoderProviders, nominatimGeocoder) {
+			logger.Warn("GMP_KEY not set, geocoding limited to the bundled city dataset and gmp source disabled")
+		} else {
+			logger.Warn("GMP_KEY not set, gmp source disabled")
+		}
+	}
+
 	gmpGeocodeURL, err := getRequiredEnv("GMP_GEOCODE_URL", logger)
 	if err != nil {
 		return cfg, err
@@ -157,32 +348,125 @@ func NewAPIConfig(output io.Writer) (*apiConfig, error) {
 	currentIntervalMin := getEnvAsInt("CURRENT_INTERVAL_MIN", 10, logger)
 	hourlyIntervalMin := getEnvAsInt("HOURLY_INTERVAL_MIN", 60, logger)
 	dailyIntervalMin := getEnvAsInt("DAILY_INTERVAL_MIN", 720, logger)
+	airQualityIntervalMin := getEnvAsInt("AIR_QUALITY_INTERVAL_MIN", 60, logger)
 
+	userAgent := getEnv("HTTP_USER_AGENT", defaultUserAgent, logger)
 	httpClient := &http.Client{
 		Timeout: 10 * time.Second,
 		Transport: &metricsTransport{
-			wrapped: http.DefaultTransport,
+			wrapped: &userAgentTransport{
+				wrapped:   http.DefaultTransport,
+				userAgent: userAgent,
+			},
 		},
 	}
 
-	geocoder := NewGmpGeocodingService(gmpKey, gmpGeocodeURL, httpClient)
+	// The keys follow rotations of their secrets, if they are loaded from one.
+	gmpKeyValue := secrets.valueOr("GMP_KEY", gmpKey)
+	nominatimURL := getEnv("NOMINATIM_URL", defaultNominatimURL, logger)
+	geocodeFallback := newGeocoderChain(geocoderProviders, gmpKeyValue, gmpGeocodeURL, nominatimURL, httpClient, logger)
+	geocoder, err := NewStaticGeocodingService(geocodeFallback)
+	if err != nil {
+		logger.Error("could not create geocoder", "error", err)
+		return cfg, err
+	}
+	gmpTimezoneURL := getEnv("GMP_TIMEZONE_URL", "https://maps.googleapis.com/maps/api/timezone/", logger)
+	timezoner := NewGmpTimezoneService(gmpKeyValue, gmpTimezoneURL, httpClient)
 
 	cfg.dbURL = dbURL
+	cfg.dbDriver = dbDriver
 	cfg.redisURL = redisURL
+	cfg.cacheBackend = cacheBackend
 	cfg.geocoder = geocoder
+	cfg.geocoderProviders = geocoderProviders
+	cfg.timezoner = timezoner
+	cfg.gmpGeocodeURL = gmpGeocodeURL
+	cfg.nominatimURL = nominatimURL
+	cfg.gmpTimezoneURL = gmpTimezoneURL
 	cfg.gmpWeatherURL = gmpWeatherURL
 	cfg.owmWeatherURL = owmWeatherURL
+	cfg.owmLegacyWeatherURL = getEnv("OWM_LEGACY_WEATHER_URL", "https://api.openweathermap.org/data/2.5/", logger)
 	cfg.ometeoWeatherURL = ometeoWeatherURL
-	cfg.gmpKey = gmpKey
-	cfg.owmKey = owmKey
+	cfg.ometeoArchiveURL = getEnv("OMETEO_ARCHIVE_URL", "https://archive-api.open-meteo.com/v1/archive?", logger)
+	cfg.metnoWeatherURL = getEnv("METNO_WEATHER_URL", "https://api.met.no/weatherapi/locationforecast/2.0/complete?", logger)
+	cfg.ometeoAirQualityURL = getEnv("OMETEO_AIR_QUALITY_URL", "https://air-quality-api.open-meteo.com/v1/air-quality?", logger)
+	cfg.owmAirPollutionURL = getEnv("OWM_AIR_POLLUTION_URL", "https://api.openweathermap.org/data/2.5/air_pollution?", logger)
+	cfg.userAgent = userAgent
+	cfg.secrets = secrets
+	cfg.secretsReloadInterval = getSecretsReloadInterval(logger)
+	cfg.gmpKey = gmpKeyValue
+	cfg.owmKey = secrets.valueOr("OWM_KEY", owmKey)
 	cfg.httpClient = httpClient
+	cfg.alertWebhookClient = newAlertWebhookClient(userAgent)
 	cfg.schedulerCurrentInterval = time.Duration(currentIntervalMin) * time.Minute
 	cfg.schedulerHourlyInterval = time.Duration(hourlyIntervalMin) * time.Minute
 	cfg.schedulerDailyInterval = time.Duration(dailyIntervalMin) * time.Minute
+	cfg.schedulerAirQualityInterval = time.Duration(airQualityIntervalMin) * time.Minute
+	cfg.schedulerConcurrency = getSchedulerConcurrency(logger)
+	cfg.schedulerJitter = getSchedulerJitter(logger)
+	cfg.schedulerDrainTimeout = getSchedulerDrainTimeout(logger)
+	cfg.schedulerJobTimeout = getSchedulerJobTimeout(logger)
+	cfg.schedulerLocationTimeout = getSchedulerLocationTimeout(logger)
+	cfg.refreshPolicy = newLocationRefreshPolicy(logger)
+	cfg.archiveHistory = getArchiveHistory(logger)
+	cfg.retentionHourlyHours = getRetentionHourlyHours(logger)
+	cfg.retentionDailyDays = getRetentionDailyDays(logger)
 	cfg.port = getEnv("PORT", "8080", logger)
 	cfg.devMode = devMode
-	cfg.newDBClientFunc = sql.Open
+	cfg.logSampler = newLogSampler(getLogSampleRate(logger))
+	cfg.newDBClientFunc = cfg.openDB
+	cfg.dbMaxConns = getDBMaxConns(logger)
+	cfg.dbMaxConnIdleTime = getDBMaxConnIdleTime(logger)
+	cfg.dbHealthCheckPeriod = getDBHealthCheckPeriod(logger)
+	cfg.migrateOnStart = getMigrateOnStart(logger)
 	cfg.newCacheClientFunc = redis.NewClient
+	cfg.localCacheSize = getLocalCacheSize(logger)
+	cfg.localCacheTTL = getLocalCacheTTL(logger)
+	cfg.usage = newProviderUsageTracker()
+	cfg.providerPricing = getProviderPricing(logger)
+	cfg.enabledSources = getEnabledSources(logger)
+	if gmpKey == "" {
+		delete(cfg.enabledSources, "gmp")
+		if len(cfg.enabledSources) == 0 {
+			err := errors.New("GMP_KEY is required when gmp is the only enabled weather source")
+			logger.Error("invalid configuration", "error", err)
+			return cfg, err
+		}
+	}
+	cfg.requestStats = newRequestStatsRecorder()
+	cfg.owmVersion = newOWMVersionTracker()
+	cfg.citySuggestions = getCitySuggestions(logger)
+	cfg.weatherStations = getWeatherStations(logger)
+	cfg.camelCaseAPIKeys = getCamelCaseAPIKeys(logger)
+	cfg.adminAPIKeyHashes = getAdminAPIKeys(logger)
+	cfg.apiKeyDailyQuota = getAPIKeyDailyQuota(logger)
+	cfg.mapTileURL = getEnv("MAP_TILE_URL", defaultMapTileURL, logger)
+	cfg.mapAttribution = getEnv("MAP_ATTRIBUTION", defaultMapAttribution, logger)
+	cfg.mapCache = newMapDiskCache(getEnv("MAP_CACHE_DIR", "", logger), getMapCacheMaxBytes(logger), logger)
+	cfg.defaultUnits = getDefaultUnits(logger)
+	cfg.forecastDays = getForecastDays(logger)
+	cfg.forecastHours = getForecastHours(logger)
+	cfg.latency = newProviderLatencyTracker()
+	cfg.hedgePercentile = getHedgePercentile(logger)
+	cfg.quota = newProviderQuotaPolicy(getProviderQuotas(logger), getQuotaDegradePercent(logger), logger)
+	cfg.rateLimits = newProviderRateLimiter(getProviderRateLimits(logger))
+	cfg.providerWeights = getProviderWeights(logger)
+	cfg.notifier = newOpsNotifier(getNotifyWebhookURL("NOTIFY_SLACK_WEBHOOK_URL", logger), getNotifyWebhookURL("NOTIFY_WEBHOOK_URL", logger), getNotifyFailureThreshold(logger), getNotifyCooldown(logger), httpClient, logger)
+	cfg.breakers = newProviderCircuitBreakers(getCircuitBreakerThreshold(logger), getCircuitBreakerCooldown(logger), logger)
+	cfg.breakers.notifier = cfg.notifier
+	cfg.userJWTSecret = secrets.valueOr("USER_JWT_SECRET", os.Getenv("USER_JWT_SECRET"))
+	cfg.userSessionTTL = getUserSessionTTL(logger)
+	cfg.fetchMaxRetries = getFetchMaxRetries(logger)
+	cfg.fetchRetryBase = getFetchRetryBase(logger)
+	cfg.inflight = newFlightGroup()
+	if demoMode {
+		if err := cfg.enableDemoMode(); err != nil {
+			logger.Error("could not enable demo mode", "error", err)
+			return cfg, err
+		}
+	}
+	logger.Info("weather sources enabled", "sources", cfg.enabledSources)
+	cfg.logEffectiveConfig()
 
 	return cfg, nil
 }
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// This file implements the daily request quotas of API keys, so that keys can be handed to
+// third-party integrators without putting the provider quotas at risk. Requests to the public
+// API with an X-API-Key header are counted per key and UTC day in the cache, which all instances
+// share. Client keys, created with -create-api-key and -api-key-quota, are limited to their own
+// quota, admin keys are never limited, and any other key is limited to API_KEY_DAILY_QUOTA; a
+// quota of 0 leaves a key unlimited. Responses to a key with a quota carry the X-RateLimit-Limit,
+// X-RateLimit-Remaining and X-RateLimit-Reset headers, and requests beyond the quota are
+// rejected with 429 Too Many Requests until the next UTC day. Counting fails open: while the
+// cache is unavailable, requests are served without being counted.
+
+const (
+	// apiKeyUsageCacheKeyPrefix is the cache key prefix of the daily request counters. The full
+	// key is "<prefix>:<key hash>:<UTC date>".
+	apiKeyUsageCacheKeyPrefix = "apikeyusage"
+	// apiKeyUsageTTL is how long a daily counter is kept after the first request of the day.
+	apiKeyUsageTTL = 48 * time.Hour
+
+	// apiKeyQuotaCacheKeyPrefix is the cache key prefix of the quotas looked up for API keys.
+	apiKeyQuotaCacheKeyPrefix = "apikeyquota"
+	// apiKeyQuotaCacheTTL is how long a looked-up quota is cached, and so how long a changed
+	// quota may take to apply.
+	apiKeyQuotaCacheTTL = 5 * time.Minute
+)
+
+// errAPIKeyQuotaExceeded is the error message of a request beyond its key's daily quota.
+const errAPIKeyQuotaExceeded = "Daily API key quota exceeded"
+
+// apiKeyUsage is the use of an API key's daily quota.
+type apiKeyUsage struct {
+	used    int64
+	limit   int64 // 0 if the key is unlimited.
+	resetAt time.Time
+}
+
+// remaining returns the number of requests left today. It is only meaningful with a limit.
+func (u apiKeyUsage) remaining() int64 {
+	return max(u.limit-u.used, 0)
+}
+
+// getAPIKeyDailyQuota reads API_KEY_DAILY_QUOTA, the number of requests an API key may make per
+// UTC day unless it has a quota of its own. The default of 0 leaves keys unlimited; negative
+// values are ignored.
+func getAPIKeyDailyQuota(logger *slog.Logger) int64 {
+	n := getEnvAsInt("API_KEY_DAILY_QUOTA", 0, logger)
+	if n < 0 {
+		logger.Warn("API_KEY_DAILY_QUOTA must not be negative, using default", "value", n)
+		return 0
+	}
+	return int64(n)
+}
+
+// apiKeyUsageCacheKey returns the cache key of an API key's request counter for a UTC day.
+func apiKeyUsageCacheKey(keyHash string, day time.Time) string {
+	return fmt.Sprintf("%s:%s:%s", apiKeyUsageCacheKeyPrefix, keyHash, day.UTC().Format(time.DateOnly))
+}
+
+// nextQuotaReset returns the start of the UTC day after now, when the daily counters restart.
+func nextQuotaReset(now time.Time) time.Time {
+	return now.UTC().Truncate(24 * time.Hour).Add(24 * time.Hour)
+}
+
+// apiKeyQuota returns the daily quota of the API key with the given hash, or 0 if the key is
+// unlimited. Client keys have the quota stored with them, admin keys are unlimited, and keys
+// that are not stored at all get API_KEY_DAILY_QUOTA.
+func (cfg *apiConfig) apiKeyQuota(ctx context.Context, keyHash string) (int64, error) {
+	if cfg.adminAPIKeyHashes[keyHash] {
+		return 0, nil
+	}
+	cacheKey := apiKeyQuotaCacheKeyPrefix + ":" + keyHash
+	if val, err := cfg.cache.Get(ctx, cacheKey); err == nil {
+		var quota int64
+		if err := json.Unmarshal([]byte(val), &quota); err == nil {
+			return quota, nil
+		}
+	}
+
+	quota := cfg.apiKeyDailyQuota
+	apiKey, err := cfg.dbQueries.GetActiveAPIKeyByHash(ctx, keyHash)
+	switch {
+	case err == nil:
+		quota = int64(apiKey.DailyQuota.Int32)
+	case !errors.Is(err, sql.ErrNoRows):
+		return 0, fmt.Errorf("could not look up API key: %w", err)
+	}
+	if err := cfg.cache.Set(ctx, cacheKey, quota, apiKeyQuotaCacheTTL); err != nil {
+		cfg.logger.Debug("could not cache API key quota", "error", err)
+	}
+	return quota, nil
+}
+
+// countAPIKeyRequest counts a request of the API key with the given hash and returns the key's
+// usage of the day including it.
+func (cfg *apiConfig) countAPIKeyRequest(ctx context.Context, keyHash string, now time.Time) (apiKeyUsage, error) {
+	limit, err := cfg.apiKeyQuota(ctx, keyHash)
+	if err != nil {
+		return apiKeyUsage{}, err
+	}
+	used, err := cfg.cache.Incr(ctx, apiKeyUsageCacheKey(keyHash, now), apiKeyUsageTTL)
+	if err != nil {
+		return apiKeyUsage{}, fmt.Errorf("could not count request: %w", err)
+	}
+	return apiKeyUsage{used: used, limit: limit, resetAt: nextQuotaReset(now)}, nil
+}
+
+// getAPIKeyUsage returns the usage of the day of the API key with the given hash without
+// counting a request.
+func (cfg *apiConfig) getAPIKeyUsage(ctx context.Context, keyHash string, now time.Time) (apiKeyUsage, error) {
+	limit, err := cfg.apiKeyQuota(ctx, keyHash)
+	if err != nil {
+		return apiKeyUsage{}, err
+	}
+	usage := apiKeyUsage{limit: limit, resetAt: nextQuotaReset(now)}
+	val, err := cfg.cache.Get(ctx, apiKeyUsageCacheKey(keyHash, now))
+	if errors.Is(err, redis.Nil) {
+		return usage, nil
+	}
+	if err != nil {
+		return apiKeyUsage{}, err
+	}
+	if usage.used, err = strconv.ParseInt(val, 10, 64); err != nil {
+		return apiKeyUsage{}, fmt.Errorf("invalid request counter %q: %w", val, err)
+	}
+	return usage, nil
+}
+
+// setRateLimitHeaders reports the usage of a key with a quota in the X-RateLimit-* headers.
+func setRateLimitHeaders(h http.Header, usage apiKeyUsage) {
+	h.Set("X-RateLimit-Limit", strconv.FormatInt(usage.limit, 10))
+	h.Set("X-RateLimit-Remaining", strconv.FormatInt(usage.remaining(), 10))
+	h.Set("X-RateLimit-Reset", strconv.FormatInt(usage.resetAt.Unix(), 10))
+}
+
+// isAPIUsagePath reports whether a path is the usage endpoint, which is not counted so that a
+// key can always look up its usage.
+func isAPIUsagePath(path string) bool {
+	return path == apiV1Prefix+"/usage" || path == legacyAPIPrefix+"/usage"
+}
+
+// apiKeyQuotaMiddleware counts the requests to the public API made with an API key and rejects
+// those beyond the key's daily quota.
+func (cfg *apiConfig) apiKeyQuotaMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("X-API-Key")
+		if key == "" || !strings.HasPrefix(r.URL.Path, legacyAPIPrefix+"/") || isAPIUsagePath(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		now := time.Now()
+		usage, err := cfg.countAPIKeyRequest(r.Context(), apiKeyHash(key), now)
+		if err != nil {
+			cfg.logger.Warn("could not count API key request, serving it uncounted", "error", err)
+			next.ServeHTTP(w, r)
+			return
+		}
+		if usage.limit > 0 {
+			setRateLimitHeaders(w.Header(), usage)
+			if usage.used > usage.limit {
+				apiKeyQuotaRejections.Inc()
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(usage.resetAt.Sub(now).Seconds()))))
+				cfg.respondWithError(w, http.StatusTooManyRequests, errAPIKeyQuotaExceeded, nil)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// @Summary      Get API key usage
+// @Description  Reports how many requests the API key in X-API-Key has made today (UTC), its daily quota and when
+// @Description  the count restarts. Limit and remaining are null for an unlimited key. Requests to this endpoint are
+// @Description  not counted.
+// @Tags         usage
+// @Produce      json
+// @Param        X-API-Key  header    string  true  "API key"
+// @Success      200  {object}  APIKeyUsageResponse
+// @Failure      401  {object}  ErrorResponse "Unauthorized - Missing API key"
+// @Failure      503  {object}  ErrorResponse "Service Unavailable - Cache is being bypassed"
+// @Router       /api/v1/usage [get]
+func (cfg *apiConfig) handlerAPIKeyUsage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		cfg.respondWithError(w, http.StatusMethodNotAllowed, "Method Not Allowed", nil)
+		return
+	}
+	key := r.Header.Get("X-API-Key")
+	if key == "" {
+		cfg.respondWithError(w, http.StatusUnauthorized, "API key required", nil)
+		return
+	}
+
+	now := time.Now()
+	usage, err := cfg.getAPIKeyUsage(r.Context(), apiKeyHash(key), now)
+	if errors.Is(err, errCacheUnavailable) {
+		cfg.respondWithError(w, http.StatusServiceUnavailable, "Cache is temporarily unavailable", err)
+		return
+	}
+	if err != nil {
+		cfg.respondWithError(w, http.StatusInternalServerError, "Failed to get API key usage", err)
+		return
+	}
+
+	response := APIKeyUsageResponse{
+		Date:    now.UTC().Format(time.DateOnly),
+		Used:    usage.used,
+		ResetAt: usage.resetAt.Format(time.RFC3339),
+	}
+	if usage.limit > 0 {
+		remaining := usage.remaining()
+		response.Limit, response.Remaining = &usage.limit, &remaining
+		setRateLimitHeaders(w.Header(), usage)
+	}
+	cfg.respondWithJSON(w, http.StatusOK, response)
+}
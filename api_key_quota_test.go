@@ -0,0 +1,216 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cor0nius/willitrain/internal/database"
+	"github.com/cor0nius/willitrain/internal/testkit"
+)
+
+func TestGetAPIKeyDailyQuota(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	t.Setenv("API_KEY_DAILY_QUOTA", "1000")
+	if got := getAPIKeyDailyQuota(logger); got != 1000 {
+		t.Errorf("getAPIKeyDailyQuota() = %d, want 1000", got)
+	}
+	t.Setenv("API_KEY_DAILY_QUOTA", "-5")
+	if got := getAPIKeyDailyQuota(logger); got != 0 {
+		t.Errorf("expected a negative quota to be ignored, got %d", got)
+	}
+}
+
+func TestAPIKeyQuota(t *testing.T) {
+	testCfg := newTestAPIConfig(t)
+	testCfg.cache = testkit.NewMemoryCache()
+	testCfg.apiKeyDailyQuota = 100
+	testCfg.adminAPIKeyHashes = map[string]bool{apiKeyHash("admin"): true}
+	lookups := 0
+	testCfg.mockDB.GetActiveAPIKeyByHashFunc = func(ctx context.Context, keyHash string) (database.ApiKey, error) {
+		lookups++
+		switch keyHash {
+		case apiKeyHash("partner"):
+			return database.ApiKey{Name: "partner", DailyQuota: sql.NullInt32{Int32: 5000, Valid: true}}, nil
+		case apiKeyHash("internal"):
+			return database.ApiKey{Name: "internal", DailyQuota: sql.NullInt32{Int32: 0, Valid: true}}, nil
+		case apiKeyHash("ci"):
+			return database.ApiKey{Name: "ci"}, nil
+		case apiKeyHash("broken"):
+			return database.ApiKey{}, errors.New("connection refused")
+		}
+		return database.ApiKey{}, sql.ErrNoRows
+	}
+
+	testCases := []struct {
+		key     string
+		want    int64
+		wantErr bool
+	}{
+		{key: "partner", want: 5000},
+		{key: "internal", want: 0},
+		{key: "ci", want: 0},
+		{key: "unknown", want: 100},
+		{key: "admin", want: 0},
+		{key: "broken", wantErr: true},
+	}
+	for _, tc := range testCases {
+		got, err := testCfg.apiKeyQuota(context.Background(), apiKeyHash(tc.key))
+		if (err != nil) != tc.wantErr || got != tc.want {
+			t.Errorf("apiKeyQuota(%q) = %d, %v; want %d", tc.key, got, err, tc.want)
+		}
+	}
+
+	// Looked-up quotas are cached.
+	lookups = 0
+	if got, _ := testCfg.apiKeyQuota(context.Background(), apiKeyHash("partner")); got != 5000 || lookups != 0 {
+		t.Errorf("expected the cached quota without a lookup, got %d after %d lookups", got, lookups)
+	}
+}
+
+func TestAPIKeyQuotaMiddleware(t *testing.T) {
+	testCfg := newTestAPIConfig(t)
+	testCfg.cache = testkit.NewMemoryCache()
+	testCfg.apiKeyDailyQuota = 2
+	testCfg.mockDB.GetActiveAPIKeyByHashFunc = func(ctx context.Context, keyHash string) (database.ApiKey, error) {
+		if keyHash == apiKeyHash("unlimited") {
+			return database.ApiKey{Name: "unlimited", DailyQuota: sql.NullInt32{Valid: true}}, nil
+		}
+		return database.ApiKey{}, sql.ErrNoRows
+	}
+	served := 0
+	handler := testCfg.apiKeyQuotaMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		served++
+	}))
+	send := func(path, key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if key != "" {
+			req.Header.Set("X-API-Key", key)
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	reset := strconv.FormatInt(nextQuotaReset(time.Now()).Unix(), 10)
+	for i, wantRemaining := range []string{"1", "0"} {
+		rr := send("/api/v1/currentweather", "partner")
+		if rr.Code != http.StatusOK {
+			t.Fatalf("request %d: status = %d, want 200", i+1, rr.Code)
+		}
+		if got := rr.Header().Get("X-RateLimit-Remaining"); got != wantRemaining {
+			t.Errorf("request %d: X-RateLimit-Remaining = %q, want %q", i+1, got, wantRemaining)
+		}
+		if rr.Header().Get("X-RateLimit-Limit") != "2" || rr.Header().Get("X-RateLimit-Reset") != reset {
+			t.Errorf("request %d: unexpected headers %v", i+1, rr.Header())
+		}
+	}
+
+	rr := send("/api/currentweather", "partner")
+	if rr.Code != http.StatusTooManyRequests || rr.Body.String() != `{"error":"Daily API key quota exceeded"}` {
+		t.Errorf("expected the request beyond the quota to be rejected, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if retryAfter, err := strconv.Atoi(rr.Header().Get("Retry-After")); err != nil || retryAfter <= 0 || retryAfter > 86400 {
+		t.Errorf("unexpected Retry-After %q", rr.Header().Get("Retry-After"))
+	}
+	if served != 2 {
+		t.Errorf("expected 2 requests to be served, got %d", served)
+	}
+
+	// Other keys, requests without a key, the usage endpoint and paths outside the API are not
+	// limited by the exhausted key.
+	for _, req := range []struct{ path, key string }{
+		{"/api/v1/currentweather", "other"},
+		{"/api/v1/currentweather", ""},
+		{"/api/v1/usage", "partner"},
+		{"/metrics", "partner"},
+	} {
+		if rr := send(req.path, req.key); rr.Code != http.StatusOK {
+			t.Errorf("%s with key %q: status = %d, want 200", req.path, req.key, rr.Code)
+		}
+	}
+
+	// A key without a quota is counted, but gets no rate limit headers.
+	for range 3 {
+		if rr := send("/api/v1/currentweather", "unlimited"); rr.Code != http.StatusOK || rr.Header().Get("X-RateLimit-Limit") != "" {
+			t.Errorf("unexpected response for an unlimited key: %d %v", rr.Code, rr.Header())
+		}
+	}
+	if usage, err := testCfg.getAPIKeyUsage(context.Background(), apiKeyHash("unlimited"), time.Now()); err != nil || usage.used != 3 {
+		t.Errorf("expected 3 counted requests, got %+v, %v", usage, err)
+	}
+
+	// Requests are served uncounted while the cache is unavailable.
+	testCfg.cache = &testkit.Cache{IncrFunc: func(ctx context.Context, key string, expiration time.Duration) (int64, error) {
+		return 0, errCacheUnavailable
+	}}
+	if rr := send("/api/v1/currentweather", "partner"); rr.Code != http.StatusOK || rr.Header().Get("X-RateLimit-Limit") != "" {
+		t.Errorf("expected the request to be served uncounted, got %d %v", rr.Code, rr.Header())
+	}
+}
+
+func TestHandlerAPIKeyUsage(t *testing.T) {
+	testCases := []struct {
+		name       string
+		method     string
+		key        string
+		used       int
+		cacheDown  bool
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "Limited", method: http.MethodGet, key: "partner", used: 3, wantStatus: http.StatusOK, wantBody: `"used":3,"limit":10,"remaining":7`},
+		{name: "Exhausted", method: http.MethodGet, key: "partner", used: 12, wantStatus: http.StatusOK, wantBody: `"used":12,"limit":10,"remaining":0`},
+		{name: "Unlimited", method: http.MethodGet, key: "unlimited", used: 1, wantStatus: http.StatusOK, wantBody: `"used":1,"limit":null,"remaining":null`},
+		{name: "Unused", method: http.MethodGet, key: "partner", wantStatus: http.StatusOK, wantBody: `"used":0,"limit":10,"remaining":10`},
+		{name: "Missing Key", method: http.MethodGet, wantStatus: http.StatusUnauthorized, wantBody: `{"error":"API key required"}`},
+		{name: "Cache Unavailable", method: http.MethodGet, key: "partner", cacheDown: true, wantStatus: http.StatusServiceUnavailable, wantBody: `{"error":"Cache is temporarily unavailable"}`},
+		{name: "Wrong Method", method: http.MethodPost, key: "partner", wantStatus: http.StatusMethodNotAllowed, wantBody: `{"error":"Method Not Allowed"}`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testCfg := newTestAPIConfig(t)
+			testCfg.cache = testkit.NewMemoryCache()
+			testCfg.apiKeyDailyQuota = 10
+			testCfg.mockDB.GetActiveAPIKeyByHashFunc = func(ctx context.Context, keyHash string) (database.ApiKey, error) {
+				if keyHash == apiKeyHash("unlimited") {
+					return database.ApiKey{DailyQuota: sql.NullInt32{Valid: true}}, nil
+				}
+				return database.ApiKey{}, sql.ErrNoRows
+			}
+			for range tc.used {
+				if _, err := testCfg.countAPIKeyRequest(context.Background(), apiKeyHash(tc.key), time.Now()); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tc.cacheDown {
+				testCfg.cache = &testkit.Cache{GetFunc: func(ctx context.Context, key string) (string, error) {
+					return "", errCacheUnavailable
+				}}
+			}
+
+			req := httptest.NewRequest(tc.method, "/api/v1/usage", nil)
+			if tc.key != "" {
+				req.Header.Set("X-API-Key", tc.key)
+			}
+			rr := httptest.NewRecorder()
+			testCfg.apiConfig.handlerAPIKeyUsage(rr, req)
+
+			if rr.Code != tc.wantStatus {
+				t.Fatalf("status = %d, want %d; body: %s", rr.Code, tc.wantStatus, rr.Body.String())
+			}
+			if !strings.Contains(rr.Body.String(), tc.wantBody) {
+				t.Errorf("body = %s, want it to contain %s", rr.Body.String(), tc.wantBody)
+			}
+		})
+	}
+}
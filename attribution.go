@@ -0,0 +1,65 @@
+package main
+
+import (
+	"net/http"
+	"slices"
+)
+
+// This file exposes the licensing and attribution requirements of the weather providers.
+// The metadata lives in the provider registry; forecast responses embed the entries for
+// the sources they contain, and /api/attribution lists all of them so that clients can
+// render the required notices without hard-coding provider strings. The geocoder is listed as
+// well when its data requires attribution.
+
+// attributionForSources returns the attribution entries for the given SourceAPI values,
+// deduplicated and in registry order. Unknown sources are ignored.
+func attributionForSources(sources []string) []AttributionJSON {
+	seen := make(map[string]bool, len(sources))
+	for _, s := range sources {
+		seen[s] = true
+	}
+
+	var attribution []AttributionJSON
+	for _, p := range weatherProviders {
+		if seen[p.DisplayName] {
+			attribution = append(attribution, providerAttribution(p))
+		}
+	}
+	return attribution
+}
+
+// providerAttribution converts a registry entry to its attribution JSON representation.
+func providerAttribution(p weatherProvider) AttributionJSON {
+	return AttributionJSON{
+		Provider:    p.ID,
+		DisplayName: p.DisplayName,
+		HomepageURL: p.HomepageURL,
+		LicenseName: p.LicenseName,
+		LicenseURL:  p.LicenseURL,
+		Notice:      p.Notice,
+	}
+}
+
+// @Summary      Get provider attribution
+// @Description  Lists the display names, license URLs and required notices of all weather data providers,
+// @Description  and of the geocoder if it requires attribution.
+// @Tags         weather
+// @Produce      json
+// @Success      200  {object}  AttributionResponse
+// @Router       /api/v1/attribution [get]
+func (cfg *apiConfig) handlerAttribution(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		cfg.respondWithError(w, http.StatusMethodNotAllowed, "Method Not Allowed", nil)
+		return
+	}
+
+	providers := make([]AttributionJSON, len(weatherProviders))
+	for i, p := range weatherProviders {
+		providers[i] = providerAttribution(p)
+	}
+	response := AttributionResponse{Providers: providers}
+	if slices.Contains(cfg.geocoderProviders, nominatimGeocoder) {
+		response.Geocoder = &nominatimAttribution
+	}
+	cfg.respondWithJSON(w, http.StatusOK, response)
+}
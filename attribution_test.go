@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAttributionForSources(t *testing.T) {
+	testCases := []struct {
+		name    string
+		sources []string
+		want    []string
+	}{
+		{
+			name:    "Deduplicated In Registry Order",
+			sources: []string{"Open-Meteo API", "Google Weather API", "Open-Meteo API"},
+			want:    []string{"gmp", "ometeo"},
+		},
+		{
+			name:    "Unknown Sources Ignored",
+			sources: []string{"test1", "OpenWeatherMap API"},
+			want:    []string{"owm"},
+		},
+		{
+			name:    "No Known Sources",
+			sources: []string{"test1"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := attributionForSources(tc.sources)
+			if len(got) != len(tc.want) {
+				t.Fatalf("expected %d entries, got %d: %+v", len(tc.want), len(got), got)
+			}
+			for i, id := range tc.want {
+				if got[i].Provider != id {
+					t.Errorf("entry %d: expected provider %q, got %q", i, id, got[i].Provider)
+				}
+				if got[i].Notice == "" || got[i].LicenseURL == "" {
+					t.Errorf("entry %d: expected notice and license URL, got %+v", i, got[i])
+				}
+			}
+		})
+	}
+}
+
+func TestHandlerAttribution(t *testing.T) {
+	testCfg := newTestAPIConfig(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/attribution", nil)
+	rr := httptest.NewRecorder()
+	testCfg.apiConfig.handlerAttribution(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+	var response AttributionResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(response.Providers) != len(weatherProviders) {
+		t.Errorf("expected %d providers, got %d", len(weatherProviders), len(response.Providers))
+	}
+	if response.Geocoder != nil {
+		t.Errorf("expected no geocoder attribution for the Google geocoder, got %+v", response.Geocoder)
+	}
+
+	testCfg.geocoderProviders = []string{googleGeocoder, nominatimGeocoder}
+	rr = httptest.NewRecorder()
+	testCfg.apiConfig.handlerAttribution(rr, req)
+	response = AttributionResponse{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if response.Geocoder == nil || response.Geocoder.Provider != nominatimGeocoder {
+		t.Errorf("expected Nominatim geocoder attribution, got %+v", response.Geocoder)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/api/attribution", nil)
+	rr = httptest.NewRecorder()
+	testCfg.apiConfig.handlerAttribution(rr, req)
+
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/cor0nius/willitrain/internal/database"
+	"github.com/google/uuid"
+)
+
+// This file implements API key authentication for the development and admin endpoints, and
+// for any future endpoint that changes state on behalf of an operator rather than a subscriber.
+// A request is authorized by an X-API-Key header holding one of the static keys in
+// ADMIN_API_KEYS or a key stored in the api_keys table. Keys are compared by their SHA-256 hash,
+// so raw keys are neither kept in memory nor stored.
+
+// errInvalidAPIKey is returned for an API key that is unknown or revoked.
+var errInvalidAPIKey = errors.New("invalid API key")
+
+// staticAPIKeyName is the name logged for requests authorized by a key from ADMIN_API_KEYS.
+const staticAPIKeyName = "ADMIN_API_KEYS"
+
+// getAdminAPIKeys reads ADMIN_API_KEYS, a comma-separated list of static API keys, and returns
+// their hashes.
+func getAdminAPIKeys(logger *slog.Logger) map[string]bool {
+	hashes := make(map[string]bool)
+	for _, key := range strings.Split(os.Getenv("ADMIN_API_KEYS"), ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			hashes[apiKeyHash(key)] = true
+		}
+	}
+	if len(hashes) > 0 {
+		logger.Info("static admin API keys configured", "count", len(hashes))
+	}
+	return hashes
+}
+
+// authorizeAPIKey returns the name of an API key, or errInvalidAPIKey if the key is neither a
+// static key nor an active admin key in the database. Client keys, which have a daily quota, are
+// only accepted by the public API.
+func (cfg *apiConfig) authorizeAPIKey(ctx context.Context, key string) (string, error) {
+	hash := apiKeyHash(key)
+	if cfg.adminAPIKeyHashes[hash] {
+		return staticAPIKeyName, nil
+	}
+	apiKey, err := cfg.dbQueries.GetActiveAPIKeyByHash(ctx, hash)
+	if errors.Is(err, sql.ErrNoRows) || err == nil && apiKey.DailyQuota.Valid {
+		return "", errInvalidAPIKey
+	}
+	if err != nil {
+		return "", fmt.Errorf("could not look up API key: %w", err)
+	}
+	return apiKey.Name, nil
+}
+
+// requireAPIKey rejects requests without a valid API key: a request without a key with 401
+// Unauthorized, and a request with an unknown or revoked key with 403 Forbidden.
+func (cfg *apiConfig) requireAPIKey(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("X-API-Key")
+		if key == "" {
+			cfg.respondWithError(w, http.StatusUnauthorized, "API key required", nil)
+			return
+		}
+		name, err := cfg.authorizeAPIKey(r.Context(), key)
+		if errors.Is(err, errInvalidAPIKey) {
+			cfg.logger.Warn("request with invalid API key", "path", r.URL.Path)
+			cfg.respondWithError(w, http.StatusForbidden, "Invalid API key", nil)
+			return
+		}
+		if err != nil {
+			cfg.respondWithError(w, http.StatusInternalServerError, "Failed to verify API key", err)
+			return
+		}
+		cfg.logger.Debug("request authorized", "key", name, "path", r.URL.Path)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// createAPIKey generates an API key, stores its hash under the given name and returns the key.
+// The key cannot be recovered later. With a negative dailyQuota the key is an admin key; otherwise
+// it is a client key of the public API that may make dailyQuota requests per day, or any number
+// with 0.
+func (cfg *apiConfig) createAPIKey(ctx context.Context, name string, dailyQuota int) (string, error) {
+	if strings.TrimSpace(name) == "" {
+		return "", errors.New("API key name must not be empty")
+	}
+	if dailyQuota > math.MaxInt32 {
+		return "", fmt.Errorf("API key quota must not exceed %d", math.MaxInt32)
+	}
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("could not generate API key: %w", err)
+	}
+	key := hex.EncodeToString(raw)
+	_, err := cfg.dbQueries.CreateAPIKey(ctx, database.CreateAPIKeyParams{
+		ID:         uuid.New(),
+		Name:       name,
+		KeyHash:    apiKeyHash(key),
+		CreatedAt:  time.Now().UTC(),
+		DailyQuota: sql.NullInt32{Int32: int32(dailyQuota), Valid: dailyQuota >= 0},
+	})
+	if err != nil {
+		return "", fmt.Errorf("could not store API key: %w", err)
+	}
+	return key, nil
+}
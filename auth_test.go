@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cor0nius/willitrain/internal/database"
+)
+
+func TestGetAdminAPIKeys(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	t.Setenv("ADMIN_API_KEYS", " first, ,second")
+	hashes := getAdminAPIKeys(logger)
+	if len(hashes) != 2 || !hashes[apiKeyHash("first")] || !hashes[apiKeyHash("second")] {
+		t.Errorf("unexpected key hashes: %v", hashes)
+	}
+}
+
+func TestRequireAPIKey(t *testing.T) {
+	testCases := []struct {
+		name       string
+		key        string
+		dbErr      error
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "missing key", wantStatus: http.StatusUnauthorized, wantBody: `{"error":"API key required"}`},
+		{name: "static key", key: "static-key", wantStatus: http.StatusOK},
+		{name: "database key", key: "db-key", wantStatus: http.StatusOK},
+		{name: "client key", key: "client-key", wantStatus: http.StatusForbidden, wantBody: `{"error":"Invalid API key"}`},
+		{name: "unknown key", key: "other-key", dbErr: sql.ErrNoRows, wantStatus: http.StatusForbidden, wantBody: `{"error":"Invalid API key"}`},
+		{name: "database error", key: "other-key", dbErr: errors.New("db down"), wantStatus: http.StatusInternalServerError},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testCfg := newTestAPIConfig(t)
+			testCfg.apiConfig.adminAPIKeyHashes = map[string]bool{apiKeyHash("static-key"): true}
+			testCfg.mockDB.GetActiveAPIKeyByHashFunc = func(ctx context.Context, keyHash string) (database.ApiKey, error) {
+				if tc.dbErr != nil {
+					return database.ApiKey{}, tc.dbErr
+				}
+				switch keyHash {
+				case apiKeyHash("db-key"):
+					return database.ApiKey{Name: "ci"}, nil
+				case apiKeyHash("client-key"):
+					return database.ApiKey{Name: "partner", DailyQuota: sql.NullInt32{Int32: 1000, Valid: true}}, nil
+				}
+				t.Errorf("unexpected key hash %q", keyHash)
+				return database.ApiKey{}, sql.ErrNoRows
+			}
+
+			var called bool
+			handler := testCfg.apiConfig.requireAPIKey(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				called = true
+			}))
+			req := httptest.NewRequest(http.MethodPost, "/dev/reset-db", nil)
+			if tc.key != "" {
+				req.Header.Set("X-API-Key", tc.key)
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != tc.wantStatus {
+				t.Fatalf("status = %d, want %d", rr.Code, tc.wantStatus)
+			}
+			if called != (tc.wantStatus == http.StatusOK) {
+				t.Errorf("handler called: %v, want %v", called, tc.wantStatus == http.StatusOK)
+			}
+			if tc.wantBody != "" && rr.Body.String() != tc.wantBody {
+				t.Errorf("body = %s, want %s", rr.Body.String(), tc.wantBody)
+			}
+		})
+	}
+}
+
+func TestCreateAPIKey(t *testing.T) {
+	testCfg := newTestAPIConfig(t)
+	var stored database.CreateAPIKeyParams
+	testCfg.mockDB.CreateAPIKeyFunc = func(ctx context.Context, arg database.CreateAPIKeyParams) (database.ApiKey, error) {
+		stored = arg
+		return database.ApiKey{ID: arg.ID, Name: arg.Name, KeyHash: arg.KeyHash}, nil
+	}
+
+	key, err := testCfg.apiConfig.createAPIKey(context.Background(), "ci", -1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(key) != 64 {
+		t.Errorf("expected a 64-character key, got %q", key)
+	}
+	if stored.Name != "ci" || stored.KeyHash != apiKeyHash(key) || stored.DailyQuota.Valid {
+		t.Errorf("unexpected stored key: %+v", stored)
+	}
+
+	if _, err := testCfg.apiConfig.createAPIKey(context.Background(), "partner", 1000); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := (sql.NullInt32{Int32: 1000, Valid: true}); stored.Name != "partner" || stored.DailyQuota != want {
+		t.Errorf("unexpected stored key: %+v", stored)
+	}
+
+	if _, err := testCfg.apiConfig.createAPIKey(context.Background(), " ", -1); err == nil {
+		t.Error("expected an error for an empty name")
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/cor0nius/willitrain/internal/database"
+	"github.com/google/uuid"
+)
+
+// This file implements the observation backfill for new locations. The weather_observations
+// table only grows as the application runs, so a freshly added location would have no history
+// to compare forecasts against. When a location is created, the last observationBackfillDays of
+// hourly observations are fetched from the Open-Meteo archive in the background and stored, so
+// that the history is useful immediately.
+
+const (
+	// archiveSourceAPI is the SourceAPI stored with observations taken from the Open-Meteo archive.
+	archiveSourceAPI = "Open-Meteo Archive"
+
+	observationBackfillDays    = 30
+	observationBackfillTimeout = 2 * time.Minute
+)
+
+// ResponseArchiveOMeteo is used to unmarshal the JSON response from the Open-Meteo archive API.
+// The archive reports missing values as null, so all measurements are pointers.
+type ResponseArchiveOMeteo struct {
+	Hourly struct {
+		Time               []int64    `json:"time"`
+		Temperature2m      []*float64 `json:"temperature_2m"`
+		RelativeHumidity2m []*int32   `json:"relative_humidity_2m"`
+		WindSpeed10m       []*float64 `json:"wind_speed_10m"`
+		Precipitation      []*float64 `json:"precipitation"`
+		WeatherCode        []*int     `json:"weather_code"`
+	} `json:"hourly"`
+}
+
+// startObservationBackfill backfills the observation history of a new location in the background.
+// It does nothing if no archive URL is configured or Open-Meteo is disabled.
+func (cfg *apiConfig) startObservationBackfill(location Location) {
+	if cfg.ometeoArchiveURL == "" || !cfg.sourceEnabled("ometeo") {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), observationBackfillTimeout)
+		defer cancel()
+		if err := cfg.backfillObservations(ctx, location, time.Now()); err != nil {
+			cfg.logger.Warn("could not backfill observations", "location", location.CityName, "error", err)
+		}
+	}()
+}
+
+// backfillObservations fetches the hourly observations of the observationBackfillDays before now
+// from the Open-Meteo archive and stores them in a single transaction.
+func (cfg *apiConfig) backfillObservations(ctx context.Context, location Location, now time.Time) error {
+	end := now.UTC().AddDate(0, 0, -1)
+	start := end.AddDate(0, 0, -(observationBackfillDays - 1))
+	url := fmt.Sprintf("%slatitude=%.2f&longitude=%.2f&start_date=%s&end_date=%s&hourly=temperature_2m,relative_humidity_2m,wind_speed_10m,precipitation,weather_code&timeformat=unixtime",
+		cfg.ometeoArchiveURL, location.Latitude, location.Longitude, start.Format(time.DateOnly), end.Format(time.DateOnly))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("could not create archive request: %w", err)
+	}
+	resp, err := cfg.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("could not fetch archive: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("archive returned %s", resp.Status)
+	}
+
+	var archive ResponseArchiveOMeteo
+	if err := json.NewDecoder(resp.Body).Decode(&archive); err != nil {
+		return fmt.Errorf("could not decode archive response: %w", err)
+	}
+	observations := archiveObservations(archive, location.LocationID)
+	if len(observations) == 0 {
+		return errors.New("archive returned no observations")
+	}
+
+	err = cfg.runInTx(ctx, func(q dbQuerier) error {
+		for _, o := range observations {
+			if err := q.UpsertWeatherObservation(ctx, o); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("could not store observations: %w", err)
+	}
+
+	observationsBackfilled.Add(float64(len(observations)))
+	cfg.logger.Info("backfilled observations", "location", location.CityName, "observations", len(observations))
+	return nil
+}
+
+// archiveObservations converts an archive response to observation rows. Hours without a
+// temperature are skipped, since the archive only fills recent hours after a few days.
+func archiveObservations(archive ResponseArchiveOMeteo, locationID uuid.UUID) []database.UpsertWeatherObservationParams {
+	h := archive.Hourly
+	var observations []database.UpsertWeatherObservationParams
+	for i, ts := range h.Time {
+		temperature := valueAt(h.Temperature2m, i)
+		if temperature == nil {
+			continue
+		}
+		o := database.UpsertWeatherObservationParams{
+			LocationID:   locationID,
+			SourceApi:    archiveSourceAPI,
+			ObservedAt:   time.Unix(ts, 0).UTC(),
+			TemperatureC: sql.NullFloat64{Float64: *temperature, Valid: true},
+		}
+		if v := valueAt(h.RelativeHumidity2m, i); v != nil {
+			o.Humidity = sql.NullInt32{Int32: *v, Valid: true}
+		}
+		if v := valueAt(h.WindSpeed10m, i); v != nil {
+			o.WindSpeedKmh = sql.NullFloat64{Float64: *v, Valid: true}
+		}
+		if v := valueAt(h.Precipitation, i); v != nil {
+			o.PrecipitationMm = sql.NullFloat64{Float64: *v, Valid: true}
+		}
+		if v := valueAt(h.WeatherCode, i); v != nil {
+			o.ConditionText = sql.NullString{String: interpretWeatherCode(*v), Valid: true}
+		}
+		observations = append(observations, o)
+	}
+	return observations
+}
+
+// valueAt returns the i-th element of a series, or nil if the series is too short.
+func valueAt[T any](series []*T, i int) *T {
+	if i >= len(series) {
+		return nil
+	}
+	return series[i]
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cor0nius/willitrain/internal/database"
+	"github.com/google/uuid"
+)
+
+const archiveResponse = `{"hourly":{
+	"time":[1753920000,1753923600,1753927200],
+	"temperature_2m":[18.4,null,17.9],
+	"relative_humidity_2m":[71,72,null],
+	"wind_speed_10m":[9.7,10.1,8.2],
+	"precipitation":[0.0,0.1,0.4],
+	"weather_code":[3,51,61]
+}}`
+
+func TestBackfillObservations(t *testing.T) {
+	now := time.Date(2025, 8, 4, 12, 0, 0, 0, time.UTC)
+	location := Location{LocationID: uuid.New(), CityName: "Wrocław", Latitude: 51.11, Longitude: 17.04}
+
+	testCases := []struct {
+		name        string
+		status      int
+		body        string
+		upsertFails bool
+		wantErr     string
+		wantRows    int
+	}{
+		{name: "Success", status: http.StatusOK, body: archiveResponse, wantRows: 2},
+		{name: "Upstream Error", status: http.StatusBadGateway, wantErr: "archive returned 502"},
+		{name: "Invalid JSON", status: http.StatusOK, body: `{`, wantErr: "could not decode"},
+		{name: "No Observations", status: http.StatusOK, body: `{"hourly":{"time":[1753920000],"temperature_2m":[null]}}`, wantErr: "no observations"},
+		{name: "Database Error", status: http.StatusOK, body: archiveResponse, upsertFails: true, wantErr: "could not store observations"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var query string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				query = r.URL.RawQuery
+				w.WriteHeader(tc.status)
+				_, _ = w.Write([]byte(tc.body))
+			}))
+			defer server.Close()
+
+			testCfg := newTestAPIConfig(t)
+			testCfg.ometeoArchiveURL = server.URL + "/v1/archive?"
+			var rows []database.UpsertWeatherObservationParams
+			testCfg.mockDB.UpsertWeatherObservationFunc = func(ctx context.Context, arg database.UpsertWeatherObservationParams) error {
+				if tc.upsertFails {
+					return context.DeadlineExceeded
+				}
+				rows = append(rows, arg)
+				return nil
+			}
+
+			err := testCfg.backfillObservations(context.Background(), location, now)
+
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !strings.Contains(query, "start_date=2025-07-05&end_date=2025-08-03") {
+				t.Errorf("unexpected archive query: %s", query)
+			}
+			if len(rows) != tc.wantRows {
+				t.Fatalf("expected %d observations, got %d", tc.wantRows, len(rows))
+			}
+			first, last := rows[0], rows[1]
+			if first.LocationID != location.LocationID || first.SourceApi != archiveSourceAPI {
+				t.Errorf("unexpected observation identity: %+v", first)
+			}
+			if !first.ObservedAt.Equal(time.Unix(1753920000, 0)) || first.TemperatureC.Float64 != 18.4 || first.ConditionText.String != "overcast" {
+				t.Errorf("unexpected first observation: %+v", first)
+			}
+			if last.Humidity.Valid {
+				t.Errorf("expected missing humidity to be NULL, got %+v", last.Humidity)
+			}
+			if last.PrecipitationMm.Float64 != 0.4 || !last.PrecipitationMm.Valid {
+				t.Errorf("unexpected precipitation: %+v", last.PrecipitationMm)
+			}
+		})
+	}
+}
+
+func TestStartObservationBackfill(t *testing.T) {
+	location := Location{LocationID: uuid.New(), CityName: "Wrocław", Latitude: 51.11, Longitude: 17.04}
+
+	t.Run("Disabled Without Archive URL", func(t *testing.T) {
+		testCfg := newTestAPIConfig(t)
+		testCfg.startObservationBackfill(location)
+		if n := testCfg.mockDB.Calls("UpsertWeatherObservation"); n != 0 {
+			t.Errorf("expected no observations, got %d", n)
+		}
+	})
+
+	t.Run("Runs in Background", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(archiveResponse))
+		}))
+		defer server.Close()
+
+		testCfg := newTestAPIConfig(t)
+		testCfg.ometeoArchiveURL = server.URL + "/v1/archive?"
+		var wg sync.WaitGroup
+		wg.Add(2)
+		testCfg.mockDB.UpsertWeatherObservationFunc = func(ctx context.Context, arg database.UpsertWeatherObservationParams) error {
+			wg.Done()
+			return nil
+		}
+
+		testCfg.startObservationBackfill(location)
+
+		done := make(chan struct{})
+		go func() {
+			wg.Wait()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("backfill did not store the observations")
+		}
+	})
+}
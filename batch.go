@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// This file implements /api/currentweather/batch, which returns the current weather of several
+// cities in one request, for dashboards that would otherwise need a round trip per city. The
+// cities are resolved and fetched concurrently through the same cache layers as
+// /api/currentweather, by a bounded number of workers.
+
+const (
+	// maxBatchCities is the maximum number of cities a single batch request may name.
+	maxBatchCities = 20
+	// batchConcurrency limits the number of cities resolved and fetched in parallel.
+	batchConcurrency = 4
+)
+
+// batchCities returns the cities named by a batch request: the comma-separated cities query
+// parameter of a GET request, or the JSON list in the body of a POST request. Names are trimmed
+// and duplicates removed.
+func batchCities(w http.ResponseWriter, r *http.Request) ([]string, error) {
+	var raw []string
+	if r.Method == http.MethodPost {
+		dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4<<10))
+		if err := dec.Decode(&raw); err != nil {
+			return nil, fmt.Errorf("request body must be a JSON list of city names: %w", err)
+		}
+	} else {
+		raw = strings.Split(r.URL.Query().Get("cities"), ",")
+	}
+
+	seen := make(map[string]bool)
+	var cities []string
+	for _, city := range raw {
+		city = strings.TrimSpace(city)
+		if city == "" || seen[city] {
+			continue
+		}
+		seen[city] = true
+		cities = append(cities, city)
+	}
+	if len(cities) == 0 {
+		return nil, fmt.Errorf("at least one city is required")
+	}
+	if len(cities) > maxBatchCities {
+		return nil, fmt.Errorf("at most %d cities can be requested at once, got %d", maxBatchCities, len(cities))
+	}
+	return cities, nil
+}
+
+// @Summary      Get current weather for several cities
+// @Description  Retrieves the current weather conditions for up to 20 cities at once, given as the
+// @Description  comma-separated cities parameter or, with POST, as a JSON list of city names. Results are
+// @Description  keyed by the requested city names; cities that could not be resolved or fetched are listed
+// @Description  under errors instead.
+// @Tags         weather
+// @Accept       json
+// @Produce      json
+// @Param        cities  query     string    false  "Comma-separated city names (e.g., 'wroclaw,berlin,prague')"
+// @Param        units   query     string    false  "Units of measurement, 'metric' or 'imperial' (defaults to DEFAULT_UNITS)"
+// @Param        request body      []string  false  "City names (POST only)"
+// @Success      200  {object}  CurrentWeatherBatchResponse
+// @Failure      400  {object}  ErrorResponse "Bad Request - Missing, invalid or too many cities"
+// @Router       /api/v1/currentweather/batch [get]
+// @Router       /api/v1/currentweather/batch [post]
+func (cfg *apiConfig) handlerCurrentWeatherBatch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		cfg.respondWithError(w, http.StatusMethodNotAllowed, "Method Not Allowed", nil)
+		return
+	}
+
+	units, err := cfg.requestUnits(r)
+	if err != nil {
+		cfg.respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	cities, err := batchCities(w, r)
+	if err != nil {
+		cfg.respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	cfg.logger.Debug("current weather batch request", "cities", len(cities))
+
+	response := cfg.currentWeatherBatch(r.Context(), r.URL.Path, cities, units)
+	cfg.respondWithJSON(w, http.StatusOK, withUnits(response, units))
+}
+
+// currentWeatherBatch resolves the cities and fetches their current weather with at most
+// batchConcurrency workers. Failures are reported per city.
+func (cfg *apiConfig) currentWeatherBatch(ctx context.Context, endpoint string, cities []string, units unitSystem) CurrentWeatherBatchResponse {
+	response := CurrentWeatherBatchResponse{
+		Results: make(map[string]CurrentWeatherResponse, len(cities)),
+		Errors:  make(map[string]string),
+	}
+	var mu sync.Mutex
+	runBatch(len(cities), func(i int) {
+		city := cities[i]
+		result, message, err := cfg.currentWeatherForCity(ctx, endpoint, city, units)
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			cfg.logger.Warn("batch current weather failed", "city", city, "error", err)
+			response.Errors[city] = message
+			return
+		}
+		response.Results[city] = result
+	})
+	return response
+}
+
+// runBatch calls work for the indexes 0 to n-1 with at most batchConcurrency calls running at
+// once, and returns when all have finished.
+func runBatch(n int, work func(i int)) {
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, batchConcurrency)
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			work(i)
+		}()
+	}
+	wg.Wait()
+}
+
+// currentWeatherForCity returns the current weather response of a city. On failure, it also
+// returns the message reported to the client.
+func (cfg *apiConfig) currentWeatherForCity(ctx context.Context, endpoint, city string, units unitSystem) (CurrentWeatherResponse, string, error) {
+	location, err := cfg.getOrCreateLocation(ctx, city)
+	if err != nil {
+		return CurrentWeatherResponse{}, "Error getting location data", err
+	}
+	return cfg.currentWeatherForLocation(ctx, endpoint, location, units)
+}
+
+// currentWeatherForLocation returns the current weather response of a resolved location. On
+// failure, it also returns the message reported to the client.
+func (cfg *apiConfig) currentWeatherForLocation(ctx context.Context, endpoint string, location Location, units unitSystem) (CurrentWeatherResponse, string, error) {
+	cfg.requestStats.recordLocation(endpoint, location.LocationID, time.Now())
+
+	result, _, err := cfg.currentWeatherResponse(ctx, location, units, false)
+	if err != nil {
+		return CurrentWeatherResponse{}, "Error getting current weather data", err
+	}
+	return result, "", nil
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cor0nius/willitrain/internal/database"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestHandlerCurrentWeatherBatch(t *testing.T) {
+	berlin := database.Location{ID: uuid.New(), CityName: "Berlin", Timezone: sql.NullString{String: "Europe/Berlin", Valid: true}}
+
+	var cities []string
+	for i := 0; i <= maxBatchCities; i++ {
+		cities = append(cities, fmt.Sprintf("city%d", i))
+	}
+	manyCities := strings.Join(cities, ",")
+
+	testCases := []struct {
+		name        string
+		method      string
+		query       string
+		body        string
+		wantStatus  int
+		wantResults []string
+		wantErrors  []string
+		wantBody    string
+	}{
+		{name: "GET", method: http.MethodGet, query: "?cities=wroclaw,berlin", wantStatus: http.StatusOK, wantResults: []string{"wroclaw", "berlin"}},
+		{name: "POST", method: http.MethodPost, body: `["wroclaw", " berlin ", "wroclaw"]`, wantStatus: http.StatusOK, wantResults: []string{"wroclaw", "berlin"}},
+		{name: "unknown city", method: http.MethodGet, query: "?cities=wroclaw,atlantis", wantStatus: http.StatusOK, wantResults: []string{"wroclaw"}, wantErrors: []string{"atlantis"}},
+		{name: "imperial", method: http.MethodGet, query: "?cities=wroclaw&units=imperial", wantStatus: http.StatusOK, wantResults: []string{"wroclaw"}, wantBody: `"temperature_f"`},
+		{name: "no cities", method: http.MethodGet, query: "?cities=,", wantStatus: http.StatusBadRequest},
+		{name: "too many cities", method: http.MethodGet, query: "?cities=" + manyCities, wantStatus: http.StatusBadRequest},
+		{name: "invalid body", method: http.MethodPost, body: `{"cities": "wroclaw"}`, wantStatus: http.StatusBadRequest},
+		{name: "method not allowed", method: http.MethodDelete, wantStatus: http.StatusMethodNotAllowed},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testCfg := newTestAPIConfig(t)
+			testCfg.apiConfig.enabledSources = map[string]bool{"gmp": true, "owm": true, "ometeo": true}
+			testCfg.mockDB.GetLocationByAliasFunc = func(ctx context.Context, alias string) (database.Location, error) {
+				switch alias {
+				case "wroclaw":
+					return MockDBLocation, nil
+				case "berlin":
+					return berlin, nil
+				}
+				return database.Location{}, sql.ErrNoRows
+			}
+			testCfg.mockGeo.GeocodeFunc = func(cityName string) (Location, error) {
+				return Location{}, errors.New("city not found")
+			}
+			testCfg.mockCache.GetFunc = func(ctx context.Context, key string) (string, error) {
+				return "", redis.Nil
+			}
+			testCfg.mockCache.SetFunc = func(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+				return nil
+			}
+			testCfg.mockDB.GetCurrentWeatherAtLocationFunc = func(ctx context.Context, locationID uuid.UUID) ([]database.CurrentWeather, error) {
+				weather := []database.CurrentWeather{MockDBCurrentWeather1, MockDBCurrentWeather2, MockDBCurrentWeather3}
+				for i := range weather {
+					weather[i].LocationID = locationID
+				}
+				return weather, nil
+			}
+
+			req := httptest.NewRequest(tc.method, "/api/currentweather/batch"+tc.query, strings.NewReader(tc.body))
+			rr := httptest.NewRecorder()
+			testCfg.apiConfig.handlerCurrentWeatherBatch(rr, req)
+
+			if rr.Code != tc.wantStatus {
+				t.Fatalf("status = %d, want %d; body: %s", rr.Code, tc.wantStatus, rr.Body.String())
+			}
+			if tc.wantStatus != http.StatusOK {
+				return
+			}
+			if tc.wantBody != "" && !strings.Contains(rr.Body.String(), tc.wantBody) {
+				t.Errorf("body = %s, want it to contain %s", rr.Body.String(), tc.wantBody)
+			}
+
+			var response CurrentWeatherBatchResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+				t.Fatalf("could not unmarshal response: %v", err)
+			}
+			if len(response.Results) != len(tc.wantResults) || len(response.Errors) != len(tc.wantErrors) {
+				t.Fatalf("got results %v and errors %v, want results for %v and errors for %v", response.Results, response.Errors, tc.wantResults, tc.wantErrors)
+			}
+			for _, city := range tc.wantResults {
+				result, ok := response.Results[city]
+				if !ok {
+					t.Errorf("missing result for %s", city)
+					continue
+				}
+				if len(result.Weather) != 3 {
+					t.Errorf("expected 3 sources for %s, got %d", city, len(result.Weather))
+				}
+			}
+			if result, ok := response.Results["berlin"]; ok && result.Location.CityName != "Berlin" {
+				t.Errorf("expected the Berlin location, got %+v", result.Location)
+			}
+			for _, city := range tc.wantErrors {
+				if response.Errors[city] != "Error getting location data" {
+					t.Errorf("unexpected error for %s: %q", city, response.Errors[city])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,340 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cor0nius/willitrain/internal/database"
+	"github.com/google/uuid"
+)
+
+// This file implements weighted blending of the consensus forecast. Operators who trust some
+// providers more than others give them weights in PROVIDER_WEIGHTS and, for single locations,
+// through /admin/locations/{id}/weights, which override the global weights. As soon as a location
+// has any weight, its consensus takes the weighted mean of the sources instead of the median and
+// counts the votes for the condition by weight. Providers without a weight count with 1, and a
+// weight of 0 leaves a provider out of the blend unless all sources of an hour or day have it.
+
+// Blends of the consensus forecast.
+const (
+	blendMedian   = "median"
+	blendWeighted = "weighted"
+)
+
+// maxProviderWeight bounds provider weights, so that a typo cannot make one provider the only one
+// that counts.
+const maxProviderWeight = 100
+
+// getProviderWeights reads PROVIDER_WEIGHTS, a comma-separated list of id=weight pairs giving
+// each provider's weight in the consensus (e.g. "ometeo=2,owm=0.5"). Invalid entries are logged
+// and ignored.
+func getProviderWeights(logger *slog.Logger) map[string]float64 {
+	weights := make(map[string]float64)
+	val := os.Getenv("PROVIDER_WEIGHTS")
+	if val == "" {
+		return weights
+	}
+	for _, entry := range strings.Split(val, ",") {
+		id, weightStr, found := strings.Cut(strings.TrimSpace(entry), "=")
+		if !found {
+			logger.Warn("invalid provider weight entry, ignoring", "entry", entry)
+			continue
+		}
+		if _, ok := providerByID(id); !ok {
+			logger.Warn("unknown provider in weight configuration, ignoring", "provider", id)
+			continue
+		}
+		weight, err := strconv.ParseFloat(weightStr, 64)
+		if err != nil || !validProviderWeight(weight) {
+			logger.Warn("invalid provider weight, ignoring", "provider", id, "value", weightStr)
+			continue
+		}
+		weights[id] = weight
+	}
+	return weights
+}
+
+// validProviderWeight reports whether weight is between 0 and maxProviderWeight.
+func validProviderWeight(weight float64) bool {
+	return weight >= 0 && weight <= maxProviderWeight
+}
+
+// locationProviderWeights returns the weights of the providers at a location, keyed by provider
+// ID: the global weights overridden by those of the location. It returns nil if neither has any,
+// in which case the consensus is the median of the sources.
+func (cfg *apiConfig) locationProviderWeights(ctx context.Context, locationID uuid.UUID) (map[string]float64, error) {
+	overrides, err := cfg.dbQueries.ListLocationProviderWeights(ctx, locationID)
+	if err != nil {
+		return nil, fmt.Errorf("could not get provider weights: %w", err)
+	}
+	if len(cfg.providerWeights) == 0 && len(overrides) == 0 {
+		return nil, nil
+	}
+	weights := make(map[string]float64, len(cfg.providerWeights)+len(overrides))
+	for id, w := range cfg.providerWeights {
+		weights[id] = w
+	}
+	for _, o := range overrides {
+		weights[o.Provider] = o.Weight
+	}
+	return weights, nil
+}
+
+// sourceWeights are the weights of the sources of a forecast, keyed by SourceAPI. A nil value
+// blends the sources by median.
+type sourceWeights map[string]float64
+
+// newSourceWeights converts weights keyed by provider ID to weights keyed by SourceAPI.
+func newSourceWeights(byProvider map[string]float64) sourceWeights {
+	if byProvider == nil {
+		return nil
+	}
+	weights := make(sourceWeights, len(byProvider))
+	for id, w := range byProvider {
+		if p, ok := providerByID(id); ok {
+			weights[p.DisplayName] = w
+		}
+	}
+	return weights
+}
+
+// weight returns the weight of a source, 1 if it has none.
+func (w sourceWeights) weight(source string) float64 {
+	if weight, ok := w[source]; ok {
+		return weight
+	}
+	return 1
+}
+
+// blendGroup leaves the sources with a weight of 0 out of the forecasts of one hour or day,
+// unless that would leave none.
+func blendGroup[T any](weights sourceWeights, group []T, source func(T) string) []T {
+	if weights == nil {
+		return group
+	}
+	var kept []T
+	for _, f := range group {
+		if weights.weight(source(f)) > 0 {
+			kept = append(kept, f)
+		}
+	}
+	if len(kept) == 0 {
+		return group
+	}
+	return kept
+}
+
+// blendedMedian aggregates the values of all sources to their median or, if the sources have
+// weights, to their weighted mean.
+func blendedMedian(values, weights []float64, thresholds spreadThresholds) ConsensusValueJSON {
+	if weights == nil {
+		return consensusMedian(values, thresholds)
+	}
+	return consensusWeightedMean(values, weights, thresholds)
+}
+
+// blendedMean aggregates the values of all sources to their average or, if the sources have
+// weights, to their weighted mean.
+func blendedMean(values, weights []float64, thresholds spreadThresholds) ConsensusValueJSON {
+	if weights == nil {
+		return consensusMean(values, thresholds)
+	}
+	return consensusWeightedMean(values, weights, thresholds)
+}
+
+// consensusWeightedMean aggregates the values of all sources to their mean weighted by the
+// weights of the sources. If all weights are 0 the plain mean is taken.
+func consensusWeightedMean(values, weights []float64, thresholds spreadThresholds) ConsensusValueJSON {
+	var sum, total float64
+	for i, v := range values {
+		sum += weights[i] * v
+		total += weights[i]
+	}
+	if total == 0 {
+		return consensusMean(values, thresholds)
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	return consensusValue(sum/total, sorted, thresholds)
+}
+
+// blendedCondition picks the condition code reported by most sources or, if the sources have
+// weights, the one with the most weight. The agreement is then the share of the weight of the
+// sources with a known condition, and the confidence follows from it as in consensusCondition.
+func blendedCondition(codes []string, weights []float64) ConsensusConditionJSON {
+	if weights == nil {
+		return consensusCondition(codes)
+	}
+	votes := make(map[string]float64)
+	var known float64
+	sources := 0
+	for i, c := range codes {
+		if c != conditionUnknown && weights[i] > 0 {
+			votes[c] += weights[i]
+			known += weights[i]
+			sources++
+		}
+	}
+	code := conditionUnknown
+	for c, v := range votes {
+		if v > votes[code] || v == votes[code] && conditionSeverity[c] > conditionSeverity[code] {
+			code = c
+		}
+	}
+	condition := ConsensusConditionJSON{Code: code, Label: englishLocale.conditionLabel(code), Confidence: confidenceLow}
+	if known == 0 {
+		return condition
+	}
+	share := votes[code] / known
+	condition.Agreement = Round(share, 2)
+	switch {
+	case sources >= 2 && share == 1:
+		condition.Confidence = confidenceHigh
+	case sources >= 2 && share > 0.5:
+		condition.Confidence = confidenceMedium
+	}
+	return condition
+}
+
+// handlerLocationProviderWeights dispatches provider weight requests by method: GET lists the
+// weights of the location, PUT replaces its overrides and DELETE removes them.
+
+// @Summary      Manage a location's provider weights
+// @Description  GET lists the weights of the providers in the consensus of the location, the global PROVIDER_WEIGHTS
+// @Description  overridden by those set for the location, which are listed separately. PUT replaces the overrides
+// @Description  with the JSON object in the body, mapping provider IDs to weights between 0 and 100. DELETE removes
+// @Description  them. Providers without a weight count with 1. Changes are audit-logged.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        id  path  string  true  "Location ID (UUID)"
+// @Success      200  {object}  LocationProviderWeightsResponse
+// @Failure      400  {object}  ErrorResponse "Bad Request - Invalid location ID or weights"
+// @Failure      404  {object}  ErrorResponse "Not Found - Location does not exist"
+// @Failure      500  {object}  ErrorResponse "Internal Server Error - Failed to access weights"
+// @Security     ApiKeyAuth
+// @Failure      401  {object}  ErrorResponse "Unauthorized - Missing API key"
+// @Failure      403  {object}  ErrorResponse "Forbidden - Invalid API key"
+// @Router       /admin/locations/{id}/weights [get]
+// @Router       /admin/locations/{id}/weights [put]
+// @Router       /admin/locations/{id}/weights [delete]
+func (cfg *apiConfig) handlerLocationProviderWeights(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPut && r.Method != http.MethodDelete {
+		cfg.respondWithError(w, http.StatusMethodNotAllowed, "Method Not Allowed", nil)
+		return
+	}
+
+	locationID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		cfg.respondWithError(w, http.StatusBadRequest, "Invalid location ID", err)
+		return
+	}
+
+	ctx := r.Context()
+	dbLocation, err := cfg.dbQueries.GetLocationByID(ctx, locationID)
+	if err == sql.ErrNoRows {
+		cfg.respondWithError(w, http.StatusNotFound, "Location not found", nil)
+		return
+	}
+	if err != nil {
+		cfg.respondWithError(w, http.StatusInternalServerError, "Failed to get location", err)
+		return
+	}
+	location := databaseLocationToLocation(dbLocation)
+
+	switch r.Method {
+	case http.MethodPut:
+		var overrides map[string]float64
+		if err := json.NewDecoder(r.Body).Decode(&overrides); err != nil {
+			cfg.respondWithError(w, http.StatusBadRequest, "Invalid weights", err)
+			return
+		}
+		for id, weight := range overrides {
+			if _, ok := providerByID(id); !ok {
+				cfg.respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Unknown provider %q", id), nil)
+				return
+			}
+			if !validProviderWeight(weight) {
+				cfg.respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Weight of %s must be between 0 and %d", id, maxProviderWeight), nil)
+				return
+			}
+		}
+		err := cfg.runInTx(ctx, func(q dbQuerier) error {
+			if _, err := q.DeleteLocationProviderWeights(ctx, location.LocationID); err != nil {
+				return err
+			}
+			for id, weight := range overrides {
+				if err := q.UpsertLocationProviderWeight(ctx, database.UpsertLocationProviderWeightParams{
+					LocationID: location.LocationID,
+					Provider:   id,
+					Weight:     weight,
+				}); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			cfg.respondWithError(w, http.StatusInternalServerError, "Failed to update weights", err)
+			return
+		}
+		cfg.logger.Info("audit: location provider weights set",
+			"location_id", location.LocationID,
+			"city", location.CityName,
+			"weights", overrides,
+			"remote_addr", r.RemoteAddr,
+		)
+	case http.MethodDelete:
+		deleted, err := cfg.dbQueries.DeleteLocationProviderWeights(ctx, location.LocationID)
+		if err != nil {
+			cfg.respondWithError(w, http.StatusInternalServerError, "Failed to delete weights", err)
+			return
+		}
+		cfg.logger.Info("audit: location provider weights removed",
+			"location_id", location.LocationID,
+			"city", location.CityName,
+			"deleted", deleted,
+			"remote_addr", r.RemoteAddr,
+		)
+	}
+
+	dbOverrides, err := cfg.dbQueries.ListLocationProviderWeights(ctx, location.LocationID)
+	if err != nil {
+		cfg.respondWithError(w, http.StatusInternalServerError, "Failed to get weights", err)
+		return
+	}
+	response := LocationProviderWeightsResponse{
+		Location:  location,
+		Blend:     blendMedian,
+		Weights:   make(map[string]float64),
+		Overrides: make(map[string]float64, len(dbOverrides)),
+	}
+	for _, o := range dbOverrides {
+		response.Overrides[o.Provider] = o.Weight
+	}
+	if len(cfg.providerWeights) > 0 || len(dbOverrides) > 0 {
+		response.Blend = blendWeighted
+	}
+	for _, p := range weatherProviders {
+		if !cfg.sourceEnabled(p.ID) {
+			continue
+		}
+		weight := 1.0
+		if w, ok := cfg.providerWeights[p.ID]; ok {
+			weight = w
+		}
+		if w, ok := response.Overrides[p.ID]; ok {
+			weight = w
+		}
+		response.Weights[p.ID] = weight
+	}
+	cfg.respondWithJSON(w, http.StatusOK, response)
+}
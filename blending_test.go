@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cor0nius/willitrain/internal/database"
+	"github.com/google/uuid"
+)
+
+func TestGetProviderWeights(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	t.Setenv("PROVIDER_WEIGHTS", "ometeo=2, owm=0.5,gmp=-1,metno=101,unknown=3,invalid")
+	want := map[string]float64{"ometeo": 2, "owm": 0.5}
+	if got := getProviderWeights(logger); !reflect.DeepEqual(got, want) {
+		t.Errorf("getProviderWeights() = %v, want %v", got, want)
+	}
+}
+
+func TestConsensusDaysWeighted(t *testing.T) {
+	day := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	forecast := []DailyForecast{
+		{SourceAPI: "Open-Meteo API", ForecastDate: day, MaxTemp: 20, Precipitation: 0, PrecipitationChance: 10},
+		{SourceAPI: "OpenWeatherMap API", ForecastDate: day, MaxTemp: 14, Precipitation: 6, PrecipitationChance: 70},
+		{SourceAPI: "Met.no API", ForecastDate: day, MaxTemp: 12, Precipitation: 4, PrecipitationChance: 90},
+		{SourceAPI: "Google Weather API", ForecastDate: day, MaxTemp: 30, Precipitation: 30, PrecipitationChance: 100},
+	}
+	weights := newSourceWeights(map[string]float64{"ometeo": 3, "gmp": 0})
+
+	days := consensusDays(forecast, time.UTC, weights)
+	if len(days) != 1 {
+		t.Fatalf("unexpected days: %+v", days)
+	}
+	got := days[0]
+	if !reflect.DeepEqual(got.Sources, []string{"Met.no API", "Open-Meteo API", "OpenWeatherMap API"}) {
+		t.Errorf("expected the source with weight 0 to be left out, got %v", got.Sources)
+	}
+	// (3*20 + 14 + 12) / 5
+	if want := (ConsensusValueJSON{Value: 17.2, Min: 12, Max: 20, Confidence: confidenceLow}); got.MaxTemp != want {
+		t.Errorf("max temperature = %+v, want %+v", got.MaxTemp, want)
+	}
+	if got.PrecipitationChance.Value != 38 {
+		t.Errorf("precipitation chance = %v, want 38", got.PrecipitationChance.Value)
+	}
+	if want := (ConsensusConditionJSON{Code: conditionDry, Label: englishLocale.conditionLabel(conditionDry), Agreement: 0.6, Confidence: confidenceMedium}); got.Condition != want {
+		t.Errorf("condition = %+v, want %+v", got.Condition, want)
+	}
+
+	// Without another source, one with weight 0 is still used.
+	days = consensusDays(forecast[3:], time.UTC, weights)
+	if len(days) != 1 || days[0].MaxTemp.Value != 30 {
+		t.Errorf("unexpected days: %+v", days)
+	}
+}
+
+func TestLocationProviderWeights(t *testing.T) {
+	testCfg := newTestAPIConfig(t)
+	testCfg.mockDB.ListLocationProviderWeightsFunc = func(ctx context.Context, locationID uuid.UUID) ([]database.LocationProviderWeight, error) {
+		return nil, nil
+	}
+	if weights, err := testCfg.locationProviderWeights(context.Background(), MockLocation.LocationID); err != nil || weights != nil {
+		t.Errorf("expected no weights without configuration, got %v, %v", weights, err)
+	}
+
+	testCfg.providerWeights = map[string]float64{"ometeo": 2, "owm": 0.5}
+	testCfg.mockDB.ListLocationProviderWeightsFunc = func(ctx context.Context, locationID uuid.UUID) ([]database.LocationProviderWeight, error) {
+		return []database.LocationProviderWeight{{LocationID: locationID, Provider: "owm", Weight: 3}}, nil
+	}
+	weights, err := testCfg.locationProviderWeights(context.Background(), MockLocation.LocationID)
+	if want := map[string]float64{"ometeo": 2, "owm": 3}; err != nil || !reflect.DeepEqual(weights, want) {
+		t.Errorf("got %v, %v; want %v", weights, err, want)
+	}
+}
+
+func TestHandlerLocationProviderWeights(t *testing.T) {
+	testCases := []struct {
+		name       string
+		method     string
+		body       string
+		wantStatus int
+		wantBody   string
+		wantStored map[string]float64
+		wantLog    string
+	}{
+		{name: "List", method: http.MethodGet, wantStatus: http.StatusOK, wantBody: `"weights":{"gmp":1,"metno":1,"ometeo":2,"owm":0.5}`},
+		{
+			name:       "Replace",
+			method:     http.MethodPut,
+			body:       `{"owm":3,"gmp":0}`,
+			wantStatus: http.StatusOK,
+			wantBody:   `"blend":"weighted","weights":{"gmp":0,"metno":1,"ometeo":2,"owm":3},"overrides":{"gmp":0,"owm":3}`,
+			wantStored: map[string]float64{"owm": 3, "gmp": 0},
+			wantLog:    "audit: location provider weights set",
+		},
+		{name: "Unknown Provider", method: http.MethodPut, body: `{"accuweather":1}`, wantStatus: http.StatusBadRequest, wantBody: `{"error":"Unknown provider \"accuweather\""}`},
+		{name: "Negative Weight", method: http.MethodPut, body: `{"owm":-1}`, wantStatus: http.StatusBadRequest, wantBody: `{"error":"Weight of owm must be between 0 and 100"}`},
+		{name: "Delete", method: http.MethodDelete, wantStatus: http.StatusOK, wantBody: `"overrides":{}`, wantStored: map[string]float64{}, wantLog: "audit: location provider weights removed"},
+		{name: "Wrong Method", method: http.MethodPost, wantStatus: http.StatusMethodNotAllowed, wantBody: `{"error":"Method Not Allowed"}`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testCfg := newTestAPIConfig(t)
+			var logBuf bytes.Buffer
+			testCfg.logger = slog.New(slog.NewTextHandler(&logBuf, nil))
+			testCfg.providerWeights = map[string]float64{"ometeo": 2, "owm": 0.5}
+			stored := map[string]float64{}
+			testCfg.mockDB.GetLocationByIDFunc = func(ctx context.Context, id uuid.UUID) (database.Location, error) {
+				return MockDBLocation, nil
+			}
+			testCfg.mockDB.DeleteLocationProviderWeightsFunc = func(ctx context.Context, locationID uuid.UUID) (int64, error) {
+				n := int64(len(stored))
+				clear(stored)
+				return n, nil
+			}
+			testCfg.mockDB.UpsertLocationProviderWeightFunc = func(ctx context.Context, arg database.UpsertLocationProviderWeightParams) error {
+				stored[arg.Provider] = arg.Weight
+				return nil
+			}
+			testCfg.mockDB.ListLocationProviderWeightsFunc = func(ctx context.Context, locationID uuid.UUID) ([]database.LocationProviderWeight, error) {
+				var rows []database.LocationProviderWeight
+				for provider, weight := range stored {
+					rows = append(rows, database.LocationProviderWeight{LocationID: locationID, Provider: provider, Weight: weight})
+				}
+				return rows, nil
+			}
+
+			id := MockLocation.LocationID.String()
+			req := httptest.NewRequest(tc.method, "/admin/locations/"+id+"/weights", strings.NewReader(tc.body))
+			req.SetPathValue("id", id)
+			rr := httptest.NewRecorder()
+			testCfg.apiConfig.handlerLocationProviderWeights(rr, req)
+
+			if rr.Code != tc.wantStatus {
+				t.Fatalf("status = %d, want %d; body: %s", rr.Code, tc.wantStatus, rr.Body.String())
+			}
+			if !strings.Contains(rr.Body.String(), tc.wantBody) {
+				t.Errorf("body = %s, want it to contain %s", rr.Body.String(), tc.wantBody)
+			}
+			if tc.wantStored != nil && !reflect.DeepEqual(stored, tc.wantStored) {
+				t.Errorf("stored %v, want %v", stored, tc.wantStored)
+			}
+			if tc.wantLog != "" && !strings.Contains(logBuf.String(), tc.wantLog) {
+				t.Errorf("expected log to contain %q, got %s", tc.wantLog, logBuf.String())
+			}
+			if rr.Code == http.StatusOK {
+				var response LocationProviderWeightsResponse
+				if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil || response.Location.LocationID != MockLocation.LocationID {
+					t.Errorf("unexpected response %s: %v", rr.Body.String(), err)
+				}
+			}
+		})
+	}
+}
@@ -3,9 +3,16 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
 	"time"
 
 	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // This file implements the application's caching layer, which is crucial for
@@ -22,49 +29,220 @@ type Cache interface {
 	Set(ctx context.Context, key string, value any, expiration time.Duration) error
 	Get(ctx context.Context, key string) (string, error)
 	Flush(ctx context.Context) error
+	Delete(ctx context.Context, keys ...string) error
+	Scan(ctx context.Context, cursor uint64, match string, count int64) ([]string, uint64, error)
+	TTL(ctx context.Context, key string) (time.Duration, error)
+	Incr(ctx context.Context, key string, expiration time.Duration) (int64, error)
 }
 
+// Health tracking defaults for RedisCache. After cacheFailureThreshold consecutive failed
+// operations the cache is considered unhealthy and is bypassed for cacheCooldown. The first
+// operation after the cool-down is let through as a probe: if it succeeds the cache is healthy
+// again, otherwise it is bypassed for another cool-down period.
+const (
+	cacheFailureThreshold = 3
+	cacheCooldown         = 30 * time.Second
+)
+
+// errCacheUnavailable is returned by RedisCache while it is bypassing an unhealthy Redis.
+// Callers should treat it like a cache miss.
+var errCacheUnavailable = errors.New("cache temporarily unavailable")
+
 // RedisCache is a Redis-backed implementation of the Cache interface.
-// It uses a redis.Client to interact with the Redis server.
+// It uses a redis.Client to interact with the Redis server. Reads and writes are tracked so
+// that a Redis outage costs a few failed calls instead of a connection timeout on every request.
 type RedisCache struct {
 	client *redis.Client
+	logger *slog.Logger
+	now    func() time.Time
+
+	failureThreshold int
+	cooldown         time.Duration
+
+	mu             sync.Mutex
+	failures       int
+	unhealthy      bool
+	unhealthyUntil time.Time
 }
 
 // NewRedisCache creates and returns a new instance of RedisCache.
 func NewRedisCache(client *redis.Client) *RedisCache {
+	cacheHealthy.Set(1)
 	return &RedisCache{
-		client: client,
+		client:           client,
+		logger:           slog.New(slog.NewTextHandler(io.Discard, nil)),
+		now:              time.Now,
+		failureThreshold: cacheFailureThreshold,
+		cooldown:         cacheCooldown,
 	}
 }
 
+// allow reports whether an operation may be sent to Redis. While the cache is unhealthy,
+// operations are rejected until the cool-down has passed.
+func (c *RedisCache) allow() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.unhealthy && c.now().Before(c.unhealthyUntil) {
+		cacheBypassedTotal.Inc()
+		return false
+	}
+	return true
+}
+
+// record updates the health state with the outcome of an operation. A missing key and a
+// cancelled request say nothing about the health of Redis and are ignored.
+func (c *RedisCache) record(err error) {
+	if errors.Is(err, redis.Nil) || errors.Is(err, context.Canceled) {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err == nil {
+		if c.unhealthy {
+			c.logger.Info("cache recovered, resuming Redis usage")
+			cacheHealthy.Set(1)
+			cacheStateTransitions.WithLabelValues("healthy").Inc()
+		}
+		c.failures = 0
+		c.unhealthy = false
+		return
+	}
+
+	c.failures++
+	if !c.unhealthy && c.failures < c.failureThreshold {
+		return
+	}
+	if !c.unhealthy {
+		c.logger.Warn("cache marked unhealthy, bypassing Redis", "consecutive_failures", c.failures, "cooldown", c.cooldown.String(), "error", err)
+		cacheHealthy.Set(0)
+		cacheStateTransitions.WithLabelValues("unhealthy").Inc()
+	} else {
+		c.logger.Warn("cache probe failed, extending bypass", "cooldown", c.cooldown.String(), "error", err)
+	}
+	c.unhealthy = true
+	c.unhealthyUntil = c.now().Add(c.cooldown)
+}
+
 // Set serializes the given value to JSON and stores it in the Redis cache.
 // This approach allows complex data structures to be cached as simple strings.
 // An expiration is set to ensure that stale data is automatically evicted.
-func (c *RedisCache) Set(ctx context.Context, key string, value any, expiration time.Duration) error {
+func (c *RedisCache) Set(ctx context.Context, key string, value any, expiration time.Duration) (err error) {
+	ctx, span := tracer.Start(ctx, "cache set", trace.WithSpanKind(trace.SpanKindClient), cacheSpanAttributes("SET", key))
+	defer func() { endSpan(span, err) }()
+
 	p, err := json.Marshal(value)
 	if err != nil {
 		return err
 	}
-	return c.client.Set(ctx, key, p, expiration).Err()
+	if !c.allow() {
+		return errCacheUnavailable
+	}
+	err = c.client.Set(ctx, key, p, expiration).Err()
+	c.record(err)
+	return err
 }
 
 // Get retrieves an item from the Redis cache by its key.
 // The returned value is a raw string, which the caller is responsible for
 // deserializing back into a Go struct.
 func (c *RedisCache) Get(ctx context.Context, key string) (string, error) {
-	return c.client.Get(ctx, key).Result()
+	ctx, span := tracer.Start(ctx, "cache get", trace.WithSpanKind(trace.SpanKindClient), cacheSpanAttributes("GET", key))
+	defer span.End()
+
+	if !c.allow() {
+		span.SetAttributes(attribute.Bool("cache.bypassed", true))
+		return "", errCacheUnavailable
+	}
+	val, err := c.client.Get(ctx, key).Result()
+	c.record(err)
+	span.SetAttributes(attribute.Bool("cache.hit", err == nil))
+	if err != nil && err != redis.Nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return val, err
 }
 
 // Flush removes all keys from the current Redis database.
 // This is primarily used in development and testing to reset the application's state.
 func (c *RedisCache) Flush(ctx context.Context) error {
-	return c.client.FlushDB(ctx).Err()
+	err := c.client.FlushDB(ctx).Err()
+	c.record(err)
+	return err
+}
+
+// Delete removes the given keys from the Redis cache. Keys that do not exist are ignored.
+// This allows targeted invalidation of a single location's data without a full flush.
+// Unlike reads and writes, Flush and Delete are always sent to Redis, even while it is
+// bypassed, so that an invalidation is never skipped silently.
+func (c *RedisCache) Delete(ctx context.Context, keys ...string) error {
+	err := c.client.Del(ctx, keys...).Err()
+	c.record(err)
+	return err
+}
+
+// Scan returns a page of keys matching a glob pattern and the cursor of the next page, which
+// is 0 once the iteration is complete. Like Redis SCAN, it may return a key more than once.
+func (c *RedisCache) Scan(ctx context.Context, cursor uint64, match string, count int64) ([]string, uint64, error) {
+	if !c.allow() {
+		return nil, 0, errCacheUnavailable
+	}
+	keys, next, err := c.client.Scan(ctx, cursor, match, count).Result()
+	c.record(err)
+	return keys, next, err
+}
+
+// TTL returns the remaining time to live of a key. As in Redis, it returns -1 for a key without
+// an expiration and -2 for a key that does not exist.
+func (c *RedisCache) TTL(ctx context.Context, key string) (time.Duration, error) {
+	if !c.allow() {
+		return 0, errCacheUnavailable
+	}
+	ttl, err := c.client.TTL(ctx, key).Result()
+	c.record(err)
+	return ttl, err
+}
+
+// Incr increments the integer counter stored at a key and returns its new value. A counter that
+// did not exist starts at 0 and expires after the given expiration; incrementing it again does
+// not extend its lifetime.
+func (c *RedisCache) Incr(ctx context.Context, key string, expiration time.Duration) (int64, error) {
+	if !c.allow() {
+		return 0, errCacheUnavailable
+	}
+	n, err := c.client.Incr(ctx, key).Result()
+	if err == nil && n == 1 && expiration > 0 {
+		err = c.client.Expire(ctx, key, expiration).Err()
+	}
+	c.record(err)
+	return n, err
 }
 
-// ConnectCache initializes the cache connection.
-// It currently supports only Redis, but the interface allows for future extensions.
+// ConnectCache initializes the cache selected by CACHE_BACKEND.
+// With the Redis backend, an in-process cache is put in front of Redis unless LOCAL_CACHE_SIZE
+// is 0.
 func (cfg *apiConfig) ConnectCache() error {
-	return cfg.ConnectRedis()
+	switch cfg.cacheBackend {
+	case cacheBackendMemory:
+		cfg.cache = newMemoryCache()
+		cfg.logger.Info("using in-memory cache")
+		return nil
+	case cacheBackendNone:
+		cfg.cache = noopCache{}
+		cfg.logger.Info("caching disabled")
+		return nil
+	}
+
+	if err := cfg.ConnectRedis(); err != nil {
+		return err
+	}
+	if cfg.localCacheSize > 0 {
+		cfg.cache = newTieredCache(newLRUCache(cfg.localCacheSize, cfg.localCacheTTL), cfg.cache)
+		cfg.logger.Debug("local cache enabled", "size", cfg.localCacheSize, "ttl", cfg.localCacheTTL.String())
+	}
+	return nil
 }
 
 // ConnectRedis initializes the Redis client and verifies the connection.
@@ -75,12 +253,17 @@ func (cfg *apiConfig) ConnectRedis() error {
 		cfg.logger.Error("could not parse Redis URL", "error", err)
 		return err
 	}
+	if cfg.secrets.value("REDIS_URL") != nil {
+		opt.CredentialsProvider = cfg.redisCredentials(opt)
+	}
 	redisClient := cfg.newCacheClientFunc(opt)
 	if _, err := redisClient.Ping(context.Background()).Result(); err != nil {
 		cfg.logger.Error("could not connect to Redis", "error", err)
 		return err
 	}
-	cfg.cache = NewRedisCache(redisClient)
+	cache := NewRedisCache(redisClient)
+	cache.logger = cfg.logger
+	cfg.cache = cache
 	cfg.logger.Debug("connected to Redis cache")
 	return nil
 }
\ No newline at end of file
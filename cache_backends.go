@@ -0,0 +1,226 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// This file implements the cache backends that need no Redis server. CACHE_BACKEND selects the
+// backend: "redis" (the default), "memory", which keeps the cache in the application's memory,
+// or "none", which caches nothing and serves every request from the database or the providers.
+// The memory backend suits a single instance on a small server; its entries are lost on restart
+// and are not shared between instances.
+
+// Cache backends selectable with CACHE_BACKEND.
+const (
+	cacheBackendRedis  = "redis"
+	cacheBackendMemory = "memory"
+	cacheBackendNone   = "none"
+)
+
+// memoryCacheSweepInterval is how often the memory cache drops its expired entries.
+const memoryCacheSweepInterval = time.Minute
+
+// getCacheBackend reads the cache backend from CACHE_BACKEND.
+func getCacheBackend(logger *slog.Logger) string {
+	backend := strings.ToLower(strings.TrimSpace(getEnv("CACHE_BACKEND", cacheBackendRedis, logger)))
+	switch backend {
+	case cacheBackendRedis, cacheBackendMemory, cacheBackendNone:
+		return backend
+	}
+	logger.Warn("unknown CACHE_BACKEND, using default", "value", backend, "default", cacheBackendRedis)
+	return cacheBackendRedis
+}
+
+// memoryCache is an in-memory implementation of the Cache interface with the semantics of the
+// Redis cache: values are stored as JSON, entries expire, and keys can be scanned with glob
+// patterns. Expired entries are dropped when they are read and by a periodic sweep.
+type memoryCache struct {
+	mu        sync.Mutex
+	entries   map[string]memoryCacheEntry
+	now       func() time.Time
+	nextSweep time.Time
+}
+
+type memoryCacheEntry struct {
+	value     string
+	expiresAt time.Time // Zero if the entry does not expire.
+}
+
+func (e memoryCacheEntry) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && !now.Before(e.expiresAt)
+}
+
+func newMemoryCache() *memoryCache {
+	return &memoryCache{
+		entries: make(map[string]memoryCacheEntry),
+		now:     time.Now,
+	}
+}
+
+// Set serializes the value to JSON and stores it. An expiration of 0 keeps it indefinitely.
+func (c *memoryCache) Set(ctx context.Context, key string, value any, expiration time.Duration) error {
+	p, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	entry := memoryCacheEntry{value: string(p)}
+	if expiration > 0 {
+		entry.expiresAt = now.Add(expiration)
+	}
+	c.entries[key] = entry
+	if now.After(c.nextSweep) {
+		for k, e := range c.entries {
+			if e.expired(now) {
+				delete(c.entries, k)
+			}
+		}
+		c.nextSweep = now.Add(memoryCacheSweepInterval)
+	}
+	return nil
+}
+
+// Get returns the value of a key, or redis.Nil if it is missing or expired.
+func (c *memoryCache) Get(ctx context.Context, key string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok {
+		return "", redis.Nil
+	}
+	if entry.expired(c.now()) {
+		delete(c.entries, key)
+		return "", redis.Nil
+	}
+	return entry.value, nil
+}
+
+// Flush removes all entries.
+func (c *memoryCache) Flush(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	clear(c.entries)
+	return nil
+}
+
+// Delete removes the given keys. Keys that do not exist are ignored.
+func (c *memoryCache) Delete(ctx context.Context, keys ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, key := range keys {
+		delete(c.entries, key)
+	}
+	return nil
+}
+
+// Scan returns a page of the keys matching a glob pattern, in sorted order, and the cursor of
+// the next page, which is 0 once the iteration is complete. The cursor is an offset into the
+// sorted keys, so keys deleted during a scan may cause others to be skipped, as SCAN may.
+func (c *memoryCache) Scan(ctx context.Context, cursor uint64, match string, count int64) ([]string, uint64, error) {
+	c.mu.Lock()
+	now := c.now()
+	var keys []string
+	for key, entry := range c.entries {
+		if entry.expired(now) {
+			continue
+		}
+		if ok, _ := path.Match(match, key); ok {
+			keys = append(keys, key)
+		}
+	}
+	c.mu.Unlock()
+
+	slices.Sort(keys)
+	if cursor >= uint64(len(keys)) {
+		return nil, 0, nil
+	}
+	end := min(cursor+uint64(max(count, 1)), uint64(len(keys)))
+	if end == uint64(len(keys)) {
+		return keys[cursor:end], 0, nil
+	}
+	return keys[cursor:end], end, nil
+}
+
+// TTL returns the remaining time to live of a key. As in Redis, it returns -1 for a key without
+// an expiration and -2 for a key that does not exist.
+func (c *memoryCache) TTL(ctx context.Context, key string) (time.Duration, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	now := c.now()
+	switch {
+	case !ok || entry.expired(now):
+		return -2, nil
+	case entry.expiresAt.IsZero():
+		return -1, nil
+	}
+	return entry.expiresAt.Sub(now), nil
+}
+
+// Incr increments the integer counter stored at a key and returns its new value. A counter that
+// did not exist starts at 0 and expires after the given expiration.
+func (c *memoryCache) Incr(ctx context.Context, key string, expiration time.Duration) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	entry, ok := c.entries[key]
+	if !ok || entry.expired(now) {
+		entry = memoryCacheEntry{value: "0"}
+		if expiration > 0 {
+			entry.expiresAt = now.Add(expiration)
+		}
+	}
+	n, err := strconv.ParseInt(entry.value, 10, 64)
+	if err != nil {
+		return 0, errors.New("value is not an integer")
+	}
+	n++
+	entry.value = strconv.FormatInt(n, 10)
+	c.entries[key] = entry
+	return n, nil
+}
+
+// noopCache is a Cache that stores nothing. Every read is a miss and every write succeeds.
+type noopCache struct{}
+
+func (noopCache) Set(ctx context.Context, key string, value any, expiration time.Duration) error {
+	return nil
+}
+
+func (noopCache) Get(ctx context.Context, key string) (string, error) {
+	return "", redis.Nil
+}
+
+func (noopCache) Flush(ctx context.Context) error {
+	return nil
+}
+
+func (noopCache) Delete(ctx context.Context, keys ...string) error {
+	return nil
+}
+
+func (noopCache) Scan(ctx context.Context, cursor uint64, match string, count int64) ([]string, uint64, error) {
+	return nil, 0, nil
+}
+
+func (noopCache) TTL(ctx context.Context, key string) (time.Duration, error) {
+	return -2, nil
+}
+
+// Incr fails with errCacheUnavailable, as there is nowhere to keep a counter.
+func (noopCache) Incr(ctx context.Context, key string, expiration time.Duration) (int64, error) {
+	return 0, errCacheUnavailable
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestMemoryCache(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	c := newMemoryCache()
+	c.now = func() time.Time { return now }
+
+	if err := c.Set(ctx, "currentweather:a", map[string]int{"t": 20}, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Set(ctx, "currentweather:b", 1, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Set(ctx, "geocode:c", 2, 0); err != nil {
+		t.Fatal(err)
+	}
+	if val, err := c.Get(ctx, "currentweather:a"); err != nil || val != `{"t":20}` {
+		t.Errorf("Get = %q, %v", val, err)
+	}
+	if ttl, _ := c.TTL(ctx, "currentweather:a"); ttl != time.Minute {
+		t.Errorf("TTL = %v, want 1m", ttl)
+	}
+	if ttl, _ := c.TTL(ctx, "currentweather:b"); ttl != -1 {
+		t.Errorf("TTL of a key without expiration = %v, want -1", ttl)
+	}
+
+	// Scan pages through the matching keys in order.
+	keys, cursor, err := c.Scan(ctx, 0, "currentweather:*", 1)
+	if err != nil || len(keys) != 1 || keys[0] != "currentweather:a" || cursor == 0 {
+		t.Fatalf("first page = %v, %d, %v", keys, cursor, err)
+	}
+	keys, cursor, err = c.Scan(ctx, cursor, "currentweather:*", 1)
+	if err != nil || len(keys) != 1 || keys[0] != "currentweather:b" || cursor != 0 {
+		t.Fatalf("second page = %v, %d, %v", keys, cursor, err)
+	}
+
+	now = now.Add(time.Minute)
+	if _, err := c.Get(ctx, "currentweather:a"); err != redis.Nil {
+		t.Errorf("expected an expired key to be missing, got %v", err)
+	}
+	if ttl, _ := c.TTL(ctx, "currentweather:a"); ttl != -2 {
+		t.Errorf("TTL of an expired key = %v, want -2", ttl)
+	}
+
+	if err := c.Delete(ctx, "currentweather:b", "missing"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Get(ctx, "currentweather:b"); err != redis.Nil {
+		t.Errorf("expected a deleted key to be missing, got %v", err)
+	}
+	if err := c.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Get(ctx, "geocode:c"); err != redis.Nil {
+		t.Errorf("expected a flushed key to be missing, got %v", err)
+	}
+}
+
+func TestMemoryCacheIncr(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	c := newMemoryCache()
+	c.now = func() time.Time { return now }
+
+	for want := int64(1); want <= 2; want++ {
+		if n, err := c.Incr(ctx, "counter", time.Minute); err != nil || n != want {
+			t.Errorf("Incr = %d, %v; want %d", n, err, want)
+		}
+	}
+	if val, err := c.Get(ctx, "counter"); err != nil || val != "2" {
+		t.Errorf("Get = %q, %v", val, err)
+	}
+	if ttl, _ := c.TTL(ctx, "counter"); ttl != time.Minute {
+		t.Errorf("TTL = %v, want 1m", ttl)
+	}
+
+	now = now.Add(time.Minute)
+	if n, err := c.Incr(ctx, "counter", time.Minute); err != nil || n != 1 {
+		t.Errorf("expected an expired counter to restart, got %d, %v", n, err)
+	}
+
+	if err := c.Set(ctx, "text", "a", 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Incr(ctx, "text", 0); err == nil {
+		t.Error("expected an error for a value that is not an integer")
+	}
+	if _, err := (noopCache{}).Incr(ctx, "counter", 0); err != errCacheUnavailable {
+		t.Errorf("expected noopCache to fail with errCacheUnavailable, got %v", err)
+	}
+}
+
+func TestConnectCacheBackends(t *testing.T) {
+	testCases := []struct {
+		backend  string
+		wantType string
+	}{
+		{backend: cacheBackendMemory, wantType: "*main.memoryCache"},
+		{backend: cacheBackendNone, wantType: "main.noopCache"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.backend, func(t *testing.T) {
+			cfg := &apiConfig{
+				cacheBackend: tc.backend,
+				logger:       slog.New(slog.NewTextHandler(io.Discard, nil)),
+			}
+			if err := cfg.ConnectCache(); err != nil {
+				t.Fatalf("ConnectCache returned an error without Redis: %v", err)
+			}
+			if got := fmt.Sprintf("%T", cfg.cache); got != tc.wantType {
+				t.Errorf("cache = %s, want %s", got, tc.wantType)
+			}
+		})
+	}
+}
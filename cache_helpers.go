@@ -4,7 +4,9 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"slices"
 	"time"
 
 	"github.com/cor0nius/willitrain/internal/database"
@@ -33,6 +35,19 @@ const redisHourlyForecastCacheTTL = 55 * time.Minute
 // 2. If Redis is a miss or the data is invalid, it checks the PostgreSQL database.
 // 3. If the database data is also stale or missing, it fetches fresh data from the external APIs.
 // 4. After a successful API fetch, it updates both the database and the Redis cache.
+// 5. If every provider fails, for example because their circuits are open, it serves the stale
+//    database data instead, if there is any, without caching it.
+//
+// The API fetch is hedged, so a slow provider may be left out of the response. Its data is
+// persisted when it arrives, and the Redis entry is dropped so that the next request reads
+// the complete data from the database.
+//
+// Steps 2 to 5 are coalesced: concurrent requests that miss Redis for the same key wait for the
+// first one and share its result, so that a burst of requests for a location not yet cached
+// reads the database and calls each provider once.
+//
+// Providers that fail during the API fetch are recorded in the context's providerFetchErrors,
+// if it has one, so that handlers can report why a source is missing.
 func getCachedOrFetch[T apiModel, D dbModel](
 	cfg *apiConfig,
 	ctx context.Context,
@@ -41,66 +56,149 @@ func getCachedOrFetch[T apiModel, D dbModel](
 	dbCacheTTL time.Duration,
 	redisCacheTTL time.Duration,
 	dbFetcher func(context.Context, uuid.UUID) ([]D, error),
-	apiFetcher func(Location) ([]T, error),
+	apiFetcher func(context.Context, Location, func([]T), func(providerFetchOutcome)) ([]T, error),
 	persister func(context.Context, []T),
 	modelConverter func(D, Location) T,
 	getTimestamp func(D) time.Time,
 	isValidCache func([]T) bool,
 ) ([]T, error) {
-	cacheKey := fmt.Sprintf("%s:%s", cacheKeyPrefix, location.LocationID.String())
+	cacheKey := cfg.weatherCacheKey(cacheKeyPrefix, location.LocationID)
 	cachedData, err := cfg.cache.Get(ctx, cacheKey)
 	if err == nil {
 		var items []T
 		jsonErr := json.Unmarshal([]byte(cachedData), &items)
+		items = filterEnabledSources(cfg, items)
 		if jsonErr == nil && isValidCache(items) {
-			cfg.logger.Debug("cache hit", "key", cacheKey)
+			cfg.debugSampled(ctx, "cache hit", "key", cacheKey)
+			cacheLookups.WithLabelValues(cacheKeyPrefix, "redis_hit").Inc()
 			return items, nil
 		}
 		if jsonErr != nil {
-			cfg.logger.Warn("invalid cache entry: unmarshal error", "key", cacheKey, "error", jsonErr)
+			cfg.logger.WarnContext(ctx, "invalid cache entry: unmarshal error", "key", cacheKey, "error", jsonErr)
 		} else {
-			cfg.logger.Warn("invalid cache entry: validation failed", "key", cacheKey, "actual_count", len(items))
+			cfg.logger.WarnContext(ctx, "invalid cache entry: validation failed", "key", cacheKey, "actual_count", len(items))
 		}
+	} else if errors.Is(err, errCacheUnavailable) {
+		cfg.debugSampled(ctx, "cache bypassed", "key", cacheKey)
 	} else if err != redis.Nil {
-		cfg.logger.Warn("error getting from redis", "key", cacheKey, "error", err)
+		cfg.logger.WarnContext(ctx, "error getting from redis", "key", cacheKey, "error", err)
 	}
 
-	dbItems, err := dbFetcher(ctx, location.LocationID)
-	if err != nil && err != sql.ErrNoRows { // sql.ErrNoRows is handled gracefully
-		return nil, fmt.Errorf("database error when fetching %s: %w", cacheKeyPrefix, err)
-	}
+	// Concurrent misses for the same key share one database read and provider fetch. The shared
+	// lookup runs without the request's cancellation, since other requests may be waiting for it.
+	result, shared, err := cfg.inflight.do(ctx, cacheKey, func() (any, error) {
+		ctx := context.WithoutCancel(ctx)
+		dbItems, err := dbFetcher(ctx, location.LocationID)
+		if err != nil && err != sql.ErrNoRows { // sql.ErrNoRows is handled gracefully
+			return nil, fmt.Errorf("database error when fetching %s: %w", cacheKeyPrefix, err)
+		}
 
-	if err == nil {
-		var freshItems []T
-		for _, dbi := range dbItems {
-			if getTimestamp(dbi).After(time.Now().UTC().Add(-dbCacheTTL)) {
-				freshItems = append(freshItems, modelConverter(dbi, location))
+		if err == nil {
+			var freshItems []T
+			for _, dbi := range dbItems {
+				if getTimestamp(dbi).After(time.Now().UTC().Add(-dbCacheTTL)) {
+					freshItems = append(freshItems, modelConverter(dbi, location))
+				}
+			}
+			freshItems = filterEnabledSources(cfg, freshItems)
+
+			if isValidCache(freshItems) {
+				cfg.debugSampled(ctx, "db cache hit", "key", cacheKey)
+				cacheLookups.WithLabelValues(cacheKeyPrefix, "db_hit").Inc()
+				if cacheErr := cfg.cache.Set(ctx, cacheKey, freshItems, redisCacheTTL); cacheErr != nil && !errors.Is(cacheErr, errCacheUnavailable) {
+					cfg.logger.WarnContext(ctx, "error setting to redis", "key", cacheKey, "error", cacheErr)
+				}
+				return cachedFetchResult[T]{items: freshItems}, nil
 			}
 		}
 
-		if isValidCache(freshItems) {
-			cfg.logger.Debug("db cache hit", "key", cacheKey)
-			if cacheErr := cfg.cache.Set(ctx, cacheKey, freshItems, redisCacheTTL); cacheErr != nil {
-				cfg.logger.Warn("error setting to redis", "key", cacheKey, "error", cacheErr)
+		// Late results must not be persisted before the served results are cached, or the cache
+		// entry written below would hide them.
+		served := make(chan struct{})
+		defer close(served)
+		onLate := func(late []T) {
+			<-served
+			persister(ctx, late)
+			if cacheErr := cfg.cache.Delete(ctx, cacheKey); cacheErr != nil && !errors.Is(cacheErr, errCacheUnavailable) {
+				cfg.logger.WarnContext(ctx, "error deleting from redis after late api fetch", "key", cacheKey, "error", cacheErr)
 			}
-			return freshItems, nil
+			cfg.logger.DebugContext(ctx, "late api fetch persisted", "key", cacheKey)
 		}
-	}
 
-	apiItems, err := apiFetcher(location)
+		cacheLookups.WithLabelValues(cacheKeyPrefix, "api_fetch").Inc()
+		fetchErrs := &providerFetchErrors{}
+		apiItems, err := apiFetcher(ctx, location, onLate, fetchErrs.observe)
+		if err != nil {
+			var staleItems []T
+			for _, dbi := range dbItems {
+				staleItems = append(staleItems, modelConverter(dbi, location))
+			}
+			staleItems = filterEnabledSources(cfg, staleItems)
+			if len(staleItems) > 0 {
+				cfg.logger.WarnContext(ctx, "api fetch failed, serving stale data", "key", cacheKey, "error", err)
+				staleResponsesServed.WithLabelValues(cacheKeyPrefix).Inc()
+				return cachedFetchResult[T]{items: staleItems, errClasses: fetchErrs.snapshot()}, nil
+			}
+			return nil, fmt.Errorf("could not fetch %s: %w", cacheKeyPrefix, err)
+		}
+		cfg.logger.DebugContext(ctx, "api fetch successful", "key", cacheKey)
+
+		persister(ctx, apiItems)
+		if cacheErr := cfg.cache.Set(ctx, cacheKey, apiItems, redisCacheTTL); errors.Is(cacheErr, errCacheUnavailable) {
+			cfg.logger.DebugContext(ctx, "cache bypassed", "key", cacheKey)
+		} else if cacheErr != nil {
+			cfg.logger.WarnContext(ctx, "error setting to redis after api fetch", "key", cacheKey, "error", cacheErr)
+		} else {
+			cfg.logger.DebugContext(ctx, "set to cache", "key", cacheKey)
+		}
+
+		return cachedFetchResult[T]{items: apiItems, errClasses: fetchErrs.snapshot()}, nil
+	})
+	if shared {
+		coalescedRequests.WithLabelValues(cacheKeyPrefix).Inc()
+		cfg.debugSampled(ctx, "shared in-flight lookup", "key", cacheKey)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("could not fetch %s: %w", cacheKeyPrefix, err)
+		return nil, err
 	}
-	cfg.logger.Debug("api fetch successful", "key", cacheKey)
+	res := result.(cachedFetchResult[T])
+	providerFetchErrorsFrom(ctx).merge(res.errClasses)
+	// Every caller gets its own copy, as handlers may reorder the items.
+	return slices.Clone(res.items), nil
+}
 
-	persister(ctx, apiItems)
-	if cacheErr := cfg.cache.Set(ctx, cacheKey, apiItems, redisCacheTTL); cacheErr != nil {
-		cfg.logger.Warn("error setting to redis after api fetch", "key", cacheKey, "error", cacheErr)
-	} else {
-		cfg.logger.Debug("set to cache", "key", cacheKey)
+// cachedFetchResult is the result of a coalesced lookup in getCachedOrFetch: the items and the
+// error classes of the providers that failed during the API fetch, keyed by provider ID.
+type cachedFetchResult[T apiModel] struct {
+	items      []T
+	errClasses map[string]string
+}
+
+// filterEnabledSources drops items from providers that were disabled through WEATHER_SOURCES,
+// so that data stored before a provider was switched off is not served.
+func filterEnabledSources[T apiModel](cfg *apiConfig, items []T) []T {
+	var filtered []T
+	for _, item := range items {
+		if cfg.sourceAPIEnabled(apiModelSourceAPI(item)) {
+			filtered = append(filtered, item)
+		}
 	}
+	return filtered
+}
 
-	return apiItems, nil
+// apiModelSourceAPI returns the SourceAPI field of any apiModel value.
+func apiModelSourceAPI[T apiModel](item T) string {
+	switch v := any(item).(type) {
+	case CurrentWeather:
+		return v.SourceAPI
+	case DailyForecast:
+		return v.SourceAPI
+	case HourlyForecast:
+		return v.SourceAPI
+	case AirQuality:
+		return v.SourceAPI
+	}
+	return ""
 }
 
 // The getCachedOrFetch... functions are specific implementations of the generic getCachedOrFetch helper.
@@ -111,7 +209,7 @@ func (cfg *apiConfig) getCachedOrFetchCurrentWeather(ctx context.Context, locati
 		cfg,
 		ctx,
 		location,
-		"currentweather",
+		currentWeatherCacheKeyPrefix,
 		weatherCacheTTL,
 		redisCurrentWeatherCacheTTL,
 		cfg.dbQueries.GetCurrentWeatherAtLocation,
@@ -122,7 +220,7 @@ func (cfg *apiConfig) getCachedOrFetchCurrentWeather(ctx context.Context, locati
 			return d.UpdatedAt
 		},
 		func(items []CurrentWeather) bool {
-			return len(items) == 3
+			return len(items) == cfg.enabledSourceCount()
 		},
 	)
 }
@@ -131,8 +229,9 @@ func (cfg *apiConfig) getCachedOrFetchDailyForecast(ctx context.Context, locatio
 	dbFetcher := func(ctx context.Context, locationID uuid.UUID) ([]database.DailyForecast, error) {
 		today := time.Now().UTC().Truncate(24 * time.Hour)
 		return cfg.dbQueries.GetUpcomingDailyForecastsAtLocation(ctx, database.GetUpcomingDailyForecastsAtLocationParams{
-			LocationID:    locationID,
-			ForecastDate:  today,
+			LocationID: locationID,
+			FromDate:   today,
+			ToDate:     today.AddDate(0, 0, cfg.dailyForecastDays()),
 		})
 	}
 
@@ -140,7 +239,7 @@ func (cfg *apiConfig) getCachedOrFetchDailyForecast(ctx context.Context, locatio
 		cfg,
 		ctx,
 		location,
-		"dailyforecast",
+		dailyForecastCacheKeyPrefix,
 		dailyForecastCacheTTL,
 		redisDailyForecastCacheTTL,
 		dbFetcher,
@@ -158,9 +257,12 @@ func (cfg *apiConfig) getCachedOrFetchDailyForecast(ctx context.Context, locatio
 
 func (cfg *apiConfig) getCachedOrFetchHourlyForecast(ctx context.Context, location Location) ([]HourlyForecast, error) {
 	dbFetcher := func(ctx context.Context, locationID uuid.UUID) ([]database.HourlyForecast, error) {
+		now := time.Now().UTC()
 		return cfg.dbQueries.GetUpcomingHourlyForecastsAtLocation(ctx, database.GetUpcomingHourlyForecastsAtLocationParams{
-			LocationID:          locationID,
-			ForecastDatetimeUtc: time.Now().UTC(),
+			LocationID: locationID,
+			FromTime:   now,
+			ToTime:     now.Add(time.Duration(cfg.hourlyForecastHours()) * time.Hour),
+			MaxHours:   int32(cfg.hourlyForecastHours()),
 		})
 	}
 
@@ -168,7 +270,7 @@ func (cfg *apiConfig) getCachedOrFetchHourlyForecast(ctx context.Context, locati
 		cfg,
 		ctx,
 		location,
-		"hourlyforecast",
+		hourlyForecastCacheKeyPrefix,
 		hourlyForecastCacheTTL,
 		redisHourlyForecastCacheTTL,
 		dbFetcher,
@@ -9,12 +9,14 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/cor0nius/willitrain/internal/database"
 	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -62,12 +64,13 @@ func TestGetCachedOrFetchCurrentWeather(t *testing.T) {
 		name       string
 		setupMocks func(cfg *testAPIConfig, server *httptest.Server)
 		check      func(t *testing.T, weather []CurrentWeather, err error)
+		lookup     string // the expected willitrain_cache_lookups_total result, if any
 	}{
 		{
 			name: "Success: Redis Hit",
 			setupMocks: func(cfg *testAPIConfig, server *httptest.Server) {
 				cachedData, _ := json.Marshal(apiWeather)
-				cfg.mockCache.getFunc = func(ctx context.Context, key string) (string, error) {
+				cfg.mockCache.GetFunc = func(ctx context.Context, key string) (string, error) {
 					return string(cachedData), nil
 				}
 			},
@@ -79,17 +82,18 @@ func TestGetCachedOrFetchCurrentWeather(t *testing.T) {
 					t.Fatalf("expected 3 weather items, got %d", len(weather))
 				}
 			},
+			lookup: "redis_hit",
 		},
 		{
 			name: "Success: DB Hit",
 			setupMocks: func(cfg *testAPIConfig, server *httptest.Server) {
-				cfg.mockCache.getFunc = func(ctx context.Context, key string) (string, error) {
+				cfg.mockCache.GetFunc = func(ctx context.Context, key string) (string, error) {
 					return "", redis.Nil
 				}
 				cfg.mockDB.GetCurrentWeatherAtLocationFunc = func(ctx context.Context, locationID uuid.UUID) ([]database.CurrentWeather, error) {
 					return dbWeather, nil
 				}
-				cfg.mockCache.setFunc = func(ctx context.Context, key string, value any, expiration time.Duration) error {
+				cfg.mockCache.SetFunc = func(ctx context.Context, key string, value any, expiration time.Duration) error {
 					return nil // Expect cache to be warmed
 				}
 			},
@@ -101,11 +105,12 @@ func TestGetCachedOrFetchCurrentWeather(t *testing.T) {
 					t.Fatalf("expected 3 weather items, got %d", len(weather))
 				}
 			},
+			lookup: "db_hit",
 		},
 		{
 			name: "Success: API Fetch",
 			setupMocks: func(cfg *testAPIConfig, server *httptest.Server) {
-				cfg.mockCache.getFunc = func(ctx context.Context, key string) (string, error) { return "", redis.Nil }
+				cfg.mockCache.GetFunc = func(ctx context.Context, key string) (string, error) { return "", redis.Nil }
 				cfg.mockDB.GetCurrentWeatherAtLocationFunc = func(ctx context.Context, locationID uuid.UUID) ([]database.CurrentWeather, error) {
 					return nil, sql.ErrNoRows
 				}
@@ -118,7 +123,7 @@ func TestGetCachedOrFetchCurrentWeather(t *testing.T) {
 				cfg.mockDB.UpdateTimezoneFunc = func(ctx context.Context, arg database.UpdateTimezoneParams) error {
 					return nil
 				}
-				cfg.mockCache.setFunc = func(ctx context.Context, key string, value any, expiration time.Duration) error { return nil }
+				cfg.mockCache.SetFunc = func(ctx context.Context, key string, value any, expiration time.Duration) error { return nil }
 			},
 			check: func(t *testing.T, weather []CurrentWeather, err error) {
 				if err != nil {
@@ -128,17 +133,18 @@ func TestGetCachedOrFetchCurrentWeather(t *testing.T) {
 					t.Fatalf("expected 3 weather items, got %d", len(weather))
 				}
 			},
+			lookup: "api_fetch",
 		},
 		{
 			name: "Fail: Invalid JSON in Redis",
 			setupMocks: func(cfg *testAPIConfig, server *httptest.Server) {
-				cfg.mockCache.getFunc = func(ctx context.Context, key string) (string, error) {
+				cfg.mockCache.GetFunc = func(ctx context.Context, key string) (string, error) {
 					return "invalid json", nil
 				}
 				cfg.mockDB.GetCurrentWeatherAtLocationFunc = func(ctx context.Context, locationID uuid.UUID) ([]database.CurrentWeather, error) {
 					return dbWeather, nil // Fallback to DB
 				}
-				cfg.mockCache.setFunc = func(ctx context.Context, key string, value any, expiration time.Duration) error {
+				cfg.mockCache.SetFunc = func(ctx context.Context, key string, value any, expiration time.Duration) error {
 					return nil
 				}
 			},
@@ -156,13 +162,13 @@ func TestGetCachedOrFetchCurrentWeather(t *testing.T) {
 			setupMocks: func(cfg *testAPIConfig, server *httptest.Server) {
 				invalidAPIWeather := []CurrentWeather{{SourceAPI: "gmp", Temperature: 22.0}}
 				cachedData, _ := json.Marshal(invalidAPIWeather)
-				cfg.mockCache.getFunc = func(ctx context.Context, key string) (string, error) {
+				cfg.mockCache.GetFunc = func(ctx context.Context, key string) (string, error) {
 					return string(cachedData), nil
 				}
 				cfg.mockDB.GetCurrentWeatherAtLocationFunc = func(ctx context.Context, locationID uuid.UUID) ([]database.CurrentWeather, error) {
 					return dbWeather, nil // Fallback to DB
 				}
-				cfg.mockCache.setFunc = func(ctx context.Context, key string, value any, expiration time.Duration) error {
+				cfg.mockCache.SetFunc = func(ctx context.Context, key string, value any, expiration time.Duration) error {
 					return nil
 				}
 			},
@@ -178,13 +184,13 @@ func TestGetCachedOrFetchCurrentWeather(t *testing.T) {
 		{
 			name: "Fail: Generic Redis error",
 			setupMocks: func(cfg *testAPIConfig, server *httptest.Server) {
-				cfg.mockCache.getFunc = func(ctx context.Context, key string) (string, error) {
+				cfg.mockCache.GetFunc = func(ctx context.Context, key string) (string, error) {
 					return "", sql.ErrConnDone // Using a random persistent error
 				}
 				cfg.mockDB.GetCurrentWeatherAtLocationFunc = func(ctx context.Context, locationID uuid.UUID) ([]database.CurrentWeather, error) {
 					return dbWeather, nil // Fallback to DB
 				}
-				cfg.mockCache.setFunc = func(ctx context.Context, key string, value any, expiration time.Duration) error {
+				cfg.mockCache.SetFunc = func(ctx context.Context, key string, value any, expiration time.Duration) error {
 					return nil
 				}
 			},
@@ -200,7 +206,7 @@ func TestGetCachedOrFetchCurrentWeather(t *testing.T) {
 		{
 			name: "Fail: DB error on fetch",
 			setupMocks: func(cfg *testAPIConfig, server *httptest.Server) {
-				cfg.mockCache.getFunc = func(ctx context.Context, key string) (string, error) {
+				cfg.mockCache.GetFunc = func(ctx context.Context, key string) (string, error) {
 					return "", redis.Nil
 				}
 				cfg.mockDB.GetCurrentWeatherAtLocationFunc = func(ctx context.Context, locationID uuid.UUID) ([]database.CurrentWeather, error) {
@@ -219,13 +225,13 @@ func TestGetCachedOrFetchCurrentWeather(t *testing.T) {
 		{
 			name: "Fail: Redis error on set after DB fetch",
 			setupMocks: func(cfg *testAPIConfig, server *httptest.Server) {
-				cfg.mockCache.getFunc = func(ctx context.Context, key string) (string, error) {
+				cfg.mockCache.GetFunc = func(ctx context.Context, key string) (string, error) {
 					return "", redis.Nil
 				}
 				cfg.mockDB.GetCurrentWeatherAtLocationFunc = func(ctx context.Context, locationID uuid.UUID) ([]database.CurrentWeather, error) {
 					return dbWeather, nil
 				}
-				cfg.mockCache.setFunc = func(ctx context.Context, key string, value any, expiration time.Duration) error {
+				cfg.mockCache.SetFunc = func(ctx context.Context, key string, value any, expiration time.Duration) error {
 					return sql.ErrConnDone // Some error
 				}
 			},
@@ -241,14 +247,14 @@ func TestGetCachedOrFetchCurrentWeather(t *testing.T) {
 		{
 			name: "Fail: API fetch error",
 			setupMocks: func(cfg *testAPIConfig, server *httptest.Server) {
-				cfg.mockCache.getFunc = func(ctx context.Context, key string) (string, error) { return "", redis.Nil }
+				cfg.mockCache.GetFunc = func(ctx context.Context, key string) (string, error) { return "", redis.Nil }
 				cfg.mockDB.GetCurrentWeatherAtLocationFunc = func(ctx context.Context, locationID uuid.UUID) ([]database.CurrentWeather, error) {
 					return nil, sql.ErrNoRows
 				}
 
 				// To simulate an API error, we replace the http client with one that always fails.
 				cfg.apiConfig.httpClient = &http.Client{
-					Transport: &errorTransport{err: errors.New("network error")},
+					Transport: &errorTransport{Err: errors.New("network error")},
 				}
 			},
 			check: func(t *testing.T, weather []CurrentWeather, err error) {
@@ -260,10 +266,39 @@ func TestGetCachedOrFetchCurrentWeather(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "Success: Stale DB data when API fetch fails",
+			setupMocks: func(cfg *testAPIConfig, server *httptest.Server) {
+				staleWeather := make([]database.CurrentWeather, len(dbWeather))
+				copy(staleWeather, dbWeather)
+				for i := range staleWeather {
+					staleWeather[i].UpdatedAt = now.Add(-2 * time.Hour)
+				}
+				cfg.mockCache.GetFunc = func(ctx context.Context, key string) (string, error) { return "", redis.Nil }
+				cfg.mockDB.GetCurrentWeatherAtLocationFunc = func(ctx context.Context, locationID uuid.UUID) ([]database.CurrentWeather, error) {
+					return staleWeather, nil
+				}
+				cfg.mockCache.SetFunc = func(ctx context.Context, key string, value any, expiration time.Duration) error {
+					t.Error("expected stale data not to be cached")
+					return nil
+				}
+				cfg.apiConfig.httpClient = &http.Client{
+					Transport: &errorTransport{Err: errors.New("network error")},
+				}
+			},
+			check: func(t *testing.T, weather []CurrentWeather, err error) {
+				if err != nil {
+					t.Fatalf("expected stale data instead of an error, got %v", err)
+				}
+				if len(weather) != 3 {
+					t.Fatalf("expected 3 stale weather items from DB, got %d", len(weather))
+				}
+			},
+		},
 		{
 			name: "Fail: Redis error on set after API fetch",
 			setupMocks: func(cfg *testAPIConfig, server *httptest.Server) {
-				cfg.mockCache.getFunc = func(ctx context.Context, key string) (string, error) { return "", redis.Nil }
+				cfg.mockCache.GetFunc = func(ctx context.Context, key string) (string, error) { return "", redis.Nil }
 				cfg.mockDB.GetCurrentWeatherAtLocationFunc = func(ctx context.Context, locationID uuid.UUID) ([]database.CurrentWeather, error) {
 					return nil, sql.ErrNoRows
 				}
@@ -276,7 +311,7 @@ func TestGetCachedOrFetchCurrentWeather(t *testing.T) {
 				cfg.mockDB.UpdateTimezoneFunc = func(ctx context.Context, arg database.UpdateTimezoneParams) error {
 					return nil
 				}
-				cfg.mockCache.setFunc = func(ctx context.Context, key string, value any, expiration time.Duration) error {
+				cfg.mockCache.SetFunc = func(ctx context.Context, key string, value any, expiration time.Duration) error {
 					return sql.ErrConnDone
 				}
 			},
@@ -304,14 +339,27 @@ func TestGetCachedOrFetchCurrentWeather(t *testing.T) {
 			testCfg.apiConfig.owmWeatherURL = mockServer.URL + "/owm"
 			testCfg.apiConfig.ometeoWeatherURL = mockServer.URL + "/ometeo"
 			testCfg.apiConfig.httpClient = mockServer.Client()
-			testCfg.apiConfig.gmpKey = "dummy"
-			testCfg.apiConfig.owmKey = "dummy"
+			testCfg.apiConfig.gmpKey = newSecretValue("dummy")
+			testCfg.apiConfig.owmKey = newSecretValue("dummy")
+			// The cached and stored fixtures hold one entry for each of three sources.
+			testCfg.apiConfig.enabledSources = map[string]bool{"gmp": true, "owm": true, "ometeo": true}
 
 			// Allow the specific test case to override the default configuration.
 			tc.setupMocks(testCfg, mockServer)
 
+			var before float64
+			if tc.lookup != "" {
+				before = testutil.ToFloat64(cacheLookups.WithLabelValues(currentWeatherCacheKeyPrefix, tc.lookup))
+			}
+
 			weather, err := testCfg.apiConfig.getCachedOrFetchCurrentWeather(ctx, location)
 			tc.check(t, weather, err)
+
+			if tc.lookup != "" {
+				if got := testutil.ToFloat64(cacheLookups.WithLabelValues(currentWeatherCacheKeyPrefix, tc.lookup)) - before; got != 1 {
+					t.Errorf("expected 1 %s lookup to be counted, got %v", tc.lookup, got)
+				}
+			}
 		})
 	}
 }
@@ -333,7 +381,7 @@ func TestGetCachedOrFetchDailyForecast(t *testing.T) {
 			name: "Success: Redis Hit",
 			setupMocks: func(cfg *testAPIConfig, server *httptest.Server) {
 				cachedData, _ := json.Marshal(apiForecast)
-				cfg.mockCache.getFunc = func(ctx context.Context, key string) (string, error) {
+				cfg.mockCache.GetFunc = func(ctx context.Context, key string) (string, error) {
 					return string(cachedData), nil
 				}
 			},
@@ -349,11 +397,11 @@ func TestGetCachedOrFetchDailyForecast(t *testing.T) {
 		{
 			name: "Success: DB Hit",
 			setupMocks: func(cfg *testAPIConfig, server *httptest.Server) {
-				cfg.mockCache.getFunc = func(ctx context.Context, key string) (string, error) { return "", redis.Nil }
+				cfg.mockCache.GetFunc = func(ctx context.Context, key string) (string, error) { return "", redis.Nil }
 				cfg.mockDB.GetUpcomingDailyForecastsAtLocationFunc = func(ctx context.Context, arg database.GetUpcomingDailyForecastsAtLocationParams) ([]database.DailyForecast, error) {
 					return dbForecast, nil
 				}
-				cfg.mockCache.setFunc = func(ctx context.Context, key string, value any, expiration time.Duration) error { return nil }
+				cfg.mockCache.SetFunc = func(ctx context.Context, key string, value any, expiration time.Duration) error { return nil }
 			},
 			check: func(t *testing.T, forecast []DailyForecast, err error) {
 				if err != nil {
@@ -367,20 +415,14 @@ func TestGetCachedOrFetchDailyForecast(t *testing.T) {
 		{
 			name: "Success: API Fetch",
 			setupMocks: func(cfg *testAPIConfig, server *httptest.Server) {
-				cfg.mockCache.getFunc = func(ctx context.Context, key string) (string, error) { return "", redis.Nil }
+				cfg.mockCache.GetFunc = func(ctx context.Context, key string) (string, error) { return "", redis.Nil }
 				cfg.mockDB.GetUpcomingDailyForecastsAtLocationFunc = func(ctx context.Context, arg database.GetUpcomingDailyForecastsAtLocationParams) ([]database.DailyForecast, error) {
 					return nil, sql.ErrNoRows
 				}
-				cfg.mockDB.GetDailyForecastAtLocationAndDateFromAPIFunc = func(ctx context.Context, arg database.GetDailyForecastAtLocationAndDateFromAPIParams) (database.DailyForecast, error) {
-					return database.DailyForecast{}, sql.ErrNoRows
-				}
-				cfg.mockDB.CreateDailyForecastFunc = func(ctx context.Context, arg database.CreateDailyForecastParams) (database.DailyForecast, error) {
-					return database.DailyForecast{}, nil
-				}
 				cfg.mockDB.UpdateTimezoneFunc = func(ctx context.Context, arg database.UpdateTimezoneParams) error {
 					return nil
 				}
-				cfg.mockCache.setFunc = func(ctx context.Context, key string, value any, expiration time.Duration) error { return nil }
+				cfg.mockCache.SetFunc = func(ctx context.Context, key string, value any, expiration time.Duration) error { return nil }
 			},
 			check: func(t *testing.T, forecast []DailyForecast, err error) {
 				if err != nil {
@@ -407,8 +449,8 @@ func TestGetCachedOrFetchDailyForecast(t *testing.T) {
 			testCfg.apiConfig.owmWeatherURL = mockServer.URL + "/owm"
 			testCfg.apiConfig.ometeoWeatherURL = mockServer.URL + "/ometeo"
 			testCfg.apiConfig.httpClient = mockServer.Client()
-			testCfg.apiConfig.gmpKey = "dummy"
-			testCfg.apiConfig.owmKey = "dummy"
+			testCfg.apiConfig.gmpKey = newSecretValue("dummy")
+			testCfg.apiConfig.owmKey = newSecretValue("dummy")
 
 			forecast, err := testCfg.apiConfig.getCachedOrFetchDailyForecast(ctx, location)
 			tc.check(t, forecast, err)
@@ -433,7 +475,7 @@ func TestGetCachedOrFetchHourlyForecast(t *testing.T) {
 			name: "Success: Redis Hit",
 			setupMocks: func(cfg *testAPIConfig, server *httptest.Server) {
 				cachedData, _ := json.Marshal(apiForecast)
-				cfg.mockCache.getFunc = func(ctx context.Context, key string) (string, error) {
+				cfg.mockCache.GetFunc = func(ctx context.Context, key string) (string, error) {
 					return string(cachedData), nil
 				}
 			},
@@ -449,11 +491,11 @@ func TestGetCachedOrFetchHourlyForecast(t *testing.T) {
 		{
 			name: "Success: DB Hit",
 			setupMocks: func(cfg *testAPIConfig, server *httptest.Server) {
-				cfg.mockCache.getFunc = func(ctx context.Context, key string) (string, error) { return "", redis.Nil }
+				cfg.mockCache.GetFunc = func(ctx context.Context, key string) (string, error) { return "", redis.Nil }
 				cfg.mockDB.GetUpcomingHourlyForecastsAtLocationFunc = func(ctx context.Context, arg database.GetUpcomingHourlyForecastsAtLocationParams) ([]database.HourlyForecast, error) {
 					return dbForecast, nil
 				}
-				cfg.mockCache.setFunc = func(ctx context.Context, key string, value any, expiration time.Duration) error { return nil }
+				cfg.mockCache.SetFunc = func(ctx context.Context, key string, value any, expiration time.Duration) error { return nil }
 			},
 			check: func(t *testing.T, forecast []HourlyForecast, err error) {
 				if err != nil {
@@ -467,20 +509,14 @@ func TestGetCachedOrFetchHourlyForecast(t *testing.T) {
 		{
 			name: "Success: API Fetch",
 			setupMocks: func(cfg *testAPIConfig, server *httptest.Server) {
-				cfg.mockCache.getFunc = func(ctx context.Context, key string) (string, error) { return "", redis.Nil }
+				cfg.mockCache.GetFunc = func(ctx context.Context, key string) (string, error) { return "", redis.Nil }
 				cfg.mockDB.GetUpcomingHourlyForecastsAtLocationFunc = func(ctx context.Context, arg database.GetUpcomingHourlyForecastsAtLocationParams) ([]database.HourlyForecast, error) {
 					return nil, sql.ErrNoRows
 				}
-				cfg.mockDB.GetHourlyForecastAtLocationAndTimeFromAPIFunc = func(ctx context.Context, arg database.GetHourlyForecastAtLocationAndTimeFromAPIParams) (database.HourlyForecast, error) {
-					return database.HourlyForecast{}, sql.ErrNoRows
-				}
-				cfg.mockDB.CreateHourlyForecastFunc = func(ctx context.Context, arg database.CreateHourlyForecastParams) (database.HourlyForecast, error) {
-					return database.HourlyForecast{}, nil
-				}
 				cfg.mockDB.UpdateTimezoneFunc = func(ctx context.Context, arg database.UpdateTimezoneParams) error {
 					return nil
 				}
-				cfg.mockCache.setFunc = func(ctx context.Context, key string, value any, expiration time.Duration) error { return nil }
+				cfg.mockCache.SetFunc = func(ctx context.Context, key string, value any, expiration time.Duration) error { return nil }
 			},
 			check: func(t *testing.T, forecast []HourlyForecast, err error) {
 				if err != nil {
@@ -507,11 +543,27 @@ func TestGetCachedOrFetchHourlyForecast(t *testing.T) {
 			testCfg.apiConfig.owmWeatherURL = mockServer.URL + "/owm"
 			testCfg.apiConfig.ometeoWeatherURL = mockServer.URL + "/ometeo"
 			testCfg.apiConfig.httpClient = mockServer.Client()
-			testCfg.apiConfig.gmpKey = "dummy"
-			testCfg.apiConfig.owmKey = "dummy"
+			testCfg.apiConfig.gmpKey = newSecretValue("dummy")
+			testCfg.apiConfig.owmKey = newSecretValue("dummy")
 
 			forecast, err := testCfg.apiConfig.getCachedOrFetchHourlyForecast(ctx, location)
 			tc.check(t, forecast, err)
 		})
 	}
-}
\ No newline at end of file
+}
+func TestFilterEnabledSources(t *testing.T) {
+	cfg := &apiConfig{enabledSources: map[string]bool{"gmp": true}}
+	items := []CurrentWeather{
+		{SourceAPI: "Google Weather API"},
+		{SourceAPI: "OpenWeatherMap API"},
+		{SourceAPI: "Open-Meteo API"},
+		{SourceAPI: "test1"},
+	}
+
+	got := filterEnabledSources(cfg, items)
+
+	want := []CurrentWeather{{SourceAPI: "Google Weather API"}, {SourceAPI: "test1"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
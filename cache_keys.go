@@ -0,0 +1,366 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// This file manages the shape of the Redis cache keys. Key builders are the only place where
+// keys are assembled, scanCacheKeys enumerates keys without blocking Redis, and the cache key
+// migration job rewrites or expires keys in an old format a page at a time. A change of the key
+// format is then shipped as a new migration rule instead of requiring a full Flush in production.
+
+// Cache key prefixes for each type of weather data. The full key is "<prefix>:<location ID>",
+// followed by ":<horizon>" for forecasts.
+const (
+	currentWeatherCacheKeyPrefix = "currentweather"
+	dailyForecastCacheKeyPrefix  = "dailyforecast"
+	hourlyForecastCacheKeyPrefix = "hourlyforecast"
+	airQualityCacheKeyPrefix     = "airquality"
+)
+
+const (
+	cacheKeyMigrationJobName  = "cache key migration"
+	cacheKeyMigrationInterval = time.Minute
+
+	// cacheKeyScanCount is the COUNT hint of every SCAN call, and cacheKeyMigrationBatch the
+	// number of keys the migration job examines per run at most.
+	cacheKeyScanCount      = 200
+	cacheKeyMigrationBatch = 1000
+)
+
+// locationCacheKey returns the cache key of a location's data under the given prefix.
+func locationCacheKey(prefix string, locationID uuid.UUID) string {
+	return fmt.Sprintf("%s:%s", prefix, locationID.String())
+}
+
+// weatherCacheKey returns the cache key of a location's weather data under the given prefix.
+// Forecast keys end in the configured horizon, so that forecasts cached by an instance with
+// another horizon are not served.
+func (cfg *apiConfig) weatherCacheKey(prefix string, locationID uuid.UUID) string {
+	key := locationCacheKey(prefix, locationID)
+	switch prefix {
+	case dailyForecastCacheKeyPrefix:
+		return fmt.Sprintf("%s:%d", key, cfg.dailyForecastDays())
+	case hourlyForecastCacheKeyPrefix:
+		return fmt.Sprintf("%s:%d", key, cfg.hourlyForecastHours())
+	}
+	return key
+}
+
+// locationCacheKeys returns all Redis keys under which weather data for a location may be cached.
+func (cfg *apiConfig) locationCacheKeys(locationID uuid.UUID) []string {
+	prefixes := []string{currentWeatherCacheKeyPrefix, dailyForecastCacheKeyPrefix, hourlyForecastCacheKeyPrefix, airQualityCacheKeyPrefix, warningsCacheKeyPrefix}
+	keys := make([]string, len(prefixes))
+	for i, prefix := range prefixes {
+		keys[i] = cfg.weatherCacheKey(prefix, locationID)
+	}
+	return keys
+}
+
+// keyLocationID returns the location ID a key under a location prefix starts with.
+func keyLocationID(prefix, key string) (uuid.UUID, error) {
+	id, _, _ := strings.Cut(strings.TrimPrefix(key, prefix+":"), ":")
+	return uuid.Parse(id)
+}
+
+// hasLocationID reports whether a key under a location prefix starts with a location ID rather
+// than a city name.
+func hasLocationID(prefix, key string) bool {
+	_, err := keyLocationID(prefix, key)
+	return err == nil
+}
+
+// isCurrentCacheKey reports whether a key under a location prefix is in the current format.
+func (cfg *apiConfig) isCurrentCacheKey(prefix, key string) bool {
+	locationID, err := keyLocationID(prefix, key)
+	return err == nil && key == cfg.weatherCacheKey(prefix, locationID)
+}
+
+// scanCacheKeys calls fn with every page of keys matching a glob pattern. Keys may be reported
+// more than once, and keys written during the scan may be missed. The scan stops at the first
+// error returned by the cache or by fn.
+func scanCacheKeys(ctx context.Context, cache Cache, match string, fn func([]string) error) error {
+	var cursor uint64
+	for {
+		keys, next, err := cache.Scan(ctx, cursor, match, cacheKeyScanCount)
+		if err != nil {
+			return err
+		}
+		if len(keys) > 0 {
+			if err := fn(keys); err != nil {
+				return err
+			}
+		}
+		if next == 0 {
+			return nil
+		}
+		cursor = next
+	}
+}
+
+// cacheKeyMigration is a rule for keys in an outdated format. Keys matching the pattern are
+// passed to rewrite, which returns the key the value belongs under now. An empty key means the
+// entry has no current equivalent and is expired instead, and ok is false for keys that are
+// already in the current format.
+type cacheKeyMigration struct {
+	name    string
+	match   string
+	rewrite func(ctx context.Context, cfg *apiConfig, key string) (newKey string, ok bool, err error)
+}
+
+// cacheKeyMigrations lists the active migration rules. Rules can be removed once every
+// deployment has run them to completion.
+var cacheKeyMigrations = []cacheKeyMigration{
+	cityNameKeyMigration(currentWeatherCacheKeyPrefix),
+	cityNameKeyMigration(dailyForecastCacheKeyPrefix),
+	cityNameKeyMigration(hourlyForecastCacheKeyPrefix),
+	cityNameKeyMigration(timezoneCacheKeyPrefix),
+	forecastHorizonKeyMigration(dailyForecastCacheKeyPrefix, defaultForecastDays),
+	forecastHorizonKeyMigration(hourlyForecastCacheKeyPrefix, defaultForecastHours),
+}
+
+// cityNameKeyMigration moves "<prefix>:<city name>" keys, used before the keys embedded
+// location IDs, to the key of the location the city name or alias refers to. Keys of unknown
+// cities are expired.
+func cityNameKeyMigration(prefix string) cacheKeyMigration {
+	return cacheKeyMigration{
+		name:  prefix + " city name keys",
+		match: prefix + ":*",
+		rewrite: func(ctx context.Context, cfg *apiConfig, key string) (string, bool, error) {
+			if hasLocationID(prefix, key) {
+				return "", false, nil
+			}
+			cityName := strings.TrimPrefix(key, prefix+":")
+			dbLocation, err := cfg.findLocation(ctx, cityName)
+			if err == sql.ErrNoRows {
+				return "", true, nil
+			}
+			if err != nil {
+				return "", false, fmt.Errorf("could not look up location %q: %w", cityName, err)
+			}
+			return locationCacheKey(prefix, dbLocation.ID), true, nil
+		},
+	}
+}
+
+// forecastHorizonKeyMigration moves "<prefix>:<location ID>" forecast keys, used before the keys
+// embedded the forecast horizon, to the key of the default horizon they were cached for. Keys of
+// a horizon other than the configured one are expired.
+func forecastHorizonKeyMigration(prefix string, defaultHorizon int) cacheKeyMigration {
+	return cacheKeyMigration{
+		name:  prefix + " horizon keys",
+		match: prefix + ":*",
+		rewrite: func(ctx context.Context, cfg *apiConfig, key string) (string, bool, error) {
+			locationID, err := keyLocationID(prefix, key)
+			if err != nil { // City name keys are moved by cityNameKeyMigration first.
+				return "", false, nil
+			}
+			newKey := cfg.weatherCacheKey(prefix, locationID)
+			if key == newKey {
+				return "", false, nil
+			}
+			if newKey == fmt.Sprintf("%s:%d", key, defaultHorizon) {
+				return newKey, true, nil
+			}
+			return "", true, nil
+		},
+	}
+}
+
+// cacheKeyMigrator tracks the progress of the migration rules across runs of the migration job.
+// A pass runs every rule over the whole key space; the migration is complete after a pass that
+// found no outdated keys. Further passes catch keys written by instances still running an older
+// version during a rolling deployment.
+type cacheKeyMigrator struct {
+	mu       sync.Mutex
+	rules    []cacheKeyMigration
+	rule     int    // Index of the rule being run.
+	cursor   uint64 // SCAN cursor of the rule being run.
+	migrated int    // Keys rewritten or expired in the current pass.
+	done     bool
+}
+
+func newCacheKeyMigrator(rules []cacheKeyMigration) *cacheKeyMigrator {
+	return &cacheKeyMigrator{rules: rules, done: len(rules) == 0}
+}
+
+// cacheKeyMigrationJob returns the scheduler job that migrates outdated cache keys.
+func (cfg *apiConfig) cacheKeyMigrationJob() SchedulerJob {
+	migrator := newCacheKeyMigrator(cacheKeyMigrations)
+	return SchedulerJob{
+		Name:     cacheKeyMigrationJobName,
+		Interval: cacheKeyMigrationInterval,
+		Run: func(ctx context.Context) error {
+			return migrator.run(ctx, cfg)
+		},
+	}
+}
+
+// run examines up to cacheKeyMigrationBatch keys, continuing where the previous run stopped.
+// A page is only left behind once all of its keys were handled, so a failed run is retried from
+// the same page. It does nothing once the migration is complete or while the cache is bypassed.
+func (m *cacheKeyMigrator) run(ctx context.Context, cfg *apiConfig) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for examined := 0; !m.done && examined < cacheKeyMigrationBatch; {
+		rule := m.rules[m.rule]
+		keys, next, err := cfg.cache.Scan(ctx, m.cursor, rule.match, cacheKeyScanCount)
+		if errors.Is(err, errCacheUnavailable) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("could not scan cache keys for %s: %w", rule.name, err)
+		}
+		for _, key := range keys {
+			migrated, err := cfg.migrateCacheKey(ctx, rule, key)
+			if err != nil {
+				return err
+			}
+			if migrated {
+				m.migrated++
+			}
+		}
+		examined += len(keys)
+
+		m.cursor = next
+		if next != 0 {
+			continue
+		}
+		m.rule++
+		if m.rule < len(m.rules) {
+			continue
+		}
+		if m.migrated == 0 {
+			m.done = true
+			cfg.logger.Info("cache key migration complete")
+			return nil
+		}
+		cfg.logger.Info("cache key migration pass finished, starting another", "migrated", m.migrated)
+		m.rule, m.migrated = 0, 0
+	}
+	return nil
+}
+
+// migrateCacheKey applies a migration rule to one key and reports whether the key was outdated.
+// The value is copied to the new key with the remaining TTL of the old one, unless the new key
+// already holds data, which is then fresher. The old key is deleted in any case.
+func (cfg *apiConfig) migrateCacheKey(ctx context.Context, rule cacheKeyMigration, key string) (bool, error) {
+	newKey, ok, err := rule.rewrite(ctx, cfg, key)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	action := "expired"
+	if newKey != "" {
+		rewritten, err := cfg.copyCacheEntry(ctx, key, newKey)
+		if err != nil {
+			return false, err
+		}
+		if rewritten {
+			action = "rewritten"
+		}
+	}
+	if err := cfg.cache.Delete(ctx, key); err != nil {
+		return false, fmt.Errorf("could not delete cache key %q: %w", key, err)
+	}
+	cacheKeysMigrated.WithLabelValues(action).Inc()
+	cfg.logger.Debug("migrated cache key", "rule", rule.name, "key", key, "new_key", newKey, "action", action)
+	return true, nil
+}
+
+// copyCacheEntry copies the value of a key to another key that does not exist yet, keeping the
+// remaining TTL. It reports whether the value was copied.
+func (cfg *apiConfig) copyCacheEntry(ctx context.Context, from, to string) (bool, error) {
+	if _, err := cfg.cache.Get(ctx, to); err == nil {
+		return false, nil
+	} else if err != redis.Nil {
+		return false, fmt.Errorf("could not read cache key %q: %w", to, err)
+	}
+
+	raw, err := cfg.cache.Get(ctx, from)
+	if err == redis.Nil {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("could not read cache key %q: %w", from, err)
+	}
+	ttl, err := cfg.cache.TTL(ctx, from)
+	if err != nil {
+		return false, fmt.Errorf("could not read TTL of cache key %q: %w", from, err)
+	}
+	switch {
+	case ttl == -2: // Expired since it was read.
+		return false, nil
+	case ttl < 0: // No expiration.
+		ttl = 0
+	}
+	if !json.Valid([]byte(raw)) {
+		return false, nil
+	}
+	if err := cfg.cache.Set(ctx, to, json.RawMessage(raw), ttl); err != nil {
+		return false, fmt.Errorf("could not write cache key %q: %w", to, err)
+	}
+	return true, nil
+}
+
+// @Summary      Count cache keys
+// @Description  Counts the Redis keys of every cache key prefix. For prefixes of location data, outdated
+// @Description  counts the keys not yet moved to the current format by the cache key migration job.
+// @Description  The keys are enumerated with SCAN, which does not block Redis but takes a while on large caches.
+// @Tags         admin
+// @Produce      json
+// @Success      200  {object}  CacheKeysResponse
+// @Failure      500  {object}  ErrorResponse "Internal Server Error - Failed to scan cache keys"
+// @Failure      503  {object}  ErrorResponse "Service Unavailable - Cache is being bypassed"
+// @Security     ApiKeyAuth
+// @Failure      401  {object}  ErrorResponse "Unauthorized - Missing API key"
+// @Failure      403  {object}  ErrorResponse "Forbidden - Invalid API key"
+// @Router       /admin/cache/keys [get]
+func (cfg *apiConfig) handlerCacheKeys(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		cfg.respondWithError(w, http.StatusMethodNotAllowed, "Method Not Allowed", nil)
+		return
+	}
+
+	locationPrefixes := []string{currentWeatherCacheKeyPrefix, dailyForecastCacheKeyPrefix, hourlyForecastCacheKeyPrefix, airQualityCacheKeyPrefix, timezoneCacheKeyPrefix, warningsCacheKeyPrefix}
+	response := CacheKeysResponse{Prefixes: make([]CacheKeyPrefixJSON, 0, len(locationPrefixes)+1)}
+	for _, prefix := range append(locationPrefixes, gridCacheKeyPrefix) {
+		counts := CacheKeyPrefixJSON{Prefix: prefix}
+		seen := make(map[string]bool)
+		err := scanCacheKeys(r.Context(), cfg.cache, prefix+":*", func(keys []string) error {
+			for _, key := range keys {
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+				counts.Keys++
+				if prefix != gridCacheKeyPrefix && !cfg.isCurrentCacheKey(prefix, key) {
+					counts.Outdated++
+				}
+			}
+			return nil
+		})
+		if errors.Is(err, errCacheUnavailable) {
+			cfg.respondWithError(w, http.StatusServiceUnavailable, "Cache is temporarily unavailable", err)
+			return
+		}
+		if err != nil {
+			cfg.respondWithError(w, http.StatusInternalServerError, "Failed to scan cache keys", err)
+			return
+		}
+		response.Prefixes = append(response.Prefixes, counts)
+	}
+	cfg.respondWithJSON(w, http.StatusOK, response)
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cor0nius/willitrain/internal/database"
+	"github.com/cor0nius/willitrain/internal/testkit"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestScanCacheKeys(t *testing.T) {
+	cache := testkit.NewMemoryCache()
+	ctx := context.Background()
+	for i := 0; i < cacheKeyScanCount+5; i++ {
+		_ = cache.Set(ctx, locationCacheKey(currentWeatherCacheKeyPrefix, uuid.New()), i, 0)
+	}
+	_ = cache.Set(ctx, "grid:1:0:0", "tile", 0)
+
+	var pages, keys int
+	err := scanCacheKeys(ctx, cache, currentWeatherCacheKeyPrefix+":*", func(page []string) error {
+		pages++
+		keys += len(page)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pages != 2 || keys != cacheKeyScanCount+5 {
+		t.Errorf("scanned %d keys in %d pages, want %d keys in 2 pages", keys, pages, cacheKeyScanCount+5)
+	}
+
+	errStop := errors.New("stop")
+	if err := scanCacheKeys(ctx, cache, "*", func([]string) error { return errStop }); !errors.Is(err, errStop) {
+		t.Errorf("expected the callback error, got %v", err)
+	}
+}
+
+func TestCacheKeyMigrator(t *testing.T) {
+	ctx := context.Background()
+	wroclawID := uuid.New()
+	otherID := uuid.New()
+
+	testCfg := newTestAPIConfig(t)
+	cfg := testCfg.apiConfig
+	cache := testkit.NewMemoryCache()
+	cfg.cache = cache
+	testCfg.mockDB.GetLocationByAliasFunc = func(ctx context.Context, alias string) (database.Location, error) {
+		if alias == "wroclaw" {
+			return database.Location{ID: wroclawID, CityName: "Wrocław"}, nil
+		}
+		return database.Location{}, sql.ErrNoRows
+	}
+	testCfg.mockDB.GetLocationByNameFunc = func(ctx context.Context, cityName string) (database.Location, error) {
+		return database.Location{}, sql.ErrNoRows
+	}
+
+	_ = cache.Set(ctx, "currentweather:Wroclaw", []CurrentWeather{{SourceAPI: "Open-Meteo API"}}, 0)
+	_ = cache.Set(ctx, "dailyforecast:Atlantis", []DailyForecast{{SourceAPI: "Open-Meteo API"}}, 0)
+	_ = cache.Set(ctx, "timezone:Wroclaw", "Europe/Berlin", 0)
+	_ = cache.Set(ctx, locationCacheKey(timezoneCacheKeyPrefix, wroclawID), "Europe/Warsaw", 0)
+	_ = cache.Set(ctx, cfg.weatherCacheKey(hourlyForecastCacheKeyPrefix, otherID), []HourlyForecast{}, 0)
+	_ = cache.Set(ctx, locationCacheKey(dailyForecastCacheKeyPrefix, wroclawID), []DailyForecast{{SourceAPI: "Open-Meteo API"}}, 0)
+	_ = cache.Set(ctx, locationCacheKey(hourlyForecastCacheKeyPrefix, otherID)+":48", []HourlyForecast{}, 0)
+
+	migrator := newCacheKeyMigrator(cacheKeyMigrations)
+	for i := 0; i < 5 && !migrator.done; i++ {
+		if err := migrator.run(ctx, cfg); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if !migrator.done {
+		t.Fatal("expected the migration to complete")
+	}
+
+	outdated := []string{
+		"currentweather:Wroclaw",
+		"dailyforecast:Atlantis",
+		"timezone:Wroclaw",
+		locationCacheKey(dailyForecastCacheKeyPrefix, wroclawID),
+		locationCacheKey(hourlyForecastCacheKeyPrefix, otherID) + ":48",
+	}
+	for _, key := range outdated {
+		if _, err := cache.Get(ctx, key); err != redis.Nil {
+			t.Errorf("expected %s to be removed, got err %v", key, err)
+		}
+	}
+	raw, err := cache.Get(ctx, locationCacheKey(currentWeatherCacheKeyPrefix, wroclawID))
+	if err != nil {
+		t.Fatalf("expected the current weather to be rewritten: %v", err)
+	}
+	var weather []CurrentWeather
+	if err := json.Unmarshal([]byte(raw), &weather); err != nil || len(weather) != 1 || weather[0].SourceAPI != "Open-Meteo API" {
+		t.Errorf("unexpected rewritten value %s (err %v)", raw, err)
+	}
+	if tz, _ := cache.Get(ctx, locationCacheKey(timezoneCacheKeyPrefix, wroclawID)); tz != `"Europe/Warsaw"` {
+		t.Errorf("expected the existing timezone key to be kept, got %s", tz)
+	}
+	if _, err := cache.Get(ctx, cfg.weatherCacheKey(hourlyForecastCacheKeyPrefix, otherID)); err != nil {
+		t.Errorf("expected the current-format key to be kept, got err %v", err)
+	}
+	if _, err := cache.Get(ctx, locationCacheKey(dailyForecastCacheKeyPrefix, wroclawID)+":5"); err != nil {
+		t.Errorf("expected the daily forecast to be moved to the default horizon key, got err %v", err)
+	}
+
+	// A completed migration no longer scans the cache.
+	cache.ScanFunc = func(ctx context.Context, cursor uint64, match string, count int64) ([]string, uint64, error) {
+		t.Fatal("unexpected scan after completion")
+		return nil, 0, nil
+	}
+	if err := migrator.run(ctx, cfg); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestCacheKeyMigrator_Errors(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("cache bypassed", func(t *testing.T) {
+		cfg := newTestAPIConfig(t).apiConfig
+		cfg.cache = &testkit.Cache{ScanFunc: func(ctx context.Context, cursor uint64, match string, count int64) ([]string, uint64, error) {
+			return nil, 0, errCacheUnavailable
+		}}
+		migrator := newCacheKeyMigrator(cacheKeyMigrations)
+		if err := migrator.run(ctx, cfg); err != nil || migrator.done {
+			t.Errorf("expected the run to be skipped, got err %v, done %v", err, migrator.done)
+		}
+	})
+
+	t.Run("database error keeps the page", func(t *testing.T) {
+		testCfg := newTestAPIConfig(t)
+		cfg := testCfg.apiConfig
+		cache := testkit.NewMemoryCache()
+		cfg.cache = cache
+		_ = cache.Set(ctx, "currentweather:Wroclaw", []CurrentWeather{}, time.Minute)
+		testCfg.mockDB.GetLocationByAliasFunc = func(ctx context.Context, alias string) (database.Location, error) {
+			return database.Location{}, errors.New("db down")
+		}
+
+		migrator := newCacheKeyMigrator(cacheKeyMigrations)
+		if err := migrator.run(ctx, cfg); err == nil {
+			t.Fatal("expected an error")
+		}
+		if migrator.rule != 0 || migrator.cursor != 0 {
+			t.Errorf("expected the failed page to be retried, at rule %d cursor %d", migrator.rule, migrator.cursor)
+		}
+		if _, err := cache.Get(ctx, "currentweather:Wroclaw"); err != nil {
+			t.Errorf("expected the key to be kept, got err %v", err)
+		}
+	})
+}
+
+func TestHandlerCacheKeys(t *testing.T) {
+	ctx := context.Background()
+	testCases := []struct {
+		name       string
+		method     string
+		setup      func(cache *testkit.Cache)
+		wantStatus int
+		want       map[string][2]int
+	}{
+		{name: "method not allowed", method: http.MethodPost, wantStatus: http.StatusMethodNotAllowed},
+		{
+			name:   "counts keys",
+			method: http.MethodGet,
+			setup: func(cache *testkit.Cache) {
+				_ = cache.Set(ctx, locationCacheKey(currentWeatherCacheKeyPrefix, uuid.New()), 1, 0)
+				_ = cache.Set(ctx, locationCacheKey(currentWeatherCacheKeyPrefix, uuid.New()), 1, 0)
+				_ = cache.Set(ctx, "currentweather:Wroclaw", 1, 0)
+				_ = cache.Set(ctx, "grid:0.5:1:2", 1, 0)
+				_ = cache.Set(ctx, locationCacheKey(dailyForecastCacheKeyPrefix, uuid.New())+":5", 1, 0)
+				_ = cache.Set(ctx, locationCacheKey(dailyForecastCacheKeyPrefix, uuid.New()), 1, 0)
+			},
+			wantStatus: http.StatusOK,
+			want: map[string][2]int{
+				currentWeatherCacheKeyPrefix: {3, 1},
+				dailyForecastCacheKeyPrefix:  {2, 1},
+				hourlyForecastCacheKeyPrefix: {0, 0},
+				airQualityCacheKeyPrefix:     {0, 0},
+				timezoneCacheKeyPrefix:       {0, 0},
+				warningsCacheKeyPrefix:       {0, 0},
+				gridCacheKeyPrefix:           {1, 0},
+			},
+		},
+		{
+			name:   "cache unavailable",
+			method: http.MethodGet,
+			setup: func(cache *testkit.Cache) {
+				cache.ScanFunc = func(ctx context.Context, cursor uint64, match string, count int64) ([]string, uint64, error) {
+					return nil, 0, errCacheUnavailable
+				}
+			},
+			wantStatus: http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := newTestAPIConfig(t).apiConfig
+			cache := testkit.NewMemoryCache()
+			cfg.cache = cache
+			if tc.setup != nil {
+				tc.setup(cache)
+			}
+
+			req := httptest.NewRequest(tc.method, "/admin/cache/keys", nil)
+			rr := httptest.NewRecorder()
+			cfg.handlerCacheKeys(rr, req)
+
+			if rr.Code != tc.wantStatus {
+				t.Fatalf("status = %d, want %d; body: %s", rr.Code, tc.wantStatus, rr.Body.String())
+			}
+			if tc.wantStatus != http.StatusOK {
+				return
+			}
+			var response CacheKeysResponse
+			if err := json.NewDecoder(strings.NewReader(rr.Body.String())).Decode(&response); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if len(response.Prefixes) != len(tc.want) {
+				t.Fatalf("got %d prefixes, want %d", len(response.Prefixes), len(tc.want))
+			}
+			for _, p := range response.Prefixes {
+				if got := [2]int{p.Keys, p.Outdated}; got != tc.want[p.Prefix] {
+					t.Errorf("%s: keys, outdated = %v, want %v", p.Prefix, got, tc.want[p.Prefix])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// This file implements the cache purge endpoint. A poisoned weather cache entry used to be
+// cleared only by flushing the whole cache, which also drops the geocoding, timezone and grid
+// entries. The purge endpoint deletes the weather entries of one location, or of all locations,
+// and leaves the rest of the cache alone. The next request for a purged location reads the
+// database, or the providers if the stored data is outdated too.
+
+// purgeableCachePrefixes are the cache key prefixes the purge endpoint deletes, in the order
+// they are reported.
+var purgeableCachePrefixes = []string{currentWeatherCacheKeyPrefix, dailyForecastCacheKeyPrefix, hourlyForecastCacheKeyPrefix}
+
+// errInvalidCacheType is returned for a type query parameter naming an unknown prefix.
+var errInvalidCacheType = errors.New("type must be a comma-separated list of currentweather, dailyforecast and hourlyforecast")
+
+// purgeCachePrefixes returns the prefixes selected by the type query parameter, or all
+// purgeable prefixes if it is not set.
+func purgeCachePrefixes(r *http.Request) ([]string, error) {
+	if !r.URL.Query().Has("type") {
+		return purgeableCachePrefixes, nil
+	}
+	var prefixes []string
+	for _, prefix := range strings.Split(r.URL.Query().Get("type"), ",") {
+		prefix = strings.TrimSpace(prefix)
+		if !slices.Contains(purgeableCachePrefixes, prefix) {
+			return nil, errInvalidCacheType
+		}
+		if !slices.Contains(prefixes, prefix) {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	return prefixes, nil
+}
+
+// purgeCacheKeys deletes the keys matching a glob pattern and returns how many it deleted.
+// Keys of every horizon are matched, as the pattern ends in a wildcard.
+func (cfg *apiConfig) purgeCacheKeys(ctx context.Context, match string) (int, error) {
+	deleted := make(map[string]bool)
+	err := scanCacheKeys(ctx, cfg.cache, match, func(keys []string) error {
+		var batch []string
+		for _, key := range keys {
+			if !deleted[key] {
+				deleted[key] = true
+				batch = append(batch, key)
+			}
+		}
+		if len(batch) == 0 {
+			return nil
+		}
+		return cfg.cache.Delete(ctx, batch...)
+	})
+	return len(deleted), err
+}
+
+// @Summary      Purge weather cache entries
+// @Description  Deletes the cached current weather, daily and hourly forecasts of one location, or of all
+// @Description  locations if no location_id is given. Other cache entries, such as geocoding results, are kept.
+// @Description  Entries of every forecast horizon are deleted. Use type to purge only some forecast types.
+// @Tags         admin
+// @Produce      json
+// @Param        location_id  query     string  false  "Location ID (UUID). All locations if omitted."
+// @Param        type         query     string  false  "Comma-separated cache types: currentweather, dailyforecast, hourlyforecast. All if omitted."
+// @Success      200  {object}  CachePurgeResponse
+// @Failure      400  {object}  ErrorResponse "Bad Request - Invalid location ID or cache type"
+// @Failure      405  {object}  ErrorResponse "Method Not Allowed"
+// @Failure      500  {object}  ErrorResponse "Internal Server Error - Failed to purge cache entries"
+// @Failure      503  {object}  ErrorResponse "Service Unavailable - Cache is being bypassed"
+// @Security     ApiKeyAuth
+// @Failure      401  {object}  ErrorResponse "Unauthorized - Missing API key"
+// @Failure      403  {object}  ErrorResponse "Forbidden - Invalid API key"
+// @Router       /admin/cache/purge [post]
+func (cfg *apiConfig) handlerCachePurge(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		cfg.respondWithError(w, http.StatusMethodNotAllowed, "Method Not Allowed", nil)
+		return
+	}
+
+	prefixes, err := purgeCachePrefixes(r)
+	if err != nil {
+		cfg.respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	response := CachePurgeResponse{Prefixes: make([]CachePurgePrefixJSON, 0, len(prefixes))}
+	locationPattern := "*"
+	if idStr := r.URL.Query().Get("location_id"); idStr != "" {
+		locationID, err := uuid.Parse(idStr)
+		if err != nil {
+			cfg.respondWithError(w, http.StatusBadRequest, "Invalid location ID", err)
+			return
+		}
+		response.LocationID = locationID.String()
+		locationPattern = locationID.String() + "*"
+	}
+
+	ctx := r.Context()
+	for _, prefix := range prefixes {
+		deleted, err := cfg.purgeCacheKeys(ctx, prefix+":"+locationPattern)
+		if errors.Is(err, errCacheUnavailable) {
+			cfg.respondWithError(w, http.StatusServiceUnavailable, "Cache is temporarily unavailable", err)
+			return
+		}
+		if err != nil {
+			cfg.respondWithError(w, http.StatusInternalServerError, "Failed to purge cache entries", err)
+			return
+		}
+		response.Prefixes = append(response.Prefixes, CachePurgePrefixJSON{Prefix: prefix, Deleted: deleted})
+	}
+	cfg.logger.InfoContext(ctx, "cache entries purged", "location_id", response.LocationID, "prefixes", prefixes)
+	cfg.respondWithJSON(w, http.StatusOK, response)
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/cor0nius/willitrain/internal/testkit"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestHandlerCachePurge(t *testing.T) {
+	ctx := context.Background()
+	purged := uuid.New()
+	other := uuid.New()
+
+	allKeys := []string{
+		locationCacheKey(currentWeatherCacheKeyPrefix, purged),
+		locationCacheKey(dailyForecastCacheKeyPrefix, purged) + ":5",
+		locationCacheKey(dailyForecastCacheKeyPrefix, purged) + ":10",
+		locationCacheKey(hourlyForecastCacheKeyPrefix, purged) + ":24",
+		locationCacheKey(currentWeatherCacheKeyPrefix, other),
+		locationCacheKey(hourlyForecastCacheKeyPrefix, other) + ":24",
+		locationCacheKey(timezoneCacheKeyPrefix, purged),
+		"grid:0.5:1:2",
+	}
+
+	testCases := []struct {
+		name       string
+		method     string
+		query      string
+		scanErr    error
+		wantStatus int
+		wantBody   string
+		wantKept   []string
+	}{
+		{
+			name:       "one location",
+			method:     http.MethodPost,
+			query:      "?location_id=" + purged.String(),
+			wantStatus: http.StatusOK,
+			wantBody:   `{"location_id":"` + purged.String() + `","prefixes":[{"prefix":"currentweather","deleted":1},{"prefix":"dailyforecast","deleted":2},{"prefix":"hourlyforecast","deleted":1}]}`,
+			wantKept:   allKeys[4:],
+		},
+		{
+			name:       "one type of all locations",
+			method:     http.MethodPost,
+			query:      "?type=currentweather",
+			wantStatus: http.StatusOK,
+			wantBody:   `{"prefixes":[{"prefix":"currentweather","deleted":2}]}`,
+			wantKept:   []string{allKeys[1], allKeys[2], allKeys[3], allKeys[5], allKeys[6], allKeys[7]},
+		},
+		{
+			name:       "all locations",
+			method:     http.MethodPost,
+			wantStatus: http.StatusOK,
+			wantBody:   `{"prefixes":[{"prefix":"currentweather","deleted":2},{"prefix":"dailyforecast","deleted":2},{"prefix":"hourlyforecast","deleted":2}]}`,
+			wantKept:   allKeys[6:],
+		},
+		{
+			name:       "invalid location ID",
+			method:     http.MethodPost,
+			query:      "?location_id=Wroclaw",
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"Invalid location ID"}`,
+			wantKept:   allKeys,
+		},
+		{
+			name:       "invalid type",
+			method:     http.MethodPost,
+			query:      "?type=timezone",
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"type must be a comma-separated list of currentweather, dailyforecast and hourlyforecast"}`,
+			wantKept:   allKeys,
+		},
+		{
+			name:       "cache unavailable",
+			method:     http.MethodPost,
+			scanErr:    errCacheUnavailable,
+			wantStatus: http.StatusServiceUnavailable,
+			wantBody:   `{"error":"Cache is temporarily unavailable"}`,
+			wantKept:   allKeys,
+		},
+		{
+			name:       "method not allowed",
+			method:     http.MethodGet,
+			wantStatus: http.StatusMethodNotAllowed,
+			wantBody:   `{"error":"Method Not Allowed"}`,
+			wantKept:   allKeys,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := newTestAPIConfig(t).apiConfig
+			cache := testkit.NewMemoryCache()
+			cfg.cache = cache
+			for _, key := range allKeys {
+				_ = cache.Set(ctx, key, 1, 0)
+			}
+			if tc.scanErr != nil {
+				cache.ScanFunc = func(ctx context.Context, cursor uint64, match string, count int64) ([]string, uint64, error) {
+					return nil, 0, tc.scanErr
+				}
+			}
+
+			req := httptest.NewRequest(tc.method, "/admin/cache/purge"+tc.query, nil)
+			rr := httptest.NewRecorder()
+			cfg.handlerCachePurge(rr, req)
+
+			if rr.Code != tc.wantStatus {
+				t.Errorf("status = %d, want %d", rr.Code, tc.wantStatus)
+			}
+			if rr.Body.String() != tc.wantBody {
+				t.Errorf("body = %s, want %s", rr.Body.String(), tc.wantBody)
+			}
+			for _, key := range allKeys {
+				_, err := cache.Get(ctx, key)
+				if kept := err != redis.Nil; kept != slices.Contains(tc.wantKept, key) {
+					t.Errorf("key %s kept = %v", key, kept)
+				}
+			}
+		})
+	}
+}
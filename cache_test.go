@@ -153,6 +153,75 @@ func TestRedisCache_Flush_Error(t *testing.T) {
 	assert.NoError(t, redisMock.ExpectationsWereMet())
 }
 
+func TestRedisCache_Delete(t *testing.T) {
+	ctx := context.Background()
+	redisClient, redisMock := redismock.NewClientMock()
+	defer redisClient.Close()
+
+	cache := NewRedisCache(redisClient)
+
+	redisMock.ExpectDel("key1", "key2").SetVal(2)
+
+	err := cache.Delete(ctx, "key1", "key2")
+
+	require.NoError(t, err)
+	assert.NoError(t, redisMock.ExpectationsWereMet())
+}
+
+func TestRedisCache_Scan(t *testing.T) {
+	ctx := context.Background()
+	redisClient, redisMock := redismock.NewClientMock()
+	defer redisClient.Close()
+
+	cache := NewRedisCache(redisClient)
+
+	redisMock.ExpectScan(0, "currentweather:*", 200).SetVal([]string{"currentweather:a"}, 42)
+
+	keys, next, err := cache.Scan(ctx, 0, "currentweather:*", 200)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"currentweather:a"}, keys)
+	assert.Equal(t, uint64(42), next)
+	assert.NoError(t, redisMock.ExpectationsWereMet())
+}
+
+func TestRedisCache_TTL(t *testing.T) {
+	ctx := context.Background()
+	redisClient, redisMock := redismock.NewClientMock()
+	defer redisClient.Close()
+
+	cache := NewRedisCache(redisClient)
+
+	redisMock.ExpectTTL("key1").SetVal(5 * time.Minute)
+
+	ttl, err := cache.TTL(ctx, "key1")
+
+	require.NoError(t, err)
+	assert.Equal(t, 5*time.Minute, ttl)
+	assert.NoError(t, redisMock.ExpectationsWereMet())
+}
+
+func TestRedisCache_Incr(t *testing.T) {
+	ctx := context.Background()
+	redisClient, redisMock := redismock.NewClientMock()
+	defer redisClient.Close()
+
+	cache := NewRedisCache(redisClient)
+
+	// The expiration is only set when the counter is created.
+	redisMock.ExpectIncr("counter").SetVal(1)
+	redisMock.ExpectExpire("counter", time.Hour).SetVal(true)
+	redisMock.ExpectIncr("counter").SetVal(2)
+
+	n, err := cache.Incr(ctx, "counter", time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	n, err = cache.Incr(ctx, "counter", time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+	assert.NoError(t, redisMock.ExpectationsWereMet())
+}
+
 func TestConnectCache(t *testing.T) {
 	testCases := []struct {
 		name        string
@@ -288,4 +357,49 @@ func TestConnectDB(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+func TestRedisCache_HealthTracking(t *testing.T) {
+	ctx := context.Background()
+	redisClient, redisMock := redismock.NewClientMock()
+	defer redisClient.Close()
+
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	cache := NewRedisCache(redisClient)
+	cache.now = func() time.Time { return now }
+
+	// A missing key is not a failure.
+	redisMock.ExpectGet("key").RedisNil()
+	_, err := cache.Get(ctx, "key")
+	assert.ErrorIs(t, err, redis.Nil)
+
+	for i := 0; i < cacheFailureThreshold; i++ {
+		redisMock.ExpectGet("key").SetErr(errors.New("connection refused"))
+		_, err := cache.Get(ctx, "key")
+		assert.EqualError(t, err, "connection refused")
+	}
+
+	// The cache is now bypassed without contacting Redis.
+	_, err = cache.Get(ctx, "key")
+	assert.ErrorIs(t, err, errCacheUnavailable)
+	assert.ErrorIs(t, cache.Set(ctx, "key", "value", time.Minute), errCacheUnavailable)
+
+	// After the cool-down a failed probe extends the bypass.
+	now = now.Add(cacheCooldown)
+	redisMock.ExpectGet("key").SetErr(errors.New("connection refused"))
+	_, err = cache.Get(ctx, "key")
+	assert.EqualError(t, err, "connection refused")
+	_, err = cache.Get(ctx, "key")
+	assert.ErrorIs(t, err, errCacheUnavailable)
+
+	// A successful probe restores the cache.
+	now = now.Add(cacheCooldown)
+	redisMock.ExpectGet("key").SetVal("value")
+	val, err := cache.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.Equal(t, "value", val)
+
+	redisMock.ExpectGet("key").SetVal("value")
+	_, err = cache.Get(ctx, "key")
+	assert.NoError(t, err)
+	assert.NoError(t, redisMock.ExpectationsWereMet())
+}
@@ -0,0 +1,275 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// This file implements a circuit breaker for every weather provider. A provider that fails
+// CIRCUIT_BREAKER_THRESHOLD fetches in a row with a timeout, a network error, a 5xx or a 429
+// response is considered down: its circuit opens and it is not called for
+// CIRCUIT_BREAKER_COOLDOWN_SEC. Responses are then served from the stored data of the other
+// providers, or from stale data if no provider answers. After the cooldown a single trial fetch
+// is let through (half-open); its success closes the circuit and its failure opens it for
+// another cooldown. Other errors, such as a 4xx response or an unparsable body, show that the
+// provider is reachable and do not count as failures.
+
+const (
+	defaultCircuitBreakerThreshold   = 5
+	defaultCircuitBreakerCooldownSec = 60
+)
+
+// Circuit states, as reported by /api/health/providers. The willitrain_circuit_breaker_state
+// metric reports them as 0, 1 and 2.
+const (
+	circuitClosed   = "closed"
+	circuitOpen     = "open"
+	circuitHalfOpen = "half_open"
+)
+
+// errCircuitOpen is returned for a fetch from a provider whose circuit is open.
+var errCircuitOpen = errors.New("provider circuit open")
+
+// getCircuitBreakerThreshold reads the number of consecutive failures that open a provider's
+// circuit from CIRCUIT_BREAKER_THRESHOLD. A value of 0 disables the circuit breaker; negative
+// values are ignored.
+func getCircuitBreakerThreshold(logger *slog.Logger) int {
+	n := getEnvAsInt("CIRCUIT_BREAKER_THRESHOLD", defaultCircuitBreakerThreshold, logger)
+	if n < 0 {
+		logger.Warn("CIRCUIT_BREAKER_THRESHOLD must not be negative, using default", "value", n)
+		return defaultCircuitBreakerThreshold
+	}
+	return n
+}
+
+// getCircuitBreakerCooldown reads how long an open circuit stays open from
+// CIRCUIT_BREAKER_COOLDOWN_SEC. Values below 1 are ignored.
+func getCircuitBreakerCooldown(logger *slog.Logger) time.Duration {
+	sec := getEnvAsInt("CIRCUIT_BREAKER_COOLDOWN_SEC", defaultCircuitBreakerCooldownSec, logger)
+	if sec < 1 {
+		logger.Warn("CIRCUIT_BREAKER_COOLDOWN_SEC must be at least 1, using default", "value", sec)
+		sec = defaultCircuitBreakerCooldownSec
+	}
+	return time.Duration(sec) * time.Second
+}
+
+// providerCircuitBreakers holds the circuit of every provider. A nil value, or one with a zero
+// threshold, is valid and never opens a circuit.
+type providerCircuitBreakers struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	circuits  map[string]*circuit
+	logger    *slog.Logger
+	now       func() time.Time
+	// notifier is told when a circuit opens.
+	notifier *opsNotifier
+}
+
+// circuit is the state of one provider's circuit.
+type circuit struct {
+	failures int
+	openedAt time.Time // Zero while the circuit is closed.
+	probing  bool      // A half-open trial fetch is in flight.
+}
+
+func newProviderCircuitBreakers(threshold int, cooldown time.Duration, logger *slog.Logger) *providerCircuitBreakers {
+	b := &providerCircuitBreakers{
+		threshold: threshold,
+		cooldown:  cooldown,
+		circuits:  make(map[string]*circuit),
+		logger:    logger,
+		now:       time.Now,
+	}
+	for _, p := range weatherProviders {
+		circuitBreakerState.WithLabelValues(p.ID).Set(0)
+	}
+	return b
+}
+
+// enabled reports whether circuits can open.
+func (b *providerCircuitBreakers) enabled() bool {
+	return b != nil && b.threshold > 0
+}
+
+// circuit returns the circuit of a provider, creating a closed one. The caller must hold b.mu.
+func (b *providerCircuitBreakers) circuit(providerID string) *circuit {
+	c, ok := b.circuits[providerID]
+	if !ok {
+		c = &circuit{}
+		b.circuits[providerID] = c
+	}
+	return c
+}
+
+// stateOf returns the state of a circuit. The caller must hold b.mu.
+func (b *providerCircuitBreakers) stateOf(c *circuit) string {
+	switch {
+	case c.openedAt.IsZero():
+		return circuitClosed
+	case b.now().Sub(c.openedAt) < b.cooldown:
+		return circuitOpen
+	}
+	return circuitHalfOpen
+}
+
+// allow reports whether a provider may be called. While the circuit is half-open, only the
+// first caller is allowed, and its outcome must be passed to record.
+func (b *providerCircuitBreakers) allow(providerID string) bool {
+	if !b.enabled() {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c := b.circuit(providerID)
+	switch b.stateOf(c) {
+	case circuitClosed:
+		return true
+	case circuitHalfOpen:
+		if c.probing {
+			return false
+		}
+		c.probing = true
+		circuitBreakerState.WithLabelValues(providerID).Set(2)
+		return true
+	}
+	return false
+}
+
+// record updates a provider's circuit with the outcome of a fetch. A fetch that was not sent,
+// because it was rate-limited or its circuit was open, or that was cancelled by its caller,
+// only ends a half-open trial.
+func (b *providerCircuitBreakers) record(providerID string, err error) {
+	if !b.enabled() {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c := b.circuit(providerID)
+	probing := c.probing
+	c.probing = false
+	if errors.Is(err, errRateLimited) || errors.Is(err, errCircuitOpen) || errors.Is(err, context.Canceled) {
+		return
+	}
+
+	if !isProviderOutage(err) {
+		if !c.openedAt.IsZero() {
+			b.logger.Info("provider recovered, closing circuit", "provider", providerID)
+		}
+		c.failures = 0
+		c.openedAt = time.Time{}
+		circuitBreakerState.WithLabelValues(providerID).Set(0)
+		return
+	}
+
+	c.failures++
+	if probing || (c.openedAt.IsZero() && c.failures >= b.threshold) {
+		if c.openedAt.IsZero() {
+			b.logger.Warn("provider failing, opening circuit", "provider", providerID, "failures", c.failures, "cooldown", b.cooldown.String())
+			b.notifier.circuitOpened(providerID, c.failures, b.cooldown)
+		}
+		c.openedAt = b.now()
+		circuitBreakerState.WithLabelValues(providerID).Set(1)
+		circuitBreakerOpened.WithLabelValues(providerID).Inc()
+	}
+}
+
+// isOpen reports whether a provider's circuit is open and its cooldown has not passed.
+func (b *providerCircuitBreakers) isOpen(providerID string) bool {
+	if !b.enabled() {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.stateOf(b.circuit(providerID)) == circuitOpen
+}
+
+// health returns the state of a provider's circuit, its consecutive failures, and when it was
+// opened, which is zero for a closed circuit.
+func (b *providerCircuitBreakers) health(providerID string) (string, int, time.Time) {
+	if !b.enabled() {
+		return circuitClosed, 0, time.Time{}
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c := b.circuit(providerID)
+	return b.stateOf(c), c.failures, c.openedAt
+}
+
+// isProviderOutage reports whether a fetch error suggests that the provider is down or
+// overloaded, as opposed to rejecting or garbling this particular request.
+func isProviderOutage(err error) bool {
+	switch fetchErrorClass(err) {
+	case errorClassServerError, errorClassRateLimited, errorClassTimeout, errorClassNetwork:
+		return true
+	}
+	return false
+}
+
+// allCircuitsOpen reports whether the circuit of every enabled provider is open, in which case
+// the scheduler leaves the stored data of its locations untouched.
+func (cfg *apiConfig) allCircuitsOpen() bool {
+	if !cfg.breakers.enabled() {
+		return false
+	}
+	enabled := 0
+	for _, p := range weatherProviders {
+		if !cfg.sourceEnabled(p.ID) {
+			continue
+		}
+		enabled++
+		if !cfg.breakers.isOpen(p.ID) {
+			return false
+		}
+	}
+	return enabled > 0
+}
+
+// @Summary      Get provider health
+// @Description  Reports the circuit breaker state of every enabled weather provider. A provider whose fetches
+// @Description  keep failing is not called until its cooldown has passed (open), after which a single trial
+// @Description  fetch decides whether it is called again (half_open). The status is "ok" while all circuits are
+// @Description  closed, "degraded" while some are not, and "down", with status 503, while all are open.
+// @Tags         health
+// @Produce      json
+// @Success      200  {object}  ProviderHealthResponse
+// @Failure      503  {object}  ProviderHealthResponse "Service Unavailable - All providers are down"
+// @Router       /api/v1/health/providers [get]
+func (cfg *apiConfig) handlerProviderHealth(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		cfg.respondWithError(w, http.StatusMethodNotAllowed, "Method Not Allowed", nil)
+		return
+	}
+
+	response := ProviderHealthResponse{Status: "ok", Providers: []ProviderHealthJSON{}}
+	open := 0
+	for _, p := range weatherProviders {
+		if !cfg.sourceEnabled(p.ID) {
+			continue
+		}
+		state, failures, openedAt := cfg.breakers.health(p.ID)
+		provider := ProviderHealthJSON{Provider: p.ID, State: state, ConsecutiveFailures: failures}
+		if !openedAt.IsZero() {
+			provider.OpenedAt = openedAt.UTC().Format(time.RFC3339)
+			provider.RetryAt = openedAt.Add(cfg.breakers.cooldown).UTC().Format(time.RFC3339)
+		}
+		if state != circuitClosed {
+			response.Status = "degraded"
+		}
+		if state == circuitOpen {
+			open++
+		}
+		response.Providers = append(response.Providers, provider)
+	}
+
+	status := http.StatusOK
+	if open > 0 && open == len(response.Providers) {
+		response.Status = "down"
+		status = http.StatusServiceUnavailable
+	}
+	cfg.respondWithJSON(w, status, response)
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/cor0nius/willitrain/internal/database"
+	"github.com/google/uuid"
+)
+
+// newTestCircuitBreakers returns circuit breakers with a fixed clock.
+func newTestCircuitBreakers(threshold int, cooldown time.Duration, now *time.Time) *providerCircuitBreakers {
+	b := newProviderCircuitBreakers(threshold, cooldown, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	b.now = func() time.Time { return *now }
+	return b
+}
+
+func TestProviderCircuitBreakers(t *testing.T) {
+	errServer := &fetchStatusError{Status: "503 Service Unavailable", StatusCode: http.StatusServiceUnavailable}
+	errNotFound := &fetchStatusError{Status: "404 Not Found", StatusCode: http.StatusNotFound}
+
+	t.Run("nil breakers allow everything", func(t *testing.T) {
+		var b *providerCircuitBreakers
+		b.record("owm", errServer)
+		if b.enabled() || !b.allow("owm") || b.isOpen("owm") {
+			t.Error("expected nil breakers to be disabled and allow all fetches")
+		}
+	})
+
+	t.Run("zero threshold never opens", func(t *testing.T) {
+		now := time.Now()
+		b := newTestCircuitBreakers(0, time.Minute, &now)
+		for range 10 {
+			b.record("owm", errServer)
+		}
+		if !b.allow("owm") {
+			t.Error("expected a zero threshold to disable the circuit breaker")
+		}
+	})
+
+	t.Run("opens, probes and closes", func(t *testing.T) {
+		now := time.Date(2025, 8, 4, 12, 0, 0, 0, time.UTC)
+		b := newTestCircuitBreakers(3, time.Minute, &now)
+
+		b.record("owm", errServer)
+		b.record("owm", errServer)
+		b.record("owm", errNotFound)
+		b.record("owm", errServer)
+		b.record("owm", errServer)
+		if !b.allow("owm") {
+			t.Fatal("expected a client error to reset the failure count")
+		}
+		b.record("owm", errServer)
+		if b.allow("owm") || !b.isOpen("owm") {
+			t.Fatal("expected the circuit to open after 3 consecutive failures")
+		}
+		if !b.allow("ometeo") {
+			t.Error("expected other providers to stay allowed")
+		}
+
+		now = now.Add(time.Minute)
+		if !b.allow("owm") {
+			t.Fatal("expected a trial fetch after the cooldown")
+		}
+		if b.allow("owm") {
+			t.Error("expected only one trial fetch while half-open")
+		}
+		b.record("owm", errServer)
+		if b.allow("owm") {
+			t.Fatal("expected a failed trial fetch to reopen the circuit")
+		}
+
+		now = now.Add(time.Minute)
+		if !b.allow("owm") {
+			t.Fatal("expected another trial fetch after the cooldown")
+		}
+		b.record("owm", errRateLimited)
+		if !b.allow("owm") {
+			t.Fatal("expected a trial fetch that was not sent to allow another one")
+		}
+		b.record("owm", fmt.Errorf("request aborted: %w", context.Canceled))
+		if !b.allow("owm") {
+			t.Fatal("expected a cancelled trial fetch to allow another one")
+		}
+		b.record("owm", nil)
+		if state, failures, openedAt := b.health("owm"); state != circuitClosed || failures != 0 || !openedAt.IsZero() {
+			t.Errorf("expected a successful trial fetch to close the circuit, got %s, %d, %v", state, failures, openedAt)
+		}
+	})
+}
+
+func TestIsProviderOutage(t *testing.T) {
+	testCases := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"success", nil, false},
+		{"server error", &fetchStatusError{Status: "500 Internal Server Error", StatusCode: 500}, true},
+		{"too many requests", &fetchStatusError{Status: "429 Too Many Requests", StatusCode: 429}, true},
+		{"unauthorized", &fetchStatusError{Status: "401 Unauthorized", StatusCode: 401}, false},
+		{"timeout", context.DeadlineExceeded, true},
+		{"network", &url.Error{Op: "Get", URL: "http://example.com", Err: errors.New("connection refused")}, true},
+		{"invalid response", errors.New("empty or invalid response from API"), false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := isProviderOutage(tc.err); got != tc.want {
+				t.Errorf("isProviderOutage(%v) = %v, want %v", tc.err, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestProcessForecastRequests_CircuitBreaker(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	urls := map[string]string{"ometeoWrappedURL": server.URL}
+	providers := map[string]forecastProvider[CurrentWeather]{
+		"ometeoWrappedURL": {parser: ParseCurrentWeatherOMeteo, errorVal: CurrentWeather{SourceAPI: "Open-Meteo API"}},
+	}
+
+	now := time.Now()
+	cfg := newTestAPIConfig(t).apiConfig
+	cfg.enabledSources = map[string]bool{"ometeo": true}
+	cfg.breakers = newTestCircuitBreakers(2, time.Minute, &now)
+
+	for range 3 {
+		if _, _, err := processForecastRequests(cfg, context.Background(), MockLocation, urls, providers, nil, nil); err == nil {
+			t.Fatal("expected an error while the provider fails, got nil")
+		}
+	}
+	if requests != 2 {
+		t.Errorf("expected the provider not to be called once its circuit opened, got %d requests", requests)
+	}
+	if !cfg.allCircuitsOpen() {
+		t.Error("expected all circuits to be reported open")
+	}
+}
+
+func TestRunCurrentWeatherJobs_AllCircuitsOpen(t *testing.T) {
+	testCfg := newTestAPIConfig(t)
+	cfg := testCfg.apiConfig
+	cfg.enabledSources = map[string]bool{"ometeo": true}
+	now := time.Now()
+	cfg.breakers = newTestCircuitBreakers(1, time.Minute, &now)
+	cfg.breakers.record("ometeo", context.DeadlineExceeded)
+	testCfg.mockDB.ListLocationsFunc = func(ctx context.Context) ([]database.Location, error) {
+		return []database.Location{{ID: uuid.New(), CityName: "Quiet Town"}}, nil
+	}
+
+	if err := NewScheduler(cfg).runCurrentWeatherJobs(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := testCfg.mockDB.Calls("DeleteCurrentWeatherAtLocation"); got != 0 {
+		t.Errorf("expected the stored current weather to be kept, got %d deletions", got)
+	}
+}
+
+func TestHandlerProviderHealth(t *testing.T) {
+	testCases := []struct {
+		name       string
+		method     string
+		failing    []string
+		wantStatus int
+		wantHealth string
+		wantStates map[string]string
+	}{
+		{
+			name:       "All Closed",
+			wantStatus: http.StatusOK,
+			wantHealth: "ok",
+			wantStates: map[string]string{"owm": circuitClosed, "ometeo": circuitClosed},
+		},
+		{
+			name:       "One Open",
+			failing:    []string{"owm"},
+			wantStatus: http.StatusOK,
+			wantHealth: "degraded",
+			wantStates: map[string]string{"owm": circuitOpen, "ometeo": circuitClosed},
+		},
+		{
+			name:       "All Open",
+			failing:    []string{"owm", "ometeo"},
+			wantStatus: http.StatusServiceUnavailable,
+			wantHealth: "down",
+			wantStates: map[string]string{"owm": circuitOpen, "ometeo": circuitOpen},
+		},
+		{
+			name:       "Wrong Method",
+			method:     http.MethodPost,
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			now := time.Date(2025, 8, 4, 12, 0, 0, 0, time.UTC)
+			cfg := newTestAPIConfig(t).apiConfig
+			cfg.enabledSources = map[string]bool{"owm": true, "ometeo": true}
+			cfg.breakers = newTestCircuitBreakers(1, time.Minute, &now)
+			for _, id := range tc.failing {
+				cfg.breakers.record(id, context.DeadlineExceeded)
+			}
+
+			method := tc.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			req := httptest.NewRequest(method, "/api/v1/health/providers", nil)
+			rr := httptest.NewRecorder()
+			cfg.handlerProviderHealth(rr, req)
+
+			if rr.Code != tc.wantStatus {
+				t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, tc.wantStatus)
+			}
+			if tc.wantHealth == "" {
+				return
+			}
+			var response ProviderHealthResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if response.Status != tc.wantHealth {
+				t.Errorf("expected status %q, got %q", tc.wantHealth, response.Status)
+			}
+			if len(response.Providers) != len(tc.wantStates) {
+				t.Fatalf("expected %d providers, got %+v", len(tc.wantStates), response.Providers)
+			}
+			for _, p := range response.Providers {
+				if p.State != tc.wantStates[p.Provider] {
+					t.Errorf("expected %s to be %s, got %s", p.Provider, tc.wantStates[p.Provider], p.State)
+				}
+				if p.State == circuitOpen && (p.ConsecutiveFailures != 1 || p.RetryAt != "2025-08-04T12:01:00Z") {
+					t.Errorf("unexpected open circuit details: %+v", p)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// This file implements the get sub-command, which fetches the weather for a city once and
+// prints it, for cron scripts and quick checks from a terminal:
+//
+//	willitrain get --city Wroclaw --type hourly --format table
+//
+// It reads the same configuration as the server and goes through the same fetch and parse
+// layer, but starts no HTTP server and connects to neither the database nor the cache: the city
+// is geocoded and the enabled providers are queried directly. The JSON format matches the
+// responses of the corresponding API endpoints.
+
+// Forecast types of the get sub-command.
+const (
+	cliTypeCurrent = "current"
+	cliTypeDaily   = "daily"
+	cliTypeHourly  = "hourly"
+)
+
+// Output formats of the get sub-command.
+const (
+	cliFormatTable = "table"
+	cliFormatJSON  = "json"
+)
+
+// runGet parses the arguments of the get sub-command, fetches the requested weather and writes
+// it to stdout. Logs are discarded unless -verbose is set, in which case they go to stderr.
+func runGet(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("get", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	city := fs.String("city", "", "name of the city to get the weather for")
+	kind := fs.String("type", cliTypeCurrent, "what to get: current, daily or hourly")
+	format := fs.String("format", cliFormatTable, "output format: table or json")
+	unitsFlag := fs.String("units", "", "units of measurement, metric or imperial (defaults to DEFAULT_UNITS)")
+	verbose := fs.Bool("verbose", false, "write logs to stderr")
+	configPath := fs.String("config", "", "path to a YAML or TOML config file, overriding CONFIG_FILE")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *configPath != "" {
+		if err := os.Setenv("CONFIG_FILE", *configPath); err != nil {
+			return err
+		}
+	}
+
+	if strings.TrimSpace(*city) == "" {
+		return errors.New("-city is required")
+	}
+	switch *kind {
+	case cliTypeCurrent, cliTypeDaily, cliTypeHourly:
+	default:
+		return fmt.Errorf("-type must be current, daily or hourly, got %q", *kind)
+	}
+	if *format != cliFormatTable && *format != cliFormatJSON {
+		return fmt.Errorf("-format must be table or json, got %q", *format)
+	}
+
+	logOutput := io.Discard
+	if *verbose {
+		logOutput = stderr
+	}
+	cfg, err := NewAPIConfig(logOutput)
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	units := cfg.defaultUnits
+	if *unitsFlag != "" {
+		if units, err = parseUnits(*unitsFlag); err != nil {
+			return err
+		}
+	}
+	// Nothing is stored: every fetch goes to the providers.
+	cfg.cache = noopCache{}
+
+	location, err := cfg.cliLocation(ctx, *city)
+	if err != nil {
+		return err
+	}
+	report, err := cfg.cliReport(ctx, location, *kind, units)
+	if err != nil {
+		return err
+	}
+
+	if *format == cliFormatJSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(withUnits(report, units))
+	}
+	return writeWeatherTable(stdout, report, units)
+}
+
+// cliLocation geocodes a city for the get sub-command. Without a database the location has no
+// stored timezone, so it is looked up here; if that fails, UTC is used, so that the fetch never
+// tries to store the timezone the providers report.
+func (cfg *apiConfig) cliLocation(ctx context.Context, city string) (Location, error) {
+	location, err := cfg.geocoder.Geocode(city)
+	if err != nil {
+		return Location{}, fmt.Errorf("could not find %s: %w", city, err)
+	}
+	if location.Timezone == "" {
+		location.Timezone, err = cfg.lookupTimezone(ctx, location)
+		if err != nil {
+			cfg.logger.Warn("could not look up timezone, using UTC", "location", location.CityName, "error", err)
+			location.Timezone = "UTC"
+		}
+	}
+	return location, nil
+}
+
+// cliReport fetches the weather of the given type at a location from the enabled providers and
+// formats it like the corresponding API endpoint.
+func (cfg *apiConfig) cliReport(ctx context.Context, location Location, kind string, units unitSystem) (any, error) {
+	ctx, fetchErrs := withProviderFetchErrors(ctx)
+	loc, err := time.LoadLocation(location.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+
+	var sources []string
+	var report any
+	switch kind {
+	case cliTypeCurrent:
+		weather, err := cfg.requestCurrentWeather(ctx, location, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp := CurrentWeatherResponse{Location: location, Weather: make([]CurrentWeatherJSON, len(weather))}
+		for i, w := range weather {
+			resp.Weather[i] = currentWeatherJSON(w, loc, units)
+			sources = append(sources, w.SourceAPI)
+		}
+		resp.Providers, resp.Partial = cfg.providerStatuses(sources, fetchErrs)
+		resp.Attribution = attributionForSources(sources)
+		report = resp
+	case cliTypeDaily:
+		forecast, err := cfg.requestDailyForecast(ctx, location, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		sortDailyForecast(forecast)
+		resp := DailyForecastsResponse{Location: location, Forecasts: make([]DailyForecastJSON, len(forecast))}
+		for i, f := range forecast {
+			resp.Forecasts[i] = dailyForecastJSON(f, loc, units)
+			sources = append(sources, f.SourceAPI)
+		}
+		resp.Providers, resp.Partial = cfg.providerStatuses(sources, fetchErrs)
+		resp.Attribution = attributionForSources(sources)
+		report = resp
+	case cliTypeHourly:
+		forecast, err := cfg.requestHourlyForecast(ctx, location, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		sortHourlyForecast(forecast)
+		resp := HourlyForecastsResponse{Location: location, Forecasts: make([]HourlyForecastJSON, len(forecast))}
+		for i, f := range forecast {
+			resp.Forecasts[i] = hourlyForecastJSON(f, loc, units)
+			sources = append(sources, f.SourceAPI)
+		}
+		resp.Transitions = hourlyTransitions(resp.Forecasts)
+		if resp.Transitions == nil {
+			resp.Transitions = []ConditionTransitionJSON{}
+		}
+		resp.Providers, resp.Partial = cfg.providerStatuses(sources, fetchErrs)
+		resp.Attribution = attributionForSources(sources)
+		report = resp
+	default:
+		return nil, fmt.Errorf("unknown type %q", kind)
+	}
+
+	if len(sources) == 0 {
+		return nil, fmt.Errorf("no weather source returned data for %s", location.CityName)
+	}
+	return report, nil
+}
+
+// writeWeatherTable writes a report of cliReport as a table with one row per source and time.
+// Values a source did not report are shown as "-".
+func writeWeatherTable(w io.Writer, report any, units unitSystem) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	temp, wind, precip := units.temperatureSymbol(), units.windSpeedSymbol(), units.precipitationSymbol()
+
+	switch r := report.(type) {
+	case CurrentWeatherResponse:
+		fmt.Fprintf(w, "Current weather in %s (%s)\n\n", r.Location.CityName, r.Location.Timezone)
+		fmt.Fprintf(tw, "SOURCE\tTIME\tTEMP %s\tFEELS LIKE %s\tHUMIDITY %%\tWIND %s\tPRECIP %s\tCONDITION\n", temp, temp, wind, precip)
+		for _, e := range r.Weather {
+			fmt.Fprintf(tw, "%s\t%s\t%.1f\t%s\t%d\t%.1f %s\t%.1f\t%s\n", e.SourceAPI, e.ObservedAtLocal, e.Temperature, cliOptional(e.ApparentTemperature), e.Humidity, e.WindSpeed, e.WindCompass, e.Precipitation, e.ConditionCode)
+		}
+	case DailyForecastsResponse:
+		fmt.Fprintf(w, "Daily forecast for %s (%s)\n\n", r.Location.CityName, r.Location.Timezone)
+		fmt.Fprintf(tw, "DATE\tSOURCE\tMIN %s\tMAX %s\tPRECIP %s\tCHANCE %%\tWIND %s\tCONDITION\n", temp, temp, precip, wind)
+		for _, e := range r.Forecasts {
+			fmt.Fprintf(tw, "%s\t%s\t%.1f\t%.1f\t%.1f\t%d\t%.1f %s\t%s\n", e.ForecastDate, e.SourceAPI, e.MinTemp, e.MaxTemp, e.Precipitation, e.PrecipitationChance, e.WindSpeed, e.WindCompass, e.ConditionCode)
+		}
+	case HourlyForecastsResponse:
+		fmt.Fprintf(w, "Hourly forecast for %s (%s)\n\n", r.Location.CityName, r.Location.Timezone)
+		fmt.Fprintf(tw, "TIME\tSOURCE\tTEMP %s\tPRECIP %s\tCHANCE %%\tWIND %s\tCONDITION\n", temp, precip, wind)
+		for _, e := range r.Forecasts {
+			fmt.Fprintf(tw, "%s\t%s\t%.1f\t%.1f\t%d\t%.1f %s\t%s\n", e.ForecastDateTime, e.SourceAPI, e.Temperature, e.Precipitation, e.PrecipitationChance, e.WindSpeed, e.WindCompass, e.ConditionCode)
+		}
+	default:
+		return fmt.Errorf("cannot print %T as a table", report)
+	}
+	return tw.Flush()
+}
+
+// cliOptional formats an optional value for a table cell.
+func cliOptional(v *float64) string {
+	if v == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f", *v)
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// setupDemoEnv runs the configuration in demo mode, so that the get sub-command needs no
+// provider or network access.
+func setupDemoEnv(t *testing.T) {
+	t.Helper()
+	for key := range demoEnvDefaults {
+		t.Setenv(key, "")
+	}
+	t.Setenv("CONFIG_FILE", "")
+	t.Setenv("DEMO_MODE", "true")
+	t.Setenv("DB_URL", filepath.Join(t.TempDir(), "demo.db"))
+}
+
+func TestRunGet(t *testing.T) {
+	setupDemoEnv(t)
+
+	testCases := []struct {
+		name     string
+		args     []string
+		wantRows []string
+	}{
+		{
+			name:     "Current Table",
+			args:     []string{"--city", "London"},
+			wantRows: []string{"Current weather in London (Europe/London)", "TEMP °C", "Open-Meteo API"},
+		},
+		{
+			name:     "Daily Imperial Table",
+			args:     []string{"--city", "Sydney", "--type", "daily", "--units", "imperial"},
+			wantRows: []string{"Daily forecast for Sydney (Australia/Sydney)", "MAX °F", "WIND mph"},
+		},
+		{
+			name:     "Hourly Table",
+			args:     []string{"-city", "Tokyo", "-type", "hourly"},
+			wantRows: []string{"Hourly forecast for Tokyo (Asia/Tokyo)", "PRECIP mm"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var stdout bytes.Buffer
+			if err := runGet(t.Context(), tc.args, &stdout, io.Discard); err != nil {
+				t.Fatalf("runGet: %v", err)
+			}
+			for _, row := range tc.wantRows {
+				if !strings.Contains(stdout.String(), row) {
+					t.Errorf("expected output to contain %q, got:\n%s", row, stdout.String())
+				}
+			}
+		})
+	}
+
+	t.Run("JSON", func(t *testing.T) {
+		var stdout bytes.Buffer
+		if err := runGet(t.Context(), []string{"-city", "Warsaw", "-type", "daily", "-format", "json"}, &stdout, io.Discard); err != nil {
+			t.Fatalf("runGet: %v", err)
+		}
+		var resp DailyForecastsResponse
+		if err := json.Unmarshal(stdout.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		if resp.Location.CityName != "Warsaw" || len(resp.Forecasts) == 0 || resp.Partial {
+			t.Errorf("unexpected response: %+v", resp)
+		}
+	})
+}
+
+func TestRunGetInvalidArguments(t *testing.T) {
+	testCases := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{name: "Missing City", args: nil, wantErr: "-city is required"},
+		{name: "Invalid Type", args: []string{"-city", "London", "-type", "weekly"}, wantErr: "-type must be"},
+		{name: "Invalid Format", args: []string{"-city", "London", "-format", "xml"}, wantErr: "-format must be"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := runGet(t.Context(), tc.args, io.Discard, io.Discard)
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tc.wantErr, err)
+			}
+		})
+	}
+
+	t.Run("Unknown City", func(t *testing.T) {
+		setupDemoEnv(t)
+		err := runGet(t.Context(), []string{"-city", "Ankh-Morpork"}, io.Discard, io.Discard)
+		if err == nil || !strings.Contains(err.Error(), "could not find Ankh-Morpork") {
+			t.Errorf("expected a geocoding error, got %v", err)
+		}
+	})
+}
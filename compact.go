@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// This file derives the compact representation of a forecast entry: a normalized condition
+// code, an emoji and a short summary. The providers describe conditions in free text ("Clouds",
+// "partly cloudy", "Mostly sunny"), so the text is first mapped to a small set of codes that
+// clients can rely on. Daily forecasts carry no condition text and are classified from their
+// precipitation and temperature instead.
+
+// maxCompactSummaryLen is the maximum length of a compact summary in characters.
+const maxCompactSummaryLen = 20
+
+// Normalized condition codes.
+const (
+	conditionClear        = "clear"
+	conditionPartlyCloudy = "partly_cloudy"
+	conditionCloudy       = "cloudy"
+	conditionFog          = "fog"
+	conditionDrizzle      = "drizzle"
+	conditionShowers      = "showers"
+	conditionRain         = "rain"
+	conditionHeavyRain    = "heavy_rain"
+	conditionThunderstorm = "thunderstorm"
+	conditionSleet        = "sleet"
+	conditionSnow         = "snow"
+	conditionDry          = "dry"
+	conditionUnknown      = "unknown"
+)
+
+// conditionDisplay holds the emoji and label shown for a condition code.
+type conditionDisplay struct {
+	Emoji string
+	Label string
+}
+
+var conditionDisplays = map[string]conditionDisplay{
+	conditionClear:        {Emoji: "☀️", Label: "Clear"},
+	conditionPartlyCloudy: {Emoji: "⛅", Label: "Partly cloudy"},
+	conditionCloudy:       {Emoji: "☁️", Label: "Cloudy"},
+	conditionFog:          {Emoji: "🌫️", Label: "Fog"},
+	conditionDrizzle:      {Emoji: "🌦️", Label: "Drizzle"},
+	conditionShowers:      {Emoji: "🌦️", Label: "Showers"},
+	conditionRain:         {Emoji: "🌧️", Label: "Rain"},
+	conditionHeavyRain:    {Emoji: "🌧️", Label: "Heavy rain"},
+	conditionThunderstorm: {Emoji: "⛈️", Label: "Thunderstorm"},
+	conditionSleet:        {Emoji: "🌨️", Label: "Sleet"},
+	conditionSnow:         {Emoji: "❄️", Label: "Snow"},
+	conditionDry:          {Emoji: "🌤️", Label: "Dry"},
+	conditionUnknown:      {Emoji: "🌡️", Label: "Unknown"},
+}
+
+// conditionKeywords maps substrings of provider condition texts to condition codes. The list
+// is checked in order, so more specific phrases must come before the words they contain.
+var conditionKeywords = []struct {
+	keyword string
+	code    string
+}{
+	{"thunder", conditionThunderstorm},
+	{"freezing", conditionSleet},
+	{"sleet", conditionSleet},
+	{"snow", conditionSnow},
+	{"drizzle", conditionDrizzle},
+	{"shower", conditionShowers},
+	{"heavy rain", conditionHeavyRain},
+	{"rain", conditionRain},
+	{"fog", conditionFog},
+	{"mist", conditionFog},
+	{"haze", conditionFog},
+	{"partly", conditionPartlyCloudy},
+	{"mainly clear", conditionPartlyCloudy},
+	{"mostly clear", conditionPartlyCloudy},
+	{"mostly sunny", conditionPartlyCloudy},
+	{"few clouds", conditionPartlyCloudy},
+	{"scattered", conditionPartlyCloudy},
+	{"overcast", conditionCloudy},
+	{"cloud", conditionCloudy},
+	{"clear", conditionClear},
+	{"sunny", conditionClear},
+}
+
+// normalizeCondition maps a provider's condition text to a condition code.
+func normalizeCondition(text string) string {
+	text = strings.ToLower(text)
+	for _, k := range conditionKeywords {
+		if strings.Contains(text, k.keyword) {
+			return k.code
+		}
+	}
+	return conditionUnknown
+}
+
+// dailyConditionCode classifies a daily forecast, which has no condition text, from its
+// precipitation amount, precipitation chance and maximum temperature.
+func dailyConditionCode(f DailyForecast) string {
+	wet := f.Precipitation >= 1 || f.PrecipitationChance >= 50
+	switch {
+	case !wet:
+		return conditionDry
+	case f.MaxTemp <= 0:
+		return conditionSnow
+	case f.Precipitation >= 10:
+		return conditionHeavyRain
+	default:
+		return conditionRain
+	}
+}
+
+// compactFor builds the compact representation of a condition code with a temperature suffix.
+// The temperature is dropped if the summary would otherwise exceed maxCompactSummaryLen.
+func compactFor(code, temperature string) CompactJSON {
+	display, ok := conditionDisplays[code]
+	if !ok {
+		display = conditionDisplays[conditionUnknown]
+	}
+	summary := display.Label + " " + temperature
+	if utf8.RuneCountInString(summary) > maxCompactSummaryLen {
+		summary = display.Label
+	}
+	return CompactJSON{Emoji: display.Emoji, Summary: summary}
+}
+
+// formatCompactTemp formats a temperature given in the units for a compact summary, e.g. "12°C".
+func formatCompactTemp(t float64, units unitSystem) string {
+	return fmt.Sprintf("%.0f%s", t, units.temperatureSymbol())
+}
+
+// formatCompactTempRange formats a daily temperature range given in the units for a compact
+// summary, e.g. "8/15°C".
+func formatCompactTempRange(min, max float64, units unitSystem) string {
+	return fmt.Sprintf("%.0f/%.0f%s", min, max, units.temperatureSymbol())
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"testing"
+	"unicode/utf8"
+)
+
+func TestNormalizeCondition(t *testing.T) {
+	testCases := []struct {
+		text string
+		want string
+	}{
+		{text: "Clear", want: conditionClear},
+		{text: "Mostly sunny", want: conditionPartlyCloudy},
+		{text: "mainly clear", want: conditionPartlyCloudy},
+		{text: "Clouds", want: conditionCloudy},
+		{text: "overcast", want: conditionCloudy},
+		{text: "Mist", want: conditionFog},
+		{text: "light drizzle", want: conditionDrizzle},
+		{text: "light freezing drizzle", want: conditionSleet},
+		{text: "Rain showers", want: conditionShowers},
+		{text: "heavy rain", want: conditionHeavyRain},
+		{text: "Rain", want: conditionRain},
+		{text: "Thunderstorm with slight hail", want: conditionThunderstorm},
+		{text: "moderate snowfall", want: conditionSnow},
+		{text: "", want: conditionUnknown},
+		{text: "Tornado", want: conditionUnknown},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.text, func(t *testing.T) {
+			if got := normalizeCondition(tc.text); got != tc.want {
+				t.Errorf("normalizeCondition(%q) = %q, want %q", tc.text, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestDailyConditionCode(t *testing.T) {
+	testCases := []struct {
+		name     string
+		forecast DailyForecast
+		want     string
+	}{
+		{name: "Dry", forecast: DailyForecast{MaxTemp: 20, Precipitation: 0.2, PrecipitationChance: 10}, want: conditionDry},
+		{name: "Likely Rain", forecast: DailyForecast{MaxTemp: 20, PrecipitationChance: 70}, want: conditionRain},
+		{name: "Heavy Rain", forecast: DailyForecast{MaxTemp: 20, Precipitation: 25}, want: conditionHeavyRain},
+		{name: "Snow", forecast: DailyForecast{MaxTemp: -2, Precipitation: 3}, want: conditionSnow},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := dailyConditionCode(tc.forecast); got != tc.want {
+				t.Errorf("expected %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestCompactFor(t *testing.T) {
+	got := compactFor(conditionRain, formatCompactTemp(12.4, unitsMetric))
+	if got.Emoji != "🌧️" || got.Summary != "Rain 12°C" {
+		t.Errorf("unexpected compact rendering: %+v", got)
+	}
+
+	// Every combination must respect the summary length limit, dropping the temperature if needed.
+	for code := range conditionDisplays {
+		c := compactFor(code, formatCompactTempRange(-15, -5, unitsMetric))
+		if n := utf8.RuneCountInString(c.Summary); n > maxCompactSummaryLen {
+			t.Errorf("summary %q for %s is %d characters long", c.Summary, code, n)
+		}
+	}
+
+	if got := compactFor("bogus", "1°C"); got.Summary != "Unknown 1°C" {
+		t.Errorf("expected unknown codes to fall back, got %+v", got)
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// This file implements conditional requests for the weather endpoints. Responses carry an ETag
+// derived from the payload and the time the data was last updated, a Last-Modified header, and a
+// Cache-Control max-age covering the time the data remains fresh in the cache. Clients polling
+// for updates can send If-None-Match or If-Modified-Since and receive 304 Not Modified, without a
+// body, while the data has not changed.
+
+// respondWithConditionalJSON sends a payload like respondWithJSON with status 200, adding the
+// caching headers, or responds with 304 Not Modified if the request's validators match.
+// updatedAt is the time the newest data in the payload was fetched, and freshFor is how long
+// data is served from the cache after it was fetched.
+func (cfg *apiConfig) respondWithConditionalJSON(w http.ResponseWriter, r *http.Request, payload any, updatedAt time.Time, freshFor time.Duration) {
+	data, ok := cfg.encodeJSON(w, payload)
+	if !ok {
+		return
+	}
+	cfg.respondWithConditional(w, r, data, updatedAt, freshFor)
+}
+
+// respondWithConditional sends an encoded payload, whose Content-Type is already set, like
+// respondWithConditionalJSON.
+func (cfg *apiConfig) respondWithConditional(w http.ResponseWriter, r *http.Request, data []byte, updatedAt time.Time, freshFor time.Duration) {
+	etag := responseETag(data, updatedAt)
+	w.Header().Set("ETag", etag)
+	if !updatedAt.IsZero() {
+		w.Header().Set("Last-Modified", updatedAt.UTC().Format(http.TimeFormat))
+	}
+	w.Header().Set("Cache-Control", cacheControl(updatedAt, freshFor, time.Now()))
+
+	if notModified(r, etag, updatedAt) {
+		w.Header().Del("Content-Type")
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	cfg.writeBody(w, data)
+}
+
+// responseETag returns a strong ETag for an encoded payload and the update time of its data.
+func responseETag(data []byte, updatedAt time.Time) string {
+	h := sha256.New()
+	h.Write(data)
+	var ts [8]byte
+	binary.BigEndian.PutUint64(ts[:], uint64(updatedAt.UnixNano()))
+	h.Write(ts[:])
+	return `"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`
+}
+
+// cacheControl returns the Cache-Control header for data updated at updatedAt, allowing clients
+// to reuse it for the rest of its time in the cache. Data with an unknown update time or past
+// its freshness must be revalidated.
+func cacheControl(updatedAt time.Time, freshFor time.Duration, now time.Time) string {
+	if updatedAt.IsZero() {
+		return "no-cache"
+	}
+	remaining := updatedAt.Add(freshFor).Sub(now)
+	if remaining < time.Second {
+		return "no-cache"
+	}
+	return fmt.Sprintf("public, max-age=%d", int(remaining.Seconds()))
+}
+
+// notModified reports whether a GET or HEAD request's validators match the response. As in
+// RFC 9110, If-Modified-Since is ignored when If-None-Match is present.
+func notModified(r *http.Request, etag string, updatedAt time.Time) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		for _, candidate := range strings.Split(inm, ",") {
+			candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+			if candidate == "*" || candidate == etag {
+				return true
+			}
+		}
+		return false
+	}
+	ims := r.Header.Get("If-Modified-Since")
+	if ims == "" || updatedAt.IsZero() {
+		return false
+	}
+	since, err := http.ParseTime(ims)
+	if err != nil {
+		return false
+	}
+	return !updatedAt.Truncate(time.Second).After(since)
+}
+
+// latestTimestamp returns the newest Timestamp of a set of weather items, or the zero time if
+// there are none.
+func latestTimestamp[T apiModel](items []T) time.Time {
+	var latest time.Time
+	for _, item := range items {
+		if ts := apiModelTimestamp(item); ts.After(latest) {
+			latest = ts
+		}
+	}
+	return latest
+}
+
+// apiModelTimestamp returns the Timestamp field of any apiModel value.
+func apiModelTimestamp[T apiModel](item T) time.Time {
+	switch v := any(item).(type) {
+	case CurrentWeather:
+		return v.Timestamp
+	case DailyForecast:
+		return v.Timestamp
+	case HourlyForecast:
+		return v.Timestamp
+	case AirQuality:
+		return v.Timestamp
+	}
+	return time.Time{}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCacheControl(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name      string
+		updatedAt time.Time
+		freshFor  time.Duration
+		expected  string
+	}{
+		{name: "fresh data", updatedAt: now.Add(-4 * time.Minute), freshFor: 10 * time.Minute, expected: "public, max-age=360"},
+		{name: "stale data", updatedAt: now.Add(-11 * time.Minute), freshFor: 10 * time.Minute, expected: "no-cache"},
+		{name: "unknown update time", freshFor: 10 * time.Minute, expected: "no-cache"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := cacheControl(tc.updatedAt, tc.freshFor, now); got != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestRespondWithConditionalJSON(t *testing.T) {
+	updatedAt := time.Now().UTC().Add(-2 * time.Minute).Truncate(time.Second)
+	payload := map[string]string{"status": "ok"}
+	etag := responseETag([]byte(`{"status":"ok"}`), updatedAt)
+
+	testCases := []struct {
+		name       string
+		method     string
+		headers    map[string]string
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "No validators",
+			method:     http.MethodGet,
+			wantStatus: http.StatusOK,
+			wantBody:   `{"status":"ok"}`,
+		},
+		{
+			name:       "Matching ETag",
+			method:     http.MethodGet,
+			headers:    map[string]string{"If-None-Match": etag},
+			wantStatus: http.StatusNotModified,
+		},
+		{
+			name:       "Matching weak ETag in a list",
+			method:     http.MethodGet,
+			headers:    map[string]string{"If-None-Match": `"other", W/` + etag},
+			wantStatus: http.StatusNotModified,
+		},
+		{
+			name:       "Changed ETag",
+			method:     http.MethodGet,
+			headers:    map[string]string{"If-None-Match": `"other"`},
+			wantStatus: http.StatusOK,
+			wantBody:   `{"status":"ok"}`,
+		},
+		{
+			name:       "Changed ETag overrides If-Modified-Since",
+			method:     http.MethodGet,
+			headers:    map[string]string{"If-None-Match": `"other"`, "If-Modified-Since": updatedAt.Format(http.TimeFormat)},
+			wantStatus: http.StatusOK,
+			wantBody:   `{"status":"ok"}`,
+		},
+		{
+			name:       "Not modified since",
+			method:     http.MethodGet,
+			headers:    map[string]string{"If-Modified-Since": updatedAt.Format(http.TimeFormat)},
+			wantStatus: http.StatusNotModified,
+		},
+		{
+			name:       "Modified since",
+			method:     http.MethodGet,
+			headers:    map[string]string{"If-Modified-Since": updatedAt.Add(-time.Minute).Format(http.TimeFormat)},
+			wantStatus: http.StatusOK,
+			wantBody:   `{"status":"ok"}`,
+		},
+		{
+			name:       "Validators ignored for POST",
+			method:     http.MethodPost,
+			headers:    map[string]string{"If-None-Match": etag},
+			wantStatus: http.StatusOK,
+			wantBody:   `{"status":"ok"}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testCfg := newTestAPIConfig(t)
+			req := httptest.NewRequest(tc.method, "/", nil)
+			for k, v := range tc.headers {
+				req.Header.Set(k, v)
+			}
+			rr := httptest.NewRecorder()
+
+			testCfg.apiConfig.respondWithConditionalJSON(rr, req, payload, updatedAt, 10*time.Minute)
+
+			if rr.Code != tc.wantStatus {
+				t.Errorf("expected status %d, got %d", tc.wantStatus, rr.Code)
+			}
+			if rr.Body.String() != tc.wantBody {
+				t.Errorf("expected body %q, got %q", tc.wantBody, rr.Body.String())
+			}
+			if got := rr.Header().Get("ETag"); got != etag {
+				t.Errorf("expected ETag %s, got %s", etag, got)
+			}
+			if got := rr.Header().Get("Last-Modified"); got != updatedAt.Format(http.TimeFormat) {
+				t.Errorf("unexpected Last-Modified header: %q", got)
+			}
+			if got := rr.Header().Get("Cache-Control"); got != "public, max-age=480" && got != "public, max-age=479" {
+				t.Errorf("unexpected Cache-Control header: %q", got)
+			}
+		})
+	}
+}
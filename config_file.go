@@ -0,0 +1,763 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// This file implements optional file-based configuration. When CONFIG_FILE, or the -config flag,
+// points to a YAML or TOML file, its settings are loaded before the environment is read. Every setting in the file maps
+// to one of the environment variables documented in the README, and a variable that is set in
+// the environment always wins over the file. This keeps the environment as the single source of
+// truth for NewAPIConfig while letting operators group the growing number of options in a file.
+
+// redactedValue replaces secrets when the configuration is printed.
+const redactedValue = "[REDACTED]"
+
+// fileConfig is the schema of the configuration file. Unknown keys are rejected when the file
+// is loaded so that typos are reported instead of being silently ignored.
+type fileConfig struct {
+	Database struct {
+		Driver               string `yaml:"driver,omitempty"`
+		URL                  string `yaml:"url,omitempty"`
+		MaxConns             *int   `yaml:"max_conns,omitempty"`
+		MaxConnIdleSec       *int   `yaml:"max_conn_idle_sec,omitempty"`
+		HealthCheckPeriodSec *int   `yaml:"health_check_period_sec,omitempty"`
+		MigrateOnStart       *bool  `yaml:"migrate_on_start,omitempty"`
+	} `yaml:"database"`
+	Redis struct {
+		URL string `yaml:"url,omitempty"`
+	} `yaml:"redis"`
+	Cache struct {
+		Backend     string `yaml:"backend,omitempty"`
+		LocalSize   *int   `yaml:"local_size,omitempty"`
+		LocalTTLSec *int   `yaml:"local_ttl_sec,omitempty"`
+	} `yaml:"cache"`
+	Server struct {
+		Port          string `yaml:"port,omitempty"`
+		DevMode       *bool  `yaml:"dev_mode,omitempty"`
+		DemoMode      *bool  `yaml:"demo_mode,omitempty"`
+		DefaultUnits  string `yaml:"default_units,omitempty"`
+		LogLevel      string `yaml:"log_level,omitempty"`
+		LogSampleRate *int   `yaml:"log_sample_rate,omitempty"`
+	} `yaml:"server"`
+	Scheduler struct {
+		CurrentIntervalMin    *int  `yaml:"current_interval_min,omitempty"`
+		HourlyIntervalMin     *int  `yaml:"hourly_interval_min,omitempty"`
+		DailyIntervalMin      *int  `yaml:"daily_interval_min,omitempty"`
+		AirQualityIntervalMin *int  `yaml:"air_quality_interval_min,omitempty"`
+		Concurrency           *int  `yaml:"concurrency,omitempty"`
+		JitterSec             *int  `yaml:"jitter_sec,omitempty"`
+		DrainTimeoutSec       *int  `yaml:"drain_timeout_sec,omitempty"`
+		JobTimeoutSec         *int  `yaml:"job_timeout_sec,omitempty"`
+		LocationTimeoutSec    *int  `yaml:"location_timeout_sec,omitempty"`
+		ArchiveHistory        *bool `yaml:"archive_history,omitempty"`
+		Demand                struct {
+			IdleDays     *int `yaml:"idle_days,omitempty"`
+			EvictDays    *int `yaml:"evict_days,omitempty"`
+			IdleStride   *int `yaml:"idle_stride,omitempty"`
+			NormalStride *int `yaml:"normal_stride,omitempty"`
+			HotLocations *int `yaml:"hot_locations,omitempty"`
+		} `yaml:"demand"`
+		Retention struct {
+			HourlyHours *int `yaml:"hourly_hours,omitempty"`
+			DailyDays   *int `yaml:"daily_days,omitempty"`
+		} `yaml:"retention"`
+	} `yaml:"scheduler"`
+	Forecast struct {
+		DailyDays   *int `yaml:"daily_days,omitempty"`
+		HourlyHours *int `yaml:"hourly_hours,omitempty"`
+	} `yaml:"forecast"`
+	Providers struct {
+		Sources             []string           `yaml:"sources,omitempty"`
+		CostPerCall         map[string]float64 `yaml:"cost_per_call,omitempty"`
+		HedgePercentile     *int               `yaml:"hedge_percentile,omitempty"`
+		DailyQuota          map[string]int64   `yaml:"daily_quota,omitempty"`
+		RateLimit           map[string]int     `yaml:"rate_limit,omitempty"`
+		Weights             map[string]float64 `yaml:"weights,omitempty"`
+		QuotaDegradePercent *int               `yaml:"quota_degrade_percent,omitempty"`
+		CircuitBreaker      struct {
+			Threshold   *int `yaml:"threshold,omitempty"`
+			CooldownSec *int `yaml:"cooldown_sec,omitempty"`
+		} `yaml:"circuit_breaker"`
+		Retry struct {
+			MaxRetries *int `yaml:"max_retries,omitempty"`
+			BaseMs     *int `yaml:"base_ms,omitempty"`
+		} `yaml:"retry"`
+		GMP struct {
+			Key         string `yaml:"key,omitempty"`
+			GeocodeURL  string `yaml:"geocode_url,omitempty"`
+			WeatherURL  string `yaml:"weather_url,omitempty"`
+			TimezoneURL string `yaml:"timezone_url,omitempty"`
+		} `yaml:"gmp"`
+		OWM struct {
+			Key              string `yaml:"key,omitempty"`
+			WeatherURL       string `yaml:"weather_url,omitempty"`
+			LegacyWeatherURL string `yaml:"legacy_weather_url,omitempty"`
+			AirPollutionURL  string `yaml:"air_pollution_url,omitempty"`
+		} `yaml:"owm"`
+		OMeteo struct {
+			WeatherURL    string `yaml:"weather_url,omitempty"`
+			ArchiveURL    string `yaml:"archive_url,omitempty"`
+			AirQualityURL string `yaml:"air_quality_url,omitempty"`
+		} `yaml:"ometeo"`
+		MetNo struct {
+			WeatherURL string `yaml:"weather_url,omitempty"`
+		} `yaml:"metno"`
+		Geocoder  string `yaml:"geocoder,omitempty"`
+		Nominatim struct {
+			URL string `yaml:"url,omitempty"`
+		} `yaml:"nominatim"`
+		UserAgent string `yaml:"user_agent,omitempty"`
+	} `yaml:"providers"`
+	Suggestions struct {
+		DefaultCities map[string][]string `yaml:"default_cities,omitempty"`
+	} `yaml:"suggestions"`
+}
+
+// loadConfigFile reads and validates the configuration file at path.
+func loadConfigFile(path string) (*fileConfig, error) {
+	ext := strings.ToLower(filepath.Ext(path))
+	switch ext {
+	case ".yaml", ".yml", ".toml":
+	default:
+		return nil, fmt.Errorf("unsupported config file format %q: use YAML (.yaml, .yml) or TOML (.toml)", ext)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read config file: %w", err)
+	}
+	if ext == ".toml" {
+		if data, err = tomlToYAML(data); err != nil {
+			return nil, fmt.Errorf("could not parse config file %s: %w", path, err)
+		}
+	}
+
+	var fc fileConfig
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&fc); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("could not parse config file %s: %w", path, err)
+	}
+	if err := fc.validate(); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	return &fc, nil
+}
+
+// tomlToYAML converts a TOML config file to YAML, so that both formats share the schema, the
+// unknown key check and the validation. TOML tables map to YAML mappings with the same keys.
+func tomlToYAML(data []byte) ([]byte, error) {
+	var doc map[string]any
+	if err := toml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc) == 0 {
+		return nil, nil
+	}
+	return yaml.Marshal(doc)
+}
+
+// validate checks the values that the YAML decoder cannot check on its own.
+func (fc *fileConfig) validate() error {
+	var errs []error
+	for name, interval := range map[string]*int{
+		"scheduler.current_interval_min":     fc.Scheduler.CurrentIntervalMin,
+		"scheduler.hourly_interval_min":      fc.Scheduler.HourlyIntervalMin,
+		"scheduler.daily_interval_min":       fc.Scheduler.DailyIntervalMin,
+		"scheduler.air_quality_interval_min": fc.Scheduler.AirQualityIntervalMin,
+	} {
+		if interval != nil && *interval <= 0 {
+			errs = append(errs, fmt.Errorf("%s must be positive, got %d", name, *interval))
+		}
+	}
+	if n := fc.Scheduler.Concurrency; n != nil && *n < 1 {
+		errs = append(errs, fmt.Errorf("scheduler.concurrency must be at least 1, got %d", *n))
+	}
+	if sec := fc.Scheduler.JitterSec; sec != nil && *sec < 0 {
+		errs = append(errs, fmt.Errorf("scheduler.jitter_sec must not be negative, got %d", *sec))
+	}
+	if sec := fc.Scheduler.DrainTimeoutSec; sec != nil && *sec < 0 {
+		errs = append(errs, fmt.Errorf("scheduler.drain_timeout_sec must not be negative, got %d", *sec))
+	}
+	if sec := fc.Scheduler.JobTimeoutSec; sec != nil && *sec < 0 {
+		errs = append(errs, fmt.Errorf("scheduler.job_timeout_sec must not be negative, got %d", *sec))
+	}
+	if sec := fc.Scheduler.LocationTimeoutSec; sec != nil && *sec < 0 {
+		errs = append(errs, fmt.Errorf("scheduler.location_timeout_sec must not be negative, got %d", *sec))
+	}
+	if n := fc.Scheduler.Demand.IdleDays; n != nil && *n < 0 {
+		errs = append(errs, fmt.Errorf("scheduler.demand.idle_days must not be negative, got %d", *n))
+	}
+	if n := fc.Scheduler.Demand.EvictDays; n != nil && *n < 0 {
+		errs = append(errs, fmt.Errorf("scheduler.demand.evict_days must not be negative, got %d", *n))
+	}
+	if n := fc.Scheduler.Demand.IdleStride; n != nil && *n < 1 {
+		errs = append(errs, fmt.Errorf("scheduler.demand.idle_stride must be at least 1, got %d", *n))
+	}
+	if n := fc.Scheduler.Demand.NormalStride; n != nil && *n < 1 {
+		errs = append(errs, fmt.Errorf("scheduler.demand.normal_stride must be at least 1, got %d", *n))
+	}
+	if n := fc.Scheduler.Demand.HotLocations; n != nil && *n < 0 {
+		errs = append(errs, fmt.Errorf("scheduler.demand.hot_locations must not be negative, got %d", *n))
+	}
+	if n := fc.Scheduler.Retention.HourlyHours; n != nil && *n < 0 {
+		errs = append(errs, fmt.Errorf("scheduler.retention.hourly_hours must not be negative, got %d", *n))
+	}
+	if n := fc.Scheduler.Retention.DailyDays; n != nil && *n < 0 {
+		errs = append(errs, fmt.Errorf("scheduler.retention.daily_days must not be negative, got %d", *n))
+	}
+	switch fc.Cache.Backend {
+	case "", cacheBackendRedis, cacheBackendMemory, cacheBackendNone:
+	default:
+		errs = append(errs, fmt.Errorf("cache.backend must be redis, memory or none, got %q", fc.Cache.Backend))
+	}
+	if n := fc.Cache.LocalSize; n != nil && *n < 0 {
+		errs = append(errs, fmt.Errorf("cache.local_size must not be negative, got %d", *n))
+	}
+	if sec := fc.Cache.LocalTTLSec; sec != nil && *sec <= 0 {
+		errs = append(errs, fmt.Errorf("cache.local_ttl_sec must be positive, got %d", *sec))
+	}
+	if fc.Server.Port != "" {
+		if port, err := strconv.Atoi(fc.Server.Port); err != nil || port <= 0 || port > 65535 {
+			errs = append(errs, fmt.Errorf("server.port must be a valid port number, got %q", fc.Server.Port))
+		}
+	}
+	if _, err := parseUnits(fc.Server.DefaultUnits); err != nil {
+		errs = append(errs, fmt.Errorf("server.default_units must be either metric or imperial, got %q", fc.Server.DefaultUnits))
+	}
+	if fc.Server.LogLevel != "" {
+		if _, err := parseLogLevel(fc.Server.LogLevel); err != nil {
+			errs = append(errs, fmt.Errorf("server.log_level must be debug, info, warn or error, got %q", fc.Server.LogLevel))
+		}
+	}
+	if n := fc.Server.LogSampleRate; n != nil && *n <= 0 {
+		errs = append(errs, fmt.Errorf("server.log_sample_rate must be positive, got %d", *n))
+	}
+	if d := fc.Forecast.DailyDays; d != nil && (*d < 1 || *d > maxForecastDays) {
+		errs = append(errs, fmt.Errorf("forecast.daily_days must be between 1 and %d, got %d", maxForecastDays, *d))
+	}
+	if h := fc.Forecast.HourlyHours; h != nil && (*h < 1 || *h > maxForecastHours) {
+		errs = append(errs, fmt.Errorf("forecast.hourly_hours must be between 1 and %d, got %d", maxForecastHours, *h))
+	}
+	for _, id := range fc.Providers.Sources {
+		if _, ok := providerByID(id); !ok {
+			errs = append(errs, fmt.Errorf("providers.sources: unknown provider %q", id))
+		}
+	}
+	for id, price := range fc.Providers.CostPerCall {
+		if _, ok := providerByID(id); !ok {
+			errs = append(errs, fmt.Errorf("providers.cost_per_call: unknown provider %q", id))
+		} else if price < 0 {
+			errs = append(errs, fmt.Errorf("providers.cost_per_call.%s must not be negative", id))
+		}
+	}
+	if p := fc.Providers.HedgePercentile; p != nil && (*p < 0 || *p > 100) {
+		errs = append(errs, fmt.Errorf("providers.hedge_percentile must be between 0 and 100, got %d", *p))
+	}
+	for id, limit := range fc.Providers.DailyQuota {
+		if _, ok := providerByID(id); !ok {
+			errs = append(errs, fmt.Errorf("providers.daily_quota: unknown provider %q", id))
+		} else if limit <= 0 {
+			errs = append(errs, fmt.Errorf("providers.daily_quota.%s must be positive", id))
+		}
+	}
+	for id, limit := range fc.Providers.RateLimit {
+		if _, ok := providerByID(id); !ok {
+			errs = append(errs, fmt.Errorf("providers.rate_limit: unknown provider %q", id))
+		} else if limit <= 0 {
+			errs = append(errs, fmt.Errorf("providers.rate_limit.%s must be positive", id))
+		}
+	}
+	for id, weight := range fc.Providers.Weights {
+		if _, ok := providerByID(id); !ok {
+			errs = append(errs, fmt.Errorf("providers.weights: unknown provider %q", id))
+		} else if !validProviderWeight(weight) {
+			errs = append(errs, fmt.Errorf("providers.weights.%s must be between 0 and %d", id, maxProviderWeight))
+		}
+	}
+	if n := fc.Providers.CircuitBreaker.Threshold; n != nil && *n < 0 {
+		errs = append(errs, fmt.Errorf("providers.circuit_breaker.threshold must not be negative, got %d", *n))
+	}
+	if sec := fc.Providers.CircuitBreaker.CooldownSec; sec != nil && *sec < 1 {
+		errs = append(errs, fmt.Errorf("providers.circuit_breaker.cooldown_sec must be positive, got %d", *sec))
+	}
+	if n := fc.Providers.Retry.MaxRetries; n != nil && *n < 0 {
+		errs = append(errs, fmt.Errorf("providers.retry.max_retries must not be negative, got %d", *n))
+	}
+	if ms := fc.Providers.Retry.BaseMs; ms != nil && *ms < 1 {
+		errs = append(errs, fmt.Errorf("providers.retry.base_ms must be positive, got %d", *ms))
+	}
+	if p := fc.Providers.QuotaDegradePercent; p != nil && (*p < 0 || *p > 100) {
+		errs = append(errs, fmt.Errorf("providers.quota_degrade_percent must be between 0 and 100, got %d", *p))
+	}
+	if fc.Providers.Geocoder != "" {
+		providers, unknown := parseGeocoderProviders(fc.Providers.Geocoder)
+		if len(providers) == 0 || len(unknown) > 0 {
+			errs = append(errs, fmt.Errorf("providers.geocoder must list %s or %s, separated by commas, got %q", googleGeocoder, nominatimGeocoder, fc.Providers.Geocoder))
+		}
+	}
+	for key, cities := range fc.Suggestions.DefaultCities {
+		if _, ok := normalizeSuggestionsKey(key); !ok {
+			errs = append(errs, fmt.Errorf("suggestions.default_cities: %q is not a two-letter country code or %q", key, defaultSuggestionsKey))
+		}
+		if len(cities) == 0 {
+			errs = append(errs, fmt.Errorf("suggestions.default_cities.%s must list at least one city", key))
+		}
+		for _, city := range cities {
+			if strings.TrimSpace(city) == "" || strings.ContainsAny(city, ",|") {
+				errs = append(errs, fmt.Errorf("suggestions.default_cities.%s: invalid city name %q", key, city))
+			}
+		}
+	}
+	switch fc.Database.Driver {
+	case "", dbDriverPostgres, dbDriverSQLite:
+	default:
+		errs = append(errs, fmt.Errorf("database.driver must be postgres or sqlite, got %q", fc.Database.Driver))
+	}
+	if n := fc.Database.MaxConns; n != nil && *n <= 0 {
+		errs = append(errs, fmt.Errorf("database.max_conns must be positive, got %d", *n))
+	}
+	if sec := fc.Database.MaxConnIdleSec; sec != nil && *sec <= 0 {
+		errs = append(errs, fmt.Errorf("database.max_conn_idle_sec must be positive, got %d", *sec))
+	}
+	if sec := fc.Database.HealthCheckPeriodSec; sec != nil && *sec <= 0 {
+		errs = append(errs, fmt.Errorf("database.health_check_period_sec must be positive, got %d", *sec))
+	}
+	// With SQLite, database.url is the path of the database file.
+	databaseURL := fc.Database.URL
+	if fc.Database.Driver == dbDriverSQLite {
+		databaseURL = ""
+	}
+	for name, raw := range map[string]string{
+		"database.url":                     databaseURL,
+		"redis.url":                        fc.Redis.URL,
+		"providers.gmp.geocode_url":        fc.Providers.GMP.GeocodeURL,
+		"providers.gmp.weather_url":        fc.Providers.GMP.WeatherURL,
+		"providers.gmp.timezone_url":       fc.Providers.GMP.TimezoneURL,
+		"providers.owm.weather_url":        fc.Providers.OWM.WeatherURL,
+		"providers.owm.legacy_weather_url": fc.Providers.OWM.LegacyWeatherURL,
+		"providers.owm.air_pollution_url":  fc.Providers.OWM.AirPollutionURL,
+		"providers.ometeo.weather_url":     fc.Providers.OMeteo.WeatherURL,
+		"providers.ometeo.archive_url":     fc.Providers.OMeteo.ArchiveURL,
+		"providers.ometeo.air_quality_url": fc.Providers.OMeteo.AirQualityURL,
+		"providers.metno.weather_url":      fc.Providers.MetNo.WeatherURL,
+		"providers.nominatim.url":          fc.Providers.Nominatim.URL,
+	} {
+		if raw == "" {
+			continue
+		}
+		if u, err := url.Parse(raw); err != nil || u.Scheme == "" {
+			errs = append(errs, fmt.Errorf("%s must be an absolute URL, got %q", name, raw))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// envValues returns the file's settings keyed by the environment variable they correspond to.
+// Settings that are not present in the file are omitted.
+func (fc *fileConfig) envValues() map[string]string {
+	values := map[string]string{
+		"DB_DRIVER":              fc.Database.Driver,
+		"DB_URL":                 fc.Database.URL,
+		"REDIS_URL":              fc.Redis.URL,
+		"CACHE_BACKEND":          fc.Cache.Backend,
+		"PORT":                   fc.Server.Port,
+		"DEFAULT_UNITS":          fc.Server.DefaultUnits,
+		"LOG_LEVEL":              fc.Server.LogLevel,
+		"GMP_KEY":                fc.Providers.GMP.Key,
+		"GMP_GEOCODE_URL":        fc.Providers.GMP.GeocodeURL,
+		"GMP_WEATHER_URL":        fc.Providers.GMP.WeatherURL,
+		"GMP_TIMEZONE_URL":       fc.Providers.GMP.TimezoneURL,
+		"OWM_KEY":                fc.Providers.OWM.Key,
+		"OWM_WEATHER_URL":        fc.Providers.OWM.WeatherURL,
+		"OWM_LEGACY_WEATHER_URL": fc.Providers.OWM.LegacyWeatherURL,
+		"OWM_AIR_POLLUTION_URL":  fc.Providers.OWM.AirPollutionURL,
+		"OMETEO_WEATHER_URL":     fc.Providers.OMeteo.WeatherURL,
+		"OMETEO_ARCHIVE_URL":     fc.Providers.OMeteo.ArchiveURL,
+		"OMETEO_AIR_QUALITY_URL": fc.Providers.OMeteo.AirQualityURL,
+		"METNO_WEATHER_URL":      fc.Providers.MetNo.WeatherURL,
+		"GEOCODER_PROVIDER":      fc.Providers.Geocoder,
+		"NOMINATIM_URL":          fc.Providers.Nominatim.URL,
+		"HTTP_USER_AGENT":        fc.Providers.UserAgent,
+		"WEATHER_SOURCES":        strings.Join(fc.Providers.Sources, ","),
+	}
+	if fc.Server.DevMode != nil {
+		values["DEV_MODE"] = strconv.FormatBool(*fc.Server.DevMode)
+	}
+	if fc.Server.LogSampleRate != nil {
+		values["LOG_SAMPLE_RATE"] = strconv.Itoa(*fc.Server.LogSampleRate)
+	}
+	if fc.Server.DemoMode != nil {
+		values["DEMO_MODE"] = strconv.FormatBool(*fc.Server.DemoMode)
+	}
+	if fc.Scheduler.CurrentIntervalMin != nil {
+		values["CURRENT_INTERVAL_MIN"] = strconv.Itoa(*fc.Scheduler.CurrentIntervalMin)
+	}
+	if fc.Scheduler.HourlyIntervalMin != nil {
+		values["HOURLY_INTERVAL_MIN"] = strconv.Itoa(*fc.Scheduler.HourlyIntervalMin)
+	}
+	if fc.Scheduler.DailyIntervalMin != nil {
+		values["DAILY_INTERVAL_MIN"] = strconv.Itoa(*fc.Scheduler.DailyIntervalMin)
+	}
+	if fc.Scheduler.AirQualityIntervalMin != nil {
+		values["AIR_QUALITY_INTERVAL_MIN"] = strconv.Itoa(*fc.Scheduler.AirQualityIntervalMin)
+	}
+	if fc.Scheduler.Concurrency != nil {
+		values["SCHEDULER_CONCURRENCY"] = strconv.Itoa(*fc.Scheduler.Concurrency)
+	}
+	if fc.Scheduler.JitterSec != nil {
+		values["SCHEDULER_JITTER_SEC"] = strconv.Itoa(*fc.Scheduler.JitterSec)
+	}
+	if fc.Scheduler.DrainTimeoutSec != nil {
+		values["SCHEDULER_DRAIN_TIMEOUT_SEC"] = strconv.Itoa(*fc.Scheduler.DrainTimeoutSec)
+	}
+	if fc.Scheduler.JobTimeoutSec != nil {
+		values["SCHEDULER_JOB_TIMEOUT_SEC"] = strconv.Itoa(*fc.Scheduler.JobTimeoutSec)
+	}
+	if fc.Scheduler.LocationTimeoutSec != nil {
+		values["SCHEDULER_LOCATION_TIMEOUT_SEC"] = strconv.Itoa(*fc.Scheduler.LocationTimeoutSec)
+	}
+	if fc.Scheduler.ArchiveHistory != nil {
+		values["ARCHIVE_HISTORY"] = strconv.FormatBool(*fc.Scheduler.ArchiveHistory)
+	}
+	if fc.Scheduler.Demand.IdleDays != nil {
+		values["LOCATION_IDLE_DAYS"] = strconv.Itoa(*fc.Scheduler.Demand.IdleDays)
+	}
+	if fc.Scheduler.Demand.EvictDays != nil {
+		values["LOCATION_EVICT_DAYS"] = strconv.Itoa(*fc.Scheduler.Demand.EvictDays)
+	}
+	if fc.Scheduler.Demand.IdleStride != nil {
+		values["SCHEDULER_IDLE_STRIDE"] = strconv.Itoa(*fc.Scheduler.Demand.IdleStride)
+	}
+	if fc.Scheduler.Demand.NormalStride != nil {
+		values["SCHEDULER_NORMAL_STRIDE"] = strconv.Itoa(*fc.Scheduler.Demand.NormalStride)
+	}
+	if fc.Scheduler.Demand.HotLocations != nil {
+		values["SCHEDULER_HOT_LOCATIONS"] = strconv.Itoa(*fc.Scheduler.Demand.HotLocations)
+	}
+	if fc.Scheduler.Retention.HourlyHours != nil {
+		values["RETENTION_HOURLY_HOURS"] = strconv.Itoa(*fc.Scheduler.Retention.HourlyHours)
+	}
+	if fc.Scheduler.Retention.DailyDays != nil {
+		values["RETENTION_DAILY_DAYS"] = strconv.Itoa(*fc.Scheduler.Retention.DailyDays)
+	}
+	if fc.Database.MaxConns != nil {
+		values["DB_MAX_CONNS"] = strconv.Itoa(*fc.Database.MaxConns)
+	}
+	if fc.Database.MaxConnIdleSec != nil {
+		values["DB_MAX_CONN_IDLE_SEC"] = strconv.Itoa(*fc.Database.MaxConnIdleSec)
+	}
+	if fc.Database.HealthCheckPeriodSec != nil {
+		values["DB_HEALTH_CHECK_PERIOD_SEC"] = strconv.Itoa(*fc.Database.HealthCheckPeriodSec)
+	}
+	if fc.Database.MigrateOnStart != nil {
+		values["MIGRATE_ON_START"] = strconv.FormatBool(*fc.Database.MigrateOnStart)
+	}
+	if fc.Cache.LocalSize != nil {
+		values["LOCAL_CACHE_SIZE"] = strconv.Itoa(*fc.Cache.LocalSize)
+	}
+	if fc.Cache.LocalTTLSec != nil {
+		values["LOCAL_CACHE_TTL_SEC"] = strconv.Itoa(*fc.Cache.LocalTTLSec)
+	}
+	if fc.Forecast.DailyDays != nil {
+		values["FORECAST_DAILY_DAYS"] = strconv.Itoa(*fc.Forecast.DailyDays)
+	}
+	if fc.Forecast.HourlyHours != nil {
+		values["FORECAST_HOURLY_HOURS"] = strconv.Itoa(*fc.Forecast.HourlyHours)
+	}
+	if fc.Providers.HedgePercentile != nil {
+		values["HEDGE_PERCENTILE"] = strconv.Itoa(*fc.Providers.HedgePercentile)
+	}
+	if len(fc.Providers.CostPerCall) > 0 {
+		pairs := make([]string, 0, len(fc.Providers.CostPerCall))
+		for id, price := range fc.Providers.CostPerCall {
+			pairs = append(pairs, id+"="+strconv.FormatFloat(price, 'f', -1, 64))
+		}
+		sort.Strings(pairs)
+		values["PROVIDER_COST_PER_CALL"] = strings.Join(pairs, ",")
+	}
+	if len(fc.Providers.DailyQuota) > 0 {
+		pairs := make([]string, 0, len(fc.Providers.DailyQuota))
+		for id, limit := range fc.Providers.DailyQuota {
+			pairs = append(pairs, id+"="+strconv.FormatInt(limit, 10))
+		}
+		sort.Strings(pairs)
+		values["PROVIDER_DAILY_QUOTA"] = strings.Join(pairs, ",")
+	}
+	if len(fc.Providers.RateLimit) > 0 {
+		pairs := make([]string, 0, len(fc.Providers.RateLimit))
+		for id, limit := range fc.Providers.RateLimit {
+			pairs = append(pairs, id+"="+strconv.Itoa(limit))
+		}
+		sort.Strings(pairs)
+		values["PROVIDER_RATE_LIMIT"] = strings.Join(pairs, ",")
+	}
+	if len(fc.Providers.Weights) > 0 {
+		pairs := make([]string, 0, len(fc.Providers.Weights))
+		for id, weight := range fc.Providers.Weights {
+			pairs = append(pairs, id+"="+strconv.FormatFloat(weight, 'f', -1, 64))
+		}
+		sort.Strings(pairs)
+		values["PROVIDER_WEIGHTS"] = strings.Join(pairs, ",")
+	}
+	if fc.Providers.CircuitBreaker.Threshold != nil {
+		values["CIRCUIT_BREAKER_THRESHOLD"] = strconv.Itoa(*fc.Providers.CircuitBreaker.Threshold)
+	}
+	if fc.Providers.CircuitBreaker.CooldownSec != nil {
+		values["CIRCUIT_BREAKER_COOLDOWN_SEC"] = strconv.Itoa(*fc.Providers.CircuitBreaker.CooldownSec)
+	}
+	if fc.Providers.Retry.MaxRetries != nil {
+		values["FETCH_MAX_RETRIES"] = strconv.Itoa(*fc.Providers.Retry.MaxRetries)
+	}
+	if fc.Providers.Retry.BaseMs != nil {
+		values["FETCH_RETRY_BASE_MS"] = strconv.Itoa(*fc.Providers.Retry.BaseMs)
+	}
+	if fc.Providers.QuotaDegradePercent != nil {
+		values["QUOTA_DEGRADE_PERCENT"] = strconv.Itoa(*fc.Providers.QuotaDegradePercent)
+	}
+	if len(fc.Suggestions.DefaultCities) > 0 {
+		entries := make([]string, 0, len(fc.Suggestions.DefaultCities))
+		for key, cities := range fc.Suggestions.DefaultCities {
+			entries = append(entries, key+"="+strings.Join(cities, "|"))
+		}
+		sort.Strings(entries)
+		values["DEFAULT_CITIES"] = strings.Join(entries, ",")
+	}
+
+	for key, val := range values {
+		if val == "" {
+			delete(values, key)
+		}
+	}
+	return values
+}
+
+// applyConfigFile loads the file named by CONFIG_FILE, if any, and exports its settings as
+// environment variables that are not already set. It returns the names of the variables that
+// were taken from the file.
+func applyConfigFile() ([]string, error) {
+	path := os.Getenv("CONFIG_FILE")
+	if path == "" {
+		return nil, nil
+	}
+
+	fc, err := loadConfigFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []string
+	for key, val := range fc.envValues() {
+		if os.Getenv(key) != "" {
+			continue
+		}
+		if err := os.Setenv(key, val); err != nil {
+			return nil, fmt.Errorf("could not apply %s from config file: %w", key, err)
+		}
+		applied = append(applied, key)
+	}
+	sort.Strings(applied)
+	return applied, nil
+}
+
+// requiredSettings are the settings the application cannot start without, by environment
+// variable, with their keys in the config file.
+var requiredSettings = []struct{ env, key string }{
+	{"DB_URL", "database.url"},
+	{"REDIS_URL", "redis.url"},
+	{"GMP_GEOCODE_URL", "providers.gmp.geocode_url"},
+	{"GMP_WEATHER_URL", "providers.gmp.weather_url"},
+	{"OWM_WEATHER_URL", "providers.owm.weather_url"},
+	{"OMETEO_WEATHER_URL", "providers.ometeo.weather_url"},
+	{"OWM_KEY", "providers.owm.key"},
+}
+
+// checkRequiredSettings returns an error listing every required setting that is set neither in
+// the environment nor in the config file. REDIS_URL is only required by the Redis cache backend.
+func checkRequiredSettings(cacheBackend string) error {
+	var missing []string
+	for _, s := range requiredSettings {
+		if s.env == "REDIS_URL" && cacheBackend != cacheBackendRedis {
+			continue
+		}
+		if os.Getenv(s.env) == "" {
+			missing = append(missing, fmt.Sprintf("%s (%s)", s.env, s.key))
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("missing required settings, set them in the environment or the config file: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// logEffectiveConfig logs the effective configuration at debug level, with one attribute per
+// environment variable and the secrets redacted.
+func (cfg *apiConfig) logEffectiveConfig() {
+	if !cfg.logger.Enabled(context.Background(), slog.LevelDebug) {
+		return
+	}
+	fc := cfg.effectiveConfig()
+	values := fc.envValues()
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	attrs := make([]any, 0, len(keys))
+	for _, key := range keys {
+		attrs = append(attrs, slog.String(key, values[key]))
+	}
+	cfg.logger.Debug("effective configuration", slog.Group("config", attrs...))
+}
+
+// effectiveConfig returns the configuration the application is running with, in the config
+// file schema. API keys are redacted and passwords are removed from connection URLs.
+func (cfg *apiConfig) effectiveConfig() fileConfig {
+	var fc fileConfig
+	fc.Database.Driver = cfg.dbDriver
+	fc.Database.URL = redactURL(cfg.dbURL)
+	fc.Redis.URL = redactURL(cfg.redisURL)
+	fc.Server.Port = cfg.port
+	fc.Server.DevMode = &cfg.devMode
+	fc.Server.DemoMode = &cfg.demoMode
+	fc.Server.DefaultUnits = cfg.defaultUnits.String()
+	if cfg.logLevel != nil {
+		fc.Server.LogLevel = strings.ToLower(cfg.logLevel.Level().String())
+	}
+	logSampleRate := cfg.logSampler.rate()
+	fc.Server.LogSampleRate = &logSampleRate
+
+	currentMin := int(cfg.schedulerCurrentInterval.Minutes())
+	hourlyMin := int(cfg.schedulerHourlyInterval.Minutes())
+	dailyMin := int(cfg.schedulerDailyInterval.Minutes())
+	airQualityMin := int(cfg.schedulerAirQualityInterval.Minutes())
+	fc.Scheduler.CurrentIntervalMin = &currentMin
+	fc.Scheduler.HourlyIntervalMin = &hourlyMin
+	fc.Scheduler.DailyIntervalMin = &dailyMin
+	fc.Scheduler.AirQualityIntervalMin = &airQualityMin
+	jitterSec := int(cfg.schedulerJitter.Seconds())
+	drainTimeoutSec := int(cfg.schedulerDrainTimeout.Seconds())
+	jobTimeoutSec := int(cfg.schedulerJobTimeout.Seconds())
+	locationTimeoutSec := int(cfg.schedulerLocationTimeout.Seconds())
+	fc.Scheduler.Concurrency = &cfg.schedulerConcurrency
+	fc.Scheduler.JitterSec = &jitterSec
+	fc.Scheduler.DrainTimeoutSec = &drainTimeoutSec
+	fc.Scheduler.JobTimeoutSec = &jobTimeoutSec
+	fc.Scheduler.LocationTimeoutSec = &locationTimeoutSec
+	fc.Scheduler.ArchiveHistory = &cfg.archiveHistory
+	if p := cfg.refreshPolicy; p != nil {
+		fc.Scheduler.Demand.IdleDays = &p.idleDays
+		fc.Scheduler.Demand.EvictDays = &p.evictDays
+		fc.Scheduler.Demand.IdleStride = &p.idleStride
+		fc.Scheduler.Demand.NormalStride = &p.normalStride
+		fc.Scheduler.Demand.HotLocations = &p.hotLocations
+	}
+	fc.Scheduler.Retention.HourlyHours = &cfg.retentionHourlyHours
+	fc.Scheduler.Retention.DailyDays = &cfg.retentionDailyDays
+
+	dbMaxConnIdleSec := int(cfg.dbMaxConnIdleTime.Seconds())
+	dbHealthCheckPeriodSec := int(cfg.dbHealthCheckPeriod.Seconds())
+	fc.Database.MaxConns = &cfg.dbMaxConns
+	fc.Database.MaxConnIdleSec = &dbMaxConnIdleSec
+	fc.Database.HealthCheckPeriodSec = &dbHealthCheckPeriodSec
+	fc.Database.MigrateOnStart = &cfg.migrateOnStart
+
+	localCacheTTLSec := int(cfg.localCacheTTL.Seconds())
+	fc.Cache.Backend = cfg.cacheBackend
+	fc.Cache.LocalSize = &cfg.localCacheSize
+	fc.Cache.LocalTTLSec = &localCacheTTLSec
+
+	forecastDays := cfg.dailyForecastDays()
+	forecastHours := cfg.hourlyForecastHours()
+	fc.Forecast.DailyDays = &forecastDays
+	fc.Forecast.HourlyHours = &forecastHours
+
+	for _, p := range weatherProviders {
+		if cfg.sourceEnabled(p.ID) {
+			fc.Providers.Sources = append(fc.Providers.Sources, p.ID)
+		}
+	}
+	fc.Providers.CostPerCall = cfg.providerPricing
+	fc.Providers.HedgePercentile = &cfg.hedgePercentile
+	if cfg.quota.enabled() {
+		fc.Providers.DailyQuota = cfg.quota.limits
+		fc.Providers.QuotaDegradePercent = &cfg.quota.degradePercent
+	}
+	if cfg.rateLimits.enabled() {
+		fc.Providers.RateLimit = cfg.rateLimits.limits
+	}
+	if len(cfg.providerWeights) > 0 {
+		fc.Providers.Weights = cfg.providerWeights
+	}
+	if cfg.breakers != nil {
+		cooldownSec := int(cfg.breakers.cooldown.Seconds())
+		fc.Providers.CircuitBreaker.Threshold = &cfg.breakers.threshold
+		fc.Providers.CircuitBreaker.CooldownSec = &cooldownSec
+	}
+	retryBaseMs := int(cfg.fetchRetryBase.Milliseconds())
+	fc.Providers.Retry.MaxRetries = &cfg.fetchMaxRetries
+	fc.Providers.Retry.BaseMs = &retryBaseMs
+	fc.Providers.GMP.Key = redactSecret(cfg.gmpKey.Get())
+	fc.Providers.GMP.GeocodeURL = cfg.gmpGeocodeURL
+	fc.Providers.GMP.WeatherURL = cfg.gmpWeatherURL
+	fc.Providers.GMP.TimezoneURL = cfg.gmpTimezoneURL
+	fc.Providers.OWM.Key = redactSecret(cfg.owmKey.Get())
+	fc.Providers.OWM.WeatherURL = cfg.owmWeatherURL
+	fc.Providers.OWM.LegacyWeatherURL = cfg.owmLegacyWeatherURL
+	fc.Providers.OWM.AirPollutionURL = cfg.owmAirPollutionURL
+	fc.Providers.OMeteo.WeatherURL = cfg.ometeoWeatherURL
+	fc.Providers.OMeteo.ArchiveURL = cfg.ometeoArchiveURL
+	fc.Providers.OMeteo.AirQualityURL = cfg.ometeoAirQualityURL
+	fc.Providers.MetNo.WeatherURL = cfg.metnoWeatherURL
+	fc.Providers.Geocoder = strings.Join(cfg.geocoderProviders, ",")
+	fc.Providers.Nominatim.URL = cfg.nominatimURL
+	fc.Providers.UserAgent = cfg.userAgent
+	fc.Suggestions.DefaultCities = cfg.citySuggestions
+	return fc
+}
+
+// printConfig writes the effective configuration as YAML.
+func (cfg *apiConfig) printConfig(w io.Writer) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(cfg.effectiveConfig()); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// redactSecret hides a non-empty secret value.
+func redactSecret(s string) string {
+	if s == "" {
+		return ""
+	}
+	return redactedValue
+}
+
+// redactURL removes the password from a connection URL. Values that cannot be parsed as URLs
+// are redacted entirely.
+func redactURL(raw string) string {
+	if raw == "" {
+		return ""
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return redactedValue
+	}
+	return u.Redacted()
+}
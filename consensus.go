@@ -0,0 +1,278 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// This file implements the consensus endpoint, which merges the forecasts of all sources into a
+// single forecast per hour or day, so that clients do not have to reconcile the entries of every
+// source themselves. Numeric fields take the median of the sources, except the precipitation
+// chance, which is averaged, and the condition is the one reported by most sources; with provider
+// weights, the sources are blended by weight instead (see blending.go). Every field carries a
+// confidence level derived from how far apart the sources are.
+
+// Consensus periods selected with the period query parameter.
+const (
+	consensusPeriodHourly = "hourly"
+	consensusPeriodDaily  = "daily"
+)
+
+// Confidence levels of consensus fields. A field reported by a single source has low confidence.
+const (
+	confidenceHigh   = "high"
+	confidenceMedium = "medium"
+	confidenceLow    = "low"
+)
+
+// spreadThresholds are the largest differences between the highest and lowest source value,
+// in the unit of a field, that still give high and medium confidence.
+type spreadThresholds struct {
+	high, medium float64
+}
+
+var (
+	temperatureSpread         = spreadThresholds{high: 2, medium: 5}
+	precipitationSpread       = spreadThresholds{high: 1, medium: 3}
+	precipitationChanceSpread = spreadThresholds{high: 20, medium: 40}
+	windSpeedSpread           = spreadThresholds{high: 5, medium: 15}
+	humiditySpread            = spreadThresholds{high: 10, medium: 25}
+)
+
+// @Summary      Get consensus forecast
+// @Description  Merges the forecasts of all sources into one forecast per hour (period=hourly, the default)
+// @Description  or per day (period=daily). Numeric fields are the median of the sources, the precipitation
+// @Description  chance is their average and the condition is the one reported by most sources. Each field has
+// @Description  a confidence of high, medium or low, based on how far apart the sources are. If provider weights
+// @Description  are configured for the location, numeric fields are the weighted mean of the sources and the
+// @Description  condition is the one with the most weight; blend reports which was used.
+// @Tags         weather
+// @Produce      json
+// @Param        city    query     string  false  "Location name to search for (e.g., 'London')"
+// @Param        lat     query     number  false  "Latitude for the location (e.g., 51.5074)"
+// @Param        lon     query     number  false  "Longitude for the location (e.g., -0.1278)"
+// @Param        period  query     string  false  "Forecast period: 'hourly' (default) or 'daily'"
+// @Success      200  {object}  ConsensusResponse
+// @Failure      400  {object}  ErrorResponse "Bad Request - Invalid location or period parameter"
+// @Failure      500  {object}  ErrorResponse "Internal Server Error - Failed to retrieve forecast data"
+// @Router       /api/v1/consensus [get]
+func (cfg *apiConfig) handlerConsensus(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if r.Method != http.MethodGet {
+		cfg.respondWithError(w, http.StatusMethodNotAllowed, "Method Not Allowed", nil)
+		return
+	}
+
+	period := r.URL.Query().Get("period")
+	if period == "" {
+		period = consensusPeriodHourly
+	}
+	if period != consensusPeriodHourly && period != consensusPeriodDaily {
+		cfg.respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid period %q, must be hourly or daily", period), nil)
+		return
+	}
+
+	location, err := cfg.getLocationFromRequest(r)
+	if err != nil {
+		cfg.respondWithError(w, http.StatusBadRequest, "Error getting location data", err)
+		return
+	}
+	cfg.logger.Debug("consensus request", "city", location.CityName, "period", period)
+
+	loc, err := time.LoadLocation(location.Timezone)
+	if err != nil {
+		cfg.logger.Warn("could not load location timezone, falling back to UTC", "timezone", location.Timezone, "error", err)
+		loc = time.UTC
+	}
+
+	weights, err := cfg.locationProviderWeights(ctx, location.LocationID)
+	if err != nil {
+		cfg.respondWithError(w, http.StatusInternalServerError, "Error getting provider weights", err)
+		return
+	}
+	blend := newSourceWeights(weights)
+
+	response := ConsensusResponse{Location: location, Period: period, Blend: blendMedian, Weights: weights}
+	if blend != nil {
+		response.Blend = blendWeighted
+	}
+	var sources []string
+	if period == consensusPeriodHourly {
+		forecast, err := cfg.getCachedOrFetchHourlyForecast(ctx, location)
+		if err != nil {
+			cfg.respondWithError(w, http.StatusInternalServerError, "Error getting hourly forecast data", err)
+			return
+		}
+		response.Hours = consensusHours(forecast, loc, blend)
+		for _, f := range forecast {
+			sources = append(sources, f.SourceAPI)
+		}
+	} else {
+		forecast, err := cfg.getCachedOrFetchDailyForecast(ctx, location)
+		if err != nil {
+			cfg.respondWithError(w, http.StatusInternalServerError, "Error getting daily forecast data", err)
+			return
+		}
+		response.Days = consensusDays(forecast, loc, blend)
+		for _, f := range forecast {
+			sources = append(sources, f.SourceAPI)
+		}
+	}
+	response.Attribution = attributionForSources(sources)
+
+	cfg.respondWithJSON(w, http.StatusOK, response)
+}
+
+// consensusHours merges the hourly forecasts of all sources into one entry per forecast hour,
+// in chronological order. Times are formatted in loc. Non-nil weights blend the sources by weight.
+func consensusHours(forecast []HourlyForecast, loc *time.Location, weights sourceWeights) []ConsensusHourJSON {
+	byTime := make(map[time.Time][]HourlyForecast)
+	for _, f := range forecast {
+		byTime[f.ForecastDateTime] = append(byTime[f.ForecastDateTime], f)
+	}
+	times := make([]time.Time, 0, len(byTime))
+	for t := range byTime {
+		times = append(times, t)
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+
+	hours := make([]ConsensusHourJSON, 0, len(times))
+	for _, t := range times {
+		group := blendGroup(weights, byTime[t], func(f HourlyForecast) string { return f.SourceAPI })
+		var sources, codes []string
+		var temperature, humidity, windSpeed, precipitation, chance, weight []float64
+		for _, f := range group {
+			sources = append(sources, f.SourceAPI)
+			if weights != nil {
+				weight = append(weight, weights.weight(f.SourceAPI))
+			}
+			codes = append(codes, normalizeCondition(f.Condition))
+			temperature = append(temperature, f.Temperature)
+			humidity = append(humidity, float64(f.Humidity))
+			windSpeed = append(windSpeed, f.WindSpeed)
+			precipitation = append(precipitation, f.Precipitation)
+			chance = append(chance, float64(f.PrecipitationChance))
+		}
+		sort.Strings(sources)
+		hours = append(hours, ConsensusHourJSON{
+			ForecastDateTime:    t.In(loc).Format("2006-01-02 15:04"),
+			Sources:             sources,
+			Temperature:         blendedMedian(temperature, weight, temperatureSpread),
+			Humidity:            blendedMedian(humidity, weight, humiditySpread),
+			WindSpeed:           blendedMedian(windSpeed, weight, windSpeedSpread),
+			Precipitation:       blendedMedian(precipitation, weight, precipitationSpread),
+			PrecipitationChance: blendedMean(chance, weight, precipitationChanceSpread),
+			Condition:           blendedCondition(codes, weight),
+		})
+	}
+	return hours
+}
+
+// consensusDays merges the daily forecasts of all sources into one entry per forecast date,
+// in chronological order. Dates are formatted in loc. Non-nil weights blend the sources by weight.
+func consensusDays(forecast []DailyForecast, loc *time.Location, weights sourceWeights) []ConsensusDayJSON {
+	byDate := make(map[time.Time][]DailyForecast)
+	for _, f := range forecast {
+		byDate[f.ForecastDate] = append(byDate[f.ForecastDate], f)
+	}
+	dates := make([]time.Time, 0, len(byDate))
+	for d := range byDate {
+		dates = append(dates, d)
+	}
+	sort.Slice(dates, func(i, j int) bool { return dates[i].Before(dates[j]) })
+
+	days := make([]ConsensusDayJSON, 0, len(dates))
+	for _, d := range dates {
+		group := blendGroup(weights, byDate[d], func(f DailyForecast) string { return f.SourceAPI })
+		var sources, codes []string
+		var minTemp, maxTemp, precipitation, chance, windSpeed, humidity, weight []float64
+		for _, f := range group {
+			sources = append(sources, f.SourceAPI)
+			if weights != nil {
+				weight = append(weight, weights.weight(f.SourceAPI))
+			}
+			codes = append(codes, dailyConditionCode(f))
+			minTemp = append(minTemp, f.MinTemp)
+			maxTemp = append(maxTemp, f.MaxTemp)
+			precipitation = append(precipitation, f.Precipitation)
+			chance = append(chance, float64(f.PrecipitationChance))
+			windSpeed = append(windSpeed, f.WindSpeed)
+			humidity = append(humidity, float64(f.Humidity))
+		}
+		sort.Strings(sources)
+		days = append(days, ConsensusDayJSON{
+			ForecastDate:        d.In(loc).Format("2006-01-02"),
+			Sources:             sources,
+			MinTemp:             blendedMedian(minTemp, weight, temperatureSpread),
+			MaxTemp:             blendedMedian(maxTemp, weight, temperatureSpread),
+			Precipitation:       blendedMedian(precipitation, weight, precipitationSpread),
+			PrecipitationChance: blendedMean(chance, weight, precipitationChanceSpread),
+			WindSpeed:           blendedMedian(windSpeed, weight, windSpeedSpread),
+			Humidity:            blendedMedian(humidity, weight, humiditySpread),
+			Condition:           blendedCondition(codes, weight),
+		})
+	}
+	return days
+}
+
+// consensusMedian aggregates the values of all sources to their median.
+func consensusMedian(values []float64, thresholds spreadThresholds) ConsensusValueJSON {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	median := sorted[len(sorted)/2]
+	if len(sorted)%2 == 0 {
+		median = (sorted[len(sorted)/2-1] + median) / 2
+	}
+	return consensusValue(median, sorted, thresholds)
+}
+
+// consensusMean aggregates the values of all sources to their average.
+func consensusMean(values []float64, thresholds spreadThresholds) ConsensusValueJSON {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	var sum float64
+	for _, v := range sorted {
+		sum += v
+	}
+	return consensusValue(sum/float64(len(sorted)), sorted, thresholds)
+}
+
+// consensusValue describes an aggregated value together with the range of the sorted source
+// values and the confidence that follows from it.
+func consensusValue(value float64, sorted []float64, thresholds spreadThresholds) ConsensusValueJSON {
+	lo, hi := sorted[0], sorted[len(sorted)-1]
+	confidence := confidenceLow
+	switch spread := hi - lo; {
+	case len(sorted) < 2:
+	case spread <= thresholds.high:
+		confidence = confidenceHigh
+	case spread <= thresholds.medium:
+		confidence = confidenceMedium
+	}
+	return ConsensusValueJSON{
+		Value:      Round(value, 1),
+		Min:        Round(lo, 1),
+		Max:        Round(hi, 1),
+		Confidence: confidence,
+	}
+}
+
+// consensusCondition picks the condition code reported by most sources. The confidence is high
+// if all sources with a known condition agree and medium if more than half of them do.
+func consensusCondition(codes []string) ConsensusConditionJSON {
+	code, votes, known := majorityCondition(codes)
+	condition := ConsensusConditionJSON{Code: code, Label: englishLocale.conditionLabel(code), Confidence: confidenceLow}
+	if known == 0 {
+		return condition
+	}
+	condition.Agreement = Round(float64(votes)/float64(known), 2)
+	switch {
+	case known >= 2 && votes == known:
+		condition.Confidence = confidenceHigh
+	case known >= 2 && votes*2 > known:
+		condition.Confidence = confidenceMedium
+	}
+	return condition
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/cor0nius/willitrain/internal/database"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestConsensusCondition(t *testing.T) {
+	testCases := []struct {
+		name  string
+		codes []string
+		want  ConsensusConditionJSON
+	}{
+		{"unanimous", []string{conditionRain, conditionRain, conditionRain}, ConsensusConditionJSON{Code: conditionRain, Label: "Rain", Agreement: 1, Confidence: confidenceHigh}},
+		{"majority", []string{conditionRain, conditionRain, conditionCloudy}, ConsensusConditionJSON{Code: conditionRain, Label: "Rain", Agreement: 0.67, Confidence: confidenceMedium}},
+		{"split favors severity", []string{conditionClear, conditionRain}, ConsensusConditionJSON{Code: conditionRain, Label: "Rain", Agreement: 0.5, Confidence: confidenceLow}},
+		{"unknown is not counted", []string{conditionSnow, conditionSnow, conditionUnknown}, ConsensusConditionJSON{Code: conditionSnow, Label: "Snow", Agreement: 1, Confidence: confidenceHigh}},
+		{"single source", []string{conditionFog}, ConsensusConditionJSON{Code: conditionFog, Label: "Fog", Agreement: 1, Confidence: confidenceLow}},
+		{"all unknown", []string{conditionUnknown}, ConsensusConditionJSON{Code: conditionUnknown, Label: "Unknown", Confidence: confidenceLow}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := consensusCondition(tc.codes); got != tc.want {
+				t.Errorf("consensusCondition() = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestConsensusValues(t *testing.T) {
+	testCases := []struct {
+		name string
+		got  ConsensusValueJSON
+		want ConsensusValueJSON
+	}{
+		{"median of odd count", consensusMedian([]float64{12, 10, 11}, temperatureSpread), ConsensusValueJSON{Value: 11, Min: 10, Max: 12, Confidence: confidenceHigh}},
+		{"median of even count", consensusMedian([]float64{10, 14}, temperatureSpread), ConsensusValueJSON{Value: 12, Min: 10, Max: 14, Confidence: confidenceMedium}},
+		{"wide spread", consensusMedian([]float64{2, 9, 10}, temperatureSpread), ConsensusValueJSON{Value: 9, Min: 2, Max: 10, Confidence: confidenceLow}},
+		{"single source", consensusMedian([]float64{7.25}, temperatureSpread), ConsensusValueJSON{Value: 7.3, Min: 7.3, Max: 7.3, Confidence: confidenceLow}},
+		{"mean", consensusMean([]float64{10, 20, 60}, precipitationChanceSpread), ConsensusValueJSON{Value: 30, Min: 10, Max: 60, Confidence: confidenceLow}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.got != tc.want {
+				t.Errorf("got %+v, want %+v", tc.got, tc.want)
+			}
+		})
+	}
+}
+
+func TestConsensusDays(t *testing.T) {
+	day := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	forecast := []DailyForecast{
+		{SourceAPI: "b", ForecastDate: day.Add(24 * time.Hour), MinTemp: 8, MaxTemp: 18},
+		{SourceAPI: "b", ForecastDate: day, MinTemp: 5, MaxTemp: 15, Precipitation: 4, PrecipitationChance: 80},
+		{SourceAPI: "a", ForecastDate: day, MinTemp: 6, MaxTemp: 16, Precipitation: 6, PrecipitationChance: 60},
+	}
+
+	days := consensusDays(forecast, time.UTC, nil)
+	if len(days) != 2 || days[0].ForecastDate != "2025-06-01" || days[1].ForecastDate != "2025-06-02" {
+		t.Fatalf("unexpected days: %+v", days)
+	}
+	first := days[0]
+	if !reflect.DeepEqual(first.Sources, []string{"a", "b"}) {
+		t.Errorf("sources = %v, want [a b]", first.Sources)
+	}
+	if first.MinTemp.Value != 5.5 || first.MaxTemp.Value != 15.5 || first.PrecipitationChance.Value != 70 {
+		t.Errorf("unexpected aggregates: %+v", first)
+	}
+	if first.Condition.Code != dailyConditionCode(forecast[1]) || first.Condition.Confidence != confidenceHigh {
+		t.Errorf("unexpected condition: %+v", first.Condition)
+	}
+	if days[1].MaxTemp.Confidence != confidenceLow {
+		t.Errorf("expected low confidence for a single source, got %+v", days[1].MaxTemp)
+	}
+}
+
+func TestHandlerConsensus(t *testing.T) {
+	mockDBLocation := MockDBLocation
+	hour1 := MockDBHourlyForecast1.ForecastDatetimeUtc.In(time.UTC).Format("2006-01-02 15:04")
+
+	testCases := []struct {
+		name       string
+		method     string
+		query      string
+		overrides  []database.LocationProviderWeight
+		wantStatus int
+		check      func(t *testing.T, response ConsensusResponse)
+	}{
+		{name: "method not allowed", method: http.MethodPost, query: "?city=wroclaw", wantStatus: http.StatusMethodNotAllowed},
+		{name: "invalid period", method: http.MethodGet, query: "?city=wroclaw&period=weekly", wantStatus: http.StatusBadRequest},
+		{
+			name:       "hourly by default",
+			method:     http.MethodGet,
+			query:      "?city=wroclaw",
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, response ConsensusResponse) {
+				if response.Period != consensusPeriodHourly || response.Blend != blendMedian || len(response.Hours) != 2 || response.Days != nil {
+					t.Fatalf("unexpected response: %+v", response)
+				}
+				first := response.Hours[0]
+				if first.ForecastDateTime != hour1 || !reflect.DeepEqual(first.Sources, []string{"test1", "test2"}) {
+					t.Errorf("unexpected first hour: %+v", first)
+				}
+				want := ConsensusValueJSON{Value: 10.5, Min: 10, Max: 11, Confidence: confidenceHigh}
+				if first.Temperature != want {
+					t.Errorf("temperature = %+v, want %+v", first.Temperature, want)
+				}
+				if first.Condition.Code != conditionCloudy || first.Condition.Agreement != 0.5 {
+					t.Errorf("unexpected condition: %+v", first.Condition)
+				}
+				if len(response.Attribution) != 0 {
+					t.Errorf("expected no attribution for test sources, got %+v", response.Attribution)
+				}
+			},
+		},
+		{
+			name:       "daily",
+			method:     http.MethodGet,
+			query:      "?city=wroclaw&period=daily",
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, response ConsensusResponse) {
+				if response.Period != consensusPeriodDaily || len(response.Days) == 0 || response.Hours != nil {
+					t.Fatalf("unexpected response: %+v", response)
+				}
+			},
+		},
+		{
+			name:       "weighted blend",
+			method:     http.MethodGet,
+			query:      "?city=wroclaw",
+			overrides:  []database.LocationProviderWeight{{Provider: "owm", Weight: 2}},
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, response ConsensusResponse) {
+				if response.Blend != blendWeighted || !reflect.DeepEqual(response.Weights, map[string]float64{"owm": 2}) {
+					t.Errorf("unexpected blend: %s %v", response.Blend, response.Weights)
+				}
+				// The test sources have no weight, so they are averaged.
+				if want := (ConsensusValueJSON{Value: 10.5, Min: 10, Max: 11, Confidence: confidenceHigh}); response.Hours[0].Temperature != want {
+					t.Errorf("temperature = %+v, want %+v", response.Hours[0].Temperature, want)
+				}
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testCfg := newTestAPIConfig(t)
+			testCfg.mockDB.GetLocationByAliasFunc = func(ctx context.Context, alias string) (database.Location, error) {
+				return mockDBLocation, nil
+			}
+			testCfg.mockCache.GetFunc = func(ctx context.Context, key string) (string, error) {
+				return "", redis.Nil
+			}
+			testCfg.mockDB.ListLocationProviderWeightsFunc = func(ctx context.Context, locationID uuid.UUID) ([]database.LocationProviderWeight, error) {
+				return tc.overrides, nil
+			}
+			testCfg.mockDB.GetUpcomingHourlyForecastsAtLocationFunc = func(ctx context.Context, arg database.GetUpcomingHourlyForecastsAtLocationParams) ([]database.HourlyForecast, error) {
+				return []database.HourlyForecast{MockDBHourlyForecast1, MockDBHourlyForecast2, MockDBHourlyForecast3}, nil
+			}
+			testCfg.mockDB.GetUpcomingDailyForecastsAtLocationFunc = func(ctx context.Context, arg database.GetUpcomingDailyForecastsAtLocationParams) ([]database.DailyForecast, error) {
+				return []database.DailyForecast{MockDBDailyForecast1, MockDBDailyForecast2, MockDBDailyForecast3}, nil
+			}
+
+			req := httptest.NewRequest(tc.method, "/api/consensus"+tc.query, nil)
+			rr := httptest.NewRecorder()
+			testCfg.apiConfig.handlerConsensus(rr, req)
+
+			if rr.Code != tc.wantStatus {
+				t.Fatalf("status = %d, want %d; body: %s", rr.Code, tc.wantStatus, rr.Body.String())
+			}
+			if tc.check == nil {
+				return
+			}
+			var response ConsensusResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			tc.check(t, response)
+		})
+	}
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// This file implements the provider cost report. It combines the provider usage persisted by
+// the usage tracker over a window of recent hours with the configured per-call prices to
+// estimate the monthly spend per provider and per location. It also projects what the spend
+// would be if providers were queried in a fallback order (one at a time, moving on only on
+// failure) instead of all at once.
+
+// costReportMonth is the period that monthly estimates are extrapolated to.
+const costReportMonth = 30 * 24 * time.Hour
+
+// @Summary      Estimate provider costs
+// @Description  Reports per-provider call counts and estimated monthly spend per provider and per location,
+// @Description  extrapolated from the provider usage recorded over the given window, which survives restarts.
+// @Description  Includes a what-if estimate for querying providers in fallback order, and the upstream API
+// @Description  version in use for providers with several supported versions.
+// @Tags         admin
+// @Produce      json
+// @Param        order  query     string  false  "Comma-separated provider IDs for the fallback what-if (default: cheapest first)"
+// @Param        hours  query     int     false  "Length of the window in hours (default 168, max 2160)"
+// @Success      200  {object}  CostReportResponse
+// @Failure      400  {object}  ErrorResponse "Bad Request - Invalid provider order or window"
+// @Failure      500  {object}  ErrorResponse "Internal Server Error - Failed to get provider usage"
+// @Security     ApiKeyAuth
+// @Failure      401  {object}  ErrorResponse "Unauthorized - Missing API key"
+// @Failure      403  {object}  ErrorResponse "Forbidden - Invalid API key"
+// @Router       /admin/costs [get]
+func (cfg *apiConfig) handlerCosts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		cfg.respondWithError(w, http.StatusMethodNotAllowed, "Method Not Allowed", nil)
+		return
+	}
+
+	order, err := parseProviderOrder(r.URL.Query().Get("order"), cfg.providerPricing)
+	if err != nil {
+		cfg.respondWithError(w, http.StatusBadRequest, "Invalid provider order", err)
+		return
+	}
+
+	hours, err := parseStatsParam(r, "hours", defaultStatsWindowHours, maxStatsWindowHours)
+	if err != nil {
+		cfg.respondWithError(w, http.StatusBadRequest, "Invalid window", err)
+		return
+	}
+
+	// The usage of the last few minutes is flushed first, so that the report includes it.
+	if err := cfg.flushProviderUsage(r.Context()); err != nil {
+		cfg.logger.WarnContext(r.Context(), "could not flush provider usage", "error", err)
+	}
+	now := time.Now()
+	since := statsHour(now).Add(-time.Duration(hours-1) * time.Hour)
+	snap, err := cfg.loadUsageSnapshot(r.Context(), since, now)
+	if err != nil {
+		cfg.respondWithError(w, http.StatusInternalServerError, "Failed to get provider usage", err)
+		return
+	}
+
+	report := buildCostReport(snap, cfg.providerPricing, order)
+	for i := range report.Providers {
+		report.Providers[i].APIVersion = cfg.providerAPIVersion(report.Providers[i].Provider)
+	}
+	cfg.respondWithJSON(w, http.StatusOK, report)
+}
+
+// parseProviderOrder validates a comma-separated list of provider IDs. An empty value yields
+// all registered providers ordered from cheapest to most expensive.
+func parseProviderOrder(value string, pricing map[string]float64) ([]string, error) {
+	if value == "" {
+		order := make([]string, len(weatherProviders))
+		for i, p := range weatherProviders {
+			order[i] = p.ID
+		}
+		sort.SliceStable(order, func(i, j int) bool {
+			return pricing[order[i]] < pricing[order[j]]
+		})
+		return order, nil
+	}
+
+	var order []string
+	for _, id := range strings.Split(value, ",") {
+		id = strings.TrimSpace(id)
+		if _, ok := providerByID(id); !ok {
+			return nil, fmt.Errorf("unknown provider %q", id)
+		}
+		if slices.Contains(order, id) {
+			return nil, fmt.Errorf("provider %q listed more than once", id)
+		}
+		order = append(order, id)
+	}
+	if len(order) == 0 {
+		return nil, errors.New("no providers given")
+	}
+	return order, nil
+}
+
+// buildCostReport computes the cost report from a usage snapshot.
+func buildCostReport(snap usageSnapshot, pricing map[string]float64, order []string) CostReportResponse {
+	observed := snap.TakenAt.Sub(snap.StartedAt)
+	monthFactor := 0.0
+	if observed > 0 {
+		monthFactor = float64(costReportMonth) / float64(observed)
+	}
+
+	report := CostReportResponse{
+		ObservedSince: snap.StartedAt.UTC().Format(time.RFC3339),
+		ObservedHours: roundCost(observed.Hours()),
+		Operations:    snap.Operations,
+		Providers:     make([]ProviderCostJSON, 0, len(weatherProviders)),
+		Locations:     make([]LocationCostJSON, 0, len(snap.Locations)),
+	}
+
+	for _, p := range weatherProviders {
+		usage := snap.Providers[p.ID]
+		cost := float64(usage.Calls) * pricing[p.ID]
+		report.Providers = append(report.Providers, ProviderCostJSON{
+			Provider:        p.ID,
+			DisplayName:     p.DisplayName,
+			Calls:           usage.Calls,
+			Failures:        usage.Failures,
+			CostPerCall:     pricing[p.ID],
+			ObservedCost:    roundCost(cost),
+			MonthlyEstimate: roundCost(cost * monthFactor),
+		})
+		report.TotalObservedCost += cost
+	}
+	report.TotalMonthlyEstimate = roundCost(report.TotalObservedCost * monthFactor)
+	report.TotalObservedCost = roundCost(report.TotalObservedCost)
+
+	for id, l := range snap.Locations {
+		var cost float64
+		for providerID, calls := range l.Calls {
+			cost += float64(calls) * pricing[providerID]
+		}
+		report.Locations = append(report.Locations, LocationCostJSON{
+			LocationID:      id,
+			CityName:        l.CityName,
+			Operations:      l.Operations,
+			ObservedCost:    roundCost(cost),
+			MonthlyEstimate: roundCost(cost * monthFactor),
+		})
+	}
+	sort.Slice(report.Locations, func(i, j int) bool {
+		if report.Locations[i].ObservedCost != report.Locations[j].ObservedCost {
+			return report.Locations[i].ObservedCost > report.Locations[j].ObservedCost
+		}
+		return report.Locations[i].CityName < report.Locations[j].CityName
+	})
+
+	report.FallbackWhatIf = estimateFallbackCosts(snap, pricing, order, monthFactor)
+	report.FallbackWhatIf.MonthlySavings = roundCost(report.TotalMonthlyEstimate - report.FallbackWhatIf.MonthlyEstimate)
+	return report
+}
+
+// estimateFallbackCosts projects provider usage if each operation queried the providers in order,
+// stopping at the first success. A provider is reached only when all previous ones failed, so its
+// expected calls are the operation count times the product of the earlier providers' failure rates.
+// Providers without observed calls are assumed never to fail.
+func estimateFallbackCosts(snap usageSnapshot, pricing map[string]float64, order []string, monthFactor float64) FallbackWhatIfJSON {
+	whatIf := FallbackWhatIfJSON{
+		Order:     order,
+		Providers: make([]FallbackProviderCostJSON, 0, len(order)),
+	}
+
+	reaching := float64(snap.Operations)
+	var total float64
+	for _, id := range order {
+		cost := reaching * pricing[id] * monthFactor
+		whatIf.Providers = append(whatIf.Providers, FallbackProviderCostJSON{
+			Provider:        id,
+			ExpectedCalls:   roundCost(reaching),
+			MonthlyEstimate: roundCost(cost),
+		})
+		total += cost
+
+		var failureRate float64
+		if usage := snap.Providers[id]; usage.Calls > 0 {
+			failureRate = float64(usage.Failures) / float64(usage.Calls)
+		}
+		reaching *= failureRate
+	}
+	whatIf.MonthlyEstimate = roundCost(total)
+	return whatIf
+}
+
+// roundCost rounds a value to six decimal places for presentation.
+func roundCost(v float64) float64 {
+	return math.Round(v*1e6) / 1e6
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/cor0nius/willitrain/internal/database"
+	"github.com/google/uuid"
+)
+
+func TestBuildCostReport(t *testing.T) {
+	start := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	calls := make(map[string]int64)
+	providers := make(map[string]providerUsage)
+	for _, p := range weatherProviders {
+		calls[p.ID] = 10
+		providers[p.ID] = providerUsage{Calls: 10}
+	}
+	providers["ometeo"] = providerUsage{Calls: 10, Failures: 2}
+
+	pricing := map[string]float64{"gmp": 0.01, "owm": 0.1, "ometeo": 0}
+	// One day observed, so monthly figures are 30 times the observed ones.
+	snap := usageSnapshot{
+		StartedAt:  start,
+		TakenAt:    start.Add(24 * time.Hour),
+		Operations: 10,
+		Providers:  providers,
+		Locations:  map[uuid.UUID]locationUsage{MockLocation.LocationID: {CityName: "Wroclaw", Operations: 10, Calls: calls}},
+	}
+
+	report := buildCostReport(snap, pricing, []string{"ometeo", "gmp", "owm"})
+
+	if report.Operations != 10 {
+		t.Errorf("expected 10 operations, got %d", report.Operations)
+	}
+	if report.TotalObservedCost != 1.1 {
+		t.Errorf("expected observed cost 1.1, got %v", report.TotalObservedCost)
+	}
+	if report.TotalMonthlyEstimate != 33 {
+		t.Errorf("expected monthly estimate 33, got %v", report.TotalMonthlyEstimate)
+	}
+	if len(report.Providers) != len(weatherProviders) || report.Providers[0].Provider != "gmp" || report.Providers[0].MonthlyEstimate != 3 {
+		t.Errorf("unexpected provider breakdown: %+v", report.Providers)
+	}
+	if len(report.Locations) != 1 || report.Locations[0].CityName != "Wroclaw" || report.Locations[0].MonthlyEstimate != 33 {
+		t.Errorf("unexpected location breakdown: %+v", report.Locations)
+	}
+
+	// Open-Meteo fails 20% of the time, so GMP is reached for 2 of 10 operations and OWM never.
+	wantFallback := []FallbackProviderCostJSON{
+		{Provider: "ometeo", ExpectedCalls: 10, MonthlyEstimate: 0},
+		{Provider: "gmp", ExpectedCalls: 2, MonthlyEstimate: 0.6},
+		{Provider: "owm", ExpectedCalls: 0, MonthlyEstimate: 0},
+	}
+	if !reflect.DeepEqual(report.FallbackWhatIf.Providers, wantFallback) {
+		t.Errorf("unexpected fallback breakdown:\ngot  %+v\nwant %+v", report.FallbackWhatIf.Providers, wantFallback)
+	}
+	if report.FallbackWhatIf.MonthlySavings != 32.4 {
+		t.Errorf("expected monthly savings 32.4, got %v", report.FallbackWhatIf.MonthlySavings)
+	}
+}
+
+func TestParseProviderOrder(t *testing.T) {
+	pricing := map[string]float64{"gmp": 0.01, "owm": 0.1, "ometeo": 0}
+
+	testCases := []struct {
+		name    string
+		value   string
+		want    []string
+		wantErr bool
+	}{
+		{name: "Default Is Cheapest First", value: "", want: []string{"ometeo", "metno", "gmp", "owm"}},
+		{name: "Explicit Partial Order", value: "owm, gmp", want: []string{"owm", "gmp"}},
+		{name: "Unknown Provider", value: "gmp,accuweather", wantErr: true},
+		{name: "Duplicate Provider", value: "gmp,gmp", wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parseProviderOrder(tc.value, pricing)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("expected error: %v, got: %v", tc.wantErr, err)
+			}
+			if !tc.wantErr && !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("expected %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestHandlerCosts(t *testing.T) {
+	testCases := []struct {
+		name       string
+		method     string
+		target     string
+		dbErr      error
+		wantStatus int
+	}{
+		{name: "Success", method: http.MethodGet, target: "/admin/costs?hours=24", wantStatus: http.StatusOK},
+		{name: "Invalid Order", method: http.MethodGet, target: "/admin/costs?order=foo", wantStatus: http.StatusBadRequest},
+		{name: "Invalid Window", method: http.MethodGet, target: "/admin/costs?hours=0", wantStatus: http.StatusBadRequest},
+		{name: "Database Error", method: http.MethodGet, target: "/admin/costs", dbErr: errors.New("db error"), wantStatus: http.StatusInternalServerError},
+		{name: "Wrong Method", method: http.MethodPost, target: "/admin/costs", wantStatus: http.StatusMethodNotAllowed},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testCfg := newTestAPIConfig(t)
+			testCfg.usage = newProviderUsageTracker()
+			testCfg.usage.recordOperation(MockLocation)
+			testCfg.usage.recordCall(MockLocation, "owm")
+
+			// Unflushed usage is flushed before the report is built.
+			flushed := 0
+			testCfg.mockDB.IncrementProviderUsageStatsFunc = func(ctx context.Context, arg database.IncrementProviderUsageStatsParams) error {
+				flushed++
+				return nil
+			}
+			testCfg.mockDB.IncrementLocationFetchStatsFunc = func(ctx context.Context, arg database.IncrementLocationFetchStatsParams) error {
+				flushed++
+				return nil
+			}
+			var since time.Time
+			testCfg.mockDB.GetFirstProviderUsageHourSinceFunc = func(ctx context.Context, hour time.Time) (time.Time, error) {
+				since = hour
+				return statsHour(time.Now()).Add(-2 * time.Hour), tc.dbErr
+			}
+			testCfg.mockDB.GetLocationFetchesSinceFunc = func(ctx context.Context, hour time.Time) ([]database.GetLocationFetchesSinceRow, error) {
+				return []database.GetLocationFetchesSinceRow{{ID: MockLocation.LocationID, CityName: "Wroclaw", OperationCount: 3}}, nil
+			}
+			testCfg.mockDB.GetProviderUsageSinceFunc = func(ctx context.Context, hour time.Time) ([]database.GetProviderUsageSinceRow, error) {
+				return []database.GetProviderUsageSinceRow{{ID: MockLocation.LocationID, CityName: "Wroclaw", Provider: "owm", CallCount: 3, FailureCount: 1}}, nil
+			}
+
+			req := httptest.NewRequest(tc.method, tc.target, nil)
+			rr := httptest.NewRecorder()
+
+			testCfg.apiConfig.handlerCosts(rr, req)
+
+			if rr.Code != tc.wantStatus {
+				t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, tc.wantStatus)
+			}
+			if tc.wantStatus != http.StatusOK {
+				return
+			}
+			if flushed != 2 {
+				t.Errorf("expected the usage to be flushed, got %d increments", flushed)
+			}
+			if want := statsHour(time.Now()).Add(-23 * time.Hour); !since.Equal(want) {
+				t.Errorf("window starts at %v, want %v", since, want)
+			}
+			var report CostReportResponse
+			if err := json.NewDecoder(rr.Body).Decode(&report); err != nil {
+				t.Fatalf("could not decode report: %v", err)
+			}
+			if report.Operations != 3 || report.ObservedHours < 2 || report.ObservedHours > 3 {
+				t.Errorf("unexpected report window: %+v", report)
+			}
+			if len(report.Locations) != 1 || report.Locations[0].CityName != "Wroclaw" {
+				t.Errorf("unexpected location breakdown: %+v", report.Locations)
+			}
+			for _, p := range report.Providers {
+				if p.Provider == "owm" && (p.Calls != 3 || p.Failures != 1) {
+					t.Errorf("unexpected owm usage: %+v", p)
+				}
+			}
+		})
+	}
+}
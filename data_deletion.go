@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// This file implements end-user data deletion. A subscriber can erase everything stored about
+// them through /api/me/delete, and operators can do the same on a subscriber's behalf through
+// /admin/subscribers/{id}/delete. All deletions run in one transaction, so a subscriber's data
+// is either removed completely or not at all, and the response is a receipt that lists what was
+// deleted. Every data store keyed by subscriber must be registered in subscriberDataStores.
+
+// subscriberDataStore is a category of data tied to a subscriber.
+type subscriberDataStore struct {
+	// Name identifies the category in deletion receipts.
+	Name string
+	// Delete removes the subscriber's data and returns the number of deleted records.
+	Delete func(ctx context.Context, q dbQuerier, subscriberID string) (int64, error)
+}
+
+// subscriberDataStores lists every category of data that is deleted with a subscriber.
+var subscriberDataStores = []subscriberDataStore{
+	{
+		Name: "watchlist_entries",
+		Delete: func(ctx context.Context, q dbQuerier, subscriberID string) (int64, error) {
+			return q.DeleteWatchlistEntriesForSubscriber(ctx, subscriberID)
+		},
+	},
+	{
+		Name: "alert_subscriptions",
+		Delete: func(ctx context.Context, q dbQuerier, subscriberID string) (int64, error) {
+			return q.DeleteAlertSubscriptionsForSubscriber(ctx, subscriberID)
+		},
+	},
+	{
+		Name: "location_groups",
+		Delete: func(ctx context.Context, q dbQuerier, subscriberID string) (int64, error) {
+			return q.DeleteLocationGroupsForSubscriber(ctx, subscriberID)
+		},
+	},
+	{
+		Name: "user_accounts",
+		Delete: func(ctx context.Context, q dbQuerier, subscriberID string) (int64, error) {
+			rest, ok := strings.CutPrefix(subscriberID, "user:")
+			if !ok {
+				return 0, nil
+			}
+			userID, err := uuid.Parse(rest)
+			if err != nil {
+				return 0, fmt.Errorf("invalid user subscriber ID: %w", err)
+			}
+			return q.DeleteUser(ctx, userID)
+		},
+	},
+}
+
+// deleteSubscriberData removes all data tied to a subscriber in a single transaction and
+// returns a receipt describing the deletion.
+func (cfg *apiConfig) deleteSubscriberData(ctx context.Context, subscriberID string) (DeletionReceiptJSON, error) {
+	deleted := make(map[string]int64, len(subscriberDataStores))
+	err := cfg.runInTx(ctx, func(q dbQuerier) error {
+		for _, store := range subscriberDataStores {
+			n, err := store.Delete(ctx, q, subscriberID)
+			if err != nil {
+				return fmt.Errorf("could not delete %s: %w", store.Name, err)
+			}
+			deleted[store.Name] = n
+		}
+		return nil
+	})
+	if err != nil {
+		return DeletionReceiptJSON{}, err
+	}
+
+	var total int64
+	for _, n := range deleted {
+		total += n
+	}
+	return DeletionReceiptJSON{
+		ReceiptID:    uuid.New().String(),
+		SubscriberID: subscriberID,
+		DeletedAt:    time.Now().UTC().Format(time.RFC3339),
+		Deleted:      deleted,
+		TotalDeleted: total,
+	}, nil
+}
+
+// @Summary      Delete my data
+// @Description  Deletes all data stored for the subscriber identified by the session token or the X-API-Key or
+// @Description  X-Device-ID header, in a single transaction, and returns a deletion receipt. With a session token,
+// @Description  the user account is deleted as well.
+// @Tags         privacy
+// @Produce      json
+// @Param        X-API-Key    header    string  false  "API key identifying the subscriber"
+// @Param        X-Device-ID  header    string  false  "Device ID identifying the subscriber"
+// @Success      200  {object}  DeletionReceiptJSON
+// @Failure      400  {object}  ErrorResponse "Bad Request - Missing subscriber"
+// @Failure      500  {object}  ErrorResponse "Internal Server Error - Failed to delete data"
+// @Router       /api/v1/me/delete [post]
+func (cfg *apiConfig) handlerDeleteMyData(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		cfg.respondWithError(w, http.StatusMethodNotAllowed, "Method Not Allowed", nil)
+		return
+	}
+
+	subscriberID, err := getSubscriberID(r)
+	if err != nil {
+		cfg.respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	cfg.respondWithDeletionReceipt(w, r, subscriberID, "subscriber")
+}
+
+// @Summary      Delete a subscriber's data
+// @Description  Deletes all data stored for the given subscriber ID (e.g. "device:abc", "key:<sha256>" or "user:<id>"),
+// @Description  in a single transaction, and returns a deletion receipt. The deletion is audit-logged.
+// @Tags         admin
+// @Produce      json
+// @Param        id   path      string  true  "Subscriber ID"
+// @Success      200  {object}  DeletionReceiptJSON
+// @Failure      400  {object}  ErrorResponse "Bad Request - Invalid subscriber ID"
+// @Failure      500  {object}  ErrorResponse "Internal Server Error - Failed to delete data"
+// @Security     ApiKeyAuth
+// @Failure      401  {object}  ErrorResponse "Unauthorized - Missing API key"
+// @Failure      403  {object}  ErrorResponse "Forbidden - Invalid API key"
+// @Router       /admin/subscribers/{id}/delete [post]
+func (cfg *apiConfig) handlerAdminDeleteSubscriberData(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		cfg.respondWithError(w, http.StatusMethodNotAllowed, "Method Not Allowed", nil)
+		return
+	}
+
+	subscriberID := r.PathValue("id")
+	if !isValidSubscriberID(subscriberID) {
+		cfg.respondWithError(w, http.StatusBadRequest, "Invalid subscriber ID", nil)
+		return
+	}
+
+	cfg.respondWithDeletionReceipt(w, r, subscriberID, "admin")
+}
+
+// isValidSubscriberID reports whether id has the form produced by getSubscriberID.
+func isValidSubscriberID(id string) bool {
+	if rest, ok := strings.CutPrefix(id, "user:"); ok {
+		_, err := uuid.Parse(rest)
+		return err == nil
+	}
+	for _, prefix := range []string{"key:", "device:"} {
+		if rest, ok := strings.CutPrefix(id, prefix); ok {
+			return rest != ""
+		}
+	}
+	return false
+}
+
+// respondWithDeletionReceipt deletes the subscriber's data, writes an audit event and responds
+// with the receipt. The audit event carries the receipt ID so that the deletion can be traced
+// without logging the subscriber ID itself.
+func (cfg *apiConfig) respondWithDeletionReceipt(w http.ResponseWriter, r *http.Request, subscriberID, requestedBy string) {
+	receipt, err := cfg.deleteSubscriberData(r.Context(), subscriberID)
+	if err != nil {
+		cfg.respondWithError(w, http.StatusInternalServerError, "Failed to delete data", err)
+		return
+	}
+
+	cfg.logger.Info("audit: subscriber data deleted",
+		"receipt_id", receipt.ReceiptID,
+		"requested_by", requestedBy,
+		"total_deleted", receipt.TotalDeleted,
+		"remote_addr", r.RemoteAddr,
+	)
+	cfg.respondWithJSON(w, http.StatusOK, receipt)
+}
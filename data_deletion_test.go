@@ -0,0 +1,260 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+)
+
+func TestHandlerDeleteMyData(t *testing.T) {
+	testCases := []struct {
+		name          string
+		requestMethod string
+		headers       map[string]string
+		setupMocks    func(cfg *testAPIConfig)
+		wantStatus    int
+		wantDeleted   int64
+		wantAlerts    int64
+		wantGroups    int64
+		wantSubID     string
+	}{
+		{
+			name:          "Device ID",
+			requestMethod: http.MethodPost,
+			headers:       map[string]string{"X-Device-ID": "abc"},
+			setupMocks: func(cfg *testAPIConfig) {
+				cfg.mockDB.DeleteWatchlistEntriesForSubscriberFunc = func(ctx context.Context, subscriberID string) (int64, error) {
+					if subscriberID != "device:abc" {
+						t.Errorf("unexpected subscriber ID: %s", subscriberID)
+					}
+					return 3, nil
+				}
+				cfg.mockDB.DeleteAlertSubscriptionsForSubscriberFunc = func(ctx context.Context, subscriberID string) (int64, error) {
+					if subscriberID != "device:abc" {
+						t.Errorf("unexpected subscriber ID: %s", subscriberID)
+					}
+					return 2, nil
+				}
+				cfg.mockDB.DeleteLocationGroupsForSubscriberFunc = func(ctx context.Context, subscriberID string) (int64, error) {
+					if subscriberID != "device:abc" {
+						t.Errorf("unexpected subscriber ID: %s", subscriberID)
+					}
+					return 1, nil
+				}
+			},
+			wantStatus:  http.StatusOK,
+			wantDeleted: 3,
+			wantAlerts:  2,
+			wantGroups:  1,
+			wantSubID:   "device:abc",
+		},
+		{
+			name:          "API Key Is Hashed",
+			requestMethod: http.MethodPost,
+			headers:       map[string]string{"X-API-Key": "secret"},
+			setupMocks: func(cfg *testAPIConfig) {
+				cfg.mockDB.DeleteWatchlistEntriesForSubscriberFunc = func(ctx context.Context, subscriberID string) (int64, error) {
+					return 0, nil
+				}
+				cfg.mockDB.DeleteAlertSubscriptionsForSubscriberFunc = func(ctx context.Context, subscriberID string) (int64, error) {
+					return 0, nil
+				}
+				cfg.mockDB.DeleteLocationGroupsForSubscriberFunc = func(ctx context.Context, subscriberID string) (int64, error) {
+					return 0, nil
+				}
+			},
+			wantStatus:  http.StatusOK,
+			wantDeleted: 0,
+			wantSubID:   "key:2bb80d537b1da3e38bd30361aa855686bde0eacd7162fef6a25fe97bf527a25b",
+		},
+		{
+			name:          "Missing Subscriber",
+			requestMethod: http.MethodPost,
+			wantStatus:    http.StatusBadRequest,
+		},
+		{
+			name:          "Database Error",
+			requestMethod: http.MethodPost,
+			headers:       map[string]string{"X-Device-ID": "abc"},
+			setupMocks: func(cfg *testAPIConfig) {
+				cfg.mockDB.DeleteWatchlistEntriesForSubscriberFunc = func(ctx context.Context, subscriberID string) (int64, error) {
+					return 0, errors.New("db error")
+				}
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name:          "Method Not Allowed",
+			requestMethod: http.MethodGet,
+			headers:       map[string]string{"X-Device-ID": "abc"},
+			wantStatus:    http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testCfg := newTestAPIConfig(t)
+			if tc.setupMocks != nil {
+				tc.setupMocks(testCfg)
+			}
+
+			req := httptest.NewRequest(tc.requestMethod, "/api/me/delete", nil)
+			for k, v := range tc.headers {
+				req.Header.Set(k, v)
+			}
+			rr := httptest.NewRecorder()
+
+			testCfg.apiConfig.handlerDeleteMyData(rr, req)
+
+			if status := rr.Code; status != tc.wantStatus {
+				t.Fatalf("handler returned wrong status code: got %v want %v", status, tc.wantStatus)
+			}
+			if tc.wantStatus != http.StatusOK {
+				return
+			}
+
+			var receipt DeletionReceiptJSON
+			if err := json.Unmarshal(rr.Body.Bytes(), &receipt); err != nil {
+				t.Fatalf("could not decode receipt: %v", err)
+			}
+			if receipt.SubscriberID != tc.wantSubID {
+				t.Errorf("unexpected subscriber ID: got %s want %s", receipt.SubscriberID, tc.wantSubID)
+			}
+			if receipt.ReceiptID == "" || receipt.DeletedAt == "" {
+				t.Errorf("receipt is missing its ID or timestamp: %+v", receipt)
+			}
+			if got := receipt.Deleted["watchlist_entries"]; got != tc.wantDeleted {
+				t.Errorf("unexpected watchlist_entries count: got %d want %d", got, tc.wantDeleted)
+			}
+			if got := receipt.Deleted["alert_subscriptions"]; got != tc.wantAlerts {
+				t.Errorf("unexpected alert_subscriptions count: got %d want %d", got, tc.wantAlerts)
+			}
+			if got := receipt.Deleted["location_groups"]; got != tc.wantGroups {
+				t.Errorf("unexpected location_groups count: got %d want %d", got, tc.wantGroups)
+			}
+			if want := tc.wantDeleted + tc.wantAlerts + tc.wantGroups; receipt.TotalDeleted != want {
+				t.Errorf("unexpected total: got %d want %d", receipt.TotalDeleted, want)
+			}
+		})
+	}
+}
+
+func TestHandlerAdminDeleteSubscriberData(t *testing.T) {
+	testCases := []struct {
+		name         string
+		subscriberID string
+		wantStatus   int
+	}{
+		{name: "Device Subscriber", subscriberID: "device:abc", wantStatus: http.StatusOK},
+		{name: "Key Subscriber", subscriberID: "key:2bb80d53", wantStatus: http.StatusOK},
+		{name: "User Subscriber", subscriberID: "user:" + MockLocation.LocationID.String(), wantStatus: http.StatusOK},
+		{name: "Invalid User ID", subscriberID: "user:abc", wantStatus: http.StatusBadRequest},
+		{name: "Unknown Prefix", subscriberID: "account:abc", wantStatus: http.StatusBadRequest},
+		{name: "Empty Device ID", subscriberID: "device:", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testCfg := newTestAPIConfig(t)
+			var logBuf bytes.Buffer
+			testCfg.logger = slog.New(slog.NewTextHandler(&logBuf, nil))
+			testCfg.mockDB.DeleteWatchlistEntriesForSubscriberFunc = func(ctx context.Context, subscriberID string) (int64, error) {
+				return 1, nil
+			}
+			testCfg.mockDB.DeleteAlertSubscriptionsForSubscriberFunc = func(ctx context.Context, subscriberID string) (int64, error) {
+				return 1, nil
+			}
+			testCfg.mockDB.DeleteLocationGroupsForSubscriberFunc = func(ctx context.Context, subscriberID string) (int64, error) {
+				return 1, nil
+			}
+			testCfg.mockDB.DeleteUserFunc = func(ctx context.Context, id uuid.UUID) (int64, error) {
+				if "user:"+id.String() != tc.subscriberID {
+					t.Errorf("deleted user %s, want %s", id, tc.subscriberID)
+				}
+				return 1, nil
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/admin/subscribers/"+tc.subscriberID+"/delete", nil)
+			req.SetPathValue("id", tc.subscriberID)
+			rr := httptest.NewRecorder()
+
+			testCfg.apiConfig.handlerAdminDeleteSubscriberData(rr, req)
+
+			if status := rr.Code; status != tc.wantStatus {
+				t.Fatalf("handler returned wrong status code: got %v want %v", status, tc.wantStatus)
+			}
+			if tc.wantStatus != http.StatusOK {
+				return
+			}
+			logs := logBuf.String()
+			if !strings.Contains(logs, "audit: subscriber data deleted") || !strings.Contains(logs, "requested_by=admin") {
+				t.Errorf("expected an audit log entry, got %q", logs)
+			}
+			if strings.Contains(logs, tc.subscriberID) {
+				t.Errorf("audit log must not contain the subscriber ID, got %q", logs)
+			}
+		})
+	}
+}
+
+func TestDeleteSubscriberDataTransaction(t *testing.T) {
+	testCases := []struct {
+		name      string
+		setupMock func(mock sqlmock.Sqlmock)
+		wantErr   bool
+	}{
+		{
+			name: "Commit",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("DELETE FROM watchlist_entries").WithArgs("device:abc").WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectExec("DELETE FROM alert_subscriptions").WithArgs("device:abc").WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("DELETE FROM location_groups").WithArgs("device:abc").WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "Rollback On Error",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("DELETE FROM watchlist_entries").WithArgs("device:abc").WillReturnError(errors.New("db error"))
+				mock.ExpectRollback()
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("could not create sqlmock: %v", err)
+			}
+			defer db.Close()
+			tc.setupMock(mock)
+
+			testCfg := newTestAPIConfig(t)
+			testCfg.db = db
+
+			receipt, err := testCfg.deleteSubscriberData(context.Background(), "device:abc")
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !tc.wantErr && (receipt.Deleted["watchlist_entries"] != 2 || receipt.Deleted["alert_subscriptions"] != 1) {
+				t.Errorf("unexpected receipt: %+v", receipt)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unmet expectations: %v", err)
+			}
+		})
+	}
+}
@@ -2,19 +2,32 @@ package main
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
 
 	"github.com/cor0nius/willitrain/internal/database"
 	"github.com/google/uuid"
-	_ "github.com/lib/pq"
+	"github.com/jackc/pgx/v5/pgconn"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
 )
 
 // ConnectDB establishes a connection to the PostgreSQL database using the provided
 // connection string in the apiConfig struct. It initializes the dbQueries field with
 // a sqlc-generated Queries struct, which provides type-safe methods for all database
 // operations. This method should be called during application startup to ensure that
-// the database is reachable before handling any requests.
+// the database is reachable before handling any requests. With MIGRATE_ON_START, it
+// applies the pending migrations. With DB_DRIVER=sqlite, it opens the SQLite database
+// file instead and always brings its schema up to date.
 func (cfg *apiConfig) ConnectDB() error {
-	db, err := cfg.newDBClientFunc("postgres", cfg.dbURL)
+	driverName, dataSourceName := "pgx", cfg.dbURL
+	if cfg.dbDriver == dbDriverSQLite {
+		registerSQLiteFunctions()
+		driverName, dataSourceName = "sqlite", sqliteDSN(cfg.dbURL)
+	}
+	db, err := cfg.newDBClientFunc(driverName, dataSourceName)
 	if err != nil {
 		cfg.logger.Error("couldn't prepare connection to database", "error", err)
 		return err
@@ -23,42 +36,190 @@ func (cfg *apiConfig) ConnectDB() error {
 		cfg.logger.Error("couldn't connect to database", "error", err)
 		return err
 	}
-	cfg.dbQueries = database.New(db)
+	switch {
+	case cfg.dbDriver == dbDriverSQLite:
+		if err := migrateSQLite(context.Background(), db); err != nil {
+			cfg.logger.Error("couldn't migrate database", "error", err)
+			return err
+		}
+	case cfg.migrateOnStart:
+		if err := cfg.migratePostgres(context.Background(), db); err != nil {
+			cfg.logger.Error("couldn't migrate database", "error", err)
+			return err
+		}
+	}
+	cfg.db = db
+	cfg.dbQueries = cfg.newQueries(db)
 	cfg.logger.Info("connected to database")
 	return nil
 }
 
+// runInTx runs fn with a querier bound to a single database transaction, which is committed if
+// fn succeeds and rolled back otherwise. Without a database connection, as in tests that inject
+// a mock querier, fn runs on cfg.dbQueries directly.
+func (cfg *apiConfig) runInTx(ctx context.Context, fn func(q dbQuerier) error) error {
+	if cfg.db == nil {
+		return fn(cfg.dbQueries)
+	}
+	return database.WithTx(ctx, cfg.db, func(tx *sql.Tx) error {
+		return fn(cfg.newQueries(tx))
+	})
+}
+
+// pgUniqueViolation is PostgreSQL's error code for a violated unique constraint.
+const pgUniqueViolation = "23505"
+
+// isUniqueViolation reports whether err is a violated unique constraint, from either database
+// driver, such as when a concurrent request inserted the same row first.
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == pgUniqueViolation
+	}
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE
+	}
+	return false
+}
+
+// newQueries returns the sqlc queries bound to a connection or transaction, traced and, with
+// the SQLite driver, translated to SQLite.
+func (cfg *apiConfig) newQueries(db database.DBTX) *database.Queries {
+	if cfg.dbDriver == dbDriverSQLite {
+		db = sqliteDB{db}
+	}
+	return database.New(tracedDB{db})
+}
+
 // dbQuerier is an interface that abstracts all database operations.
 // It is implemented by the sqlc-generated Queries struct, allowing for dependency
 // injection and easy mocking in tests. This decouples business logic from the data layer.
 type dbQuerier interface {
+	AddLocationGroupMember(ctx context.Context, arg database.AddLocationGroupMemberParams) error
+	ArchiveCurrentWeatherAtLocation(ctx context.Context, arg database.ArchiveCurrentWeatherAtLocationParams) (int64, error)
+	ArchiveDailyForecastsAtLocation(ctx context.Context, arg database.ArchiveDailyForecastsAtLocationParams) (int64, error)
+	ArchiveHourlyForecastsAtLocation(ctx context.Context, arg database.ArchiveHourlyForecastsAtLocationParams) (int64, error)
+	ClaimDueAlertDeliveries(ctx context.Context, arg database.ClaimDueAlertDeliveriesParams) ([]database.AlertDelivery, error)
+	CountAlertSubscriptionsForSubscriber(ctx context.Context, subscriberID string) (int64, error)
+	CountLocationGroupMembers(ctx context.Context, groupID uuid.UUID) (int64, error)
+	CreateAPIKey(ctx context.Context, arg database.CreateAPIKeyParams) (database.ApiKey, error)
+	CreateAirQuality(ctx context.Context, arg database.CreateAirQualityParams) (database.AirQuality, error)
+	CreateAlertDelivery(ctx context.Context, arg database.CreateAlertDeliveryParams) error
+	CreateAlertSubscription(ctx context.Context, arg database.CreateAlertSubscriptionParams) (database.AlertSubscription, error)
 	CreateCurrentWeather(ctx context.Context, arg database.CreateCurrentWeatherParams) (database.CurrentWeather, error)
 	CreateDailyForecast(ctx context.Context, arg database.CreateDailyForecastParams) (database.DailyForecast, error)
 	CreateHourlyForecast(ctx context.Context, arg database.CreateHourlyForecastParams) (database.HourlyForecast, error)
+	CreateJobRun(ctx context.Context, arg database.CreateJobRunParams) (database.JobRun, error)
+	CreateJobRunLocation(ctx context.Context, arg database.CreateJobRunLocationParams) error
 	CreateLocation(ctx context.Context, arg database.CreateLocationParams) (database.Location, error)
 	CreateLocationAlias(ctx context.Context, arg database.CreateLocationAliasParams) (database.LocationAlias, error)
+	CreateLocationGroup(ctx context.Context, arg database.CreateLocationGroupParams) (database.LocationGroup, error)
+	CreateSchedulerRun(ctx context.Context, arg database.CreateSchedulerRunParams) error
+	CreateUser(ctx context.Context, arg database.CreateUserParams) (database.User, error)
+	CreateWatchlistEntry(ctx context.Context, arg database.CreateWatchlistEntryParams) (database.WatchlistEntry, error)
+	DeleteAirQualityAtLocation(ctx context.Context, locationID uuid.UUID) error
+	DeleteAlertDeliveriesBefore(ctx context.Context, createdAt time.Time) (int64, error)
+	DeleteAlertSubscription(ctx context.Context, arg database.DeleteAlertSubscriptionParams) (int64, error)
+	DeleteAlertSubscriptionsForSubscriber(ctx context.Context, subscriberID string) (int64, error)
 	DeleteAllCurrentWeather(ctx context.Context) error
 	DeleteAllDailyForecasts(ctx context.Context) error
 	DeleteAllHourlyForecasts(ctx context.Context) error
 	DeleteAllLocations(ctx context.Context) error
 	DeleteCurrentWeatherAtLocation(ctx context.Context, locationID uuid.UUID) error
+	DeleteDailyForecastHistoryBefore(ctx context.Context, forecastDate time.Time) (int64, error)
 	DeleteDailyForecastsAtLocation(ctx context.Context, locationID uuid.UUID) error
+	DeleteDailyForecastsBefore(ctx context.Context, forecastDate time.Time) (int64, error)
+	DeleteHourlyForecastHistoryBefore(ctx context.Context, forecastDatetimeUtc time.Time) (int64, error)
 	DeleteHourlyForecastsAtLocation(ctx context.Context, locationID uuid.UUID) error
+	DeleteHourlyForecastsBefore(ctx context.Context, forecastDatetimeUtc time.Time) (int64, error)
+	DeleteIdleLocations(ctx context.Context, lastAccessedAt time.Time) ([]database.DeleteIdleLocationsRow, error)
+	DeleteJobRunsBefore(ctx context.Context, startedAt time.Time) (int64, error)
 	DeleteLocation(ctx context.Context, id uuid.UUID) error
+	DeleteLocationAlias(ctx context.Context, arg database.DeleteLocationAliasParams) (int64, error)
+	DeleteLocationGroup(ctx context.Context, arg database.DeleteLocationGroupParams) (int64, error)
+	DeleteLocationGroupsForSubscriber(ctx context.Context, subscriberID string) (int64, error)
+	DeleteLocationProviderWeights(ctx context.Context, locationID uuid.UUID) (int64, error)
+	DeleteProviderDisagreementBefore(ctx context.Context, computedOn time.Time) (int64, error)
+	DeleteSchedulerInterval(ctx context.Context, jobName string) error
+	DeleteSchedulerRunsBefore(ctx context.Context, startedAt time.Time) (int64, error)
+	DeleteUser(ctx context.Context, id uuid.UUID) (int64, error)
+	DeleteWatchlistEntriesForSubscriber(ctx context.Context, subscriberID string) (int64, error)
+	DeleteWatchlistEntry(ctx context.Context, arg database.DeleteWatchlistEntryParams) error
+	FinishJobRun(ctx context.Context, arg database.FinishJobRunParams) error
+	FinishJobRunLocation(ctx context.Context, arg database.FinishJobRunLocationParams) error
+	GetActiveAPIKeyByHash(ctx context.Context, keyHash string) (database.ApiKey, error)
+	GetAirQualityAtLocation(ctx context.Context, locationID uuid.UUID) ([]database.AirQuality, error)
+	GetAirQualityAtLocationFromAPI(ctx context.Context, arg database.GetAirQualityAtLocationFromAPIParams) (database.AirQuality, error)
 	GetAllDailyForecastsAtLocation(ctx context.Context, locationID uuid.UUID) ([]database.DailyForecast, error)
 	GetAllHourlyForecastsAtLocation(ctx context.Context, locationID uuid.UUID) ([]database.HourlyForecast, error)
 	GetCurrentWeatherAtLocation(ctx context.Context, locationID uuid.UUID) ([]database.CurrentWeather, error)
 	GetCurrentWeatherAtLocationFromAPI(ctx context.Context, arg database.GetCurrentWeatherAtLocationFromAPIParams) (database.CurrentWeather, error)
 	GetDailyForecastAtLocationAndDateFromAPI(ctx context.Context, arg database.GetDailyForecastAtLocationAndDateFromAPIParams) (database.DailyForecast, error)
+	GetEndpointRequestStatsSince(ctx context.Context, hour time.Time) ([]database.EndpointRequestStat, error)
+	GetFirstProviderUsageHourSince(ctx context.Context, hour time.Time) (time.Time, error)
 	GetHourlyForecastAtLocationAndTimeFromAPI(ctx context.Context, arg database.GetHourlyForecastAtLocationAndTimeFromAPIParams) (database.HourlyForecast, error)
+	GetJobRun(ctx context.Context, id uuid.UUID) (database.JobRun, error)
+	GetLatestWeatherObservation(ctx context.Context, arg database.GetLatestWeatherObservationParams) (database.WeatherObservation, error)
 	GetLocationByAlias(ctx context.Context, alias string) (database.Location, error)
 	GetLocationByCoordinates(ctx context.Context, arg database.GetLocationByCoordinatesParams) (database.Location, error)
+	GetLocationByID(ctx context.Context, id uuid.UUID) (database.Location, error)
 	GetLocationByName(ctx context.Context, cityName string) (database.Location, error)
+	GetLocationFetchesSince(ctx context.Context, hour time.Time) ([]database.GetLocationFetchesSinceRow, error)
+	GetLocationGroup(ctx context.Context, arg database.GetLocationGroupParams) (database.LocationGroup, error)
+	GetProviderUsageSince(ctx context.Context, hour time.Time) ([]database.GetProviderUsageSinceRow, error)
+	GetTopLocationsByRequestsSince(ctx context.Context, arg database.GetTopLocationsByRequestsSinceParams) ([]database.GetTopLocationsByRequestsSinceRow, error)
 	GetUpcomingDailyForecastsAtLocation(ctx context.Context, arg database.GetUpcomingDailyForecastsAtLocationParams) ([]database.DailyForecast, error)
 	GetUpcomingHourlyForecastsAtLocation(ctx context.Context, arg database.GetUpcomingHourlyForecastsAtLocationParams) ([]database.HourlyForecast, error)
+	GetUserByEmail(ctx context.Context, email string) (database.User, error)
+	GetUserByID(ctx context.Context, id uuid.UUID) (database.User, error)
+	GetWatchlistUpdates(ctx context.Context, arg database.GetWatchlistUpdatesParams) ([]database.GetWatchlistUpdatesRow, error)
+	GetWeatherWarningsAtLocation(ctx context.Context, locationID uuid.UUID) ([]database.WeatherWarning, error)
+	IncrementEndpointRequestStats(ctx context.Context, arg database.IncrementEndpointRequestStatsParams) error
+	IncrementLocationFetchStats(ctx context.Context, arg database.IncrementLocationFetchStatsParams) error
+	IncrementLocationRequestStats(ctx context.Context, arg database.IncrementLocationRequestStatsParams) error
+	IncrementProviderUsageStats(ctx context.Context, arg database.IncrementProviderUsageStatsParams) error
+	InterruptUnfinishedJobRuns(ctx context.Context, finishedAt sql.NullTime) (int64, error)
+	ListAlertSubscriptionsForLocation(ctx context.Context, locationID uuid.UUID) ([]database.AlertSubscription, error)
+	ListAlertSubscriptionsForSubscriber(ctx context.Context, subscriberID string) ([]database.AlertSubscription, error)
+	ListCurrentWeatherHistory(ctx context.Context, arg database.ListCurrentWeatherHistoryParams) ([]database.CurrentWeatherHistory, error)
+	ListDailyForecastHistory(ctx context.Context, arg database.ListDailyForecastHistoryParams) ([]database.DailyForecastHistory, error)
+	ListHourlyForecastHistory(ctx context.Context, arg database.ListHourlyForecastHistoryParams) ([]database.HourlyForecastHistory, error)
+	ListJobRunLocations(ctx context.Context, jobRunID uuid.UUID) ([]database.ListJobRunLocationsRow, error)
+	ListJobRunLocationsForLocation(ctx context.Context, arg database.ListJobRunLocationsForLocationParams) ([]database.ListJobRunLocationsForLocationRow, error)
+	ListJobRuns(ctx context.Context, arg database.ListJobRunsParams) ([]database.JobRun, error)
+	ListLatestProviderDisagreement(ctx context.Context, locationID uuid.UUID) ([]database.ProviderDisagreement, error)
+	ListLocationAliases(ctx context.Context, locationID uuid.UUID) ([]database.LocationAlias, error)
+	ListLocationDemand(ctx context.Context, hour time.Time) ([]database.ListLocationDemandRow, error)
+	ListLocationGroupMembers(ctx context.Context, groupID uuid.UUID) ([]database.Location, error)
+	ListLocationGroupsForSubscriber(ctx context.Context, subscriberID string) ([]database.LocationGroup, error)
+	ListLocationProviderWeights(ctx context.Context, locationID uuid.UUID) ([]database.LocationProviderWeight, error)
 	ListLocations(ctx context.Context) ([]database.Location, error)
+	ListSchedulerIntervals(ctx context.Context) ([]database.SchedulerInterval, error)
+	ListSchedulerRunsForLocation(ctx context.Context, arg database.ListSchedulerRunsForLocationParams) ([]database.SchedulerRun, error)
+	ListWatchedLocationIDs(ctx context.Context) ([]uuid.UUID, error)
+	ListWatchlistLocations(ctx context.Context, subscriberID string) ([]database.Location, error)
+	MoveAlertSubscriptions(ctx context.Context, arg database.MoveAlertSubscriptionsParams) (int64, error)
+	MoveLocationAliases(ctx context.Context, arg database.MoveLocationAliasesParams) (int64, error)
+	MoveLocationGroupMembers(ctx context.Context, arg database.MoveLocationGroupMembersParams) (int64, error)
+	MoveWatchlistEntries(ctx context.Context, arg database.MoveWatchlistEntriesParams) (int64, error)
+	RecordAlertDeliveryAttempt(ctx context.Context, arg database.RecordAlertDeliveryAttemptParams) error
+	RemoveLocationGroupMember(ctx context.Context, arg database.RemoveLocationGroupMemberParams) (int64, error)
+	StartJobRunLocation(ctx context.Context, arg database.StartJobRunLocationParams) error
+	TouchLocationAccess(ctx context.Context, arg database.TouchLocationAccessParams) error
+	UpdateAirQuality(ctx context.Context, arg database.UpdateAirQualityParams) (database.AirQuality, error)
+	UpdateAlertSubscriptionState(ctx context.Context, arg database.UpdateAlertSubscriptionStateParams) error
 	UpdateCurrentWeather(ctx context.Context, arg database.UpdateCurrentWeatherParams) (database.CurrentWeather, error)
 	UpdateDailyForecast(ctx context.Context, arg database.UpdateDailyForecastParams) (database.DailyForecast, error)
 	UpdateHourlyForecast(ctx context.Context, arg database.UpdateHourlyForecastParams) (database.HourlyForecast, error)
 	UpdateTimezone(ctx context.Context, arg database.UpdateTimezoneParams) error
-}
\ No newline at end of file
+	UpdateUserPreferences(ctx context.Context, arg database.UpdateUserPreferencesParams) (database.User, error)
+	UpsertDailyForecasts(ctx context.Context, forecasts json.RawMessage) (int64, error)
+	UpsertHourlyForecasts(ctx context.Context, forecasts json.RawMessage) (int64, error)
+	UpsertLocationAlias(ctx context.Context, arg database.UpsertLocationAliasParams) (database.LocationAlias, error)
+	UpsertLocationProviderWeight(ctx context.Context, arg database.UpsertLocationProviderWeightParams) error
+	UpsertProviderDisagreement(ctx context.Context, arg database.UpsertProviderDisagreementParams) error
+	UpsertSchedulerInterval(ctx context.Context, arg database.UpsertSchedulerIntervalParams) error
+	UpsertWeatherObservation(ctx context.Context, arg database.UpsertWeatherObservationParams) error
+	UpsertWeatherWarnings(ctx context.Context, arg database.UpsertWeatherWarningsParams) error
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// This file implements the PostgreSQL connection pool. Connections are pooled by pgxpool rather
+// than by database/sql, so that the pool size, the idle timeout and the health checks of idle
+// connections can be configured, and the sqlc queries use the pool through a *sql.DB. The pool
+// statistics are exported to Prometheus, to tell connection exhaustion apart from slow queries.
+
+// Defaults for the PostgreSQL connection pool.
+const (
+	defaultDBMaxConns          = 10
+	defaultDBMaxConnIdleTime   = 5 * time.Minute
+	defaultDBHealthCheckPeriod = time.Minute
+)
+
+// getDBMaxConns reads the maximum number of pooled connections from DB_MAX_CONNS.
+func getDBMaxConns(logger *slog.Logger) int {
+	n := getEnvAsInt("DB_MAX_CONNS", defaultDBMaxConns, logger)
+	if n <= 0 {
+		logger.Warn("DB_MAX_CONNS must be positive, using default", "value", n)
+		return defaultDBMaxConns
+	}
+	return n
+}
+
+// getDBMaxConnIdleTime reads how long a connection may stay idle before it is closed from
+// DB_MAX_CONN_IDLE_SEC.
+func getDBMaxConnIdleTime(logger *slog.Logger) time.Duration {
+	sec := getEnvAsInt("DB_MAX_CONN_IDLE_SEC", int(defaultDBMaxConnIdleTime.Seconds()), logger)
+	if sec <= 0 {
+		logger.Warn("DB_MAX_CONN_IDLE_SEC must be positive, using default", "value", sec)
+		return defaultDBMaxConnIdleTime
+	}
+	return time.Duration(sec) * time.Second
+}
+
+// getDBHealthCheckPeriod reads how often idle connections are checked from
+// DB_HEALTH_CHECK_PERIOD_SEC.
+func getDBHealthCheckPeriod(logger *slog.Logger) time.Duration {
+	sec := getEnvAsInt("DB_HEALTH_CHECK_PERIOD_SEC", int(defaultDBHealthCheckPeriod.Seconds()), logger)
+	if sec <= 0 {
+		logger.Warn("DB_HEALTH_CHECK_PERIOD_SEC must be positive, using default", "value", sec)
+		return defaultDBHealthCheckPeriod
+	}
+	return time.Duration(sec) * time.Second
+}
+
+// poolConfig parses a PostgreSQL connection string and applies the configured pool settings.
+func (cfg *apiConfig) poolConfig(dataSourceName string) (*pgxpool.Config, error) {
+	poolConfig, err := pgxpool.ParseConfig(dataSourceName)
+	if err != nil {
+		return nil, err
+	}
+	poolConfig.MaxConns = int32(cfg.dbMaxConns)
+	poolConfig.MaxConnIdleTime = cfg.dbMaxConnIdleTime
+	poolConfig.HealthCheckPeriod = cfg.dbHealthCheckPeriod
+	if cfg.secrets.value("DB_URL") != nil {
+		poolConfig.BeforeConnect = cfg.setDBCredentials
+	}
+	return poolConfig, nil
+}
+
+// openDB opens a database. PostgreSQL databases, opened with the "pgx" driver, are backed by a
+// pgxpool connection pool whose statistics are registered with Prometheus.
+func (cfg *apiConfig) openDB(driverName, dataSourceName string) (*sql.DB, error) {
+	if driverName != "pgx" {
+		return sql.Open(driverName, dataSourceName)
+	}
+	poolConfig, err := cfg.poolConfig(dataSourceName)
+	if err != nil {
+		return nil, err
+	}
+	pool, err := pgxpool.NewWithConfig(context.Background(), poolConfig)
+	if err != nil {
+		return nil, err
+	}
+	if err := prometheus.Register(newDBPoolStatsCollector(pool)); err != nil {
+		cfg.logger.Warn("could not register database pool stats collector", "error", err)
+	}
+	return stdlib.OpenDBFromPool(pool), nil
+}
+
+var (
+	dbPoolConnectionsDesc = prometheus.NewDesc(
+		"willitrain_db_pool_connections",
+		"Number of connections in the database pool, by state.",
+		[]string{"state"}, nil,
+	)
+	dbPoolMaxConnectionsDesc = prometheus.NewDesc(
+		"willitrain_db_pool_max_connections",
+		"Maximum number of connections in the database pool.",
+		nil, nil,
+	)
+	dbPoolAcquiresDesc = prometheus.NewDesc(
+		"willitrain_db_pool_acquires_total",
+		"Total number of connections acquired from the database pool.",
+		nil, nil,
+	)
+	dbPoolEmptyAcquiresDesc = prometheus.NewDesc(
+		"willitrain_db_pool_empty_acquires_total",
+		"Total number of acquires that waited for a connection because none was idle.",
+		nil, nil,
+	)
+	dbPoolCanceledAcquiresDesc = prometheus.NewDesc(
+		"willitrain_db_pool_canceled_acquires_total",
+		"Total number of acquires canceled before a connection became available.",
+		nil, nil,
+	)
+	dbPoolAcquireWaitDesc = prometheus.NewDesc(
+		"willitrain_db_pool_acquire_wait_seconds_total",
+		"Total time spent waiting for a connection from the database pool.",
+		nil, nil,
+	)
+)
+
+// dbPoolStatsCollector is a prometheus.Collector that reports the statistics of a pgxpool
+// connection pool at scrape time.
+type dbPoolStatsCollector struct {
+	pool *pgxpool.Pool
+}
+
+// newDBPoolStatsCollector creates a collector bound to the given pool.
+func newDBPoolStatsCollector(pool *pgxpool.Pool) *dbPoolStatsCollector {
+	return &dbPoolStatsCollector{pool: pool}
+}
+
+// Describe sends the descriptors of all metrics produced by the collector.
+func (c *dbPoolStatsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- dbPoolConnectionsDesc
+	ch <- dbPoolMaxConnectionsDesc
+	ch <- dbPoolAcquiresDesc
+	ch <- dbPoolEmptyAcquiresDesc
+	ch <- dbPoolCanceledAcquiresDesc
+	ch <- dbPoolAcquireWaitDesc
+}
+
+// Collect reads the pool statistics and sends them as constant metrics.
+func (c *dbPoolStatsCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := c.pool.Stat()
+	ch <- prometheus.MustNewConstMetric(dbPoolConnectionsDesc, prometheus.GaugeValue, float64(stat.AcquiredConns()), "acquired")
+	ch <- prometheus.MustNewConstMetric(dbPoolConnectionsDesc, prometheus.GaugeValue, float64(stat.IdleConns()), "idle")
+	ch <- prometheus.MustNewConstMetric(dbPoolConnectionsDesc, prometheus.GaugeValue, float64(stat.ConstructingConns()), "constructing")
+	ch <- prometheus.MustNewConstMetric(dbPoolMaxConnectionsDesc, prometheus.GaugeValue, float64(stat.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(dbPoolAcquiresDesc, prometheus.CounterValue, float64(stat.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(dbPoolEmptyAcquiresDesc, prometheus.CounterValue, float64(stat.EmptyAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(dbPoolCanceledAcquiresDesc, prometheus.CounterValue, float64(stat.CanceledAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(dbPoolAcquireWaitDesc, prometheus.CounterValue, stat.AcquireDuration().Seconds())
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"database/sql"
+	"time"
 
 	"github.com/cor0nius/willitrain/internal/database"
 	"github.com/google/uuid"
@@ -42,14 +43,23 @@ func locationToCreateLocationParams(location Location) database.CreateLocationPa
 // databaseCurrentWeatherToCurrentWeather maps a database model to a business logic model.
 func databaseCurrentWeatherToCurrentWeather(dbWeather database.CurrentWeather, location Location) CurrentWeather {
 	return CurrentWeather{
-		Location:      location,
-		SourceAPI:     dbWeather.SourceApi,
-		Timestamp:     dbWeather.UpdatedAt,
-		Temperature:   dbWeather.TemperatureC.Float64,
-		Humidity:      dbWeather.Humidity.Int32,
-		WindSpeed:     dbWeather.WindSpeedKmh.Float64,
-		Precipitation: dbWeather.PrecipitationMm.Float64,
-		Condition:     dbWeather.ConditionText.String,
+		Location:            location,
+		SourceAPI:           dbWeather.SourceApi,
+		Timestamp:           dbWeather.UpdatedAt,
+		Temperature:         dbWeather.TemperatureC.Float64,
+		Humidity:            dbWeather.Humidity.Int32,
+		WindSpeed:           dbWeather.WindSpeedKmh.Float64,
+		WindDirection:       nullFloat64ToPtr(dbWeather.WindDirectionDeg),
+		WindGust:            nullFloat64ToPtr(dbWeather.WindGustKmh),
+		ApparentTemperature: nullFloat64ToPtr(dbWeather.ApparentTemperatureC),
+		DewPoint:            nullFloat64ToPtr(dbWeather.DewPointC),
+		Precipitation:       dbWeather.PrecipitationMm.Float64,
+		Rain:                nullFloat64ToPtr(dbWeather.RainMm),
+		Snow:                nullFloat64ToPtr(dbWeather.SnowMm),
+		Condition:           dbWeather.ConditionText.String,
+		UVIndex:             nullFloat64ToPtr(dbWeather.UvIndex),
+		Sunrise:             dbWeather.Sunrise.Time,
+		Sunset:              dbWeather.Sunset.Time,
 	}
 }
 
@@ -71,14 +81,23 @@ func currentWeatherToCreateCurrentWeatherParams(weather CurrentWeather) database
 			Float64: weather.WindSpeed,
 			Valid:   true,
 		},
+		WindDirectionDeg:     ptrToNullFloat64(weather.WindDirection),
+		WindGustKmh:          ptrToNullFloat64(weather.WindGust),
+		ApparentTemperatureC: ptrToNullFloat64(weather.ApparentTemperature),
+		DewPointC:            ptrToNullFloat64(weather.DewPoint),
 		PrecipitationMm: sql.NullFloat64{
 			Float64: weather.Precipitation,
 			Valid:   true,
 		},
+		RainMm: ptrToNullFloat64(weather.Rain),
+		SnowMm: ptrToNullFloat64(weather.Snow),
 		ConditionText: sql.NullString{
 			String: weather.Condition,
 			Valid:  true,
 		},
+		UvIndex: ptrToNullFloat64(weather.UVIndex),
+		Sunrise: timeToNullTime(weather.Sunrise),
+		Sunset:  timeToNullTime(weather.Sunset),
 	}
 }
 
@@ -99,14 +118,23 @@ func currentWeatherToUpdateCurrentWeatherParams(weather CurrentWeather, dbWeathe
 			Float64: weather.WindSpeed,
 			Valid:   true,
 		},
+		WindDirectionDeg:     ptrToNullFloat64(weather.WindDirection),
+		WindGustKmh:          ptrToNullFloat64(weather.WindGust),
+		ApparentTemperatureC: ptrToNullFloat64(weather.ApparentTemperature),
+		DewPointC:            ptrToNullFloat64(weather.DewPoint),
 		PrecipitationMm: sql.NullFloat64{
 			Float64: weather.Precipitation,
 			Valid:   true,
 		},
+		RainMm: ptrToNullFloat64(weather.Rain),
+		SnowMm: ptrToNullFloat64(weather.Snow),
 		ConditionText: sql.NullString{
 			String: weather.Condition,
 			Valid:  true,
 		},
+		UvIndex: ptrToNullFloat64(weather.UVIndex),
+		Sunrise: timeToNullTime(weather.Sunrise),
+		Sunset:  timeToNullTime(weather.Sunset),
 	}
 }
 
@@ -120,9 +148,29 @@ func databaseDailyForecastToDailyForecast(dbForecast database.DailyForecast, loc
 		MinTemp:             dbForecast.MinTempC.Float64,
 		MaxTemp:             dbForecast.MaxTempC.Float64,
 		Precipitation:       dbForecast.PrecipitationMm.Float64,
+		Rain:                nullFloat64ToPtr(dbForecast.RainMm),
+		Snow:                nullFloat64ToPtr(dbForecast.SnowMm),
 		PrecipitationChance: dbForecast.PrecipitationChancePercent.Int32,
 		WindSpeed:           dbForecast.WindSpeedKmh.Float64,
+		WindDirection:       nullFloat64ToPtr(dbForecast.WindDirectionDeg),
+		WindGust:            nullFloat64ToPtr(dbForecast.WindGustKmh),
 		Humidity:            dbForecast.Humidity.Int32,
+		UVIndex:             nullFloat64ToPtr(dbForecast.UvIndex),
+		Sunrise:             dbForecast.Sunrise.Time,
+		Sunset:              dbForecast.Sunset.Time,
+		Morning:             databaseDayPartToDayPart(dbForecast.MorningTempC, dbForecast.MorningPrecipitationChancePercent, dbForecast.MorningConditionText),
+		Afternoon:           databaseDayPartToDayPart(dbForecast.AfternoonTempC, dbForecast.AfternoonPrecipitationChancePercent, dbForecast.AfternoonConditionText),
+		Evening:             databaseDayPartToDayPart(dbForecast.EveningTempC, dbForecast.EveningPrecipitationChancePercent, dbForecast.EveningConditionText),
+		Night:               databaseDayPartToDayPart(dbForecast.NightTempC, dbForecast.NightPrecipitationChancePercent, dbForecast.NightConditionText),
+	}
+}
+
+// databaseDayPartToDayPart maps the columns of a part of the day to a business logic model.
+func databaseDayPartToDayPart(temp sql.NullFloat64, precipitationChance sql.NullInt32, condition sql.NullString) DayPart {
+	return DayPart{
+		Temperature:         nullFloat64ToPtr(temp),
+		PrecipitationChance: nullInt32ToPtr(precipitationChance),
+		Condition:           nullStringToPtr(condition),
 	}
 }
 
@@ -145,6 +193,8 @@ func dailyForecastToCreateDailyForecastParams(forecast DailyForecast) database.C
 			Float64: forecast.Precipitation,
 			Valid:   true,
 		},
+		RainMm: ptrToNullFloat64(forecast.Rain),
+		SnowMm: ptrToNullFloat64(forecast.Snow),
 		PrecipitationChancePercent: sql.NullInt32{
 			Int32: int32(forecast.PrecipitationChance),
 			Valid: true,
@@ -153,10 +203,27 @@ func dailyForecastToCreateDailyForecastParams(forecast DailyForecast) database.C
 			Float64: forecast.WindSpeed,
 			Valid:   true,
 		},
+		WindDirectionDeg: ptrToNullFloat64(forecast.WindDirection),
+		WindGustKmh:      ptrToNullFloat64(forecast.WindGust),
 		Humidity: sql.NullInt32{
 			Int32: int32(forecast.Humidity),
 			Valid: true,
 		},
+		UvIndex:                             ptrToNullFloat64(forecast.UVIndex),
+		Sunrise:                             timeToNullTime(forecast.Sunrise),
+		Sunset:                              timeToNullTime(forecast.Sunset),
+		MorningTempC:                        ptrToNullFloat64(forecast.Morning.Temperature),
+		MorningPrecipitationChancePercent:   ptrToNullInt32(forecast.Morning.PrecipitationChance),
+		MorningConditionText:                ptrToNullString(forecast.Morning.Condition),
+		AfternoonTempC:                      ptrToNullFloat64(forecast.Afternoon.Temperature),
+		AfternoonPrecipitationChancePercent: ptrToNullInt32(forecast.Afternoon.PrecipitationChance),
+		AfternoonConditionText:              ptrToNullString(forecast.Afternoon.Condition),
+		EveningTempC:                        ptrToNullFloat64(forecast.Evening.Temperature),
+		EveningPrecipitationChancePercent:   ptrToNullInt32(forecast.Evening.PrecipitationChance),
+		EveningConditionText:                ptrToNullString(forecast.Evening.Condition),
+		NightTempC:                          ptrToNullFloat64(forecast.Night.Temperature),
+		NightPrecipitationChancePercent:     ptrToNullInt32(forecast.Night.PrecipitationChance),
+		NightConditionText:                  ptrToNullString(forecast.Night.Condition),
 	}
 }
 
@@ -178,6 +245,8 @@ func dailyForecastToUpdateDailyForecastParams(forecast DailyForecast, dbForecast
 			Float64: forecast.Precipitation,
 			Valid:   true,
 		},
+		RainMm: ptrToNullFloat64(forecast.Rain),
+		SnowMm: ptrToNullFloat64(forecast.Snow),
 		PrecipitationChancePercent: sql.NullInt32{
 			Int32: int32(forecast.PrecipitationChance),
 			Valid: true,
@@ -186,10 +255,27 @@ func dailyForecastToUpdateDailyForecastParams(forecast DailyForecast, dbForecast
 			Float64: forecast.WindSpeed,
 			Valid:   true,
 		},
+		WindDirectionDeg: ptrToNullFloat64(forecast.WindDirection),
+		WindGustKmh:      ptrToNullFloat64(forecast.WindGust),
 		Humidity: sql.NullInt32{
 			Int32: int32(forecast.Humidity),
 			Valid: true,
 		},
+		UvIndex:                             ptrToNullFloat64(forecast.UVIndex),
+		Sunrise:                             timeToNullTime(forecast.Sunrise),
+		Sunset:                              timeToNullTime(forecast.Sunset),
+		MorningTempC:                        ptrToNullFloat64(forecast.Morning.Temperature),
+		MorningPrecipitationChancePercent:   ptrToNullInt32(forecast.Morning.PrecipitationChance),
+		MorningConditionText:                ptrToNullString(forecast.Morning.Condition),
+		AfternoonTempC:                      ptrToNullFloat64(forecast.Afternoon.Temperature),
+		AfternoonPrecipitationChancePercent: ptrToNullInt32(forecast.Afternoon.PrecipitationChance),
+		AfternoonConditionText:              ptrToNullString(forecast.Afternoon.Condition),
+		EveningTempC:                        ptrToNullFloat64(forecast.Evening.Temperature),
+		EveningPrecipitationChancePercent:   ptrToNullInt32(forecast.Evening.PrecipitationChance),
+		EveningConditionText:                ptrToNullString(forecast.Evening.Condition),
+		NightTempC:                          ptrToNullFloat64(forecast.Night.Temperature),
+		NightPrecipitationChancePercent:     ptrToNullInt32(forecast.Night.PrecipitationChance),
+		NightConditionText:                  ptrToNullString(forecast.Night.Condition),
 	}
 }
 
@@ -203,7 +289,13 @@ func databaseHourlyForecastToHourlyForecast(dbForecast database.HourlyForecast,
 		Temperature:         dbForecast.TemperatureC.Float64,
 		Humidity:            dbForecast.Humidity.Int32,
 		WindSpeed:           dbForecast.WindSpeedKmh.Float64,
+		WindDirection:       nullFloat64ToPtr(dbForecast.WindDirectionDeg),
+		WindGust:            nullFloat64ToPtr(dbForecast.WindGustKmh),
+		ApparentTemperature: nullFloat64ToPtr(dbForecast.ApparentTemperatureC),
+		DewPoint:            nullFloat64ToPtr(dbForecast.DewPointC),
 		Precipitation:       dbForecast.PrecipitationMm.Float64,
+		Rain:                nullFloat64ToPtr(dbForecast.RainMm),
+		Snow:                nullFloat64ToPtr(dbForecast.SnowMm),
 		PrecipitationChance: dbForecast.PrecipitationChancePercent.Int32,
 		Condition:           dbForecast.ConditionText.String,
 	}
@@ -228,10 +320,16 @@ func hourlyForecastToCreateHourlyForecastParams(forecast HourlyForecast) databas
 			Float64: forecast.WindSpeed,
 			Valid:   true,
 		},
+		WindDirectionDeg:     ptrToNullFloat64(forecast.WindDirection),
+		WindGustKmh:          ptrToNullFloat64(forecast.WindGust),
+		ApparentTemperatureC: ptrToNullFloat64(forecast.ApparentTemperature),
+		DewPointC:            ptrToNullFloat64(forecast.DewPoint),
 		PrecipitationMm: sql.NullFloat64{
 			Float64: forecast.Precipitation,
 			Valid:   true,
 		},
+		RainMm: ptrToNullFloat64(forecast.Rain),
+		SnowMm: ptrToNullFloat64(forecast.Snow),
 		PrecipitationChancePercent: sql.NullInt32{
 			Int32: int32(forecast.PrecipitationChance),
 			Valid: true,
@@ -261,10 +359,16 @@ func hourlyForecastToUpdateHourlyForecastParams(forecast HourlyForecast, dbForec
 			Float64: forecast.WindSpeed,
 			Valid:   true,
 		},
+		WindDirectionDeg:     ptrToNullFloat64(forecast.WindDirection),
+		WindGustKmh:          ptrToNullFloat64(forecast.WindGust),
+		ApparentTemperatureC: ptrToNullFloat64(forecast.ApparentTemperature),
+		DewPointC:            ptrToNullFloat64(forecast.DewPoint),
 		PrecipitationMm: sql.NullFloat64{
 			Float64: forecast.Precipitation,
 			Valid:   true,
 		},
+		RainMm: ptrToNullFloat64(forecast.Rain),
+		SnowMm: ptrToNullFloat64(forecast.Snow),
 		PrecipitationChancePercent: sql.NullInt32{
 			Int32: int32(forecast.PrecipitationChance),
 			Valid: true,
@@ -275,3 +379,247 @@ func hourlyForecastToUpdateHourlyForecastParams(forecast HourlyForecast, dbForec
 		},
 	}
 }
+
+// databaseAirQualityToAirQuality maps a database model to a business logic model.
+func databaseAirQualityToAirQuality(dbAirQuality database.AirQuality, location Location) AirQuality {
+	return AirQuality{
+		Location:  location,
+		SourceAPI: dbAirQuality.SourceApi,
+		Timestamp: dbAirQuality.UpdatedAt,
+		AQI:       dbAirQuality.Aqi.Int32,
+		PM25:      dbAirQuality.Pm25Ugm3.Float64,
+		PM10:      dbAirQuality.Pm10Ugm3.Float64,
+		Ozone:     dbAirQuality.OzoneUgm3.Float64,
+	}
+}
+
+// airQualityToCreateAirQualityParams maps a business logic model to database create parameters.
+func airQualityToCreateAirQualityParams(airQuality AirQuality) database.CreateAirQualityParams {
+	return database.CreateAirQualityParams{
+		LocationID: airQuality.Location.LocationID,
+		SourceApi:  airQuality.SourceAPI,
+		UpdatedAt:  airQuality.Timestamp,
+		Aqi: sql.NullInt32{
+			Int32: airQuality.AQI,
+			Valid: true,
+		},
+		Pm25Ugm3: sql.NullFloat64{
+			Float64: airQuality.PM25,
+			Valid:   true,
+		},
+		Pm10Ugm3: sql.NullFloat64{
+			Float64: airQuality.PM10,
+			Valid:   true,
+		},
+		OzoneUgm3: sql.NullFloat64{
+			Float64: airQuality.Ozone,
+			Valid:   true,
+		},
+	}
+}
+
+// airQualityToUpdateAirQualityParams maps a business logic model to database update parameters.
+func airQualityToUpdateAirQualityParams(airQuality AirQuality, dbAirQualityID uuid.UUID) database.UpdateAirQualityParams {
+	return database.UpdateAirQualityParams{
+		ID:        dbAirQualityID,
+		UpdatedAt: airQuality.Timestamp,
+		Aqi: sql.NullInt32{
+			Int32: airQuality.AQI,
+			Valid: true,
+		},
+		Pm25Ugm3: sql.NullFloat64{
+			Float64: airQuality.PM25,
+			Valid:   true,
+		},
+		Pm10Ugm3: sql.NullFloat64{
+			Float64: airQuality.PM10,
+			Valid:   true,
+		},
+		OzoneUgm3: sql.NullFloat64{
+			Float64: airQuality.Ozone,
+			Valid:   true,
+		},
+	}
+}
+
+// dbTimeFormat is the format of the times in the JSON rows of the bulk upserts. Times keep their
+// offset, so that the database interprets them as it does time parameters.
+const dbTimeFormat = "2006-01-02 15:04:05.999999999-07:00"
+
+// dbTime is a time that is marshaled to JSON in dbTimeFormat, which both PostgreSQL and SQLite parse.
+type dbTime time.Time
+
+func (t dbTime) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + time.Time(t).Format(dbTimeFormat) + `"`), nil
+}
+
+// timeToDBTime maps a time to a nullable JSON value, which is null for the zero time.
+func timeToDBTime(t time.Time) *dbTime {
+	if t.IsZero() {
+		return nil
+	}
+	return (*dbTime)(&t)
+}
+
+// hourlyForecastRow is a row of the JSON array taken by UpsertHourlyForecasts.
+type hourlyForecastRow struct {
+	LocationID                 uuid.UUID `json:"location_id"`
+	SourceApi                  string    `json:"source_api"`
+	ForecastDatetimeUtc        dbTime    `json:"forecast_datetime_utc"`
+	UpdatedAt                  dbTime    `json:"updated_at"`
+	TemperatureC               float64   `json:"temperature_c"`
+	Humidity                   int32     `json:"humidity"`
+	WindSpeedKmh               float64   `json:"wind_speed_kmh"`
+	PrecipitationMm            float64   `json:"precipitation_mm"`
+	PrecipitationChancePercent int32     `json:"precipitation_chance_percent"`
+	ConditionText              string    `json:"condition_text"`
+	WindDirectionDeg           *float64  `json:"wind_direction_deg"`
+	WindGustKmh                *float64  `json:"wind_gust_kmh"`
+	ApparentTemperatureC       *float64  `json:"apparent_temperature_c"`
+	DewPointC                  *float64  `json:"dew_point_c"`
+	RainMm                     *float64  `json:"rain_mm"`
+	SnowMm                     *float64  `json:"snow_mm"`
+}
+
+// hourlyForecastToHourlyForecastRow maps a business logic model to a bulk upsert row.
+func hourlyForecastToHourlyForecastRow(forecast HourlyForecast) hourlyForecastRow {
+	return hourlyForecastRow{
+		LocationID:                 forecast.Location.LocationID,
+		SourceApi:                  forecast.SourceAPI,
+		ForecastDatetimeUtc:        dbTime(forecast.ForecastDateTime),
+		UpdatedAt:                  dbTime(forecast.Timestamp),
+		TemperatureC:               forecast.Temperature,
+		Humidity:                   forecast.Humidity,
+		WindSpeedKmh:               forecast.WindSpeed,
+		PrecipitationMm:            forecast.Precipitation,
+		PrecipitationChancePercent: forecast.PrecipitationChance,
+		ConditionText:              forecast.Condition,
+		WindDirectionDeg:           forecast.WindDirection,
+		WindGustKmh:                forecast.WindGust,
+		ApparentTemperatureC:       forecast.ApparentTemperature,
+		DewPointC:                  forecast.DewPoint,
+		RainMm:                     forecast.Rain,
+		SnowMm:                     forecast.Snow,
+	}
+}
+
+// dailyForecastRow is a row of the JSON array taken by UpsertDailyForecasts.
+type dailyForecastRow struct {
+	LocationID                          uuid.UUID `json:"location_id"`
+	SourceApi                           string    `json:"source_api"`
+	ForecastDate                        dbTime    `json:"forecast_date"`
+	UpdatedAt                           dbTime    `json:"updated_at"`
+	MinTempC                            float64   `json:"min_temp_c"`
+	MaxTempC                            float64   `json:"max_temp_c"`
+	PrecipitationMm                     float64   `json:"precipitation_mm"`
+	PrecipitationChancePercent          int32     `json:"precipitation_chance_percent"`
+	WindSpeedKmh                        float64   `json:"wind_speed_kmh"`
+	Humidity                            int32     `json:"humidity"`
+	UvIndex                             *float64  `json:"uv_index"`
+	Sunrise                             *dbTime   `json:"sunrise"`
+	Sunset                              *dbTime   `json:"sunset"`
+	WindDirectionDeg                    *float64  `json:"wind_direction_deg"`
+	WindGustKmh                         *float64  `json:"wind_gust_kmh"`
+	RainMm                              *float64  `json:"rain_mm"`
+	SnowMm                              *float64  `json:"snow_mm"`
+	MorningTempC                        *float64  `json:"morning_temp_c"`
+	MorningPrecipitationChancePercent   *int32    `json:"morning_precipitation_chance_percent"`
+	MorningConditionText                *string   `json:"morning_condition_text"`
+	AfternoonTempC                      *float64  `json:"afternoon_temp_c"`
+	AfternoonPrecipitationChancePercent *int32    `json:"afternoon_precipitation_chance_percent"`
+	AfternoonConditionText              *string   `json:"afternoon_condition_text"`
+	EveningTempC                        *float64  `json:"evening_temp_c"`
+	EveningPrecipitationChancePercent   *int32    `json:"evening_precipitation_chance_percent"`
+	EveningConditionText                *string   `json:"evening_condition_text"`
+	NightTempC                          *float64  `json:"night_temp_c"`
+	NightPrecipitationChancePercent     *int32    `json:"night_precipitation_chance_percent"`
+	NightConditionText                  *string   `json:"night_condition_text"`
+}
+
+// dailyForecastToDailyForecastRow maps a business logic model to a bulk upsert row.
+func dailyForecastToDailyForecastRow(forecast DailyForecast) dailyForecastRow {
+	return dailyForecastRow{
+		LocationID:                          forecast.Location.LocationID,
+		SourceApi:                           forecast.SourceAPI,
+		ForecastDate:                        dbTime(forecast.ForecastDate),
+		UpdatedAt:                           dbTime(forecast.Timestamp),
+		MinTempC:                            forecast.MinTemp,
+		MaxTempC:                            forecast.MaxTemp,
+		PrecipitationMm:                     forecast.Precipitation,
+		PrecipitationChancePercent:          forecast.PrecipitationChance,
+		WindSpeedKmh:                        forecast.WindSpeed,
+		Humidity:                            forecast.Humidity,
+		UvIndex:                             forecast.UVIndex,
+		Sunrise:                             timeToDBTime(forecast.Sunrise),
+		Sunset:                              timeToDBTime(forecast.Sunset),
+		WindDirectionDeg:                    forecast.WindDirection,
+		WindGustKmh:                         forecast.WindGust,
+		RainMm:                              forecast.Rain,
+		SnowMm:                              forecast.Snow,
+		MorningTempC:                        forecast.Morning.Temperature,
+		MorningPrecipitationChancePercent:   forecast.Morning.PrecipitationChance,
+		MorningConditionText:                forecast.Morning.Condition,
+		AfternoonTempC:                      forecast.Afternoon.Temperature,
+		AfternoonPrecipitationChancePercent: forecast.Afternoon.PrecipitationChance,
+		AfternoonConditionText:              forecast.Afternoon.Condition,
+		EveningTempC:                        forecast.Evening.Temperature,
+		EveningPrecipitationChancePercent:   forecast.Evening.PrecipitationChance,
+		EveningConditionText:                forecast.Evening.Condition,
+		NightTempC:                          forecast.Night.Temperature,
+		NightPrecipitationChancePercent:     forecast.Night.PrecipitationChance,
+		NightConditionText:                  forecast.Night.Condition,
+	}
+}
+
+// ptrToNullFloat64 maps an optional value to a nullable database column.
+func ptrToNullFloat64(value *float64) sql.NullFloat64 {
+	if value == nil {
+		return sql.NullFloat64{}
+	}
+	return sql.NullFloat64{Float64: *value, Valid: true}
+}
+
+// ptrToNullInt32 maps an optional value to a nullable database column.
+func ptrToNullInt32(value *int32) sql.NullInt32 {
+	if value == nil {
+		return sql.NullInt32{}
+	}
+	return sql.NullInt32{Int32: *value, Valid: true}
+}
+
+// ptrToNullString maps an optional value to a nullable database column.
+func ptrToNullString(value *string) sql.NullString {
+	if value == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: *value, Valid: true}
+}
+
+// nullFloat64ToPtr maps a nullable database column to an optional value.
+func nullFloat64ToPtr(value sql.NullFloat64) *float64 {
+	if !value.Valid {
+		return nil
+	}
+	return &value.Float64
+}
+
+// nullInt32ToPtr maps a nullable database column to an optional value.
+func nullInt32ToPtr(value sql.NullInt32) *int32 {
+	if !value.Valid {
+		return nil
+	}
+	return &value.Int32
+}
+
+// nullStringToPtr maps a nullable database column to an optional value.
+func nullStringToPtr(value sql.NullString) *string {
+	if !value.Valid {
+		return nil
+	}
+	return &value.String
+}
+
+// timeToNullTime maps a time to a nullable database column, storing NULL for the zero time.
+func timeToNullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
@@ -0,0 +1,435 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// This file implements the demo mode. With DEMO_MODE=true, the application runs without API keys,
+// provider accounts or internet access: every outbound request is answered in-process by a fake
+// Open-Meteo that returns deterministic synthetic weather, geocoding is limited to the bundled
+// city dataset, and the demo cities are added on startup so that the scheduler keeps them
+// populated. Unless they are set, the database defaults to a SQLite file and the cache to memory,
+// so the binary starts on its own. It is meant for frontend development and for end-to-end tests
+// against the real binary; the same coordinates and time always yield the same weather.
+
+// demoCities are the cities added on startup in demo mode. All of them are in the bundled dataset.
+var demoCities = []string{"London", "New York", "Sydney", "Tokyo", "Warsaw"}
+
+// demoEnvDefaults are the settings applied in demo mode to variables that are not set. The
+// provider URLs and keys are placeholders, since no request leaves the process.
+var demoEnvDefaults = map[string]string{
+	"DB_DRIVER":          dbDriverSQLite,
+	"DB_URL":             "willitrain-demo.db",
+	"CACHE_BACKEND":      cacheBackendMemory,
+	"GMP_GEOCODE_URL":    "http://demo.invalid/gmp/geocode/",
+	"GMP_WEATHER_URL":    "http://demo.invalid/gmp/weather/",
+	"OWM_WEATHER_URL":    "http://demo.invalid/owm/",
+	"OWM_KEY":            "demo",
+	"OMETEO_WEATHER_URL": "http://demo.invalid/ometeo/forecast?",
+}
+
+// demoSourceID is the only weather source enabled in demo mode, the one the fake provider mimics.
+const demoSourceID = "ometeo"
+
+// applyDemoMode reads DEMO_MODE and, if it is true, exports demoEnvDefaults for the variables that
+// are not already set. It returns whether demo mode is enabled and the names of the variables it
+// set. Like the config file, it has to run before anything reads the environment.
+func applyDemoMode() (bool, []string, error) {
+	raw := os.Getenv("DEMO_MODE")
+	if raw == "" {
+		return false, nil, nil
+	}
+	demo, err := strconv.ParseBool(raw)
+	if err != nil {
+		return false, nil, fmt.Errorf("invalid DEMO_MODE value %q: %w", raw, err)
+	}
+	if !demo {
+		return false, nil, nil
+	}
+
+	var applied []string
+	for key, val := range demoEnvDefaults {
+		if os.Getenv(key) != "" {
+			continue
+		}
+		if err := os.Setenv(key, val); err != nil {
+			return false, nil, fmt.Errorf("could not apply demo default for %s: %w", key, err)
+		}
+		applied = append(applied, key)
+	}
+	sort.Strings(applied)
+	return true, applied, nil
+}
+
+// enableDemoMode replaces the external services with their demo versions: the HTTP client answers
+// every request with the fake provider, geocoding and timezone lookups use the bundled city
+// dataset only, and only the weather source that the fake provider mimics is enabled.
+func (cfg *apiConfig) enableDemoMode() error {
+	cities, err := NewStaticGeocodingService(nil)
+	if err != nil {
+		return err
+	}
+	cfg.demoMode = true
+	cfg.httpClient.Transport = &metricsTransport{wrapped: &demoTransport{cities: cities, now: time.Now}}
+	cfg.geocoder = cities
+	cfg.timezoner = demoTimezoneService{cities: cities}
+	cfg.enabledSources = map[string]bool{demoSourceID: true}
+	cfg.logger.Warn("demo mode enabled, serving synthetic weather for the bundled cities", "cities", demoCities)
+	return nil
+}
+
+// seedDemoLocations adds the demo cities, so that the scheduler fetches their weather right away.
+func (cfg *apiConfig) seedDemoLocations(ctx context.Context) error {
+	var errs []error
+	for _, city := range demoCities {
+		if _, err := cfg.getOrCreateLocation(ctx, city); err != nil {
+			errs = append(errs, fmt.Errorf("could not add demo city %s: %w", city, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// demoTimezoneService is a TimezoneService that returns the timezone of the nearest bundled city.
+type demoTimezoneService struct {
+	cities *StaticGeocodingService
+}
+
+func (s demoTimezoneService) Timezone(lat, lng float64) (string, error) {
+	location, err := s.cities.ReverseGeocode(lat, lng)
+	if err != nil || location.Timezone == "" {
+		return "UTC", nil
+	}
+	return location.Timezone, nil
+}
+
+// demoTransport is an http.RoundTripper that answers Open-Meteo forecast, air quality and
+// archive requests with synthetic data, and every other request with 404 Not Found.
+type demoTransport struct {
+	cities *StaticGeocodingService
+	now    func() time.Time
+}
+
+func (t *demoTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := t.respond(req.URL.Query())
+	status := http.StatusOK
+	if err != nil {
+		status = http.StatusNotFound
+		body = []byte(`{"error":true,"reason":` + strconv.Quote(err.Error()) + `}`)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// respond builds the JSON response to an Open-Meteo request from its query parameters.
+func (t *demoTransport) respond(q url.Values) ([]byte, error) {
+	lats, lons := strings.Split(q.Get("latitude"), ","), strings.Split(q.Get("longitude"), ",")
+	if len(lats) != len(lons) {
+		return nil, errors.New("latitude and longitude must have the same number of values")
+	}
+	points := make([][2]float64, len(lats))
+	for i := range lats {
+		lat, latErr := strconv.ParseFloat(lats[i], 64)
+		lon, lonErr := strconv.ParseFloat(lons[i], 64)
+		if latErr != nil || lonErr != nil {
+			return nil, errors.New("not available in demo mode")
+		}
+		points[i] = [2]float64{lat, lon}
+	}
+	now := t.now()
+
+	switch {
+	case q.Has("start_date"):
+		start, err := time.Parse(time.DateOnly, q.Get("start_date"))
+		if err != nil {
+			return nil, err
+		}
+		end, err := time.Parse(time.DateOnly, q.Get("end_date"))
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(demoArchive(points[0][0], points[0][1], start, end.AddDate(0, 0, 1)))
+	case strings.Contains(q.Get("current"), "european_aqi"):
+		return json.Marshal(demoAirQuality(points[0][0], points[0][1], now))
+	case q.Has("current") && len(points) > 1:
+		responses := make([]ResponseCurrentWeatherOMeteo, len(points))
+		for i, p := range points {
+			responses[i] = t.currentWeather(p[0], p[1], now)
+		}
+		return json.Marshal(responses)
+	case q.Has("current"):
+		return json.Marshal(t.currentWeather(points[0][0], points[0][1], now))
+	case q.Has("hourly"):
+		return json.Marshal(t.hourlyForecast(points[0][0], points[0][1], now, demoForecastDays(q)))
+	case q.Has("daily"):
+		return json.Marshal(t.dailyForecast(points[0][0], points[0][1], now, demoForecastDays(q)))
+	}
+	return nil, errors.New("not available in demo mode")
+}
+
+// demoForecastDays returns the number of days requested with forecast_days.
+func demoForecastDays(q url.Values) int {
+	days, err := strconv.Atoi(q.Get("forecast_days"))
+	if err != nil || days < 1 {
+		return 7
+	}
+	return days
+}
+
+// location returns the timezone of the nearest bundled city and its location.
+func (t *demoTransport) location(lat, lon float64) (string, *time.Location) {
+	tz, _ := demoTimezoneService{cities: t.cities}.Timezone(lat, lon)
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return "UTC", time.UTC
+	}
+	return tz, loc
+}
+
+func (t *demoTransport) currentWeather(lat, lon float64, now time.Time) ResponseCurrentWeatherOMeteo {
+	tz, loc := t.location(lat, lon)
+	at := now.Truncate(15 * time.Minute)
+	w := demoWeatherAt(lat, lon, at)
+	sunrise, sunset := demoSunTimes(lat, lon, demoMidnight(now, loc))
+	return ResponseCurrentWeatherOMeteo{
+		CurrentWeather: CurrentOMeteo{
+			Time:                at.Unix(),
+			Temperature2m:       w.temperature,
+			ApparentTemperature: ptr(w.apparentTemperature),
+			DewPoint2m:          ptr(w.dewPoint),
+			RelativeHumidity2m:  w.humidity,
+			WindSpeed10m:        w.windSpeed,
+			WindDirection10m:    ptr(w.windDirection),
+			WindGusts10m:        ptr(w.windGust),
+			Precipitation:       w.precipitation,
+			Rain:                ptr(w.precipitation),
+			Showers:             ptr(0.0),
+			WeatherCode:         w.code,
+			UVIndex:             ptr(w.uvIndex),
+		},
+		Daily:    SunTimesOMeteo{Sunrise: []int64{sunrise.Unix()}, Sunset: []int64{sunset.Unix()}},
+		Timezone: tz,
+	}
+}
+
+func (t *demoTransport) hourlyForecast(lat, lon float64, now time.Time, days int) ResponseHourlyForecastOMeteo {
+	tz, loc := t.location(lat, lon)
+	var h HourlyOMeteo
+	start := demoMidnight(now, loc)
+	for at := start; at.Before(start.AddDate(0, 0, days)); at = at.Add(time.Hour) {
+		w := demoWeatherAt(lat, lon, at)
+		h.Time = append(h.Time, at.Unix())
+		h.Temperature2m = append(h.Temperature2m, w.temperature)
+		h.RelativeHumidity2m = append(h.RelativeHumidity2m, w.humidity)
+		h.WindSpeed10m = append(h.WindSpeed10m, w.windSpeed)
+		h.Precipitation = append(h.Precipitation, w.precipitation)
+		h.Rain = append(h.Rain, ptr(w.precipitation))
+		h.Showers = append(h.Showers, ptr(0.0))
+		h.PrecipitationProbability = append(h.PrecipitationProbability, w.precipitationChance)
+		h.WeatherCode = append(h.WeatherCode, w.code)
+		h.WindDirection10m = append(h.WindDirection10m, ptr(w.windDirection))
+		h.WindGusts10m = append(h.WindGusts10m, ptr(w.windGust))
+		h.ApparentTemperature = append(h.ApparentTemperature, ptr(w.apparentTemperature))
+		h.DewPoint2m = append(h.DewPoint2m, ptr(w.dewPoint))
+	}
+	return ResponseHourlyForecastOMeteo{HourlyForecast: h, Timezone: tz}
+}
+
+// dailyForecast aggregates the synthetic hourly weather of every local day.
+func (t *demoTransport) dailyForecast(lat, lon float64, now time.Time, days int) ResponseDailyForecastOMeteo {
+	tz, loc := t.location(lat, lon)
+	var d DailyOMeteo
+	midnight := demoMidnight(now, loc)
+	for range days {
+		next := midnight.AddDate(0, 0, 1)
+		minTemp, maxTemp := math.Inf(1), math.Inf(-1)
+		var precipitation, windSpeed, windGust, uvIndex float64
+		var chance, humidity int32
+		codes := make(map[int]int)
+		for at := midnight; at.Before(next); at = at.Add(time.Hour) {
+			w := demoWeatherAt(lat, lon, at)
+			minTemp, maxTemp = min(minTemp, w.temperature), max(maxTemp, w.temperature)
+			precipitation += w.precipitation
+			windSpeed, windGust, uvIndex = max(windSpeed, w.windSpeed), max(windGust, w.windGust), max(uvIndex, w.uvIndex)
+			chance, humidity = max(chance, w.precipitationChance), max(humidity, w.humidity)
+			codes[w.code]++
+		}
+		code := 0
+		for c, n := range codes {
+			if n > codes[code] || (n == codes[code] && c > code) {
+				code = c
+			}
+		}
+		sunrise, sunset := demoSunTimes(lat, lon, midnight)
+		precipitation = math.Round(precipitation*10) / 10
+		d.Time = append(d.Time, midnight.Unix())
+		d.Temperature2mMax = append(d.Temperature2mMax, maxTemp)
+		d.Temperature2mMin = append(d.Temperature2mMin, minTemp)
+		d.PrecipitationSum = append(d.PrecipitationSum, precipitation)
+		d.RainSum = append(d.RainSum, ptr(precipitation))
+		d.ShowersSum = append(d.ShowersSum, ptr(0.0))
+		d.PrecipitationProbabilityMax = append(d.PrecipitationProbabilityMax, chance)
+		d.WeatherCode = append(d.WeatherCode, code)
+		d.WindSpeed10mMax = append(d.WindSpeed10mMax, windSpeed)
+		d.RelativeHumidity2mMax = append(d.RelativeHumidity2mMax, humidity)
+		d.WindDirection10mDominant = append(d.WindDirection10mDominant, ptr(demoWeatherAt(lat, lon, midnight.Add(12*time.Hour)).windDirection))
+		d.WindGusts10mMax = append(d.WindGusts10mMax, ptr(windGust))
+		d.UVIndexMax = append(d.UVIndexMax, ptr(uvIndex))
+		d.Sunrise = append(d.Sunrise, sunrise.Unix())
+		d.Sunset = append(d.Sunset, sunset.Unix())
+		midnight = next
+	}
+	return ResponseDailyForecastOMeteo{DailyForecast: d, Timezone: tz}
+}
+
+func demoAirQuality(lat, lon float64, now time.Time) ResponseAirQualityOMeteo {
+	at := now.Truncate(time.Hour)
+	r := demoNoise(lat, lon, at, "air")
+	pm25 := math.Round((5+r*30)*10) / 10
+	return ResponseAirQualityOMeteo{
+		Current: CurrentAirQualityOMeteo{
+			Time:        at.Unix(),
+			EuropeanAQI: math.Round(10 + r*60),
+			PM25:        pm25,
+			PM10:        math.Round(pm25*1.6*10) / 10,
+			Ozone:       math.Round((40+r*80)*10) / 10,
+		},
+		Timezone: "GMT",
+	}
+}
+
+// demoArchive returns the synthetic hourly observations in [start, end).
+func demoArchive(lat, lon float64, start, end time.Time) ResponseArchiveOMeteo {
+	var archive ResponseArchiveOMeteo
+	for at := start; at.Before(end); at = at.Add(time.Hour) {
+		w := demoWeatherAt(lat, lon, at)
+		archive.Hourly.Time = append(archive.Hourly.Time, at.Unix())
+		archive.Hourly.Temperature2m = append(archive.Hourly.Temperature2m, ptr(w.temperature))
+		archive.Hourly.RelativeHumidity2m = append(archive.Hourly.RelativeHumidity2m, ptr(w.humidity))
+		archive.Hourly.WindSpeed10m = append(archive.Hourly.WindSpeed10m, ptr(w.windSpeed))
+		archive.Hourly.Precipitation = append(archive.Hourly.Precipitation, ptr(w.precipitation))
+		archive.Hourly.WeatherCode = append(archive.Hourly.WeatherCode, ptr(w.code))
+	}
+	return archive
+}
+
+// demoWeather is the synthetic weather at one place and time.
+type demoWeather struct {
+	temperature         float64
+	apparentTemperature float64
+	dewPoint            float64
+	humidity            int32
+	windSpeed           float64
+	windDirection       float64
+	windGust            float64
+	precipitation       float64
+	precipitationChance int32
+	code                int
+	uvIndex             float64
+}
+
+// demoWeatherAt derives the weather at a place and time from a seasonal and a daily temperature
+// cycle, with noise seeded by the coordinates and the hour, so that it is plausible, varies from
+// hour to hour and is the same on every call.
+func demoWeatherAt(lat, lon float64, t time.Time) demoWeather {
+	t = t.UTC().Truncate(time.Hour)
+	season := math.Cos(2 * math.Pi * float64(t.YearDay()-200) / 365)
+	if lat < 0 {
+		season = -season
+	}
+	solarHour := math.Mod(float64(t.Hour())+lon/15+24, 24)
+	daily := math.Sin(2 * math.Pi * (solarHour - 9) / 24)
+
+	r := demoNoise(lat, lon, t, "weather")
+	wet := demoNoise(lat, lon, t.Truncate(6*time.Hour), "rain")
+	temperature := 27 - 0.35*math.Abs(lat) + season*math.Abs(lat)/4 + daily*5 + (r-0.5)*3
+	humidity := 55 + (wet-0.5)*50 - daily*10
+
+	w := demoWeather{
+		temperature:   round1(temperature),
+		humidity:      int32(math.Round(math.Min(math.Max(humidity, 20), 100))),
+		windSpeed:     round1(5 + r*20),
+		windDirection: math.Round(demoNoise(lat, lon, t.Truncate(6*time.Hour), "wind") * 359),
+		code:          0,
+	}
+	w.windGust = round1(w.windSpeed * 1.6)
+	w.dewPoint = round1(w.temperature - (100-float64(w.humidity))/5)
+	w.apparentTemperature = round1(w.temperature - w.windSpeed/10)
+	w.precipitationChance = int32(math.Round(math.Max(0, wet-0.4) / 0.6 * 100))
+	switch {
+	case wet > 0.75:
+		w.precipitation = round1((wet - 0.75) * 4 * (1 + r*3))
+		w.code = 61
+		if w.precipitation >= 2.5 {
+			w.code = 63
+		}
+		if w.temperature < 0 {
+			w.code = 71
+		}
+	case wet > 0.55:
+		w.code = 3
+	case wet > 0.35:
+		w.code = 2
+	case r > 0.5:
+		w.code = 1
+	}
+	if daily > 0 {
+		w.uvIndex = round1(daily * (10 - math.Abs(lat)/10) * (1 - math.Max(wet-0.35, 0)))
+	}
+	return w
+}
+
+// demoNoise returns a number in [0, 1) determined by the coordinates, the time and a salt.
+func demoNoise(lat, lon float64, t time.Time, salt string) float64 {
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%.2f:%.2f:%d:%s", lat, lon, t.Unix(), salt)
+	return float64(h.Sum64()%10000) / 10000
+}
+
+// demoSunTimes returns approximate sunrise and sunset times on the day starting at midnight, with
+// the day length varying with the latitude and the season.
+func demoSunTimes(lat, lon float64, midnight time.Time) (time.Time, time.Time) {
+	season := math.Cos(2 * math.Pi * float64(midnight.YearDay()-172) / 365)
+	dayHours := math.Min(math.Max(12+season*lat/10, 4), 20)
+	noonUTC := 12 - lon/15
+	_, offset := midnight.Zone()
+	noon := midnight.Add(time.Duration((noonUTC + float64(offset)/3600) * float64(time.Hour)))
+	half := time.Duration(dayHours / 2 * float64(time.Hour))
+	return noon.Add(-half).Truncate(time.Minute), noon.Add(half).Truncate(time.Minute)
+}
+
+// demoMidnight returns the start of the local day of t.
+func demoMidnight(t time.Time, loc *time.Location) time.Time {
+	y, m, d := t.In(loc).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, loc)
+}
+
+func round1(v float64) float64 {
+	return math.Round(v*10) / 10
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
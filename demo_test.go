@@ -0,0 +1,145 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"slices"
+	"testing"
+	"time"
+)
+
+func newTestDemoClient(t *testing.T, now time.Time) *http.Client {
+	t.Helper()
+	cities, err := NewStaticGeocodingService(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &http.Client{Transport: &demoTransport{cities: cities, now: func() time.Time { return now }}}
+}
+
+func demoGet(t *testing.T, client *http.Client, url string) *http.Response {
+	t.Helper()
+	resp, err := client.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func TestDemoTransport(t *testing.T) {
+	// The hourly parser drops the hours before the current time.
+	client := newTestDemoClient(t, time.Now())
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	const base = "http://demo.invalid/ometeo/forecast?latitude=52.23&longitude=21.01&timezone=auto&timeformat=unixtime"
+
+	t.Run("Current Weather", func(t *testing.T) {
+		resp := demoGet(t, client, base+"&current=temperature_2m&daily=sunrise,sunset&forecast_days=1")
+		current, tz, err := ParseCurrentWeatherOMeteo(resp.Body, logger)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tz != "Europe/Warsaw" {
+			t.Errorf("expected timezone Europe/Warsaw, got %q", tz)
+		}
+		if current.Temperature < -10 || current.Temperature > 40 {
+			t.Errorf("implausible temperature %v", current.Temperature)
+		}
+	})
+
+	t.Run("Hourly Forecast", func(t *testing.T) {
+		resp := demoGet(t, client, base+"&hourly=temperature_2m&forecast_days=2")
+		forecasts, _, err := ParseHourlyForecastOMeteo(resp.Body, logger, 24)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(forecasts) != 24 {
+			t.Errorf("expected 24 hourly forecasts, got %d", len(forecasts))
+		}
+	})
+
+	t.Run("Daily Forecast", func(t *testing.T) {
+		resp := demoGet(t, client, base+"&daily=temperature_2m_max&forecast_days=5")
+		forecasts, _, err := ParseDailyForecastOMeteo(resp.Body, logger, 5)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(forecasts) != 5 {
+			t.Fatalf("expected 5 daily forecasts, got %d", len(forecasts))
+		}
+		for _, f := range forecasts {
+			if f.MinTemp > f.MaxTemp {
+				t.Errorf("expected min temperature below max, got %+v", f)
+			}
+		}
+	})
+
+	t.Run("Grid", func(t *testing.T) {
+		resp := demoGet(t, client, "http://demo.invalid/ometeo/forecast?latitude=52.23,51.51&longitude=21.01,-0.13&current=temperature_2m,precipitation")
+		var points []ResponseCurrentWeatherOMeteo
+		if err := json.NewDecoder(resp.Body).Decode(&points); err != nil {
+			t.Fatal(err)
+		}
+		if len(points) != 2 {
+			t.Errorf("expected 2 grid points, got %d", len(points))
+		}
+	})
+
+	t.Run("Unsupported Request", func(t *testing.T) {
+		resp := demoGet(t, client, "http://demo.invalid/owm/?lat=52.23&lon=21.01&appid=demo")
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("expected status 404, got %d", resp.StatusCode)
+		}
+	})
+}
+
+func TestDemoWeatherDeterministic(t *testing.T) {
+	at := time.Date(2025, 1, 15, 6, 0, 0, 0, time.UTC)
+	first, second := demoWeatherAt(51.51, -0.13, at), demoWeatherAt(51.51, -0.13, at.Add(30*time.Minute))
+	if first != second {
+		t.Errorf("expected the same weather within an hour, got %+v and %+v", first, second)
+	}
+
+	var temps []float64
+	for h := range 24 {
+		temps = append(temps, demoWeatherAt(51.51, -0.13, at.Add(time.Duration(h)*time.Hour)).temperature)
+	}
+	if slices.Min(temps) == slices.Max(temps) {
+		t.Errorf("expected the temperature to vary during the day, got %v", temps)
+	}
+	if sydney, london := demoWeatherAt(-33.87, 151.21, at), demoWeatherAt(51.51, -0.13, at); sydney.temperature <= london.temperature {
+		t.Errorf("expected Sydney to be warmer than London in January, got %v and %v", sydney.temperature, london.temperature)
+	}
+}
+
+func TestApplyDemoMode(t *testing.T) {
+	for key := range demoEnvDefaults {
+		t.Setenv(key, "")
+	}
+	t.Setenv("DB_URL", "custom.db")
+
+	t.Setenv("DEMO_MODE", "false")
+	if demo, applied, err := applyDemoMode(); demo || len(applied) != 0 || err != nil {
+		t.Fatalf("expected demo mode to be disabled, got %v %v %v", demo, applied, err)
+	}
+
+	t.Setenv("DEMO_MODE", "true")
+	demo, applied, err := applyDemoMode()
+	if err != nil || !demo {
+		t.Fatalf("expected demo mode to be enabled, got %v %v", demo, err)
+	}
+	if slices.Contains(applied, "DB_URL") || os.Getenv("DB_URL") != "custom.db" {
+		t.Errorf("expected DB_URL to be kept, got %q", os.Getenv("DB_URL"))
+	}
+	if os.Getenv("DB_DRIVER") != dbDriverSQLite || os.Getenv("CACHE_BACKEND") != cacheBackendMemory {
+		t.Errorf("expected the SQLite and memory cache defaults, got %q and %q", os.Getenv("DB_DRIVER"), os.Getenv("CACHE_BACKEND"))
+	}
+
+	t.Setenv("DEMO_MODE", "maybe")
+	if _, _, err := applyDemoMode(); err == nil {
+		t.Error("expected an error for an invalid DEMO_MODE")
+	}
+}
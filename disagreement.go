@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/cor0nius/willitrain/internal/database"
+)
+
+// This file implements the provider disagreement report. Once a day a job compares the stored
+// daily forecasts of all providers for every location and records, per forecast date, how far
+// apart they are: the spread between the highest and the lowest source value of each field, and
+// the share of the sources reporting the most common condition. Large spreads mark the days the
+// forecast is uncertain for, which the consensus alone hides. /api/v1/disagreement returns the
+// latest report of a location; reports are kept for RETENTION_DAILY_DAYS.
+
+const (
+	disagreementJobName  = "provider disagreement report"
+	disagreementInterval = 24 * time.Hour
+)
+
+// disagreementReport computes the disagreement of the providers on every stored forecast date of
+// a location with at least two sources.
+func disagreementReport(forecast []DailyForecast) []ConsensusDayJSON {
+	var days []ConsensusDayJSON
+	// Stored forecast dates are midnight UTC, so the dates are formatted in UTC.
+	for _, day := range consensusDays(forecast, time.UTC, nil) {
+		if len(day.Sources) >= 2 {
+			days = append(days, day)
+		}
+	}
+	return days
+}
+
+// spread returns the difference between the highest and the lowest source value.
+func spread(v ConsensusValueJSON) float64 {
+	return Round(v.Max-v.Min, 1)
+}
+
+// storeProviderDisagreement computes and stores the disagreement report of a location for the
+// day of now, replacing one computed earlier that day. It returns the number of stored dates.
+func (cfg *apiConfig) storeProviderDisagreement(ctx context.Context, location Location, now time.Time) (int, error) {
+	y, m, d := now.UTC().Date()
+	today := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	dbForecast, err := cfg.dbQueries.GetUpcomingDailyForecastsAtLocation(ctx, database.GetUpcomingDailyForecastsAtLocationParams{
+		LocationID: location.LocationID,
+		FromDate:   today,
+		ToDate:     today.AddDate(0, 0, cfg.dailyForecastDays()),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("could not get daily forecasts: %w", err)
+	}
+	forecast := make([]DailyForecast, len(dbForecast))
+	for i, f := range dbForecast {
+		forecast[i] = databaseDailyForecastToDailyForecast(f, location)
+	}
+
+	days := disagreementReport(forecast)
+	for _, day := range days {
+		date, err := time.Parse("2006-01-02", day.ForecastDate)
+		if err != nil {
+			return 0, err
+		}
+		if err := cfg.dbQueries.UpsertProviderDisagreement(ctx, database.UpsertProviderDisagreementParams{
+			LocationID:                location.LocationID,
+			ComputedOn:                today,
+			ForecastDate:              date,
+			ComputedAt:                now.UTC(),
+			Sources:                   int32(len(day.Sources)),
+			MinTempSpreadC:            spread(day.MinTemp),
+			MaxTempSpreadC:            spread(day.MaxTemp),
+			PrecipitationSpreadMm:     spread(day.Precipitation),
+			PrecipitationChanceSpread: spread(day.PrecipitationChance),
+			WindSpeedSpreadKmh:        spread(day.WindSpeed),
+			ConditionAgreement:        day.Condition.Agreement,
+		}); err != nil {
+			return 0, fmt.Errorf("could not store disagreement for %s: %w", day.ForecastDate, err)
+		}
+	}
+	return len(days), nil
+}
+
+// storeProviderDisagreements stores the disagreement report of every location. Every location is
+// reported even if another fails, and the errors are joined.
+func (cfg *apiConfig) storeProviderDisagreements(ctx context.Context, now time.Time) error {
+	locations, err := cfg.dbQueries.ListLocations(ctx)
+	if err != nil {
+		return fmt.Errorf("could not list locations: %w", err)
+	}
+	var errs []error
+	stored := 0
+	for _, l := range locations {
+		location := databaseLocationToLocation(l)
+		n, err := cfg.storeProviderDisagreement(ctx, location, now)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", location.CityName, err))
+			continue
+		}
+		stored += n
+	}
+	cfg.logger.Info("provider disagreement report computed", "locations", len(locations), "dates", stored, "failed", len(errs))
+	return errors.Join(errs...)
+}
+
+// disagreementJob returns the scheduler job that computes the daily provider disagreement report.
+func (cfg *apiConfig) disagreementJob() SchedulerJob {
+	return SchedulerJob{
+		Name:     disagreementJobName,
+		Interval: disagreementInterval,
+		Run: func(ctx context.Context) error {
+			return cfg.storeProviderDisagreements(ctx, time.Now())
+		},
+	}
+}
+
+// spreadConfidence rates a spread as the consensus does: high up to thresholds.high, medium up to
+// thresholds.medium and low above.
+func spreadConfidence(spread float64, thresholds spreadThresholds) string {
+	switch {
+	case spread <= thresholds.high:
+		return confidenceHigh
+	case spread <= thresholds.medium:
+		return confidenceMedium
+	}
+	return confidenceLow
+}
+
+// disagreementConfidence is the lowest confidence of the temperature, precipitation and wind
+// speed spreads of a day.
+func disagreementConfidence(row database.ProviderDisagreement) string {
+	rank := map[string]int{confidenceLow: 0, confidenceMedium: 1, confidenceHigh: 2}
+	confidence := confidenceHigh
+	for _, c := range []string{
+		spreadConfidence(max(row.MinTempSpreadC, row.MaxTempSpreadC), temperatureSpread),
+		spreadConfidence(row.PrecipitationSpreadMm, precipitationSpread),
+		spreadConfidence(row.WindSpeedSpreadKmh, windSpeedSpread),
+	} {
+		if rank[c] < rank[confidence] {
+			confidence = c
+		}
+	}
+	return confidence
+}
+
+// @Summary      Get provider disagreement report
+// @Description  Retrieves the latest daily report of how far the daily forecasts of the providers diverge for a
+// @Description  location. Every forecast date with at least two sources lists the spread between the highest and
+// @Description  the lowest source value of each field, in metric units, the share of the sources reporting the
+// @Description  most common condition, and the lowest confidence of the temperature, precipitation and wind spreads.
+// @Description  The list is empty until the report has been computed for the location.
+// @Tags         weather
+// @Produce      json
+// @Param        city    query     string  false  "Location name to search for (e.g., 'London')"
+// @Param        lat     query     number  false  "Latitude for the location (e.g., 51.5074)"
+// @Param        lon     query     number  false  "Longitude for the location (e.g., -0.1278)"
+// @Success      200  {object}  DisagreementResponse
+// @Failure      400  {object}  ErrorResponse "Bad Request - Invalid location parameters"
+// @Failure      500  {object}  ErrorResponse "Internal Server Error - Failed to retrieve the report"
+// @Router       /api/v1/disagreement [get]
+func (cfg *apiConfig) handlerDisagreement(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		cfg.respondWithError(w, http.StatusMethodNotAllowed, "Method Not Allowed", nil)
+		return
+	}
+
+	location, err := cfg.getLocationFromRequest(r)
+	if err != nil {
+		cfg.respondWithError(w, http.StatusBadRequest, "Error getting location data", err)
+		return
+	}
+
+	rows, err := cfg.dbQueries.ListLatestProviderDisagreement(r.Context(), location.LocationID)
+	if err != nil {
+		cfg.respondWithError(w, http.StatusInternalServerError, "Error getting disagreement report", err)
+		return
+	}
+
+	response := DisagreementResponse{Location: location, Days: make([]DisagreementDayJSON, len(rows))}
+	for i, row := range rows {
+		if i == 0 {
+			response.ComputedAt = row.ComputedAt.UTC().Format(time.RFC3339)
+		}
+		response.Days[i] = DisagreementDayJSON{
+			ForecastDate:              row.ForecastDate.Format("2006-01-02"),
+			Sources:                   int(row.Sources),
+			MinTempSpread:             row.MinTempSpreadC,
+			MaxTempSpread:             row.MaxTempSpreadC,
+			PrecipitationSpread:       row.PrecipitationSpreadMm,
+			PrecipitationChanceSpread: row.PrecipitationChanceSpread,
+			WindSpeedSpread:           row.WindSpeedSpreadKmh,
+			ConditionAgreement:        row.ConditionAgreement,
+			Confidence:                disagreementConfidence(row),
+		}
+	}
+	cfg.respondWithJSON(w, http.StatusOK, response)
+}
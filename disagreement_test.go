@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cor0nius/willitrain/internal/database"
+	"github.com/google/uuid"
+)
+
+func TestStoreProviderDisagreements(t *testing.T) {
+	now := time.Date(2025, 6, 1, 3, 0, 0, 0, time.FixedZone("CEST", 2*60*60))
+	testCfg := newTestAPIConfig(t)
+	other := MockDBLocation
+	other.CityName = "Poznan"
+	testCfg.mockDB.ListLocationsFunc = func(ctx context.Context) ([]database.Location, error) {
+		return []database.Location{MockDBLocation, other}, nil
+	}
+	testCfg.mockDB.GetUpcomingDailyForecastsAtLocationFunc = func(ctx context.Context, arg database.GetUpcomingDailyForecastsAtLocationParams) ([]database.DailyForecast, error) {
+		if want := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC); !arg.FromDate.Equal(want) {
+			t.Errorf("FromDate = %v, want %v", arg.FromDate, want)
+		}
+		return []database.DailyForecast{MockDBDailyForecast1, MockDBDailyForecast2, MockDBDailyForecast3}, nil
+	}
+	var stored []database.UpsertProviderDisagreementParams
+	testCfg.mockDB.UpsertProviderDisagreementFunc = func(ctx context.Context, arg database.UpsertProviderDisagreementParams) error {
+		if len(stored) > 0 {
+			return errors.New("db down")
+		}
+		stored = append(stored, arg)
+		return nil
+	}
+
+	err := testCfg.storeProviderDisagreements(context.Background(), now)
+	if err == nil || !strings.Contains(err.Error(), "Poznan: could not store disagreement") {
+		t.Errorf("expected the failure of the second location to be reported, got %v", err)
+	}
+	if len(stored) != 1 {
+		t.Fatalf("expected the date with a single source to be skipped, got %+v", stored)
+	}
+	want := database.UpsertProviderDisagreementParams{
+		LocationID:                MockDBLocation.ID,
+		ComputedOn:                time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC),
+		ForecastDate:              time.Date(MockDBDailyForecast1.ForecastDate.Year(), MockDBDailyForecast1.ForecastDate.Month(), MockDBDailyForecast1.ForecastDate.Day(), 0, 0, 0, 0, time.UTC),
+		ComputedAt:                now.UTC(),
+		Sources:                   2,
+		MinTempSpreadC:            1,
+		MaxTempSpreadC:            1,
+		PrecipitationSpreadMm:     1,
+		PrecipitationChanceSpread: 5,
+		WindSpeedSpreadKmh:        1,
+		ConditionAgreement:        1,
+	}
+	if stored[0] != want {
+		t.Errorf("stored %+v, want %+v", stored[0], want)
+	}
+}
+
+func TestHandlerDisagreement(t *testing.T) {
+	computedAt := time.Date(2025, 6, 1, 3, 0, 0, 0, time.UTC)
+	rows := []database.ProviderDisagreement{
+		{ForecastDate: time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC), ComputedAt: computedAt, Sources: 3, MinTempSpreadC: 1, MaxTempSpreadC: 1.5, PrecipitationSpreadMm: 0.5, WindSpeedSpreadKmh: 4, ConditionAgreement: 1},
+		{ForecastDate: time.Date(2025, 6, 3, 0, 0, 0, 0, time.UTC), ComputedAt: computedAt, Sources: 3, MinTempSpreadC: 3, MaxTempSpreadC: 1, PrecipitationSpreadMm: 2, WindSpeedSpreadKmh: 4, ConditionAgreement: 0.67},
+		{ForecastDate: time.Date(2025, 6, 4, 0, 0, 0, 0, time.UTC), ComputedAt: computedAt, Sources: 2, MinTempSpreadC: 1, MaxTempSpreadC: 1, PrecipitationSpreadMm: 0, WindSpeedSpreadKmh: 20, ConditionAgreement: 0.5},
+	}
+
+	testCases := []struct {
+		name           string
+		method         string
+		rows           []database.ProviderDisagreement
+		wantStatus     int
+		wantComputedAt string
+		wantConfidence []string
+	}{
+		{name: "method not allowed", method: http.MethodPost, wantStatus: http.StatusMethodNotAllowed},
+		{name: "no report yet", method: http.MethodGet, wantStatus: http.StatusOK, wantConfidence: []string{}},
+		{
+			name:           "latest report",
+			method:         http.MethodGet,
+			rows:           rows,
+			wantStatus:     http.StatusOK,
+			wantComputedAt: "2025-06-01T03:00:00Z",
+			wantConfidence: []string{confidenceHigh, confidenceMedium, confidenceLow},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testCfg := newTestAPIConfig(t)
+			testCfg.mockDB.GetLocationByAliasFunc = func(ctx context.Context, alias string) (database.Location, error) {
+				return MockDBLocation, nil
+			}
+			testCfg.mockDB.ListLatestProviderDisagreementFunc = func(ctx context.Context, locationID uuid.UUID) ([]database.ProviderDisagreement, error) {
+				return tc.rows, nil
+			}
+
+			req := httptest.NewRequest(tc.method, "/api/v1/disagreement?city=wroclaw", nil)
+			rr := httptest.NewRecorder()
+			testCfg.apiConfig.handlerDisagreement(rr, req)
+
+			if rr.Code != tc.wantStatus {
+				t.Fatalf("status = %d, want %d; body: %s", rr.Code, tc.wantStatus, rr.Body.String())
+			}
+			if tc.wantConfidence == nil {
+				return
+			}
+			var response DisagreementResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if response.ComputedAt != tc.wantComputedAt || len(response.Days) != len(tc.wantConfidence) {
+				t.Fatalf("unexpected response: %+v", response)
+			}
+			for i, day := range response.Days {
+				if day.Confidence != tc.wantConfidence[i] {
+					t.Errorf("day %s: confidence = %q, want %q", day.ForecastDate, day.Confidence, tc.wantConfidence[i])
+				}
+			}
+			if len(tc.rows) > 0 && (response.Days[1].ForecastDate != "2025-06-03" || response.Days[1].MinTempSpread != 3) {
+				t.Errorf("unexpected day: %+v", response.Days[1])
+			}
+		})
+	}
+}
@@ -21,56 +21,156 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/api/config": {
+        "/admin/cache/keys": {
             "get": {
-                "description": "Provides client-side applications with necessary configuration details,\nsuch as whether the application is running in development mode and the\nintervals for scheduled weather data updates.",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Counts the Redis keys of every cache key prefix. For prefixes of location data, outdated\ncounts the keys not yet moved to the current format by the cache key migration job.\nThe keys are enumerated with SCAN, which does not block Redis but takes a while on large caches.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "configuration"
+                    "admin"
                 ],
-                "summary": "Get application configuration",
+                "summary": "Count cache keys",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/main.ConfigResponse"
+                            "$ref": "#/definitions/main.CacheKeysResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Missing API key",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - Invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error - Failed to scan cache keys",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable - Cache is being bypassed",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/api/currentweather": {
-            "get": {
-                "description": "Retrieves the current weather conditions for a specified location.\nThe location can be identified by its name, or by latitude and longitude.",
-                "consumes": [
-                    "application/json"
+        "/admin/cache/purge": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
                 ],
+                "description": "Deletes the cached current weather, daily and hourly forecasts of one location, or of all\nlocations if no location_id is given. Other cache entries, such as geocoding results, are kept.\nEntries of every forecast horizon are deleted. Use type to purge only some forecast types.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "weather"
+                    "admin"
                 ],
-                "summary": "Get current weather",
+                "summary": "Purge weather cache entries",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Location name to search for (e.g., 'London')",
-                        "name": "city",
+                        "description": "Location ID (UUID). All locations if omitted.",
+                        "name": "location_id",
                         "in": "query"
                     },
                     {
-                        "type": "number",
-                        "description": "Latitude for the location (e.g., 51.5074)",
-                        "name": "lat",
+                        "type": "string",
+                        "description": "Comma-separated cache types: currentweather, dailyforecast, hourlyforecast. All if omitted.",
+                        "name": "type",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.CachePurgeResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request - Invalid location ID or cache type",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Missing API key",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - Invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "405": {
+                        "description": "Method Not Allowed",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error - Failed to purge cache entries",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable - Cache is being bypassed",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/costs": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Reports per-provider call counts and estimated monthly spend per provider and per location,\nextrapolated from the provider usage recorded over the given window, which survives restarts.\nIncludes a what-if estimate for querying providers in fallback order, and the upstream API\nversion in use for providers with several supported versions.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Estimate provider costs",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Comma-separated provider IDs for the fallback what-if (default: cheapest first)",
+                        "name": "order",
                         "in": "query"
                     },
                     {
-                        "type": "number",
-                        "description": "Longitude for the location (e.g., -0.1278)",
-                        "name": "lon",
+                        "type": "integer",
+                        "description": "Length of the window in hours (default 168, max 2160)",
+                        "name": "hours",
                         "in": "query"
                     }
                 ],
@@ -78,17 +178,29 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/main.CurrentWeatherResponse"
+                            "$ref": "#/definitions/main.CostReportResponse"
                         }
                     },
                     "400": {
-                        "description": "Bad Request - Invalid location parameters",
+                        "description": "Bad Request - Invalid provider order or window",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Missing API key",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - Invalid API key",
                         "schema": {
                             "$ref": "#/definitions/main.ErrorResponse"
                         }
                     },
                     "500": {
-                        "description": "Internal Server Error - Failed to retrieve weather data",
+                        "description": "Internal Server Error - Failed to get provider usage",
                         "schema": {
                             "$ref": "#/definitions/main.ErrorResponse"
                         }
@@ -96,19 +208,25 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/dailyforecast": {
-            "get": {
-                "description": "Retrieves the weather forecast for the next 5 days for a specified location.\nThe location can be identified by its name, or by latitude and longitude.",
+        "/admin/import": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Validates past observations of a location, given as a JSON list or as CSV with a header row\n(Content-Type text/csv), and stores them as observations from the given source, replacing stored\nobservations of that source at the same times. Each observation has an RFC 3339 timestamp and\nat least one of temperature_c, humidity, wind_speed_kmh, precipitation_mm and condition_text.\nIf any observation is invalid, nothing is stored and the invalid rows are listed, counted from 1\nwithout the CSV header. Imports are audit-logged.",
                 "consumes": [
-                    "application/json"
+                    "application/json",
+                    "text/csv"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "weather"
+                    "admin"
                 ],
-                "summary": "Get daily forecast",
+                "summary": "Import past observations",
                 "parameters": [
                     {
                         "type": "string",
@@ -127,23 +245,65 @@ const docTemplate = `{
                         "description": "Longitude for the location (e.g., -0.1278)",
                         "name": "lon",
                         "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Source stored with the observations, such as the station name (default 'Import')",
+                        "name": "source",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Observations to import",
+                        "name": "observations",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/main.ImportObservationJSON"
+                            }
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/main.DailyForecastsResponse"
+                            "$ref": "#/definitions/main.ImportResponse"
                         }
                     },
                     "400": {
-                        "description": "Bad Request - Invalid location parameters",
+                        "description": "Bad Request - Invalid location, source or observations",
+                        "schema": {
+                            "$ref": "#/definitions/main.ImportErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Missing API key",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - Invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large - More than 8 MiB or 50000 observations",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type - Body is neither JSON nor CSV",
                         "schema": {
                             "$ref": "#/definitions/main.ErrorResponse"
                         }
                     },
                     "500": {
-                        "description": "Internal Server Error - Failed to retrieve forecast data",
+                        "description": "Internal Server Error - Failed to store observations",
                         "schema": {
                             "$ref": "#/definitions/main.ErrorResponse"
                         }
@@ -151,36 +311,38 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/hourlyforecast": {
+        "/admin/jobs": {
             "get": {
-                "description": "Retrieves the weather forecast for the next 24 hours for a specified location.\nThe location can be identified by its name, or by latitude and longitude.",
-                "consumes": [
-                    "application/json"
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
                 ],
+                "description": "Returns the most recent scheduler job runs with their status (running, succeeded, failed or\ninterrupted), duration, error and the number of locations that succeeded, failed or were\nskipped. With city, returns that location's most recent queued updates instead, each with the\nrun it belongs to, its status, queue and start times, duration and error. Runs are kept for 14 days.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "weather"
+                    "admin"
                 ],
-                "summary": "Get hourly forecast",
+                "summary": "List scheduler job runs",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Location name to search for (e.g., 'London')",
-                        "name": "city",
+                        "description": "Job name to filter by, e.g. 'current weather'",
+                        "name": "job",
                         "in": "query"
                     },
                     {
-                        "type": "number",
-                        "description": "Latitude for the location (e.g., 51.5074)",
-                        "name": "lat",
+                        "type": "string",
+                        "description": "City name or alias of an existing location",
+                        "name": "city",
                         "in": "query"
                     },
                     {
-                        "type": "number",
-                        "description": "Longitude for the location (e.g., -0.1278)",
-                        "name": "lon",
+                        "type": "integer",
+                        "description": "Maximum number of entries (default 20, max 200)",
+                        "name": "limit",
                         "in": "query"
                     }
                 ],
@@ -188,17 +350,35 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/main.HourlyForecastsResponse"
+                            "$ref": "#/definitions/main.JobRunsResponse"
                         }
                     },
                     "400": {
-                        "description": "Bad Request - Invalid location parameters",
+                        "description": "Bad Request - Invalid limit",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Missing API key",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - Invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found - Location does not exist",
                         "schema": {
                             "$ref": "#/definitions/main.ErrorResponse"
                         }
                     },
                     "500": {
-                        "description": "Internal Server Error - Failed to retrieve forecast data",
+                        "description": "Internal Server Error - Failed to get job runs",
                         "schema": {
                             "$ref": "#/definitions/main.ErrorResponse"
                         }
@@ -206,28 +386,63 @@ const docTemplate = `{
                 }
             }
         },
-        "/dev/reset-db": {
-            "post": {
-                "description": "Completely wipes the database and Redis cache. This action deletes all stored locations\nand their associated weather data. This endpoint is intended for development and testing purposes only.\nIt should not be enabled in production environments.",
+        "/admin/jobs/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Returns a scheduler job run with the queued update of every location: its status (queued,\nrunning, succeeded, failed, skipped or interrupted), queue and start times, duration and error.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "development"
+                    "admin"
+                ],
+                "summary": "Get a scheduler job run",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Job run ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Reset database and cache (development only)",
                 "responses": {
                     "200": {
-                        "description": "Confirmation of reset. Example: ` + "`" + `{\\\"status\\\":\\\"database and cache reset\\\"}` + "`" + `",
+                        "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/main.JobRunJSON"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request - Invalid job run ID",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Missing API key",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - Invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found - Job run does not exist",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
                         }
                     },
                     "500": {
-                        "description": "Internal Server Error - Failed to reset database or cache",
+                        "description": "Internal Server Error - Failed to get job run",
                         "schema": {
                             "$ref": "#/definitions/main.ErrorResponse"
                         }
@@ -235,205 +450,6600 @@ const docTemplate = `{
                 }
             }
         },
-        "/dev/runschedulerjobs": {
-            "post": {
-                "description": "Manually triggers a run of all scheduled data update jobs, including current weather,\nhourly forecast, and daily forecast updates. This endpoint is intended for development\nand testing purposes only. It should not be enabled in production environments.",
+        "/admin/locations": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "GET lists all tracked locations, ordered by city name. POST geocodes the given city and\nstores it as a tracked location if it is not one yet, so that the scheduler refreshes it on its\nnext cycle; refresh=true fetches its weather data right away in the background. Additions are audit-logged.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "development"
+                    "admin"
+                ],
+                "summary": "List or add tracked locations",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "City name to add (POST)",
+                        "name": "city",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Fetch the added location's weather data immediately (POST)",
+                        "name": "refresh",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Manually trigger scheduler jobs (development only)",
                 "responses": {
-                    "202": {
-                        "description": "Confirmation of triggering. Example:` + "`" + `{\\\"status\\\": \\\"scheduler jobs triggered\\\"}` + "`" + `",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/main.LocationsResponse"
+                        }
+                    },
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/main.Location"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request - Missing city or invalid refresh flag",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Missing API key",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - Invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error - Failed to list or add locations",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
                         }
                     }
                 }
-            }
-        }
-    },
-    "definitions": {
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "GET lists all tracked locations, ordered by city name. POST geocodes the given city and\nstores it as a tracked location if it is not one yet, so that the scheduler refreshes it on its\nnext cycle; refresh=true fetches its weather data right away in the background. Additions are audit-logged.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "List or add tracked locations",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "City name to add (POST)",
+                        "name": "city",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Fetch the added location's weather data immediately (POST)",
+                        "name": "refresh",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.LocationsResponse"
+                        }
+                    },
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/main.Location"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request - Missing city or invalid refresh flag",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Missing API key",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - Invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error - Failed to list or add locations",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/locations/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Deletes the location together with its aliases, weather data, history, watchlist entries and\nalert rules, and purges its cache entries. The deletion is audit-logged.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Delete a tracked location",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Location ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Confirmation of deletion. Example: ` + "`" + `{\\\"status\\\":\\\"location deleted\\\"}` + "`" + `",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request - Invalid location ID",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Missing API key",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - Invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found - Location does not exist",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error - Failed to delete location",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/locations/{id}/aliases": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "GET lists the aliases that resolve to the location. POST points the given alias at the\nlocation, moving it from any other location it resolved to. DELETE removes the alias.\nAliases are normalized the same way as city names in weather requests. Changes are audit-logged.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Manage a location's aliases",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Location ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Alias to add or remove (POST, DELETE)",
+                        "name": "alias",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.LocationAliasesResponse"
+                        }
+                    },
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/main.LocationAliasJSON"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request - Invalid location ID or alias",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Missing API key",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - Invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found - Location or alias does not exist",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error - Failed to access aliases",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "GET lists the aliases that resolve to the location. POST points the given alias at the\nlocation, moving it from any other location it resolved to. DELETE removes the alias.\nAliases are normalized the same way as city names in weather requests. Changes are audit-logged.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Manage a location's aliases",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Location ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Alias to add or remove (POST, DELETE)",
+                        "name": "alias",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.LocationAliasesResponse"
+                        }
+                    },
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/main.LocationAliasJSON"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request - Invalid location ID or alias",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Missing API key",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - Invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found - Location or alias does not exist",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error - Failed to access aliases",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "GET lists the aliases that resolve to the location. POST points the given alias at the\nlocation, moving it from any other location it resolved to. DELETE removes the alias.\nAliases are normalized the same way as city names in weather requests. Changes are audit-logged.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Manage a location's aliases",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Location ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Alias to add or remove (POST, DELETE)",
+                        "name": "alias",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.LocationAliasesResponse"
+                        }
+                    },
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/main.LocationAliasJSON"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request - Invalid location ID or alias",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Missing API key",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - Invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found - Location or alias does not exist",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error - Failed to access aliases",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/locations/{id}/merge": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Moves the aliases, watchlist entries, alert rules and group memberships of the location to the location given\nby into, then deletes the location with its remaining data and purges its cache entries.\nThe merge is audit-logged.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Merge a duplicate location into another one",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID of the duplicate location (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the location to merge into (UUID)",
+                        "name": "into",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.LocationMergeResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request - Invalid location IDs",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Missing API key",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - Invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found - Location does not exist",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error - Failed to merge locations",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/locations/{id}/reset": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Deletes all current weather, hourly and daily forecast rows for the given location\nand purges its cache entries. The location record and its aliases are kept.\nWhen refresh=true, the location's weather data and air quality are then fetched from the\nproviders again in the background.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Reset a single location's weather data",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Location ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Trigger an immediate refresh after the reset",
+                        "name": "refresh",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Confirmation of reset. Example: ` + "`" + `{\\\"status\\\":\\\"location reset\\\"}` + "`" + `",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "202": {
+                        "description": "Confirmation of reset with a pending refresh.",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request - Invalid location ID or refresh flag",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Missing API key",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - Invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found - Location does not exist",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error - Failed to reset location data",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/locations/{id}/weights": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "GET lists the weights of the providers in the consensus of the location, the global PROVIDER_WEIGHTS\noverridden by those set for the location, which are listed separately. PUT replaces the overrides\nwith the JSON object in the body, mapping provider IDs to weights between 0 and 100. DELETE removes\nthem. Providers without a weight count with 1. Changes are audit-logged.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Manage a location's provider weights",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Location ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.LocationProviderWeightsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request - Invalid location ID or weights",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Missing API key",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - Invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found - Location does not exist",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error - Failed to access weights",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "GET lists the weights of the providers in the consensus of the location, the global PROVIDER_WEIGHTS\noverridden by those set for the location, which are listed separately. PUT replaces the overrides\nwith the JSON object in the body, mapping provider IDs to weights between 0 and 100. DELETE removes\nthem. Providers without a weight count with 1. Changes are audit-logged.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Manage a location's provider weights",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Location ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.LocationProviderWeightsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request - Invalid location ID or weights",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Missing API key",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - Invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found - Location does not exist",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error - Failed to access weights",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "GET lists the weights of the providers in the consensus of the location, the global PROVIDER_WEIGHTS\noverridden by those set for the location, which are listed separately. PUT replaces the overrides\nwith the JSON object in the body, mapping provider IDs to weights between 0 and 100. DELETE removes\nthem. Providers without a weight count with 1. Changes are audit-logged.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Manage a location's provider weights",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Location ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.LocationProviderWeightsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request - Invalid location ID or weights",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Missing API key",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - Invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found - Location does not exist",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error - Failed to access weights",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/loglevel": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "GET reports the log level and the sample rate of the high-volume cache debug lines. PATCH changes\nthem at runtime; fields that are not set are left unchanged. Changes last until restart.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Get or change the log level",
+                "parameters": [
+                    {
+                        "description": "New level (debug, info, warn or error) and sample rate (PATCH)",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/main.LogLevelRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.LogLevelResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request - Invalid level or sample rate",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Missing API key",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - Invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "405": {
+                        "description": "Method Not Allowed",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "GET reports the log level and the sample rate of the high-volume cache debug lines. PATCH changes\nthem at runtime; fields that are not set are left unchanged. Changes last until restart.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Get or change the log level",
+                "parameters": [
+                    {
+                        "description": "New level (debug, info, warn or error) and sample rate (PATCH)",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/main.LogLevelRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.LogLevelResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request - Invalid level or sample rate",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Missing API key",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - Invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "405": {
+                        "description": "Method Not Allowed",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/migrations": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists the database migrations embedded in the binary with whether and when they were applied,\nthe latest applied version and the number of pending migrations. PostgreSQL migrations are\napplied on startup with MIGRATE_ON_START; SQLite migrations always are. Only available in\ndevelopment mode.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Get migration status",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.MigrationsResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Missing API key",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - Invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error - Failed to get migration status",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/scheduler": {
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Changes the intervals of the weather update jobs at runtime, for example to slow the refreshes\nwhen approaching a provider's quota. The tickers of the changed jobs are reset, so their next\nrun happens a full new interval from now. The intervals are stored and survive restarts; an\ninterval of 0 restores the one configured in the environment. Omitted jobs are unchanged.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Change scheduler intervals",
+                "parameters": [
+                    {
+                        "description": "New intervals in minutes (0 to 10080)",
+                        "name": "intervals",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.SchedulerIntervalsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.SchedulerStatusResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request - Invalid request body or interval",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Missing API key",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - Invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error - Failed to store scheduler intervals",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/scheduler/runs": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Returns the most recent scheduled updates of a location, one entry per job and provider,\nwith the rows written, the forecast hours covered and the error class of failed fetches.\nRuns are kept for 30 days.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Get scheduler runs for a location",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "City name or alias of an existing location",
+                        "name": "city",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Provider ID to filter by (gmp, owm, ometeo, metno)",
+                        "name": "provider",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of runs (default 20, max 500)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.SchedulerRunsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request - Missing city, unknown provider or invalid limit",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Missing API key",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - Invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found - Location does not exist",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error - Failed to get scheduler runs",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/stats/endpoints": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Returns the number of requests per public API endpoint and per hour over the given window,\nfrom the persisted request statistics. Counts from the last few minutes may not be flushed yet.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Get request statistics per endpoint",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Length of the window in hours (default 168, max 2160)",
+                        "name": "hours",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.EndpointStatsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request - Invalid window",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Missing API key",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - Invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error - Failed to get statistics",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/stats/locations": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Returns the most requested locations over the given window, from the persisted request statistics.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Get request statistics per location",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Length of the window in hours (default 168, max 2160)",
+                        "name": "hours",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of locations (default 20, max 100)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.LocationStatsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request - Invalid window or limit",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Missing API key",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - Invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error - Failed to get statistics",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/subscribers/{id}/delete": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Deletes all data stored for the given subscriber ID (e.g. \"device:abc\", \"key:\u003csha256\u003e\" or \"user:\u003cid\u003e\"),\nin a single transaction, and returns a deletion receipt. The deletion is audit-logged.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Delete a subscriber's data",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Subscriber ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.DeletionReceiptJSON"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request - Invalid subscriber ID",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Missing API key",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - Invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error - Failed to delete data",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/timezones/repair": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Compares the stored timezone of every location with the one computed from its coordinates\nand corrects any mismatches. Intended as a one-off repair for timezones that flapped between providers.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Repair location timezones (development only)",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.TimezoneRepairResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Missing API key",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - Invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error - Failed to repair timezones",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/airquality": {
+            "get": {
+                "description": "Retrieves the air quality index and the PM2.5, PM10 and ozone concentrations for a specified\nlocation, from the Open-Meteo Air Quality and OpenWeatherMap Air Pollution APIs. The index is\ngiven on each provider's own scale and mapped to a common category.\nThe location can be identified by its name, or by latitude and longitude.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "weather"
+                ],
+                "summary": "Get air quality",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Location name to search for (e.g., 'London')",
+                        "name": "city",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Latitude for the location (e.g., 51.5074)",
+                        "name": "lat",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Longitude for the location (e.g., -0.1278)",
+                        "name": "lon",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.AirQualityResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request - Invalid location parameters",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error - Failed to retrieve air quality data",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/alerts": {
+            "get": {
+                "description": "GET lists the subscriber's alerts. POST creates an alert for the location given by city or\nlat/lon: the webhook URL receives a POST when the rule starts to match the consensus hourly\nforecast within the next window_hours hours. DELETE removes the alert given by id. The subscriber\nis identified by the X-API-Key or X-Device-ID header.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "alerts"
+                ],
+                "summary": "Manage rain alerts",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "API key identifying the subscriber",
+                        "name": "X-API-Key",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Device ID identifying the subscriber",
+                        "name": "X-Device-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Location name (POST)",
+                        "name": "city",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Latitude of the location (POST)",
+                        "name": "lat",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Longitude of the location (POST)",
+                        "name": "lon",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the alert to remove (DELETE)",
+                        "name": "id",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Alert rule and webhook URL (POST)",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/main.AlertRuleRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.AlertsResponse"
+                        }
+                    },
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/main.AlertSubscriptionJSON"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request - Missing subscriber, invalid location or invalid rule",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found - Alert not found",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict - Too many alerts",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error - Failed to access alerts",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "GET lists the subscriber's alerts. POST creates an alert for the location given by city or\nlat/lon: the webhook URL receives a POST when the rule starts to match the consensus hourly\nforecast within the next window_hours hours. DELETE removes the alert given by id. The subscriber\nis identified by the X-API-Key or X-Device-ID header.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "alerts"
+                ],
+                "summary": "Manage rain alerts",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "API key identifying the subscriber",
+                        "name": "X-API-Key",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Device ID identifying the subscriber",
+                        "name": "X-Device-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Location name (POST)",
+                        "name": "city",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Latitude of the location (POST)",
+                        "name": "lat",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Longitude of the location (POST)",
+                        "name": "lon",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the alert to remove (DELETE)",
+                        "name": "id",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Alert rule and webhook URL (POST)",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/main.AlertRuleRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.AlertsResponse"
+                        }
+                    },
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/main.AlertSubscriptionJSON"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request - Missing subscriber, invalid location or invalid rule",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found - Alert not found",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict - Too many alerts",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error - Failed to access alerts",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "GET lists the subscriber's alerts. POST creates an alert for the location given by city or\nlat/lon: the webhook URL receives a POST when the rule starts to match the consensus hourly\nforecast within the next window_hours hours. DELETE removes the alert given by id. The subscriber\nis identified by the X-API-Key or X-Device-ID header.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "alerts"
+                ],
+                "summary": "Manage rain alerts",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "API key identifying the subscriber",
+                        "name": "X-API-Key",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Device ID identifying the subscriber",
+                        "name": "X-Device-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Location name (POST)",
+                        "name": "city",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Latitude of the location (POST)",
+                        "name": "lat",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Longitude of the location (POST)",
+                        "name": "lon",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the alert to remove (DELETE)",
+                        "name": "id",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Alert rule and webhook URL (POST)",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/main.AlertRuleRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.AlertsResponse"
+                        }
+                    },
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/main.AlertSubscriptionJSON"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request - Missing subscriber, invalid location or invalid rule",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found - Alert not found",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict - Too many alerts",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error - Failed to access alerts",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/attribution": {
+            "get": {
+                "description": "Lists the display names, license URLs and required notices of all weather data providers,\nand of the geocoder if it requires attribution.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "weather"
+                ],
+                "summary": "Get provider attribution",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.AttributionResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/config": {
+            "get": {
+                "description": "Provides client-side applications with necessary configuration details,\nsuch as whether the application is running in development mode and the\nintervals for scheduled weather data updates. Also suggests default cities\nfor the country given in the query or guessed from the Accept-Language header.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "configuration"
+                ],
+                "summary": "Get application configuration",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Two-letter country code hint for the city suggestions",
+                        "name": "country",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Preferred languages, used to guess the country",
+                        "name": "Accept-Language",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.ConfigResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/consensus": {
+            "get": {
+                "description": "Merges the forecasts of all sources into one forecast per hour (period=hourly, the default)\nor per day (period=daily). Numeric fields are the median of the sources, the precipitation\nchance is their average and the condition is the one reported by most sources. Each field has\na confidence of high, medium or low, based on how far apart the sources are. If provider weights\nare configured for the location, numeric fields are the weighted mean of the sources and the\ncondition is the one with the most weight; blend reports which was used.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "weather"
+                ],
+                "summary": "Get consensus forecast",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Location name to search for (e.g., 'London')",
+                        "name": "city",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Latitude for the location (e.g., 51.5074)",
+                        "name": "lat",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Longitude for the location (e.g., -0.1278)",
+                        "name": "lon",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Forecast period: 'hourly' (default) or 'daily'",
+                        "name": "period",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.ConsensusResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request - Invalid location or period parameter",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error - Failed to retrieve forecast data",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/currentweather": {
+            "get": {
+                "description": "Retrieves the current weather conditions for a specified location.\nThe location can be identified by its name, or by latitude and longitude.\nWith compare=age, each source is annotated with the minutes since its observation\nand sources are ordered from freshest to oldest.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "weather"
+                ],
+                "summary": "Get current weather",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Location name to search for (e.g., 'London')",
+                        "name": "city",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Latitude for the location (e.g., 51.5074)",
+                        "name": "lat",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Longitude for the location (e.g., -0.1278)",
+                        "name": "lon",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comparison mode; 'age' annotates and orders sources by freshness",
+                        "name": "compare",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Units of measurement, 'metric' or 'imperial' (defaults to DEFAULT_UNITS)",
+                        "name": "units",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated fields of each entry to return (e.g., 'temperature_c,precipitation_chance')",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of a previous response",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.CurrentWeatherResponse"
+                        }
+                    },
+                    "304": {
+                        "description": "Not Modified - The data has not changed since the given ETag or If-Modified-Since",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request - Invalid location parameters",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error - Failed to retrieve weather data",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/currentweather/batch": {
+            "get": {
+                "description": "Retrieves the current weather conditions for up to 20 cities at once, given as the\ncomma-separated cities parameter or, with POST, as a JSON list of city names. Results are\nkeyed by the requested city names; cities that could not be resolved or fetched are listed\nunder errors instead.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "weather"
+                ],
+                "summary": "Get current weather for several cities",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Comma-separated city names (e.g., 'wroclaw,berlin,prague')",
+                        "name": "cities",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Units of measurement, 'metric' or 'imperial' (defaults to DEFAULT_UNITS)",
+                        "name": "units",
+                        "in": "query"
+                    },
+                    {
+                        "description": "City names (POST only)",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.CurrentWeatherBatchResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request - Missing, invalid or too many cities",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Retrieves the current weather conditions for up to 20 cities at once, given as the\ncomma-separated cities parameter or, with POST, as a JSON list of city names. Results are\nkeyed by the requested city names; cities that could not be resolved or fetched are listed\nunder errors instead.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "weather"
+                ],
+                "summary": "Get current weather for several cities",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Comma-separated city names (e.g., 'wroclaw,berlin,prague')",
+                        "name": "cities",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Units of measurement, 'metric' or 'imperial' (defaults to DEFAULT_UNITS)",
+                        "name": "units",
+                        "in": "query"
+                    },
+                    {
+                        "description": "City names (POST only)",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.CurrentWeatherBatchResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request - Missing, invalid or too many cities",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/dailyforecast": {
+            "get": {
+                "description": "Retrieves the daily weather forecast for a specified location, for the next 5 days unless\nFORECAST_DAILY_DAYS configures another horizon. A shorter horizon can be requested with days.\nEach day is broken down into morning, afternoon, evening and night where the source reports them.\nThe location can be identified by its name, or by latitude and longitude.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "weather"
+                ],
+                "summary": "Get daily forecast",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Location name to search for (e.g., 'London')",
+                        "name": "city",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Latitude for the location (e.g., 51.5074)",
+                        "name": "lat",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Longitude for the location (e.g., -0.1278)",
+                        "name": "lon",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Units of measurement, 'metric' or 'imperial' (defaults to DEFAULT_UNITS)",
+                        "name": "units",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of days, between 1 and FORECAST_DAILY_DAYS (defaults to FORECAST_DAILY_DAYS)",
+                        "name": "days",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated fields of each entry to return (e.g., 'temperature_c,precipitation_chance')",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of a previous response",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.DailyForecastsResponse"
+                        }
+                    },
+                    "304": {
+                        "description": "Not Modified - The data has not changed since the given ETag or If-Modified-Since",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request - Invalid location parameters",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error - Failed to retrieve forecast data",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/disagreement": {
+            "get": {
+                "description": "Retrieves the latest daily report of how far the daily forecasts of the providers diverge for a\nlocation. Every forecast date with at least two sources lists the spread between the highest and\nthe lowest source value of each field, in metric units, the share of the sources reporting the\nmost common condition, and the lowest confidence of the temperature, precipitation and wind spreads.\nThe list is empty until the report has been computed for the location.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "weather"
+                ],
+                "summary": "Get provider disagreement report",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Location name to search for (e.g., 'London')",
+                        "name": "city",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Latitude for the location (e.g., 51.5074)",
+                        "name": "lat",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Longitude for the location (e.g., -0.1278)",
+                        "name": "lon",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.DisagreementResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request - Invalid location parameters",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error - Failed to retrieve the report",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/export": {
+            "get": {
+                "description": "Streams every stored entry of one type for a location: observed current weather (type=current,\nthe default), or the hourly or daily forecasts with the time each was issued (type=hourly,\ntype=daily). Archived entries come first in chronological order, followed by the entries that\nare still current, which have archived set to false. Entries are selected by observation time,\nforecast hour or forecast date in [from, to). The response is sent with chunked transfer encoding;\nif the export fails after it started, the connection is closed before the end of the response.",
+                "produces": [
+                    "application/json",
+                    "text/csv"
+                ],
+                "tags": [
+                    "weather"
+                ],
+                "summary": "Export stored weather data",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Location name to search for (e.g., 'London')",
+                        "name": "city",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Latitude for the location (e.g., 51.5074)",
+                        "name": "lat",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Longitude for the location (e.g., -0.1278)",
+                        "name": "lon",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Export type: 'current' (default), 'hourly' or 'daily'",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Format: 'json' (default) or 'csv'",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start, as RFC 3339 timestamp or YYYY-MM-DD date in the location's timezone (default: all stored entries)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End, exclusive, in the same formats (default: all stored entries)",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "A JSON document, or a CSV file with a header row if format=csv",
+                        "schema": {
+                            "$ref": "#/definitions/main.ExportResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request - Invalid location, type, format or time range",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/feed.atom": {
+            "get": {
+                "description": "Atom feed of a location with the same entries as the RSS feed.",
+                "produces": [
+                    "text/xml"
+                ],
+                "tags": [
+                    "weather"
+                ],
+                "summary": "Get weather summary Atom feed",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Location name to search for (e.g., 'London')",
+                        "name": "city",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Latitude for the location (e.g., 51.5074)",
+                        "name": "lat",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Longitude for the location (e.g., -0.1278)",
+                        "name": "lon",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Units of the summaries: 'metric' (default) or 'imperial'",
+                        "name": "units",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Atom feed",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request - Invalid location or units parameter",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error - Failed to retrieve forecast data",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/feed.rss": {
+            "get": {
+                "description": "RSS 2.0 feed of a location with one entry per day, summarizing today's and tomorrow's consensus\nforecast in plain text. The entry of the day is updated as the forecasts are refreshed.",
+                "produces": [
+                    "text/xml"
+                ],
+                "tags": [
+                    "weather"
+                ],
+                "summary": "Get weather summary RSS feed",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Location name to search for (e.g., 'London')",
+                        "name": "city",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Latitude for the location (e.g., 51.5074)",
+                        "name": "lat",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Longitude for the location (e.g., -0.1278)",
+                        "name": "lon",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Units of the summaries: 'metric' (default) or 'imperial'",
+                        "name": "units",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "RSS feed",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request - Invalid location or units parameter",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error - Failed to retrieve forecast data",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/grid": {
+            "post": {
+                "description": "Returns current temperature and precipitation from Open-Meteo for a regular grid of points\ninside the bounding box, for map overlays. Points are aligned to multiples of the resolution,\nwhich must be one of 0.1, 0.25, 0.5, 1 or 2 degrees. At most 2500 points are returned.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "weather"
+                ],
+                "summary": "Get a grid of current conditions",
+                "parameters": [
+                    {
+                        "description": "Bounding box and resolution",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.GridRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.GridResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request - Invalid bounding box or resolution",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway - Failed to fetch grid data",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable - Open-Meteo source disabled",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/groups": {
+            "get": {
+                "description": "GET lists the subscriber's location groups with their locations. POST creates a group from a name\nand an optional list of up to 20 city names. DELETE removes the group given by id. The subscriber\nis identified by the X-API-Key or X-Device-ID header.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "groups"
+                ],
+                "summary": "Manage location groups",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "API key identifying the subscriber",
+                        "name": "X-API-Key",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Device ID identifying the subscriber",
+                        "name": "X-Device-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the group to remove (DELETE)",
+                        "name": "id",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Group name and city names (POST)",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/main.LocationGroupRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.LocationGroupsResponse"
+                        }
+                    },
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/main.LocationGroupJSON"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request - Missing subscriber, invalid group or unknown city",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found - Group not found",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict - Too many groups or duplicate name",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error - Failed to access groups",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "GET lists the subscriber's location groups with their locations. POST creates a group from a name\nand an optional list of up to 20 city names. DELETE removes the group given by id. The subscriber\nis identified by the X-API-Key or X-Device-ID header.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "groups"
+                ],
+                "summary": "Manage location groups",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "API key identifying the subscriber",
+                        "name": "X-API-Key",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Device ID identifying the subscriber",
+                        "name": "X-Device-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the group to remove (DELETE)",
+                        "name": "id",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Group name and city names (POST)",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/main.LocationGroupRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.LocationGroupsResponse"
+                        }
+                    },
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/main.LocationGroupJSON"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request - Missing subscriber, invalid group or unknown city",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found - Group not found",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict - Too many groups or duplicate name",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error - Failed to access groups",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "GET lists the subscriber's location groups with their locations. POST creates a group from a name\nand an optional list of up to 20 city names. DELETE removes the group given by id. The subscriber\nis identified by the X-API-Key or X-Device-ID header.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "groups"
+                ],
+                "summary": "Manage location groups",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "API key identifying the subscriber",
+                        "name": "X-API-Key",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Device ID identifying the subscriber",
+                        "name": "X-Device-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the group to remove (DELETE)",
+                        "name": "id",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Group name and city names (POST)",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/main.LocationGroupRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.LocationGroupsResponse"
+                        }
+                    },
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/main.LocationGroupJSON"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request - Missing subscriber, invalid group or unknown city",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found - Group not found",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict - Too many groups or duplicate name",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error - Failed to access groups",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/groups/currentweather": {
+            "get": {
+                "description": "Retrieves the current weather conditions for every location of the group given by id in one\nrequest. Results are listed in the order of the group's locations; locations whose weather\ncould not be fetched are listed under errors, keyed by location ID, instead. The subscriber is\nidentified by the X-API-Key or X-Device-ID header.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "groups"
+                ],
+                "summary": "Get current weather for a location group",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "API key identifying the subscriber",
+                        "name": "X-API-Key",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Device ID identifying the subscriber",
+                        "name": "X-Device-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Group ID",
+                        "name": "id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Units of measurement, 'metric' or 'imperial' (defaults to DEFAULT_UNITS)",
+                        "name": "units",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.LocationGroupWeatherResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request - Missing subscriber, invalid group ID or invalid units",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found - Group not found",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error - Failed to get the group",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/groups/locations": {
+            "post": {
+                "description": "POST adds the location given by city or lat/lon to the group given by id; a group holds up to 20\nlocations. DELETE removes the location given by location_id from the group. The subscriber is\nidentified by the X-API-Key or X-Device-ID header.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "groups"
+                ],
+                "summary": "Manage the locations of a group",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "API key identifying the subscriber",
+                        "name": "X-API-Key",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Device ID identifying the subscriber",
+                        "name": "X-Device-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Group ID",
+                        "name": "id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Location name to add (POST)",
+                        "name": "city",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Latitude of the location to add (POST)",
+                        "name": "lat",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Longitude of the location to add (POST)",
+                        "name": "lon",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the location to remove (DELETE)",
+                        "name": "location_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.LocationGroupJSON"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request - Missing subscriber, invalid group ID or invalid location",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found - Group or location not found",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict - Group is full",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error - Failed to update the group",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "POST adds the location given by city or lat/lon to the group given by id; a group holds up to 20\nlocations. DELETE removes the location given by location_id from the group. The subscriber is\nidentified by the X-API-Key or X-Device-ID header.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "groups"
+                ],
+                "summary": "Manage the locations of a group",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "API key identifying the subscriber",
+                        "name": "X-API-Key",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Device ID identifying the subscriber",
+                        "name": "X-Device-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Group ID",
+                        "name": "id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Location name to add (POST)",
+                        "name": "city",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Latitude of the location to add (POST)",
+                        "name": "lat",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Longitude of the location to add (POST)",
+                        "name": "lon",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the location to remove (DELETE)",
+                        "name": "location_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.LocationGroupJSON"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request - Missing subscriber, invalid group ID or invalid location",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found - Group or location not found",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict - Group is full",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error - Failed to update the group",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/health/providers": {
+            "get": {
+                "description": "Reports the circuit breaker state of every enabled weather provider. A provider whose fetches\nkeep failing is not called until its cooldown has passed (open), after which a single trial\nfetch decides whether it is called again (half_open). The status is \"ok\" while all circuits are\nclosed, \"degraded\" while some are not, and \"down\", with status 503, while all are open.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Get provider health",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.ProviderHealthResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable - All providers are down",
+                        "schema": {
+                            "$ref": "#/definitions/main.ProviderHealthResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/history": {
+            "get": {
+                "description": "Returns archived weather of a location in chronological order: observed current weather\n(type=current, the default), or the hourly or daily forecasts as they were issued (type=hourly,\ntype=daily). Entries are selected by observation time, forecast hour or forecast date in [from, to).\nHistory is only recorded while ARCHIVE_HISTORY is enabled. If next_cursor is set, pass it as\ncursor, with the same parameters, to get the next page.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "weather"
+                ],
+                "summary": "Get weather history",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Location name to search for (e.g., 'London')",
+                        "name": "city",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Latitude for the location (e.g., 51.5074)",
+                        "name": "lat",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Longitude for the location (e.g., -0.1278)",
+                        "name": "lon",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "History type: 'current' (default), 'hourly' or 'daily'",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start, as RFC 3339 timestamp or YYYY-MM-DD date in the location's timezone (default: 7 days before to)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End, exclusive, in the same formats (default: now)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of entries per page (default 100, max 1000)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor returned as next_cursor by the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.HistoryResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request - Invalid location, type, time range, limit or cursor",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error - Failed to retrieve history",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/hourlyforecast": {
+            "get": {
+                "description": "Retrieves the hourly weather forecast for a specified location, for the next 24 hours unless\nFORECAST_HOURLY_HOURS configures another horizon. A shorter horizon can be requested with hours,\nand a window of it with from, to and limit, e.g. limit=6 for the next 6 hours.\nThe location can be identified by its name, or by latitude and longitude.\nThe response lists condition transitions (e.g. cloudy to rain at 14:00) per source and for the consensus.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "weather"
+                ],
+                "summary": "Get hourly forecast",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Location name to search for (e.g., 'London')",
+                        "name": "city",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Latitude for the location (e.g., 51.5074)",
+                        "name": "lat",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Longitude for the location (e.g., -0.1278)",
+                        "name": "lon",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Units of measurement, 'metric' or 'imperial' (defaults to DEFAULT_UNITS)",
+                        "name": "units",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of hours, between 1 and FORECAST_HOURLY_HOURS (defaults to FORECAST_HOURLY_HOURS)",
+                        "name": "hours",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start of the window as an RFC 3339 timestamp or a date in the location's timezone; the forecast of the hour it falls in is included (defaults to now)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End of the window, exclusive, as an RFC 3339 timestamp or a date in the location's timezone (defaults to the end of the horizon)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of forecast hours, between 1 and the number of hours (defaults to all)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated fields of each entry to return (e.g., 'temperature_c,precipitation_chance')",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of a previous response",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.HourlyForecastsResponse"
+                        }
+                    },
+                    "304": {
+                        "description": "Not Modified - The data has not changed since the given ETag or If-Modified-Since",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request - Invalid location or window parameters",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error - Failed to retrieve forecast data",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/map": {
+            "get": {
+                "description": "Renders a small PNG map centred on a location and marked with a pin, for location cards. The\nlocation can be identified by its name, or by latitude and longitude. The tiles are served by\nOpenStreetMap unless MAP_TILE_URL names another tile server; the attribution they require is\nreturned in the X-Attribution header.",
+                "produces": [
+                    "image/png"
+                ],
+                "tags": [
+                    "locations"
+                ],
+                "summary": "Get a static map",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Location name to search for (e.g., 'London')",
+                        "name": "city",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Latitude for the location (e.g., 51.5074)",
+                        "name": "lat",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Longitude for the location (e.g., -0.1278)",
+                        "name": "lon",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Width in pixels, between 64 and 640 (defaults to 320)",
+                        "name": "width",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Height in pixels, between 64 and 640 (defaults to 160)",
+                        "name": "height",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Zoom level, between 1 and 17 (defaults to 12)",
+                        "name": "zoom",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request - Invalid location, size or zoom",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway - Failed to fetch map tiles",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/me/delete": {
+            "post": {
+                "description": "Deletes all data stored for the subscriber identified by the session token or the X-API-Key or\nX-Device-ID header, in a single transaction, and returns a deletion receipt. With a session token,\nthe user account is deleted as well.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "privacy"
+                ],
+                "summary": "Delete my data",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "API key identifying the subscriber",
+                        "name": "X-API-Key",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Device ID identifying the subscriber",
+                        "name": "X-Device-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.DeletionReceiptJSON"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request - Missing subscriber",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error - Failed to delete data",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/refresh": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Fetches fresh current weather, hourly and daily forecasts for a location from the providers,\nregardless of how old the stored data is, stores them and purges the location's cache entries.\nThe outcome of each forecast type is ` + "`" + `updated` + "`" + `, ` + "`" + `skipped` + "`" + ` (no provider available) or ` + "`" + `failed` + "`" + `.\nIntended for support staff when a user reports wrong data.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Refresh a location's weather data",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "City name (e.g., 'Wroclaw'). Required unless lat and lon are given.",
+                        "name": "city",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Latitude. Must be given together with lon.",
+                        "name": "lat",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Longitude. Must be given together with lat.",
+                        "name": "lon",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.RefreshResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request - Missing or invalid location",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Missing API key",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - Invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "405": {
+                        "description": "Method Not Allowed",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway - No forecast type could be refreshed",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/simple/frost": {
+            "get": {
+                "description": "Returns \"1\" if any source forecasts a temperature at or below 0°C within the next\n` + "`" + `hours` + "`" + ` hours, otherwise \"0\". Intended for microcontrollers.",
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "simple"
+                ],
+                "summary": "Will there be frost? (plain text)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Location name to search for (e.g., 'London')",
+                        "name": "city",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Latitude for the location (e.g., 51.5074)",
+                        "name": "lat",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Longitude for the location (e.g., -0.1278)",
+                        "name": "lon",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Look-ahead window in hours (1-24, default 12)",
+                        "name": "hours",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "1 or 0",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request - Invalid location or hours parameter",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error - Failed to retrieve forecast data",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/simple/rain": {
+            "get": {
+                "description": "Returns \"1\" if any source forecasts precipitation (or a precipitation chance of at least\n50%) within the next ` + "`" + `hours` + "`" + ` hours, otherwise \"0\". Intended for microcontrollers.",
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "simple"
+                ],
+                "summary": "Will it rain? (plain text)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Location name to search for (e.g., 'London')",
+                        "name": "city",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Latitude for the location (e.g., 51.5074)",
+                        "name": "lat",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Longitude for the location (e.g., -0.1278)",
+                        "name": "lon",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Look-ahead window in hours (1-24, default 6)",
+                        "name": "hours",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "1 or 0",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request - Invalid location or hours parameter",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error - Failed to retrieve forecast data",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/stations/ecowitt": {
+            "post": {
+                "description": "Accepts a reading posted by an Ecowitt gateway configured to upload to a customized server in the\nEcowitt protocol. The station is identified by its PASSKEY, which must be listed in WEATHER_STATIONS.\nThe reading is stored as a local-station observation of the station's city and listed with its current weather.",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "stations"
+                ],
+                "summary": "Upload an Ecowitt station reading",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Station passkey",
+                        "name": "PASSKEY",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Observation time in UTC as 'YYYY-MM-DD HH:MM:SS', or 'now'",
+                        "name": "dateutc",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Outdoor temperature in °F",
+                        "name": "tempf",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Outdoor relative humidity in %",
+                        "name": "humidity",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Wind speed in mph",
+                        "name": "windspeedmph",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Rain rate in in/h",
+                        "name": "rainratein",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.StationUploadResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request - Invalid reading",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - Unknown station",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error - Failed to store the reading",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/stations/weatherflow": {
+            "post": {
+                "description": "Accepts a UDP broadcast message of a WeatherFlow hub, forwarded as JSON by a UDP-to-HTTP bridge. The\nstation is identified by its serial_number, which must be listed in WEATHER_STATIONS. Tempest\nobservations (type obs_st) are stored as local-station observations of the station's city and listed with\nits current weather; other message types are accepted and ignored.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "stations"
+                ],
+                "summary": "Upload a WeatherFlow station reading",
+                "parameters": [
+                    {
+                        "description": "WeatherFlow UDP message",
+                        "name": "message",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.WeatherFlowMessage"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.StationUploadResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request - Invalid message",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - Unknown station",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error - Failed to store the reading",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/summary": {
+            "get": {
+                "description": "Summarizes the consensus forecast of all sources for the rest of the day in a short sentence, such as\n\"Cloudy morning, 60% chance of rain after 15:00, high 21°C\": the prevailing condition of the current part\nof the day, the first hour with a precipitation chance of at least 50% and the day's high. The language\nis selected with lang, or otherwise from the Accept-Language header.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "weather"
+                ],
+                "summary": "Get natural-language forecast summary",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Location name to search for (e.g., 'London')",
+                        "name": "city",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Latitude for the location (e.g., 51.5074)",
+                        "name": "lat",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Longitude for the location (e.g., -0.1278)",
+                        "name": "lon",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Language of the summary: 'en', 'pl' or 'de'",
+                        "name": "lang",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Units of the summary: 'metric' (default) or 'imperial'",
+                        "name": "units",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Preferred languages, used without lang",
+                        "name": "Accept-Language",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.SummaryResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request - Invalid location, language or units parameter",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error - Failed to retrieve forecast data",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/usage": {
+            "get": {
+                "description": "Reports how many requests the API key in X-API-Key has made today (UTC), its daily quota and when\nthe count restarts. Limit and remaining are null for an unlimited key. Requests to this endpoint are\nnot counted.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "usage"
+                ],
+                "summary": "Get API key usage",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "API key",
+                        "name": "X-API-Key",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.APIKeyUsageResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Missing API key",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable - Cache is being bypassed",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/login": {
+            "post": {
+                "description": "Verifies the email address and password in the body and returns a new session token, to be sent\nas \"Authorization: Bearer \u003ctoken\u003e\". Only available with USER_JWT_SECRET set.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Log in to a user account",
+                "parameters": [
+                    {
+                        "description": "Email address and password",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.UserCredentialsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.UserSessionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request - Invalid request body",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Invalid email address or password",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found - User accounts are not enabled",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error - Failed to log in",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/me": {
+            "get": {
+                "description": "GET returns the account of the user of the session token, with its preferences. PUT replaces the\npreferences with those in the body: units ('metric' or 'imperial') and language ('en', 'pl' or\n'de') apply to requests that do not set them, and default_city to requests that name no location.\nEmpty values fall back to the server defaults.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get or update the user account",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer session token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Preferences (PUT)",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/main.UserPreferencesJSON"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.UserJSON"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request - Invalid preferences",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Missing, invalid or expired session",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found - User accounts are not enabled",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error - Failed to update the account",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "GET returns the account of the user of the session token, with its preferences. PUT replaces the\npreferences with those in the body: units ('metric' or 'imperial') and language ('en', 'pl' or\n'de') apply to requests that do not set them, and default_city to requests that name no location.\nEmpty values fall back to the server defaults.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get or update the user account",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer session token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Preferences (PUT)",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/main.UserPreferencesJSON"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.UserJSON"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request - Invalid preferences",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Missing, invalid or expired session",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found - User accounts are not enabled",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error - Failed to update the account",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/register": {
+            "post": {
+                "description": "Creates a user account with the email address and password in the body and returns a session token.\nOnly available with USER_JWT_SECRET set.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Register a user account",
+                "parameters": [
+                    {
+                        "description": "Email address and password (8 to 72 bytes)",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.UserCredentialsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/main.UserSessionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request - Invalid email address or password",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found - User accounts are not enabled",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict - Email address already registered",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error - Failed to create the account",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/warnings": {
+            "get": {
+                "description": "Retrieves the current and upcoming severe weather warnings issued by national weather services\nfor a specified location, such as storm, flood or heat warnings. The location can be identified\nby its name, or by latitude and longitude. Warnings are relayed from OpenWeatherMap One Call 3.0;\nthe list is empty while that API is unavailable.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "weather"
+                ],
+                "summary": "Get severe weather warnings",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Location name to search for (e.g., 'London')",
+                        "name": "city",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Latitude for the location (e.g., 51.5074)",
+                        "name": "lat",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Longitude for the location (e.g., -0.1278)",
+                        "name": "lon",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.WeatherWarningsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request - Invalid location parameters",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error - Failed to retrieve warnings",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/watchlist": {
+            "get": {
+                "description": "GET lists the subscriber's watched locations. POST adds the location given by city\nor lat/lon. DELETE removes the location given by location_id. The subscriber is\nidentified by the X-API-Key or X-Device-ID header.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "watchlist"
+                ],
+                "summary": "Manage a watchlist",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "API key identifying the subscriber",
+                        "name": "X-API-Key",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Device ID identifying the subscriber",
+                        "name": "X-Device-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Location name to watch (POST)",
+                        "name": "city",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Latitude of the location to watch (POST)",
+                        "name": "lat",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Longitude of the location to watch (POST)",
+                        "name": "lon",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the location to stop watching (DELETE)",
+                        "name": "location_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.WatchlistResponse"
+                        }
+                    },
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/main.Location"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request - Missing subscriber or invalid location",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error - Failed to access the watchlist",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "GET lists the subscriber's watched locations. POST adds the location given by city\nor lat/lon. DELETE removes the location given by location_id. The subscriber is\nidentified by the X-API-Key or X-Device-ID header.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "watchlist"
+                ],
+                "summary": "Manage a watchlist",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "API key identifying the subscriber",
+                        "name": "X-API-Key",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Device ID identifying the subscriber",
+                        "name": "X-Device-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Location name to watch (POST)",
+                        "name": "city",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Latitude of the location to watch (POST)",
+                        "name": "lat",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Longitude of the location to watch (POST)",
+                        "name": "lon",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the location to stop watching (DELETE)",
+                        "name": "location_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.WatchlistResponse"
+                        }
+                    },
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/main.Location"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request - Missing subscriber or invalid location",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error - Failed to access the watchlist",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "GET lists the subscriber's watched locations. POST adds the location given by city\nor lat/lon. DELETE removes the location given by location_id. The subscriber is\nidentified by the X-API-Key or X-Device-ID header.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "watchlist"
+                ],
+                "summary": "Manage a watchlist",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "API key identifying the subscriber",
+                        "name": "X-API-Key",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Device ID identifying the subscriber",
+                        "name": "X-Device-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Location name to watch (POST)",
+                        "name": "city",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Latitude of the location to watch (POST)",
+                        "name": "lat",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Longitude of the location to watch (POST)",
+                        "name": "lon",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the location to stop watching (DELETE)",
+                        "name": "location_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.WatchlistResponse"
+                        }
+                    },
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/main.Location"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request - Missing subscriber or invalid location",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error - Failed to access the watchlist",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/watchlist/updates": {
+            "get": {
+                "description": "Returns the watched locations whose weather data changed after the supplied cursor,\ntogether with a new cursor to pass on the next poll. Omitting the cursor returns\nevery watched location that has any data.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "watchlist"
+                ],
+                "summary": "Poll watchlist updates",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "API key identifying the subscriber",
+                        "name": "X-API-Key",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Device ID identifying the subscriber",
+                        "name": "X-Device-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor returned by the previous poll (RFC 3339 timestamp)",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.WatchlistUpdatesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request - Missing subscriber or invalid cursor",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error - Failed to get updates",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/dev/reset-db": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Completely wipes the database and Redis cache. This action deletes all stored locations\nand their associated weather data. This endpoint is intended for development and testing purposes only.\nIt should not be enabled in production environments.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "development"
+                ],
+                "summary": "Reset database and cache (development only)",
+                "responses": {
+                    "200": {
+                        "description": "Confirmation of reset. Example: ` + "`" + `{\\\"status\\\":\\\"database and cache reset\\\"}` + "`" + `",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Missing API key",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - Invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error - Failed to reset database or cache",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/dev/runschedulerjobs": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Manually triggers a run of all registered scheduler jobs that are not paused, including current weather,\nhourly forecast, and daily forecast updates. Pass ` + "`" + `job` + "`" + ` to run a single job, even if it is paused.\nThis endpoint is intended for development and testing purposes only. It should not be enabled in production environments.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "development"
+                ],
+                "summary": "Manually trigger scheduler jobs (development only)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Name of a single job to run (e.g., 'current weather')",
+                        "name": "job",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Confirmation of triggering. Example:` + "`" + `{\\\"status\\\": \\\"scheduler jobs triggered\\\"}` + "`" + `",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Missing API key",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - Invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found - Unknown scheduler job",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/dev/scheduler/jobs": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists every registered scheduler job with its interval, pause state and last and next run times.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "development"
+                ],
+                "summary": "Get scheduler job status (development only)",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.SchedulerStatusResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Missing API key",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - Invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/dev/scheduler/pause": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Stops the given job from running on its schedule until it is resumed.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "development"
+                ],
+                "summary": "Pause a scheduler job (development only)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Name of the job to pause (e.g., 'daily forecast')",
+                        "name": "job",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Example:` + "`" + `{\\\"status\\\": \\\"scheduler job paused\\\"}` + "`" + `",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Missing API key",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - Invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found - Unknown scheduler job",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/dev/scheduler/resume": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Re-enables scheduled runs of a paused job.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "development"
+                ],
+                "summary": "Resume a scheduler job (development only)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Name of the job to resume (e.g., 'daily forecast')",
+                        "name": "job",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Example:` + "`" + `{\\\"status\\\": \\\"scheduler job resumed\\\"}` + "`" + `",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Missing API key",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - Invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found - Unknown scheduler job",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/embed": {
+            "get": {
+                "description": "Returns a self-contained HTML widget with the current weather and a 3-day outlook of a location,\nmerged from all sources, for use in an iframe. The widget reloads itself when its weather is out\nof date and is served with caching headers like the current weather.",
+                "produces": [
+                    "text/html"
+                ],
+                "tags": [
+                    "weather"
+                ],
+                "summary": "Get embeddable weather widget",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Location name to search for (e.g., 'London')",
+                        "name": "city",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Latitude for the location (e.g., 51.5074)",
+                        "name": "lat",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Longitude for the location (e.g., -0.1278)",
+                        "name": "lon",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Color theme: 'light' (default) or 'dark'",
+                        "name": "theme",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Units: 'metric' (default) or 'imperial'",
+                        "name": "units",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Language of the condition labels: 'en', 'pl' or 'de'",
+                        "name": "lang",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "HTML widget",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request - Invalid location, theme, units or language parameter",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error - Failed to retrieve weather data",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/ws": {
+            "get": {
+                "description": "Upgrades the connection to a WebSocket and streams scheduler job events as JSON messages:\njob_started, location_succeeded, location_failed or location_skipped for every location a\nweather job updates, and job_finished with the duration and error of the run. Messages\nsent by the client are ignored. Events are dropped for clients that cannot keep up.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "scheduler"
+                ],
+                "summary": "Stream scheduler events",
+                "responses": {
+                    "101": {
+                        "description": "Switching Protocols",
+                        "schema": {
+                            "$ref": "#/definitions/main.SchedulerEventJSON"
+                        }
+                    },
+                    "405": {
+                        "description": "Method Not Allowed",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable - Too many clients or scheduler stopped",
+                        "schema": {
+                            "$ref": "#/definitions/main.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "main.APIKeyUsageResponse": {
+            "type": "object",
+            "properties": {
+                "date": {
+                    "type": "string"
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "remaining": {
+                    "type": "integer"
+                },
+                "reset_at": {
+                    "type": "string"
+                },
+                "used": {
+                    "type": "integer"
+                }
+            }
+        },
+        "main.AirQualityJSON": {
+            "type": "object",
+            "properties": {
+                "aqi": {
+                    "type": "integer"
+                },
+                "aqi_scale": {
+                    "type": "string"
+                },
+                "category": {
+                    "type": "string"
+                },
+                "ozone_ugm3": {
+                    "type": "number"
+                },
+                "pm10_ugm3": {
+                    "type": "number"
+                },
+                "pm2_5_ugm3": {
+                    "type": "number"
+                },
+                "source_api": {
+                    "type": "string"
+                },
+                "timestamp": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.AirQualityResponse": {
+            "type": "object",
+            "properties": {
+                "air_quality": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.AirQualityJSON"
+                    }
+                },
+                "attribution": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.AttributionJSON"
+                    }
+                },
+                "location": {
+                    "$ref": "#/definitions/main.Location"
+                }
+            }
+        },
+        "main.AlertRuleRequest": {
+            "type": "object",
+            "properties": {
+                "metric": {
+                    "type": "string",
+                    "example": "precipitation_chance"
+                },
+                "operator": {
+                    "type": "string",
+                    "example": "\u003e"
+                },
+                "threshold": {
+                    "type": "number",
+                    "example": 60
+                },
+                "webhook_url": {
+                    "type": "string",
+                    "example": "https://example.com/hooks/rain"
+                },
+                "window_hours": {
+                    "type": "integer",
+                    "example": 12
+                }
+            }
+        },
+        "main.AlertSubscriptionJSON": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "last_triggered_at": {
+                    "type": "string"
+                },
+                "location_id": {
+                    "type": "string"
+                },
+                "metric": {
+                    "type": "string"
+                },
+                "operator": {
+                    "type": "string"
+                },
+                "threshold": {
+                    "type": "number"
+                },
+                "triggered": {
+                    "type": "boolean"
+                },
+                "unit": {
+                    "type": "string"
+                },
+                "webhook_url": {
+                    "type": "string"
+                },
+                "window_hours": {
+                    "type": "integer"
+                }
+            }
+        },
+        "main.AlertsResponse": {
+            "type": "object",
+            "properties": {
+                "alerts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.AlertSubscriptionJSON"
+                    }
+                }
+            }
+        },
+        "main.AttributionJSON": {
+            "type": "object",
+            "properties": {
+                "display_name": {
+                    "type": "string"
+                },
+                "homepage_url": {
+                    "type": "string"
+                },
+                "license_name": {
+                    "type": "string"
+                },
+                "license_url": {
+                    "type": "string"
+                },
+                "notice": {
+                    "type": "string"
+                },
+                "provider": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.AttributionResponse": {
+            "type": "object",
+            "properties": {
+                "geocoder": {
+                    "$ref": "#/definitions/main.AttributionJSON"
+                },
+                "providers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.AttributionJSON"
+                    }
+                }
+            }
+        },
+        "main.CacheKeyPrefixJSON": {
+            "type": "object",
+            "properties": {
+                "keys": {
+                    "type": "integer"
+                },
+                "outdated": {
+                    "type": "integer"
+                },
+                "prefix": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.CacheKeysResponse": {
+            "type": "object",
+            "properties": {
+                "prefixes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.CacheKeyPrefixJSON"
+                    }
+                }
+            }
+        },
+        "main.CachePurgePrefixJSON": {
+            "type": "object",
+            "properties": {
+                "deleted": {
+                    "type": "integer"
+                },
+                "prefix": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.CachePurgeResponse": {
+            "type": "object",
+            "properties": {
+                "location_id": {
+                    "type": "string"
+                },
+                "prefixes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.CachePurgePrefixJSON"
+                    }
+                }
+            }
+        },
+        "main.CompactJSON": {
+            "type": "object",
+            "properties": {
+                "emoji": {
+                    "type": "string"
+                },
+                "summary": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.ConditionTransitionJSON": {
+            "type": "object",
+            "properties": {
+                "at": {
+                    "type": "string"
+                },
+                "forecast_datetime": {
+                    "type": "string"
+                },
+                "from": {
+                    "type": "string"
+                },
+                "source": {
+                    "type": "string"
+                },
+                "to": {
+                    "type": "string"
+                }
+            }
+        },
         "main.ConfigResponse": {
             "type": "object",
             "properties": {
-                "current_interval": {
+                "current_interval": {
+                    "type": "string"
+                },
+                "daily_interval": {
+                    "type": "string"
+                },
+                "dev_mode": {
+                    "type": "boolean"
+                },
+                "hourly_interval": {
+                    "type": "string"
+                },
+                "suggested_cities": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "suggested_country": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.ConsensusConditionJSON": {
+            "type": "object",
+            "properties": {
+                "agreement": {
+                    "type": "number"
+                },
+                "code": {
+                    "type": "string"
+                },
+                "confidence": {
+                    "type": "string"
+                },
+                "label": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.ConsensusDayJSON": {
+            "type": "object",
+            "properties": {
+                "condition": {
+                    "$ref": "#/definitions/main.ConsensusConditionJSON"
+                },
+                "forecast_date": {
+                    "type": "string"
+                },
+                "humidity": {
+                    "$ref": "#/definitions/main.ConsensusValueJSON"
+                },
+                "max_temp_c": {
+                    "$ref": "#/definitions/main.ConsensusValueJSON"
+                },
+                "min_temp_c": {
+                    "$ref": "#/definitions/main.ConsensusValueJSON"
+                },
+                "precipitation_chance": {
+                    "$ref": "#/definitions/main.ConsensusValueJSON"
+                },
+                "precipitation_mm": {
+                    "$ref": "#/definitions/main.ConsensusValueJSON"
+                },
+                "sources": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "wind_speed_kmh": {
+                    "$ref": "#/definitions/main.ConsensusValueJSON"
+                }
+            }
+        },
+        "main.ConsensusHourJSON": {
+            "type": "object",
+            "properties": {
+                "condition": {
+                    "$ref": "#/definitions/main.ConsensusConditionJSON"
+                },
+                "forecast_datetime": {
+                    "type": "string"
+                },
+                "humidity": {
+                    "$ref": "#/definitions/main.ConsensusValueJSON"
+                },
+                "precipitation_chance": {
+                    "$ref": "#/definitions/main.ConsensusValueJSON"
+                },
+                "precipitation_mm": {
+                    "$ref": "#/definitions/main.ConsensusValueJSON"
+                },
+                "sources": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "temperature_c": {
+                    "$ref": "#/definitions/main.ConsensusValueJSON"
+                },
+                "wind_speed_kmh": {
+                    "$ref": "#/definitions/main.ConsensusValueJSON"
+                }
+            }
+        },
+        "main.ConsensusResponse": {
+            "type": "object",
+            "properties": {
+                "attribution": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.AttributionJSON"
+                    }
+                },
+                "blend": {
+                    "type": "string"
+                },
+                "days": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.ConsensusDayJSON"
+                    }
+                },
+                "hours": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.ConsensusHourJSON"
+                    }
+                },
+                "location": {
+                    "$ref": "#/definitions/main.Location"
+                },
+                "period": {
+                    "type": "string"
+                },
+                "weights": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "number",
+                        "format": "float64"
+                    }
+                }
+            }
+        },
+        "main.ConsensusValueJSON": {
+            "type": "object",
+            "properties": {
+                "confidence": {
+                    "type": "string"
+                },
+                "max": {
+                    "type": "number"
+                },
+                "min": {
+                    "type": "number"
+                },
+                "value": {
+                    "type": "number"
+                }
+            }
+        },
+        "main.CostReportResponse": {
+            "type": "object",
+            "properties": {
+                "fallback_what_if": {
+                    "$ref": "#/definitions/main.FallbackWhatIfJSON"
+                },
+                "locations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.LocationCostJSON"
+                    }
+                },
+                "observed_hours": {
+                    "type": "number"
+                },
+                "observed_since": {
+                    "type": "string"
+                },
+                "operations": {
+                    "type": "integer"
+                },
+                "providers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.ProviderCostJSON"
+                    }
+                },
+                "total_monthly_estimate": {
+                    "type": "number"
+                },
+                "total_observed_cost": {
+                    "type": "number"
+                }
+            }
+        },
+        "main.CurrentWeatherBatchResponse": {
+            "type": "object",
+            "properties": {
+                "errors": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "results": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/main.CurrentWeatherResponse"
+                    }
+                }
+            }
+        },
+        "main.CurrentWeatherJSON": {
+            "type": "object",
+            "properties": {
+                "apparent_temperature_c": {
+                    "type": "number"
+                },
+                "compact": {
+                    "$ref": "#/definitions/main.CompactJSON"
+                },
+                "condition_code": {
+                    "type": "string"
+                },
+                "condition_text": {
+                    "type": "string"
+                },
+                "dew_point_c": {
+                    "type": "number"
+                },
+                "humidity": {
+                    "type": "integer"
+                },
+                "minutes_since_observation": {
+                    "type": "integer"
+                },
+                "observed_at_local": {
+                    "type": "string"
+                },
+                "precipitation_mm": {
+                    "type": "number"
+                },
+                "rain_mm": {
+                    "type": "number"
+                },
+                "snow_mm": {
+                    "type": "number"
+                },
+                "source_api": {
+                    "type": "string"
+                },
+                "sunrise": {
+                    "type": "string"
+                },
+                "sunset": {
+                    "type": "string"
+                },
+                "temperature_c": {
+                    "type": "number"
+                },
+                "timestamp": {
+                    "type": "string"
+                },
+                "uv_index": {
+                    "type": "number"
+                },
+                "wind_direction": {
+                    "type": "string"
+                },
+                "wind_direction_deg": {
+                    "type": "number"
+                },
+                "wind_gust_kmh": {
+                    "type": "number"
+                },
+                "wind_speed_kmh": {
+                    "type": "number"
+                }
+            }
+        },
+        "main.CurrentWeatherResponse": {
+            "type": "object",
+            "properties": {
+                "attribution": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.AttributionJSON"
+                    }
+                },
+                "location": {
+                    "$ref": "#/definitions/main.Location"
+                },
+                "partial": {
+                    "type": "boolean"
+                },
+                "providers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.ProviderStatusJSON"
+                    }
+                },
+                "weather": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.CurrentWeatherJSON"
+                    }
+                }
+            }
+        },
+        "main.DailyForecastJSON": {
+            "type": "object",
+            "properties": {
+                "compact": {
+                    "$ref": "#/definitions/main.CompactJSON"
+                },
+                "condition_code": {
+                    "type": "string"
+                },
+                "forecast_date": {
+                    "type": "string"
+                },
+                "humidity": {
+                    "type": "integer"
+                },
+                "max_temp_c": {
+                    "type": "number"
+                },
+                "min_temp_c": {
+                    "type": "number"
+                },
+                "parts": {
+                    "$ref": "#/definitions/main.DayPartsJSON"
+                },
+                "precipitation_chance": {
+                    "type": "integer"
+                },
+                "precipitation_mm": {
+                    "type": "number"
+                },
+                "rain_mm": {
+                    "type": "number"
+                },
+                "snow_mm": {
+                    "type": "number"
+                },
+                "source_api": {
+                    "type": "string"
+                },
+                "sunrise": {
+                    "type": "string"
+                },
+                "sunset": {
+                    "type": "string"
+                },
+                "uv_index": {
+                    "type": "number"
+                },
+                "wind_direction": {
+                    "type": "string"
+                },
+                "wind_direction_deg": {
+                    "type": "number"
+                },
+                "wind_gust_kmh": {
+                    "type": "number"
+                },
+                "wind_speed_kmh": {
+                    "type": "number"
+                }
+            }
+        },
+        "main.DailyForecastsResponse": {
+            "type": "object",
+            "properties": {
+                "attribution": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.AttributionJSON"
+                    }
+                },
+                "forecasts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.DailyForecastJSON"
+                    }
+                },
+                "location": {
+                    "$ref": "#/definitions/main.Location"
+                },
+                "partial": {
+                    "type": "boolean"
+                },
+                "providers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.ProviderStatusJSON"
+                    }
+                }
+            }
+        },
+        "main.DayPartJSON": {
+            "type": "object",
+            "properties": {
+                "condition": {
+                    "type": "string"
+                },
+                "precipitation_chance": {
+                    "type": "integer"
+                },
+                "temperature_c": {
+                    "type": "number"
+                }
+            }
+        },
+        "main.DayPartsJSON": {
+            "type": "object",
+            "properties": {
+                "afternoon": {
+                    "$ref": "#/definitions/main.DayPartJSON"
+                },
+                "evening": {
+                    "$ref": "#/definitions/main.DayPartJSON"
+                },
+                "morning": {
+                    "$ref": "#/definitions/main.DayPartJSON"
+                },
+                "night": {
+                    "$ref": "#/definitions/main.DayPartJSON"
+                }
+            }
+        },
+        "main.DeletionReceiptJSON": {
+            "type": "object",
+            "properties": {
+                "deleted": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer",
+                        "format": "int64"
+                    }
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "receipt_id": {
+                    "type": "string"
+                },
+                "subscriber_id": {
+                    "type": "string"
+                },
+                "total_deleted": {
+                    "type": "integer"
+                }
+            }
+        },
+        "main.DisagreementDayJSON": {
+            "type": "object",
+            "properties": {
+                "condition_agreement": {
+                    "type": "number"
+                },
+                "confidence": {
+                    "type": "string"
+                },
+                "forecast_date": {
+                    "type": "string"
+                },
+                "max_temp_spread_c": {
+                    "type": "number"
+                },
+                "min_temp_spread_c": {
+                    "type": "number"
+                },
+                "precipitation_chance_spread": {
+                    "type": "number"
+                },
+                "precipitation_spread_mm": {
+                    "type": "number"
+                },
+                "sources": {
+                    "type": "integer"
+                },
+                "wind_speed_spread_kmh": {
+                    "type": "number"
+                }
+            }
+        },
+        "main.DisagreementResponse": {
+            "type": "object",
+            "properties": {
+                "computed_at": {
+                    "type": "string"
+                },
+                "days": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.DisagreementDayJSON"
+                    }
+                },
+                "location": {
+                    "$ref": "#/definitions/main.Location"
+                }
+            }
+        },
+        "main.EndpointStatJSON": {
+            "type": "object",
+            "properties": {
+                "endpoint": {
+                    "type": "string"
+                },
+                "requests": {
+                    "type": "integer"
+                }
+            }
+        },
+        "main.EndpointStatsResponse": {
+            "type": "object",
+            "properties": {
+                "endpoints": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.EndpointStatJSON"
+                    }
+                },
+                "hourly": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.HourlyStatJSON"
+                    }
+                },
+                "since": {
+                    "type": "string"
+                },
+                "total_requests": {
+                    "type": "integer"
+                }
+            }
+        },
+        "main.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "request_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.ExportResponse": {
+            "type": "object",
+            "properties": {
+                "entries": {
+                    "type": "array",
+                    "items": {}
+                },
+                "location": {
+                    "$ref": "#/definitions/main.Location"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.FallbackProviderCostJSON": {
+            "type": "object",
+            "properties": {
+                "expected_calls": {
+                    "type": "number"
+                },
+                "monthly_estimate": {
+                    "type": "number"
+                },
+                "provider": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.FallbackWhatIfJSON": {
+            "type": "object",
+            "properties": {
+                "monthly_estimate": {
+                    "type": "number"
+                },
+                "monthly_savings": {
+                    "type": "number"
+                },
+                "order": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "providers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.FallbackProviderCostJSON"
+                    }
+                }
+            }
+        },
+        "main.GridPointJSON": {
+            "type": "object",
+            "properties": {
+                "latitude": {
+                    "type": "number"
+                },
+                "longitude": {
+                    "type": "number"
+                },
+                "observed_at": {
+                    "type": "string"
+                },
+                "precipitation_mm": {
+                    "type": "number"
+                },
+                "temperature_c": {
+                    "type": "number"
+                }
+            }
+        },
+        "main.GridRequest": {
+            "type": "object",
+            "properties": {
+                "max_lat": {
+                    "type": "number"
+                },
+                "max_lon": {
+                    "type": "number"
+                },
+                "min_lat": {
+                    "type": "number"
+                },
+                "min_lon": {
+                    "type": "number"
+                },
+                "resolution": {
+                    "type": "number"
+                }
+            }
+        },
+        "main.GridResponse": {
+            "type": "object",
+            "properties": {
+                "attribution": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.AttributionJSON"
+                    }
+                },
+                "points": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.GridPointJSON"
+                    }
+                },
+                "resolution": {
+                    "type": "number"
+                }
+            }
+        },
+        "main.HistoryDailyForecastJSON": {
+            "type": "object",
+            "properties": {
+                "compact": {
+                    "$ref": "#/definitions/main.CompactJSON"
+                },
+                "condition_code": {
+                    "type": "string"
+                },
+                "forecast_date": {
+                    "type": "string"
+                },
+                "humidity": {
+                    "type": "integer"
+                },
+                "issued_at": {
+                    "type": "string"
+                },
+                "max_temp_c": {
+                    "type": "number"
+                },
+                "min_temp_c": {
+                    "type": "number"
+                },
+                "parts": {
+                    "$ref": "#/definitions/main.DayPartsJSON"
+                },
+                "precipitation_chance": {
+                    "type": "integer"
+                },
+                "precipitation_mm": {
+                    "type": "number"
+                },
+                "rain_mm": {
+                    "type": "number"
+                },
+                "snow_mm": {
+                    "type": "number"
+                },
+                "source_api": {
+                    "type": "string"
+                },
+                "sunrise": {
+                    "type": "string"
+                },
+                "sunset": {
+                    "type": "string"
+                },
+                "uv_index": {
+                    "type": "number"
+                },
+                "wind_direction": {
+                    "type": "string"
+                },
+                "wind_direction_deg": {
+                    "type": "number"
+                },
+                "wind_gust_kmh": {
+                    "type": "number"
+                },
+                "wind_speed_kmh": {
+                    "type": "number"
+                }
+            }
+        },
+        "main.HistoryHourlyForecastJSON": {
+            "type": "object",
+            "properties": {
+                "apparent_temperature_c": {
+                    "type": "number"
+                },
+                "compact": {
+                    "$ref": "#/definitions/main.CompactJSON"
+                },
+                "condition_code": {
+                    "type": "string"
+                },
+                "condition_text": {
+                    "type": "string"
+                },
+                "dew_point_c": {
+                    "type": "number"
+                },
+                "forecast_datetime": {
+                    "type": "string"
+                },
+                "humidity": {
+                    "type": "integer"
+                },
+                "issued_at": {
+                    "type": "string"
+                },
+                "precipitation_chance": {
+                    "type": "integer"
+                },
+                "precipitation_mm": {
+                    "type": "number"
+                },
+                "rain_mm": {
+                    "type": "number"
+                },
+                "snow_mm": {
+                    "type": "number"
+                },
+                "source_api": {
+                    "type": "string"
+                },
+                "temperature_c": {
+                    "type": "number"
+                },
+                "wind_direction": {
+                    "type": "string"
+                },
+                "wind_direction_deg": {
+                    "type": "number"
+                },
+                "wind_gust_kmh": {
+                    "type": "number"
+                },
+                "wind_speed_kmh": {
+                    "type": "number"
+                }
+            }
+        },
+        "main.HistoryResponse": {
+            "type": "object",
+            "properties": {
+                "attribution": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.AttributionJSON"
+                    }
+                },
+                "daily": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.HistoryDailyForecastJSON"
+                    }
+                },
+                "from": {
+                    "type": "string"
+                },
+                "hourly": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.HistoryHourlyForecastJSON"
+                    }
+                },
+                "location": {
+                    "$ref": "#/definitions/main.Location"
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
+                "to": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "weather": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.CurrentWeatherJSON"
+                    }
+                }
+            }
+        },
+        "main.HourlyForecastJSON": {
+            "type": "object",
+            "properties": {
+                "apparent_temperature_c": {
+                    "type": "number"
+                },
+                "compact": {
+                    "$ref": "#/definitions/main.CompactJSON"
+                },
+                "condition_code": {
+                    "type": "string"
+                },
+                "condition_text": {
+                    "type": "string"
+                },
+                "dew_point_c": {
+                    "type": "number"
+                },
+                "forecast_datetime": {
+                    "type": "string"
+                },
+                "humidity": {
+                    "type": "integer"
+                },
+                "precipitation_chance": {
+                    "type": "integer"
+                },
+                "precipitation_mm": {
+                    "type": "number"
+                },
+                "rain_mm": {
+                    "type": "number"
+                },
+                "snow_mm": {
+                    "type": "number"
+                },
+                "source_api": {
+                    "type": "string"
+                },
+                "temperature_c": {
+                    "type": "number"
+                },
+                "wind_direction": {
+                    "type": "string"
+                },
+                "wind_direction_deg": {
+                    "type": "number"
+                },
+                "wind_gust_kmh": {
+                    "type": "number"
+                },
+                "wind_speed_kmh": {
+                    "type": "number"
+                }
+            }
+        },
+        "main.HourlyForecastsResponse": {
+            "type": "object",
+            "properties": {
+                "attribution": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.AttributionJSON"
+                    }
+                },
+                "forecasts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.HourlyForecastJSON"
+                    }
+                },
+                "location": {
+                    "$ref": "#/definitions/main.Location"
+                },
+                "partial": {
+                    "type": "boolean"
+                },
+                "providers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.ProviderStatusJSON"
+                    }
+                },
+                "transitions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.ConditionTransitionJSON"
+                    }
+                }
+            }
+        },
+        "main.HourlyStatJSON": {
+            "type": "object",
+            "properties": {
+                "hour": {
+                    "type": "string"
+                },
+                "requests": {
+                    "type": "integer"
+                }
+            }
+        },
+        "main.ImportErrorResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "request_id": {
+                    "type": "string"
+                },
+                "rows": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.ImportRowError"
+                    }
+                }
+            }
+        },
+        "main.ImportObservationJSON": {
+            "type": "object",
+            "properties": {
+                "condition_text": {
+                    "type": "string"
+                },
+                "humidity": {
+                    "type": "integer"
+                },
+                "precipitation_mm": {
+                    "type": "number"
+                },
+                "temperature_c": {
+                    "type": "number"
+                },
+                "timestamp": {
+                    "type": "string"
+                },
+                "wind_speed_kmh": {
+                    "type": "number"
+                }
+            }
+        },
+        "main.ImportResponse": {
+            "type": "object",
+            "properties": {
+                "from": {
+                    "type": "string"
+                },
+                "imported": {
+                    "type": "integer"
+                },
+                "location": {
+                    "$ref": "#/definitions/main.Location"
+                },
+                "source": {
+                    "type": "string"
+                },
+                "to": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.ImportRowError": {
+            "type": "object",
+            "properties": {
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "row": {
+                    "type": "integer"
+                }
+            }
+        },
+        "main.JobRunJSON": {
+            "type": "object",
+            "properties": {
+                "duration_ms": {
+                    "type": "integer"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "job": {
+                    "type": "string"
+                },
+                "locations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.JobRunLocationJSON"
+                    }
+                },
+                "locations_failed": {
+                    "type": "integer"
+                },
+                "locations_skipped": {
+                    "type": "integer"
+                },
+                "locations_succeeded": {
+                    "type": "integer"
+                },
+                "locations_total": {
+                    "type": "integer"
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.JobRunLocationJSON": {
+            "type": "object",
+            "properties": {
+                "city_name": {
+                    "type": "string"
+                },
+                "duration_ms": {
+                    "type": "integer"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "job": {
+                    "type": "string"
+                },
+                "location_id": {
+                    "type": "string"
+                },
+                "queued_at": {
+                    "type": "string"
+                },
+                "run_id": {
+                    "type": "string"
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.JobRunsResponse": {
+            "type": "object",
+            "properties": {
+                "city_name": {
+                    "type": "string"
+                },
+                "location_id": {
+                    "type": "string"
+                },
+                "runs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.JobRunJSON"
+                    }
+                },
+                "updates": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.JobRunLocationJSON"
+                    }
+                }
+            }
+        },
+        "main.Location": {
+            "type": "object",
+            "properties": {
+                "city_name": {
+                    "type": "string"
+                },
+                "country_code": {
+                    "type": "string"
+                },
+                "latitude": {
+                    "type": "number"
+                },
+                "location_id": {
+                    "type": "string"
+                },
+                "longitude": {
+                    "type": "number"
+                },
+                "timezone": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.LocationAliasJSON": {
+            "type": "object",
+            "properties": {
+                "alias": {
+                    "type": "string"
+                },
+                "location_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.LocationAliasesResponse": {
+            "type": "object",
+            "properties": {
+                "aliases": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "location": {
+                    "$ref": "#/definitions/main.Location"
+                }
+            }
+        },
+        "main.LocationCostJSON": {
+            "type": "object",
+            "properties": {
+                "city_name": {
+                    "type": "string"
+                },
+                "location_id": {
+                    "type": "string"
+                },
+                "monthly_estimate": {
+                    "type": "number"
+                },
+                "observed_cost": {
+                    "type": "number"
+                },
+                "operations": {
+                    "type": "integer"
+                }
+            }
+        },
+        "main.LocationGroupJSON": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "locations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.Location"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.LocationGroupRequest": {
+            "type": "object",
+            "properties": {
+                "cities": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.LocationGroupWeatherResponse": {
+            "type": "object",
+            "properties": {
+                "errors": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "group": {
+                    "$ref": "#/definitions/main.LocationGroupJSON"
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.CurrentWeatherResponse"
+                    }
+                }
+            }
+        },
+        "main.LocationGroupsResponse": {
+            "type": "object",
+            "properties": {
+                "groups": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.LocationGroupJSON"
+                    }
+                }
+            }
+        },
+        "main.LocationMergeResponse": {
+            "type": "object",
+            "properties": {
+                "alert_subscriptions_moved": {
+                    "type": "integer"
+                },
+                "aliases_moved": {
+                    "type": "integer"
+                },
+                "group_members_moved": {
+                    "type": "integer"
+                },
+                "location": {
+                    "$ref": "#/definitions/main.Location"
+                },
+                "merged_location_id": {
+                    "type": "string"
+                },
+                "watchlist_entries_moved": {
+                    "type": "integer"
+                }
+            }
+        },
+        "main.LocationProviderWeightsResponse": {
+            "type": "object",
+            "properties": {
+                "blend": {
+                    "type": "string"
+                },
+                "location": {
+                    "$ref": "#/definitions/main.Location"
+                },
+                "overrides": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "number",
+                        "format": "float64"
+                    }
+                },
+                "weights": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "number",
+                        "format": "float64"
+                    }
+                }
+            }
+        },
+        "main.LocationStatJSON": {
+            "type": "object",
+            "properties": {
+                "city_name": {
+                    "type": "string"
+                },
+                "country_code": {
+                    "type": "string"
+                },
+                "location_id": {
+                    "type": "string"
+                },
+                "requests": {
+                    "type": "integer"
+                }
+            }
+        },
+        "main.LocationStatsResponse": {
+            "type": "object",
+            "properties": {
+                "locations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.LocationStatJSON"
+                    }
+                },
+                "since": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.LocationsResponse": {
+            "type": "object",
+            "properties": {
+                "locations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.Location"
+                    }
+                }
+            }
+        },
+        "main.LogLevelRequest": {
+            "type": "object",
+            "properties": {
+                "level": {
+                    "type": "string"
+                },
+                "sample_rate": {
+                    "type": "integer"
+                }
+            }
+        },
+        "main.LogLevelResponse": {
+            "type": "object",
+            "properties": {
+                "level": {
+                    "type": "string"
+                },
+                "sample_rate": {
+                    "type": "integer"
+                }
+            }
+        },
+        "main.MigrationJSON": {
+            "type": "object",
+            "properties": {
+                "applied_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "state": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "main.MigrationsResponse": {
+            "type": "object",
+            "properties": {
+                "driver": {
+                    "type": "string"
+                },
+                "migrations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.MigrationJSON"
+                    }
+                },
+                "pending": {
+                    "type": "integer"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "main.ProviderCostJSON": {
+            "type": "object",
+            "properties": {
+                "api_version": {
+                    "type": "string"
+                },
+                "calls": {
+                    "type": "integer"
+                },
+                "cost_per_call": {
+                    "type": "number"
+                },
+                "display_name": {
+                    "type": "string"
+                },
+                "failures": {
+                    "type": "integer"
+                },
+                "monthly_estimate": {
+                    "type": "number"
+                },
+                "observed_cost": {
+                    "type": "number"
+                },
+                "provider": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.ProviderHealthJSON": {
+            "type": "object",
+            "properties": {
+                "consecutive_failures": {
+                    "type": "integer"
+                },
+                "opened_at": {
+                    "type": "string"
+                },
+                "provider": {
+                    "type": "string"
+                },
+                "retry_at": {
+                    "type": "string"
+                },
+                "state": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.ProviderHealthResponse": {
+            "type": "object",
+            "properties": {
+                "providers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.ProviderHealthJSON"
+                    }
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.ProviderStatusJSON": {
+            "type": "object",
+            "properties": {
+                "display_name": {
                     "type": "string"
                 },
-                "daily_interval": {
+                "message": {
                     "type": "string"
                 },
-                "dev_mode": {
-                    "type": "boolean"
+                "provider": {
+                    "type": "string"
                 },
-                "hourly_interval": {
+                "status": {
                     "type": "string"
                 }
             }
         },
-        "main.CurrentWeatherJSON": {
+        "main.RefreshResponse": {
             "type": "object",
             "properties": {
-                "condition_text": {
+                "location": {
+                    "$ref": "#/definitions/main.Location"
+                },
+                "results": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "main.SchedulerEventJSON": {
+            "type": "object",
+            "properties": {
+                "city_name": {
                     "type": "string"
                 },
-                "humidity": {
+                "duration_ms": {
                     "type": "integer"
                 },
-                "precipitation_mm": {
-                    "type": "number"
+                "error": {
+                    "type": "string"
                 },
-                "source_api": {
+                "job": {
                     "type": "string"
                 },
-                "temperature_c": {
-                    "type": "number"
+                "location_id": {
+                    "type": "string"
                 },
-                "timestamp": {
+                "time": {
                     "type": "string"
                 },
-                "wind_speed_kmh": {
-                    "type": "number"
+                "type": {
+                    "type": "string"
                 }
             }
         },
-        "main.CurrentWeatherResponse": {
+        "main.SchedulerIntervalsRequest": {
             "type": "object",
             "properties": {
-                "location": {
-                    "$ref": "#/definitions/main.Location"
+                "air_quality_interval_min": {
+                    "type": "integer",
+                    "example": 120
                 },
-                "weather": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/main.CurrentWeatherJSON"
-                    }
+                "current_interval_min": {
+                    "type": "integer",
+                    "example": 30
+                },
+                "daily_interval_min": {
+                    "type": "integer",
+                    "example": 720
+                },
+                "hourly_interval_min": {
+                    "type": "integer",
+                    "example": 180
                 }
             }
         },
-        "main.DailyForecastJSON": {
+        "main.SchedulerJobStatus": {
             "type": "object",
             "properties": {
-                "forecast_date": {
+                "interval": {
                     "type": "string"
                 },
-                "humidity": {
+                "last_error": {
+                    "type": "string"
+                },
+                "last_run": {
+                    "type": "string"
+                },
+                "last_success": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "next_run": {
+                    "type": "string"
+                },
+                "paused": {
+                    "type": "boolean"
+                },
+                "running": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "main.SchedulerRunJSON": {
+            "type": "object",
+            "properties": {
+                "duration_ms": {
                     "type": "integer"
                 },
-                "max_temp_c": {
-                    "type": "number"
+                "error": {
+                    "type": "string"
                 },
-                "min_temp_c": {
-                    "type": "number"
+                "error_class": {
+                    "type": "string"
                 },
-                "precipitation_chance": {
+                "hours_covered": {
                     "type": "integer"
                 },
-                "precipitation_mm": {
-                    "type": "number"
+                "job_type": {
+                    "type": "string"
                 },
-                "source_api": {
+                "provider": {
                     "type": "string"
                 },
-                "wind_speed_kmh": {
-                    "type": "number"
+                "rows_written": {
+                    "type": "integer"
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "success": {
+                    "type": "boolean"
                 }
             }
         },
-        "main.DailyForecastsResponse": {
+        "main.SchedulerRunsResponse": {
             "type": "object",
             "properties": {
-                "forecasts": {
+                "city_name": {
+                    "type": "string"
+                },
+                "location_id": {
+                    "type": "string"
+                },
+                "runs": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/main.DailyForecastJSON"
+                        "$ref": "#/definitions/main.SchedulerRunJSON"
+                    }
+                }
+            }
+        },
+        "main.SchedulerStatusResponse": {
+            "type": "object",
+            "properties": {
+                "jobs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.SchedulerJobStatus"
+                    }
+                }
+            }
+        },
+        "main.StationUploadResponse": {
+            "type": "object",
+            "properties": {
+                "location": {
+                    "$ref": "#/definitions/main.Location"
+                },
+                "source": {
+                    "type": "string"
+                },
+                "stored": {
+                    "type": "integer"
+                }
+            }
+        },
+        "main.SummaryResponse": {
+            "type": "object",
+            "properties": {
+                "attribution": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.AttributionJSON"
                     }
                 },
+                "date": {
+                    "type": "string"
+                },
+                "language": {
+                    "type": "string"
+                },
                 "location": {
                     "$ref": "#/definitions/main.Location"
+                },
+                "summary": {
+                    "type": "string"
                 }
             }
         },
-        "main.ErrorResponse": {
+        "main.TimezoneRepairResponse": {
             "type": "object",
             "properties": {
-                "error": {
+                "checked": {
+                    "type": "integer"
+                },
+                "repaired": {
+                    "type": "integer"
+                }
+            }
+        },
+        "main.UserCredentialsRequest": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "password": {
                     "type": "string"
                 }
             }
         },
-        "main.HourlyForecastJSON": {
+        "main.UserJSON": {
             "type": "object",
             "properties": {
-                "condition_text": {
+                "created_at": {
                     "type": "string"
                 },
-                "forecast_datetime": {
+                "email": {
                     "type": "string"
                 },
-                "humidity": {
-                    "type": "integer"
+                "id": {
+                    "type": "string"
                 },
-                "precipitation_chance": {
-                    "type": "integer"
+                "preferences": {
+                    "$ref": "#/definitions/main.UserPreferencesJSON"
+                }
+            }
+        },
+        "main.UserPreferencesJSON": {
+            "type": "object",
+            "properties": {
+                "default_city": {
+                    "type": "string"
                 },
-                "precipitation_mm": {
-                    "type": "number"
+                "language": {
+                    "type": "string"
                 },
-                "source_api": {
+                "units": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.UserSessionResponse": {
+            "type": "object",
+            "properties": {
+                "expires_at": {
                     "type": "string"
                 },
-                "temperature_c": {
-                    "type": "number"
+                "token": {
+                    "type": "string"
                 },
-                "wind_speed_kmh": {
-                    "type": "number"
+                "user": {
+                    "$ref": "#/definitions/main.UserJSON"
                 }
             }
         },
-        "main.HourlyForecastsResponse": {
+        "main.WatchlistResponse": {
             "type": "object",
             "properties": {
-                "forecasts": {
+                "locations": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/main.HourlyForecastJSON"
+                        "$ref": "#/definitions/main.Location"
                     }
-                },
+                }
+            }
+        },
+        "main.WatchlistUpdateJSON": {
+            "type": "object",
+            "properties": {
                 "location": {
                     "$ref": "#/definitions/main.Location"
+                },
+                "updated_at": {
+                    "type": "string"
                 }
             }
         },
-        "main.Location": {
+        "main.WatchlistUpdatesResponse": {
             "type": "object",
             "properties": {
-                "city_name": {
+                "cursor": {
                     "type": "string"
                 },
-                "country_code": {
+                "updates": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.WatchlistUpdateJSON"
+                    }
+                }
+            }
+        },
+        "main.WeatherFlowMessage": {
+            "type": "object",
+            "properties": {
+                "hub_sn": {
                     "type": "string"
                 },
-                "latitude": {
-                    "type": "number"
+                "obs": {
+                    "type": "array",
+                    "items": {
+                        "type": "array",
+                        "items": {
+                            "type": "number",
+                            "format": "float64"
+                        }
+                    }
                 },
-                "location_id": {
+                "serial_number": {
                     "type": "string"
                 },
-                "longitude": {
-                    "type": "number"
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.WeatherWarningJSON": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string"
                 },
-                "timezone": {
+                "end": {
+                    "type": "string"
+                },
+                "event": {
+                    "type": "string"
+                },
+                "sender": {
+                    "type": "string"
+                },
+                "source_api": {
+                    "type": "string"
+                },
+                "start": {
                     "type": "string"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "main.WeatherWarningsResponse": {
+            "type": "object",
+            "properties": {
+                "attribution": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.AttributionJSON"
+                    }
+                },
+                "location": {
+                    "$ref": "#/definitions/main.Location"
+                },
+                "warnings": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.WeatherWarningJSON"
+                    }
                 }
             }
         }
+    },
+    "securityDefinitions": {
+        "ApiKeyAuth": {
+            "type": "apiKey",
+            "name": "X-API-Key",
+            "in": "header"
+        }
     }
 }`
 
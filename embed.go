@@ -0,0 +1,221 @@
+package main
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// This file implements /embed, a small weather widget that bloggers can include in their pages
+// with an iframe. It shows the consensus of the current weather of all sources and a 3-day
+// outlook, and is rendered on the server from its own template, independent of the frontend, so
+// that it is a single self-contained HTML document without further requests. Like the weather
+// endpoints, it is sent with an ETag and a max-age covering the time the current weather is
+// cached, so that proxies and browsers can serve the pages of popular locations, and it reloads
+// itself once its weather is out of date.
+
+// embedDays is the number of days of the outlook, starting today.
+const embedDays = 3
+
+// Themes of the widget, selected with the theme query parameter.
+const (
+	embedThemeLight = "light"
+	embedThemeDark  = "dark"
+)
+
+var errInvalidEmbedTheme = errors.New("theme must be either light or dark")
+
+//go:embed templates/embed.html
+var embedTemplateFS embed.FS
+
+// embedTemplate renders the widget. It is parsed at startup so that a broken template fails fast.
+var embedTemplate = template.Must(template.ParseFS(embedTemplateFS, "templates/embed.html"))
+
+// embedData is the data the widget template is rendered with. Values are formatted in the
+// request's units and condition labels in its locale.
+type embedData struct {
+	Lang          string
+	Theme         string
+	City          string
+	Link          string
+	Current       *embedCurrent
+	Days          []embedDay
+	Updated       string
+	Sources       string
+	RefreshMillis int64
+}
+
+// embedCurrent is the consensus of the current weather of all sources.
+type embedCurrent struct {
+	Emoji       string
+	Condition   string
+	Temperature string
+	Wind        string
+}
+
+// embedDay is the consensus forecast of one day of the outlook.
+type embedDay struct {
+	Label               string
+	Emoji               string
+	Condition           string
+	High                string
+	Low                 string
+	PrecipitationChance int
+}
+
+// @Summary      Get embeddable weather widget
+// @Description  Returns a self-contained HTML widget with the current weather and a 3-day outlook of a location,
+// @Description  merged from all sources, for use in an iframe. The widget reloads itself when its weather is out
+// @Description  of date and is served with caching headers like the current weather.
+// @Tags         weather
+// @Produce      html
+// @Param        city   query     string  false  "Location name to search for (e.g., 'London')"
+// @Param        lat    query     number  false  "Latitude for the location (e.g., 51.5074)"
+// @Param        lon    query     number  false  "Longitude for the location (e.g., -0.1278)"
+// @Param        theme  query     string  false  "Color theme: 'light' (default) or 'dark'"
+// @Param        units  query     string  false  "Units: 'metric' (default) or 'imperial'"
+// @Param        lang   query     string  false  "Language of the condition labels: 'en', 'pl' or 'de'"
+// @Success      200  {string}  string  "HTML widget"
+// @Failure      400  {object}  ErrorResponse "Bad Request - Invalid location, theme, units or language parameter"
+// @Failure      500  {object}  ErrorResponse "Internal Server Error - Failed to retrieve weather data"
+// @Router       /embed [get]
+func (cfg *apiConfig) handlerEmbed(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		cfg.respondWithError(w, http.StatusMethodNotAllowed, "Method Not Allowed", nil)
+		return
+	}
+
+	theme := strings.ToLower(r.URL.Query().Get("theme"))
+	switch theme {
+	case "":
+		theme = embedThemeLight
+	case embedThemeLight, embedThemeDark:
+	default:
+		cfg.respondWithError(w, http.StatusBadRequest, errInvalidEmbedTheme.Error(), nil)
+		return
+	}
+	lang, ok := requestLocale(r)
+	if !ok {
+		cfg.respondWithError(w, http.StatusBadRequest, errInvalidLocale.Error(), nil)
+		return
+	}
+	units, err := cfg.requestUnits(r)
+	if err != nil {
+		cfg.respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	location, err := cfg.getLocationFromRequest(r)
+	if err != nil {
+		cfg.respondWithError(w, http.StatusBadRequest, "Error getting location data", err)
+		return
+	}
+
+	current, err := cfg.getCachedOrFetchCurrentWeather(ctx, location)
+	if err != nil {
+		cfg.respondWithError(w, http.StatusInternalServerError, "Error getting current weather data", err)
+		return
+	}
+	daily, err := cfg.getCachedOrFetchDailyForecast(ctx, location)
+	if err != nil {
+		cfg.respondWithError(w, http.StatusInternalServerError, "Error getting daily forecast data", err)
+		return
+	}
+
+	loc, err := time.LoadLocation(location.Timezone)
+	if err != nil {
+		cfg.logger.WarnContext(ctx, "could not load location timezone, falling back to UTC", "timezone", location.Timezone, "error", err)
+		loc = time.UTC
+	}
+
+	locale := localeBundles[lang]
+	data := embedData{
+		Lang:          lang,
+		Theme:         theme,
+		City:          location.CityName,
+		Link:          requestBaseURL(r) + "/?" + url.Values{"city": {location.CityName}}.Encode(),
+		Current:       embedCurrentWeather(current, locale, units),
+		Days:          embedOutlook(consensusDays(daily, loc, nil), time.Now().In(loc), locale, units),
+		RefreshMillis: weatherCacheTTL.Milliseconds(),
+	}
+	var sources []string
+	for _, c := range current {
+		sources = append(sources, c.SourceAPI)
+	}
+	for _, f := range daily {
+		sources = append(sources, f.SourceAPI)
+	}
+	var names []string
+	for _, a := range attributionForSources(sources) {
+		names = append(names, a.DisplayName)
+	}
+	data.Sources = strings.Join(names, ", ")
+	updatedAt := latestTimestamp(current)
+	if !updatedAt.IsZero() {
+		data.Updated = updatedAt.UTC().Format(time.RFC3339)
+	}
+
+	var buf bytes.Buffer
+	if err := embedTemplate.Execute(&buf, data); err != nil {
+		cfg.respondWithError(w, http.StatusInternalServerError, "Error rendering widget", err)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	cfg.respondWithConditional(w, r, buf.Bytes(), updatedAt, weatherCacheTTL)
+}
+
+// embedCurrentWeather merges the current weather of all sources into the median temperature and
+// wind speed and the condition reported by most sources. It returns nil without any source.
+func embedCurrentWeather(weather []CurrentWeather, locale *localeBundle, units unitSystem) *embedCurrent {
+	if len(weather) == 0 {
+		return nil
+	}
+	var codes []string
+	var temperature, windSpeed []float64
+	for _, c := range weather {
+		codes = append(codes, normalizeCondition(c.Condition))
+		temperature = append(temperature, c.Temperature)
+		windSpeed = append(windSpeed, c.WindSpeed)
+	}
+	code := consensusCondition(codes).Code
+	return &embedCurrent{
+		Emoji:       conditionDisplays[code].Emoji,
+		Condition:   locale.conditionLabel(code),
+		Temperature: formatSummaryNumber(units.temperature(consensusMedian(temperature, temperatureSpread).Value), 0) + " " + units.temperatureSymbol(),
+		Wind:        formatSummaryNumber(units.windSpeed(consensusMedian(windSpeed, windSpeedSpread).Value), 0) + " " + units.windSpeedSymbol(),
+	}
+}
+
+// embedOutlook returns the first embedDays days of the consensus forecast from the day of now,
+// in the location's timezone. The first day is labeled "Today", the others by their weekday.
+func embedOutlook(days []ConsensusDayJSON, now time.Time, locale *localeBundle, units unitSystem) []embedDay {
+	today := now.Format("2006-01-02")
+	var outlook []embedDay
+	for _, day := range days {
+		if day.ForecastDate < today || len(outlook) == embedDays {
+			continue
+		}
+		label := "Today"
+		if day.ForecastDate != today {
+			date, err := time.Parse("2006-01-02", day.ForecastDate)
+			if err != nil {
+				continue
+			}
+			label = date.Format("Mon")
+		}
+		outlook = append(outlook, embedDay{
+			Label:               label,
+			Emoji:               conditionDisplays[day.Condition.Code].Emoji,
+			Condition:           locale.conditionLabel(day.Condition.Code),
+			High:                formatSummaryNumber(units.temperature(day.MaxTemp.Value), 0) + "°",
+			Low:                 formatSummaryNumber(units.temperature(day.MinTemp.Value), 0) + "°",
+			PrecipitationChance: int(Round(day.PrecipitationChance.Value, 0)),
+		})
+	}
+	return outlook
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cor0nius/willitrain/internal/database"
+)
+
+func TestEmbedCurrentWeather(t *testing.T) {
+	weather := []CurrentWeather{
+		{SourceAPI: "a", Temperature: 11.6, WindSpeed: 10, Condition: "Light rain"},
+		{SourceAPI: "b", Temperature: 12.4, WindSpeed: 14, Condition: "Rain"},
+		{SourceAPI: "c", Temperature: 20, WindSpeed: 30, Condition: "Clear sky"},
+	}
+
+	got := embedCurrentWeather(weather, englishLocale, unitsMetric)
+	want := embedCurrent{Emoji: conditionDisplays[conditionRain].Emoji, Condition: "Rain", Temperature: "12 °C", Wind: "14 km/h"}
+	if got == nil || *got != want {
+		t.Errorf("embedCurrentWeather() = %+v, want %+v", got, want)
+	}
+	if got := embedCurrentWeather(weather, polishLocale, unitsImperial); got.Condition != polishLocale.conditionLabel(conditionRain) || got.Temperature != "54 °F" {
+		t.Errorf("unexpected localized current weather %+v", got)
+	}
+	if got := embedCurrentWeather(nil, englishLocale, unitsMetric); got != nil {
+		t.Errorf("expected nil without sources, got %+v", got)
+	}
+}
+
+func TestEmbedOutlook(t *testing.T) {
+	now := time.Date(2025, 6, 2, 8, 30, 0, 0, time.UTC) // A Monday.
+	var days []ConsensusDayJSON
+	for _, date := range []string{"2025-06-01", "2025-06-02", "2025-06-03", "2025-06-04", "2025-06-05"} {
+		days = append(days, ConsensusDayJSON{
+			ForecastDate:        date,
+			MinTemp:             ConsensusValueJSON{Value: 8.4},
+			MaxTemp:             ConsensusValueJSON{Value: 17.6},
+			PrecipitationChance: ConsensusValueJSON{Value: 33.3},
+			Condition:           ConsensusConditionJSON{Code: conditionDry},
+		})
+	}
+
+	outlook := embedOutlook(days, now, englishLocale, unitsMetric)
+	if len(outlook) != embedDays {
+		t.Fatalf("expected %d days, got %d", embedDays, len(outlook))
+	}
+	for i, label := range []string{"Today", "Tue", "Wed"} {
+		if outlook[i].Label != label {
+			t.Errorf("day %d: label = %q, want %q", i, outlook[i].Label, label)
+		}
+	}
+	want := embedDay{Label: "Today", Emoji: conditionDisplays[conditionDry].Emoji, Condition: "Dry", High: "18°", Low: "8°", PrecipitationChance: 33}
+	if outlook[0] != want {
+		t.Errorf("outlook[0] = %+v, want %+v", outlook[0], want)
+	}
+}
+
+func TestHandlerEmbed(t *testing.T) {
+	updatedAt := time.Now().UTC().Add(-time.Minute).Truncate(time.Second)
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	current, _ := json.Marshal([]CurrentWeather{
+		{SourceAPI: "Open-Meteo API", Timestamp: updatedAt, Temperature: 14.2, WindSpeed: 12, Condition: "Overcast"},
+	})
+	daily, _ := json.Marshal([]DailyForecast{
+		{SourceAPI: "Open-Meteo API", Timestamp: updatedAt, ForecastDate: today, MinTemp: 9, MaxTemp: 16, Precipitation: 4.2, PrecipitationChance: 70},
+		{SourceAPI: "Open-Meteo API", Timestamp: updatedAt, ForecastDate: today.AddDate(0, 0, 1), MinTemp: 10, MaxTemp: 21},
+	})
+
+	testCases := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantBody   []string
+	}{
+		{
+			name:       "light",
+			query:      "?city=wroclaw",
+			wantStatus: http.StatusOK,
+			wantBody:   []string{`<html lang="en">`, `<div class="widget">`, "<h1>Wroclaw</h1>", "14 °C", "Cloudy", "Today", "16°", "70%", "Data: Open-Meteo", `href="http://example.com/?city=Wroclaw"`},
+		},
+		{
+			name:       "dark",
+			query:      "?city=wroclaw&theme=dark&units=imperial&lang=de",
+			wantStatus: http.StatusOK,
+			wantBody:   []string{`<html lang="de">`, `<div class="widget dark">`, "58 °F", germanLocale.conditionLabel(conditionCloudy)},
+		},
+		{name: "invalid theme", query: "?city=wroclaw&theme=blue", wantStatus: http.StatusBadRequest, wantBody: []string{`{"error":"theme must be either light or dark"}`}},
+		{name: "invalid units", query: "?city=wroclaw&units=kelvin", wantStatus: http.StatusBadRequest},
+		{name: "invalid lang", query: "?city=wroclaw&lang=fr", wantStatus: http.StatusBadRequest},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testCfg := newTestAPIConfig(t)
+			testCfg.apiConfig.enabledSources = map[string]bool{"ometeo": true}
+			testCfg.mockDB.GetLocationByAliasFunc = func(ctx context.Context, alias string) (database.Location, error) {
+				return MockDBLocation, nil
+			}
+			testCfg.mockCache.GetFunc = func(ctx context.Context, key string) (string, error) {
+				if strings.HasPrefix(key, currentWeatherCacheKeyPrefix) {
+					return string(current), nil
+				}
+				return string(daily), nil
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/embed"+tc.query, nil)
+			rr := httptest.NewRecorder()
+			testCfg.apiConfig.handlerEmbed(rr, req)
+
+			if rr.Code != tc.wantStatus {
+				t.Fatalf("status = %d, want %d; body: %s", rr.Code, tc.wantStatus, rr.Body.String())
+			}
+			for _, want := range tc.wantBody {
+				if !strings.Contains(rr.Body.String(), want) {
+					t.Errorf("expected the body to contain %q, got:\n%s", want, rr.Body.String())
+				}
+			}
+			if tc.wantStatus != http.StatusOK {
+				return
+			}
+			if got := rr.Header().Get("Content-Type"); got != "text/html; charset=utf-8" {
+				t.Errorf("Content-Type = %q", got)
+			}
+			if !strings.HasPrefix(rr.Header().Get("Cache-Control"), "public, max-age=") || rr.Header().Get("ETag") == "" {
+				t.Errorf("expected caching headers, got %v", rr.Header())
+			}
+
+			// The widget is revalidated like the weather endpoints.
+			req = httptest.NewRequest(http.MethodGet, "/embed"+tc.query, nil)
+			req.Header.Set("If-None-Match", rr.Header().Get("ETag"))
+			rr = httptest.NewRecorder()
+			testCfg.apiConfig.handlerEmbed(rr, req)
+			if rr.Code != http.StatusNotModified {
+				t.Errorf("status = %d, want 304", rr.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,507 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cor0nius/willitrain/internal/database"
+	"github.com/google/uuid"
+)
+
+// This file implements the /api/export endpoint, which streams every stored entry of one type
+// for a location as JSON or CSV: first the archived history in chronological order, then the
+// entries that are still current. Unlike /api/history, it is not paged; history is read from the
+// database a page at a time and each page is flushed to the client as soon as it is written, so
+// that large ranges are sent with chunked transfer encoding without being held in memory.
+
+// Export formats selected with the format query parameter.
+const (
+	exportFormatJSON = "json"
+	exportFormatCSV  = "csv"
+)
+
+// exportPageSize is the number of history rows read from the database at a time.
+const exportPageSize = 1000
+
+// exportOpenEnd is the end of the range exported when to is omitted. Stored forecasts reach into
+// the future, so the range does not end now as it does for /api/history.
+var exportOpenEnd = time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)
+
+// CSV columns of each export type, in the order of the fields of the JSON entries.
+var (
+	exportCurrentWeatherColumns = []string{"source_api", "observed_at", "temperature_c", "humidity", "wind_speed_kmh", "precipitation_mm", "condition_text", "archived"}
+	exportHourlyForecastColumns = []string{"source_api", "forecast_datetime", "issued_at", "temperature_c", "humidity", "wind_speed_kmh", "precipitation_mm", "precipitation_chance", "condition_text", "archived"}
+	exportDailyForecastColumns  = []string{"source_api", "forecast_date", "issued_at", "min_temp_c", "max_temp_c", "precipitation_mm", "precipitation_chance", "wind_speed_kmh", "humidity", "archived"}
+)
+
+// exportEntry is an entry of an export, which can be written as a CSV record.
+type exportEntry interface {
+	csvRecord() []string
+}
+
+func (e ExportCurrentWeatherJSON) csvRecord() []string {
+	return []string{e.SourceAPI, e.ObservedAt, csvFloat(e.Temperature), csvInt(e.Humidity), csvFloat(e.WindSpeed),
+		csvFloat(e.Precipitation), csvString(e.Condition), strconv.FormatBool(e.Archived)}
+}
+
+func (e ExportHourlyForecastJSON) csvRecord() []string {
+	return []string{e.SourceAPI, e.ForecastDateTime, e.IssuedAt, csvFloat(e.Temperature), csvInt(e.Humidity), csvFloat(e.WindSpeed),
+		csvFloat(e.Precipitation), csvInt(e.PrecipitationChance), csvString(e.Condition), strconv.FormatBool(e.Archived)}
+}
+
+func (e ExportDailyForecastJSON) csvRecord() []string {
+	return []string{e.SourceAPI, e.ForecastDate, e.IssuedAt, csvFloat(e.MinTemp), csvFloat(e.MaxTemp), csvFloat(e.Precipitation),
+		csvInt(e.PrecipitationChance), csvFloat(e.WindSpeed), csvInt(e.Humidity), strconv.FormatBool(e.Archived)}
+}
+
+// csvFloat, csvInt and csvString format optional values as CSV fields, which are empty for
+// values the provider did not report.
+func csvFloat(v *float64) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*v, 'f', -1, 64)
+}
+
+func csvInt(v *int32) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.FormatInt(int64(*v), 10)
+}
+
+func csvString(v *string) string {
+	if v == nil {
+		return ""
+	}
+	return *v
+}
+
+// exportWriter writes the entries of an export in the requested format. A JSON export is an
+// object with the location, the type and the list of entries; a CSV export has a header row and
+// a record per entry.
+type exportWriter struct {
+	w       http.ResponseWriter
+	csv     *csv.Writer
+	camel   bool
+	entries int
+}
+
+func newExportWriter(w http.ResponseWriter, format string) *exportWriter {
+	ew := &exportWriter{w: w, camel: responseNaming(w) == namingCamel}
+	if format == exportFormatCSV {
+		ew.csv = csv.NewWriter(w)
+	}
+	return ew
+}
+
+// begin writes what precedes the entries.
+func (ew *exportWriter) begin(location Location, exportType string, columns []string) error {
+	if ew.csv != nil {
+		return ew.csv.Write(columns)
+	}
+	data, err := ew.marshal(location)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(ew.w, `{"location":%s,"type":%q,"entries":[`, data, exportType)
+	return err
+}
+
+// write writes an entry.
+func (ew *exportWriter) write(entry exportEntry) error {
+	if ew.csv != nil {
+		return ew.csv.Write(entry.csvRecord())
+	}
+	data, err := ew.marshal(entry)
+	if err != nil {
+		return err
+	}
+	if ew.entries > 0 {
+		data = append([]byte(",\n"), data...)
+	} else {
+		data = append([]byte("\n"), data...)
+	}
+	ew.entries++
+	_, err = ew.w.Write(data)
+	return err
+}
+
+// flush sends what was written so far to the client.
+func (ew *exportWriter) flush() error {
+	if ew.csv != nil {
+		ew.csv.Flush()
+		if err := ew.csv.Error(); err != nil {
+			return err
+		}
+	}
+	if err := http.NewResponseController(ew.w).Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+	return nil
+}
+
+// end writes what follows the entries and flushes the export.
+func (ew *exportWriter) end() error {
+	if ew.csv == nil {
+		if _, err := ew.w.Write([]byte("\n]}\n")); err != nil {
+			return err
+		}
+	}
+	return ew.flush()
+}
+
+// marshal encodes a value of a JSON export, with camelCase keys if the request asked for them.
+func (ew *exportWriter) marshal(v any) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil || !ew.camel {
+		return data, err
+	}
+	return camelCaseKeys(data)
+}
+
+// exportFilename returns the name an export is saved under, such as Warsaw-daily.csv.
+func exportFilename(city, exportType, format string) string {
+	name := strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == '"' || r < ' ' {
+			return '_'
+		}
+		return r
+	}, city)
+	return name + "-" + exportType + "." + format
+}
+
+// @Summary      Export stored weather data
+// @Description  Streams every stored entry of one type for a location: observed current weather (type=current,
+// @Description  the default), or the hourly or daily forecasts with the time each was issued (type=hourly,
+// @Description  type=daily). Archived entries come first in chronological order, followed by the entries that
+// @Description  are still current, which have archived set to false. Entries are selected by observation time,
+// @Description  forecast hour or forecast date in [from, to). The response is sent with chunked transfer encoding;
+// @Description  if the export fails after it started, the connection is closed before the end of the response.
+// @Tags         weather
+// @Produce      json
+// @Produce      text/csv
+// @Param        city    query     string  false  "Location name to search for (e.g., 'London')"
+// @Param        lat     query     number  false  "Latitude for the location (e.g., 51.5074)"
+// @Param        lon     query     number  false  "Longitude for the location (e.g., -0.1278)"
+// @Param        type    query     string  false  "Export type: 'current' (default), 'hourly' or 'daily'"
+// @Param        format  query     string  false  "Format: 'json' (default) or 'csv'"
+// @Param        from    query     string  false  "Start, as RFC 3339 timestamp or YYYY-MM-DD date in the location's timezone (default: all stored entries)"
+// @Param        to      query     string  false  "End, exclusive, in the same formats (default: all stored entries)"
+// @Success      200  {object}  ExportResponse "A JSON document, or a CSV file with a header row if format=csv"
+// @Failure      400  {object}  ErrorResponse "Bad Request - Invalid location, type, format or time range"
+// @Router       /api/v1/export [get]
+func (cfg *apiConfig) handlerExport(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if r.Method != http.MethodGet {
+		cfg.respondWithError(w, http.StatusMethodNotAllowed, "Method Not Allowed", nil)
+		return
+	}
+
+	query := r.URL.Query()
+	exportType := query.Get("type")
+	if exportType == "" {
+		exportType = historyTypeCurrent
+	}
+	if exportType != historyTypeCurrent && exportType != historyTypeHourly && exportType != historyTypeDaily {
+		cfg.respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid type %q, must be current, hourly or daily", exportType), nil)
+		return
+	}
+	format := strings.ToLower(query.Get("format"))
+	if format == "" {
+		format = exportFormatJSON
+	}
+	if format != exportFormatJSON && format != exportFormatCSV {
+		cfg.respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid format %q, must be json or csv", format), nil)
+		return
+	}
+
+	location, err := cfg.getLocationFromRequest(r)
+	if err != nil {
+		cfg.respondWithError(w, http.StatusBadRequest, "Error getting location data", err)
+		return
+	}
+	cfg.logger.DebugContext(ctx, "export request", "city", location.CityName, "type", exportType, "format", format)
+
+	loc, err := time.LoadLocation(location.Timezone)
+	if err != nil {
+		cfg.logger.Warn("could not load location timezone, falling back to UTC", "timezone", location.Timezone, "error", err)
+		loc = time.UTC
+	}
+	to, err := parseHistoryTime(query.Get("to"), loc, exportOpenEnd)
+	if err != nil {
+		cfg.respondWithError(w, http.StatusBadRequest, "Invalid to parameter", err)
+		return
+	}
+	from, err := parseHistoryTime(query.Get("from"), loc, time.Unix(0, 0).UTC())
+	if err != nil {
+		cfg.respondWithError(w, http.StatusBadRequest, "Invalid from parameter", err)
+		return
+	}
+	if !from.Before(to) {
+		cfg.respondWithError(w, http.StatusBadRequest, "from must be before to", nil)
+		return
+	}
+
+	contentType := "application/json"
+	if format == exportFormatCSV {
+		contentType = "text/csv; charset=utf-8"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{
+		"filename": exportFilename(location.CityName, exportType, format),
+	}))
+	w.WriteHeader(http.StatusOK)
+
+	ew := newExportWriter(w, format)
+	switch exportType {
+	case historyTypeCurrent:
+		err = cfg.exportCurrentWeather(ctx, ew, location, loc, from, to)
+	case historyTypeHourly:
+		err = cfg.exportHourlyForecasts(ctx, ew, location, loc, from, to)
+	case historyTypeDaily:
+		err = cfg.exportDailyForecasts(ctx, ew, location, loc, from, to)
+	}
+	if err == nil {
+		err = ew.end()
+	}
+	if err != nil {
+		// The status has been sent, so the only way to tell the client that the export is
+		// incomplete is to break the connection instead of ending the response.
+		cfg.logger.ErrorContext(ctx, "export failed", "city", location.CityName, "type", exportType, "entries", ew.entries, "error", err)
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// exportPages writes the history rows returned by list, which reads the page after a cursor,
+// flushing the export after each page.
+func exportPages[R any](ew *exportWriter, list func(after *historyCursor) ([]R, error), cursor func(R) historyCursor, entry func(R) exportEntry) error {
+	var after *historyCursor
+	for {
+		rows, err := list(after)
+		if err != nil {
+			return err
+		}
+		for _, row := range rows {
+			if err := ew.write(entry(row)); err != nil {
+				return err
+			}
+		}
+		if err := ew.flush(); err != nil {
+			return err
+		}
+		if len(rows) < exportPageSize {
+			return nil
+		}
+		c := cursor(rows[len(rows)-1])
+		after = &c
+	}
+}
+
+// exportCurrentWeather writes the archived current weather of a location observed in
+// [from, to), followed by the current observations.
+func (cfg *apiConfig) exportCurrentWeather(ctx context.Context, ew *exportWriter, location Location, loc *time.Location, from, to time.Time) error {
+	if err := ew.begin(location, historyTypeCurrent, exportCurrentWeatherColumns); err != nil {
+		return err
+	}
+	entry := func(source string, updatedAt time.Time, temperature, windSpeed, precipitation sql.NullFloat64, humidity sql.NullInt32, condition sql.NullString, archived bool) exportEntry {
+		return ExportCurrentWeatherJSON{
+			SourceAPI:     source,
+			ObservedAt:    updatedAt.In(loc).Format(time.RFC3339),
+			Temperature:   nullFloat64ToPtr(temperature),
+			Humidity:      nullInt32ToPtr(humidity),
+			WindSpeed:     nullFloat64ToPtr(windSpeed),
+			Precipitation: nullFloat64ToPtr(precipitation),
+			Condition:     nullStringToPtr(condition),
+			Archived:      archived,
+		}
+	}
+
+	err := exportPages(ew,
+		func(after *historyCursor) ([]database.CurrentWeatherHistory, error) {
+			afterTime, afterID := after.params()
+			return cfg.dbQueries.ListCurrentWeatherHistory(ctx, database.ListCurrentWeatherHistoryParams{
+				LocationID: location.LocationID,
+				FromTime:   from.UTC(),
+				ToTime:     to.UTC(),
+				AfterTime:  afterTime,
+				AfterID:    afterID,
+				RowLimit:   exportPageSize,
+			})
+		},
+		func(row database.CurrentWeatherHistory) historyCursor {
+			return historyCursor{at: row.UpdatedAt, id: row.ID}
+		},
+		func(row database.CurrentWeatherHistory) exportEntry {
+			return entry(row.SourceApi, row.UpdatedAt, row.TemperatureC, row.WindSpeedKmh, row.PrecipitationMm, row.Humidity, row.ConditionText, true)
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	rows, err := cfg.dbQueries.GetCurrentWeatherAtLocation(ctx, location.LocationID)
+	if err != nil {
+		return err
+	}
+	rows = slices.DeleteFunc(rows, func(row database.CurrentWeather) bool {
+		return row.UpdatedAt.Before(from) || !row.UpdatedAt.Before(to)
+	})
+	slices.SortFunc(rows, func(a, b database.CurrentWeather) int {
+		return compareExportRows(a.UpdatedAt, b.UpdatedAt, a.ID, b.ID)
+	})
+	for _, row := range rows {
+		if err := ew.write(entry(row.SourceApi, row.UpdatedAt, row.TemperatureC, row.WindSpeedKmh, row.PrecipitationMm, row.Humidity, row.ConditionText, false)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// exportHourlyForecasts writes the archived hourly forecasts of a location for hours in
+// [from, to), followed by the current forecasts.
+func (cfg *apiConfig) exportHourlyForecasts(ctx context.Context, ew *exportWriter, location Location, loc *time.Location, from, to time.Time) error {
+	if err := ew.begin(location, historyTypeHourly, exportHourlyForecastColumns); err != nil {
+		return err
+	}
+	entry := func(source string, forecastAt, updatedAt time.Time, temperature, windSpeed, precipitation sql.NullFloat64, humidity, chance sql.NullInt32, condition sql.NullString, archived bool) exportEntry {
+		return ExportHourlyForecastJSON{
+			SourceAPI:           source,
+			ForecastDateTime:    forecastAt.In(loc).Format(time.RFC3339),
+			IssuedAt:            updatedAt.In(loc).Format(time.RFC3339),
+			Temperature:         nullFloat64ToPtr(temperature),
+			Humidity:            nullInt32ToPtr(humidity),
+			WindSpeed:           nullFloat64ToPtr(windSpeed),
+			Precipitation:       nullFloat64ToPtr(precipitation),
+			PrecipitationChance: nullInt32ToPtr(chance),
+			Condition:           nullStringToPtr(condition),
+			Archived:            archived,
+		}
+	}
+
+	err := exportPages(ew,
+		func(after *historyCursor) ([]database.HourlyForecastHistory, error) {
+			afterTime, afterID := after.params()
+			return cfg.dbQueries.ListHourlyForecastHistory(ctx, database.ListHourlyForecastHistoryParams{
+				LocationID: location.LocationID,
+				FromTime:   from.UTC(),
+				ToTime:     to.UTC(),
+				AfterTime:  afterTime,
+				AfterID:    afterID,
+				RowLimit:   exportPageSize,
+			})
+		},
+		func(row database.HourlyForecastHistory) historyCursor {
+			return historyCursor{at: row.ForecastDatetimeUtc, id: row.ID}
+		},
+		func(row database.HourlyForecastHistory) exportEntry {
+			return entry(row.SourceApi, row.ForecastDatetimeUtc, row.UpdatedAt, row.TemperatureC, row.WindSpeedKmh, row.PrecipitationMm,
+				row.Humidity, row.PrecipitationChancePercent, row.ConditionText, true)
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	rows, err := cfg.dbQueries.GetAllHourlyForecastsAtLocation(ctx, location.LocationID)
+	if err != nil {
+		return err
+	}
+	rows = slices.DeleteFunc(rows, func(row database.HourlyForecast) bool {
+		return row.ForecastDatetimeUtc.Before(from) || !row.ForecastDatetimeUtc.Before(to)
+	})
+	slices.SortFunc(rows, func(a, b database.HourlyForecast) int {
+		return compareExportRows(a.ForecastDatetimeUtc, b.ForecastDatetimeUtc, a.ID, b.ID)
+	})
+	for _, row := range rows {
+		if err := ew.write(entry(row.SourceApi, row.ForecastDatetimeUtc, row.UpdatedAt, row.TemperatureC, row.WindSpeedKmh, row.PrecipitationMm,
+			row.Humidity, row.PrecipitationChancePercent, row.ConditionText, false)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// exportDailyForecasts writes the archived daily forecasts of a location for dates in
+// [from, to), followed by the current forecasts.
+func (cfg *apiConfig) exportDailyForecasts(ctx context.Context, ew *exportWriter, location Location, loc *time.Location, from, to time.Time) error {
+	if err := ew.begin(location, historyTypeDaily, exportDailyForecastColumns); err != nil {
+		return err
+	}
+	// Forecast dates are read from the database as UTC midnight and formatted as such.
+	fromDate, toDate := historyDate(from, loc), historyDate(to, loc)
+	entry := func(source string, forecastDate, updatedAt time.Time, minTemp, maxTemp, precipitation, windSpeed sql.NullFloat64, chance, humidity sql.NullInt32, archived bool) exportEntry {
+		return ExportDailyForecastJSON{
+			SourceAPI:           source,
+			ForecastDate:        forecastDate.UTC().Format("2006-01-02"),
+			IssuedAt:            updatedAt.In(loc).Format(time.RFC3339),
+			MinTemp:             nullFloat64ToPtr(minTemp),
+			MaxTemp:             nullFloat64ToPtr(maxTemp),
+			Precipitation:       nullFloat64ToPtr(precipitation),
+			PrecipitationChance: nullInt32ToPtr(chance),
+			WindSpeed:           nullFloat64ToPtr(windSpeed),
+			Humidity:            nullInt32ToPtr(humidity),
+			Archived:            archived,
+		}
+	}
+
+	err := exportPages(ew,
+		func(after *historyCursor) ([]database.DailyForecastHistory, error) {
+			afterTime, afterID := after.params()
+			return cfg.dbQueries.ListDailyForecastHistory(ctx, database.ListDailyForecastHistoryParams{
+				LocationID: location.LocationID,
+				FromTime:   fromDate,
+				ToTime:     toDate,
+				AfterTime:  afterTime,
+				AfterID:    afterID,
+				RowLimit:   exportPageSize,
+			})
+		},
+		func(row database.DailyForecastHistory) historyCursor {
+			return historyCursor{at: row.ForecastDate, id: row.ID}
+		},
+		func(row database.DailyForecastHistory) exportEntry {
+			return entry(row.SourceApi, row.ForecastDate, row.UpdatedAt, row.MinTempC, row.MaxTempC, row.PrecipitationMm, row.WindSpeedKmh,
+				row.PrecipitationChancePercent, row.Humidity, true)
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	rows, err := cfg.dbQueries.GetAllDailyForecastsAtLocation(ctx, location.LocationID)
+	if err != nil {
+		return err
+	}
+	rows = slices.DeleteFunc(rows, func(row database.DailyForecast) bool {
+		return row.ForecastDate.Before(fromDate) || !row.ForecastDate.Before(toDate)
+	})
+	slices.SortFunc(rows, func(a, b database.DailyForecast) int {
+		return compareExportRows(a.ForecastDate, b.ForecastDate, a.ID, b.ID)
+	})
+	for _, row := range rows {
+		if err := ew.write(entry(row.SourceApi, row.ForecastDate, row.UpdatedAt, row.MinTempC, row.MaxTempC, row.PrecipitationMm, row.WindSpeedKmh,
+			row.PrecipitationChancePercent, row.Humidity, false)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// compareExportRows orders rows by time and then by ID, like the history queries.
+func compareExportRows(aTime, bTime time.Time, aID, bID uuid.UUID) int {
+	if c := aTime.Compare(bTime); c != 0 {
+		return c
+	}
+	return strings.Compare(aID.String(), bID.String())
+}
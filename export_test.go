@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cor0nius/willitrain/internal/database"
+	"github.com/google/uuid"
+)
+
+func TestHandlerExport(t *testing.T) {
+	observedAt := time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name       string
+		query      string
+		wantStatus int
+	}{
+		{name: "invalid type", query: "?city=wroclaw&type=minutely", wantStatus: http.StatusBadRequest},
+		{name: "invalid format", query: "?city=wroclaw&format=xml", wantStatus: http.StatusBadRequest},
+		{name: "invalid to", query: "?city=wroclaw&to=tomorrow", wantStatus: http.StatusBadRequest},
+		{name: "empty range", query: "?city=wroclaw&from=2025-06-02&to=2025-06-01", wantStatus: http.StatusBadRequest},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testCfg := newTestAPIConfig(t)
+			testCfg.mockDB.GetLocationByAliasFunc = func(ctx context.Context, alias string) (database.Location, error) {
+				return MockDBLocation, nil
+			}
+			rr := httptest.NewRecorder()
+			testCfg.apiConfig.handlerExport(rr, httptest.NewRequest(http.MethodGet, "/api/export"+tc.query, nil))
+			if rr.Code != tc.wantStatus {
+				t.Errorf("status = %d, want %d; body: %s", rr.Code, tc.wantStatus, rr.Body.String())
+			}
+		})
+	}
+
+	t.Run("current as JSON across pages", func(t *testing.T) {
+		testCfg := newTestAPIConfig(t)
+		testCfg.mockDB.GetLocationByAliasFunc = func(ctx context.Context, alias string) (database.Location, error) {
+			return MockDBLocation, nil
+		}
+		var pages []database.ListCurrentWeatherHistoryParams
+		testCfg.mockDB.ListCurrentWeatherHistoryFunc = func(ctx context.Context, arg database.ListCurrentWeatherHistoryParams) ([]database.CurrentWeatherHistory, error) {
+			pages = append(pages, arg)
+			// The first page is full, so that a second one is requested.
+			n := int(arg.RowLimit)
+			if len(pages) > 1 {
+				n = 1
+			}
+			rows := make([]database.CurrentWeatherHistory, n)
+			for i := range rows {
+				rows[i] = database.CurrentWeatherHistory{ID: uuid.New(), SourceApi: "Open-Meteo API", UpdatedAt: observedAt.Add(-time.Duration(n-i) * time.Minute)}
+			}
+			return rows, nil
+		}
+		testCfg.mockDB.GetCurrentWeatherAtLocationFunc = func(ctx context.Context, locationID uuid.UUID) ([]database.CurrentWeather, error) {
+			return []database.CurrentWeather{{
+				ID:           uuid.New(),
+				SourceApi:    "Open-Meteo API",
+				UpdatedAt:    observedAt,
+				TemperatureC: sql.NullFloat64{Float64: 18.5, Valid: true},
+			}}, nil
+		}
+
+		rr := httptest.NewRecorder()
+		testCfg.apiConfig.handlerExport(rr, httptest.NewRequest(http.MethodGet, "/api/export?city=wroclaw", nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200; body: %s", rr.Code, rr.Body.String())
+		}
+		if len(pages) != 2 || pages[0].AfterTime.Valid || !pages[1].AfterTime.Valid || pages[1].AfterTime.Time.After(observedAt) {
+			t.Errorf("unexpected pages: %+v", pages)
+		}
+		if !rr.Flushed {
+			t.Errorf("expected the export to be flushed")
+		}
+
+		var response struct {
+			Location Location                   `json:"location"`
+			Type     string                     `json:"type"`
+			Entries  []ExportCurrentWeatherJSON `json:"entries"`
+		}
+		if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+			t.Fatalf("failed to decode response: %v; body: %s", err, rr.Body.String())
+		}
+		if response.Type != historyTypeCurrent || response.Location.LocationID != MockDBLocation.ID {
+			t.Errorf("unexpected header: %+v", response)
+		}
+		if got, want := len(response.Entries), exportPageSize+2; got != want {
+			t.Fatalf("got %d entries, want %d", got, want)
+		}
+		last := response.Entries[len(response.Entries)-1]
+		if last.Archived || last.Temperature == nil || *last.Temperature != 18.5 || last.Humidity != nil {
+			t.Errorf("unexpected current entry: %+v", last)
+		}
+		if !response.Entries[0].Archived {
+			t.Errorf("expected archived entries first, got %+v", response.Entries[0])
+		}
+	})
+
+	t.Run("daily as CSV", func(t *testing.T) {
+		testCfg := newTestAPIConfig(t)
+		testCfg.mockDB.GetLocationByAliasFunc = func(ctx context.Context, alias string) (database.Location, error) {
+			return MockDBLocation, nil
+		}
+		testCfg.mockDB.ListDailyForecastHistoryFunc = func(ctx context.Context, arg database.ListDailyForecastHistoryParams) ([]database.DailyForecastHistory, error) {
+			return []database.DailyForecastHistory{{
+				ID:              uuid.New(),
+				SourceApi:       "test1",
+				ForecastDate:    time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC),
+				UpdatedAt:       observedAt,
+				PrecipitationMm: sql.NullFloat64{Float64: 4.2, Valid: true},
+			}}, nil
+		}
+		testCfg.mockDB.GetAllDailyForecastsAtLocationFunc = func(ctx context.Context, locationID uuid.UUID) ([]database.DailyForecast, error) {
+			return []database.DailyForecast{
+				{ID: uuid.New(), SourceApi: "test1", ForecastDate: time.Date(2025, 6, 3, 0, 0, 0, 0, time.UTC), UpdatedAt: observedAt},
+				{ID: uuid.New(), SourceApi: "test1", ForecastDate: time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC), UpdatedAt: observedAt},
+				// Outside the requested range.
+				{ID: uuid.New(), SourceApi: "test1", ForecastDate: time.Date(2025, 6, 10, 0, 0, 0, 0, time.UTC), UpdatedAt: observedAt},
+			}, nil
+		}
+
+		rr := httptest.NewRecorder()
+		testCfg.apiConfig.handlerExport(rr, httptest.NewRequest(http.MethodGet, "/api/export?city=wroclaw&type=daily&format=csv&to=2025-06-05", nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200; body: %s", rr.Code, rr.Body.String())
+		}
+		if got := rr.Header().Get("Content-Type"); !strings.HasPrefix(got, "text/csv") {
+			t.Errorf("Content-Type = %q, want text/csv", got)
+		}
+		if got := rr.Header().Get("Content-Disposition"); !strings.Contains(got, "daily.csv") {
+			t.Errorf("Content-Disposition = %q, want a daily.csv attachment", got)
+		}
+
+		records, err := csv.NewReader(rr.Body).ReadAll()
+		if err != nil {
+			t.Fatalf("failed to read CSV: %v", err)
+		}
+		if len(records) != 4 || strings.Join(records[0], ",") != strings.Join(exportDailyForecastColumns, ",") {
+			t.Fatalf("unexpected records: %v", records)
+		}
+		if records[1][1] != "2025-06-01" || records[1][5] != "4.2" || records[1][3] != "" || records[1][9] != "true" {
+			t.Errorf("unexpected archived record: %v", records[1])
+		}
+		if records[2][1] != "2025-06-02" || records[3][1] != "2025-06-03" || records[3][9] != "false" {
+			t.Errorf("expected current forecasts in date order, got %v", records[2:])
+		}
+	})
+
+	t.Run("failure after the start aborts the response", func(t *testing.T) {
+		testCfg := newTestAPIConfig(t)
+		testCfg.mockDB.GetLocationByAliasFunc = func(ctx context.Context, alias string) (database.Location, error) {
+			return MockDBLocation, nil
+		}
+		testCfg.mockDB.ListHourlyForecastHistoryFunc = func(ctx context.Context, arg database.ListHourlyForecastHistoryParams) ([]database.HourlyForecastHistory, error) {
+			return nil, errors.New("db down")
+		}
+
+		defer func() {
+			if r := recover(); r != http.ErrAbortHandler {
+				t.Errorf("recovered %v, want http.ErrAbortHandler", r)
+			}
+		}()
+		rr := httptest.NewRecorder()
+		testCfg.apiConfig.handlerExport(rr, httptest.NewRequest(http.MethodGet, "/api/export?city=wroclaw&type=hourly", nil))
+		t.Errorf("expected the handler to abort")
+	})
+}
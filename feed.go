@@ -0,0 +1,348 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// This file implements the weather summary feeds. A location's feed has a single entry per day,
+// which describes today's and tomorrow's consensus forecast in a sentence each, so that feed
+// readers show a new entry every morning and update it as the forecasts are refreshed. The feed is
+// offered as RSS 2.0 and as Atom.
+
+const (
+	// feedAuthor is the author of feed entries, which Atom requires.
+	feedAuthor = "WillItRain"
+	// feedTTL is the number of minutes RSS readers are asked to wait between polls, matching how
+	// long daily forecasts are cached.
+	feedTTL = int(dailyForecastCacheTTL / time.Minute)
+)
+
+// feedEntry is the entry of one day in a location's feed.
+type feedEntry struct {
+	ID        string
+	Title     string
+	Summary   string
+	Link      string
+	Published time.Time
+	Updated   time.Time
+}
+
+// feedData is a location's feed, independent of the feed format.
+type feedData struct {
+	ID          string
+	Title       string
+	Link        string
+	Self        string
+	Description string
+	Updated     time.Time
+	Entries     []feedEntry
+}
+
+// @Summary      Get weather summary RSS feed
+// @Description  RSS 2.0 feed of a location with one entry per day, summarizing today's and tomorrow's consensus
+// @Description  forecast in plain text. The entry of the day is updated as the forecasts are refreshed.
+// @Tags         weather
+// @Produce      xml
+// @Param        city   query     string  false  "Location name to search for (e.g., 'London')"
+// @Param        lat    query     number  false  "Latitude for the location (e.g., 51.5074)"
+// @Param        lon    query     number  false  "Longitude for the location (e.g., -0.1278)"
+// @Param        units  query     string  false  "Units of the summaries: 'metric' (default) or 'imperial'"
+// @Success      200  {string}  string  "RSS feed"
+// @Failure      400  {object}  ErrorResponse "Bad Request - Invalid location or units parameter"
+// @Failure      500  {object}  ErrorResponse "Internal Server Error - Failed to retrieve forecast data"
+// @Router       /api/v1/feed.rss [get]
+func (cfg *apiConfig) handlerFeedRSS(w http.ResponseWriter, r *http.Request) {
+	feed, ok := cfg.weatherFeed(w, r)
+	if !ok {
+		return
+	}
+
+	channel := rssChannel{
+		Title:       feed.Title,
+		Link:        feed.Link,
+		Description: feed.Description,
+		TTL:         feedTTL,
+		AtomLink:    atomLink{Href: feed.Self, Rel: "self", Type: "application/rss+xml"},
+	}
+	if !feed.Updated.IsZero() {
+		channel.LastBuildDate = feed.Updated.UTC().Format(time.RFC1123Z)
+	}
+	for _, e := range feed.Entries {
+		channel.Items = append(channel.Items, rssItem{
+			Title:       e.Title,
+			Link:        e.Link,
+			Description: e.Summary,
+			GUID:        rssGUID{Value: e.ID},
+			PubDate:     e.Published.Format(time.RFC1123Z),
+		})
+	}
+	cfg.respondWithFeed(w, r, "application/rss+xml; charset=utf-8", rssFeed{Version: "2.0", AtomNS: "http://www.w3.org/2005/Atom", Channel: channel}, feed.Updated)
+}
+
+// @Summary      Get weather summary Atom feed
+// @Description  Atom feed of a location with the same entries as the RSS feed.
+// @Tags         weather
+// @Produce      xml
+// @Param        city   query     string  false  "Location name to search for (e.g., 'London')"
+// @Param        lat    query     number  false  "Latitude for the location (e.g., 51.5074)"
+// @Param        lon    query     number  false  "Longitude for the location (e.g., -0.1278)"
+// @Param        units  query     string  false  "Units of the summaries: 'metric' (default) or 'imperial'"
+// @Success      200  {string}  string  "Atom feed"
+// @Failure      400  {object}  ErrorResponse "Bad Request - Invalid location or units parameter"
+// @Failure      500  {object}  ErrorResponse "Internal Server Error - Failed to retrieve forecast data"
+// @Router       /api/v1/feed.atom [get]
+func (cfg *apiConfig) handlerFeedAtom(w http.ResponseWriter, r *http.Request) {
+	feed, ok := cfg.weatherFeed(w, r)
+	if !ok {
+		return
+	}
+
+	atom := atomFeed{
+		ID:     feed.ID,
+		Title:  feed.Title,
+		Author: atomPerson{Name: feedAuthor},
+		Links: []atomLink{
+			{Href: feed.Link, Rel: "alternate", Type: "text/html"},
+			{Href: feed.Self, Rel: "self", Type: "application/atom+xml"},
+		},
+	}
+	// Atom requires an update time; a feed without forecasts was last updated now.
+	updated := feed.Updated
+	if updated.IsZero() {
+		updated = time.Now()
+	}
+	atom.Updated = updated.UTC().Format(time.RFC3339)
+	for _, e := range feed.Entries {
+		atom.Entries = append(atom.Entries, atomEntry{
+			ID:        e.ID,
+			Title:     e.Title,
+			Links:     []atomLink{{Href: e.Link, Rel: "alternate", Type: "text/html"}},
+			Published: e.Published.Format(time.RFC3339),
+			Updated:   e.Updated.UTC().Format(time.RFC3339),
+			Summary:   atomText{Type: "text", Value: e.Summary},
+		})
+	}
+	cfg.respondWithFeed(w, r, "application/atom+xml; charset=utf-8", atom, feed.Updated)
+}
+
+// weatherFeed builds the feed of the requested location from its daily consensus forecast. It
+// responds with an error and returns false if the request is invalid or the forecast is not
+// available.
+func (cfg *apiConfig) weatherFeed(w http.ResponseWriter, r *http.Request) (feedData, bool) {
+	ctx := r.Context()
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		cfg.respondWithError(w, http.StatusMethodNotAllowed, "Method Not Allowed", nil)
+		return feedData{}, false
+	}
+	units, err := cfg.requestUnits(r)
+	if err != nil {
+		cfg.respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return feedData{}, false
+	}
+	location, err := cfg.getLocationFromRequest(r)
+	if err != nil {
+		cfg.respondWithError(w, http.StatusBadRequest, "Error getting location data", err)
+		return feedData{}, false
+	}
+	forecast, err := cfg.getCachedOrFetchDailyForecast(ctx, location)
+	if err != nil {
+		cfg.respondWithError(w, http.StatusInternalServerError, "Error getting daily forecast data", err)
+		return feedData{}, false
+	}
+
+	loc, err := time.LoadLocation(location.Timezone)
+	if err != nil {
+		cfg.logger.WarnContext(ctx, "could not load location timezone, falling back to UTC", "timezone", location.Timezone, "error", err)
+		loc = time.UTC
+	}
+
+	base := requestBaseURL(r)
+	link := base + "/?" + url.Values{"city": {location.CityName}}.Encode()
+	feed := feedData{
+		ID:          "urn:uuid:" + location.LocationID.String(),
+		Title:       "Weather for " + location.CityName,
+		Link:        link,
+		Self:        base + r.URL.RequestURI(),
+		Description: "Daily summaries of the forecasts of all sources for " + location.CityName,
+		Updated:     latestTimestamp(forecast),
+	}
+	if entry, ok := dailyFeedEntry(location, consensusDays(forecast, loc, nil), time.Now().In(loc), units); ok {
+		entry.Link = link
+		// Forecasts fetched before midnight are part of the entry published at midnight.
+		entry.Updated = feed.Updated
+		if entry.Updated.Before(entry.Published) {
+			entry.Updated = entry.Published
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+	return feed, true
+}
+
+// dailyFeedEntry returns the feed entry of the day of now, in the location's timezone, from the
+// consensus forecast. It returns false if the forecast does not cover that day.
+func dailyFeedEntry(location Location, days []ConsensusDayJSON, now time.Time, units unitSystem) (feedEntry, bool) {
+	today := now.Format("2006-01-02")
+	tomorrow := now.AddDate(0, 0, 1).Format("2006-01-02")
+	var summaries []string
+	for _, day := range days {
+		switch day.ForecastDate {
+		case today:
+			summaries = append([]string{daySummary("Today", day, units)}, summaries...)
+		case tomorrow:
+			summaries = append(summaries, daySummary("Tomorrow", day, units))
+		}
+	}
+	if len(summaries) == 0 || !strings.HasPrefix(summaries[0], "Today") {
+		return feedEntry{}, false
+	}
+
+	start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	return feedEntry{
+		// The ID is derived from the location and the date, so that the entry of a day keeps its
+		// ID while it is updated.
+		ID:        "urn:uuid:" + uuid.NewSHA1(location.LocationID, []byte(today)).String(),
+		Title:     fmt.Sprintf("Weather for %s on %s", location.CityName, now.Format("Monday, 2 January")),
+		Summary:   strings.Join(summaries, " "),
+		Published: start,
+	}, true
+}
+
+// daySummary describes the consensus forecast of one day in a sentence, such as "Today: Rain,
+// 12 to 19 °C, 4.2 mm of precipitation (70% chance), wind 15 km/h."
+func daySummary(label string, day ConsensusDayJSON, units unitSystem) string {
+	condition := conditionDisplays[day.Condition.Code].Label
+	if condition == "" {
+		condition = conditionDisplays[conditionUnknown].Label
+	}
+	parts := []string{
+		condition,
+		fmt.Sprintf("%s to %s %s", formatSummaryNumber(units.temperature(day.MinTemp.Value), 0), formatSummaryNumber(units.temperature(day.MaxTemp.Value), 0), units.temperatureSymbol()),
+	}
+
+	chance := int(Round(day.PrecipitationChance.Value, 0))
+	switch precipitation := units.precipitation(day.Precipitation.Value); {
+	case precipitation > 0 && chance > 0:
+		parts = append(parts, fmt.Sprintf("%s %s of precipitation (%d%% chance)", formatSummaryNumber(precipitation, 2), units.precipitationSymbol(), chance))
+	case precipitation > 0:
+		parts = append(parts, fmt.Sprintf("%s %s of precipitation", formatSummaryNumber(precipitation, 2), units.precipitationSymbol()))
+	case chance > 0:
+		parts = append(parts, fmt.Sprintf("%d%% chance of precipitation", chance))
+	default:
+		parts = append(parts, "no precipitation expected")
+	}
+	parts = append(parts, fmt.Sprintf("wind %s %s", formatSummaryNumber(units.windSpeed(day.WindSpeed.Value), 0), units.windSpeedSymbol()))
+
+	summary := label + ": " + strings.Join(parts, ", ") + "."
+	if day.Condition.Confidence == confidenceLow && len(day.Sources) > 1 {
+		summary += " The sources disagree on the conditions."
+	}
+	return summary
+}
+
+// formatSummaryNumber formats a value for a summary, rounded to at most the given number of
+// decimals and without trailing zeros.
+func formatSummaryNumber(v float64, decimals int) string {
+	v = Round(v, decimals)
+	if v == 0 {
+		// Avoid "-0" for values rounded up to zero.
+		v = 0
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// requestBaseURL returns the scheme and host the request was sent to, honoring the
+// X-Forwarded-Proto header of a TLS-terminating proxy.
+func requestBaseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil || strings.EqualFold(r.Header.Get("X-Forwarded-Proto"), "https") {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
+
+// respondWithFeed encodes a feed as XML and sends it with the caching headers of the forecasts it
+// summarizes.
+func (cfg *apiConfig) respondWithFeed(w http.ResponseWriter, r *http.Request, contentType string, feed any, updatedAt time.Time) {
+	data, err := xml.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		cfg.respondWithError(w, http.StatusInternalServerError, "Error encoding feed", err)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	cfg.respondWithConditional(w, r, append([]byte(xml.Header), data...), updatedAt, dailyForecastCacheTTL)
+}
+
+// rssFeed is the root element of an RSS 2.0 feed.
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	AtomNS  string     `xml:"xmlns:atom,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	TTL           int       `xml:"ttl,omitempty"`
+	AtomLink      atomLink  `xml:"atom:link"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link"`
+	Description string  `xml:"description"`
+	GUID        rssGUID `xml:"guid"`
+	PubDate     string  `xml:"pubDate"`
+}
+
+// rssGUID is an item's GUID, which is not a link.
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+// atomFeed is the root element of an Atom feed.
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Author  atomPerson  `xml:"author"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomEntry struct {
+	ID        string     `xml:"id"`
+	Title     string     `xml:"title"`
+	Links     []atomLink `xml:"link"`
+	Published string     `xml:"published"`
+	Updated   string     `xml:"updated"`
+	Summary   atomText   `xml:"summary"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+}
+
+type atomText struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cor0nius/willitrain/internal/database"
+	"github.com/google/uuid"
+)
+
+func TestDaySummary(t *testing.T) {
+	day := ConsensusDayJSON{
+		Sources:             []string{"a", "b"},
+		MinTemp:             ConsensusValueJSON{Value: 11.6},
+		MaxTemp:             ConsensusValueJSON{Value: 19.2},
+		Precipitation:       ConsensusValueJSON{Value: 4.23},
+		PrecipitationChance: ConsensusValueJSON{Value: 66.7},
+		WindSpeed:           ConsensusValueJSON{Value: 15.4},
+		Condition:           ConsensusConditionJSON{Code: conditionRain, Confidence: confidenceHigh},
+	}
+
+	testCases := []struct {
+		name   string
+		modify func(d *ConsensusDayJSON)
+		units  unitSystem
+		want   string
+	}{
+		{name: "rain", want: "Today: Rain, 12 to 19 °C, 4.23 mm of precipitation (67% chance), wind 15 km/h."},
+		{name: "imperial", units: unitsImperial, want: "Today: Rain, 53 to 67 °F, 0.17 in of precipitation (67% chance), wind 10 mph."},
+		{
+			name:   "chance only",
+			modify: func(d *ConsensusDayJSON) { d.Precipitation.Value = 0; d.PrecipitationChance.Value = 20 },
+			want:   "Today: Rain, 12 to 19 °C, 20% chance of precipitation, wind 15 km/h.",
+		},
+		{
+			name: "dry",
+			modify: func(d *ConsensusDayJSON) {
+				d.Precipitation.Value, d.PrecipitationChance.Value = 0, 0
+				d.Condition.Code = conditionDry
+			},
+			want: "Today: Dry, 12 to 19 °C, no precipitation expected, wind 15 km/h.",
+		},
+		{
+			name:   "disagreement",
+			modify: func(d *ConsensusDayJSON) { d.Condition.Confidence = confidenceLow; d.MinTemp.Value = -0.3 },
+			want:   "Today: Rain, 0 to 19 °C, 4.23 mm of precipitation (67% chance), wind 15 km/h. The sources disagree on the conditions.",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := day
+			if tc.modify != nil {
+				tc.modify(&d)
+			}
+			if got := daySummary("Today", d, tc.units); got != tc.want {
+				t.Errorf("daySummary() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestDailyFeedEntry(t *testing.T) {
+	location := Location{LocationID: uuid.New(), CityName: "Wroclaw"}
+	now := time.Date(2025, 6, 2, 8, 30, 0, 0, time.UTC)
+	days := []ConsensusDayJSON{
+		{ForecastDate: "2025-06-03", Condition: ConsensusConditionJSON{Code: conditionDry}},
+		{ForecastDate: "2025-06-02", Condition: ConsensusConditionJSON{Code: conditionRain}},
+		{ForecastDate: "2025-06-04", Condition: ConsensusConditionJSON{Code: conditionSnow}},
+	}
+
+	entry, ok := dailyFeedEntry(location, days, now, unitsMetric)
+	if !ok {
+		t.Fatal("expected an entry")
+	}
+	if !strings.HasPrefix(entry.Summary, "Today: Rain") || !strings.Contains(entry.Summary, " Tomorrow: Dry") || strings.Contains(entry.Summary, "Snow") {
+		t.Errorf("unexpected summary %q", entry.Summary)
+	}
+	if entry.Title != "Weather for Wroclaw on Monday, 2 June" || !entry.Published.Equal(time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected entry: %+v", entry)
+	}
+
+	later, _ := dailyFeedEntry(location, days, now.Add(10*time.Hour), unitsMetric)
+	nextDay, _ := dailyFeedEntry(location, days, now.Add(24*time.Hour), unitsMetric)
+	if later.ID != entry.ID || nextDay.ID == entry.ID {
+		t.Errorf("expected the ID to change only with the day, got %q, %q and %q", entry.ID, later.ID, nextDay.ID)
+	}
+
+	if _, ok := dailyFeedEntry(location, days[2:], now, unitsMetric); ok {
+		t.Error("expected no entry without a forecast for today")
+	}
+}
+
+func TestHandlerFeed(t *testing.T) {
+	updatedAt := time.Now().UTC().Add(-time.Hour).Truncate(time.Second)
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+
+	setup := func(t *testing.T) *testAPIConfig {
+		testCfg := newTestAPIConfig(t)
+		testCfg.mockDB.GetLocationByAliasFunc = func(ctx context.Context, alias string) (database.Location, error) {
+			return MockDBLocation, nil
+		}
+		cached, _ := json.Marshal([]DailyForecast{
+			{SourceAPI: "Open-Meteo API", Timestamp: updatedAt, ForecastDate: today, MinTemp: 12, MaxTemp: 19, Precipitation: 4.2, PrecipitationChance: 70},
+			{SourceAPI: "Open-Meteo API", Timestamp: updatedAt, ForecastDate: today.AddDate(0, 0, 1), MinTemp: 10, MaxTemp: 21},
+		})
+		testCfg.mockCache.GetFunc = func(ctx context.Context, key string) (string, error) {
+			return string(cached), nil
+		}
+		return testCfg
+	}
+
+	t.Run("rss", func(t *testing.T) {
+		testCfg := setup(t)
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/feed.rss?city=wroclaw", nil)
+		req.Header.Set("X-Forwarded-Proto", "https")
+		rr := httptest.NewRecorder()
+		testCfg.apiConfig.handlerFeedRSS(rr, req)
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200; body: %s", rr.Code, rr.Body.String())
+		}
+		if got := rr.Header().Get("Content-Type"); !strings.HasPrefix(got, "application/rss+xml") {
+			t.Errorf("Content-Type = %q, want application/rss+xml", got)
+		}
+		var feed rssFeed
+		if err := xml.Unmarshal(rr.Body.Bytes(), &feed); err != nil {
+			t.Fatalf("failed to decode feed: %v", err)
+		}
+		if feed.Channel.Title != "Weather for Wroclaw" || len(feed.Channel.Items) != 1 {
+			t.Fatalf("unexpected channel: %+v", feed.Channel)
+		}
+		// The channel's atom:link is decoded into Link too, so the links are checked in the body.
+		body := rr.Body.String()
+		if !strings.Contains(body, "<link>https://example.com/?city=Wroclaw</link>") || !strings.Contains(body, `<atom:link href="https://example.com/api/v1/feed.rss?city=wroclaw" rel="self"`) {
+			t.Errorf("unexpected links in %s", body)
+		}
+		item := feed.Channel.Items[0]
+		if !strings.HasPrefix(item.Description, "Today: Rain, 12 to 19 °C, 4.2 mm of precipitation (70% chance)") || !strings.Contains(item.Description, "Tomorrow: Dry, 10 to 21 °C") {
+			t.Errorf("unexpected description %q", item.Description)
+		}
+		if item.GUID.IsPermaLink || !strings.HasPrefix(item.GUID.Value, "urn:uuid:") {
+			t.Errorf("unexpected GUID: %+v", item.GUID)
+		}
+
+		// Feed readers polling with the ETag are told that nothing changed.
+		req = httptest.NewRequest(http.MethodGet, "/api/v1/feed.rss?city=wroclaw", nil)
+		req.Header.Set("X-Forwarded-Proto", "https")
+		req.Header.Set("If-None-Match", rr.Header().Get("ETag"))
+		rr = httptest.NewRecorder()
+		testCfg.apiConfig.handlerFeedRSS(rr, req)
+		if rr.Code != http.StatusNotModified {
+			t.Errorf("status = %d, want 304", rr.Code)
+		}
+	})
+
+	t.Run("atom", func(t *testing.T) {
+		testCfg := setup(t)
+		rr := httptest.NewRecorder()
+		testCfg.apiConfig.handlerFeedAtom(rr, httptest.NewRequest(http.MethodGet, "/api/v1/feed.atom?city=wroclaw", nil))
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200; body: %s", rr.Code, rr.Body.String())
+		}
+		var feed atomFeed
+		if err := xml.Unmarshal(rr.Body.Bytes(), &feed); err != nil {
+			t.Fatalf("failed to decode feed: %v", err)
+		}
+		if feed.Updated != updatedAt.Format(time.RFC3339) || feed.Author.Name != feedAuthor || len(feed.Entries) != 1 {
+			t.Fatalf("unexpected feed: %+v", feed)
+		}
+		if entry := feed.Entries[0]; !strings.HasPrefix(entry.Summary.Value, "Today: Rain") || entry.Published != today.Format(time.RFC3339) {
+			t.Errorf("unexpected entry: %+v", entry)
+		}
+	})
+
+	t.Run("invalid units", func(t *testing.T) {
+		testCfg := setup(t)
+		rr := httptest.NewRecorder()
+		testCfg.apiConfig.handlerFeedRSS(rr, httptest.NewRequest(http.MethodGet, "/api/v1/feed.rss?city=wroclaw&units=kelvin", nil))
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("status = %d, want 400", rr.Code)
+		}
+	})
+}
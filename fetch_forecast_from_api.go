@@ -1,12 +1,16 @@
 package main
 
 import (
-	"fmt"
+	"context"
+	"errors"
 	"io"
 	"log/slog"
 	"net/http"
 	"sync"
 	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // fetchForecastFromAPI provides a generic and concurrent mechanism for fetching and
@@ -25,6 +29,7 @@ import (
 // parallel, improving performance and resilience.
 func fetchForecastFromAPI[T Forecast](
 	cfg *apiConfig, // The application's configuration, containing the HTTP client.
+	ctx context.Context, // Cancels the request and any retries.
 	url string, // The specific API endpoint URL to fetch.
 	parser func(body io.Reader, logger *slog.Logger) (T, string, error), // A function that takes the HTTP response body and returns the parsed forecast data, a timezone string, and an error.
 	errorVal T, // A zero-value instance of the forecast type, used to return a typed nil on error.
@@ -37,25 +42,83 @@ func fetchForecastFromAPI[T Forecast](
 ) {
 	defer wg.Done()
 
-	resp, err := cfg.httpClient.Get(url)
-	if err != nil {
-		results <- struct {
-			t   T
-			tz  string
-			err error
-		}{t: errorVal, tz: "", err: err}
-		return
+	data, tz, err := fetchForecast(cfg, ctx, url, parser, errorVal)
+	results <- struct {
+		t   T
+		tz  string
+		err error
+	}{t: data, tz: tz, err: err}
+}
+
+// fetchForecastWithFallback works like fetchForecastFromAPI, but when the provider rejects the
+// request as unauthorized and has a fallback endpoint configured, the fallback is activated and
+// queried in the same call, so that the provider's data is not lost for this fetch.
+func fetchForecastWithFallback[T Forecast](
+	cfg *apiConfig,
+	ctx context.Context,
+	url string,
+	provider forecastProvider[T],
+	wg *sync.WaitGroup,
+	results chan<- struct {
+		t   T
+		tz  string
+		err error
+	},
+) {
+	defer wg.Done()
+
+	data, tz, err := fetchForecast(cfg, ctx, url, provider.parser, provider.errorVal)
+	var statusErr *fetchStatusError
+	if provider.fallback != nil && errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusUnauthorized {
+		cfg.logger.WarnContext(ctx, "provider rejected request, switching to fallback endpoint", "provider", forecastSourceAPI(provider.errorVal), "status", statusErr.Status)
+		if provider.fallback.onSwitch != nil {
+			provider.fallback.onSwitch()
+		}
+		data, tz, err = fetchForecast(cfg, ctx, provider.fallback.url, provider.fallback.parser, provider.errorVal)
 	}
-	defer resp.Body.Close()
+	results <- struct {
+		t   T
+		tz  string
+		err error
+	}{t: data, tz: tz, err: err}
+}
+
+// fetchStatusError is returned when a provider responds with a status other than 200 OK.
+type fetchStatusError struct {
+	Status     string
+	StatusCode int
+}
+
+func (e *fetchStatusError) Error() string {
+	return "failed to fetch forecast: " + e.Status
+}
+
+// fetchForecast performs a request and parses the response body. Transient failures are retried
+// with exponential backoff (see getWithRetries), and the outcome of the last attempt is recorded
+// in the provider's circuit. On failure it returns errorVal, or whatever the parser returned,
+// together with the error.
+func fetchForecast[T Forecast](
+	cfg *apiConfig,
+	ctx context.Context,
+	url string,
+	parser func(body io.Reader, logger *slog.Logger) (T, string, error),
+	errorVal T,
+) (_ T, _ string, err error) {
+	p, known := providerByDisplayName(forecastSourceAPI(errorVal))
+	ctx, span := tracer.Start(ctx, "provider fetch", trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		attribute.String("provider", p.ID),
+		attribute.String("forecast_type", forecastTypeLabel[T]()),
+	))
+	defer func() { endSpan(span, err) }()
 
-	if resp.StatusCode != http.StatusOK {
-		results <- struct {
-			t   T
-			tz  string
-			err error
-		}{t: errorVal, tz: "", err: fmt.Errorf("failed to fetch forecast: %s", resp.Status)}
-		return
+	resp, started, err := cfg.getWithRetries(ctx, url, p.ID)
+	if known {
+		cfg.breakers.record(p.ID, err)
 	}
+	if err != nil {
+		return errorVal, "", err
+	}
+	defer resp.Body.Close()
 
 	// Instrument the parser duration.
 	start := time.Now()
@@ -63,39 +126,17 @@ func fetchForecastFromAPI[T Forecast](
 	duration := time.Since(start).Seconds()
 
 	// Determine provider and forecast type for metric labels.
-	var provider, forecastType string
-	v := any(errorVal)
-	switch val := v.(type) {
-	case CurrentWeather:
-		provider = val.SourceAPI
-		forecastType = "current"
-	case []DailyForecast:
-		if len(val) > 0 {
-			provider = val[0].SourceAPI
-		}
-		forecastType = "daily"
-	case []HourlyForecast:
-		if len(val) > 0 {
-			provider = val[0].SourceAPI
-		}
-		forecastType = "hourly"
-	}
+	provider := forecastSourceAPI(errorVal)
+	forecastType := forecastTypeLabel[T]()
 	if provider != "" {
 		parserDuration.WithLabelValues(provider, forecastType).Observe(duration)
 	}
 
 	if err != nil {
-		results <- struct {
-			t   T
-			tz  string
-			err error
-		}{t: data, tz: "", err: err}
-		return
+		return data, "", err
 	}
-
-	results <- struct {
-		t   T
-		tz  string
-		err error
-	}{t: data, tz: tz, err: nil}
+	if known {
+		cfg.latency.observe(p.ID, time.Since(started))
+	}
+	return data, tz, nil
 }
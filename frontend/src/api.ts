@@ -1,6 +1,6 @@
 import type { CurrentWeatherResponse, DailyForecastsResponse, HourlyForecastsResponse, ConfigResponse } from './types';
 
-export const API_BASE_URL = '/api';
+export const API_BASE_URL = '/api/v1';
 
 async function fetchFromApi<T>(endpoint: string, location?: string): Promise<T> {
   const url = location ? `${API_BASE_URL}/${endpoint}?city=${encodeURIComponent(location)}` : `${API_BASE_URL}/${endpoint}`;
@@ -27,3 +27,18 @@ export function fetchHourlyForecast(location: string): Promise<HourlyForecastsRe
 export function fetchConfig(): Promise<ConfigResponse> {
   return fetchFromApi<ConfigResponse>('config');
 }
+
+export interface StaticMap {
+  url: string;
+  attribution: string;
+}
+
+// Fetches the map thumbnail of a location. The returned object URL stays valid until revoked.
+export async function fetchStaticMap(location: string): Promise<StaticMap> {
+  const response = await fetch(`${API_BASE_URL}/map?city=${encodeURIComponent(location)}`);
+  if (!response.ok) {
+    throw new Error(`HTTP error! status: ${response.status}`);
+  }
+  const blob = await response.blob();
+  return { url: URL.createObjectURL(blob), attribution: response.headers.get('X-Attribution') ?? '' };
+}
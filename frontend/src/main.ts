@@ -1,6 +1,6 @@
 import './style.css';
-import { fetchCurrentWeather, fetchDailyForecast, fetchHourlyForecast, fetchConfig } from './api';
-import { dom, setActiveTab, renderCurrentWeather, renderDailyForecast, renderHourlyForecast, showError, showLoading } from './ui';
+import { fetchCurrentWeather, fetchDailyForecast, fetchHourlyForecast, fetchConfig, fetchStaticMap } from './api';
+import { dom, setActiveTab, renderCurrentWeather, renderLocationMap, renderDailyForecast, renderHourlyForecast, showError, showLoading } from './ui';
 
 // --- Initial Setup ---
 async function initializeApp() {
@@ -54,19 +54,50 @@ dom.runSchedulerBtn.addEventListener('click', async () => {
   }
 });
 
+// --- Client-Side Routes ---
+// A searched location is reflected in the URL as /city/<name>, so that it can be bookmarked and
+// shared. The server answers these paths with index.html.
+const CITY_ROUTE_PREFIX = '/city/';
+
+function locationFromPath(): string {
+  if (!window.location.pathname.startsWith(CITY_ROUTE_PREFIX)) {
+    return '';
+  }
+  return decodeURIComponent(window.location.pathname.slice(CITY_ROUTE_PREFIX.length)).trim();
+}
+
+window.addEventListener('popstate', () => {
+  const location = locationFromPath();
+  if (location) {
+    dom.locationInput.value = location;
+    showWeather(location);
+  }
+});
+
 // --- Main Application Logic ---
-dom.getWeatherBtn.addEventListener('click', async () => {
+dom.getWeatherBtn.addEventListener('click', () => {
   const location = dom.locationInput.value.trim();
   if (!location) {
     showError('current', new Error('Please enter a location.'));
     return;
   }
+  const path = CITY_ROUTE_PREFIX + encodeURIComponent(location);
+  if (window.location.pathname !== path) {
+    history.pushState(null, '', path);
+  }
+  showWeather(location);
+});
 
+async function showWeather(location: string) {
   // --- Current Weather ---
   showLoading('current');
   try {
     const currentData = await fetchCurrentWeather(location);
     renderCurrentWeather(currentData);
+    // The map is a nicety: the weather is shown without it if it cannot be rendered.
+    fetchStaticMap(location)
+      .then(map => renderLocationMap(map, currentData.location.city_name))
+      .catch(error => console.error('Failed to load location map:', error));
   } catch (error) {
     showError('current', error as Error);
   }
@@ -88,7 +119,12 @@ dom.getWeatherBtn.addEventListener('click', async () => {
   } catch (error) {
     showError('hourly', error as Error);
   }
-});
+}
 
 // --- App Initialization ---
 initializeApp();
+const initialLocation = locationFromPath();
+if (initialLocation) {
+  dom.locationInput.value = initialLocation;
+  showWeather(initialLocation);
+}
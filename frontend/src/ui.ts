@@ -1,3 +1,4 @@
+import type { StaticMap } from './api';
 import type { CurrentWeatherResponse, DailyForecastsResponse, HourlyForecastsResponse, DailyForecast, HourlyForecast } from './types';
 
 // --- Helper Functions ---
@@ -86,12 +87,28 @@ export function renderCurrentWeather(data: CurrentWeatherResponse) {
   `).join('');
   dom.panels.current.innerHTML = `
     <h3>Current Weather in ${data.location.city_name}</h3>
+    <div id="location-map"></div>
     <div class="weather-cards-container">
       ${weatherHtml}
     </div>
   `;
 }
 
+export function renderLocationMap(map: StaticMap, cityName: string) {
+  const container = document.querySelector<HTMLDivElement>('#location-map');
+  if (!container) {
+    URL.revokeObjectURL(map.url);
+    return;
+  }
+  container.innerHTML = `
+    <figure class="location-map">
+      <img src="${map.url}" alt="Map of ${cityName}">
+      <figcaption><small>${map.attribution}</small></figcaption>
+    </figure>
+  `;
+  container.querySelector('img')!.addEventListener('load', () => URL.revokeObjectURL(map.url), { once: true });
+}
+
 export function renderDailyForecast(data: DailyForecastsResponse) {
   if (!data.forecasts || data.forecasts.length === 0) {
     throw new Error('No daily forecast data available for this location.');
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// This file implements the geocoder fallback chain. GEOCODER_PROVIDER lists one or more
+// geocoders in order of preference, e.g. "google,nominatim". A lookup is answered by the first
+// geocoder in the chain that succeeds, so that an outage or an exhausted quota of the primary
+// geocoder does not turn into failed weather requests. The bundled city dataset is consulted
+// before the chain.
+
+const (
+	// googleGeocoder and nominatimGeocoder are the geocoders that GEOCODER_PROVIDER can list.
+	googleGeocoder    = "google"
+	nominatimGeocoder = "nominatim"
+)
+
+// getGeocoderProviders reads GEOCODER_PROVIDER, the comma-separated geocoders used for names
+// that are not in the bundled city dataset, in the order they are tried. Unknown and repeated
+// entries are ignored; without a valid entry, the Google geocoder is used.
+func getGeocoderProviders(logger *slog.Logger) []string {
+	providers, unknown := parseGeocoderProviders(getEnv("GEOCODER_PROVIDER", googleGeocoder, logger))
+	for _, name := range unknown {
+		logger.Warn("unknown geocoder in GEOCODER_PROVIDER, ignoring", "geocoder", name)
+	}
+	if len(providers) == 0 {
+		logger.Warn("no valid geocoder in GEOCODER_PROVIDER, using default", "default", googleGeocoder)
+		return []string{googleGeocoder}
+	}
+	return providers
+}
+
+// parseGeocoderProviders splits a comma-separated geocoder list into the known geocoders, in
+// order and without repetitions, and the unknown entries.
+func parseGeocoderProviders(raw string) (providers, unknown []string) {
+	for _, name := range strings.Split(raw, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		switch {
+		case name == "" || slices.Contains(providers, name):
+		case name == googleGeocoder || name == nominatimGeocoder:
+			providers = append(providers, name)
+		default:
+			unknown = append(unknown, name)
+		}
+	}
+	return providers, unknown
+}
+
+// newGeocoderChain creates the geocoders listed in providers and chains them. The Google
+// geocoder is skipped without a Google Maps key. It returns nil if no geocoder remains, and the
+// geocoder itself if only one does.
+func newGeocoderChain(providers []string, gmpKey *secretValue, gmpGeocodeURL, nominatimURL string, httpClient *http.Client, logger *slog.Logger) GeocodingService {
+	var links []geocoderLink
+	for _, name := range providers {
+		switch name {
+		case googleGeocoder:
+			if gmpKey.Get() == "" {
+				logger.Warn("GMP_KEY not set, skipping the Google geocoder")
+				continue
+			}
+			links = append(links, geocoderLink{name: name, geocoder: NewGmpGeocodingService(gmpKey, gmpGeocodeURL, httpClient)})
+		case nominatimGeocoder:
+			links = append(links, geocoderLink{name: name, geocoder: NewNominatimGeocodingService(nominatimURL, httpClient)})
+		}
+	}
+
+	switch len(links) {
+	case 0:
+		return nil
+	case 1:
+		return links[0].geocoder
+	default:
+		return &ChainGeocodingService{links: links, logger: logger}
+	}
+}
+
+// geocoderLink is a named geocoder in a ChainGeocodingService.
+type geocoderLink struct {
+	name     string
+	geocoder GeocodingService
+}
+
+// ChainGeocodingService is an implementation of GeocodingService that tries a list of
+// geocoders in order and returns the first successful answer.
+type ChainGeocodingService struct {
+	links  []geocoderLink
+	logger *slog.Logger
+}
+
+// Geocode returns the first successful answer of the chained geocoders.
+func (s *ChainGeocodingService) Geocode(cityName string) (Location, error) {
+	return s.try(func(g GeocodingService) (Location, error) {
+		return g.Geocode(cityName)
+	})
+}
+
+// ReverseGeocode returns the first successful answer of the chained geocoders.
+func (s *ChainGeocodingService) ReverseGeocode(lat, lng float64) (Location, error) {
+	return s.try(func(g GeocodingService) (Location, error) {
+		return g.ReverseGeocode(lat, lng)
+	})
+}
+
+// try calls lookup with each geocoder until one succeeds. If all of them fail, the errors are
+// joined, so that the result matches ErrNoResultsFound if any geocoder reported no results.
+func (s *ChainGeocodingService) try(lookup func(GeocodingService) (Location, error)) (Location, error) {
+	var errs []error
+	for i, link := range s.links {
+		location, err := lookup(link.geocoder)
+		if err == nil {
+			if i > 0 {
+				geocoderFallbacks.WithLabelValues(link.name).Inc()
+				s.logger.Info("geocoding answered by fallback geocoder", "geocoder", link.name)
+			}
+			return location, nil
+		}
+		if !errors.Is(err, ErrNoResultsFound) {
+			s.logger.Warn("geocoder failed, trying next", "geocoder", link.name, "error", err)
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", link.name, err))
+	}
+	return Location{}, errors.Join(errs...)
+}
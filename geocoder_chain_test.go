@@ -0,0 +1,118 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestGetGeocoderProviders(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	for value, want := range map[string]string{
+		"":                      "google",
+		"google":                "google",
+		" Nominatim":            "nominatim",
+		"google, nominatim":     "google,nominatim",
+		"nominatim,bing,google": "nominatim,google",
+		"google,google":         "google",
+		"bing":                  "google",
+	} {
+		t.Setenv("GEOCODER_PROVIDER", value)
+		if got := strings.Join(getGeocoderProviders(logger), ","); got != want {
+			t.Errorf("GEOCODER_PROVIDER=%q: got %q, want %q", value, got, want)
+		}
+	}
+}
+
+func TestNewGeocoderChain(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	client := &http.Client{}
+
+	if g := newGeocoderChain([]string{googleGeocoder}, nil, "", "", client, logger); g != nil {
+		t.Errorf("expected no geocoder without a Google Maps key, got %T", g)
+	}
+	if g := newGeocoderChain([]string{googleGeocoder, nominatimGeocoder}, nil, "", "", client, logger); g == nil {
+		t.Error("expected the Nominatim geocoder without a Google Maps key, got nil")
+	} else if _, ok := g.(*NominatimGeocodingService); !ok {
+		t.Errorf("expected the Nominatim geocoder without a Google Maps key, got %T", g)
+	}
+	g := newGeocoderChain([]string{googleGeocoder, nominatimGeocoder}, newSecretValue("key"), "", "", client, logger)
+	chain, ok := g.(*ChainGeocodingService)
+	if !ok {
+		t.Fatalf("expected a geocoder chain, got %T", g)
+	}
+	if len(chain.links) != 2 || chain.links[0].name != googleGeocoder || chain.links[1].name != nominatimGeocoder {
+		t.Errorf("unexpected chain order: %+v", chain.links)
+	}
+}
+
+func TestChainGeocodingService(t *testing.T) {
+	errQuota := errors.New("geocoding API returned status: OVER_QUERY_LIMIT")
+	failing := func(err error) *mockGeocodingService {
+		return &mockGeocodingService{
+			GeocodeFunc:        func(cityName string) (Location, error) { return Location{}, err },
+			ReverseGeocodeFunc: func(lat, lng float64) (Location, error) { return Location{}, err },
+		}
+	}
+	answering := &mockGeocodingService{
+		GeocodeFunc:        func(cityName string) (Location, error) { return MockLocation, nil },
+		ReverseGeocodeFunc: func(lat, lng float64) (Location, error) { return MockLocation, nil },
+	}
+
+	testCases := []struct {
+		name      string
+		links     []geocoderLink
+		wantErr   error
+		wantError bool
+	}{
+		{
+			name:  "Primary Answers",
+			links: []geocoderLink{{name: googleGeocoder, geocoder: answering}, {name: nominatimGeocoder, geocoder: failing(errQuota)}},
+		},
+		{
+			name:  "Fallback After Error",
+			links: []geocoderLink{{name: googleGeocoder, geocoder: failing(errQuota)}, {name: nominatimGeocoder, geocoder: answering}},
+		},
+		{
+			name:  "Fallback After No Results",
+			links: []geocoderLink{{name: googleGeocoder, geocoder: failing(ErrNoResultsFound)}, {name: nominatimGeocoder, geocoder: answering}},
+		},
+		{
+			name:      "All Fail",
+			links:     []geocoderLink{{name: googleGeocoder, geocoder: failing(errQuota)}, {name: nominatimGeocoder, geocoder: failing(ErrNoResultsFound)}},
+			wantErr:   ErrNoResultsFound,
+			wantError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			chain := &ChainGeocodingService{links: tc.links, logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+
+			for _, lookup := range []func() (Location, error){
+				func() (Location, error) { return chain.Geocode("Wroclaw") },
+				func() (Location, error) { return chain.ReverseGeocode(51.1, 17.03) },
+			} {
+				location, err := lookup()
+				if tc.wantError {
+					if err == nil {
+						t.Fatal("expected an error, got nil")
+					}
+					if !errors.Is(err, tc.wantErr) || !errors.Is(err, errQuota) {
+						t.Errorf("expected the errors of all geocoders, got %v", err)
+					}
+					continue
+				}
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if location != MockLocation {
+					t.Errorf("expected %+v, got %+v", MockLocation, location)
+				}
+			}
+		})
+	}
+}
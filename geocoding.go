@@ -28,13 +28,13 @@ type GeocodingService interface {
 
 // GmpGeocodingService is an implementation of GeocodingService that uses the Google Maps Platform API.
 type GmpGeocodingService struct {
-	gmpKey        string
+	gmpKey        *secretValue
 	gmpGeocodeURL string
 	httpClient    *http.Client
 }
 
 // NewGmpGeocodingService creates a new GmpGeocodingService.
-func NewGmpGeocodingService(gmpKey, gmpGeocodeURL string, httpClient *http.Client) *GmpGeocodingService {
+func NewGmpGeocodingService(gmpKey *secretValue, gmpGeocodeURL string, httpClient *http.Client) *GmpGeocodingService {
 	return &GmpGeocodingService{
 		gmpKey:        gmpKey,
 		gmpGeocodeURL: gmpGeocodeURL,
@@ -67,7 +67,7 @@ func (s *GmpGeocodingService) performGeocodeRequest(queryParams map[string]strin
 	}
 
 	q := baseURL.Query()
-	q.Set("key", s.gmpKey)
+	q.Set("key", s.gmpKey.Get())
 	for key, value := range queryParams {
 		q.Set(key, value)
 	}
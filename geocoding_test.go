@@ -126,7 +126,7 @@ func TestGmpGeocodingService(t *testing.T) {
 
 			if tc.name == "API Request Failure" {
 				client = &http.Client{
-					Transport: &errorTransport{err: errors.New("network error")},
+					Transport: &errorTransport{Err: errors.New("network error")},
 				}
 			}
 
@@ -135,7 +135,7 @@ func TestGmpGeocodingService(t *testing.T) {
 			}
 
 			geocoder := NewGmpGeocodingService(
-				"dummy-key",
+				newSecretValue("dummy-key"),
 				serviceURL,
 				client,
 			)
@@ -0,0 +1,166 @@
+//go:build ignore
+
+// generate builds geodata/cities.tsv from the GeoNames cities1000 dump: the most populous
+// cities worldwide, ordered by population. It downloads the dump unless -input names a local
+// copy of cities1000.txt or cities1000.zip.
+//
+//	go run geodata/generate.go -o geodata/cities.tsv
+package main
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"cmp"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const dumpURL = "https://download.geonames.org/export/dump/cities1000.zip"
+
+// renamed maps GeoNames names to the names the cities are usually searched by.
+var renamed = map[string]string{
+	"New York City": "New York",
+}
+
+const header = `# Bundled city dataset for the static geocoder, generated by geodata/generate.go.
+# Columns: name, ISO 3166 country code, latitude, longitude, IANA timezone.
+# The %d most populous cities of the GeoNames cities1000 dump (CC BY 4.0,
+# https://www.geonames.org), ranked by population worldwide. Rows are ordered by population, so
+# that for names that occur more than once the first row, which wins, is the larger city. Other
+# cities fall back to the configured geocoder.
+`
+
+type city struct {
+	id         int
+	name       string
+	country    string
+	lat, lon   string
+	population int
+	timezone   string
+}
+
+func main() {
+	input := flag.String("input", "", "local cities1000.txt or cities1000.zip (default: download "+dumpURL+")")
+	output := flag.String("o", "geodata/cities.tsv", "output file")
+	limit := flag.Int("n", 10000, "number of cities")
+	flag.Parse()
+
+	data, err := readDump(*input)
+	if err != nil {
+		log.Fatal(err)
+	}
+	cities, err := parseDump(data)
+	if err != nil {
+		log.Fatal(err)
+	}
+	slices.SortFunc(cities, func(a, b city) int {
+		return cmp.Or(cmp.Compare(b.population, a.population), cmp.Compare(a.id, b.id))
+	})
+	cities = cities[:min(*limit, len(cities))]
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, header, len(cities))
+	for _, c := range cities {
+		fmt.Fprintf(&out, "%s\t%s\t%s\t%s\t%s\n", c.name, c.country, c.lat, c.lon, c.timezone)
+	}
+	if err := os.WriteFile(*output, out.Bytes(), 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// readDump returns the contents of cities1000.txt, read from path or downloaded.
+func readDump(path string) ([]byte, error) {
+	var data []byte
+	var err error
+	if path == "" {
+		data, err = download(dumpURL)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(data, []byte("PK")) {
+		return data, nil
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+	f, err := zr.Open("cities1000.txt")
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
+
+func download(url string) ([]byte, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// parseDump parses the populated places of a GeoNames dump. Its columns are described in
+// https://download.geonames.org/export/dump/readme.txt.
+func parseDump(data []byte) ([]city, error) {
+	var cities []city
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, 1<<20)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		fields := strings.Split(scanner.Text(), "\t")
+		if len(fields) != 19 {
+			return nil, fmt.Errorf("line %d: expected 19 fields, got %d", lineNo, len(fields))
+		}
+		if fields[6] != "P" || fields[17] == "" {
+			continue
+		}
+		id, err := strconv.Atoi(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid geonameid: %w", lineNo, err)
+		}
+		population, err := strconv.Atoi(fields[14])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid population: %w", lineNo, err)
+		}
+		lat, err := strconv.ParseFloat(fields[4], 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid latitude: %w", lineNo, err)
+		}
+		lon, err := strconv.ParseFloat(fields[5], 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid longitude: %w", lineNo, err)
+		}
+		name := fields[1]
+		if r, ok := renamed[name]; ok {
+			name = r
+		}
+		cities = append(cities, city{
+			id:         id,
+			name:       name,
+			country:    fields[8],
+			lat:        strconv.FormatFloat(lat, 'f', 4, 64),
+			lon:        strconv.FormatFloat(lon, 'f', 4, 64),
+			population: population,
+			timezone:   fields[17],
+		})
+	}
+	return cities, scanner.Err()
+}
@@ -0,0 +1,313 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// This file implements /api/grid, which returns current temperature and precipitation for a
+// regular grid of points inside a bounding box, for map overlays such as a temperature
+// heat-map. Grid points are aligned to multiples of the resolution and grouped into fixed
+// tiles of gridTileSize x gridTileSize points. Each tile is fetched from Open-Meteo with a
+// single multi-point request and cached in Redis, so neighbouring and overlapping map views
+// share the same upstream requests.
+
+const (
+	// gridTileSize is the number of grid points along each side of a tile.
+	gridTileSize = 10
+	// gridMaxPoints is the maximum number of grid points a single request may cover.
+	gridMaxPoints = 2500
+	// gridTileCacheTTL matches the Redis TTL of current weather.
+	gridTileCacheTTL = redisCurrentWeatherCacheTTL
+	// gridFetchConcurrency limits the number of tiles fetched from Open-Meteo in parallel.
+	gridFetchConcurrency = 4
+	// gridCacheKeyPrefix is the cache key prefix for grid tiles.
+	gridCacheKeyPrefix = "grid"
+)
+
+// gridResolutions lists the supported grid resolutions in degrees. Restricting the
+// resolution keeps the number of distinct tiles, and thus cache entries, bounded.
+var gridResolutions = []float64{0.1, 0.25, 0.5, 1, 2}
+
+// errGridSourceDisabled is returned when grid data is requested while Open-Meteo is disabled.
+var errGridSourceDisabled = errors.New("grid data requires the ometeo source, which is disabled")
+
+// GridRequest is the request body of the /api/grid endpoint.
+type GridRequest struct {
+	MinLat     float64 `json:"min_lat"`
+	MinLon     float64 `json:"min_lon"`
+	MaxLat     float64 `json:"max_lat"`
+	MaxLon     float64 `json:"max_lon"`
+	Resolution float64 `json:"resolution"`
+}
+
+// gridTile identifies a tile by its resolution and its position in tile units.
+type gridTile struct {
+	resolution float64
+	x, y       int
+}
+
+// cacheKey returns the cache key of the tile.
+func (t gridTile) cacheKey() string {
+	return fmt.Sprintf("%s:%s:%d:%d", gridCacheKeyPrefix, strconv.FormatFloat(t.resolution, 'f', -1, 64), t.x, t.y)
+}
+
+// points returns the grid indices covered by the tile, clipped to valid coordinates.
+func (t gridTile) points() [][2]int {
+	maxLatIdx := int(math.Floor(90 / t.resolution))
+	maxLonIdx := int(math.Floor(180 / t.resolution))
+	var points [][2]int
+	for latIdx := t.y * gridTileSize; latIdx < (t.y+1)*gridTileSize; latIdx++ {
+		if latIdx < -maxLatIdx || latIdx > maxLatIdx {
+			continue
+		}
+		for lonIdx := t.x * gridTileSize; lonIdx < (t.x+1)*gridTileSize; lonIdx++ {
+			if lonIdx < -maxLonIdx || lonIdx > maxLonIdx {
+				continue
+			}
+			points = append(points, [2]int{latIdx, lonIdx})
+		}
+	}
+	return points
+}
+
+// gridCoordinate converts a grid index to a coordinate, rounded to remove float noise.
+func gridCoordinate(idx int, resolution float64) float64 {
+	return math.Round(float64(idx)*resolution*1e4) / 1e4
+}
+
+// floorDiv divides a by b, rounding towards negative infinity.
+func floorDiv(a, b int) int {
+	q := a / b
+	if a%b != 0 && (a < 0) != (b < 0) {
+		q--
+	}
+	return q
+}
+
+// validate checks the bounding box and resolution of a grid request.
+func (req GridRequest) validate() error {
+	supported := false
+	for _, r := range gridResolutions {
+		if req.Resolution == r {
+			supported = true
+			break
+		}
+	}
+	if !supported {
+		return fmt.Errorf("resolution must be one of %v degrees", gridResolutions)
+	}
+	if req.MinLat < -90 || req.MaxLat > 90 || req.MinLon < -180 || req.MaxLon > 180 {
+		return errors.New("bounding box must lie within latitude -90..90 and longitude -180..180")
+	}
+	if req.MinLat > req.MaxLat || req.MinLon > req.MaxLon {
+		return errors.New("min_lat and min_lon must not exceed max_lat and max_lon")
+	}
+	rows := int(math.Floor(req.MaxLat/req.Resolution)) - int(math.Ceil(req.MinLat/req.Resolution)) + 1
+	cols := int(math.Floor(req.MaxLon/req.Resolution)) - int(math.Ceil(req.MinLon/req.Resolution)) + 1
+	if rows <= 0 || cols <= 0 {
+		return errors.New("bounding box contains no grid points at this resolution")
+	}
+	if rows*cols > gridMaxPoints {
+		return fmt.Errorf("bounding box covers %d grid points, the maximum is %d", rows*cols, gridMaxPoints)
+	}
+	return nil
+}
+
+// tiles returns the tiles that cover the request's bounding box.
+func (req GridRequest) tiles() []gridTile {
+	minX := floorDiv(int(math.Ceil(req.MinLon/req.Resolution)), gridTileSize)
+	maxX := floorDiv(int(math.Floor(req.MaxLon/req.Resolution)), gridTileSize)
+	minY := floorDiv(int(math.Ceil(req.MinLat/req.Resolution)), gridTileSize)
+	maxY := floorDiv(int(math.Floor(req.MaxLat/req.Resolution)), gridTileSize)
+	var tiles []gridTile
+	for y := minY; y <= maxY; y++ {
+		for x := minX; x <= maxX; x++ {
+			tiles = append(tiles, gridTile{resolution: req.Resolution, x: x, y: y})
+		}
+	}
+	return tiles
+}
+
+// contains reports whether a point lies inside the request's bounding box.
+func (req GridRequest) contains(p GridPointJSON) bool {
+	return p.Latitude >= req.MinLat && p.Latitude <= req.MaxLat && p.Longitude >= req.MinLon && p.Longitude <= req.MaxLon
+}
+
+// @Summary      Get a grid of current conditions
+// @Description  Returns current temperature and precipitation from Open-Meteo for a regular grid of points
+// @Description  inside the bounding box, for map overlays. Points are aligned to multiples of the resolution,
+// @Description  which must be one of 0.1, 0.25, 0.5, 1 or 2 degrees. At most 2500 points are returned.
+// @Tags         weather
+// @Accept       json
+// @Produce      json
+// @Param        request  body      GridRequest  true  "Bounding box and resolution"
+// @Success      200  {object}  GridResponse
+// @Failure      400  {object}  ErrorResponse "Bad Request - Invalid bounding box or resolution"
+// @Failure      502  {object}  ErrorResponse "Bad Gateway - Failed to fetch grid data"
+// @Failure      503  {object}  ErrorResponse "Service Unavailable - Open-Meteo source disabled"
+// @Router       /api/v1/grid [post]
+func (cfg *apiConfig) handlerGrid(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		cfg.respondWithError(w, http.StatusMethodNotAllowed, "Method Not Allowed", nil)
+		return
+	}
+	if !cfg.sourceEnabled("ometeo") {
+		cfg.respondWithError(w, http.StatusServiceUnavailable, errGridSourceDisabled.Error(), nil)
+		return
+	}
+
+	var req GridRequest
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<10))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		cfg.respondWithError(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	if err := req.validate(); err != nil {
+		cfg.respondWithError(w, http.StatusBadRequest, "Invalid grid request", err)
+		return
+	}
+
+	points, err := cfg.getGridPoints(r.Context(), req)
+	if err != nil {
+		cfg.respondWithError(w, http.StatusBadGateway, "Error getting grid data", err)
+		return
+	}
+
+	var attribution []AttributionJSON
+	if p, ok := providerByID("ometeo"); ok {
+		attribution = []AttributionJSON{providerAttribution(p)}
+	}
+	cfg.respondWithJSON(w, http.StatusOK, GridResponse{
+		Resolution:  req.Resolution,
+		Points:      points,
+		Attribution: attribution,
+	})
+}
+
+// getGridPoints returns the grid points inside the request's bounding box, reading tiles from
+// the cache and fetching the missing ones from Open-Meteo.
+func (cfg *apiConfig) getGridPoints(ctx context.Context, req GridRequest) ([]GridPointJSON, error) {
+	tiles := req.tiles()
+	tilePoints := make([][]GridPointJSON, len(tiles))
+	errs := make([]error, len(tiles))
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, gridFetchConcurrency)
+	for i, tile := range tiles {
+		if cached, err := cfg.cache.Get(ctx, tile.cacheKey()); err == nil {
+			if err := json.Unmarshal([]byte(cached), &tilePoints[i]); err == nil {
+				continue
+			}
+		}
+		wg.Add(1)
+		go func(i int, tile gridTile) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			points, err := cfg.fetchGridTile(tile)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			tilePoints[i] = points
+			if err := cfg.cache.Set(ctx, tile.cacheKey(), points, gridTileCacheTTL); err != nil {
+				cfg.logger.Warn("failed to cache grid tile", "key", tile.cacheKey(), "error", err)
+			}
+		}(i, tile)
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	points := []GridPointJSON{}
+	for _, tp := range tilePoints {
+		for _, p := range tp {
+			if req.contains(p) {
+				points = append(points, p)
+			}
+		}
+	}
+	return points, nil
+}
+
+// ometeoGridResponse is a single location in an Open-Meteo multi-point response.
+type ometeoGridResponse struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Current   struct {
+		Time          int64   `json:"time"`
+		Temperature   float64 `json:"temperature_2m"`
+		Precipitation float64 `json:"precipitation"`
+	} `json:"current"`
+}
+
+// fetchGridTile requests current conditions for all points of a tile in one Open-Meteo call.
+// Open-Meteo returns one result per requested coordinate, in request order, and snaps each
+// coordinate to its model grid, so results are matched to grid points by position.
+func (cfg *apiConfig) fetchGridTile(tile gridTile) ([]GridPointJSON, error) {
+	indices := tile.points()
+	if len(indices) == 0 {
+		return []GridPointJSON{}, nil
+	}
+
+	lats := make([]string, len(indices))
+	lons := make([]string, len(indices))
+	for i, idx := range indices {
+		lats[i] = strconv.FormatFloat(gridCoordinate(idx[0], tile.resolution), 'f', -1, 64)
+		lons[i] = strconv.FormatFloat(gridCoordinate(idx[1], tile.resolution), 'f', -1, 64)
+	}
+	url := fmt.Sprintf("%slatitude=%s&longitude=%s&current=temperature_2m,precipitation&timeformat=unixtime",
+		cfg.ometeoWeatherURL, strings.Join(lats, ","), strings.Join(lons, ","))
+
+	resp, err := cfg.httpClient.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("grid tile request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("grid tile request returned non-200 status: %s", resp.Status)
+	}
+
+	// A request for a single coordinate returns an object instead of an array.
+	var raw json.RawMessage
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("failed to decode grid tile response: %w", err)
+	}
+	var results []ometeoGridResponse
+	if len(raw) > 0 && raw[0] == '[' {
+		err = json.Unmarshal(raw, &results)
+	} else {
+		results = make([]ometeoGridResponse, 1)
+		err = json.Unmarshal(raw, &results[0])
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode grid tile response: %w", err)
+	}
+	if len(results) != len(indices) {
+		return nil, fmt.Errorf("grid tile response has %d results for %d points", len(results), len(indices))
+	}
+
+	points := make([]GridPointJSON, len(indices))
+	for i, idx := range indices {
+		points[i] = GridPointJSON{
+			Latitude:      gridCoordinate(idx[0], tile.resolution),
+			Longitude:     gridCoordinate(idx[1], tile.resolution),
+			Temperature:   results[i].Current.Temperature,
+			Precipitation: results[i].Current.Precipitation,
+			ObservedAt:    time.Unix(results[i].Current.Time, 0).UTC().Format(time.RFC3339),
+		}
+	}
+	return points, nil
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// ometeoGridHandler answers Open-Meteo multi-point requests with one result per coordinate,
+// using the latitude as temperature so that results can be traced back to their points.
+func ometeoGridHandler(calls *atomic.Int32) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		lats := strings.Split(r.URL.Query().Get("latitude"), ",")
+		results := make([]string, len(lats))
+		for i, lat := range lats {
+			results[i] = fmt.Sprintf(`{"latitude":%s,"longitude":0,"current":{"time":1748779200,"temperature_2m":%s,"precipitation":0.5}}`, lat, lat)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if len(results) == 1 {
+			_, _ = w.Write([]byte(results[0]))
+			return
+		}
+		_, _ = w.Write([]byte("[" + strings.Join(results, ",") + "]"))
+	}
+}
+
+func TestHandlerGrid(t *testing.T) {
+	testCases := []struct {
+		name           string
+		method         string
+		body           string
+		disableOMeteo  bool
+		upstreamStatus int
+		cachedTile     []GridPointJSON
+		wantStatus     int
+		wantPoints     int
+		wantCalls      int32
+	}{
+		{
+			name:       "Success",
+			method:     http.MethodPost,
+			body:       `{"min_lat":50,"min_lon":16,"max_lat":51,"max_lon":17,"resolution":1}`,
+			wantStatus: http.StatusOK,
+			wantPoints: 4,
+			wantCalls:  1,
+		},
+		{
+			name:       "Spans Several Tiles",
+			method:     http.MethodPost,
+			body:       `{"min_lat":-1,"min_lon":-1,"max_lat":1,"max_lon":1,"resolution":0.5}`,
+			wantStatus: http.StatusOK,
+			wantPoints: 25,
+			wantCalls:  4,
+		},
+		{
+			name:   "Served From Cache",
+			method: http.MethodPost,
+			body:   `{"min_lat":50,"min_lon":16,"max_lat":51,"max_lon":17,"resolution":1}`,
+			cachedTile: []GridPointJSON{
+				{Latitude: 50, Longitude: 16, Temperature: 12},
+				{Latitude: 55, Longitude: 16, Temperature: 10},
+			},
+			wantStatus: http.StatusOK,
+			wantPoints: 1,
+			wantCalls:  0,
+		},
+		{
+			name:       "Unsupported Resolution",
+			method:     http.MethodPost,
+			body:       `{"min_lat":50,"min_lon":16,"max_lat":51,"max_lon":17,"resolution":0.3}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Too Many Points",
+			method:     http.MethodPost,
+			body:       `{"min_lat":0,"min_lon":0,"max_lat":10,"max_lon":10,"resolution":0.1}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Inverted Bounding Box",
+			method:     http.MethodPost,
+			body:       `{"min_lat":51,"min_lon":16,"max_lat":50,"max_lon":17,"resolution":1}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Unknown Field",
+			method:     http.MethodPost,
+			body:       `{"north":51,"resolution":1}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Upstream Error",
+			method:         http.MethodPost,
+			body:           `{"min_lat":50,"min_lon":16,"max_lat":51,"max_lon":17,"resolution":1}`,
+			upstreamStatus: http.StatusInternalServerError,
+			wantStatus:     http.StatusBadGateway,
+			wantCalls:      1,
+		},
+		{
+			name:          "Open-Meteo Disabled",
+			method:        http.MethodPost,
+			body:          `{"min_lat":50,"min_lon":16,"max_lat":51,"max_lon":17,"resolution":1}`,
+			disableOMeteo: true,
+			wantStatus:    http.StatusServiceUnavailable,
+		},
+		{
+			name:       "Method Not Allowed",
+			method:     http.MethodGet,
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var calls atomic.Int32
+			handler := ometeoGridHandler(&calls)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tc.upstreamStatus != 0 {
+					calls.Add(1)
+					w.WriteHeader(tc.upstreamStatus)
+					return
+				}
+				handler(w, r)
+			}))
+			defer server.Close()
+
+			testCfg := newTestAPIConfig(t)
+			testCfg.ometeoWeatherURL = server.URL + "/?"
+			if tc.disableOMeteo {
+				testCfg.enabledSources = map[string]bool{"gmp": true, "owm": true}
+			}
+			if tc.cachedTile != nil {
+				cached, _ := json.Marshal(tc.cachedTile)
+				testCfg.mockCache.GetFunc = func(ctx context.Context, key string) (string, error) {
+					return string(cached), nil
+				}
+			}
+			var mu sync.Mutex
+			var cachedKeys []string
+			testCfg.mockCache.SetFunc = func(ctx context.Context, key string, value any, expiration time.Duration) error {
+				mu.Lock()
+				defer mu.Unlock()
+				cachedKeys = append(cachedKeys, key)
+				return nil
+			}
+
+			req := httptest.NewRequest(tc.method, "/api/grid", strings.NewReader(tc.body))
+			rr := httptest.NewRecorder()
+
+			testCfg.apiConfig.handlerGrid(rr, req)
+
+			if status := rr.Code; status != tc.wantStatus {
+				t.Fatalf("handler returned wrong status code: got %v want %v, body %s", status, tc.wantStatus, rr.Body.String())
+			}
+			if got := calls.Load(); got != tc.wantCalls {
+				t.Errorf("unexpected number of upstream calls: got %d want %d", got, tc.wantCalls)
+			}
+			if tc.wantStatus != http.StatusOK {
+				return
+			}
+
+			var response GridResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+				t.Fatalf("could not decode response: %v", err)
+			}
+			if len(response.Points) != tc.wantPoints {
+				t.Errorf("unexpected number of points: got %d want %d", len(response.Points), tc.wantPoints)
+			}
+			if len(cachedKeys) != int(tc.wantCalls) {
+				t.Errorf("expected every fetched tile to be cached, got keys %v", cachedKeys)
+			}
+			for _, p := range response.Points {
+				if tc.cachedTile == nil && p.Temperature != p.Latitude {
+					t.Errorf("point %+v was matched to the wrong upstream result", p)
+				}
+			}
+		})
+	}
+}
+
+func TestGridTiles(t *testing.T) {
+	req := GridRequest{MinLat: -0.5, MinLon: -0.5, MaxLat: 0.5, MaxLon: 0.5, Resolution: 0.1}
+	tiles := req.tiles()
+	want := map[gridTile]bool{
+		{resolution: 0.1, x: -1, y: -1}: true,
+		{resolution: 0.1, x: 0, y: -1}:  true,
+		{resolution: 0.1, x: -1, y: 0}:  true,
+		{resolution: 0.1, x: 0, y: 0}:   true,
+	}
+	if len(tiles) != len(want) {
+		t.Fatalf("expected %d tiles, got %v", len(want), tiles)
+	}
+	for _, tile := range tiles {
+		if !want[tile] {
+			t.Errorf("unexpected tile %+v", tile)
+		}
+	}
+
+	if key := (gridTile{resolution: 0.25, x: -3, y: 2}).cacheKey(); key != "grid:0.25:-3:2" {
+		t.Errorf("unexpected cache key %q", key)
+	}
+
+	// Latitudes above 90 degrees are clipped from tiles at the pole.
+	polar := gridTile{resolution: 2, x: 0, y: 4}
+	if got := len(polar.points()); got != 6*gridTileSize {
+		t.Errorf("expected %d points in the polar tile, got %d", 6*gridTileSize, got)
+	}
+}
+
+func TestFloorDiv(t *testing.T) {
+	for _, tc := range []struct{ a, b, want int }{{7, 10, 0}, {10, 10, 1}, {-1, 10, -1}, {-10, 10, -1}, {-11, 10, -2}} {
+		if got := floorDiv(tc.a, tc.b); got != tc.want {
+			t.Errorf("floorDiv(%d, %d) = %d, want %d", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,438 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/cor0nius/willitrain/internal/database"
+	"github.com/google/uuid"
+)
+
+// This file implements location groups: named lists of locations, such as "my cities", that a
+// subscriber stores on the server instead of keeping the list in the client. Like watchlists,
+// groups are scoped to the subscriber identified by the X-API-Key or X-Device-ID header.
+// /api/groups/currentweather returns the current weather of every location of a group in one
+// request, fetched concurrently as in /api/currentweather/batch.
+
+const (
+	// maxLocationGroups is the maximum number of groups of a subscriber.
+	maxLocationGroups = 20
+	// maxLocationGroupNameLength is the maximum length of a group name, in characters.
+	maxLocationGroupNameLength = 64
+)
+
+var (
+	// errLocationGroupLimit is returned when a subscriber already has maxLocationGroups groups.
+	errLocationGroupLimit = fmt.Errorf("at most %d groups per subscriber are allowed", maxLocationGroups)
+	// errLocationGroupFull is returned when a group already has maxBatchCities locations.
+	errLocationGroupFull = fmt.Errorf("at most %d locations per group are allowed", maxBatchCities)
+)
+
+// LocationGroupRequest is the body of a request creating a location group.
+type LocationGroupRequest struct {
+	Name   string   `json:"name"`
+	Cities []string `json:"cities"`
+}
+
+// validate trims the name and the cities of the request, removes duplicate cities and checks the
+// limits.
+func (req *LocationGroupRequest) validate() error {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if utf8.RuneCountInString(req.Name) > maxLocationGroupNameLength {
+		return fmt.Errorf("name must be at most %d characters long", maxLocationGroupNameLength)
+	}
+
+	seen := make(map[string]bool)
+	var cities []string
+	for _, city := range req.Cities {
+		city = strings.TrimSpace(city)
+		if city == "" || seen[city] {
+			continue
+		}
+		seen[city] = true
+		cities = append(cities, city)
+	}
+	if len(cities) > maxBatchCities {
+		return errLocationGroupFull
+	}
+	req.Cities = cities
+	return nil
+}
+
+// handlerLocationGroups dispatches location group requests by method: GET lists the groups with
+// their locations, POST creates one and DELETE removes one.
+
+// @Summary      Manage location groups
+// @Description  GET lists the subscriber's location groups with their locations. POST creates a group from a name
+// @Description  and an optional list of up to 20 city names. DELETE removes the group given by id. The subscriber
+// @Description  is identified by the X-API-Key or X-Device-ID header.
+// @Tags         groups
+// @Accept       json
+// @Produce      json
+// @Param        X-API-Key    header    string                false  "API key identifying the subscriber"
+// @Param        X-Device-ID  header    string                false  "Device ID identifying the subscriber"
+// @Param        id           query     string                false  "ID of the group to remove (DELETE)"
+// @Param        request      body      LocationGroupRequest  false  "Group name and city names (POST)"
+// @Success      200  {object}  LocationGroupsResponse
+// @Success      201  {object}  LocationGroupJSON
+// @Failure      400  {object}  ErrorResponse "Bad Request - Missing subscriber, invalid group or unknown city"
+// @Failure      404  {object}  ErrorResponse "Not Found - Group not found"
+// @Failure      409  {object}  ErrorResponse "Conflict - Too many groups or duplicate name"
+// @Failure      500  {object}  ErrorResponse "Internal Server Error - Failed to access groups"
+// @Router       /api/v1/groups [get]
+// @Router       /api/v1/groups [post]
+// @Router       /api/v1/groups [delete]
+func (cfg *apiConfig) handlerLocationGroups(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost && r.Method != http.MethodDelete {
+		cfg.respondWithError(w, http.StatusMethodNotAllowed, "Method Not Allowed", nil)
+		return
+	}
+
+	subscriberID, err := getSubscriberID(r)
+	if err != nil {
+		cfg.respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		cfg.listLocationGroups(w, r, subscriberID)
+	case http.MethodPost:
+		cfg.createLocationGroup(w, r, subscriberID)
+	case http.MethodDelete:
+		cfg.deleteLocationGroup(w, r, subscriberID)
+	}
+}
+
+func (cfg *apiConfig) listLocationGroups(w http.ResponseWriter, r *http.Request, subscriberID string) {
+	groups, err := cfg.dbQueries.ListLocationGroupsForSubscriber(r.Context(), subscriberID)
+	if err != nil {
+		cfg.respondWithError(w, http.StatusInternalServerError, "Failed to get groups", err)
+		return
+	}
+
+	response := LocationGroupsResponse{Groups: make([]LocationGroupJSON, len(groups))}
+	for i, g := range groups {
+		response.Groups[i], err = cfg.locationGroupToJSON(r, g)
+		if err != nil {
+			cfg.respondWithError(w, http.StatusInternalServerError, "Failed to get groups", err)
+			return
+		}
+	}
+	cfg.respondWithJSON(w, http.StatusOK, response)
+}
+
+func (cfg *apiConfig) createLocationGroup(w http.ResponseWriter, r *http.Request, subscriberID string) {
+	var req LocationGroupRequest
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4<<10))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		cfg.respondWithError(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	if err := req.validate(); err != nil {
+		cfg.respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	ctx := r.Context()
+	groups, err := cfg.dbQueries.ListLocationGroupsForSubscriber(ctx, subscriberID)
+	if err != nil {
+		cfg.respondWithError(w, http.StatusInternalServerError, "Failed to create group", err)
+		return
+	}
+	if len(groups) >= maxLocationGroups {
+		cfg.respondWithError(w, http.StatusConflict, errLocationGroupLimit.Error(), nil)
+		return
+	}
+	for _, g := range groups {
+		if g.Name == req.Name {
+			cfg.respondWithError(w, http.StatusConflict, fmt.Sprintf("a group named %q already exists", req.Name), nil)
+			return
+		}
+	}
+
+	locations := make([]Location, len(req.Cities))
+	for i, city := range req.Cities {
+		locations[i], err = cfg.getOrCreateLocation(ctx, city)
+		if err != nil {
+			cfg.respondWithError(w, http.StatusBadRequest, "Error getting location data", err)
+			return
+		}
+	}
+
+	now := time.Now().UTC()
+	var group database.LocationGroup
+	err = cfg.runInTx(ctx, func(q dbQuerier) error {
+		var err error
+		group, err = q.CreateLocationGroup(ctx, database.CreateLocationGroupParams{
+			ID:           uuid.New(),
+			SubscriberID: subscriberID,
+			Name:         req.Name,
+			CreatedAt:    now,
+		})
+		if err != nil {
+			return err
+		}
+		for i, location := range locations {
+			// Members are listed in the order they were added, so the cities keep the order
+			// of the request.
+			if err := q.AddLocationGroupMember(ctx, database.AddLocationGroupMemberParams{
+				GroupID:    group.ID,
+				LocationID: location.LocationID,
+				AddedAt:    now.Add(time.Duration(i) * time.Microsecond),
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		cfg.respondWithError(w, http.StatusInternalServerError, "Failed to create group", err)
+		return
+	}
+	cfg.logger.Debug("location group created", "subscriber", subscriberID, "name", group.Name, "locations", len(locations))
+
+	response := locationGroupJSON(group, locations)
+	cfg.respondWithJSON(w, http.StatusCreated, response)
+}
+
+func (cfg *apiConfig) deleteLocationGroup(w http.ResponseWriter, r *http.Request, subscriberID string) {
+	id, err := uuid.Parse(r.URL.Query().Get("id"))
+	if err != nil {
+		cfg.respondWithError(w, http.StatusBadRequest, "Invalid group ID", err)
+		return
+	}
+
+	deleted, err := cfg.dbQueries.DeleteLocationGroup(r.Context(), database.DeleteLocationGroupParams{
+		ID:           id,
+		SubscriberID: subscriberID,
+	})
+	if err != nil {
+		cfg.respondWithError(w, http.StatusInternalServerError, "Failed to delete group", err)
+		return
+	}
+	if deleted == 0 {
+		cfg.respondWithError(w, http.StatusNotFound, "Group not found", nil)
+		return
+	}
+	cfg.respondWithJSON(w, http.StatusOK, map[string]string{"status": "group deleted"})
+}
+
+// handlerLocationGroupLocations dispatches group membership requests by method: POST adds a
+// location to a group and DELETE removes one.
+
+// @Summary      Manage the locations of a group
+// @Description  POST adds the location given by city or lat/lon to the group given by id; a group holds up to 20
+// @Description  locations. DELETE removes the location given by location_id from the group. The subscriber is
+// @Description  identified by the X-API-Key or X-Device-ID header.
+// @Tags         groups
+// @Produce      json
+// @Param        X-API-Key    header    string  false  "API key identifying the subscriber"
+// @Param        X-Device-ID  header    string  false  "Device ID identifying the subscriber"
+// @Param        id           query     string  true   "Group ID"
+// @Param        city         query     string  false  "Location name to add (POST)"
+// @Param        lat          query     number  false  "Latitude of the location to add (POST)"
+// @Param        lon          query     number  false  "Longitude of the location to add (POST)"
+// @Param        location_id  query     string  false  "ID of the location to remove (DELETE)"
+// @Success      200  {object}  LocationGroupJSON
+// @Failure      400  {object}  ErrorResponse "Bad Request - Missing subscriber, invalid group ID or invalid location"
+// @Failure      404  {object}  ErrorResponse "Not Found - Group or location not found"
+// @Failure      409  {object}  ErrorResponse "Conflict - Group is full"
+// @Failure      500  {object}  ErrorResponse "Internal Server Error - Failed to update the group"
+// @Router       /api/v1/groups/locations [post]
+// @Router       /api/v1/groups/locations [delete]
+func (cfg *apiConfig) handlerLocationGroupLocations(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost && r.Method != http.MethodDelete {
+		cfg.respondWithError(w, http.StatusMethodNotAllowed, "Method Not Allowed", nil)
+		return
+	}
+
+	group, ok := cfg.locationGroupFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+	switch r.Method {
+	case http.MethodPost:
+		count, err := cfg.dbQueries.CountLocationGroupMembers(ctx, group.ID)
+		if err != nil {
+			cfg.respondWithError(w, http.StatusInternalServerError, "Failed to update group", err)
+			return
+		}
+		if count >= maxBatchCities {
+			cfg.respondWithError(w, http.StatusConflict, errLocationGroupFull.Error(), nil)
+			return
+		}
+		location, err := cfg.getLocationFromRequest(r)
+		if err != nil {
+			cfg.respondWithError(w, http.StatusBadRequest, "Error getting location data", err)
+			return
+		}
+		if err := cfg.dbQueries.AddLocationGroupMember(ctx, database.AddLocationGroupMemberParams{
+			GroupID:    group.ID,
+			LocationID: location.LocationID,
+			AddedAt:    time.Now().UTC(),
+		}); err != nil {
+			cfg.respondWithError(w, http.StatusInternalServerError, "Failed to update group", err)
+			return
+		}
+		cfg.logger.Debug("location added to group", "group", group.Name, "city", location.CityName)
+	case http.MethodDelete:
+		locationID, err := uuid.Parse(r.URL.Query().Get("location_id"))
+		if err != nil {
+			cfg.respondWithError(w, http.StatusBadRequest, "Invalid location ID", err)
+			return
+		}
+		removed, err := cfg.dbQueries.RemoveLocationGroupMember(ctx, database.RemoveLocationGroupMemberParams{
+			GroupID:    group.ID,
+			LocationID: locationID,
+		})
+		if err != nil {
+			cfg.respondWithError(w, http.StatusInternalServerError, "Failed to update group", err)
+			return
+		}
+		if removed == 0 {
+			cfg.respondWithError(w, http.StatusNotFound, "Location not in group", nil)
+			return
+		}
+	}
+
+	response, err := cfg.locationGroupToJSON(r, group)
+	if err != nil {
+		cfg.respondWithError(w, http.StatusInternalServerError, "Failed to get group", err)
+		return
+	}
+	cfg.respondWithJSON(w, http.StatusOK, response)
+}
+
+// @Summary      Get current weather for a location group
+// @Description  Retrieves the current weather conditions for every location of the group given by id in one
+// @Description  request. Results are listed in the order of the group's locations; locations whose weather
+// @Description  could not be fetched are listed under errors, keyed by location ID, instead. The subscriber is
+// @Description  identified by the X-API-Key or X-Device-ID header.
+// @Tags         groups
+// @Produce      json
+// @Param        X-API-Key    header    string  false  "API key identifying the subscriber"
+// @Param        X-Device-ID  header    string  false  "Device ID identifying the subscriber"
+// @Param        id           query     string  true   "Group ID"
+// @Param        units        query     string  false  "Units of measurement, 'metric' or 'imperial' (defaults to DEFAULT_UNITS)"
+// @Success      200  {object}  LocationGroupWeatherResponse
+// @Failure      400  {object}  ErrorResponse "Bad Request - Missing subscriber, invalid group ID or invalid units"
+// @Failure      404  {object}  ErrorResponse "Not Found - Group not found"
+// @Failure      500  {object}  ErrorResponse "Internal Server Error - Failed to get the group"
+// @Router       /api/v1/groups/currentweather [get]
+func (cfg *apiConfig) handlerLocationGroupCurrentWeather(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		cfg.respondWithError(w, http.StatusMethodNotAllowed, "Method Not Allowed", nil)
+		return
+	}
+
+	units, err := cfg.requestUnits(r)
+	if err != nil {
+		cfg.respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	group, ok := cfg.locationGroupFromRequest(w, r)
+	if !ok {
+		return
+	}
+	groupJSON, err := cfg.locationGroupToJSON(r, group)
+	if err != nil {
+		cfg.respondWithError(w, http.StatusInternalServerError, "Failed to get group", err)
+		return
+	}
+
+	ctx := r.Context()
+	results := make([]*CurrentWeatherResponse, len(groupJSON.Locations))
+	errs := make(map[string]string)
+	var mu sync.Mutex
+	runBatch(len(groupJSON.Locations), func(i int) {
+		location := groupJSON.Locations[i]
+		result, message, err := cfg.currentWeatherForLocation(ctx, r.URL.Path, location, units)
+		if err != nil {
+			cfg.logger.Warn("group current weather failed", "group", group.Name, "city", location.CityName, "error", err)
+			mu.Lock()
+			errs[location.LocationID.String()] = message
+			mu.Unlock()
+			return
+		}
+		results[i] = &result
+	})
+
+	response := LocationGroupWeatherResponse{
+		Group:   groupJSON,
+		Results: make([]CurrentWeatherResponse, 0, len(results)),
+		Errors:  errs,
+	}
+	for _, result := range results {
+		if result != nil {
+			response.Results = append(response.Results, *result)
+		}
+	}
+	cfg.respondWithJSON(w, http.StatusOK, withUnits(response, units))
+}
+
+// locationGroupFromRequest returns the group given by the id query parameter if it belongs to the
+// subscriber of the request. Otherwise it responds with an error and returns false.
+func (cfg *apiConfig) locationGroupFromRequest(w http.ResponseWriter, r *http.Request) (database.LocationGroup, bool) {
+	subscriberID, err := getSubscriberID(r)
+	if err != nil {
+		cfg.respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return database.LocationGroup{}, false
+	}
+
+	id, err := uuid.Parse(r.URL.Query().Get("id"))
+	if err != nil {
+		cfg.respondWithError(w, http.StatusBadRequest, "Invalid group ID", err)
+		return database.LocationGroup{}, false
+	}
+
+	group, err := cfg.dbQueries.GetLocationGroup(r.Context(), database.GetLocationGroupParams{
+		ID:           id,
+		SubscriberID: subscriberID,
+	})
+	if err == sql.ErrNoRows {
+		cfg.respondWithError(w, http.StatusNotFound, "Group not found", nil)
+		return database.LocationGroup{}, false
+	}
+	if err != nil {
+		cfg.respondWithError(w, http.StatusInternalServerError, "Failed to get group", err)
+		return database.LocationGroup{}, false
+	}
+	return group, true
+}
+
+// locationGroupToJSON loads the locations of a group and returns its API representation.
+func (cfg *apiConfig) locationGroupToJSON(r *http.Request, group database.LocationGroup) (LocationGroupJSON, error) {
+	dbLocations, err := cfg.dbQueries.ListLocationGroupMembers(r.Context(), group.ID)
+	if err != nil {
+		return LocationGroupJSON{}, fmt.Errorf("could not get locations of group %s: %w", group.ID, err)
+	}
+	locations := make([]Location, len(dbLocations))
+	for i, l := range dbLocations {
+		locations[i] = databaseLocationToLocation(l)
+	}
+	return locationGroupJSON(group, locations), nil
+}
+
+// locationGroupJSON converts a stored group and its locations to their API representation.
+func locationGroupJSON(group database.LocationGroup, locations []Location) LocationGroupJSON {
+	return LocationGroupJSON{
+		ID:        group.ID.String(),
+		Name:      group.Name,
+		CreatedAt: group.CreatedAt.UTC().Format(time.RFC3339),
+		Locations: locations,
+	}
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cor0nius/willitrain/internal/database"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestHandlerLocationGroups(t *testing.T) {
+	existing := database.LocationGroup{ID: uuid.New(), SubscriberID: "device:abc", Name: "Home", CreatedAt: time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)}
+	berlin := database.Location{ID: uuid.New(), CityName: "Berlin"}
+
+	var manyGroups []database.LocationGroup
+	for i := 0; i < maxLocationGroups; i++ {
+		manyGroups = append(manyGroups, database.LocationGroup{ID: uuid.New(), Name: fmt.Sprintf("group%d", i)})
+	}
+	var cities []string
+	for i := 0; i <= maxBatchCities; i++ {
+		cities = append(cities, fmt.Sprintf(`"city%d"`, i))
+	}
+	tooManyCities := `{"name":"Trip","cities":[` + strings.Join(cities, ",") + `]}`
+
+	testCases := []struct {
+		name        string
+		method      string
+		query       string
+		body        string
+		noHeader    bool
+		groups      []database.LocationGroup
+		wantStatus  int
+		wantBody    string
+		wantMembers []uuid.UUID
+	}{
+		{name: "List", method: http.MethodGet, groups: []database.LocationGroup{existing}, wantStatus: http.StatusOK, wantBody: `{"groups":[{"id":"` + existing.ID.String() + `","name":"Home","created_at":"2025-06-01T12:00:00Z","locations":[{"location_id":"` + MockLocation.LocationID.String() + `"`},
+		{
+			name:        "Create",
+			method:      http.MethodPost,
+			body:        `{"name":" Trip ","cities":["berlin","wroclaw","berlin"]}`,
+			groups:      []database.LocationGroup{existing},
+			wantStatus:  http.StatusCreated,
+			wantBody:    `"name":"Trip"`,
+			wantMembers: []uuid.UUID{berlin.ID, MockDBLocation.ID},
+		},
+		{name: "Create Empty", method: http.MethodPost, body: `{"name":"Later"}`, wantStatus: http.StatusCreated, wantBody: `"locations":[]`},
+		{name: "Duplicate Name", method: http.MethodPost, body: `{"name":"Home"}`, groups: []database.LocationGroup{existing}, wantStatus: http.StatusConflict, wantBody: `{"error":"a group named \"Home\" already exists"}`},
+		{name: "Too Many Groups", method: http.MethodPost, body: `{"name":"Trip"}`, groups: manyGroups, wantStatus: http.StatusConflict, wantBody: `{"error":"at most 20 groups per subscriber are allowed"}`},
+		{name: "Too Many Cities", method: http.MethodPost, body: tooManyCities, wantStatus: http.StatusBadRequest, wantBody: `{"error":"at most 20 locations per group are allowed"}`},
+		{name: "Missing Name", method: http.MethodPost, body: `{"name":"  ","cities":["berlin"]}`, wantStatus: http.StatusBadRequest, wantBody: `{"error":"name is required"}`},
+		{name: "Unknown City", method: http.MethodPost, body: `{"name":"Trip","cities":["atlantis"]}`, wantStatus: http.StatusBadRequest, wantBody: `{"error":"Error getting location data"}`},
+		{name: "Unknown Field", method: http.MethodPost, body: `{"name":"Trip","city":"berlin"}`, wantStatus: http.StatusBadRequest, wantBody: `{"error":"Invalid request body"}`},
+		{name: "Delete", method: http.MethodDelete, query: "?id=" + existing.ID.String(), wantStatus: http.StatusOK, wantBody: `{"status":"group deleted"}`},
+		{name: "Delete Not Found", method: http.MethodDelete, query: "?id=" + uuid.New().String(), wantStatus: http.StatusNotFound, wantBody: `{"error":"Group not found"}`},
+		{name: "Delete Invalid ID", method: http.MethodDelete, query: "?id=home", wantStatus: http.StatusBadRequest, wantBody: `{"error":"Invalid group ID"}`},
+		{name: "Missing Subscriber", method: http.MethodGet, noHeader: true, wantStatus: http.StatusBadRequest, wantBody: errMissingSubscriber.Error()},
+		{name: "Method Not Allowed", method: http.MethodPut, wantStatus: http.StatusMethodNotAllowed},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testCfg := newTestAPIConfig(t)
+			testCfg.mockDB.ListLocationGroupsForSubscriberFunc = func(ctx context.Context, subscriberID string) ([]database.LocationGroup, error) {
+				if subscriberID != "device:abc" {
+					t.Errorf("unexpected subscriber ID: %s", subscriberID)
+				}
+				return tc.groups, nil
+			}
+			testCfg.mockDB.ListLocationGroupMembersFunc = func(ctx context.Context, groupID uuid.UUID) ([]database.Location, error) {
+				return []database.Location{MockDBLocation}, nil
+			}
+			testCfg.mockDB.GetLocationByAliasFunc = func(ctx context.Context, alias string) (database.Location, error) {
+				switch alias {
+				case "wroclaw":
+					return MockDBLocation, nil
+				case "berlin":
+					return berlin, nil
+				}
+				return database.Location{}, sql.ErrNoRows
+			}
+			testCfg.mockGeo.GeocodeFunc = func(cityName string) (Location, error) {
+				return Location{}, errors.New("city not found")
+			}
+			testCfg.mockDB.CreateLocationGroupFunc = func(ctx context.Context, arg database.CreateLocationGroupParams) (database.LocationGroup, error) {
+				return database.LocationGroup{ID: arg.ID, SubscriberID: arg.SubscriberID, Name: arg.Name, CreatedAt: arg.CreatedAt}, nil
+			}
+			var members []uuid.UUID
+			var lastAdded time.Time
+			testCfg.mockDB.AddLocationGroupMemberFunc = func(ctx context.Context, arg database.AddLocationGroupMemberParams) error {
+				if !arg.AddedAt.After(lastAdded) {
+					t.Errorf("members must be added in order, got %v after %v", arg.AddedAt, lastAdded)
+				}
+				lastAdded = arg.AddedAt
+				members = append(members, arg.LocationID)
+				return nil
+			}
+			testCfg.mockDB.DeleteLocationGroupFunc = func(ctx context.Context, arg database.DeleteLocationGroupParams) (int64, error) {
+				if arg.ID == existing.ID && arg.SubscriberID == "device:abc" {
+					return 1, nil
+				}
+				return 0, nil
+			}
+
+			req := httptest.NewRequest(tc.method, "/api/v1/groups"+tc.query, strings.NewReader(tc.body))
+			if !tc.noHeader {
+				req.Header.Set("X-Device-ID", "abc")
+			}
+			rr := httptest.NewRecorder()
+			testCfg.apiConfig.handlerLocationGroups(rr, req)
+
+			if rr.Code != tc.wantStatus {
+				t.Fatalf("status = %d, want %d; body: %s", rr.Code, tc.wantStatus, rr.Body.String())
+			}
+			if !strings.Contains(rr.Body.String(), tc.wantBody) {
+				t.Errorf("body = %s, want it to contain %s", rr.Body.String(), tc.wantBody)
+			}
+			if fmt.Sprint(members) != fmt.Sprint(tc.wantMembers) {
+				t.Errorf("added members %v, want %v", members, tc.wantMembers)
+			}
+		})
+	}
+}
+
+func TestHandlerLocationGroupLocations(t *testing.T) {
+	group := database.LocationGroup{ID: uuid.New(), SubscriberID: "device:abc", Name: "Home"}
+
+	testCases := []struct {
+		name       string
+		method     string
+		query      string
+		members    int64
+		wantStatus int
+		wantBody   string
+		wantAdded  bool
+	}{
+		{name: "Add", method: http.MethodPost, query: "?id=" + group.ID.String() + "&city=wroclaw", members: 1, wantStatus: http.StatusOK, wantBody: `"name":"Home"`, wantAdded: true},
+		{name: "Group Full", method: http.MethodPost, query: "?id=" + group.ID.String() + "&city=wroclaw", members: maxBatchCities, wantStatus: http.StatusConflict, wantBody: `{"error":"at most 20 locations per group are allowed"}`},
+		{name: "Add Without Location", method: http.MethodPost, query: "?id=" + group.ID.String(), wantStatus: http.StatusBadRequest, wantBody: `{"error":"Error getting location data"}`},
+		{name: "Remove", method: http.MethodDelete, query: "?id=" + group.ID.String() + "&location_id=" + MockLocation.LocationID.String(), wantStatus: http.StatusOK, wantBody: `"locations":[`},
+		{name: "Remove Not In Group", method: http.MethodDelete, query: "?id=" + group.ID.String() + "&location_id=" + uuid.New().String(), wantStatus: http.StatusNotFound, wantBody: `{"error":"Location not in group"}`},
+		{name: "Remove Invalid Location ID", method: http.MethodDelete, query: "?id=" + group.ID.String() + "&location_id=x", wantStatus: http.StatusBadRequest, wantBody: `{"error":"Invalid location ID"}`},
+		{name: "Other Subscriber's Group", method: http.MethodPost, query: "?id=" + uuid.New().String() + "&city=wroclaw", wantStatus: http.StatusNotFound, wantBody: `{"error":"Group not found"}`},
+		{name: "Method Not Allowed", method: http.MethodGet, query: "?id=" + group.ID.String(), wantStatus: http.StatusMethodNotAllowed},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testCfg := newTestAPIConfig(t)
+			testCfg.mockDB.GetLocationGroupFunc = func(ctx context.Context, arg database.GetLocationGroupParams) (database.LocationGroup, error) {
+				if arg.ID == group.ID && arg.SubscriberID == group.SubscriberID {
+					return group, nil
+				}
+				return database.LocationGroup{}, sql.ErrNoRows
+			}
+			testCfg.mockDB.CountLocationGroupMembersFunc = func(ctx context.Context, groupID uuid.UUID) (int64, error) {
+				return tc.members, nil
+			}
+			testCfg.mockDB.GetLocationByAliasFunc = func(ctx context.Context, alias string) (database.Location, error) {
+				return MockDBLocation, nil
+			}
+			added := false
+			testCfg.mockDB.AddLocationGroupMemberFunc = func(ctx context.Context, arg database.AddLocationGroupMemberParams) error {
+				if arg.GroupID != group.ID || arg.LocationID != MockDBLocation.ID {
+					t.Errorf("unexpected member: %+v", arg)
+				}
+				added = true
+				return nil
+			}
+			testCfg.mockDB.RemoveLocationGroupMemberFunc = func(ctx context.Context, arg database.RemoveLocationGroupMemberParams) (int64, error) {
+				if arg.LocationID == MockDBLocation.ID {
+					return 1, nil
+				}
+				return 0, nil
+			}
+			testCfg.mockDB.ListLocationGroupMembersFunc = func(ctx context.Context, groupID uuid.UUID) ([]database.Location, error) {
+				return []database.Location{MockDBLocation}, nil
+			}
+
+			req := httptest.NewRequest(tc.method, "/api/v1/groups/locations"+tc.query, nil)
+			req.Header.Set("X-Device-ID", "abc")
+			rr := httptest.NewRecorder()
+			testCfg.apiConfig.handlerLocationGroupLocations(rr, req)
+
+			if rr.Code != tc.wantStatus {
+				t.Fatalf("status = %d, want %d; body: %s", rr.Code, tc.wantStatus, rr.Body.String())
+			}
+			if !strings.Contains(rr.Body.String(), tc.wantBody) {
+				t.Errorf("body = %s, want it to contain %s", rr.Body.String(), tc.wantBody)
+			}
+			if added != tc.wantAdded {
+				t.Errorf("added = %v, want %v", added, tc.wantAdded)
+			}
+		})
+	}
+}
+
+func TestHandlerLocationGroupCurrentWeather(t *testing.T) {
+	group := database.LocationGroup{ID: uuid.New(), SubscriberID: "device:abc", Name: "Home"}
+	berlin := database.Location{ID: uuid.New(), CityName: "Berlin", Timezone: sql.NullString{String: "Europe/Berlin", Valid: true}}
+	prague := database.Location{ID: uuid.New(), CityName: "Prague", Timezone: sql.NullString{String: "Europe/Prague", Valid: true}}
+
+	testCases := []struct {
+		name        string
+		query       string
+		wantStatus  int
+		wantResults []string
+		wantBody    string
+	}{
+		{name: "Metric", query: "?id=" + group.ID.String(), wantStatus: http.StatusOK, wantResults: []string{"Berlin", "Wroclaw"}, wantBody: `"temperature_c"`},
+		{name: "Imperial", query: "?id=" + group.ID.String() + "&units=imperial", wantStatus: http.StatusOK, wantResults: []string{"Berlin", "Wroclaw"}, wantBody: `"temperature_f"`},
+		{name: "Invalid Units", query: "?id=" + group.ID.String() + "&units=kelvin", wantStatus: http.StatusBadRequest},
+		{name: "Not Found", query: "?id=" + uuid.New().String(), wantStatus: http.StatusNotFound},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testCfg := newTestAPIConfig(t)
+			testCfg.apiConfig.enabledSources = map[string]bool{"gmp": true, "owm": true, "ometeo": true}
+			testCfg.mockDB.GetLocationGroupFunc = func(ctx context.Context, arg database.GetLocationGroupParams) (database.LocationGroup, error) {
+				if arg.ID == group.ID && arg.SubscriberID == group.SubscriberID {
+					return group, nil
+				}
+				return database.LocationGroup{}, sql.ErrNoRows
+			}
+			testCfg.mockDB.ListLocationGroupMembersFunc = func(ctx context.Context, groupID uuid.UUID) ([]database.Location, error) {
+				return []database.Location{berlin, prague, MockDBLocation}, nil
+			}
+			testCfg.mockCache.GetFunc = func(ctx context.Context, key string) (string, error) {
+				return "", redis.Nil
+			}
+			testCfg.mockCache.SetFunc = func(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+				return nil
+			}
+			testCfg.mockDB.GetCurrentWeatherAtLocationFunc = func(ctx context.Context, locationID uuid.UUID) ([]database.CurrentWeather, error) {
+				if locationID == prague.ID {
+					return nil, errors.New("db error")
+				}
+				weather := []database.CurrentWeather{MockDBCurrentWeather1, MockDBCurrentWeather2, MockDBCurrentWeather3}
+				for i := range weather {
+					weather[i].LocationID = locationID
+				}
+				return weather, nil
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/groups/currentweather"+tc.query, nil)
+			req.Header.Set("X-Device-ID", "abc")
+			rr := httptest.NewRecorder()
+			testCfg.apiConfig.handlerLocationGroupCurrentWeather(rr, req)
+
+			if rr.Code != tc.wantStatus {
+				t.Fatalf("status = %d, want %d; body: %s", rr.Code, tc.wantStatus, rr.Body.String())
+			}
+			if tc.wantStatus != http.StatusOK {
+				return
+			}
+			if !strings.Contains(rr.Body.String(), tc.wantBody) {
+				t.Errorf("body = %s, want it to contain %s", rr.Body.String(), tc.wantBody)
+			}
+			var response struct {
+				Group   LocationGroupJSON `json:"group"`
+				Results []struct {
+					Location Location `json:"location"`
+				} `json:"results"`
+				Errors map[string]string `json:"errors"`
+			}
+			if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			var got []string
+			for _, result := range response.Results {
+				got = append(got, result.Location.CityName)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tc.wantResults) {
+				t.Errorf("results = %v, want %v in group order", got, tc.wantResults)
+			}
+			if len(response.Group.Locations) != 3 || response.Errors[prague.ID.String()] == "" || len(response.Errors) != 1 {
+				t.Errorf("expected the failed location to be reported, got %+v", response)
+			}
+		})
+	}
+}
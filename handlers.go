@@ -1,10 +1,16 @@
 package main
 
 import (
+	"context"
+	"database/sql"
+	"fmt"
 	"net/http"
 	"sort"
+	"strconv"
 	"sync"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 // This file contains the main HTTP handlers for the application. Each handler is responsible
@@ -23,16 +29,23 @@ import (
 // @Summary      Get current weather
 // @Description  Retrieves the current weather conditions for a specified location.
 // @Description  The location can be identified by its name, or by latitude and longitude.
+// @Description  With compare=age, each source is annotated with the minutes since its observation
+// @Description  and sources are ordered from freshest to oldest.
 // @Tags         weather
 // @Accept       json
 // @Produce      json
-// @Param        city query     string  false  "Location name to search for (e.g., 'London')"
-// @Param        lat  query     number  false  "Latitude for the location (e.g., 51.5074)"
-// @Param        lon  query     number  false  "Longitude for the location (e.g., -0.1278)"
+// @Param        city    query     string  false  "Location name to search for (e.g., 'London')"
+// @Param        lat     query     number  false  "Latitude for the location (e.g., 51.5074)"
+// @Param        lon     query     number  false  "Longitude for the location (e.g., -0.1278)"
+// @Param        compare query     string  false  "Comparison mode; 'age' annotates and orders sources by freshness"
+// @Param        units   query     string  false  "Units of measurement, 'metric' or 'imperial' (defaults to DEFAULT_UNITS)"
+// @Param        fields query    string  false  "Comma-separated fields of each entry to return (e.g., 'temperature_c,precipitation_chance')"
+// @Param        If-None-Match  header  string  false  "ETag of a previous response"
 // @Success      200  {object}  CurrentWeatherResponse
+// @Success      304  {string}  string  "Not Modified - The data has not changed since the given ETag or If-Modified-Since"
 // @Failure      400  {object}  ErrorResponse "Bad Request - Invalid location parameters"
 // @Failure      500  {object}  ErrorResponse "Internal Server Error - Failed to retrieve weather data"
-// @Router       /api/currentweather [get]
+// @Router       /api/v1/currentweather [get]
 func (cfg *apiConfig) handlerCurrentWeather(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	if r.Method != http.MethodGet {
@@ -40,55 +53,226 @@ func (cfg *apiConfig) handlerCurrentWeather(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	var compareByAge bool
+	switch compare := r.URL.Query().Get("compare"); compare {
+	case "":
+	case "age":
+		compareByAge = true
+	default:
+		cfg.respondWithError(w, http.StatusBadRequest, "Invalid compare mode", fmt.Errorf("unknown compare mode %q", compare))
+		return
+	}
+
+	units, err := cfg.requestUnits(r)
+	if err != nil {
+		cfg.respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
 	location, err := cfg.getLocationFromRequest(r)
 	if err != nil {
 		cfg.respondWithError(w, http.StatusBadRequest, "Error getting location data", err)
 		return
 	}
-	cfg.logger.Debug("current weather request", "city", location.CityName)
+	cfg.logger.DebugContext(ctx, "current weather request", "city", location.CityName)
 
-	weather, err := cfg.getCachedOrFetchCurrentWeather(ctx, location)
+	response, updatedAt, err := cfg.currentWeatherResponse(ctx, location, units, compareByAge)
 	if err != nil {
 		cfg.respondWithError(w, http.StatusInternalServerError, "Error getting current weather data", err)
 		return
 	}
 
+	cfg.respondWithConditionalJSON(w, r, withUnits(response, units), updatedAt, weatherCacheTTL)
+}
+
+// currentWeatherResponse fetches the current weather at a location and formats it in the given
+// units. With compareByAge, sources are annotated with the age of their observation and ordered
+// from freshest to oldest. It also returns when the newest of the data was fetched.
+func (cfg *apiConfig) currentWeatherResponse(ctx context.Context, location Location, units unitSystem, compareByAge bool) (CurrentWeatherResponse, time.Time, error) {
+	ctx, fetchErrs := withProviderFetchErrors(ctx)
+	weather, err := cfg.getCachedOrFetchCurrentWeather(ctx, location)
+	if err != nil {
+		return CurrentWeatherResponse{}, time.Time{}, err
+	}
+	// The latest personal weather station reading is listed with the providers. It is read
+	// separately, as the cached and stored current weather only holds the providers' data.
+	if len(cfg.weatherStations) > 0 {
+		station, ok, err := cfg.localStationWeather(ctx, location)
+		if err != nil {
+			cfg.logger.WarnContext(ctx, "could not get local station weather", "location_id", location.LocationID, "error", err)
+		} else if ok {
+			weather = append(weather[:len(weather):len(weather)], station)
+		}
+	}
+
+	// In age comparison mode the freshest observation comes first; otherwise sources are
+	// listed chronologically.
 	sort.Slice(weather, func(i, j int) bool {
 		if weather[i].Timestamp.Equal(weather[j].Timestamp) {
 			return weather[i].SourceAPI < weather[j].SourceAPI
 		}
+		if compareByAge {
+			return weather[i].Timestamp.After(weather[j].Timestamp)
+		}
 		return weather[i].Timestamp.Before(weather[j].Timestamp)
 	})
 
 	loc, err := time.LoadLocation(location.Timezone)
 	if err != nil {
-		cfg.logger.Warn("could not load location timezone, falling back to UTC", "timezone", location.Timezone, "error", err)
+		cfg.logger.WarnContext(ctx, "could not load location timezone, falling back to UTC", "timezone", location.Timezone, "error", err)
 		loc = time.UTC
 	}
 
+	now := time.Now()
 	weatherJSON := make([]CurrentWeatherJSON, len(weather))
 	for i, w := range weather {
-		weatherJSON[i] = CurrentWeatherJSON{
-			SourceAPI:     w.SourceAPI,
-			Timestamp:     w.Timestamp.In(loc).Format("2006-01-02 15:04"),
-			Temperature:   w.Temperature,
-			Humidity:      w.Humidity,
-			WindSpeed:     w.WindSpeed,
-			Precipitation: w.Precipitation,
-			Condition:     w.Condition,
+		weatherJSON[i] = currentWeatherJSON(w, loc, units)
+		if compareByAge {
+			minutes := max(int(now.Sub(w.Timestamp).Minutes()), 0)
+			weatherJSON[i].MinutesSinceObservation = &minutes
 		}
 	}
 
-	response := CurrentWeatherResponse{
-		Location: location,
-		Weather:  weatherJSON,
+	sources := make([]string, len(weather))
+	for i, w := range weather {
+		sources[i] = w.SourceAPI
 	}
 
-	cfg.respondWithJSON(w, http.StatusOK, response)
+	providers, partial := cfg.providerStatuses(sources, fetchErrs)
+	return CurrentWeatherResponse{
+		Location:    location,
+		Weather:     weatherJSON,
+		Partial:     partial,
+		Providers:   providers,
+		Attribution: attributionForSources(sources),
+	}, latestTimestamp(weather), nil
+}
+
+// formatSunEvent formats a sunrise or sunset as a local time of day, or returns an empty string
+// if the source did not report it.
+func formatSunEvent(t time.Time, loc *time.Location) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.In(loc).Format("15:04")
+}
+
+// currentWeatherJSON formats the current weather reported by one source in the given units and
+// the timezone of the location.
+func currentWeatherJSON(w CurrentWeather, loc *time.Location, units unitSystem) CurrentWeatherJSON {
+	weather := CurrentWeatherJSON{
+		SourceAPI:           w.SourceAPI,
+		Timestamp:           w.Timestamp.In(loc).Format("2006-01-02 15:04"),
+		ObservedAtLocal:     w.Timestamp.In(loc).Format("15:04"),
+		Temperature:         units.temperature(w.Temperature),
+		ApparentTemperature: units.optionalTemperature(w.ApparentTemperature),
+		DewPoint:            units.optionalTemperature(w.DewPoint),
+		Humidity:            w.Humidity,
+		WindSpeed:           units.windSpeed(w.WindSpeed),
+		WindDirection:       w.WindDirection,
+		WindCompass:         compassDirection(w.WindDirection),
+		WindGust:            units.windGust(w.WindGust),
+		Precipitation:       units.precipitation(w.Precipitation),
+		Rain:                units.optionalPrecipitation(w.Rain),
+		Snow:                units.optionalPrecipitation(w.Snow),
+		Condition:           w.Condition,
+		UVIndex:             w.UVIndex,
+		Sunrise:             formatSunEvent(w.Sunrise, loc),
+		Sunset:              formatSunEvent(w.Sunset, loc),
+	}
+	weather.ConditionCode = normalizeCondition(w.Condition)
+	weather.Compact = compactFor(weather.ConditionCode, formatCompactTemp(weather.Temperature, units))
+	return weather
+}
+
+// dailyForecastJSON formats a daily forecast in the given units and the timezone of the location.
+func dailyForecastJSON(f DailyForecast, loc *time.Location, units unitSystem) DailyForecastJSON {
+	forecast := DailyForecastJSON{
+		SourceAPI:           f.SourceAPI,
+		ForecastDate:        f.ForecastDate.In(loc).Format("2006-01-02"),
+		MinTemp:             units.temperature(f.MinTemp),
+		MaxTemp:             units.temperature(f.MaxTemp),
+		Precipitation:       units.precipitation(f.Precipitation),
+		Rain:                units.optionalPrecipitation(f.Rain),
+		Snow:                units.optionalPrecipitation(f.Snow),
+		PrecipitationChance: f.PrecipitationChance,
+		WindSpeed:           units.windSpeed(f.WindSpeed),
+		WindDirection:       f.WindDirection,
+		WindCompass:         compassDirection(f.WindDirection),
+		WindGust:            units.windGust(f.WindGust),
+		Humidity:            f.Humidity,
+		ConditionCode:       dailyConditionCode(f),
+		UVIndex:             f.UVIndex,
+		Sunrise:             formatSunEvent(f.Sunrise, loc),
+		Sunset:              formatSunEvent(f.Sunset, loc),
+		Parts: DayPartsJSON{
+			Morning:   dayPartJSON(f.Morning, units),
+			Afternoon: dayPartJSON(f.Afternoon, units),
+			Evening:   dayPartJSON(f.Evening, units),
+			Night:     dayPartJSON(f.Night, units),
+		},
+	}
+	forecast.Compact = compactFor(forecast.ConditionCode, formatCompactTempRange(forecast.MinTemp, forecast.MaxTemp, units))
+	return forecast
+}
+
+// dayPartJSON formats a part of the day of a daily forecast in the given units.
+func dayPartJSON(p DayPart, units unitSystem) DayPartJSON {
+	return DayPartJSON{
+		Temperature:         units.optionalTemperature(p.Temperature),
+		PrecipitationChance: p.PrecipitationChance,
+		Condition:           p.Condition,
+	}
+}
+
+// hourlyForecastJSON formats an hourly forecast in the given units and the timezone of the location.
+func hourlyForecastJSON(f HourlyForecast, loc *time.Location, units unitSystem) HourlyForecastJSON {
+	forecast := HourlyForecastJSON{
+		SourceAPI:           f.SourceAPI,
+		ForecastDateTime:    f.ForecastDateTime.In(loc).Format("2006-01-02 15:04"),
+		Temperature:         units.temperature(f.Temperature),
+		ApparentTemperature: units.optionalTemperature(f.ApparentTemperature),
+		DewPoint:            units.optionalTemperature(f.DewPoint),
+		Humidity:            f.Humidity,
+		WindSpeed:           units.windSpeed(f.WindSpeed),
+		WindDirection:       f.WindDirection,
+		WindCompass:         compassDirection(f.WindDirection),
+		WindGust:            units.windGust(f.WindGust),
+		Precipitation:       units.precipitation(f.Precipitation),
+		Rain:                units.optionalPrecipitation(f.Rain),
+		Snow:                units.optionalPrecipitation(f.Snow),
+		PrecipitationChance: f.PrecipitationChance,
+		Condition:           f.Condition,
+		ConditionCode:       normalizeCondition(f.Condition),
+	}
+	forecast.Compact = compactFor(forecast.ConditionCode, formatCompactTemp(forecast.Temperature, units))
+	return forecast
+}
+
+// sortDailyForecast orders daily forecasts by date, and forecasts for the same date by source.
+func sortDailyForecast(forecast []DailyForecast) {
+	sort.Slice(forecast, func(i, j int) bool {
+		if forecast[i].ForecastDate.Equal(forecast[j].ForecastDate) {
+			return forecast[i].SourceAPI < forecast[j].SourceAPI
+		}
+		return forecast[i].ForecastDate.Before(forecast[j].ForecastDate)
+	})
+}
+
+// sortHourlyForecast orders hourly forecasts by time, and forecasts for the same hour by source.
+func sortHourlyForecast(forecast []HourlyForecast) {
+	sort.Slice(forecast, func(i, j int) bool {
+		if forecast[i].ForecastDateTime.Equal(forecast[j].ForecastDateTime) {
+			return forecast[i].SourceAPI < forecast[j].SourceAPI
+		}
+		return forecast[i].ForecastDateTime.Before(forecast[j].ForecastDateTime)
+	})
 }
 
 // @Summary      Get daily forecast
-// @Description  Retrieves the weather forecast for the next 5 days for a specified location.
+// @Description  Retrieves the daily weather forecast for a specified location, for the next 5 days unless
+// @Description  FORECAST_DAILY_DAYS configures another horizon. A shorter horizon can be requested with days.
+// @Description  Each day is broken down into morning, afternoon, evening and night where the source reports them.
 // @Description  The location can be identified by its name, or by latitude and longitude.
 // @Tags         weather
 // @Accept       json
@@ -96,10 +280,15 @@ func (cfg *apiConfig) handlerCurrentWeather(w http.ResponseWriter, r *http.Reque
 // @Param        city query     string  false  "Location name to search for (e.g., 'London')"
 // @Param        lat  query     number  false  "Latitude for the location (e.g., 51.5074)"
 // @Param        lon  query     number  false  "Longitude for the location (e.g., -0.1278)"
+// @Param        units query    string  false  "Units of measurement, 'metric' or 'imperial' (defaults to DEFAULT_UNITS)"
+// @Param        days query     int     false  "Number of days, between 1 and FORECAST_DAILY_DAYS (defaults to FORECAST_DAILY_DAYS)"
+// @Param        fields query    string  false  "Comma-separated fields of each entry to return (e.g., 'temperature_c,precipitation_chance')"
+// @Param        If-None-Match  header  string  false  "ETag of a previous response"
 // @Success      200  {object}  DailyForecastsResponse
+// @Success      304  {string}  string  "Not Modified - The data has not changed since the given ETag or If-Modified-Since"
 // @Failure      400  {object}  ErrorResponse "Bad Request - Invalid location parameters"
 // @Failure      500  {object}  ErrorResponse "Internal Server Error - Failed to retrieve forecast data"
-// @Router       /api/dailyforecast [get]
+// @Router       /api/v1/dailyforecast [get]
 func (cfg *apiConfig) handlerDailyForecast(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	if r.Method != http.MethodGet {
@@ -107,67 +296,89 @@ func (cfg *apiConfig) handlerDailyForecast(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	units, err := cfg.requestUnits(r)
+	if err != nil {
+		cfg.respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	days, err := requestHorizon(r, "days", cfg.dailyForecastDays())
+	if err != nil {
+		cfg.respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
 	location, err := cfg.getLocationFromRequest(r)
 	if err != nil {
 		cfg.respondWithError(w, http.StatusBadRequest, "Error getting location data", err)
 		return
 	}
-	cfg.logger.Debug("daily forecast request", "city", location.CityName)
+	cfg.logger.DebugContext(ctx, "daily forecast request", "city", location.CityName)
 
+	ctx, fetchErrs := withProviderFetchErrors(ctx)
 	forecast, err := cfg.getCachedOrFetchDailyForecast(ctx, location)
 	if err != nil {
 		cfg.respondWithError(w, http.StatusInternalServerError, "Error getting daily forecast data", err)
 		return
 	}
+	if days < cfg.dailyForecastDays() {
+		forecast = limitDailyForecast(forecast, days)
+	}
 
-	sort.Slice(forecast, func(i, j int) bool {
-		if forecast[i].ForecastDate.Equal(forecast[j].ForecastDate) {
-			return forecast[i].SourceAPI < forecast[j].SourceAPI
-		}
-		return forecast[i].ForecastDate.Before(forecast[j].ForecastDate)
-	})
+	sortDailyForecast(forecast)
 
 	loc, err := time.LoadLocation(location.Timezone)
 	if err != nil {
-		cfg.logger.Warn("could not load location timezone, falling back to UTC", "timezone", location.Timezone, "error", err)
+		cfg.logger.WarnContext(ctx, "could not load location timezone, falling back to UTC", "timezone", location.Timezone, "error", err)
 		loc = time.UTC
 	}
 
 	forecastsJSON := make([]DailyForecastJSON, len(forecast))
 	for i, f := range forecast {
-		forecastsJSON[i] = DailyForecastJSON{
-			SourceAPI:           f.SourceAPI,
-			ForecastDate:        f.ForecastDate.In(loc).Format("2006-01-02"),
-			MinTemp:             f.MinTemp,
-			MaxTemp:             f.MaxTemp,
-			Precipitation:       f.Precipitation,
-			PrecipitationChance: f.PrecipitationChance,
-			WindSpeed:           f.WindSpeed,
-			Humidity:            f.Humidity,
-		}
+		forecastsJSON[i] = dailyForecastJSON(f, loc, units)
 	}
 
+	sources := make([]string, len(forecastsJSON))
+	for i, f := range forecastsJSON {
+		sources[i] = f.SourceAPI
+	}
+
+	providers, partial := cfg.providerStatuses(sources, fetchErrs)
 	response := DailyForecastsResponse{
-		Location:  location,
-		Forecasts: forecastsJSON,
+		Location:    location,
+		Forecasts:   forecastsJSON,
+		Partial:     partial,
+		Providers:   providers,
+		Attribution: attributionForSources(sources),
 	}
 
-	cfg.respondWithJSON(w, http.StatusOK, response)
+	cfg.respondWithConditionalJSON(w, r, withUnits(response, units), latestTimestamp(forecast), dailyForecastCacheTTL)
 }
 
 // @Summary      Get hourly forecast
-// @Description  Retrieves the weather forecast for the next 24 hours for a specified location.
+// @Description  Retrieves the hourly weather forecast for a specified location, for the next 24 hours unless
+// @Description  FORECAST_HOURLY_HOURS configures another horizon. A shorter horizon can be requested with hours,
+// @Description  and a window of it with from, to and limit, e.g. limit=6 for the next 6 hours.
 // @Description  The location can be identified by its name, or by latitude and longitude.
+// @Description  The response lists condition transitions (e.g. cloudy to rain at 14:00) per source and for the consensus.
 // @Tags         weather
 // @Accept       json
 // @Produce      json
 // @Param        city query     string  false  "Location name to search for (e.g., 'London')"
 // @Param        lat  query     number  false  "Latitude for the location (e.g., 51.5074)"
 // @Param        lon  query     number  false  "Longitude for the location (e.g., -0.1278)"
+// @Param        units query    string  false  "Units of measurement, 'metric' or 'imperial' (defaults to DEFAULT_UNITS)"
+// @Param        hours query    int     false  "Number of hours, between 1 and FORECAST_HOURLY_HOURS (defaults to FORECAST_HOURLY_HOURS)"
+// @Param        from  query    string  false  "Start of the window as an RFC 3339 timestamp or a date in the location's timezone; the forecast of the hour it falls in is included (defaults to now)"
+// @Param        to    query    string  false  "End of the window, exclusive, as an RFC 3339 timestamp or a date in the location's timezone (defaults to the end of the horizon)"
+// @Param        limit query    int     false  "Maximum number of forecast hours, between 1 and the number of hours (defaults to all)"
+// @Param        fields query    string  false  "Comma-separated fields of each entry to return (e.g., 'temperature_c,precipitation_chance')"
+// @Param        If-None-Match  header  string  false  "ETag of a previous response"
 // @Success      200  {object}  HourlyForecastsResponse
-// @Failure      400  {object}  ErrorResponse "Bad Request - Invalid location parameters"
+// @Success      304  {string}  string  "Not Modified - The data has not changed since the given ETag or If-Modified-Since"
+// @Failure      400  {object}  ErrorResponse "Bad Request - Invalid location or window parameters"
 // @Failure      500  {object}  ErrorResponse "Internal Server Error - Failed to retrieve forecast data"
-// @Router       /api/hourlyforecast [get]
+// @Router       /api/v1/hourlyforecast [get]
 func (cfg *apiConfig) handlerHourlyForecast(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	if r.Method != http.MethodGet {
@@ -175,52 +386,102 @@ func (cfg *apiConfig) handlerHourlyForecast(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	location, err := cfg.getLocationFromRequest(r)
+	units, err := cfg.requestUnits(r)
 	if err != nil {
-		cfg.respondWithError(w, http.StatusBadRequest, "Error getting location data", err)
+		cfg.respondWithError(w, http.StatusBadRequest, err.Error(), nil)
 		return
 	}
-	cfg.logger.Debug("hourly forecast request", "city", location.CityName)
 
-	forecast, err := cfg.getCachedOrFetchHourlyForecast(ctx, location)
+	hours, err := requestHorizon(r, "hours", cfg.hourlyForecastHours())
 	if err != nil {
-		cfg.respondWithError(w, http.StatusInternalServerError, "Error getting hourly forecast data", err)
+		cfg.respondWithError(w, http.StatusBadRequest, err.Error(), nil)
 		return
 	}
 
-	sort.Slice(forecast, func(i, j int) bool {
-		if forecast[i].ForecastDateTime.Equal(forecast[j].ForecastDateTime) {
-			return forecast[i].SourceAPI < forecast[j].SourceAPI
-		}
-		return forecast[i].ForecastDateTime.Before(forecast[j].ForecastDateTime)
-	})
+	location, err := cfg.getLocationFromRequest(r)
+	if err != nil {
+		cfg.respondWithError(w, http.StatusBadRequest, "Error getting location data", err)
+		return
+	}
+	cfg.logger.DebugContext(ctx, "hourly forecast request", "city", location.CityName)
 
 	loc, err := time.LoadLocation(location.Timezone)
 	if err != nil {
-		cfg.logger.Warn("could not load location timezone, falling back to UTC", "timezone", location.Timezone, "error", err)
+		cfg.logger.WarnContext(ctx, "could not load location timezone, falling back to UTC", "timezone", location.Timezone, "error", err)
 		loc = time.UTC
 	}
 
+	// A window given with from, to or limit is selected by the database query.
+	query := r.URL.Query()
+	windowed := query.Has("from") || query.Has("to") || query.Has("limit")
+	var window hourlyWindow
+	if windowed {
+		now := time.Now()
+		from, err := parseHistoryTime(query.Get("from"), loc, now)
+		if err != nil {
+			cfg.respondWithError(w, http.StatusBadRequest, "Invalid from parameter", err)
+			return
+		}
+		to, err := parseHistoryTime(query.Get("to"), loc, now.Add(time.Duration(hours)*time.Hour))
+		if err != nil {
+			cfg.respondWithError(w, http.StatusBadRequest, "Invalid to parameter", err)
+			return
+		}
+		if !from.Before(to) {
+			cfg.respondWithError(w, http.StatusBadRequest, "from must be before to", nil)
+			return
+		}
+		limit, err := parseStatsParam(r, "limit", hours, hours)
+		if err != nil {
+			cfg.respondWithError(w, http.StatusBadRequest, "Invalid limit", err)
+			return
+		}
+		window = newHourlyWindow(from, to, limit, hours, now)
+	}
+
+	ctx, fetchErrs := withProviderFetchErrors(ctx)
+	var forecast []HourlyForecast
+	if windowed {
+		forecast, err = cfg.getHourlyForecastWindow(ctx, location, window)
+	} else {
+		forecast, err = cfg.getCachedOrFetchHourlyForecast(ctx, location)
+	}
+	if err != nil {
+		cfg.respondWithError(w, http.StatusInternalServerError, "Error getting hourly forecast data", err)
+		return
+	}
+	if !windowed && hours < cfg.hourlyForecastHours() {
+		forecast = limitHourlyForecast(forecast, hours, time.Now())
+	}
+
+	sortHourlyForecast(forecast)
+
 	forecastsJSON := make([]HourlyForecastJSON, len(forecast))
 	for i, f := range forecast {
-		forecastsJSON[i] = HourlyForecastJSON{
-			SourceAPI:           f.SourceAPI,
-			ForecastDateTime:    f.ForecastDateTime.In(loc).Format("2006-01-02 15:04"),
-			Temperature:         f.Temperature,
-			Humidity:            f.Humidity,
-			WindSpeed:           f.WindSpeed,
-			Precipitation:       f.Precipitation,
-			PrecipitationChance: f.PrecipitationChance,
-			Condition:           f.Condition,
-		}
+		forecastsJSON[i] = hourlyForecastJSON(f, loc, units)
 	}
 
+	sources := make([]string, len(forecastsJSON))
+	for i, f := range forecastsJSON {
+		sources[i] = f.SourceAPI
+	}
+
+	transitions := hourlyTransitions(forecastsJSON)
+	if transitions == nil {
+		transitions = []ConditionTransitionJSON{}
+	}
+
+	providers, partial := cfg.providerStatuses(sources, fetchErrs)
 	response := HourlyForecastsResponse{
-		Location:  location,
-		Forecasts: forecastsJSON,
+		Location:    location,
+		Forecasts:   forecastsJSON,
+		Transitions: transitions,
+		Partial:     partial,
+		Providers:   providers,
+		Attribution: attributionForSources(sources),
 	}
 
-	cfg.respondWithJSON(w, http.StatusOK, response)
+	cfg.respondWithConditionalJSON(w, r, withUnits(response, units), latestTimestamp(forecast), hourlyForecastCacheTTL)
 }
 
 // handlerResetDB is a development-only endpoint that completely wipes the database and the Redis cache.
@@ -234,13 +495,16 @@ func (cfg *apiConfig) handlerHourlyForecast(w http.ResponseWriter, r *http.Reque
 // @Produce      json
 // @Success	 	 200  {object}  map[string]string "Confirmation of reset. Example: `{\"status\":\"database and cache reset\"}`"
 // @Failure	     500  {object}  ErrorResponse "Internal Server Error - Failed to reset database or cache"
+// @Security     ApiKeyAuth
+// @Failure      401  {object}  ErrorResponse "Unauthorized - Missing API key"
+// @Failure      403  {object}  ErrorResponse "Forbidden - Invalid API key"
 // @Router       /dev/reset-db [post]
 func (cfg *apiConfig) handlerResetDB(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		cfg.respondWithError(w, http.StatusMethodNotAllowed, "Method Not Allowed", nil)
 		return
 	}
-	cfg.logger.Debug("database reset request received")
+	cfg.logger.DebugContext(r.Context(), "database reset request received")
 
 	ctx := r.Context()
 
@@ -259,47 +523,125 @@ func (cfg *apiConfig) handlerResetDB(w http.ResponseWriter, r *http.Request) {
 	cfg.respondWithJSON(w, http.StatusOK, map[string]string{"status": "database and cache reset"})
 }
 
+// handlerResetLocation is an administrative endpoint that wipes the stored weather data for a
+// single location and purges its cache entries. It is a targeted alternative to handlerResetDB
+// for when only one city's data is corrupted. Passing refresh=true fetches the location's
+// weather data and air quality again in the background, as part of the scheduler's work.
+
+// @Summary      Reset a single location's weather data
+// @Description  Deletes all current weather, hourly and daily forecast rows for the given location
+// @Description  and purges its cache entries. The location record and its aliases are kept.
+// @Description  When refresh=true, the location's weather data and air quality are then fetched from the
+// @Description  providers again in the background.
+// @Tags         admin
+// @Produce      json
+// @Param        id       path      string  true   "Location ID (UUID)"
+// @Param        refresh  query     bool    false  "Trigger an immediate refresh after the reset"
+// @Success      200  {object}  map[string]string "Confirmation of reset. Example: `{\"status\":\"location reset\"}`"
+// @Success      202  {object}  map[string]string "Confirmation of reset with a pending refresh."
+// @Failure      400  {object}  ErrorResponse "Bad Request - Invalid location ID or refresh flag"
+// @Failure      404  {object}  ErrorResponse "Not Found - Location does not exist"
+// @Failure      500  {object}  ErrorResponse "Internal Server Error - Failed to reset location data"
+// @Security     ApiKeyAuth
+// @Failure      401  {object}  ErrorResponse "Unauthorized - Missing API key"
+// @Failure      403  {object}  ErrorResponse "Forbidden - Invalid API key"
+// @Router       /admin/locations/{id}/reset [post]
+func (s *Scheduler) handlerResetLocation(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.cfg.respondWithError(w, http.StatusMethodNotAllowed, "Method Not Allowed", nil)
+		return
+	}
+
+	locationID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		s.cfg.respondWithError(w, http.StatusBadRequest, "Invalid location ID", err)
+		return
+	}
+
+	refresh := false
+	if refreshStr := r.URL.Query().Get("refresh"); refreshStr != "" {
+		refresh, err = strconv.ParseBool(refreshStr)
+		if err != nil {
+			s.cfg.respondWithError(w, http.StatusBadRequest, "Invalid refresh flag", err)
+			return
+		}
+	}
+
+	ctx := r.Context()
+	dbLocation, err := s.cfg.dbQueries.GetLocationByID(ctx, locationID)
+	if err == sql.ErrNoRows {
+		s.cfg.respondWithError(w, http.StatusNotFound, "Location not found", nil)
+		return
+	}
+	if err != nil {
+		s.cfg.respondWithError(w, http.StatusInternalServerError, "Failed to get location", err)
+		return
+	}
+	location := databaseLocationToLocation(dbLocation)
+	s.cfg.logger.InfoContext(ctx, "location reset requested", "location", location.CityName, "refresh", refresh)
+
+	if err := s.cfg.resetLocationData(ctx, location.LocationID); err != nil {
+		s.cfg.respondWithError(w, http.StatusInternalServerError, "Failed to reset location data", err)
+		return
+	}
+	if !refresh {
+		s.cfg.respondWithJSON(w, http.StatusOK, map[string]string{"status": "location reset"})
+		return
+	}
+
+	started := s.runInBackground(func(ctx context.Context) {
+		s.refreshLocation(ctx, location)
+		if err := s.runLocationUpdate(ctx, airQualityJobName, location, s.updateAirQuality); err != nil {
+			s.cfg.logger.WarnContext(ctx, "could not refresh air quality after reset", "location", location.CityName, "error", err)
+		}
+	})
+	if !started {
+		s.cfg.respondWithJSON(w, http.StatusOK, map[string]string{"status": "location reset"})
+		return
+	}
+	s.cfg.respondWithJSON(w, http.StatusAccepted, map[string]string{"status": "location reset, refresh triggered"})
+}
+
 // handlerRunSchedulerJobs is a development-only endpoint that manually triggers
-// a run of all scheduled data update jobs.
+// a run of all scheduled data update jobs, or of a single job given by the job parameter.
 
 // @Summary      Manually trigger scheduler jobs (development only)
-// @Description  Manually triggers a run of all scheduled data update jobs, including current weather,
-// @Description  hourly forecast, and daily forecast updates. This endpoint is intended for development
-// @Description  and testing purposes only. It should not be enabled in production environments.
+// @Description  Manually triggers a run of all registered scheduler jobs that are not paused, including current weather,
+// @Description  hourly forecast, and daily forecast updates. Pass `job` to run a single job, even if it is paused.
+// @Description  This endpoint is intended for development and testing purposes only. It should not be enabled in production environments.
 // @Tags         development
 // @Produce      json
+// @Param        job  query     string  false  "Name of a single job to run (e.g., 'current weather')"
 // @Success      202  {object}  map[string]string "Confirmation of triggering. Example:`{\"status\": \"scheduler jobs triggered\"}`"
+// @Failure      404  {object}  ErrorResponse "Not Found - Unknown scheduler job"
+// @Security     ApiKeyAuth
+// @Failure      401  {object}  ErrorResponse "Unauthorized - Missing API key"
+// @Failure      403  {object}  ErrorResponse "Forbidden - Invalid API key"
 // @Router       /dev/runschedulerjobs [post]
 func (s *Scheduler) handlerRunSchedulerJobs(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		s.cfg.respondWithError(w, http.StatusMethodNotAllowed, "Method Not Allowed", nil)
 		return
 	}
-	s.cfg.logger.Info("manual scheduler run triggered")
+	name := r.URL.Query().Get("job")
+	s.cfg.logger.Info("manual scheduler run triggered", "job", name)
 
-	// Reset tickers
-	s.tickers[0].Reset(s.cfg.schedulerCurrentInterval)
-	s.tickers[1].Reset(s.cfg.schedulerHourlyInterval)
-	s.tickers[2].Reset(s.cfg.schedulerDailyInterval)
+	jobs, err := s.jobsForTrigger(name)
+	if err != nil {
+		s.cfg.respondWithError(w, http.StatusNotFound, "Unknown scheduler job", err)
+		return
+	}
 
 	go func() {
 		s.cfg.logger.Info("starting manual scheduler jobs")
 		var wg sync.WaitGroup
-		wg.Add(3)
-
-		go func() {
-			defer wg.Done()
-			s.currentWeatherJobs()
-		}()
-		go func() {
-			defer wg.Done()
-			s.hourlyForecastJobs()
-		}()
-		go func() {
-			defer wg.Done()
-			s.dailyForecastJobs()
-		}()
-
+		for _, j := range jobs {
+			wg.Add(1)
+			go func(j *scheduledJob) {
+				defer wg.Done()
+				s.runJob(j)
+			}(j)
+		}
 		wg.Wait()
 		s.cfg.logger.Info("manual scheduler run finished")
 	}()
@@ -307,17 +649,87 @@ func (s *Scheduler) handlerRunSchedulerJobs(w http.ResponseWriter, r *http.Reque
 	s.cfg.respondWithJSON(w, http.StatusAccepted, map[string]string{"status": "scheduler jobs triggered"})
 }
 
+// @Summary      Get scheduler job status (development only)
+// @Description  Lists every registered scheduler job with its interval, pause state and last and next run times.
+// @Tags         development
+// @Produce      json
+// @Success      200  {object}  SchedulerStatusResponse
+// @Security     ApiKeyAuth
+// @Failure      401  {object}  ErrorResponse "Unauthorized - Missing API key"
+// @Failure      403  {object}  ErrorResponse "Forbidden - Invalid API key"
+// @Router       /dev/scheduler/jobs [get]
+func (s *Scheduler) handlerSchedulerStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.cfg.respondWithError(w, http.StatusMethodNotAllowed, "Method Not Allowed", nil)
+		return
+	}
+	s.cfg.respondWithJSON(w, http.StatusOK, SchedulerStatusResponse{Jobs: s.Status()})
+}
+
+// @Summary      Pause a scheduler job (development only)
+// @Description  Stops the given job from running on its schedule until it is resumed.
+// @Tags         development
+// @Produce      json
+// @Param        job  query     string  true  "Name of the job to pause (e.g., 'daily forecast')"
+// @Success      200  {object}  map[string]string "Example:`{\"status\": \"scheduler job paused\"}`"
+// @Failure      404  {object}  ErrorResponse "Not Found - Unknown scheduler job"
+// @Security     ApiKeyAuth
+// @Failure      401  {object}  ErrorResponse "Unauthorized - Missing API key"
+// @Failure      403  {object}  ErrorResponse "Forbidden - Invalid API key"
+// @Router       /dev/scheduler/pause [post]
+func (s *Scheduler) handlerPauseSchedulerJob(w http.ResponseWriter, r *http.Request) {
+	s.handleSetSchedulerJobPaused(w, r, true)
+}
+
+// @Summary      Resume a scheduler job (development only)
+// @Description  Re-enables scheduled runs of a paused job.
+// @Tags         development
+// @Produce      json
+// @Param        job  query     string  true  "Name of the job to resume (e.g., 'daily forecast')"
+// @Success      200  {object}  map[string]string "Example:`{\"status\": \"scheduler job resumed\"}`"
+// @Failure      404  {object}  ErrorResponse "Not Found - Unknown scheduler job"
+// @Security     ApiKeyAuth
+// @Failure      401  {object}  ErrorResponse "Unauthorized - Missing API key"
+// @Failure      403  {object}  ErrorResponse "Forbidden - Invalid API key"
+// @Router       /dev/scheduler/resume [post]
+func (s *Scheduler) handlerResumeSchedulerJob(w http.ResponseWriter, r *http.Request) {
+	s.handleSetSchedulerJobPaused(w, r, false)
+}
+
+func (s *Scheduler) handleSetSchedulerJobPaused(w http.ResponseWriter, r *http.Request, paused bool) {
+	if r.Method != http.MethodPost {
+		s.cfg.respondWithError(w, http.StatusMethodNotAllowed, "Method Not Allowed", nil)
+		return
+	}
+
+	name := r.URL.Query().Get("job")
+	if err := s.setPaused(name, paused); err != nil {
+		s.cfg.respondWithError(w, http.StatusNotFound, "Unknown scheduler job", err)
+		return
+	}
+
+	status := "scheduler job resumed"
+	if paused {
+		status = "scheduler job paused"
+	}
+	s.cfg.logger.Info(status, "job", name)
+	s.cfg.respondWithJSON(w, http.StatusOK, map[string]string{"status": status})
+}
+
 // handlerConfig provides client-side applications with necessary configuration,
 // such as whether the application is running in development mode.
 
 // @Summary      Get application configuration
 // @Description  Provides client-side applications with necessary configuration details,
 // @Description  such as whether the application is running in development mode and the
-// @Description  intervals for scheduled weather data updates.
+// @Description  intervals for scheduled weather data updates. Also suggests default cities
+// @Description  for the country given in the query or guessed from the Accept-Language header.
 // @Tags         configuration
 // @Produce      json
+// @Param        country          query   string  false  "Two-letter country code hint for the city suggestions"
+// @Param        Accept-Language  header  string  false  "Preferred languages, used to guess the country"
 // @Success	     200  {object}  ConfigResponse
-// @Router       /api/config [get]
+// @Router       /api/v1/config [get]
 func (cfg *apiConfig) handlerConfig(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		cfg.respondWithError(w, http.StatusMethodNotAllowed, "Method Not Allowed", nil)
@@ -330,6 +742,7 @@ func (cfg *apiConfig) handlerConfig(w http.ResponseWriter, r *http.Request) {
 		HourlyInterval:  cfg.schedulerHourlyInterval.String(),
 		DailyInterval:   cfg.schedulerDailyInterval.String(),
 	}
+	response.SuggestedCountry, response.SuggestedCities = cfg.suggestCities(r)
 
 	cfg.respondWithJSON(w, http.StatusOK, response)
 }
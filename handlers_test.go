@@ -4,15 +4,20 @@ import (
 	"bytes"
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"slices"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/cor0nius/willitrain/internal/database"
+	"github.com/cor0nius/willitrain/internal/testkit"
 	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
 )
@@ -25,6 +30,8 @@ func TestHandlerConfig(t *testing.T) {
 		currentInterval time.Duration
 		hourlyInterval  time.Duration
 		dailyInterval   time.Duration
+		suggestions     map[string][]string
+		acceptLanguage  string
 		wantStatus      int
 		wantBody        string
 	}{
@@ -52,6 +59,14 @@ func TestHandlerConfig(t *testing.T) {
 			wantStatus:      http.StatusOK,
 			wantBody:        `{"dev_mode":true,"current_interval":"5m0s","hourly_interval":"1h0m0s","daily_interval":"24h0m0s"}`,
 		},
+		{
+			name:           "City Suggestions from Accept-Language",
+			method:         http.MethodGet,
+			suggestions:    map[string][]string{"PL": {"Warsaw", "Kraków"}, defaultSuggestionsKey: {"London"}},
+			acceptLanguage: "pl-PL,pl;q=0.9,en;q=0.8",
+			wantStatus:     http.StatusOK,
+			wantBody:       `{"dev_mode":false,"current_interval":"0s","hourly_interval":"0s","daily_interval":"0s","suggested_country":"PL","suggested_cities":["Warsaw","Kraków"]}`,
+		},
 		{
 			name:       "Wrong Method",
 			method:     http.MethodPost,
@@ -68,9 +83,11 @@ func TestHandlerConfig(t *testing.T) {
 				schedulerCurrentInterval: tc.currentInterval,
 				schedulerHourlyInterval:  tc.hourlyInterval,
 				schedulerDailyInterval:   tc.dailyInterval,
+				citySuggestions:          tc.suggestions,
 			}
 
 			req := httptest.NewRequest(tc.method, "/api/config", nil)
+			req.Header.Set("Accept-Language", tc.acceptLanguage)
 			rr := httptest.NewRecorder()
 
 			apiCfg.handlerConfig(rr, req)
@@ -103,7 +120,7 @@ func TestHandlerResetDB(t *testing.T) {
 				cfg.mockDB.DeleteAllLocationsFunc = func(ctx context.Context) error {
 					return nil
 				}
-				cfg.mockCache.flushFunc = func(ctx context.Context) error {
+				cfg.mockCache.FlushFunc = func(ctx context.Context) error {
 					return nil
 				}
 			},
@@ -134,7 +151,7 @@ func TestHandlerResetDB(t *testing.T) {
 				cfg.mockDB.DeleteAllLocationsFunc = func(ctx context.Context) error {
 					return nil
 				}
-				cfg.mockCache.flushFunc = func(ctx context.Context) error {
+				cfg.mockCache.FlushFunc = func(ctx context.Context) error {
 					return errors.New("cache error")
 				}
 			},
@@ -181,6 +198,195 @@ func TestHandlerResetDB(t *testing.T) {
 	}
 }
 
+func TestHandlerResetLocation(t *testing.T) {
+	testCases := []struct {
+		name          string
+		requestMethod string
+		locationID    string
+		query         string
+		setupMocks    func(cfg *testAPIConfig)
+		wantStatus    int
+		wantBody      string
+	}{
+		{
+			name:          "Success",
+			requestMethod: http.MethodPost,
+			locationID:    MockLocation.LocationID.String(),
+			setupMocks: func(cfg *testAPIConfig) {
+				cfg.mockDB.GetLocationByIDFunc = func(ctx context.Context, id uuid.UUID) (database.Location, error) {
+					return MockDBLocation, nil
+				}
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `{"status":"location reset"}`,
+		},
+		{
+			name:          "Invalid Location ID",
+			requestMethod: http.MethodPost,
+			locationID:    "not-a-uuid",
+			setupMocks:    func(cfg *testAPIConfig) {},
+			wantStatus:    http.StatusBadRequest,
+			wantBody:      `{"error":"Invalid location ID"}`,
+		},
+		{
+			name:          "Invalid Refresh Flag",
+			requestMethod: http.MethodPost,
+			locationID:    MockLocation.LocationID.String(),
+			query:         "?refresh=maybe",
+			setupMocks:    func(cfg *testAPIConfig) {},
+			wantStatus:    http.StatusBadRequest,
+			wantBody:      `{"error":"Invalid refresh flag"}`,
+		},
+		{
+			name:          "Location Not Found",
+			requestMethod: http.MethodPost,
+			locationID:    MockLocation.LocationID.String(),
+			setupMocks: func(cfg *testAPIConfig) {
+				cfg.mockDB.GetLocationByIDFunc = func(ctx context.Context, id uuid.UUID) (database.Location, error) {
+					return database.Location{}, sql.ErrNoRows
+				}
+			},
+			wantStatus: http.StatusNotFound,
+			wantBody:   `{"error":"Location not found"}`,
+		},
+		{
+			name:          "Reset Fails",
+			requestMethod: http.MethodPost,
+			locationID:    MockLocation.LocationID.String(),
+			setupMocks: func(cfg *testAPIConfig) {
+				cfg.mockDB.GetLocationByIDFunc = func(ctx context.Context, id uuid.UUID) (database.Location, error) {
+					return MockDBLocation, nil
+				}
+				cfg.mockCache.DeleteFunc = func(ctx context.Context, keys ...string) error {
+					return errors.New("cache error")
+				}
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   `{"error":"Failed to reset location data"}`,
+		},
+		{
+			name:          "Wrong Method",
+			requestMethod: http.MethodGet,
+			locationID:    MockLocation.LocationID.String(),
+			setupMocks:    func(cfg *testAPIConfig) {},
+			wantStatus:    http.StatusMethodNotAllowed,
+			wantBody:      `{"error":"Method Not Allowed"}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testCfg := newTestAPIConfig(t)
+			tc.setupMocks(testCfg)
+
+			req := httptest.NewRequest(tc.requestMethod, "/admin/locations/"+tc.locationID+"/reset"+tc.query, nil)
+			req.SetPathValue("id", tc.locationID)
+			rr := httptest.NewRecorder()
+
+			NewScheduler(testCfg.apiConfig).handlerResetLocation(rr, req)
+
+			if status := rr.Code; status != tc.wantStatus {
+				t.Errorf("handler returned wrong status code: got %v want %v",
+					status, tc.wantStatus)
+			}
+
+			if rr.Body.String() != tc.wantBody {
+				t.Errorf("handler returned unexpected body: got %v want %v",
+					rr.Body.String(), tc.wantBody)
+			}
+		})
+	}
+}
+
+func TestHandlerResetLocationRefresh(t *testing.T) {
+	ometeoData, _ := os.ReadFile("testdata/current_weather_ometeo.json")
+
+	// Only Open-Meteo's current weather answers, so the other data stays deleted.
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !r.URL.Query().Has("current") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write(ometeoData)
+	}))
+	defer mockServer.Close()
+
+	testCfg := newTestAPIConfig(t)
+	testCfg.apiConfig.httpClient = mockServer.Client()
+	testCfg.apiConfig.ometeoWeatherURL = mockServer.URL + "/ometeo?"
+
+	var mu sync.Mutex
+	var events []string
+	record := func(event string) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, event)
+	}
+	testCfg.mockDB.GetLocationByIDFunc = func(ctx context.Context, id uuid.UUID) (database.Location, error) {
+		return MockDBLocation, nil
+	}
+	testCfg.mockDB.DeleteCurrentWeatherAtLocationFunc = func(ctx context.Context, id uuid.UUID) error {
+		record("delete current weather")
+		return nil
+	}
+	testCfg.mockDB.DeleteHourlyForecastsAtLocationFunc = func(ctx context.Context, id uuid.UUID) error {
+		record("delete hourly forecasts")
+		return nil
+	}
+	testCfg.mockDB.DeleteDailyForecastsAtLocationFunc = func(ctx context.Context, id uuid.UUID) error {
+		record("delete daily forecasts")
+		return nil
+	}
+	testCfg.mockDB.DeleteAirQualityAtLocationFunc = func(ctx context.Context, id uuid.UUID) error {
+		record("delete air quality")
+		return nil
+	}
+	testCfg.mockDB.GetCurrentWeatherAtLocationFromAPIFunc = func(ctx context.Context, arg database.GetCurrentWeatherAtLocationFromAPIParams) (database.CurrentWeather, error) {
+		return database.CurrentWeather{}, sql.ErrNoRows
+	}
+	testCfg.mockDB.CreateCurrentWeatherFunc = func(ctx context.Context, arg database.CreateCurrentWeatherParams) (database.CurrentWeather, error) {
+		record("create current weather")
+		return database.CurrentWeather{}, nil
+	}
+	testCfg.mockCache.DeleteFunc = func(ctx context.Context, keys ...string) error {
+		record("purge cache")
+		return nil
+	}
+
+	scheduler := NewScheduler(testCfg.apiConfig)
+	req := httptest.NewRequest(http.MethodPost, "/admin/locations/"+MockLocation.LocationID.String()+"/reset?refresh=true", nil)
+	req.SetPathValue("id", MockLocation.LocationID.String())
+	rr := httptest.NewRecorder()
+	scheduler.handlerResetLocation(rr, req)
+
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusAccepted, rr.Code, rr.Body.String())
+	}
+	// Stopping the scheduler waits for the background refresh.
+	scheduler.Stop()
+
+	mu.Lock()
+	got := slices.Clone(events)
+	mu.Unlock()
+	wantPrefix := []string{"delete current weather", "delete hourly forecasts", "delete daily forecasts", "delete air quality", "purge cache"}
+	if len(got) < len(wantPrefix) || strings.Join(got[:len(wantPrefix)], ", ") != strings.Join(wantPrefix, ", ") {
+		t.Fatalf("expected the reset to delete all data first, got %v", got)
+	}
+	if !slices.Contains(got[len(wantPrefix):], "create current weather") {
+		t.Errorf("expected the current weather to be replaced after the reset, got %v", got)
+	}
+	if testCfg.mockDB.Calls("DeleteAirQualityAtLocation") != 2 {
+		t.Errorf("expected the air quality to be refreshed too, got %v", got)
+	}
+
+	// A stopped scheduler resets the location without starting a refresh.
+	rr = httptest.NewRecorder()
+	scheduler.handlerResetLocation(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Errorf("expected status %d after shutdown, got %d", http.StatusOK, rr.Code)
+	}
+}
+
 func TestHandlerCurrentWeather(t *testing.T) {
 	mockLocationWithTimezone := MockLocation
 	mockLocationWithTimezone.Timezone = "Europe/Warsaw"
@@ -202,21 +408,157 @@ func TestHandlerCurrentWeather(t *testing.T) {
 				cfg.mockDB.GetLocationByAliasFunc = func(ctx context.Context, alias string) (database.Location, error) {
 					return mockDBLocationWithTimezone, nil
 				}
-				cfg.mockCache.getFunc = func(ctx context.Context, key string) (string, error) {
+				cfg.mockCache.GetFunc = func(ctx context.Context, key string) (string, error) {
 					return "", redis.Nil
 				}
 				cfg.mockDB.GetCurrentWeatherAtLocationFunc = func(ctx context.Context, locationID uuid.UUID) ([]database.CurrentWeather, error) {
 					return []database.CurrentWeather{MockDBCurrentWeather1, MockDBCurrentWeather2, MockDBCurrentWeather3}, nil
 				}
-				cfg.mockCache.setFunc = func(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+				cfg.mockCache.SetFunc = func(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+					return nil
+				}
+			},
+			wantStatus: http.StatusOK,
+			wantBody: `{"location":{"location_id":"` + mockLocationWithTimezone.LocationID.String() + `","city_name":"Wroclaw","latitude":51.1,"longitude":17.03,"country_code":"PL","timezone":"Europe/Warsaw"},"weather":[` +
+				`{"source_api":"test1","timestamp":"` + MockDBCurrentWeather1.UpdatedAt.In(time.FixedZone("Europe/Warsaw", 7200)).Format("2006-01-02 15:04") + `","observed_at_local":"` + MockDBCurrentWeather1.UpdatedAt.In(time.FixedZone("Europe/Warsaw", 7200)).Format("15:04") + `","temperature_c":10,"humidity":50,"wind_speed_kmh":5,"precipitation_mm":0,"condition_text":"sunny","condition_code":"clear","compact":{"emoji":"☀️","summary":"Clear 10°C"}},` +
+				`{"source_api":"test2","timestamp":"` + MockDBCurrentWeather2.UpdatedAt.In(time.FixedZone("Europe/Warsaw", 7200)).Format("2006-01-02 15:04") + `","observed_at_local":"` + MockDBCurrentWeather2.UpdatedAt.In(time.FixedZone("Europe/Warsaw", 7200)).Format("15:04") + `","temperature_c":11,"humidity":51,"wind_speed_kmh":6,"precipitation_mm":0.1,"condition_text":"partly cloudy","condition_code":"partly_cloudy","compact":{"emoji":"⛅","summary":"Partly cloudy 11°C"}},` +
+				`{"source_api":"test3","timestamp":"` + MockDBCurrentWeather3.UpdatedAt.In(time.FixedZone("Europe/Warsaw", 7200)).Format("2006-01-02 15:04") + `","observed_at_local":"` + MockDBCurrentWeather3.UpdatedAt.In(time.FixedZone("Europe/Warsaw", 7200)).Format("15:04") + `","temperature_c":12,"humidity":52,"wind_speed_kmh":7,"precipitation_mm":0.2,"condition_text":"cloudy","condition_code":"cloudy","compact":{"emoji":"☁️","summary":"Cloudy 12°C"}}]` + missingProvidersJSON("gmp", "owm", "ometeo") + `}`,
+			checkMocks: func(t *testing.T, cfg *testAPIConfig) {},
+		},
+		{
+			name:      "Success - UV index and sun times",
+			reqMethod: "GET",
+			setupMocks: func(cfg *testAPIConfig) {
+				cfg.mockDB.GetLocationByAliasFunc = func(ctx context.Context, alias string) (database.Location, error) {
+					return mockDBLocationWithTimezone, nil
+				}
+				cfg.mockCache.GetFunc = func(ctx context.Context, key string) (string, error) {
+					return "", redis.Nil
+				}
+				withSun := MockDBCurrentWeather1
+				withSun.UvIndex = sql.NullFloat64{Float64: 3.5, Valid: true}
+				withSun.Sunrise = sql.NullTime{Time: time.Date(2025, 8, 4, 3, 21, 0, 0, time.UTC), Valid: true}
+				withSun.Sunset = sql.NullTime{Time: time.Date(2025, 8, 4, 18, 34, 0, 0, time.UTC), Valid: true}
+				cfg.mockDB.GetCurrentWeatherAtLocationFunc = func(ctx context.Context, locationID uuid.UUID) ([]database.CurrentWeather, error) {
+					return []database.CurrentWeather{withSun, MockDBCurrentWeather2, MockDBCurrentWeather3}, nil
+				}
+				cfg.mockCache.SetFunc = func(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+					return nil
+				}
+			},
+			wantStatus: http.StatusOK,
+			wantBody: `{"location":{"location_id":"` + mockLocationWithTimezone.LocationID.String() + `","city_name":"Wroclaw","latitude":51.1,"longitude":17.03,"country_code":"PL","timezone":"Europe/Warsaw"},"weather":[` +
+				`{"source_api":"test1","timestamp":"` + MockDBCurrentWeather1.UpdatedAt.In(time.FixedZone("Europe/Warsaw", 7200)).Format("2006-01-02 15:04") + `","observed_at_local":"` + MockDBCurrentWeather1.UpdatedAt.In(time.FixedZone("Europe/Warsaw", 7200)).Format("15:04") + `","temperature_c":10,"humidity":50,"wind_speed_kmh":5,"precipitation_mm":0,"condition_text":"sunny","condition_code":"clear","uv_index":3.5,"sunrise":"05:21","sunset":"20:34","compact":{"emoji":"☀️","summary":"Clear 10°C"}},` +
+				`{"source_api":"test2","timestamp":"` + MockDBCurrentWeather2.UpdatedAt.In(time.FixedZone("Europe/Warsaw", 7200)).Format("2006-01-02 15:04") + `","observed_at_local":"` + MockDBCurrentWeather2.UpdatedAt.In(time.FixedZone("Europe/Warsaw", 7200)).Format("15:04") + `","temperature_c":11,"humidity":51,"wind_speed_kmh":6,"precipitation_mm":0.1,"condition_text":"partly cloudy","condition_code":"partly_cloudy","compact":{"emoji":"⛅","summary":"Partly cloudy 11°C"}},` +
+				`{"source_api":"test3","timestamp":"` + MockDBCurrentWeather3.UpdatedAt.In(time.FixedZone("Europe/Warsaw", 7200)).Format("2006-01-02 15:04") + `","observed_at_local":"` + MockDBCurrentWeather3.UpdatedAt.In(time.FixedZone("Europe/Warsaw", 7200)).Format("15:04") + `","temperature_c":12,"humidity":52,"wind_speed_kmh":7,"precipitation_mm":0.2,"condition_text":"cloudy","condition_code":"cloudy","compact":{"emoji":"☁️","summary":"Cloudy 12°C"}}]` + missingProvidersJSON("gmp", "owm", "ometeo") + `}`,
+			checkMocks: func(t *testing.T, cfg *testAPIConfig) {},
+		},
+		{
+			name:      "Success - Wind direction and gusts",
+			reqMethod: "GET",
+			setupMocks: func(cfg *testAPIConfig) {
+				cfg.mockDB.GetLocationByAliasFunc = func(ctx context.Context, alias string) (database.Location, error) {
+					return mockDBLocationWithTimezone, nil
+				}
+				cfg.mockCache.GetFunc = func(ctx context.Context, key string) (string, error) {
+					return "", redis.Nil
+				}
+				withWind := MockDBCurrentWeather1
+				withWind.WindDirectionDeg = sql.NullFloat64{Float64: 225, Valid: true}
+				withWind.WindGustKmh = sql.NullFloat64{Float64: 12, Valid: true}
+				cfg.mockDB.GetCurrentWeatherAtLocationFunc = func(ctx context.Context, locationID uuid.UUID) ([]database.CurrentWeather, error) {
+					return []database.CurrentWeather{withWind, MockDBCurrentWeather2, MockDBCurrentWeather3}, nil
+				}
+				cfg.mockCache.SetFunc = func(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+					return nil
+				}
+			},
+			wantStatus: http.StatusOK,
+			wantBody: `{"location":{"location_id":"` + mockLocationWithTimezone.LocationID.String() + `","city_name":"Wroclaw","latitude":51.1,"longitude":17.03,"country_code":"PL","timezone":"Europe/Warsaw"},"weather":[` +
+				`{"source_api":"test1","timestamp":"` + MockDBCurrentWeather1.UpdatedAt.In(time.FixedZone("Europe/Warsaw", 7200)).Format("2006-01-02 15:04") + `","observed_at_local":"` + MockDBCurrentWeather1.UpdatedAt.In(time.FixedZone("Europe/Warsaw", 7200)).Format("15:04") + `","temperature_c":10,"humidity":50,"wind_speed_kmh":5,"wind_direction_deg":225,"wind_direction":"SW","wind_gust_kmh":12,"precipitation_mm":0,"condition_text":"sunny","condition_code":"clear","compact":{"emoji":"☀️","summary":"Clear 10°C"}},` +
+				`{"source_api":"test2","timestamp":"` + MockDBCurrentWeather2.UpdatedAt.In(time.FixedZone("Europe/Warsaw", 7200)).Format("2006-01-02 15:04") + `","observed_at_local":"` + MockDBCurrentWeather2.UpdatedAt.In(time.FixedZone("Europe/Warsaw", 7200)).Format("15:04") + `","temperature_c":11,"humidity":51,"wind_speed_kmh":6,"precipitation_mm":0.1,"condition_text":"partly cloudy","condition_code":"partly_cloudy","compact":{"emoji":"⛅","summary":"Partly cloudy 11°C"}},` +
+				`{"source_api":"test3","timestamp":"` + MockDBCurrentWeather3.UpdatedAt.In(time.FixedZone("Europe/Warsaw", 7200)).Format("2006-01-02 15:04") + `","observed_at_local":"` + MockDBCurrentWeather3.UpdatedAt.In(time.FixedZone("Europe/Warsaw", 7200)).Format("15:04") + `","temperature_c":12,"humidity":52,"wind_speed_kmh":7,"precipitation_mm":0.2,"condition_text":"cloudy","condition_code":"cloudy","compact":{"emoji":"☁️","summary":"Cloudy 12°C"}}]` + missingProvidersJSON("gmp", "owm", "ometeo") + `}`,
+			checkMocks: func(t *testing.T, cfg *testAPIConfig) {},
+		},
+		{
+			name:      "Success - Apparent temperature and dew point",
+			reqMethod: "GET",
+			setupMocks: func(cfg *testAPIConfig) {
+				cfg.mockDB.GetLocationByAliasFunc = func(ctx context.Context, alias string) (database.Location, error) {
+					return mockDBLocationWithTimezone, nil
+				}
+				cfg.mockCache.GetFunc = func(ctx context.Context, key string) (string, error) {
+					return "", redis.Nil
+				}
+				withApparent := MockDBCurrentWeather1
+				withApparent.ApparentTemperatureC = sql.NullFloat64{Float64: 8.5, Valid: true}
+				withApparent.DewPointC = sql.NullFloat64{Float64: 0.1, Valid: true}
+				cfg.mockDB.GetCurrentWeatherAtLocationFunc = func(ctx context.Context, locationID uuid.UUID) ([]database.CurrentWeather, error) {
+					return []database.CurrentWeather{withApparent, MockDBCurrentWeather2, MockDBCurrentWeather3}, nil
+				}
+				cfg.mockCache.SetFunc = func(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+					return nil
+				}
+			},
+			wantStatus: http.StatusOK,
+			wantBody: `{"location":{"location_id":"` + mockLocationWithTimezone.LocationID.String() + `","city_name":"Wroclaw","latitude":51.1,"longitude":17.03,"country_code":"PL","timezone":"Europe/Warsaw"},"weather":[` +
+				`{"source_api":"test1","timestamp":"` + MockDBCurrentWeather1.UpdatedAt.In(time.FixedZone("Europe/Warsaw", 7200)).Format("2006-01-02 15:04") + `","observed_at_local":"` + MockDBCurrentWeather1.UpdatedAt.In(time.FixedZone("Europe/Warsaw", 7200)).Format("15:04") + `","temperature_c":10,"apparent_temperature_c":8.5,"dew_point_c":0.1,"humidity":50,"wind_speed_kmh":5,"precipitation_mm":0,"condition_text":"sunny","condition_code":"clear","compact":{"emoji":"☀️","summary":"Clear 10°C"}},` +
+				`{"source_api":"test2","timestamp":"` + MockDBCurrentWeather2.UpdatedAt.In(time.FixedZone("Europe/Warsaw", 7200)).Format("2006-01-02 15:04") + `","observed_at_local":"` + MockDBCurrentWeather2.UpdatedAt.In(time.FixedZone("Europe/Warsaw", 7200)).Format("15:04") + `","temperature_c":11,"humidity":51,"wind_speed_kmh":6,"precipitation_mm":0.1,"condition_text":"partly cloudy","condition_code":"partly_cloudy","compact":{"emoji":"⛅","summary":"Partly cloudy 11°C"}},` +
+				`{"source_api":"test3","timestamp":"` + MockDBCurrentWeather3.UpdatedAt.In(time.FixedZone("Europe/Warsaw", 7200)).Format("2006-01-02 15:04") + `","observed_at_local":"` + MockDBCurrentWeather3.UpdatedAt.In(time.FixedZone("Europe/Warsaw", 7200)).Format("15:04") + `","temperature_c":12,"humidity":52,"wind_speed_kmh":7,"precipitation_mm":0.2,"condition_text":"cloudy","condition_code":"cloudy","compact":{"emoji":"☁️","summary":"Cloudy 12°C"}}]` + missingProvidersJSON("gmp", "owm", "ometeo") + `}`,
+			checkMocks: func(t *testing.T, cfg *testAPIConfig) {},
+		},
+		{
+			name:      "Success - Rain and snow",
+			reqMethod: "GET",
+			setupMocks: func(cfg *testAPIConfig) {
+				cfg.mockDB.GetLocationByAliasFunc = func(ctx context.Context, alias string) (database.Location, error) {
+					return mockDBLocationWithTimezone, nil
+				}
+				cfg.mockCache.GetFunc = func(ctx context.Context, key string) (string, error) {
+					return "", redis.Nil
+				}
+				withSnow := MockDBCurrentWeather1
+				withSnow.RainMm = sql.NullFloat64{Float64: 0, Valid: true}
+				withSnow.SnowMm = sql.NullFloat64{Float64: 0.8, Valid: true}
+				cfg.mockDB.GetCurrentWeatherAtLocationFunc = func(ctx context.Context, locationID uuid.UUID) ([]database.CurrentWeather, error) {
+					return []database.CurrentWeather{withSnow, MockDBCurrentWeather2, MockDBCurrentWeather3}, nil
+				}
+				cfg.mockCache.SetFunc = func(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
 					return nil
 				}
 			},
 			wantStatus: http.StatusOK,
 			wantBody: `{"location":{"location_id":"` + mockLocationWithTimezone.LocationID.String() + `","city_name":"Wroclaw","latitude":51.1,"longitude":17.03,"country_code":"PL","timezone":"Europe/Warsaw"},"weather":[` +
-				`{"source_api":"test1","timestamp":"` + MockDBCurrentWeather1.UpdatedAt.In(time.FixedZone("Europe/Warsaw", 7200)).Format("2006-01-02 15:04") + `","temperature_c":10,"humidity":50,"wind_speed_kmh":5,"precipitation_mm":0,"condition_text":"sunny"},` +
-				`{"source_api":"test2","timestamp":"` + MockDBCurrentWeather2.UpdatedAt.In(time.FixedZone("Europe/Warsaw", 7200)).Format("2006-01-02 15:04") + `","temperature_c":11,"humidity":51,"wind_speed_kmh":6,"precipitation_mm":0.1,"condition_text":"partly cloudy"},` +
-				`{"source_api":"test3","timestamp":"` + MockDBCurrentWeather3.UpdatedAt.In(time.FixedZone("Europe/Warsaw", 7200)).Format("2006-01-02 15:04") + `","temperature_c":12,"humidity":52,"wind_speed_kmh":7,"precipitation_mm":0.2,"condition_text":"cloudy"}]}`,
+				`{"source_api":"test1","timestamp":"` + MockDBCurrentWeather1.UpdatedAt.In(time.FixedZone("Europe/Warsaw", 7200)).Format("2006-01-02 15:04") + `","observed_at_local":"` + MockDBCurrentWeather1.UpdatedAt.In(time.FixedZone("Europe/Warsaw", 7200)).Format("15:04") + `","temperature_c":10,"humidity":50,"wind_speed_kmh":5,"precipitation_mm":0,"rain_mm":0,"snow_mm":0.8,"condition_text":"sunny","condition_code":"clear","compact":{"emoji":"☀️","summary":"Clear 10°C"}},` +
+				`{"source_api":"test2","timestamp":"` + MockDBCurrentWeather2.UpdatedAt.In(time.FixedZone("Europe/Warsaw", 7200)).Format("2006-01-02 15:04") + `","observed_at_local":"` + MockDBCurrentWeather2.UpdatedAt.In(time.FixedZone("Europe/Warsaw", 7200)).Format("15:04") + `","temperature_c":11,"humidity":51,"wind_speed_kmh":6,"precipitation_mm":0.1,"condition_text":"partly cloudy","condition_code":"partly_cloudy","compact":{"emoji":"⛅","summary":"Partly cloudy 11°C"}},` +
+				`{"source_api":"test3","timestamp":"` + MockDBCurrentWeather3.UpdatedAt.In(time.FixedZone("Europe/Warsaw", 7200)).Format("2006-01-02 15:04") + `","observed_at_local":"` + MockDBCurrentWeather3.UpdatedAt.In(time.FixedZone("Europe/Warsaw", 7200)).Format("15:04") + `","temperature_c":12,"humidity":52,"wind_speed_kmh":7,"precipitation_mm":0.2,"condition_text":"cloudy","condition_code":"cloudy","compact":{"emoji":"☁️","summary":"Cloudy 12°C"}}]` + missingProvidersJSON("gmp", "owm", "ometeo") + `}`,
+			checkMocks: func(t *testing.T, cfg *testAPIConfig) {},
+		},
+		{
+			name:      "Success - Apparent temperature and dew point",
+			reqMethod: "GET",
+			setupMocks: func(cfg *testAPIConfig) {
+				cfg.mockDB.GetLocationByAliasFunc = func(ctx context.Context, alias string) (database.Location, error) {
+					return mockDBLocationWithTimezone, nil
+				}
+				cfg.mockCache.GetFunc = func(ctx context.Context, key string) (string, error) {
+					return "", redis.Nil
+				}
+				withApparent := MockDBCurrentWeather1
+				withApparent.ApparentTemperatureC = sql.NullFloat64{Float64: 8.5, Valid: true}
+				withApparent.DewPointC = sql.NullFloat64{Float64: 0.1, Valid: true}
+				cfg.mockDB.GetCurrentWeatherAtLocationFunc = func(ctx context.Context, locationID uuid.UUID) ([]database.CurrentWeather, error) {
+					return []database.CurrentWeather{withApparent, MockDBCurrentWeather2, MockDBCurrentWeather3}, nil
+				}
+				cfg.mockCache.SetFunc = func(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+					return nil
+				}
+			},
+			wantStatus: http.StatusOK,
+			wantBody: `{"location":{"location_id":"` + mockLocationWithTimezone.LocationID.String() + `","city_name":"Wroclaw","latitude":51.1,"longitude":17.03,"country_code":"PL","timezone":"Europe/Warsaw"},"weather":[` +
+				`{"source_api":"test1","timestamp":"` + MockDBCurrentWeather1.UpdatedAt.In(time.FixedZone("Europe/Warsaw", 7200)).Format("2006-01-02 15:04") + `","observed_at_local":"` + MockDBCurrentWeather1.UpdatedAt.In(time.FixedZone("Europe/Warsaw", 7200)).Format("15:04") + `","temperature_c":10,"apparent_temperature_c":8.5,"dew_point_c":0.1,"humidity":50,"wind_speed_kmh":5,"precipitation_mm":0,"condition_text":"sunny","condition_code":"clear","compact":{"emoji":"☀️","summary":"Clear 10°C"}},` +
+				`{"source_api":"test2","timestamp":"` + MockDBCurrentWeather2.UpdatedAt.In(time.FixedZone("Europe/Warsaw", 7200)).Format("2006-01-02 15:04") + `","observed_at_local":"` + MockDBCurrentWeather2.UpdatedAt.In(time.FixedZone("Europe/Warsaw", 7200)).Format("15:04") + `","temperature_c":11,"humidity":51,"wind_speed_kmh":6,"precipitation_mm":0.1,"condition_text":"partly cloudy","condition_code":"partly_cloudy","compact":{"emoji":"⛅","summary":"Partly cloudy 11°C"}},` +
+				`{"source_api":"test3","timestamp":"` + MockDBCurrentWeather3.UpdatedAt.In(time.FixedZone("Europe/Warsaw", 7200)).Format("2006-01-02 15:04") + `","observed_at_local":"` + MockDBCurrentWeather3.UpdatedAt.In(time.FixedZone("Europe/Warsaw", 7200)).Format("15:04") + `","temperature_c":12,"humidity":52,"wind_speed_kmh":7,"precipitation_mm":0.2,"condition_text":"cloudy","condition_code":"cloudy","compact":{"emoji":"☁️","summary":"Cloudy 12°C"}}]` + missingProvidersJSON("gmp", "owm", "ometeo") + `}`,
 			checkMocks: func(t *testing.T, cfg *testAPIConfig) {},
 		},
 		{
@@ -248,7 +590,7 @@ func TestHandlerCurrentWeather(t *testing.T) {
 				cfg.mockDB.GetLocationByAliasFunc = func(ctx context.Context, alias string) (database.Location, error) {
 					return mockDBLocationWithTimezone, nil
 				}
-				cfg.mockCache.getFunc = func(ctx context.Context, key string) (string, error) {
+				cfg.mockCache.GetFunc = func(ctx context.Context, key string) (string, error) {
 					return "", redis.Nil
 				}
 				cfg.mockDB.GetCurrentWeatherAtLocationFunc = func(ctx context.Context, locationID uuid.UUID) ([]database.CurrentWeather, error) {
@@ -269,21 +611,21 @@ func TestHandlerCurrentWeather(t *testing.T) {
 				cfg.mockDB.GetLocationByAliasFunc = func(ctx context.Context, alias string) (database.Location, error) {
 					return badTimezoneLocation, nil
 				}
-				cfg.mockCache.getFunc = func(ctx context.Context, key string) (string, error) {
+				cfg.mockCache.GetFunc = func(ctx context.Context, key string) (string, error) {
 					return "", redis.Nil
 				}
 				cfg.mockDB.GetCurrentWeatherAtLocationFunc = func(ctx context.Context, locationID uuid.UUID) ([]database.CurrentWeather, error) {
 					return []database.CurrentWeather{MockDBCurrentWeather1, MockDBCurrentWeather2, MockDBCurrentWeather3}, nil
 				}
-				cfg.mockCache.setFunc = func(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+				cfg.mockCache.SetFunc = func(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
 					return nil
 				}
 			},
 			wantStatus: http.StatusOK,
 			wantBody: `{"location":{"location_id":"` + mockLocationWithTimezone.LocationID.String() + `","city_name":"Wroclaw","latitude":51.1,"longitude":17.03,"country_code":"PL","timezone":"Invalid/Timezone"},"weather":[` +
-				`{"source_api":"test1","timestamp":"` + MockDBCurrentWeather1.UpdatedAt.In(time.UTC).Format("2006-01-02 15:04") + `","temperature_c":10,"humidity":50,"wind_speed_kmh":5,"precipitation_mm":0,"condition_text":"sunny"},` +
-				`{"source_api":"test2","timestamp":"` + MockDBCurrentWeather2.UpdatedAt.In(time.UTC).Format("2006-01-02 15:04") + `","temperature_c":11,"humidity":51,"wind_speed_kmh":6,"precipitation_mm":0.1,"condition_text":"partly cloudy"},` +
-				`{"source_api":"test3","timestamp":"` + MockDBCurrentWeather3.UpdatedAt.In(time.UTC).Format("2006-01-02 15:04") + `","temperature_c":12,"humidity":52,"wind_speed_kmh":7,"precipitation_mm":0.2,"condition_text":"cloudy"}]}`,
+				`{"source_api":"test1","timestamp":"` + MockDBCurrentWeather1.UpdatedAt.In(time.UTC).Format("2006-01-02 15:04") + `","observed_at_local":"` + MockDBCurrentWeather1.UpdatedAt.In(time.UTC).Format("15:04") + `","temperature_c":10,"humidity":50,"wind_speed_kmh":5,"precipitation_mm":0,"condition_text":"sunny","condition_code":"clear","compact":{"emoji":"☀️","summary":"Clear 10°C"}},` +
+				`{"source_api":"test2","timestamp":"` + MockDBCurrentWeather2.UpdatedAt.In(time.UTC).Format("2006-01-02 15:04") + `","observed_at_local":"` + MockDBCurrentWeather2.UpdatedAt.In(time.UTC).Format("15:04") + `","temperature_c":11,"humidity":51,"wind_speed_kmh":6,"precipitation_mm":0.1,"condition_text":"partly cloudy","condition_code":"partly_cloudy","compact":{"emoji":"⛅","summary":"Partly cloudy 11°C"}},` +
+				`{"source_api":"test3","timestamp":"` + MockDBCurrentWeather3.UpdatedAt.In(time.UTC).Format("2006-01-02 15:04") + `","observed_at_local":"` + MockDBCurrentWeather3.UpdatedAt.In(time.UTC).Format("15:04") + `","temperature_c":12,"humidity":52,"wind_speed_kmh":7,"precipitation_mm":0.2,"condition_text":"cloudy","condition_code":"cloudy","compact":{"emoji":"☁️","summary":"Cloudy 12°C"}}]` + missingProvidersJSON("gmp", "owm", "ometeo") + `}`,
 			checkMocks: func(t *testing.T, cfg *testAPIConfig) {},
 		},
 	}
@@ -291,6 +633,8 @@ func TestHandlerCurrentWeather(t *testing.T) {
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			testCfg := newTestAPIConfig(t)
+			// The stored fixtures hold one entry for each of three sources.
+			testCfg.apiConfig.enabledSources = map[string]bool{"gmp": true, "owm": true, "ometeo": true}
 			tc.setupMocks(testCfg)
 
 			req := httptest.NewRequest(tc.reqMethod, "/?city=wroclaw", nil)
@@ -312,6 +656,68 @@ func TestHandlerCurrentWeather(t *testing.T) {
 	}
 }
 
+func TestHandlerCurrentWeatherCompareByAge(t *testing.T) {
+	testCases := []struct {
+		name        string
+		target      string
+		wantStatus  int
+		wantSources []string
+	}{
+		{name: "Freshest First", target: "/?city=wroclaw&compare=age", wantStatus: http.StatusOK, wantSources: []string{"test3", "test1", "test2"}},
+		{name: "Invalid Compare Mode", target: "/?city=wroclaw&compare=temperature", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testCfg := newTestAPIConfig(t)
+			testCfg.apiConfig.enabledSources = map[string]bool{"gmp": true, "owm": true, "ometeo": true}
+			testCfg.mockDB.GetLocationByAliasFunc = func(ctx context.Context, alias string) (database.Location, error) {
+				return MockDBLocation, nil
+			}
+			testCfg.mockCache.GetFunc = func(ctx context.Context, key string) (string, error) {
+				return "", redis.Nil
+			}
+			testCfg.mockDB.GetCurrentWeatherAtLocationFunc = func(ctx context.Context, locationID uuid.UUID) ([]database.CurrentWeather, error) {
+				return []database.CurrentWeather{MockDBCurrentWeather1, MockDBCurrentWeather2, MockDBCurrentWeather3}, nil
+			}
+			testCfg.mockCache.SetFunc = func(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+				return nil
+			}
+
+			req := httptest.NewRequest(http.MethodGet, tc.target, nil)
+			rr := httptest.NewRecorder()
+
+			testCfg.apiConfig.handlerCurrentWeather(rr, req)
+
+			if rr.Code != tc.wantStatus {
+				t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, tc.wantStatus)
+			}
+			if tc.wantStatus != http.StatusOK {
+				return
+			}
+
+			var resp CurrentWeatherResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("could not decode response: %v", err)
+			}
+			if len(resp.Weather) != len(tc.wantSources) {
+				t.Fatalf("expected %d sources, got %d", len(tc.wantSources), len(resp.Weather))
+			}
+			for i, w := range resp.Weather {
+				if w.SourceAPI != tc.wantSources[i] {
+					t.Errorf("position %d: expected source %s, got %s", i, tc.wantSources[i], w.SourceAPI)
+				}
+				if w.MinutesSinceObservation == nil {
+					t.Errorf("source %s: expected minutes_since_observation to be set", w.SourceAPI)
+				}
+			}
+			if got := *resp.Weather[0].MinutesSinceObservation; got != 2 {
+				t.Errorf("expected freshest source to be 2 minutes old, got %d", got)
+			}
+		})
+	}
+}
+
 func TestHandlerDailyForecast(t *testing.T) {
 	mockLocationWithTimezone := MockLocation
 	mockLocationWithTimezone.Timezone = "Europe/Warsaw"
@@ -332,21 +738,21 @@ func TestHandlerDailyForecast(t *testing.T) {
 				cfg.mockDB.GetLocationByAliasFunc = func(ctx context.Context, alias string) (database.Location, error) {
 					return mockDBLocationWithTimezone, nil
 				}
-				cfg.mockCache.getFunc = func(ctx context.Context, key string) (string, error) {
+				cfg.mockCache.GetFunc = func(ctx context.Context, key string) (string, error) {
 					return "", redis.Nil
 				}
 				cfg.mockDB.GetUpcomingDailyForecastsAtLocationFunc = func(ctx context.Context, arg database.GetUpcomingDailyForecastsAtLocationParams) ([]database.DailyForecast, error) {
 					return []database.DailyForecast{MockDBDailyForecast1, MockDBDailyForecast2, MockDBDailyForecast3}, nil
 				}
-				cfg.mockCache.setFunc = func(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+				cfg.mockCache.SetFunc = func(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
 					return nil
 				}
 			},
 			wantStatus: http.StatusOK,
 			wantBody: `{"location":{"location_id":"` + mockLocationWithTimezone.LocationID.String() + `","city_name":"Wroclaw","latitude":51.1,"longitude":17.03,"country_code":"PL","timezone":"Europe/Warsaw"},"forecasts":[` +
-				`{"source_api":"test1","forecast_date":"` + MockDBDailyForecast1.ForecastDate.In(time.FixedZone("Europe/Warsaw", 7200)).Format("2006-01-02") + `","min_temp_c":5,"max_temp_c":15,"precipitation_mm":1,"precipitation_chance":50,"wind_speed_kmh":10,"humidity":60},` +
-				`{"source_api":"test2","forecast_date":"` + MockDBDailyForecast2.ForecastDate.In(time.FixedZone("Europe/Warsaw", 7200)).Format("2006-01-02") + `","min_temp_c":6,"max_temp_c":16,"precipitation_mm":2,"precipitation_chance":55,"wind_speed_kmh":11,"humidity":62},` +
-				`{"source_api":"test3","forecast_date":"` + MockDBDailyForecast3.ForecastDate.In(time.FixedZone("Europe/Warsaw", 7200)).Format("2006-01-02") + `","min_temp_c":7,"max_temp_c":17,"precipitation_mm":3,"precipitation_chance":60,"wind_speed_kmh":12,"humidity":65}]}`,
+				`{"source_api":"test1","forecast_date":"` + MockDBDailyForecast1.ForecastDate.In(time.FixedZone("Europe/Warsaw", 7200)).Format("2006-01-02") + `","min_temp_c":5,"max_temp_c":15,"precipitation_mm":1,"precipitation_chance":50,"wind_speed_kmh":10,"humidity":60,"condition_code":"rain",` + emptyDayPartsJSON + `,"compact":{"emoji":"🌧️","summary":"Rain 5/15°C"}},` +
+				`{"source_api":"test2","forecast_date":"` + MockDBDailyForecast2.ForecastDate.In(time.FixedZone("Europe/Warsaw", 7200)).Format("2006-01-02") + `","min_temp_c":6,"max_temp_c":16,"precipitation_mm":2,"precipitation_chance":55,"wind_speed_kmh":11,"humidity":62,"condition_code":"rain",` + emptyDayPartsJSON + `,"compact":{"emoji":"🌧️","summary":"Rain 6/16°C"}},` +
+				`{"source_api":"test3","forecast_date":"` + MockDBDailyForecast3.ForecastDate.In(time.FixedZone("Europe/Warsaw", 7200)).Format("2006-01-02") + `","min_temp_c":7,"max_temp_c":17,"precipitation_mm":3,"precipitation_chance":60,"wind_speed_kmh":12,"humidity":65,"condition_code":"rain",` + emptyDayPartsJSON + `,"compact":{"emoji":"🌧️","summary":"Rain 7/17°C"}}]` + missingProvidersJSON("gmp", "owm", "ometeo", "metno") + `}`,
 			checkMocks: func(t *testing.T, cfg *testAPIConfig) {},
 		},
 		{
@@ -378,7 +784,7 @@ func TestHandlerDailyForecast(t *testing.T) {
 				cfg.mockDB.GetLocationByAliasFunc = func(ctx context.Context, alias string) (database.Location, error) {
 					return mockDBLocationWithTimezone, nil
 				}
-				cfg.mockCache.getFunc = func(ctx context.Context, key string) (string, error) {
+				cfg.mockCache.GetFunc = func(ctx context.Context, key string) (string, error) {
 					return "", redis.Nil
 				}
 				cfg.mockDB.GetUpcomingDailyForecastsAtLocationFunc = func(ctx context.Context, arg database.GetUpcomingDailyForecastsAtLocationParams) ([]database.DailyForecast, error) {
@@ -399,21 +805,21 @@ func TestHandlerDailyForecast(t *testing.T) {
 				cfg.mockDB.GetLocationByAliasFunc = func(ctx context.Context, alias string) (database.Location, error) {
 					return badTimezoneLocation, nil
 				}
-				cfg.mockCache.getFunc = func(ctx context.Context, key string) (string, error) {
+				cfg.mockCache.GetFunc = func(ctx context.Context, key string) (string, error) {
 					return "", redis.Nil
 				}
 				cfg.mockDB.GetUpcomingDailyForecastsAtLocationFunc = func(ctx context.Context, arg database.GetUpcomingDailyForecastsAtLocationParams) ([]database.DailyForecast, error) {
 					return []database.DailyForecast{MockDBDailyForecast1, MockDBDailyForecast2, MockDBDailyForecast3}, nil
 				}
-				cfg.mockCache.setFunc = func(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+				cfg.mockCache.SetFunc = func(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
 					return nil
 				}
 			},
 			wantStatus: http.StatusOK,
 			wantBody: `{"location":{"location_id":"` + mockLocationWithTimezone.LocationID.String() + `","city_name":"Wroclaw","latitude":51.1,"longitude":17.03,"country_code":"PL","timezone":"Invalid/Timezone"},"forecasts":[` +
-				`{"source_api":"test1","forecast_date":"` + MockDBDailyForecast1.ForecastDate.In(time.UTC).Format("2006-01-02") + `","min_temp_c":5,"max_temp_c":15,"precipitation_mm":1,"precipitation_chance":50,"wind_speed_kmh":10,"humidity":60},` +
-				`{"source_api":"test2","forecast_date":"` + MockDBDailyForecast2.ForecastDate.In(time.UTC).Format("2006-01-02") + `","min_temp_c":6,"max_temp_c":16,"precipitation_mm":2,"precipitation_chance":55,"wind_speed_kmh":11,"humidity":62},` +
-				`{"source_api":"test3","forecast_date":"` + MockDBDailyForecast3.ForecastDate.In(time.UTC).Format("2006-01-02") + `","min_temp_c":7,"max_temp_c":17,"precipitation_mm":3,"precipitation_chance":60,"wind_speed_kmh":12,"humidity":65}]}`,
+				`{"source_api":"test1","forecast_date":"` + MockDBDailyForecast1.ForecastDate.In(time.UTC).Format("2006-01-02") + `","min_temp_c":5,"max_temp_c":15,"precipitation_mm":1,"precipitation_chance":50,"wind_speed_kmh":10,"humidity":60,"condition_code":"rain",` + emptyDayPartsJSON + `,"compact":{"emoji":"🌧️","summary":"Rain 5/15°C"}},` +
+				`{"source_api":"test2","forecast_date":"` + MockDBDailyForecast2.ForecastDate.In(time.UTC).Format("2006-01-02") + `","min_temp_c":6,"max_temp_c":16,"precipitation_mm":2,"precipitation_chance":55,"wind_speed_kmh":11,"humidity":62,"condition_code":"rain",` + emptyDayPartsJSON + `,"compact":{"emoji":"🌧️","summary":"Rain 6/16°C"}},` +
+				`{"source_api":"test3","forecast_date":"` + MockDBDailyForecast3.ForecastDate.In(time.UTC).Format("2006-01-02") + `","min_temp_c":7,"max_temp_c":17,"precipitation_mm":3,"precipitation_chance":60,"wind_speed_kmh":12,"humidity":65,"condition_code":"rain",` + emptyDayPartsJSON + `,"compact":{"emoji":"🌧️","summary":"Rain 7/17°C"}}]` + missingProvidersJSON("gmp", "owm", "ometeo", "metno") + `}`,
 			checkMocks: func(t *testing.T, cfg *testAPIConfig) {},
 		},
 	}
@@ -462,7 +868,7 @@ func TestHandlerHourlyForecast(t *testing.T) {
 				cfg.mockDB.GetLocationByAliasFunc = func(ctx context.Context, alias string) (database.Location, error) {
 					return mockDBLocationWithTimezone, nil
 				}
-				cfg.mockCache.getFunc = func(ctx context.Context, key string) (string, error) {
+				cfg.mockCache.GetFunc = func(ctx context.Context, key string) (string, error) {
 					return "", errors.New("not in cache")
 				}
 				cfg.mockDB.GetUpcomingHourlyForecastsAtLocationFunc = func(ctx context.Context, arg database.GetUpcomingHourlyForecastsAtLocationParams) ([]database.HourlyForecast, error) {
@@ -471,9 +877,10 @@ func TestHandlerHourlyForecast(t *testing.T) {
 			},
 			wantStatus: http.StatusOK,
 			wantBody: `{"location":{"location_id":"` + mockLocationWithTimezone.LocationID.String() + `","city_name":"Wroclaw","latitude":51.1,"longitude":17.03,"country_code":"PL","timezone":"Europe/Warsaw"},"forecasts":[` +
-				`{"source_api":"test1","forecast_datetime":"` + MockDBHourlyForecast1.ForecastDatetimeUtc.In(time.FixedZone("Europe/Warsaw", 7200)).Format("2006-01-02 15:04") + `","temperature_c":10,"humidity":50,"wind_speed_kmh":5,"precipitation_mm":0,"precipitation_chance":10,"condition_text":"cloudy"},` +
-				`{"source_api":"test2","forecast_datetime":"` + MockDBHourlyForecast2.ForecastDatetimeUtc.In(time.FixedZone("Europe/Warsaw", 7200)).Format("2006-01-02 15:04") + `","temperature_c":11,"humidity":51,"wind_speed_kmh":6,"precipitation_mm":0.1,"precipitation_chance":15,"condition_text":"partly cloudy"},` +
-				`{"source_api":"test3","forecast_datetime":"` + MockDBHourlyForecast3.ForecastDatetimeUtc.In(time.FixedZone("Europe/Warsaw", 7200)).Format("2006-01-02 15:04") + `","temperature_c":12,"humidity":52,"wind_speed_kmh":7,"precipitation_mm":0.2,"precipitation_chance":20,"condition_text":"sunny"}]}`,
+				`{"source_api":"test1","forecast_datetime":"` + MockDBHourlyForecast1.ForecastDatetimeUtc.In(time.FixedZone("Europe/Warsaw", 7200)).Format("2006-01-02 15:04") + `","temperature_c":10,"humidity":50,"wind_speed_kmh":5,"precipitation_mm":0,"precipitation_chance":10,"condition_text":"cloudy","condition_code":"cloudy","compact":{"emoji":"☁️","summary":"Cloudy 10°C"}},` +
+				`{"source_api":"test2","forecast_datetime":"` + MockDBHourlyForecast2.ForecastDatetimeUtc.In(time.FixedZone("Europe/Warsaw", 7200)).Format("2006-01-02 15:04") + `","temperature_c":11,"humidity":51,"wind_speed_kmh":6,"precipitation_mm":0.1,"precipitation_chance":15,"condition_text":"partly cloudy","condition_code":"partly_cloudy","compact":{"emoji":"⛅","summary":"Partly cloudy 11°C"}},` +
+				`{"source_api":"test3","forecast_datetime":"` + MockDBHourlyForecast3.ForecastDatetimeUtc.In(time.FixedZone("Europe/Warsaw", 7200)).Format("2006-01-02 15:04") + `","temperature_c":12,"humidity":52,"wind_speed_kmh":7,"precipitation_mm":0.2,"precipitation_chance":20,"condition_text":"sunny","condition_code":"clear","compact":{"emoji":"☀️","summary":"Clear 12°C"}}],` +
+				`"transitions":[{"source":"consensus","at":"` + MockDBHourlyForecast3.ForecastDatetimeUtc.In(time.FixedZone("Europe/Warsaw", 7200)).Format("15:04") + `","forecast_datetime":"` + MockDBHourlyForecast3.ForecastDatetimeUtc.In(time.FixedZone("Europe/Warsaw", 7200)).Format("2006-01-02 15:04") + `","from":"cloudy","to":"clear"}]` + missingProvidersJSON("gmp", "owm", "ometeo", "metno") + `}`,
 			checkMocks: func(t *testing.T, cfg *testAPIConfig) {},
 		},
 		{
@@ -505,7 +912,7 @@ func TestHandlerHourlyForecast(t *testing.T) {
 				cfg.mockDB.GetLocationByAliasFunc = func(ctx context.Context, alias string) (database.Location, error) {
 					return mockDBLocationWithTimezone, nil
 				}
-				cfg.mockCache.getFunc = func(ctx context.Context, key string) (string, error) {
+				cfg.mockCache.GetFunc = func(ctx context.Context, key string) (string, error) {
 					return "", redis.Nil
 				}
 				cfg.mockDB.GetUpcomingHourlyForecastsAtLocationFunc = func(ctx context.Context, arg database.GetUpcomingHourlyForecastsAtLocationParams) ([]database.HourlyForecast, error) {
@@ -526,7 +933,7 @@ func TestHandlerHourlyForecast(t *testing.T) {
 				cfg.mockDB.GetLocationByAliasFunc = func(ctx context.Context, alias string) (database.Location, error) {
 					return badTimezoneLocation, nil
 				}
-				cfg.mockCache.getFunc = func(ctx context.Context, key string) (string, error) {
+				cfg.mockCache.GetFunc = func(ctx context.Context, key string) (string, error) {
 					return "", redis.Nil
 				}
 				cfg.mockDB.GetUpcomingHourlyForecastsAtLocationFunc = func(ctx context.Context, arg database.GetUpcomingHourlyForecastsAtLocationParams) ([]database.HourlyForecast, error) {
@@ -535,9 +942,10 @@ func TestHandlerHourlyForecast(t *testing.T) {
 			},
 			wantStatus: http.StatusOK,
 			wantBody: `{"location":{"location_id":"` + mockLocationWithTimezone.LocationID.String() + `","city_name":"Wroclaw","latitude":51.1,"longitude":17.03,"country_code":"PL","timezone":"Invalid/Timezone"},"forecasts":[` +
-				`{"source_api":"test1","forecast_datetime":"` + MockDBHourlyForecast1.ForecastDatetimeUtc.In(time.UTC).Format("2006-01-02 15:04") + `","temperature_c":10,"humidity":50,"wind_speed_kmh":5,"precipitation_mm":0,"precipitation_chance":10,"condition_text":"cloudy"},` +
-				`{"source_api":"test2","forecast_datetime":"` + MockDBHourlyForecast2.ForecastDatetimeUtc.In(time.UTC).Format("2006-01-02 15:04") + `","temperature_c":11,"humidity":51,"wind_speed_kmh":6,"precipitation_mm":0.1,"precipitation_chance":15,"condition_text":"partly cloudy"},` +
-				`{"source_api":"test3","forecast_datetime":"` + MockDBHourlyForecast3.ForecastDatetimeUtc.In(time.UTC).Format("2006-01-02 15:04") + `","temperature_c":12,"humidity":52,"wind_speed_kmh":7,"precipitation_mm":0.2,"precipitation_chance":20,"condition_text":"sunny"}]}`,
+				`{"source_api":"test1","forecast_datetime":"` + MockDBHourlyForecast1.ForecastDatetimeUtc.In(time.UTC).Format("2006-01-02 15:04") + `","temperature_c":10,"humidity":50,"wind_speed_kmh":5,"precipitation_mm":0,"precipitation_chance":10,"condition_text":"cloudy","condition_code":"cloudy","compact":{"emoji":"☁️","summary":"Cloudy 10°C"}},` +
+				`{"source_api":"test2","forecast_datetime":"` + MockDBHourlyForecast2.ForecastDatetimeUtc.In(time.UTC).Format("2006-01-02 15:04") + `","temperature_c":11,"humidity":51,"wind_speed_kmh":6,"precipitation_mm":0.1,"precipitation_chance":15,"condition_text":"partly cloudy","condition_code":"partly_cloudy","compact":{"emoji":"⛅","summary":"Partly cloudy 11°C"}},` +
+				`{"source_api":"test3","forecast_datetime":"` + MockDBHourlyForecast3.ForecastDatetimeUtc.In(time.UTC).Format("2006-01-02 15:04") + `","temperature_c":12,"humidity":52,"wind_speed_kmh":7,"precipitation_mm":0.2,"precipitation_chance":20,"condition_text":"sunny","condition_code":"clear","compact":{"emoji":"☀️","summary":"Clear 12°C"}}],` +
+				`"transitions":[{"source":"consensus","at":"` + MockDBHourlyForecast3.ForecastDatetimeUtc.In(time.UTC).Format("15:04") + `","forecast_datetime":"` + MockDBHourlyForecast3.ForecastDatetimeUtc.In(time.UTC).Format("2006-01-02 15:04") + `","from":"cloudy","to":"clear"}]` + missingProvidersJSON("gmp", "owm", "ometeo", "metno") + `}`,
 			checkMocks: func(t *testing.T, cfg *testAPIConfig) {},
 		},
 	}
@@ -566,27 +974,53 @@ func TestHandlerHourlyForecast(t *testing.T) {
 	}
 }
 
+// syncBuffer is a bytes.Buffer that can be logged to from the scheduler's goroutines while a
+// test reads it.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func (b *syncBuffer) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.buf.Reset()
+}
+
 func TestHandlerRunSchedulerJobs(t *testing.T) {
-	var logBuf bytes.Buffer
+	var logBuf syncBuffer
 	testLogger := slog.New(slog.NewTextHandler(&logBuf, nil))
 
 	cfg := &apiConfig{
+		dbQueries:                testkit.NewQuerier(t),
 		logger:                   testLogger,
 		schedulerCurrentInterval: 20 * time.Millisecond,
 		schedulerHourlyInterval:  20 * time.Millisecond,
 		schedulerDailyInterval:   20 * time.Millisecond,
 	}
 
-	scheduler := NewScheduler(cfg, cfg.schedulerCurrentInterval, cfg.schedulerHourlyInterval, cfg.schedulerDailyInterval)
-
-	scheduler.currentWeatherJobs = func() {
-		cfg.logger.Info("mock current weather job run")
-	}
-	scheduler.hourlyForecastJobs = func() {
-		cfg.logger.Info("mock hourly forecast job run")
-	}
-	scheduler.dailyForecastJobs = func() {
-		cfg.logger.Info("mock daily forecast job run")
+	scheduler := NewScheduler(cfg)
+	for _, job := range scheduler.weatherJobs(cfg.schedulerCurrentInterval, cfg.schedulerHourlyInterval, cfg.schedulerDailyInterval) {
+		name := job.Name
+		job.Run = func(context.Context) error {
+			cfg.logger.Info("mock " + name + " job run")
+			return nil
+		}
+		if err := scheduler.RegisterJob(job); err != nil {
+			t.Fatalf("failed to register job: %v", err)
+		}
 	}
 
 	handler := scheduler.handlerRunSchedulerJobs
@@ -623,6 +1057,59 @@ func TestHandlerRunSchedulerJobs(t *testing.T) {
 		}
 	})
 
+	t.Run("Single Job", func(t *testing.T) {
+		testCfg := newTestAPIConfig(t)
+		scheduler := NewScheduler(testCfg.apiConfig)
+		ran := make(chan string, 2)
+		for _, name := range []string{"current weather", "daily forecast"} {
+			err := scheduler.RegisterJob(SchedulerJob{Name: name, Interval: time.Hour, Run: func(context.Context) error {
+				ran <- name
+				return nil
+			}})
+			if err != nil {
+				t.Fatalf("failed to register job: %v", err)
+			}
+		}
+
+		req := httptest.NewRequest(http.MethodPost, "/scheduler/run?job=daily+forecast", nil)
+		rr := httptest.NewRecorder()
+
+		scheduler.handlerRunSchedulerJobs(rr, req)
+
+		if rr.Code != http.StatusAccepted {
+			t.Fatalf("expected status %d; got %d", http.StatusAccepted, rr.Code)
+		}
+
+		select {
+		case name := <-ran:
+			if name != "daily forecast" {
+				t.Errorf("expected the daily forecast job to run, got %q", name)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for the daily forecast job")
+		}
+		// Only the requested job is triggered.
+		if jobs, err := scheduler.jobsForTrigger("daily forecast"); err != nil || len(jobs) != 1 {
+			t.Errorf("expected only the daily forecast job to be triggered, got %d jobs, %v", len(jobs), err)
+		}
+		select {
+		case name := <-ran:
+			t.Errorf("unexpected run of the %s job", name)
+		default:
+		}
+	})
+
+	t.Run("Failure - Unknown Job", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/scheduler/run?job=unknown", nil)
+		rr := httptest.NewRecorder()
+
+		handler(rr, req)
+
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("expected status %d; got %d", http.StatusNotFound, rr.Code)
+		}
+	})
+
 	t.Run("Failure - non-POST method", func(t *testing.T) {
 		logBuf.Reset()
 
@@ -643,6 +1130,80 @@ func TestHandlerRunSchedulerJobs(t *testing.T) {
 	})
 }
 
+func TestHandlerSchedulerJobControls(t *testing.T) {
+	testCfg := newTestAPIConfig(t)
+	scheduler := NewScheduler(testCfg.apiConfig)
+	if err := scheduler.RegisterJob(SchedulerJob{Name: "daily forecast", Interval: time.Hour, Run: func(context.Context) error { return nil }}); err != nil {
+		t.Fatalf("failed to register job: %v", err)
+	}
+
+	testCases := []struct {
+		name       string
+		handler    http.HandlerFunc
+		method     string
+		target     string
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "Pause",
+			handler:    scheduler.handlerPauseSchedulerJob,
+			method:     http.MethodPost,
+			target:     "/dev/scheduler/pause?job=daily+forecast",
+			wantStatus: http.StatusOK,
+			wantBody:   `{"status":"scheduler job paused"}`,
+		},
+		{
+			name:       "Status Shows Paused",
+			handler:    scheduler.handlerSchedulerStatus,
+			method:     http.MethodGet,
+			target:     "/dev/scheduler/jobs",
+			wantStatus: http.StatusOK,
+			wantBody:   `{"jobs":[{"name":"daily forecast","interval":"1h0m0s","paused":true,"running":false}]}`,
+		},
+		{
+			name:       "Resume",
+			handler:    scheduler.handlerResumeSchedulerJob,
+			method:     http.MethodPost,
+			target:     "/dev/scheduler/resume?job=daily+forecast",
+			wantStatus: http.StatusOK,
+			wantBody:   `{"status":"scheduler job resumed"}`,
+		},
+		{
+			name:       "Pause - Unknown Job",
+			handler:    scheduler.handlerPauseSchedulerJob,
+			method:     http.MethodPost,
+			target:     "/dev/scheduler/pause?job=unknown",
+			wantStatus: http.StatusNotFound,
+			wantBody:   `{"error":"Unknown scheduler job"}`,
+		},
+		{
+			name:       "Status - Wrong Method",
+			handler:    scheduler.handlerSchedulerStatus,
+			method:     http.MethodPost,
+			target:     "/dev/scheduler/jobs",
+			wantStatus: http.StatusMethodNotAllowed,
+			wantBody:   `{"error":"Method Not Allowed"}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.target, nil)
+			rr := httptest.NewRecorder()
+
+			tc.handler(rr, req)
+
+			if rr.Code != tc.wantStatus {
+				t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, tc.wantStatus)
+			}
+			if rr.Body.String() != tc.wantBody {
+				t.Errorf("handler returned unexpected body: got %v want %v", rr.Body.String(), tc.wantBody)
+			}
+		})
+	}
+}
+
 func TestRespondWithJSON(t *testing.T) {
 	t.Run("Success", func(t *testing.T) {
 		rr := httptest.NewRecorder()
@@ -699,3 +1260,21 @@ func TestRespondWithJSON(t *testing.T) {
 		}
 	})
 }
+
+// emptyDayPartsJSON is the parts block of a daily forecast from a source that does not break the
+// day down.
+const emptyDayPartsJSON = `"parts":{"morning":{"temperature_c":null,"precipitation_chance":null,"condition":null},` +
+	`"afternoon":{"temperature_c":null,"precipitation_chance":null,"condition":null},` +
+	`"evening":{"temperature_c":null,"precipitation_chance":null,"condition":null},` +
+	`"night":{"temperature_c":null,"precipitation_chance":null,"condition":null}}`
+
+// missingProvidersJSON returns the provider status block of a response built from the test
+// fixtures. Their sources are not registered providers, so every enabled provider is missing.
+func missingProvidersJSON(ids ...string) string {
+	entries := make([]string, len(ids))
+	for i, id := range ids {
+		p, _ := providerByID(id)
+		entries[i] = `{"provider":"` + p.ID + `","display_name":"` + p.DisplayName + `","status":"error","message":"no data available"}`
+	}
+	return `,"partial":true,"providers":[` + strings.Join(entries, ",") + `]`
+}
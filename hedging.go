@@ -0,0 +1,110 @@
+package main
+
+import (
+	"log/slog"
+	"math"
+	"slices"
+	"sync"
+	"time"
+)
+
+// This file implements latency-aware hedging for forecasts fetched on a user request. One slow
+// upstream should not hold back a response that the other providers already answered, so once
+// at least one provider has responded, the remaining ones get until a percentile of their recent
+// latency (HEDGE_PERCENTILE, p95 by default) to respond. Providers that miss this deadline are
+// served without, but their requests are not cancelled: late results are still persisted so that
+// the next request includes them. Providers without enough latency samples are always waited for.
+
+const (
+	// defaultHedgePercentile is the latency percentile used as the hedge deadline unless
+	// overridden with HEDGE_PERCENTILE.
+	defaultHedgePercentile = 95
+	// latencySampleSize is the number of recent successful fetches kept per provider.
+	latencySampleSize = 50
+	// hedgeMinSamples is the number of samples needed before a provider's latency is trusted.
+	hedgeMinSamples = 10
+)
+
+// getHedgePercentile reads the latency percentile used as the hedge deadline from
+// HEDGE_PERCENTILE. A value of 0 disables hedging; values outside 0-100 are ignored.
+func getHedgePercentile(logger *slog.Logger) int {
+	p := getEnvAsInt("HEDGE_PERCENTILE", defaultHedgePercentile, logger)
+	if p < 0 || p > 100 {
+		logger.Warn("HEDGE_PERCENTILE must be between 0 and 100, using default", "value", p)
+		return defaultHedgePercentile
+	}
+	return p
+}
+
+// providerLatencyTracker keeps the durations of recent successful fetches per provider.
+// A nil tracker is valid, records nothing, and reports no percentiles.
+type providerLatencyTracker struct {
+	mu      sync.Mutex
+	samples map[string]*latencyRing
+}
+
+// latencyRing is a fixed-size ring buffer of durations.
+type latencyRing struct {
+	buf  [latencySampleSize]time.Duration
+	n    int
+	next int
+}
+
+func newProviderLatencyTracker() *providerLatencyTracker {
+	return &providerLatencyTracker{samples: make(map[string]*latencyRing)}
+}
+
+// observe records the duration of a successful fetch from a provider.
+func (t *providerLatencyTracker) observe(providerID string, d time.Duration) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	ring, ok := t.samples[providerID]
+	if !ok {
+		ring = &latencyRing{}
+		t.samples[providerID] = ring
+	}
+	ring.buf[ring.next] = d
+	ring.next = (ring.next + 1) % latencySampleSize
+	ring.n = min(ring.n+1, latencySampleSize)
+}
+
+// percentile returns the q-th percentile (0 < q <= 1) of a provider's recent latency. It reports
+// false until the provider has hedgeMinSamples samples.
+func (t *providerLatencyTracker) percentile(providerID string, q float64) (time.Duration, bool) {
+	if t == nil {
+		return 0, false
+	}
+	t.mu.Lock()
+	ring, ok := t.samples[providerID]
+	if !ok || ring.n < hedgeMinSamples {
+		t.mu.Unlock()
+		return 0, false
+	}
+	sorted := slices.Clone(ring.buf[:ring.n])
+	t.mu.Unlock()
+
+	slices.Sort(sorted)
+	idx := int(math.Ceil(q*float64(len(sorted)))) - 1
+	return sorted[max(idx, 0)], true
+}
+
+// hedgeDeadline returns how long after the start of a fetch the given pending providers are
+// waited for. It reports false if hedging is disabled or any pending provider has too few
+// latency samples, in which case all of them should be waited for.
+func (cfg *apiConfig) hedgeDeadline(pending map[string]bool) (time.Duration, bool) {
+	if cfg.hedgePercentile <= 0 || len(pending) == 0 {
+		return 0, false
+	}
+	var deadline time.Duration
+	for id := range pending {
+		p, ok := cfg.latency.percentile(id, float64(cfg.hedgePercentile)/100)
+		if !ok {
+			return 0, false
+		}
+		deadline = max(deadline, p)
+	}
+	return deadline, true
+}
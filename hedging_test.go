@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestProviderLatencyTracker(t *testing.T) {
+	t.Run("nil tracker reports no percentile", func(t *testing.T) {
+		var tracker *providerLatencyTracker
+		tracker.observe("owm", time.Second)
+		if _, ok := tracker.percentile("owm", 0.95); ok {
+			t.Error("expected no percentile from a nil tracker")
+		}
+	})
+
+	t.Run("percentile requires minimum samples", func(t *testing.T) {
+		tracker := newProviderLatencyTracker()
+		for i := 1; i < hedgeMinSamples; i++ {
+			tracker.observe("owm", time.Duration(i)*time.Millisecond)
+		}
+		if _, ok := tracker.percentile("owm", 0.95); ok {
+			t.Fatalf("expected no percentile with %d samples", hedgeMinSamples-1)
+		}
+		tracker.observe("owm", 10*time.Millisecond)
+		if got, ok := tracker.percentile("owm", 0.9); !ok || got != 9*time.Millisecond {
+			t.Errorf("percentile(0.9) = %v, %v; want 9ms, true", got, ok)
+		}
+		if got, _ := tracker.percentile("owm", 1); got != 10*time.Millisecond {
+			t.Errorf("percentile(1) = %v, want 10ms", got)
+		}
+	})
+
+	t.Run("only recent samples are kept", func(t *testing.T) {
+		tracker := newProviderLatencyTracker()
+		for i := 0; i < latencySampleSize; i++ {
+			tracker.observe("owm", time.Hour)
+		}
+		for i := 0; i < latencySampleSize; i++ {
+			tracker.observe("owm", time.Millisecond)
+		}
+		if got, _ := tracker.percentile("owm", 1); got != time.Millisecond {
+			t.Errorf("percentile(1) = %v, want 1ms", got)
+		}
+	})
+}
+
+func TestHedgeDeadline(t *testing.T) {
+	tracker := newProviderLatencyTracker()
+	for i := 0; i < hedgeMinSamples; i++ {
+		tracker.observe("owm", 100*time.Millisecond)
+		tracker.observe("ometeo", 300*time.Millisecond)
+	}
+
+	testCases := []struct {
+		name       string
+		percentile int
+		pending    map[string]bool
+		want       time.Duration
+		wantOK     bool
+	}{
+		{"slowest pending provider", 95, map[string]bool{"owm": true, "ometeo": true}, 300 * time.Millisecond, true},
+		{"single pending provider", 95, map[string]bool{"owm": true}, 100 * time.Millisecond, true},
+		{"provider without samples", 95, map[string]bool{"owm": true, "gmp": true}, 0, false},
+		{"hedging disabled", 0, map[string]bool{"owm": true}, 0, false},
+		{"nothing pending", 95, map[string]bool{}, 0, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &apiConfig{latency: tracker, hedgePercentile: tc.percentile}
+			got, ok := cfg.hedgeDeadline(tc.pending)
+			if got != tc.want || ok != tc.wantOK {
+				t.Errorf("hedgeDeadline() = %v, %v; want %v, %v", got, ok, tc.want, tc.wantOK)
+			}
+		})
+	}
+}
+
+func TestGetHedgePercentile(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	testCases := []struct {
+		value string
+		want  int
+	}{
+		{"", defaultHedgePercentile},
+		{"90", 90},
+		{"0", 0},
+		{"101", defaultHedgePercentile},
+		{"-5", defaultHedgePercentile},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.value, func(t *testing.T) {
+			t.Setenv("HEDGE_PERCENTILE", tc.value)
+			if got := getHedgePercentile(logger); got != tc.want {
+				t.Errorf("getHedgePercentile() = %d, want %d", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestProcessForecastRequests_Hedging(t *testing.T) {
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer fast.Close()
+
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer slow.Close()
+
+	parserFor := func(sourceAPI string) func(io.Reader, *slog.Logger) (CurrentWeather, string, error) {
+		return func(io.Reader, *slog.Logger) (CurrentWeather, string, error) {
+			return CurrentWeather{SourceAPI: sourceAPI}, "", nil
+		}
+	}
+	urls := map[string]string{
+		"gmpWrappedURL":    fast.URL,
+		"ometeoWrappedURL": slow.URL,
+	}
+	providers := map[string]forecastProvider[CurrentWeather]{
+		"gmpWrappedURL":    {parser: parserFor("Google Weather API"), errorVal: CurrentWeather{SourceAPI: "Google Weather API"}},
+		"ometeoWrappedURL": {parser: parserFor("Open-Meteo API"), errorVal: CurrentWeather{SourceAPI: "Open-Meteo API"}},
+	}
+
+	cfg := newTestAPIConfig(t).apiConfig
+	cfg.hedgePercentile = 95
+	cfg.latency = newProviderLatencyTracker()
+	for i := 0; i < hedgeMinSamples; i++ {
+		cfg.latency.observe("ometeo", 20*time.Millisecond)
+	}
+
+	late := make(chan CurrentWeather, 1)
+	results, _, err := processForecastRequests(cfg, context.Background(), MockLocation, urls, providers, func(w CurrentWeather) {
+		late <- w
+	}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 1 || results[0].SourceAPI != "Google Weather API" {
+		t.Fatalf("expected only the fast provider's result, got %+v", results)
+	}
+
+	close(release)
+	select {
+	case w := <-late:
+		if w.SourceAPI != "Open-Meteo API" {
+			t.Errorf("late result from %q, want Open-Meteo API", w.SourceAPI)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("late result was not delivered")
+	}
+}
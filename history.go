@@ -0,0 +1,402 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cor0nius/willitrain/internal/database"
+	"github.com/google/uuid"
+)
+
+// This file implements the weather history archive. The scheduler replaces the current weather
+// and forecasts of a location on every cycle; with ARCHIVE_HISTORY enabled, the replaced rows
+// are moved to history tables instead of being deleted. The /api/history endpoint pages
+// through the archived rows of a location in chronological order, so that clients can chart
+// past conditions.
+
+// History types selected with the type query parameter.
+const (
+	historyTypeCurrent = "current"
+	historyTypeHourly  = "hourly"
+	historyTypeDaily   = "daily"
+)
+
+const (
+	defaultHistoryEntries = 100
+	maxHistoryEntries     = 1000
+
+	// defaultHistoryRange is the period covered when the from parameter is omitted.
+	defaultHistoryRange = 7 * 24 * time.Hour
+)
+
+// getArchiveHistory reads ARCHIVE_HISTORY, which makes the scheduler move replaced weather rows
+// to the history tables instead of deleting them. Archiving is off unless the variable is true.
+func getArchiveHistory(logger *slog.Logger) bool {
+	raw := os.Getenv("ARCHIVE_HISTORY")
+	if raw == "" {
+		return false
+	}
+	archive, err := strconv.ParseBool(raw)
+	if err != nil {
+		logger.Warn("invalid ARCHIVE_HISTORY value, archiving disabled", "value", raw, "error", err)
+		return false
+	}
+	return archive
+}
+
+// clearCurrentWeather removes the current weather of a location before it is replaced, using q so
+// that it can share a transaction with the new rows. The rows are moved to the history table if
+// archiving is enabled and deleted otherwise.
+func (cfg *apiConfig) clearCurrentWeather(ctx context.Context, q dbQuerier, locationID uuid.UUID) error {
+	if !cfg.archiveHistory {
+		return q.DeleteCurrentWeatherAtLocation(ctx, locationID)
+	}
+	archived, err := q.ArchiveCurrentWeatherAtLocation(ctx, database.ArchiveCurrentWeatherAtLocationParams{
+		LocationID: locationID,
+		ArchivedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return err
+	}
+	historyRowsArchived.WithLabelValues(historyTypeCurrent).Add(float64(archived))
+	return nil
+}
+
+// clearHourlyForecasts removes the hourly forecasts of a location before they are replaced, using
+// q so that it can share a transaction with the new rows. The rows are moved to the history table
+// if archiving is enabled and deleted otherwise.
+func (cfg *apiConfig) clearHourlyForecasts(ctx context.Context, q dbQuerier, locationID uuid.UUID) error {
+	if !cfg.archiveHistory {
+		return q.DeleteHourlyForecastsAtLocation(ctx, locationID)
+	}
+	archived, err := q.ArchiveHourlyForecastsAtLocation(ctx, database.ArchiveHourlyForecastsAtLocationParams{
+		LocationID: locationID,
+		ArchivedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return err
+	}
+	historyRowsArchived.WithLabelValues(historyTypeHourly).Add(float64(archived))
+	return nil
+}
+
+// clearDailyForecasts removes the daily forecasts of a location before they are replaced, using q
+// so that it can share a transaction with the new rows. The rows are moved to the history table
+// if archiving is enabled and deleted otherwise.
+func (cfg *apiConfig) clearDailyForecasts(ctx context.Context, q dbQuerier, locationID uuid.UUID) error {
+	if !cfg.archiveHistory {
+		return q.DeleteDailyForecastsAtLocation(ctx, locationID)
+	}
+	archived, err := q.ArchiveDailyForecastsAtLocation(ctx, database.ArchiveDailyForecastsAtLocationParams{
+		LocationID: locationID,
+		ArchivedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return err
+	}
+	historyRowsArchived.WithLabelValues(historyTypeDaily).Add(float64(archived))
+	return nil
+}
+
+// historyCursor is the position after the last entry of a history page: the time the entries
+// are ordered by and the ID of the row, which breaks ties.
+type historyCursor struct {
+	at time.Time
+	id uuid.UUID
+}
+
+// encode returns the opaque form of the cursor passed to clients.
+func (c historyCursor) encode() string {
+	raw := strconv.FormatInt(c.at.UnixNano(), 10) + ":" + c.id.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeHistoryCursor parses a cursor returned by encode.
+func decodeHistoryCursor(s string) (historyCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return historyCursor{}, err
+	}
+	nanos, id, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return historyCursor{}, errors.New("malformed cursor")
+	}
+	n, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return historyCursor{}, err
+	}
+	parsedID, err := uuid.Parse(id)
+	if err != nil {
+		return historyCursor{}, err
+	}
+	return historyCursor{at: time.Unix(0, n).UTC(), id: parsedID}, nil
+}
+
+// params returns the cursor as query parameters, which are NULL for the first page.
+func (c *historyCursor) params() (sql.NullTime, uuid.NullUUID) {
+	if c == nil {
+		return sql.NullTime{}, uuid.NullUUID{}
+	}
+	return sql.NullTime{Time: c.at, Valid: true}, uuid.NullUUID{UUID: c.id, Valid: true}
+}
+
+// parseHistoryTime parses a from or to parameter, either an RFC 3339 timestamp or a date, which
+// stands for midnight in the location's timezone. An empty value returns the fallback.
+func parseHistoryTime(raw string, loc *time.Location, fallback time.Time) (time.Time, error) {
+	if raw == "" {
+		return fallback, nil
+	}
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", raw, loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is neither an RFC 3339 timestamp nor a YYYY-MM-DD date", raw)
+	}
+	return t, nil
+}
+
+// historyDate returns the UTC midnight of the date t falls on in loc, the form in which dates
+// are compared with the forecast_date column.
+func historyDate(t time.Time, loc *time.Location) time.Time {
+	y, m, d := t.In(loc).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// @Summary      Get weather history
+// @Description  Returns archived weather of a location in chronological order: observed current weather
+// @Description  (type=current, the default), or the hourly or daily forecasts as they were issued (type=hourly,
+// @Description  type=daily). Entries are selected by observation time, forecast hour or forecast date in [from, to).
+// @Description  History is only recorded while ARCHIVE_HISTORY is enabled. If next_cursor is set, pass it as
+// @Description  cursor, with the same parameters, to get the next page.
+// @Tags         weather
+// @Produce      json
+// @Param        city    query     string  false  "Location name to search for (e.g., 'London')"
+// @Param        lat     query     number  false  "Latitude for the location (e.g., 51.5074)"
+// @Param        lon     query     number  false  "Longitude for the location (e.g., -0.1278)"
+// @Param        type    query     string  false  "History type: 'current' (default), 'hourly' or 'daily'"
+// @Param        from    query     string  false  "Start, as RFC 3339 timestamp or YYYY-MM-DD date in the location's timezone (default: 7 days before to)"
+// @Param        to      query     string  false  "End, exclusive, in the same formats (default: now)"
+// @Param        limit   query     int     false  "Maximum number of entries per page (default 100, max 1000)"
+// @Param        cursor  query     string  false  "Cursor returned as next_cursor by the previous page"
+// @Success      200  {object}  HistoryResponse
+// @Failure      400  {object}  ErrorResponse "Bad Request - Invalid location, type, time range, limit or cursor"
+// @Failure      500  {object}  ErrorResponse "Internal Server Error - Failed to retrieve history"
+// @Router       /api/v1/history [get]
+func (cfg *apiConfig) handlerHistory(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if r.Method != http.MethodGet {
+		cfg.respondWithError(w, http.StatusMethodNotAllowed, "Method Not Allowed", nil)
+		return
+	}
+
+	query := r.URL.Query()
+	historyType := query.Get("type")
+	if historyType == "" {
+		historyType = historyTypeCurrent
+	}
+	if historyType != historyTypeCurrent && historyType != historyTypeHourly && historyType != historyTypeDaily {
+		cfg.respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid type %q, must be current, hourly or daily", historyType), nil)
+		return
+	}
+	limit, err := parseStatsParam(r, "limit", defaultHistoryEntries, maxHistoryEntries)
+	if err != nil {
+		cfg.respondWithError(w, http.StatusBadRequest, "Invalid limit", err)
+		return
+	}
+	var cursor *historyCursor
+	if raw := query.Get("cursor"); raw != "" {
+		c, err := decodeHistoryCursor(raw)
+		if err != nil {
+			cfg.respondWithError(w, http.StatusBadRequest, "Invalid cursor", err)
+			return
+		}
+		cursor = &c
+	}
+
+	location, err := cfg.getLocationFromRequest(r)
+	if err != nil {
+		cfg.respondWithError(w, http.StatusBadRequest, "Error getting location data", err)
+		return
+	}
+	cfg.logger.Debug("history request", "city", location.CityName, "type", historyType)
+
+	loc, err := time.LoadLocation(location.Timezone)
+	if err != nil {
+		cfg.logger.Warn("could not load location timezone, falling back to UTC", "timezone", location.Timezone, "error", err)
+		loc = time.UTC
+	}
+	to, err := parseHistoryTime(query.Get("to"), loc, time.Now())
+	if err != nil {
+		cfg.respondWithError(w, http.StatusBadRequest, "Invalid to parameter", err)
+		return
+	}
+	from, err := parseHistoryTime(query.Get("from"), loc, to.Add(-defaultHistoryRange))
+	if err != nil {
+		cfg.respondWithError(w, http.StatusBadRequest, "Invalid from parameter", err)
+		return
+	}
+	if !from.Before(to) {
+		cfg.respondWithError(w, http.StatusBadRequest, "from must be before to", nil)
+		return
+	}
+
+	response := HistoryResponse{
+		Location: location,
+		Type:     historyType,
+		From:     from.In(loc).Format(time.RFC3339),
+		To:       to.In(loc).Format(time.RFC3339),
+	}
+	afterTime, afterID := cursor.params()
+	// One entry more than the limit is requested to find out whether there is a next page.
+	rowLimit := int32(limit + 1)
+	var sources []string
+
+	switch historyType {
+	case historyTypeCurrent:
+		rows, err := cfg.dbQueries.ListCurrentWeatherHistory(ctx, database.ListCurrentWeatherHistoryParams{
+			LocationID: location.LocationID,
+			FromTime:   from.UTC(),
+			ToTime:     to.UTC(),
+			AfterTime:  afterTime,
+			AfterID:    afterID,
+			RowLimit:   rowLimit,
+		})
+		if err != nil {
+			cfg.respondWithError(w, http.StatusInternalServerError, "Error getting weather history", err)
+			return
+		}
+		if len(rows) > limit {
+			rows = rows[:limit]
+			last := rows[limit-1]
+			response.NextCursor = historyCursor{at: last.UpdatedAt, id: last.ID}.encode()
+		}
+		response.Weather = make([]CurrentWeatherJSON, len(rows))
+		for i, row := range rows {
+			response.Weather[i] = historyCurrentWeatherJSON(row, loc)
+			sources = append(sources, row.SourceApi)
+		}
+	case historyTypeHourly:
+		rows, err := cfg.dbQueries.ListHourlyForecastHistory(ctx, database.ListHourlyForecastHistoryParams{
+			LocationID: location.LocationID,
+			FromTime:   from.UTC(),
+			ToTime:     to.UTC(),
+			AfterTime:  afterTime,
+			AfterID:    afterID,
+			RowLimit:   rowLimit,
+		})
+		if err != nil {
+			cfg.respondWithError(w, http.StatusInternalServerError, "Error getting hourly forecast history", err)
+			return
+		}
+		if len(rows) > limit {
+			rows = rows[:limit]
+			last := rows[limit-1]
+			response.NextCursor = historyCursor{at: last.ForecastDatetimeUtc, id: last.ID}.encode()
+		}
+		response.Hourly = make([]HistoryHourlyForecastJSON, len(rows))
+		for i, row := range rows {
+			response.Hourly[i] = historyHourlyForecastJSON(row, loc)
+			sources = append(sources, row.SourceApi)
+		}
+	case historyTypeDaily:
+		rows, err := cfg.dbQueries.ListDailyForecastHistory(ctx, database.ListDailyForecastHistoryParams{
+			LocationID: location.LocationID,
+			FromTime:   historyDate(from, loc),
+			ToTime:     historyDate(to, loc),
+			AfterTime:  afterTime,
+			AfterID:    afterID,
+			RowLimit:   rowLimit,
+		})
+		if err != nil {
+			cfg.respondWithError(w, http.StatusInternalServerError, "Error getting daily forecast history", err)
+			return
+		}
+		if len(rows) > limit {
+			rows = rows[:limit]
+			last := rows[limit-1]
+			response.NextCursor = historyCursor{at: last.ForecastDate, id: last.ID}.encode()
+		}
+		response.Daily = make([]HistoryDailyForecastJSON, len(rows))
+		for i, row := range rows {
+			response.Daily[i] = historyDailyForecastJSON(row, loc)
+			sources = append(sources, row.SourceApi)
+		}
+	}
+	response.Attribution = attributionForSources(sources)
+
+	cfg.respondWithJSON(w, http.StatusOK, response)
+}
+
+// historyCurrentWeatherJSON maps an archived current weather row to its JSON representation.
+func historyCurrentWeatherJSON(row database.CurrentWeatherHistory, loc *time.Location) CurrentWeatherJSON {
+	weather := CurrentWeatherJSON{
+		SourceAPI:       row.SourceApi,
+		Timestamp:       row.UpdatedAt.In(loc).Format("2006-01-02 15:04"),
+		ObservedAtLocal: row.UpdatedAt.In(loc).Format("15:04"),
+		Temperature:     row.TemperatureC.Float64,
+		Humidity:        row.Humidity.Int32,
+		WindSpeed:       row.WindSpeedKmh.Float64,
+		Precipitation:   row.PrecipitationMm.Float64,
+		Condition:       row.ConditionText.String,
+		ConditionCode:   normalizeCondition(row.ConditionText.String),
+	}
+	weather.Compact = compactFor(weather.ConditionCode, formatCompactTemp(weather.Temperature, unitsMetric))
+	return weather
+}
+
+// historyHourlyForecastJSON maps an archived hourly forecast row to its JSON representation.
+func historyHourlyForecastJSON(row database.HourlyForecastHistory, loc *time.Location) HistoryHourlyForecastJSON {
+	forecast := HourlyForecastJSON{
+		SourceAPI:           row.SourceApi,
+		ForecastDateTime:    row.ForecastDatetimeUtc.In(loc).Format("2006-01-02 15:04"),
+		Temperature:         row.TemperatureC.Float64,
+		Humidity:            row.Humidity.Int32,
+		WindSpeed:           row.WindSpeedKmh.Float64,
+		Precipitation:       row.PrecipitationMm.Float64,
+		PrecipitationChance: row.PrecipitationChancePercent.Int32,
+		Condition:           row.ConditionText.String,
+		ConditionCode:       normalizeCondition(row.ConditionText.String),
+	}
+	forecast.Compact = compactFor(forecast.ConditionCode, formatCompactTemp(forecast.Temperature, unitsMetric))
+	return HistoryHourlyForecastJSON{
+		HourlyForecastJSON: forecast,
+		IssuedAt:           row.UpdatedAt.In(loc).Format("2006-01-02 15:04"),
+	}
+}
+
+// historyDailyForecastJSON maps an archived daily forecast row to its JSON representation.
+// Forecast dates are read from the database as UTC midnight and formatted as such.
+func historyDailyForecastJSON(row database.DailyForecastHistory, loc *time.Location) HistoryDailyForecastJSON {
+	daily := DailyForecast{
+		MinTemp:             row.MinTempC.Float64,
+		MaxTemp:             row.MaxTempC.Float64,
+		Precipitation:       row.PrecipitationMm.Float64,
+		PrecipitationChance: row.PrecipitationChancePercent.Int32,
+	}
+	forecast := DailyForecastJSON{
+		SourceAPI:           row.SourceApi,
+		ForecastDate:        row.ForecastDate.UTC().Format("2006-01-02"),
+		MinTemp:             daily.MinTemp,
+		MaxTemp:             daily.MaxTemp,
+		Precipitation:       daily.Precipitation,
+		PrecipitationChance: daily.PrecipitationChance,
+		WindSpeed:           row.WindSpeedKmh.Float64,
+		Humidity:            row.Humidity.Int32,
+		ConditionCode:       dailyConditionCode(daily),
+	}
+	forecast.Compact = compactFor(forecast.ConditionCode, formatCompactTempRange(daily.MinTemp, daily.MaxTemp, unitsMetric))
+	return HistoryDailyForecastJSON{
+		DailyForecastJSON: forecast,
+		IssuedAt:          row.UpdatedAt.In(loc).Format("2006-01-02 15:04"),
+	}
+}
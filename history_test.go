@@ -0,0 +1,265 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cor0nius/willitrain/internal/database"
+	"github.com/google/uuid"
+)
+
+func TestGetArchiveHistory(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	testCases := map[string]bool{"": false, "true": true, "1": true, "false": false, "sometimes": false}
+	for value, want := range testCases {
+		t.Run(value, func(t *testing.T) {
+			t.Setenv("ARCHIVE_HISTORY", value)
+			if got := getArchiveHistory(logger); got != want {
+				t.Errorf("getArchiveHistory() = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestClearWeatherRows(t *testing.T) {
+	locationID := uuid.New()
+	clears := []struct {
+		name    string
+		clear   func(*apiConfig, context.Context, dbQuerier, uuid.UUID) error
+		delete  string
+		archive string
+	}{
+		{"current", (*apiConfig).clearCurrentWeather, "DeleteCurrentWeatherAtLocation", "ArchiveCurrentWeatherAtLocation"},
+		{"hourly", (*apiConfig).clearHourlyForecasts, "DeleteHourlyForecastsAtLocation", "ArchiveHourlyForecastsAtLocation"},
+		{"daily", (*apiConfig).clearDailyForecasts, "DeleteDailyForecastsAtLocation", "ArchiveDailyForecastsAtLocation"},
+	}
+
+	for _, c := range clears {
+		for _, archive := range []bool{false, true} {
+			name := c.name + " delete"
+			if archive {
+				name = c.name + " archive"
+			}
+			t.Run(name, func(t *testing.T) {
+				testCfg := newTestAPIConfig(t)
+				testCfg.apiConfig.archiveHistory = archive
+
+				if err := c.clear(testCfg.apiConfig, context.Background(), testCfg.apiConfig.dbQueries, locationID); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				wantDeletes, wantArchives := 1, 0
+				if archive {
+					wantDeletes, wantArchives = 0, 1
+				}
+				if got := testCfg.mockDB.Calls(c.delete); got != wantDeletes {
+					t.Errorf("%s called %d times, want %d", c.delete, got, wantDeletes)
+				}
+				if got := testCfg.mockDB.Calls(c.archive); got != wantArchives {
+					t.Errorf("%s called %d times, want %d", c.archive, got, wantArchives)
+				}
+			})
+		}
+	}
+
+	t.Run("archive error", func(t *testing.T) {
+		testCfg := newTestAPIConfig(t)
+		testCfg.apiConfig.archiveHistory = true
+		var gotID uuid.UUID
+		testCfg.mockDB.ArchiveCurrentWeatherAtLocationFunc = func(ctx context.Context, arg database.ArchiveCurrentWeatherAtLocationParams) (int64, error) {
+			gotID = arg.LocationID
+			return 0, errors.New("db down")
+		}
+		if err := testCfg.apiConfig.clearCurrentWeather(context.Background(), testCfg.apiConfig.dbQueries, locationID); err == nil {
+			t.Error("expected an error")
+		}
+		if gotID != locationID {
+			t.Errorf("archived location %v, want %v", gotID, locationID)
+		}
+	})
+}
+
+func TestHistoryCursor(t *testing.T) {
+	want := historyCursor{at: time.Date(2025, 6, 1, 12, 30, 0, 123, time.UTC), id: uuid.New()}
+	got, err := decodeHistoryCursor(want.encode())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !got.at.Equal(want.at) || got.id != want.id {
+		t.Errorf("decoded %+v, want %+v", got, want)
+	}
+
+	for _, invalid := range []string{"not base64!", "bm8tY29sb24", "eDp5"} {
+		if _, err := decodeHistoryCursor(invalid); err == nil {
+			t.Errorf("expected an error for %q", invalid)
+		}
+	}
+}
+
+func TestParseHistoryTime(t *testing.T) {
+	warsaw, _ := time.LoadLocation("Europe/Warsaw")
+	fallback := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name    string
+		raw     string
+		want    time.Time
+		wantErr bool
+	}{
+		{name: "empty", raw: "", want: fallback},
+		{name: "timestamp", raw: "2025-06-02T10:00:00Z", want: time.Date(2025, 6, 2, 10, 0, 0, 0, time.UTC)},
+		{name: "date in location timezone", raw: "2025-06-02", want: time.Date(2025, 6, 1, 22, 0, 0, 0, time.UTC)},
+		{name: "invalid", raw: "yesterday", wantErr: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parseHistoryTime(tc.raw, warsaw, fallback)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("expected error: %v, got: %v", tc.wantErr, err)
+			}
+			if !tc.wantErr && !got.Equal(tc.want) {
+				t.Errorf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestHandlerHistory(t *testing.T) {
+	observedAt := time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC)
+	currentRow := func(offset time.Duration) database.CurrentWeatherHistory {
+		return database.CurrentWeatherHistory{
+			ID:            uuid.New(),
+			SourceApi:     "Open-Meteo API",
+			UpdatedAt:     observedAt.Add(offset),
+			TemperatureC:  sql.NullFloat64{Float64: 18.5, Valid: true},
+			ConditionText: sql.NullString{String: "light rain", Valid: true},
+		}
+	}
+	currentRows := []database.CurrentWeatherHistory{currentRow(0), currentRow(time.Hour)}
+
+	testCases := []struct {
+		name       string
+		method     string
+		query      string
+		wantStatus int
+		check      func(t *testing.T, testCfg *testAPIConfig, response HistoryResponse)
+	}{
+		{name: "method not allowed", method: http.MethodPost, query: "?city=wroclaw", wantStatus: http.StatusMethodNotAllowed},
+		{name: "invalid type", method: http.MethodGet, query: "?city=wroclaw&type=minutely", wantStatus: http.StatusBadRequest},
+		{name: "invalid limit", method: http.MethodGet, query: "?city=wroclaw&limit=0", wantStatus: http.StatusBadRequest},
+		{name: "invalid cursor", method: http.MethodGet, query: "?city=wroclaw&cursor=abc", wantStatus: http.StatusBadRequest},
+		{name: "invalid from", method: http.MethodGet, query: "?city=wroclaw&from=last-week", wantStatus: http.StatusBadRequest},
+		{name: "empty range", method: http.MethodGet, query: "?city=wroclaw&from=2025-06-02&to=2025-06-01", wantStatus: http.StatusBadRequest},
+		{
+			name:       "current with next page",
+			method:     http.MethodGet,
+			query:      "?city=wroclaw&from=2025-06-01&to=2025-06-02&limit=1",
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, testCfg *testAPIConfig, response HistoryResponse) {
+				if response.Type != historyTypeCurrent || len(response.Weather) != 1 || response.Hourly != nil || response.Daily != nil {
+					t.Fatalf("unexpected response: %+v", response)
+				}
+				if response.Weather[0].ConditionCode != conditionRain || response.Weather[0].Temperature != 18.5 {
+					t.Errorf("unexpected entry: %+v", response.Weather[0])
+				}
+				cursor, err := decodeHistoryCursor(response.NextCursor)
+				if err != nil || !cursor.at.Equal(currentRows[0].UpdatedAt) || cursor.id != currentRows[0].ID {
+					t.Errorf("unexpected next cursor %+v (err %v)", cursor, err)
+				}
+				if len(response.Attribution) != 1 {
+					t.Errorf("expected attribution for Open-Meteo, got %+v", response.Attribution)
+				}
+			},
+		},
+		{
+			name:       "current last page",
+			method:     http.MethodGet,
+			query:      "?city=wroclaw&cursor=" + historyCursor{at: observedAt, id: uuid.New()}.encode(),
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, testCfg *testAPIConfig, response HistoryResponse) {
+				if len(response.Weather) != 2 || response.NextCursor != "" {
+					t.Errorf("unexpected response: %+v", response)
+				}
+			},
+		},
+		{
+			name:       "hourly",
+			method:     http.MethodGet,
+			query:      "?city=wroclaw&type=hourly",
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, testCfg *testAPIConfig, response HistoryResponse) {
+				if len(response.Hourly) != 1 || response.Hourly[0].IssuedAt == "" || response.Weather != nil {
+					t.Errorf("unexpected response: %+v", response)
+				}
+			},
+		},
+		{
+			name:       "daily",
+			method:     http.MethodGet,
+			query:      "?city=wroclaw&type=daily",
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, testCfg *testAPIConfig, response HistoryResponse) {
+				if len(response.Daily) != 1 || response.Daily[0].ForecastDate != "2025-06-01" || response.Daily[0].ConditionCode != conditionRain {
+					t.Errorf("unexpected response: %+v", response)
+				}
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testCfg := newTestAPIConfig(t)
+			testCfg.mockDB.GetLocationByAliasFunc = func(ctx context.Context, alias string) (database.Location, error) {
+				return MockDBLocation, nil
+			}
+			testCfg.mockDB.ListCurrentWeatherHistoryFunc = func(ctx context.Context, arg database.ListCurrentWeatherHistoryParams) ([]database.CurrentWeatherHistory, error) {
+				if arg.LocationID != MockDBLocation.ID || !arg.FromTime.Before(arg.ToTime) {
+					t.Errorf("unexpected query parameters: %+v", arg)
+				}
+				if int(arg.RowLimit) <= len(currentRows) {
+					return currentRows[:arg.RowLimit], nil
+				}
+				return currentRows, nil
+			}
+			testCfg.mockDB.ListHourlyForecastHistoryFunc = func(ctx context.Context, arg database.ListHourlyForecastHistoryParams) ([]database.HourlyForecastHistory, error) {
+				return []database.HourlyForecastHistory{{ID: uuid.New(), SourceApi: "test1", ForecastDatetimeUtc: observedAt, UpdatedAt: observedAt.Add(-time.Hour)}}, nil
+			}
+			testCfg.mockDB.ListDailyForecastHistoryFunc = func(ctx context.Context, arg database.ListDailyForecastHistoryParams) ([]database.DailyForecastHistory, error) {
+				if arg.FromTime.Hour() != 0 || arg.ToTime.Hour() != 0 {
+					t.Errorf("expected dates at midnight, got %v to %v", arg.FromTime, arg.ToTime)
+				}
+				return []database.DailyForecastHistory{{
+					ID:              uuid.New(),
+					SourceApi:       "test1",
+					ForecastDate:    time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC),
+					UpdatedAt:       observedAt,
+					PrecipitationMm: sql.NullFloat64{Float64: 4, Valid: true},
+					MaxTempC:        sql.NullFloat64{Float64: 20, Valid: true},
+				}}, nil
+			}
+
+			req := httptest.NewRequest(tc.method, "/api/history"+tc.query, nil)
+			rr := httptest.NewRecorder()
+			testCfg.apiConfig.handlerHistory(rr, req)
+
+			if rr.Code != tc.wantStatus {
+				t.Fatalf("status = %d, want %d; body: %s", rr.Code, tc.wantStatus, rr.Body.String())
+			}
+			if tc.check == nil {
+				return
+			}
+			var response HistoryResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			tc.check(t, testCfg, response)
+		})
+	}
+}
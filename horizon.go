@@ -0,0 +1,222 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/cor0nius/willitrain/internal/database"
+)
+
+// This file implements the forecast horizon: how many days of daily forecasts and hours of
+// hourly forecasts are fetched, stored and served. FORECAST_DAILY_DAYS and FORECAST_HOURLY_HOURS
+// set the horizon of the providers' requests, the parsers, the database queries and the cache
+// keys. Clients can ask for a shorter horizon with ?days= and ?hours=, which is cut from the
+// stored forecast, and for a window of the hourly forecast with ?from=, ?to= and ?limit=, which
+// is selected by the database query. Providers that forecast fewer days or hours than configured
+// return what they have.
+
+const (
+	defaultForecastDays  = 5
+	defaultForecastHours = 24
+
+	// maxForecastDays and maxForecastHours are the longest horizons any provider offers:
+	// Open-Meteo forecasts 16 days and Google 240 hours.
+	maxForecastDays  = 16
+	maxForecastHours = 240
+
+	// gmpMaxForecastDays is the number of days Google returns on one page, and
+	// gmpMaxForecastHours the number of hours. Further pages are not requested.
+	gmpMaxForecastDays  = 10
+	gmpMaxForecastHours = 24
+)
+
+// getForecastDays reads FORECAST_DAILY_DAYS, the number of days of daily forecasts.
+func getForecastDays(logger *slog.Logger) int {
+	days := getEnvAsInt("FORECAST_DAILY_DAYS", defaultForecastDays, logger)
+	if days < 1 || days > maxForecastDays {
+		logger.Warn("FORECAST_DAILY_DAYS must be between 1 and 16, using default", "value", days)
+		return defaultForecastDays
+	}
+	return days
+}
+
+// getForecastHours reads FORECAST_HOURLY_HOURS, the number of hours of hourly forecasts.
+func getForecastHours(logger *slog.Logger) int {
+	hours := getEnvAsInt("FORECAST_HOURLY_HOURS", defaultForecastHours, logger)
+	if hours < 1 || hours > maxForecastHours {
+		logger.Warn("FORECAST_HOURLY_HOURS must be between 1 and 240, using default", "value", hours)
+		return defaultForecastHours
+	}
+	return hours
+}
+
+// dailyForecastDays returns the configured number of days of daily forecasts. An unset horizon
+// selects the default.
+func (cfg *apiConfig) dailyForecastDays() int {
+	if cfg.forecastDays == 0 {
+		return defaultForecastDays
+	}
+	return cfg.forecastDays
+}
+
+// hourlyForecastHours returns the configured number of hours of hourly forecasts. An unset
+// horizon selects the default.
+func (cfg *apiConfig) hourlyForecastHours() int {
+	if cfg.forecastHours == 0 {
+		return defaultForecastHours
+	}
+	return cfg.forecastHours
+}
+
+// withHorizon binds a horizon to a forecast parser, so that it can be used as a forecastProvider
+// parser.
+func withHorizon[T Forecast](parse func(io.Reader, *slog.Logger, int) (T, string, error), horizon int) func(io.Reader, *slog.Logger) (T, string, error) {
+	return func(body io.Reader, logger *slog.Logger) (T, string, error) {
+		return parse(body, logger, horizon)
+	}
+}
+
+// requestHorizon parses the query parameter name of r as a horizon between 1 and limit. A
+// missing parameter selects limit.
+func requestHorizon(r *http.Request, name string, limit int) (int, error) {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		return limit, nil
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 1 || n > limit {
+		return 0, fmt.Errorf("%s must be a number between 1 and %d", name, limit)
+	}
+	return n, nil
+}
+
+// limitDailyForecast keeps the entries of the first days forecast dates.
+func limitDailyForecast(forecast []DailyForecast, days int) []DailyForecast {
+	dates := make(map[string]bool)
+	for _, f := range forecast {
+		dates[f.ForecastDate.Format(time.DateOnly)] = true
+	}
+	if len(dates) <= days {
+		return forecast
+	}
+	sorted := make([]string, 0, len(dates))
+	for date := range dates {
+		sorted = append(sorted, date)
+	}
+	sort.Strings(sorted)
+	last := sorted[days-1]
+
+	var limited []DailyForecast
+	for _, f := range forecast {
+		if f.ForecastDate.Format(time.DateOnly) <= last {
+			limited = append(limited, f)
+		}
+	}
+	return limited
+}
+
+// hourlyWindow selects the entries of an hourly forecast whose forecast time is in [from, to),
+// for the first limit forecast times.
+type hourlyWindow struct {
+	from  time.Time
+	to    time.Time
+	limit int
+}
+
+// newHourlyWindow returns the window of the forecast times in [from, to), limited to the first
+// limit of them. The window starts at the hour of from, whose forecast is in effect at from, and
+// never reaches before the current hour or beyond hours from it.
+func newHourlyWindow(from, to time.Time, limit, hours int, now time.Time) hourlyWindow {
+	hour := now.Truncate(time.Hour)
+	end := hour.Add(time.Duration(hours) * time.Hour)
+	return hourlyWindow{
+		from:  latestTime(from.Truncate(time.Hour), hour).UTC(),
+		to:    earliestTime(to, end).UTC(),
+		limit: limit,
+	}
+}
+
+// latestTime returns the later of two times, and earliestTime the earlier.
+func latestTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+func earliestTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+// apply keeps the entries of a sorted hourly forecast that are in the window.
+func (w hourlyWindow) apply(forecast []HourlyForecast) []HourlyForecast {
+	var selected []HourlyForecast
+	times := 0
+	for i, f := range forecast {
+		if f.ForecastDateTime.Before(w.from) || !f.ForecastDateTime.Before(w.to) {
+			continue
+		}
+		if i == 0 || !f.ForecastDateTime.Equal(forecast[i-1].ForecastDateTime) {
+			if times == w.limit {
+				break
+			}
+			times++
+		}
+		selected = append(selected, f)
+	}
+	return selected
+}
+
+// getHourlyForecastWindow returns the hourly forecasts of a location in a window. Stored
+// forecasts that are still fresh are read with the window applied by the database query;
+// otherwise the full forecast is loaded through the cache, fetching it if needed, and cut to the
+// window.
+func (cfg *apiConfig) getHourlyForecastWindow(ctx context.Context, location Location, window hourlyWindow) ([]HourlyForecast, error) {
+	rows, err := cfg.dbQueries.GetUpcomingHourlyForecastsAtLocation(ctx, database.GetUpcomingHourlyForecastsAtLocationParams{
+		LocationID: location.LocationID,
+		FromTime:   window.from,
+		ToTime:     window.to,
+		MaxHours:   int32(window.limit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not get hourly forecasts: %w", err)
+	}
+	var fresh []HourlyForecast
+	for _, row := range rows {
+		if row.UpdatedAt.After(time.Now().UTC().Add(-hourlyForecastCacheTTL)) {
+			fresh = append(fresh, databaseHourlyForecastToHourlyForecast(row, location))
+		}
+	}
+	if fresh = filterEnabledSources(cfg, fresh); len(fresh) > 0 {
+		cacheLookups.WithLabelValues(hourlyForecastCacheKeyPrefix, "db_hit").Inc()
+		return fresh, nil
+	}
+
+	forecast, err := cfg.getCachedOrFetchHourlyForecast(ctx, location)
+	if err != nil {
+		return nil, err
+	}
+	sortHourlyForecast(forecast)
+	return window.apply(forecast), nil
+}
+
+// limitHourlyForecast keeps the entries that start within hours of the current hour.
+func limitHourlyForecast(forecast []HourlyForecast, hours int, now time.Time) []HourlyForecast {
+	end := now.Truncate(time.Hour).Add(time.Duration(hours) * time.Hour)
+	var limited []HourlyForecast
+	for _, f := range forecast {
+		if f.ForecastDateTime.Before(end) {
+			limited = append(limited, f)
+		}
+	}
+	return limited
+}
@@ -0,0 +1,307 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cor0nius/willitrain/internal/database"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestGetForecastHorizon(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	testCases := []struct {
+		value     string
+		wantDays  int
+		wantHours int
+	}{
+		{value: "", wantDays: defaultForecastDays, wantHours: defaultForecastHours},
+		{value: "7", wantDays: 7, wantHours: 7},
+		{value: "16", wantDays: 16, wantHours: 16},
+		{value: "48", wantDays: defaultForecastDays, wantHours: 48},
+		{value: "0", wantDays: defaultForecastDays, wantHours: defaultForecastHours},
+		{value: "500", wantDays: defaultForecastDays, wantHours: defaultForecastHours},
+		{value: "week", wantDays: defaultForecastDays, wantHours: defaultForecastHours},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.value, func(t *testing.T) {
+			t.Setenv("FORECAST_DAILY_DAYS", tc.value)
+			t.Setenv("FORECAST_HOURLY_HOURS", tc.value)
+			if got := getForecastDays(logger); got != tc.wantDays {
+				t.Errorf("getForecastDays() = %d, want %d", got, tc.wantDays)
+			}
+			if got := getForecastHours(logger); got != tc.wantHours {
+				t.Errorf("getForecastHours() = %d, want %d", got, tc.wantHours)
+			}
+		})
+	}
+}
+
+func TestRequestHorizon(t *testing.T) {
+	testCases := []struct {
+		query   string
+		want    int
+		wantErr bool
+	}{
+		{query: "", want: 7},
+		{query: "days=1", want: 1},
+		{query: "days=7", want: 7},
+		{query: "days=0", wantErr: true},
+		{query: "days=8", wantErr: true},
+		{query: "days=three", wantErr: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.query, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/?"+tc.query, nil)
+			got, err := requestHorizon(req, "days", 7)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("expected error: %v, got: %v", tc.wantErr, err)
+			}
+			if !tc.wantErr && got != tc.want {
+				t.Errorf("requestHorizon() = %d, want %d", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestLimitForecast(t *testing.T) {
+	day := time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)
+	daily := []DailyForecast{
+		{SourceAPI: "a", ForecastDate: day},
+		{SourceAPI: "b", ForecastDate: day},
+		{SourceAPI: "a", ForecastDate: day.AddDate(0, 0, 1)},
+		{SourceAPI: "a", ForecastDate: day.AddDate(0, 0, 2)},
+		{SourceAPI: "b", ForecastDate: day.AddDate(0, 0, 1)},
+	}
+	if got := limitDailyForecast(daily, 2); len(got) != 4 {
+		t.Errorf("expected the entries of 2 days, got %+v", got)
+	}
+	if got := limitDailyForecast(daily, 3); len(got) != len(daily) {
+		t.Errorf("expected all entries, got %+v", got)
+	}
+
+	now := time.Date(2025, 7, 1, 10, 30, 0, 0, time.UTC)
+	var hourly []HourlyForecast
+	for i := 0; i < 48; i++ {
+		hourly = append(hourly, HourlyForecast{ForecastDateTime: now.Truncate(time.Hour).Add(time.Duration(i) * time.Hour)})
+	}
+	if got := limitHourlyForecast(hourly, 6, now); len(got) != 6 {
+		t.Errorf("expected 6 hours, got %d", len(got))
+	}
+}
+
+func TestParseForecastHorizon(t *testing.T) {
+	for _, days := range []int{3, 8} {
+		sampleJSON, err := testData.Open("testdata/daily_forecast_owm.json")
+		if err != nil {
+			t.Fatalf("failed to open test data: %v", err)
+		}
+		forecast, _, err := ParseDailyForecastOWM(sampleJSON, slog.Default(), days)
+		sampleJSON.Close()
+		if err != nil {
+			t.Fatalf("ParseDailyForecastOWM failed with error: %v", err)
+		}
+		if len(forecast) != days {
+			t.Errorf("expected %d days, got %d", days, len(forecast))
+		}
+	}
+
+	for _, hours := range []int{6, 48} {
+		sampleJSON, err := testData.Open("testdata/hourly_forecast_owm.json")
+		if err != nil {
+			t.Fatalf("failed to open test data: %v", err)
+		}
+		forecast, _, err := withHorizon(ParseHourlyForecastOWM, hours)(sampleJSON, slog.Default())
+		sampleJSON.Close()
+		if err != nil {
+			t.Fatalf("ParseHourlyForecastOWM failed with error: %v", err)
+		}
+		if len(forecast) != hours {
+			t.Errorf("expected %d hours, got %d", hours, len(forecast))
+		}
+	}
+}
+
+func TestHandlerForecastHorizon(t *testing.T) {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	var dbForecast []database.DailyForecast
+	for i := 0; i < 7; i++ {
+		dbForecast = append(dbForecast, database.DailyForecast{
+			ID:           uuid.New(),
+			LocationID:   MockDBLocation.ID,
+			SourceApi:    "Open-Meteo API",
+			ForecastDate: today.AddDate(0, 0, i),
+			UpdatedAt:    time.Now().UTC(),
+		})
+	}
+
+	testCases := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantDays   int
+	}{
+		{name: "configured horizon", wantStatus: http.StatusOK, wantDays: 7},
+		{name: "shorter horizon", query: "&days=3", wantStatus: http.StatusOK, wantDays: 3},
+		{name: "longer than configured", query: "&days=8", wantStatus: http.StatusBadRequest},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testCfg := newTestAPIConfig(t)
+			testCfg.apiConfig.enabledSources = map[string]bool{"ometeo": true}
+			testCfg.apiConfig.forecastDays = 7
+			testCfg.mockDB.GetLocationByAliasFunc = func(ctx context.Context, alias string) (database.Location, error) {
+				return MockDBLocation, nil
+			}
+			testCfg.mockCache.GetFunc = func(ctx context.Context, key string) (string, error) {
+				if key != "dailyforecast:"+MockDBLocation.ID.String()+":7" {
+					t.Errorf("unexpected cache key %q", key)
+				}
+				return "", redis.Nil
+			}
+			testCfg.mockCache.SetFunc = func(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+				return nil
+			}
+			testCfg.mockDB.GetUpcomingDailyForecastsAtLocationFunc = func(ctx context.Context, arg database.GetUpcomingDailyForecastsAtLocationParams) ([]database.DailyForecast, error) {
+				if !arg.FromDate.Equal(today) || !arg.ToDate.Equal(today.AddDate(0, 0, 7)) {
+					t.Errorf("unexpected query range %v to %v", arg.FromDate, arg.ToDate)
+				}
+				return dbForecast, nil
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/?city=wroclaw"+tc.query, nil)
+			rr := httptest.NewRecorder()
+			testCfg.apiConfig.handlerDailyForecast(rr, req)
+
+			if rr.Code != tc.wantStatus {
+				t.Fatalf("status = %d, want %d; body: %s", rr.Code, tc.wantStatus, rr.Body.String())
+			}
+			if tc.wantStatus != http.StatusOK {
+				return
+			}
+			var response DailyForecastsResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+				t.Fatalf("could not unmarshal response: %v", err)
+			}
+			if len(response.Forecasts) != tc.wantDays {
+				t.Errorf("expected %d days, got %d", tc.wantDays, len(response.Forecasts))
+			}
+		})
+	}
+}
+
+func TestHourlyWindow(t *testing.T) {
+	now := time.Date(2025, 7, 1, 10, 30, 0, 0, time.UTC)
+	window := newHourlyWindow(now.Add(-2*time.Hour), now.Add(72*time.Hour), 48, 48, now)
+	if !window.from.Equal(time.Date(2025, 7, 1, 10, 0, 0, 0, time.UTC)) || !window.to.Equal(time.Date(2025, 7, 3, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected the window to be clamped to the horizon, got %v to %v", window.from, window.to)
+	}
+
+	var hourly []HourlyForecast
+	for i := 0; i < 12; i++ {
+		at := time.Date(2025, 7, 1, 10+i, 0, 0, 0, time.UTC)
+		hourly = append(hourly, HourlyForecast{SourceAPI: "a", ForecastDateTime: at}, HourlyForecast{SourceAPI: "b", ForecastDateTime: at})
+	}
+	afternoon := newHourlyWindow(time.Date(2025, 7, 1, 13, 0, 0, 0, time.UTC), time.Date(2025, 7, 1, 18, 0, 0, 0, time.UTC), 48, 48, now)
+	if got := afternoon.apply(hourly); len(got) != 10 || got[0].ForecastDateTime.Hour() != 13 || got[9].ForecastDateTime.Hour() != 17 {
+		t.Errorf("expected the entries from 13:00 to 17:00, got %+v", got)
+	}
+	next := newHourlyWindow(now, now.Add(48*time.Hour), 3, 48, now)
+	if got := next.apply(hourly); len(got) != 6 || got[0].ForecastDateTime.Hour() != 10 || got[5].ForecastDateTime.Hour() != 12 {
+		t.Errorf("expected the entries of 3 hours from the current hour, got %+v", got)
+	}
+	// Halfway through the hour, the current hour's entry is still in effect.
+	current := newHourlyWindow(now, now.Add(48*time.Hour), 1, 48, now)
+	if got := current.apply(hourly); len(got) != 2 || got[0].ForecastDateTime.Hour() != 10 {
+		t.Errorf("expected the entries of the current hour, got %+v", got)
+	}
+}
+
+func TestHandlerHourlyForecastWindow(t *testing.T) {
+	now := time.Now().UTC()
+	var dbForecast []database.HourlyForecast
+	for i := 1; i <= 6; i++ {
+		dbForecast = append(dbForecast, database.HourlyForecast{
+			ID:                  uuid.New(),
+			LocationID:          MockDBLocation.ID,
+			SourceApi:           "Open-Meteo API",
+			ForecastDatetimeUtc: now.Truncate(time.Hour).Add(time.Duration(i) * time.Hour),
+			UpdatedAt:           now,
+		})
+	}
+	from := now.Truncate(time.Hour).Add(2 * time.Hour)
+	to := from.Add(3 * time.Hour)
+
+	testCases := []struct {
+		name         string
+		query        string
+		stale        bool
+		wantStatus   int
+		wantFrom     time.Time
+		wantTo       time.Time
+		wantMaxHours int32
+		wantHours    int
+	}{
+		{name: "limit", query: "&limit=3", wantStatus: http.StatusOK, wantFrom: now.Truncate(time.Hour), wantTo: now.Truncate(time.Hour).Add(48 * time.Hour), wantMaxHours: 3, wantHours: 3},
+		{name: "from and to", query: "&from=" + from.Format(time.RFC3339) + "&to=" + to.Format(time.RFC3339), wantStatus: http.StatusOK, wantFrom: from, wantTo: to, wantMaxHours: 48, wantHours: 6},
+		{name: "stale rows", query: "&from=" + from.Format(time.RFC3339) + "&to=" + to.Format(time.RFC3339), stale: true, wantStatus: http.StatusOK, wantFrom: from, wantTo: to, wantMaxHours: 48, wantHours: 3},
+		{name: "invalid from", query: "&from=soon", wantStatus: http.StatusBadRequest},
+		{name: "invalid to", query: "&to=later", wantStatus: http.StatusBadRequest},
+		{name: "from after to", query: "&from=" + to.Format(time.RFC3339) + "&to=" + from.Format(time.RFC3339), wantStatus: http.StatusBadRequest},
+		{name: "invalid limit", query: "&limit=0", wantStatus: http.StatusBadRequest},
+		{name: "limit beyond horizon", query: "&limit=49", wantStatus: http.StatusBadRequest},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testCfg := newTestAPIConfig(t)
+			testCfg.apiConfig.enabledSources = map[string]bool{"ometeo": true}
+			testCfg.apiConfig.forecastHours = 48
+			testCfg.mockDB.GetLocationByAliasFunc = func(ctx context.Context, alias string) (database.Location, error) {
+				return MockDBLocation, nil
+			}
+			testCfg.mockCache.SetFunc = func(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+				return nil
+			}
+			testCfg.mockDB.GetUpcomingHourlyForecastsAtLocationFunc = func(ctx context.Context, arg database.GetUpcomingHourlyForecastsAtLocationParams) ([]database.HourlyForecast, error) {
+				if tc.stale && !arg.FromTime.Equal(tc.wantFrom) {
+					// The full forecast loaded after the window found only stale rows.
+					return dbForecast, nil
+				}
+				if arg.FromTime.Sub(tc.wantFrom).Abs() > time.Second || !arg.ToTime.Equal(tc.wantTo) || arg.MaxHours != tc.wantMaxHours {
+					t.Errorf("unexpected query window %v to %v, limit %d", arg.FromTime, arg.ToTime, arg.MaxHours)
+				}
+				if tc.stale {
+					stale := dbForecast[0]
+					stale.UpdatedAt = now.Add(-2 * hourlyForecastCacheTTL)
+					return []database.HourlyForecast{stale}, nil
+				}
+				return dbForecast[:min(len(dbForecast), int(arg.MaxHours))], nil
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/?city=wroclaw"+tc.query, nil)
+			rr := httptest.NewRecorder()
+			testCfg.apiConfig.handlerHourlyForecast(rr, req)
+
+			if rr.Code != tc.wantStatus {
+				t.Fatalf("status = %d, want %d; body: %s", rr.Code, tc.wantStatus, rr.Body.String())
+			}
+			if tc.wantStatus != http.StatusOK {
+				return
+			}
+			var response HourlyForecastsResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+				t.Fatalf("could not unmarshal response: %v", err)
+			}
+			if len(response.Forecasts) != tc.wantHours {
+				t.Errorf("expected %d hours, got %d", tc.wantHours, len(response.Forecasts))
+			}
+		})
+	}
+}
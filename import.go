@@ -0,0 +1,337 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cor0nius/willitrain/internal/database"
+	"github.com/google/uuid"
+)
+
+// This file implements the bulk import of past observations. The observation history of a
+// location normally comes from the Open-Meteo archive backfill and grows as the application
+// runs; operators can also seed it with observations recorded elsewhere, such as by a personal
+// weather station, so that forecasts can be compared against them. An import is validated as a
+// whole and stored in a single transaction, so that a rejected file leaves no partial data.
+
+const (
+	// defaultImportSource is the SourceAPI stored with imported observations if the request does
+	// not name their source.
+	defaultImportSource = "Import"
+
+	maxImportBytes        = 8 << 20
+	maxImportObservations = 50000
+	// maxImportErrors is the number of invalid rows reported in a rejected import.
+	maxImportErrors = 20
+	// maxImportSourceLength bounds the source name, which is shown with the observations.
+	maxImportSourceLength = 64
+)
+
+// Plausible ranges of imported measurements. Values outside them are more likely unit mix-ups
+// or sensor faults than weather.
+const (
+	minImportTemperatureC    = -90.0
+	maxImportTemperatureC    = 60.0
+	maxImportWindSpeedKmh    = 500.0
+	maxImportPrecipitationMm = 500.0
+)
+
+// importCSVColumns are the columns a CSV import may have. timestamp is required; the others
+// may be omitted or left empty.
+var importCSVColumns = map[string]bool{
+	"timestamp": true, "temperature_c": true, "humidity": true, "wind_speed_kmh": true, "precipitation_mm": true, "condition_text": true,
+}
+
+// @Summary      Import past observations
+// @Description  Validates past observations of a location, given as a JSON list or as CSV with a header row
+// @Description  (Content-Type text/csv), and stores them as observations from the given source, replacing stored
+// @Description  observations of that source at the same times. Each observation has an RFC 3339 timestamp and
+// @Description  at least one of temperature_c, humidity, wind_speed_kmh, precipitation_mm and condition_text.
+// @Description  If any observation is invalid, nothing is stored and the invalid rows are listed, counted from 1
+// @Description  without the CSV header. Imports are audit-logged.
+// @Tags         admin
+// @Accept       json
+// @Accept       text/csv
+// @Produce      json
+// @Param        city    query     string  false  "Location name to search for (e.g., 'London')"
+// @Param        lat     query     number  false  "Latitude for the location (e.g., 51.5074)"
+// @Param        lon     query     number  false  "Longitude for the location (e.g., -0.1278)"
+// @Param        source  query     string  false  "Source stored with the observations, such as the station name (default 'Import')"
+// @Param        observations  body  []ImportObservationJSON  true  "Observations to import"
+// @Success      200  {object}  ImportResponse
+// @Failure      400  {object}  ImportErrorResponse "Bad Request - Invalid location, source or observations"
+// @Failure      413  {object}  ErrorResponse "Request Entity Too Large - More than 8 MiB or 50000 observations"
+// @Failure      415  {object}  ErrorResponse "Unsupported Media Type - Body is neither JSON nor CSV"
+// @Failure      500  {object}  ErrorResponse "Internal Server Error - Failed to store observations"
+// @Security     ApiKeyAuth
+// @Failure      401  {object}  ErrorResponse "Unauthorized - Missing API key"
+// @Failure      403  {object}  ErrorResponse "Forbidden - Invalid API key"
+// @Router       /admin/import [post]
+func (cfg *apiConfig) handlerImportObservations(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if r.Method != http.MethodPost {
+		cfg.respondWithError(w, http.StatusMethodNotAllowed, "Method Not Allowed", nil)
+		return
+	}
+
+	source := strings.TrimSpace(r.URL.Query().Get("source"))
+	if source == "" {
+		source = defaultImportSource
+	}
+	if len(source) > maxImportSourceLength {
+		cfg.respondWithError(w, http.StatusBadRequest, fmt.Sprintf("source must be at most %d characters", maxImportSourceLength), nil)
+		return
+	}
+
+	mediaType := "application/json"
+	if ct := r.Header.Get("Content-Type"); ct != "" {
+		var err error
+		if mediaType, _, err = mime.ParseMediaType(ct); err != nil {
+			cfg.respondWithError(w, http.StatusUnsupportedMediaType, "Invalid Content-Type", err)
+			return
+		}
+	}
+	body := http.MaxBytesReader(w, r.Body, maxImportBytes)
+	var rows []ImportObservationJSON
+	var err error
+	switch mediaType {
+	case "application/json":
+		rows, err = decodeImportJSON(body)
+	case "text/csv":
+		rows, err = decodeImportCSV(body)
+	default:
+		cfg.respondWithError(w, http.StatusUnsupportedMediaType, fmt.Sprintf("Unsupported Content-Type %q, must be application/json or text/csv", mediaType), nil)
+		return
+	}
+	if err != nil {
+		if maxErr := (*http.MaxBytesError)(nil); errors.As(err, &maxErr) || errors.Is(err, errTooManyObservations) {
+			cfg.respondWithError(w, http.StatusRequestEntityTooLarge, "Import too large", err)
+			return
+		}
+		cfg.respondWithError(w, http.StatusBadRequest, "Invalid import body", err)
+		return
+	}
+	if len(rows) == 0 {
+		cfg.respondWithError(w, http.StatusBadRequest, "Import contains no observations", nil)
+		return
+	}
+
+	location, err := cfg.getLocationFromRequest(r)
+	if err != nil {
+		cfg.respondWithError(w, http.StatusBadRequest, "Error getting location data", err)
+		return
+	}
+
+	observations, rowErrors := validateImportObservations(rows, location.LocationID, source, time.Now())
+	if len(rowErrors) > 0 {
+		cfg.respondWithJSON(w, http.StatusBadRequest, ImportErrorResponse{
+			Error:     fmt.Sprintf("%d of %d observations are invalid, nothing was imported", len(rowErrors), len(rows)),
+			RequestID: responseRequestID(w),
+			Rows:      rowErrors[:min(len(rowErrors), maxImportErrors)],
+		})
+		return
+	}
+
+	err = cfg.runInTx(ctx, func(q dbQuerier) error {
+		for _, o := range observations {
+			if err := q.UpsertWeatherObservation(ctx, o); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		cfg.respondWithError(w, http.StatusInternalServerError, "Failed to store observations", err)
+		return
+	}
+	observationsImported.Add(float64(len(observations)))
+
+	from, to := observations[0].ObservedAt, observations[0].ObservedAt
+	for _, o := range observations[1:] {
+		if o.ObservedAt.Before(from) {
+			from = o.ObservedAt
+		}
+		if o.ObservedAt.After(to) {
+			to = o.ObservedAt
+		}
+	}
+	response := ImportResponse{
+		Location: location,
+		Source:   source,
+		Imported: len(observations),
+		From:     from.Format(time.RFC3339),
+		To:       to.Format(time.RFC3339),
+	}
+	cfg.logger.Info("audit: observations imported",
+		"city", location.CityName,
+		"location_id", location.LocationID,
+		"source", source,
+		"observations", len(observations),
+		"from", response.From,
+		"to", response.To,
+		"remote_addr", r.RemoteAddr,
+	)
+	cfg.respondWithJSON(w, http.StatusOK, response)
+}
+
+// errTooManyObservations is returned for imports of more than maxImportObservations rows.
+var errTooManyObservations = fmt.Errorf("an import may contain at most %d observations", maxImportObservations)
+
+// decodeImportJSON decodes a JSON list of observations.
+func decodeImportJSON(body io.Reader) ([]ImportObservationJSON, error) {
+	dec := json.NewDecoder(body)
+	dec.DisallowUnknownFields()
+	var rows []ImportObservationJSON
+	if err := dec.Decode(&rows); err != nil {
+		return nil, err
+	}
+	if len(rows) > maxImportObservations {
+		return nil, errTooManyObservations
+	}
+	return rows, nil
+}
+
+// decodeImportCSV decodes CSV observations. The header row names the columns, in any order;
+// empty fields are missing values. Fields that are not numbers where numbers are expected are
+// kept in Invalid, so that they are reported with the row's other errors.
+func decodeImportCSV(body io.Reader) ([]ImportObservationJSON, error) {
+	reader := csv.NewReader(body)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	for i, column := range header {
+		column = strings.ToLower(strings.TrimSpace(column))
+		if !importCSVColumns[column] {
+			return nil, fmt.Errorf("unknown column %q", column)
+		}
+		header[i] = column
+	}
+	if !slices.Contains(header, "timestamp") {
+		return nil, errors.New("missing timestamp column")
+	}
+
+	var rows []ImportObservationJSON
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(rows) == maxImportObservations {
+			return nil, errTooManyObservations
+		}
+		var row ImportObservationJSON
+		for i, field := range record {
+			field = strings.TrimSpace(field)
+			if field == "" {
+				continue
+			}
+			switch header[i] {
+			case "timestamp":
+				row.Timestamp = field
+			case "temperature_c":
+				row.Temperature = parseImportFloat(field, header[i], &row)
+			case "humidity":
+				if v := parseImportFloat(field, header[i], &row); v != nil {
+					humidity := int32(*v)
+					if float64(humidity) != *v {
+						row.invalid = append(row.invalid, "humidity must be a whole number")
+					}
+					row.Humidity = &humidity
+				}
+			case "wind_speed_kmh":
+				row.WindSpeed = parseImportFloat(field, header[i], &row)
+			case "precipitation_mm":
+				row.Precipitation = parseImportFloat(field, header[i], &row)
+			case "condition_text":
+				row.Condition = &field
+			}
+		}
+		rows = append(rows, row)
+	}
+}
+
+// parseImportFloat parses a numeric CSV field, recording an error with the row if it is not a
+// number.
+func parseImportFloat(field, column string, row *ImportObservationJSON) *float64 {
+	v, err := strconv.ParseFloat(field, 64)
+	if err != nil {
+		row.invalid = append(row.invalid, fmt.Sprintf("%s %q is not a number", column, field))
+		return nil
+	}
+	return &v
+}
+
+// validateImportObservations checks every imported observation and converts the valid ones to
+// rows of the location and source. It returns the errors of all invalid rows.
+func validateImportObservations(rows []ImportObservationJSON, locationID uuid.UUID, source string, now time.Time) ([]database.UpsertWeatherObservationParams, []ImportRowError) {
+	observations := make([]database.UpsertWeatherObservationParams, 0, len(rows))
+	var rowErrors []ImportRowError
+	seen := make(map[time.Time]int, len(rows))
+	for i, row := range rows {
+		problems := append([]string(nil), row.invalid...)
+		observedAt, err := time.Parse(time.RFC3339, row.Timestamp)
+		switch {
+		case row.Timestamp == "":
+			problems = append(problems, "timestamp is required")
+		case err != nil:
+			problems = append(problems, fmt.Sprintf("timestamp %q is not an RFC 3339 timestamp", row.Timestamp))
+		case observedAt.After(now):
+			problems = append(problems, "timestamp is in the future")
+		default:
+			observedAt = observedAt.UTC().Truncate(time.Second)
+			if first, ok := seen[observedAt]; ok {
+				problems = append(problems, fmt.Sprintf("timestamp repeats row %d", first))
+			}
+			seen[observedAt] = i + 1
+		}
+
+		if row.Temperature == nil && row.Humidity == nil && row.WindSpeed == nil && row.Precipitation == nil && row.Condition == nil && len(row.invalid) == 0 {
+			problems = append(problems, "no measurements")
+		}
+		if v := row.Temperature; v != nil && (*v < minImportTemperatureC || *v > maxImportTemperatureC) {
+			problems = append(problems, fmt.Sprintf("temperature_c %g is outside [%g, %g]", *v, minImportTemperatureC, maxImportTemperatureC))
+		}
+		if v := row.Humidity; v != nil && (*v < 0 || *v > 100) {
+			problems = append(problems, fmt.Sprintf("humidity %d is outside [0, 100]", *v))
+		}
+		if v := row.WindSpeed; v != nil && (*v < 0 || *v > maxImportWindSpeedKmh) {
+			problems = append(problems, fmt.Sprintf("wind_speed_kmh %g is outside [0, %g]", *v, maxImportWindSpeedKmh))
+		}
+		if v := row.Precipitation; v != nil && (*v < 0 || *v > maxImportPrecipitationMm) {
+			problems = append(problems, fmt.Sprintf("precipitation_mm %g is outside [0, %g]", *v, maxImportPrecipitationMm))
+		}
+
+		if len(problems) > 0 {
+			rowErrors = append(rowErrors, ImportRowError{Row: i + 1, Errors: problems})
+			continue
+		}
+		observations = append(observations, database.UpsertWeatherObservationParams{
+			LocationID:      locationID,
+			SourceApi:       source,
+			ObservedAt:      observedAt,
+			TemperatureC:    ptrToNullFloat64(row.Temperature),
+			Humidity:        ptrToNullInt32(row.Humidity),
+			WindSpeedKmh:    ptrToNullFloat64(row.WindSpeed),
+			PrecipitationMm: ptrToNullFloat64(row.Precipitation),
+			ConditionText:   ptrToNullString(row.Condition),
+		})
+	}
+	return observations, rowErrors
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cor0nius/willitrain/internal/database"
+	"github.com/google/uuid"
+)
+
+func TestDecodeImportCSV(t *testing.T) {
+	body := "Timestamp,temperature_c,humidity,condition_text\n" +
+		"2025-06-01T10:00:00Z,18.5,, light rain\n" +
+		"2025-06-01T11:00:00Z,warm,55.5,\n"
+	rows, err := decodeImportCSV(strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("decodeImportCSV() error = %v", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("got %d rows, want 2", len(rows))
+	}
+	if rows[0].Temperature == nil || *rows[0].Temperature != 18.5 || rows[0].Humidity != nil || rows[0].Condition == nil || *rows[0].Condition != "light rain" {
+		t.Errorf("unexpected first row: %+v", rows[0])
+	}
+	if len(rows[1].invalid) != 2 || rows[1].Temperature != nil {
+		t.Errorf("expected the invalid temperature and humidity to be recorded, got %+v", rows[1])
+	}
+
+	for name, body := range map[string]string{
+		"unknown column":    "timestamp,pressure_hpa\n2025-06-01T10:00:00Z,1013\n",
+		"missing timestamp": "temperature_c\n18.5\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := decodeImportCSV(strings.NewReader(body)); err == nil {
+				t.Errorf("expected an error")
+			}
+		})
+	}
+}
+
+func TestValidateImportObservations(t *testing.T) {
+	now := time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC)
+	temp := func(v float64) *float64 { return &v }
+	humidity := func(v int32) *int32 { return &v }
+
+	testCases := []struct {
+		name     string
+		row      ImportObservationJSON
+		wantErrs int
+	}{
+		{name: "valid", row: ImportObservationJSON{Timestamp: "2025-06-01T12:00:00+02:00", Temperature: temp(18), Humidity: humidity(60)}},
+		{name: "missing timestamp", row: ImportObservationJSON{Temperature: temp(18)}, wantErrs: 1},
+		{name: "invalid timestamp", row: ImportObservationJSON{Timestamp: "2025-06-01 12:00", Temperature: temp(18)}, wantErrs: 1},
+		{name: "future", row: ImportObservationJSON{Timestamp: "2025-06-03T00:00:00Z", Temperature: temp(18)}, wantErrs: 1},
+		{name: "no measurements", row: ImportObservationJSON{Timestamp: "2025-06-01T09:00:00Z"}, wantErrs: 1},
+		{name: "out of range", row: ImportObservationJSON{Timestamp: "2025-06-01T08:00:00Z", Temperature: temp(65), Humidity: humidity(101)}, wantErrs: 2},
+		{name: "decoding errors", row: ImportObservationJSON{Timestamp: "2025-06-01T07:00:00Z", invalid: []string{"temperature_c \"warm\" is not a number"}}, wantErrs: 1},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			observations, rowErrors := validateImportObservations([]ImportObservationJSON{tc.row}, uuid.New(), "Station", now)
+			if tc.wantErrs == 0 {
+				if len(rowErrors) != 0 || len(observations) != 1 {
+					t.Fatalf("unexpected errors: %+v", rowErrors)
+				}
+				if want := time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC); !observations[0].ObservedAt.Equal(want) || observations[0].ObservedAt.Location() != time.UTC {
+					t.Errorf("observed at = %v, want %v in UTC", observations[0].ObservedAt, want)
+				}
+				if observations[0].SourceApi != "Station" || !observations[0].Humidity.Valid || observations[0].WindSpeedKmh.Valid {
+					t.Errorf("unexpected observation: %+v", observations[0])
+				}
+				return
+			}
+			if len(observations) != 0 || len(rowErrors) != 1 || rowErrors[0].Row != 1 || len(rowErrors[0].Errors) != tc.wantErrs {
+				t.Errorf("got observations %+v and errors %+v, want %d errors", observations, rowErrors, tc.wantErrs)
+			}
+		})
+	}
+
+	t.Run("repeated timestamp", func(t *testing.T) {
+		rows := []ImportObservationJSON{
+			{Timestamp: "2025-06-01T10:00:00Z", Temperature: temp(18)},
+			{Timestamp: "2025-06-01T12:00:00+02:00", Temperature: temp(19)},
+		}
+		_, rowErrors := validateImportObservations(rows, uuid.New(), "Station", now)
+		if len(rowErrors) != 1 || rowErrors[0].Row != 2 || !strings.Contains(rowErrors[0].Errors[0], "row 1") {
+			t.Errorf("unexpected errors: %+v", rowErrors)
+		}
+	})
+}
+
+func TestHandlerImportObservations(t *testing.T) {
+	testCases := []struct {
+		name        string
+		method      string
+		query       string
+		contentType string
+		body        string
+		wantStatus  int
+		wantStored  int
+	}{
+		{name: "method not allowed", method: http.MethodGet, query: "?city=wroclaw", wantStatus: http.StatusMethodNotAllowed},
+		{name: "unsupported content type", method: http.MethodPost, query: "?city=wroclaw", contentType: "application/xml", body: "<observations/>", wantStatus: http.StatusUnsupportedMediaType},
+		{name: "malformed JSON", method: http.MethodPost, query: "?city=wroclaw", body: `[{"timestamp": 1}]`, wantStatus: http.StatusBadRequest},
+		{name: "empty", method: http.MethodPost, query: "?city=wroclaw", body: `[]`, wantStatus: http.StatusBadRequest},
+		{name: "source too long", method: http.MethodPost, query: "?city=wroclaw&source=" + strings.Repeat("x", maxImportSourceLength+1), body: `[]`, wantStatus: http.StatusBadRequest},
+		{
+			name:       "JSON",
+			method:     http.MethodPost,
+			query:      "?city=wroclaw&source=Backyard",
+			body:       `[{"timestamp": "2025-06-01T11:00:00Z", "temperature_c": 18.5}, {"timestamp": "2025-06-01T10:00:00Z", "precipitation_mm": 0.4}]`,
+			wantStatus: http.StatusOK,
+			wantStored: 2,
+		},
+		{
+			name:        "CSV",
+			method:      http.MethodPost,
+			query:       "?city=wroclaw",
+			contentType: "text/csv; charset=utf-8",
+			body:        "timestamp,temperature_c,precipitation_mm\n2025-06-01T10:00:00Z,18.5,0\n",
+			wantStatus:  http.StatusOK,
+			wantStored:  1,
+		},
+		{
+			name:        "invalid row stores nothing",
+			method:      http.MethodPost,
+			query:       "?city=wroclaw",
+			contentType: "text/csv",
+			body:        "timestamp,temperature_c\n2025-06-01T10:00:00Z,18.5\n2025-06-01T11:00:00Z,150\n",
+			wantStatus:  http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testCfg := newTestAPIConfig(t)
+			testCfg.mockDB.GetLocationByAliasFunc = func(ctx context.Context, alias string) (database.Location, error) {
+				return MockDBLocation, nil
+			}
+			var stored []database.UpsertWeatherObservationParams
+			testCfg.mockDB.UpsertWeatherObservationFunc = func(ctx context.Context, arg database.UpsertWeatherObservationParams) error {
+				stored = append(stored, arg)
+				return nil
+			}
+
+			req := httptest.NewRequest(tc.method, "/admin/import"+tc.query, strings.NewReader(tc.body))
+			if tc.contentType != "" {
+				req.Header.Set("Content-Type", tc.contentType)
+			}
+			rr := httptest.NewRecorder()
+			testCfg.apiConfig.handlerImportObservations(rr, req)
+
+			if rr.Code != tc.wantStatus {
+				t.Fatalf("status = %d, want %d; body: %s", rr.Code, tc.wantStatus, rr.Body.String())
+			}
+			if len(stored) != tc.wantStored {
+				t.Errorf("stored %d observations, want %d", len(stored), tc.wantStored)
+			}
+			if tc.wantStatus != http.StatusOK {
+				return
+			}
+			var response ImportResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if response.Imported != tc.wantStored || response.Location.LocationID != MockDBLocation.ID || stored[0].LocationID != MockDBLocation.ID {
+				t.Errorf("unexpected response: %+v", response)
+			}
+			if tc.name == "JSON" && (response.Source != "Backyard" || response.From != "2025-06-01T10:00:00Z" || response.To != "2025-06-01T11:00:00Z") {
+				t.Errorf("unexpected source or range: %+v", response)
+			}
+		})
+	}
+
+	t.Run("invalid rows are reported", func(t *testing.T) {
+		testCfg := newTestAPIConfig(t)
+		testCfg.mockDB.GetLocationByAliasFunc = func(ctx context.Context, alias string) (database.Location, error) {
+			return MockDBLocation, nil
+		}
+		req := httptest.NewRequest(http.MethodPost, "/admin/import?city=wroclaw", strings.NewReader(`[{"timestamp": "2025-06-01T10:00:00Z", "humidity": 140}]`))
+		rr := httptest.NewRecorder()
+		testCfg.apiConfig.handlerImportObservations(rr, req)
+
+		var response ImportErrorResponse
+		if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if rr.Code != http.StatusBadRequest || len(response.Rows) != 1 || response.Rows[0].Row != 1 || !strings.Contains(response.Rows[0].Errors[0], "humidity") {
+			t.Errorf("unexpected response %d: %+v", rr.Code, response)
+		}
+	})
+}
@@ -23,21 +23,39 @@ INSERT INTO current_weather (
     humidity,
     wind_speed_kmh,
     precipitation_mm,
-    condition_text
+    condition_text,
+    uv_index,
+    sunrise,
+    sunset,
+    wind_direction_deg,
+    wind_gust_kmh,
+    apparent_temperature_c,
+    dew_point_c,
+    rain_mm,
+    snow_mm
 )
-VALUES (gen_random_uuid(), $1, $2, $3, $4, $5, $6, $7, $8)
-RETURNING id, location_id, source_api, updated_at, temperature_c, humidity, wind_speed_kmh, precipitation_mm, condition_text
+VALUES (gen_random_uuid(), $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
+RETURNING id, location_id, source_api, updated_at, temperature_c, humidity, wind_speed_kmh, precipitation_mm, condition_text, uv_index, sunrise, sunset, wind_direction_deg, wind_gust_kmh, apparent_temperature_c, dew_point_c, rain_mm, snow_mm
 `
 
 type CreateCurrentWeatherParams struct {
-	LocationID      uuid.UUID
-	SourceApi       string
-	UpdatedAt       time.Time
-	TemperatureC    sql.NullFloat64
-	Humidity        sql.NullInt32
-	WindSpeedKmh    sql.NullFloat64
-	PrecipitationMm sql.NullFloat64
-	ConditionText   sql.NullString
+	LocationID           uuid.UUID
+	SourceApi            string
+	UpdatedAt            time.Time
+	TemperatureC         sql.NullFloat64
+	Humidity             sql.NullInt32
+	WindSpeedKmh         sql.NullFloat64
+	PrecipitationMm      sql.NullFloat64
+	ConditionText        sql.NullString
+	UvIndex              sql.NullFloat64
+	Sunrise              sql.NullTime
+	Sunset               sql.NullTime
+	WindDirectionDeg     sql.NullFloat64
+	WindGustKmh          sql.NullFloat64
+	ApparentTemperatureC sql.NullFloat64
+	DewPointC            sql.NullFloat64
+	RainMm               sql.NullFloat64
+	SnowMm               sql.NullFloat64
 }
 
 // CreateCurrentWeather inserts a new current weather record into the database.
@@ -51,6 +69,15 @@ func (q *Queries) CreateCurrentWeather(ctx context.Context, arg CreateCurrentWea
 		arg.WindSpeedKmh,
 		arg.PrecipitationMm,
 		arg.ConditionText,
+		arg.UvIndex,
+		arg.Sunrise,
+		arg.Sunset,
+		arg.WindDirectionDeg,
+		arg.WindGustKmh,
+		arg.ApparentTemperatureC,
+		arg.DewPointC,
+		arg.RainMm,
+		arg.SnowMm,
 	)
 	var i CurrentWeather
 	err := row.Scan(
@@ -63,6 +90,15 @@ func (q *Queries) CreateCurrentWeather(ctx context.Context, arg CreateCurrentWea
 		&i.WindSpeedKmh,
 		&i.PrecipitationMm,
 		&i.ConditionText,
+		&i.UvIndex,
+		&i.Sunrise,
+		&i.Sunset,
+		&i.WindDirectionDeg,
+		&i.WindGustKmh,
+		&i.ApparentTemperatureC,
+		&i.DewPointC,
+		&i.RainMm,
+		&i.SnowMm,
 	)
 	return i, err
 }
@@ -113,7 +149,7 @@ func (q *Queries) DeleteCurrentWeatherAtLocationFromAPI(ctx context.Context, arg
 }
 
 const getCurrentWeatherAtLocation = `-- name: GetCurrentWeatherAtLocation :many
-SELECT id, location_id, source_api, updated_at, temperature_c, humidity, wind_speed_kmh, precipitation_mm, condition_text FROM current_weather WHERE location_id=$1
+SELECT id, location_id, source_api, updated_at, temperature_c, humidity, wind_speed_kmh, precipitation_mm, condition_text, uv_index, sunrise, sunset, wind_direction_deg, wind_gust_kmh, apparent_temperature_c, dew_point_c, rain_mm, snow_mm FROM current_weather WHERE location_id=$1
 `
 
 // GetCurrentWeatherAtLocation retrieves all current weather records for a specific location.
@@ -136,6 +172,15 @@ func (q *Queries) GetCurrentWeatherAtLocation(ctx context.Context, locationID uu
 			&i.WindSpeedKmh,
 			&i.PrecipitationMm,
 			&i.ConditionText,
+			&i.UvIndex,
+			&i.Sunrise,
+			&i.Sunset,
+			&i.WindDirectionDeg,
+			&i.WindGustKmh,
+			&i.ApparentTemperatureC,
+			&i.DewPointC,
+			&i.RainMm,
+			&i.SnowMm,
 		); err != nil {
 			return nil, err
 		}
@@ -151,7 +196,7 @@ func (q *Queries) GetCurrentWeatherAtLocation(ctx context.Context, locationID uu
 }
 
 const getCurrentWeatherAtLocationFromAPI = `-- name: GetCurrentWeatherAtLocationFromAPI :one
-SELECT id, location_id, source_api, updated_at, temperature_c, humidity, wind_speed_kmh, precipitation_mm, condition_text FROM current_weather WHERE location_id=$1 AND source_api=$2
+SELECT id, location_id, source_api, updated_at, temperature_c, humidity, wind_speed_kmh, precipitation_mm, condition_text, uv_index, sunrise, sunset, wind_direction_deg, wind_gust_kmh, apparent_temperature_c, dew_point_c, rain_mm, snow_mm FROM current_weather WHERE location_id=$1 AND source_api=$2
 `
 
 type GetCurrentWeatherAtLocationFromAPIParams struct {
@@ -173,25 +218,43 @@ func (q *Queries) GetCurrentWeatherAtLocationFromAPI(ctx context.Context, arg Ge
 		&i.WindSpeedKmh,
 		&i.PrecipitationMm,
 		&i.ConditionText,
+		&i.UvIndex,
+		&i.Sunrise,
+		&i.Sunset,
+		&i.WindDirectionDeg,
+		&i.WindGustKmh,
+		&i.ApparentTemperatureC,
+		&i.DewPointC,
+		&i.RainMm,
+		&i.SnowMm,
 	)
 	return i, err
 }
 
 const updateCurrentWeather = `-- name: UpdateCurrentWeather :one
 UPDATE current_weather
-SET updated_at=$2, temperature_c=$3, humidity=$4, wind_speed_kmh=$5, precipitation_mm=$6, condition_text=$7
+SET updated_at=$2, temperature_c=$3, humidity=$4, wind_speed_kmh=$5, precipitation_mm=$6, condition_text=$7, uv_index=$8, sunrise=$9, sunset=$10, wind_direction_deg=$11, wind_gust_kmh=$12, apparent_temperature_c=$13, dew_point_c=$14, rain_mm=$15, snow_mm=$16
 WHERE id=$1
-RETURNING id, location_id, source_api, updated_at, temperature_c, humidity, wind_speed_kmh, precipitation_mm, condition_text
+RETURNING id, location_id, source_api, updated_at, temperature_c, humidity, wind_speed_kmh, precipitation_mm, condition_text, uv_index, sunrise, sunset, wind_direction_deg, wind_gust_kmh, apparent_temperature_c, dew_point_c, rain_mm, snow_mm
 `
 
 type UpdateCurrentWeatherParams struct {
-	ID              uuid.UUID
-	UpdatedAt       time.Time
-	TemperatureC    sql.NullFloat64
-	Humidity        sql.NullInt32
-	WindSpeedKmh    sql.NullFloat64
-	PrecipitationMm sql.NullFloat64
-	ConditionText   sql.NullString
+	ID                   uuid.UUID
+	UpdatedAt            time.Time
+	TemperatureC         sql.NullFloat64
+	Humidity             sql.NullInt32
+	WindSpeedKmh         sql.NullFloat64
+	PrecipitationMm      sql.NullFloat64
+	ConditionText        sql.NullString
+	UvIndex              sql.NullFloat64
+	Sunrise              sql.NullTime
+	Sunset               sql.NullTime
+	WindDirectionDeg     sql.NullFloat64
+	WindGustKmh          sql.NullFloat64
+	ApparentTemperatureC sql.NullFloat64
+	DewPointC            sql.NullFloat64
+	RainMm               sql.NullFloat64
+	SnowMm               sql.NullFloat64
 }
 
 // UpdateCurrentWeather updates an existing current weather record.
@@ -204,6 +267,15 @@ func (q *Queries) UpdateCurrentWeather(ctx context.Context, arg UpdateCurrentWea
 		arg.WindSpeedKmh,
 		arg.PrecipitationMm,
 		arg.ConditionText,
+		arg.UvIndex,
+		arg.Sunrise,
+		arg.Sunset,
+		arg.WindDirectionDeg,
+		arg.WindGustKmh,
+		arg.ApparentTemperatureC,
+		arg.DewPointC,
+		arg.RainMm,
+		arg.SnowMm,
 	)
 	var i CurrentWeather
 	err := row.Scan(
@@ -216,6 +288,15 @@ func (q *Queries) UpdateCurrentWeather(ctx context.Context, arg UpdateCurrentWea
 		&i.WindSpeedKmh,
 		&i.PrecipitationMm,
 		&i.ConditionText,
+		&i.UvIndex,
+		&i.Sunrise,
+		&i.Sunset,
+		&i.WindDirectionDeg,
+		&i.WindGustKmh,
+		&i.ApparentTemperatureC,
+		&i.DewPointC,
+		&i.RainMm,
+		&i.SnowMm,
 	)
 	return i, err
 }
@@ -8,6 +8,7 @@ package database
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
@@ -25,23 +26,61 @@ INSERT INTO daily_forecasts (
     precipitation_mm,
     precipitation_chance_percent,
     wind_speed_kmh,
-    humidity 
+    humidity,
+    uv_index,
+    sunrise,
+    sunset,
+    wind_direction_deg,
+    wind_gust_kmh,
+    rain_mm,
+    snow_mm,
+    morning_temp_c,
+    morning_precipitation_chance_percent,
+    morning_condition_text,
+    afternoon_temp_c,
+    afternoon_precipitation_chance_percent,
+    afternoon_condition_text,
+    evening_temp_c,
+    evening_precipitation_chance_percent,
+    evening_condition_text,
+    night_temp_c,
+    night_precipitation_chance_percent,
+    night_condition_text
 )
-VALUES (gen_random_uuid(), $1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
-RETURNING id, location_id, source_api, forecast_date, updated_at, min_temp_c, max_temp_c, precipitation_mm, precipitation_chance_percent, wind_speed_kmh, humidity
+VALUES (gen_random_uuid(), $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27, $28, $29)
+RETURNING id, location_id, source_api, forecast_date, updated_at, min_temp_c, max_temp_c, precipitation_mm, precipitation_chance_percent, wind_speed_kmh, humidity, uv_index, sunrise, sunset, wind_direction_deg, wind_gust_kmh, rain_mm, snow_mm, morning_temp_c, morning_precipitation_chance_percent, morning_condition_text, afternoon_temp_c, afternoon_precipitation_chance_percent, afternoon_condition_text, evening_temp_c, evening_precipitation_chance_percent, evening_condition_text, night_temp_c, night_precipitation_chance_percent, night_condition_text
 `
 
 type CreateDailyForecastParams struct {
-	LocationID                 uuid.UUID
-	SourceApi                  string
-	ForecastDate               time.Time
-	UpdatedAt                  time.Time
-	MinTempC                   sql.NullFloat64
-	MaxTempC                   sql.NullFloat64
-	PrecipitationMm            sql.NullFloat64
-	PrecipitationChancePercent sql.NullInt32
-	WindSpeedKmh               sql.NullFloat64
-	Humidity                   sql.NullInt32
+	LocationID                          uuid.UUID
+	SourceApi                           string
+	ForecastDate                        time.Time
+	UpdatedAt                           time.Time
+	MinTempC                            sql.NullFloat64
+	MaxTempC                            sql.NullFloat64
+	PrecipitationMm                     sql.NullFloat64
+	PrecipitationChancePercent          sql.NullInt32
+	WindSpeedKmh                        sql.NullFloat64
+	Humidity                            sql.NullInt32
+	UvIndex                             sql.NullFloat64
+	Sunrise                             sql.NullTime
+	Sunset                              sql.NullTime
+	WindDirectionDeg                    sql.NullFloat64
+	WindGustKmh                         sql.NullFloat64
+	RainMm                              sql.NullFloat64
+	SnowMm                              sql.NullFloat64
+	MorningTempC                        sql.NullFloat64
+	MorningPrecipitationChancePercent   sql.NullInt32
+	MorningConditionText                sql.NullString
+	AfternoonTempC                      sql.NullFloat64
+	AfternoonPrecipitationChancePercent sql.NullInt32
+	AfternoonConditionText              sql.NullString
+	EveningTempC                        sql.NullFloat64
+	EveningPrecipitationChancePercent   sql.NullInt32
+	EveningConditionText                sql.NullString
+	NightTempC                          sql.NullFloat64
+	NightPrecipitationChancePercent     sql.NullInt32
+	NightConditionText                  sql.NullString
 }
 
 // CreateDailyForecast inserts a new daily forecast record.
@@ -57,6 +96,25 @@ func (q *Queries) CreateDailyForecast(ctx context.Context, arg CreateDailyForeca
 		arg.PrecipitationChancePercent,
 		arg.WindSpeedKmh,
 		arg.Humidity,
+		arg.UvIndex,
+		arg.Sunrise,
+		arg.Sunset,
+		arg.WindDirectionDeg,
+		arg.WindGustKmh,
+		arg.RainMm,
+		arg.SnowMm,
+		arg.MorningTempC,
+		arg.MorningPrecipitationChancePercent,
+		arg.MorningConditionText,
+		arg.AfternoonTempC,
+		arg.AfternoonPrecipitationChancePercent,
+		arg.AfternoonConditionText,
+		arg.EveningTempC,
+		arg.EveningPrecipitationChancePercent,
+		arg.EveningConditionText,
+		arg.NightTempC,
+		arg.NightPrecipitationChancePercent,
+		arg.NightConditionText,
 	)
 	var i DailyForecast
 	err := row.Scan(
@@ -71,6 +129,25 @@ func (q *Queries) CreateDailyForecast(ctx context.Context, arg CreateDailyForeca
 		&i.PrecipitationChancePercent,
 		&i.WindSpeedKmh,
 		&i.Humidity,
+		&i.UvIndex,
+		&i.Sunrise,
+		&i.Sunset,
+		&i.WindDirectionDeg,
+		&i.WindGustKmh,
+		&i.RainMm,
+		&i.SnowMm,
+		&i.MorningTempC,
+		&i.MorningPrecipitationChancePercent,
+		&i.MorningConditionText,
+		&i.AfternoonTempC,
+		&i.AfternoonPrecipitationChancePercent,
+		&i.AfternoonConditionText,
+		&i.EveningTempC,
+		&i.EveningPrecipitationChancePercent,
+		&i.EveningConditionText,
+		&i.NightTempC,
+		&i.NightPrecipitationChancePercent,
+		&i.NightConditionText,
 	)
 	return i, err
 }
@@ -110,6 +187,19 @@ func (q *Queries) DeleteDailyForecastsAtLocationFromAPI(ctx context.Context, arg
 	return err
 }
 
+const deleteDailyForecastsBefore = `-- name: DeleteDailyForecastsBefore :execrows
+DELETE FROM daily_forecasts WHERE forecast_date < $1
+`
+
+// DeleteDailyForecastsBefore deletes the daily forecasts for dates before the given date.
+func (q *Queries) DeleteDailyForecastsBefore(ctx context.Context, forecastDate time.Time) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteDailyForecastsBefore, forecastDate)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const deleteDailyForecastsFromApi = `-- name: DeleteDailyForecastsFromApi :exec
 DELETE FROM daily_forecasts WHERE source_api=$1
 `
@@ -121,7 +211,7 @@ func (q *Queries) DeleteDailyForecastsFromApi(ctx context.Context, sourceApi str
 }
 
 const getAllDailyForecastsAtLocation = `-- name: GetAllDailyForecastsAtLocation :many
-SELECT id, location_id, source_api, forecast_date, updated_at, min_temp_c, max_temp_c, precipitation_mm, precipitation_chance_percent, wind_speed_kmh, humidity FROM daily_forecasts WHERE location_id=$1
+SELECT id, location_id, source_api, forecast_date, updated_at, min_temp_c, max_temp_c, precipitation_mm, precipitation_chance_percent, wind_speed_kmh, humidity, uv_index, sunrise, sunset, wind_direction_deg, wind_gust_kmh, rain_mm, snow_mm, morning_temp_c, morning_precipitation_chance_percent, morning_condition_text, afternoon_temp_c, afternoon_precipitation_chance_percent, afternoon_condition_text, evening_temp_c, evening_precipitation_chance_percent, evening_condition_text, night_temp_c, night_precipitation_chance_percent, night_condition_text FROM daily_forecasts WHERE location_id=$1
 `
 
 // GetAllDailyForecastsAtLocation retrieves all daily forecasts for a specific location.
@@ -146,6 +236,25 @@ func (q *Queries) GetAllDailyForecastsAtLocation(ctx context.Context, locationID
 			&i.PrecipitationChancePercent,
 			&i.WindSpeedKmh,
 			&i.Humidity,
+			&i.UvIndex,
+			&i.Sunrise,
+			&i.Sunset,
+			&i.WindDirectionDeg,
+			&i.WindGustKmh,
+			&i.RainMm,
+			&i.SnowMm,
+			&i.MorningTempC,
+			&i.MorningPrecipitationChancePercent,
+			&i.MorningConditionText,
+			&i.AfternoonTempC,
+			&i.AfternoonPrecipitationChancePercent,
+			&i.AfternoonConditionText,
+			&i.EveningTempC,
+			&i.EveningPrecipitationChancePercent,
+			&i.EveningConditionText,
+			&i.NightTempC,
+			&i.NightPrecipitationChancePercent,
+			&i.NightConditionText,
 		); err != nil {
 			return nil, err
 		}
@@ -161,7 +270,7 @@ func (q *Queries) GetAllDailyForecastsAtLocation(ctx context.Context, locationID
 }
 
 const getDailyForecastAtLocationAndDate = `-- name: GetDailyForecastAtLocationAndDate :many
-SELECT id, location_id, source_api, forecast_date, updated_at, min_temp_c, max_temp_c, precipitation_mm, precipitation_chance_percent, wind_speed_kmh, humidity FROM daily_forecasts WHERE location_id=$1 AND forecast_date=$2
+SELECT id, location_id, source_api, forecast_date, updated_at, min_temp_c, max_temp_c, precipitation_mm, precipitation_chance_percent, wind_speed_kmh, humidity, uv_index, sunrise, sunset, wind_direction_deg, wind_gust_kmh, rain_mm, snow_mm, morning_temp_c, morning_precipitation_chance_percent, morning_condition_text, afternoon_temp_c, afternoon_precipitation_chance_percent, afternoon_condition_text, evening_temp_c, evening_precipitation_chance_percent, evening_condition_text, night_temp_c, night_precipitation_chance_percent, night_condition_text FROM daily_forecasts WHERE location_id=$1 AND forecast_date=$2
 `
 
 type GetDailyForecastAtLocationAndDateParams struct {
@@ -191,6 +300,25 @@ func (q *Queries) GetDailyForecastAtLocationAndDate(ctx context.Context, arg Get
 			&i.PrecipitationChancePercent,
 			&i.WindSpeedKmh,
 			&i.Humidity,
+			&i.UvIndex,
+			&i.Sunrise,
+			&i.Sunset,
+			&i.WindDirectionDeg,
+			&i.WindGustKmh,
+			&i.RainMm,
+			&i.SnowMm,
+			&i.MorningTempC,
+			&i.MorningPrecipitationChancePercent,
+			&i.MorningConditionText,
+			&i.AfternoonTempC,
+			&i.AfternoonPrecipitationChancePercent,
+			&i.AfternoonConditionText,
+			&i.EveningTempC,
+			&i.EveningPrecipitationChancePercent,
+			&i.EveningConditionText,
+			&i.NightTempC,
+			&i.NightPrecipitationChancePercent,
+			&i.NightConditionText,
 		); err != nil {
 			return nil, err
 		}
@@ -206,7 +334,7 @@ func (q *Queries) GetDailyForecastAtLocationAndDate(ctx context.Context, arg Get
 }
 
 const getDailyForecastAtLocationAndDateFromAPI = `-- name: GetDailyForecastAtLocationAndDateFromAPI :one
-SELECT id, location_id, source_api, forecast_date, updated_at, min_temp_c, max_temp_c, precipitation_mm, precipitation_chance_percent, wind_speed_kmh, humidity FROM daily_forecasts WHERE location_id=$1 AND forecast_date=$2 AND source_api=$3
+SELECT id, location_id, source_api, forecast_date, updated_at, min_temp_c, max_temp_c, precipitation_mm, precipitation_chance_percent, wind_speed_kmh, humidity, uv_index, sunrise, sunset, wind_direction_deg, wind_gust_kmh, rain_mm, snow_mm, morning_temp_c, morning_precipitation_chance_percent, morning_condition_text, afternoon_temp_c, afternoon_precipitation_chance_percent, afternoon_condition_text, evening_temp_c, evening_precipitation_chance_percent, evening_condition_text, night_temp_c, night_precipitation_chance_percent, night_condition_text FROM daily_forecasts WHERE location_id=$1 AND forecast_date=$2 AND source_api=$3
 `
 
 type GetDailyForecastAtLocationAndDateFromAPIParams struct {
@@ -231,24 +359,44 @@ func (q *Queries) GetDailyForecastAtLocationAndDateFromAPI(ctx context.Context,
 		&i.PrecipitationChancePercent,
 		&i.WindSpeedKmh,
 		&i.Humidity,
+		&i.UvIndex,
+		&i.Sunrise,
+		&i.Sunset,
+		&i.WindDirectionDeg,
+		&i.WindGustKmh,
+		&i.RainMm,
+		&i.SnowMm,
+		&i.MorningTempC,
+		&i.MorningPrecipitationChancePercent,
+		&i.MorningConditionText,
+		&i.AfternoonTempC,
+		&i.AfternoonPrecipitationChancePercent,
+		&i.AfternoonConditionText,
+		&i.EveningTempC,
+		&i.EveningPrecipitationChancePercent,
+		&i.EveningConditionText,
+		&i.NightTempC,
+		&i.NightPrecipitationChancePercent,
+		&i.NightConditionText,
 	)
 	return i, err
 }
 
 const getUpcomingDailyForecastsAtLocation = `-- name: GetUpcomingDailyForecastsAtLocation :many
-SELECT id, location_id, source_api, forecast_date, updated_at, min_temp_c, max_temp_c, precipitation_mm, precipitation_chance_percent, wind_speed_kmh, humidity FROM daily_forecasts
-WHERE location_id = $1 AND forecast_date >= $2
+SELECT id, location_id, source_api, forecast_date, updated_at, min_temp_c, max_temp_c, precipitation_mm, precipitation_chance_percent, wind_speed_kmh, humidity, uv_index, sunrise, sunset, wind_direction_deg, wind_gust_kmh, rain_mm, snow_mm, morning_temp_c, morning_precipitation_chance_percent, morning_condition_text, afternoon_temp_c, afternoon_precipitation_chance_percent, afternoon_condition_text, evening_temp_c, evening_precipitation_chance_percent, evening_condition_text, night_temp_c, night_precipitation_chance_percent, night_condition_text FROM daily_forecasts
+WHERE location_id = $1 AND forecast_date >= $2 AND forecast_date < $3
 ORDER BY forecast_date ASC
 `
 
 type GetUpcomingDailyForecastsAtLocationParams struct {
-	LocationID   uuid.UUID
-	ForecastDate time.Time
+	LocationID uuid.UUID
+	FromDate   time.Time
+	ToDate     time.Time
 }
 
-// GetUpcomingDailyForecastsAtLocation retrieves all upcoming daily forecasts for a specific location.
+// GetUpcomingDailyForecastsAtLocation retrieves the daily forecasts for a specific location from from_date up to, but excluding, to_date.
 func (q *Queries) GetUpcomingDailyForecastsAtLocation(ctx context.Context, arg GetUpcomingDailyForecastsAtLocationParams) ([]DailyForecast, error) {
-	rows, err := q.db.QueryContext(ctx, getUpcomingDailyForecastsAtLocation, arg.LocationID, arg.ForecastDate)
+	rows, err := q.db.QueryContext(ctx, getUpcomingDailyForecastsAtLocation, arg.LocationID, arg.FromDate, arg.ToDate)
 	if err != nil {
 		return nil, err
 	}
@@ -268,6 +416,25 @@ func (q *Queries) GetUpcomingDailyForecastsAtLocation(ctx context.Context, arg G
 			&i.PrecipitationChancePercent,
 			&i.WindSpeedKmh,
 			&i.Humidity,
+			&i.UvIndex,
+			&i.Sunrise,
+			&i.Sunset,
+			&i.WindDirectionDeg,
+			&i.WindGustKmh,
+			&i.RainMm,
+			&i.SnowMm,
+			&i.MorningTempC,
+			&i.MorningPrecipitationChancePercent,
+			&i.MorningConditionText,
+			&i.AfternoonTempC,
+			&i.AfternoonPrecipitationChancePercent,
+			&i.AfternoonConditionText,
+			&i.EveningTempC,
+			&i.EveningPrecipitationChancePercent,
+			&i.EveningConditionText,
+			&i.NightTempC,
+			&i.NightPrecipitationChancePercent,
+			&i.NightConditionText,
 		); err != nil {
 			return nil, err
 		}
@@ -284,21 +451,40 @@ func (q *Queries) GetUpcomingDailyForecastsAtLocation(ctx context.Context, arg G
 
 const updateDailyForecast = `-- name: UpdateDailyForecast :one
 UPDATE daily_forecasts
-SET updated_at=$2, forecast_date=$3, min_temp_c=$4, max_temp_c=$5, precipitation_mm=$6, precipitation_chance_percent=$7, wind_speed_kmh=$8, humidity=$9
+SET updated_at=$2, forecast_date=$3, min_temp_c=$4, max_temp_c=$5, precipitation_mm=$6, precipitation_chance_percent=$7, wind_speed_kmh=$8, humidity=$9, uv_index=$10, sunrise=$11, sunset=$12, wind_direction_deg=$13, wind_gust_kmh=$14, rain_mm=$15, snow_mm=$16, morning_temp_c=$17, morning_precipitation_chance_percent=$18, morning_condition_text=$19, afternoon_temp_c=$20, afternoon_precipitation_chance_percent=$21, afternoon_condition_text=$22, evening_temp_c=$23, evening_precipitation_chance_percent=$24, evening_condition_text=$25, night_temp_c=$26, night_precipitation_chance_percent=$27, night_condition_text=$28
 WHERE id=$1
-RETURNING id, location_id, source_api, forecast_date, updated_at, min_temp_c, max_temp_c, precipitation_mm, precipitation_chance_percent, wind_speed_kmh, humidity
+RETURNING id, location_id, source_api, forecast_date, updated_at, min_temp_c, max_temp_c, precipitation_mm, precipitation_chance_percent, wind_speed_kmh, humidity, uv_index, sunrise, sunset, wind_direction_deg, wind_gust_kmh, rain_mm, snow_mm, morning_temp_c, morning_precipitation_chance_percent, morning_condition_text, afternoon_temp_c, afternoon_precipitation_chance_percent, afternoon_condition_text, evening_temp_c, evening_precipitation_chance_percent, evening_condition_text, night_temp_c, night_precipitation_chance_percent, night_condition_text
 `
 
 type UpdateDailyForecastParams struct {
-	ID                         uuid.UUID
-	UpdatedAt                  time.Time
-	ForecastDate               time.Time
-	MinTempC                   sql.NullFloat64
-	MaxTempC                   sql.NullFloat64
-	PrecipitationMm            sql.NullFloat64
-	PrecipitationChancePercent sql.NullInt32
-	WindSpeedKmh               sql.NullFloat64
-	Humidity                   sql.NullInt32
+	ID                                  uuid.UUID
+	UpdatedAt                           time.Time
+	ForecastDate                        time.Time
+	MinTempC                            sql.NullFloat64
+	MaxTempC                            sql.NullFloat64
+	PrecipitationMm                     sql.NullFloat64
+	PrecipitationChancePercent          sql.NullInt32
+	WindSpeedKmh                        sql.NullFloat64
+	Humidity                            sql.NullInt32
+	UvIndex                             sql.NullFloat64
+	Sunrise                             sql.NullTime
+	Sunset                              sql.NullTime
+	WindDirectionDeg                    sql.NullFloat64
+	WindGustKmh                         sql.NullFloat64
+	RainMm                              sql.NullFloat64
+	SnowMm                              sql.NullFloat64
+	MorningTempC                        sql.NullFloat64
+	MorningPrecipitationChancePercent   sql.NullInt32
+	MorningConditionText                sql.NullString
+	AfternoonTempC                      sql.NullFloat64
+	AfternoonPrecipitationChancePercent sql.NullInt32
+	AfternoonConditionText              sql.NullString
+	EveningTempC                        sql.NullFloat64
+	EveningPrecipitationChancePercent   sql.NullInt32
+	EveningConditionText                sql.NullString
+	NightTempC                          sql.NullFloat64
+	NightPrecipitationChancePercent     sql.NullInt32
+	NightConditionText                  sql.NullString
 }
 
 // UpdateDailyForecast updates an existing daily forecast record.
@@ -313,6 +499,25 @@ func (q *Queries) UpdateDailyForecast(ctx context.Context, arg UpdateDailyForeca
 		arg.PrecipitationChancePercent,
 		arg.WindSpeedKmh,
 		arg.Humidity,
+		arg.UvIndex,
+		arg.Sunrise,
+		arg.Sunset,
+		arg.WindDirectionDeg,
+		arg.WindGustKmh,
+		arg.RainMm,
+		arg.SnowMm,
+		arg.MorningTempC,
+		arg.MorningPrecipitationChancePercent,
+		arg.MorningConditionText,
+		arg.AfternoonTempC,
+		arg.AfternoonPrecipitationChancePercent,
+		arg.AfternoonConditionText,
+		arg.EveningTempC,
+		arg.EveningPrecipitationChancePercent,
+		arg.EveningConditionText,
+		arg.NightTempC,
+		arg.NightPrecipitationChancePercent,
+		arg.NightConditionText,
 	)
 	var i DailyForecast
 	err := row.Scan(
@@ -327,6 +532,103 @@ func (q *Queries) UpdateDailyForecast(ctx context.Context, arg UpdateDailyForeca
 		&i.PrecipitationChancePercent,
 		&i.WindSpeedKmh,
 		&i.Humidity,
+		&i.UvIndex,
+		&i.Sunrise,
+		&i.Sunset,
+		&i.WindDirectionDeg,
+		&i.WindGustKmh,
+		&i.RainMm,
+		&i.SnowMm,
+		&i.MorningTempC,
+		&i.MorningPrecipitationChancePercent,
+		&i.MorningConditionText,
+		&i.AfternoonTempC,
+		&i.AfternoonPrecipitationChancePercent,
+		&i.AfternoonConditionText,
+		&i.EveningTempC,
+		&i.EveningPrecipitationChancePercent,
+		&i.EveningConditionText,
+		&i.NightTempC,
+		&i.NightPrecipitationChancePercent,
+		&i.NightConditionText,
 	)
 	return i, err
 }
+
+const upsertDailyForecasts = `-- name: UpsertDailyForecasts :execrows
+INSERT INTO daily_forecasts (
+    id,
+    location_id,
+    source_api,
+    forecast_date,
+    updated_at,
+    min_temp_c,
+    max_temp_c,
+    precipitation_mm,
+    precipitation_chance_percent,
+    wind_speed_kmh,
+    humidity,
+    uv_index,
+    sunrise,
+    sunset,
+    wind_direction_deg,
+    wind_gust_kmh,
+    rain_mm,
+    snow_mm,
+    morning_temp_c,
+    morning_precipitation_chance_percent,
+    morning_condition_text,
+    afternoon_temp_c,
+    afternoon_precipitation_chance_percent,
+    afternoon_condition_text,
+    evening_temp_c,
+    evening_precipitation_chance_percent,
+    evening_condition_text,
+    night_temp_c,
+    night_precipitation_chance_percent,
+    night_condition_text
+)
+SELECT gen_random_uuid(), f.*
+FROM jsonb_to_recordset($1::jsonb) AS f(
+    location_id UUID,
+    source_api TEXT,
+    forecast_date DATE,
+    updated_at TIMESTAMPTZ,
+    min_temp_c FLOAT,
+    max_temp_c FLOAT,
+    precipitation_mm FLOAT,
+    precipitation_chance_percent INT,
+    wind_speed_kmh FLOAT,
+    humidity INT,
+    uv_index FLOAT,
+    sunrise TIMESTAMPTZ,
+    sunset TIMESTAMPTZ,
+    wind_direction_deg FLOAT,
+    wind_gust_kmh FLOAT,
+    rain_mm FLOAT,
+    snow_mm FLOAT,
+    morning_temp_c FLOAT,
+    morning_precipitation_chance_percent INT,
+    morning_condition_text TEXT,
+    afternoon_temp_c FLOAT,
+    afternoon_precipitation_chance_percent INT,
+    afternoon_condition_text TEXT,
+    evening_temp_c FLOAT,
+    evening_precipitation_chance_percent INT,
+    evening_condition_text TEXT,
+    night_temp_c FLOAT,
+    night_precipitation_chance_percent INT,
+    night_condition_text TEXT
+)
+ON CONFLICT (location_id, source_api, forecast_date) DO UPDATE
+SET updated_at=EXCLUDED.updated_at, min_temp_c=EXCLUDED.min_temp_c, max_temp_c=EXCLUDED.max_temp_c, precipitation_mm=EXCLUDED.precipitation_mm, precipitation_chance_percent=EXCLUDED.precipitation_chance_percent, wind_speed_kmh=EXCLUDED.wind_speed_kmh, humidity=EXCLUDED.humidity, uv_index=EXCLUDED.uv_index, sunrise=EXCLUDED.sunrise, sunset=EXCLUDED.sunset, wind_direction_deg=EXCLUDED.wind_direction_deg, wind_gust_kmh=EXCLUDED.wind_gust_kmh, rain_mm=EXCLUDED.rain_mm, snow_mm=EXCLUDED.snow_mm, morning_temp_c=EXCLUDED.morning_temp_c, morning_precipitation_chance_percent=EXCLUDED.morning_precipitation_chance_percent, morning_condition_text=EXCLUDED.morning_condition_text, afternoon_temp_c=EXCLUDED.afternoon_temp_c, afternoon_precipitation_chance_percent=EXCLUDED.afternoon_precipitation_chance_percent, afternoon_condition_text=EXCLUDED.afternoon_condition_text, evening_temp_c=EXCLUDED.evening_temp_c, evening_precipitation_chance_percent=EXCLUDED.evening_precipitation_chance_percent, evening_condition_text=EXCLUDED.evening_condition_text, night_temp_c=EXCLUDED.night_temp_c, night_precipitation_chance_percent=EXCLUDED.night_precipitation_chance_percent, night_condition_text=EXCLUDED.night_condition_text
+`
+
+// UpsertDailyForecasts inserts the daily forecasts in a JSON array of rows, or updates those that already exist for the same location, API source, and date.
+func (q *Queries) UpsertDailyForecasts(ctx context.Context, forecasts json.RawMessage) (int64, error) {
+	result, err := q.db.ExecContext(ctx, upsertDailyForecasts, forecasts)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
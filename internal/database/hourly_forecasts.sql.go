@@ -8,6 +8,7 @@ package database
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
@@ -25,10 +26,16 @@ INSERT INTO hourly_forecasts (
     wind_speed_kmh,
     precipitation_mm,
     precipitation_chance_percent,
-    condition_text 
+    condition_text,
+    wind_direction_deg,
+    wind_gust_kmh,
+    apparent_temperature_c,
+    dew_point_c,
+    rain_mm,
+    snow_mm
 )
-VALUES (gen_random_uuid(), $1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
-RETURNING id, location_id, source_api, forecast_datetime_utc, updated_at, temperature_c, humidity, wind_speed_kmh, precipitation_mm, precipitation_chance_percent, condition_text
+VALUES (gen_random_uuid(), $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+RETURNING id, location_id, source_api, forecast_datetime_utc, updated_at, temperature_c, humidity, wind_speed_kmh, precipitation_mm, precipitation_chance_percent, condition_text, wind_direction_deg, wind_gust_kmh, apparent_temperature_c, dew_point_c, rain_mm, snow_mm
 `
 
 type CreateHourlyForecastParams struct {
@@ -42,6 +49,12 @@ type CreateHourlyForecastParams struct {
 	PrecipitationMm            sql.NullFloat64
 	PrecipitationChancePercent sql.NullInt32
 	ConditionText              sql.NullString
+	WindDirectionDeg           sql.NullFloat64
+	WindGustKmh                sql.NullFloat64
+	ApparentTemperatureC       sql.NullFloat64
+	DewPointC                  sql.NullFloat64
+	RainMm                     sql.NullFloat64
+	SnowMm                     sql.NullFloat64
 }
 
 // CreateHourlyForecast inserts a new hourly forecast record.
@@ -57,6 +70,12 @@ func (q *Queries) CreateHourlyForecast(ctx context.Context, arg CreateHourlyFore
 		arg.PrecipitationMm,
 		arg.PrecipitationChancePercent,
 		arg.ConditionText,
+		arg.WindDirectionDeg,
+		arg.WindGustKmh,
+		arg.ApparentTemperatureC,
+		arg.DewPointC,
+		arg.RainMm,
+		arg.SnowMm,
 	)
 	var i HourlyForecast
 	err := row.Scan(
@@ -71,6 +90,12 @@ func (q *Queries) CreateHourlyForecast(ctx context.Context, arg CreateHourlyFore
 		&i.PrecipitationMm,
 		&i.PrecipitationChancePercent,
 		&i.ConditionText,
+		&i.WindDirectionDeg,
+		&i.WindGustKmh,
+		&i.ApparentTemperatureC,
+		&i.DewPointC,
+		&i.RainMm,
+		&i.SnowMm,
 	)
 	return i, err
 }
@@ -110,6 +135,19 @@ func (q *Queries) DeleteHourlyForecastsAtLocationFromAPI(ctx context.Context, ar
 	return err
 }
 
+const deleteHourlyForecastsBefore = `-- name: DeleteHourlyForecastsBefore :execrows
+DELETE FROM hourly_forecasts WHERE forecast_datetime_utc < $1
+`
+
+// DeleteHourlyForecastsBefore deletes the hourly forecasts for times before the given time.
+func (q *Queries) DeleteHourlyForecastsBefore(ctx context.Context, forecastDatetimeUtc time.Time) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteHourlyForecastsBefore, forecastDatetimeUtc)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const deleteHourlyForecastsFromAPI = `-- name: DeleteHourlyForecastsFromAPI :exec
 DELETE FROM hourly_forecasts WHERE source_api=$1
 `
@@ -121,7 +159,7 @@ func (q *Queries) DeleteHourlyForecastsFromAPI(ctx context.Context, sourceApi st
 }
 
 const getAllHourlyForecastsAtLocation = `-- name: GetAllHourlyForecastsAtLocation :many
-SELECT id, location_id, source_api, forecast_datetime_utc, updated_at, temperature_c, humidity, wind_speed_kmh, precipitation_mm, precipitation_chance_percent, condition_text FROM hourly_forecasts WHERE location_id=$1
+SELECT id, location_id, source_api, forecast_datetime_utc, updated_at, temperature_c, humidity, wind_speed_kmh, precipitation_mm, precipitation_chance_percent, condition_text, wind_direction_deg, wind_gust_kmh, apparent_temperature_c, dew_point_c, rain_mm, snow_mm FROM hourly_forecasts WHERE location_id=$1
 `
 
 // GetAllHourlyForecastsAtLocation retrieves all hourly forecasts for a specific location.
@@ -146,6 +184,12 @@ func (q *Queries) GetAllHourlyForecastsAtLocation(ctx context.Context, locationI
 			&i.PrecipitationMm,
 			&i.PrecipitationChancePercent,
 			&i.ConditionText,
+			&i.WindDirectionDeg,
+			&i.WindGustKmh,
+			&i.ApparentTemperatureC,
+			&i.DewPointC,
+			&i.RainMm,
+			&i.SnowMm,
 		); err != nil {
 			return nil, err
 		}
@@ -161,7 +205,7 @@ func (q *Queries) GetAllHourlyForecastsAtLocation(ctx context.Context, locationI
 }
 
 const getHourlyForecastAtLocationAndTime = `-- name: GetHourlyForecastAtLocationAndTime :many
-SELECT id, location_id, source_api, forecast_datetime_utc, updated_at, temperature_c, humidity, wind_speed_kmh, precipitation_mm, precipitation_chance_percent, condition_text FROM hourly_forecasts WHERE location_id=$1 AND forecast_datetime_utc=$2
+SELECT id, location_id, source_api, forecast_datetime_utc, updated_at, temperature_c, humidity, wind_speed_kmh, precipitation_mm, precipitation_chance_percent, condition_text, wind_direction_deg, wind_gust_kmh, apparent_temperature_c, dew_point_c, rain_mm, snow_mm FROM hourly_forecasts WHERE location_id=$1 AND forecast_datetime_utc=$2
 `
 
 type GetHourlyForecastAtLocationAndTimeParams struct {
@@ -191,6 +235,12 @@ func (q *Queries) GetHourlyForecastAtLocationAndTime(ctx context.Context, arg Ge
 			&i.PrecipitationMm,
 			&i.PrecipitationChancePercent,
 			&i.ConditionText,
+			&i.WindDirectionDeg,
+			&i.WindGustKmh,
+			&i.ApparentTemperatureC,
+			&i.DewPointC,
+			&i.RainMm,
+			&i.SnowMm,
 		); err != nil {
 			return nil, err
 		}
@@ -206,7 +256,7 @@ func (q *Queries) GetHourlyForecastAtLocationAndTime(ctx context.Context, arg Ge
 }
 
 const getHourlyForecastAtLocationAndTimeFromAPI = `-- name: GetHourlyForecastAtLocationAndTimeFromAPI :one
-SELECT id, location_id, source_api, forecast_datetime_utc, updated_at, temperature_c, humidity, wind_speed_kmh, precipitation_mm, precipitation_chance_percent, condition_text FROM hourly_forecasts WHERE location_id=$1 AND forecast_datetime_utc=$2 AND source_api=$3
+SELECT id, location_id, source_api, forecast_datetime_utc, updated_at, temperature_c, humidity, wind_speed_kmh, precipitation_mm, precipitation_chance_percent, condition_text, wind_direction_deg, wind_gust_kmh, apparent_temperature_c, dew_point_c, rain_mm, snow_mm FROM hourly_forecasts WHERE location_id=$1 AND forecast_datetime_utc=$2 AND source_api=$3
 `
 
 type GetHourlyForecastAtLocationAndTimeFromAPIParams struct {
@@ -231,24 +281,42 @@ func (q *Queries) GetHourlyForecastAtLocationAndTimeFromAPI(ctx context.Context,
 		&i.PrecipitationMm,
 		&i.PrecipitationChancePercent,
 		&i.ConditionText,
+		&i.WindDirectionDeg,
+		&i.WindGustKmh,
+		&i.ApparentTemperatureC,
+		&i.DewPointC,
+		&i.RainMm,
+		&i.SnowMm,
 	)
 	return i, err
 }
 
 const getUpcomingHourlyForecastsAtLocation = `-- name: GetUpcomingHourlyForecastsAtLocation :many
-SELECT id, location_id, source_api, forecast_datetime_utc, updated_at, temperature_c, humidity, wind_speed_kmh, precipitation_mm, precipitation_chance_percent, condition_text FROM hourly_forecasts
-WHERE location_id = $1 AND forecast_datetime_utc >= $2
+SELECT id, location_id, source_api, forecast_datetime_utc, updated_at, temperature_c, humidity, wind_speed_kmh, precipitation_mm, precipitation_chance_percent, condition_text, wind_direction_deg, wind_gust_kmh, apparent_temperature_c, dew_point_c, rain_mm, snow_mm FROM hourly_forecasts
+WHERE location_id = $1 AND forecast_datetime_utc IN (
+    SELECT DISTINCT forecast_datetime_utc FROM hourly_forecasts
+    WHERE location_id = $1 AND forecast_datetime_utc >= $2 AND forecast_datetime_utc < $3
+    ORDER BY forecast_datetime_utc ASC
+    LIMIT $4
+)
 ORDER BY forecast_datetime_utc ASC
 `
 
 type GetUpcomingHourlyForecastsAtLocationParams struct {
-	LocationID          uuid.UUID
-	ForecastDatetimeUtc time.Time
+	LocationID uuid.UUID
+	FromTime   time.Time
+	ToTime     time.Time
+	MaxHours   int32
 }
 
-// GetUpcomingHourlyForecastsAtLocation retrieves all upcoming hourly forecasts for a specific location.
+// GetUpcomingHourlyForecastsAtLocation retrieves the hourly forecasts for a specific location from from_time up to, but excluding, to_time, for the first max_hours forecast times with any forecast.
 func (q *Queries) GetUpcomingHourlyForecastsAtLocation(ctx context.Context, arg GetUpcomingHourlyForecastsAtLocationParams) ([]HourlyForecast, error) {
-	rows, err := q.db.QueryContext(ctx, getUpcomingHourlyForecastsAtLocation, arg.LocationID, arg.ForecastDatetimeUtc)
+	rows, err := q.db.QueryContext(ctx, getUpcomingHourlyForecastsAtLocation,
+		arg.LocationID,
+		arg.FromTime,
+		arg.ToTime,
+		arg.MaxHours,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -268,6 +336,12 @@ func (q *Queries) GetUpcomingHourlyForecastsAtLocation(ctx context.Context, arg
 			&i.PrecipitationMm,
 			&i.PrecipitationChancePercent,
 			&i.ConditionText,
+			&i.WindDirectionDeg,
+			&i.WindGustKmh,
+			&i.ApparentTemperatureC,
+			&i.DewPointC,
+			&i.RainMm,
+			&i.SnowMm,
 		); err != nil {
 			return nil, err
 		}
@@ -284,9 +358,9 @@ func (q *Queries) GetUpcomingHourlyForecastsAtLocation(ctx context.Context, arg
 
 const updateHourlyForecast = `-- name: UpdateHourlyForecast :one
 UPDATE hourly_forecasts
-SET updated_at=$2, forecast_datetime_utc=$3, temperature_c=$4, humidity=$5, wind_speed_kmh=$6, precipitation_mm=$7, precipitation_chance_percent=$8, condition_text=$9
+SET updated_at=$2, forecast_datetime_utc=$3, temperature_c=$4, humidity=$5, wind_speed_kmh=$6, precipitation_mm=$7, precipitation_chance_percent=$8, condition_text=$9, wind_direction_deg=$10, wind_gust_kmh=$11, apparent_temperature_c=$12, dew_point_c=$13, rain_mm=$14, snow_mm=$15
 WHERE id=$1
-RETURNING id, location_id, source_api, forecast_datetime_utc, updated_at, temperature_c, humidity, wind_speed_kmh, precipitation_mm, precipitation_chance_percent, condition_text
+RETURNING id, location_id, source_api, forecast_datetime_utc, updated_at, temperature_c, humidity, wind_speed_kmh, precipitation_mm, precipitation_chance_percent, condition_text, wind_direction_deg, wind_gust_kmh, apparent_temperature_c, dew_point_c, rain_mm, snow_mm
 `
 
 type UpdateHourlyForecastParams struct {
@@ -299,6 +373,12 @@ type UpdateHourlyForecastParams struct {
 	PrecipitationMm            sql.NullFloat64
 	PrecipitationChancePercent sql.NullInt32
 	ConditionText              sql.NullString
+	WindDirectionDeg           sql.NullFloat64
+	WindGustKmh                sql.NullFloat64
+	ApparentTemperatureC       sql.NullFloat64
+	DewPointC                  sql.NullFloat64
+	RainMm                     sql.NullFloat64
+	SnowMm                     sql.NullFloat64
 }
 
 // UpdateHourlyForecast updates an existing hourly forecast record.
@@ -313,6 +393,12 @@ func (q *Queries) UpdateHourlyForecast(ctx context.Context, arg UpdateHourlyFore
 		arg.PrecipitationMm,
 		arg.PrecipitationChancePercent,
 		arg.ConditionText,
+		arg.WindDirectionDeg,
+		arg.WindGustKmh,
+		arg.ApparentTemperatureC,
+		arg.DewPointC,
+		arg.RainMm,
+		arg.SnowMm,
 	)
 	var i HourlyForecast
 	err := row.Scan(
@@ -327,6 +413,64 @@ func (q *Queries) UpdateHourlyForecast(ctx context.Context, arg UpdateHourlyFore
 		&i.PrecipitationMm,
 		&i.PrecipitationChancePercent,
 		&i.ConditionText,
+		&i.WindDirectionDeg,
+		&i.WindGustKmh,
+		&i.ApparentTemperatureC,
+		&i.DewPointC,
+		&i.RainMm,
+		&i.SnowMm,
 	)
 	return i, err
 }
+
+const upsertHourlyForecasts = `-- name: UpsertHourlyForecasts :execrows
+INSERT INTO hourly_forecasts (
+    id,
+    location_id,
+    source_api,
+    forecast_datetime_utc,
+    updated_at,
+    temperature_c,
+    humidity,
+    wind_speed_kmh,
+    precipitation_mm,
+    precipitation_chance_percent,
+    condition_text,
+    wind_direction_deg,
+    wind_gust_kmh,
+    apparent_temperature_c,
+    dew_point_c,
+    rain_mm,
+    snow_mm
+)
+SELECT gen_random_uuid(), f.*
+FROM jsonb_to_recordset($1::jsonb) AS f(
+    location_id UUID,
+    source_api TEXT,
+    forecast_datetime_utc TIMESTAMP,
+    updated_at TIMESTAMPTZ,
+    temperature_c FLOAT,
+    humidity INT,
+    wind_speed_kmh FLOAT,
+    precipitation_mm FLOAT,
+    precipitation_chance_percent INT,
+    condition_text TEXT,
+    wind_direction_deg FLOAT,
+    wind_gust_kmh FLOAT,
+    apparent_temperature_c FLOAT,
+    dew_point_c FLOAT,
+    rain_mm FLOAT,
+    snow_mm FLOAT
+)
+ON CONFLICT (location_id, source_api, forecast_datetime_utc) DO UPDATE
+SET updated_at=EXCLUDED.updated_at, temperature_c=EXCLUDED.temperature_c, humidity=EXCLUDED.humidity, wind_speed_kmh=EXCLUDED.wind_speed_kmh, precipitation_mm=EXCLUDED.precipitation_mm, precipitation_chance_percent=EXCLUDED.precipitation_chance_percent, condition_text=EXCLUDED.condition_text, wind_direction_deg=EXCLUDED.wind_direction_deg, wind_gust_kmh=EXCLUDED.wind_gust_kmh, apparent_temperature_c=EXCLUDED.apparent_temperature_c, dew_point_c=EXCLUDED.dew_point_c, rain_mm=EXCLUDED.rain_mm, snow_mm=EXCLUDED.snow_mm
+`
+
+// UpsertHourlyForecasts inserts the hourly forecasts in a JSON array of rows, or updates those that already exist for the same location, API source, and time.
+func (q *Queries) UpsertHourlyForecasts(ctx context.Context, forecasts json.RawMessage) (int64, error) {
+	result, err := q.db.ExecContext(ctx, upsertHourlyForecasts, forecasts)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -30,6 +30,24 @@ func (q *Queries) CreateLocationAlias(ctx context.Context, arg CreateLocationAli
 	return i, err
 }
 
+const deleteLocationAlias = `-- name: DeleteLocationAlias :execrows
+DELETE FROM location_aliases WHERE alias = $1 AND location_id = $2
+`
+
+type DeleteLocationAliasParams struct {
+	Alias      string
+	LocationID uuid.UUID
+}
+
+// DeleteLocationAlias removes an alias from a location and reports how many rows were deleted.
+func (q *Queries) DeleteLocationAlias(ctx context.Context, arg DeleteLocationAliasParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteLocationAlias, arg.Alias, arg.LocationID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const getLocationByAlias = `-- name: GetLocationByAlias :one
 SELECT l.id, l.city_name, l.latitude, l.longitude, l.country_code, l.timezone FROM locations l JOIN location_aliases la ON l.id = la.location_id
 WHERE la.alias = $1
@@ -49,3 +67,72 @@ func (q *Queries) GetLocationByAlias(ctx context.Context, alias string) (Locatio
 	)
 	return i, err
 }
+
+const listLocationAliases = `-- name: ListLocationAliases :many
+SELECT alias, location_id FROM location_aliases
+WHERE location_id = $1
+ORDER BY alias ASC
+`
+
+// ListLocationAliases retrieves all aliases that resolve to a location, ordered alphabetically.
+func (q *Queries) ListLocationAliases(ctx context.Context, locationID uuid.UUID) ([]LocationAlias, error) {
+	rows, err := q.db.QueryContext(ctx, listLocationAliases, locationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []LocationAlias
+	for rows.Next() {
+		var i LocationAlias
+		if err := rows.Scan(&i.Alias, &i.LocationID); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const moveLocationAliases = `-- name: MoveLocationAliases :execrows
+UPDATE location_aliases SET location_id = $1
+WHERE location_id = $2
+`
+
+type MoveLocationAliasesParams struct {
+	ToLocationID   uuid.UUID
+	FromLocationID uuid.UUID
+}
+
+// MoveLocationAliases points all aliases of a location at another location and reports how many were moved.
+func (q *Queries) MoveLocationAliases(ctx context.Context, arg MoveLocationAliasesParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, moveLocationAliases, arg.ToLocationID, arg.FromLocationID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const upsertLocationAlias = `-- name: UpsertLocationAlias :one
+INSERT INTO location_aliases (alias, location_id)
+VALUES ($1, $2)
+ON CONFLICT (alias) DO UPDATE SET location_id = EXCLUDED.location_id
+RETURNING alias, location_id
+`
+
+type UpsertLocationAliasParams struct {
+	Alias      string
+	LocationID uuid.UUID
+}
+
+// UpsertLocationAlias points an alias at a location, moving it away from any location it resolved to before.
+func (q *Queries) UpsertLocationAlias(ctx context.Context, arg UpsertLocationAliasParams) (LocationAlias, error) {
+	row := q.db.QueryRowContext(ctx, upsertLocationAlias, arg.Alias, arg.LocationID)
+	var i LocationAlias
+	err := row.Scan(&i.Alias, &i.LocationID)
+	return i, err
+}
@@ -89,6 +89,25 @@ func (q *Queries) GetLocationByCoordinates(ctx context.Context, arg GetLocationB
 	return i, err
 }
 
+const getLocationByID = `-- name: GetLocationByID :one
+SELECT id, city_name, latitude, longitude, country_code, timezone FROM locations WHERE id=$1
+`
+
+// GetLocationByID retrieves a location by its ID.
+func (q *Queries) GetLocationByID(ctx context.Context, id uuid.UUID) (Location, error) {
+	row := q.db.QueryRowContext(ctx, getLocationByID, id)
+	var i Location
+	err := row.Scan(
+		&i.ID,
+		&i.CityName,
+		&i.Latitude,
+		&i.Longitude,
+		&i.CountryCode,
+		&i.Timezone,
+	)
+	return i, err
+}
+
 const getLocationByName = `-- name: GetLocationByName :one
 SELECT id, city_name, latitude, longitude, country_code, timezone FROM locations WHERE city_name=$1
 `
@@ -6,12 +6,80 @@ package database
 
 import (
 	"database/sql"
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+type AirQuality struct {
+	ID         uuid.UUID
+	LocationID uuid.UUID
+	SourceApi  string
+	UpdatedAt  time.Time
+	Aqi        sql.NullInt32
+	Pm25Ugm3   sql.NullFloat64
+	Pm10Ugm3   sql.NullFloat64
+	OzoneUgm3  sql.NullFloat64
+}
+
+type AlertDelivery struct {
+	ID             uuid.UUID
+	SubscriptionID uuid.UUID
+	WebhookUrl     string
+	Payload        json.RawMessage
+	CreatedAt      time.Time
+	Attempts       int32
+	NextAttemptAt  sql.NullTime
+	DeliveredAt    sql.NullTime
+	LastError      sql.NullString
+}
+
+type AlertSubscription struct {
+	ID              uuid.UUID
+	SubscriberID    string
+	LocationID      uuid.UUID
+	Metric          string
+	Operator        string
+	Threshold       float64
+	WindowHours     int32
+	WebhookUrl      string
+	CreatedAt       time.Time
+	Triggered       bool
+	LastTriggeredAt sql.NullTime
+}
+
+type ApiKey struct {
+	ID         uuid.UUID
+	Name       string
+	KeyHash    string
+	CreatedAt  time.Time
+	RevokedAt  sql.NullTime
+	DailyQuota sql.NullInt32
+}
+
 type CurrentWeather struct {
+	ID                   uuid.UUID
+	LocationID           uuid.UUID
+	SourceApi            string
+	UpdatedAt            time.Time
+	TemperatureC         sql.NullFloat64
+	Humidity             sql.NullInt32
+	WindSpeedKmh         sql.NullFloat64
+	PrecipitationMm      sql.NullFloat64
+	ConditionText        sql.NullString
+	UvIndex              sql.NullFloat64
+	Sunrise              sql.NullTime
+	Sunset               sql.NullTime
+	WindDirectionDeg     sql.NullFloat64
+	WindGustKmh          sql.NullFloat64
+	ApparentTemperatureC sql.NullFloat64
+	DewPointC            sql.NullFloat64
+	RainMm               sql.NullFloat64
+	SnowMm               sql.NullFloat64
+}
+
+type CurrentWeatherHistory struct {
 	ID              uuid.UUID
 	LocationID      uuid.UUID
 	SourceApi       string
@@ -21,9 +89,43 @@ type CurrentWeather struct {
 	WindSpeedKmh    sql.NullFloat64
 	PrecipitationMm sql.NullFloat64
 	ConditionText   sql.NullString
+	ArchivedAt      time.Time
 }
 
 type DailyForecast struct {
+	ID                                  uuid.UUID
+	LocationID                          uuid.UUID
+	SourceApi                           string
+	ForecastDate                        time.Time
+	UpdatedAt                           time.Time
+	MinTempC                            sql.NullFloat64
+	MaxTempC                            sql.NullFloat64
+	PrecipitationMm                     sql.NullFloat64
+	PrecipitationChancePercent          sql.NullInt32
+	WindSpeedKmh                        sql.NullFloat64
+	Humidity                            sql.NullInt32
+	UvIndex                             sql.NullFloat64
+	Sunrise                             sql.NullTime
+	Sunset                              sql.NullTime
+	WindDirectionDeg                    sql.NullFloat64
+	WindGustKmh                         sql.NullFloat64
+	RainMm                              sql.NullFloat64
+	SnowMm                              sql.NullFloat64
+	MorningTempC                        sql.NullFloat64
+	MorningPrecipitationChancePercent   sql.NullInt32
+	MorningConditionText                sql.NullString
+	AfternoonTempC                      sql.NullFloat64
+	AfternoonPrecipitationChancePercent sql.NullInt32
+	AfternoonConditionText              sql.NullString
+	EveningTempC                        sql.NullFloat64
+	EveningPrecipitationChancePercent   sql.NullInt32
+	EveningConditionText                sql.NullString
+	NightTempC                          sql.NullFloat64
+	NightPrecipitationChancePercent     sql.NullInt32
+	NightConditionText                  sql.NullString
+}
+
+type DailyForecastHistory struct {
 	ID                         uuid.UUID
 	LocationID                 uuid.UUID
 	SourceApi                  string
@@ -35,6 +137,13 @@ type DailyForecast struct {
 	PrecipitationChancePercent sql.NullInt32
 	WindSpeedKmh               sql.NullFloat64
 	Humidity                   sql.NullInt32
+	ArchivedAt                 time.Time
+}
+
+type EndpointRequestStat struct {
+	Hour         time.Time
+	Endpoint     string
+	RequestCount int64
 }
 
 type HourlyForecast struct {
@@ -49,6 +158,52 @@ type HourlyForecast struct {
 	PrecipitationMm            sql.NullFloat64
 	PrecipitationChancePercent sql.NullInt32
 	ConditionText              sql.NullString
+	WindDirectionDeg           sql.NullFloat64
+	WindGustKmh                sql.NullFloat64
+	ApparentTemperatureC       sql.NullFloat64
+	DewPointC                  sql.NullFloat64
+	RainMm                     sql.NullFloat64
+	SnowMm                     sql.NullFloat64
+}
+
+type HourlyForecastHistory struct {
+	ID                         uuid.UUID
+	LocationID                 uuid.UUID
+	SourceApi                  string
+	ForecastDatetimeUtc        time.Time
+	UpdatedAt                  time.Time
+	TemperatureC               sql.NullFloat64
+	Humidity                   sql.NullInt32
+	WindSpeedKmh               sql.NullFloat64
+	PrecipitationMm            sql.NullFloat64
+	PrecipitationChancePercent sql.NullInt32
+	ConditionText              sql.NullString
+	ArchivedAt                 time.Time
+}
+
+type JobRun struct {
+	ID                 uuid.UUID
+	JobName            string
+	Status             string
+	StartedAt          time.Time
+	FinishedAt         sql.NullTime
+	DurationMs         sql.NullInt64
+	LocationsTotal     int32
+	LocationsSucceeded int32
+	LocationsFailed    int32
+	LocationsSkipped   int32
+	Error              sql.NullString
+}
+
+type JobRunLocation struct {
+	JobRunID   uuid.UUID
+	LocationID uuid.UUID
+	Status     string
+	QueuedAt   time.Time
+	StartedAt  sql.NullTime
+	FinishedAt sql.NullTime
+	DurationMs sql.NullInt64
+	Error      sql.NullString
 }
 
 type Location struct {
@@ -60,7 +215,120 @@ type Location struct {
 	Timezone    sql.NullString
 }
 
+type LocationAccess struct {
+	LocationID     uuid.UUID
+	LastAccessedAt time.Time
+}
+
 type LocationAlias struct {
 	Alias      string
 	LocationID uuid.UUID
 }
+
+type LocationFetchStat struct {
+	Hour           time.Time
+	LocationID     uuid.UUID
+	OperationCount int64
+}
+
+type LocationGroup struct {
+	ID           uuid.UUID
+	SubscriberID string
+	Name         string
+	CreatedAt    time.Time
+}
+
+type LocationGroupMember struct {
+	GroupID    uuid.UUID
+	LocationID uuid.UUID
+	AddedAt    time.Time
+}
+
+type LocationProviderWeight struct {
+	LocationID uuid.UUID
+	Provider   string
+	Weight     float64
+}
+
+type LocationRequestStat struct {
+	Hour         time.Time
+	LocationID   uuid.UUID
+	Endpoint     string
+	RequestCount int64
+}
+
+type ProviderDisagreement struct {
+	LocationID                uuid.UUID
+	ComputedOn                time.Time
+	ForecastDate              time.Time
+	ComputedAt                time.Time
+	Sources                   int32
+	MinTempSpreadC            float64
+	MaxTempSpreadC            float64
+	PrecipitationSpreadMm     float64
+	PrecipitationChanceSpread float64
+	WindSpeedSpreadKmh        float64
+	ConditionAgreement        float64
+}
+
+type ProviderUsageStat struct {
+	Hour         time.Time
+	LocationID   uuid.UUID
+	Provider     string
+	CallCount    int64
+	FailureCount int64
+}
+
+type SchedulerInterval struct {
+	JobName         string
+	IntervalSeconds int32
+	UpdatedAt       time.Time
+}
+
+type SchedulerRun struct {
+	ID           uuid.UUID
+	LocationID   uuid.UUID
+	JobType      string
+	Provider     string
+	StartedAt    time.Time
+	DurationMs   int64
+	RowsWritten  int32
+	HoursCovered int32
+	ErrorClass   sql.NullString
+	ErrorMessage sql.NullString
+}
+
+type User struct {
+	ID           uuid.UUID
+	Email        string
+	PasswordHash string
+	Units        string
+	Language     string
+	DefaultCity  string
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+type WatchlistEntry struct {
+	SubscriberID string
+	LocationID   uuid.UUID
+	CreatedAt    time.Time
+}
+
+type WeatherObservation struct {
+	LocationID      uuid.UUID
+	SourceApi       string
+	ObservedAt      time.Time
+	TemperatureC    sql.NullFloat64
+	Humidity        sql.NullInt32
+	WindSpeedKmh    sql.NullFloat64
+	PrecipitationMm sql.NullFloat64
+	ConditionText   sql.NullString
+}
+
+type WeatherWarning struct {
+	LocationID uuid.UUID
+	SourceApi  string
+	Warnings   json.RawMessage
+	UpdatedAt  time.Time
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+)
+
+// staleSeriesAfter is how long a cumulative series is remembered after it was last scraped.
+const staleSeriesAfter = time.Hour
+
+// cumulativeSeries remembers the cumulative series of all targets for as long as the scraper
+// instance is running, so that consecutive scrapes agree on their start times.
+var cumulativeSeries = newCumulativeTracker()
+
+// cumulativeTracker tracks the start time of cumulative series, such as counters and the counts
+// of histograms and summaries. Backends rely on the start time to tell a counter that restarted
+// from zero apart from one that kept growing, so it must stay the same between scrapes and change
+// only when the series is reset.
+type cumulativeTracker struct {
+	mu     sync.Mutex
+	series map[string]cumulativeState
+}
+
+// cumulativeState is the last known state of a cumulative series.
+type cumulativeState struct {
+	start time.Time
+	last  float64
+	seen  time.Time
+}
+
+func newCumulativeTracker() *cumulativeTracker {
+	return &cumulativeTracker{series: make(map[string]cumulativeState)}
+}
+
+// startTime records the value of a cumulative series scraped at now and returns its start time.
+// A series that was not seen before, or whose value dropped since the last scrape, was reset.
+// It then starts when the target's process started, if the target reports that and it is later
+// than the previous start, and otherwise a millisecond before now, as the interval of a
+// cumulative point must not be empty.
+func (t *cumulativeTracker) startTime(key string, value float64, processStart, now time.Time) time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	st, ok := t.series[key]
+	if !ok || value < st.last {
+		st.start = resetTime(st.start, processStart, now)
+	}
+	st.last = value
+	st.seen = now
+	t.series[key] = st
+	return st.start
+}
+
+// prune forgets the series that were not scraped since before cutoff, such as those of label
+// values that are no longer used.
+func (t *cumulativeTracker) prune(cutoff time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for key, st := range t.series {
+		if st.seen.Before(cutoff) {
+			delete(t.series, key)
+		}
+	}
+}
+
+// resetTime returns the start time of a series that was reset after previous.
+func resetTime(previous, processStart, now time.Time) time.Time {
+	if processStart.After(previous) && processStart.Before(now) {
+		return processStart
+	}
+	return now.Add(-time.Millisecond)
+}
+
+// processStartTime returns the start time of the target's process from the standard
+// process_start_time_seconds gauge, or the zero time if the target does not expose it.
+func processStartTime(metricFamilies map[string]*dto.MetricFamily) time.Time {
+	mf, ok := metricFamilies["process_start_time_seconds"]
+	if !ok || len(mf.GetMetric()) == 0 {
+		return time.Time{}
+	}
+	seconds := mf.GetMetric()[0].GetGauge().GetValue()
+	if seconds <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, int64(seconds*float64(time.Second)))
+}
+
+// seriesKey identifies a series of a target by its metric name and labels, in a form that does
+// not depend on the order of the labels.
+func seriesKey(target scrapeTarget, name string, m *dto.Metric) string {
+	labels := make([]string, 0, len(m.GetLabel()))
+	for _, lp := range m.GetLabel() {
+		labels = append(labels, lp.GetName()+"="+lp.GetValue())
+	}
+	sort.Strings(labels)
+	return strings.Join(append([]string{target.Job, target.Instance, name}, labels...), "\xff")
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	dto "github.com/prometheus/client_model/go"
+)
+
+// Backends the scraped metrics can be exported to, selected with EXPORTER.
+const (
+	exporterGCM         = "gcm"
+	exporterPushgateway = "pushgateway"
+	exporterOTLP        = "otlp"
+)
+
+// exporter writes the metrics scraped from a target to a monitoring backend. It is called
+// concurrently for different targets.
+type exporter interface {
+	export(ctx context.Context, target scrapeTarget, metricFamilies map[string]*dto.MetricFamily) error
+}
+
+// newExporter creates the exporter selected by EXPORTER. It defaults to Google Cloud
+// Monitoring, which the scraper was first written for.
+func newExporter(logger *slog.Logger) (exporter, error) {
+	name := strings.ToLower(strings.TrimSpace(os.Getenv("EXPORTER")))
+	switch name {
+	case "", exporterGCM:
+		return newGCMExporter(logger)
+	case exporterPushgateway:
+		return newPushgatewayExporter()
+	case exporterOTLP:
+		return newOTLPExporter(logger), nil
+	default:
+		return nil, fmt.Errorf("EXPORTER must be one of %s, %s or %s, got %q", exporterGCM, exporterPushgateway, exporterOTLP, name)
+	}
+}
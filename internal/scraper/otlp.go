@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// otlpExporter sends metrics to an OpenTelemetry collector over OTLP/HTTP. The collector is
+// configured with the standard OTEL_EXPORTER_OTLP_* variables, such as
+// OTEL_EXPORTER_OTLP_ENDPOINT and OTEL_EXPORTER_OTLP_HEADERS.
+type otlpExporter struct {
+	logger *slog.Logger
+}
+
+func newOTLPExporter(logger *slog.Logger) *otlpExporter {
+	return &otlpExporter{logger: logger}
+}
+
+// export converts the metrics to OTLP and sends them. Like the Cloud Monitoring client, the
+// OTLP exporter is created for each scrape, as the scraper only runs when it is triggered.
+func (e *otlpExporter) export(ctx context.Context, target scrapeTarget, metricFamilies map[string]*dto.MetricFamily) error {
+	rm, err := convertToResourceMetrics(target, metricFamilies, cumulativeSeries, time.Now(), e.logger)
+	if err != nil {
+		return err
+	}
+	exp, err := otlpmetrichttp.New(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create OTLP exporter: %w", err)
+	}
+	defer func() {
+		if err := exp.Shutdown(context.WithoutCancel(ctx)); err != nil {
+			e.logger.Warn("could not shut down OTLP exporter", "error", err)
+		}
+	}()
+	if err := exp.Export(ctx, rm); err != nil {
+		return fmt.Errorf("failed to send OTLP metrics: %w", err)
+	}
+	return nil
+}
+
+// convertToResourceMetrics converts parsed Prometheus metrics to OpenTelemetry metric data.
+// Counters become monotonic cumulative sums, gauges and untyped metrics become gauges,
+// histograms become explicit-bucket histograms and summaries become summaries. The start times
+// of cumulative data points are kept by tracker. The job and instance of the target are recorded
+// as the service name and instance.
+func convertToResourceMetrics(target scrapeTarget, metricFamilies map[string]*dto.MetricFamily, tracker *cumulativeTracker, now time.Time, logger *slog.Logger) (*metricdata.ResourceMetrics, error) {
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceName(target.Job),
+		semconv.ServiceInstanceID(target.Instance),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP resource: %w", err)
+	}
+
+	processStart := processStartTime(metricFamilies)
+	names := make([]string, 0, len(metricFamilies))
+	for name := range metricFamilies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var metrics []metricdata.Metrics
+	for _, name := range names {
+		mf := metricFamilies[name]
+		m := metricdata.Metrics{Name: name, Description: mf.GetHelp()}
+		switch mf.GetType() {
+		case dto.MetricType_COUNTER:
+			sum := metricdata.Sum[float64]{Temporality: metricdata.CumulativeTemporality, IsMonotonic: true}
+			for _, pm := range mf.GetMetric() {
+				value := pm.GetCounter().GetValue()
+				sum.DataPoints = append(sum.DataPoints, metricdata.DataPoint[float64]{
+					Attributes: otlpAttributes(pm),
+					StartTime:  tracker.startTime(seriesKey(target, name, pm), value, processStart, now),
+					Time:       now,
+					Value:      value,
+				})
+			}
+			m.Data = sum
+		case dto.MetricType_GAUGE, dto.MetricType_UNTYPED:
+			var gauge metricdata.Gauge[float64]
+			for _, pm := range mf.GetMetric() {
+				value := pm.GetGauge().GetValue()
+				if mf.GetType() == dto.MetricType_UNTYPED {
+					value = pm.GetUntyped().GetValue()
+				}
+				gauge.DataPoints = append(gauge.DataPoints, metricdata.DataPoint[float64]{
+					Attributes: otlpAttributes(pm),
+					Time:       now,
+					Value:      value,
+				})
+			}
+			m.Data = gauge
+		case dto.MetricType_HISTOGRAM:
+			hist := metricdata.Histogram[float64]{Temporality: metricdata.CumulativeTemporality}
+			for _, pm := range mf.GetMetric() {
+				dp := otlpHistogramDataPoint(pm, now)
+				dp.StartTime = tracker.startTime(seriesKey(target, name, pm), float64(dp.Count), processStart, now)
+				hist.DataPoints = append(hist.DataPoints, dp)
+			}
+			m.Data = hist
+		case dto.MetricType_SUMMARY:
+			var summary metricdata.Summary
+			for _, pm := range mf.GetMetric() {
+				dp := otlpSummaryDataPoint(pm, now)
+				dp.StartTime = tracker.startTime(seriesKey(target, name, pm), float64(dp.Count), processStart, now)
+				summary.DataPoints = append(summary.DataPoints, dp)
+			}
+			m.Data = summary
+		default:
+			logger.Warn("skipping metric with unhandled type", "metric", name, "type", mf.GetType())
+			continue
+		}
+		metrics = append(metrics, m)
+	}
+	tracker.prune(now.Add(-staleSeriesAfter))
+
+	return &metricdata.ResourceMetrics{
+		Resource: res,
+		ScopeMetrics: []metricdata.ScopeMetrics{{
+			Scope:   instrumentation.Scope{Name: "github.com/cor0nius/willitrain/internal/scraper"},
+			Metrics: metrics,
+		}},
+	}, nil
+}
+
+// otlpAttributes returns the labels of a Prometheus metric as OpenTelemetry attributes.
+func otlpAttributes(m *dto.Metric) attribute.Set {
+	kvs := make([]attribute.KeyValue, 0, len(m.GetLabel()))
+	for _, lp := range m.GetLabel() {
+		kvs = append(kvs, attribute.String(lp.GetName(), lp.GetValue()))
+	}
+	return attribute.NewSet(kvs...)
+}
+
+// otlpHistogramDataPoint converts a Prometheus histogram, whose bucket counts are cumulative
+// and end with the +Inf bucket, to an OpenTelemetry data point with a count per bucket.
+func otlpHistogramDataPoint(m *dto.Metric, now time.Time) metricdata.HistogramDataPoint[float64] {
+	h := m.GetHistogram()
+	dp := metricdata.HistogramDataPoint[float64]{
+		Attributes: otlpAttributes(m),
+		Time:       now,
+		Count:      h.GetSampleCount(),
+		Sum:        h.GetSampleSum(),
+	}
+	var last uint64
+	for _, b := range h.GetBucket() {
+		if !math.IsInf(b.GetUpperBound(), 1) {
+			dp.Bounds = append(dp.Bounds, b.GetUpperBound())
+		}
+		dp.BucketCounts = append(dp.BucketCounts, b.GetCumulativeCount()-last)
+		last = b.GetCumulativeCount()
+	}
+	// The text format may omit the +Inf bucket, whose count is then the remainder of the total.
+	if len(dp.BucketCounts) == len(dp.Bounds) {
+		dp.BucketCounts = append(dp.BucketCounts, h.GetSampleCount()-last)
+	}
+	return dp
+}
+
+// otlpSummaryDataPoint converts a Prometheus summary to an OpenTelemetry data point. Quantiles
+// of a summary without observations are NaN and are left out.
+func otlpSummaryDataPoint(m *dto.Metric, now time.Time) metricdata.SummaryDataPoint {
+	s := m.GetSummary()
+	dp := metricdata.SummaryDataPoint{
+		Attributes: otlpAttributes(m),
+		Time:       now,
+		Count:      s.GetSampleCount(),
+		Sum:        s.GetSampleSum(),
+	}
+	for _, q := range s.GetQuantile() {
+		if math.IsNaN(q.GetValue()) {
+			continue
+		}
+		dp.QuantileValues = append(dp.QuantileValues, metricdata.QuantileValue{Quantile: q.GetQuantile(), Value: q.GetValue()})
+	}
+	return dp
+}
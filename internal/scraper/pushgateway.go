@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/push"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// pushgatewayExporter pushes metrics to a Prometheus Pushgateway, from which a Prometheus
+// server without access to the application can scrape them.
+type pushgatewayExporter struct {
+	url string
+	job string
+}
+
+// newPushgatewayExporter reads the Pushgateway address from PUSHGATEWAY_URL and the job the
+// metrics are grouped under from PUSHGATEWAY_JOB. Without it, the job of each target is used.
+func newPushgatewayExporter() (*pushgatewayExporter, error) {
+	url := os.Getenv("PUSHGATEWAY_URL")
+	if url == "" {
+		return nil, fmt.Errorf("environment variable PUSHGATEWAY_URL must be set")
+	}
+	return &pushgatewayExporter{url: url, job: os.Getenv("PUSHGATEWAY_JOB")}, nil
+}
+
+// export replaces the metrics of the target's group, identified by the job and the instance
+// label of the target, with the scraped ones.
+func (e *pushgatewayExporter) export(ctx context.Context, target scrapeTarget, metricFamilies map[string]*dto.MetricFamily) error {
+	families := make([]*dto.MetricFamily, 0, len(metricFamilies))
+	for _, mf := range metricFamilies {
+		families = append(families, mf)
+	}
+	sort.Slice(families, func(i, j int) bool { return families[i].GetName() < families[j].GetName() })
+
+	gatherer := prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+		return families, nil
+	})
+	job := e.job
+	if job == "" {
+		job = target.Job
+	}
+	if err := push.New(e.url, job).Grouping("instance", target.Instance).Gatherer(gatherer).PushContext(ctx); err != nil {
+		return fmt.Errorf("failed to push metrics to %s: %w", e.url, err)
+	}
+	return nil
+}
@@ -4,12 +4,14 @@
 //
 // The scraper performs the following steps:
 //  1. Receives an HTTP request from the scheduler.
-//  2. Fetches Prometheus metrics from the main application's /metrics endpoint.
+//  2. Fetches Prometheus metrics from the main application's /metrics endpoint, and
+//     from any other targets listed in METRICS_URL, concurrently.
 //  3. Parses the text-based Prometheus exposition format, handling counters, gauges,
-//     and histograms.
-//  4. Converts the parsed metrics into the format required by Google Cloud's
-//     Managed Service for Prometheus.
-//  5. Ingests the converted metrics into Google Cloud Monitoring.
+//     histograms and summaries.
+//  4. Hands the parsed metrics to the exporter selected by the EXPORTER environment
+//     variable, which converts them to the format of its backend and writes them:
+//     Google Cloud's Managed Service for Prometheus (gcm, the default), a Prometheus
+//     Pushgateway (pushgateway) or an OpenTelemetry collector over OTLP (otlp).
 //
 // This approach decouples metrics collection from the main application, ensuring
 // that scraping is reliable and independently managed.
@@ -22,6 +24,8 @@ import (
 	"math"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	monitoring "cloud.google.com/go/monitoring/apiv3/v2"
@@ -70,56 +74,67 @@ func main() {
 }
 
 // scrapeHandler handles incoming HTTP requests from Cloud Scheduler.
-// It orchestrates the scraping and ingestion process and logs the outcome.
+// It orchestrates the scraping and ingestion process and reports the outcome of each
+// target. The request fails only if no target could be scraped and exported, so that a
+// target that is down does not make the scheduler retry the others.
 func scrapeHandler(w http.ResponseWriter, r *http.Request, logger *slog.Logger) {
 	logger.Info("scrape request received")
-	if err := scrapeAndIngest(r.Context(), logger); err != nil {
+	results, err := scrapeAndIngest(r.Context(), logger)
+	if err != nil {
 		logger.Error("error during scrape and ingest", "error", err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	logger.Info("successfully scraped and ingested metrics")
-	fmt.Fprintln(w, "Success")
-}
 
-// scrapeAndIngest performs the core logic of fetching, parsing, and ingesting metrics.
-// It reads configuration from environment variables, calls the function to convert
-// Prometheus metrics to Google Cloud Monitoring TimeSeries, and then writes them.
-func scrapeAndIngest(ctx context.Context, logger *slog.Logger) error {
-	metricsURL := os.Getenv("METRICS_URL")
-	if metricsURL == "" {
-		return fmt.Errorf("environment variable METRICS_URL must be set")
+	var failed int
+	var report strings.Builder
+	for _, res := range results {
+		if res.err != nil {
+			failed++
+			logger.Error("error during scrape and ingest", "job", res.target.Job, "instance", res.target.Instance, "error", res.err)
+			fmt.Fprintf(&report, "%s %s: %v\n", res.target.Job, res.target.Instance, res.err)
+		} else {
+			fmt.Fprintf(&report, "%s %s: ok\n", res.target.Job, res.target.Instance)
+		}
 	}
-	projectID := os.Getenv("PROJECT_ID")
-	if projectID == "" {
-		return fmt.Errorf("environment variable PROJECT_ID must be set")
+	if failed == len(results) {
+		http.Error(w, report.String(), http.StatusInternalServerError)
+		return
 	}
-
-	// Fetch metrics and convert them to the Google Cloud Monitoring format.
-	timeSeries, err := fetchAndConvertToTimeSeries(ctx, projectID, metricsURL, logger)
-	if err != nil {
-		return fmt.Errorf("failed to fetch and convert metrics: %w", err)
+	if failed > 0 {
+		logger.Warn("scraped and ingested metrics of some targets", "failed", failed, "targets", len(results))
+		fmt.Fprint(w, report.String())
+		return
 	}
+	logger.Info("successfully scraped and ingested metrics", "targets", len(results))
+	fmt.Fprintln(w, "Success")
+}
 
-	if len(timeSeries) == 0 {
-		logger.Info("no metric samples found to ingest")
-		return nil
+// scrapeAndIngest performs the core logic of fetching, parsing, and ingesting metrics.
+// It reads configuration from environment variables, scrapes the metrics of every
+// target and passes them to the configured exporter. It returns an error only if the
+// configuration is invalid, and the outcome of each target otherwise.
+func scrapeAndIngest(ctx context.Context, logger *slog.Logger) ([]targetResult, error) {
+	targets, err := parseTargets(os.Getenv("METRICS_URL"))
+	if err != nil {
+		return nil, err
 	}
-
-	// Ingest the TimeSeries data into Google Cloud Monitoring.
-	if err := ingestMetrics(ctx, projectID, timeSeries); err != nil {
-		return fmt.Errorf("failed to ingest metrics: %w", err)
+	exp, err := newExporter(logger)
+	if err != nil {
+		return nil, err
 	}
-
-	return nil
+	return scrapeTargets(ctx, targets, exp, logger), nil
 }
 
-// fetchAndConvertToTimeSeries scrapes a Prometheus endpoint, parses the response,
-// and converts the metrics into Google Cloud Monitoring's TimeSeries format.
-// It handles Counter, Gauge, Untyped, and Histogram metric types.
-func fetchAndConvertToTimeSeries(ctx context.Context, projectID, url string, logger *slog.Logger) ([]*monitoringpb.TimeSeries, error) {
+// fetchMetricFamilies scrapes a Prometheus endpoint and parses the text exposition
+// format of the response. It is the front-end shared by all exporters.
+func fetchMetricFamilies(ctx context.Context, url string) (map[string]*dto.MetricFamily, error) {
 	httpClient := &http.Client{Timeout: 10 * time.Second}
-	resp, err := httpClient.Get(url)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := httpClient.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -134,7 +149,42 @@ func fetchAndConvertToTimeSeries(ctx context.Context, projectID, url string, log
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse prometheus metrics: %w", err)
 	}
+	return metricFamilies, nil
+}
 
+// gcmExporter writes metrics to Google Cloud's Managed Service for Prometheus through the
+// Cloud Monitoring API.
+type gcmExporter struct {
+	projectID string
+	logger    *slog.Logger
+}
+
+// newGCMExporter reads the Google Cloud project to write to from PROJECT_ID.
+func newGCMExporter(logger *slog.Logger) (*gcmExporter, error) {
+	projectID := os.Getenv("PROJECT_ID")
+	if projectID == "" {
+		return nil, fmt.Errorf("environment variable PROJECT_ID must be set")
+	}
+	return &gcmExporter{projectID: projectID, logger: logger}, nil
+}
+
+// export converts the metrics to Cloud Monitoring TimeSeries and ingests them.
+func (e *gcmExporter) export(ctx context.Context, target scrapeTarget, metricFamilies map[string]*dto.MetricFamily) error {
+	timeSeries := convertToTimeSeries(e.projectID, target, metricFamilies, cumulativeSeries, time.Now(), e.logger)
+	if len(timeSeries) == 0 {
+		e.logger.Info("no metric samples found to ingest")
+		return nil
+	}
+	return ingestMetrics(ctx, e.projectID, timeSeries)
+}
+
+// convertToTimeSeries converts parsed Prometheus metrics into Google Cloud Monitoring's
+// TimeSeries format, following the naming of Managed Service for Prometheus, which appends the
+// kind of the metric to its name. Gauges and untyped metrics become GAUGE points. Counters and
+// histograms become CUMULATIVE points whose start time is tracked across scrapes, so that rates
+// stay correct when a target restarts. Summaries become a gauge per quantile and cumulative
+// _sum and _count series.
+func convertToTimeSeries(projectID string, target scrapeTarget, metricFamilies map[string]*dto.MetricFamily, tracker *cumulativeTracker, now time.Time, logger *slog.Logger) []*monitoringpb.TimeSeries {
 	resource := &monitoredres.MonitoredResource{
 		Type: "prometheus_target",
 		Labels: map[string]string{
@@ -142,13 +192,27 @@ func fetchAndConvertToTimeSeries(ctx context.Context, projectID, url string, log
 			"location":   "europe-west1",
 			"cluster":    "__gce__",
 			"namespace":  "willitrain",
-			"job":        "willitrain",
-			"instance":   url,
+			"job":        target.Job,
+			"instance":   target.Instance,
 		},
 	}
 
 	var timeSeriesList []*monitoringpb.TimeSeries
-	now := timestamppb.New(time.Now())
+	add := func(metricType string, labels map[string]string, kind metric.MetricDescriptor_MetricKind, valueType metric.MetricDescriptor_ValueType, point *monitoringpb.Point) {
+		timeSeriesList = append(timeSeriesList, &monitoringpb.TimeSeries{
+			Metric: &metric.Metric{
+				Type:   "prometheus.googleapis.com/" + metricType,
+				Labels: labels,
+			},
+			Resource:   resource,
+			MetricKind: kind,
+			ValueType:  valueType,
+			Points:     []*monitoringpb.Point{point},
+		})
+	}
+
+	end := timestamppb.New(now)
+	processStart := processStartTime(metricFamilies)
 
 	for name, mf := range metricFamilies {
 		for _, m := range mf.GetMetric() {
@@ -157,41 +221,53 @@ func fetchAndConvertToTimeSeries(ctx context.Context, projectID, url string, log
 				labels[lp.GetName()] = lp.GetValue()
 			}
 
-			ts := &monitoringpb.TimeSeries{
-				Metric: &metric.Metric{
-					Type:   "prometheus.googleapis.com/" + name,
-					Labels: labels,
-				},
-				Resource: resource,
-			}
-
-			var point *monitoringpb.Point
 			switch mf.GetType() {
 			case dto.MetricType_COUNTER:
-				point = createPoint(now, m.GetCounter().GetValue())
+				value := m.GetCounter().GetValue()
+				start := tracker.startTime(seriesKey(target, name, m), value, processStart, now)
+				add(name+"/counter", labels, metric.MetricDescriptor_CUMULATIVE, metric.MetricDescriptor_DOUBLE, createCumulativePoint(start, now, value))
 			case dto.MetricType_GAUGE:
-				point = createPoint(now, m.GetGauge().GetValue())
+				add(name+"/gauge", labels, metric.MetricDescriptor_GAUGE, metric.MetricDescriptor_DOUBLE, createPoint(end, m.GetGauge().GetValue()))
 			case dto.MetricType_UNTYPED:
-				point = createPoint(now, m.GetUntyped().GetValue())
+				add(name+"/unknown", labels, metric.MetricDescriptor_GAUGE, metric.MetricDescriptor_DOUBLE, createPoint(end, m.GetUntyped().GetValue()))
 			case dto.MetricType_HISTOGRAM:
-				point = createDistributionPoint(now, m.GetHistogram(), logger)
+				h := m.GetHistogram()
+				start := tracker.startTime(seriesKey(target, name, m), float64(h.GetSampleCount()), processStart, now)
+				point := createDistributionPoint(end, h, logger)
+				point.Interval.StartTime = timestamppb.New(start)
+				add(name+"/histogram", labels, metric.MetricDescriptor_CUMULATIVE, metric.MetricDescriptor_DISTRIBUTION, point)
 			case dto.MetricType_SUMMARY:
-				logger.Debug("skipping metric with unhandled summary type", "metric", name)
-				continue
+				s := m.GetSummary()
+				// The sum of a summary may decrease if negative values are observed, so resets
+				// are detected with its count, and both series share the start time.
+				start := tracker.startTime(seriesKey(target, name, m), float64(s.GetSampleCount()), processStart, now)
+				for _, q := range s.GetQuantile() {
+					// Quantiles of a summary without observations are NaN, which Cloud
+					// Monitoring does not accept.
+					if math.IsNaN(q.GetValue()) {
+						continue
+					}
+					quantileLabels := make(map[string]string, len(labels)+1)
+					for k, v := range labels {
+						quantileLabels[k] = v
+					}
+					quantileLabels["quantile"] = strconv.FormatFloat(q.GetQuantile(), 'g', -1, 64)
+					add(name+"/summary", quantileLabels, metric.MetricDescriptor_GAUGE, metric.MetricDescriptor_DOUBLE, createPoint(end, q.GetValue()))
+				}
+				add(name+"_sum/summary:counter", labels, metric.MetricDescriptor_CUMULATIVE, metric.MetricDescriptor_DOUBLE, createCumulativePoint(start, now, s.GetSampleSum()))
+				add(name+"_count/summary", labels, metric.MetricDescriptor_CUMULATIVE, metric.MetricDescriptor_DOUBLE, createCumulativePoint(start, now, float64(s.GetSampleCount())))
 			default:
 				logger.Warn("skipping metric with unhandled type", "metric", name, "type", mf.GetType())
-				continue
 			}
-
-			ts.Points = []*monitoringpb.Point{point}
-			timeSeriesList = append(timeSeriesList, ts)
 		}
 	}
-	return timeSeriesList, nil
+
+	tracker.prune(now.Add(-staleSeriesAfter))
+	return timeSeriesList
 }
 
 // createPoint creates a monitoring TimeSeries point with a double value.
-// This is used for simple metrics like counters and gauges.
+// This is used for gauges and untyped metrics.
 func createPoint(timestamp *timestamppb.Timestamp, value float64) *monitoringpb.Point {
 	return &monitoringpb.Point{
 		Interval: &monitoringpb.TimeInterval{
@@ -205,6 +281,22 @@ func createPoint(timestamp *timestamppb.Timestamp, value float64) *monitoringpb.
 	}
 }
 
+// createCumulativePoint creates a monitoring TimeSeries point with a double value that has
+// accumulated since start. This is used for counters and the sum and count of summaries.
+func createCumulativePoint(start, end time.Time, value float64) *monitoringpb.Point {
+	return &monitoringpb.Point{
+		Interval: &monitoringpb.TimeInterval{
+			StartTime: timestamppb.New(start),
+			EndTime:   timestamppb.New(end),
+		},
+		Value: &monitoringpb.TypedValue{
+			Value: &monitoringpb.TypedValue_DoubleValue{
+				DoubleValue: value,
+			},
+		},
+	}
+}
+
 // createDistributionPoint creates a monitoring TimeSeries point for a histogram.
 // It converts a Prometheus histogram DTO into a Google Cloud Monitoring Distribution value.
 func createDistributionPoint(timestamp *timestamppb.Timestamp, h *dto.Histogram, logger *slog.Logger) *monitoringpb.Point {
@@ -238,9 +330,15 @@ func createDistributionPoint(timestamp *timestamppb.Timestamp, h *dto.Histogram,
 		finalSampleCount = int64(sampleCount)
 	}
 
+	// The mean of a histogram without observations is undefined, and NaN is not accepted.
+	var mean float64
+	if sampleCount > 0 {
+		mean = h.GetSampleSum() / float64(sampleCount)
+	}
+
 	dist := &distribution.Distribution{
 		Count: finalSampleCount,
-		Mean:  h.GetSampleSum() / float64(h.GetSampleCount()),
+		Mean:  mean,
 		BucketOptions: &distribution.Distribution_BucketOptions{
 			Options: &distribution.Distribution_BucketOptions_ExplicitBuckets{
 				ExplicitBuckets: &distribution.Distribution_BucketOptions_Explicit{
@@ -0,0 +1,357 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/monitoring/apiv3/v2/monitoringpb"
+	dto "github.com/prometheus/client_model/go"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"google.golang.org/genproto/googleapis/api/metric"
+)
+
+const testMetrics = `# HELP willitrain_http_requests_total Total number of HTTP requests by path, method and code.
+# TYPE willitrain_http_requests_total counter
+willitrain_http_requests_total{code="200",method="GET",path="/api/v1/currentweather"} 7
+# HELP willitrain_tracked_locations Number of tracked locations.
+# TYPE willitrain_tracked_locations gauge
+willitrain_tracked_locations 3
+# HELP willitrain_parser_duration_seconds Duration of parsing API responses.
+# TYPE willitrain_parser_duration_seconds histogram
+willitrain_parser_duration_seconds_bucket{le="0.1"} 2
+willitrain_parser_duration_seconds_bucket{le="1"} 5
+willitrain_parser_duration_seconds_bucket{le="+Inf"} 6
+willitrain_parser_duration_seconds_sum 4.5
+willitrain_parser_duration_seconds_count 6
+# HELP willitrain_gc_duration_seconds Duration of garbage collection pauses.
+# TYPE willitrain_gc_duration_seconds summary
+willitrain_gc_duration_seconds{quantile="0.5"} 0.002
+willitrain_gc_duration_seconds{quantile="1"} NaN
+willitrain_gc_duration_seconds_sum 0.01
+willitrain_gc_duration_seconds_count 4
+`
+
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func newMetricsServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, testMetrics)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestNewExporter(t *testing.T) {
+	testCases := []struct {
+		name     string
+		env      map[string]string
+		wantType string
+		wantErr  string
+	}{
+		{name: "Default", env: map[string]string{"PROJECT_ID": "p"}, wantType: "*main.gcmExporter"},
+		{name: "GCM Without Project", env: map[string]string{"EXPORTER": "gcm"}, wantErr: "PROJECT_ID"},
+		{name: "Pushgateway", env: map[string]string{"EXPORTER": "pushgateway", "PUSHGATEWAY_URL": "http://pushgateway:9091"}, wantType: "*main.pushgatewayExporter"},
+		{name: "Pushgateway Without URL", env: map[string]string{"EXPORTER": "pushgateway"}, wantErr: "PUSHGATEWAY_URL"},
+		{name: "OTLP", env: map[string]string{"EXPORTER": "OTLP"}, wantType: "*main.otlpExporter"},
+		{name: "Unknown", env: map[string]string{"EXPORTER": "statsd"}, wantErr: "EXPORTER must be one of"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			for _, key := range []string{"EXPORTER", "PROJECT_ID", "PUSHGATEWAY_URL"} {
+				t.Setenv(key, tc.env[key])
+			}
+			exp, err := newExporter(discardLogger)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("newExporter() error = %v, want %q", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("newExporter() error = %v", err)
+			}
+			if got := fmt.Sprintf("%T", exp); got != tc.wantType {
+				t.Errorf("newExporter() = %s, want %s", got, tc.wantType)
+			}
+		})
+	}
+}
+
+func TestPushgatewayExporter(t *testing.T) {
+	var gotPath, gotMethod, gotBody string
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotMethod = r.URL.Path, r.Method
+		body, _ := io.ReadAll(r.Body)
+		gotBody = string(body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer gateway.Close()
+
+	families, err := fetchMetricFamilies(context.Background(), newMetricsServer(t).URL)
+	if err != nil {
+		t.Fatalf("fetchMetricFamilies() error = %v", err)
+	}
+	exp := &pushgatewayExporter{url: gateway.URL}
+	if err := exp.export(context.Background(), scrapeTarget{URL: "http://app:8080/metrics", Job: "willitrain", Instance: "api-1"}, families); err != nil {
+		t.Fatalf("export() error = %v", err)
+	}
+
+	if gotMethod != http.MethodPut {
+		t.Errorf("method = %s, want PUT, so that the group is replaced", gotMethod)
+	}
+	if gotPath != "/metrics/job/willitrain/instance/api-1" {
+		t.Errorf("path = %s, want the job and instance grouping", gotPath)
+	}
+	if !strings.Contains(gotBody, "willitrain_tracked_locations") {
+		t.Errorf("pushed body does not contain the scraped metrics")
+	}
+}
+
+func TestConvertToResourceMetrics(t *testing.T) {
+	families, err := fetchMetricFamilies(context.Background(), newMetricsServer(t).URL)
+	if err != nil {
+		t.Fatalf("fetchMetricFamilies() error = %v", err)
+	}
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	rm, err := convertToResourceMetrics(scrapeTarget{URL: "http://app:8080/metrics", Job: "willitrain", Instance: "api-1"}, families, newCumulativeTracker(), now, discardLogger)
+	if err != nil {
+		t.Fatalf("convertToResourceMetrics() error = %v", err)
+	}
+
+	metrics := rm.ScopeMetrics[0].Metrics
+	if len(metrics) != 4 {
+		t.Fatalf("got %d metrics, want 4", len(metrics))
+	}
+	byName := make(map[string]metricdata.Aggregation)
+	for _, m := range metrics {
+		byName[m.Name] = m.Data
+	}
+
+	sum, ok := byName["willitrain_http_requests_total"].(metricdata.Sum[float64])
+	if !ok || !sum.IsMonotonic || sum.Temporality != metricdata.CumulativeTemporality || sum.DataPoints[0].Value != 7 {
+		t.Errorf("counter = %+v, want a monotonic cumulative sum of 7", byName["willitrain_http_requests_total"])
+	}
+	if !sum.DataPoints[0].StartTime.Before(now) {
+		t.Errorf("counter start time = %v, want before %v", sum.DataPoints[0].StartTime, now)
+	}
+	if code, _ := sum.DataPoints[0].Attributes.Value("code"); code.AsString() != "200" {
+		t.Errorf("counter attributes = %v, want the labels", sum.DataPoints[0].Attributes)
+	}
+	if gauge, ok := byName["willitrain_tracked_locations"].(metricdata.Gauge[float64]); !ok || gauge.DataPoints[0].Value != 3 {
+		t.Errorf("gauge = %+v, want 3", byName["willitrain_tracked_locations"])
+	}
+
+	hist, ok := byName["willitrain_parser_duration_seconds"].(metricdata.Histogram[float64])
+	if !ok {
+		t.Fatalf("histogram = %T, want a histogram", byName["willitrain_parser_duration_seconds"])
+	}
+	dp := hist.DataPoints[0]
+	if dp.Count != 6 || dp.Sum != 4.5 {
+		t.Errorf("histogram count and sum = %d, %v, want 6, 4.5", dp.Count, dp.Sum)
+	}
+	wantBounds, wantCounts := []float64{0.1, 1}, []uint64{2, 3, 1}
+	if len(dp.Bounds) != len(wantBounds) || len(dp.BucketCounts) != len(wantCounts) {
+		t.Fatalf("histogram buckets = %v %v, want %v %v", dp.Bounds, dp.BucketCounts, wantBounds, wantCounts)
+	}
+	for i := range wantCounts {
+		if dp.BucketCounts[i] != wantCounts[i] {
+			t.Errorf("bucket counts = %v, want %v", dp.BucketCounts, wantCounts)
+			break
+		}
+	}
+}
+
+func TestConvertToTimeSeries(t *testing.T) {
+	families, err := fetchMetricFamilies(context.Background(), newMetricsServer(t).URL)
+	if err != nil {
+		t.Fatalf("fetchMetricFamilies() error = %v", err)
+	}
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	series := convertToTimeSeries("p", scrapeTarget{URL: "http://app:8080/metrics", Job: "willitrain", Instance: "api-1"}, families, newCumulativeTracker(), now, discardLogger)
+
+	byType := make(map[string]*monitoringpb.TimeSeries)
+	for _, ts := range series {
+		byType[strings.TrimPrefix(ts.GetMetric().GetType(), "prometheus.googleapis.com/")] = ts
+	}
+	testCases := []struct {
+		metricType string
+		kind       metric.MetricDescriptor_MetricKind
+		value      float64
+	}{
+		{metricType: "willitrain_http_requests_total/counter", kind: metric.MetricDescriptor_CUMULATIVE, value: 7},
+		{metricType: "willitrain_tracked_locations/gauge", kind: metric.MetricDescriptor_GAUGE, value: 3},
+		{metricType: "willitrain_parser_duration_seconds/histogram", kind: metric.MetricDescriptor_CUMULATIVE},
+		{metricType: "willitrain_gc_duration_seconds/summary", kind: metric.MetricDescriptor_GAUGE, value: 0.002},
+		{metricType: "willitrain_gc_duration_seconds_sum/summary:counter", kind: metric.MetricDescriptor_CUMULATIVE, value: 0.01},
+		{metricType: "willitrain_gc_duration_seconds_count/summary", kind: metric.MetricDescriptor_CUMULATIVE, value: 4},
+	}
+	if len(series) != len(testCases) {
+		t.Errorf("got %d time series, want %d", len(series), len(testCases))
+	}
+	for _, tc := range testCases {
+		t.Run(tc.metricType, func(t *testing.T) {
+			ts, ok := byType[tc.metricType]
+			if !ok {
+				t.Fatalf("no time series of type %s", tc.metricType)
+			}
+			if ts.GetMetricKind() != tc.kind {
+				t.Errorf("metric kind = %v, want %v", ts.GetMetricKind(), tc.kind)
+			}
+			point := ts.GetPoints()[0]
+			hasStart := point.GetInterval().GetStartTime() != nil
+			if cumulative := tc.kind == metric.MetricDescriptor_CUMULATIVE; hasStart != cumulative {
+				t.Errorf("point has start time = %v, want %v", hasStart, cumulative)
+			}
+			if hasStart && !point.GetInterval().GetStartTime().AsTime().Before(now) {
+				t.Errorf("start time = %v, want before %v", point.GetInterval().GetStartTime().AsTime(), now)
+			}
+			if dist := point.GetValue().GetDistributionValue(); dist != nil {
+				if dist.GetCount() != 6 || dist.GetMean() != 0.75 {
+					t.Errorf("distribution count and mean = %d, %v, want 6, 0.75", dist.GetCount(), dist.GetMean())
+				}
+			} else if got := point.GetValue().GetDoubleValue(); got != tc.value {
+				t.Errorf("value = %v, want %v", got, tc.value)
+			}
+		})
+	}
+	if got := byType["willitrain_gc_duration_seconds/summary"].GetMetric().GetLabels()["quantile"]; got != "0.5" {
+		t.Errorf("quantile label = %q, want 0.5", got)
+	}
+}
+
+func TestCumulativeTrackerStartTime(t *testing.T) {
+	target := scrapeTarget{URL: "http://app:8080/metrics", Job: "willitrain", Instance: "api-1"}
+	key := seriesKey(target, "willitrain_http_requests_total", &dto.Metric{})
+	processStart := time.Date(2025, 6, 1, 11, 0, 0, 0, time.UTC)
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	tracker := newCumulativeTracker()
+
+	if got := tracker.startTime(key, 5, processStart, now); !got.Equal(processStart) {
+		t.Errorf("start of a new series = %v, want the process start %v", got, processStart)
+	}
+	if got := tracker.startTime(key, 8, processStart, now.Add(time.Minute)); !got.Equal(processStart) {
+		t.Errorf("start of a growing series = %v, want it unchanged at %v", got, processStart)
+	}
+
+	restart := now.Add(90 * time.Second)
+	if got := tracker.startTime(key, 2, restart, now.Add(2*time.Minute)); !got.Equal(restart) {
+		t.Errorf("start after a restart = %v, want the new process start %v", got, restart)
+	}
+	reset := now.Add(3 * time.Minute)
+	if got := tracker.startTime(key, 1, restart, reset); !got.Equal(reset.Add(-time.Millisecond)) {
+		t.Errorf("start after a reset without a restart = %v, want just before %v", got, reset)
+	}
+
+	tracker.prune(reset.Add(time.Second))
+	later := reset.Add(time.Minute)
+	if got := tracker.startTime(key, 1, time.Time{}, later); !got.Equal(later.Add(-time.Millisecond)) {
+		t.Errorf("start of a pruned series = %v, want just before %v", got, later)
+	}
+}
+
+func TestParseTargets(t *testing.T) {
+	testCases := []struct {
+		name    string
+		input   string
+		want    []scrapeTarget
+		wantErr string
+	}{
+		{
+			name:  "Single URL",
+			input: "http://api:8080/metrics",
+			want:  []scrapeTarget{{URL: "http://api:8080/metrics", Job: "willitrain", Instance: "http://api:8080/metrics"}},
+		},
+		{
+			name:  "Comma-Separated",
+			input: "http://api:8080/metrics, http://worker:9090/metrics,",
+			want: []scrapeTarget{
+				{URL: "http://api:8080/metrics", Job: "willitrain", Instance: "http://api:8080/metrics"},
+				{URL: "http://worker:9090/metrics", Job: "willitrain", Instance: "http://worker:9090/metrics"},
+			},
+		},
+		{
+			name:  "JSON",
+			input: `[{"url": "http://api:8080/metrics", "job": "api", "instance": "api-1"}, {"url": "http://worker:9090/metrics", "job": "worker"}]`,
+			want: []scrapeTarget{
+				{URL: "http://api:8080/metrics", Job: "api", Instance: "api-1"},
+				{URL: "http://worker:9090/metrics", Job: "worker", Instance: "http://worker:9090/metrics"},
+			},
+		},
+		{name: "Empty", input: " ", wantErr: "must be set"},
+		{name: "Invalid JSON", input: `[{"url": }]`, wantErr: "not a valid JSON"},
+		{name: "JSON Without URL", input: `[{"job": "api"}]`, wantErr: "has no url"},
+		{name: "Duplicate", input: `[{"url": "http://a/metrics", "instance": "x"}, {"url": "http://b/metrics", "instance": "x"}]`, wantErr: "listed twice"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parseTargets(tc.input)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("parseTargets() error = %v, want %q", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseTargets() error = %v", err)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tc.want) {
+				t.Errorf("parseTargets() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+// recordingExporter records the metric families exported per target instance.
+type recordingExporter struct {
+	mu       sync.Mutex
+	exported map[string]map[string]*dto.MetricFamily
+}
+
+func (e *recordingExporter) export(ctx context.Context, target scrapeTarget, metricFamilies map[string]*dto.MetricFamily) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.exported[target.Instance] = metricFamilies
+	return nil
+}
+
+func TestScrapeTargetsPartialFailure(t *testing.T) {
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer down.Close()
+	targets := []scrapeTarget{
+		{URL: newMetricsServer(t).URL, Job: "api", Instance: "api-1"},
+		{URL: down.URL, Job: "worker", Instance: "worker-1"},
+	}
+	exp := &recordingExporter{exported: make(map[string]map[string]*dto.MetricFamily)}
+
+	results := scrapeTargets(context.Background(), targets, exp, discardLogger)
+
+	if results[0].err != nil {
+		t.Errorf("api-1 error = %v, want none", results[0].err)
+	}
+	if results[1].err == nil || !strings.Contains(results[1].err.Error(), "503") {
+		t.Errorf("worker-1 error = %v, want the failed scrape", results[1].err)
+	}
+	if got := len(exp.exported["api-1"]); got != 5 {
+		t.Errorf("api-1 exported %d families, want the 4 scraped and up", got)
+	}
+	for instance, want := range map[string]float64{"api-1": 1, "worker-1": 0} {
+		up := exp.exported[instance]["up"]
+		if up == nil || up.GetMetric()[0].GetGauge().GetValue() != want {
+			t.Errorf("up of %s = %v, want %v", instance, up, want)
+		}
+	}
+	if len(exp.exported["worker-1"]) != 1 {
+		t.Errorf("worker-1 exported %v, want only up", exp.exported["worker-1"])
+	}
+}
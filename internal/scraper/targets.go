@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/proto"
+)
+
+// defaultJob is the job label of targets that do not name one.
+const defaultJob = "willitrain"
+
+// scrapeTarget is a metrics endpoint together with the job and instance labels its metrics are
+// exported under.
+type scrapeTarget struct {
+	URL      string `json:"url"`
+	Job      string `json:"job"`
+	Instance string `json:"instance"`
+}
+
+// parseTargets parses METRICS_URL, which is either a comma-separated list of URLs or a JSON
+// array of targets such as [{"url": "http://api:8080/metrics", "job": "api", "instance": "api-1"}].
+// The job defaults to willitrain and the instance to the URL.
+func parseTargets(s string) ([]scrapeTarget, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, errors.New("environment variable METRICS_URL must be set")
+	}
+
+	var targets []scrapeTarget
+	if strings.HasPrefix(s, "[") {
+		if err := json.Unmarshal([]byte(s), &targets); err != nil {
+			return nil, fmt.Errorf("METRICS_URL is not a valid JSON list of targets: %w", err)
+		}
+	} else {
+		for _, url := range strings.Split(s, ",") {
+			if url = strings.TrimSpace(url); url != "" {
+				targets = append(targets, scrapeTarget{URL: url})
+			}
+		}
+	}
+	if len(targets) == 0 {
+		return nil, errors.New("METRICS_URL lists no targets")
+	}
+
+	seen := make(map[[2]string]bool, len(targets))
+	for i := range targets {
+		t := &targets[i]
+		if t.URL == "" {
+			return nil, fmt.Errorf("target %d in METRICS_URL has no url", i+1)
+		}
+		if t.Job == "" {
+			t.Job = defaultJob
+		}
+		if t.Instance == "" {
+			t.Instance = t.URL
+		}
+		key := [2]string{t.Job, t.Instance}
+		if seen[key] {
+			return nil, fmt.Errorf("targets in METRICS_URL must differ in job or instance, %s/%s is listed twice", t.Job, t.Instance)
+		}
+		seen[key] = true
+	}
+	return targets, nil
+}
+
+// targetResult is the outcome of scraping and exporting one target.
+type targetResult struct {
+	target scrapeTarget
+	err    error
+}
+
+// scrapeTargets scrapes all targets concurrently and exports the metrics of each. A target that
+// cannot be scraped does not stop the others. As in Prometheus, an up metric is exported for
+// every target, 1 if it was scraped and 0 if not, so that failed scrapes can be alerted on.
+func scrapeTargets(ctx context.Context, targets []scrapeTarget, exp exporter, logger *slog.Logger) []targetResult {
+	results := make([]targetResult, len(targets))
+	var wg sync.WaitGroup
+	for i, target := range targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = targetResult{target: target, err: scrapeTargetAndExport(ctx, target, exp, logger)}
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// scrapeTargetAndExport scrapes one target and exports its metrics with its up metric. If the
+// scrape fails, only the up metric is exported.
+func scrapeTargetAndExport(ctx context.Context, target scrapeTarget, exp exporter, logger *slog.Logger) error {
+	metricFamilies, scrapeErr := fetchMetricFamilies(ctx, target.URL)
+	if scrapeErr != nil {
+		scrapeErr = fmt.Errorf("failed to fetch metrics: %w", scrapeErr)
+		metricFamilies = make(map[string]*dto.MetricFamily)
+	}
+	metricFamilies["up"] = upMetricFamily(scrapeErr == nil)
+
+	if err := exp.export(ctx, target, metricFamilies); err != nil {
+		return errors.Join(scrapeErr, fmt.Errorf("failed to export metrics: %w", err))
+	}
+	if scrapeErr == nil {
+		logger.Debug("scraped and exported target", "job", target.Job, "instance", target.Instance, "families", len(metricFamilies))
+	}
+	return scrapeErr
+}
+
+// upMetricFamily returns the up gauge of a target.
+func upMetricFamily(up bool) *dto.MetricFamily {
+	value := 0.0
+	if up {
+		value = 1
+	}
+	return &dto.MetricFamily{
+		Name: proto.String("up"),
+		Help: proto.String("Whether the target was scraped successfully."),
+		Type: dto.MetricType_GAUGE.Enum(),
+		Metric: []*dto.Metric{{
+			Gauge: &dto.Gauge{Value: proto.Float64(value)},
+		}},
+	}
+}
@@ -0,0 +1,159 @@
+package testkit
+
+import (
+	"context"
+	"encoding/json"
+	"path"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Cache is a configurable mock of the application's Cache interface. Unset functions behave
+// like an empty, healthy cache: Get reports a miss with redis.Nil and all writes succeed.
+type Cache struct {
+	GetFunc    func(ctx context.Context, key string) (string, error)
+	SetFunc    func(ctx context.Context, key string, value any, expiration time.Duration) error
+	FlushFunc  func(ctx context.Context) error
+	DeleteFunc func(ctx context.Context, keys ...string) error
+	ScanFunc   func(ctx context.Context, cursor uint64, match string, count int64) ([]string, uint64, error)
+	TTLFunc    func(ctx context.Context, key string) (time.Duration, error)
+	IncrFunc   func(ctx context.Context, key string, expiration time.Duration) (int64, error)
+}
+
+func (c *Cache) Get(ctx context.Context, key string) (string, error) {
+	if c.GetFunc != nil {
+		return c.GetFunc(ctx, key)
+	}
+	return "", redis.Nil
+}
+
+func (c *Cache) Set(ctx context.Context, key string, value any, expiration time.Duration) error {
+	if c.SetFunc != nil {
+		return c.SetFunc(ctx, key, value, expiration)
+	}
+	return nil
+}
+
+func (c *Cache) Flush(ctx context.Context) error {
+	if c.FlushFunc != nil {
+		return c.FlushFunc(ctx)
+	}
+	return nil
+}
+
+func (c *Cache) Delete(ctx context.Context, keys ...string) error {
+	if c.DeleteFunc != nil {
+		return c.DeleteFunc(ctx, keys...)
+	}
+	return nil
+}
+
+func (c *Cache) Scan(ctx context.Context, cursor uint64, match string, count int64) ([]string, uint64, error) {
+	if c.ScanFunc != nil {
+		return c.ScanFunc(ctx, cursor, match, count)
+	}
+	return nil, 0, nil
+}
+
+func (c *Cache) TTL(ctx context.Context, key string) (time.Duration, error) {
+	if c.TTLFunc != nil {
+		return c.TTLFunc(ctx, key)
+	}
+	return -2, nil
+}
+
+func (c *Cache) Incr(ctx context.Context, key string, expiration time.Duration) (int64, error) {
+	if c.IncrFunc != nil {
+		return c.IncrFunc(ctx, key, expiration)
+	}
+	return 1, nil
+}
+
+// NewMemoryCache returns a Cache backed by a map, for tests that need values written by one
+// component to be read back by another. Values are stored as JSON, like in the Redis cache.
+// Expirations are ignored, so TTL reports every stored key as having none, and Incr counts in
+// the same entries, as Redis does. Scan returns the
+// matching keys in sorted order, count at a time, with the cursor being an offset into them.
+func NewMemoryCache() *Cache {
+	var mu sync.Mutex
+	entries := make(map[string]string)
+	return &Cache{
+		GetFunc: func(ctx context.Context, key string) (string, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			val, ok := entries[key]
+			if !ok {
+				return "", redis.Nil
+			}
+			return val, nil
+		},
+		SetFunc: func(ctx context.Context, key string, value any, expiration time.Duration) error {
+			data, err := json.Marshal(value)
+			if err != nil {
+				return err
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			entries[key] = string(data)
+			return nil
+		},
+		FlushFunc: func(ctx context.Context) error {
+			mu.Lock()
+			defer mu.Unlock()
+			clear(entries)
+			return nil
+		},
+		DeleteFunc: func(ctx context.Context, keys ...string) error {
+			mu.Lock()
+			defer mu.Unlock()
+			for _, key := range keys {
+				delete(entries, key)
+			}
+			return nil
+		},
+		ScanFunc: func(ctx context.Context, cursor uint64, match string, count int64) ([]string, uint64, error) {
+			mu.Lock()
+			var keys []string
+			for key := range entries {
+				if ok, _ := path.Match(match, key); ok {
+					keys = append(keys, key)
+				}
+			}
+			mu.Unlock()
+			slices.Sort(keys)
+			if cursor >= uint64(len(keys)) {
+				return nil, 0, nil
+			}
+			end := min(cursor+uint64(max(count, 1)), uint64(len(keys)))
+			if end == uint64(len(keys)) {
+				return keys[cursor:end], 0, nil
+			}
+			return keys[cursor:end], end, nil
+		},
+		TTLFunc: func(ctx context.Context, key string) (time.Duration, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			if _, ok := entries[key]; !ok {
+				return -2, nil
+			}
+			return -1, nil
+		},
+		IncrFunc: func(ctx context.Context, key string, expiration time.Duration) (int64, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			var n int64
+			if val, ok := entries[key]; ok {
+				if err := json.Unmarshal([]byte(val), &n); err != nil {
+					return 0, err
+				}
+			}
+			n++
+			entries[key] = strconv.FormatInt(n, 10)
+			return n, nil
+		},
+	}
+}
@@ -0,0 +1,8 @@
+// Package testkit provides reusable test doubles and fixtures for the application's tests:
+// a strict mock of the database querier, a mock and an in-memory implementation of the cache,
+// provider test servers and canned database fixtures. New subsystems should build their tests
+// on these instead of defining their own mocks.
+//
+// Mocks for interfaces whose types are defined in the main package, such as the geocoding
+// and timezone services, remain in the main package's test helpers.
+package testkit
@@ -0,0 +1,282 @@
+package testkit
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/cor0nius/willitrain/internal/database"
+	"github.com/google/uuid"
+)
+
+// Canned database fixtures for a single location with three sources ("test1", "test2" and
+// "test3"). The forecasts lie in the near future relative to the start of the test binary,
+// so they are returned by the queries for upcoming forecasts.
+
+// DBLocation is a location fixture for Wroclaw, Poland.
+var DBLocation = database.Location{
+	ID:          uuid.New(),
+	CityName:    "Wroclaw",
+	Latitude:    51.1,
+	Longitude:   17.03,
+	CountryCode: "PL",
+}
+
+var (
+	fixtureTime       = time.Now().UTC()
+	DBCurrentWeather1 = database.CurrentWeather{
+		SourceApi: "test1",
+		UpdatedAt: fixtureTime.Add(-5 * time.Minute),
+		TemperatureC: sql.NullFloat64{
+			Float64: 10.0,
+			Valid:   true,
+		},
+		Humidity: sql.NullInt32{
+			Int32: 50,
+			Valid: true,
+		},
+		WindSpeedKmh: sql.NullFloat64{
+			Float64: 5.0,
+			Valid:   true,
+		},
+		PrecipitationMm: sql.NullFloat64{
+			Float64: 0.0,
+			Valid:   true,
+		},
+		ConditionText: sql.NullString{
+			String: "sunny",
+			Valid:  true,
+		},
+	}
+	DBCurrentWeather2 = database.CurrentWeather{
+		SourceApi: "test2",
+		UpdatedAt: fixtureTime.Add(-5 * time.Minute),
+		TemperatureC: sql.NullFloat64{
+			Float64: 11.0,
+			Valid:   true,
+		},
+		Humidity: sql.NullInt32{
+			Int32: 51,
+			Valid: true,
+		},
+		WindSpeedKmh: sql.NullFloat64{
+			Float64: 6.0,
+			Valid:   true,
+		},
+		PrecipitationMm: sql.NullFloat64{
+			Float64: 0.1,
+			Valid:   true,
+		},
+		ConditionText: sql.NullString{
+			String: "partly cloudy",
+			Valid:  true,
+		},
+	}
+	DBCurrentWeather3 = database.CurrentWeather{
+		SourceApi: "test3",
+		UpdatedAt: fixtureTime.Add(-2 * time.Minute),
+		TemperatureC: sql.NullFloat64{
+			Float64: 12.0,
+			Valid:   true,
+		},
+		Humidity: sql.NullInt32{
+			Int32: 52,
+			Valid: true,
+		},
+		WindSpeedKmh: sql.NullFloat64{
+			Float64: 7.0,
+			Valid:   true,
+		},
+		PrecipitationMm: sql.NullFloat64{
+			Float64: 0.2,
+			Valid:   true,
+		},
+		ConditionText: sql.NullString{
+			String: "cloudy",
+			Valid:  true,
+		},
+	}
+)
+
+var (
+	fixtureDate1     = time.Now().UTC().AddDate(0, 0, 1)
+	fixtureDate2     = time.Now().UTC().AddDate(0, 0, 2)
+	DBDailyForecast1 = database.DailyForecast{
+		SourceApi:    "test1",
+		ForecastDate: fixtureDate1,
+		UpdatedAt:    fixtureTime,
+		MinTempC: sql.NullFloat64{
+			Float64: 5.0,
+			Valid:   true,
+		},
+		MaxTempC: sql.NullFloat64{
+			Float64: 15.0,
+			Valid:   true,
+		},
+		PrecipitationMm: sql.NullFloat64{
+			Float64: 1.0,
+			Valid:   true,
+		},
+		PrecipitationChancePercent: sql.NullInt32{
+			Int32: 50,
+			Valid: true,
+		},
+		WindSpeedKmh: sql.NullFloat64{
+			Float64: 10.0,
+			Valid:   true,
+		},
+		Humidity: sql.NullInt32{
+			Int32: 60,
+			Valid: true,
+		},
+	}
+	DBDailyForecast2 = database.DailyForecast{
+		SourceApi:    "test2",
+		ForecastDate: fixtureDate1,
+		UpdatedAt:    fixtureTime,
+		MinTempC: sql.NullFloat64{
+			Float64: 6.0,
+			Valid:   true,
+		},
+		MaxTempC: sql.NullFloat64{
+			Float64: 16.0,
+			Valid:   true,
+		},
+		PrecipitationMm: sql.NullFloat64{
+			Float64: 2.0,
+			Valid:   true,
+		},
+		PrecipitationChancePercent: sql.NullInt32{
+			Int32: 55,
+			Valid: true,
+		},
+		WindSpeedKmh: sql.NullFloat64{
+			Float64: 11.0,
+			Valid:   true,
+		},
+		Humidity: sql.NullInt32{
+			Int32: 62,
+			Valid: true,
+		},
+	}
+	DBDailyForecast3 = database.DailyForecast{
+		SourceApi:    "test3",
+		ForecastDate: fixtureDate2,
+		UpdatedAt:    fixtureTime,
+		MinTempC: sql.NullFloat64{
+			Float64: 7.0,
+			Valid:   true,
+		},
+		MaxTempC: sql.NullFloat64{
+			Float64: 17.0,
+			Valid:   true,
+		},
+		PrecipitationMm: sql.NullFloat64{
+			Float64: 3.0,
+			Valid:   true,
+		},
+		PrecipitationChancePercent: sql.NullInt32{
+			Int32: 60,
+			Valid: true,
+		},
+		WindSpeedKmh: sql.NullFloat64{
+			Float64: 12.0,
+			Valid:   true,
+		},
+		Humidity: sql.NullInt32{
+			Int32: 65,
+			Valid: true,
+		},
+	}
+)
+
+var (
+	fixtureHour1      = time.Now().UTC().Add(1 * time.Hour).Truncate(time.Hour)
+	fixtureHour2      = time.Now().UTC().Add(2 * time.Hour).Truncate(time.Hour)
+	DBHourlyForecast1 = database.HourlyForecast{
+		SourceApi:           "test1",
+		ForecastDatetimeUtc: fixtureHour1,
+		UpdatedAt:           fixtureTime,
+		TemperatureC: sql.NullFloat64{
+			Float64: 10.0,
+			Valid:   true,
+		},
+		Humidity: sql.NullInt32{
+			Int32: 50,
+			Valid: true,
+		},
+		WindSpeedKmh: sql.NullFloat64{
+			Float64: 5.0,
+			Valid:   true,
+		},
+		PrecipitationMm: sql.NullFloat64{
+			Float64: 0.0,
+			Valid:   true,
+		},
+		PrecipitationChancePercent: sql.NullInt32{
+			Int32: 10,
+			Valid: true,
+		},
+		ConditionText: sql.NullString{
+			String: "cloudy",
+			Valid:  true,
+		},
+	}
+	DBHourlyForecast2 = database.HourlyForecast{
+		SourceApi:           "test2",
+		ForecastDatetimeUtc: fixtureHour1,
+		UpdatedAt:           fixtureTime,
+		TemperatureC: sql.NullFloat64{
+			Float64: 11.0,
+			Valid:   true,
+		},
+		Humidity: sql.NullInt32{
+			Int32: 51,
+			Valid: true,
+		},
+		WindSpeedKmh: sql.NullFloat64{
+			Float64: 6.0,
+			Valid:   true,
+		},
+		PrecipitationMm: sql.NullFloat64{
+			Float64: 0.1,
+			Valid:   true,
+		},
+		PrecipitationChancePercent: sql.NullInt32{
+			Int32: 15,
+			Valid: true,
+		},
+		ConditionText: sql.NullString{
+			String: "partly cloudy",
+			Valid:  true,
+		},
+	}
+	DBHourlyForecast3 = database.HourlyForecast{
+		SourceApi:           "test3",
+		ForecastDatetimeUtc: fixtureHour2,
+		UpdatedAt:           fixtureTime,
+		TemperatureC: sql.NullFloat64{
+			Float64: 12.0,
+			Valid:   true,
+		},
+		Humidity: sql.NullInt32{
+			Int32: 52,
+			Valid: true,
+		},
+		WindSpeedKmh: sql.NullFloat64{
+			Float64: 7.0,
+			Valid:   true,
+		},
+		PrecipitationMm: sql.NullFloat64{
+			Float64: 0.2,
+			Valid:   true,
+		},
+		PrecipitationChancePercent: sql.NullInt32{
+			Int32: 20,
+			Valid: true,
+		},
+		ConditionText: sql.NullString{
+			String: "sunny",
+			Valid:  true,
+		},
+	}
+)
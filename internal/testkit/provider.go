@@ -0,0 +1,49 @@
+package testkit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// NewProviderServer starts a test server that imitates the weather and geocoding providers.
+// Requests are answered with the body of the first route whose key is contained in the
+// request path, e.g. "/gmp" or "/ometeo"; other requests get 404 Not Found. Point the
+// provider base URLs at server.URL plus the route key. The server is closed when the test ends.
+func NewProviderServer(t testing.TB, routes map[string][]byte) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for key, body := range routes {
+			if strings.Contains(r.URL.Path, key) {
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write(body)
+				return
+			}
+		}
+		http.NotFound(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// StatusServer starts a test server that answers every request with the given status code.
+// The server is closed when the test ends.
+func StatusServer(t testing.TB, status int) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// ErrorTransport is an http.RoundTripper that fails every request with Err, for testing
+// network failures without a server.
+type ErrorTransport struct {
+	Err error
+}
+
+func (t *ErrorTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return nil, t.Err
+}
@@ -0,0 +1,1331 @@
+package testkit
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cor0nius/willitrain/internal/database"
+	"github.com/google/uuid"
+)
+
+// Querier is a configurable mock of the application's database querier. Every query has a
+// matching <Name>Func field that is called when set. Calling a query whose field is not set
+// fails the test, so unexpected database access is caught, except for the Create*, DeleteAll*,
+// Delete*AtLocation and Archive*AtLocation writes, the Delete*Before prunes, the forecast upserts,
+// the job run status writes and UpdateTimezone, which succeed with zero values.
+//
+// Queries used concurrently by the scheduler run their <Name>Func under a mutex, so those
+// functions do not need their own synchronization.
+type Querier struct {
+	t testing.TB
+
+	mu      sync.Mutex
+	callsMu sync.Mutex
+	calls   map[string]int
+
+	AddLocationGroupMemberFunc                    func(ctx context.Context, arg database.AddLocationGroupMemberParams) error
+	ArchiveCurrentWeatherAtLocationFunc           func(ctx context.Context, arg database.ArchiveCurrentWeatherAtLocationParams) (int64, error)
+	ArchiveDailyForecastsAtLocationFunc           func(ctx context.Context, arg database.ArchiveDailyForecastsAtLocationParams) (int64, error)
+	ArchiveHourlyForecastsAtLocationFunc          func(ctx context.Context, arg database.ArchiveHourlyForecastsAtLocationParams) (int64, error)
+	ClaimDueAlertDeliveriesFunc                   func(ctx context.Context, arg database.ClaimDueAlertDeliveriesParams) ([]database.AlertDelivery, error)
+	CountAlertSubscriptionsForSubscriberFunc      func(ctx context.Context, subscriberID string) (int64, error)
+	CountLocationGroupMembersFunc                 func(ctx context.Context, groupID uuid.UUID) (int64, error)
+	CreateAPIKeyFunc                              func(ctx context.Context, arg database.CreateAPIKeyParams) (database.ApiKey, error)
+	CreateAirQualityFunc                          func(ctx context.Context, arg database.CreateAirQualityParams) (database.AirQuality, error)
+	CreateAlertDeliveryFunc                       func(ctx context.Context, arg database.CreateAlertDeliveryParams) error
+	CreateAlertSubscriptionFunc                   func(ctx context.Context, arg database.CreateAlertSubscriptionParams) (database.AlertSubscription, error)
+	CreateCurrentWeatherFunc                      func(ctx context.Context, arg database.CreateCurrentWeatherParams) (database.CurrentWeather, error)
+	CreateDailyForecastFunc                       func(ctx context.Context, arg database.CreateDailyForecastParams) (database.DailyForecast, error)
+	CreateHourlyForecastFunc                      func(ctx context.Context, arg database.CreateHourlyForecastParams) (database.HourlyForecast, error)
+	CreateJobRunFunc                              func(ctx context.Context, arg database.CreateJobRunParams) (database.JobRun, error)
+	CreateJobRunLocationFunc                      func(ctx context.Context, arg database.CreateJobRunLocationParams) error
+	CreateLocationAliasFunc                       func(ctx context.Context, arg database.CreateLocationAliasParams) (database.LocationAlias, error)
+	CreateLocationFunc                            func(ctx context.Context, arg database.CreateLocationParams) (database.Location, error)
+	CreateLocationGroupFunc                       func(ctx context.Context, arg database.CreateLocationGroupParams) (database.LocationGroup, error)
+	CreateSchedulerRunFunc                        func(ctx context.Context, arg database.CreateSchedulerRunParams) error
+	CreateUserFunc                                func(ctx context.Context, arg database.CreateUserParams) (database.User, error)
+	CreateWatchlistEntryFunc                      func(ctx context.Context, arg database.CreateWatchlistEntryParams) (database.WatchlistEntry, error)
+	DeleteAirQualityAtLocationFunc                func(ctx context.Context, locationID uuid.UUID) error
+	DeleteAlertDeliveriesBeforeFunc               func(ctx context.Context, createdAt time.Time) (int64, error)
+	DeleteAlertSubscriptionFunc                   func(ctx context.Context, arg database.DeleteAlertSubscriptionParams) (int64, error)
+	DeleteAlertSubscriptionsForSubscriberFunc     func(ctx context.Context, subscriberID string) (int64, error)
+	DeleteAllCurrentWeatherFunc                   func(ctx context.Context) error
+	DeleteAllDailyForecastsFunc                   func(ctx context.Context) error
+	DeleteAllHourlyForecastsFunc                  func(ctx context.Context) error
+	DeleteAllLocationsFunc                        func(ctx context.Context) error
+	DeleteCurrentWeatherAtLocationFunc            func(ctx context.Context, locationID uuid.UUID) error
+	DeleteDailyForecastHistoryBeforeFunc          func(ctx context.Context, forecastDate time.Time) (int64, error)
+	DeleteDailyForecastsAtLocationFunc            func(ctx context.Context, locationID uuid.UUID) error
+	DeleteDailyForecastsBeforeFunc                func(ctx context.Context, forecastDate time.Time) (int64, error)
+	DeleteHourlyForecastHistoryBeforeFunc         func(ctx context.Context, forecastDatetimeUtc time.Time) (int64, error)
+	DeleteHourlyForecastsAtLocationFunc           func(ctx context.Context, locationID uuid.UUID) error
+	DeleteHourlyForecastsBeforeFunc               func(ctx context.Context, forecastDatetimeUtc time.Time) (int64, error)
+	DeleteIdleLocationsFunc                       func(ctx context.Context, lastAccessedAt time.Time) ([]database.DeleteIdleLocationsRow, error)
+	DeleteJobRunsBeforeFunc                       func(ctx context.Context, startedAt time.Time) (int64, error)
+	DeleteLocationAliasFunc                       func(ctx context.Context, arg database.DeleteLocationAliasParams) (int64, error)
+	DeleteLocationFunc                            func(ctx context.Context, id uuid.UUID) error
+	DeleteLocationGroupFunc                       func(ctx context.Context, arg database.DeleteLocationGroupParams) (int64, error)
+	DeleteLocationGroupsForSubscriberFunc         func(ctx context.Context, subscriberID string) (int64, error)
+	DeleteLocationProviderWeightsFunc             func(ctx context.Context, locationID uuid.UUID) (int64, error)
+	DeleteProviderDisagreementBeforeFunc          func(ctx context.Context, computedOn time.Time) (int64, error)
+	DeleteSchedulerIntervalFunc                   func(ctx context.Context, jobName string) error
+	DeleteSchedulerRunsBeforeFunc                 func(ctx context.Context, startedAt time.Time) (int64, error)
+	DeleteUserFunc                                func(ctx context.Context, id uuid.UUID) (int64, error)
+	DeleteWatchlistEntriesForSubscriberFunc       func(ctx context.Context, subscriberID string) (int64, error)
+	DeleteWatchlistEntryFunc                      func(ctx context.Context, arg database.DeleteWatchlistEntryParams) error
+	FinishJobRunFunc                              func(ctx context.Context, arg database.FinishJobRunParams) error
+	FinishJobRunLocationFunc                      func(ctx context.Context, arg database.FinishJobRunLocationParams) error
+	GetActiveAPIKeyByHashFunc                     func(ctx context.Context, keyHash string) (database.ApiKey, error)
+	GetAirQualityAtLocationFromAPIFunc            func(ctx context.Context, arg database.GetAirQualityAtLocationFromAPIParams) (database.AirQuality, error)
+	GetAirQualityAtLocationFunc                   func(ctx context.Context, locationID uuid.UUID) ([]database.AirQuality, error)
+	GetAllDailyForecastsAtLocationFunc            func(ctx context.Context, locationID uuid.UUID) ([]database.DailyForecast, error)
+	GetAllHourlyForecastsAtLocationFunc           func(ctx context.Context, locationID uuid.UUID) ([]database.HourlyForecast, error)
+	GetCurrentWeatherAtLocationFromAPIFunc        func(ctx context.Context, arg database.GetCurrentWeatherAtLocationFromAPIParams) (database.CurrentWeather, error)
+	GetCurrentWeatherAtLocationFunc               func(ctx context.Context, locationID uuid.UUID) ([]database.CurrentWeather, error)
+	GetDailyForecastAtLocationAndDateFromAPIFunc  func(ctx context.Context, arg database.GetDailyForecastAtLocationAndDateFromAPIParams) (database.DailyForecast, error)
+	GetEndpointRequestStatsSinceFunc              func(ctx context.Context, hour time.Time) ([]database.EndpointRequestStat, error)
+	GetFirstProviderUsageHourSinceFunc            func(ctx context.Context, hour time.Time) (time.Time, error)
+	GetHourlyForecastAtLocationAndTimeFromAPIFunc func(ctx context.Context, arg database.GetHourlyForecastAtLocationAndTimeFromAPIParams) (database.HourlyForecast, error)
+	GetJobRunFunc                                 func(ctx context.Context, id uuid.UUID) (database.JobRun, error)
+	GetLatestWeatherObservationFunc               func(ctx context.Context, arg database.GetLatestWeatherObservationParams) (database.WeatherObservation, error)
+	GetLocationByAliasFunc                        func(ctx context.Context, alias string) (database.Location, error)
+	GetLocationByCoordinatesFunc                  func(ctx context.Context, arg database.GetLocationByCoordinatesParams) (database.Location, error)
+	GetLocationByIDFunc                           func(ctx context.Context, id uuid.UUID) (database.Location, error)
+	GetLocationByNameFunc                         func(ctx context.Context, cityName string) (database.Location, error)
+	GetLocationFetchesSinceFunc                   func(ctx context.Context, hour time.Time) ([]database.GetLocationFetchesSinceRow, error)
+	GetLocationGroupFunc                          func(ctx context.Context, arg database.GetLocationGroupParams) (database.LocationGroup, error)
+	GetProviderUsageSinceFunc                     func(ctx context.Context, hour time.Time) ([]database.GetProviderUsageSinceRow, error)
+	GetTopLocationsByRequestsSinceFunc            func(ctx context.Context, arg database.GetTopLocationsByRequestsSinceParams) ([]database.GetTopLocationsByRequestsSinceRow, error)
+	GetUpcomingDailyForecastsAtLocationFunc       func(ctx context.Context, arg database.GetUpcomingDailyForecastsAtLocationParams) ([]database.DailyForecast, error)
+	GetUpcomingHourlyForecastsAtLocationFunc      func(ctx context.Context, arg database.GetUpcomingHourlyForecastsAtLocationParams) ([]database.HourlyForecast, error)
+	GetUserByEmailFunc                            func(ctx context.Context, email string) (database.User, error)
+	GetUserByIDFunc                               func(ctx context.Context, id uuid.UUID) (database.User, error)
+	GetWatchlistUpdatesFunc                       func(ctx context.Context, arg database.GetWatchlistUpdatesParams) ([]database.GetWatchlistUpdatesRow, error)
+	GetWeatherWarningsAtLocationFunc              func(ctx context.Context, locationID uuid.UUID) ([]database.WeatherWarning, error)
+	IncrementEndpointRequestStatsFunc             func(ctx context.Context, arg database.IncrementEndpointRequestStatsParams) error
+	IncrementLocationFetchStatsFunc               func(ctx context.Context, arg database.IncrementLocationFetchStatsParams) error
+	IncrementLocationRequestStatsFunc             func(ctx context.Context, arg database.IncrementLocationRequestStatsParams) error
+	IncrementProviderUsageStatsFunc               func(ctx context.Context, arg database.IncrementProviderUsageStatsParams) error
+	InterruptUnfinishedJobRunsFunc                func(ctx context.Context, finishedAt sql.NullTime) (int64, error)
+	ListAlertSubscriptionsForLocationFunc         func(ctx context.Context, locationID uuid.UUID) ([]database.AlertSubscription, error)
+	ListAlertSubscriptionsForSubscriberFunc       func(ctx context.Context, subscriberID string) ([]database.AlertSubscription, error)
+	ListCurrentWeatherHistoryFunc                 func(ctx context.Context, arg database.ListCurrentWeatherHistoryParams) ([]database.CurrentWeatherHistory, error)
+	ListDailyForecastHistoryFunc                  func(ctx context.Context, arg database.ListDailyForecastHistoryParams) ([]database.DailyForecastHistory, error)
+	ListHourlyForecastHistoryFunc                 func(ctx context.Context, arg database.ListHourlyForecastHistoryParams) ([]database.HourlyForecastHistory, error)
+	ListJobRunLocationsForLocationFunc            func(ctx context.Context, arg database.ListJobRunLocationsForLocationParams) ([]database.ListJobRunLocationsForLocationRow, error)
+	ListJobRunLocationsFunc                       func(ctx context.Context, jobRunID uuid.UUID) ([]database.ListJobRunLocationsRow, error)
+	ListJobRunsFunc                               func(ctx context.Context, arg database.ListJobRunsParams) ([]database.JobRun, error)
+	ListLatestProviderDisagreementFunc            func(ctx context.Context, locationID uuid.UUID) ([]database.ProviderDisagreement, error)
+	ListLocationAliasesFunc                       func(ctx context.Context, locationID uuid.UUID) ([]database.LocationAlias, error)
+	ListLocationDemandFunc                        func(ctx context.Context, hour time.Time) ([]database.ListLocationDemandRow, error)
+	ListLocationGroupMembersFunc                  func(ctx context.Context, groupID uuid.UUID) ([]database.Location, error)
+	ListLocationGroupsForSubscriberFunc           func(ctx context.Context, subscriberID string) ([]database.LocationGroup, error)
+	ListLocationProviderWeightsFunc               func(ctx context.Context, locationID uuid.UUID) ([]database.LocationProviderWeight, error)
+	ListLocationsFunc                             func(ctx context.Context) ([]database.Location, error)
+	ListSchedulerIntervalsFunc                    func(ctx context.Context) ([]database.SchedulerInterval, error)
+	ListSchedulerRunsForLocationFunc              func(ctx context.Context, arg database.ListSchedulerRunsForLocationParams) ([]database.SchedulerRun, error)
+	ListWatchedLocationIDsFunc                    func(ctx context.Context) ([]uuid.UUID, error)
+	ListWatchlistLocationsFunc                    func(ctx context.Context, subscriberID string) ([]database.Location, error)
+	MoveAlertSubscriptionsFunc                    func(ctx context.Context, arg database.MoveAlertSubscriptionsParams) (int64, error)
+	MoveLocationAliasesFunc                       func(ctx context.Context, arg database.MoveLocationAliasesParams) (int64, error)
+	MoveLocationGroupMembersFunc                  func(ctx context.Context, arg database.MoveLocationGroupMembersParams) (int64, error)
+	MoveWatchlistEntriesFunc                      func(ctx context.Context, arg database.MoveWatchlistEntriesParams) (int64, error)
+	RecordAlertDeliveryAttemptFunc                func(ctx context.Context, arg database.RecordAlertDeliveryAttemptParams) error
+	RemoveLocationGroupMemberFunc                 func(ctx context.Context, arg database.RemoveLocationGroupMemberParams) (int64, error)
+	StartJobRunLocationFunc                       func(ctx context.Context, arg database.StartJobRunLocationParams) error
+	TouchLocationAccessFunc                       func(ctx context.Context, arg database.TouchLocationAccessParams) error
+	UpdateAirQualityFunc                          func(ctx context.Context, arg database.UpdateAirQualityParams) (database.AirQuality, error)
+	UpdateAlertSubscriptionStateFunc              func(ctx context.Context, arg database.UpdateAlertSubscriptionStateParams) error
+	UpdateCurrentWeatherFunc                      func(ctx context.Context, arg database.UpdateCurrentWeatherParams) (database.CurrentWeather, error)
+	UpdateDailyForecastFunc                       func(ctx context.Context, arg database.UpdateDailyForecastParams) (database.DailyForecast, error)
+	UpdateHourlyForecastFunc                      func(ctx context.Context, arg database.UpdateHourlyForecastParams) (database.HourlyForecast, error)
+	UpdateTimezoneFunc                            func(ctx context.Context, arg database.UpdateTimezoneParams) error
+	UpdateUserPreferencesFunc                     func(ctx context.Context, arg database.UpdateUserPreferencesParams) (database.User, error)
+	UpsertDailyForecastsFunc                      func(ctx context.Context, forecasts json.RawMessage) (int64, error)
+	UpsertHourlyForecastsFunc                     func(ctx context.Context, forecasts json.RawMessage) (int64, error)
+	UpsertLocationAliasFunc                       func(ctx context.Context, arg database.UpsertLocationAliasParams) (database.LocationAlias, error)
+	UpsertLocationProviderWeightFunc              func(ctx context.Context, arg database.UpsertLocationProviderWeightParams) error
+	UpsertProviderDisagreementFunc                func(ctx context.Context, arg database.UpsertProviderDisagreementParams) error
+	UpsertSchedulerIntervalFunc                   func(ctx context.Context, arg database.UpsertSchedulerIntervalParams) error
+	UpsertWeatherObservationFunc                  func(ctx context.Context, arg database.UpsertWeatherObservationParams) error
+	UpsertWeatherWarningsFunc                     func(ctx context.Context, arg database.UpsertWeatherWarningsParams) error
+}
+
+// NewQuerier returns a Querier that reports unexpected calls to t.
+func NewQuerier(t testing.TB) *Querier {
+	return &Querier{t: t, calls: make(map[string]int)}
+}
+
+// Calls returns how many times the query with the given name was called.
+func (q *Querier) Calls(name string) int {
+	q.callsMu.Lock()
+	defer q.callsMu.Unlock()
+	return q.calls[name]
+}
+
+func (q *Querier) record(name string) {
+	q.callsMu.Lock()
+	defer q.callsMu.Unlock()
+	if q.calls == nil {
+		q.calls = make(map[string]int)
+	}
+	q.calls[name]++
+}
+
+func (q *Querier) fail(name string) {
+	q.t.Helper()
+	q.t.Fatalf("unexpected call to Querier method: %s", name)
+}
+
+func (q *Querier) AddLocationGroupMember(ctx context.Context, arg database.AddLocationGroupMemberParams) error {
+	q.record("AddLocationGroupMember")
+	if q.AddLocationGroupMemberFunc != nil {
+		return q.AddLocationGroupMemberFunc(ctx, arg)
+	}
+	q.fail("AddLocationGroupMember")
+	return nil
+}
+
+func (q *Querier) ArchiveCurrentWeatherAtLocation(ctx context.Context, arg database.ArchiveCurrentWeatherAtLocationParams) (int64, error) {
+	q.record("ArchiveCurrentWeatherAtLocation")
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.ArchiveCurrentWeatherAtLocationFunc != nil {
+		return q.ArchiveCurrentWeatherAtLocationFunc(ctx, arg)
+	}
+	return 0, nil
+}
+
+func (q *Querier) ArchiveDailyForecastsAtLocation(ctx context.Context, arg database.ArchiveDailyForecastsAtLocationParams) (int64, error) {
+	q.record("ArchiveDailyForecastsAtLocation")
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.ArchiveDailyForecastsAtLocationFunc != nil {
+		return q.ArchiveDailyForecastsAtLocationFunc(ctx, arg)
+	}
+	return 0, nil
+}
+
+func (q *Querier) ArchiveHourlyForecastsAtLocation(ctx context.Context, arg database.ArchiveHourlyForecastsAtLocationParams) (int64, error) {
+	q.record("ArchiveHourlyForecastsAtLocation")
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.ArchiveHourlyForecastsAtLocationFunc != nil {
+		return q.ArchiveHourlyForecastsAtLocationFunc(ctx, arg)
+	}
+	return 0, nil
+}
+
+func (q *Querier) ClaimDueAlertDeliveries(ctx context.Context, arg database.ClaimDueAlertDeliveriesParams) ([]database.AlertDelivery, error) {
+	q.record("ClaimDueAlertDeliveries")
+	if q.ClaimDueAlertDeliveriesFunc != nil {
+		return q.ClaimDueAlertDeliveriesFunc(ctx, arg)
+	}
+	q.fail("ClaimDueAlertDeliveries")
+	return nil, nil
+}
+
+func (q *Querier) CountAlertSubscriptionsForSubscriber(ctx context.Context, subscriberID string) (int64, error) {
+	q.record("CountAlertSubscriptionsForSubscriber")
+	if q.CountAlertSubscriptionsForSubscriberFunc != nil {
+		return q.CountAlertSubscriptionsForSubscriberFunc(ctx, subscriberID)
+	}
+	q.fail("CountAlertSubscriptionsForSubscriber")
+	return 0, nil
+}
+
+func (q *Querier) CountLocationGroupMembers(ctx context.Context, groupID uuid.UUID) (int64, error) {
+	q.record("CountLocationGroupMembers")
+	if q.CountLocationGroupMembersFunc != nil {
+		return q.CountLocationGroupMembersFunc(ctx, groupID)
+	}
+	q.fail("CountLocationGroupMembers")
+	return 0, nil
+}
+
+func (q *Querier) CreateAPIKey(ctx context.Context, arg database.CreateAPIKeyParams) (database.ApiKey, error) {
+	q.record("CreateAPIKey")
+	if q.CreateAPIKeyFunc != nil {
+		return q.CreateAPIKeyFunc(ctx, arg)
+	}
+	q.fail("CreateAPIKey")
+	return database.ApiKey{}, nil
+}
+
+func (q *Querier) CreateAirQuality(ctx context.Context, arg database.CreateAirQualityParams) (database.AirQuality, error) {
+	q.record("CreateAirQuality")
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.CreateAirQualityFunc != nil {
+		return q.CreateAirQualityFunc(ctx, arg)
+	}
+	return database.AirQuality{}, nil
+}
+
+func (q *Querier) CreateAlertDelivery(ctx context.Context, arg database.CreateAlertDeliveryParams) error {
+	q.record("CreateAlertDelivery")
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.CreateAlertDeliveryFunc != nil {
+		return q.CreateAlertDeliveryFunc(ctx, arg)
+	}
+	return nil
+}
+
+func (q *Querier) CreateAlertSubscription(ctx context.Context, arg database.CreateAlertSubscriptionParams) (database.AlertSubscription, error) {
+	q.record("CreateAlertSubscription")
+	if q.CreateAlertSubscriptionFunc != nil {
+		return q.CreateAlertSubscriptionFunc(ctx, arg)
+	}
+	q.fail("CreateAlertSubscription")
+	return database.AlertSubscription{}, nil
+}
+
+func (q *Querier) CreateCurrentWeather(ctx context.Context, arg database.CreateCurrentWeatherParams) (database.CurrentWeather, error) {
+	q.record("CreateCurrentWeather")
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.CreateCurrentWeatherFunc != nil {
+		return q.CreateCurrentWeatherFunc(ctx, arg)
+	}
+	return database.CurrentWeather{}, nil
+}
+
+func (q *Querier) CreateDailyForecast(ctx context.Context, arg database.CreateDailyForecastParams) (database.DailyForecast, error) {
+	q.record("CreateDailyForecast")
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.CreateDailyForecastFunc != nil {
+		return q.CreateDailyForecastFunc(ctx, arg)
+	}
+	return database.DailyForecast{}, nil
+}
+
+func (q *Querier) CreateHourlyForecast(ctx context.Context, arg database.CreateHourlyForecastParams) (database.HourlyForecast, error) {
+	q.record("CreateHourlyForecast")
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.CreateHourlyForecastFunc != nil {
+		return q.CreateHourlyForecastFunc(ctx, arg)
+	}
+	return database.HourlyForecast{}, nil
+}
+
+func (q *Querier) CreateJobRun(ctx context.Context, arg database.CreateJobRunParams) (database.JobRun, error) {
+	q.record("CreateJobRun")
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.CreateJobRunFunc != nil {
+		return q.CreateJobRunFunc(ctx, arg)
+	}
+	return database.JobRun{}, nil
+}
+
+func (q *Querier) CreateJobRunLocation(ctx context.Context, arg database.CreateJobRunLocationParams) error {
+	q.record("CreateJobRunLocation")
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.CreateJobRunLocationFunc != nil {
+		return q.CreateJobRunLocationFunc(ctx, arg)
+	}
+	return nil
+}
+
+func (q *Querier) CreateLocation(ctx context.Context, arg database.CreateLocationParams) (database.Location, error) {
+	q.record("CreateLocation")
+	if q.CreateLocationFunc != nil {
+		return q.CreateLocationFunc(ctx, arg)
+	}
+	q.fail("CreateLocation")
+	return database.Location{}, nil
+}
+
+func (q *Querier) CreateLocationAlias(ctx context.Context, arg database.CreateLocationAliasParams) (database.LocationAlias, error) {
+	q.record("CreateLocationAlias")
+	if q.CreateLocationAliasFunc != nil {
+		return q.CreateLocationAliasFunc(ctx, arg)
+	}
+	q.fail("CreateLocationAlias")
+	return database.LocationAlias{}, nil
+}
+
+func (q *Querier) CreateLocationGroup(ctx context.Context, arg database.CreateLocationGroupParams) (database.LocationGroup, error) {
+	q.record("CreateLocationGroup")
+	if q.CreateLocationGroupFunc != nil {
+		return q.CreateLocationGroupFunc(ctx, arg)
+	}
+	q.fail("CreateLocationGroup")
+	return database.LocationGroup{}, nil
+}
+
+func (q *Querier) CreateSchedulerRun(ctx context.Context, arg database.CreateSchedulerRunParams) error {
+	q.record("CreateSchedulerRun")
+	if q.CreateSchedulerRunFunc != nil {
+		return q.CreateSchedulerRunFunc(ctx, arg)
+	}
+	return nil
+}
+
+func (q *Querier) CreateUser(ctx context.Context, arg database.CreateUserParams) (database.User, error) {
+	q.record("CreateUser")
+	if q.CreateUserFunc != nil {
+		return q.CreateUserFunc(ctx, arg)
+	}
+	q.fail("CreateUser")
+	return database.User{}, nil
+}
+
+func (q *Querier) CreateWatchlistEntry(ctx context.Context, arg database.CreateWatchlistEntryParams) (database.WatchlistEntry, error) {
+	q.record("CreateWatchlistEntry")
+	if q.CreateWatchlistEntryFunc != nil {
+		return q.CreateWatchlistEntryFunc(ctx, arg)
+	}
+	q.fail("CreateWatchlistEntry")
+	return database.WatchlistEntry{}, nil
+}
+
+func (q *Querier) DeleteAirQualityAtLocation(ctx context.Context, locationID uuid.UUID) error {
+	q.record("DeleteAirQualityAtLocation")
+	if q.DeleteAirQualityAtLocationFunc != nil {
+		return q.DeleteAirQualityAtLocationFunc(ctx, locationID)
+	}
+	return nil
+}
+
+func (q *Querier) DeleteAlertDeliveriesBefore(ctx context.Context, createdAt time.Time) (int64, error) {
+	q.record("DeleteAlertDeliveriesBefore")
+	if q.DeleteAlertDeliveriesBeforeFunc != nil {
+		return q.DeleteAlertDeliveriesBeforeFunc(ctx, createdAt)
+	}
+	return 0, nil
+}
+
+func (q *Querier) DeleteAlertSubscription(ctx context.Context, arg database.DeleteAlertSubscriptionParams) (int64, error) {
+	q.record("DeleteAlertSubscription")
+	if q.DeleteAlertSubscriptionFunc != nil {
+		return q.DeleteAlertSubscriptionFunc(ctx, arg)
+	}
+	q.fail("DeleteAlertSubscription")
+	return 0, nil
+}
+
+func (q *Querier) DeleteAlertSubscriptionsForSubscriber(ctx context.Context, subscriberID string) (int64, error) {
+	q.record("DeleteAlertSubscriptionsForSubscriber")
+	if q.DeleteAlertSubscriptionsForSubscriberFunc != nil {
+		return q.DeleteAlertSubscriptionsForSubscriberFunc(ctx, subscriberID)
+	}
+	q.fail("DeleteAlertSubscriptionsForSubscriber")
+	return 0, nil
+}
+
+func (q *Querier) DeleteAllCurrentWeather(ctx context.Context) error {
+	q.record("DeleteAllCurrentWeather")
+	if q.DeleteAllCurrentWeatherFunc != nil {
+		return q.DeleteAllCurrentWeatherFunc(ctx)
+	}
+	return nil
+}
+
+func (q *Querier) DeleteAllDailyForecasts(ctx context.Context) error {
+	q.record("DeleteAllDailyForecasts")
+	if q.DeleteAllDailyForecastsFunc != nil {
+		return q.DeleteAllDailyForecastsFunc(ctx)
+	}
+	return nil
+}
+
+func (q *Querier) DeleteAllHourlyForecasts(ctx context.Context) error {
+	q.record("DeleteAllHourlyForecasts")
+	if q.DeleteAllHourlyForecastsFunc != nil {
+		return q.DeleteAllHourlyForecastsFunc(ctx)
+	}
+	return nil
+}
+
+func (q *Querier) DeleteAllLocations(ctx context.Context) error {
+	q.record("DeleteAllLocations")
+	if q.DeleteAllLocationsFunc != nil {
+		return q.DeleteAllLocationsFunc(ctx)
+	}
+	return nil
+}
+
+func (q *Querier) DeleteCurrentWeatherAtLocation(ctx context.Context, locationID uuid.UUID) error {
+	q.record("DeleteCurrentWeatherAtLocation")
+	if q.DeleteCurrentWeatherAtLocationFunc != nil {
+		return q.DeleteCurrentWeatherAtLocationFunc(ctx, locationID)
+	}
+	return nil
+}
+
+func (q *Querier) DeleteDailyForecastHistoryBefore(ctx context.Context, forecastDate time.Time) (int64, error) {
+	q.record("DeleteDailyForecastHistoryBefore")
+	if q.DeleteDailyForecastHistoryBeforeFunc != nil {
+		return q.DeleteDailyForecastHistoryBeforeFunc(ctx, forecastDate)
+	}
+	return 0, nil
+}
+
+func (q *Querier) DeleteDailyForecastsAtLocation(ctx context.Context, locationID uuid.UUID) error {
+	q.record("DeleteDailyForecastsAtLocation")
+	if q.DeleteDailyForecastsAtLocationFunc != nil {
+		return q.DeleteDailyForecastsAtLocationFunc(ctx, locationID)
+	}
+	return nil
+}
+
+func (q *Querier) DeleteDailyForecastsBefore(ctx context.Context, forecastDate time.Time) (int64, error) {
+	q.record("DeleteDailyForecastsBefore")
+	if q.DeleteDailyForecastsBeforeFunc != nil {
+		return q.DeleteDailyForecastsBeforeFunc(ctx, forecastDate)
+	}
+	return 0, nil
+}
+
+func (q *Querier) DeleteHourlyForecastHistoryBefore(ctx context.Context, forecastDatetimeUtc time.Time) (int64, error) {
+	q.record("DeleteHourlyForecastHistoryBefore")
+	if q.DeleteHourlyForecastHistoryBeforeFunc != nil {
+		return q.DeleteHourlyForecastHistoryBeforeFunc(ctx, forecastDatetimeUtc)
+	}
+	return 0, nil
+}
+
+func (q *Querier) DeleteHourlyForecastsAtLocation(ctx context.Context, locationID uuid.UUID) error {
+	q.record("DeleteHourlyForecastsAtLocation")
+	if q.DeleteHourlyForecastsAtLocationFunc != nil {
+		return q.DeleteHourlyForecastsAtLocationFunc(ctx, locationID)
+	}
+	return nil
+}
+
+func (q *Querier) DeleteIdleLocations(ctx context.Context, lastAccessedAt time.Time) ([]database.DeleteIdleLocationsRow, error) {
+	q.record("DeleteIdleLocations")
+	if q.DeleteIdleLocationsFunc != nil {
+		return q.DeleteIdleLocationsFunc(ctx, lastAccessedAt)
+	}
+	q.fail("DeleteIdleLocations")
+	return nil, nil
+}
+
+func (q *Querier) DeleteHourlyForecastsBefore(ctx context.Context, forecastDatetimeUtc time.Time) (int64, error) {
+	q.record("DeleteHourlyForecastsBefore")
+	if q.DeleteHourlyForecastsBeforeFunc != nil {
+		return q.DeleteHourlyForecastsBeforeFunc(ctx, forecastDatetimeUtc)
+	}
+	return 0, nil
+}
+
+func (q *Querier) DeleteJobRunsBefore(ctx context.Context, startedAt time.Time) (int64, error) {
+	q.record("DeleteJobRunsBefore")
+	if q.DeleteJobRunsBeforeFunc != nil {
+		return q.DeleteJobRunsBeforeFunc(ctx, startedAt)
+	}
+	return 0, nil
+}
+
+func (q *Querier) DeleteLocation(ctx context.Context, id uuid.UUID) error {
+	q.record("DeleteLocation")
+	if q.DeleteLocationFunc != nil {
+		return q.DeleteLocationFunc(ctx, id)
+	}
+	q.fail("DeleteLocation")
+	return nil
+}
+
+func (q *Querier) DeleteLocationAlias(ctx context.Context, arg database.DeleteLocationAliasParams) (int64, error) {
+	q.record("DeleteLocationAlias")
+	if q.DeleteLocationAliasFunc != nil {
+		return q.DeleteLocationAliasFunc(ctx, arg)
+	}
+	q.fail("DeleteLocationAlias")
+	return 0, nil
+}
+
+func (q *Querier) DeleteLocationGroup(ctx context.Context, arg database.DeleteLocationGroupParams) (int64, error) {
+	q.record("DeleteLocationGroup")
+	if q.DeleteLocationGroupFunc != nil {
+		return q.DeleteLocationGroupFunc(ctx, arg)
+	}
+	q.fail("DeleteLocationGroup")
+	return 0, nil
+}
+
+func (q *Querier) DeleteLocationGroupsForSubscriber(ctx context.Context, subscriberID string) (int64, error) {
+	q.record("DeleteLocationGroupsForSubscriber")
+	if q.DeleteLocationGroupsForSubscriberFunc != nil {
+		return q.DeleteLocationGroupsForSubscriberFunc(ctx, subscriberID)
+	}
+	q.fail("DeleteLocationGroupsForSubscriber")
+	return 0, nil
+}
+
+func (q *Querier) DeleteLocationProviderWeights(ctx context.Context, locationID uuid.UUID) (int64, error) {
+	q.record("DeleteLocationProviderWeights")
+	if q.DeleteLocationProviderWeightsFunc != nil {
+		return q.DeleteLocationProviderWeightsFunc(ctx, locationID)
+	}
+	q.fail("DeleteLocationProviderWeights")
+	return 0, nil
+}
+
+func (q *Querier) DeleteProviderDisagreementBefore(ctx context.Context, computedOn time.Time) (int64, error) {
+	q.record("DeleteProviderDisagreementBefore")
+	if q.DeleteProviderDisagreementBeforeFunc != nil {
+		return q.DeleteProviderDisagreementBeforeFunc(ctx, computedOn)
+	}
+	return 0, nil
+}
+
+func (q *Querier) DeleteSchedulerInterval(ctx context.Context, jobName string) error {
+	q.record("DeleteSchedulerInterval")
+	if q.DeleteSchedulerIntervalFunc != nil {
+		return q.DeleteSchedulerIntervalFunc(ctx, jobName)
+	}
+	return nil
+}
+
+func (q *Querier) DeleteSchedulerRunsBefore(ctx context.Context, startedAt time.Time) (int64, error) {
+	q.record("DeleteSchedulerRunsBefore")
+	if q.DeleteSchedulerRunsBeforeFunc != nil {
+		return q.DeleteSchedulerRunsBeforeFunc(ctx, startedAt)
+	}
+	return 0, nil
+}
+
+func (q *Querier) DeleteUser(ctx context.Context, id uuid.UUID) (int64, error) {
+	q.record("DeleteUser")
+	if q.DeleteUserFunc != nil {
+		return q.DeleteUserFunc(ctx, id)
+	}
+	q.fail("DeleteUser")
+	return 0, nil
+}
+
+func (q *Querier) DeleteWatchlistEntriesForSubscriber(ctx context.Context, subscriberID string) (int64, error) {
+	q.record("DeleteWatchlistEntriesForSubscriber")
+	if q.DeleteWatchlistEntriesForSubscriberFunc != nil {
+		return q.DeleteWatchlistEntriesForSubscriberFunc(ctx, subscriberID)
+	}
+	q.fail("DeleteWatchlistEntriesForSubscriber")
+	return 0, nil
+}
+
+func (q *Querier) DeleteWatchlistEntry(ctx context.Context, arg database.DeleteWatchlistEntryParams) error {
+	q.record("DeleteWatchlistEntry")
+	if q.DeleteWatchlistEntryFunc != nil {
+		return q.DeleteWatchlistEntryFunc(ctx, arg)
+	}
+	q.fail("DeleteWatchlistEntry")
+	return nil
+}
+
+func (q *Querier) FinishJobRun(ctx context.Context, arg database.FinishJobRunParams) error {
+	q.record("FinishJobRun")
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.FinishJobRunFunc != nil {
+		return q.FinishJobRunFunc(ctx, arg)
+	}
+	return nil
+}
+
+func (q *Querier) FinishJobRunLocation(ctx context.Context, arg database.FinishJobRunLocationParams) error {
+	q.record("FinishJobRunLocation")
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.FinishJobRunLocationFunc != nil {
+		return q.FinishJobRunLocationFunc(ctx, arg)
+	}
+	return nil
+}
+
+func (q *Querier) GetActiveAPIKeyByHash(ctx context.Context, keyHash string) (database.ApiKey, error) {
+	q.record("GetActiveAPIKeyByHash")
+	if q.GetActiveAPIKeyByHashFunc != nil {
+		return q.GetActiveAPIKeyByHashFunc(ctx, keyHash)
+	}
+	q.fail("GetActiveAPIKeyByHash")
+	return database.ApiKey{}, nil
+}
+
+func (q *Querier) GetAirQualityAtLocation(ctx context.Context, locationID uuid.UUID) ([]database.AirQuality, error) {
+	q.record("GetAirQualityAtLocation")
+	if q.GetAirQualityAtLocationFunc != nil {
+		return q.GetAirQualityAtLocationFunc(ctx, locationID)
+	}
+	q.fail("GetAirQualityAtLocation")
+	return nil, nil
+}
+
+func (q *Querier) GetAirQualityAtLocationFromAPI(ctx context.Context, arg database.GetAirQualityAtLocationFromAPIParams) (database.AirQuality, error) {
+	q.record("GetAirQualityAtLocationFromAPI")
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.GetAirQualityAtLocationFromAPIFunc != nil {
+		return q.GetAirQualityAtLocationFromAPIFunc(ctx, arg)
+	}
+	q.fail("GetAirQualityAtLocationFromAPI")
+	return database.AirQuality{}, nil
+}
+
+func (q *Querier) GetAllDailyForecastsAtLocation(ctx context.Context, locationID uuid.UUID) ([]database.DailyForecast, error) {
+	q.record("GetAllDailyForecastsAtLocation")
+	if q.GetAllDailyForecastsAtLocationFunc != nil {
+		return q.GetAllDailyForecastsAtLocationFunc(ctx, locationID)
+	}
+	q.fail("GetAllDailyForecastsAtLocation")
+	return nil, nil
+}
+
+func (q *Querier) GetAllHourlyForecastsAtLocation(ctx context.Context, locationID uuid.UUID) ([]database.HourlyForecast, error) {
+	q.record("GetAllHourlyForecastsAtLocation")
+	if q.GetAllHourlyForecastsAtLocationFunc != nil {
+		return q.GetAllHourlyForecastsAtLocationFunc(ctx, locationID)
+	}
+	q.fail("GetAllHourlyForecastsAtLocation")
+	return nil, nil
+}
+
+func (q *Querier) GetCurrentWeatherAtLocation(ctx context.Context, locationID uuid.UUID) ([]database.CurrentWeather, error) {
+	q.record("GetCurrentWeatherAtLocation")
+	if q.GetCurrentWeatherAtLocationFunc != nil {
+		return q.GetCurrentWeatherAtLocationFunc(ctx, locationID)
+	}
+	q.fail("GetCurrentWeatherAtLocation")
+	return nil, nil
+}
+
+func (q *Querier) GetCurrentWeatherAtLocationFromAPI(ctx context.Context, arg database.GetCurrentWeatherAtLocationFromAPIParams) (database.CurrentWeather, error) {
+	q.record("GetCurrentWeatherAtLocationFromAPI")
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.GetCurrentWeatherAtLocationFromAPIFunc != nil {
+		return q.GetCurrentWeatherAtLocationFromAPIFunc(ctx, arg)
+	}
+	q.fail("GetCurrentWeatherAtLocationFromAPI")
+	return database.CurrentWeather{}, nil
+}
+
+func (q *Querier) GetDailyForecastAtLocationAndDateFromAPI(ctx context.Context, arg database.GetDailyForecastAtLocationAndDateFromAPIParams) (database.DailyForecast, error) {
+	q.record("GetDailyForecastAtLocationAndDateFromAPI")
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.GetDailyForecastAtLocationAndDateFromAPIFunc != nil {
+		return q.GetDailyForecastAtLocationAndDateFromAPIFunc(ctx, arg)
+	}
+	q.fail("GetDailyForecastAtLocationAndDateFromAPI")
+	return database.DailyForecast{}, nil
+}
+
+func (q *Querier) GetEndpointRequestStatsSince(ctx context.Context, hour time.Time) ([]database.EndpointRequestStat, error) {
+	q.record("GetEndpointRequestStatsSince")
+	if q.GetEndpointRequestStatsSinceFunc != nil {
+		return q.GetEndpointRequestStatsSinceFunc(ctx, hour)
+	}
+	q.fail("GetEndpointRequestStatsSince")
+	return nil, nil
+}
+
+func (q *Querier) GetFirstProviderUsageHourSince(ctx context.Context, hour time.Time) (time.Time, error) {
+	q.record("GetFirstProviderUsageHourSince")
+	if q.GetFirstProviderUsageHourSinceFunc != nil {
+		return q.GetFirstProviderUsageHourSinceFunc(ctx, hour)
+	}
+	q.fail("GetFirstProviderUsageHourSince")
+	return time.Time{}, nil
+}
+
+func (q *Querier) GetHourlyForecastAtLocationAndTimeFromAPI(ctx context.Context, arg database.GetHourlyForecastAtLocationAndTimeFromAPIParams) (database.HourlyForecast, error) {
+	q.record("GetHourlyForecastAtLocationAndTimeFromAPI")
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.GetHourlyForecastAtLocationAndTimeFromAPIFunc != nil {
+		return q.GetHourlyForecastAtLocationAndTimeFromAPIFunc(ctx, arg)
+	}
+	q.fail("GetHourlyForecastAtLocationAndTimeFromAPI")
+	return database.HourlyForecast{}, nil
+}
+
+func (q *Querier) GetJobRun(ctx context.Context, id uuid.UUID) (database.JobRun, error) {
+	q.record("GetJobRun")
+	if q.GetJobRunFunc != nil {
+		return q.GetJobRunFunc(ctx, id)
+	}
+	q.fail("GetJobRun")
+	return database.JobRun{}, nil
+}
+
+func (q *Querier) GetLatestWeatherObservation(ctx context.Context, arg database.GetLatestWeatherObservationParams) (database.WeatherObservation, error) {
+	q.record("GetLatestWeatherObservation")
+	if q.GetLatestWeatherObservationFunc != nil {
+		return q.GetLatestWeatherObservationFunc(ctx, arg)
+	}
+	q.fail("GetLatestWeatherObservation")
+	return database.WeatherObservation{}, nil
+}
+
+func (q *Querier) GetLocationByAlias(ctx context.Context, alias string) (database.Location, error) {
+	q.record("GetLocationByAlias")
+	if q.GetLocationByAliasFunc != nil {
+		return q.GetLocationByAliasFunc(ctx, alias)
+	}
+	q.fail("GetLocationByAlias")
+	return database.Location{}, nil
+}
+
+func (q *Querier) GetLocationByCoordinates(ctx context.Context, arg database.GetLocationByCoordinatesParams) (database.Location, error) {
+	q.record("GetLocationByCoordinates")
+	if q.GetLocationByCoordinatesFunc != nil {
+		return q.GetLocationByCoordinatesFunc(ctx, arg)
+	}
+	q.fail("GetLocationByCoordinates")
+	return database.Location{}, nil
+}
+
+func (q *Querier) GetLocationByID(ctx context.Context, id uuid.UUID) (database.Location, error) {
+	q.record("GetLocationByID")
+	if q.GetLocationByIDFunc != nil {
+		return q.GetLocationByIDFunc(ctx, id)
+	}
+	q.fail("GetLocationByID")
+	return database.Location{}, nil
+}
+
+func (q *Querier) GetLocationByName(ctx context.Context, cityName string) (database.Location, error) {
+	q.record("GetLocationByName")
+	if q.GetLocationByNameFunc != nil {
+		return q.GetLocationByNameFunc(ctx, cityName)
+	}
+	q.fail("GetLocationByName")
+	return database.Location{}, nil
+}
+
+func (q *Querier) GetLocationFetchesSince(ctx context.Context, hour time.Time) ([]database.GetLocationFetchesSinceRow, error) {
+	q.record("GetLocationFetchesSince")
+	if q.GetLocationFetchesSinceFunc != nil {
+		return q.GetLocationFetchesSinceFunc(ctx, hour)
+	}
+	q.fail("GetLocationFetchesSince")
+	return nil, nil
+}
+
+func (q *Querier) GetLocationGroup(ctx context.Context, arg database.GetLocationGroupParams) (database.LocationGroup, error) {
+	q.record("GetLocationGroup")
+	if q.GetLocationGroupFunc != nil {
+		return q.GetLocationGroupFunc(ctx, arg)
+	}
+	q.fail("GetLocationGroup")
+	return database.LocationGroup{}, nil
+}
+
+func (q *Querier) GetProviderUsageSince(ctx context.Context, hour time.Time) ([]database.GetProviderUsageSinceRow, error) {
+	q.record("GetProviderUsageSince")
+	if q.GetProviderUsageSinceFunc != nil {
+		return q.GetProviderUsageSinceFunc(ctx, hour)
+	}
+	q.fail("GetProviderUsageSince")
+	return nil, nil
+}
+
+func (q *Querier) GetTopLocationsByRequestsSince(ctx context.Context, arg database.GetTopLocationsByRequestsSinceParams) ([]database.GetTopLocationsByRequestsSinceRow, error) {
+	q.record("GetTopLocationsByRequestsSince")
+	if q.GetTopLocationsByRequestsSinceFunc != nil {
+		return q.GetTopLocationsByRequestsSinceFunc(ctx, arg)
+	}
+	q.fail("GetTopLocationsByRequestsSince")
+	return nil, nil
+}
+
+func (q *Querier) GetUpcomingDailyForecastsAtLocation(ctx context.Context, arg database.GetUpcomingDailyForecastsAtLocationParams) ([]database.DailyForecast, error) {
+	q.record("GetUpcomingDailyForecastsAtLocation")
+	if q.GetUpcomingDailyForecastsAtLocationFunc != nil {
+		return q.GetUpcomingDailyForecastsAtLocationFunc(ctx, arg)
+	}
+	q.fail("GetUpcomingDailyForecastsAtLocation")
+	return nil, nil
+}
+
+func (q *Querier) GetUpcomingHourlyForecastsAtLocation(ctx context.Context, arg database.GetUpcomingHourlyForecastsAtLocationParams) ([]database.HourlyForecast, error) {
+	q.record("GetUpcomingHourlyForecastsAtLocation")
+	if q.GetUpcomingHourlyForecastsAtLocationFunc != nil {
+		return q.GetUpcomingHourlyForecastsAtLocationFunc(ctx, arg)
+	}
+	q.fail("GetUpcomingHourlyForecastsAtLocation")
+	return nil, nil
+}
+
+func (q *Querier) GetUserByEmail(ctx context.Context, email string) (database.User, error) {
+	q.record("GetUserByEmail")
+	if q.GetUserByEmailFunc != nil {
+		return q.GetUserByEmailFunc(ctx, email)
+	}
+	q.fail("GetUserByEmail")
+	return database.User{}, nil
+}
+
+func (q *Querier) GetUserByID(ctx context.Context, id uuid.UUID) (database.User, error) {
+	q.record("GetUserByID")
+	if q.GetUserByIDFunc != nil {
+		return q.GetUserByIDFunc(ctx, id)
+	}
+	q.fail("GetUserByID")
+	return database.User{}, nil
+}
+
+func (q *Querier) GetWatchlistUpdates(ctx context.Context, arg database.GetWatchlistUpdatesParams) ([]database.GetWatchlistUpdatesRow, error) {
+	q.record("GetWatchlistUpdates")
+	if q.GetWatchlistUpdatesFunc != nil {
+		return q.GetWatchlistUpdatesFunc(ctx, arg)
+	}
+	q.fail("GetWatchlistUpdates")
+	return nil, nil
+}
+
+func (q *Querier) GetWeatherWarningsAtLocation(ctx context.Context, locationID uuid.UUID) ([]database.WeatherWarning, error) {
+	q.record("GetWeatherWarningsAtLocation")
+	if q.GetWeatherWarningsAtLocationFunc != nil {
+		return q.GetWeatherWarningsAtLocationFunc(ctx, locationID)
+	}
+	q.fail("GetWeatherWarningsAtLocation")
+	return nil, nil
+}
+
+func (q *Querier) IncrementEndpointRequestStats(ctx context.Context, arg database.IncrementEndpointRequestStatsParams) error {
+	q.record("IncrementEndpointRequestStats")
+	if q.IncrementEndpointRequestStatsFunc != nil {
+		return q.IncrementEndpointRequestStatsFunc(ctx, arg)
+	}
+	q.fail("IncrementEndpointRequestStats")
+	return nil
+}
+
+func (q *Querier) IncrementLocationFetchStats(ctx context.Context, arg database.IncrementLocationFetchStatsParams) error {
+	q.record("IncrementLocationFetchStats")
+	if q.IncrementLocationFetchStatsFunc != nil {
+		return q.IncrementLocationFetchStatsFunc(ctx, arg)
+	}
+	q.fail("IncrementLocationFetchStats")
+	return nil
+}
+
+func (q *Querier) IncrementLocationRequestStats(ctx context.Context, arg database.IncrementLocationRequestStatsParams) error {
+	q.record("IncrementLocationRequestStats")
+	if q.IncrementLocationRequestStatsFunc != nil {
+		return q.IncrementLocationRequestStatsFunc(ctx, arg)
+	}
+	q.fail("IncrementLocationRequestStats")
+	return nil
+}
+
+func (q *Querier) IncrementProviderUsageStats(ctx context.Context, arg database.IncrementProviderUsageStatsParams) error {
+	q.record("IncrementProviderUsageStats")
+	if q.IncrementProviderUsageStatsFunc != nil {
+		return q.IncrementProviderUsageStatsFunc(ctx, arg)
+	}
+	q.fail("IncrementProviderUsageStats")
+	return nil
+}
+
+func (q *Querier) InterruptUnfinishedJobRuns(ctx context.Context, finishedAt sql.NullTime) (int64, error) {
+	q.record("InterruptUnfinishedJobRuns")
+	if q.InterruptUnfinishedJobRunsFunc != nil {
+		return q.InterruptUnfinishedJobRunsFunc(ctx, finishedAt)
+	}
+	return 0, nil
+}
+
+func (q *Querier) ListAlertSubscriptionsForLocation(ctx context.Context, locationID uuid.UUID) ([]database.AlertSubscription, error) {
+	q.record("ListAlertSubscriptionsForLocation")
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.ListAlertSubscriptionsForLocationFunc != nil {
+		return q.ListAlertSubscriptionsForLocationFunc(ctx, locationID)
+	}
+	q.fail("ListAlertSubscriptionsForLocation")
+	return nil, nil
+}
+
+func (q *Querier) ListAlertSubscriptionsForSubscriber(ctx context.Context, subscriberID string) ([]database.AlertSubscription, error) {
+	q.record("ListAlertSubscriptionsForSubscriber")
+	if q.ListAlertSubscriptionsForSubscriberFunc != nil {
+		return q.ListAlertSubscriptionsForSubscriberFunc(ctx, subscriberID)
+	}
+	q.fail("ListAlertSubscriptionsForSubscriber")
+	return nil, nil
+}
+
+func (q *Querier) ListCurrentWeatherHistory(ctx context.Context, arg database.ListCurrentWeatherHistoryParams) ([]database.CurrentWeatherHistory, error) {
+	q.record("ListCurrentWeatherHistory")
+	if q.ListCurrentWeatherHistoryFunc != nil {
+		return q.ListCurrentWeatherHistoryFunc(ctx, arg)
+	}
+	q.fail("ListCurrentWeatherHistory")
+	return nil, nil
+}
+
+func (q *Querier) ListDailyForecastHistory(ctx context.Context, arg database.ListDailyForecastHistoryParams) ([]database.DailyForecastHistory, error) {
+	q.record("ListDailyForecastHistory")
+	if q.ListDailyForecastHistoryFunc != nil {
+		return q.ListDailyForecastHistoryFunc(ctx, arg)
+	}
+	q.fail("ListDailyForecastHistory")
+	return nil, nil
+}
+
+func (q *Querier) ListHourlyForecastHistory(ctx context.Context, arg database.ListHourlyForecastHistoryParams) ([]database.HourlyForecastHistory, error) {
+	q.record("ListHourlyForecastHistory")
+	if q.ListHourlyForecastHistoryFunc != nil {
+		return q.ListHourlyForecastHistoryFunc(ctx, arg)
+	}
+	q.fail("ListHourlyForecastHistory")
+	return nil, nil
+}
+
+func (q *Querier) ListJobRunLocations(ctx context.Context, jobRunID uuid.UUID) ([]database.ListJobRunLocationsRow, error) {
+	q.record("ListJobRunLocations")
+	if q.ListJobRunLocationsFunc != nil {
+		return q.ListJobRunLocationsFunc(ctx, jobRunID)
+	}
+	q.fail("ListJobRunLocations")
+	return nil, nil
+}
+
+func (q *Querier) ListJobRunLocationsForLocation(ctx context.Context, arg database.ListJobRunLocationsForLocationParams) ([]database.ListJobRunLocationsForLocationRow, error) {
+	q.record("ListJobRunLocationsForLocation")
+	if q.ListJobRunLocationsForLocationFunc != nil {
+		return q.ListJobRunLocationsForLocationFunc(ctx, arg)
+	}
+	q.fail("ListJobRunLocationsForLocation")
+	return nil, nil
+}
+
+func (q *Querier) ListJobRuns(ctx context.Context, arg database.ListJobRunsParams) ([]database.JobRun, error) {
+	q.record("ListJobRuns")
+	if q.ListJobRunsFunc != nil {
+		return q.ListJobRunsFunc(ctx, arg)
+	}
+	q.fail("ListJobRuns")
+	return nil, nil
+}
+
+func (q *Querier) ListLatestProviderDisagreement(ctx context.Context, locationID uuid.UUID) ([]database.ProviderDisagreement, error) {
+	q.record("ListLatestProviderDisagreement")
+	if q.ListLatestProviderDisagreementFunc != nil {
+		return q.ListLatestProviderDisagreementFunc(ctx, locationID)
+	}
+	q.fail("ListLatestProviderDisagreement")
+	return nil, nil
+}
+
+func (q *Querier) ListLocationAliases(ctx context.Context, locationID uuid.UUID) ([]database.LocationAlias, error) {
+	q.record("ListLocationAliases")
+	if q.ListLocationAliasesFunc != nil {
+		return q.ListLocationAliasesFunc(ctx, locationID)
+	}
+	q.fail("ListLocationAliases")
+	return nil, nil
+}
+
+func (q *Querier) ListLocationDemand(ctx context.Context, hour time.Time) ([]database.ListLocationDemandRow, error) {
+	q.record("ListLocationDemand")
+	if q.ListLocationDemandFunc != nil {
+		return q.ListLocationDemandFunc(ctx, hour)
+	}
+	q.fail("ListLocationDemand")
+	return nil, nil
+}
+
+func (q *Querier) ListLocationGroupMembers(ctx context.Context, groupID uuid.UUID) ([]database.Location, error) {
+	q.record("ListLocationGroupMembers")
+	if q.ListLocationGroupMembersFunc != nil {
+		return q.ListLocationGroupMembersFunc(ctx, groupID)
+	}
+	q.fail("ListLocationGroupMembers")
+	return nil, nil
+}
+
+func (q *Querier) ListLocationGroupsForSubscriber(ctx context.Context, subscriberID string) ([]database.LocationGroup, error) {
+	q.record("ListLocationGroupsForSubscriber")
+	if q.ListLocationGroupsForSubscriberFunc != nil {
+		return q.ListLocationGroupsForSubscriberFunc(ctx, subscriberID)
+	}
+	q.fail("ListLocationGroupsForSubscriber")
+	return nil, nil
+}
+
+func (q *Querier) ListLocationProviderWeights(ctx context.Context, locationID uuid.UUID) ([]database.LocationProviderWeight, error) {
+	q.record("ListLocationProviderWeights")
+	if q.ListLocationProviderWeightsFunc != nil {
+		return q.ListLocationProviderWeightsFunc(ctx, locationID)
+	}
+	q.fail("ListLocationProviderWeights")
+	return nil, nil
+}
+
+func (q *Querier) ListLocations(ctx context.Context) ([]database.Location, error) {
+	q.record("ListLocations")
+	if q.ListLocationsFunc != nil {
+		return q.ListLocationsFunc(ctx)
+	}
+	q.fail("ListLocations")
+	return nil, nil
+}
+
+func (q *Querier) ListSchedulerIntervals(ctx context.Context) ([]database.SchedulerInterval, error) {
+	q.record("ListSchedulerIntervals")
+	if q.ListSchedulerIntervalsFunc != nil {
+		return q.ListSchedulerIntervalsFunc(ctx)
+	}
+	q.fail("ListSchedulerIntervals")
+	return nil, nil
+}
+
+func (q *Querier) ListSchedulerRunsForLocation(ctx context.Context, arg database.ListSchedulerRunsForLocationParams) ([]database.SchedulerRun, error) {
+	q.record("ListSchedulerRunsForLocation")
+	if q.ListSchedulerRunsForLocationFunc != nil {
+		return q.ListSchedulerRunsForLocationFunc(ctx, arg)
+	}
+	q.fail("ListSchedulerRunsForLocation")
+	return nil, nil
+}
+
+func (q *Querier) ListWatchedLocationIDs(ctx context.Context) ([]uuid.UUID, error) {
+	q.record("ListWatchedLocationIDs")
+	if q.ListWatchedLocationIDsFunc != nil {
+		return q.ListWatchedLocationIDsFunc(ctx)
+	}
+	q.fail("ListWatchedLocationIDs")
+	return nil, nil
+}
+
+func (q *Querier) ListWatchlistLocations(ctx context.Context, subscriberID string) ([]database.Location, error) {
+	q.record("ListWatchlistLocations")
+	if q.ListWatchlistLocationsFunc != nil {
+		return q.ListWatchlistLocationsFunc(ctx, subscriberID)
+	}
+	q.fail("ListWatchlistLocations")
+	return nil, nil
+}
+
+func (q *Querier) MoveAlertSubscriptions(ctx context.Context, arg database.MoveAlertSubscriptionsParams) (int64, error) {
+	q.record("MoveAlertSubscriptions")
+	if q.MoveAlertSubscriptionsFunc != nil {
+		return q.MoveAlertSubscriptionsFunc(ctx, arg)
+	}
+	q.fail("MoveAlertSubscriptions")
+	return 0, nil
+}
+
+func (q *Querier) MoveLocationAliases(ctx context.Context, arg database.MoveLocationAliasesParams) (int64, error) {
+	q.record("MoveLocationAliases")
+	if q.MoveLocationAliasesFunc != nil {
+		return q.MoveLocationAliasesFunc(ctx, arg)
+	}
+	q.fail("MoveLocationAliases")
+	return 0, nil
+}
+
+func (q *Querier) MoveLocationGroupMembers(ctx context.Context, arg database.MoveLocationGroupMembersParams) (int64, error) {
+	q.record("MoveLocationGroupMembers")
+	if q.MoveLocationGroupMembersFunc != nil {
+		return q.MoveLocationGroupMembersFunc(ctx, arg)
+	}
+	q.fail("MoveLocationGroupMembers")
+	return 0, nil
+}
+
+func (q *Querier) MoveWatchlistEntries(ctx context.Context, arg database.MoveWatchlistEntriesParams) (int64, error) {
+	q.record("MoveWatchlistEntries")
+	if q.MoveWatchlistEntriesFunc != nil {
+		return q.MoveWatchlistEntriesFunc(ctx, arg)
+	}
+	q.fail("MoveWatchlistEntries")
+	return 0, nil
+}
+
+func (q *Querier) RecordAlertDeliveryAttempt(ctx context.Context, arg database.RecordAlertDeliveryAttemptParams) error {
+	q.record("RecordAlertDeliveryAttempt")
+	if q.RecordAlertDeliveryAttemptFunc != nil {
+		return q.RecordAlertDeliveryAttemptFunc(ctx, arg)
+	}
+	q.fail("RecordAlertDeliveryAttempt")
+	return nil
+}
+
+func (q *Querier) RemoveLocationGroupMember(ctx context.Context, arg database.RemoveLocationGroupMemberParams) (int64, error) {
+	q.record("RemoveLocationGroupMember")
+	if q.RemoveLocationGroupMemberFunc != nil {
+		return q.RemoveLocationGroupMemberFunc(ctx, arg)
+	}
+	q.fail("RemoveLocationGroupMember")
+	return 0, nil
+}
+
+func (q *Querier) StartJobRunLocation(ctx context.Context, arg database.StartJobRunLocationParams) error {
+	q.record("StartJobRunLocation")
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.StartJobRunLocationFunc != nil {
+		return q.StartJobRunLocationFunc(ctx, arg)
+	}
+	return nil
+}
+
+func (q *Querier) TouchLocationAccess(ctx context.Context, arg database.TouchLocationAccessParams) error {
+	q.record("TouchLocationAccess")
+	if q.TouchLocationAccessFunc != nil {
+		return q.TouchLocationAccessFunc(ctx, arg)
+	}
+	q.fail("TouchLocationAccess")
+	return nil
+}
+
+func (q *Querier) UpdateAirQuality(ctx context.Context, arg database.UpdateAirQualityParams) (database.AirQuality, error) {
+	q.record("UpdateAirQuality")
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.UpdateAirQualityFunc != nil {
+		return q.UpdateAirQualityFunc(ctx, arg)
+	}
+	q.fail("UpdateAirQuality")
+	return database.AirQuality{}, nil
+}
+
+func (q *Querier) UpdateAlertSubscriptionState(ctx context.Context, arg database.UpdateAlertSubscriptionStateParams) error {
+	q.record("UpdateAlertSubscriptionState")
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.UpdateAlertSubscriptionStateFunc != nil {
+		return q.UpdateAlertSubscriptionStateFunc(ctx, arg)
+	}
+	q.fail("UpdateAlertSubscriptionState")
+	return nil
+}
+
+func (q *Querier) UpdateCurrentWeather(ctx context.Context, arg database.UpdateCurrentWeatherParams) (database.CurrentWeather, error) {
+	q.record("UpdateCurrentWeather")
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.UpdateCurrentWeatherFunc != nil {
+		return q.UpdateCurrentWeatherFunc(ctx, arg)
+	}
+	q.fail("UpdateCurrentWeather")
+	return database.CurrentWeather{}, nil
+}
+
+func (q *Querier) UpdateDailyForecast(ctx context.Context, arg database.UpdateDailyForecastParams) (database.DailyForecast, error) {
+	q.record("UpdateDailyForecast")
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.UpdateDailyForecastFunc != nil {
+		return q.UpdateDailyForecastFunc(ctx, arg)
+	}
+	q.fail("UpdateDailyForecast")
+	return database.DailyForecast{}, nil
+}
+
+func (q *Querier) UpdateHourlyForecast(ctx context.Context, arg database.UpdateHourlyForecastParams) (database.HourlyForecast, error) {
+	q.record("UpdateHourlyForecast")
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.UpdateHourlyForecastFunc != nil {
+		return q.UpdateHourlyForecastFunc(ctx, arg)
+	}
+	q.fail("UpdateHourlyForecast")
+	return database.HourlyForecast{}, nil
+}
+
+func (q *Querier) UpdateTimezone(ctx context.Context, arg database.UpdateTimezoneParams) error {
+	q.record("UpdateTimezone")
+	if q.UpdateTimezoneFunc != nil {
+		return q.UpdateTimezoneFunc(ctx, arg)
+	}
+	return nil
+}
+
+func (q *Querier) UpdateUserPreferences(ctx context.Context, arg database.UpdateUserPreferencesParams) (database.User, error) {
+	q.record("UpdateUserPreferences")
+	if q.UpdateUserPreferencesFunc != nil {
+		return q.UpdateUserPreferencesFunc(ctx, arg)
+	}
+	q.fail("UpdateUserPreferences")
+	return database.User{}, nil
+}
+
+func (q *Querier) UpsertDailyForecasts(ctx context.Context, forecasts json.RawMessage) (int64, error) {
+	q.record("UpsertDailyForecasts")
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.UpsertDailyForecastsFunc != nil {
+		return q.UpsertDailyForecastsFunc(ctx, forecasts)
+	}
+	return 0, nil
+}
+
+func (q *Querier) UpsertHourlyForecasts(ctx context.Context, forecasts json.RawMessage) (int64, error) {
+	q.record("UpsertHourlyForecasts")
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.UpsertHourlyForecastsFunc != nil {
+		return q.UpsertHourlyForecastsFunc(ctx, forecasts)
+	}
+	return 0, nil
+}
+
+func (q *Querier) UpsertLocationAlias(ctx context.Context, arg database.UpsertLocationAliasParams) (database.LocationAlias, error) {
+	q.record("UpsertLocationAlias")
+	if q.UpsertLocationAliasFunc != nil {
+		return q.UpsertLocationAliasFunc(ctx, arg)
+	}
+	q.fail("UpsertLocationAlias")
+	return database.LocationAlias{}, nil
+}
+
+func (q *Querier) UpsertLocationProviderWeight(ctx context.Context, arg database.UpsertLocationProviderWeightParams) error {
+	q.record("UpsertLocationProviderWeight")
+	if q.UpsertLocationProviderWeightFunc != nil {
+		return q.UpsertLocationProviderWeightFunc(ctx, arg)
+	}
+	q.fail("UpsertLocationProviderWeight")
+	return nil
+}
+
+func (q *Querier) UpsertProviderDisagreement(ctx context.Context, arg database.UpsertProviderDisagreementParams) error {
+	q.record("UpsertProviderDisagreement")
+	if q.UpsertProviderDisagreementFunc != nil {
+		return q.UpsertProviderDisagreementFunc(ctx, arg)
+	}
+	q.fail("UpsertProviderDisagreement")
+	return nil
+}
+
+func (q *Querier) UpsertSchedulerInterval(ctx context.Context, arg database.UpsertSchedulerIntervalParams) error {
+	q.record("UpsertSchedulerInterval")
+	if q.UpsertSchedulerIntervalFunc != nil {
+		return q.UpsertSchedulerIntervalFunc(ctx, arg)
+	}
+	q.fail("UpsertSchedulerInterval")
+	return nil
+}
+
+func (q *Querier) UpsertWeatherObservation(ctx context.Context, arg database.UpsertWeatherObservationParams) error {
+	q.record("UpsertWeatherObservation")
+	if q.UpsertWeatherObservationFunc != nil {
+		return q.UpsertWeatherObservationFunc(ctx, arg)
+	}
+	q.fail("UpsertWeatherObservation")
+	return nil
+}
+
+func (q *Querier) UpsertWeatherWarnings(ctx context.Context, arg database.UpsertWeatherWarningsParams) error {
+	q.record("UpsertWeatherWarnings")
+	if q.UpsertWeatherWarningsFunc != nil {
+		return q.UpsertWeatherWarningsFunc(ctx, arg)
+	}
+	q.fail("UpsertWeatherWarnings")
+	return nil
+}
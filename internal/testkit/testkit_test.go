@@ -0,0 +1,86 @@
+package testkit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/cor0nius/willitrain/internal/database"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestQuerierCountsCalls(t *testing.T) {
+	q := NewQuerier(t)
+	q.ListLocationsFunc = func(ctx context.Context) ([]database.Location, error) {
+		return []database.Location{DBLocation}, nil
+	}
+
+	for range 2 {
+		if _, err := q.ListLocations(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if _, err := q.CreateCurrentWeather(context.Background(), database.CreateCurrentWeatherParams{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := q.Calls("ListLocations"); got != 2 {
+		t.Errorf("expected 2 calls to ListLocations, got %d", got)
+	}
+	if got := q.Calls("CreateCurrentWeather"); got != 1 {
+		t.Errorf("expected 1 call to CreateCurrentWeather, got %d", got)
+	}
+}
+
+func TestMemoryCache(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemoryCache()
+
+	if _, err := c.Get(ctx, "missing"); !errors.Is(err, redis.Nil) {
+		t.Fatalf("expected redis.Nil for a missing key, got %v", err)
+	}
+	if err := c.Set(ctx, "key", map[string]int{"a": 1}, 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, err := c.Get(ctx, "key"); err != nil || got != `{"a":1}` {
+		t.Errorf("expected the JSON encoded value, got %q, %v", got, err)
+	}
+	if err := c.Delete(ctx, "key"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := c.Get(ctx, "key"); !errors.Is(err, redis.Nil) {
+		t.Errorf("expected the key to be deleted, got %v", err)
+	}
+	for want := int64(1); want <= 2; want++ {
+		if n, err := c.Incr(ctx, "counter", time.Minute); err != nil || n != want {
+			t.Errorf("Incr = %d, %v; want %d", n, err, want)
+		}
+	}
+	if got, err := c.Get(ctx, "counter"); err != nil || got != "2" {
+		t.Errorf("expected the counter to be readable, got %q, %v", got, err)
+	}
+}
+
+func TestNewProviderServer(t *testing.T) {
+	server := NewProviderServer(t, map[string][]byte{"/ometeo": []byte(`{"ok":true}`)})
+
+	resp, err := http.Get(server.URL + "/ometeo/forecast")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected 200 for a known route, got %d", resp.StatusCode)
+	}
+
+	resp, err = http.Get(server.URL + "/owm")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown route, got %d", resp.StatusCode)
+	}
+}
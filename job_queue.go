@@ -0,0 +1,472 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/cor0nius/willitrain/internal/database"
+	"github.com/google/uuid"
+)
+
+// This file implements the job queue through which the scheduler runs its jobs. Every job run is
+// recorded in the job_runs table with its status, duration and error. A run that updates
+// locations enqueues one task per location, which a fixed pool of SCHEDULER_CONCURRENCY workers
+// takes off the queue in the order of their jittered start times. The status of every task, from
+// queued through running to its outcome, is recorded in job_run_locations, so that /admin/jobs
+// can answer why a particular city's data is stale without searching the logs. Runs that a
+// previous process left unfinished are marked as interrupted on startup, and records older than
+// jobRunRetention are pruned after every run.
+
+const (
+	jobRunRetention = 14 * 24 * time.Hour
+
+	defaultJobRuns = 20
+	maxJobRuns     = 200
+)
+
+// Statuses of job runs and of the location tasks they queue.
+const (
+	jobStatusQueued      = "queued"
+	jobStatusRunning     = "running"
+	jobStatusSucceeded   = "succeeded"
+	jobStatusFailed      = "failed"
+	jobStatusSkipped     = "skipped"
+	jobStatusInterrupted = "interrupted"
+)
+
+// jobRun is a recorded run of a scheduler job. It counts the outcomes of the locations the run
+// updates, which are stored with the run once it finishes.
+type jobRun struct {
+	id        uuid.UUID
+	name      string
+	startedAt time.Time
+
+	mu                                sync.Mutex
+	total, succeeded, failed, skipped int
+}
+
+// locationTask is the queued update of one location within a job run. It starts no earlier
+// than notBefore, which spreads the updates of a cycle over the scheduler's jitter.
+type locationTask struct {
+	location  Location
+	notBefore time.Time
+}
+
+// jobTaskStatus maps the result of a location update to the status stored for its task.
+func jobTaskStatus(err error) string {
+	switch {
+	case err == nil:
+		return jobStatusSucceeded
+	case errors.Is(err, errUpdateSkipped):
+		return jobStatusSkipped
+	default:
+		return jobStatusFailed
+	}
+}
+
+// startJobRun records the start of a job run. It returns nil if the run could not be recorded,
+// in which case the run goes ahead without a record.
+func (cfg *apiConfig) startJobRun(ctx context.Context, name string, startedAt time.Time) *jobRun {
+	record, err := cfg.dbQueries.CreateJobRun(ctx, database.CreateJobRunParams{JobName: name, StartedAt: startedAt.UTC()})
+	if err != nil {
+		cfg.logger.Warn("could not record job run", "type", name, "error", err)
+		return nil
+	}
+	return &jobRun{id: record.ID, name: name, startedAt: startedAt}
+}
+
+// finishJobRun records the outcome of a job run and prunes the runs older than jobRunRetention.
+// A nil run is ignored.
+func (cfg *apiConfig) finishJobRun(ctx context.Context, run *jobRun, runErr error, finishedAt time.Time) {
+	if run == nil {
+		return
+	}
+	run.mu.Lock()
+	params := database.FinishJobRunParams{
+		ID:                 run.id,
+		Status:             jobStatusSucceeded,
+		FinishedAt:         timeToNullTime(finishedAt.UTC()),
+		DurationMs:         sql.NullInt64{Int64: finishedAt.Sub(run.startedAt).Milliseconds(), Valid: true},
+		LocationsTotal:     int32(run.total),
+		LocationsSucceeded: int32(run.succeeded),
+		LocationsFailed:    int32(run.failed),
+		LocationsSkipped:   int32(run.skipped),
+	}
+	run.mu.Unlock()
+	if runErr != nil {
+		params.Status = jobStatusFailed
+		params.Error = sql.NullString{String: runErr.Error(), Valid: true}
+	}
+	if err := cfg.dbQueries.FinishJobRun(ctx, params); err != nil {
+		cfg.logger.Warn("could not record job run outcome", "type", run.name, "error", err)
+	}
+
+	deleted, err := cfg.dbQueries.DeleteJobRunsBefore(ctx, finishedAt.Add(-jobRunRetention))
+	if err != nil {
+		cfg.logger.Warn("could not prune job runs", "error", err)
+	} else if deleted > 0 {
+		cfg.logger.Debug("pruned job runs", "deleted", deleted)
+	}
+}
+
+// interruptUnfinishedJobRuns marks the job runs left unfinished by a previous process, which was
+// killed before its runs completed, as interrupted. It must be called before the scheduler starts.
+func (cfg *apiConfig) interruptUnfinishedJobRuns(ctx context.Context) {
+	interrupted, err := cfg.dbQueries.InterruptUnfinishedJobRuns(ctx, timeToNullTime(time.Now().UTC()))
+	if err != nil {
+		cfg.logger.Warn("could not mark unfinished job runs as interrupted", "error", err)
+		return
+	}
+	if interrupted > 0 {
+		cfg.logger.Info("marked unfinished job runs as interrupted", "runs", interrupted)
+	}
+}
+
+// queueTask records a location task as queued. Failures are logged, since a missing record must
+// not hold up the update itself. A nil run is ignored.
+func (cfg *apiConfig) queueTask(ctx context.Context, run *jobRun, task locationTask, queuedAt time.Time) {
+	if run == nil {
+		return
+	}
+	run.mu.Lock()
+	run.total++
+	run.mu.Unlock()
+	err := cfg.dbQueries.CreateJobRunLocation(ctx, database.CreateJobRunLocationParams{
+		JobRunID:   run.id,
+		LocationID: task.location.LocationID,
+		QueuedAt:   queuedAt.UTC(),
+	})
+	if err != nil {
+		cfg.logger.Warn("could not record queued location update", "type", run.name, "location", task.location.CityName, "error", err)
+	}
+}
+
+// startTask records a location task as running. A nil run is ignored.
+func (cfg *apiConfig) startTask(ctx context.Context, run *jobRun, task locationTask, startedAt time.Time) {
+	if run == nil {
+		return
+	}
+	err := cfg.dbQueries.StartJobRunLocation(ctx, database.StartJobRunLocationParams{
+		JobRunID:   run.id,
+		LocationID: task.location.LocationID,
+		StartedAt:  timeToNullTime(startedAt.UTC()),
+	})
+	if err != nil {
+		cfg.logger.Warn("could not record running location update", "type", run.name, "location", task.location.CityName, "error", err)
+	}
+}
+
+// finishTask records the outcome of a location task and counts it for its run. The duration is
+// zero for tasks that never started. A nil run is ignored.
+func (cfg *apiConfig) finishTask(ctx context.Context, run *jobRun, task locationTask, taskErr error, duration time.Duration) {
+	if run == nil {
+		return
+	}
+	status := jobTaskStatus(taskErr)
+	run.mu.Lock()
+	switch status {
+	case jobStatusSucceeded:
+		run.succeeded++
+	case jobStatusSkipped:
+		run.skipped++
+	default:
+		run.failed++
+	}
+	run.mu.Unlock()
+
+	params := database.FinishJobRunLocationParams{
+		JobRunID:   run.id,
+		LocationID: task.location.LocationID,
+		Status:     status,
+		FinishedAt: timeToNullTime(time.Now().UTC()),
+		DurationMs: sql.NullInt64{Int64: duration.Milliseconds(), Valid: true},
+	}
+	if status == jobStatusFailed {
+		params.Error = sql.NullString{String: taskErr.Error(), Valid: true}
+	}
+	// The outcome is recorded even if the update failed because its context ended.
+	if err := cfg.dbQueries.FinishJobRunLocation(context.WithoutCancel(ctx), params); err != nil {
+		cfg.logger.Warn("could not record location update outcome", "type", run.name, "location", task.location.CityName, "error", err)
+	}
+}
+
+// activeJobRun returns the recorded run of the named job that is in progress, or nil.
+func (s *Scheduler) activeJobRun(name string) *jobRun {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, j := range s.jobs {
+		if j.Name == name {
+			return j.run
+		}
+	}
+	return nil
+}
+
+// runLocationQueue enqueues a task for every location and runs the tasks on a pool of workers,
+// one per concurrent update allowed, or one per task if the concurrency is not limited. Every task
+// starts at a random point within the scheduler's jitter, and the queue is ordered by these start
+// times. Tasks that are still waiting for their start when the scheduler stops or the job's
+// context ends are skipped.
+func (s *Scheduler) runLocationQueue(ctx context.Context, jobType string, run *jobRun, locations []database.Location, updateFunc func(context.Context, Location) error) {
+	now := time.Now()
+	tasks := make([]locationTask, len(locations))
+	for i, loc := range locations {
+		tasks[i] = locationTask{location: databaseLocationToLocation(loc), notBefore: now}
+		if s.jitter > 0 {
+			tasks[i].notBefore = now.Add(rand.N(s.jitter))
+		}
+	}
+	slices.SortStableFunc(tasks, func(a, b locationTask) int { return a.notBefore.Compare(b.notBefore) })
+
+	queue := make(chan locationTask, len(tasks))
+	for _, task := range tasks {
+		s.cfg.queueTask(ctx, run, task, now)
+		queue <- task
+	}
+	close(queue)
+
+	workers := len(tasks)
+	if s.concurrency > 0 && s.concurrency < workers {
+		workers = s.concurrency
+	}
+	queueDepth := schedulerQueueDepth.WithLabelValues(jobType)
+	queueDepth.Set(float64(len(tasks)))
+
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for task := range queue {
+				s.runLocationTask(ctx, jobType, run, task, updateFunc)
+				queueDepth.Dec()
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// runLocationTask waits for the start of a task, runs it with the scheduler's location timeout
+// and records, publishes and, if it keeps failing, reports its outcome.
+func (s *Scheduler) runLocationTask(ctx context.Context, jobType string, run *jobRun, task locationTask, updateFunc func(context.Context, Location) error) {
+	if !s.awaitStart(ctx, task.notBefore) {
+		s.cfg.finishTask(ctx, run, task, errUpdateSkipped, 0)
+		s.publishLocationEvent(jobType, task.location, errUpdateSkipped)
+		return
+	}
+	start := time.Now()
+	s.cfg.startTask(ctx, run, task, start)
+	err := s.runLocationUpdate(ctx, jobType, task.location, updateFunc)
+	s.cfg.finishTask(ctx, run, task, err, time.Since(start))
+	s.publishLocationEvent(jobType, task.location, err)
+	s.cfg.notifier.recordLocationUpdate(jobType, task.location, err)
+}
+
+// awaitStart waits until the given time. It reports false if the scheduler stopped or the job's
+// context ended before or in the meantime.
+func (s *Scheduler) awaitStart(ctx context.Context, at time.Time) bool {
+	select {
+	case <-s.stop:
+		return false
+	case <-ctx.Done():
+		return false
+	default:
+	}
+	wait := time.Until(at)
+	if wait <= 0 {
+		return true
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-s.stop:
+		return false
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// jobRunToJSON converts a recorded job run to its JSON representation.
+func jobRunToJSON(run database.JobRun) JobRunJSON {
+	return JobRunJSON{
+		ID:                 run.ID.String(),
+		Job:                run.JobName,
+		Status:             run.Status,
+		StartedAt:          run.StartedAt.UTC().Format(time.RFC3339),
+		FinishedAt:         formatNullTime(run.FinishedAt),
+		DurationMs:         run.DurationMs.Int64,
+		LocationsTotal:     run.LocationsTotal,
+		LocationsSucceeded: run.LocationsSucceeded,
+		LocationsFailed:    run.LocationsFailed,
+		LocationsSkipped:   run.LocationsSkipped,
+		Error:              run.Error.String,
+	}
+}
+
+// formatNullTime formats a nullable timestamp as RFC 3339 in UTC, or as an empty string if it is NULL.
+func formatNullTime(t sql.NullTime) string {
+	if !t.Valid {
+		return ""
+	}
+	return t.Time.UTC().Format(time.RFC3339)
+}
+
+// @Summary      List scheduler job runs
+// @Description  Returns the most recent scheduler job runs with their status (running, succeeded, failed or
+// @Description  interrupted), duration, error and the number of locations that succeeded, failed or were
+// @Description  skipped. With city, returns that location's most recent queued updates instead, each with the
+// @Description  run it belongs to, its status, queue and start times, duration and error. Runs are kept for 14 days.
+// @Tags         admin
+// @Produce      json
+// @Param        job    query     string  false  "Job name to filter by, e.g. 'current weather'"
+// @Param        city   query     string  false  "City name or alias of an existing location"
+// @Param        limit  query     int     false  "Maximum number of entries (default 20, max 200)"
+// @Success      200  {object}  JobRunsResponse
+// @Failure      400  {object}  ErrorResponse "Bad Request - Invalid limit"
+// @Failure      404  {object}  ErrorResponse "Not Found - Location does not exist"
+// @Failure      500  {object}  ErrorResponse "Internal Server Error - Failed to get job runs"
+// @Security     ApiKeyAuth
+// @Failure      401  {object}  ErrorResponse "Unauthorized - Missing API key"
+// @Failure      403  {object}  ErrorResponse "Forbidden - Invalid API key"
+// @Router       /admin/jobs [get]
+func (cfg *apiConfig) handlerJobRuns(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		cfg.respondWithError(w, http.StatusMethodNotAllowed, "Method Not Allowed", nil)
+		return
+	}
+
+	var job sql.NullString
+	if name := r.URL.Query().Get("job"); name != "" {
+		job = sql.NullString{String: name, Valid: true}
+	}
+	limit, err := parseStatsParam(r, "limit", defaultJobRuns, maxJobRuns)
+	if err != nil {
+		cfg.respondWithError(w, http.StatusBadRequest, "Invalid limit", err)
+		return
+	}
+
+	if city := r.URL.Query().Get("city"); city != "" {
+		cfg.respondWithLocationJobRuns(w, r, city, job, limit)
+		return
+	}
+
+	runs, err := cfg.dbQueries.ListJobRuns(r.Context(), database.ListJobRunsParams{JobName: job, RowLimit: int32(limit)})
+	if err != nil {
+		cfg.respondWithError(w, http.StatusInternalServerError, "Failed to get job runs", err)
+		return
+	}
+	response := JobRunsResponse{Runs: make([]JobRunJSON, 0, len(runs))}
+	for _, run := range runs {
+		response.Runs = append(response.Runs, jobRunToJSON(run))
+	}
+	cfg.respondWithJSON(w, http.StatusOK, response)
+}
+
+// respondWithLocationJobRuns responds with the most recent queued updates of a location.
+func (cfg *apiConfig) respondWithLocationJobRuns(w http.ResponseWriter, r *http.Request, city string, job sql.NullString, limit int) {
+	dbLocation, err := cfg.findLocation(r.Context(), city)
+	if err == sql.ErrNoRows {
+		cfg.respondWithError(w, http.StatusNotFound, "Location not found", nil)
+		return
+	}
+	if err != nil {
+		cfg.respondWithError(w, http.StatusInternalServerError, "Failed to get location", err)
+		return
+	}
+
+	updates, err := cfg.dbQueries.ListJobRunLocationsForLocation(r.Context(), database.ListJobRunLocationsForLocationParams{
+		LocationID: dbLocation.ID,
+		JobName:    job,
+		RowLimit:   int32(limit),
+	})
+	if err != nil {
+		cfg.respondWithError(w, http.StatusInternalServerError, "Failed to get job runs", err)
+		return
+	}
+
+	response := JobRunsResponse{
+		LocationID: dbLocation.ID.String(),
+		CityName:   dbLocation.CityName,
+		Updates:    make([]JobRunLocationJSON, 0, len(updates)),
+	}
+	for _, u := range updates {
+		response.Updates = append(response.Updates, JobRunLocationJSON{
+			RunID:      u.JobRunID.String(),
+			Job:        u.JobName,
+			LocationID: u.LocationID.String(),
+			Status:     u.Status,
+			QueuedAt:   u.QueuedAt.UTC().Format(time.RFC3339),
+			StartedAt:  formatNullTime(u.StartedAt),
+			FinishedAt: formatNullTime(u.FinishedAt),
+			DurationMs: u.DurationMs.Int64,
+			Error:      u.Error.String,
+		})
+	}
+	cfg.respondWithJSON(w, http.StatusOK, response)
+}
+
+// @Summary      Get a scheduler job run
+// @Description  Returns a scheduler job run with the queued update of every location: its status (queued,
+// @Description  running, succeeded, failed, skipped or interrupted), queue and start times, duration and error.
+// @Tags         admin
+// @Produce      json
+// @Param        id   path      string  true  "Job run ID"
+// @Success      200  {object}  JobRunJSON
+// @Failure      400  {object}  ErrorResponse "Bad Request - Invalid job run ID"
+// @Failure      404  {object}  ErrorResponse "Not Found - Job run does not exist"
+// @Failure      500  {object}  ErrorResponse "Internal Server Error - Failed to get job run"
+// @Security     ApiKeyAuth
+// @Failure      401  {object}  ErrorResponse "Unauthorized - Missing API key"
+// @Failure      403  {object}  ErrorResponse "Forbidden - Invalid API key"
+// @Router       /admin/jobs/{id} [get]
+func (cfg *apiConfig) handlerJobRun(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		cfg.respondWithError(w, http.StatusMethodNotAllowed, "Method Not Allowed", nil)
+		return
+	}
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		cfg.respondWithError(w, http.StatusBadRequest, "Invalid job run ID", err)
+		return
+	}
+
+	run, err := cfg.dbQueries.GetJobRun(r.Context(), id)
+	if err == sql.ErrNoRows {
+		cfg.respondWithError(w, http.StatusNotFound, "Job run not found", nil)
+		return
+	}
+	if err != nil {
+		cfg.respondWithError(w, http.StatusInternalServerError, "Failed to get job run", err)
+		return
+	}
+	locations, err := cfg.dbQueries.ListJobRunLocations(r.Context(), id)
+	if err != nil {
+		cfg.respondWithError(w, http.StatusInternalServerError, "Failed to get job run", fmt.Errorf("failed to list locations: %w", err))
+		return
+	}
+
+	response := jobRunToJSON(run)
+	response.Locations = make([]JobRunLocationJSON, 0, len(locations))
+	for _, l := range locations {
+		response.Locations = append(response.Locations, JobRunLocationJSON{
+			LocationID: l.LocationID.String(),
+			CityName:   l.CityName,
+			Status:     l.Status,
+			QueuedAt:   l.QueuedAt.UTC().Format(time.RFC3339),
+			StartedAt:  formatNullTime(l.StartedAt),
+			FinishedAt: formatNullTime(l.FinishedAt),
+			DurationMs: l.DurationMs.Int64,
+			Error:      l.Error.String,
+		})
+	}
+	cfg.respondWithJSON(w, http.StatusOK, response)
+}
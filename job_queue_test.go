@@ -0,0 +1,360 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cor0nius/willitrain/internal/database"
+	"github.com/google/uuid"
+)
+
+func TestRunJob_RecordsJobRun(t *testing.T) {
+	testCfg := newTestAPIConfig(t)
+	runID := uuid.New()
+	ok, failing, skipped := uuid.New(), uuid.New(), uuid.New()
+	testCfg.mockDB.ListLocationsFunc = func(ctx context.Context) ([]database.Location, error) {
+		return []database.Location{
+			{ID: ok, CityName: "Ok"},
+			{ID: failing, CityName: "Failing"},
+			{ID: skipped, CityName: "Skipped"},
+		}, nil
+	}
+	testCfg.mockDB.CreateJobRunFunc = func(ctx context.Context, arg database.CreateJobRunParams) (database.JobRun, error) {
+		return database.JobRun{ID: runID, JobName: arg.JobName, Status: jobStatusRunning, StartedAt: arg.StartedAt}, nil
+	}
+	var mu sync.Mutex
+	queued := make(map[uuid.UUID]bool)
+	started := make(map[uuid.UUID]bool)
+	outcomes := make(map[uuid.UUID]database.FinishJobRunLocationParams)
+	testCfg.mockDB.CreateJobRunLocationFunc = func(ctx context.Context, arg database.CreateJobRunLocationParams) error {
+		mu.Lock()
+		defer mu.Unlock()
+		queued[arg.LocationID] = arg.JobRunID == runID
+		return nil
+	}
+	testCfg.mockDB.StartJobRunLocationFunc = func(ctx context.Context, arg database.StartJobRunLocationParams) error {
+		mu.Lock()
+		defer mu.Unlock()
+		started[arg.LocationID] = arg.StartedAt.Valid
+		return nil
+	}
+	testCfg.mockDB.FinishJobRunLocationFunc = func(ctx context.Context, arg database.FinishJobRunLocationParams) error {
+		mu.Lock()
+		defer mu.Unlock()
+		outcomes[arg.LocationID] = arg
+		return nil
+	}
+	var finished database.FinishJobRunParams
+	testCfg.mockDB.FinishJobRunFunc = func(ctx context.Context, arg database.FinishJobRunParams) error {
+		finished = arg
+		return nil
+	}
+	var prunedBefore time.Time
+	testCfg.mockDB.DeleteJobRunsBeforeFunc = func(ctx context.Context, startedAt time.Time) (int64, error) {
+		prunedBefore = startedAt
+		return 0, nil
+	}
+
+	s := NewScheduler(testCfg.apiConfig)
+	s.concurrency = 2
+	updateErr := errors.New("provider down")
+	job := &scheduledJob{SchedulerJob: SchedulerJob{Name: "queue job", Interval: time.Hour, Run: func(ctx context.Context) error {
+		return s.runUpdateForLocations(ctx, "queue job", func(ctx context.Context, location Location) error {
+			switch location.LocationID {
+			case failing:
+				return updateErr
+			case skipped:
+				return errUpdateSkipped
+			}
+			return nil
+		})
+	}}}
+	s.jobs = append(s.jobs, job)
+
+	s.runJob(job)
+
+	if len(queued) != 3 || !queued[ok] || !queued[failing] || !queued[skipped] {
+		t.Errorf("expected every location to be queued with the run, got %v", queued)
+	}
+	if len(started) != 3 {
+		t.Errorf("expected every location to be started, got %v", started)
+	}
+	if got := outcomes[ok]; got.Status != jobStatusSucceeded || got.Error.Valid || !got.FinishedAt.Valid {
+		t.Errorf("expected a succeeded update, got %+v", got)
+	}
+	if got := outcomes[failing]; got.Status != jobStatusFailed || got.Error.String != updateErr.Error() {
+		t.Errorf("expected a failed update with its error, got %+v", got)
+	}
+	if got := outcomes[skipped]; got.Status != jobStatusSkipped || got.Error.Valid {
+		t.Errorf("expected a skipped update, got %+v", got)
+	}
+	if finished.ID != runID || finished.Status != jobStatusSucceeded || !finished.DurationMs.Valid {
+		t.Errorf("expected the run to be recorded as succeeded, got %+v", finished)
+	}
+	if finished.LocationsTotal != 3 || finished.LocationsSucceeded != 1 || finished.LocationsFailed != 1 || finished.LocationsSkipped != 1 {
+		t.Errorf("unexpected location counts: %+v", finished)
+	}
+	if prunedBefore.IsZero() || time.Since(prunedBefore) < jobRunRetention {
+		t.Errorf("expected runs older than the retention to be pruned, pruned before %v", prunedBefore)
+	}
+	if job.run != nil {
+		t.Error("expected the active run to be cleared after the run")
+	}
+}
+
+func TestRunJob_RecordsFailedRun(t *testing.T) {
+	testCfg := newTestAPIConfig(t)
+	var finished database.FinishJobRunParams
+	testCfg.mockDB.FinishJobRunFunc = func(ctx context.Context, arg database.FinishJobRunParams) error {
+		finished = arg
+		return nil
+	}
+
+	s := NewScheduler(testCfg.apiConfig)
+	job := &scheduledJob{SchedulerJob: SchedulerJob{Name: "failing job", Interval: time.Hour, Run: func(ctx context.Context) error {
+		return errors.New("flush failed")
+	}}}
+	s.jobs = append(s.jobs, job)
+
+	s.runJob(job)
+
+	if finished.Status != jobStatusFailed || finished.Error.String != "flush failed" || finished.LocationsTotal != 0 {
+		t.Errorf("expected the run to be recorded as failed, got %+v", finished)
+	}
+}
+
+func TestRunJob_JobRunNotRecorded(t *testing.T) {
+	testCfg := newTestAPIConfig(t)
+	testCfg.mockDB.CreateJobRunFunc = func(ctx context.Context, arg database.CreateJobRunParams) (database.JobRun, error) {
+		return database.JobRun{}, errors.New("db down")
+	}
+	testCfg.mockDB.ListLocationsFunc = func(ctx context.Context) ([]database.Location, error) {
+		return []database.Location{{ID: uuid.New(), CityName: "City"}}, nil
+	}
+
+	s := NewScheduler(testCfg.apiConfig)
+	var updated bool
+	job := &scheduledJob{SchedulerJob: SchedulerJob{Name: "unrecorded job", Interval: time.Hour, Run: func(ctx context.Context) error {
+		return s.runUpdateForLocations(ctx, "unrecorded job", func(ctx context.Context, location Location) error {
+			updated = true
+			return nil
+		})
+	}}}
+	s.jobs = append(s.jobs, job)
+
+	s.runJob(job)
+
+	if !updated {
+		t.Error("expected the location to be updated although the run could not be recorded")
+	}
+	for _, query := range []string{"CreateJobRunLocation", "StartJobRunLocation", "FinishJobRunLocation", "FinishJobRun"} {
+		if n := testCfg.mockDB.Calls(query); n != 0 {
+			t.Errorf("expected no calls to %s, got %d", query, n)
+		}
+	}
+}
+
+func TestHandlerJobRuns(t *testing.T) {
+	locationID := uuid.New()
+	runID := uuid.New()
+	startedAt := time.Date(2025, 8, 4, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name        string
+		method      string
+		query       string
+		setup       func(cfg *testAPIConfig)
+		wantStatus  int
+		wantRuns    int
+		wantUpdates int
+	}{
+		{name: "method not allowed", method: http.MethodPost, wantStatus: http.StatusMethodNotAllowed},
+		{name: "invalid limit", method: http.MethodGet, query: "?limit=1000", wantStatus: http.StatusBadRequest},
+		{
+			name:   "recent runs of a job",
+			method: http.MethodGet,
+			query:  "?job=current+weather&limit=5",
+			setup: func(cfg *testAPIConfig) {
+				cfg.mockDB.ListJobRunsFunc = func(ctx context.Context, arg database.ListJobRunsParams) ([]database.JobRun, error) {
+					if arg.JobName.String != currentWeatherJobName || arg.RowLimit != 5 {
+						t.Errorf("unexpected params %+v", arg)
+					}
+					return []database.JobRun{
+						{ID: runID, JobName: currentWeatherJobName, Status: jobStatusRunning, StartedAt: startedAt, LocationsTotal: 3},
+						{ID: uuid.New(), JobName: currentWeatherJobName, Status: jobStatusFailed, StartedAt: startedAt.Add(-time.Hour), Error: sql.NullString{String: "failed to list locations", Valid: true}},
+					}, nil
+				}
+			},
+			wantStatus: http.StatusOK,
+			wantRuns:   2,
+		},
+		{
+			name:   "location not found",
+			method: http.MethodGet,
+			query:  "?city=Atlantis",
+			setup: func(cfg *testAPIConfig) {
+				cfg.mockDB.GetLocationByAliasFunc = func(ctx context.Context, alias string) (database.Location, error) {
+					return database.Location{}, sql.ErrNoRows
+				}
+				cfg.mockDB.GetLocationByNameFunc = func(ctx context.Context, cityName string) (database.Location, error) {
+					return database.Location{}, sql.ErrNoRows
+				}
+			},
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:   "recent updates of a location",
+			method: http.MethodGet,
+			query:  "?city=Wroclaw",
+			setup: func(cfg *testAPIConfig) {
+				cfg.mockDB.GetLocationByAliasFunc = func(ctx context.Context, alias string) (database.Location, error) {
+					return database.Location{ID: locationID, CityName: "Wrocław"}, nil
+				}
+				cfg.mockDB.ListJobRunLocationsForLocationFunc = func(ctx context.Context, arg database.ListJobRunLocationsForLocationParams) ([]database.ListJobRunLocationsForLocationRow, error) {
+					if arg.LocationID != locationID || arg.JobName.Valid || arg.RowLimit != defaultJobRuns {
+						t.Errorf("unexpected params %+v", arg)
+					}
+					return []database.ListJobRunLocationsForLocationRow{
+						{JobRunID: runID, LocationID: locationID, JobName: hourlyForecastJobName, Status: jobStatusQueued, QueuedAt: startedAt},
+					}, nil
+				}
+			},
+			wantStatus:  http.StatusOK,
+			wantUpdates: 1,
+		},
+		{
+			name:   "database error",
+			method: http.MethodGet,
+			setup: func(cfg *testAPIConfig) {
+				cfg.mockDB.ListJobRunsFunc = func(ctx context.Context, arg database.ListJobRunsParams) ([]database.JobRun, error) {
+					return nil, errors.New("db down")
+				}
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testCfg := newTestAPIConfig(t)
+			if tc.setup != nil {
+				tc.setup(testCfg)
+			}
+
+			req := httptest.NewRequest(tc.method, "/admin/jobs"+tc.query, nil)
+			rr := httptest.NewRecorder()
+			testCfg.handlerJobRuns(rr, req)
+
+			if rr.Code != tc.wantStatus {
+				t.Fatalf("expected status %d, got %d: %s", tc.wantStatus, rr.Code, rr.Body.String())
+			}
+			if tc.wantStatus != http.StatusOK {
+				return
+			}
+			var response JobRunsResponse
+			if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if len(response.Runs) != tc.wantRuns || len(response.Updates) != tc.wantUpdates {
+				t.Fatalf("expected %d runs and %d updates, got %+v", tc.wantRuns, tc.wantUpdates, response)
+			}
+			if tc.wantRuns > 0 {
+				if first := response.Runs[0]; first.ID != runID.String() || first.FinishedAt != "" || first.LocationsTotal != 3 {
+					t.Errorf("unexpected running run: %+v", first)
+				}
+			}
+			if tc.wantUpdates > 0 {
+				if response.CityName != "Wrocław" || response.Updates[0].RunID != runID.String() || response.Updates[0].Job != hourlyForecastJobName {
+					t.Errorf("unexpected updates: %+v", response)
+				}
+			}
+		})
+	}
+}
+
+func TestHandlerJobRun(t *testing.T) {
+	runID := uuid.New()
+	startedAt := time.Date(2025, 8, 4, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name       string
+		id         string
+		setup      func(cfg *testAPIConfig)
+		wantStatus int
+	}{
+		{name: "invalid ID", id: "not-a-uuid", wantStatus: http.StatusBadRequest},
+		{
+			name: "run not found",
+			id:   runID.String(),
+			setup: func(cfg *testAPIConfig) {
+				cfg.mockDB.GetJobRunFunc = func(ctx context.Context, id uuid.UUID) (database.JobRun, error) {
+					return database.JobRun{}, sql.ErrNoRows
+				}
+			},
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name: "run with locations",
+			id:   runID.String(),
+			setup: func(cfg *testAPIConfig) {
+				cfg.mockDB.GetJobRunFunc = func(ctx context.Context, id uuid.UUID) (database.JobRun, error) {
+					return database.JobRun{
+						ID:              id,
+						JobName:         dailyForecastJobName,
+						Status:          jobStatusSucceeded,
+						StartedAt:       startedAt,
+						FinishedAt:      sql.NullTime{Time: startedAt.Add(time.Minute), Valid: true},
+						DurationMs:      sql.NullInt64{Int64: 60000, Valid: true},
+						LocationsTotal:  2,
+						LocationsFailed: 1,
+					}, nil
+				}
+				cfg.mockDB.ListJobRunLocationsFunc = func(ctx context.Context, jobRunID uuid.UUID) ([]database.ListJobRunLocationsRow, error) {
+					return []database.ListJobRunLocationsRow{
+						{JobRunID: jobRunID, LocationID: uuid.New(), CityName: "Berlin", Status: jobStatusSucceeded, QueuedAt: startedAt},
+						{JobRunID: jobRunID, LocationID: uuid.New(), CityName: "Wrocław", Status: jobStatusFailed, QueuedAt: startedAt, Error: sql.NullString{String: "context deadline exceeded", Valid: true}},
+					}, nil
+				}
+			},
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testCfg := newTestAPIConfig(t)
+			if tc.setup != nil {
+				tc.setup(testCfg)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/admin/jobs/"+tc.id, nil)
+			req.SetPathValue("id", tc.id)
+			rr := httptest.NewRecorder()
+			testCfg.handlerJobRun(rr, req)
+
+			if rr.Code != tc.wantStatus {
+				t.Fatalf("expected status %d, got %d: %s", tc.wantStatus, rr.Code, rr.Body.String())
+			}
+			if tc.wantStatus != http.StatusOK {
+				return
+			}
+			var response JobRunJSON
+			if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if response.ID != runID.String() || response.DurationMs != 60000 || response.FinishedAt == "" || len(response.Locations) != 2 {
+				t.Fatalf("unexpected run: %+v", response)
+			}
+			if failed := response.Locations[1]; failed.CityName != "Wrocław" || failed.Status != jobStatusFailed || failed.Error == "" {
+				t.Errorf("unexpected failed location: %+v", failed)
+			}
+		})
+	}
+}
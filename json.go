@@ -12,29 +12,81 @@ import (
 // respondWithError standardizes error responses. It logs the actual error for
 // server-side debugging while sending a clean, structured JSON error message to the
 // client. This prevents exposing internal implementation details in error messages.
+// The request ID is included in both, so that an error reported by a user can be found
+// in the logs. The message is logged in English and sent in the locale of the request.
 func (cfg *apiConfig) respondWithError(w http.ResponseWriter, code int, msg string, err error) {
+	requestID := responseRequestID(w)
 	if err != nil {
-		cfg.logger.Error(msg, "error", err)
+		if requestID != "" {
+			cfg.logger.Error(msg, "error", err, "request_id", requestID)
+		} else {
+			cfg.logger.Error(msg, "error", err)
+		}
+	}
+	if locale := responseLocale(w); locale != nil {
+		msg = locale.message(msg)
 	}
 	cfg.respondWithJSON(w, code, ErrorResponse{
-		Error: msg,
+		Error:     msg,
+		RequestID: requestID,
 	})
 }
 
 // respondWithJSON handles the serialization and transmission of all successful JSON
 // responses. It ensures that the correct HTTP status code and `Content-Type`
-// header are set, providing a consistent and reliable response format.
+// header are set, providing a consistent and reliable response format. Field names are
+// converted to camelCase if the request asked for it (see namingMiddleware), list entries
+// are reduced to the fields the request selected (see fieldsMiddleware), and condition texts
+// are translated to the locale of the request (see localeMiddleware).
 func (cfg *apiConfig) respondWithJSON(w http.ResponseWriter, code int, payload any) {
+	data, ok := cfg.encodeJSON(w, payload)
+	if !ok {
+		return
+	}
+	w.WriteHeader(code)
+	cfg.writeBody(w, data)
+}
+
+// encodeJSON serializes a payload the way respondWithJSON sends it and sets the Content-Type
+// header. If serialization fails, it logs the error, responds with 500 and returns false.
+func (cfg *apiConfig) encodeJSON(w http.ResponseWriter, payload any) ([]byte, bool) {
 	w.Header().Set("Content-Type", "application/json")
 	data, err := json.Marshal(payload)
 	if err != nil {
 		cfg.logger.Error("error marshalling JSON", "error", err)
 		w.WriteHeader(500)
-		return
+		return nil, false
 	}
-	w.WriteHeader(code)
-	_, err = w.Write(data)
-	if err != nil {
+	if locale := responseLocale(w); locale != nil {
+		data, err = localizeJSON(data, locale)
+		if err != nil {
+			cfg.logger.Error("error localizing JSON", "error", err)
+			w.WriteHeader(500)
+			return nil, false
+		}
+	}
+	if fields := responseFields(w); fields != nil {
+		data, err = projectFields(data, fields)
+		if err != nil {
+			cfg.logger.Error("error selecting JSON fields", "error", err)
+			w.WriteHeader(500)
+			return nil, false
+		}
+	}
+	if responseNaming(w) == namingCamel {
+		data, err = camelCaseKeys(data)
+		if err != nil {
+			cfg.logger.Error("error converting JSON field names", "error", err)
+			w.WriteHeader(500)
+			return nil, false
+		}
+	}
+	return data, true
+}
+
+// writeBody writes an encoded response body, logging write errors.
+func (cfg *apiConfig) writeBody(w http.ResponseWriter, data []byte) {
+	if _, err := w.Write(data); err != nil {
 		cfg.logger.Error("error writing response", "error", err)
 	}
 }
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// This file implements the in-process cache tier. A small LRU cache with a short TTL is
+// consulted before Redis, so that requests for the most popular cities are served without a
+// Redis round trip. Entries written while Redis is unavailable are still kept in memory, which
+// keeps those cities cached through a brief Redis outage.
+//
+// Every instance has its own local cache, so an entry invalidated in Redis, for example through
+// /admin/cache/purge, may be served by other instances until its local copy expires. The local
+// TTL bounds that delay and is kept short for this reason.
+
+// Defaults for the local cache. A size of 0 disables it.
+const (
+	defaultLocalCacheSize = 1000
+	defaultLocalCacheTTL  = 30 * time.Second
+)
+
+// getLocalCacheSize reads the maximum number of local cache entries from LOCAL_CACHE_SIZE.
+func getLocalCacheSize(logger *slog.Logger) int {
+	size := getEnvAsInt("LOCAL_CACHE_SIZE", defaultLocalCacheSize, logger)
+	if size < 0 {
+		logger.Warn("LOCAL_CACHE_SIZE must not be negative, using default", "value", size)
+		return defaultLocalCacheSize
+	}
+	return size
+}
+
+// getLocalCacheTTL reads the lifetime of local cache entries from LOCAL_CACHE_TTL_SEC.
+func getLocalCacheTTL(logger *slog.Logger) time.Duration {
+	sec := getEnvAsInt("LOCAL_CACHE_TTL_SEC", int(defaultLocalCacheTTL.Seconds()), logger)
+	if sec <= 0 {
+		logger.Warn("LOCAL_CACHE_TTL_SEC must be positive, using default", "value", sec)
+		return defaultLocalCacheTTL
+	}
+	return time.Duration(sec) * time.Second
+}
+
+// lruCache is a fixed-size in-memory cache that evicts the least recently used entry when full.
+// Entries also expire after the cache's TTL, or earlier if they were stored with a shorter
+// expiration. Values are stored as the JSON strings the Redis cache returns.
+type lruCache struct {
+	mu      sync.Mutex
+	size    int
+	ttl     time.Duration
+	now     func() time.Time
+	order   *list.List // Front is the most recently used entry.
+	entries map[string]*list.Element
+}
+
+type lruEntry struct {
+	key       string
+	value     string
+	expiresAt time.Time
+}
+
+func newLRUCache(size int, ttl time.Duration) *lruCache {
+	return &lruCache{
+		size:    size,
+		ttl:     ttl,
+		now:     time.Now,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// get returns the value of a key that is present and not expired.
+func (c *lruCache) get(key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[key]
+	if !ok {
+		return "", false
+	}
+	entry := elem.Value.(*lruEntry)
+	if !c.now().Before(entry.expiresAt) {
+		c.removeElement(elem)
+		return "", false
+	}
+	c.order.MoveToFront(elem)
+	return entry.value, true
+}
+
+// set stores a value for the cache's TTL, or for expiration if it is shorter. An expiration of
+// 0 means the entry has none of its own.
+func (c *lruCache) set(key, value string, expiration time.Duration) {
+	ttl := c.ttl
+	if expiration > 0 && expiration < ttl {
+		ttl = expiration
+	}
+	expiresAt := c.now().Add(ttl)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[key]; ok {
+		entry := elem.Value.(*lruEntry)
+		entry.value = value
+		entry.expiresAt = expiresAt
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&lruEntry{key: key, value: value, expiresAt: expiresAt})
+	for c.order.Len() > c.size {
+		c.removeElement(c.order.Back())
+		localCacheEvictions.Inc()
+	}
+}
+
+// delete removes the given keys.
+func (c *lruCache) delete(keys ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, key := range keys {
+		if elem, ok := c.entries[key]; ok {
+			c.removeElement(elem)
+		}
+	}
+}
+
+// flush removes all entries.
+func (c *lruCache) flush() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.order.Init()
+	clear(c.entries)
+}
+
+// removeElement removes an entry. The caller must hold c.mu.
+func (c *lruCache) removeElement(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.entries, elem.Value.(*lruEntry).key)
+}
+
+// tieredCache is a Cache that keeps recently used entries in a local LRU cache in front of
+// another Cache, normally Redis. Reads are answered locally when possible, and writes and
+// deletions go to both tiers. Scan and TTL report the state of the remote tier.
+type tieredCache struct {
+	local  *lruCache
+	remote Cache
+}
+
+func newTieredCache(local *lruCache, remote Cache) *tieredCache {
+	return &tieredCache{local: local, remote: remote}
+}
+
+// Get returns a local entry if there is one, and otherwise reads the remote cache and keeps the
+// value locally.
+func (c *tieredCache) Get(ctx context.Context, key string) (string, error) {
+	if val, ok := c.local.get(key); ok {
+		localCacheLookups.WithLabelValues("hit").Inc()
+		return val, nil
+	}
+	localCacheLookups.WithLabelValues("miss").Inc()
+	val, err := c.remote.Get(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	c.local.set(key, val, 0)
+	return val, nil
+}
+
+// Set stores the value in both tiers. The local entry is kept even if the remote write fails, so
+// that the value can still be served while the remote cache is unavailable.
+func (c *tieredCache) Set(ctx context.Context, key string, value any, expiration time.Duration) error {
+	p, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	c.local.set(key, string(p), expiration)
+	return c.remote.Set(ctx, key, json.RawMessage(p), expiration)
+}
+
+// Flush removes all entries from both tiers.
+func (c *tieredCache) Flush(ctx context.Context) error {
+	c.local.flush()
+	return c.remote.Flush(ctx)
+}
+
+// Delete removes the given keys from both tiers.
+func (c *tieredCache) Delete(ctx context.Context, keys ...string) error {
+	c.local.delete(keys...)
+	return c.remote.Delete(ctx, keys...)
+}
+
+// Scan pages through the keys of the remote cache.
+func (c *tieredCache) Scan(ctx context.Context, cursor uint64, match string, count int64) ([]string, uint64, error) {
+	return c.remote.Scan(ctx, cursor, match, count)
+}
+
+// TTL returns the remaining time to live of a key in the remote cache.
+func (c *tieredCache) TTL(ctx context.Context, key string) (time.Duration, error) {
+	return c.remote.TTL(ctx, key)
+}
+
+// Incr increments a counter in the remote cache only, so that all instances count together.
+// A local copy of the key is dropped.
+func (c *tieredCache) Incr(ctx context.Context, key string, expiration time.Duration) (int64, error) {
+	c.local.delete(key)
+	return c.remote.Incr(ctx, key, expiration)
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cor0nius/willitrain/internal/testkit"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestLRUCache(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	c := newLRUCache(2, time.Minute)
+	c.now = func() time.Time { return now }
+
+	c.set("a", "1", 0)
+	c.set("b", "2", 0)
+	if _, ok := c.get("a"); !ok { // a is now the most recently used entry.
+		t.Fatal("expected a to be cached")
+	}
+	evictions := testutil.ToFloat64(localCacheEvictions)
+	c.set("c", "3", 0)
+	if _, ok := c.get("b"); ok {
+		t.Error("expected the least recently used entry to be evicted")
+	}
+	if got := testutil.ToFloat64(localCacheEvictions) - evictions; got != 1 {
+		t.Errorf("expected 1 eviction, got %v", got)
+	}
+	if val, ok := c.get("a"); !ok || val != "1" {
+		t.Errorf("expected a = 1, got %q, %v", val, ok)
+	}
+
+	c.set("short", "4", 10*time.Second)
+	now = now.Add(10 * time.Second)
+	if _, ok := c.get("short"); ok {
+		t.Error("expected an entry to expire after its own expiration")
+	}
+	if _, ok := c.get("a"); !ok {
+		t.Error("expected an entry to be kept for the cache's TTL")
+	}
+	now = now.Add(time.Minute)
+	if _, ok := c.get("a"); ok {
+		t.Error("expected an entry to expire after the cache's TTL")
+	}
+
+	c.set("d", "5", 0)
+	c.delete("d")
+	if _, ok := c.get("d"); ok {
+		t.Error("expected a deleted entry to be gone")
+	}
+	c.set("e", "6", 0)
+	c.flush()
+	if _, ok := c.get("e"); ok {
+		t.Error("expected a flushed entry to be gone")
+	}
+}
+
+func TestTieredCache(t *testing.T) {
+	ctx := context.Background()
+	remote := testkit.NewMemoryCache()
+	var remoteGets int
+	remoteGet := remote.GetFunc
+	remote.GetFunc = func(ctx context.Context, key string) (string, error) {
+		remoteGets++
+		return remoteGet(ctx, key)
+	}
+	c := newTieredCache(newLRUCache(10, time.Minute), remote)
+
+	// A value read from the remote cache is kept locally.
+	_ = remote.Set(ctx, "k", []int{1, 2}, 0)
+	hits := testutil.ToFloat64(localCacheLookups.WithLabelValues("hit"))
+	for range 2 {
+		val, err := c.Get(ctx, "k")
+		if err != nil || val != "[1,2]" {
+			t.Fatalf("Get = %q, %v", val, err)
+		}
+	}
+	if remoteGets != 1 {
+		t.Errorf("expected 1 remote read, got %d", remoteGets)
+	}
+	if got := testutil.ToFloat64(localCacheLookups.WithLabelValues("hit")) - hits; got != 1 {
+		t.Errorf("expected 1 local hit, got %v", got)
+	}
+
+	// A value written while the remote cache is unavailable is still served locally.
+	remote.SetFunc = func(ctx context.Context, key string, value any, expiration time.Duration) error {
+		return errCacheUnavailable
+	}
+	remote.GetFunc = func(ctx context.Context, key string) (string, error) {
+		return "", errCacheUnavailable
+	}
+	if err := c.Set(ctx, "outage", map[string]string{"a": "b"}, time.Minute); !errors.Is(err, errCacheUnavailable) {
+		t.Errorf("expected the remote error, got %v", err)
+	}
+	if val, err := c.Get(ctx, "outage"); err != nil || val != `{"a":"b"}` {
+		t.Errorf("Get = %q, %v", val, err)
+	}
+
+	// Deleted keys are removed from both tiers.
+	if err := c.Delete(ctx, "k", "outage"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Get(ctx, "outage"); !errors.Is(err, errCacheUnavailable) {
+		t.Errorf("expected a deleted key to be read from the remote cache, got %v", err)
+	}
+	remote.GetFunc = remoteGet
+	if _, err := c.Get(ctx, "k"); err != redis.Nil {
+		t.Errorf("expected a deleted key to be missing, got %v", err)
+	}
+
+	// Counters are kept in the remote cache, so a local copy never hides an increment.
+	if _, err := c.Incr(ctx, "counter", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if val, err := c.Get(ctx, "counter"); err != nil || val != "1" {
+		t.Fatalf("Get = %q, %v", val, err)
+	}
+	if n, err := c.Incr(ctx, "counter", time.Minute); err != nil || n != 2 {
+		t.Errorf("Incr = %d, %v; want 2", n, err)
+	}
+	if val, err := c.Get(ctx, "counter"); err != nil || val != "2" {
+		t.Errorf("expected the incremented counter, got %q, %v", val, err)
+	}
+}
@@ -0,0 +1,385 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// This file implements the localization of API responses. A locale bundle holds the
+// translations of one language: the descriptions of the Open-Meteo weather codes, the labels
+// of the consensus condition codes and the error messages sent to clients. The locale of a
+// request is selected with the lang query parameter or, without it, from the Accept-Language
+// header. Weather data is stored and cached in English and translated when it is sent, so
+// responses in every locale are served from the same data.
+
+// localeBundles are the supported locales by language code.
+var localeBundles = map[string]*localeBundle{
+	"en": englishLocale,
+	"pl": polishLocale,
+	"de": germanLocale,
+}
+
+// defaultLocale is used if neither the request nor its Accept-Language header select a
+// supported locale.
+const defaultLocale = "en"
+
+var errInvalidLocale = errors.New("lang must be one of en, pl or de")
+
+// localeBundle holds the translations of a locale. Messages are looked up by their English
+// text; messages without a translation are sent in English.
+type localeBundle struct {
+	// weatherCodes describes the WMO weather codes reported by Open-Meteo.
+	weatherCodes map[int]string
+	// unknownWeatherCode describes weather codes missing from weatherCodes.
+	unknownWeatherCode string
+	// conditions labels the normalized condition codes. English labels are those of
+	// conditionDisplays.
+	conditions map[string]string
+	// messages translates error messages.
+	messages map[string]string
+}
+
+// weatherDescription returns the description of a WMO weather code.
+func (b *localeBundle) weatherDescription(code int) string {
+	if description, ok := b.weatherCodes[code]; ok {
+		return description
+	}
+	return b.unknownWeatherCode
+}
+
+// conditionText translates a condition text stored in English. Texts that are not descriptions
+// of an Open-Meteo weather code are the wording of other providers and are returned unchanged.
+func (b *localeBundle) conditionText(text string) string {
+	if text == englishLocale.unknownWeatherCode {
+		return b.unknownWeatherCode
+	}
+	if code, ok := englishWeatherCodes[text]; ok {
+		return b.weatherDescription(code)
+	}
+	return text
+}
+
+// conditionLabel returns the label of a normalized condition code.
+func (b *localeBundle) conditionLabel(code string) string {
+	if label, ok := b.conditions[code]; ok {
+		return label
+	}
+	if display, ok := conditionDisplays[code]; ok {
+		return display.Label
+	}
+	return conditionDisplays[conditionUnknown].Label
+}
+
+// message translates an error message, falling back to English.
+func (b *localeBundle) message(msg string) string {
+	if translated, ok := b.messages[msg]; ok {
+		return translated
+	}
+	return msg
+}
+
+var englishLocale = &localeBundle{
+	weatherCodes: map[int]string{
+		0:  "clear sky",
+		1:  "mainly clear",
+		2:  "partly cloudy",
+		3:  "overcast",
+		45: "fog",
+		48: "depositing rime fog",
+		51: "light drizzle",
+		53: "moderate drizzle",
+		55: "dense drizzle",
+		56: "light freezing drizzle",
+		57: "dense freezing drizzle",
+		61: "slight rain",
+		63: "moderate rain",
+		65: "heavy rain",
+		66: "light freezing rain",
+		67: "heavy freezing rain",
+		71: "slight snowfall",
+		73: "moderate snowfall",
+		75: "heavy snowfall",
+		77: "snow grains",
+		80: "slight showers",
+		81: "moderate showers",
+		82: "violent showers",
+		85: "slight snow showers",
+		86: "heavy snow showers",
+		95: "thunderstorm",
+		96: "thunderstorm with slight hail",
+		99: "thunderstorm with heavy hail",
+	},
+	unknownWeatherCode: "unknown code",
+}
+
+// englishWeatherCodes maps the English weather code descriptions back to their codes, so that
+// stored condition texts can be translated.
+var englishWeatherCodes = func() map[string]int {
+	codes := make(map[string]int, len(englishLocale.weatherCodes))
+	for code, description := range englishLocale.weatherCodes {
+		codes[description] = code
+	}
+	return codes
+}()
+
+var polishLocale = &localeBundle{
+	weatherCodes: map[int]string{
+		0:  "bezchmurnie",
+		1:  "przeważnie bezchmurnie",
+		2:  "częściowe zachmurzenie",
+		3:  "całkowite zachmurzenie",
+		45: "mgła",
+		48: "mgła osadzająca szadź",
+		51: "słaba mżawka",
+		53: "umiarkowana mżawka",
+		55: "gęsta mżawka",
+		56: "słaba marznąca mżawka",
+		57: "gęsta marznąca mżawka",
+		61: "słaby deszcz",
+		63: "umiarkowany deszcz",
+		65: "silny deszcz",
+		66: "słaby marznący deszcz",
+		67: "silny marznący deszcz",
+		71: "słaby opad śniegu",
+		73: "umiarkowany opad śniegu",
+		75: "silny opad śniegu",
+		77: "śnieg ziarnisty",
+		80: "słabe przelotne opady deszczu",
+		81: "umiarkowane przelotne opady deszczu",
+		82: "gwałtowne przelotne opady deszczu",
+		85: "słabe przelotne opady śniegu",
+		86: "silne przelotne opady śniegu",
+		95: "burza",
+		96: "burza ze słabym gradem",
+		99: "burza z silnym gradem",
+	},
+	unknownWeatherCode: "nieznany kod",
+	conditions: map[string]string{
+		conditionClear:        "Bezchmurnie",
+		conditionPartlyCloudy: "Częściowe zachmurzenie",
+		conditionCloudy:       "Pochmurno",
+		conditionFog:          "Mgła",
+		conditionDrizzle:      "Mżawka",
+		conditionShowers:      "Przelotne opady",
+		conditionRain:         "Deszcz",
+		conditionHeavyRain:    "Ulewa",
+		conditionThunderstorm: "Burza",
+		conditionSleet:        "Deszcz ze śniegiem",
+		conditionSnow:         "Śnieg",
+		conditionDry:          "Sucho",
+		conditionUnknown:      "Nieznane",
+	},
+	messages: map[string]string{
+		"Method Not Allowed":                      "Niedozwolona metoda",
+		"Invalid request body":                    "Nieprawidłowa treść żądania",
+		"API key required":                        "Wymagany klucz API",
+		"Invalid API key":                         "Nieprawidłowy klucz API",
+		"Failed to verify API key":                "Nie udało się zweryfikować klucza API",
+		"Daily API key quota exceeded":            "Wyczerpano dzienny limit klucza API",
+		"Cache is temporarily unavailable":        "Pamięć podręczna jest chwilowo niedostępna",
+		"Error getting location data":             "Błąd pobierania danych lokalizacji",
+		"Error getting current weather data":      "Błąd pobierania aktualnej pogody",
+		"Error getting hourly forecast data":      "Błąd pobierania prognozy godzinowej",
+		"Error getting daily forecast data":       "Błąd pobierania prognozy dziennej",
+		"Location not found":                      "Nie znaleziono lokalizacji",
+		"Invalid location ID":                     "Nieprawidłowy identyfikator lokalizacji",
+		"Failed to get location":                  "Nie udało się pobrać lokalizacji",
+		"city query parameter is required":        "Parametr city jest wymagany",
+		"Invalid limit":                           "Nieprawidłowy limit",
+		"Invalid cursor":                          "Nieprawidłowy kursor",
+		"Invalid from parameter":                  "Nieprawidłowy parametr from",
+		"Invalid to parameter":                    "Nieprawidłowy parametr to",
+		"from must be before to":                  "from musi być wcześniejsze niż to",
+		"city parameter is required":              "Parametr city jest wymagany",
+		"Invalid hours parameter":                 "Nieprawidłowy parametr hours",
+		"Invalid compare mode":                    "Nieprawidłowy tryb porównania",
+		"units must be either metric or imperial": "units musi mieć wartość metric lub imperial",
+		"naming must be either snake or camel":    "naming musi mieć wartość snake lub camel",
+		"lang must be one of en, pl or de":        "lang musi mieć wartość en, pl lub de",
+		"Invalid map size":                        "Nieprawidłowy rozmiar mapy",
+		"Invalid zoom":                            "Nieprawidłowe powiększenie",
+		"Error rendering map":                     "Błąd renderowania mapy",
+		"theme must be either light or dark":      "theme musi mieć wartość light lub dark",
+		"Error rendering widget":                  "Błąd renderowania widżetu",
+		"Not Found":                               "Nie znaleziono",
+	},
+}
+
+var germanLocale = &localeBundle{
+	weatherCodes: map[int]string{
+		0:  "klarer Himmel",
+		1:  "überwiegend klar",
+		2:  "teilweise bewölkt",
+		3:  "bedeckt",
+		45: "Nebel",
+		48: "Nebel mit Reifablagerung",
+		51: "leichter Nieselregen",
+		53: "mäßiger Nieselregen",
+		55: "starker Nieselregen",
+		56: "leichter gefrierender Nieselregen",
+		57: "starker gefrierender Nieselregen",
+		61: "leichter Regen",
+		63: "mäßiger Regen",
+		65: "starker Regen",
+		66: "leichter gefrierender Regen",
+		67: "starker gefrierender Regen",
+		71: "leichter Schneefall",
+		73: "mäßiger Schneefall",
+		75: "starker Schneefall",
+		77: "Schneegriesel",
+		80: "leichte Regenschauer",
+		81: "mäßige Regenschauer",
+		82: "heftige Regenschauer",
+		85: "leichte Schneeschauer",
+		86: "starke Schneeschauer",
+		95: "Gewitter",
+		96: "Gewitter mit leichtem Hagel",
+		99: "Gewitter mit starkem Hagel",
+	},
+	unknownWeatherCode: "unbekannter Code",
+	conditions: map[string]string{
+		conditionClear:        "Klar",
+		conditionPartlyCloudy: "Teilweise bewölkt",
+		conditionCloudy:       "Bewölkt",
+		conditionFog:          "Nebel",
+		conditionDrizzle:      "Nieselregen",
+		conditionShowers:      "Schauer",
+		conditionRain:         "Regen",
+		conditionHeavyRain:    "Starkregen",
+		conditionThunderstorm: "Gewitter",
+		conditionSleet:        "Schneeregen",
+		conditionSnow:         "Schnee",
+		conditionDry:          "Trocken",
+		conditionUnknown:      "Unbekannt",
+	},
+	messages: map[string]string{
+		"Method Not Allowed":                      "Methode nicht erlaubt",
+		"Invalid request body":                    "Ungültiger Anfrageinhalt",
+		"API key required":                        "API-Schlüssel erforderlich",
+		"Invalid API key":                         "Ungültiger API-Schlüssel",
+		"Failed to verify API key":                "API-Schlüssel konnte nicht überprüft werden",
+		"Daily API key quota exceeded":            "Tageskontingent des API-Schlüssels ausgeschöpft",
+		"Cache is temporarily unavailable":        "Der Cache ist vorübergehend nicht verfügbar",
+		"Error getting location data":             "Fehler beim Abrufen der Standortdaten",
+		"Error getting current weather data":      "Fehler beim Abrufen des aktuellen Wetters",
+		"Error getting hourly forecast data":      "Fehler beim Abrufen der stündlichen Vorhersage",
+		"Error getting daily forecast data":       "Fehler beim Abrufen der Tagesvorhersage",
+		"Location not found":                      "Standort nicht gefunden",
+		"Invalid location ID":                     "Ungültige Standort-ID",
+		"Failed to get location":                  "Standort konnte nicht abgerufen werden",
+		"city query parameter is required":        "Der Parameter city ist erforderlich",
+		"Invalid limit":                           "Ungültiges Limit",
+		"Invalid cursor":                          "Ungültiger Cursor",
+		"Invalid from parameter":                  "Ungültiger Parameter from",
+		"Invalid to parameter":                    "Ungültiger Parameter to",
+		"from must be before to":                  "from muss vor to liegen",
+		"city parameter is required":              "Der Parameter city ist erforderlich",
+		"Invalid hours parameter":                 "Ungültiger Parameter hours",
+		"Invalid compare mode":                    "Ungültiger Vergleichsmodus",
+		"units must be either metric or imperial": "units muss metric oder imperial sein",
+		"naming must be either snake or camel":    "naming muss snake oder camel sein",
+		"lang must be one of en, pl or de":        "lang muss en, pl oder de sein",
+		"Invalid map size":                        "Ungültige Kartengröße",
+		"Invalid zoom":                            "Ungültige Zoomstufe",
+		"Error rendering map":                     "Fehler beim Rendern der Karte",
+		"theme must be either light or dark":      "theme muss light oder dark sein",
+		"Error rendering widget":                  "Fehler beim Rendern des Widgets",
+		"Not Found":                               "Nicht gefunden",
+	},
+}
+
+// requestLocale returns the locale selected by the lang query parameter or, without it, the
+// preferred language of the session's user or the first supported language of the
+// Accept-Language header. It returns false if lang selects an unsupported locale.
+func requestLocale(r *http.Request) (string, bool) {
+	if lang := strings.ToLower(r.URL.Query().Get("lang")); lang != "" {
+		_, ok := localeBundles[lang]
+		return lang, ok
+	}
+	if user, ok := userFrom(r.Context()); ok && user.Language != "" {
+		return user.Language, true
+	}
+	tags, _, err := language.ParseAcceptLanguage(r.Header.Get("Accept-Language"))
+	if err != nil {
+		return defaultLocale, true
+	}
+	for _, tag := range tags {
+		base, _ := tag.Base()
+		if _, ok := localeBundles[base.String()]; ok {
+			return base.String(), true
+		}
+	}
+	return defaultLocale, true
+}
+
+// localeResponseWriter carries the locale of a request to respondWithJSON.
+type localeResponseWriter struct {
+	http.ResponseWriter
+	locale *localeBundle
+}
+
+// Hijack lets WebSocket handlers take over the connection.
+func (lw *localeResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(lw.ResponseWriter).Hijack()
+}
+
+// Unwrap returns the wrapped ResponseWriter.
+func (lw *localeResponseWriter) Unwrap() http.ResponseWriter {
+	return lw.ResponseWriter
+}
+
+// localeMiddleware determines the locale of a request and passes it on to the handler with the
+// ResponseWriter. Requests with an invalid lang parameter are rejected.
+func (cfg *apiConfig) localeMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Language")
+		locale, ok := requestLocale(r)
+		if !ok {
+			cfg.respondWithError(w, http.StatusBadRequest, errInvalidLocale.Error(), nil)
+			return
+		}
+		if locale == defaultLocale {
+			next.ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(&localeResponseWriter{ResponseWriter: w, locale: localeBundles[locale]}, r)
+	})
+}
+
+// responseLocale returns the locale attached to w by localeMiddleware, or nil for English.
+func responseLocale(w http.ResponseWriter) *localeBundle {
+	if lw, ok := findResponseWriter[*localeResponseWriter](w); ok {
+		return lw.locale
+	}
+	return nil
+}
+
+// localizeJSON translates the condition texts and consensus condition labels of a JSON document,
+// at any depth. Other values and the order of fields are left unchanged.
+func localizeJSON(data []byte, locale *localeBundle) ([]byte, error) {
+	return rewriteJSON(data, nil, func(key, value string) string {
+		switch key {
+		case "condition_text":
+			return locale.conditionText(value)
+		case "label":
+			if code, ok := englishConditionCodes[value]; ok {
+				return locale.conditionLabel(code)
+			}
+		}
+		return value
+	})
+}
+
+// englishConditionCodes maps the English condition labels back to their codes.
+var englishConditionCodes = func() map[string]string {
+	codes := make(map[string]string, len(conditionDisplays))
+	for code, display := range conditionDisplays {
+		codes[display.Label] = code
+	}
+	return codes
+}()
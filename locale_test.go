@@ -0,0 +1,121 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLocaleBundlesAreComplete(t *testing.T) {
+	for lang, locale := range localeBundles {
+		if locale == englishLocale {
+			continue
+		}
+		for code := range englishLocale.weatherCodes {
+			if locale.weatherCodes[code] == "" {
+				t.Errorf("%s: missing description of weather code %d", lang, code)
+			}
+		}
+		for code := range conditionDisplays {
+			if locale.conditions[code] == "" {
+				t.Errorf("%s: missing label of condition %s", lang, code)
+			}
+		}
+		for other, otherLocale := range localeBundles {
+			for msg := range otherLocale.messages {
+				if _, ok := locale.messages[msg]; !ok {
+					t.Errorf("%s: missing translation of %q, which %s has", lang, msg, other)
+				}
+			}
+		}
+		if _, ok := summaryLanguages[lang]; !ok {
+			t.Errorf("%s: missing summary templates", lang)
+		}
+	}
+}
+
+func TestRequestLocale(t *testing.T) {
+	testCases := []struct {
+		query, acceptLanguage string
+		want                  string
+		wantOK                bool
+	}{
+		{query: "", want: "en", wantOK: true},
+		{query: "?lang=PL", want: "pl", wantOK: true},
+		{query: "?lang=de", want: "de", wantOK: true},
+		{query: "?lang=fr", wantOK: false},
+		{query: "", acceptLanguage: "fr-FR, de;q=0.8, en;q=0.5", want: "de", wantOK: true},
+		{query: "?lang=en", acceptLanguage: "pl-PL", want: "en", wantOK: true},
+		{query: "", acceptLanguage: "fr", want: "en", wantOK: true},
+	}
+	for _, tc := range testCases {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/summary"+tc.query, nil)
+		r.Header.Set("Accept-Language", tc.acceptLanguage)
+		got, ok := requestLocale(r)
+		if ok != tc.wantOK || (ok && got != tc.want) {
+			t.Errorf("requestLocale(%q, %q) = %q, %v; want %q, %v", tc.query, tc.acceptLanguage, got, ok, tc.want, tc.wantOK)
+		}
+	}
+}
+
+func TestLocalizeJSON(t *testing.T) {
+	in := `{"weather":[{"source_api":"Open-Meteo API","condition_text":"slight rain"},{"source_api":"WeatherAPI","condition_text":"Patchy rain nearby"},{"condition_text":"unknown code"}],` +
+		`"hours":[{"condition":{"code":"heavy_rain","label":"Heavy rain","confidence":"high"}}],"sources":["label","condition_text"],"label":"Not a condition"}`
+	want := `{"weather":[{"source_api":"Open-Meteo API","condition_text":"słaby deszcz"},{"source_api":"WeatherAPI","condition_text":"Patchy rain nearby"},{"condition_text":"nieznany kod"}],` +
+		`"hours":[{"condition":{"code":"heavy_rain","label":"Ulewa","confidence":"high"}}],"sources":["label","condition_text"],"label":"Not a condition"}`
+
+	got, err := localizeJSON([]byte(in), polishLocale)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(got) != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestLocaleMiddleware(t *testing.T) {
+	testCfg := newTestAPIConfig(t)
+	cfg := testCfg.apiConfig
+
+	handler := cfg.localeMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/error" {
+			cfg.respondWithError(w, http.StatusNotFound, "Location not found", nil)
+			return
+		}
+		cfg.respondWithJSON(w, http.StatusOK, HourlyForecastJSON{Condition: "overcast"})
+	}))
+
+	testCases := []struct {
+		name           string
+		path           string
+		acceptLanguage string
+		wantStatus     int
+		wantBody       string
+	}{
+		{"english by default", "/api/v1/test", "", http.StatusOK, `"condition_text":"overcast"`},
+		{"lang parameter", "/api/v1/test?lang=de", "pl", http.StatusOK, `"condition_text":"bedeckt"`},
+		{"accept-language", "/api/v1/test", "pl-PL,pl;q=0.9", http.StatusOK, `"condition_text":"całkowite zachmurzenie"`},
+		{"localized error", "/api/v1/error?lang=de", "", http.StatusNotFound, `{"error":"Standort nicht gefunden"}`},
+		{"invalid lang", "/api/v1/test?lang=xx", "", http.StatusBadRequest, `{"error":"lang must be one of en, pl or de"}`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			req.Header.Set("Accept-Language", tc.acceptLanguage)
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != tc.wantStatus {
+				t.Errorf("status = %d, want %d", rr.Code, tc.wantStatus)
+			}
+			if !strings.Contains(rr.Body.String(), tc.wantBody) {
+				t.Errorf("body = %s, want it to contain %s", rr.Body.String(), tc.wantBody)
+			}
+			if rr.Header().Get("Vary") != "Accept-Language" {
+				t.Errorf("Vary = %q, want Accept-Language", rr.Header().Get("Vary"))
+			}
+		})
+	}
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/cor0nius/willitrain/internal/database"
+	"github.com/google/uuid"
+)
+
+// This file implements demand-driven scheduling. Every location is refreshed at a stride of
+// cycles that depends on how much it is requested:
+//
+//   - hot locations, the SCHEDULER_HOT_LOCATIONS most requested ones of the last day and every
+//     location on a watchlist or used by an alert rule, are refreshed on every cycle;
+//   - idle locations, not requested for LOCATION_IDLE_DAYS, every SCHEDULER_IDLE_STRIDE cycles;
+//   - all other locations every SCHEDULER_NORMAL_STRIDE cycles.
+//
+// With a normal stride above 1 and shorter intervals, popular locations are refreshed more often
+// than the rest for the same number of provider calls. Within a cycle, locations are updated in
+// order of their requests of the last day. Requests for a location that was not refreshed still
+// fetch fresh data once the stored data is outdated. Locations not requested for
+// LOCATION_EVICT_DAYS, and neither watched nor used by an alert rule, are deleted by the hourly
+// data retention job.
+
+const (
+	defaultLocationIdleDays      = 7
+	defaultSchedulerIdleStride   = 4
+	defaultSchedulerNormalStride = 1
+	defaultSchedulerHotLocations = 20
+
+	// locationDemandWindow is the window over which the requests of a location are counted.
+	locationDemandWindow = 24 * time.Hour
+)
+
+// Refresh tiers of a location.
+const (
+	refreshTierHot    = "hot"
+	refreshTierNormal = "normal"
+	refreshTierIdle   = "idle"
+)
+
+// getLocationIdleDays reads after how many days without requests a location is refreshed less
+// often from LOCATION_IDLE_DAYS. A value of 0 disables the demotion; negative values are ignored.
+func getLocationIdleDays(logger *slog.Logger) int {
+	n := getEnvAsInt("LOCATION_IDLE_DAYS", defaultLocationIdleDays, logger)
+	if n < 0 {
+		logger.Warn("LOCATION_IDLE_DAYS must not be negative, using default", "value", n)
+		return defaultLocationIdleDays
+	}
+	return n
+}
+
+// getLocationEvictDays reads after how many days without requests a location is deleted from
+// LOCATION_EVICT_DAYS. The default of 0 never deletes locations; negative values are ignored.
+func getLocationEvictDays(logger *slog.Logger) int {
+	n := getEnvAsInt("LOCATION_EVICT_DAYS", 0, logger)
+	if n < 0 {
+		logger.Warn("LOCATION_EVICT_DAYS must not be negative, using default", "value", n)
+		return 0
+	}
+	return n
+}
+
+// getSchedulerStride reads a refresh stride, in cycles, from the given variable. Values below 1
+// are ignored.
+func getSchedulerStride(key string, defaultValue int, logger *slog.Logger) int {
+	n := getEnvAsInt(key, defaultValue, logger)
+	if n < 1 {
+		logger.Warn(key+" must be at least 1, using default", "value", n)
+		return defaultValue
+	}
+	return n
+}
+
+// getSchedulerHotLocations reads the number of most requested locations refreshed on every cycle
+// from SCHEDULER_HOT_LOCATIONS. Negative values are ignored.
+func getSchedulerHotLocations(logger *slog.Logger) int {
+	n := getEnvAsInt("SCHEDULER_HOT_LOCATIONS", defaultSchedulerHotLocations, logger)
+	if n < 0 {
+		logger.Warn("SCHEDULER_HOT_LOCATIONS must not be negative, using default", "value", n)
+		return defaultSchedulerHotLocations
+	}
+	return n
+}
+
+// locationRefreshPolicy decides how often the scheduler refreshes a location and when it is
+// evicted. A nil policy is valid and refreshes every location on every cycle.
+type locationRefreshPolicy struct {
+	idleDays     int
+	evictDays    int
+	idleStride   int
+	normalStride int
+	hotLocations int
+}
+
+func newLocationRefreshPolicy(logger *slog.Logger) *locationRefreshPolicy {
+	p := &locationRefreshPolicy{
+		idleDays:     getLocationIdleDays(logger),
+		evictDays:    getLocationEvictDays(logger),
+		idleStride:   getSchedulerStride("SCHEDULER_IDLE_STRIDE", defaultSchedulerIdleStride, logger),
+		normalStride: getSchedulerStride("SCHEDULER_NORMAL_STRIDE", defaultSchedulerNormalStride, logger),
+		hotLocations: getSchedulerHotLocations(logger),
+	}
+	if p.evictDays > 0 && p.idleDays > 0 && p.evictDays <= p.idleDays {
+		logger.Warn("LOCATION_EVICT_DAYS is not above LOCATION_IDLE_DAYS, idle locations are evicted before they are demoted", "evict_days", p.evictDays, "idle_days", p.idleDays)
+	}
+	return p
+}
+
+// staggers reports whether some locations may be skipped in a cycle.
+func (p *locationRefreshPolicy) staggers() bool {
+	return p != nil && ((p.idleDays > 0 && p.idleStride > 1) || p.normalStride > 1)
+}
+
+// evicts reports whether idle locations are deleted.
+func (p *locationRefreshPolicy) evicts() bool {
+	return p != nil && p.evictDays > 0
+}
+
+// tier classifies a location by its demand. rank is its position among the locations ordered by
+// their recent requests.
+func (p *locationRefreshPolicy) tier(d database.ListLocationDemandRow, rank int, now time.Time) string {
+	switch {
+	case d.Pinned || (d.RecentRequests > 0 && rank < p.hotLocations):
+		return refreshTierHot
+	case p.idleDays > 0 && d.LastAccessedAt.Valid && now.Sub(d.LastAccessedAt.Time) >= time.Duration(p.idleDays)*24*time.Hour:
+		return refreshTierIdle
+	}
+	return refreshTierNormal
+}
+
+// stride returns every how many cycles a location of the given tier is refreshed.
+func (p *locationRefreshPolicy) stride(tier string) int {
+	switch tier {
+	case refreshTierHot:
+		return 1
+	case refreshTierIdle:
+		return p.idleStride
+	}
+	return p.normalStride
+}
+
+// dueInCycle reports whether a location with the given stride is refreshed in a cycle. Locations
+// are spread over the cycles of their stride by their ID, so that every cycle refreshes a share of
+// them instead of all of them every stride-th cycle.
+func dueInCycle(id uuid.UUID, stride, cycle int) bool {
+	if stride <= 1 {
+		return true
+	}
+	offset := int(binary.BigEndian.Uint32(id[:4]) % uint32(stride))
+	return (cycle+offset)%stride == 0
+}
+
+// locationsForCycle selects the locations a job refreshes in its current cycle and orders them by
+// their requests of the last day. If the demand of the locations cannot be read, all locations
+// are refreshed.
+func (s *Scheduler) locationsForCycle(ctx context.Context, jobType string, locations []database.Location) []database.Location {
+	policy := s.cfg.refreshPolicy
+	if !policy.staggers() {
+		return locations
+	}
+
+	s.mu.Lock()
+	if s.cycles == nil {
+		s.cycles = make(map[string]int)
+	}
+	cycle := s.cycles[jobType]
+	s.cycles[jobType]++
+	s.mu.Unlock()
+
+	now := time.Now()
+	rows, err := s.cfg.dbQueries.ListLocationDemand(ctx, statsHour(now.Add(-locationDemandWindow)))
+	if err != nil {
+		s.cfg.logger.Warn("could not get location demand, refreshing all locations", "type", jobType, "error", err)
+		return locations
+	}
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].RecentRequests > rows[j].RecentRequests })
+	demand := make(map[uuid.UUID]database.ListLocationDemandRow, len(rows))
+	ranks := make(map[uuid.UUID]int, len(rows))
+	for i, row := range rows {
+		demand[row.LocationID] = row
+		ranks[row.LocationID] = i
+	}
+
+	selected := make([]database.Location, 0, len(locations))
+	for _, loc := range locations {
+		tier := refreshTierNormal
+		if d, ok := demand[loc.ID]; ok {
+			tier = policy.tier(d, ranks[loc.ID], now)
+		}
+		if !dueInCycle(loc.ID, policy.stride(tier), cycle) {
+			schedulerDeferredLocations.WithLabelValues(jobType, tier).Inc()
+			continue
+		}
+		selected = append(selected, loc)
+	}
+	sort.SliceStable(selected, func(i, j int) bool {
+		return demand[selected[i].ID].RecentRequests > demand[selected[j].ID].RecentRequests
+	})
+	if deferred := len(locations) - len(selected); deferred > 0 {
+		s.cfg.logger.Debug("deferring locations with low demand", "type", jobType, "deferred", deferred, "refreshed", len(selected))
+	}
+	return selected
+}
+
+// evictIdleLocations deletes the locations that have not been requested for the policy's
+// eviction period and are neither watched nor used by an alert rule, and purges their cache.
+func (cfg *apiConfig) evictIdleLocations(ctx context.Context, now time.Time) error {
+	if !cfg.refreshPolicy.evicts() {
+		return nil
+	}
+	evicted, err := cfg.dbQueries.DeleteIdleLocations(ctx, now.Add(-time.Duration(cfg.refreshPolicy.evictDays)*24*time.Hour))
+	if err != nil {
+		return fmt.Errorf("could not evict idle locations: %w", err)
+	}
+	for _, loc := range evicted {
+		if err := cfg.cache.Delete(ctx, cfg.locationCacheKeys(loc.ID)...); err != nil {
+			cfg.logger.Warn("could not purge cache of evicted location", "location_id", loc.ID, "error", err)
+		}
+		cfg.logger.Info("evicted idle location", "city", loc.CityName, "location_id", loc.ID, "idle_days", cfg.refreshPolicy.evictDays)
+	}
+	evictedLocations.Add(float64(len(evicted)))
+	retentionPrunedRows.WithLabelValues(retentionTableLocations).Add(float64(len(evicted)))
+	return nil
+}
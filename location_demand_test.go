@@ -0,0 +1,236 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/cor0nius/willitrain/internal/database"
+	"github.com/google/uuid"
+)
+
+func TestLocationRefreshPolicy_Tier(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	policy := &locationRefreshPolicy{idleDays: 7, idleStride: 4, normalStride: 2, hotLocations: 2}
+	accessedAgo := func(d time.Duration) sql.NullTime { return sql.NullTime{Time: now.Add(-d), Valid: true} }
+
+	testCases := []struct {
+		name   string
+		demand database.ListLocationDemandRow
+		rank   int
+		want   string
+	}{
+		{name: "Most Requested", demand: database.ListLocationDemandRow{RecentRequests: 50, LastAccessedAt: accessedAgo(time.Hour)}, rank: 0, want: refreshTierHot},
+		{name: "Requested Below Hot Rank", demand: database.ListLocationDemandRow{RecentRequests: 3, LastAccessedAt: accessedAgo(time.Hour)}, rank: 2, want: refreshTierNormal},
+		{name: "No Recent Requests", demand: database.ListLocationDemandRow{LastAccessedAt: accessedAgo(48 * time.Hour)}, rank: 0, want: refreshTierNormal},
+		{name: "Idle", demand: database.ListLocationDemandRow{LastAccessedAt: accessedAgo(8 * 24 * time.Hour)}, rank: 5, want: refreshTierIdle},
+		{name: "Idle But Watched", demand: database.ListLocationDemandRow{LastAccessedAt: accessedAgo(30 * 24 * time.Hour), Pinned: true}, rank: 5, want: refreshTierHot},
+		{name: "Never Accessed", demand: database.ListLocationDemandRow{}, rank: 5, want: refreshTierNormal},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := policy.tier(tc.demand, tc.rank, now); got != tc.want {
+				t.Errorf("expected tier %s, got %s", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestDueInCycle(t *testing.T) {
+	const stride = 4
+	for range 20 {
+		id := uuid.New()
+		due := 0
+		for cycle := range 4 * stride {
+			if dueInCycle(id, stride, cycle) {
+				due++
+			}
+		}
+		if due != 4 {
+			t.Fatalf("expected location %s to be due 4 times in %d cycles, got %d", id, 4*stride, due)
+		}
+	}
+	if !dueInCycle(uuid.New(), 1, 7) {
+		t.Error("expected a stride of 1 to be due on every cycle")
+	}
+}
+
+func TestLocationsForCycle(t *testing.T) {
+	now := time.Now()
+	hot := MockDBLocation
+	hot.ID, hot.CityName = uuid.New(), "Hotville"
+	watched := MockDBLocation
+	watched.ID, watched.CityName = uuid.New(), "Watchtown"
+	normal := MockDBLocation
+	normal.ID, normal.CityName = uuid.New(), "Normalburg"
+	idle := MockDBLocation
+	idle.ID, idle.CityName = uuid.New(), "Idleham"
+	locations := []database.Location{idle, normal, watched, hot}
+
+	demand := []database.ListLocationDemandRow{
+		{LocationID: idle.ID, LastAccessedAt: sql.NullTime{Time: now.Add(-10 * 24 * time.Hour), Valid: true}},
+		{LocationID: normal.ID, LastAccessedAt: sql.NullTime{Time: now.Add(-2 * 24 * time.Hour), Valid: true}},
+		{LocationID: watched.ID, LastAccessedAt: sql.NullTime{Time: now.Add(-30 * 24 * time.Hour), Valid: true}, Pinned: true},
+		{LocationID: hot.ID, LastAccessedAt: sql.NullTime{Time: now.Add(-time.Minute), Valid: true}, RecentRequests: 40},
+	}
+
+	t.Run("Locations Are Staggered By Demand", func(t *testing.T) {
+		testCfg := newTestAPIConfig(t)
+		testCfg.refreshPolicy = &locationRefreshPolicy{idleDays: 7, idleStride: 3, normalStride: 2, hotLocations: 1}
+		testCfg.mockDB.ListLocationDemandFunc = func(ctx context.Context, hour time.Time) ([]database.ListLocationDemandRow, error) {
+			return demand, nil
+		}
+		s := NewScheduler(testCfg.apiConfig)
+
+		refreshed := make(map[uuid.UUID]int)
+		for range 6 {
+			selected := s.locationsForCycle(context.Background(), currentWeatherJobName, locations)
+			if len(selected) == 0 || selected[0].ID != hot.ID {
+				t.Fatalf("expected the most requested location first, got %v", selected)
+			}
+			for _, loc := range selected {
+				refreshed[loc.ID]++
+			}
+		}
+
+		want := map[uuid.UUID]int{hot.ID: 6, watched.ID: 6, normal.ID: 3, idle.ID: 2}
+		for id, n := range want {
+			if refreshed[id] != n {
+				t.Errorf("expected location %s to be refreshed %d times in 6 cycles, got %d", id, n, refreshed[id])
+			}
+		}
+	})
+
+	t.Run("Demand Error Refreshes All Locations", func(t *testing.T) {
+		testCfg := newTestAPIConfig(t)
+		testCfg.refreshPolicy = &locationRefreshPolicy{idleDays: 7, idleStride: 3, normalStride: 2}
+		testCfg.mockDB.ListLocationDemandFunc = func(ctx context.Context, hour time.Time) ([]database.ListLocationDemandRow, error) {
+			return nil, errors.New("db down")
+		}
+		s := NewScheduler(testCfg.apiConfig)
+
+		if got := s.locationsForCycle(context.Background(), currentWeatherJobName, locations); len(got) != len(locations) {
+			t.Errorf("expected all %d locations, got %d", len(locations), len(got))
+		}
+	})
+
+	t.Run("Policy Without Staggering Skips The Demand Query", func(t *testing.T) {
+		testCfg := newTestAPIConfig(t)
+		testCfg.refreshPolicy = &locationRefreshPolicy{idleStride: 4, normalStride: 1}
+		s := NewScheduler(testCfg.apiConfig)
+
+		if got := s.locationsForCycle(context.Background(), currentWeatherJobName, locations); len(got) != len(locations) {
+			t.Errorf("expected all %d locations, got %d", len(locations), len(got))
+		}
+		if n := testCfg.mockDB.Calls("ListLocationDemand"); n != 0 {
+			t.Errorf("expected no demand query, got %d", n)
+		}
+	})
+}
+
+func TestEvictIdleLocations(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	evictedID := uuid.New()
+
+	testCases := []struct {
+		name        string
+		policy      *locationRefreshPolicy
+		deleteErr   error
+		wantErr     bool
+		wantDeletes int
+		wantPurged  int
+	}{
+		{name: "Disabled", policy: &locationRefreshPolicy{idleDays: 7}},
+		{name: "No Policy"},
+		{name: "Evicted", policy: &locationRefreshPolicy{evictDays: 30}, wantDeletes: 1, wantPurged: 1},
+		{name: "Database Error", policy: &locationRefreshPolicy{evictDays: 30}, deleteErr: errors.New("db down"), wantErr: true, wantDeletes: 1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testCfg := newTestAPIConfig(t)
+			testCfg.refreshPolicy = tc.policy
+			testCfg.mockDB.DeleteIdleLocationsFunc = func(ctx context.Context, lastAccessedAt time.Time) ([]database.DeleteIdleLocationsRow, error) {
+				if want := now.Add(-30 * 24 * time.Hour); !lastAccessedAt.Equal(want) {
+					t.Errorf("expected cutoff %v, got %v", want, lastAccessedAt)
+				}
+				if tc.deleteErr != nil {
+					return nil, tc.deleteErr
+				}
+				return []database.DeleteIdleLocationsRow{{ID: evictedID, CityName: "Ghost Town"}}, nil
+			}
+			purged := 0
+			testCfg.mockCache.DeleteFunc = func(ctx context.Context, keys ...string) error {
+				purged++
+				if len(keys) != len(testCfg.locationCacheKeys(evictedID)) {
+					t.Errorf("expected all cache keys of the location to be purged, got %v", keys)
+				}
+				return nil
+			}
+
+			err := testCfg.evictIdleLocations(context.Background(), now)
+
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("expected error: %v, got %v", tc.wantErr, err)
+			}
+			if n := testCfg.mockDB.Calls("DeleteIdleLocations"); n != tc.wantDeletes {
+				t.Errorf("expected %d eviction queries, got %d", tc.wantDeletes, n)
+			}
+			if purged != tc.wantPurged {
+				t.Errorf("expected %d cache purges, got %d", tc.wantPurged, purged)
+			}
+		})
+	}
+}
+
+func TestNewLocationRefreshPolicy(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	testCases := []struct {
+		name string
+		env  map[string]string
+		want locationRefreshPolicy
+	}{
+		{
+			name: "Defaults",
+			want: locationRefreshPolicy{idleDays: 7, idleStride: 4, normalStride: 1, hotLocations: 20},
+		},
+		{
+			name: "Configured",
+			env: map[string]string{
+				"LOCATION_IDLE_DAYS":      "3",
+				"LOCATION_EVICT_DAYS":     "60",
+				"SCHEDULER_IDLE_STRIDE":   "6",
+				"SCHEDULER_NORMAL_STRIDE": "2",
+				"SCHEDULER_HOT_LOCATIONS": "0",
+			},
+			want: locationRefreshPolicy{idleDays: 3, evictDays: 60, idleStride: 6, normalStride: 2},
+		},
+		{
+			name: "Invalid",
+			env: map[string]string{
+				"LOCATION_IDLE_DAYS":      "-1",
+				"LOCATION_EVICT_DAYS":     "-1",
+				"SCHEDULER_IDLE_STRIDE":   "0",
+				"SCHEDULER_NORMAL_STRIDE": "-2",
+				"SCHEDULER_HOT_LOCATIONS": "-5",
+			},
+			want: locationRefreshPolicy{idleDays: 7, idleStride: 4, normalStride: 1, hotLocations: 20},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			for _, key := range []string{"LOCATION_IDLE_DAYS", "LOCATION_EVICT_DAYS", "SCHEDULER_IDLE_STRIDE", "SCHEDULER_NORMAL_STRIDE", "SCHEDULER_HOT_LOCATIONS"} {
+				t.Setenv(key, tc.env[key])
+			}
+			if got := newLocationRefreshPolicy(logger); *got != tc.want {
+				t.Errorf("expected %+v, got %+v", tc.want, *got)
+			}
+		})
+	}
+}
@@ -6,8 +6,11 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/cor0nius/willitrain/internal/database"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // This file contains helper functions related to location management.
@@ -24,8 +27,10 @@ import (
 // 4. If not found, call the geocoding service to get the canonical location data.
 // 5. Check if a location with the canonical name already exists in the `locations` table.
 // 6. If it exists, create a new alias for the user's original input and link it to the existing location.
-// 7. If no location exists by either alias or canonical name, create a new location record.
-// 8. Finally, create aliases for both the user's normalized input and the canonical name to ensure future lookups are successful.
+// 7. If no location exists by either alias or canonical name, create a new location record,
+// storing the timezone if the geocoder provided one.
+// 8. Finally, create aliases for both the user's normalized input and the canonical name to ensure future lookups are successful,
+// and start backfilling the new location's observation history in the background.
 func (cfg *apiConfig) getOrCreateLocation(ctx context.Context, cityName string) (Location, error) {
 	alias, err := normalizeCityName(cityName)
 	if err != nil {
@@ -34,14 +39,14 @@ func (cfg *apiConfig) getOrCreateLocation(ctx context.Context, cityName string)
 
 	dbLocation, err := cfg.dbQueries.GetLocationByAlias(ctx, alias)
 	if err == nil {
-		cfg.logger.Debug("location found by alias", "alias", alias, "city", dbLocation.CityName)
+		cfg.logger.DebugContext(ctx, "location found by alias", "alias", alias, "city", dbLocation.CityName)
 		return databaseLocationToLocation(dbLocation), nil
 	}
 	if err != sql.ErrNoRows {
 		return Location{}, fmt.Errorf("database error when fetching location by alias: %w", err)
 	}
 
-	cfg.logger.Debug("alias not found, geocoding", "alias", alias, "original_city", cityName)
+	cfg.logger.DebugContext(ctx, "alias not found, geocoding", "alias", alias, "original_city", cityName)
 	geocodedLocation, geoErr := cfg.geocoder.Geocode(cityName)
 	if geoErr != nil {
 		return Location{}, fmt.Errorf("could not geocode city '%s': %w", cityName, geoErr)
@@ -49,10 +54,10 @@ func (cfg *apiConfig) getOrCreateLocation(ctx context.Context, cityName string)
 
 	dbLocation, err = cfg.dbQueries.GetLocationByName(ctx, geocodedLocation.CityName)
 	if err == nil {
-		cfg.logger.Debug("canonical location found in db, creating new alias", "city", dbLocation.CityName, "alias", alias)
+		cfg.logger.DebugContext(ctx, "canonical location found in db, creating new alias", "city", dbLocation.CityName, "alias", alias)
 		_, aliasErr := cfg.dbQueries.CreateLocationAlias(ctx, database.CreateLocationAliasParams{Alias: alias, LocationID: dbLocation.ID})
 		if aliasErr != nil {
-			cfg.logger.Warn("could not create location alias", "alias", alias, "location_id", dbLocation.ID, "error", aliasErr)
+			cfg.logger.WarnContext(ctx, "could not create location alias", "alias", alias, "location_id", dbLocation.ID, "error", aliasErr)
 		}
 		return databaseLocationToLocation(dbLocation), nil
 	}
@@ -60,41 +65,68 @@ func (cfg *apiConfig) getOrCreateLocation(ctx context.Context, cityName string)
 		return Location{}, fmt.Errorf("database error when fetching location by canonical name: %w", err)
 	}
 
-	cfg.logger.Debug("no location found, creating new location and aliases", "city", geocodedLocation.CityName)
+	cfg.logger.DebugContext(ctx, "no location found, creating new location and aliases", "city", geocodedLocation.CityName)
 	persistedLocation, createErr := cfg.dbQueries.CreateLocation(ctx, locationToCreateLocationParams(geocodedLocation))
 	if createErr != nil {
 		return Location{}, fmt.Errorf("could not persist new location: %w", createErr)
 	}
+	if geocodedLocation.Timezone != "" {
+		if err := cfg.updateTimezone(ctx, persistedLocation.ID, geocodedLocation.Timezone); err != nil {
+			cfg.logger.WarnContext(ctx, "could not store geocoded timezone", "city", persistedLocation.CityName, "error", err)
+		} else {
+			persistedLocation.Timezone = sql.NullString{String: geocodedLocation.Timezone, Valid: true}
+		}
+	}
 
 	_, aliasErr := cfg.dbQueries.CreateLocationAlias(ctx, database.CreateLocationAliasParams{Alias: alias, LocationID: persistedLocation.ID})
 	if aliasErr != nil {
-		cfg.logger.Warn("could not create user input alias", "alias", alias, "location_id", persistedLocation.ID, "error", aliasErr)
+		cfg.logger.WarnContext(ctx, "could not create user input alias", "alias", alias, "location_id", persistedLocation.ID, "error", aliasErr)
 	}
 
 	canonicalAlias, err := normalizeCityName(persistedLocation.CityName)
 	if err != nil {
-		cfg.logger.Error("could not normalize canonical city name", "city", persistedLocation.CityName, "error", err)
+		cfg.logger.ErrorContext(ctx, "could not normalize canonical city name", "city", persistedLocation.CityName, "error", err)
 	} else if alias != canonicalAlias {
 		_, aliasErr = cfg.dbQueries.CreateLocationAlias(ctx, database.CreateLocationAliasParams{Alias: canonicalAlias, LocationID: persistedLocation.ID})
 		if aliasErr != nil {
-			cfg.logger.Warn("could not create canonical alias", "alias", canonicalAlias, "location_id", persistedLocation.ID, "error", aliasErr)
+			cfg.logger.WarnContext(ctx, "could not create canonical alias", "alias", canonicalAlias, "location_id", persistedLocation.ID, "error", aliasErr)
 		}
 	}
 
-	return databaseLocationToLocation(persistedLocation), nil
+	location := databaseLocationToLocation(persistedLocation)
+	cfg.startObservationBackfill(location)
+	return location, nil
 }
 
 // getLocationFromRequest extracts location details from an HTTP request, supporting both
 // city name and latitude/longitude query parameters. It uses getOrCreateLocation to
-// ensure a consistent and canonical location record is used.
-func (cfg *apiConfig) getLocationFromRequest(r *http.Request) (Location, error) {
+// ensure a consistent and canonical location record is used. Every resolved location is
+// counted in the request statistics under the request path. The lookup is traced.
+func (cfg *apiConfig) getLocationFromRequest(r *http.Request) (location Location, err error) {
+	ctx, span := tracer.Start(r.Context(), "getLocationFromRequest")
+	defer func() {
+		if err == nil {
+			span.SetAttributes(attribute.String("location.city", location.CityName))
+		}
+		endSpan(span, err)
+	}()
+	return cfg.locationFromRequest(r.WithContext(ctx))
+}
+
+// locationFromRequest implements getLocationFromRequest.
+func (cfg *apiConfig) locationFromRequest(r *http.Request) (Location, error) {
 	ctx := r.Context()
 	cityName := r.URL.Query().Get("city")
 	latStr := r.URL.Query().Get("lat")
 	lonStr := r.URL.Query().Get("lon")
 
 	if cityName != "" {
-		return cfg.getOrCreateLocation(ctx, cityName)
+		location, err := cfg.getOrCreateLocation(ctx, cityName)
+		if err != nil {
+			return Location{}, err
+		}
+		cfg.requestStats.recordLocation(r.URL.Path, location.LocationID, time.Now())
+		return location, nil
 	}
 
 	if latStr != "" && lonStr != "" {
@@ -113,8 +145,51 @@ func (cfg *apiConfig) getLocationFromRequest(r *http.Request) (Location, error)
 			return Location{}, fmt.Errorf("could not reverse geocode coordinates: %w", err)
 		}
 
-		return cfg.getOrCreateLocation(ctx, location.CityName)
+		location, err = cfg.getOrCreateLocation(ctx, location.CityName)
+		if err != nil {
+			return Location{}, err
+		}
+		cfg.requestStats.recordLocation(r.URL.Path, location.LocationID, time.Now())
+		return location, nil
+	}
+
+	if user, ok := userFrom(ctx); ok && user.DefaultCity != "" {
+		location, err := cfg.getOrCreateLocation(ctx, user.DefaultCity)
+		if err != nil {
+			return Location{}, err
+		}
+		cfg.requestStats.recordLocation(r.URL.Path, location.LocationID, time.Now())
+		return location, nil
 	}
 
 	return Location{}, fmt.Errorf("either city or lat/lon query parameters are required")
 }
+
+// resetLocationData removes all stored weather data for a single location. It deletes the
+// current weather, hourly and daily forecast and air quality rows from the database in one
+// transaction and purges the location's entries from the cache, leaving the location record and
+// its aliases intact.
+func (cfg *apiConfig) resetLocationData(ctx context.Context, locationID uuid.UUID) error {
+	err := cfg.runInTx(ctx, func(q dbQuerier) error {
+		if err := q.DeleteCurrentWeatherAtLocation(ctx, locationID); err != nil {
+			return fmt.Errorf("could not delete current weather: %w", err)
+		}
+		if err := q.DeleteHourlyForecastsAtLocation(ctx, locationID); err != nil {
+			return fmt.Errorf("could not delete hourly forecasts: %w", err)
+		}
+		if err := q.DeleteDailyForecastsAtLocation(ctx, locationID); err != nil {
+			return fmt.Errorf("could not delete daily forecasts: %w", err)
+		}
+		if err := q.DeleteAirQualityAtLocation(ctx, locationID); err != nil {
+			return fmt.Errorf("could not delete air quality: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := cfg.cache.Delete(ctx, cfg.locationCacheKeys(locationID)...); err != nil {
+		return fmt.Errorf("could not purge cache: %w", err)
+	}
+	return nil
+}
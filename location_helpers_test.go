@@ -101,6 +101,42 @@ func TestGetOrCreateLocation(t *testing.T) {
 				}
 			},
 		},
+		{
+			name:     "Success: New City With Geocoded Timezone",
+			cityName: "london",
+			setupMocks: func(cfg *testAPIConfig) {
+				newID := uuid.New()
+				cfg.mockDB.GetLocationByAliasFunc = func(ctx context.Context, alias string) (database.Location, error) {
+					return database.Location{}, sql.ErrNoRows
+				}
+				cfg.mockGeo.GeocodeFunc = func(cityName string) (Location, error) {
+					return Location{CityName: "London", CountryCode: "GB", Timezone: "Europe/London"}, nil
+				}
+				cfg.mockDB.GetLocationByNameFunc = func(ctx context.Context, cityName string) (database.Location, error) {
+					return database.Location{}, sql.ErrNoRows
+				}
+				cfg.mockDB.CreateLocationFunc = func(ctx context.Context, arg database.CreateLocationParams) (database.Location, error) {
+					return database.Location{ID: newID, CityName: arg.CityName, CountryCode: arg.CountryCode}, nil
+				}
+				cfg.mockDB.UpdateTimezoneFunc = func(ctx context.Context, arg database.UpdateTimezoneParams) error {
+					if arg.ID != newID || arg.Timezone.String != "Europe/London" {
+						t.Errorf("unexpected timezone update: %+v", arg)
+					}
+					return nil
+				}
+				cfg.mockDB.CreateLocationAliasFunc = func(ctx context.Context, arg database.CreateLocationAliasParams) (database.LocationAlias, error) {
+					return database.LocationAlias{}, nil
+				}
+			},
+			check: func(t *testing.T, loc Location, err error) {
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				if loc.Timezone != "Europe/London" {
+					t.Errorf("expected timezone 'Europe/London', got '%s'", loc.Timezone)
+				}
+			},
+		},
 		{
 			name:     "Failure: City Name Contains Invalid UTF-8",
 			cityName: "abc\x80def",
@@ -457,3 +493,50 @@ func TestGetLocationFromRequest(t *testing.T) {
 		})
 	}
 }
+
+func TestResetLocationData(t *testing.T) {
+	testCfg := newTestAPIConfig(t)
+	locationID := MockLocation.LocationID
+
+	var deletedTables []string
+	testCfg.mockDB.DeleteCurrentWeatherAtLocationFunc = func(ctx context.Context, id uuid.UUID) error {
+		deletedTables = append(deletedTables, "current_weather")
+		return nil
+	}
+	testCfg.mockDB.DeleteHourlyForecastsAtLocationFunc = func(ctx context.Context, id uuid.UUID) error {
+		deletedTables = append(deletedTables, "hourly_forecasts")
+		return nil
+	}
+	testCfg.mockDB.DeleteDailyForecastsAtLocationFunc = func(ctx context.Context, id uuid.UUID) error {
+		deletedTables = append(deletedTables, "daily_forecasts")
+		return nil
+	}
+	testCfg.mockDB.DeleteAirQualityAtLocationFunc = func(ctx context.Context, id uuid.UUID) error {
+		deletedTables = append(deletedTables, "air_quality")
+		return nil
+	}
+	var deletedKeys []string
+	testCfg.mockCache.DeleteFunc = func(ctx context.Context, keys ...string) error {
+		deletedKeys = keys
+		return nil
+	}
+
+	if err := testCfg.resetLocationData(context.Background(), locationID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	wantTables := []string{"current_weather", "hourly_forecasts", "daily_forecasts", "air_quality"}
+	if !reflect.DeepEqual(deletedTables, wantTables) {
+		t.Errorf("expected deletes on %v, got %v", wantTables, deletedTables)
+	}
+	wantKeys := []string{
+		"currentweather:" + locationID.String(),
+		"dailyforecast:" + locationID.String() + ":5",
+		"hourlyforecast:" + locationID.String() + ":24",
+		"airquality:" + locationID.String(),
+		"warnings:" + locationID.String(),
+	}
+	if !reflect.DeepEqual(deletedKeys, wantKeys) {
+		t.Errorf("expected cache keys %v, got %v", wantKeys, deletedKeys)
+	}
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/cor0nius/willitrain/internal/database"
+	"github.com/google/uuid"
+)
+
+// This file implements the administrative API for tracked locations. Locations are normally
+// created on demand by the first weather request for a city; operators can also list them, add a
+// location ahead of the first request so that the scheduler starts refreshing it, merge a
+// duplicate location into its canonical one and delete a location together with all data stored
+// for it. Every change is written to the log as an audit event.
+
+// handlerAdminLocations dispatches requests for the collection of locations by method: GET lists
+// all locations and POST adds one.
+
+// @Summary      List or add tracked locations
+// @Description  GET lists all tracked locations, ordered by city name. POST geocodes the given city and
+// @Description  stores it as a tracked location if it is not one yet, so that the scheduler refreshes it on its
+// @Description  next cycle; refresh=true fetches its weather data right away in the background. Additions are audit-logged.
+// @Tags         admin
+// @Produce      json
+// @Param        city     query     string  false  "City name to add (POST)"
+// @Param        refresh  query     bool    false  "Fetch the added location's weather data immediately (POST)"
+// @Success      200  {object}  LocationsResponse
+// @Success      201  {object}  Location
+// @Failure      400  {object}  ErrorResponse "Bad Request - Missing city or invalid refresh flag"
+// @Failure      500  {object}  ErrorResponse "Internal Server Error - Failed to list or add locations"
+// @Security     ApiKeyAuth
+// @Failure      401  {object}  ErrorResponse "Unauthorized - Missing API key"
+// @Failure      403  {object}  ErrorResponse "Forbidden - Invalid API key"
+// @Router       /admin/locations [get]
+// @Router       /admin/locations [post]
+func (s *Scheduler) handlerAdminLocations(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.cfg.listLocations(w, r)
+	case http.MethodPost:
+		s.addLocation(w, r)
+	default:
+		s.cfg.respondWithError(w, http.StatusMethodNotAllowed, "Method Not Allowed", nil)
+	}
+}
+
+func (cfg *apiConfig) listLocations(w http.ResponseWriter, r *http.Request) {
+	dbLocations, err := cfg.dbQueries.ListLocations(r.Context())
+	if err != nil {
+		cfg.respondWithError(w, http.StatusInternalServerError, "Failed to list locations", err)
+		return
+	}
+
+	locations := make([]Location, len(dbLocations))
+	for i, l := range dbLocations {
+		locations[i] = databaseLocationToLocation(l)
+	}
+	cfg.respondWithJSON(w, http.StatusOK, LocationsResponse{Locations: locations})
+}
+
+func (s *Scheduler) addLocation(w http.ResponseWriter, r *http.Request) {
+	city := strings.TrimSpace(r.URL.Query().Get("city"))
+	if city == "" {
+		s.cfg.respondWithError(w, http.StatusBadRequest, "city parameter is required", nil)
+		return
+	}
+
+	refresh := false
+	if refreshStr := r.URL.Query().Get("refresh"); refreshStr != "" {
+		var err error
+		refresh, err = strconv.ParseBool(refreshStr)
+		if err != nil {
+			s.cfg.respondWithError(w, http.StatusBadRequest, "Invalid refresh flag", err)
+			return
+		}
+	}
+
+	location, err := s.cfg.getOrCreateLocation(r.Context(), city)
+	if err != nil {
+		s.cfg.respondWithError(w, http.StatusInternalServerError, "Failed to add location", err)
+		return
+	}
+
+	s.cfg.logger.Info("audit: location added",
+		"city", location.CityName,
+		"location_id", location.LocationID,
+		"refresh", refresh,
+		"remote_addr", r.RemoteAddr,
+	)
+	if refresh {
+		go s.refreshLocation(context.WithoutCancel(r.Context()), location)
+	}
+	s.cfg.respondWithJSON(w, http.StatusCreated, location)
+}
+
+// @Summary      Delete a tracked location
+// @Description  Deletes the location together with its aliases, weather data, history, watchlist entries and
+// @Description  alert rules, and purges its cache entries. The deletion is audit-logged.
+// @Tags         admin
+// @Produce      json
+// @Param        id   path      string  true  "Location ID (UUID)"
+// @Success      200  {object}  map[string]string "Confirmation of deletion. Example: `{\"status\":\"location deleted\"}`"
+// @Failure      400  {object}  ErrorResponse "Bad Request - Invalid location ID"
+// @Failure      404  {object}  ErrorResponse "Not Found - Location does not exist"
+// @Failure      500  {object}  ErrorResponse "Internal Server Error - Failed to delete location"
+// @Security     ApiKeyAuth
+// @Failure      401  {object}  ErrorResponse "Unauthorized - Missing API key"
+// @Failure      403  {object}  ErrorResponse "Forbidden - Invalid API key"
+// @Router       /admin/locations/{id} [delete]
+func (cfg *apiConfig) handlerDeleteLocation(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		cfg.respondWithError(w, http.StatusMethodNotAllowed, "Method Not Allowed", nil)
+		return
+	}
+
+	location, ok := cfg.locationFromPath(w, r, "id")
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+	if err := cfg.dbQueries.DeleteLocation(ctx, location.LocationID); err != nil {
+		cfg.respondWithError(w, http.StatusInternalServerError, "Failed to delete location", err)
+		return
+	}
+	if err := cfg.cache.Delete(ctx, cfg.locationCacheKeys(location.LocationID)...); err != nil {
+		cfg.logger.Warn("could not purge cache of deleted location", "location_id", location.LocationID, "error", err)
+	}
+
+	cfg.logger.Info("audit: location deleted",
+		"city", location.CityName,
+		"location_id", location.LocationID,
+		"remote_addr", r.RemoteAddr,
+	)
+	cfg.respondWithJSON(w, http.StatusOK, map[string]string{"status": "location deleted"})
+}
+
+// handlerMergeLocation merges a duplicate location into another one. The aliases, watchlist entries,
+// alert rules and group memberships of the duplicate are moved to the location given by the into
+// parameter, and the duplicate is deleted with the rest of its data, all in one transaction.
+// Watchlist entries of subscribers who already watch both locations, and memberships of groups
+// that already contain both, are dropped with the duplicate.
+
+// @Summary      Merge a duplicate location into another one
+// @Description  Moves the aliases, watchlist entries, alert rules and group memberships of the location to the location given
+// @Description  by into, then deletes the location with its remaining data and purges its cache entries.
+// @Description  The merge is audit-logged.
+// @Tags         admin
+// @Produce      json
+// @Param        id    path      string  true  "ID of the duplicate location (UUID)"
+// @Param        into  query     string  true  "ID of the location to merge into (UUID)"
+// @Success      200  {object}  LocationMergeResponse
+// @Failure      400  {object}  ErrorResponse "Bad Request - Invalid location IDs"
+// @Failure      404  {object}  ErrorResponse "Not Found - Location does not exist"
+// @Failure      500  {object}  ErrorResponse "Internal Server Error - Failed to merge locations"
+// @Security     ApiKeyAuth
+// @Failure      401  {object}  ErrorResponse "Unauthorized - Missing API key"
+// @Failure      403  {object}  ErrorResponse "Forbidden - Invalid API key"
+// @Router       /admin/locations/{id}/merge [post]
+func (cfg *apiConfig) handlerMergeLocation(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		cfg.respondWithError(w, http.StatusMethodNotAllowed, "Method Not Allowed", nil)
+		return
+	}
+
+	intoID, err := uuid.Parse(r.URL.Query().Get("into"))
+	if err != nil {
+		cfg.respondWithError(w, http.StatusBadRequest, "Invalid into location ID", err)
+		return
+	}
+
+	duplicate, ok := cfg.locationFromPath(w, r, "id")
+	if !ok {
+		return
+	}
+	if duplicate.LocationID == intoID {
+		cfg.respondWithError(w, http.StatusBadRequest, "A location cannot be merged into itself", nil)
+		return
+	}
+	ctx := r.Context()
+	dbTarget, err := cfg.dbQueries.GetLocationByID(ctx, intoID)
+	if err == sql.ErrNoRows {
+		cfg.respondWithError(w, http.StatusNotFound, "Location to merge into not found", nil)
+		return
+	}
+	if err != nil {
+		cfg.respondWithError(w, http.StatusInternalServerError, "Failed to get location", err)
+		return
+	}
+	target := databaseLocationToLocation(dbTarget)
+
+	response := LocationMergeResponse{Location: target, MergedLocationID: duplicate.LocationID.String()}
+	err = cfg.runInTx(ctx, func(q dbQuerier) error {
+		var err error
+		if response.AliasesMoved, err = q.MoveLocationAliases(ctx, database.MoveLocationAliasesParams{
+			ToLocationID:   target.LocationID,
+			FromLocationID: duplicate.LocationID,
+		}); err != nil {
+			return err
+		}
+		if response.WatchlistEntriesMoved, err = q.MoveWatchlistEntries(ctx, database.MoveWatchlistEntriesParams{
+			ToLocationID:   target.LocationID,
+			FromLocationID: duplicate.LocationID,
+		}); err != nil {
+			return err
+		}
+		if response.AlertSubscriptionsMoved, err = q.MoveAlertSubscriptions(ctx, database.MoveAlertSubscriptionsParams{
+			ToLocationID:   target.LocationID,
+			FromLocationID: duplicate.LocationID,
+		}); err != nil {
+			return err
+		}
+		if response.GroupMembersMoved, err = q.MoveLocationGroupMembers(ctx, database.MoveLocationGroupMembersParams{
+			ToLocationID:   target.LocationID,
+			FromLocationID: duplicate.LocationID,
+		}); err != nil {
+			return err
+		}
+		return q.DeleteLocation(ctx, duplicate.LocationID)
+	})
+	if err != nil {
+		cfg.respondWithError(w, http.StatusInternalServerError, "Failed to merge locations", err)
+		return
+	}
+	if err := cfg.cache.Delete(ctx, cfg.locationCacheKeys(duplicate.LocationID)...); err != nil {
+		cfg.logger.Warn("could not purge cache of merged location", "location_id", duplicate.LocationID, "error", err)
+	}
+
+	cfg.logger.Info("audit: location merged",
+		"city", duplicate.CityName,
+		"location_id", duplicate.LocationID,
+		"into_city", target.CityName,
+		"into_location_id", target.LocationID,
+		"aliases_moved", response.AliasesMoved,
+		"watchlist_entries_moved", response.WatchlistEntriesMoved,
+		"alert_subscriptions_moved", response.AlertSubscriptionsMoved,
+		"group_members_moved", response.GroupMembersMoved,
+		"remote_addr", r.RemoteAddr,
+	)
+	cfg.respondWithJSON(w, http.StatusOK, response)
+}
+
+// locationFromPath returns the location whose ID is the named path value. If the ID is invalid or
+// the location cannot be found, it writes the error response and returns false.
+func (cfg *apiConfig) locationFromPath(w http.ResponseWriter, r *http.Request, name string) (Location, bool) {
+	locationID, err := uuid.Parse(r.PathValue(name))
+	if err != nil {
+		cfg.respondWithError(w, http.StatusBadRequest, "Invalid location ID", err)
+		return Location{}, false
+	}
+
+	dbLocation, err := cfg.dbQueries.GetLocationByID(r.Context(), locationID)
+	if err == sql.ErrNoRows {
+		cfg.respondWithError(w, http.StatusNotFound, "Location not found", nil)
+		return Location{}, false
+	}
+	if err != nil {
+		cfg.respondWithError(w, http.StatusInternalServerError, "Failed to get location", err)
+		return Location{}, false
+	}
+	return databaseLocationToLocation(dbLocation), true
+}
@@ -0,0 +1,281 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cor0nius/willitrain/internal/database"
+	"github.com/google/uuid"
+)
+
+func TestHandlerAdminLocations(t *testing.T) {
+	testCases := []struct {
+		name          string
+		requestMethod string
+		query         string
+		setupMocks    func(cfg *testAPIConfig)
+		wantStatus    int
+		wantBody      string
+		wantLog       string
+	}{
+		{
+			name:          "List",
+			requestMethod: http.MethodGet,
+			setupMocks: func(cfg *testAPIConfig) {
+				cfg.mockDB.ListLocationsFunc = func(ctx context.Context) ([]database.Location, error) {
+					return []database.Location{MockDBLocation}, nil
+				}
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `{"locations":[{"location_id":"` + MockLocation.LocationID.String() + `","city_name":"Wroclaw","latitude":51.1,"longitude":17.03,"country_code":"PL"}]}`,
+		},
+		{
+			name:          "List Empty",
+			requestMethod: http.MethodGet,
+			setupMocks: func(cfg *testAPIConfig) {
+				cfg.mockDB.ListLocationsFunc = func(ctx context.Context) ([]database.Location, error) {
+					return nil, nil
+				}
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `{"locations":[]}`,
+		},
+		{
+			name:          "Add",
+			requestMethod: http.MethodPost,
+			query:         "?city=Wroclaw",
+			setupMocks: func(cfg *testAPIConfig) {
+				cfg.mockDB.GetLocationByAliasFunc = func(ctx context.Context, alias string) (database.Location, error) {
+					return MockDBLocation, nil
+				}
+			},
+			wantStatus: http.StatusCreated,
+			wantBody:   `{"location_id":"` + MockLocation.LocationID.String() + `","city_name":"Wroclaw","latitude":51.1,"longitude":17.03,"country_code":"PL"}`,
+			wantLog:    "audit: location added",
+		},
+		{
+			name:          "Add Missing City",
+			requestMethod: http.MethodPost,
+			setupMocks:    func(cfg *testAPIConfig) {},
+			wantStatus:    http.StatusBadRequest,
+			wantBody:      `{"error":"city parameter is required"}`,
+		},
+		{
+			name:          "Add Invalid Refresh Flag",
+			requestMethod: http.MethodPost,
+			query:         "?city=Wroclaw&refresh=maybe",
+			setupMocks:    func(cfg *testAPIConfig) {},
+			wantStatus:    http.StatusBadRequest,
+			wantBody:      `{"error":"Invalid refresh flag"}`,
+		},
+		{
+			name:          "Wrong Method",
+			requestMethod: http.MethodPut,
+			setupMocks:    func(cfg *testAPIConfig) {},
+			wantStatus:    http.StatusMethodNotAllowed,
+			wantBody:      `{"error":"Method Not Allowed"}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testCfg := newTestAPIConfig(t)
+			var logBuf bytes.Buffer
+			testCfg.logger = slog.New(slog.NewTextHandler(&logBuf, nil))
+			tc.setupMocks(testCfg)
+
+			req := httptest.NewRequest(tc.requestMethod, "/admin/locations"+tc.query, nil)
+			rr := httptest.NewRecorder()
+
+			NewScheduler(testCfg.apiConfig).handlerAdminLocations(rr, req)
+
+			if status := rr.Code; status != tc.wantStatus {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, tc.wantStatus)
+			}
+			if rr.Body.String() != tc.wantBody {
+				t.Errorf("handler returned unexpected body: got %v want %v", rr.Body.String(), tc.wantBody)
+			}
+			if tc.wantLog != "" && !strings.Contains(logBuf.String(), tc.wantLog) {
+				t.Errorf("expected log to contain %q, got %s", tc.wantLog, logBuf.String())
+			}
+		})
+	}
+}
+
+func TestHandlerDeleteLocation(t *testing.T) {
+	testCases := []struct {
+		name       string
+		locationID string
+		setupMocks func(cfg *testAPIConfig)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "Success",
+			locationID: MockLocation.LocationID.String(),
+			setupMocks: func(cfg *testAPIConfig) {
+				cfg.mockDB.GetLocationByIDFunc = func(ctx context.Context, id uuid.UUID) (database.Location, error) {
+					return MockDBLocation, nil
+				}
+				cfg.mockDB.DeleteLocationFunc = func(ctx context.Context, id uuid.UUID) error {
+					return nil
+				}
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `{"status":"location deleted"}`,
+		},
+		{
+			name:       "Not Found",
+			locationID: MockLocation.LocationID.String(),
+			setupMocks: func(cfg *testAPIConfig) {
+				cfg.mockDB.GetLocationByIDFunc = func(ctx context.Context, id uuid.UUID) (database.Location, error) {
+					return database.Location{}, sql.ErrNoRows
+				}
+			},
+			wantStatus: http.StatusNotFound,
+			wantBody:   `{"error":"Location not found"}`,
+		},
+		{
+			name:       "Invalid Location ID",
+			locationID: "not-a-uuid",
+			setupMocks: func(cfg *testAPIConfig) {},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"Invalid location ID"}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testCfg := newTestAPIConfig(t)
+			tc.setupMocks(testCfg)
+			var purged []string
+			testCfg.mockCache.DeleteFunc = func(ctx context.Context, keys ...string) error {
+				purged = keys
+				return nil
+			}
+
+			req := httptest.NewRequest(http.MethodDelete, "/admin/locations/"+tc.locationID, nil)
+			req.SetPathValue("id", tc.locationID)
+			rr := httptest.NewRecorder()
+
+			testCfg.apiConfig.handlerDeleteLocation(rr, req)
+
+			if status := rr.Code; status != tc.wantStatus {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, tc.wantStatus)
+			}
+			if rr.Body.String() != tc.wantBody {
+				t.Errorf("handler returned unexpected body: got %v want %v", rr.Body.String(), tc.wantBody)
+			}
+			if tc.wantStatus == http.StatusOK && len(purged) == 0 {
+				t.Error("expected the location's cache entries to be purged")
+			}
+		})
+	}
+}
+
+func TestHandlerMergeLocation(t *testing.T) {
+	duplicateID := uuid.New()
+	getLocations := func(cfg *testAPIConfig) {
+		cfg.mockDB.GetLocationByIDFunc = func(ctx context.Context, id uuid.UUID) (database.Location, error) {
+			if id == duplicateID {
+				return database.Location{ID: duplicateID, CityName: "Breslau"}, nil
+			}
+			if id == MockLocation.LocationID {
+				return MockDBLocation, nil
+			}
+			return database.Location{}, sql.ErrNoRows
+		}
+	}
+
+	testCases := []struct {
+		name       string
+		locationID string
+		into       string
+		setupMocks func(cfg *testAPIConfig)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "Success",
+			locationID: duplicateID.String(),
+			into:       MockLocation.LocationID.String(),
+			setupMocks: func(cfg *testAPIConfig) {
+				getLocations(cfg)
+				cfg.mockDB.MoveLocationAliasesFunc = func(ctx context.Context, arg database.MoveLocationAliasesParams) (int64, error) {
+					if arg.FromLocationID != duplicateID || arg.ToLocationID != MockLocation.LocationID {
+						t.Errorf("unexpected alias move: %+v", arg)
+					}
+					return 2, nil
+				}
+				cfg.mockDB.MoveWatchlistEntriesFunc = func(ctx context.Context, arg database.MoveWatchlistEntriesParams) (int64, error) {
+					return 1, nil
+				}
+				cfg.mockDB.MoveAlertSubscriptionsFunc = func(ctx context.Context, arg database.MoveAlertSubscriptionsParams) (int64, error) {
+					return 0, nil
+				}
+				cfg.mockDB.MoveLocationGroupMembersFunc = func(ctx context.Context, arg database.MoveLocationGroupMembersParams) (int64, error) {
+					return 3, nil
+				}
+				cfg.mockDB.DeleteLocationFunc = func(ctx context.Context, id uuid.UUID) error {
+					if id != duplicateID {
+						t.Errorf("deleted location %s, want %s", id, duplicateID)
+					}
+					return nil
+				}
+			},
+			wantStatus: http.StatusOK,
+			wantBody: `{"location":{"location_id":"` + MockLocation.LocationID.String() + `","city_name":"Wroclaw","latitude":51.1,"longitude":17.03,"country_code":"PL"},` +
+				`"merged_location_id":"` + duplicateID.String() + `","aliases_moved":2,"watchlist_entries_moved":1,"alert_subscriptions_moved":0,"group_members_moved":3}`,
+		},
+		{
+			name:       "Merge Into Itself",
+			locationID: duplicateID.String(),
+			into:       duplicateID.String(),
+			setupMocks: getLocations,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"A location cannot be merged into itself"}`,
+		},
+		{
+			name:       "Target Not Found",
+			locationID: duplicateID.String(),
+			into:       uuid.New().String(),
+			setupMocks: getLocations,
+			wantStatus: http.StatusNotFound,
+			wantBody:   `{"error":"Location to merge into not found"}`,
+		},
+		{
+			name:       "Invalid Target ID",
+			locationID: duplicateID.String(),
+			into:       "not-a-uuid",
+			setupMocks: func(cfg *testAPIConfig) {},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"Invalid into location ID"}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testCfg := newTestAPIConfig(t)
+			tc.setupMocks(testCfg)
+
+			req := httptest.NewRequest(http.MethodPost, "/admin/locations/"+tc.locationID+"/merge?into="+tc.into, nil)
+			req.SetPathValue("id", tc.locationID)
+			rr := httptest.NewRecorder()
+
+			testCfg.apiConfig.handlerMergeLocation(rr, req)
+
+			if status := rr.Code; status != tc.wantStatus {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, tc.wantStatus)
+			}
+			if rr.Body.String() != tc.wantBody {
+				t.Errorf("handler returned unexpected body: got %v want %v", rr.Body.String(), tc.wantBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// This file implements changing the log level at runtime and sampling the high-volume debug
+// lines of the cache lookups. The level starts at LOG_LEVEL and can be changed through
+// /admin/loglevel without a restart, for example to enable debug logging in production for a
+// few minutes. With debug logging enabled, every lookup logs a line, so the most frequent ones,
+// such as cache hits, are logged only once per LOG_SAMPLE_RATE occurrences.
+
+const defaultLogSampleRate = 100
+
+// parseLogLevel parses a log level name: debug, info, warn or error, in any case.
+func parseLogLevel(s string) (slog.Level, error) {
+	var level slog.Level
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "debug", "info", "warn", "error":
+		if err := level.UnmarshalText([]byte(s)); err != nil {
+			return level, err
+		}
+		return level, nil
+	}
+	return level, fmt.Errorf("log level must be debug, info, warn or error, got %q", s)
+}
+
+// getLogLevel reads the initial log level from LOG_LEVEL. It defaults to debug in development
+// mode and to info otherwise. It is read before the logger exists, so an invalid value is
+// returned as an error to be logged once it does.
+func getLogLevel(devMode bool) (slog.Level, error) {
+	fallback := slog.LevelInfo
+	if devMode {
+		fallback = slog.LevelDebug
+	}
+	s := os.Getenv("LOG_LEVEL")
+	if s == "" {
+		return fallback, nil
+	}
+	level, err := parseLogLevel(s)
+	if err != nil {
+		return fallback, err
+	}
+	return level, nil
+}
+
+// getLogSampleRate reads from LOG_SAMPLE_RATE how many occurrences of a high-volume debug line
+// are logged once. 1 logs every occurrence.
+func getLogSampleRate(logger *slog.Logger) int {
+	n := getEnvAsInt("LOG_SAMPLE_RATE", defaultLogSampleRate, logger)
+	if n <= 0 {
+		logger.Warn("LOG_SAMPLE_RATE must be positive, using default", "value", n)
+		return defaultLogSampleRate
+	}
+	return n
+}
+
+// logSampler decides which occurrences of a log line are logged: the first one and then one in
+// every rate. Each message is counted separately. A nil logSampler logs every occurrence.
+type logSampler struct {
+	every  atomic.Int64
+	counts sync.Map // message -> *atomic.Uint64
+}
+
+func newLogSampler(rate int) *logSampler {
+	s := &logSampler{}
+	s.setRate(rate)
+	return s
+}
+
+// rate returns how many occurrences of a message are logged once.
+func (s *logSampler) rate() int {
+	if s == nil {
+		return 1
+	}
+	return int(s.every.Load())
+}
+
+// setRate changes how many occurrences of a message are logged once.
+func (s *logSampler) setRate(rate int) {
+	s.every.Store(int64(max(rate, 1)))
+}
+
+// sample counts an occurrence of msg and reports whether it is to be logged.
+func (s *logSampler) sample(msg string) bool {
+	if s == nil {
+		return true
+	}
+	every := uint64(s.every.Load())
+	if every <= 1 {
+		return true
+	}
+	count, _ := s.counts.LoadOrStore(msg, new(atomic.Uint64))
+	return (count.(*atomic.Uint64).Add(1)-1)%every == 0
+}
+
+// debugSampled logs a high-volume debug line, such as a cache hit, once per sample rate
+// occurrences. Occurrences are only counted while debug logging is enabled.
+func (cfg *apiConfig) debugSampled(ctx context.Context, msg string, args ...any) {
+	if !cfg.logger.Enabled(ctx, slog.LevelDebug) || !cfg.logSampler.sample(msg) {
+		return
+	}
+	if rate := cfg.logSampler.rate(); rate > 1 {
+		args = append(args, "sample_rate", rate)
+	}
+	cfg.logger.DebugContext(ctx, msg, args...)
+}
+
+// LogLevelResponse is the response of /admin/loglevel.
+type LogLevelResponse struct {
+	Level      string `json:"level"`
+	SampleRate int    `json:"sample_rate"`
+}
+
+// LogLevelRequest is the request body of PATCH /admin/loglevel. Fields that are not set are left
+// unchanged.
+type LogLevelRequest struct {
+	Level      *string `json:"level"`
+	SampleRate *int    `json:"sample_rate"`
+}
+
+// @Summary      Get or change the log level
+// @Description  GET reports the log level and the sample rate of the high-volume cache debug lines. PATCH changes
+// @Description  them at runtime; fields that are not set are left unchanged. Changes last until restart.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        request  body      LogLevelRequest  false  "New level (debug, info, warn or error) and sample rate (PATCH)"
+// @Success      200  {object}  LogLevelResponse
+// @Failure      400  {object}  ErrorResponse "Bad Request - Invalid level or sample rate"
+// @Failure      405  {object}  ErrorResponse "Method Not Allowed"
+// @Security     ApiKeyAuth
+// @Failure      401  {object}  ErrorResponse "Unauthorized - Missing API key"
+// @Failure      403  {object}  ErrorResponse "Forbidden - Invalid API key"
+// @Router       /admin/loglevel [get]
+// @Router       /admin/loglevel [patch]
+func (cfg *apiConfig) handlerLogLevel(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPatch:
+		var req LogLevelRequest
+		dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4<<10))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&req); err != nil {
+			cfg.respondWithError(w, http.StatusBadRequest, "Invalid request body", err)
+			return
+		}
+		var level slog.Level
+		if req.Level != nil {
+			var err error
+			if level, err = parseLogLevel(*req.Level); err != nil {
+				cfg.respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+				return
+			}
+		}
+		if req.SampleRate != nil && *req.SampleRate <= 0 {
+			cfg.respondWithError(w, http.StatusBadRequest, "sample_rate must be positive", nil)
+			return
+		}
+
+		if req.Level != nil {
+			previous := cfg.logLevel.Level()
+			cfg.logLevel.Set(level)
+			// Logged at warn, so that the change is recorded whatever the new level.
+			cfg.logger.Warn("log level changed", "from", previous.String(), "to", level.String())
+		}
+		if req.SampleRate != nil {
+			cfg.logSampler.setRate(*req.SampleRate)
+			cfg.logger.Warn("log sample rate changed", "sample_rate", *req.SampleRate)
+		}
+	default:
+		cfg.respondWithError(w, http.StatusMethodNotAllowed, "Method Not Allowed", nil)
+		return
+	}
+	cfg.respondWithJSON(w, http.StatusOK, LogLevelResponse{
+		Level:      cfg.logLevel.Level().String(),
+		SampleRate: cfg.logSampler.rate(),
+	})
+}
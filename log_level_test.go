@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseLogLevel(t *testing.T) {
+	for input, want := range map[string]slog.Level{
+		"debug": slog.LevelDebug,
+		"INFO":  slog.LevelInfo,
+		"Warn":  slog.LevelWarn,
+		"error": slog.LevelError,
+	} {
+		if got, err := parseLogLevel(input); err != nil || got != want {
+			t.Errorf("parseLogLevel(%q) = %v, %v, want %v", input, got, err, want)
+		}
+	}
+	for _, input := range []string{"", "trace", "debug+1"} {
+		if _, err := parseLogLevel(input); err == nil {
+			t.Errorf("parseLogLevel(%q) error = nil, want an error", input)
+		}
+	}
+}
+
+func TestLogSampler(t *testing.T) {
+	s := newLogSampler(3)
+	var logged []bool
+	for range 7 {
+		logged = append(logged, s.sample("cache hit"))
+	}
+	want := []bool{true, false, false, true, false, false, true}
+	for i := range want {
+		if logged[i] != want[i] {
+			t.Fatalf("sample() = %v, want %v", logged, want)
+		}
+	}
+	if !s.sample("db cache hit") {
+		t.Error("the first occurrence of another message was not logged")
+	}
+
+	s.setRate(1)
+	if !s.sample("cache hit") || !s.sample("cache hit") {
+		t.Error("a sample rate of 1 did not log every occurrence")
+	}
+	if !(*logSampler)(nil).sample("cache hit") {
+		t.Error("a nil sampler did not log every occurrence")
+	}
+}
+
+func TestDebugSampled(t *testing.T) {
+	var buf bytes.Buffer
+	level := new(slog.LevelVar)
+	cfg := &apiConfig{
+		logger:     slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: level})),
+		logLevel:   level,
+		logSampler: newLogSampler(2),
+	}
+
+	// Occurrences are not counted while debug logging is disabled.
+	cfg.debugSampled(context.Background(), "cache hit", "key", "a")
+	level.Set(slog.LevelDebug)
+	for range 3 {
+		cfg.debugSampled(context.Background(), "cache hit", "key", "a")
+	}
+	if got := strings.Count(buf.String(), "cache hit"); got != 2 {
+		t.Errorf("logged %d lines, want 2:\n%s", got, buf.String())
+	}
+	if !strings.Contains(buf.String(), "sample_rate=2") {
+		t.Errorf("log does not report the sample rate:\n%s", buf.String())
+	}
+}
+
+func TestHandlerLogLevel(t *testing.T) {
+	testCases := []struct {
+		name           string
+		method         string
+		body           string
+		wantStatus     int
+		wantLevel      string
+		wantSampleRate int
+	}{
+		{
+			name:           "Success: Get",
+			method:         http.MethodGet,
+			wantStatus:     http.StatusOK,
+			wantLevel:      "INFO",
+			wantSampleRate: 100,
+		},
+		{
+			name:           "Success: Level Changed",
+			method:         http.MethodPatch,
+			body:           `{"level": "debug"}`,
+			wantStatus:     http.StatusOK,
+			wantLevel:      "DEBUG",
+			wantSampleRate: 100,
+		},
+		{
+			name:           "Success: Both Changed",
+			method:         http.MethodPatch,
+			body:           `{"level": "warn", "sample_rate": 10}`,
+			wantStatus:     http.StatusOK,
+			wantLevel:      "WARN",
+			wantSampleRate: 10,
+		},
+		{
+			name:           "Failure: Invalid Level",
+			method:         http.MethodPatch,
+			body:           `{"level": "verbose", "sample_rate": 10}`,
+			wantStatus:     http.StatusBadRequest,
+			wantLevel:      "INFO",
+			wantSampleRate: 100,
+		},
+		{
+			name:           "Failure: Invalid Sample Rate",
+			method:         http.MethodPatch,
+			body:           `{"level": "debug", "sample_rate": 0}`,
+			wantStatus:     http.StatusBadRequest,
+			wantLevel:      "INFO",
+			wantSampleRate: 100,
+		},
+		{
+			name:           "Failure: Method Not Allowed",
+			method:         http.MethodPost,
+			body:           `{"level": "debug"}`,
+			wantStatus:     http.StatusMethodNotAllowed,
+			wantLevel:      "INFO",
+			wantSampleRate: 100,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testCfg := newTestAPIConfig(t)
+			testCfg.logLevel = new(slog.LevelVar)
+			testCfg.logSampler = newLogSampler(defaultLogSampleRate)
+
+			req := httptest.NewRequest(tc.method, "/admin/loglevel", strings.NewReader(tc.body))
+			rr := httptest.NewRecorder()
+			testCfg.handlerLogLevel(rr, req)
+
+			if rr.Code != tc.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rr.Code, tc.wantStatus, rr.Body.String())
+			}
+			if got := testCfg.logLevel.Level().String(); got != tc.wantLevel {
+				t.Errorf("level = %s, want %s", got, tc.wantLevel)
+			}
+			if got := testCfg.logSampler.rate(); got != tc.wantSampleRate {
+				t.Errorf("sample rate = %d, want %d", got, tc.wantSampleRate)
+			}
+			if tc.wantStatus != http.StatusOK {
+				return
+			}
+			var resp LogLevelResponse
+			if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+				t.Fatalf("could not decode response: %v", err)
+			}
+			if resp.Level != tc.wantLevel || resp.SampleRate != tc.wantSampleRate {
+				t.Errorf("response = %+v, want level %s and sample rate %d", resp, tc.wantLevel, tc.wantSampleRate)
+			}
+		})
+	}
+}
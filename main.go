@@ -10,20 +10,28 @@
 // @host willitrain-908739103426.europe-west1.run.app
 // @BasePath /
 // @schemes https http
+
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
 package main
 
 import (
 	"context"
 	"embed"
+	"flag"
 	"fmt"
+	"io"
 	"io/fs"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	_ "github.com/cor0nius/willitrain/docs"
-	_ "github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	httpSwagger "github.com/swaggo/http-swagger"
 )
@@ -35,6 +43,7 @@ import (
 // 3. Sets up the HTTP router with all API and frontend routes.
 // 4. Wraps the router in middleware for metrics and CORS.
 // 5. Starts the web server.
+// 6. On SIGINT or SIGTERM, shuts down the server and then drains the scheduler.
 
 // frontendFS embeds the compiled frontend assets into the Go binary.
 // This allows the application to be deployed as a single, self-contained executable.
@@ -43,6 +52,9 @@ import (
 var frontendFS embed.FS
 
 func run(ctx context.Context) error {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	// Initialize the application configuration, which includes setting up
 	// the logger, database connections, and other dependencies.
 	cfg, err := NewAPIConfig(os.Stdout)
@@ -51,6 +63,18 @@ func run(ctx context.Context) error {
 	}
 	cfg.logger.Debug("configuration loaded")
 
+	shutdownTracing, err := setupTracing(ctx, cfg.logger)
+	if err != nil {
+		return fmt.Errorf("couldn't set up tracing: %w", err)
+	}
+	defer func() {
+		flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(flushCtx); err != nil {
+			cfg.logger.Error("could not flush traces on shutdown", "error", err)
+		}
+	}()
+
 	// Establish connections to the database and cache.
 	err = cfg.ConnectDB()
 	if err != nil {
@@ -62,52 +86,164 @@ func run(ctx context.Context) error {
 		return fmt.Errorf("couldn't connect to cache: %w", err)
 	}
 
+	// Rotated secrets are picked up without a restart.
+	go cfg.watchSecrets(ctx, cfg.secretsReloadInterval)
+
+	if cfg.demoMode {
+		if err := cfg.seedDemoLocations(ctx); err != nil {
+			cfg.logger.Warn("could not add all demo cities", "error", err)
+		}
+	}
+
 	// Create and start the scheduler for periodic weather data updates.
-	scheduler := NewScheduler(cfg,
+	scheduler := NewScheduler(cfg)
+	for _, job := range scheduler.weatherJobs(
 		cfg.schedulerCurrentInterval,
 		cfg.schedulerHourlyInterval,
 		cfg.schedulerDailyInterval,
-	)
+	) {
+		if err := scheduler.RegisterJob(job); err != nil {
+			return fmt.Errorf("couldn't register scheduler job: %w", err)
+		}
+	}
+	if err := scheduler.RegisterJob(scheduler.airQualityJob(cfg.schedulerAirQualityInterval)); err != nil {
+		return fmt.Errorf("couldn't register scheduler job: %w", err)
+	}
+	if err := scheduler.RegisterJob(cfg.providerUsageJob()); err != nil {
+		return fmt.Errorf("couldn't register scheduler job: %w", err)
+	}
+	if err := scheduler.RegisterJob(cfg.requestStatsJob()); err != nil {
+		return fmt.Errorf("couldn't register scheduler job: %w", err)
+	}
+	if err := scheduler.RegisterJob(cfg.cacheKeyMigrationJob()); err != nil {
+		return fmt.Errorf("couldn't register scheduler job: %w", err)
+	}
+	if err := scheduler.RegisterJob(cfg.alertDeliveryJob()); err != nil {
+		return fmt.Errorf("couldn't register scheduler job: %w", err)
+	}
+	if err := scheduler.RegisterJob(cfg.retentionJob()); err != nil {
+		return fmt.Errorf("couldn't register scheduler job: %w", err)
+	}
+	if err := scheduler.RegisterJob(cfg.disagreementJob()); err != nil {
+		return fmt.Errorf("couldn't register scheduler job: %w", err)
+	}
 	cfg.logger.Info(
 		"starting scheduler",
 		"current", cfg.schedulerCurrentInterval.String(),
 		"hourly", cfg.schedulerHourlyInterval.String(),
 		"daily", cfg.schedulerDailyInterval.String(),
+		"air quality", cfg.schedulerAirQualityInterval.String(),
 	)
+	// Intervals changed at runtime through /admin/scheduler take precedence over the configured ones.
+	scheduler.applyStoredIntervals(ctx)
+	cfg.interruptUnfinishedJobRuns(ctx)
 	scheduler.Start()
+	if err := prometheus.Register(newSchedulerStatsCollector(scheduler)); err != nil {
+		cfg.logger.Warn("could not register scheduler stats collector", "error", err)
+	}
 
 	// Set up the HTTP request multiplexer (router).
 	mux := http.NewServeMux()
 
-	// Register the public API endpoints.
-	mux.HandleFunc("/api/config", cfg.handlerConfig)
-	mux.HandleFunc("/api/currentweather", cfg.handlerCurrentWeather)
-	mux.HandleFunc("/api/dailyforecast", cfg.handlerDailyForecast)
-	mux.HandleFunc("/api/hourlyforecast", cfg.handlerHourlyForecast)
-	mux.Handle("/metrics", promhttp.Handler())
+	// Register the public API endpoints under /api/v1, and under /api as deprecated aliases.
+	apiV1Routes := []apiRoute{
+		{"/airquality", cfg.handlerAirQuality},
+		{"/alerts", cfg.handlerAlerts},
+		{"/attribution", cfg.handlerAttribution},
+		{"/config", cfg.handlerConfig},
+		{"/consensus", cfg.handlerConsensus},
+		{"/currentweather", cfg.handlerCurrentWeather},
+		{"/currentweather/batch", cfg.handlerCurrentWeatherBatch},
+		{"/dailyforecast", cfg.handlerDailyForecast},
+		{"/disagreement", cfg.handlerDisagreement},
+		{"/export", cfg.handlerExport},
+		{"/feed.atom", cfg.handlerFeedAtom},
+		{"/feed.rss", cfg.handlerFeedRSS},
+		{"/grid", cfg.handlerGrid},
+		{"/groups", cfg.handlerLocationGroups},
+		{"/groups/currentweather", cfg.handlerLocationGroupCurrentWeather},
+		{"/groups/locations", cfg.handlerLocationGroupLocations},
+		{"/health/providers", cfg.handlerProviderHealth},
+		{"/history", cfg.handlerHistory},
+		{"/hourlyforecast", cfg.handlerHourlyForecast},
+		{"/map", cfg.handlerStaticMap},
+		{"/me/delete", cfg.handlerDeleteMyData},
+		{"/refresh", cfg.requireAPIKey(http.HandlerFunc(scheduler.handlerRefreshLocation)).ServeHTTP},
+		{"/simple/rain", cfg.handlerSimpleRain},
+		{"/simple/frost", cfg.handlerSimpleFrost},
+		{"/stations/ecowitt", cfg.handlerStationEcowitt},
+		{"/stations/weatherflow", cfg.handlerStationWeatherFlow},
+		{"/summary", cfg.handlerSummary},
+		{"/usage", cfg.handlerAPIKeyUsage},
+		{"/users/login", cfg.handlerUserLogin},
+		{"/users/me", cfg.handlerUserMe},
+		{"/users/register", cfg.handlerUserRegister},
+		{"/warnings", cfg.handlerWeatherWarnings},
+		{"/watchlist", cfg.handlerWatchlist},
+		{"/watchlist/updates", cfg.handlerWatchlistUpdates},
+	}
+	registerAPIRoutes(mux, apiV1Prefix, apiV1Routes)
+	registerLegacyAPIRoutes(mux, apiV1Prefix, apiV1Routes)
+	// The OpenMetrics format is offered so that scrapers asking for it receive the trace exemplars.
+	mux.Handle("/metrics", promhttp.InstrumentMetricHandler(prometheus.DefaultRegisterer,
+		promhttp.HandlerFor(prometheus.DefaultGatherer, promhttp.HandlerOpts{EnableOpenMetrics: true})))
 	mux.HandleFunc("/swagger/", httpSwagger.WrapHandler)
+	mux.HandleFunc("/ws", scheduler.handlerSchedulerEvents)
+
+	// The administrative endpoints are registered in production too, where the problems they
+	// diagnose and fix occur. They require an API key.
+	mux.Handle("/admin/loglevel", cfg.requireAPIKey(http.HandlerFunc(cfg.handlerLogLevel)))
+	mux.Handle("/admin/import", cfg.requireAPIKey(http.HandlerFunc(cfg.handlerImportObservations)))
+	mux.Handle("/admin/scheduler", cfg.requireAPIKey(http.HandlerFunc(scheduler.handlerUpdateSchedulerIntervals)))
+	mux.Handle("/admin/migrations", cfg.requireAPIKey(http.HandlerFunc(cfg.handlerMigrations)))
+	mux.Handle("/admin/costs", cfg.requireAPIKey(http.HandlerFunc(cfg.handlerCosts)))
+	mux.Handle("/admin/cache/keys", cfg.requireAPIKey(http.HandlerFunc(cfg.handlerCacheKeys)))
+	mux.Handle("/admin/cache/purge", cfg.requireAPIKey(http.HandlerFunc(cfg.handlerCachePurge)))
+	mux.Handle("/admin/locations", cfg.requireAPIKey(http.HandlerFunc(scheduler.handlerAdminLocations)))
+	mux.Handle("/admin/locations/{id}", cfg.requireAPIKey(http.HandlerFunc(cfg.handlerDeleteLocation)))
+	mux.Handle("/admin/locations/{id}/merge", cfg.requireAPIKey(http.HandlerFunc(cfg.handlerMergeLocation)))
+	mux.Handle("/admin/subscribers/{id}/delete", cfg.requireAPIKey(http.HandlerFunc(cfg.handlerAdminDeleteSubscriberData)))
+	mux.Handle("/admin/locations/{id}/reset", cfg.requireAPIKey(http.HandlerFunc(scheduler.handlerResetLocation)))
+	mux.Handle("/admin/locations/{id}/aliases", cfg.requireAPIKey(http.HandlerFunc(cfg.handlerLocationAliases)))
+	mux.Handle("/admin/stats/endpoints", cfg.requireAPIKey(http.HandlerFunc(cfg.handlerEndpointStats)))
+	mux.Handle("/admin/stats/locations", cfg.requireAPIKey(http.HandlerFunc(cfg.handlerLocationStats)))
+	mux.Handle("/admin/timezones/repair", cfg.requireAPIKey(http.HandlerFunc(cfg.handlerRepairTimezones)))
+	mux.Handle("/admin/locations/{id}/weights", cfg.requireAPIKey(http.HandlerFunc(cfg.handlerLocationProviderWeights)))
+	mux.Handle("/admin/jobs", cfg.requireAPIKey(http.HandlerFunc(cfg.handlerJobRuns)))
+	mux.Handle("/admin/jobs/{id}", cfg.requireAPIKey(http.HandlerFunc(cfg.handlerJobRun)))
+	mux.Handle("/admin/scheduler/runs", cfg.requireAPIKey(http.HandlerFunc(cfg.handlerSchedulerRuns)))
 
-	// Register development-only endpoints if dev mode is enabled.
+	// Register development-only endpoints if dev mode is enabled. They require an API key.
 	if cfg.devMode {
-		cfg.logger.Debug("development mode enabled. Registering /dev/reset-db, /dev/runschedulerjobs endpoints.")
-		mux.HandleFunc("/dev/reset-db", cfg.handlerResetDB)
-		mux.HandleFunc("/dev/runschedulerjobs", scheduler.handlerRunSchedulerJobs)
+		cfg.logger.Debug("development mode enabled. Registering /dev/reset-db, /dev/runschedulerjobs, /dev/scheduler endpoints.")
+		protected := func(pattern string, handler http.HandlerFunc) {
+			mux.Handle(pattern, cfg.requireAPIKey(handler))
+		}
+		protected("/dev/reset-db", cfg.handlerResetDB)
+		protected("/dev/runschedulerjobs", scheduler.handlerRunSchedulerJobs)
+		protected("/dev/scheduler/jobs", scheduler.handlerSchedulerStatus)
+		protected("/dev/scheduler/pause", scheduler.handlerPauseSchedulerJob)
+		protected("/dev/scheduler/resume", scheduler.handlerResumeSchedulerJob)
 	}
 
-	// Set up the file server to serve the embedded frontend assets.
+	// The embeddable widget is rendered from its own template, outside the frontend.
+	mux.HandleFunc("/embed", cfg.handlerEmbed)
+
+	// Serve the embedded frontend, with index.html for its client-side routes.
 	distFS, err := fs.Sub(frontendFS, "frontend/dist")
 	if err != nil {
 		return fmt.Errorf("failed to create frontend file system: %w", err)
 	}
-	mux.Handle("/", http.FileServer(http.FS(distFS)))
+	mux.Handle("/", cfg.spaHandler(distFS))
 
 	// Configure and start the HTTP server, wrapping the router with middleware.
-	// The /metrics endpoint is excluded from metricsMiddleware.
+	// The /metrics endpoint is excluded from metricsMiddleware and tracingMiddleware.
+	// requestIDMiddleware is the outermost, so every request is logged and traced with its ID.
 	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.URL.Path == "/metrics" {
 			corsMiddleware(mux).ServeHTTP(w, r)
 		} else {
-			metricsMiddleware(corsMiddleware(mux)).ServeHTTP(w, r)
+			requestIDMiddleware(userContextMiddleware(tracingMiddleware(metricsMiddleware(requestStatsMiddleware(cfg.requestStats, corsMiddleware(cfg.userSessionMiddleware(cfg.localeMiddleware(cfg.apiKeyQuotaMiddleware(cfg.namingMiddleware(cfg.fieldsMiddleware(mux))))))))))).ServeHTTP(w, r)
 		}
 	})
 
@@ -117,14 +253,28 @@ func run(ctx context.Context) error {
 		ReadHeaderTimeout: 10 * time.Second,
 	}
 
-	// Goroutine for graceful shutdown
+	// Goroutine for graceful shutdown. The server stops accepting requests first, then the
+	// scheduler is drained, so that no job is cut off while the process is still serving.
+	shutdownDone := make(chan struct{})
 	go func() {
-		<-ctx.Done() // Block until context is cancelled
+		defer close(shutdownDone)
+		<-ctx.Done() // Block until context is cancelled or a signal arrives
+		cfg.logger.Info("shutting down")
 		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
 		if err := server.Shutdown(shutdownCtx); err != nil {
 			cfg.logger.Error("server shutdown failed", "error", err)
 		}
+		scheduler.Stop()
+		cfg.notifier.wait()
+		flushCtx, cancelFlush := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancelFlush()
+		if err := cfg.flushRequestStats(flushCtx); err != nil {
+			cfg.logger.Error("could not flush request stats on shutdown", "error", err)
+		}
+		if err := cfg.flushProviderUsage(flushCtx); err != nil {
+			cfg.logger.Error("could not flush provider usage on shutdown", "error", err)
+		}
 	}()
 
 	cfg.logger.Info("starting server", "port", cfg.port)
@@ -132,10 +282,66 @@ func run(ctx context.Context) error {
 	if err != nil && err != http.ErrServerClosed {
 		return fmt.Errorf("server startup failed: %w", err)
 	}
+	<-shutdownDone
+	cfg.logger.Info("shutdown complete")
 	return nil
 }
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "mockapis":
+			if err := runMockAPIs(os.Args[2:], os.Stdout); err != nil {
+				log.Fatal(err)
+			}
+			return
+		case "get":
+			if err := runGet(context.Background(), os.Args[2:], os.Stdout, os.Stderr); err != nil {
+				log.Fatal(err)
+			}
+			return
+		}
+	}
+
+	printConfig := flag.Bool("print-config", false, "print the effective configuration with secrets redacted and exit")
+	createAPIKey := flag.String("create-api-key", "", "create an API key for the development and admin endpoints with the given name, print it and exit")
+	apiKeyQuota := flag.Int("api-key-quota", -1, "create the key of -create-api-key as a client key of the public API with this daily request quota (0 for unlimited) instead of an admin key")
+	configPath := flag.String("config", "", "path to a YAML or TOML config file, overriding CONFIG_FILE")
+	flag.Parse()
+
+	if *configPath != "" {
+		if err := os.Setenv("CONFIG_FILE", *configPath); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if *printConfig {
+		cfg, err := NewAPIConfig(io.Discard)
+		if err != nil {
+			log.Fatal(fmt.Errorf("failed to load configuration: %w", err))
+		}
+		if err := cfg.printConfig(os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if *createAPIKey != "" {
+		cfg, err := NewAPIConfig(io.Discard)
+		if err != nil {
+			log.Fatal(fmt.Errorf("failed to load configuration: %w", err))
+		}
+		if err := cfg.ConnectDB(); err != nil {
+			log.Fatal(fmt.Errorf("couldn't connect to database: %w", err))
+		}
+		key, err := cfg.createAPIKey(context.Background(), *createAPIKey, *apiKeyQuota)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(key)
+		return
+	}
+
 	if err := run(context.Background()); err != nil {
 		log.Fatal(err)
 	}
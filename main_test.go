@@ -113,7 +113,7 @@ func TestMainExecution(t *testing.T) {
 
 	// Wait for DB and Redis to be ready
 	if err := pool.Retry(func() error {
-		db, err := sql.Open("postgres", dbURL)
+		db, err := sql.Open("pgx", dbURL)
 		if err != nil {
 			return err
 		}
@@ -182,8 +182,8 @@ func TestMainExecution(t *testing.T) {
 		if err == nil {
 			t.Fatal("expected an error but got nil")
 		}
-		if !strings.Contains(err.Error(), "missing required environment variable: DB_URL") {
-			t.Errorf("expected error to contain 'missing required environment variable: DB_URL', got %q", err.Error())
+		if !strings.Contains(err.Error(), "missing required settings, set them in the environment or the config file: DB_URL (database.url)") {
+			t.Errorf("expected error to contain 'missing required settings, set them in the environment or the config file: DB_URL (database.url)', got %q", err.Error())
 		}
 	})
 
@@ -0,0 +1,89 @@
+package main
+
+import "math"
+
+// This file holds the meteorological formulas used to derive the apparent temperature and the
+// dew point when a provider does not report them. Google, OpenWeatherMap One Call and Open-Meteo
+// report both; OpenWeatherMap 2.5 reports no dew point and Met.no no apparent temperature. The
+// derived values are rounded to one decimal, as the providers report them.
+
+// apparentTemperature returns the feels-like temperature in °C for an air temperature in °C, a
+// relative humidity in percent and a wind speed in km/h, following the US National Weather
+// Service: the wind chill in cold, windy weather, the heat index in hot weather, and the air
+// temperature in between.
+func apparentTemperature(tempC float64, humidity int32, windKmh float64) float64 {
+	switch {
+	case tempC <= 10 && windKmh > 4.8:
+		return Round(windChill(tempC, windKmh), 1)
+	case tempC >= 26.7:
+		return Round(heatIndex(tempC, float64(humidity)), 1)
+	}
+	return tempC
+}
+
+// windChill returns the wind chill in °C, using the formula shared by the NWS and Environment
+// Canada. It is defined for temperatures up to 10°C and wind speeds above 4.8 km/h.
+func windChill(tempC, windKmh float64) float64 {
+	v := math.Pow(windKmh, 0.16)
+	return 13.12 + 0.6215*tempC - 11.37*v + 0.3965*tempC*v
+}
+
+// heatIndex returns the heat index in °C, using the Rothfusz regression with the NWS
+// adjustments for low and high humidity. The regression is defined in °F.
+func heatIndex(tempC, humidity float64) float64 {
+	t := tempC*9/5 + 32
+	simple := 0.5 * (t + 61 + (t-68)*1.2 + humidity*0.094)
+	if (simple+t)/2 < 80 {
+		return (simple - 32) * 5 / 9
+	}
+
+	hi := -42.379 + 2.04901523*t + 10.14333127*humidity - 0.22475541*t*humidity -
+		0.00683783*t*t - 0.05481717*humidity*humidity + 0.00122874*t*t*humidity +
+		0.00085282*t*humidity*humidity - 0.00000199*t*t*humidity*humidity
+	switch {
+	case humidity < 13 && t >= 80 && t <= 112:
+		hi -= (13 - humidity) / 4 * math.Sqrt((17-math.Abs(t-95))/17)
+	case humidity > 85 && t >= 80 && t <= 87:
+		hi += (humidity - 85) / 10 * (87 - t) / 5
+	}
+	return (hi - 32) * 5 / 9
+}
+
+// dewPoint returns the dew point in °C for an air temperature in °C and a relative humidity in
+// percent, using the Magnus formula. It returns nil without humidity, where the dew point is
+// undefined.
+func dewPoint(tempC float64, humidity int32) *float64 {
+	if humidity <= 0 {
+		return nil
+	}
+	const b, c = 17.62, 243.12
+	gamma := math.Log(float64(humidity)/100) + b*tempC/(c+tempC)
+	dp := Round(c*gamma/(b-gamma), 1)
+	return &dp
+}
+
+// withDerivedValues returns the current weather with the apparent temperature and dew point
+// computed from its other values where the source does not report them.
+func (w CurrentWeather) withDerivedValues() CurrentWeather {
+	if w.ApparentTemperature == nil {
+		at := apparentTemperature(w.Temperature, w.Humidity, w.WindSpeed)
+		w.ApparentTemperature = &at
+	}
+	if w.DewPoint == nil {
+		w.DewPoint = dewPoint(w.Temperature, w.Humidity)
+	}
+	return w
+}
+
+// withDerivedValues returns the hourly forecast with the apparent temperature and dew point
+// computed from its other values where the source does not report them.
+func (f HourlyForecast) withDerivedValues() HourlyForecast {
+	if f.ApparentTemperature == nil {
+		at := apparentTemperature(f.Temperature, f.Humidity, f.WindSpeed)
+		f.ApparentTemperature = &at
+	}
+	if f.DewPoint == nil {
+		f.DewPoint = dewPoint(f.Temperature, f.Humidity)
+	}
+	return f
+}
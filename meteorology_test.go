@@ -0,0 +1,64 @@
+package main
+
+import "testing"
+
+func TestApparentTemperature(t *testing.T) {
+	testCases := []struct {
+		name     string
+		tempC    float64
+		humidity int32
+		windKmh  float64
+		want     float64
+	}{
+		{name: "wind chill", tempC: -10, humidity: 80, windKmh: 30, want: -19.5},
+		{name: "wind chill at freezing", tempC: 0, humidity: 80, windKmh: 20, want: -5.2},
+		{name: "calm cold air", tempC: 5, humidity: 80, windKmh: 3, want: 5},
+		{name: "mild air", tempC: 20, humidity: 60, windKmh: 15, want: 20},
+		{name: "heat index", tempC: 32, humidity: 70, windKmh: 10, want: 40.4},
+		{name: "heat index below the regression threshold", tempC: 27, humidity: 40, windKmh: 10, want: 26.9},
+		{name: "heat index in dry air", tempC: 35, humidity: 10, windKmh: 10, want: 31.9},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := apparentTemperature(tc.tempC, tc.humidity, tc.windKmh); got != tc.want {
+				t.Errorf("apparentTemperature(%v, %d, %v) = %v, want %v", tc.tempC, tc.humidity, tc.windKmh, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestDewPoint(t *testing.T) {
+	testCases := []struct {
+		tempC    float64
+		humidity int32
+		want     *float64
+	}{
+		{tempC: 20, humidity: 50, want: optional(9.3)},
+		{tempC: 25, humidity: 100, want: optional(25)},
+		{tempC: -5, humidity: 80, want: optional(-7.9)},
+		{tempC: 20, humidity: 0, want: nil},
+	}
+
+	for _, tc := range testCases {
+		if got := dewPoint(tc.tempC, tc.humidity); !equalOptional(got, tc.want) {
+			t.Errorf("dewPoint(%v, %d) = %s, want %s", tc.tempC, tc.humidity, formatOptional(got), formatOptional(tc.want))
+		}
+	}
+}
+
+func TestWithDerivedValues(t *testing.T) {
+	reported := 12.5
+	weather := CurrentWeather{Temperature: 20, Humidity: 50, WindSpeed: 10, ApparentTemperature: &reported}.withDerivedValues()
+	if !equalOptional(weather.ApparentTemperature, optional(12.5)) {
+		t.Errorf("expected the reported apparent temperature to be kept, got %s", formatOptional(weather.ApparentTemperature))
+	}
+	if !equalOptional(weather.DewPoint, optional(9.3)) {
+		t.Errorf("expected the computed dew point 9.3, got %s", formatOptional(weather.DewPoint))
+	}
+
+	forecast := HourlyForecast{Temperature: -10, Humidity: 80, WindSpeed: 30}.withDerivedValues()
+	if !equalOptional(forecast.ApparentTemperature, optional(-19.5)) {
+		t.Errorf("expected the computed wind chill -19.5, got %s", formatOptional(forecast.ApparentTemperature))
+	}
+}
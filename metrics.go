@@ -15,6 +15,23 @@ var (
 		Help: "Total number of HTTP requests by path, method and code.",
 	}, []string{"path", "method", "code"})
 
+	// httpRequestDuration is a Prometheus histogram that tracks the duration of HTTP requests.
+	// It is partitioned by the matched route pattern, the HTTP method and the status class
+	// (e.g., 2xx, 5xx), so that latency percentiles and error ratios can be alerted on per
+	// endpoint.
+	httpRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "willitrain_http_request_duration_seconds",
+		Help:    "Duration of HTTP requests by route, method and status class.",
+		Buckets: []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+	}, []string{"route", "method", "status_class"})
+
+	// httpRequestsInFlight is a Prometheus gauge that reports the number of HTTP requests being
+	// served.
+	httpRequestsInFlight = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "willitrain_http_requests_in_flight",
+		Help: "Number of HTTP requests currently being served.",
+	})
+
 	// externalRequestDuration is a Prometheus histogram that tracks the duration of outgoing HTTP requests
 	// to external APIs. It is partitioned by the target host.
 	externalRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
@@ -30,4 +47,317 @@ var (
 		Help:    "Duration of parsing API responses.",
 		Buckets: prometheus.DefBuckets, // Default buckets
 	}, []string{"provider", "forecast_type"})
+
+	// externalRequestsInFlight is a Prometheus gauge that tracks the number of outgoing HTTP requests
+	// to external APIs that have been sent but have not yet completed. It is partitioned by the target host.
+	externalRequestsInFlight = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "willitrain_external_requests_in_flight",
+		Help: "Number of outgoing HTTP requests to external APIs currently in flight.",
+	}, []string{"host"})
+
+	// schedulerQueueDepth is a Prometheus gauge that tracks the number of locations still waiting
+	// to be processed in the current scheduler cycle. It is partitioned by the job type.
+	schedulerQueueDepth = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "willitrain_scheduler_queue_depth",
+		Help: "Number of locations pending in the current scheduler cycle by job type.",
+	}, []string{"job_type"})
+
+	// schedulerTimeouts is a Prometheus counter vector that tracks the scheduler job runs and
+	// location updates cancelled because they exceeded their timeout. It is partitioned by job
+	// type and by scope: "job" for a whole run, "location" for the update of one location.
+	schedulerTimeouts = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "willitrain_scheduler_timeouts_total",
+		Help: "Total number of scheduler job runs and location updates that exceeded their timeout, by job type and scope.",
+	}, []string{"job_type", "scope"})
+
+	// schedulerDeferredLocations is a Prometheus counter vector that tracks the locations left out
+	// of a scheduler cycle because of their low demand. It is partitioned by job type and by the
+	// refresh tier of the location.
+	schedulerDeferredLocations = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "willitrain_scheduler_deferred_locations_total",
+		Help: "Total number of locations left out of a scheduler cycle because of their low demand, by job type and tier.",
+	}, []string{"job_type", "tier"})
+
+	// evictedLocations is a Prometheus counter that tracks the locations deleted because nobody
+	// requested them for LOCATION_EVICT_DAYS.
+	evictedLocations = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "willitrain_evicted_locations_total",
+		Help: "Total number of locations deleted because they were not requested for LOCATION_EVICT_DAYS.",
+	})
+
+	// retentionPrunedRows is a Prometheus counter vector that tracks the rows deleted by the data
+	// retention job, by table.
+	retentionPrunedRows = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "willitrain_retention_pruned_rows_total",
+		Help: "Total number of rows deleted by the data retention job, by table.",
+	}, []string{"table"})
+
+	// schedulerLastSuccessTimestamp is a Prometheus gauge that records the Unix time at which each
+	// scheduler job type last completed a full cycle. Alerting on `time() - metric` is the intended use.
+	schedulerLastSuccessTimestamp = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "willitrain_scheduler_last_success_timestamp_seconds",
+		Help: "Unix timestamp of the last successfully completed scheduler cycle by job type.",
+	}, []string{"job_type"})
+
+	// geocoderFallbacks is a Prometheus counter vector that tracks how often a geocoding lookup was
+	// answered by a fallback geocoder after the preceding geocoders of the chain failed. It is
+	// partitioned by the geocoder that answered.
+	geocoderFallbacks = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "willitrain_geocoder_fallbacks_total",
+		Help: "Total number of geocoding lookups answered by a fallback geocoder, by geocoder.",
+	}, []string{"geocoder"})
+
+	// timezoneDisagreements is a Prometheus counter that tracks how often the weather providers
+	// reported different timezones for the same location in a single fetch.
+	timezoneDisagreements = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "willitrain_timezone_disagreements_total",
+		Help: "Total number of fetches in which providers reported different timezones for a location.",
+	})
+
+	// cacheHealthy is a Prometheus gauge that is 1 while the Redis cache is in use and 0 while it
+	// is bypassed after repeated failures.
+	cacheHealthy = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "willitrain_cache_healthy",
+		Help: "Whether the Redis cache is considered healthy (1) or is being bypassed (0).",
+	})
+
+	// cacheStateTransitions is a Prometheus counter vector that tracks how often the cache changed
+	// its health state. It is partitioned by the state entered.
+	cacheStateTransitions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "willitrain_cache_state_transitions_total",
+		Help: "Total number of cache health state transitions by the state entered.",
+	}, []string{"state"})
+
+	// cacheBypassedTotal is a Prometheus counter that tracks the cache operations skipped
+	// because the cache was unhealthy.
+	cacheBypassedTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "willitrain_cache_bypassed_total",
+		Help: "Total number of cache operations skipped while the cache was unhealthy.",
+	})
+
+	// staticGeocodeLookups is a Prometheus counter vector that tracks lookups in the bundled city
+	// dataset. It is partitioned by whether the dataset answered the lookup ("hit") or it was
+	// passed on to the external geocoder ("miss").
+	staticGeocodeLookups = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "willitrain_static_geocode_lookups_total",
+		Help: "Total number of geocoding lookups in the bundled city dataset by result.",
+	}, []string{"result"})
+
+	// owmVersionFallbacks is a Prometheus counter that tracks how often OpenWeatherMap requests
+	// were switched from One Call 3.0 to the 2.5 API after an unauthorized response.
+	owmVersionFallbacks = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "willitrain_owm_version_fallbacks_total",
+		Help: "Total number of switches from OpenWeatherMap One Call 3.0 to the 2.5 API.",
+	})
+
+	// observationsBackfilled is a Prometheus counter that tracks the observations stored by the
+	// backfill from the Open-Meteo archive for new locations.
+	observationsBackfilled = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "willitrain_observations_backfilled_total",
+		Help: "Total number of hourly observations backfilled from the Open-Meteo archive.",
+	})
+
+	// observationsImported is a Prometheus counter that tracks the observations stored by
+	// imports through /admin/import.
+	observationsImported = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "willitrain_observations_imported_total",
+		Help: "Total number of observations imported through /admin/import.",
+	})
+
+	// stationReadings is a Prometheus counter vector that tracks the readings stored from personal
+	// weather station uploads. It is partitioned by upload protocol.
+	stationReadings = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "willitrain_station_readings_total",
+		Help: "Total number of personal weather station readings stored, by protocol.",
+	}, []string{"protocol"})
+
+	// hedgedFetches is a Prometheus counter vector that tracks how often a forecast was served
+	// without a provider that missed its hedge deadline. It is partitioned by provider.
+	hedgedFetches = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "willitrain_hedged_fetches_total",
+		Help: "Total number of forecast fetches served without a slow provider by provider.",
+	}, []string{"provider"})
+
+	// quotaSkippedFetches is a Prometheus counter vector that tracks the hourly forecast fetches
+	// skipped by the quota policy. It is partitioned by provider.
+	quotaSkippedFetches = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "willitrain_quota_skipped_fetches_total",
+		Help: "Total number of hourly forecast fetches skipped because the provider's daily quota is running low, by provider.",
+	}, []string{"provider"})
+
+	// providerQuotaRemaining is a Prometheus gauge vector that reports the remaining daily call
+	// quota of each provider with a configured quota.
+	providerQuotaRemaining = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "willitrain_provider_quota_remaining",
+		Help: "Remaining daily call quota by provider.",
+	}, []string{"provider"})
+
+	// providerQuotaUsed is a Prometheus gauge vector that reports the calls made today to each
+	// provider with a configured quota.
+	providerQuotaUsed = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "willitrain_provider_quota_used",
+		Help: "Calls made to the provider in the current UTC day, by provider with a daily quota.",
+	}, []string{"provider"})
+
+	// rateLimitedFetches is a Prometheus counter vector that tracks the fetches held back by a
+	// provider's rate limit or daily quota. It is partitioned by provider and by outcome: delayed
+	// until the provider had capacity, rejected because the wait was too long, or skipped because
+	// the daily quota was used up.
+	rateLimitedFetches = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "willitrain_rate_limited_fetches_total",
+		Help: "Total number of provider fetches delayed, rejected or skipped by the rate limiter or daily quota, by provider and outcome.",
+	}, []string{"provider", "outcome"})
+
+	// apiKeyQuotaRejections is a Prometheus counter that tracks the requests rejected because
+	// their API key had used up its daily quota.
+	apiKeyQuotaRejections = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "willitrain_api_key_quota_rejections_total",
+		Help: "Total number of requests rejected because their API key had used up its daily quota.",
+	})
+
+	// circuitBreakerState is a Prometheus gauge vector that reports the circuit breaker state of
+	// each provider: 0 closed, 1 open, 2 half-open.
+	circuitBreakerState = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "willitrain_circuit_breaker_state",
+		Help: "Circuit breaker state by provider: 0 closed, 1 open, 2 half-open.",
+	}, []string{"provider"})
+
+	// circuitBreakerOpened is a Prometheus counter vector that tracks how often the circuit of
+	// each provider was opened, including re-openings after a failed trial fetch.
+	circuitBreakerOpened = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "willitrain_circuit_breaker_opened_total",
+		Help: "Total number of times a provider's circuit breaker opened, by provider.",
+	}, []string{"provider"})
+
+	// staleResponsesServed is a Prometheus counter vector that tracks the responses served from
+	// stale stored data because no provider could be fetched. It is partitioned by data type.
+	staleResponsesServed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "willitrain_stale_responses_served_total",
+		Help: "Total number of responses served from stale stored data because all provider fetches failed, by type.",
+	}, []string{"type"})
+
+	// coalescedRequests is a Prometheus counter vector that tracks the requests that missed the
+	// Redis cache and waited for a lookup of the same data already in progress instead of
+	// starting their own. It is partitioned by data type.
+	coalescedRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "willitrain_coalesced_requests_total",
+		Help: "Total number of cache misses served by a database read and provider fetch already in progress for the same data, by type.",
+	}, []string{"type"})
+
+	// fetchRetries is a Prometheus counter vector that tracks the provider requests retried after
+	// a transient failure. It is partitioned by provider and by the error class of the failure.
+	fetchRetries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "willitrain_fetch_retries_total",
+		Help: "Total number of provider requests retried after a transient failure, by provider and error class.",
+	}, []string{"provider", "error"})
+
+	// providerQuotaDegraded is a Prometheus gauge vector that is 1 while a provider's hourly
+	// forecast fetches are reduced by the quota policy and 0 otherwise.
+	providerQuotaDegraded = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "willitrain_provider_quota_degraded",
+		Help: "Whether hourly forecast fetches from the provider are reduced to priority locations (1) or not (0).",
+	}, []string{"provider"})
+
+	// cacheKeysMigrated is a Prometheus counter vector that tracks the outdated cache keys handled
+	// by the cache key migration job, by whether the value was rewritten to the current key or expired.
+	cacheKeysMigrated = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "willitrain_cache_keys_migrated_total",
+		Help: "Total number of outdated cache keys migrated, by action (rewritten, expired).",
+	}, []string{"action"})
+
+	// historyRowsArchived is a Prometheus counter vector that tracks the rows moved to the history
+	// tables by the scheduler, by history type.
+	historyRowsArchived = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "willitrain_history_rows_archived_total",
+		Help: "Total number of weather rows moved to the history tables, by type (current, hourly, daily).",
+	}, []string{"type"})
+
+	// alertsTriggered is a Prometheus counter vector that tracks the alert rules that started to
+	// match, by metric.
+	alertsTriggered = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "willitrain_alerts_triggered_total",
+		Help: "Total number of triggered alerts, by metric.",
+	}, []string{"metric"})
+
+	// alertDeliveries is a Prometheus counter vector that tracks the webhook delivery attempts of
+	// triggered alerts, by outcome.
+	alertDeliveries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "willitrain_alert_deliveries_total",
+		Help: "Total number of alert webhook delivery attempts, by outcome (delivered, retried, failed).",
+	}, []string{"outcome"})
+
+	// opsNotifications is a Prometheus counter vector that tracks the operational notifications
+	// of failing locations and opened circuits, by event and outcome.
+	opsNotifications = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "willitrain_ops_notifications_total",
+		Help: "Total number of operational notifications, by event and outcome (sent, failed, suppressed).",
+	}, []string{"event", "outcome"})
+
+	// schedulerEventClients is a Prometheus gauge that reports the number of clients connected
+	// to the scheduler event stream.
+	schedulerEventClients = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "willitrain_scheduler_event_clients",
+		Help: "Number of clients connected to the scheduler event stream.",
+	})
+
+	// schedulerEventsDropped is a Prometheus counter that tracks the scheduler events not sent to
+	// a client because its buffer was full.
+	schedulerEventsDropped = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "willitrain_scheduler_events_dropped_total",
+		Help: "Total number of scheduler events dropped for clients that could not keep up.",
+	})
+
+	// cacheLookups is a Prometheus counter vector that tracks where the weather data of requests
+	// was found: in Redis ("redis_hit"), fresh in the database ("db_hit"), or fetched from the
+	// providers ("api_fetch"). It is partitioned by data type and result. Requests that shared a
+	// lookup already in progress are counted in willitrain_coalesced_requests_total instead.
+	cacheLookups = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "willitrain_cache_lookups_total",
+		Help: "Total number of weather data lookups by type and result (redis_hit, db_hit, api_fetch).",
+	}, []string{"type", "result"})
+
+	// providerFetchDuration is a Prometheus histogram that tracks how long each provider took to
+	// return a parsed forecast, including retries. It is partitioned by provider, forecast type
+	// and outcome.
+	providerFetchDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "willitrain_provider_fetch_duration_seconds",
+		Help:    "Duration of forecast fetches from the weather providers, by provider, forecast type and outcome (ok, error).",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"provider", "forecast_type", "outcome"})
+
+	// trackedLocations is a Prometheus gauge that reports the number of locations stored in the
+	// database, as of the last scheduler cycle.
+	trackedLocations = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "willitrain_tracked_locations",
+		Help: "Number of locations stored in the database as of the last scheduler cycle.",
+	})
+
+	// schedulerJobDuration is a Prometheus gauge vector that reports how long the last run of
+	// each scheduler job took, whether it succeeded or not.
+	schedulerJobDuration = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "willitrain_scheduler_job_duration_seconds",
+		Help: "Duration of the last run of each scheduler job, by job type.",
+	}, []string{"job_type"})
+
+	// localCacheLookups is a Prometheus counter vector that tracks reads of the in-process cache
+	// in front of Redis. It is partitioned by whether the entry was found ("hit") or Redis had to
+	// be asked ("miss").
+	localCacheLookups = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "willitrain_local_cache_lookups_total",
+		Help: "Total number of in-process cache lookups by result (hit, miss).",
+	}, []string{"result"})
+
+	// localCacheEvictions is a Prometheus counter that tracks the entries dropped from the
+	// in-process cache to make room for new ones.
+	localCacheEvictions = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "willitrain_local_cache_evictions_total",
+		Help: "Total number of entries evicted from the full in-process cache.",
+	})
+
+	// secretRotations is a Prometheus counter vector that tracks the changed values found when
+	// the secrets are reloaded. It is partitioned by setting.
+	secretRotations = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "willitrain_secret_rotations_total",
+		Help: "Total number of rotated secrets picked up without a restart, by setting.",
+	}, []string{"setting"})
 )
@@ -1,9 +1,15 @@
 package main
 
 import (
+	"bufio"
+	"context"
+	"net"
 	"net/http"
 	"strconv"
 	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // This file contains the HTTP middleware functions used by the application.
@@ -31,18 +37,69 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.ResponseWriter.WriteHeader(code)
 }
 
-// metricsMiddleware is a wrapping handler that captures the HTTP status code of a
-// response and records it as a Prometheus metric, along with the request path and method.
+// Hijack lets WebSocket handlers take over the connection. A hijacked connection is recorded
+// with the 101 Switching Protocols status.
+func (rw *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	rw.statusCode = http.StatusSwitchingProtocols
+	return http.NewResponseController(rw.ResponseWriter).Hijack()
+}
+
+// Unwrap returns the wrapped ResponseWriter, so that streaming handlers can flush through it.
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// metricsMiddleware is a wrapping handler that records the count, duration and status of
+// HTTP requests as Prometheus metrics. Durations are labeled by the matched route pattern rather
+// than the path, so that latency and error ratios can be tracked per endpoint. With tracing
+// enabled, each observation carries the trace ID of a sampled request as an exemplar.
 func metricsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		httpRequestsInFlight.Inc()
+		defer httpRequestsInFlight.Dec()
+
+		start := time.Now()
 		rw := newResponseWriter(w)
 		next.ServeHTTP(rw, r)
+		duration := time.Since(start).Seconds()
 
 		statusCodeStr := strconv.Itoa(rw.statusCode)
 		httpRequestsTotal.WithLabelValues(r.URL.Path, r.Method, statusCodeStr).Inc()
+
+		// The mux records the matched pattern on the request it was given. Requests no route
+		// matched share one label, so that scanners cannot create a series per path.
+		route := r.Pattern
+		if route == "" {
+			route = "unmatched"
+		}
+		observer := httpRequestDuration.WithLabelValues(route, r.Method, statusClass(rw.statusCode))
+		if exemplar := traceExemplar(r.Context()); exemplar != nil {
+			observer.(prometheus.ExemplarObserver).ObserveWithExemplar(duration, exemplar)
+		} else {
+			observer.Observe(duration)
+		}
 	})
 }
 
+// statusClass returns the class of an HTTP status code, such as "2xx" or "5xx".
+func statusClass(code int) string {
+	if code < 100 || code > 599 {
+		return "unknown"
+	}
+	return strconv.Itoa(code/100) + "xx"
+}
+
+// traceExemplar returns the exemplar labels linking a metric observation to the trace of ctx, or
+// nil if the request is not traced or its trace is not sampled.
+func traceExemplar(ctx context.Context) prometheus.Labels {
+	span := trace.SpanFromContext(ctx)
+	sc := span.SpanContext()
+	if !span.IsRecording() || !sc.IsSampled() {
+		return nil
+	}
+	return prometheus.Labels{"trace_id": sc.TraceID().String()}
+}
+
 // corsMiddleware is a wrapping handler that adds the Access-Control-Allow-Origin
 // header to all responses to allow cross-origin requests from any domain.
 func corsMiddleware(next http.Handler) http.Handler {
@@ -63,6 +120,10 @@ type metricsTransport struct {
 // RoundTrip executes a single HTTP transaction, wrapping the call to the nested
 // RoundTripper to measure the request's duration.
 func (t *metricsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	inFlight := externalRequestsInFlight.WithLabelValues(req.URL.Host)
+	inFlight.Inc()
+	defer inFlight.Dec()
+
 	start := time.Now()
 	resp, err := t.wrapped.RoundTrip(req)
 	duration := time.Since(start).Seconds()
@@ -72,3 +133,23 @@ func (t *metricsTransport) RoundTrip(req *http.Request) (*http.Response, error)
 
 	return resp, err
 }
+
+// defaultUserAgent identifies the application to the upstream APIs. Met.no rejects requests
+// without an identifying User-Agent, and its terms ask for a way to contact the operator.
+const defaultUserAgent = "willitrain/1.0 (+https://github.com/cor0nius/willitrain)"
+
+// userAgentTransport is an http.RoundTripper that sets the User-Agent header of requests
+// that do not have one.
+type userAgentTransport struct {
+	wrapped   http.RoundTripper
+	userAgent string
+}
+
+// RoundTrip adds the User-Agent header to a copy of the request and passes it on.
+func (t *userAgentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("User-Agent") == "" && t.userAgent != "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("User-Agent", t.userAgent)
+	}
+	return t.wrapped.RoundTrip(req)
+}
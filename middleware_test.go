@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"io"
 	"net/http"
@@ -12,6 +13,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/testutil"
 	dto "github.com/prometheus/client_model/go"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 )
 
 // mockHandler is a test HTTP handler that simulates the behavior of real handlers.
@@ -101,6 +103,73 @@ func TestMetricsMiddleware(t *testing.T) {
 	}
 }
 
+func TestMetricsMiddlewareRouteDuration(t *testing.T) {
+	httpRequestDuration.Reset()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/hourlyforecast", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	})
+	mux.HandleFunc("/admin/locations/{id}", func(w http.ResponseWriter, r *http.Request) {})
+	handler := metricsMiddleware(mux)
+
+	tp := sdktrace.NewTracerProvider()
+	ctx, span := tp.Tracer("test").Start(context.Background(), "request")
+	defer span.End()
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/api/v1/hourlyforecast?city=Wroclaw", nil).WithContext(ctx),
+		httptest.NewRequest(http.MethodGet, "/admin/locations/1", nil),
+		httptest.NewRequest(http.MethodGet, "/admin/locations/2", nil),
+		httptest.NewRequest(http.MethodGet, "/wp-login.php", nil),
+	} {
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	for _, labels := range [][]string{
+		{"/api/v1/hourlyforecast", "GET", "5xx"},
+		{"/admin/locations/{id}", "GET", "2xx"},
+		{"unmatched", "GET", "4xx"},
+	} {
+		var m dto.Metric
+		if err := httpRequestDuration.WithLabelValues(labels...).(prometheus.Metric).Write(&m); err != nil {
+			t.Fatalf("could not read histogram %v: %v", labels, err)
+		}
+		want := uint64(1)
+		if labels[0] == "/admin/locations/{id}" {
+			want = 2
+		}
+		if got := m.GetHistogram().GetSampleCount(); got != want {
+			t.Errorf("count of %v = %d, want %d", labels, got, want)
+		}
+
+		var exemplar *dto.Exemplar
+		for _, b := range m.GetHistogram().GetBucket() {
+			if b.GetExemplar() != nil {
+				exemplar = b.GetExemplar()
+			}
+		}
+		traced := labels[0] == "/api/v1/hourlyforecast"
+		if traced && (exemplar == nil || exemplar.GetLabel()[0].GetValue() != span.SpanContext().TraceID().String()) {
+			t.Errorf("exemplar of %v = %v, want the trace ID", labels, exemplar)
+		}
+		if !traced && exemplar != nil {
+			t.Errorf("exemplar of untraced %v = %v, want none", labels, exemplar)
+		}
+	}
+
+	if got := testutil.ToFloat64(httpRequestsInFlight); got != 0 {
+		t.Errorf("in-flight requests = %v, want 0", got)
+	}
+}
+
+func TestStatusClass(t *testing.T) {
+	for code, want := range map[int]string{101: "1xx", 200: "2xx", 304: "3xx", 404: "4xx", 503: "5xx", 0: "unknown"} {
+		if got := statusClass(code); got != want {
+			t.Errorf("statusClass(%d) = %q, want %q", code, got, want)
+		}
+	}
+}
+
 func TestCorsMiddleware(t *testing.T) {
 	req := httptest.NewRequest("GET", "/", nil)
 	rr := httptest.NewRecorder()
@@ -148,7 +217,7 @@ func TestMetricsTransport(t *testing.T) {
 		{
 			name: "Error RoundTrip",
 			transport: &errorTransport{
-				err: errors.New("network error"),
+				Err: errors.New("network error"),
 			},
 			expectError: true,
 		},
@@ -191,3 +260,47 @@ func TestMetricsTransport(t *testing.T) {
 		})
 	}
 }
+
+// headerRecordingTransport records the User-Agent header of the last request it received.
+type headerRecordingTransport struct {
+	userAgent string
+}
+
+func (t *headerRecordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.userAgent = req.Header.Get("User-Agent")
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("OK"))}, nil
+}
+
+func TestUserAgentTransport(t *testing.T) {
+	tests := []struct {
+		name      string
+		header    string
+		userAgent string
+		want      string
+	}{
+		{name: "Sets Missing Header", userAgent: defaultUserAgent, want: defaultUserAgent},
+		{name: "Keeps Existing Header", header: "custom/1.0", userAgent: defaultUserAgent, want: "custom/1.0"},
+		{name: "Empty User-Agent", userAgent: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := &headerRecordingTransport{}
+			transport := &userAgentTransport{wrapped: recorder, userAgent: tt.userAgent}
+			req := httptest.NewRequest("GET", "http://testhost/api", nil)
+			if tt.header != "" {
+				req.Header.Set("User-Agent", tt.header)
+			}
+
+			if _, err := transport.RoundTrip(req); err != nil {
+				t.Fatalf("expected no error, but got: %v", err)
+			}
+			if recorder.userAgent != tt.want {
+				t.Errorf("expected User-Agent %q, got %q", tt.want, recorder.userAgent)
+			}
+			if tt.header == "" && req.Header.Get("User-Agent") != "" {
+				t.Error("expected the original request to be left unchanged")
+			}
+		})
+	}
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/lock"
+)
+
+// This file implements the database migrations embedded in the binary. The goose migrations in
+// sql/schema are applied to PostgreSQL on startup when MIGRATE_ON_START is set, so that a fresh
+// deploy needs no separate schema setup. They are recorded in goose's own version table, so a
+// database migrated with the goose CLI is picked up where it was left. Instances starting at the
+// same time take a PostgreSQL advisory lock, so that only one of them applies the migrations.
+// SQLite databases are always migrated on startup, from sql/sqlite/schema. /admin/migrations
+// reports which migrations are applied.
+
+//go:embed sql/schema/*.sql
+var postgresMigrations embed.FS
+
+// Migration states reported by /admin/migrations.
+const (
+	migrationStateApplied = "applied"
+	migrationStatePending = "pending"
+)
+
+// getMigrateOnStart reads MIGRATE_ON_START, which applies the pending PostgreSQL migrations when
+// the application connects to the database. Migrations are not applied unless it is true.
+func getMigrateOnStart(logger *slog.Logger) bool {
+	raw := os.Getenv("MIGRATE_ON_START")
+	if raw == "" {
+		return false
+	}
+	migrate, err := strconv.ParseBool(raw)
+	if err != nil {
+		logger.Warn("invalid MIGRATE_ON_START value, migrations disabled", "value", raw, "error", err)
+		return false
+	}
+	return migrate
+}
+
+// newPostgresMigrationProvider returns a goose provider for the embedded PostgreSQL migrations.
+// Its Close method closes db, so it must not be called.
+func newPostgresMigrationProvider(db *sql.DB) (*goose.Provider, error) {
+	fsys, err := fs.Sub(postgresMigrations, "sql/schema")
+	if err != nil {
+		return nil, err
+	}
+	locker, err := lock.NewPostgresSessionLocker()
+	if err != nil {
+		return nil, err
+	}
+	return goose.NewProvider(goose.DialectPostgres, db, fsys, goose.WithSessionLocker(locker))
+}
+
+// migratePostgres applies the embedded PostgreSQL migrations that have not been applied yet.
+func (cfg *apiConfig) migratePostgres(ctx context.Context, db *sql.DB) error {
+	provider, err := newPostgresMigrationProvider(db)
+	if err != nil {
+		return fmt.Errorf("could not load migrations: %w", err)
+	}
+	results, err := provider.Up(ctx)
+	if err != nil {
+		return fmt.Errorf("could not apply migrations: %w", err)
+	}
+	for _, result := range results {
+		cfg.logger.Info("applied migration", "version", result.Source.Version, "file", path.Base(result.Source.Path), "duration", result.Duration)
+	}
+	if len(results) == 0 {
+		cfg.logger.Info("database schema is up to date")
+	}
+	return nil
+}
+
+// migrationStatus lists the migrations embedded in the binary, ordered by version, with whether
+// they were applied to the connected database.
+func (cfg *apiConfig) migrationStatus(ctx context.Context) ([]MigrationJSON, error) {
+	if cfg.dbDriver == dbDriverSQLite {
+		return sqliteMigrationStatus(ctx, cfg.db)
+	}
+	provider, err := newPostgresMigrationProvider(cfg.db)
+	if err != nil {
+		return nil, fmt.Errorf("could not load migrations: %w", err)
+	}
+	statuses, err := provider.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+	migrations := make([]MigrationJSON, 0, len(statuses))
+	for _, status := range statuses {
+		m := MigrationJSON{
+			Version: status.Source.Version,
+			Name:    path.Base(status.Source.Path),
+			State:   migrationStatePending,
+		}
+		if status.State == goose.StateApplied {
+			m.State = migrationStateApplied
+			m.AppliedAt = status.AppliedAt.UTC().Format(time.RFC3339)
+		}
+		migrations = append(migrations, m)
+	}
+	return migrations, nil
+}
+
+// sqliteMigrationStatus lists the migrations in sql/sqlite/schema with the time migrateSQLite
+// applied them.
+func sqliteMigrationStatus(ctx context.Context, db *sql.DB) ([]MigrationJSON, error) {
+	rows, err := db.QueryContext(ctx, "SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	applied := make(map[string]time.Time)
+	for rows.Next() {
+		var version string
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = appliedAt
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	files, err := fs.Glob(sqliteFiles, "sql/sqlite/schema/*.sql")
+	if err != nil {
+		return nil, err
+	}
+	migrations := make([]MigrationJSON, 0, len(files))
+	for _, file := range files {
+		name := path.Base(file)
+		prefix, _, _ := strings.Cut(name, "_")
+		version, err := strconv.ParseInt(prefix, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration file name %s: %w", name, err)
+		}
+		m := MigrationJSON{Version: version, Name: name, State: migrationStatePending}
+		if appliedAt, ok := applied[strings.TrimSuffix(name, ".sql")]; ok {
+			m.State = migrationStateApplied
+			m.AppliedAt = appliedAt.UTC().Format(time.RFC3339)
+		}
+		migrations = append(migrations, m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// @Summary      Get migration status
+// @Description  Lists the database migrations embedded in the binary with whether and when they were applied,
+// @Description  the latest applied version and the number of pending migrations. PostgreSQL migrations are
+// @Description  applied on startup with MIGRATE_ON_START; SQLite migrations always are. Only available in
+// @Description  development mode.
+// @Tags         admin
+// @Produce      json
+// @Success      200  {object}  MigrationsResponse
+// @Failure      500  {object}  ErrorResponse "Internal Server Error - Failed to get migration status"
+// @Security     ApiKeyAuth
+// @Failure      401  {object}  ErrorResponse "Unauthorized - Missing API key"
+// @Failure      403  {object}  ErrorResponse "Forbidden - Invalid API key"
+// @Router       /admin/migrations [get]
+func (cfg *apiConfig) handlerMigrations(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		cfg.respondWithError(w, http.StatusMethodNotAllowed, "Method Not Allowed", nil)
+		return
+	}
+
+	migrations, err := cfg.migrationStatus(r.Context())
+	if err != nil {
+		cfg.respondWithError(w, http.StatusInternalServerError, "Failed to get migration status", err)
+		return
+	}
+	resp := MigrationsResponse{Driver: cfg.dbDriver, Migrations: migrations}
+	for _, m := range migrations {
+		if m.State == migrationStateApplied {
+			resp.Version = max(resp.Version, m.Version)
+		} else {
+			resp.Pending++
+		}
+	}
+	cfg.respondWithJSON(w, http.StatusOK, resp)
+}
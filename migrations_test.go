@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// TestPostgresMigrationsEmbedded checks that every migration in sql/schema is embedded and parsed.
+func TestPostgresMigrationsEmbedded(t *testing.T) {
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	provider, err := newPostgresMigrationProvider(db)
+	if err != nil {
+		t.Fatalf("newPostgresMigrationProvider: %v", err)
+	}
+	files, err := filepath.Glob("sql/schema/*.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sources := provider.ListSources()
+	if len(sources) != len(files) {
+		t.Fatalf("expected %d embedded migrations, got %d", len(files), len(sources))
+	}
+	for i, source := range sources {
+		if want := int64(i + 1); source.Version != want {
+			t.Errorf("expected migration %d to have version %d, got %d", i, want, source.Version)
+		}
+	}
+}
+
+func TestHandlerMigrations(t *testing.T) {
+	cfg := newSQLiteTestConfig(t)
+
+	rr := httptest.NewRecorder()
+	cfg.handlerMigrations(rr, httptest.NewRequest(http.MethodGet, "/admin/migrations", nil))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp MigrationsResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	files, err := filepath.Glob("sql/sqlite/schema/*.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Driver != dbDriverSQLite || resp.Pending != 0 || len(resp.Migrations) != len(files) || resp.Version != int64(len(files)) {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+	for _, m := range resp.Migrations {
+		if m.State != migrationStateApplied || m.AppliedAt == "" {
+			t.Errorf("expected migration %s to be applied, got %+v", m.Name, m)
+		}
+	}
+
+	// A migration that was not recorded is reported as pending.
+	if _, err := cfg.db.Exec("DELETE FROM schema_migrations WHERE version = $1", "001_initial"); err != nil {
+		t.Fatal(err)
+	}
+	migrations, err := cfg.migrationStatus(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if migrations[0].State != migrationStatePending || migrations[0].AppliedAt != "" {
+		t.Errorf("expected the first migration to be pending, got %+v", migrations[0])
+	}
+
+	rr = httptest.NewRecorder()
+	cfg.handlerMigrations(rr, httptest.NewRequest(http.MethodPost, "/admin/migrations", nil))
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected status 405, got %d", rr.Code)
+	}
+}
+
+func TestGetMigrateOnStart(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	for value, want := range map[string]bool{"": false, "true": true, "0": false, "yes": false} {
+		t.Setenv("MIGRATE_ON_START", value)
+		if got := getMigrateOnStart(logger); got != want {
+			t.Errorf("MIGRATE_ON_START=%q: expected %v, got %v", value, want, got)
+		}
+	}
+}
@@ -0,0 +1,318 @@
+package main
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// This file implements the mockapis sub-command, a server that mimics the Google Maps Platform,
+// OpenWeatherMap, Open-Meteo, Met.no and Nominatim endpoints the application calls and answers
+// them with the JSON fixtures in testdata, so that integration environments can run the full
+// pipeline without provider keys or internet access. Each provider is served under its own path
+// prefix, which the provider URL variables point to. The fixtures were recorded at a fixed time,
+// so their timestamps are moved forward by whole days to the current date, as the hourly
+// forecasts already in the past would be dropped otherwise. Every response can be delayed and a
+// share of them, or all responses of some providers, replaced with an error, to exercise the
+// timeouts, retries, circuit breakers and fallbacks.
+
+//go:embed testdata/*.json
+var mockFixtures embed.FS
+
+// Providers served by the mock server, used to inject failures per provider.
+const (
+	mockProviderGMP       = "gmp"
+	mockProviderOWM       = "owm"
+	mockProviderOMeteo    = "ometeo"
+	mockProviderMetNo     = "metno"
+	mockProviderNominatim = "nominatim"
+)
+
+// mockTimezoneID is the timezone of the location the fixtures were recorded for.
+const mockTimezoneID = "Europe/Warsaw"
+
+// mockUnixTimeKeys are the JSON keys whose values are unix timestamps in the fixtures.
+var mockUnixTimeKeys = []string{"time", "dt", "sunrise", "sunset", "start", "end"}
+
+// mockAPIsOptions configures the mock server.
+type mockAPIsOptions struct {
+	latency       time.Duration
+	jitter        time.Duration
+	failureRate   float64
+	failureStatus int
+	failProviders []string
+	shiftTimes    bool
+	now           func() time.Time
+}
+
+// mockRoute is a mock endpoint. fixture picks the fixture to serve from the request; an empty
+// name means the request is not supported.
+type mockRoute struct {
+	provider string
+	pattern  string
+	fixture  func(r *http.Request) string
+}
+
+// mockRoutes lists the endpoints of the mock server. The comment on each provider gives the
+// URL variables to point at it, relative to the server address.
+var mockRoutes = []mockRoute{
+	// GMP_GEOCODE_URL=/gmp/geocode/, GMP_TIMEZONE_URL=/gmp/timezone/, GMP_WEATHER_URL=/gmp/weather/
+	{mockProviderGMP, "GET /gmp/geocode/json", func(r *http.Request) string {
+		if r.URL.Query().Has("latlng") {
+			return "reverse_geocode_gmp.json"
+		}
+		return "geocode_gmp.json"
+	}},
+	{mockProviderGMP, "GET /gmp/timezone/json", nil},
+	{mockProviderGMP, "GET /gmp/weather/currentConditions:lookup", mockFixture("current_weather_gmp.json")},
+	{mockProviderGMP, "GET /gmp/weather/forecast/days:lookup", mockFixture("daily_forecast_gmp.json")},
+	{mockProviderGMP, "GET /gmp/weather/forecast/hours:lookup", mockFixture("hourly_forecast_gmp.json")},
+	// OWM_WEATHER_URL=/owm/onecall?, OWM_LEGACY_WEATHER_URL=/owm/2.5/, OWM_AIR_POLLUTION_URL=/owm/air_pollution?
+	{mockProviderOWM, "GET /owm/onecall", func(r *http.Request) string {
+		// One Call responses are selected with the parts that are not excluded.
+		exclude := strings.Split(r.URL.Query().Get("exclude"), ",")
+		switch {
+		case !slices.Contains(exclude, "current"):
+			return "current_weather_owm.json"
+		case !slices.Contains(exclude, "daily"):
+			return "daily_forecast_owm.json"
+		case !slices.Contains(exclude, "hourly"):
+			return "hourly_forecast_owm.json"
+		case !slices.Contains(exclude, "alerts"):
+			return "warnings_owm.json"
+		}
+		return ""
+	}},
+	{mockProviderOWM, "GET /owm/2.5/weather", mockFixture("current_weather_owm25.json")},
+	{mockProviderOWM, "GET /owm/2.5/forecast", mockFixture("forecast_owm25.json")},
+	{mockProviderOWM, "GET /owm/air_pollution", mockFixture("air_quality_owm.json")},
+	// OMETEO_WEATHER_URL=/ometeo/forecast?, OMETEO_AIR_QUALITY_URL=/ometeo/air-quality?
+	{mockProviderOMeteo, "GET /ometeo/forecast", func(r *http.Request) string {
+		q := r.URL.Query()
+		switch {
+		case q.Has("current"):
+			return "current_weather_ometeo.json"
+		case q.Has("hourly"):
+			return "hourly_forecast_ometeo.json"
+		case q.Has("daily"):
+			return "daily_forecast_ometeo.json"
+		}
+		return ""
+	}},
+	{mockProviderOMeteo, "GET /ometeo/air-quality", mockFixture("air_quality_ometeo.json")},
+	// METNO_WEATHER_URL=/metno/complete?
+	{mockProviderMetNo, "GET /metno/complete", mockFixture("forecast_metno.json")},
+	// NOMINATIM_URL=/nominatim/
+	{mockProviderNominatim, "GET /nominatim/search", mockFixture("geocode_nominatim.json")},
+	{mockProviderNominatim, "GET /nominatim/reverse", mockFixture("reverse_geocode_nominatim.json")},
+}
+
+func mockFixture(name string) func(*http.Request) string {
+	return func(*http.Request) string { return name }
+}
+
+// runMockAPIs parses the arguments of the mockapis sub-command and runs the mock server until
+// it fails.
+func runMockAPIs(args []string, output io.Writer) error {
+	fs := flag.NewFlagSet("mockapis", flag.ContinueOnError)
+	addr := fs.String("addr", ":8090", "address to listen on")
+	latency := fs.Duration("latency", 0, "delay added to every response")
+	jitter := fs.Duration("jitter", 0, "maximum random delay added on top of -latency")
+	failureRate := fs.Float64("failure-rate", 0, "share of requests, between 0 and 1, answered with -failure-status")
+	failureStatus := fs.Int("failure-status", http.StatusServiceUnavailable, "HTTP status of injected failures")
+	failProviders := fs.String("fail", "", "comma-separated providers whose requests always fail: gmp, owm, ometeo, metno, nominatim")
+	shiftTimes := fs.Bool("shift-times", true, "move the fixture timestamps forward to the current date")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	opts := mockAPIsOptions{
+		latency:       *latency,
+		jitter:        *jitter,
+		failureRate:   *failureRate,
+		failureStatus: *failureStatus,
+		shiftTimes:    *shiftTimes,
+		now:           time.Now,
+	}
+	if *failProviders != "" {
+		opts.failProviders = strings.Split(*failProviders, ",")
+	}
+	if err := opts.validate(); err != nil {
+		return err
+	}
+
+	logger := slog.New(slog.NewJSONHandler(output, nil))
+	server := &http.Server{
+		Addr:              *addr,
+		Handler:           newMockAPIsHandler(opts, logger),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	logger.Info("starting mock provider APIs", "addr", *addr, "latency", opts.latency, "jitter", opts.jitter, "failure_rate", opts.failureRate, "fail", opts.failProviders)
+	return server.ListenAndServe()
+}
+
+func (opts mockAPIsOptions) validate() error {
+	var errs []error
+	if opts.latency < 0 || opts.jitter < 0 {
+		errs = append(errs, errors.New("-latency and -jitter must not be negative"))
+	}
+	if opts.failureRate < 0 || opts.failureRate > 1 {
+		errs = append(errs, fmt.Errorf("-failure-rate must be between 0 and 1, got %v", opts.failureRate))
+	}
+	if opts.failureStatus < 400 || opts.failureStatus > 599 {
+		errs = append(errs, fmt.Errorf("-failure-status must be an HTTP error status, got %d", opts.failureStatus))
+	}
+	for _, provider := range opts.failProviders {
+		if !slices.ContainsFunc(mockRoutes, func(route mockRoute) bool { return route.provider == provider }) {
+			errs = append(errs, fmt.Errorf("-fail: unknown provider %q", provider))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// newMockAPIsHandler returns the handler serving mockRoutes.
+func newMockAPIsHandler(opts mockAPIsOptions, logger *slog.Logger) http.Handler {
+	mux := http.NewServeMux()
+	for _, route := range mockRoutes {
+		mux.HandleFunc(route.pattern, func(w http.ResponseWriter, r *http.Request) {
+			delay := opts.latency
+			if opts.jitter > 0 {
+				delay += rand.N(opts.jitter)
+			}
+			select {
+			case <-time.After(delay):
+			case <-r.Context().Done():
+				return
+			}
+
+			if slices.Contains(opts.failProviders, route.provider) || rand.Float64() < opts.failureRate {
+				logger.Info("injected failure", "provider", route.provider, "path", r.URL.Path, "status", opts.failureStatus)
+				http.Error(w, http.StatusText(opts.failureStatus), opts.failureStatus)
+				return
+			}
+
+			var body []byte
+			var err error
+			if route.fixture == nil {
+				body, err = json.Marshal(TimezoneAPIResponse{Status: "OK", TimeZoneID: mockTimezoneID})
+			} else if name := route.fixture(r); name == "" {
+				http.Error(w, "not supported by the mock provider APIs", http.StatusNotFound)
+				return
+			} else {
+				body, err = mockFixtures.ReadFile("testdata/" + name)
+				if err == nil && opts.shiftTimes {
+					body, err = shiftFixtureTimes(body, opts.now())
+				}
+			}
+			if err != nil {
+				logger.Error("could not serve fixture", "path", r.URL.Path, "error", err)
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			logger.Info("served request", "provider", route.provider, "path", r.URL.Path, "delay", delay)
+			w.Header().Set("Content-Type", "application/json")
+			w.Write(body)
+		})
+	}
+	return mux
+}
+
+// shiftFixtureTimes moves every timestamp in a fixture by the same whole number of days, so that
+// the earliest one falls on the date of now. The time of day, and so the local hours and dates
+// derived from it, stays the same. Unix timestamps, RFC 3339 strings and the date objects of
+// the Google Weather API are shifted.
+func shiftFixtureTimes(fixture []byte, now time.Time) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(fixture))
+	dec.UseNumber()
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	var earliest time.Time
+	walkFixtureTimes(doc, func(t time.Time) time.Time {
+		if earliest.IsZero() || t.Before(earliest) {
+			earliest = t
+		}
+		return t
+	})
+	if earliest.IsZero() {
+		return fixture, nil
+	}
+	days := int(now.UTC().Truncate(24*time.Hour).Sub(earliest.UTC().Truncate(24*time.Hour)) / (24 * time.Hour))
+	doc = walkFixtureTimes(doc, func(t time.Time) time.Time { return t.AddDate(0, 0, days) })
+	return json.Marshal(doc)
+}
+
+// walkFixtureTimes replaces every timestamp in a decoded fixture with the result of f.
+func walkFixtureTimes(v any, f func(time.Time) time.Time) any {
+	switch v := v.(type) {
+	case map[string]any:
+		if date, ok := fixtureDate(v); ok {
+			y, m, d := f(date).Date()
+			v["year"], v["month"], v["day"] = json.Number(fmt.Sprint(y)), json.Number(fmt.Sprint(int(m))), json.Number(fmt.Sprint(d))
+			return v
+		}
+		for key, child := range v {
+			if slices.Contains(mockUnixTimeKeys, key) {
+				v[key] = walkUnixTimes(child, f)
+			} else {
+				v[key] = walkFixtureTimes(child, f)
+			}
+		}
+	case []any:
+		for i, child := range v {
+			v[i] = walkFixtureTimes(child, f)
+		}
+	case string:
+		if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+			return f(t).Format(time.RFC3339Nano)
+		}
+	}
+	return v
+}
+
+// walkUnixTimes replaces the unix timestamps in v, a number or an array of numbers, with the
+// result of f.
+func walkUnixTimes(v any, f func(time.Time) time.Time) any {
+	switch v := v.(type) {
+	case json.Number:
+		// Smaller numbers, such as the timezone offsets, are not timestamps.
+		if sec, err := v.Int64(); err == nil && sec > 1e9 {
+			return json.Number(fmt.Sprint(f(time.Unix(sec, 0)).Unix()))
+		}
+	case []any:
+		for i, child := range v {
+			v[i] = walkUnixTimes(child, f)
+		}
+	default:
+		return walkFixtureTimes(v, f)
+	}
+	return v
+}
+
+// fixtureDate reads a Google Weather API date object, which has year, month and day fields.
+func fixtureDate(v map[string]any) (time.Time, bool) {
+	var parts [3]int64
+	for i, key := range []string{"year", "month", "day"} {
+		n, ok := v[key].(json.Number)
+		if !ok {
+			return time.Time{}, false
+		}
+		var err error
+		if parts[i], err = n.Int64(); err != nil {
+			return time.Time{}, false
+		}
+	}
+	return time.Date(int(parts[0]), time.Month(parts[1]), int(parts[2]), 0, 0, 0, 0, time.UTC), true
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newTestMockAPIsServer(t *testing.T, opts mockAPIsOptions) *httptest.Server {
+	t.Helper()
+	if opts.failureStatus == 0 {
+		opts.failureStatus = http.StatusServiceUnavailable
+	}
+	if opts.now == nil {
+		opts.now = time.Now
+	}
+	server := httptest.NewServer(newMockAPIsHandler(opts, slog.New(slog.NewTextHandler(io.Discard, nil))))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestMockAPIsParse(t *testing.T) {
+	server := newTestMockAPIsServer(t, mockAPIsOptions{shiftTimes: true})
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	testCases := []struct {
+		name  string
+		path  string
+		parse func(io.Reader) (int, error)
+	}{
+		{
+			name: "GMP Hourly",
+			path: "/gmp/weather/forecast/hours:lookup?key=k&location.latitude=51.11&location.longitude=17.04&hours=24",
+			parse: func(body io.Reader) (int, error) {
+				forecasts, _, err := ParseHourlyForecastGMP(body, logger, 24)
+				return len(forecasts), err
+			},
+		},
+		{
+			name: "OWM Hourly",
+			path: "/owm/onecall?lat=51.11&lon=17.04&exclude=current,minutely,daily,alerts&units=metric&appid=k",
+			parse: func(body io.Reader) (int, error) {
+				forecasts, _, err := ParseHourlyForecastOWM(body, logger, 24)
+				return len(forecasts), err
+			},
+		},
+		{
+			name: "Open-Meteo Hourly",
+			path: "/ometeo/forecast?latitude=51.11&longitude=17.04&hourly=temperature_2m&forecast_days=2",
+			parse: func(body io.Reader) (int, error) {
+				forecasts, _, err := ParseHourlyForecastOMeteo(body, logger, 24)
+				return len(forecasts), err
+			},
+		},
+		{
+			name: "Open-Meteo Daily",
+			path: "/ometeo/forecast?latitude=51.11&longitude=17.04&daily=temperature_2m_max&forecast_days=7",
+			parse: func(body io.Reader) (int, error) {
+				forecasts, _, err := ParseDailyForecastOMeteo(body, logger, 7)
+				return len(forecasts), err
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, err := http.Get(server.URL + tc.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("expected status 200, got %d", resp.StatusCode)
+			}
+			// Without shifting, every hour of the fixtures is in the past and nothing is parsed.
+			n, err := tc.parse(resp.Body)
+			if err != nil {
+				t.Fatalf("could not parse the mock response: %v", err)
+			}
+			if n == 0 {
+				t.Error("expected forecasts, got none")
+			}
+		})
+	}
+}
+
+func TestMockAPIsFailures(t *testing.T) {
+	testCases := []struct {
+		name       string
+		opts       mockAPIsOptions
+		path       string
+		wantStatus int
+	}{
+		{
+			name:       "Timezone",
+			path:       "/gmp/timezone/json?location=51.1,17.0",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "Unsupported Request",
+			path:       "/ometeo/forecast?latitude=51.11&longitude=17.04",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "Failing Provider",
+			opts:       mockAPIsOptions{failProviders: []string{mockProviderOWM}},
+			path:       "/owm/2.5/weather?lat=51.11&lon=17.04",
+			wantStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name:       "Other Provider Not Failing",
+			opts:       mockAPIsOptions{failProviders: []string{mockProviderOWM}},
+			path:       "/metno/complete?lat=51.11&lon=17.04",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "Failure Rate",
+			opts:       mockAPIsOptions{failureRate: 1, failureStatus: http.StatusTooManyRequests},
+			path:       "/nominatim/search?q=Wroclaw",
+			wantStatus: http.StatusTooManyRequests,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := newTestMockAPIsServer(t, tc.opts)
+			resp, err := http.Get(server.URL + tc.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tc.wantStatus {
+				t.Errorf("expected status %d, got %d", tc.wantStatus, resp.StatusCode)
+			}
+		})
+	}
+}
+
+func TestShiftFixtureTimes(t *testing.T) {
+	fixture := []byte(`{"dt":1754395200,"timezone_offset":7200,"hourly":[{"dt":1754398800}],"interval":{"startTime":"2025-08-05T13:00:00Z"},"displayDate":{"year":2025,"month":8,"day":5},"temp":21.37}`)
+	now := time.Date(2025, 8, 8, 9, 0, 0, 0, time.UTC)
+
+	got, err := shiftFixtureTimes(fixture, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"displayDate":{"day":8,"month":8,"year":2025},"dt":1754654400,"hourly":[{"dt":1754658000}],"interval":{"startTime":"2025-08-08T13:00:00Z"},"temp":21.37,"timezone_offset":7200}`
+	if string(got) != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+}
+
+func TestMockAPIsOptionsValidate(t *testing.T) {
+	valid := mockAPIsOptions{failureRate: 0.5, failureStatus: http.StatusBadGateway, failProviders: []string{mockProviderGMP}}
+	if err := valid.validate(); err != nil {
+		t.Errorf("expected valid options, got %v", err)
+	}
+	invalid := mockAPIsOptions{latency: -time.Second, failureRate: 2, failureStatus: http.StatusOK, failProviders: []string{"accuweather"}}
+	if err := invalid.validate(); err == nil {
+		t.Error("expected invalid options to be rejected")
+	}
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// This file implements the JSON field naming compatibility layer. Responses use snake_case
+// field names, but some consumers generate their clients from camelCase schemas. Instead of
+// keeping a second set of response types, the marshaled response is rewritten with camelCase
+// object keys (location_id becomes locationId) when the request asks for it with
+// ?naming=camel, or when it carries an X-API-Key listed in CAMEL_CASE_API_KEYS. The query
+// parameter takes precedence, so ?naming=snake restores the default for such a key.
+
+// jsonNaming selects the naming convention of JSON object keys in responses.
+type jsonNaming int
+
+const (
+	namingSnake jsonNaming = iota
+	namingCamel
+)
+
+// errInvalidNaming is returned for a naming query parameter other than snake or camel.
+var errInvalidNaming = errors.New("naming must be either snake or camel")
+
+// getCamelCaseAPIKeys reads CAMEL_CASE_API_KEYS, a comma-separated list of API keys whose
+// responses default to camelCase. The keys are kept as subscriber IDs, so the raw keys are
+// not held in memory after startup.
+func getCamelCaseAPIKeys(logger *slog.Logger) map[string]bool {
+	keys := make(map[string]bool)
+	for _, key := range strings.Split(os.Getenv("CAMEL_CASE_API_KEYS"), ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			keys[apiKeySubscriberID(key)] = true
+		}
+	}
+	if len(keys) > 0 {
+		logger.Info("camelCase responses enabled for API keys", "count", len(keys))
+	}
+	return keys
+}
+
+// requestNaming returns the naming convention requested by r.
+func (cfg *apiConfig) requestNaming(r *http.Request) (jsonNaming, error) {
+	switch r.URL.Query().Get("naming") {
+	case "snake":
+		return namingSnake, nil
+	case "camel":
+		return namingCamel, nil
+	case "":
+	default:
+		return namingSnake, errInvalidNaming
+	}
+	if apiKey := r.Header.Get("X-API-Key"); apiKey != "" && cfg.camelCaseAPIKeys[apiKeySubscriberID(apiKey)] {
+		return namingCamel, nil
+	}
+	return namingSnake, nil
+}
+
+// namingResponseWriter carries the requested naming convention to respondWithJSON.
+type namingResponseWriter struct {
+	http.ResponseWriter
+	naming jsonNaming
+}
+
+// Hijack lets WebSocket handlers take over the connection.
+func (nw *namingResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(nw.ResponseWriter).Hijack()
+}
+
+// Unwrap returns the wrapped ResponseWriter.
+func (nw *namingResponseWriter) Unwrap() http.ResponseWriter {
+	return nw.ResponseWriter
+}
+
+// namingMiddleware determines the naming convention of a request and passes it on to the
+// handler with the ResponseWriter. Requests with an invalid naming parameter are rejected.
+func (cfg *apiConfig) namingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(cfg.camelCaseAPIKeys) > 0 {
+			w.Header().Add("Vary", "X-API-Key")
+		}
+		naming, err := cfg.requestNaming(r)
+		if err != nil {
+			cfg.respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+			return
+		}
+		if naming == namingSnake {
+			next.ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(&namingResponseWriter{ResponseWriter: w, naming: naming}, r)
+	})
+}
+
+// responseNaming returns the naming convention attached to w by namingMiddleware.
+func responseNaming(w http.ResponseWriter) jsonNaming {
+	if nw, ok := findResponseWriter[*namingResponseWriter](w); ok {
+		return nw.naming
+	}
+	return namingSnake
+}
+
+// findResponseWriter returns the first ResponseWriter of type T in the chain of writers
+// wrapped by w, including w itself.
+func findResponseWriter[T http.ResponseWriter](w http.ResponseWriter) (T, bool) {
+	for {
+		if t, ok := w.(T); ok {
+			return t, true
+		}
+		u, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			var zero T
+			return zero, false
+		}
+		w = u.Unwrap()
+	}
+}
+
+// camelCaseKeys rewrites the object keys of a JSON document to camelCase. Values, including
+// strings that look like keys, and the order of fields are left unchanged.
+func camelCaseKeys(data []byte) ([]byte, error) {
+	return rewriteKeys(data, snakeToCamel)
+}
+
+// rewriteKeys replaces every object key of a JSON document with rename(key), leaving values and
+// the order of fields unchanged.
+func rewriteKeys(data []byte, rename func(string) string) ([]byte, error) {
+	return rewriteJSON(data, rename, nil)
+}
+
+// rewriteJSON replaces every object key of a JSON document with rename(key) and every string
+// value of an object field with value(key, s), leaving the order of fields unchanged. Either
+// function may be nil to keep keys or values as they are.
+func rewriteJSON(data []byte, rename func(string) string, value func(key, s string) string) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	// Each frame counts the tokens written to an open object or array. In an object, an even
+	// count means the next token is a key.
+	type frame struct {
+		object bool
+		n      int
+	}
+	var stack []frame
+	var buf bytes.Buffer
+	var key string
+
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if d, ok := tok.(json.Delim); ok && (d == '}' || d == ']') {
+			stack = stack[:len(stack)-1]
+			buf.WriteRune(rune(d))
+			if len(stack) > 0 {
+				stack[len(stack)-1].n++
+			}
+			continue
+		}
+
+		isKey := false
+		if len(stack) > 0 {
+			top := &stack[len(stack)-1]
+			isKey = top.object && top.n%2 == 0
+			if top.n > 0 && (isKey || !top.object) {
+				buf.WriteByte(',')
+			}
+		}
+
+		if d, ok := tok.(json.Delim); ok {
+			buf.WriteRune(rune(d))
+			stack = append(stack, frame{object: d == '{'})
+			continue
+		}
+
+		if isKey {
+			k, ok := tok.(string)
+			if !ok {
+				return nil, fmt.Errorf("unexpected object key %v", tok)
+			}
+			key = k
+			if rename != nil {
+				tok = rename(k)
+			}
+		} else if s, ok := tok.(string); ok && value != nil && len(stack) > 0 && stack[len(stack)-1].object {
+			tok = value(key, s)
+		}
+		encoded, err := json.Marshal(tok)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(encoded)
+		if isKey {
+			buf.WriteByte(':')
+		}
+		if len(stack) > 0 {
+			stack[len(stack)-1].n++
+		}
+	}
+	if len(stack) > 0 {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return buf.Bytes(), nil
+}
+
+// snakeToCamel converts a snake_case name to camelCase. Names without underscores are
+// returned unchanged.
+func snakeToCamel(s string) string {
+	if !strings.Contains(s, "_") {
+		return s
+	}
+	var b strings.Builder
+	b.Grow(len(s))
+	upper := false
+	for _, part := range strings.Split(s, "_") {
+		if part == "" {
+			continue
+		}
+		if upper {
+			r, size := utf8.DecodeRuneInString(part)
+			b.WriteRune(unicode.ToUpper(r))
+			b.WriteString(part[size:])
+		} else {
+			b.WriteString(part)
+		}
+		upper = true
+	}
+	return b.String()
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSnakeToCamel(t *testing.T) {
+	testCases := map[string]string{
+		"location_id":        "locationId",
+		"temperature_c":      "temperatureC",
+		"total_deleted":      "totalDeleted",
+		"wind_speed_kmh_max": "windSpeedKmhMax",
+		"city":               "city",
+		"cityName":           "cityName",
+		"_private":           "private",
+	}
+	for in, want := range testCases {
+		if got := snakeToCamel(in); got != want {
+			t.Errorf("snakeToCamel(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestCamelCaseKeys(t *testing.T) {
+	testCases := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			name: "nested objects and arrays",
+			in:   `{"location":{"city_name":"Wrocław","country_code":"PL"},"forecasts":[{"source_api":"Open-Meteo API","max_temp_c":21.5},{"source_api":"snake_value","max_temp_c":-3}],"empty_list":[],"empty_obj":{}}`,
+			want: `{"location":{"cityName":"Wrocław","countryCode":"PL"},"forecasts":[{"sourceApi":"Open-Meteo API","maxTempC":21.5},{"sourceApi":"snake_value","maxTempC":-3}],"emptyList":[],"emptyObj":{}}`,
+		},
+		{
+			name: "field order and number formatting are kept",
+			in:   `{"z_last":1e-7,"a_first":12345678901234567890,"flag_set":true,"no_value":null}`,
+			want: `{"zLast":1e-7,"aFirst":12345678901234567890,"flagSet":true,"noValue":null}`,
+		},
+		{
+			name: "top-level array of scalars",
+			in:   `["snake_case",1,[2,3]]`,
+			want: `["snake_case",1,[2,3]]`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := camelCaseKeys([]byte(tc.in))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(got) != tc.want {
+				t.Errorf("got  %s\nwant %s", got, tc.want)
+			}
+		})
+	}
+
+	if _, err := camelCaseKeys([]byte(`{"broken":`)); err == nil {
+		t.Error("expected an error for truncated JSON")
+	}
+}
+
+func TestNamingMiddleware(t *testing.T) {
+	testCfg := newTestAPIConfig(t)
+	cfg := testCfg.apiConfig
+	cfg.camelCaseAPIKeys = map[string]bool{apiKeySubscriberID("partner-key"): true}
+
+	handler := cfg.namingMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cfg.respondWithJSON(w, http.StatusOK, LocationAliasJSON{Alias: "wro", LocationID: "123"})
+	}))
+
+	testCases := []struct {
+		name       string
+		query      string
+		apiKey     string
+		wantStatus int
+		wantBody   string
+	}{
+		{"default is snake_case", "", "", http.StatusOK, `{"alias":"wro","location_id":"123"}`},
+		{"camel requested", "?naming=camel", "", http.StatusOK, `{"alias":"wro","locationId":"123"}`},
+		{"snake requested", "?naming=snake", "", http.StatusOK, `{"alias":"wro","location_id":"123"}`},
+		{"api key preference", "", "partner-key", http.StatusOK, `{"alias":"wro","locationId":"123"}`},
+		{"query overrides api key preference", "?naming=snake", "partner-key", http.StatusOK, `{"alias":"wro","location_id":"123"}`},
+		{"other api key", "", "other-key", http.StatusOK, `{"alias":"wro","location_id":"123"}`},
+		{"invalid naming", "?naming=kebab", "", http.StatusBadRequest, `{"error":"naming must be either snake or camel"}`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/test"+tc.query, nil)
+			if tc.apiKey != "" {
+				req.Header.Set("X-API-Key", tc.apiKey)
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != tc.wantStatus {
+				t.Errorf("status = %d, want %d", rr.Code, tc.wantStatus)
+			}
+			if got := strings.TrimSpace(rr.Body.String()); got != tc.wantBody {
+				t.Errorf("body = %s, want %s", got, tc.wantBody)
+			}
+			if rr.Header().Get("Vary") != "X-API-Key" {
+				t.Errorf("Vary = %q, want X-API-Key", rr.Header().Get("Vary"))
+			}
+		})
+	}
+}
+
+func TestGetCamelCaseAPIKeys(t *testing.T) {
+	t.Setenv("CAMEL_CASE_API_KEYS", " partner-key, ,other-key")
+	keys := getCamelCaseAPIKeys(newTestAPIConfig(t).logger)
+	if len(keys) != 2 || !keys[apiKeySubscriberID("partner-key")] || !keys[apiKeySubscriberID("other-key")] {
+		t.Errorf("unexpected keys: %v", keys)
+	}
+}
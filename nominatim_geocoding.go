@@ -0,0 +1,182 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// This file implements geocoding with OpenStreetMap Nominatim, an alternative to the Google
+// geocoder that needs no API key and can be self-hosted. The public instance's usage policy
+// allows at most one request per second from an application that identifies itself with its
+// User-Agent, and requires the OpenStreetMap attribution to be displayed. Requests are
+// therefore throttled, the shared HTTP client sets the User-Agent, and /api/attribution lists
+// the notice while Nominatim is one of the configured geocoders.
+
+const (
+	// defaultNominatimURL is the base URL of the public Nominatim instance.
+	defaultNominatimURL = "https://nominatim.openstreetmap.org/"
+
+	// nominatimMinInterval is the minimum time between two requests to Nominatim, as required
+	// by the usage policy of the public instance.
+	nominatimMinInterval = time.Second
+)
+
+// nominatimAttribution is the attribution entry required for data from Nominatim.
+var nominatimAttribution = AttributionJSON{
+	Provider:    nominatimGeocoder,
+	DisplayName: "OpenStreetMap Nominatim",
+	HomepageURL: "https://nominatim.org",
+	LicenseName: "ODbL 1.0",
+	LicenseURL:  "https://www.openstreetmap.org/copyright",
+	Notice:      "Geocoding data © OpenStreetMap contributors",
+}
+
+// NominatimGeocodingService is an implementation of GeocodingService that uses the Nominatim
+// search and reverse geocoding API.
+type NominatimGeocodingService struct {
+	baseURL     string
+	httpClient  *http.Client
+	minInterval time.Duration
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+// NewNominatimGeocodingService creates a new NominatimGeocodingService that sends at most one
+// request per second.
+func NewNominatimGeocodingService(baseURL string, httpClient *http.Client) *NominatimGeocodingService {
+	return &NominatimGeocodingService{
+		baseURL:     baseURL,
+		httpClient:  httpClient,
+		minInterval: nominatimMinInterval,
+	}
+}
+
+// Geocode looks up the city with the given name. Only settlements are considered, so that a
+// name like "Paris" does not resolve to a street of that name.
+func (s *NominatimGeocodingService) Geocode(cityName string) (Location, error) {
+	params := url.Values{}
+	params.Set("q", cityName)
+	params.Set("featureType", "settlement")
+	params.Set("limit", "1")
+
+	var results []nominatimResult
+	if err := s.performRequest("search", params, &results); err != nil {
+		return Location{}, err
+	}
+	if len(results) == 0 {
+		return Location{}, ErrNoResultsFound
+	}
+	return results[0].location()
+}
+
+// ReverseGeocode looks up the city at the given coordinates.
+func (s *NominatimGeocodingService) ReverseGeocode(lat, lng float64) (Location, error) {
+	params := url.Values{}
+	params.Set("lat", fmt.Sprintf("%.2f", lat))
+	params.Set("lon", fmt.Sprintf("%.2f", lng))
+	// Zoom level 10 limits the result to cities and towns.
+	params.Set("zoom", "10")
+
+	var result nominatimResult
+	if err := s.performRequest("reverse", params, &result); err != nil {
+		return Location{}, err
+	}
+	if result.Error != "" {
+		return Location{}, ErrNoResultsFound
+	}
+	return result.location()
+}
+
+// performRequest waits for the throttle and sends a request to the given Nominatim endpoint,
+// decoding the JSON response into v.
+func (s *NominatimGeocodingService) performRequest(endpoint string, params url.Values, v any) error {
+	params.Set("format", "jsonv2")
+	params.Set("addressdetails", "1")
+	reqURL := s.baseURL + endpoint + "?" + params.Encode()
+
+	s.wait()
+	resp, err := s.httpClient.Get(reqURL)
+	if err != nil {
+		return fmt.Errorf("nominatim request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("nominatim request returned non-200 status: %s", resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode nominatim response: %w", err)
+	}
+	return nil
+}
+
+// wait blocks until the next request may be sent. Concurrent callers are given consecutive
+// slots minInterval apart.
+func (s *NominatimGeocodingService) wait() {
+	s.mu.Lock()
+	now := time.Now()
+	slot := s.next
+	if slot.Before(now) {
+		slot = now
+	}
+	s.next = slot.Add(s.minInterval)
+	s.mu.Unlock()
+
+	time.Sleep(time.Until(slot))
+}
+
+// The following structs represent the parts of the Nominatim jsonv2 response used by the
+// geocoder. Coordinates are encoded as strings.
+type nominatimResult struct {
+	Lat     string           `json:"lat"`
+	Lon     string           `json:"lon"`
+	Name    string           `json:"name"`
+	Address nominatimAddress `json:"address"`
+	Error   string           `json:"error"`
+}
+
+type nominatimAddress struct {
+	City         string `json:"city"`
+	Town         string `json:"town"`
+	Village      string `json:"village"`
+	Municipality string `json:"municipality"`
+	CountryCode  string `json:"country_code"`
+}
+
+// location converts a Nominatim result to a Location. The city name is taken from the most
+// specific settlement in the address, falling back to the name of the result.
+func (r nominatimResult) location() (Location, error) {
+	lat, err := strconv.ParseFloat(r.Lat, 64)
+	if err != nil {
+		return Location{}, fmt.Errorf("invalid latitude in nominatim response: %w", err)
+	}
+	lon, err := strconv.ParseFloat(r.Lon, 64)
+	if err != nil {
+		return Location{}, fmt.Errorf("invalid longitude in nominatim response: %w", err)
+	}
+
+	cityName := r.Name
+	for _, name := range []string{r.Address.City, r.Address.Town, r.Address.Village, r.Address.Municipality} {
+		if name != "" {
+			cityName = name
+			break
+		}
+	}
+	if cityName == "" {
+		return Location{}, ErrNoResultsFound
+	}
+
+	return Location{
+		CityName:    cityName,
+		Latitude:    lat,
+		Longitude:   lon,
+		CountryCode: strings.ToUpper(r.Address.CountryCode),
+	}, nil
+}
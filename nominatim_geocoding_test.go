@@ -0,0 +1,164 @@
+package main
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestNominatimGeocodingService(t *testing.T) {
+	serveFile := func(name string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			data, err := testData.ReadFile(name)
+			if err != nil {
+				t.Fatalf("Failed to read test data: %v", err)
+			}
+			_, _ = w.Write(data)
+		}
+	}
+
+	testCases := []struct {
+		name             string
+		isReverse        bool
+		handler          http.HandlerFunc
+		wantPath         string
+		expectedLocation Location
+		expectedErr      error
+		expectErr        bool
+	}{
+		{
+			name:     "Successful Geocode",
+			handler:  serveFile("testdata/geocode_nominatim.json"),
+			wantPath: "/search",
+			expectedLocation: Location{
+				CityName:    "Wrocław",
+				CountryCode: "PL",
+				Latitude:    51.1263106,
+				Longitude:   16.9781963,
+			},
+		},
+		{
+			name:      "Successful Reverse Geocode",
+			isReverse: true,
+			handler:   serveFile("testdata/reverse_geocode_nominatim.json"),
+			wantPath:  "/reverse",
+			expectedLocation: Location{
+				CityName:    "Wrocław",
+				CountryCode: "PL",
+				Latitude:    51.1263106,
+				Longitude:   16.9781963,
+			},
+		},
+		{
+			name: "Town Instead Of City",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`[{"lat":"50.2","lon":"16.8","name":"Gmina","address":{"town":"Kłodzko","country_code":"pl"}}]`))
+			},
+			expectedLocation: Location{CityName: "Kłodzko", CountryCode: "PL", Latitude: 50.2, Longitude: 16.8},
+		},
+		{
+			name: "No Results",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`[]`))
+			},
+			expectErr:   true,
+			expectedErr: ErrNoResultsFound,
+		},
+		{
+			name:      "Reverse Geocode Without Result",
+			isReverse: true,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`{"error":"Unable to geocode"}`))
+			},
+			expectErr:   true,
+			expectedErr: ErrNoResultsFound,
+		},
+		{
+			name: "API Error",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusTooManyRequests)
+			},
+			expectErr: true,
+		},
+		{
+			name: "Invalid Coordinates",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`[{"lat":"north","lon":"16.8","name":"Kłodzko"}]`))
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := setupMockServer(func(w http.ResponseWriter, r *http.Request) {
+				if tc.wantPath != "" && r.URL.Path != tc.wantPath {
+					t.Errorf("expected path %q, got %q", tc.wantPath, r.URL.Path)
+				}
+				if got := r.URL.Query().Get("format"); got != "jsonv2" {
+					t.Errorf("expected format jsonv2, got %q", got)
+				}
+				tc.handler(w, r)
+			})
+			defer server.Close()
+
+			geocoder := NewNominatimGeocodingService(server.URL+"/", server.Client())
+			geocoder.minInterval = 0
+
+			var location Location
+			var err error
+			if tc.isReverse {
+				location, err = geocoder.ReverseGeocode(51.11, 17.04)
+			} else {
+				location, err = geocoder.Geocode("wroclaw")
+			}
+
+			if tc.expectErr {
+				if err == nil {
+					t.Fatal("Expected an error, but got nil")
+				}
+				if tc.expectedErr != nil && !errors.Is(err, tc.expectedErr) {
+					t.Errorf("Expected error %v, got %v", tc.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Returned an unexpected error: %v", err)
+			}
+			if location.CityName != tc.expectedLocation.CityName || location.CountryCode != tc.expectedLocation.CountryCode {
+				t.Errorf("Expected %s, %s, got %s, %s", tc.expectedLocation.CityName, tc.expectedLocation.CountryCode, location.CityName, location.CountryCode)
+			}
+			if math.Abs(location.Latitude-tc.expectedLocation.Latitude) > 0.0001 || math.Abs(location.Longitude-tc.expectedLocation.Longitude) > 0.0001 {
+				t.Errorf("Expected coordinates %f, %f, got %f, %f", tc.expectedLocation.Latitude, tc.expectedLocation.Longitude, location.Latitude, location.Longitude)
+			}
+		})
+	}
+}
+
+func TestNominatimGeocodingServiceThrottle(t *testing.T) {
+	requests := 0
+	server := setupMockServer(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		_, _ = w.Write([]byte(`[]`))
+	})
+	defer server.Close()
+
+	geocoder := NewNominatimGeocodingService(server.URL+"/", server.Client())
+	geocoder.minInterval = 50 * time.Millisecond
+	start := time.Now()
+	for range 3 {
+		_, _ = geocoder.Geocode("wroclaw")
+	}
+	elapsed := time.Since(start)
+
+	if requests != 3 {
+		t.Fatalf("expected 3 requests, got %d", requests)
+	}
+	// The arrival times at the server jitter, so the throttle is checked on the client side:
+	// the third request cannot be sent before two intervals have passed.
+	if want := 2 * geocoder.minInterval; elapsed < want {
+		t.Errorf("3 requests sent within %v, want at least %v", elapsed, want)
+	}
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+)
+
+// This file implements operational notifications. When a scheduler job fails for a location
+// NOTIFY_FAILURE_THRESHOLD times in a row, or a provider's circuit opens, a notification with the
+// job, location, provider and error is posted to the Slack incoming webhook in
+// NOTIFY_SLACK_WEBHOOK_URL and, as JSON, to the generic webhook in NOTIFY_WEBHOOK_URL. The same
+// problem is reported again only after NOTIFY_COOLDOWN_MIN, so that a location or provider that
+// keeps failing does not flood the channel. Notifications are sent in the background and are not
+// retried; they complement the metrics and logs rather than replacing them.
+
+const (
+	defaultNotifyFailureThreshold = 3
+	defaultNotifyCooldownMin      = 60
+	// notifyTimeout bounds every webhook call.
+	notifyTimeout = 10 * time.Second
+)
+
+// Events of operational notifications.
+const (
+	notifyEventLocationFailing = "location_failing"
+	notifyEventCircuitOpened   = "circuit_opened"
+)
+
+// getNotifyWebhookURL reads an optional webhook URL from key. Values that are not absolute http
+// or https URLs are ignored.
+func getNotifyWebhookURL(key string, logger *slog.Logger) string {
+	val := os.Getenv(key)
+	if val == "" {
+		return ""
+	}
+	u, err := url.Parse(val)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		logger.Warn("notification webhook must be an absolute http or https URL, ignoring", "key", key)
+		return ""
+	}
+	return val
+}
+
+// getNotifyFailureThreshold reads the number of consecutive failed updates of a location after
+// which a notification is sent from NOTIFY_FAILURE_THRESHOLD. Values below 1 are ignored.
+func getNotifyFailureThreshold(logger *slog.Logger) int {
+	n := getEnvAsInt("NOTIFY_FAILURE_THRESHOLD", defaultNotifyFailureThreshold, logger)
+	if n < 1 {
+		logger.Warn("NOTIFY_FAILURE_THRESHOLD must be at least 1, using default", "value", n)
+		return defaultNotifyFailureThreshold
+	}
+	return n
+}
+
+// getNotifyCooldown reads how long the same problem is not reported again from
+// NOTIFY_COOLDOWN_MIN. Negative values are ignored.
+func getNotifyCooldown(logger *slog.Logger) time.Duration {
+	minutes := getEnvAsInt("NOTIFY_COOLDOWN_MIN", defaultNotifyCooldownMin, logger)
+	if minutes < 0 {
+		logger.Warn("NOTIFY_COOLDOWN_MIN must not be negative, using default", "value", minutes)
+		minutes = defaultNotifyCooldownMin
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// opsNotifier sends operational notifications. A nil value, or one without webhooks, is valid
+// and sends nothing.
+type opsNotifier struct {
+	slackURL   string
+	webhookURL string
+	threshold  int
+	cooldown   time.Duration
+	client     *http.Client
+	logger     *slog.Logger
+	now        func() time.Time
+
+	mu sync.Mutex
+	// failures counts the consecutive failed updates per job and location.
+	failures map[string]int
+	// sent holds when each problem was last reported.
+	sent map[string]time.Time
+	// wg tracks the notifications in flight.
+	wg sync.WaitGroup
+}
+
+func newOpsNotifier(slackURL, webhookURL string, threshold int, cooldown time.Duration, client *http.Client, logger *slog.Logger) *opsNotifier {
+	return &opsNotifier{
+		slackURL:   slackURL,
+		webhookURL: webhookURL,
+		threshold:  threshold,
+		cooldown:   cooldown,
+		client:     client,
+		logger:     logger,
+		now:        time.Now,
+		failures:   make(map[string]int),
+		sent:       make(map[string]time.Time),
+	}
+}
+
+// enabled reports whether notifications are sent anywhere.
+func (n *opsNotifier) enabled() bool {
+	return n != nil && (n.slackURL != "" || n.webhookURL != "")
+}
+
+// recordLocationUpdate counts the outcome of a job's update of a location and reports the
+// location once it failed threshold times in a row. Skipped and cancelled updates are not
+// counted.
+func (n *opsNotifier) recordLocationUpdate(jobType string, location Location, err error) {
+	if !n.enabled() || errors.Is(err, errUpdateSkipped) || errors.Is(err, context.Canceled) {
+		return
+	}
+	key := jobType + ":" + location.LocationID.String()
+	n.mu.Lock()
+	if err == nil {
+		delete(n.failures, key)
+		n.mu.Unlock()
+		return
+	}
+	n.failures[key]++
+	failures := n.failures[key]
+	n.mu.Unlock()
+
+	if failures < n.threshold {
+		return
+	}
+	n.notify(key, OpsNotificationPayload{
+		Event:               notifyEventLocationFailing,
+		Job:                 jobType,
+		LocationID:          location.LocationID.String(),
+		CityName:            location.CityName,
+		ConsecutiveFailures: failures,
+		Error:               err.Error(),
+	})
+}
+
+// circuitOpened reports that a provider's circuit opened after the given consecutive failures.
+func (n *opsNotifier) circuitOpened(providerID string, failures int, cooldown time.Duration) {
+	if !n.enabled() {
+		return
+	}
+	n.notify("circuit:"+providerID, OpsNotificationPayload{
+		Event:               notifyEventCircuitOpened,
+		Provider:            providerID,
+		ConsecutiveFailures: failures,
+		RetryAfter:          cooldown.String(),
+	})
+}
+
+// notify sends a notification in the background, unless the problem with the given key was
+// reported within the cooldown.
+func (n *opsNotifier) notify(key string, payload OpsNotificationPayload) {
+	now := n.now()
+	n.mu.Lock()
+	if last, ok := n.sent[key]; ok && now.Sub(last) < n.cooldown {
+		n.mu.Unlock()
+		opsNotifications.WithLabelValues(payload.Event, "suppressed").Inc()
+		return
+	}
+	n.sent[key] = now
+	n.mu.Unlock()
+
+	payload.Time = now.UTC().Format(time.RFC3339)
+	payload.Summary = notificationSummary(payload)
+	n.wg.Add(1)
+	go func() {
+		defer n.wg.Done()
+		ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+		defer cancel()
+		if n.slackURL != "" {
+			n.send(ctx, n.slackURL, payload.Event, SlackMessage{Text: ":rotating_light: " + payload.Summary})
+		}
+		if n.webhookURL != "" {
+			n.send(ctx, n.webhookURL, payload.Event, payload)
+		}
+	}()
+}
+
+// send posts a notification body to a webhook and records the outcome.
+func (n *opsNotifier) send(ctx context.Context, webhookURL, event string, body any) {
+	if err := n.post(ctx, webhookURL, body); err != nil {
+		n.logger.Warn("could not send notification", "event", event, "error", err)
+		opsNotifications.WithLabelValues(event, "failed").Inc()
+		return
+	}
+	opsNotifications.WithLabelValues(event, "sent").Inc()
+}
+
+// post sends a JSON body to a webhook. Any status other than 2xx counts as a failure.
+func (n *opsNotifier) post(ctx context.Context, webhookURL string, body any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("could not encode notification: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhookURL, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("could not create notification request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("could not call webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// wait blocks until the notifications in flight have been sent.
+func (n *opsNotifier) wait() {
+	if n == nil {
+		return
+	}
+	n.wg.Wait()
+}
+
+// notificationSummary describes a notification in one line of text.
+func notificationSummary(p OpsNotificationPayload) string {
+	switch p.Event {
+	case notifyEventLocationFailing:
+		return fmt.Sprintf("%s job failed %d times in a row for %s (%s): %s", p.Job, p.ConsecutiveFailures, p.CityName, p.LocationID, p.Error)
+	case notifyEventCircuitOpened:
+		return fmt.Sprintf("Circuit of provider %s opened after %d consecutive failures; retrying in %s", p.Provider, p.ConsecutiveFailures, p.RetryAfter)
+	}
+	return p.Event
+}
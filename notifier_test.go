@@ -0,0 +1,145 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// notificationRecorder is a webhook receiver that records the bodies posted to it.
+type notificationRecorder struct {
+	mu     sync.Mutex
+	bodies map[string][]string
+}
+
+func newNotificationServer(t *testing.T) (*httptest.Server, *notificationRecorder) {
+	rec := &notificationRecorder{bodies: make(map[string][]string)}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		rec.mu.Lock()
+		rec.bodies[r.URL.Path] = append(rec.bodies[r.URL.Path], string(body))
+		rec.mu.Unlock()
+	}))
+	t.Cleanup(server.Close)
+	return server, rec
+}
+
+func (rec *notificationRecorder) received(path string) []string {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return rec.bodies[path]
+}
+
+func TestGetNotifyWebhookURL(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	t.Setenv("NOTIFY_WEBHOOK_URL", "https://hooks.example.com/ops")
+	if got := getNotifyWebhookURL("NOTIFY_WEBHOOK_URL", logger); got != "https://hooks.example.com/ops" {
+		t.Errorf("got %q, want the configured URL", got)
+	}
+	t.Setenv("NOTIFY_WEBHOOK_URL", "hooks.example.com/ops")
+	if got := getNotifyWebhookURL("NOTIFY_WEBHOOK_URL", logger); got != "" {
+		t.Errorf("got %q, want a relative URL to be ignored", got)
+	}
+}
+
+func TestOpsNotifierLocationFailures(t *testing.T) {
+	server, rec := newNotificationServer(t)
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	n := newOpsNotifier(server.URL+"/slack", server.URL+"/hook", 3, time.Hour, server.Client(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	n.now = func() time.Time { return now }
+	location := Location{LocationID: uuid.New(), CityName: "Wroclaw"}
+	errFetch := errors.New("all providers failed")
+
+	n.recordLocationUpdate("hourly", location, errFetch)
+	n.recordLocationUpdate("hourly", location, errFetch)
+	n.recordLocationUpdate("hourly", location, nil)
+	n.recordLocationUpdate("hourly", location, errFetch)
+	n.recordLocationUpdate("hourly", location, errFetch)
+	n.recordLocationUpdate("hourly", location, errUpdateSkipped)
+	n.wait()
+	if got := rec.received("/hook"); len(got) != 0 {
+		t.Fatalf("expected a success to reset the failures and skips not to count, got %v", got)
+	}
+
+	n.recordLocationUpdate("hourly", location, errFetch)
+	n.recordLocationUpdate("daily", location, errFetch)
+	n.wait()
+	hooks := rec.received("/hook")
+	if len(hooks) != 1 {
+		t.Fatalf("got %d notifications, want 1", len(hooks))
+	}
+	var payload OpsNotificationPayload
+	if err := json.Unmarshal([]byte(hooks[0]), &payload); err != nil {
+		t.Fatalf("failed to decode payload: %v", err)
+	}
+	if payload.Event != notifyEventLocationFailing || payload.Job != "hourly" || payload.LocationID != location.LocationID.String() ||
+		payload.ConsecutiveFailures != 3 || payload.Error != errFetch.Error() || payload.Time != "2025-06-01T12:00:00Z" {
+		t.Errorf("unexpected payload: %+v", payload)
+	}
+	var slack SlackMessage
+	if slackBodies := rec.received("/slack"); len(slackBodies) != 1 || json.Unmarshal([]byte(slackBodies[0]), &slack) != nil || !strings.Contains(slack.Text, "hourly job failed 3 times in a row for Wroclaw") {
+		t.Errorf("unexpected Slack messages: %v", rec.received("/slack"))
+	}
+
+	// Further failures are reported again only after the cooldown.
+	n.recordLocationUpdate("hourly", location, errFetch)
+	now = now.Add(30 * time.Minute)
+	n.recordLocationUpdate("hourly", location, errFetch)
+	now = now.Add(31 * time.Minute)
+	n.recordLocationUpdate("hourly", location, errFetch)
+	n.wait()
+	if hooks := rec.received("/hook"); len(hooks) != 2 || !strings.Contains(hooks[1], `"consecutive_failures":6`) {
+		t.Errorf("expected a second notification after the cooldown, got %v", hooks)
+	}
+}
+
+func TestOpsNotifierCircuitOpened(t *testing.T) {
+	server, rec := newNotificationServer(t)
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	b := newTestCircuitBreakers(2, time.Minute, &now)
+	b.notifier = newOpsNotifier("", server.URL+"/hook", 3, time.Hour, server.Client(), b.logger)
+	b.notifier.now = func() time.Time { return now }
+	errServer := &fetchStatusError{Status: "503 Service Unavailable", StatusCode: http.StatusServiceUnavailable}
+
+	b.record("owm", errServer)
+	b.record("owm", errServer)
+	// A failed trial fetch re-opens the circuit without another notification.
+	now = now.Add(2 * time.Minute)
+	b.allow("owm")
+	b.record("owm", errServer)
+	b.notifier.wait()
+
+	hooks := rec.received("/hook")
+	if len(hooks) != 1 {
+		t.Fatalf("got %d notifications, want 1", len(hooks))
+	}
+	var payload OpsNotificationPayload
+	if err := json.Unmarshal([]byte(hooks[0]), &payload); err != nil {
+		t.Fatalf("failed to decode payload: %v", err)
+	}
+	if payload.Event != notifyEventCircuitOpened || payload.Provider != "owm" || payload.ConsecutiveFailures != 2 || payload.RetryAfter != "1m0s" {
+		t.Errorf("unexpected payload: %+v", payload)
+	}
+}
+
+func TestOpsNotifierDisabled(t *testing.T) {
+	var nilNotifier *opsNotifier
+	nilNotifier.recordLocationUpdate("hourly", Location{}, errors.New("boom"))
+	nilNotifier.circuitOpened("owm", 5, time.Minute)
+	nilNotifier.wait()
+
+	n := newOpsNotifier("", "", 1, time.Hour, http.DefaultClient, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	n.recordLocationUpdate("hourly", Location{}, errors.New("boom"))
+	if len(n.failures) != 0 || len(n.sent) != 0 {
+		t.Error("expected a notifier without webhooks to do nothing")
+	}
+}
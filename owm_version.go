@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// This file implements the OpenWeatherMap API version negotiation. One Call 3.0 requires a
+// separate subscription that not every OWM key has; without it every request fails with
+// 401 Unauthorized. Instead of losing the provider, requests that are rejected by One Call 3.0
+// are retried against the free 2.5 endpoints (current weather and the 5 day / 3 hour forecast),
+// and the 2.5 API stays active for all further requests. One Call 3.0 is probed again after
+// owmVersionRetryInterval, so a subscription that is added later is picked up without a restart.
+
+const (
+	owmVersionOneCall = "3.0"
+	owmVersionLegacy  = "2.5"
+
+	// owmVersionRetryInterval is how long the 2.5 API stays active before One Call 3.0 is tried again.
+	owmVersionRetryInterval = 24 * time.Hour
+)
+
+// owmForecastKind selects the OWM endpoint and parameters for a forecast type.
+type owmForecastKind int
+
+const (
+	owmCurrent owmForecastKind = iota
+	owmDaily
+	owmHourly
+	owmWarnings
+)
+
+// owmVersionTracker records which OWM API version is in use. A nil tracker always reports
+// One Call 3.0 and ignores fallbacks.
+type owmVersionTracker struct {
+	mu          sync.Mutex
+	legacySince time.Time // Zero while One Call 3.0 is active.
+	now         func() time.Time
+}
+
+func newOWMVersionTracker() *owmVersionTracker {
+	return &owmVersionTracker{now: time.Now}
+}
+
+// active returns the API version that requests should use.
+func (t *owmVersionTracker) active() string {
+	if t == nil {
+		return owmVersionOneCall
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.legacySince.IsZero() || t.now().Sub(t.legacySince) >= owmVersionRetryInterval {
+		return owmVersionOneCall
+	}
+	return owmVersionLegacy
+}
+
+// fallBack switches to the 2.5 API after One Call 3.0 rejected a request.
+func (t *owmVersionTracker) fallBack() {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.legacySince = t.now()
+	owmVersionFallbacks.Inc()
+}
+
+// providerAPIVersion returns the upstream API version in use for a provider, or an empty string
+// for providers with a single supported version.
+func (cfg *apiConfig) providerAPIVersion(id string) string {
+	if id == "owm" {
+		return cfg.owmVersion.active()
+	}
+	return ""
+}
+
+// owmURL returns the OWM request URL for the given forecast type in the active API version.
+func (cfg *apiConfig) owmURL(location Location, kind owmForecastKind) string {
+	if cfg.owmVersion.active() == owmVersionLegacy && cfg.owmLegacyWeatherURL != "" {
+		return cfg.owmLegacyURL(location, kind)
+	}
+	return cfg.owmOneCallURL(location, kind)
+}
+
+// owmOneCallURL returns the One Call 3.0 request URL for the given forecast type.
+func (cfg *apiConfig) owmOneCallURL(location Location, kind owmForecastKind) string {
+	var exclude string
+	switch kind {
+	case owmCurrent:
+		exclude = "minutely,hourly,daily,alerts"
+	case owmDaily:
+		exclude = "current,minutely,hourly,alerts"
+	case owmHourly:
+		exclude = "current,minutely,daily,alerts"
+	case owmWarnings:
+		exclude = "current,minutely,hourly,daily"
+	}
+	return fmt.Sprintf("%slat=%.2f&lon=%.2f&exclude=%s&units=metric&appid=%s", cfg.owmWeatherURL, location.Latitude, location.Longitude, exclude, cfg.owmKey.Get())
+}
+
+// owmLegacyURL returns the 2.5 request URL for the given forecast type. Daily and hourly
+// forecasts are both derived from the 5 day / 3 hour forecast.
+func (cfg *apiConfig) owmLegacyURL(location Location, kind owmForecastKind) string {
+	endpoint := "forecast"
+	if kind == owmCurrent {
+		endpoint = "weather"
+	}
+	return fmt.Sprintf("%s%s?lat=%.2f&lon=%.2f&units=metric&appid=%s", cfg.owmLegacyWeatherURL, endpoint, location.Latitude, location.Longitude, cfg.owmKey.Get())
+}
+
+// owmForecastProvider returns the OWM provider entry for a forecast request. While One Call 3.0
+// is active, the 2.5 endpoint is attached as a fallback for unauthorized responses; while the
+// 2.5 API is active, its parser is used directly.
+func owmForecastProvider[T Forecast](
+	cfg *apiConfig,
+	location Location,
+	kind owmForecastKind,
+	parser func(io.Reader, *slog.Logger) (T, string, error),
+	legacyParser func(io.Reader, *slog.Logger) (T, string, error),
+	errorVal T,
+) forecastProvider[T] {
+	if cfg.owmLegacyWeatherURL == "" {
+		return forecastProvider[T]{parser: parser, errorVal: errorVal}
+	}
+	if cfg.owmVersion.active() == owmVersionLegacy {
+		return forecastProvider[T]{parser: legacyParser, errorVal: errorVal}
+	}
+	return forecastProvider[T]{
+		parser:   parser,
+		errorVal: errorVal,
+		fallback: &forecastFallback[T]{
+			url:      cfg.owmLegacyURL(location, kind),
+			parser:   legacyParser,
+			onSwitch: cfg.owmVersion.fallBack,
+		},
+	}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestOWMVersionTracker(t *testing.T) {
+	t.Run("nil tracker uses One Call 3.0", func(t *testing.T) {
+		var tracker *owmVersionTracker
+		tracker.fallBack()
+		if got := tracker.active(); got != owmVersionOneCall {
+			t.Errorf("active() = %q, want %q", got, owmVersionOneCall)
+		}
+	})
+
+	t.Run("fallback is retried after the retry interval", func(t *testing.T) {
+		now := time.Date(2025, 8, 4, 12, 0, 0, 0, time.UTC)
+		tracker := newOWMVersionTracker()
+		tracker.now = func() time.Time { return now }
+
+		if got := tracker.active(); got != owmVersionOneCall {
+			t.Fatalf("active() = %q before fallback, want %q", got, owmVersionOneCall)
+		}
+		tracker.fallBack()
+		if got := tracker.active(); got != owmVersionLegacy {
+			t.Fatalf("active() = %q after fallback, want %q", got, owmVersionLegacy)
+		}
+		now = now.Add(owmVersionRetryInterval - time.Minute)
+		if got := tracker.active(); got != owmVersionLegacy {
+			t.Errorf("active() = %q before retry interval, want %q", got, owmVersionLegacy)
+		}
+		now = now.Add(time.Minute)
+		if got := tracker.active(); got != owmVersionOneCall {
+			t.Errorf("active() = %q after retry interval, want %q", got, owmVersionOneCall)
+		}
+	})
+}
+
+func TestOWMURL(t *testing.T) {
+	cfg := &apiConfig{
+		owmWeatherURL:       "https://api.openweathermap.org/data/3.0/onecall?",
+		owmLegacyWeatherURL: "https://api.openweathermap.org/data/2.5/",
+		owmKey:              newSecretValue("owmKey"),
+		owmVersion:          newOWMVersionTracker(),
+	}
+	location := Location{Latitude: 51.11, Longitude: 17.04}
+
+	if got, want := cfg.owmURL(location, owmDaily), "https://api.openweathermap.org/data/3.0/onecall?lat=51.11&lon=17.04&exclude=current,minutely,hourly,alerts&units=metric&appid=owmKey"; got != want {
+		t.Errorf("One Call URL: got %q, want %q", got, want)
+	}
+
+	cfg.owmVersion.fallBack()
+	testCases := []struct {
+		kind owmForecastKind
+		want string
+	}{
+		{owmCurrent, "https://api.openweathermap.org/data/2.5/weather?lat=51.11&lon=17.04&units=metric&appid=owmKey"},
+		{owmDaily, "https://api.openweathermap.org/data/2.5/forecast?lat=51.11&lon=17.04&units=metric&appid=owmKey"},
+		{owmHourly, "https://api.openweathermap.org/data/2.5/forecast?lat=51.11&lon=17.04&units=metric&appid=owmKey"},
+	}
+	for _, tc := range testCases {
+		if got := cfg.owmURL(location, tc.kind); got != tc.want {
+			t.Errorf("2.5 URL for kind %d: got %q, want %q", tc.kind, got, tc.want)
+		}
+	}
+	if got := cfg.providerAPIVersion("owm"); got != owmVersionLegacy {
+		t.Errorf("providerAPIVersion(owm) = %q, want %q", got, owmVersionLegacy)
+	}
+	if got := cfg.providerAPIVersion("ometeo"); got != "" {
+		t.Errorf("providerAPIVersion(ometeo) = %q, want empty", got)
+	}
+}
+
+func TestRequestCurrentWeather_OWMVersionFallback(t *testing.T) {
+	current25, err := os.ReadFile("testdata/current_weather_owm25.json")
+	if err != nil {
+		t.Fatalf("failed to read test data: %v", err)
+	}
+
+	var mu sync.Mutex
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		mu.Unlock()
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/data/3.0/"):
+			w.WriteHeader(http.StatusUnauthorized)
+		case r.URL.Path == "/data/2.5/weather":
+			_, _ = w.Write(current25)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	testCfg := newTestAPIConfig(t)
+	cfg := testCfg.apiConfig
+	cfg.owmWeatherURL = server.URL + "/data/3.0/onecall?"
+	cfg.owmLegacyWeatherURL = server.URL + "/data/2.5/"
+	cfg.enabledSources = map[string]bool{"owm": true}
+	cfg.owmVersion = newOWMVersionTracker()
+	location := Location{CityName: "Wroclaw", Latitude: 51.11, Longitude: 17.04, Timezone: "Europe/Warsaw"}
+
+	for i := 0; i < 2; i++ {
+		results, err := cfg.requestCurrentWeather(context.Background(), location, nil, nil)
+		if err != nil {
+			t.Fatalf("request %d: unexpected error: %v", i, err)
+		}
+		if len(results) != 1 || results[0].SourceAPI != "OpenWeatherMap API" || results[0].Temperature != 18.5 {
+			t.Fatalf("request %d: unexpected results: %+v", i, results)
+		}
+	}
+
+	if got := cfg.owmVersion.active(); got != owmVersionLegacy {
+		t.Errorf("active version = %q, want %q", got, owmVersionLegacy)
+	}
+	want := []string{"/data/3.0/onecall", "/data/2.5/weather", "/data/2.5/weather"}
+	if strings.Join(paths, ",") != strings.Join(want, ",") {
+		t.Errorf("requested paths = %v, want %v", paths, want)
+	}
+}
+
+func TestFetchForecastWithFallback_OnlyOnUnauthorized(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	switched := false
+	provider := forecastProvider[CurrentWeather]{
+		parser:   mockParserSuccess,
+		errorVal: CurrentWeather{SourceAPI: "OpenWeatherMap API"},
+		fallback: &forecastFallback[CurrentWeather]{
+			url:      server.URL,
+			parser:   mockParserSuccess,
+			onSwitch: func() { switched = true },
+		},
+	}
+	cfg := newTestAPIConfig(t).apiConfig
+
+	var wg sync.WaitGroup
+	results := make(chan struct {
+		t   CurrentWeather
+		tz  string
+		err error
+	}, 1)
+	wg.Add(1)
+	fetchForecastWithFallback(cfg, context.Background(), server.URL, provider, &wg, results)
+
+	res := <-results
+	if res.err == nil || !strings.Contains(res.err.Error(), "500") {
+		t.Errorf("expected the original 500 error, got %v", res.err)
+	}
+	if switched {
+		t.Error("fallback should not be activated for errors other than 401")
+	}
+}
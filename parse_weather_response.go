@@ -6,6 +6,7 @@ import (
 	"io"
 	"log/slog"
 	"math"
+	"strings"
 	"time"
 )
 
@@ -19,7 +20,8 @@ import (
 // for the crucial task of translating this provider-specific data into the
 // application's common domain models. This decoupling is essential for maintaining
 // a clean architecture and allows new API providers to be added without changing
-// the core application logic.
+// the core application logic. The forecast parsers keep at most the requested number
+// of days or hours (see horizon.go).
 
 // ParseCurrentWeatherGMP decodes the JSON response from the Google Weather API and maps it to the internal CurrentWeather struct.
 func ParseCurrentWeatherGMP(body io.Reader, logger *slog.Logger) (CurrentWeather, string, error) {
@@ -38,17 +40,25 @@ func ParseCurrentWeatherGMP(body io.Reader, logger *slog.Logger) (CurrentWeather
 		loc = time.UTC
 	}
 
+	rain, snow := response.Precipitation.split()
 	weather := CurrentWeather{
-		SourceAPI:     "Google Weather API",
-		Timestamp:     (response.Timestamp).In(loc),
-		Temperature:   response.Temperature.Degrees,
-		Humidity:      int32(response.Humidity),
-		WindSpeed:     response.Wind.Speed.Value,
-		Precipitation: response.Precipitation.Qpf.Quantity,
-		Condition:     response.Condition.Description.Text,
+		SourceAPI:           "Google Weather API",
+		Timestamp:           (response.Timestamp).In(loc),
+		Temperature:         response.Temperature.Degrees,
+		ApparentTemperature: response.FeelsLikeTemperature.degrees(),
+		DewPoint:            response.DewPoint.degrees(),
+		Humidity:            int32(response.Humidity),
+		WindSpeed:           response.Wind.Speed.Value,
+		WindDirection:       response.Wind.Direction.Degrees,
+		WindGust:            response.Wind.gust(),
+		Precipitation:       response.Precipitation.Qpf.Quantity,
+		Rain:                rain,
+		Snow:                snow,
+		Condition:           response.Condition.Description.Text,
+		UVIndex:             response.UVIndex,
 	}
 
-	return weather, response.TimeZone.ID, nil
+	return weather.withDerivedValues(), response.TimeZone.ID, nil
 }
 
 // ParseCurrentWeatherOWM decodes the JSON response from the OpenWeatherMap API and maps it to the internal CurrentWeather struct.
@@ -69,16 +79,60 @@ func ParseCurrentWeatherOWM(body io.Reader, logger *slog.Logger) (CurrentWeather
 	}
 
 	weather := CurrentWeather{
-		SourceAPI:     "OpenWeatherMap API",
-		Timestamp:     time.Unix(response.CurrentWeather.Dt, 0).UTC().In(loc),
-		Temperature:   response.CurrentWeather.Temp,
-		Humidity:      int32(response.CurrentWeather.Humidity),
-		WindSpeed:     Round(response.CurrentWeather.WindSpeed*3.6, 4),
-		Precipitation: response.CurrentWeather.Rain.Quantity + response.CurrentWeather.Snow.Quantity,
-		Condition:     response.CurrentWeather.Weather[0].Main,
+		SourceAPI:           "OpenWeatherMap API",
+		Timestamp:           time.Unix(response.CurrentWeather.Dt, 0).UTC().In(loc),
+		Temperature:         response.CurrentWeather.Temp,
+		ApparentTemperature: response.CurrentWeather.FeelsLike,
+		DewPoint:            response.CurrentWeather.DewPoint,
+		Humidity:            int32(response.CurrentWeather.Humidity),
+		WindSpeed:           Round(response.CurrentWeather.WindSpeed*3.6, 4),
+		WindDirection:       response.CurrentWeather.WindDeg,
+		WindGust:            msToKmh(response.CurrentWeather.WindGust),
+		Precipitation:       response.CurrentWeather.Rain.Quantity + response.CurrentWeather.Snow.Quantity,
+		Rain:                precipitationAmount(response.CurrentWeather.Rain.Quantity),
+		Snow:                precipitationAmount(response.CurrentWeather.Snow.Quantity),
+		Condition:           response.CurrentWeather.Weather[0].Main,
+		UVIndex:             response.CurrentWeather.UVI,
+		Sunrise:             sunEventTime(response.CurrentWeather.Sunrise, loc),
+		Sunset:              sunEventTime(response.CurrentWeather.Sunset, loc),
 	}
 
-	return weather, response.Timezone, nil
+	return weather.withDerivedValues(), response.Timezone, nil
+}
+
+// ParseCurrentWeatherOWM25 decodes the JSON response from the OpenWeatherMap 2.5 current weather
+// endpoint and maps it to the internal CurrentWeather struct. The 2.5 API reports only a UTC offset,
+// so no timezone name is returned.
+func ParseCurrentWeatherOWM25(body io.Reader, logger *slog.Logger) (CurrentWeather, string, error) {
+	var response ResponseCurrentWeatherOWM25
+
+	if err := json.NewDecoder(body).Decode(&response); err != nil {
+		return CurrentWeather{SourceAPI: "OpenWeatherMap API"}, "", err
+	}
+	if response.Dt == 0 {
+		return CurrentWeather{SourceAPI: "OpenWeatherMap API"}, "", errors.New("empty or invalid response from API")
+	}
+
+	loc := time.FixedZone("", response.Timezone)
+
+	weather := CurrentWeather{
+		SourceAPI:           "OpenWeatherMap API",
+		Timestamp:           time.Unix(response.Dt, 0).UTC().In(loc),
+		Temperature:         response.Main.Temp,
+		ApparentTemperature: response.Main.FeelsLike,
+		Humidity:            int32(response.Main.Humidity),
+		WindSpeed:           Round(response.Wind.Speed*3.6, 4),
+		WindDirection:       response.Wind.Deg,
+		WindGust:            msToKmh(response.Wind.Gust),
+		Precipitation:       response.Rain.Quantity + response.Snow.Quantity,
+		Rain:                precipitationAmount(response.Rain.Quantity),
+		Snow:                precipitationAmount(response.Snow.Quantity),
+		Condition:           owm25Condition(response.Weather),
+		Sunrise:             sunEventTime(response.Sys.Sunrise, loc),
+		Sunset:              sunEventTime(response.Sys.Sunset, loc),
+	}
+
+	return weather.withDerivedValues(), "", nil
 }
 
 // ParseCurrentWeatherOMeteo decodes the JSON response from the Open-Meteo API and maps it to the internal CurrentWeather struct.
@@ -98,21 +152,35 @@ func ParseCurrentWeatherOMeteo(body io.Reader, logger *slog.Logger) (CurrentWeat
 		loc = time.UTC
 	}
 
+	rain, snow := splitPrecipitationOMeteo(response.CurrentWeather.Precipitation, response.CurrentWeather.Rain, response.CurrentWeather.Showers)
 	weather := CurrentWeather{
-		SourceAPI:     "Open-Meteo API",
-		Timestamp:     time.Unix(response.CurrentWeather.Time, 0).UTC().In(loc),
-		Temperature:   response.CurrentWeather.Temperature2m,
-		Humidity:      response.CurrentWeather.RelativeHumidity2m,
-		WindSpeed:     response.CurrentWeather.WindSpeed10m,
-		Precipitation: response.CurrentWeather.Precipitation,
-		Condition:     interpretWeatherCode(response.CurrentWeather.WeatherCode),
+		SourceAPI:           "Open-Meteo API",
+		Timestamp:           time.Unix(response.CurrentWeather.Time, 0).UTC().In(loc),
+		Temperature:         response.CurrentWeather.Temperature2m,
+		ApparentTemperature: response.CurrentWeather.ApparentTemperature,
+		DewPoint:            response.CurrentWeather.DewPoint2m,
+		Humidity:            response.CurrentWeather.RelativeHumidity2m,
+		WindSpeed:           response.CurrentWeather.WindSpeed10m,
+		WindDirection:       response.CurrentWeather.WindDirection10m,
+		WindGust:            response.CurrentWeather.WindGusts10m,
+		Precipitation:       response.CurrentWeather.Precipitation,
+		Rain:                rain,
+		Snow:                snow,
+		Condition:           interpretWeatherCode(response.CurrentWeather.WeatherCode),
+		UVIndex:             response.CurrentWeather.UVIndex,
+	}
+	if len(response.Daily.Sunrise) > 0 && len(response.Daily.Sunset) > 0 {
+		weather.Sunrise = sunEventTime(response.Daily.Sunrise[0], loc)
+		weather.Sunset = sunEventTime(response.Daily.Sunset[0], loc)
 	}
 
-	return weather, response.Timezone, nil
+	return weather.withDerivedValues(), response.Timezone, nil
 }
 
 // ParseDailyForecastGMP decodes the JSON response from the Google Weather API and maps it to a slice of internal DailyForecast structs.
-func ParseDailyForecastGMP(body io.Reader, logger *slog.Logger) ([]DailyForecast, string, error) {
+// The API splits a day into a daytime part, from 07:00 to 19:00, and a nighttime part, from 19:00 to 07:00, which are
+// mapped onto the parts of the day they overlap.
+func ParseDailyForecastGMP(body io.Reader, logger *slog.Logger, days int) ([]DailyForecast, string, error) {
 	var response ResponseDailyForecastGMP
 
 	if err := json.NewDecoder(body).Decode(&response); err != nil {
@@ -128,30 +196,97 @@ func ParseDailyForecastGMP(body io.Reader, logger *slog.Logger) ([]DailyForecast
 		loc = time.UTC
 	}
 
+	halves := gmpDayHalves(response.ForecastDays)
 	var forecast []DailyForecast
 	for i, day := range response.ForecastDays {
-		if i >= 5 {
+		if i >= days {
 			break
 		}
 		localTime := day.Interval.StartTime.In(loc)
 		forecastDate := time.Date(localTime.Year(), localTime.Month(), localTime.Day(), 0, 0, 0, 0, loc)
+		parts := gmpDayParts(halves, forecastDate)
+		rain, snow := day.DaytimeForecast.Precipitation.split()
 		forecast = append(forecast, DailyForecast{
 			SourceAPI:           "Google Weather API",
 			ForecastDate:        forecastDate,
 			MinTemp:             day.MinTemperature.Degrees,
 			MaxTemp:             day.MaxTemperature.Degrees,
 			Precipitation:       day.DaytimeForecast.Precipitation.Qpf.Quantity,
+			Rain:                rain,
+			Snow:                snow,
 			PrecipitationChance: day.DaytimeForecast.Precipitation.Probability.Percent,
 			WindSpeed:           day.DaytimeForecast.Wind.Speed.Value,
+			WindDirection:       day.DaytimeForecast.Wind.Direction.Degrees,
+			WindGust:            day.DaytimeForecast.Wind.gust(),
 			Humidity:            day.DaytimeForecast.RelativeHumidity,
+			UVIndex:             day.DaytimeForecast.UVIndex,
+			Sunrise:             day.SunEvents.SunriseTime.In(loc),
+			Sunset:              day.SunEvents.SunsetTime.In(loc),
+			Morning:             parts[0],
+			Afternoon:           parts[1],
+			Evening:             parts[2],
+			Night:               parts[3],
 		})
 	}
 
 	return forecast, response.TimeZone.ID, nil
 }
 
+// gmpDayHalf is a daytime or nighttime forecast of the Google Weather API with the time it covers.
+type gmpDayHalf struct {
+	start, end time.Time
+	forecast   *ForecastDayPart
+}
+
+// gmpDayHalves lists the daytime and nighttime forecasts of all days in the response. Forecasts
+// without an interval are left out, as they cannot be placed on the parts of a day.
+func gmpDayHalves(days []ForecastDay) []gmpDayHalf {
+	var halves []gmpDayHalf
+	add := func(p *ForecastDayPart) {
+		if p != nil && !p.Interval.StartTime.IsZero() && p.Interval.EndTime.After(p.Interval.StartTime) {
+			halves = append(halves, gmpDayHalf{start: p.Interval.StartTime, end: p.Interval.EndTime, forecast: p})
+		}
+	}
+	for i := range days {
+		add(&days[i].DaytimeForecast)
+		add(days[i].NighttimeForecast)
+	}
+	return halves
+}
+
+// gmpDayParts returns the morning, afternoon, evening and night of date. Each part takes the
+// precipitation chance and condition of the daytime or nighttime forecast that overlaps it the
+// most, so the evening comes from the nighttime forecast starting at 19:00 and the night from the
+// one that started the evening before. Parts that no forecast overlaps are left empty.
+func gmpDayParts(halves []gmpDayHalf, date time.Time) [4]DayPart {
+	at := func(hour int) time.Time {
+		return time.Date(date.Year(), date.Month(), date.Day(), hour, 0, 0, 0, date.Location())
+	}
+	bounds := [4][2]time.Time{{at(6), at(12)}, {at(12), at(18)}, {at(18), at(24)}, {at(0), at(6)}}
+
+	var parts [4]DayPart
+	for i, b := range bounds {
+		var best time.Duration
+		for _, h := range halves {
+			start, end := h.start, h.end
+			if b[0].After(start) {
+				start = b[0]
+			}
+			if b[1].Before(end) {
+				end = b[1]
+			}
+			if overlap := end.Sub(start); overlap > best {
+				best = overlap
+				parts[i] = h.forecast.dayPart()
+			}
+		}
+	}
+	return parts
+}
+
 // ParseDailyForecastOWM decodes the JSON response from the OpenWeatherMap API and maps it to a slice of internal DailyForecast structs.
-func ParseDailyForecastOWM(body io.Reader, logger *slog.Logger) ([]DailyForecast, string, error) {
+// The API reports a temperature for each part of the day, but no precipitation chance or condition.
+func ParseDailyForecastOWM(body io.Reader, logger *slog.Logger, days int) ([]DailyForecast, string, error) {
 	var response ResponseDailyForecastOWM
 
 	if err := json.NewDecoder(body).Decode(&response); err != nil {
@@ -169,7 +304,7 @@ func ParseDailyForecastOWM(body io.Reader, logger *slog.Logger) ([]DailyForecast
 
 	var forecast []DailyForecast
 	for i, day := range response.DailyForecast {
-		if i >= 5 {
+		if i >= days {
 			break
 		}
 		localTime := time.Unix(day.Dt, 0).In(loc)
@@ -180,17 +315,89 @@ func ParseDailyForecastOWM(body io.Reader, logger *slog.Logger) ([]DailyForecast
 			MinTemp:             day.Temp.Min,
 			MaxTemp:             day.Temp.Max,
 			Precipitation:       day.Rain + day.Snow,
+			Rain:                precipitationAmount(day.Rain),
+			Snow:                precipitationAmount(day.Snow),
 			PrecipitationChance: int32(day.Pop * 100),
 			WindSpeed:           Round(day.WindSpeed*3.6, 4),
+			WindDirection:       day.WindDeg,
+			WindGust:            msToKmh(day.WindGust),
 			Humidity:            day.Humidity,
+			UVIndex:             day.UVI,
+			Sunrise:             sunEventTime(day.Sunrise, loc),
+			Sunset:              sunEventTime(day.Sunset, loc),
+			Morning:             DayPart{Temperature: day.Temp.Morn},
+			Afternoon:           DayPart{Temperature: day.Temp.Day},
+			Evening:             DayPart{Temperature: day.Temp.Eve},
+			Night:               DayPart{Temperature: day.Temp.Night},
 		})
 	}
 
 	return forecast, response.Timezone, nil
 }
 
+// ParseDailyForecastOWM25 decodes the JSON response from the OpenWeatherMap 2.5 5 day / 3 hour
+// forecast endpoint and aggregates the 3-hour steps into a slice of internal DailyForecast structs,
+// one per local calendar day. The 2.5 API reports only a UTC offset, so no timezone name is returned.
+func ParseDailyForecastOWM25(body io.Reader, logger *slog.Logger, days int) ([]DailyForecast, string, error) {
+	var response ResponseForecastOWM25
+
+	if err := json.NewDecoder(body).Decode(&response); err != nil {
+		return []DailyForecast{{SourceAPI: "OpenWeatherMap API"}}, "", err
+	}
+	if len(response.List) == 0 {
+		return []DailyForecast{{SourceAPI: "OpenWeatherMap API"}}, "", errors.New("empty or invalid response from API")
+	}
+
+	loc := time.FixedZone("", response.City.Timezone)
+
+	var forecast []DailyForecast
+	for _, step := range response.List {
+		localTime := time.Unix(step.Dt, 0).In(loc)
+		forecastDate := time.Date(localTime.Year(), localTime.Month(), localTime.Day(), 0, 0, 0, 0, loc)
+		precipitationChance := int32(step.Pop * 100)
+		windSpeed := Round(step.Wind.Speed*3.6, 4)
+		humidity := int32(step.Main.Humidity)
+
+		if n := len(forecast); n > 0 && forecast[n-1].ForecastDate.Equal(forecastDate) {
+			day := &forecast[n-1]
+			day.MinTemp = math.Min(day.MinTemp, step.Main.TempMin)
+			day.MaxTemp = math.Max(day.MaxTemp, step.Main.TempMax)
+			day.Precipitation = Round(day.Precipitation+step.Rain.Quantity+step.Snow.Quantity, 4)
+			day.Rain = precipitationAmount(*day.Rain + step.Rain.Quantity)
+			day.Snow = precipitationAmount(*day.Snow + step.Snow.Quantity)
+			day.PrecipitationChance = max(day.PrecipitationChance, precipitationChance)
+			if windSpeed > day.WindSpeed {
+				day.WindSpeed = windSpeed
+				day.WindDirection = step.Wind.Deg
+			}
+			day.WindGust = maxOptional(day.WindGust, msToKmh(step.Wind.Gust))
+			day.Humidity = max(day.Humidity, humidity)
+			continue
+		}
+		if len(forecast) >= days {
+			break
+		}
+		forecast = append(forecast, DailyForecast{
+			SourceAPI:           "OpenWeatherMap API",
+			ForecastDate:        forecastDate,
+			MinTemp:             step.Main.TempMin,
+			MaxTemp:             step.Main.TempMax,
+			Precipitation:       Round(step.Rain.Quantity+step.Snow.Quantity, 4),
+			Rain:                precipitationAmount(step.Rain.Quantity),
+			Snow:                precipitationAmount(step.Snow.Quantity),
+			PrecipitationChance: precipitationChance,
+			WindSpeed:           windSpeed,
+			WindDirection:       step.Wind.Deg,
+			WindGust:            msToKmh(step.Wind.Gust),
+			Humidity:            humidity,
+		})
+	}
+
+	return forecast, "", nil
+}
+
 // ParseDailyForecastOMeteo decodes the JSON response from the Open-Meteo API and maps it to a slice of internal DailyForecast structs.
-func ParseDailyForecastOMeteo(body io.Reader, logger *slog.Logger) ([]DailyForecast, string, error) {
+func ParseDailyForecastOMeteo(body io.Reader, logger *slog.Logger, days int) ([]DailyForecast, string, error) {
 	var response ResponseDailyForecastOMeteo
 
 	if err := json.NewDecoder(body).Decode(&response); err != nil {
@@ -207,10 +414,7 @@ func ParseDailyForecastOMeteo(body io.Reader, logger *slog.Logger) ([]DailyForec
 	}
 
 	var forecast []DailyForecast
-	numDays := len(response.DailyForecast.Time)
-	if numDays > 5 {
-		numDays = 5
-	}
+	numDays := min(len(response.DailyForecast.Time), days)
 
 	for i := 0; i < numDays; i++ {
 		unixTime := response.DailyForecast.Time[i]
@@ -226,13 +430,34 @@ func ParseDailyForecastOMeteo(body io.Reader, logger *slog.Logger) ([]DailyForec
 			WindSpeed:           response.DailyForecast.WindSpeed10mMax[i],
 			Humidity:            response.DailyForecast.RelativeHumidity2mMax[i],
 		})
+		day := &forecast[len(forecast)-1]
+		if i < len(response.DailyForecast.RainSum) {
+			var showers *float64
+			if i < len(response.DailyForecast.ShowersSum) {
+				showers = response.DailyForecast.ShowersSum[i]
+			}
+			day.Rain, day.Snow = splitPrecipitationOMeteo(day.Precipitation, response.DailyForecast.RainSum[i], showers)
+		}
+		if i < len(response.DailyForecast.WindDirection10mDominant) {
+			day.WindDirection = response.DailyForecast.WindDirection10mDominant[i]
+		}
+		if i < len(response.DailyForecast.WindGusts10mMax) {
+			day.WindGust = response.DailyForecast.WindGusts10mMax[i]
+		}
+		if i < len(response.DailyForecast.UVIndexMax) {
+			day.UVIndex = response.DailyForecast.UVIndexMax[i]
+		}
+		if i < len(response.DailyForecast.Sunrise) && i < len(response.DailyForecast.Sunset) {
+			day.Sunrise = sunEventTime(response.DailyForecast.Sunrise[i], loc)
+			day.Sunset = sunEventTime(response.DailyForecast.Sunset[i], loc)
+		}
 	}
 
 	return forecast, response.Timezone, nil
 }
 
 // ParseHourlyForecastGMP decodes the JSON response from the Google Weather API and maps it to a slice of internal HourlyForecast structs.
-func ParseHourlyForecastGMP(body io.Reader, logger *slog.Logger) ([]HourlyForecast, string, error) {
+func ParseHourlyForecastGMP(body io.Reader, logger *slog.Logger, hours int) ([]HourlyForecast, string, error) {
 	var response ResponseHourlyForecastGMP
 
 	if err := json.NewDecoder(body).Decode(&response); err != nil {
@@ -250,26 +475,33 @@ func ParseHourlyForecastGMP(body io.Reader, logger *slog.Logger) ([]HourlyForeca
 
 	var forecast []HourlyForecast
 	for i, hour := range response.ForecastHours {
-		if i >= 24 {
+		if i >= hours {
 			break
 		}
+		rain, snow := hour.Precipitation.split()
 		forecast = append(forecast, HourlyForecast{
 			SourceAPI:           "Google Weather API",
 			ForecastDateTime:    (hour.Interval.StartTime).In(loc),
 			Temperature:         hour.Temperature.Degrees,
+			ApparentTemperature: hour.FeelsLikeTemperature.degrees(),
+			DewPoint:            hour.DewPoint.degrees(),
 			Humidity:            hour.Humidity,
 			WindSpeed:           hour.Wind.Speed.Value,
+			WindDirection:       hour.Wind.Direction.Degrees,
+			WindGust:            hour.Wind.gust(),
 			Precipitation:       hour.Precipitation.Qpf.Quantity,
+			Rain:                rain,
+			Snow:                snow,
 			PrecipitationChance: hour.Precipitation.Probability.Percent,
 			Condition:           hour.Condition.Description.Text,
-		})
+		}.withDerivedValues())
 	}
 
 	return forecast, response.TimeZone.ID, nil
 }
 
 // ParseHourlyForecastOWM decodes the JSON response from the OpenWeatherMap API and maps it to a slice of internal HourlyForecast structs.
-func ParseHourlyForecastOWM(body io.Reader, logger *slog.Logger) ([]HourlyForecast, string, error) {
+func ParseHourlyForecastOWM(body io.Reader, logger *slog.Logger, hours int) ([]HourlyForecast, string, error) {
 	var response ResponseHourlyForecastOWM
 
 	if err := json.NewDecoder(body).Decode(&response); err != nil {
@@ -287,26 +519,82 @@ func ParseHourlyForecastOWM(body io.Reader, logger *slog.Logger) ([]HourlyForeca
 
 	var forecast []HourlyForecast
 	for i, hour := range response.HourlyForecast {
-		if i >= 24 {
+		if i >= hours {
 			break
 		}
 		forecast = append(forecast, HourlyForecast{
 			SourceAPI:           "OpenWeatherMap API",
 			ForecastDateTime:    time.Unix(hour.Dt, 0).UTC().In(loc),
 			Temperature:         hour.Temp,
+			ApparentTemperature: hour.FeelsLike,
+			DewPoint:            hour.DewPoint,
 			Humidity:            hour.Humidity,
 			WindSpeed:           Round(hour.WindSpeed*3.6, 4),
+			WindDirection:       hour.WindDeg,
+			WindGust:            msToKmh(hour.WindGust),
 			Precipitation:       hour.Rain.Quantity + hour.Snow.Quantity,
+			Rain:                precipitationAmount(hour.Rain.Quantity),
+			Snow:                precipitationAmount(hour.Snow.Quantity),
 			PrecipitationChance: int32(hour.Pop * 100),
 			Condition:           hour.Weather[0].Main,
-		})
+		}.withDerivedValues())
 	}
 
 	return forecast, response.Timezone, nil
 }
 
+// ParseHourlyForecastOWM25 decodes the JSON response from the OpenWeatherMap 2.5 5 day / 3 hour
+// forecast endpoint and maps the steps covering the next hours to a slice of internal
+// HourlyForecast structs. Each entry stands for a 3-hour step, with the precipitation converted to
+// an hourly average. The 2.5 API reports only a UTC offset, so no timezone name is returned.
+func ParseHourlyForecastOWM25(body io.Reader, logger *slog.Logger, hours int) ([]HourlyForecast, string, error) {
+	var response ResponseForecastOWM25
+
+	if err := json.NewDecoder(body).Decode(&response); err != nil {
+		return []HourlyForecast{{SourceAPI: "OpenWeatherMap API"}}, "", err
+	}
+	if len(response.List) == 0 {
+		return []HourlyForecast{{SourceAPI: "OpenWeatherMap API"}}, "", errors.New("empty or invalid response from API")
+	}
+
+	loc := time.FixedZone("", response.City.Timezone)
+
+	var forecast []HourlyForecast
+	steps := (hours + 2) / 3
+	for i, step := range response.List {
+		if i >= steps {
+			break
+		}
+		forecast = append(forecast, HourlyForecast{
+			SourceAPI:           "OpenWeatherMap API",
+			ForecastDateTime:    time.Unix(step.Dt, 0).UTC().In(loc),
+			Temperature:         step.Main.Temp,
+			ApparentTemperature: step.Main.FeelsLike,
+			Humidity:            int32(step.Main.Humidity),
+			WindSpeed:           Round(step.Wind.Speed*3.6, 4),
+			WindDirection:       step.Wind.Deg,
+			WindGust:            msToKmh(step.Wind.Gust),
+			Precipitation:       Round((step.Rain.Quantity+step.Snow.Quantity)/3, 4),
+			Rain:                precipitationAmount(step.Rain.Quantity / 3),
+			Snow:                precipitationAmount(step.Snow.Quantity / 3),
+			PrecipitationChance: int32(step.Pop * 100),
+			Condition:           owm25Condition(step.Weather),
+		}.withDerivedValues())
+	}
+
+	return forecast, "", nil
+}
+
+// owm25Condition returns the main condition of an OWM 2.5 response, which may omit it.
+func owm25Condition(weather []Weather) string {
+	if len(weather) == 0 {
+		return ""
+	}
+	return weather[0].Main
+}
+
 // ParseHourlyForecastOMeteo decodes the JSON response from the Open-Meteo API and maps it to a slice of internal HourlyForecast structs.
-func ParseHourlyForecastOMeteo(body io.Reader, logger *slog.Logger) ([]HourlyForecast, string, error) {
+func ParseHourlyForecastOMeteo(body io.Reader, logger *slog.Logger, hours int) ([]HourlyForecast, string, error) {
 	var response ResponseHourlyForecastOMeteo
 
 	if err := json.NewDecoder(body).Decode(&response); err != nil {
@@ -330,10 +618,7 @@ func ParseHourlyForecastOMeteo(body io.Reader, logger *slog.Logger) ([]HourlyFor
 		return []HourlyForecast{{SourceAPI: "Open-Meteo API"}}, "", errors.New("all forecasts are in the past")
 	}
 
-	endIndex := startIndex + 24
-	if endIndex > len(response.HourlyForecast.Time) {
-		endIndex = len(response.HourlyForecast.Time)
-	}
+	endIndex := min(startIndex+hours, len(response.HourlyForecast.Time))
 
 	loc, err := time.LoadLocation(response.Timezone)
 	if err != nil {
@@ -354,21 +639,212 @@ func ParseHourlyForecastOMeteo(body io.Reader, logger *slog.Logger) ([]HourlyFor
 			PrecipitationChance: response.HourlyForecast.PrecipitationProbability[i],
 			Condition:           interpretWeatherCode(response.HourlyForecast.WeatherCode[i]),
 		})
+		hour := &forecast[len(forecast)-1]
+		if i < len(response.HourlyForecast.Rain) {
+			var showers *float64
+			if i < len(response.HourlyForecast.Showers) {
+				showers = response.HourlyForecast.Showers[i]
+			}
+			hour.Rain, hour.Snow = splitPrecipitationOMeteo(hour.Precipitation, response.HourlyForecast.Rain[i], showers)
+		}
+		if i < len(response.HourlyForecast.WindDirection10m) {
+			hour.WindDirection = response.HourlyForecast.WindDirection10m[i]
+		}
+		if i < len(response.HourlyForecast.WindGusts10m) {
+			hour.WindGust = response.HourlyForecast.WindGusts10m[i]
+		}
+		if i < len(response.HourlyForecast.ApparentTemperature) {
+			hour.ApparentTemperature = response.HourlyForecast.ApparentTemperature[i]
+		}
+		if i < len(response.HourlyForecast.DewPoint2m) {
+			hour.DewPoint = response.HourlyForecast.DewPoint2m[i]
+		}
+		*hour = hour.withDerivedValues()
 	}
 
 	return forecast, response.Timezone, nil
 }
 
+// Met.no serves the current weather and both forecasts from the same Locationforecast response,
+// which has an hourly resolution for the next two to three days and a 6-hour resolution after
+// that. It reports neither a timezone nor the location's local time, so forecast dates are UTC
+// dates and the parsers return an empty timezone.
+
+// ParseCurrentWeatherMetNo decodes a Locationforecast response from Met.no and maps its latest
+// time step that has started to the internal CurrentWeather struct.
+func ParseCurrentWeatherMetNo(body io.Reader, logger *slog.Logger) (CurrentWeather, string, error) {
+	var response ResponseForecastMetNo
+
+	if err := json.NewDecoder(body).Decode(&response); err != nil {
+		return CurrentWeather{SourceAPI: "Met.no API"}, "", err
+	}
+	steps := response.Properties.Timeseries
+	if len(steps) == 0 {
+		return CurrentWeather{SourceAPI: "Met.no API"}, "", errors.New("empty or invalid response from API")
+	}
+
+	step := steps[0]
+	now := time.Now().UTC()
+	for _, s := range steps[1:] {
+		if s.Time.After(now) {
+			break
+		}
+		step = s
+	}
+
+	weather := CurrentWeather{
+		SourceAPI:     "Met.no API",
+		Timestamp:     step.Time.UTC(),
+		Temperature:   step.Data.Instant.Details.AirTemperature,
+		DewPoint:      step.Data.Instant.Details.DewPointTemperature,
+		Humidity:      int32(math.Round(step.Data.Instant.Details.RelativeHumidity)),
+		WindSpeed:     Round(step.Data.Instant.Details.WindSpeed*3.6, 4),
+		WindDirection: step.Data.Instant.Details.WindFromDirection,
+		WindGust:      msToKmh(step.Data.Instant.Details.WindSpeedOfGust),
+		UVIndex:       step.Data.Instant.Details.UltravioletIndexClearSky,
+	}
+	if next := step.Data.Next1Hours; next != nil {
+		weather.Precipitation = next.Details.PrecipitationAmount
+		weather.Condition = interpretSymbolCode(next.Summary.SymbolCode)
+	} else if next := step.Data.Next6Hours; next != nil {
+		weather.Precipitation = Round(next.Details.PrecipitationAmount/6, 4)
+		weather.Condition = interpretSymbolCode(next.Summary.SymbolCode)
+	}
+
+	return weather.withDerivedValues(), "", nil
+}
+
+// ParseDailyForecastMetNo decodes a Locationforecast response from Met.no and aggregates its
+// time steps into up to days UTC days. Precipitation is summed over the hourly periods where
+// they are available and over the 6-hour periods after that, so that no hour is counted twice.
+func ParseDailyForecastMetNo(body io.Reader, logger *slog.Logger, days int) ([]DailyForecast, string, error) {
+	var response ResponseForecastMetNo
+
+	if err := json.NewDecoder(body).Decode(&response); err != nil {
+		return []DailyForecast{{SourceAPI: "Met.no API"}}, "", err
+	}
+	if len(response.Properties.Timeseries) == 0 {
+		return []DailyForecast{{SourceAPI: "Met.no API"}}, "", errors.New("empty or invalid response from API")
+	}
+
+	var forecast []DailyForecast
+	var coveredUntil time.Time
+	for _, step := range response.Properties.Timeseries {
+		t := step.Time.UTC()
+		forecastDate := t.Truncate(24 * time.Hour)
+		details := step.Data.Instant.Details
+
+		var precipitation, chance float64
+		switch {
+		case step.Data.Next1Hours != nil && !t.Before(coveredUntil):
+			precipitation = step.Data.Next1Hours.Details.PrecipitationAmount
+			chance = step.Data.Next1Hours.Details.ProbabilityOfPrecipitation
+			coveredUntil = t.Add(time.Hour)
+		case step.Data.Next6Hours != nil && !t.Before(coveredUntil):
+			precipitation = step.Data.Next6Hours.Details.PrecipitationAmount
+			chance = step.Data.Next6Hours.Details.ProbabilityOfPrecipitation
+			coveredUntil = t.Add(6 * time.Hour)
+		}
+		minTemp, maxTemp := details.AirTemperature, details.AirTemperature
+		if next := step.Data.Next6Hours; next != nil && !t.Add(6*time.Hour).After(forecastDate.Add(24*time.Hour)) {
+			minTemp = math.Min(minTemp, next.Details.AirTemperatureMin)
+			maxTemp = math.Max(maxTemp, next.Details.AirTemperatureMax)
+		}
+		windSpeed := Round(details.WindSpeed*3.6, 4)
+		humidity := int32(math.Round(details.RelativeHumidity))
+
+		if n := len(forecast); n > 0 && forecast[n-1].ForecastDate.Equal(forecastDate) {
+			day := &forecast[n-1]
+			day.MinTemp = math.Min(day.MinTemp, minTemp)
+			day.MaxTemp = math.Max(day.MaxTemp, maxTemp)
+			day.Precipitation = Round(day.Precipitation+precipitation, 4)
+			day.PrecipitationChance = max(day.PrecipitationChance, int32(math.Round(chance)))
+			if windSpeed > day.WindSpeed {
+				day.WindSpeed = windSpeed
+				day.WindDirection = details.WindFromDirection
+			}
+			day.WindGust = maxOptional(day.WindGust, msToKmh(details.WindSpeedOfGust))
+			day.Humidity = max(day.Humidity, humidity)
+			day.UVIndex = maxOptional(day.UVIndex, details.UltravioletIndexClearSky)
+			continue
+		}
+		if len(forecast) >= days {
+			break
+		}
+		forecast = append(forecast, DailyForecast{
+			SourceAPI:           "Met.no API",
+			ForecastDate:        forecastDate,
+			MinTemp:             minTemp,
+			MaxTemp:             maxTemp,
+			Precipitation:       Round(precipitation, 4),
+			PrecipitationChance: int32(math.Round(chance)),
+			WindSpeed:           windSpeed,
+			WindDirection:       details.WindFromDirection,
+			WindGust:            msToKmh(details.WindSpeedOfGust),
+			Humidity:            humidity,
+			UVIndex:             details.UltravioletIndexClearSky,
+		})
+	}
+
+	return forecast, "", nil
+}
+
+// ParseHourlyForecastMetNo decodes a Locationforecast response from Met.no and maps up to hours
+// hourly time steps to a slice of internal HourlyForecast structs.
+func ParseHourlyForecastMetNo(body io.Reader, logger *slog.Logger, hours int) ([]HourlyForecast, string, error) {
+	var response ResponseForecastMetNo
+
+	if err := json.NewDecoder(body).Decode(&response); err != nil {
+		return []HourlyForecast{{SourceAPI: "Met.no API"}}, "", err
+	}
+	if len(response.Properties.Timeseries) == 0 {
+		return []HourlyForecast{{SourceAPI: "Met.no API"}}, "", errors.New("empty or invalid response from API")
+	}
+
+	now := time.Now().UTC()
+	var forecast []HourlyForecast
+	for _, step := range response.Properties.Timeseries {
+		if len(forecast) >= hours {
+			break
+		}
+		next := step.Data.Next1Hours
+		if next == nil || !step.Time.After(now.Add(-1*time.Hour)) {
+			continue
+		}
+		forecast = append(forecast, HourlyForecast{
+			SourceAPI:           "Met.no API",
+			ForecastDateTime:    step.Time.UTC(),
+			Temperature:         step.Data.Instant.Details.AirTemperature,
+			DewPoint:            step.Data.Instant.Details.DewPointTemperature,
+			Humidity:            int32(math.Round(step.Data.Instant.Details.RelativeHumidity)),
+			WindSpeed:           Round(step.Data.Instant.Details.WindSpeed*3.6, 4),
+			WindDirection:       step.Data.Instant.Details.WindFromDirection,
+			WindGust:            msToKmh(step.Data.Instant.Details.WindSpeedOfGust),
+			Precipitation:       next.Details.PrecipitationAmount,
+			PrecipitationChance: int32(math.Round(next.Details.ProbabilityOfPrecipitation)),
+			Condition:           interpretSymbolCode(next.Summary.SymbolCode),
+		}.withDerivedValues())
+	}
+	if len(forecast) == 0 {
+		return []HourlyForecast{{SourceAPI: "Met.no API"}}, "", errors.New("no hourly forecasts in response")
+	}
+
+	return forecast, "", nil
+}
+
 // The following structs are used to unmarshal the JSON response from the Google Weather API.
 // GMP Structs
 type ResponseCurrentWeatherGMP struct {
-	Timestamp     time.Time        `json:"currentTime"`
-	TimeZone      TimeZone         `json:"timeZone"`
-	Temperature   Temperature      `json:"temperature"`
-	Humidity      float64          `json:"relativeHumidity"`
-	Wind          Wind             `json:"wind"`
-	Precipitation Precipitation    `json:"precipitation"`
-	Condition     WeatherCondition `json:"weatherCondition"`
+	Timestamp            time.Time        `json:"currentTime"`
+	TimeZone             TimeZone         `json:"timeZone"`
+	Temperature          Temperature      `json:"temperature"`
+	FeelsLikeTemperature *Temperature     `json:"feelsLikeTemperature"`
+	DewPoint             *Temperature     `json:"dewPoint"`
+	Humidity             float64          `json:"relativeHumidity"`
+	Wind                 Wind             `json:"wind"`
+	Precipitation        Precipitation    `json:"precipitation"`
+	Condition            WeatherCondition `json:"weatherCondition"`
+	UVIndex              *float64         `json:"uvIndex"`
 }
 
 type ResponseDailyForecastGMP struct {
@@ -382,23 +858,33 @@ type ResponseHourlyForecastGMP struct {
 }
 
 type ForecastDay struct {
-	Interval        Interval        `json:"interval"`
-	DaytimeForecast ForecastDayPart `json:"daytimeForecast"`
-	MaxTemperature  Temperature     `json:"maxTemperature"`
-	MinTemperature  Temperature     `json:"minTemperature"`
+	Interval          Interval         `json:"interval"`
+	DaytimeForecast   ForecastDayPart  `json:"daytimeForecast"`
+	NighttimeForecast *ForecastDayPart `json:"nighttimeForecast"`
+	MaxTemperature    Temperature      `json:"maxTemperature"`
+	MinTemperature    Temperature      `json:"minTemperature"`
+	SunEvents         SunEvents        `json:"sunEvents"`
 }
 
 type ForecastHour struct {
-	Interval      Interval         `json:"interval"`
-	Condition     WeatherCondition `json:"weatherCondition"`
-	Temperature   Temperature      `json:"temperature"`
-	Precipitation Precipitation    `json:"precipitation"`
-	Wind          Wind             `json:"wind"`
-	Humidity      int32            `json:"relativeHumidity"`
+	Interval             Interval         `json:"interval"`
+	Condition            WeatherCondition `json:"weatherCondition"`
+	Temperature          Temperature      `json:"temperature"`
+	FeelsLikeTemperature *Temperature     `json:"feelsLikeTemperature"`
+	DewPoint             *Temperature     `json:"dewPoint"`
+	Precipitation        Precipitation    `json:"precipitation"`
+	Wind                 Wind             `json:"wind"`
+	Humidity             int32            `json:"relativeHumidity"`
 }
 
 type Interval struct {
 	StartTime time.Time `json:"startTime"`
+	EndTime   time.Time `json:"endTime"`
+}
+
+type SunEvents struct {
+	SunriseTime time.Time `json:"sunriseTime"`
+	SunsetTime  time.Time `json:"sunsetTime"`
 }
 
 type TimeZone struct {
@@ -406,18 +892,53 @@ type TimeZone struct {
 }
 
 type ForecastDayPart struct {
+	Interval         Interval         `json:"interval"`
 	Condition        WeatherCondition `json:"weatherCondition"`
 	Precipitation    Precipitation    `json:"precipitation"`
 	Wind             Wind             `json:"wind"`
 	RelativeHumidity int32            `json:"relativeHumidity"`
+	UVIndex          *float64         `json:"uvIndex"`
+}
+
+// dayPart returns the precipitation chance and the condition of the daytime or nighttime forecast.
+// The API reports no temperature for them.
+func (p *ForecastDayPart) dayPart() DayPart {
+	chance, condition := p.Precipitation.Probability.Percent, p.Condition.Description.Text
+	part := DayPart{PrecipitationChance: &chance}
+	if condition != "" {
+		part.Condition = &condition
+	}
+	return part
 }
 
 type Temperature struct {
 	Degrees float64 `json:"degrees"`
 }
 
+// degrees returns the temperature, or nil if the response has none.
+func (t *Temperature) degrees() *float64 {
+	if t == nil {
+		return nil
+	}
+	return &t.Degrees
+}
+
 type Wind struct {
-	Speed Speed `json:"speed"`
+	Direction Direction `json:"direction"`
+	Speed     Speed     `json:"speed"`
+	Gust      *Speed    `json:"gust"`
+}
+
+// gust returns the gust speed, or nil if the response has none.
+func (w Wind) gust() *float64 {
+	if w.Gust == nil {
+		return nil
+	}
+	return &w.Gust.Value
+}
+
+type Direction struct {
+	Degrees *float64 `json:"degrees"`
 }
 
 type Speed struct {
@@ -426,9 +947,19 @@ type Speed struct {
 
 type Precipitation struct {
 	Qpf         Qpf                      `json:"qpf"`
+	SnowQpf     *Qpf                     `json:"snowQpf"`
 	Probability PrecipitationProbability `json:"probability"`
 }
 
+// split returns the rain and the snow of the precipitation, both as liquid water equivalent, or
+// nil if the response does not report the snow.
+func (p Precipitation) split() (rain, snow *float64) {
+	if p.SnowQpf == nil {
+		return nil, nil
+	}
+	return precipitationAmount(max(p.Qpf.Quantity-p.SnowQpf.Quantity, 0)), precipitationAmount(p.SnowQpf.Quantity)
+}
+
 type Qpf struct {
 	Quantity float64 `json:"quantity"`
 }
@@ -465,11 +996,18 @@ type ResponseHourlyForecastOWM struct {
 type CurrentOWM struct {
 	Dt        int64     `json:"dt"`
 	Temp      float64   `json:"temp"`
+	FeelsLike *float64  `json:"feels_like"`
+	DewPoint  *float64  `json:"dew_point"`
 	Humidity  float64   `json:"humidity"`
 	WindSpeed float64   `json:"wind_speed"`
+	WindDeg   *float64  `json:"wind_deg"`
+	WindGust  *float64  `json:"wind_gust"`
 	Rain      Rain      `json:"rain"`
 	Snow      Snow      `json:"snow"`
 	Weather   []Weather `json:"weather"`
+	UVI       *float64  `json:"uvi"`
+	Sunrise   int64     `json:"sunrise"`
+	Sunset    int64     `json:"sunset"`
 }
 
 type DailyOWM struct {
@@ -480,14 +1018,23 @@ type DailyOWM struct {
 	Weather   []Weather `json:"weather"`
 	Pop       float64   `json:"pop"`
 	WindSpeed float64   `json:"wind_speed"`
+	WindDeg   *float64  `json:"wind_deg"`
+	WindGust  *float64  `json:"wind_gust"`
 	Humidity  int32     `json:"humidity"`
+	UVI       *float64  `json:"uvi"`
+	Sunrise   int64     `json:"sunrise"`
+	Sunset    int64     `json:"sunset"`
 }
 
 type HourlyOWM struct {
 	Dt        int64     `json:"dt"`
 	Temp      float64   `json:"temp"`
+	FeelsLike *float64  `json:"feels_like"`
+	DewPoint  *float64  `json:"dew_point"`
 	Humidity  int32     `json:"humidity"`
 	WindSpeed float64   `json:"wind_speed"`
+	WindDeg   *float64  `json:"wind_deg"`
+	WindGust  *float64  `json:"wind_gust"`
 	Rain      Rain      `json:"rain"`
 	Snow      Snow      `json:"snow"`
 	Weather   []Weather `json:"weather"`
@@ -495,8 +1042,12 @@ type HourlyOWM struct {
 }
 
 type Temp struct {
-	Min float64 `json:"min"`
-	Max float64 `json:"max"`
+	Min   float64  `json:"min"`
+	Max   float64  `json:"max"`
+	Morn  *float64 `json:"morn"`
+	Day   *float64 `json:"day"`
+	Eve   *float64 `json:"eve"`
+	Night *float64 `json:"night"`
 }
 
 type Rain struct {
@@ -511,11 +1062,72 @@ type Weather struct {
 	Main string `json:"main"`
 }
 
+// The following structs are used to unmarshal the JSON responses from the OpenWeatherMap 2.5 API,
+// which is used when One Call 3.0 is not available for the configured key.
+// OWM 2.5 Structs
+type ResponseCurrentWeatherOWM25 struct {
+	Dt       int64     `json:"dt"`
+	Main     MainOWM25 `json:"main"`
+	Wind     WindOWM25 `json:"wind"`
+	Rain     Rain      `json:"rain"`
+	Snow     Snow      `json:"snow"`
+	Weather  []Weather `json:"weather"`
+	Sys      SysOWM25  `json:"sys"`
+	Timezone int       `json:"timezone"` // Offset from UTC in seconds.
+}
+
+type SysOWM25 struct {
+	Sunrise int64 `json:"sunrise"`
+	Sunset  int64 `json:"sunset"`
+}
+
+type ResponseForecastOWM25 struct {
+	List []ForecastOWM25 `json:"list"`
+	City CityOWM25       `json:"city"`
+}
+
+type ForecastOWM25 struct {
+	Dt      int64     `json:"dt"`
+	Main    MainOWM25 `json:"main"`
+	Wind    WindOWM25 `json:"wind"`
+	Rain    Rain3h    `json:"rain"`
+	Snow    Snow3h    `json:"snow"`
+	Weather []Weather `json:"weather"`
+	Pop     float64   `json:"pop"`
+}
+
+type CityOWM25 struct {
+	Timezone int `json:"timezone"` // Offset from UTC in seconds.
+}
+
+type MainOWM25 struct {
+	Temp      float64  `json:"temp"`
+	FeelsLike *float64 `json:"feels_like"`
+	TempMin   float64  `json:"temp_min"`
+	TempMax   float64  `json:"temp_max"`
+	Humidity  float64  `json:"humidity"`
+}
+
+type WindOWM25 struct {
+	Speed float64  `json:"speed"`
+	Deg   *float64 `json:"deg"`
+	Gust  *float64 `json:"gust"`
+}
+
+type Rain3h struct {
+	Quantity float64 `json:"3h"`
+}
+
+type Snow3h struct {
+	Quantity float64 `json:"3h"`
+}
+
 // The following structs are used to unmarshal the JSON response from the Open-Meteo API.
 // OMeteo Structs
 type ResponseCurrentWeatherOMeteo struct {
-	CurrentWeather CurrentOMeteo `json:"current"`
-	Timezone       string        `json:"timezone"`
+	CurrentWeather CurrentOMeteo  `json:"current"`
+	Daily          SunTimesOMeteo `json:"daily"`
+	Timezone       string         `json:"timezone"`
 }
 
 type ResponseDailyForecastOMeteo struct {
@@ -529,33 +1141,97 @@ type ResponseHourlyForecastOMeteo struct {
 }
 
 type CurrentOMeteo struct {
-	Time               int64   `json:"time"`
-	Temperature2m      float64 `json:"temperature_2m"`
-	RelativeHumidity2m int32   `json:"relative_humidity_2m"`
-	WindSpeed10m       float64 `json:"wind_speed_10m"`
-	Precipitation      float64 `json:"precipitation"`
-	WeatherCode        int     `json:"weather_code"`
+	Time                int64    `json:"time"`
+	Temperature2m       float64  `json:"temperature_2m"`
+	ApparentTemperature *float64 `json:"apparent_temperature"`
+	DewPoint2m          *float64 `json:"dew_point_2m"`
+	RelativeHumidity2m  int32    `json:"relative_humidity_2m"`
+	WindSpeed10m        float64  `json:"wind_speed_10m"`
+	WindDirection10m    *float64 `json:"wind_direction_10m"`
+	WindGusts10m        *float64 `json:"wind_gusts_10m"`
+	Precipitation       float64  `json:"precipitation"`
+	Rain                *float64 `json:"rain"`
+	Showers             *float64 `json:"showers"`
+	WeatherCode         int      `json:"weather_code"`
+	UVIndex             *float64 `json:"uv_index"`
+}
+
+type SunTimesOMeteo struct {
+	Sunrise []int64 `json:"sunrise"`
+	Sunset  []int64 `json:"sunset"`
 }
 
 type DailyOMeteo struct {
-	Time                        []int64   `json:"time"`
-	Temperature2mMax            []float64 `json:"temperature_2m_max"`
-	Temperature2mMin            []float64 `json:"temperature_2m_min"`
-	PrecipitationSum            []float64 `json:"precipitation_sum"`
-	PrecipitationProbabilityMax []int32   `json:"precipitation_probability_max"`
-	WeatherCode                 []int     `json:"weather_code"`
-	WindSpeed10mMax             []float64 `json:"wind_speed_10m_max"`
-	RelativeHumidity2mMax       []int32   `json:"relative_humidity_2m_max"`
+	Time                        []int64    `json:"time"`
+	Temperature2mMax            []float64  `json:"temperature_2m_max"`
+	Temperature2mMin            []float64  `json:"temperature_2m_min"`
+	PrecipitationSum            []float64  `json:"precipitation_sum"`
+	RainSum                     []*float64 `json:"rain_sum"`
+	ShowersSum                  []*float64 `json:"showers_sum"`
+	PrecipitationProbabilityMax []int32    `json:"precipitation_probability_max"`
+	WeatherCode                 []int      `json:"weather_code"`
+	WindSpeed10mMax             []float64  `json:"wind_speed_10m_max"`
+	RelativeHumidity2mMax       []int32    `json:"relative_humidity_2m_max"`
+	WindDirection10mDominant    []*float64 `json:"wind_direction_10m_dominant"`
+	WindGusts10mMax             []*float64 `json:"wind_gusts_10m_max"`
+	UVIndexMax                  []*float64 `json:"uv_index_max"`
+	Sunrise                     []int64    `json:"sunrise"`
+	Sunset                      []int64    `json:"sunset"`
 }
 
 type HourlyOMeteo struct {
-	Time                     []int64   `json:"time"`
-	Temperature2m            []float64 `json:"temperature_2m"`
-	RelativeHumidity2m       []int32   `json:"relative_humidity_2m"`
-	WindSpeed10m             []float64 `json:"wind_speed_10m"`
-	Precipitation            []float64 `json:"precipitation"`
-	PrecipitationProbability []int32   `json:"precipitation_probability"`
-	WeatherCode              []int     `json:"weather_code"`
+	Time                     []int64    `json:"time"`
+	Temperature2m            []float64  `json:"temperature_2m"`
+	RelativeHumidity2m       []int32    `json:"relative_humidity_2m"`
+	WindSpeed10m             []float64  `json:"wind_speed_10m"`
+	Precipitation            []float64  `json:"precipitation"`
+	Rain                     []*float64 `json:"rain"`
+	Showers                  []*float64 `json:"showers"`
+	PrecipitationProbability []int32    `json:"precipitation_probability"`
+	WeatherCode              []int      `json:"weather_code"`
+	WindDirection10m         []*float64 `json:"wind_direction_10m"`
+	WindGusts10m             []*float64 `json:"wind_gusts_10m"`
+	ApparentTemperature      []*float64 `json:"apparent_temperature"`
+	DewPoint2m               []*float64 `json:"dew_point_2m"`
+}
+
+// Met.no Structs
+type ResponseForecastMetNo struct {
+	Properties struct {
+		Timeseries []MetNoTimeStep `json:"timeseries"`
+	} `json:"properties"`
+}
+
+type MetNoTimeStep struct {
+	Time time.Time `json:"time"`
+	Data struct {
+		Instant struct {
+			Details MetNoDetails `json:"details"`
+		} `json:"instant"`
+		Next1Hours *MetNoPeriod `json:"next_1_hours"`
+		Next6Hours *MetNoPeriod `json:"next_6_hours"`
+	} `json:"data"`
+}
+
+type MetNoPeriod struct {
+	Summary struct {
+		SymbolCode string `json:"symbol_code"`
+	} `json:"summary"`
+	Details MetNoDetails `json:"details"`
+}
+
+type MetNoDetails struct {
+	AirTemperature             float64  `json:"air_temperature"`
+	AirTemperatureMax          float64  `json:"air_temperature_max"`
+	AirTemperatureMin          float64  `json:"air_temperature_min"`
+	DewPointTemperature        *float64 `json:"dew_point_temperature"`
+	RelativeHumidity           float64  `json:"relative_humidity"`
+	WindSpeed                  float64  `json:"wind_speed"` // m/s
+	WindFromDirection          *float64 `json:"wind_from_direction"`
+	WindSpeedOfGust            *float64 `json:"wind_speed_of_gust"` // m/s
+	PrecipitationAmount        float64  `json:"precipitation_amount"`
+	ProbabilityOfPrecipitation float64  `json:"probability_of_precipitation"`
+	UltravioletIndexClearSky   *float64 `json:"ultraviolet_index_clear_sky"`
 }
 
 // Utility functions
@@ -566,66 +1242,96 @@ func Round(val float64, precision int) float64 {
 	return math.Round(val*p) / p
 }
 
+// sunEventTime converts the Unix timestamp of a sunrise or sunset to a time in loc. Providers
+// report 0 for events that do not occur on a day, e.g. the sunrise during the polar night, which
+// maps to the zero time.
+func sunEventTime(sec int64, loc *time.Location) time.Time {
+	if sec == 0 {
+		return time.Time{}
+	}
+	return time.Unix(sec, 0).In(loc)
+}
+
+// msToKmh converts a speed in m/s that may be missing to km/h.
+func msToKmh(ms *float64) *float64 {
+	if ms == nil {
+		return nil
+	}
+	kmh := Round(*ms*3.6, 4)
+	return &kmh
+}
+
+// precipitationAmount returns a pointer to a precipitation amount, rounded to four decimals.
+func precipitationAmount(mm float64) *float64 {
+	rounded := Round(mm, 4)
+	return &rounded
+}
+
+// splitPrecipitationOMeteo splits an Open-Meteo precipitation total into rain, including showers,
+// and snow, both as liquid water equivalent. Open-Meteo reports snowfall in centimeters of snow,
+// so the snow is the remainder of the total. Both are nil if the response has no rain.
+func splitPrecipitationOMeteo(total float64, rain, showers *float64) (*float64, *float64) {
+	if rain == nil {
+		return nil, nil
+	}
+	liquid := *rain
+	if showers != nil {
+		liquid += *showers
+	}
+	return precipitationAmount(liquid), precipitationAmount(max(total-liquid, 0))
+}
+
+// maxOptional returns the higher of two values, either of which may be missing.
+func maxOptional(a, b *float64) *float64 {
+	if a == nil || (b != nil && *b > *a) {
+		return b
+	}
+	return a
+}
+
 // interpretWeatherCode translates a WMO weather code from the Open-Meteo API into a human-readable string.
+// Descriptions are stored in English and translated when they are sent (see localizeJSON).
 func interpretWeatherCode(i int) string {
-	switch i {
-	case 0:
-		return "clear sky"
-	case 1:
-		return "mainly clear"
-	case 2:
-		return "partly cloudy"
-	case 3:
-		return "overcast"
-	case 45:
-		return "fog"
-	case 48:
-		return "depositing rime fog"
-	case 51:
-		return "light drizzle"
-	case 53:
-		return "moderate drizzle"
-	case 55:
-		return "dense drizzle"
-	case 56:
-		return "light freezing drizzle"
-	case 57:
-		return "dense freezing drizzle"
-	case 61:
-		return "slight rain"
-	case 63:
-		return "moderate rain"
-	case 65:
-		return "heavy rain"
-	case 66:
-		return "light freezing rain"
-	case 67:
-		return "heavy freezing rain"
-	case 71:
-		return "slight snowfall"
-	case 73:
-		return "moderate snowfall"
-	case 75:
-		return "heavy snowfall"
-	case 77:
-		return "snow grains"
-	case 80:
-		return "slight showers"
-	case 81:
-		return "moderate showers"
-	case 82:
-		return "violent showers"
-	case 85:
-		return "slight snow showers"
-	case 86:
-		return "heavy snow showers"
-	case 95:
-		return "thunderstorm"
-	case 96:
-		return "thunderstorm with slight hail"
-	case 99:
-		return "thunderstorm with heavy hail"
-	default:
-		return "unknown code"
+	return englishLocale.weatherDescription(i)
+}
+
+// metNoSymbols maps the Met.no weather symbols without precipitation to condition texts.
+var metNoSymbols = map[string]string{
+	"clearsky":     "clear sky",
+	"fair":         "mainly clear",
+	"partlycloudy": "partly cloudy",
+	"cloudy":       "cloudy",
+	"fog":          "fog",
+}
+
+// interpretSymbolCode translates a Met.no symbol code such as "lightrainshowers_day" into a
+// human-readable string such as "light rain showers". The time-of-day suffix is dropped.
+func interpretSymbolCode(code string) string {
+	code, _, _ = strings.Cut(code, "_")
+	if text, ok := metNoSymbols[code]; ok {
+		return text
+	}
+
+	var words []string
+	for _, intensity := range []string{"light", "heavy"} {
+		if rest, ok := strings.CutPrefix(code, intensity); ok {
+			words = append(words, intensity)
+			// Met.no spells some light and heavy symbols with a doubled "s", e.g. "lightssleetshowers".
+			if strings.HasPrefix(rest, "ss") {
+				rest = rest[1:]
+			}
+			code = rest
+			break
+		}
+	}
+	code, thunder := strings.CutSuffix(code, "andthunder")
+	code, showers := strings.CutSuffix(code, "showers")
+	words = append(words, code)
+	if showers {
+		words = append(words, "showers")
+	}
+	if thunder {
+		words = append(words, "and thunder")
 	}
+	return strings.Join(words, " ")
 }
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"embed"
 	"encoding/json"
+	"fmt"
 	"io"
 	"log/slog"
 	"strings"
@@ -298,7 +299,7 @@ func TestParseDailyForecastGMP(t *testing.T) {
 		}
 		expectedTimezone := "Europe/Warsaw"
 
-		parsedForecast, tz, err := ParseDailyForecastGMP(sampleJSON, slog.Default())
+		parsedForecast, tz, err := ParseDailyForecastGMP(sampleJSON, slog.Default(), defaultForecastDays)
 		if err != nil {
 			t.Fatalf("ParseDailyForecastGMP failed with error: %v", err)
 		}
@@ -348,7 +349,7 @@ func TestParseDailyForecastGMP(t *testing.T) {
 		modifiedContent := strings.Replace(string(content), "Europe/Warsaw", "Mars/Olympus_Mons", 1)
 		reader := strings.NewReader(modifiedContent)
 
-		parsedForecast, _, err := ParseDailyForecastGMP(reader, slog.Default())
+		parsedForecast, _, err := ParseDailyForecastGMP(reader, slog.Default(), defaultForecastDays)
 		if err != nil {
 			t.Fatalf("ParseDailyForecastGMP failed with error: %v", err)
 		}
@@ -378,7 +379,7 @@ func TestParseDailyForecastGMP(t *testing.T) {
 		}
 		reader := bytes.NewReader(modifiedContent)
 
-		parsedForecast, _, err := ParseDailyForecastGMP(reader, slog.Default())
+		parsedForecast, _, err := ParseDailyForecastGMP(reader, slog.Default(), defaultForecastDays)
 		if err != nil {
 			t.Fatalf("ParseDailyForecastGMP failed with error: %v", err)
 		}
@@ -392,7 +393,7 @@ func TestParseDailyForecastGMP(t *testing.T) {
 func TestParseDailyForecastGMP_Error(t *testing.T) {
 	invalidJSON := strings.NewReader(`{ "invalid": "json" }`)
 
-	parsedForecast, _, err := ParseDailyForecastGMP(invalidJSON, slog.Default())
+	parsedForecast, _, err := ParseDailyForecastGMP(invalidJSON, slog.Default(), defaultForecastDays)
 	if err == nil {
 		t.Fatal("expected an error for invalid JSON, but got nil")
 	}
@@ -429,7 +430,7 @@ func TestParseHourlyForecastGMP(t *testing.T) {
 		}
 		expectedTimezone := "Europe/Warsaw"
 
-		parsedForecast, tz, err := ParseHourlyForecastGMP(sampleJSON, slog.Default())
+		parsedForecast, tz, err := ParseHourlyForecastGMP(sampleJSON, slog.Default(), defaultForecastHours)
 		if err != nil {
 			t.Fatalf("ParseHourlyForecastGMP failed with error: %v", err)
 		}
@@ -479,7 +480,7 @@ func TestParseHourlyForecastGMP(t *testing.T) {
 		modifiedContent := strings.Replace(string(content), "Europe/Warsaw", "Mars/Olympus_Mons", 1)
 		reader := strings.NewReader(modifiedContent)
 
-		parsedForecast, _, err := ParseHourlyForecastGMP(reader, slog.Default())
+		parsedForecast, _, err := ParseHourlyForecastGMP(reader, slog.Default(), defaultForecastHours)
 		if err != nil {
 			t.Fatalf("ParseHourlyForecastGMP failed with error: %v", err)
 		}
@@ -509,7 +510,7 @@ func TestParseHourlyForecastGMP(t *testing.T) {
 		}
 		reader := bytes.NewReader(modifiedContent)
 
-		parsedForecast, _, err := ParseHourlyForecastGMP(reader, slog.Default())
+		parsedForecast, _, err := ParseHourlyForecastGMP(reader, slog.Default(), defaultForecastHours)
 		if err != nil {
 			t.Fatalf("ParseHourlyForecastGMP failed with error: %v", err)
 		}
@@ -542,7 +543,7 @@ func TestParseDailyForecastOWM(t *testing.T) {
 		}
 		expectedTimezone := "Europe/Warsaw"
 
-		parsedForecast, tz, err := ParseDailyForecastOWM(sampleJSON, slog.Default())
+		parsedForecast, tz, err := ParseDailyForecastOWM(sampleJSON, slog.Default(), defaultForecastDays)
 		if err != nil {
 			t.Fatalf("ParseDailyForecastOWM failed with error: %v", err)
 		}
@@ -592,7 +593,7 @@ func TestParseDailyForecastOWM(t *testing.T) {
 		modifiedContent := strings.Replace(string(content), "Europe/Warsaw", "Mars/Olympus_Mons", 1)
 		reader := strings.NewReader(modifiedContent)
 
-		parsedForecast, _, err := ParseDailyForecastOWM(reader, slog.Default())
+		parsedForecast, _, err := ParseDailyForecastOWM(reader, slog.Default(), defaultForecastDays)
 		if err != nil {
 			t.Fatalf("ParseDailyForecastOWM failed with error: %v", err)
 		}
@@ -622,7 +623,7 @@ func TestParseDailyForecastOWM(t *testing.T) {
 		}
 		reader := bytes.NewReader(modifiedContent)
 
-		parsedForecast, _, err := ParseDailyForecastOWM(reader, slog.Default())
+		parsedForecast, _, err := ParseDailyForecastOWM(reader, slog.Default(), defaultForecastDays)
 		if err != nil {
 			t.Fatalf("ParseDailyForecastOWM failed with error: %v", err)
 		}
@@ -633,10 +634,95 @@ func TestParseDailyForecastOWM(t *testing.T) {
 	})
 }
 
+func TestParseDailyForecastDayParts(t *testing.T) {
+	formatDayPart := func(p DayPart) string {
+		temp, chance, condition := "null", "null", "null"
+		if p.Temperature != nil {
+			temp = fmt.Sprint(*p.Temperature)
+		}
+		if p.PrecipitationChance != nil {
+			chance = fmt.Sprint(*p.PrecipitationChance)
+		}
+		if p.Condition != nil {
+			condition = *p.Condition
+		}
+		return temp + "/" + chance + "/" + condition
+	}
+
+	testCases := []struct {
+		name  string
+		file  string
+		parse func(io.Reader, *slog.Logger, int) ([]DailyForecast, string, error)
+		want  [4]string
+	}{
+		{
+			name:  "Google",
+			file:  "testdata/daily_forecast_gmp.json",
+			parse: ParseDailyForecastGMP,
+			want:  [4]string{"null/50/Light rain", "null/50/Light rain", "null/0/Clear", "null/50/Clear"},
+		},
+		{
+			name:  "OpenWeatherMap",
+			file:  "testdata/daily_forecast_owm.json",
+			parse: ParseDailyForecastOWM,
+			want:  [4]string{"14.59/null/null", "23.96/null/null", "21.5/null/null", "15.55/null/null"},
+		},
+		{
+			name:  "Open-Meteo",
+			file:  "testdata/daily_forecast_ometeo.json",
+			parse: ParseDailyForecastOMeteo,
+			want:  [4]string{"null/null/null", "null/null/null", "null/null/null", "null/null/null"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sampleJSON, err := testData.Open(tc.file)
+			if err != nil {
+				t.Fatalf("failed to open test data: %v", err)
+			}
+			defer sampleJSON.Close()
+
+			forecast, _, err := tc.parse(sampleJSON, slog.Default(), defaultForecastDays)
+			if err != nil {
+				t.Fatalf("parse failed with error: %v", err)
+			}
+			day := forecast[0]
+			got := [4]string{formatDayPart(day.Morning), formatDayPart(day.Afternoon), formatDayPart(day.Evening), formatDayPart(day.Night)}
+			if got != tc.want {
+				t.Errorf("day parts = %v, want %v", got, tc.want)
+			}
+		})
+	}
+
+	// The evening comes from the nighttime forecast and the night from the previous day's, so the
+	// first day's night is empty without one.
+	response := `{"forecastDays":[
+		{"interval":{"startTime":"2025-08-06T05:00:00Z"},
+		 "daytimeForecast":{"interval":{"startTime":"2025-08-06T05:00:00Z","endTime":"2025-08-06T17:00:00Z"},"precipitation":{"probability":{"percent":10}}},
+		 "nighttimeForecast":{"interval":{"startTime":"2025-08-06T17:00:00Z","endTime":"2025-08-07T05:00:00Z"},"precipitation":{"probability":{"percent":70}},"weatherCondition":{"description":{"text":"Rain"}}}},
+		{"interval":{"startTime":"2025-08-07T05:00:00Z"},
+		 "daytimeForecast":{"interval":{"startTime":"2025-08-07T05:00:00Z","endTime":"2025-08-07T17:00:00Z"},"precipitation":{"probability":{"percent":20}}}}
+	],"timeZone":{"id":"Europe/Warsaw"}}`
+	forecast, _, err := ParseDailyForecastGMP(strings.NewReader(response), slog.Default(), defaultForecastDays)
+	if err != nil {
+		t.Fatalf("ParseDailyForecastGMP failed with error: %v", err)
+	}
+	want := [][4]string{
+		{"null/10/null", "null/10/null", "null/70/Rain", "null/null/null"},
+		{"null/20/null", "null/20/null", "null/20/null", "null/70/Rain"},
+	}
+	for i, day := range forecast {
+		got := [4]string{formatDayPart(day.Morning), formatDayPart(day.Afternoon), formatDayPart(day.Evening), formatDayPart(day.Night)}
+		if got != want[i] {
+			t.Errorf("day %d parts = %v, want %v", i, got, want[i])
+		}
+	}
+}
+
 func TestParseDailyForecastOWM_Error(t *testing.T) {
 	invalidJSON := strings.NewReader(`{ "invalid": "json" }`)
 
-	parsedForecast, _, err := ParseDailyForecastOWM(invalidJSON, slog.Default())
+	parsedForecast, _, err := ParseDailyForecastOWM(invalidJSON, slog.Default(), defaultForecastDays)
 	if err == nil {
 		t.Fatal("expected an error for invalid JSON, but got nil")
 	}
@@ -654,7 +740,7 @@ func TestParseDailyForecastOWM_Error(t *testing.T) {
 func TestParseHourlyForecastGMP_Error(t *testing.T) {
 	invalidJSON := strings.NewReader(`{ "invalid": "json" }`)
 
-	parsedForecast, _, err := ParseHourlyForecastGMP(invalidJSON, slog.Default())
+	parsedForecast, _, err := ParseHourlyForecastGMP(invalidJSON, slog.Default(), defaultForecastHours)
 	if err == nil {
 		t.Fatal("expected an error for invalid JSON, but got nil")
 	}
@@ -672,7 +758,7 @@ func TestParseHourlyForecastGMP_Error(t *testing.T) {
 func TestParseHourlyForecastOWM_Error(t *testing.T) {
 	invalidJSON := strings.NewReader(`{ "invalid": "json" }`)
 
-	parsedForecast, _, err := ParseHourlyForecastOWM(invalidJSON, slog.Default())
+	parsedForecast, _, err := ParseHourlyForecastOWM(invalidJSON, slog.Default(), defaultForecastHours)
 	if err == nil {
 		t.Fatal("expected an error for invalid JSON, but got nil")
 	}
@@ -690,7 +776,7 @@ func TestParseHourlyForecastOWM_Error(t *testing.T) {
 func TestParseHourlyForecastOMeteo_Error(t *testing.T) {
 	invalidJSON := strings.NewReader(`{ "invalid": "json" }`)
 
-	parsedForecast, _, err := ParseHourlyForecastOMeteo(invalidJSON, slog.Default())
+	parsedForecast, _, err := ParseHourlyForecastOMeteo(invalidJSON, slog.Default(), defaultForecastHours)
 	if err == nil {
 		t.Fatal("expected an error for invalid JSON, but got nil")
 	}
@@ -727,7 +813,7 @@ func TestParseHourlyForecastOWM(t *testing.T) {
 		}
 		expectedTimezone := "Europe/Warsaw"
 
-		parsedForecast, tz, err := ParseHourlyForecastOWM(sampleJSON, slog.Default())
+		parsedForecast, tz, err := ParseHourlyForecastOWM(sampleJSON, slog.Default(), defaultForecastHours)
 		if err != nil {
 			t.Fatalf("ParseHourlyForecastOWM failed with error: %v", err)
 		}
@@ -777,7 +863,7 @@ func TestParseHourlyForecastOWM(t *testing.T) {
 		modifiedContent := strings.Replace(string(content), "Europe/Warsaw", "Mars/Olympus_Mons", 1)
 		reader := strings.NewReader(modifiedContent)
 
-		parsedForecast, _, err := ParseHourlyForecastOWM(reader, slog.Default())
+		parsedForecast, _, err := ParseHourlyForecastOWM(reader, slog.Default(), defaultForecastHours)
 		if err != nil {
 			t.Fatalf("ParseHourlyForecastOWM failed with error: %v", err)
 		}
@@ -807,7 +893,7 @@ func TestParseHourlyForecastOWM(t *testing.T) {
 		}
 		reader := bytes.NewReader(modifiedContent)
 
-		parsedForecast, _, err := ParseHourlyForecastOWM(reader, slog.Default())
+		parsedForecast, _, err := ParseHourlyForecastOWM(reader, slog.Default(), defaultForecastHours)
 		if err != nil {
 			t.Fatalf("ParseHourlyForecastOWM failed with error: %v", err)
 		}
@@ -840,7 +926,7 @@ func TestParseHourlyForecastOMeteo(t *testing.T) {
 		}
 		expectedTimezone := "Europe/Warsaw"
 
-		parsedForecast, tz, err := ParseHourlyForecastOMeteo(sampleJSON, slog.Default())
+		parsedForecast, tz, err := ParseHourlyForecastOMeteo(sampleJSON, slog.Default(), defaultForecastHours)
 		if err != nil {
 			t.Fatalf("ParseHourlyForecastOMeteo failed with error: %v", err)
 		}
@@ -890,7 +976,7 @@ func TestParseHourlyForecastOMeteo(t *testing.T) {
 		modifiedContent := strings.Replace(string(content), "Europe/Warsaw", "Mars/Olympus_Mons", 1)
 		reader := strings.NewReader(modifiedContent)
 
-		parsedForecast, _, err := ParseHourlyForecastOMeteo(reader, slog.Default())
+		parsedForecast, _, err := ParseHourlyForecastOMeteo(reader, slog.Default(), defaultForecastHours)
 		if err != nil {
 			t.Fatalf("ParseHourlyForecastOMeteo failed with error: %v", err)
 		}
@@ -926,7 +1012,7 @@ func TestParseHourlyForecastOMeteo(t *testing.T) {
 		}
 		reader := bytes.NewReader(modifiedContent)
 
-		parsedForecast, _, err := ParseHourlyForecastOMeteo(reader, slog.Default())
+		parsedForecast, _, err := ParseHourlyForecastOMeteo(reader, slog.Default(), defaultForecastHours)
 		if err != nil {
 			t.Fatalf("ParseHourlyForecastOMeteo failed with error: %v", err)
 		}
@@ -960,7 +1046,7 @@ func TestParseDailyForecastOMeteo(t *testing.T) {
 		}
 		expectedTimezone := "Europe/Warsaw"
 
-		parsedForecast, tz, err := ParseDailyForecastOMeteo(sampleJSON, slog.Default())
+		parsedForecast, tz, err := ParseDailyForecastOMeteo(sampleJSON, slog.Default(), defaultForecastDays)
 		if err != nil {
 			t.Fatalf("ParseDailyForecastOMeteo failed with error: %v", err)
 		}
@@ -1010,7 +1096,7 @@ func TestParseDailyForecastOMeteo(t *testing.T) {
 		modifiedContent := strings.Replace(string(content), "Europe/Warsaw", "Mars/Olympus_Mons", 1)
 		reader := strings.NewReader(modifiedContent)
 
-		parsedForecast, _, err := ParseDailyForecastOMeteo(reader, slog.Default())
+		parsedForecast, _, err := ParseDailyForecastOMeteo(reader, slog.Default(), defaultForecastDays)
 		if err != nil {
 			t.Fatalf("ParseDailyForecastOMeteo failed with error: %v", err)
 		}
@@ -1047,7 +1133,7 @@ func TestParseDailyForecastOMeteo(t *testing.T) {
 		}
 		reader := bytes.NewReader(modifiedContent)
 
-		parsedForecast, _, err := ParseDailyForecastOMeteo(reader, slog.Default())
+		parsedForecast, _, err := ParseDailyForecastOMeteo(reader, slog.Default(), defaultForecastDays)
 		if err != nil {
 			t.Fatalf("ParseDailyForecastOMeteo failed with error: %v", err)
 		}
@@ -1061,7 +1147,7 @@ func TestParseDailyForecastOMeteo(t *testing.T) {
 func TestParseDailyForecastOMeteo_Error(t *testing.T) {
 	invalidJSON := strings.NewReader(`{ "invalid": "json" }`)
 
-	parsedForecast, _, err := ParseDailyForecastOMeteo(invalidJSON, slog.Default())
+	parsedForecast, _, err := ParseDailyForecastOMeteo(invalidJSON, slog.Default(), defaultForecastDays)
 	if err == nil {
 		t.Fatal("expected an error for invalid JSON, but got nil")
 	}
@@ -1102,7 +1188,7 @@ func TestParseCurrentWeatherOMeteo_DecoderError(t *testing.T) {
 
 func TestParseDailyForecastGMP_DecoderError(t *testing.T) {
 	malformedJSON := strings.NewReader(`{,}`)
-	_, _, err := ParseDailyForecastGMP(malformedJSON, slog.Default())
+	_, _, err := ParseDailyForecastGMP(malformedJSON, slog.Default(), defaultForecastDays)
 	if err == nil {
 		t.Fatal("expected a decoder error, but got nil")
 	}
@@ -1110,7 +1196,7 @@ func TestParseDailyForecastGMP_DecoderError(t *testing.T) {
 
 func TestParseDailyForecastOWM_DecoderError(t *testing.T) {
 	malformedJSON := strings.NewReader(`{,}`)
-	_, _, err := ParseDailyForecastOWM(malformedJSON, slog.Default())
+	_, _, err := ParseDailyForecastOWM(malformedJSON, slog.Default(), defaultForecastDays)
 	if err == nil {
 		t.Fatal("expected a decoder error, but got nil")
 	}
@@ -1118,7 +1204,7 @@ func TestParseDailyForecastOWM_DecoderError(t *testing.T) {
 
 func TestParseDailyForecastOMeteo_DecoderError(t *testing.T) {
 	malformedJSON := strings.NewReader(`{,}`)
-	_, _, err := ParseDailyForecastOMeteo(malformedJSON, slog.Default())
+	_, _, err := ParseDailyForecastOMeteo(malformedJSON, slog.Default(), defaultForecastDays)
 	if err == nil {
 		t.Fatal("expected a decoder error, but got nil")
 	}
@@ -1126,7 +1212,7 @@ func TestParseDailyForecastOMeteo_DecoderError(t *testing.T) {
 
 func TestParseHourlyForecastGMP_DecoderError(t *testing.T) {
 	malformedJSON := strings.NewReader(`{,}`)
-	_, _, err := ParseHourlyForecastGMP(malformedJSON, slog.Default())
+	_, _, err := ParseHourlyForecastGMP(malformedJSON, slog.Default(), defaultForecastHours)
 	if err == nil {
 		t.Fatal("expected a decoder error, but got nil")
 	}
@@ -1134,7 +1220,7 @@ func TestParseHourlyForecastGMP_DecoderError(t *testing.T) {
 
 func TestParseHourlyForecastOWM_DecoderError(t *testing.T) {
 	malformedJSON := strings.NewReader(`{,}`)
-	_, _, err := ParseHourlyForecastOWM(malformedJSON, slog.Default())
+	_, _, err := ParseHourlyForecastOWM(malformedJSON, slog.Default(), defaultForecastHours)
 	if err == nil {
 		t.Fatal("expected a decoder error, but got nil")
 	}
@@ -1142,7 +1228,7 @@ func TestParseHourlyForecastOWM_DecoderError(t *testing.T) {
 
 func TestParseHourlyForecastOMeteo_DecoderError(t *testing.T) {
 	malformedJSON := strings.NewReader(`{,}`)
-	_, _, err := ParseHourlyForecastOMeteo(malformedJSON, slog.Default())
+	_, _, err := ParseHourlyForecastOMeteo(malformedJSON, slog.Default(), defaultForecastHours)
 	if err == nil {
 		t.Fatal("expected a decoder error, but got nil")
 	}
@@ -1193,3 +1279,945 @@ func TestInterpretWeatherCode(t *testing.T) {
 		})
 	}
 }
+
+func TestParseCurrentWeatherOWM25(t *testing.T) {
+	sampleJSON, err := testData.Open("testdata/current_weather_owm25.json")
+	if err != nil {
+		t.Fatalf("failed to open test data: %v", err)
+	}
+	defer sampleJSON.Close()
+
+	parsedWeather, tz, err := ParseCurrentWeatherOWM25(sampleJSON, slog.Default())
+	if err != nil {
+		t.Fatalf("ParseCurrentWeatherOWM25 failed with error: %v", err)
+	}
+
+	if tz != "" {
+		t.Errorf("Timezone: got %q, want empty", tz)
+	}
+	if !parsedWeather.Timestamp.Equal(time.Unix(1754300688, 0)) {
+		t.Errorf("Timestamp: got %v, want %v", parsedWeather.Timestamp, time.Unix(1754300688, 0))
+	}
+	if _, offset := parsedWeather.Timestamp.Zone(); offset != 7200 {
+		t.Errorf("Timestamp offset: got %d, want 7200", offset)
+	}
+	expectedWeather := CurrentWeather{
+		SourceAPI:     "OpenWeatherMap API",
+		Timestamp:     parsedWeather.Timestamp,
+		Temperature:   18.5,
+		Humidity:      77,
+		WindSpeed:     Round(3.5*3.6, 4),
+		Precipitation: 0.42,
+		Condition:     "Rain",
+		Sunrise:       time.Unix(1754277695, 0).In(parsedWeather.Timestamp.Location()),
+		Sunset:        time.Unix(1754332454, 0).In(parsedWeather.Timestamp.Location()),
+	}
+	parsedWeather.WindDirection, parsedWeather.ApparentTemperature, parsedWeather.DewPoint = nil, nil, nil
+	parsedWeather.Rain, parsedWeather.Snow = nil, nil
+	if parsedWeather != expectedWeather {
+		t.Errorf("got %+v, want %+v", parsedWeather, expectedWeather)
+	}
+}
+
+func TestParseDailyForecastOWM25(t *testing.T) {
+	sampleJSON, err := testData.Open("testdata/forecast_owm25.json")
+	if err != nil {
+		t.Fatalf("failed to open test data: %v", err)
+	}
+	defer sampleJSON.Close()
+
+	forecasts, tz, err := ParseDailyForecastOWM25(sampleJSON, slog.Default(), defaultForecastDays)
+	if err != nil {
+		t.Fatalf("ParseDailyForecastOWM25 failed with error: %v", err)
+	}
+
+	if tz != "" {
+		t.Errorf("Timezone: got %q, want empty", tz)
+	}
+	if len(forecasts) != 2 {
+		t.Fatalf("expected 2 days, got %d", len(forecasts))
+	}
+
+	loc := time.FixedZone("", 7200)
+	expected := []DailyForecast{
+		{
+			SourceAPI:           "OpenWeatherMap API",
+			ForecastDate:        time.Date(2025, 8, 4, 0, 0, 0, 0, loc),
+			MinTemp:             16.8,
+			MaxTemp:             20.1,
+			Precipitation:       0.9,
+			PrecipitationChance: 10,
+			WindSpeed:           Round(2.5*3.6, 4),
+			Humidity:            61,
+		},
+		{
+			SourceAPI:           "OpenWeatherMap API",
+			ForecastDate:        time.Date(2025, 8, 5, 0, 0, 0, 0, loc),
+			MinTemp:             13.2,
+			MaxTemp:             25.6,
+			Precipitation:       2.7,
+			PrecipitationChance: 80,
+			WindSpeed:           Round(6.0*3.6, 4),
+			Humidity:            68,
+		},
+	}
+	for i, want := range expected {
+		got := forecasts[i]
+		if !got.ForecastDate.Equal(want.ForecastDate) {
+			t.Errorf("day %d: ForecastDate: got %v, want %v", i, got.ForecastDate, want.ForecastDate)
+		}
+		got.ForecastDate = want.ForecastDate
+		got.WindDirection, got.WindGust, got.Rain, got.Snow = nil, nil, nil, nil
+		if got != want {
+			t.Errorf("day %d: got %+v, want %+v", i, got, want)
+		}
+	}
+}
+
+func TestParseHourlyForecastOWM25(t *testing.T) {
+	sampleJSON, err := testData.Open("testdata/forecast_owm25.json")
+	if err != nil {
+		t.Fatalf("failed to open test data: %v", err)
+	}
+	defer sampleJSON.Close()
+
+	forecasts, tz, err := ParseHourlyForecastOWM25(sampleJSON, slog.Default(), defaultForecastHours)
+	if err != nil {
+		t.Fatalf("ParseHourlyForecastOWM25 failed with error: %v", err)
+	}
+
+	if tz != "" {
+		t.Errorf("Timezone: got %q, want empty", tz)
+	}
+	if len(forecasts) != 8 {
+		t.Fatalf("expected 8 steps covering 24 hours, got %d", len(forecasts))
+	}
+
+	first := forecasts[0]
+	if !first.ForecastDateTime.Equal(time.Unix(1754330400, 0)) {
+		t.Errorf("ForecastDateTime: got %v, want %v", first.ForecastDateTime, time.Unix(1754330400, 0))
+	}
+	if first.Temperature != 20.1 || first.Humidity != 60 || first.Condition != "Rain" {
+		t.Errorf("unexpected first step: %+v", first)
+	}
+	if first.Precipitation != 0.3 {
+		t.Errorf("Precipitation: got %f, want the hourly average 0.3", first.Precipitation)
+	}
+	if forecasts[3].Precipitation != 0.4 {
+		t.Errorf("Precipitation: got %f, want the hourly average 0.4", forecasts[3].Precipitation)
+	}
+	if forecasts[7].PrecipitationChance != 70 {
+		t.Errorf("PrecipitationChance: got %d, want 70", forecasts[7].PrecipitationChance)
+	}
+}
+
+func TestParseOWM25_Errors(t *testing.T) {
+	testCases := []struct {
+		name  string
+		parse func(io.Reader) (string, error)
+	}{
+		{"current", func(r io.Reader) (string, error) {
+			w, _, err := ParseCurrentWeatherOWM25(r, slog.Default())
+			return w.SourceAPI, err
+		}},
+		{"daily", func(r io.Reader) (string, error) {
+			f, _, err := ParseDailyForecastOWM25(r, slog.Default(), defaultForecastDays)
+			return f[0].SourceAPI, err
+		}},
+		{"hourly", func(r io.Reader) (string, error) {
+			f, _, err := ParseHourlyForecastOWM25(r, slog.Default(), defaultForecastHours)
+			return f[0].SourceAPI, err
+		}},
+	}
+
+	for _, tc := range testCases {
+		for _, body := range []string{`{,}`, `{}`} {
+			t.Run(tc.name+" "+body, func(t *testing.T) {
+				source, err := tc.parse(strings.NewReader(body))
+				if err == nil {
+					t.Fatal("expected an error, but got nil")
+				}
+				if source != "OpenWeatherMap API" {
+					t.Errorf("SourceAPI: got %q, want %q", source, "OpenWeatherMap API")
+				}
+			})
+		}
+	}
+}
+
+func TestParseCurrentWeatherMetNo(t *testing.T) {
+	sampleJSON, err := testData.Open("testdata/forecast_metno.json")
+	if err != nil {
+		t.Fatalf("failed to open test data: %v", err)
+	}
+	defer sampleJSON.Close()
+
+	// All time steps of the sample lie in the future, so the first one is the current weather.
+	expectedWeather := CurrentWeather{
+		SourceAPI:     "Met.no API",
+		Timestamp:     time.Date(2058, 4, 8, 10, 0, 0, 0, time.UTC),
+		Temperature:   14.2,
+		Humidity:      68,
+		WindSpeed:     12.6,
+		Precipitation: 0,
+		Condition:     "partly cloudy",
+	}
+
+	parsedWeather, tz, err := ParseCurrentWeatherMetNo(sampleJSON, slog.Default())
+	if err != nil {
+		t.Fatalf("ParseCurrentWeatherMetNo failed with error: %v", err)
+	}
+	if tz != "" {
+		t.Errorf("Timezone: got %q, want empty", tz)
+	}
+	if !equalOptional(parsedWeather.UVIndex, optional(3.1)) {
+		t.Errorf("UVIndex: got %v, want 3.1", formatOptional(parsedWeather.UVIndex))
+	}
+	parsedWeather.UVIndex, parsedWeather.WindDirection = nil, nil
+	parsedWeather.ApparentTemperature, parsedWeather.DewPoint = nil, nil
+	if parsedWeather != expectedWeather {
+		t.Errorf("got %+v, want %+v", parsedWeather, expectedWeather)
+	}
+}
+
+func TestParseDailyForecastMetNo(t *testing.T) {
+	sampleJSON, err := testData.Open("testdata/forecast_metno.json")
+	if err != nil {
+		t.Fatalf("failed to open test data: %v", err)
+	}
+	defer sampleJSON.Close()
+
+	expectedForecast := []DailyForecast{
+		{
+			SourceAPI:           "Met.no API",
+			ForecastDate:        time.Date(2058, 4, 8, 0, 0, 0, 0, time.UTC),
+			MinTemp:             9.4,
+			MaxTemp:             17.1,
+			Precipitation:       2.6,
+			PrecipitationChance: 80,
+			WindSpeed:           18.36,
+			Humidity:            80,
+		},
+		{
+			SourceAPI:           "Met.no API",
+			ForecastDate:        time.Date(2058, 4, 9, 0, 0, 0, 0, time.UTC),
+			MinTemp:             7.2,
+			MaxTemp:             11.8,
+			Precipitation:       1.2,
+			PrecipitationChance: 60,
+			WindSpeed:           23.4,
+			Humidity:            90,
+		},
+	}
+
+	parsedForecast, _, err := ParseDailyForecastMetNo(sampleJSON, slog.Default(), defaultForecastDays)
+	if err != nil {
+		t.Fatalf("ParseDailyForecastMetNo failed with error: %v", err)
+	}
+	if len(parsedForecast) != len(expectedForecast) {
+		t.Fatalf("expected %d days, got %d: %+v", len(expectedForecast), len(parsedForecast), parsedForecast)
+	}
+	// The UV index of a day is the highest clear sky index of its time steps.
+	for i, want := range []*float64{optional(4.2), optional(3.6)} {
+		if !equalOptional(parsedForecast[i].UVIndex, want) {
+			t.Errorf("day %d UVIndex: got %v, want %v", i, formatOptional(parsedForecast[i].UVIndex), formatOptional(want))
+		}
+		parsedForecast[i].UVIndex, parsedForecast[i].WindDirection = nil, nil
+	}
+	for i, want := range expectedForecast {
+		if parsedForecast[i] != want {
+			t.Errorf("day %d: got %+v, want %+v", i, parsedForecast[i], want)
+		}
+	}
+}
+
+func TestParseHourlyForecastMetNo(t *testing.T) {
+	sampleJSON, err := testData.Open("testdata/forecast_metno.json")
+	if err != nil {
+		t.Fatalf("failed to open test data: %v", err)
+	}
+	defer sampleJSON.Close()
+
+	parsedForecast, _, err := ParseHourlyForecastMetNo(sampleJSON, slog.Default(), defaultForecastHours)
+	if err != nil {
+		t.Fatalf("ParseHourlyForecastMetNo failed with error: %v", err)
+	}
+
+	// Only the time steps with a next_1_hours period are hourly forecasts.
+	if len(parsedForecast) != 3 {
+		t.Fatalf("expected 3 hourly forecasts, got %d", len(parsedForecast))
+	}
+	expectedFirst := HourlyForecast{
+		SourceAPI:           "Met.no API",
+		ForecastDateTime:    time.Date(2058, 4, 8, 10, 0, 0, 0, time.UTC),
+		Temperature:         14.2,
+		Humidity:            68,
+		WindSpeed:           12.6,
+		Precipitation:       0,
+		PrecipitationChance: 4,
+		Condition:           "partly cloudy",
+	}
+	parsedForecast[0].WindDirection = nil
+	parsedForecast[0].ApparentTemperature, parsedForecast[0].DewPoint = nil, nil
+	if parsedForecast[0] != expectedFirst {
+		t.Errorf("first hour: got %+v, want %+v", parsedForecast[0], expectedFirst)
+	}
+	if last := parsedForecast[2]; last.Condition != "heavy rain and thunder" || last.Precipitation != 2.4 || last.PrecipitationChance != 80 {
+		t.Errorf("last hour: got %+v", last)
+	}
+}
+
+func TestParseMetNo_Errors(t *testing.T) {
+	testCases := []struct {
+		name  string
+		parse func(io.Reader) (string, error)
+	}{
+		{"current", func(r io.Reader) (string, error) {
+			w, _, err := ParseCurrentWeatherMetNo(r, slog.Default())
+			return w.SourceAPI, err
+		}},
+		{"daily", func(r io.Reader) (string, error) {
+			f, _, err := ParseDailyForecastMetNo(r, slog.Default(), defaultForecastDays)
+			return f[0].SourceAPI, err
+		}},
+		{"hourly", func(r io.Reader) (string, error) {
+			f, _, err := ParseHourlyForecastMetNo(r, slog.Default(), defaultForecastHours)
+			return f[0].SourceAPI, err
+		}},
+	}
+
+	for _, tc := range testCases {
+		for _, body := range []string{`{,}`, `{}`} {
+			t.Run(tc.name+" "+body, func(t *testing.T) {
+				source, err := tc.parse(strings.NewReader(body))
+				if err == nil {
+					t.Fatal("expected an error, but got nil")
+				}
+				if source != "Met.no API" {
+					t.Errorf("SourceAPI: got %q, want %q", source, "Met.no API")
+				}
+			})
+		}
+	}
+}
+
+func TestInterpretSymbolCode(t *testing.T) {
+	testCases := map[string]string{
+		"clearsky_night":                   "clear sky",
+		"fair_day":                         "mainly clear",
+		"cloudy":                           "cloudy",
+		"rain":                             "rain",
+		"lightrainshowers_day":             "light rain showers",
+		"heavysnowandthunder":              "heavy snow and thunder",
+		"lightssleetshowersandthunder_day": "light sleet showers and thunder",
+		"sleetshowers_polartwilight":       "sleet showers",
+	}
+	for code, want := range testCases {
+		if got := interpretSymbolCode(code); got != want {
+			t.Errorf("interpretSymbolCode(%q) = %q, want %q", code, got, want)
+		}
+	}
+}
+
+func TestParseUVIndexAndSunTimes(t *testing.T) {
+	gmpSunrise, _ := time.Parse(time.RFC3339Nano, "2025-08-05T03:23:14.656292094Z")
+	gmpSunset, _ := time.Parse(time.RFC3339Nano, "2025-08-05T18:31:25.192673747Z")
+
+	current := func(parser func(io.Reader, *slog.Logger) (CurrentWeather, string, error)) func(io.Reader) (*float64, time.Time, time.Time, error) {
+		return func(body io.Reader) (*float64, time.Time, time.Time, error) {
+			w, _, err := parser(body, slog.Default())
+			return w.UVIndex, w.Sunrise, w.Sunset, err
+		}
+	}
+	daily := func(parser func(io.Reader, *slog.Logger, int) ([]DailyForecast, string, error)) func(io.Reader) (*float64, time.Time, time.Time, error) {
+		return func(body io.Reader) (*float64, time.Time, time.Time, error) {
+			f, _, err := parser(body, slog.Default(), defaultForecastDays)
+			if err != nil {
+				return nil, time.Time{}, time.Time{}, err
+			}
+			return f[0].UVIndex, f[0].Sunrise, f[0].Sunset, nil
+		}
+	}
+
+	testCases := []struct {
+		name        string
+		file        string
+		parse       func(io.Reader) (*float64, time.Time, time.Time, error)
+		wantUVIndex *float64
+		wantSunrise time.Time
+		wantSunset  time.Time
+	}{
+		{
+			name:        "Current GMP Without Sun Times",
+			file:        "testdata/current_weather_gmp.json",
+			parse:       current(ParseCurrentWeatherGMP),
+			wantUVIndex: optional(2),
+		},
+		{
+			name:        "Current OWM",
+			file:        "testdata/current_weather_owm.json",
+			parse:       current(ParseCurrentWeatherOWM),
+			wantUVIndex: optional(1.32),
+			wantSunrise: time.Unix(1754277695, 0),
+			wantSunset:  time.Unix(1754332454, 0),
+		},
+		{
+			name:        "Current OWM 2.5 Without UV Index",
+			file:        "testdata/current_weather_owm25.json",
+			parse:       current(ParseCurrentWeatherOWM25),
+			wantSunrise: time.Unix(1754277695, 0),
+			wantSunset:  time.Unix(1754332454, 0),
+		},
+		{
+			name:        "Current OMeteo",
+			file:        "testdata/current_weather_ometeo.json",
+			parse:       current(ParseCurrentWeatherOMeteo),
+			wantUVIndex: optional(1.85),
+			wantSunrise: time.Unix(1754277720, 0),
+			wantSunset:  time.Unix(1754332440, 0),
+		},
+		{
+			name:        "Daily GMP",
+			file:        "testdata/daily_forecast_gmp.json",
+			parse:       daily(ParseDailyForecastGMP),
+			wantUVIndex: optional(6),
+			wantSunrise: gmpSunrise,
+			wantSunset:  gmpSunset,
+		},
+		{
+			name:        "Daily OWM",
+			file:        "testdata/daily_forecast_owm.json",
+			parse:       daily(ParseDailyForecastOWM),
+			wantUVIndex: optional(4.82),
+			wantSunrise: time.Unix(1754364186, 0),
+			wantSunset:  time.Unix(1754418753, 0),
+		},
+		{
+			name:        "Daily OMeteo",
+			file:        "testdata/daily_forecast_ometeo.json",
+			parse:       daily(ParseDailyForecastOMeteo),
+			wantUVIndex: optional(5.65),
+			wantSunrise: time.Unix(1754536980, 0),
+			wantSunset:  time.Unix(1754592360, 0),
+		},
+		{
+			name:  "Daily OWM 2.5 Without UV Index Or Sun Times",
+			file:  "testdata/forecast_owm25.json",
+			parse: daily(ParseDailyForecastOWM25),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sampleJSON, err := testData.Open(tc.file)
+			if err != nil {
+				t.Fatalf("failed to open test data: %v", err)
+			}
+			defer sampleJSON.Close()
+
+			uv, sunrise, sunset, err := tc.parse(sampleJSON)
+			if err != nil {
+				t.Fatalf("parser failed with error: %v", err)
+			}
+			if !equalOptional(uv, tc.wantUVIndex) {
+				t.Errorf("UVIndex: got %s, want %s", formatOptional(uv), formatOptional(tc.wantUVIndex))
+			}
+			if !sunrise.Equal(tc.wantSunrise) {
+				t.Errorf("Sunrise: got %v, want %v", sunrise, tc.wantSunrise)
+			}
+			if !sunset.Equal(tc.wantSunset) {
+				t.Errorf("Sunset: got %v, want %v", sunset, tc.wantSunset)
+			}
+		})
+	}
+}
+
+func TestParseCurrentWeatherOWMPolarDay(t *testing.T) {
+	body := `{"timezone":"Europe/Oslo","current":{"dt":1754300711,"temp":12,"uvi":0,"weather":[{"main":"Clear"}]}}`
+
+	weather, _, err := ParseCurrentWeatherOWM(strings.NewReader(body), slog.Default())
+	if err != nil {
+		t.Fatalf("ParseCurrentWeatherOWM failed with error: %v", err)
+	}
+	if !weather.Sunrise.IsZero() || !weather.Sunset.IsZero() {
+		t.Errorf("expected no sun times when the sun does not set, got %v and %v", weather.Sunrise, weather.Sunset)
+	}
+	if !equalOptional(weather.UVIndex, optional(0)) {
+		t.Errorf("UVIndex: got %s, want 0", formatOptional(weather.UVIndex))
+	}
+}
+
+func TestParseWindDirectionAndGusts(t *testing.T) {
+	current := func(parser func(io.Reader, *slog.Logger) (CurrentWeather, string, error)) func(io.Reader) (*float64, *float64, error) {
+		return func(body io.Reader) (*float64, *float64, error) {
+			w, _, err := parser(body, slog.Default())
+			return w.WindDirection, w.WindGust, err
+		}
+	}
+	daily := func(parser func(io.Reader, *slog.Logger, int) ([]DailyForecast, string, error)) func(io.Reader) (*float64, *float64, error) {
+		return func(body io.Reader) (*float64, *float64, error) {
+			f, _, err := parser(body, slog.Default(), defaultForecastDays)
+			if err != nil {
+				return nil, nil, err
+			}
+			return f[0].WindDirection, f[0].WindGust, nil
+		}
+	}
+	hourly := func(parser func(io.Reader, *slog.Logger, int) ([]HourlyForecast, string, error)) func(io.Reader) (*float64, *float64, error) {
+		return func(body io.Reader) (*float64, *float64, error) {
+			f, _, err := parser(body, slog.Default(), defaultForecastHours)
+			if err != nil {
+				return nil, nil, err
+			}
+			return f[0].WindDirection, f[0].WindGust, nil
+		}
+	}
+
+	testCases := []struct {
+		name          string
+		file          string
+		parse         func(io.Reader) (*float64, *float64, error)
+		wantDirection *float64
+		wantGust      *float64
+	}{
+		{
+			name:          "Current GMP",
+			file:          "testdata/current_weather_gmp.json",
+			parse:         current(ParseCurrentWeatherGMP),
+			wantDirection: optional(225),
+			wantGust:      optional(12),
+		},
+		{
+			name:          "Current OWM Without Gust",
+			file:          "testdata/current_weather_owm.json",
+			parse:         current(ParseCurrentWeatherOWM),
+			wantDirection: optional(230),
+		},
+		{
+			name:          "Current OWM 2.5 Without Gust",
+			file:          "testdata/current_weather_owm25.json",
+			parse:         current(ParseCurrentWeatherOWM25),
+			wantDirection: optional(250),
+		},
+		{
+			name:          "Current OMeteo",
+			file:          "testdata/current_weather_ometeo.json",
+			parse:         current(ParseCurrentWeatherOMeteo),
+			wantDirection: optional(241),
+			wantGust:      optional(20.5),
+		},
+		{
+			name:          "Current Met.no Without Gust",
+			file:          "testdata/forecast_metno.json",
+			parse:         current(ParseCurrentWeatherMetNo),
+			wantDirection: optional(250.1),
+		},
+		{
+			name:          "Daily GMP",
+			file:          "testdata/daily_forecast_gmp.json",
+			parse:         daily(ParseDailyForecastGMP),
+			wantDirection: optional(212),
+			wantGust:      optional(31),
+		},
+		{
+			name:          "Daily OWM",
+			file:          "testdata/daily_forecast_owm.json",
+			parse:         daily(ParseDailyForecastOWM),
+			wantDirection: optional(328),
+			wantGust:      optional(Round(12.01*3.6, 4)),
+		},
+		{
+			// The direction is taken from the windiest step, the gust is the day's strongest.
+			name:          "Daily OWM 2.5",
+			file:          "testdata/forecast_owm25.json",
+			parse:         daily(ParseDailyForecastOWM25),
+			wantDirection: optional(190),
+			wantGust:      optional(Round(3.8*3.6, 4)),
+		},
+		{
+			name:          "Daily OMeteo",
+			file:          "testdata/daily_forecast_ometeo.json",
+			parse:         daily(ParseDailyForecastOMeteo),
+			wantDirection: optional(275),
+			wantGust:      optional(24.1),
+		},
+		{
+			name:          "Daily Met.no",
+			file:          "testdata/forecast_metno.json",
+			parse:         daily(ParseDailyForecastMetNo),
+			wantDirection: optional(215),
+		},
+		{
+			name:          "Hourly GMP",
+			file:          "testdata/hourly_forecast_gmp.json",
+			parse:         hourly(ParseHourlyForecastGMP),
+			wantDirection: optional(210),
+			wantGust:      optional(26),
+		},
+		{
+			name:          "Hourly OWM",
+			file:          "testdata/hourly_forecast_owm.json",
+			parse:         hourly(ParseHourlyForecastOWM),
+			wantDirection: optional(245),
+			wantGust:      optional(Round(4.93*3.6, 4)),
+		},
+		{
+			name:          "Hourly OWM 2.5",
+			file:          "testdata/forecast_owm25.json",
+			parse:         hourly(ParseHourlyForecastOWM25),
+			wantDirection: optional(180),
+			wantGust:      optional(Round(3.1*3.6, 4)),
+		},
+		{
+			name:          "Hourly OMeteo",
+			file:          "testdata/hourly_forecast_ometeo.json",
+			parse:         hourly(ParseHourlyForecastOMeteo),
+			wantDirection: optional(200),
+			wantGust:      optional(13.9),
+		},
+		{
+			name:          "Hourly Met.no",
+			file:          "testdata/forecast_metno.json",
+			parse:         hourly(ParseHourlyForecastMetNo),
+			wantDirection: optional(250.1),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sampleJSON, err := testData.Open(tc.file)
+			if err != nil {
+				t.Fatalf("failed to open test data: %v", err)
+			}
+			defer sampleJSON.Close()
+
+			direction, gust, err := tc.parse(sampleJSON)
+			if err != nil {
+				t.Fatalf("parser failed with error: %v", err)
+			}
+			if !equalOptional(direction, tc.wantDirection) {
+				t.Errorf("WindDirection: got %s, want %s", formatOptional(direction), formatOptional(tc.wantDirection))
+			}
+			if !equalOptional(gust, tc.wantGust) {
+				t.Errorf("WindGust: got %s, want %s", formatOptional(gust), formatOptional(tc.wantGust))
+			}
+		})
+	}
+}
+
+func TestParseApparentTemperatureAndDewPoint(t *testing.T) {
+	current := func(parser func(io.Reader, *slog.Logger) (CurrentWeather, string, error)) func(io.Reader) (*float64, *float64, error) {
+		return func(body io.Reader) (*float64, *float64, error) {
+			w, _, err := parser(body, slog.Default())
+			return w.ApparentTemperature, w.DewPoint, err
+		}
+	}
+	hourly := func(parser func(io.Reader, *slog.Logger, int) ([]HourlyForecast, string, error)) func(io.Reader) (*float64, *float64, error) {
+		return func(body io.Reader) (*float64, *float64, error) {
+			f, _, err := parser(body, slog.Default(), defaultForecastHours)
+			if err != nil {
+				return nil, nil, err
+			}
+			return f[0].ApparentTemperature, f[0].DewPoint, nil
+		}
+	}
+
+	testCases := []struct {
+		name                    string
+		file                    string
+		parse                   func(io.Reader) (*float64, *float64, error)
+		wantApparentTemperature *float64
+		wantDewPoint            *float64
+	}{
+		{
+			name:                    "Current GMP",
+			file:                    "testdata/current_weather_gmp.json",
+			parse:                   current(ParseCurrentWeatherGMP),
+			wantApparentTemperature: optional(18.2),
+			wantDewPoint:            optional(13.4),
+		},
+		{
+			name:                    "Current OWM",
+			file:                    "testdata/current_weather_owm.json",
+			parse:                   current(ParseCurrentWeatherOWM),
+			wantApparentTemperature: optional(16.82),
+			wantDewPoint:            optional(13.34),
+		},
+		{
+			name:                    "Current OWM 2.5 With Computed Dew Point",
+			file:                    "testdata/current_weather_owm25.json",
+			parse:                   current(ParseCurrentWeatherOWM25),
+			wantApparentTemperature: optional(18.2),
+			wantDewPoint:            optional(14.4),
+		},
+		{
+			name:                    "Current OMeteo",
+			file:                    "testdata/current_weather_ometeo.json",
+			parse:                   current(ParseCurrentWeatherOMeteo),
+			wantApparentTemperature: optional(17.9),
+			wantDewPoint:            optional(13),
+		},
+		{
+			name:                    "Current Met.no With Computed Apparent Temperature",
+			file:                    "testdata/forecast_metno.json",
+			parse:                   current(ParseCurrentWeatherMetNo),
+			wantApparentTemperature: optional(14.2),
+			wantDewPoint:            optional(8.6),
+		},
+		{
+			name:                    "Hourly GMP",
+			file:                    "testdata/hourly_forecast_gmp.json",
+			parse:                   hourly(ParseHourlyForecastGMP),
+			wantApparentTemperature: optional(25.2),
+			wantDewPoint:            optional(15.5),
+		},
+		{
+			name:                    "Hourly OWM",
+			file:                    "testdata/hourly_forecast_owm.json",
+			parse:                   hourly(ParseHourlyForecastOWM),
+			wantApparentTemperature: optional(25.23),
+			wantDewPoint:            optional(15.8),
+		},
+		{
+			name:                    "Hourly OWM 2.5 Computed",
+			file:                    "testdata/forecast_owm25.json",
+			parse:                   hourly(ParseHourlyForecastOWM25),
+			wantApparentTemperature: optional(20.1),
+			wantDewPoint:            optional(12.1),
+		},
+		{
+			name:                    "Hourly OMeteo",
+			file:                    "testdata/hourly_forecast_ometeo.json",
+			parse:                   hourly(ParseHourlyForecastOMeteo),
+			wantApparentTemperature: optional(15.5),
+			wantDewPoint:            optional(10.9),
+		},
+		{
+			name:                    "Hourly Met.no",
+			file:                    "testdata/forecast_metno.json",
+			parse:                   hourly(ParseHourlyForecastMetNo),
+			wantApparentTemperature: optional(14.2),
+			wantDewPoint:            optional(8.6),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sampleJSON, err := testData.Open(tc.file)
+			if err != nil {
+				t.Fatalf("failed to open test data: %v", err)
+			}
+			defer sampleJSON.Close()
+
+			apparentTemperature, dewPoint, err := tc.parse(sampleJSON)
+			if err != nil {
+				t.Fatalf("parser failed with error: %v", err)
+			}
+			if !equalOptional(apparentTemperature, tc.wantApparentTemperature) {
+				t.Errorf("ApparentTemperature: got %s, want %s", formatOptional(apparentTemperature), formatOptional(tc.wantApparentTemperature))
+			}
+			if !equalOptional(dewPoint, tc.wantDewPoint) {
+				t.Errorf("DewPoint: got %s, want %s", formatOptional(dewPoint), formatOptional(tc.wantDewPoint))
+			}
+		})
+	}
+}
+
+func TestParseRainAndSnow(t *testing.T) {
+	current := func(parser func(io.Reader, *slog.Logger) (CurrentWeather, string, error)) func(io.Reader) (*float64, *float64, error) {
+		return func(body io.Reader) (*float64, *float64, error) {
+			w, _, err := parser(body, slog.Default())
+			return w.Rain, w.Snow, err
+		}
+	}
+	daily := func(parser func(io.Reader, *slog.Logger, int) ([]DailyForecast, string, error)) func(io.Reader) (*float64, *float64, error) {
+		return func(body io.Reader) (*float64, *float64, error) {
+			f, _, err := parser(body, slog.Default(), defaultForecastDays)
+			if err != nil {
+				return nil, nil, err
+			}
+			return f[0].Rain, f[0].Snow, nil
+		}
+	}
+	hourly := func(parser func(io.Reader, *slog.Logger, int) ([]HourlyForecast, string, error)) func(io.Reader) (*float64, *float64, error) {
+		return func(body io.Reader) (*float64, *float64, error) {
+			f, _, err := parser(body, slog.Default(), defaultForecastHours)
+			if err != nil {
+				return nil, nil, err
+			}
+			return f[0].Rain, f[0].Snow, nil
+		}
+	}
+
+	testCases := []struct {
+		name     string
+		file     string
+		body     string
+		parse    func(io.Reader) (*float64, *float64, error)
+		wantRain *float64
+		wantSnow *float64
+	}{
+		{
+			name:     "Current GMP",
+			file:     "testdata/current_weather_gmp.json",
+			parse:    current(ParseCurrentWeatherGMP),
+			wantRain: optional(0.1321),
+			wantSnow: optional(0),
+		},
+		{
+			name:     "Current GMP Snow",
+			body:     `{"currentTime":"2025-08-04T09:45:00Z","timeZone":{"id":"Europe/Oslo"},"precipitation":{"qpf":{"quantity":2.5},"snowQpf":{"quantity":1.5}}}`,
+			parse:    current(ParseCurrentWeatherGMP),
+			wantRain: optional(1),
+			wantSnow: optional(1.5),
+		},
+		{
+			name:     "Current GMP Without Snow Figure",
+			body:     `{"currentTime":"2025-08-04T09:45:00Z","timeZone":{"id":"Europe/Oslo"},"precipitation":{"qpf":{"quantity":2.5}}}`,
+			parse:    current(ParseCurrentWeatherGMP),
+			wantRain: nil,
+			wantSnow: nil,
+		},
+		{
+			name:     "Current OWM",
+			file:     "testdata/current_weather_owm.json",
+			parse:    current(ParseCurrentWeatherOWM),
+			wantRain: optional(0.32),
+			wantSnow: optional(0),
+		},
+		{
+			name:     "Current OWM Snow",
+			body:     `{"timezone":"Europe/Oslo","current":{"dt":1754300711,"temp":-3,"humidity":90,"snow":{"1h":0.8},"weather":[{"main":"Snow"}]}}`,
+			parse:    current(ParseCurrentWeatherOWM),
+			wantRain: optional(0),
+			wantSnow: optional(0.8),
+		},
+		{
+			name:     "Current OWM 2.5",
+			file:     "testdata/current_weather_owm25.json",
+			parse:    current(ParseCurrentWeatherOWM25),
+			wantRain: optional(0.42),
+			wantSnow: optional(0),
+		},
+		{
+			name:     "Current OMeteo",
+			file:     "testdata/current_weather_ometeo.json",
+			parse:    current(ParseCurrentWeatherOMeteo),
+			wantRain: optional(0.1),
+			wantSnow: optional(0),
+		},
+		{
+			// Open-Meteo's snow is the part of the total that is neither rain nor showers.
+			name:     "Current OMeteo Snow",
+			body:     `{"timezone":"Europe/Oslo","current":{"time":1754300700,"temperature_2m":-1,"precipitation":1.2,"rain":0.2,"showers":0.1,"weather_code":73}}`,
+			parse:    current(ParseCurrentWeatherOMeteo),
+			wantRain: optional(0.3),
+			wantSnow: optional(0.9),
+		},
+		{
+			name:  "Current Met.no Without Split",
+			file:  "testdata/forecast_metno.json",
+			parse: current(ParseCurrentWeatherMetNo),
+		},
+		{
+			name:     "Daily GMP",
+			file:     "testdata/daily_forecast_gmp.json",
+			parse:    daily(ParseDailyForecastGMP),
+			wantRain: optional(1.5748),
+			wantSnow: optional(0),
+		},
+		{
+			name:     "Daily OWM",
+			file:     "testdata/daily_forecast_owm.json",
+			parse:    daily(ParseDailyForecastOWM),
+			wantRain: optional(9.15),
+			wantSnow: optional(0),
+		},
+		{
+			name:     "Daily OWM 2.5",
+			file:     "testdata/forecast_owm25.json",
+			parse:    daily(ParseDailyForecastOWM25),
+			wantRain: optional(0.9),
+			wantSnow: optional(0),
+		},
+		{
+			name:     "Daily OMeteo",
+			file:     "testdata/daily_forecast_ometeo.json",
+			parse:    daily(ParseDailyForecastOMeteo),
+			wantRain: optional(0),
+			wantSnow: optional(0),
+		},
+		{
+			name:     "Hourly GMP",
+			file:     "testdata/hourly_forecast_gmp.json",
+			parse:    hourly(ParseHourlyForecastGMP),
+			wantRain: optional(0),
+			wantSnow: optional(0),
+		},
+		{
+			name:     "Hourly OWM 2.5 Hourly Average",
+			file:     "testdata/forecast_owm25.json",
+			parse:    hourly(ParseHourlyForecastOWM25),
+			wantRain: optional(0.3),
+			wantSnow: optional(0),
+		},
+		{
+			name:     "Hourly OMeteo",
+			file:     "testdata/hourly_forecast_ometeo.json",
+			parse:    hourly(ParseHourlyForecastOMeteo),
+			wantRain: optional(0),
+			wantSnow: optional(0),
+		},
+		{
+			name:  "Hourly Met.no Without Split",
+			file:  "testdata/forecast_metno.json",
+			parse: hourly(ParseHourlyForecastMetNo),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var body io.Reader = strings.NewReader(tc.body)
+			if tc.file != "" {
+				sampleJSON, err := testData.Open(tc.file)
+				if err != nil {
+					t.Fatalf("failed to open test data: %v", err)
+				}
+				defer sampleJSON.Close()
+				body = sampleJSON
+			}
+
+			rain, snow, err := tc.parse(body)
+			if err != nil {
+				t.Fatalf("parser failed with error: %v", err)
+			}
+			if !equalOptional(rain, tc.wantRain) {
+				t.Errorf("Rain: got %s, want %s", formatOptional(rain), formatOptional(tc.wantRain))
+			}
+			if !equalOptional(snow, tc.wantSnow) {
+				t.Errorf("Snow: got %s, want %s", formatOptional(snow), formatOptional(tc.wantSnow))
+			}
+		})
+	}
+}
+
+// optional returns a pointer to an optional value for expected values in tests.
+func optional(v float64) *float64 {
+	return &v
+}
+
+// equalOptional reports whether two optional values, either of which may be missing, are equal.
+func equalOptional(a, b *float64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// formatOptional formats an optional value that may be missing for test failure messages.
+func formatOptional(v *float64) string {
+	if v == nil {
+		return "<nil>"
+	}
+	return fmt.Sprintf("%g", *v)
+}
@@ -3,68 +3,89 @@ package main
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/cor0nius/willitrain/internal/database"
 )
 
 // This file contains helper functions for persisting data to the database.
 // It includes a generic "upsert" (update or insert) function and specific
-// implementations for each type of weather data.
+// implementations for each type of weather data. Forecasts, which come in dozens
+// per API, are saved with a bulk upsert instead, in one query per API.
 
 // upsertWeatherItem is a generic helper for the "upsert" (update or insert) logic.
 // It abstracts the common pattern of checking if a database record exists, and then either
-// updating it or creating a new one. This is used to persist weather data.
+// updating it or creating a new one. This is used to persist weather data. Errors are logged
+// and returned.
 func (cfg *apiConfig) upsertWeatherItem(
 	ctx context.Context,
 	getItemFunc func() (any, error),
 	createItemFunc func() (any, error),
 	updateItemFunc func(existingItem any) (any, error),
 	logInfo map[string]string,
-) {
+) error {
 	existing, err := getItemFunc()
 	if err != nil {
 		if err == sql.ErrNoRows {
 			_, createErr := createItemFunc()
 			if createErr != nil {
-				cfg.logger.Error("error creating cache", "type", logInfo["type"], "location", logInfo["location"], "api", logInfo["api"], "error", createErr)
-			} else {
-				cfg.logger.Debug("created cache item", "type", logInfo["type"], "location", logInfo["location"], "api", logInfo["api"])
+				cfg.logger.ErrorContext(ctx, "error creating cache", "type", logInfo["type"], "location", logInfo["location"], "api", logInfo["api"], "error", createErr)
+				return createErr
 			}
-		} else {
-			cfg.logger.Error("error getting cache", "type", logInfo["type"], "location", logInfo["location"], "api", logInfo["api"], "error", err)
+			cfg.logger.DebugContext(ctx, "created cache item", "type", logInfo["type"], "location", logInfo["location"], "api", logInfo["api"])
+			return nil
 		}
-		return
+		cfg.logger.ErrorContext(ctx, "error getting cache", "type", logInfo["type"], "location", logInfo["location"], "api", logInfo["api"], "error", err)
+		return err
 	}
 
 	if _, updateErr := updateItemFunc(existing); updateErr != nil {
-		cfg.logger.Error("error updating cache", "type", logInfo["type"], "location", logInfo["location"], "api", logInfo["api"], "error", updateErr)
-	} else {
-		cfg.logger.Debug("updated cache item", "type", logInfo["type"], "location", logInfo["location"], "api", logInfo["api"])
+		cfg.logger.ErrorContext(ctx, "error updating cache", "type", logInfo["type"], "location", logInfo["location"], "api", logInfo["api"], "error", updateErr)
+		return updateErr
 	}
+	cfg.logger.DebugContext(ctx, "updated cache item", "type", logInfo["type"], "location", logInfo["location"], "api", logInfo["api"])
+	return nil
 }
 
-// The persist... functions use the generic upsertWeatherItem helper to save weather data to the database.
-// Each function is specific to a forecast type and provides the necessary getItem, createItem, and updateItem
-// functions to the upsert helper.
+// The persist... functions save weather data to the database and log any error. They are
+// passed to the caching helpers, which store what they fetch from the providers. The store...
+// functions behind them take the querier to save the data with, so that the scheduler can
+// replace the data of a location within a transaction, and return the first error.
+
 func (cfg *apiConfig) persistCurrentWeather(ctx context.Context, weatherData []CurrentWeather) {
+	_ = cfg.storeCurrentWeather(ctx, cfg.dbQueries, weatherData)
+}
+
+func (cfg *apiConfig) persistDailyForecast(ctx context.Context, forecastData []DailyForecast) {
+	_ = cfg.storeDailyForecast(ctx, cfg.dbQueries, forecastData)
+}
+
+func (cfg *apiConfig) persistHourlyForecast(ctx context.Context, forecastData []HourlyForecast) {
+	_ = cfg.storeHourlyForecast(ctx, cfg.dbQueries, forecastData)
+}
+
+// storeCurrentWeather uses the generic upsertWeatherItem helper, providing the necessary getItem,
+// createItem, and updateItem functions for each weather report.
+func (cfg *apiConfig) storeCurrentWeather(ctx context.Context, q dbQuerier, weatherData []CurrentWeather) error {
 	for _, weather := range weatherData {
-		cfg.upsertWeatherItem(ctx,
+		err := cfg.upsertWeatherItem(ctx,
 			func() (any, error) {
-				return cfg.dbQueries.GetCurrentWeatherAtLocationFromAPI(ctx, database.GetCurrentWeatherAtLocationFromAPIParams{
+				return q.GetCurrentWeatherAtLocationFromAPI(ctx, database.GetCurrentWeatherAtLocationFromAPIParams{
 					LocationID: weather.Location.LocationID,
 					SourceApi:  weather.SourceAPI,
 				})
 			},
 			func() (any, error) {
-				return cfg.dbQueries.CreateCurrentWeather(ctx, currentWeatherToCreateCurrentWeatherParams(weather))
+				return q.CreateCurrentWeather(ctx, currentWeatherToCreateCurrentWeatherParams(weather))
 			},
 			func(existing any) (any, error) {
 				existingWeather, ok := existing.(database.CurrentWeather)
 				if !ok {
 					return nil, fmt.Errorf("unexpected type for existing item: %T", existing)
 				}
-				return cfg.dbQueries.UpdateCurrentWeather(ctx, currentWeatherToUpdateCurrentWeatherParams(weather, existingWeather.ID))
+				return q.UpdateCurrentWeather(ctx, currentWeatherToUpdateCurrentWeatherParams(weather, existingWeather.ID))
 			},
 			map[string]string{
 				"location": weather.Location.CityName,
@@ -72,63 +93,81 @@ func (cfg *apiConfig) persistCurrentWeather(ctx context.Context, weatherData []C
 				"type":     "current weather",
 			},
 		)
+		if err != nil {
+			return err
+		}
 	}
+	return nil
 }
 
-func (cfg *apiConfig) persistDailyForecast(ctx context.Context, forecastData []DailyForecast) {
-	for _, forecast := range forecastData {
-		cfg.upsertWeatherItem(ctx,
-			func() (any, error) {
-				return cfg.dbQueries.GetDailyForecastAtLocationAndDateFromAPI(ctx, database.GetDailyForecastAtLocationAndDateFromAPIParams{
-					LocationID:   forecast.Location.LocationID,
-					ForecastDate: forecast.ForecastDate,
-					SourceApi:    forecast.SourceAPI,
-				})
-			},
-			func() (any, error) {
-				return cfg.dbQueries.CreateDailyForecast(ctx, dailyForecastToCreateDailyForecastParams(forecast))
-			},
-			func(existing any) (any, error) {
-				existingForecast, ok := existing.(database.DailyForecast)
-				if !ok {
-					return nil, fmt.Errorf("unexpected type for existing item: %T", existing)
-				}
-				return cfg.dbQueries.UpdateDailyForecast(ctx, dailyForecastToUpdateDailyForecastParams(forecast, existingForecast.ID))
-			},
-			map[string]string{
-				"location": forecast.Location.CityName,
-				"api":      forecast.SourceAPI,
-				"type":     "daily forecast",
-			},
-		)
-	}
+// storeDailyForecast saves daily forecasts with one bulk upsert per API, which inserts new
+// forecasts and updates those already stored for the same location, API and date.
+func (cfg *apiConfig) storeDailyForecast(ctx context.Context, q dbQuerier, forecastData []DailyForecast) error {
+	return bulkUpsertForecasts(ctx, cfg, forecastData, "daily forecast",
+		func(forecast DailyForecast) (string, string, string, dailyForecastRow) {
+			// Dates are stored without a time zone, as the date in the forecast's own zone.
+			key := forecast.ForecastDate.Format(time.DateOnly)
+			return forecast.Location.CityName, forecast.SourceAPI, key, dailyForecastToDailyForecastRow(forecast)
+		},
+		q.UpsertDailyForecasts,
+	)
 }
 
-func (cfg *apiConfig) persistHourlyForecast(ctx context.Context, forecastData []HourlyForecast) {
-	for _, forecast := range forecastData {
-		cfg.upsertWeatherItem(ctx,
-			func() (any, error) {
-				return cfg.dbQueries.GetHourlyForecastAtLocationAndTimeFromAPI(ctx, database.GetHourlyForecastAtLocationAndTimeFromAPIParams{
-					LocationID:          forecast.Location.LocationID,
-					ForecastDatetimeUtc: forecast.ForecastDateTime,
-					SourceApi:           forecast.SourceAPI,
-				})
-			},
-			func() (any, error) {
-				return cfg.dbQueries.CreateHourlyForecast(ctx, hourlyForecastToCreateHourlyForecastParams(forecast))
-			},
-			func(existing any) (any, error) {
-				existingForecast, ok := existing.(database.HourlyForecast)
-				if !ok {
-					return nil, fmt.Errorf("unexpected type for existing item: %T", existing)
-				}
-				return cfg.dbQueries.UpdateHourlyForecast(ctx, hourlyForecastToUpdateHourlyForecastParams(forecast, existingForecast.ID))
-			},
-			map[string]string{
-				"location": forecast.Location.CityName,
-				"api":      forecast.SourceAPI,
-				"type":     "hourly forecast",
-			},
-		)
+// storeHourlyForecast saves hourly forecasts with one bulk upsert per API, which inserts new
+// forecasts and updates those already stored for the same location, API and time.
+func (cfg *apiConfig) storeHourlyForecast(ctx context.Context, q dbQuerier, forecastData []HourlyForecast) error {
+	return bulkUpsertForecasts(ctx, cfg, forecastData, "hourly forecast",
+		func(forecast HourlyForecast) (string, string, string, hourlyForecastRow) {
+			key := forecast.ForecastDateTime.Format(dbTimeFormat)
+			return forecast.Location.CityName, forecast.SourceAPI, key, hourlyForecastToHourlyForecastRow(forecast)
+		},
+		q.UpsertHourlyForecasts,
+	)
+}
+
+// bulkUpsertForecasts groups forecasts of one location by API and saves each group with a single
+// call of the upsert query, which takes the rows as a JSON array. describe returns the location,
+// API and key of a forecast together with its row. A forecast reported twice for the same key is
+// sent once, keeping the last one, since PostgreSQL rejects an upsert that updates a row twice.
+// It stops at the first error, which it logs and returns.
+func bulkUpsertForecasts[F apiModel, R any](
+	ctx context.Context,
+	cfg *apiConfig,
+	forecasts []F,
+	forecastType string,
+	describe func(F) (location, api, key string, row R),
+	upsert func(ctx context.Context, forecasts json.RawMessage) (int64, error),
+) error {
+	var apis []string
+	rowsByAPI := make(map[string][]R)
+	indexByKey := make(map[[2]string]int)
+	var location string
+	for _, forecast := range forecasts {
+		loc, api, key, row := describe(forecast)
+		location = loc
+		if _, ok := rowsByAPI[api]; !ok {
+			apis = append(apis, api)
+		}
+		if i, ok := indexByKey[[2]string{api, key}]; ok {
+			rowsByAPI[api][i] = row
+			continue
+		}
+		indexByKey[[2]string{api, key}] = len(rowsByAPI[api])
+		rowsByAPI[api] = append(rowsByAPI[api], row)
+	}
+
+	for _, api := range apis {
+		rows := rowsByAPI[api]
+		payload, err := json.Marshal(rows)
+		if err != nil {
+			cfg.logger.ErrorContext(ctx, "error encoding cache", "type", forecastType, "location", location, "api", api, "error", err)
+			return err
+		}
+		if _, err := upsert(ctx, payload); err != nil {
+			cfg.logger.ErrorContext(ctx, "error upserting cache", "type", forecastType, "location", location, "api", api, "rows", len(rows), "error", err)
+			return err
+		}
+		cfg.logger.DebugContext(ctx, "upserted cache items", "type", forecastType, "location", location, "api", api, "rows", len(rows))
 	}
+	return nil
 }
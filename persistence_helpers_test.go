@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"log/slog"
 	"strings"
@@ -162,104 +163,95 @@ func TestPersistCurrentWeather(t *testing.T) {
 
 func TestPersistDailyForecast(t *testing.T) {
 	ctx := context.Background()
+	date := time.Date(2025, 6, 1, 0, 0, 0, 0, time.FixedZone("CEST", 2*60*60))
 	mockForecast := []DailyForecast{
-		{
-			Location:     MockLocation,
-			SourceAPI:    "test-api",
-			ForecastDate: time.Now(),
-		},
-	}
-	mockExistingDBForecast := database.DailyForecast{
-		ID:         uuid.New(),
-		LocationID: MockLocation.LocationID,
-		SourceApi:  "test-api",
+		{Location: MockLocation, SourceAPI: "api-a", ForecastDate: date, MaxTemp: 20},
+		{Location: MockLocation, SourceAPI: "api-b", ForecastDate: date, MaxTemp: 21},
+		{Location: MockLocation, SourceAPI: "api-a", ForecastDate: date.AddDate(0, 0, 1), MaxTemp: 22},
+		{Location: MockLocation, SourceAPI: "api-a", ForecastDate: date, MaxTemp: 23},
 	}
 
-	t.Run("Success - Item is Updated", func(t *testing.T) {
-		// Setup
+	t.Run("Success - One Upsert per API", func(t *testing.T) {
 		testCfg := newTestAPIConfig(t)
-		updateCalled := 0
-		createCalled := 0
-
-		// Mock the DB to return an existing item
-		testCfg.mockDB.GetDailyForecastAtLocationAndDateFromAPIFunc = func(ctx context.Context, arg database.GetDailyForecastAtLocationAndDateFromAPIParams) (database.DailyForecast, error) {
-			return mockExistingDBForecast, nil
+		var batches []string
+		testCfg.mockDB.UpsertDailyForecastsFunc = func(ctx context.Context, forecasts json.RawMessage) (int64, error) {
+			batches = append(batches, string(forecasts))
+			return 1, nil
 		}
 
-		// Track calls to Update and Create
-		testCfg.mockDB.UpdateDailyForecastFunc = func(ctx context.Context, arg database.UpdateDailyForecastParams) (database.DailyForecast, error) {
-			updateCalled++
-			if arg.ID != mockExistingDBForecast.ID {
-				t.Errorf("expected ID %v but got %v", mockExistingDBForecast.ID, arg.ID)
-			}
-			return database.DailyForecast{}, nil
+		testCfg.apiConfig.persistDailyForecast(ctx, mockForecast)
+
+		if len(batches) != 2 {
+			t.Fatalf("expected 2 upserts, got %d: %v", len(batches), batches)
 		}
-		testCfg.mockDB.CreateDailyForecastFunc = func(ctx context.Context, arg database.CreateDailyForecastParams) (database.DailyForecast, error) {
-			createCalled++
-			return database.DailyForecast{}, nil
+		var rows []map[string]any
+		if err := json.Unmarshal([]byte(batches[0]), &rows); err != nil {
+			t.Fatal(err)
+		}
+		// The repeated forecast replaces the first one for the same date.
+		if len(rows) != 2 || rows[0]["max_temp_c"] != 23.0 || rows[1]["max_temp_c"] != 22.0 {
+			t.Errorf("unexpected rows for api-a: %v", rows)
+		}
+		if rows[0]["forecast_date"] != "2025-06-01 00:00:00+02:00" || rows[0]["sunrise"] != nil {
+			t.Errorf("unexpected times: %v", rows[0])
 		}
+		if !strings.Contains(batches[1], `"source_api":"api-b"`) {
+			t.Errorf("expected the second upsert to hold api-b, got %s", batches[1])
+		}
+	})
 
-		// Execute
-		testCfg.apiConfig.persistDailyForecast(ctx, mockForecast)
+	t.Run("Failure - Upsert Error is Logged and Returned", func(t *testing.T) {
+		testCfg := newTestAPIConfig(t)
+		var logBuffer bytes.Buffer
+		testCfg.apiConfig.logger = slog.New(slog.NewTextHandler(&logBuffer, nil))
+		testCfg.mockDB.UpsertDailyForecastsFunc = func(ctx context.Context, forecasts json.RawMessage) (int64, error) {
+			return 0, errors.New("db error")
+		}
 
-		// Verify
-		if updateCalled != 1 {
-			t.Errorf("expected UpdateDailyForecast to be called once, but got %d", updateCalled)
+		err := testCfg.apiConfig.storeDailyForecast(ctx, testCfg.apiConfig.dbQueries, mockForecast)
+
+		if err == nil {
+			t.Error("expected an error")
 		}
-		if createCalled != 0 {
-			t.Errorf("expected CreateDailyForecast not to be called, but got %d", createCalled)
+		if testCfg.mockDB.Calls("UpsertDailyForecasts") != 1 {
+			t.Errorf("expected the upserts to stop at the error, got %d calls", testCfg.mockDB.Calls("UpsertDailyForecasts"))
+		}
+		if !strings.Contains(logBuffer.String(), "error upserting cache") {
+			t.Errorf("expected the error to be logged, got %q", logBuffer.String())
 		}
 	})
 }
 
 func TestPersistHourlyForecast(t *testing.T) {
 	ctx := context.Background()
+	start := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	rain := 0.4
 	mockForecast := []HourlyForecast{
-		{
-			Location:         MockLocation,
-			SourceAPI:        "test-api",
-			ForecastDateTime: time.Now(),
-		},
-	}
-	mockExistingDBForecast := database.HourlyForecast{
-		ID:         uuid.New(),
-		LocationID: MockLocation.LocationID,
-		SourceApi:  "test-api",
+		{Location: MockLocation, SourceAPI: "test-api", ForecastDateTime: start, Condition: "Rain", Rain: &rain},
+		{Location: MockLocation, SourceAPI: "test-api", ForecastDateTime: start.Add(time.Hour), Condition: "Clear"},
 	}
 
-	t.Run("Success - Item is Updated", func(t *testing.T) {
-		// Setup
-		testCfg := newTestAPIConfig(t)
-		updateCalled := 0
-		createCalled := 0
-
-		// Mock the DB to return an existing item
-		testCfg.mockDB.GetHourlyForecastAtLocationAndTimeFromAPIFunc = func(ctx context.Context, arg database.GetHourlyForecastAtLocationAndTimeFromAPIParams) (database.HourlyForecast, error) {
-			return mockExistingDBForecast, nil
-		}
-
-		// Track calls to Update and Create
-		testCfg.mockDB.UpdateHourlyForecastFunc = func(ctx context.Context, arg database.UpdateHourlyForecastParams) (database.HourlyForecast, error) {
-			updateCalled++
-			if arg.ID != mockExistingDBForecast.ID {
-				t.Errorf("expected ID %v but got %v", mockExistingDBForecast.ID, arg.ID)
-			}
-			return database.HourlyForecast{}, nil
-		}
-		testCfg.mockDB.CreateHourlyForecastFunc = func(ctx context.Context, arg database.CreateHourlyForecastParams) (database.HourlyForecast, error) {
-			createCalled++
-			return database.HourlyForecast{}, nil
+	testCfg := newTestAPIConfig(t)
+	var rows []map[string]any
+	testCfg.mockDB.UpsertHourlyForecastsFunc = func(ctx context.Context, forecasts json.RawMessage) (int64, error) {
+		if err := json.Unmarshal(forecasts, &rows); err != nil {
+			t.Fatal(err)
 		}
+		return int64(len(rows)), nil
+	}
 
-		// Execute
-		testCfg.apiConfig.persistHourlyForecast(ctx, mockForecast)
+	testCfg.apiConfig.persistHourlyForecast(ctx, mockForecast)
 
-		// Verify
-		if updateCalled != 1 {
-			t.Errorf("expected UpdateHourlyForecast to be called once, but got %d", updateCalled)
-		}
-		if createCalled != 0 {
-			t.Errorf("expected CreateHourlyForecast not to be called, but got %d", createCalled)
-		}
-	})
-}
\ No newline at end of file
+	if testCfg.mockDB.Calls("UpsertHourlyForecasts") != 1 {
+		t.Fatalf("expected 1 upsert, got %d", testCfg.mockDB.Calls("UpsertHourlyForecasts"))
+	}
+	if len(rows) != 2 {
+		t.Fatalf("expected 2 rows, got %v", rows)
+	}
+	if rows[0]["location_id"] != MockLocation.LocationID.String() || rows[0]["forecast_datetime_utc"] != "2025-06-01 12:00:00+00:00" {
+		t.Errorf("unexpected row: %v", rows[0])
+	}
+	if rows[0]["rain_mm"] != 0.4 || rows[1]["rain_mm"] != nil || rows[1]["condition_text"] != "Clear" {
+		t.Errorf("unexpected optional values: %v", rows)
+	}
+}
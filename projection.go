@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// This file implements response field selection. Watch faces and embedded clients only need a
+// few values of each forecast entry, so a request can name the fields it wants with
+// ?fields=temperature_c,precipitation_chance. Like the naming layer, the projection works on the
+// marshaled response rather than on the response types: the entries of every weather and
+// forecasts list are reduced to the requested fields, plus the fields that identify an entry
+// (its source and time). The rest of the response, such as the location and the attribution,
+// is left unchanged. Field names may be given in snake_case or camelCase.
+
+// projectedLists are the names of the response lists whose entries are projected.
+var projectedLists = map[string]bool{
+	"weather":   true,
+	"forecasts": true,
+}
+
+// identifyingFields are kept in every projected entry.
+var identifyingFields = map[string]bool{
+	"source_api":        true,
+	"timestamp":         true,
+	"forecast_date":     true,
+	"forecast_datetime": true,
+}
+
+// errInvalidFields is returned for a fields query parameter with an empty field name.
+var errInvalidFields = errors.New("fields must be a comma-separated list of field names")
+
+// requestFields returns the fields selected by r, or nil if it selects all of them.
+func requestFields(r *http.Request) (map[string]bool, error) {
+	if !r.URL.Query().Has("fields") {
+		return nil, nil
+	}
+	fields := make(map[string]bool)
+	for _, field := range strings.Split(r.URL.Query().Get("fields"), ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			return nil, errInvalidFields
+		}
+		fields[field] = true
+	}
+	return fields, nil
+}
+
+// fieldsResponseWriter carries the selected fields to respondWithJSON.
+type fieldsResponseWriter struct {
+	http.ResponseWriter
+	fields map[string]bool
+}
+
+// Hijack lets WebSocket handlers take over the connection.
+func (fw *fieldsResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(fw.ResponseWriter).Hijack()
+}
+
+// Unwrap returns the wrapped ResponseWriter.
+func (fw *fieldsResponseWriter) Unwrap() http.ResponseWriter {
+	return fw.ResponseWriter
+}
+
+// fieldsMiddleware determines the fields selected by a request and passes them on to the
+// handler with the ResponseWriter. Requests with an invalid fields parameter are rejected.
+func (cfg *apiConfig) fieldsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fields, err := requestFields(r)
+		if err != nil {
+			cfg.respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+			return
+		}
+		if fields == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(&fieldsResponseWriter{ResponseWriter: w, fields: fields}, r)
+	})
+}
+
+// responseFields returns the fields attached to w by fieldsMiddleware, or nil if all fields
+// are selected.
+func responseFields(w http.ResponseWriter) map[string]bool {
+	if fw, ok := findResponseWriter[*fieldsResponseWriter](w); ok {
+		return fw.fields
+	}
+	return nil
+}
+
+// projectFields reduces the entries of the weather and forecasts lists of a JSON document, at
+// any depth, to the given fields and the identifying ones. Other values and the order of fields
+// are left unchanged.
+func projectFields(data []byte, fields map[string]bool) ([]byte, error) {
+	return projectValue(data, fields, false)
+}
+
+// projectValue projects a JSON value. If projected is true and the value is a list, its object
+// entries are reduced to the selected fields.
+func projectValue(data json.RawMessage, fields map[string]bool, projected bool) ([]byte, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return data, nil
+	}
+	switch data[0] {
+	case '{':
+		return projectObject(data, fields, false)
+	case '[':
+		var items []json.RawMessage
+		if err := json.Unmarshal(data, &items); err != nil {
+			return nil, err
+		}
+		var buf bytes.Buffer
+		buf.WriteByte('[')
+		for i, item := range items {
+			var err error
+			item = bytes.TrimSpace(item)
+			if projected && len(item) > 0 && item[0] == '{' {
+				item, err = projectObject(item, fields, true)
+			} else {
+				item, err = projectValue(item, fields, false)
+			}
+			if err != nil {
+				return nil, err
+			}
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			buf.Write(item)
+		}
+		buf.WriteByte(']')
+		return buf.Bytes(), nil
+	}
+	return data, nil
+}
+
+// projectObject projects a JSON object. An entry of a projected list keeps only the selected
+// fields; other objects keep all fields and have their values projected.
+func projectObject(data json.RawMessage, fields map[string]bool, entry bool) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	n := 0
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		key, ok := tok.(string)
+		if !ok {
+			return nil, errors.New("unexpected object key")
+		}
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, err
+		}
+
+		if entry {
+			if !fields[key] && !fields[snakeToCamel(key)] && !identifyingFields[key] {
+				continue
+			}
+		} else if value, err = projectValue(value, fields, projectedLists[key]); err != nil {
+			return nil, err
+		}
+
+		if n > 0 {
+			buf.WriteByte(',')
+		}
+		encodedKey, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(encodedKey)
+		buf.WriteByte(':')
+		buf.Write(value)
+		n++
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
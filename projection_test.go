@@ -0,0 +1,116 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestProjectFields(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		fields   map[string]bool
+		expected string
+	}{
+		{
+			name:     "forecast entries",
+			input:    `{"location":{"city_name":"Wroclaw"},"forecasts":[{"source_api":"a","forecast_datetime":"2025-06-01 12:00","temperature_c":20,"humidity":50,"precipitation_chance":10}],"attribution":[{"provider":"gmp","notice":"x"}]}`,
+			fields:   map[string]bool{"temperature_c": true, "precipitation_chance": true},
+			expected: `{"location":{"city_name":"Wroclaw"},"forecasts":[{"source_api":"a","forecast_datetime":"2025-06-01 12:00","temperature_c":20,"precipitation_chance":10}],"attribution":[{"provider":"gmp","notice":"x"}]}`,
+		},
+		{
+			name:     "camelCase field names",
+			input:    `{"weather":[{"source_api":"a","timestamp":"2025-06-01 12:00","temperature_c":20,"wind_speed_kmh":5}]}`,
+			fields:   map[string]bool{"windSpeedKmh": true},
+			expected: `{"weather":[{"source_api":"a","timestamp":"2025-06-01 12:00","wind_speed_kmh":5}]}`,
+		},
+		{
+			name:     "nested lists",
+			input:    `{"results":{"wroclaw":{"weather":[{"source_api":"a","temperature_c":20,"humidity":50}]}}}`,
+			fields:   map[string]bool{"humidity": true},
+			expected: `{"results":{"wroclaw":{"weather":[{"source_api":"a","humidity":50}]}}}`,
+		},
+		{
+			name:     "unknown fields",
+			input:    `{"forecasts":[{"source_api":"a","temperature_c":20}]}`,
+			fields:   map[string]bool{"nonexistent": true},
+			expected: `{"forecasts":[{"source_api":"a"}]}`,
+		},
+		{
+			name:     "documents without projected lists",
+			input:    `{"status":"ok","items":[{"a":1}]}`,
+			fields:   map[string]bool{"b": true},
+			expected: `{"status":"ok","items":[{"a":1}]}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := projectFields([]byte(tc.input), tc.fields)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(got) != tc.expected {
+				t.Errorf("got %s, want %s", got, tc.expected)
+			}
+		})
+	}
+
+	if _, err := projectFields([]byte(`{"forecasts":[{"a":`), map[string]bool{"a": true}); err == nil {
+		t.Error("expected an error for truncated JSON")
+	}
+}
+
+func TestFieldsMiddleware(t *testing.T) {
+	testCfg := newTestAPIConfig(t)
+	cfg := testCfg.apiConfig
+
+	handler := cfg.namingMiddleware(cfg.fieldsMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cfg.respondWithJSON(w, http.StatusOK, HourlyForecastsResponse{
+			Forecasts: []HourlyForecastJSON{{SourceAPI: "a", ForecastDateTime: "2025-06-01 12:00", Temperature: 20, Humidity: 50, PrecipitationChance: 10}},
+		})
+	})))
+
+	testCases := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "fields selected",
+			query:      "?fields=temperature_c,precipitation_chance",
+			wantStatus: http.StatusOK,
+			wantBody:   `"forecasts":[{"source_api":"a","forecast_datetime":"2025-06-01 12:00","temperature_c":20,"precipitation_chance":10}]`,
+		},
+		{
+			name:       "fields selected with camelCase naming",
+			query:      "?fields=temperatureC&naming=camel",
+			wantStatus: http.StatusOK,
+			wantBody:   `"forecasts":[{"sourceApi":"a","forecastDatetime":"2025-06-01 12:00","temperatureC":20}]`,
+		},
+		{
+			name:       "empty field name",
+			query:      "?fields=temperature_c,",
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"fields must be a comma-separated list of field names"}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/test"+tc.query, nil)
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != tc.wantStatus {
+				t.Errorf("status = %d, want %d", rr.Code, tc.wantStatus)
+			}
+			if got := rr.Body.String(); !strings.Contains(got, tc.wantBody) {
+				t.Errorf("body = %s, want it to contain %s", got, tc.wantBody)
+			}
+		})
+	}
+}
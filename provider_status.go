@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"sync"
+)
+
+// This file builds the per-provider status block of the forecast responses. A single provider
+// failure does not fail a request: the data of the remaining providers is returned, every enabled
+// provider is listed with its status, and the response is flagged as partial, so that clients can
+// show which sources are missing.
+
+// Provider statuses reported in forecast responses.
+const (
+	providerStatusOK    = "ok"
+	providerStatusError = "error"
+)
+
+// providerErrorMessages describes the error classes of failed fetches. Raw fetch errors are not
+// exposed, since they may contain request URLs with API keys.
+var providerErrorMessages = map[string]string{
+	errorClassUnauthorized:    "provider rejected the request as unauthorized",
+	errorClassRateLimited:     "provider rate limit reached",
+	errorClassClientError:     "provider rejected the request",
+	errorClassServerError:     "provider returned a server error",
+	errorClassTimeout:         "provider timed out",
+	errorClassNetwork:         "provider could not be reached",
+	errorClassInvalidResponse: "provider returned an invalid response",
+}
+
+// providerFetchErrors collects the error classes of providers that failed during live fetches,
+// keyed by provider ID. A nil *providerFetchErrors ignores all calls.
+type providerFetchErrors struct {
+	mu      sync.Mutex
+	classes map[string]string
+}
+
+type providerFetchErrorsKey struct{}
+
+// withProviderFetchErrors returns a context under which getCachedOrFetch records the provider
+// failures of its live fetches, and the collector they are recorded in.
+func withProviderFetchErrors(ctx context.Context) (context.Context, *providerFetchErrors) {
+	errs := &providerFetchErrors{}
+	return context.WithValue(ctx, providerFetchErrorsKey{}, errs), errs
+}
+
+// providerFetchErrorsFrom returns the collector of a context, or nil if it has none.
+func providerFetchErrorsFrom(ctx context.Context) *providerFetchErrors {
+	errs, _ := ctx.Value(providerFetchErrorsKey{}).(*providerFetchErrors)
+	return errs
+}
+
+// observe records a failed provider outcome. It is passed to the request functions as onOutcome.
+func (e *providerFetchErrors) observe(outcome providerFetchOutcome) {
+	if e == nil || outcome.Err == nil {
+		return
+	}
+	e.merge(map[string]string{outcome.ProviderID: fetchErrorClass(outcome.Err)})
+}
+
+// merge records the given error classes.
+func (e *providerFetchErrors) merge(classes map[string]string) {
+	if e == nil || len(classes) == 0 {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.classes == nil {
+		e.classes = make(map[string]string, len(classes))
+	}
+	for id, class := range classes {
+		e.classes[id] = class
+	}
+}
+
+// snapshot returns a copy of the recorded error classes.
+func (e *providerFetchErrors) snapshot() map[string]string {
+	if e == nil {
+		return nil
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	classes := make(map[string]string, len(e.classes))
+	for id, class := range e.classes {
+		classes[id] = class
+	}
+	return classes
+}
+
+// providerStatuses returns the status of every enabled provider, in registry order, for a
+// response containing data from the given SourceAPI values. It also reports whether the
+// response is partial, that is whether any enabled provider contributed no data.
+func (cfg *apiConfig) providerStatuses(sources []string, fetchErrs *providerFetchErrors) ([]ProviderStatusJSON, bool) {
+	seen := make(map[string]bool, len(sources))
+	for _, s := range sources {
+		seen[s] = true
+	}
+	classes := fetchErrs.snapshot()
+
+	statuses := []ProviderStatusJSON{}
+	partial := false
+	for _, p := range weatherProviders {
+		if !cfg.sourceEnabled(p.ID) {
+			continue
+		}
+		status := ProviderStatusJSON{
+			Provider:    p.ID,
+			DisplayName: p.DisplayName,
+			Status:      providerStatusOK,
+		}
+		if !seen[p.DisplayName] {
+			status.Status = providerStatusError
+			status.Message = cfg.missingProviderMessage(p.ID, classes)
+			partial = true
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, partial
+}
+
+// missingProviderMessage explains why a provider contributed no data to a response.
+func (cfg *apiConfig) missingProviderMessage(providerID string, classes map[string]string) string {
+	if msg, ok := providerErrorMessages[classes[providerID]]; ok {
+		return msg
+	}
+	switch {
+	case cfg.breakers.isOpen(providerID):
+		return errCircuitOpen.Error()
+	case cfg.quota.exhausted(providerID):
+		return errQuotaExhausted.Error()
+	}
+	return "no data available"
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cor0nius/willitrain/internal/database"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestProviderStatuses(t *testing.T) {
+	now := time.Now()
+
+	testCases := []struct {
+		name        string
+		sources     []string
+		fetchErrs   map[string]string
+		openCircuit string
+		expected    []ProviderStatusJSON
+		partial     bool
+	}{
+		{
+			name:    "all providers present",
+			sources: []string{"Google Weather API", "OpenWeatherMap API", "Open-Meteo API"},
+			expected: []ProviderStatusJSON{
+				{Provider: "gmp", DisplayName: "Google Weather API", Status: providerStatusOK},
+				{Provider: "owm", DisplayName: "OpenWeatherMap API", Status: providerStatusOK},
+				{Provider: "ometeo", DisplayName: "Open-Meteo API", Status: providerStatusOK},
+			},
+		},
+		{
+			name:      "provider failed during fetch",
+			sources:   []string{"Google Weather API", "Open-Meteo API"},
+			fetchErrs: map[string]string{"owm": errorClassTimeout},
+			expected: []ProviderStatusJSON{
+				{Provider: "gmp", DisplayName: "Google Weather API", Status: providerStatusOK},
+				{Provider: "owm", DisplayName: "OpenWeatherMap API", Status: providerStatusError, Message: "provider timed out"},
+				{Provider: "ometeo", DisplayName: "Open-Meteo API", Status: providerStatusOK},
+			},
+			partial: true,
+		},
+		{
+			name:        "provider circuit open",
+			sources:     []string{"OpenWeatherMap API", "Open-Meteo API"},
+			openCircuit: "gmp",
+			expected: []ProviderStatusJSON{
+				{Provider: "gmp", DisplayName: "Google Weather API", Status: providerStatusError, Message: errCircuitOpen.Error()},
+				{Provider: "owm", DisplayName: "OpenWeatherMap API", Status: providerStatusOK},
+				{Provider: "ometeo", DisplayName: "Open-Meteo API", Status: providerStatusOK},
+			},
+			partial: true,
+		},
+		{
+			name:    "provider missing without a known reason",
+			sources: []string{"Google Weather API", "OpenWeatherMap API"},
+			expected: []ProviderStatusJSON{
+				{Provider: "gmp", DisplayName: "Google Weather API", Status: providerStatusOK},
+				{Provider: "owm", DisplayName: "OpenWeatherMap API", Status: providerStatusOK},
+				{Provider: "ometeo", DisplayName: "Open-Meteo API", Status: providerStatusError, Message: "no data available"},
+			},
+			partial: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testCfg := newTestAPIConfig(t)
+			testCfg.enabledSources = map[string]bool{"gmp": true, "owm": true, "ometeo": true}
+			if tc.openCircuit != "" {
+				testCfg.breakers = newTestCircuitBreakers(1, time.Minute, &now)
+				testCfg.breakers.record(tc.openCircuit, &fetchStatusError{Status: "503 Service Unavailable", StatusCode: http.StatusServiceUnavailable})
+			}
+			fetchErrs := &providerFetchErrors{}
+			fetchErrs.merge(tc.fetchErrs)
+
+			statuses, partial := testCfg.apiConfig.providerStatuses(tc.sources, fetchErrs)
+			if !reflect.DeepEqual(statuses, tc.expected) {
+				t.Errorf("expected statuses %+v, got %+v", tc.expected, statuses)
+			}
+			if partial != tc.partial {
+				t.Errorf("expected partial %v, got %v", tc.partial, partial)
+			}
+		})
+	}
+}
+
+func TestProviderFetchErrors_Observe(t *testing.T) {
+	var nilErrs *providerFetchErrors
+	nilErrs.observe(providerFetchOutcome{ProviderID: "owm", Err: errors.New("boom")})
+	if got := nilErrs.snapshot(); got != nil {
+		t.Errorf("expected nil snapshot from nil collector, got %v", got)
+	}
+
+	fetchErrs := &providerFetchErrors{}
+	fetchErrs.observe(providerFetchOutcome{ProviderID: "gmp"})
+	fetchErrs.observe(providerFetchOutcome{ProviderID: "owm", Err: &fetchStatusError{Status: "500 Internal Server Error", StatusCode: http.StatusInternalServerError}})
+
+	expected := map[string]string{"owm": errorClassServerError}
+	if got := fetchErrs.snapshot(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+}
+
+func TestCurrentWeatherResponse_PartialOnProviderFailure(t *testing.T) {
+	handler := createWeatherAPIHandler(t, "current_weather")
+	server := setupMockServer(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Path, "owm") {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		handler(w, r)
+	})
+	defer server.Close()
+
+	testCfg := newTestAPIConfig(t)
+	testCfg.gmpWeatherURL = server.URL + "/gmp"
+	testCfg.owmWeatherURL = server.URL + "/owm"
+	testCfg.ometeoWeatherURL = server.URL + "/ometeo"
+	testCfg.httpClient = server.Client()
+	testCfg.enabledSources = map[string]bool{"gmp": true, "owm": true, "ometeo": true}
+	testCfg.inflight = newFlightGroup()
+
+	location := Location{LocationID: uuid.New(), CityName: "Testville", Latitude: 51.11, Longitude: 17.04, Timezone: "UTC"}
+
+	testCfg.mockCache.GetFunc = func(ctx context.Context, key string) (string, error) {
+		return "", redis.Nil
+	}
+	testCfg.mockDB.GetCurrentWeatherAtLocationFunc = func(ctx context.Context, locationID uuid.UUID) ([]database.CurrentWeather, error) {
+		return nil, sql.ErrNoRows
+	}
+	testCfg.mockDB.GetCurrentWeatherAtLocationFromAPIFunc = func(ctx context.Context, arg database.GetCurrentWeatherAtLocationFromAPIParams) (database.CurrentWeather, error) {
+		return database.CurrentWeather{}, sql.ErrNoRows
+	}
+
+	response, _, err := testCfg.apiConfig.currentWeatherResponse(context.Background(), location, unitsMetric, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(response.Weather) != 2 {
+		t.Errorf("expected 2 weather items, got %d", len(response.Weather))
+	}
+	if !response.Partial {
+		t.Error("expected a partial response")
+	}
+	expected := []ProviderStatusJSON{
+		{Provider: "gmp", DisplayName: "Google Weather API", Status: providerStatusOK},
+		{Provider: "owm", DisplayName: "OpenWeatherMap API", Status: providerStatusError, Message: "provider returned a server error"},
+		{Provider: "ometeo", DisplayName: "Open-Meteo API", Status: providerStatusOK},
+	}
+	if !reflect.DeepEqual(response.Providers, expected) {
+		t.Errorf("expected providers %+v, got %+v", expected, response.Providers)
+	}
+}
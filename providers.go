@@ -0,0 +1,139 @@
+package main
+
+// This file defines the registry of weather providers the application aggregates.
+// Each entry ties together the identifiers used in different parts of the code base:
+// the short ID used in configuration, the display name stored as SourceAPI, and the
+// key used by the WrapFor... functions for the provider's request URL. Features that
+// need per-provider settings (pricing, attribution, enablement) look them up here
+// instead of hard-coding provider names.
+
+// weatherProvider describes a single upstream weather API.
+type weatherProvider struct {
+	ID          string // Short identifier used in configuration, e.g. "gmp".
+	DisplayName string // Human-readable name, stored as SourceAPI on every forecast.
+	URLKey      string // Key of the provider's URL in the maps returned by WrapFor... functions.
+
+	// Attribution metadata that downstream UIs must display alongside the provider's data.
+	HomepageURL string
+	LicenseName string
+	LicenseURL  string
+	Notice      string
+
+	// DefaultCostPerCall is the list price of a single request in USD, used for cost
+	// estimation unless overridden with PROVIDER_COST_PER_CALL.
+	DefaultCostPerCall float64
+}
+
+// weatherProviders lists all supported providers in their canonical order.
+var weatherProviders = []weatherProvider{
+	{
+		ID:          "gmp",
+		DisplayName: "Google Weather API",
+		URLKey:      "gmpWrappedURL",
+
+		HomepageURL: "https://developers.google.com/maps/documentation/weather",
+		LicenseName: "Google Maps Platform Terms of Service",
+		LicenseURL:  "https://cloud.google.com/maps-platform/terms",
+		Notice:      "Powered by Google",
+
+		DefaultCostPerCall: 0.00015,
+	},
+	{
+		ID:          "owm",
+		DisplayName: "OpenWeatherMap API",
+		URLKey:      "owmWrappedURL",
+
+		HomepageURL: "https://openweathermap.org",
+		LicenseName: "CC BY-SA 4.0",
+		LicenseURL:  "https://creativecommons.org/licenses/by-sa/4.0/",
+		Notice:      "Weather data provided by OpenWeather",
+
+		DefaultCostPerCall: 0.0015,
+	},
+	{
+		ID:          "ometeo",
+		DisplayName: "Open-Meteo API",
+		URLKey:      "ometeoWrappedURL",
+
+		HomepageURL: "https://open-meteo.com",
+		LicenseName: "CC BY 4.0",
+		LicenseURL:  "https://creativecommons.org/licenses/by/4.0/",
+		Notice:      "Weather data by Open-Meteo.com",
+
+		DefaultCostPerCall: 0,
+	},
+	{
+		ID:          "metno",
+		DisplayName: "Met.no API",
+		URLKey:      "metnoWrappedURL",
+
+		HomepageURL: "https://api.met.no",
+		LicenseName: "CC BY 4.0",
+		LicenseURL:  "https://creativecommons.org/licenses/by/4.0/",
+		Notice:      "Weather data from MET Norway",
+
+		DefaultCostPerCall: 0,
+	},
+}
+
+// providerByID returns the registered provider with the given short ID.
+func providerByID(id string) (weatherProvider, bool) {
+	for _, p := range weatherProviders {
+		if p.ID == id {
+			return p, true
+		}
+	}
+	return weatherProvider{}, false
+}
+
+// providerByDisplayName returns the registered provider whose display name matches
+// the given SourceAPI value.
+func providerByDisplayName(name string) (weatherProvider, bool) {
+	for _, p := range weatherProviders {
+		if p.DisplayName == name {
+			return p, true
+		}
+	}
+	return weatherProvider{}, false
+}
+
+// providerByURLKey returns the registered provider whose request URL is stored under the
+// given key by the WrapFor... functions.
+func providerByURLKey(key string) (weatherProvider, bool) {
+	for _, p := range weatherProviders {
+		if p.URLKey == key {
+			return p, true
+		}
+	}
+	return weatherProvider{}, false
+}
+
+// sourceEnabled reports whether the provider with the given ID may be queried and served.
+// A nil enabledSources map means every provider is enabled.
+func (cfg *apiConfig) sourceEnabled(id string) bool {
+	if cfg.enabledSources == nil {
+		return true
+	}
+	return cfg.enabledSources[id]
+}
+
+// sourceAPIEnabled is like sourceEnabled but takes a SourceAPI display name. Names that do
+// not belong to a registered provider are always considered enabled.
+func (cfg *apiConfig) sourceAPIEnabled(sourceAPI string) bool {
+	p, ok := providerByDisplayName(sourceAPI)
+	if !ok {
+		return true
+	}
+	return cfg.sourceEnabled(p.ID)
+}
+
+// enabledSourceCount returns the number of registered providers that are currently enabled.
+func (cfg *apiConfig) enabledSourceCount() int {
+	count := 0
+	for _, p := range weatherProviders {
+		if cfg.sourceEnabled(p.ID) {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cor0nius/willitrain/internal/database"
+	"github.com/google/uuid"
+)
+
+// This file implements the soft quota policy. Providers with a daily call quota
+// (PROVIDER_DAILY_QUOTA) are counted per UTC day. Once the remaining quota of a provider falls
+// below QUOTA_DEGRADE_PERCENT of its limit, the provider is degraded: hourly forecasts are only
+// fetched from it for priority locations, i.e. locations on a watchlist and the most requested
+// locations of the last day. Other locations keep their daily forecast and current weather from
+// the provider, which cost far fewer calls than refreshing the hourly forecast every hour. A
+// fetch is never reduced to zero providers by the policy; the scheduler instead leaves such a
+// location's hourly forecast untouched until the next day. Once the quota is used up, the
+// provider is not called at all until the next day. Call counts are kept in memory, so a restart
+// resets the count for the current day.
+
+const (
+	defaultQuotaDegradePercent = 20
+
+	// quotaPriorityLocations is the number of most requested locations that keep their hourly
+	// forecasts from degraded providers, in addition to the watched locations.
+	quotaPriorityLocations = 20
+	quotaPriorityWindow    = 24 * time.Hour
+)
+
+// errQuotaExhausted is returned by a fetch from a provider that has used up its daily quota.
+var errQuotaExhausted = errors.New("provider daily quota exhausted")
+
+// providerQuotaPolicy counts daily provider calls against the configured quotas and decides which
+// hourly fetches are skipped. A nil policy is valid, never degrades a provider, and records nothing.
+type providerQuotaPolicy struct {
+	mu             sync.Mutex
+	limits         map[string]int64 // Daily call limit by provider ID; missing providers are unlimited.
+	degradePercent int
+	day            time.Time // Start of the UTC day the calls are counted for.
+	calls          map[string]int64
+	degraded       map[string]bool
+	priority       map[uuid.UUID]bool
+	logger         *slog.Logger
+	now            func() time.Time
+}
+
+func newProviderQuotaPolicy(limits map[string]int64, degradePercent int, logger *slog.Logger) *providerQuotaPolicy {
+	q := &providerQuotaPolicy{
+		limits:         limits,
+		degradePercent: degradePercent,
+		calls:          make(map[string]int64),
+		degraded:       make(map[string]bool),
+		priority:       make(map[uuid.UUID]bool),
+		logger:         logger,
+		now:            time.Now,
+	}
+	for id, limit := range limits {
+		providerQuotaRemaining.WithLabelValues(id).Set(float64(limit))
+		providerQuotaUsed.WithLabelValues(id).Set(0)
+		providerQuotaDegraded.WithLabelValues(id).Set(0)
+	}
+	return q
+}
+
+// getProviderQuotas reads PROVIDER_DAILY_QUOTA, a comma-separated list of id=calls pairs
+// (e.g. "owm=1000,gmp=5000"). Invalid entries are logged and ignored.
+func getProviderQuotas(logger *slog.Logger) map[string]int64 {
+	quotas := make(map[string]int64)
+	val := os.Getenv("PROVIDER_DAILY_QUOTA")
+	if val == "" {
+		return quotas
+	}
+	for _, entry := range strings.Split(val, ",") {
+		id, limitStr, found := strings.Cut(strings.TrimSpace(entry), "=")
+		if !found {
+			logger.Warn("invalid provider quota entry, ignoring", "entry", entry)
+			continue
+		}
+		if _, ok := providerByID(id); !ok {
+			logger.Warn("unknown provider in quota configuration, ignoring", "provider", id)
+			continue
+		}
+		limit, err := strconv.ParseInt(limitStr, 10, 64)
+		if err != nil || limit <= 0 {
+			logger.Warn("invalid provider quota, ignoring", "provider", id, "value", limitStr)
+			continue
+		}
+		quotas[id] = limit
+	}
+	return quotas
+}
+
+// getQuotaDegradePercent reads the remaining-quota percentage below which a provider is degraded
+// from QUOTA_DEGRADE_PERCENT. A value of 0 only counts calls; values outside 0-100 are ignored.
+func getQuotaDegradePercent(logger *slog.Logger) int {
+	p := getEnvAsInt("QUOTA_DEGRADE_PERCENT", defaultQuotaDegradePercent, logger)
+	if p < 0 || p > 100 {
+		logger.Warn("QUOTA_DEGRADE_PERCENT must be between 0 and 100, using default", "value", p)
+		return defaultQuotaDegradePercent
+	}
+	return p
+}
+
+// enabled reports whether any provider has a quota.
+func (q *providerQuotaPolicy) enabled() bool {
+	return q != nil && len(q.limits) > 0
+}
+
+// recordCall counts one call to a provider against its daily quota.
+func (q *providerQuotaPolicy) recordCall(providerID string) {
+	if q == nil {
+		return
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	limit, ok := q.limits[providerID]
+	if !ok {
+		return
+	}
+	q.rollover()
+	q.calls[providerID]++
+	providerQuotaUsed.WithLabelValues(providerID).Set(float64(q.calls[providerID]))
+	providerQuotaRemaining.WithLabelValues(providerID).Set(float64(max(limit-q.calls[providerID], 0)))
+
+	remaining := limit - q.calls[providerID]
+	if q.degradePercent > 0 && !q.degraded[providerID] && remaining*100 < limit*int64(q.degradePercent) {
+		q.degraded[providerID] = true
+		providerQuotaDegraded.WithLabelValues(providerID).Set(1)
+		q.logger.Warn("provider quota running low, reducing hourly forecast fetches", "provider", providerID, "remaining", remaining, "limit", limit)
+	}
+}
+
+// rollover resets the counts when a new UTC day has started. The caller must hold q.mu.
+func (q *providerQuotaPolicy) rollover() {
+	day := q.now().UTC().Truncate(24 * time.Hour)
+	if day.Equal(q.day) {
+		return
+	}
+	q.day = day
+	clear(q.calls)
+	for id, limit := range q.limits {
+		if q.degraded[id] {
+			q.logger.Info("provider quota reset, restoring hourly forecast fetches", "provider", id)
+		}
+		providerQuotaRemaining.WithLabelValues(id).Set(float64(limit))
+		providerQuotaUsed.WithLabelValues(id).Set(0)
+		providerQuotaDegraded.WithLabelValues(id).Set(0)
+	}
+	clear(q.degraded)
+}
+
+// exhausted reports whether a provider has used up its daily quota.
+func (q *providerQuotaPolicy) exhausted(providerID string) bool {
+	if q == nil {
+		return false
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	limit, ok := q.limits[providerID]
+	if !ok {
+		return false
+	}
+	q.rollover()
+	return q.calls[providerID] >= limit
+}
+
+// allowsHourly reports whether the hourly forecast of a location may be fetched from a provider.
+func (q *providerQuotaPolicy) allowsHourly(providerID string, locationID uuid.UUID) bool {
+	if q == nil {
+		return true
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.rollover()
+	return !q.degraded[providerID] || q.priority[locationID]
+}
+
+// setPriority replaces the set of priority locations.
+func (q *providerQuotaPolicy) setPriority(locationIDs []uuid.UUID) {
+	if q == nil {
+		return
+	}
+	priority := make(map[uuid.UUID]bool, len(locationIDs))
+	for _, id := range locationIDs {
+		priority[id] = true
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.priority = priority
+}
+
+// hourlyQuotaSkips returns the enabled providers whose hourly forecast for a location is skipped
+// under the quota policy, and whether that includes every enabled provider.
+func (cfg *apiConfig) hourlyQuotaSkips(location Location) (map[string]bool, bool) {
+	if !cfg.quota.enabled() {
+		return nil, false
+	}
+	skips := make(map[string]bool)
+	enabled := 0
+	for _, p := range weatherProviders {
+		if !cfg.sourceEnabled(p.ID) {
+			continue
+		}
+		enabled++
+		if !cfg.quota.allowsHourly(p.ID, location.LocationID) {
+			skips[p.ID] = true
+		}
+	}
+	return skips, enabled > 0 && len(skips) == enabled
+}
+
+// allQuotasExhausted reports whether every enabled provider has used up its daily quota, in
+// which case the scheduler leaves the stored data of its locations untouched.
+func (cfg *apiConfig) allQuotasExhausted() bool {
+	if !cfg.quota.enabled() {
+		return false
+	}
+	enabled := 0
+	for _, p := range weatherProviders {
+		if !cfg.sourceEnabled(p.ID) {
+			continue
+		}
+		enabled++
+		if !cfg.quota.exhausted(p.ID) {
+			return false
+		}
+	}
+	return enabled > 0
+}
+
+// refreshQuotaPriority recomputes the priority locations from the watchlists and the request
+// statistics. It does nothing if no provider has a quota.
+func (cfg *apiConfig) refreshQuotaPriority(ctx context.Context, now time.Time) error {
+	if !cfg.quota.enabled() {
+		return nil
+	}
+	watched, err := cfg.dbQueries.ListWatchedLocationIDs(ctx)
+	if err != nil {
+		return fmt.Errorf("could not list watched locations: %w", err)
+	}
+	top, err := cfg.dbQueries.GetTopLocationsByRequestsSince(ctx, database.GetTopLocationsByRequestsSinceParams{
+		Hour:  statsHour(now.Add(-quotaPriorityWindow)),
+		Limit: quotaPriorityLocations,
+	})
+	if err != nil {
+		return fmt.Errorf("could not get most requested locations: %w", err)
+	}
+	for _, row := range top {
+		watched = append(watched, row.ID)
+	}
+	cfg.quota.setPriority(watched)
+	return nil
+}
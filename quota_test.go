@@ -0,0 +1,260 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cor0nius/willitrain/internal/database"
+	"github.com/google/uuid"
+)
+
+// newTestQuotaPolicy returns a quota policy with a fixed clock.
+func newTestQuotaPolicy(limits map[string]int64, degradePercent int, now *time.Time) *providerQuotaPolicy {
+	q := newProviderQuotaPolicy(limits, degradePercent, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	q.now = func() time.Time { return *now }
+	return q
+}
+
+func TestProviderQuotaPolicy(t *testing.T) {
+	t.Run("nil policy allows everything", func(t *testing.T) {
+		var q *providerQuotaPolicy
+		q.recordCall("owm")
+		if q.enabled() || !q.allowsHourly("owm", uuid.New()) {
+			t.Error("expected a nil policy to be disabled and allow all fetches")
+		}
+	})
+
+	t.Run("degrades below threshold until the next day", func(t *testing.T) {
+		now := time.Date(2025, 8, 4, 12, 0, 0, 0, time.UTC)
+		q := newTestQuotaPolicy(map[string]int64{"owm": 10}, 20, &now)
+		priority, other := uuid.New(), uuid.New()
+		q.setPriority([]uuid.UUID{priority})
+
+		for i := 0; i < 8; i++ {
+			q.recordCall("owm")
+			q.recordCall("ometeo")
+		}
+		if !q.allowsHourly("owm", other) {
+			t.Fatal("expected owm to be allowed with 20% of its quota left")
+		}
+		q.recordCall("owm")
+		if q.allowsHourly("owm", other) {
+			t.Error("expected owm to be degraded for other locations with 10% of its quota left")
+		}
+		if !q.allowsHourly("owm", priority) {
+			t.Error("expected owm to stay allowed for priority locations")
+		}
+		if !q.allowsHourly("ometeo", other) {
+			t.Error("expected providers without a quota to stay allowed")
+		}
+
+		now = now.Add(12 * time.Hour)
+		if !q.allowsHourly("owm", other) {
+			t.Error("expected owm to be allowed again on the next day")
+		}
+	})
+
+	t.Run("exhausted at the limit until the next day", func(t *testing.T) {
+		now := time.Date(2025, 8, 4, 12, 0, 0, 0, time.UTC)
+		q := newTestQuotaPolicy(map[string]int64{"owm": 2}, 0, &now)
+		q.recordCall("owm")
+		if q.exhausted("owm") {
+			t.Fatal("expected owm not to be exhausted with calls left")
+		}
+		q.recordCall("owm")
+		if !q.exhausted("owm") {
+			t.Error("expected owm to be exhausted at its limit")
+		}
+		if q.exhausted("ometeo") {
+			t.Error("expected providers without a quota never to be exhausted")
+		}
+		now = now.Add(12 * time.Hour)
+		if q.exhausted("owm") {
+			t.Error("expected owm to be available again on the next day")
+		}
+	})
+
+	t.Run("zero percent only counts", func(t *testing.T) {
+		now := time.Date(2025, 8, 4, 12, 0, 0, 0, time.UTC)
+		q := newTestQuotaPolicy(map[string]int64{"owm": 2}, 0, &now)
+		for i := 0; i < 5; i++ {
+			q.recordCall("owm")
+		}
+		if !q.allowsHourly("owm", uuid.New()) {
+			t.Error("expected no degradation with QUOTA_DEGRADE_PERCENT=0")
+		}
+	})
+}
+
+func TestGetProviderQuotas(t *testing.T) {
+	t.Setenv("PROVIDER_DAILY_QUOTA", "owm=1000, gmp=5000,accuweather=10,ometeo=-1,broken")
+	quotas := getProviderQuotas(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if len(quotas) != 2 || quotas["owm"] != 1000 || quotas["gmp"] != 5000 {
+		t.Errorf("unexpected quotas: %v", quotas)
+	}
+}
+
+func TestProcessForecastRequests_QuotaSkips(t *testing.T) {
+	var mu sync.Mutex
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		mu.Unlock()
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	parserFor := func(sourceAPI string) func(io.Reader, *slog.Logger) ([]HourlyForecast, string, error) {
+		return func(io.Reader, *slog.Logger) ([]HourlyForecast, string, error) {
+			return []HourlyForecast{{SourceAPI: sourceAPI}}, "", nil
+		}
+	}
+	urls := map[string]string{
+		"owmWrappedURL":    server.URL + "/owm",
+		"ometeoWrappedURL": server.URL + "/ometeo",
+	}
+	providers := map[string]forecastProvider[[]HourlyForecast]{
+		"owmWrappedURL":    {parser: parserFor("OpenWeatherMap API"), errorVal: []HourlyForecast{{SourceAPI: "OpenWeatherMap API"}}},
+		"ometeoWrappedURL": {parser: parserFor("Open-Meteo API"), errorVal: []HourlyForecast{{SourceAPI: "Open-Meteo API"}}},
+	}
+
+	testCases := []struct {
+		name      string
+		limits    map[string]int64
+		wantPaths string
+	}{
+		{"degraded provider is skipped", map[string]int64{"owm": 10}, "/ometeo"},
+		{"all providers degraded are all queried", map[string]int64{"owm": 10, "ometeo": 10}, "/ometeo,/owm"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			paths = nil
+			now := time.Now()
+			cfg := newTestAPIConfig(t).apiConfig
+			cfg.enabledSources = map[string]bool{"owm": true, "ometeo": true}
+			cfg.quota = newTestQuotaPolicy(tc.limits, 20, &now)
+			for id, limit := range tc.limits {
+				for range limit - 1 {
+					cfg.quota.recordCall(id)
+				}
+			}
+
+			if _, _, err := processForecastRequests(cfg, context.Background(), MockLocation, urls, providers, nil, nil); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			slices.Sort(paths)
+			if got := strings.Join(paths, ","); got != tc.wantPaths {
+				t.Errorf("queried %s, want %s", got, tc.wantPaths)
+			}
+		})
+	}
+}
+
+func TestProcessForecastRequests_QuotaExhausted(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	urls := map[string]string{"owmWrappedURL": server.URL + "/owm"}
+	providers := map[string]forecastProvider[CurrentWeather]{
+		"owmWrappedURL": {
+			parser: func(io.Reader, *slog.Logger) (CurrentWeather, string, error) {
+				return CurrentWeather{SourceAPI: "OpenWeatherMap API"}, "", nil
+			},
+			errorVal: CurrentWeather{SourceAPI: "OpenWeatherMap API"},
+		},
+	}
+
+	now := time.Now()
+	cfg := newTestAPIConfig(t).apiConfig
+	cfg.enabledSources = map[string]bool{"owm": true}
+	cfg.quota = newTestQuotaPolicy(map[string]int64{"owm": 1}, 20, &now)
+	cfg.quota.recordCall("owm")
+
+	if _, _, err := processForecastRequests(cfg, context.Background(), MockLocation, urls, providers, nil, nil); err == nil {
+		t.Error("expected an error when the only provider is out of quota, got nil")
+	}
+	if len(paths) != 0 {
+		t.Errorf("expected no requests to a provider out of quota, got %v", paths)
+	}
+}
+
+func TestRunCurrentWeatherJobs_AllQuotasExhausted(t *testing.T) {
+	testCfg := newTestAPIConfig(t)
+	cfg := testCfg.apiConfig
+	cfg.enabledSources = map[string]bool{"ometeo": true}
+	now := time.Now()
+	cfg.quota = newTestQuotaPolicy(map[string]int64{"ometeo": 1}, 20, &now)
+	cfg.quota.recordCall("ometeo")
+	testCfg.mockDB.ListLocationsFunc = func(ctx context.Context) ([]database.Location, error) {
+		return []database.Location{{ID: uuid.New(), CityName: "Quiet Town"}}, nil
+	}
+
+	if err := NewScheduler(cfg).runCurrentWeatherJobs(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := testCfg.mockDB.Calls("DeleteCurrentWeatherAtLocation"); got != 0 {
+		t.Errorf("expected the stored current weather to be kept, got %d deletions", got)
+	}
+}
+
+func TestRunHourlyForecastJobs_QuotaExhausted(t *testing.T) {
+	testCfg := newTestAPIConfig(t)
+	cfg := testCfg.apiConfig
+	cfg.enabledSources = map[string]bool{"ometeo": true}
+	now := time.Now()
+	cfg.quota = newTestQuotaPolicy(map[string]int64{"ometeo": 10}, 20, &now)
+	for range 9 {
+		cfg.quota.recordCall("ometeo")
+	}
+
+	priorityID := uuid.New()
+	testCfg.mockDB.ListWatchedLocationIDsFunc = func(ctx context.Context) ([]uuid.UUID, error) {
+		return []uuid.UUID{priorityID}, nil
+	}
+	testCfg.mockDB.GetTopLocationsByRequestsSinceFunc = func(ctx context.Context, arg database.GetTopLocationsByRequestsSinceParams) ([]database.GetTopLocationsByRequestsSinceRow, error) {
+		return nil, nil
+	}
+	testCfg.mockDB.ListLocationsFunc = func(ctx context.Context) ([]database.Location, error) {
+		return []database.Location{{ID: uuid.New(), CityName: "Quiet Town"}, {ID: priorityID, CityName: "Watched City"}}, nil
+	}
+	var deleted []uuid.UUID
+	testCfg.mockDB.DeleteHourlyForecastsAtLocationFunc = func(ctx context.Context, locationID uuid.UUID) error {
+		deleted = append(deleted, locationID)
+		return nil
+	}
+	testCfg.mockDB.ListAlertSubscriptionsForLocationFunc = func(ctx context.Context, locationID uuid.UUID) ([]database.AlertSubscription, error) {
+		return nil, nil
+	}
+	// The watched location is still fetched and its forecasts are replaced.
+	ometeoData, _ := os.ReadFile("testdata/hourly_forecast_ometeo.json")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(ometeoData)
+	}))
+	defer server.Close()
+	cfg.httpClient = server.Client()
+	cfg.ometeoWeatherURL = server.URL + "/?"
+
+	if err := NewScheduler(cfg).runHourlyForecastJobs(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(deleted) != 1 || deleted[0] != priorityID {
+		t.Errorf("expected only the watched location to be updated, got %v", deleted)
+	}
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// This file implements the per-provider rate limiter of the fetch layer. Providers with a
+// request-per-minute limit (PROVIDER_RATE_LIMIT) are paced evenly, with a burst allowance of
+// rateLimitBurstWindow worth of requests. A fetch that would exceed the limit is queued until
+// the provider has capacity again, or rejected with errRateLimited if that takes longer than
+// rateLimitMaxWait, so that neither user requests nor scheduler cycles pile up behind a busy
+// provider. Queued fetches stop waiting when their request is cancelled. Fetches from a
+// provider whose daily quota (PROVIDER_DAILY_QUOTA) is used up are skipped by
+// processForecastRequests before they reach the limiter.
+
+const (
+	// rateLimitBurstWindow is the share of a minute's requests that may be sent back to back.
+	rateLimitBurstWindow = 10 * time.Second
+	// rateLimitMaxWait is the longest a fetch is queued for a rate-limited provider.
+	rateLimitMaxWait = 10 * time.Second
+)
+
+// errRateLimited is returned by a fetch that could not be sent within rateLimitMaxWait.
+var errRateLimited = errors.New("provider rate limit exceeded")
+
+// providerRateLimiter paces the requests to each provider with a configured limit. A nil
+// limiter is valid and never delays a request.
+type providerRateLimiter struct {
+	mu     sync.Mutex
+	limits map[string]int // Requests per minute by provider ID; missing providers are unlimited.
+	next   map[string]time.Time
+	now    func() time.Time
+	sleep  func(context.Context, time.Duration) error
+}
+
+func newProviderRateLimiter(limits map[string]int) *providerRateLimiter {
+	return &providerRateLimiter{
+		limits: limits,
+		next:   make(map[string]time.Time),
+		now:    time.Now,
+		sleep:  sleepContext,
+	}
+}
+
+// sleepContext waits for d, or returns the context's error if it is done first.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// getProviderRateLimits reads PROVIDER_RATE_LIMIT, a comma-separated list of id=requests pairs
+// giving each provider's limit per minute (e.g. "owm=60,gmp=600"). Invalid entries are logged
+// and ignored.
+func getProviderRateLimits(logger *slog.Logger) map[string]int {
+	limits := make(map[string]int)
+	val := os.Getenv("PROVIDER_RATE_LIMIT")
+	if val == "" {
+		return limits
+	}
+	for _, entry := range strings.Split(val, ",") {
+		id, limitStr, found := strings.Cut(strings.TrimSpace(entry), "=")
+		if !found {
+			logger.Warn("invalid provider rate limit entry, ignoring", "entry", entry)
+			continue
+		}
+		if _, ok := providerByID(id); !ok {
+			logger.Warn("unknown provider in rate limit configuration, ignoring", "provider", id)
+			continue
+		}
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 {
+			logger.Warn("invalid provider rate limit, ignoring", "provider", id, "value", limitStr)
+			continue
+		}
+		limits[id] = limit
+	}
+	return limits
+}
+
+// enabled reports whether any provider has a rate limit.
+func (l *providerRateLimiter) enabled() bool {
+	return l != nil && len(l.limits) > 0
+}
+
+// wait blocks until a request may be sent to a provider. It returns errRateLimited without
+// waiting if the provider has no capacity within rateLimitMaxWait, and the context's error if
+// it is done while waiting.
+func (l *providerRateLimiter) wait(ctx context.Context, providerID string) error {
+	delay, err := l.reserve(providerID)
+	if err != nil {
+		rateLimitedFetches.WithLabelValues(providerID, "rejected").Inc()
+		return err
+	}
+	if delay > 0 {
+		rateLimitedFetches.WithLabelValues(providerID, "delayed").Inc()
+		return l.sleep(ctx, delay)
+	}
+	return nil
+}
+
+// reserve books the next free slot of a provider and returns how long to wait for it. The
+// slots are spaced by the provider's interval; up to a burst of them may be booked ahead.
+func (l *providerRateLimiter) reserve(providerID string) (time.Duration, error) {
+	if l == nil {
+		return 0, nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	limit, ok := l.limits[providerID]
+	if !ok {
+		return 0, nil
+	}
+
+	interval := time.Minute / time.Duration(limit)
+	burst := max(time.Duration(limit)*rateLimitBurstWindow/time.Minute, 1)
+	now := l.now()
+	next := l.next[providerID]
+	if next.Before(now) {
+		next = now
+	}
+	delay := max(next.Sub(now)-(burst-1)*interval, 0)
+	if delay > rateLimitMaxWait {
+		return 0, errRateLimited
+	}
+	l.next[providerID] = next.Add(interval)
+	return delay, nil
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestProviderRateLimiter(t *testing.T) {
+	t.Run("nil limiter never waits", func(t *testing.T) {
+		var l *providerRateLimiter
+		if l.enabled() {
+			t.Error("expected a nil limiter to be disabled")
+		}
+		if delay, err := l.reserve("owm"); delay != 0 || err != nil {
+			t.Errorf("expected no delay, got %v, %v", delay, err)
+		}
+	})
+
+	t.Run("paces requests after the burst", func(t *testing.T) {
+		now := time.Date(2025, 8, 4, 12, 0, 0, 0, time.UTC)
+		l := newProviderRateLimiter(map[string]int{"owm": 60})
+		l.now = func() time.Time { return now }
+
+		// 60 requests per minute allow a burst of 10, then one request per second.
+		for i := range 10 {
+			if delay, err := l.reserve("owm"); delay != 0 || err != nil {
+				t.Fatalf("request %d: expected no delay, got %v, %v", i, delay, err)
+			}
+		}
+		for i := 1; i <= 10; i++ {
+			delay, err := l.reserve("owm")
+			if err != nil || delay != time.Duration(i)*time.Second {
+				t.Fatalf("expected a delay of %ds, got %v, %v", i, delay, err)
+			}
+		}
+		if _, err := l.reserve("owm"); !errors.Is(err, errRateLimited) {
+			t.Errorf("expected errRateLimited beyond the maximum wait, got %v", err)
+		}
+		if delay, err := l.reserve("ometeo"); delay != 0 || err != nil {
+			t.Errorf("expected providers without a limit to pass, got %v, %v", delay, err)
+		}
+
+		now = now.Add(time.Minute)
+		if delay, err := l.reserve("owm"); delay != 0 || err != nil {
+			t.Errorf("expected the burst to be available again after a minute, got %v, %v", delay, err)
+		}
+	})
+
+	t.Run("low limits allow one request at a time", func(t *testing.T) {
+		now := time.Date(2025, 8, 4, 12, 0, 0, 0, time.UTC)
+		l := newProviderRateLimiter(map[string]int{"gmp": 4})
+		l.now = func() time.Time { return now }
+
+		if delay, _ := l.reserve("gmp"); delay != 0 {
+			t.Errorf("expected the first request to pass, got a delay of %v", delay)
+		}
+		if _, err := l.reserve("gmp"); !errors.Is(err, errRateLimited) {
+			t.Errorf("expected errRateLimited for a 15s wait, got %v", err)
+		}
+	})
+
+	t.Run("cancelled context stops the wait", func(t *testing.T) {
+		l := newProviderRateLimiter(map[string]int{"gmp": 6})
+		if err := l.wait(context.Background(), "gmp"); err != nil {
+			t.Fatalf("expected the first request to pass, got %v", err)
+		}
+
+		// The second request would wait 10s for its slot.
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		start := time.Now()
+		if err := l.wait(ctx, "gmp"); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected the context's error, got %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("wait returned after %v, want it to stop with the context", elapsed)
+		}
+	})
+}
+
+func TestGetProviderRateLimits(t *testing.T) {
+	t.Setenv("PROVIDER_RATE_LIMIT", "owm=60, gmp=600,accuweather=10,ometeo=0,broken")
+	limits := getProviderRateLimits(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if len(limits) != 2 || limits["owm"] != 60 || limits["gmp"] != 600 {
+		t.Errorf("unexpected rate limits: %v", limits)
+	}
+}
+
+func TestFetchForecast_RateLimit(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	cfg := newTestAPIConfig(t).apiConfig
+	now := time.Now()
+	var slept []time.Duration
+	cfg.rateLimits = newProviderRateLimiter(map[string]int{"ometeo": 60})
+	cfg.rateLimits.now = func() time.Time { return now }
+	cfg.rateLimits.sleep = func(ctx context.Context, d time.Duration) error {
+		slept = append(slept, d)
+		return nil
+	}
+
+	parser := func(io.Reader, *slog.Logger) (CurrentWeather, string, error) {
+		return CurrentWeather{SourceAPI: "Open-Meteo API"}, "", nil
+	}
+	errorVal := CurrentWeather{SourceAPI: "Open-Meteo API"}
+
+	for range 20 {
+		if _, _, err := fetchForecast(cfg, context.Background(), server.URL, parser, errorVal); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if _, _, err := fetchForecast(cfg, context.Background(), server.URL, parser, errorVal); !errors.Is(err, errRateLimited) {
+		t.Errorf("expected errRateLimited, got %v", err)
+	}
+	if requests != 20 {
+		t.Errorf("expected 20 requests to reach the provider, got %d", requests)
+	}
+	if len(slept) != 10 || slept[9] != 10*time.Second {
+		t.Errorf("expected the 10 requests after the burst to be delayed, got %v", slept)
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+// This file implements the refresh endpoint. When a user reports obviously wrong data for a
+// location, support staff can refresh that location at once instead of waiting for the cached
+// data to expire. The refresh runs the scheduler's update of each forecast type for the location
+// and then purges its cache entries, so that the next request reads the fresh data.
+
+// Refresh outcomes of a forecast type, as reported by the refresh endpoint.
+const (
+	refreshStatusUpdated = "updated"
+	refreshStatusSkipped = "skipped"
+	refreshStatusFailed  = "failed"
+)
+
+// refreshLocation replaces the current weather, hourly and daily forecasts of a location with
+// fresh data from the providers, regardless of how old the stored data is. Each forecast type is
+// updated with the scheduler's location timeout, and a failure of one type does not prevent the
+// others from being refreshed. It returns the outcome of each type by scheduler job name.
+func (s *Scheduler) refreshLocation(ctx context.Context, location Location) map[string]string {
+	updates := []struct {
+		jobType    string
+		updateFunc func(context.Context, Location) error
+	}{
+		{currentWeatherJobName, s.updateCurrentWeather},
+		{hourlyForecastJobName, s.updateHourlyForecast},
+		{dailyForecastJobName, s.updateDailyForecast},
+	}
+
+	results := make(map[string]string, len(updates))
+	for _, update := range updates {
+		err := s.runLocationUpdate(ctx, update.jobType, location, update.updateFunc)
+		switch {
+		case errors.Is(err, errUpdateSkipped):
+			results[update.jobType] = refreshStatusSkipped
+		case err != nil:
+			results[update.jobType] = refreshStatusFailed
+		default:
+			results[update.jobType] = refreshStatusUpdated
+		}
+	}
+
+	if err := s.cfg.cache.Delete(ctx, s.cfg.locationCacheKeys(location.LocationID)...); err != nil && !errors.Is(err, errCacheUnavailable) {
+		s.cfg.logger.WarnContext(ctx, "could not purge cache after refresh", "location", location.CityName, "error", err)
+	}
+	s.cfg.logger.InfoContext(ctx, "location refreshed on demand", "location", location.CityName, "results", results)
+	return results
+}
+
+// handlerRefreshLocation forces an immediate refresh of a single location's weather data.
+
+// @Summary      Refresh a location's weather data
+// @Description  Fetches fresh current weather, hourly and daily forecasts for a location from the providers,
+// @Description  regardless of how old the stored data is, stores them and purges the location's cache entries.
+// @Description  The outcome of each forecast type is `updated`, `skipped` (no provider available) or `failed`.
+// @Description  Intended for support staff when a user reports wrong data.
+// @Tags         admin
+// @Produce      json
+// @Param        city  query     string  false  "City name (e.g., 'Wroclaw'). Required unless lat and lon are given."
+// @Param        lat   query     number  false  "Latitude. Must be given together with lon."
+// @Param        lon   query     number  false  "Longitude. Must be given together with lat."
+// @Success      200  {object}  RefreshResponse
+// @Failure      400  {object}  ErrorResponse "Bad Request - Missing or invalid location"
+// @Failure      405  {object}  ErrorResponse "Method Not Allowed"
+// @Failure      502  {object}  ErrorResponse "Bad Gateway - No forecast type could be refreshed"
+// @Security     ApiKeyAuth
+// @Failure      401  {object}  ErrorResponse "Unauthorized - Missing API key"
+// @Failure      403  {object}  ErrorResponse "Forbidden - Invalid API key"
+// @Router       /api/v1/refresh [post]
+func (s *Scheduler) handlerRefreshLocation(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.cfg.respondWithError(w, http.StatusMethodNotAllowed, "Method Not Allowed", nil)
+		return
+	}
+
+	location, err := s.cfg.getLocationFromRequest(r)
+	if err != nil {
+		s.cfg.respondWithError(w, http.StatusBadRequest, "Error getting location data", err)
+		return
+	}
+
+	// The stored data of each forecast type is replaced in one transaction once its fetch has
+	// succeeded, so a failed fetch keeps the old data. The refresh is finished even if the caller
+	// goes away.
+	results := s.refreshLocation(context.WithoutCancel(r.Context()), location)
+
+	failed := 0
+	for _, status := range results {
+		if status == refreshStatusFailed {
+			failed++
+		}
+	}
+	if failed == len(results) {
+		s.cfg.respondWithError(w, http.StatusBadGateway, "Failed to refresh location data", nil)
+		return
+	}
+
+	s.cfg.respondWithJSON(w, http.StatusOK, RefreshResponse{
+		Location: location,
+		Results:  results,
+	})
+}
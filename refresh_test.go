@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/cor0nius/willitrain/internal/database"
+)
+
+func TestHandlerRefreshLocation(t *testing.T) {
+	ometeoData, _ := os.ReadFile("testdata/current_weather_ometeo.json")
+
+	// Only Open-Meteo's current weather answers, so the forecast updates fail.
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !r.URL.Query().Has("current") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write(ometeoData)
+	}))
+	defer mockServer.Close()
+
+	testCases := []struct {
+		name       string
+		method     string
+		query      string
+		httpClient *http.Client
+		wantStatus int
+		wantBody   string
+		wantPurge  bool
+	}{
+		{
+			name:       "Current weather refreshed",
+			method:     http.MethodPost,
+			query:      "?city=Wroclaw",
+			httpClient: mockServer.Client(),
+			wantStatus: http.StatusOK,
+			wantBody:   `"results":{"current weather":"updated","daily forecast":"failed","hourly forecast":"failed"}`,
+			wantPurge:  true,
+		},
+		{
+			name:       "All providers failing",
+			method:     http.MethodPost,
+			query:      "?city=Wroclaw",
+			httpClient: &http.Client{Transport: &errorTransport{Err: errors.New("API error")}},
+			wantStatus: http.StatusBadGateway,
+			wantBody:   `{"error":"Failed to refresh location data"}`,
+			wantPurge:  true,
+		},
+		{
+			name:       "Missing location",
+			method:     http.MethodPost,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"Error getting location data"}`,
+		},
+		{
+			name:       "Wrong method",
+			method:     http.MethodGet,
+			query:      "?city=Wroclaw",
+			wantStatus: http.StatusMethodNotAllowed,
+			wantBody:   `{"error":"Method Not Allowed"}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testCfg := newTestAPIConfig(t)
+			testCfg.mockDB.GetLocationByAliasFunc = func(ctx context.Context, alias string) (database.Location, error) {
+				return MockDBLocation, nil
+			}
+			testCfg.mockDB.GetCurrentWeatherAtLocationFromAPIFunc = func(ctx context.Context, arg database.GetCurrentWeatherAtLocationFromAPIParams) (database.CurrentWeather, error) {
+				return database.CurrentWeather{}, sql.ErrNoRows
+			}
+			var purged []string
+			testCfg.mockCache.DeleteFunc = func(ctx context.Context, keys ...string) error {
+				purged = append(purged, keys...)
+				return nil
+			}
+			if tc.httpClient != nil {
+				testCfg.apiConfig.httpClient = tc.httpClient
+			}
+			testCfg.apiConfig.ometeoWeatherURL = mockServer.URL + "/ometeo?"
+
+			req := httptest.NewRequest(tc.method, "/api/v1/refresh"+tc.query, nil)
+			rr := httptest.NewRecorder()
+			NewScheduler(testCfg.apiConfig).handlerRefreshLocation(rr, req)
+
+			if rr.Code != tc.wantStatus {
+				t.Errorf("expected status %d, got %d", tc.wantStatus, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), tc.wantBody) {
+				t.Errorf("expected body to contain %s, got %s", tc.wantBody, rr.Body.String())
+			}
+			if tc.wantPurge && !strings.Contains(strings.Join(purged, " "), MockLocation.LocationID.String()) {
+				t.Errorf("expected the location's cache entries to be purged, got %v", purged)
+			}
+			if !tc.wantPurge && len(purged) > 0 {
+				t.Errorf("expected no cache purge, got %v", purged)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// This file implements the request coalescing of the read path. When many clients ask for the
+// same location at once and the Redis cache has no entry for it, every request would otherwise
+// read the database and, with stale data, fetch from every provider. getCachedOrFetch instead
+// runs the lookup through a flightGroup keyed by the cache key: the first request does the work,
+// and the requests arriving while it runs wait for its result.
+
+// flightGroup deduplicates concurrent calls with the same key. It wraps a singleflight.Group so
+// that callers can stop waiting when their context is cancelled. A nil group is valid and runs
+// every call on its own.
+type flightGroup struct {
+	group singleflight.Group
+}
+
+func newFlightGroup() *flightGroup {
+	return &flightGroup{}
+}
+
+// do runs fn unless a call with the same key is already running, in which case it waits for that
+// call and returns its result. shared reports whether the result came from another call. A
+// caller whose context is cancelled stops waiting, but the running call is not cancelled: fn
+// must not depend on the context of the request that started it. A panic in fn is returned as
+// an error to every caller waiting for it.
+func (g *flightGroup) do(ctx context.Context, key string, fn func() (any, error)) (val any, shared bool, err error) {
+	if g == nil {
+		val, err = fn()
+		return val, false, err
+	}
+
+	// The call runs in its own goroutine, where a panic could not be recovered by the caller.
+	var ran atomic.Bool
+	ch := g.group.DoChan(key, func() (val any, err error) {
+		ran.Store(true)
+		defer func() {
+			if r := recover(); r != nil {
+				val, err = nil, fmt.Errorf("coalesced call for %s panicked: %v", key, r)
+			}
+		}()
+		return fn()
+	})
+	select {
+	case res := <-ch:
+		return res.Val, !ran.Load(), res.Err
+	case <-ctx.Done():
+		return nil, !ran.Load(), ctx.Err()
+	}
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cor0nius/willitrain/internal/database"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestFlightGroup(t *testing.T) {
+	t.Run("nil group runs every call", func(t *testing.T) {
+		var g *flightGroup
+		val, shared, err := g.do(context.Background(), "key", func() (any, error) { return 1, nil })
+		if val != 1 || shared || err != nil {
+			t.Errorf("expected an unshared result, got %v, %v, %v", val, shared, err)
+		}
+	})
+
+	t.Run("concurrent calls share one result", func(t *testing.T) {
+		g := newFlightGroup()
+		release := make(chan struct{})
+		started := make(chan struct{})
+		var runs atomic.Int32
+		fn := func() (any, error) {
+			runs.Add(1)
+			close(started)
+			<-release
+			return "result", nil
+		}
+
+		var wg sync.WaitGroup
+		var sharedCount atomic.Int32
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if val, _, err := g.do(context.Background(), "key", fn); val != "result" || err != nil {
+				t.Errorf("unexpected result %v, %v", val, err)
+			}
+		}()
+		<-started
+		for range 5 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				val, shared, err := g.do(context.Background(), "key", fn)
+				if val != "result" || err != nil {
+					t.Errorf("unexpected result %v, %v", val, err)
+				}
+				if shared {
+					sharedCount.Add(1)
+				}
+			}()
+		}
+		time.Sleep(20 * time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		if n := runs.Load(); n != 1 {
+			t.Errorf("expected 1 run, got %d", n)
+		}
+		if n := sharedCount.Load(); n != 5 {
+			t.Errorf("expected 5 shared results, got %d", n)
+		}
+		if _, shared, _ := g.do(context.Background(), "key", func() (any, error) { return nil, nil }); shared {
+			t.Error("expected a completed call not to be shared with later calls")
+		}
+	})
+
+	t.Run("cancelled waiter stops waiting", func(t *testing.T) {
+		g := newFlightGroup()
+		release := make(chan struct{})
+		started := make(chan struct{})
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			_, _, _ = g.do(context.Background(), "key", func() (any, error) {
+				close(started)
+				<-release
+				return nil, nil
+			})
+		}()
+		<-started
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if _, _, err := g.do(ctx, "key", func() (any, error) { return nil, nil }); !errors.Is(err, context.Canceled) {
+			t.Errorf("expected context.Canceled, got %v", err)
+		}
+		close(release)
+		<-done
+	})
+
+	t.Run("panic is returned to every caller", func(t *testing.T) {
+		g := newFlightGroup()
+		release := make(chan struct{})
+		started := make(chan struct{})
+		errs := make(chan error, 2)
+		go func() {
+			_, _, err := g.do(context.Background(), "key", func() (any, error) {
+				close(started)
+				<-release
+				panic("boom")
+			})
+			errs <- err
+		}()
+		<-started
+		go func() {
+			_, _, err := g.do(context.Background(), "key", func() (any, error) { return nil, nil })
+			errs <- err
+		}()
+		time.Sleep(20 * time.Millisecond)
+		close(release)
+
+		for range 2 {
+			if err := <-errs; err == nil || !strings.Contains(err.Error(), "panicked: boom") {
+				t.Errorf("expected the panic as an error, got %v", err)
+			}
+		}
+		// The key is released, so the next call runs again.
+		if val, shared, err := g.do(context.Background(), "key", func() (any, error) { return 1, nil }); val != 1 || shared || err != nil {
+			t.Errorf("expected a new call after the panic, got %v, %v, %v", val, shared, err)
+		}
+	})
+}
+
+func TestGetCachedOrFetch_Coalescing(t *testing.T) {
+	const requests = 10
+
+	var mu sync.Mutex
+	upstream := make(map[string]int)
+	handler := createWeatherAPIHandler(t, "current_weather")
+	server := setupMockServer(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		for _, provider := range []string{"gmp", "owm", "ometeo"} {
+			if strings.Contains(r.URL.Path, provider) {
+				upstream[provider]++
+			}
+		}
+		mu.Unlock()
+		handler(w, r)
+	})
+	defer server.Close()
+
+	testCfg := newTestAPIConfig(t)
+	testCfg.gmpWeatherURL = server.URL + "/gmp"
+	testCfg.owmWeatherURL = server.URL + "/owm"
+	testCfg.ometeoWeatherURL = server.URL + "/ometeo"
+	testCfg.httpClient = server.Client()
+	testCfg.enabledSources = map[string]bool{"gmp": true, "owm": true, "ometeo": true}
+	testCfg.inflight = newFlightGroup()
+
+	location := Location{LocationID: uuid.New(), CityName: "Testville", Latitude: 51.11, Longitude: 17.04}
+
+	// The database read of the first request is held until every request has missed Redis.
+	var misses sync.WaitGroup
+	misses.Add(requests)
+	weatherKey := testCfg.weatherCacheKey(currentWeatherCacheKeyPrefix, location.LocationID)
+	testCfg.mockCache.GetFunc = func(ctx context.Context, key string) (string, error) {
+		if key == weatherKey {
+			misses.Done()
+		}
+		return "", redis.Nil
+	}
+	testCfg.mockDB.GetCurrentWeatherAtLocationFunc = func(ctx context.Context, locationID uuid.UUID) ([]database.CurrentWeather, error) {
+		misses.Wait()
+		time.Sleep(20 * time.Millisecond)
+		return nil, sql.ErrNoRows
+	}
+	testCfg.mockDB.GetCurrentWeatherAtLocationFromAPIFunc = func(ctx context.Context, arg database.GetCurrentWeatherAtLocationFromAPIParams) (database.CurrentWeather, error) {
+		return database.CurrentWeather{}, sql.ErrNoRows
+	}
+	testCfg.mockDB.UpdateTimezoneFunc = func(ctx context.Context, arg database.UpdateTimezoneParams) error {
+		return nil
+	}
+
+	var wg sync.WaitGroup
+	for range requests {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			weather, err := testCfg.apiConfig.getCachedOrFetchCurrentWeather(context.Background(), location)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+			if len(weather) != 3 {
+				t.Errorf("expected 3 weather items, got %d", len(weather))
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := testCfg.mockDB.Calls("GetCurrentWeatherAtLocation"); n != 1 {
+		t.Errorf("expected 1 database read, got %d", n)
+	}
+	if n := testCfg.mockDB.Calls("CreateCurrentWeather"); n != 3 {
+		t.Errorf("expected 3 persisted items, got %d", n)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	for _, provider := range []string{"gmp", "owm", "ometeo"} {
+		if upstream[provider] != 1 {
+			t.Errorf("expected 1 request to %s, got %d", provider, upstream[provider])
+		}
+	}
+}
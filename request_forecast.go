@@ -2,14 +2,11 @@ package main
 
 import (
 	"context"
-	"database/sql"
 	"errors"
 	"io"
 	"log/slog"
 	"sync"
 	"time"
-
-	"github.com/cor0nius/willitrain/internal/database"
 )
 
 // This file contains the high-level logic for fetching weather forecasts.
@@ -19,9 +16,11 @@ import (
 // The request... functions are the main entry points for fetching a specific type of forecast.
 // Each function prepares the necessary URLs and provider configurations for its forecast type
 // (current, daily, or hourly) and then passes them to the generic processForecastRequests function
-// to handle the concurrent API calls. They also handle post-processing, such as updating
-// the location's timezone in the database if it's discovered during the fetch.
-func (cfg *apiConfig) requestCurrentWeather(location Location) ([]CurrentWeather, error) {
+// to handle the concurrent API calls. They also handle post-processing, such as reconciling
+// the location's timezone with the one reported by the providers. A non-nil onLate hedges the
+// fetch and receives the data of providers that responded after the results were returned.
+// A non-nil onOutcome receives the outcome of each provider.
+func (cfg *apiConfig) requestCurrentWeather(ctx context.Context, location Location, onLate func([]CurrentWeather), onOutcome func(providerFetchOutcome)) ([]CurrentWeather, error) {
 	urls := cfg.WrapForCurrentWeather(location)
 
 	providers := map[string]forecastProvider[CurrentWeather]{
@@ -29,30 +28,31 @@ func (cfg *apiConfig) requestCurrentWeather(location Location) ([]CurrentWeather
 			parser:   ParseCurrentWeatherGMP,
 			errorVal: CurrentWeather{SourceAPI: "Google Weather API"},
 		},
-		"owmWrappedURL": {
-			parser:   ParseCurrentWeatherOWM,
-			errorVal: CurrentWeather{SourceAPI: "OpenWeatherMap API"},
-		},
+		"owmWrappedURL": owmForecastProvider(cfg, location, owmCurrent, ParseCurrentWeatherOWM, ParseCurrentWeatherOWM25, CurrentWeather{SourceAPI: "OpenWeatherMap API"}),
 		"ometeoWrappedURL": {
 			parser:   ParseCurrentWeatherOMeteo,
 			errorVal: CurrentWeather{SourceAPI: "Open-Meteo API"},
 		},
+		"metnoWrappedURL": {
+			parser:   ParseCurrentWeatherMetNo,
+			errorVal: CurrentWeather{SourceAPI: "Met.no API"},
+		},
 	}
 
-	results, tz, err := processForecastRequests(cfg, urls, providers)
+	var late func(CurrentWeather)
+	if onLate != nil {
+		late = func(w CurrentWeather) {
+			w.Location = location
+			onLate([]CurrentWeather{w})
+		}
+	}
+
+	results, tz, err := processForecastRequests(cfg, ctx, location, urls, providers, late, onOutcome)
 	if err != nil {
 		return nil, err
 	}
 
-	if tz != "" && location.Timezone == "" {
-		params := database.UpdateTimezoneParams{
-			ID:       location.LocationID,
-			Timezone: sql.NullString{String: tz, Valid: true},
-		}
-		if err := cfg.dbQueries.UpdateTimezone(context.Background(), params); err != nil {
-			cfg.logger.Warn("failed to update timezone", "location", location.CityName, "error", err)
-		}
-	}
+	cfg.reconcileTimezone(ctx, location, tz)
 
 	for i := range results {
 		results[i].Location = location
@@ -61,39 +61,44 @@ func (cfg *apiConfig) requestCurrentWeather(location Location) ([]CurrentWeather
 	return results, nil
 }
 
-func (cfg *apiConfig) requestDailyForecast(location Location) ([]DailyForecast, error) {
+func (cfg *apiConfig) requestDailyForecast(ctx context.Context, location Location, onLate func([]DailyForecast), onOutcome func(providerFetchOutcome)) ([]DailyForecast, error) {
 	fetchedAt := time.Now().UTC()
+	days := cfg.dailyForecastDays()
 	urls := cfg.WrapForDailyForecast(location)
 
 	providers := map[string]forecastProvider[[]DailyForecast]{
 		"gmpWrappedURL": {
-			parser:   ParseDailyForecastGMP,
+			parser:   withHorizon(ParseDailyForecastGMP, days),
 			errorVal: []DailyForecast{{SourceAPI: "Google Weather API"}},
 		},
-		"owmWrappedURL": {
-			parser:   ParseDailyForecastOWM,
-			errorVal: []DailyForecast{{SourceAPI: "OpenWeatherMap API"}},
-		},
+		"owmWrappedURL": owmForecastProvider(cfg, location, owmDaily, withHorizon(ParseDailyForecastOWM, days), withHorizon(ParseDailyForecastOWM25, days), []DailyForecast{{SourceAPI: "OpenWeatherMap API"}}),
 		"ometeoWrappedURL": {
-			parser:   ParseDailyForecastOMeteo,
+			parser:   withHorizon(ParseDailyForecastOMeteo, days),
 			errorVal: []DailyForecast{{SourceAPI: "Open-Meteo API"}},
 		},
+		"metnoWrappedURL": {
+			parser:   withHorizon(ParseDailyForecastMetNo, days),
+			errorVal: []DailyForecast{{SourceAPI: "Met.no API"}},
+		},
 	}
 
-	results, tz, err := processForecastRequests(cfg, urls, providers)
+	var late func([]DailyForecast)
+	if onLate != nil {
+		late = func(forecasts []DailyForecast) {
+			for i := range forecasts {
+				forecasts[i].Location = location
+				forecasts[i].Timestamp = fetchedAt
+			}
+			onLate(forecasts)
+		}
+	}
+
+	results, tz, err := processForecastRequests(cfg, ctx, location, urls, providers, late, onOutcome)
 	if err != nil {
 		return nil, err
 	}
 
-	if tz != "" && location.Timezone == "" {
-		params := database.UpdateTimezoneParams{
-			ID:       location.LocationID,
-			Timezone: sql.NullString{String: tz, Valid: true},
-		}
-		if err := cfg.dbQueries.UpdateTimezone(context.Background(), params); err != nil {
-			cfg.logger.Warn("failed to update timezone", "location", location.CityName, "error", err)
-		}
-	}
+	cfg.reconcileTimezone(ctx, location, tz)
 
 	var allForecasts []DailyForecast
 	for _, forecastSlice := range results {
@@ -108,39 +113,44 @@ func (cfg *apiConfig) requestDailyForecast(location Location) ([]DailyForecast,
 	return allForecasts, nil
 }
 
-func (cfg *apiConfig) requestHourlyForecast(location Location) ([]HourlyForecast, error) {
+func (cfg *apiConfig) requestHourlyForecast(ctx context.Context, location Location, onLate func([]HourlyForecast), onOutcome func(providerFetchOutcome)) ([]HourlyForecast, error) {
 	fetchedAt := time.Now().UTC()
+	hours := cfg.hourlyForecastHours()
 	urls := cfg.WrapForHourlyForecast(location)
 
 	providers := map[string]forecastProvider[[]HourlyForecast]{
 		"gmpWrappedURL": {
-			parser:   ParseHourlyForecastGMP,
+			parser:   withHorizon(ParseHourlyForecastGMP, hours),
 			errorVal: []HourlyForecast{{SourceAPI: "Google Weather API"}},
 		},
-		"owmWrappedURL": {
-			parser:   ParseHourlyForecastOWM,
-			errorVal: []HourlyForecast{{SourceAPI: "OpenWeatherMap API"}},
-		},
+		"owmWrappedURL": owmForecastProvider(cfg, location, owmHourly, withHorizon(ParseHourlyForecastOWM, hours), withHorizon(ParseHourlyForecastOWM25, hours), []HourlyForecast{{SourceAPI: "OpenWeatherMap API"}}),
 		"ometeoWrappedURL": {
-			parser:   ParseHourlyForecastOMeteo,
+			parser:   withHorizon(ParseHourlyForecastOMeteo, hours),
 			errorVal: []HourlyForecast{{SourceAPI: "Open-Meteo API"}},
 		},
+		"metnoWrappedURL": {
+			parser:   withHorizon(ParseHourlyForecastMetNo, hours),
+			errorVal: []HourlyForecast{{SourceAPI: "Met.no API"}},
+		},
 	}
 
-	results, tz, err := processForecastRequests(cfg, urls, providers)
+	var late func([]HourlyForecast)
+	if onLate != nil {
+		late = func(forecasts []HourlyForecast) {
+			for i := range forecasts {
+				forecasts[i].Location = location
+				forecasts[i].Timestamp = fetchedAt
+			}
+			onLate(forecasts)
+		}
+	}
+
+	results, tz, err := processForecastRequests(cfg, ctx, location, urls, providers, late, onOutcome)
 	if err != nil {
 		return nil, err
 	}
 
-	if tz != "" && location.Timezone == "" {
-		params := database.UpdateTimezoneParams{
-			ID:       location.LocationID,
-			Timezone: sql.NullString{String: tz, Valid: true},
-		}
-		if err := cfg.dbQueries.UpdateTimezone(context.Background(), params); err != nil {
-			cfg.logger.Warn("failed to update timezone", "location", location.CityName, "error", err)
-		}
-	}
+	cfg.reconcileTimezone(ctx, location, tz)
 
 	var allForecasts []HourlyForecast
 	for _, forecastSlice := range results {
@@ -157,11 +167,24 @@ func (cfg *apiConfig) requestHourlyForecast(location Location) ([]HourlyForecast
 
 // processForecastRequests is a generic function that manages the concurrent fetching of forecasts.
 // It takes a map of URLs and a corresponding map of providers, launches a goroutine for each,
-// waits for them to complete, and then aggregates the results.
+// waits for them to complete, and then aggregates the results. Providers disabled through
+// WEATHER_SOURCES are skipped, and so are hourly fetches from providers that the quota policy
+// reserves for priority locations, unless that would skip every provider, and all fetches from
+// providers that have used up their daily quota or whose circuit is open. Every call and failure is recorded against the location in the
+// usage tracker for cost reporting. The returned timezone is the one reported by most providers;
+// disagreements are logged and counted.
+//
+// If onLate is not nil, the fetch is hedged: once a provider has responded, the others are only
+// waited for until their hedge deadline, and results that arrive later are passed to onLate.
+// If onOutcome is not nil, it receives the outcome of every provider as its result arrives.
 func processForecastRequests[T Forecast](
 	cfg *apiConfig,
+	ctx context.Context,
+	location Location,
 	urls map[string]string,
 	providers map[string]forecastProvider[T],
+	onLate func(T),
+	onOutcome func(providerFetchOutcome),
 ) ([]T, string, error) {
 	var wg sync.WaitGroup
 	results := make(chan struct {
@@ -170,12 +193,46 @@ func processForecastRequests[T Forecast](
 		err error
 	}, len(urls))
 
+	var quotaSkips map[string]bool
+	if isHourlyForecast[T]() {
+		if skips, all := cfg.hourlyQuotaSkips(location); !all {
+			quotaSkips = skips
+		}
+	}
+
+	started := time.Now()
+	pending := make(map[string]bool)
+	cfg.usage.recordOperation(location)
 	for key, url := range urls {
+		if p, ok := providerByURLKey(key); ok && !cfg.sourceEnabled(p.ID) {
+			cfg.logger.DebugContext(ctx, "skipping disabled provider", "provider", p.ID)
+			continue
+		} else if ok && quotaSkips[p.ID] {
+			cfg.logger.DebugContext(ctx, "skipping provider low on quota", "provider", p.ID, "location", location.CityName)
+			quotaSkippedFetches.WithLabelValues(p.ID).Inc()
+			continue
+		} else if ok && cfg.quota.exhausted(p.ID) {
+			cfg.logger.DebugContext(ctx, "skipping provider out of quota", "provider", p.ID, "location", location.CityName)
+			rateLimitedFetches.WithLabelValues(p.ID, "skipped").Inc()
+			continue
+		} else if ok && !cfg.breakers.allow(p.ID) {
+			cfg.logger.DebugContext(ctx, "skipping provider with open circuit", "provider", p.ID, "location", location.CityName)
+			continue
+		}
 		if provider, ok := providers[key]; ok {
+			if p, ok := providerByDisplayName(forecastSourceAPI(provider.errorVal)); ok {
+				cfg.usage.recordCall(location, p.ID)
+				cfg.quota.recordCall(p.ID)
+				pending[p.ID] = true
+			}
 			wg.Add(1)
-			go fetchForecastFromAPI(cfg, url, provider.parser, provider.errorVal, &wg, results)
+			if provider.fallback != nil {
+				go fetchForecastWithFallback(cfg, ctx, url, provider, &wg, results)
+			} else {
+				go fetchForecastFromAPI(cfg, ctx, url, provider.parser, provider.errorVal, &wg, results)
+			}
 		} else {
-			cfg.logger.Error("no provider found for key", "key", key)
+			cfg.logger.ErrorContext(ctx, "no provider found for key", "key", key)
 		}
 	}
 
@@ -184,39 +241,89 @@ func processForecastRequests[T Forecast](
 		close(results)
 	}()
 
-	var allResults []T
-	var timezone string
-	for res := range results {
-		if res.err != nil {
-			var sourceAPI string
-			v := any(res.t)
-			switch v := v.(type) {
-			case CurrentWeather:
-				sourceAPI = v.SourceAPI
-			case []DailyForecast:
-				if len(v) > 0 {
-					sourceAPI = v[0].SourceAPI
+	// handle records a result and reports whether it was successful.
+	handle := func(res struct {
+		t   T
+		tz  string
+		err error
+	}) bool {
+		sourceAPI := forecastSourceAPI(res.t)
+		p, known := providerByDisplayName(sourceAPI)
+		if known {
+			delete(pending, p.ID)
+			outcomeLabel := "ok"
+			if res.err != nil {
+				outcomeLabel = "error"
+			}
+			providerFetchDuration.WithLabelValues(p.ID, forecastTypeLabel[T](), outcomeLabel).Observe(time.Since(started).Seconds())
+			if onOutcome != nil {
+				outcome := providerFetchOutcome{ProviderID: p.ID, Duration: time.Since(started), Err: res.err}
+				if res.err == nil {
+					outcome.Rows, outcome.HoursCovered = forecastCoverage(res.t)
 				}
-			case []HourlyForecast:
-				if len(v) > 0 {
-					sourceAPI = v[0].SourceAPI
+				onOutcome(outcome)
+			}
+		}
+		if res.err == nil {
+			return true
+		}
+		if known {
+			cfg.usage.recordFailure(location, p.ID)
+		}
+		if sourceAPI != "" {
+			cfg.logger.WarnContext(ctx, "error fetching forecast from provider", "provider", sourceAPI, "error", res.err)
+		} else {
+			cfg.logger.WarnContext(ctx, "error fetching forecast from unknown provider", "error", res.err)
+		}
+		return false
+	}
+
+	var allResults []T
+	reportedTimezones := make(map[string]string)
+	var hedge <-chan time.Time
+collect:
+	for {
+		select {
+		case res, ok := <-results:
+			if !ok {
+				break collect
+			}
+			if handle(res) {
+				allResults = append(allResults, res.t)
+				if res.tz != "" {
+					reportedTimezones[forecastSourceAPI(res.t)] = res.tz
 				}
 			}
-			if sourceAPI != "" {
-				cfg.logger.Warn("error fetching forecast from provider", "provider", sourceAPI, "error", res.err)
-			} else {
-				cfg.logger.Warn("error fetching forecast from unknown provider", "error", res.err)
+			if onLate != nil && len(allResults) > 0 {
+				hedge = nil
+				if deadline, ok := cfg.hedgeDeadline(pending); ok {
+					hedge = time.After(time.Until(started.Add(deadline)))
+				}
 			}
-		} else {
-			allResults = append(allResults, res.t)
-			if timezone == "" && res.tz != "" {
-				timezone = res.tz
+		case <-hedge:
+			for id := range pending {
+				cfg.logger.InfoContext(ctx, "serving forecast without slow provider", "provider", id, "location", location.CityName)
+				hedgedFetches.WithLabelValues(id).Inc()
 			}
+			go func() {
+				for res := range results {
+					if handle(res) {
+						onLate(res.t)
+					}
+				}
+			}()
+			break collect
 		}
 	}
 
+	timezone, disagree := consensusTimezone(reportedTimezones)
+	if disagree {
+		cfg.logger.WarnContext(ctx, "providers reported different timezones", "location", location.CityName, "timezones", reportedTimezones)
+		timezoneDisagreements.Inc()
+	}
+
 	if len(allResults) == 0 {
-		cfg.logger.Error("all forecast fetches failed")
+		cfg.logger.ErrorContext(ctx, "all forecast fetches failed")
 		return nil, "", errors.New("all forecast fetches failed")
 	}
 
@@ -225,7 +332,76 @@ func processForecastRequests[T Forecast](
 
 // forecastProvider is a helper struct that bundles a parser function with its corresponding zero-value.
 // This allows the generic fetcher to know which parser to use for a given API response.
+// An optional fallback is queried instead when the provider rejects the request as unauthorized.
 type forecastProvider[T Forecast] struct {
 	parser   func(io.Reader, *slog.Logger) (T, string, error)
 	errorVal T
+	fallback *forecastFallback[T]
+}
+
+// forecastFallback describes an alternative endpoint of a provider and the parser for its
+// response format. onSwitch, if set, is called before the fallback is queried.
+type forecastFallback[T Forecast] struct {
+	url      string
+	parser   func(io.Reader, *slog.Logger) (T, string, error)
+	onSwitch func()
+}
+
+// isHourlyForecast reports whether T is the hourly forecast type.
+func isHourlyForecast[T Forecast]() bool {
+	var t T
+	_, ok := any(t).([]HourlyForecast)
+	return ok
+}
+
+// forecastTypeLabel returns the forecast type of T as used in metric labels.
+func forecastTypeLabel[T Forecast]() string {
+	var t T
+	switch any(t).(type) {
+	case CurrentWeather:
+		return "current"
+	case []DailyForecast:
+		return "daily"
+	case []HourlyForecast:
+		return "hourly"
+	case AirQuality:
+		return "airquality"
+	}
+	return ""
+}
+
+// forecastCoverage returns the number of rows in a forecast value and the number of hours they cover.
+// Current weather and air quality cover no forecast hours.
+func forecastCoverage[T Forecast](t T) (rows, hours int) {
+	switch v := any(t).(type) {
+	case CurrentWeather:
+		return 1, 0
+	case []DailyForecast:
+		return len(v), 24 * len(v)
+	case []HourlyForecast:
+		return len(v), len(v)
+	case AirQuality:
+		return 1, 0
+	}
+	return 0, 0
+}
+
+// forecastSourceAPI returns the SourceAPI of a forecast value, or of the first element
+// for slice types. It returns an empty string if the value carries no source.
+func forecastSourceAPI[T Forecast](t T) string {
+	switch v := any(t).(type) {
+	case CurrentWeather:
+		return v.SourceAPI
+	case []DailyForecast:
+		if len(v) > 0 {
+			return v[0].SourceAPI
+		}
+	case []HourlyForecast:
+		if len(v) > 0 {
+			return v[0].SourceAPI
+		}
+	case AirQuality:
+		return v.SourceAPI
+	}
+	return ""
 }
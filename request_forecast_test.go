@@ -10,6 +10,7 @@ import (
 	"net/http/httptest"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 
 	"github.com/cor0nius/willitrain/internal/database"
@@ -99,7 +100,7 @@ func TestFetchForecastFromAPI(t *testing.T) {
 
 			wg.Add(1)
 			errorVal := CurrentWeather{SourceAPI: "TestAPI"}
-			go fetchForecastFromAPI(cfg, url, tc.parser, errorVal, &wg, results)
+			go fetchForecastFromAPI(cfg, context.Background(), url, tc.parser, errorVal, &wg, results)
 
 			res := <-results
 			wg.Wait()
@@ -225,7 +226,7 @@ func TestProcessForecastRequests(t *testing.T) {
 				httpClient: http.DefaultClient,
 			}
 
-			results, tz, err := processForecastRequests(cfg, tc.urls, tc.providers)
+			results, tz, err := processForecastRequests(cfg, context.Background(), MockLocation, tc.urls, tc.providers, nil, nil)
 
 			if (err != nil) != tc.expectError {
 				t.Errorf("Expected error: %v, got: %v", tc.expectError, err)
@@ -255,6 +256,42 @@ func TestProcessForecastRequests(t *testing.T) {
 	}
 }
 
+func TestProcessForecastRequestsSkipsDisabledSources(t *testing.T) {
+	var hits atomic.Int32
+	server := setupMockServer(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"temp": 25.0}`))
+	})
+	defer server.Close()
+
+	urls := map[string]string{
+		"gmpWrappedURL":    server.URL,
+		"ometeoWrappedURL": server.URL,
+	}
+	providers := map[string]forecastProvider[CurrentWeather]{
+		"gmpWrappedURL":    {parser: mockParserSuccess, errorVal: CurrentWeather{SourceAPI: "Google Weather API"}},
+		"ometeoWrappedURL": {parser: mockParserSuccess, errorVal: CurrentWeather{SourceAPI: "Open-Meteo API"}},
+	}
+
+	cfg := &apiConfig{
+		logger:         slog.New(slog.NewTextHandler(io.Discard, nil)),
+		httpClient:     http.DefaultClient,
+		enabledSources: map[string]bool{"ometeo": true},
+	}
+
+	results, _, err := processForecastRequests(cfg, context.Background(), MockLocation, urls, providers, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 1 {
+		t.Errorf("expected 1 result, got %d", len(results))
+	}
+	if got := hits.Load(); got != 1 {
+		t.Errorf("expected 1 provider call, got %d", got)
+	}
+}
+
 func TestRequestWeatherFunctions(t *testing.T) {
 	location := Location{LocationID: uuid.New(), CityName: "Testville"}
 
@@ -274,7 +311,7 @@ func TestRequestWeatherFunctions(t *testing.T) {
 			name:           "requestCurrentWeather - All providers fail",
 			functionToTest: "current",
 			setupMocks: func(cfg *testAPIConfig) {
-				cfg.apiConfig.httpClient = &http.Client{Transport: &errorTransport{err: errors.New("network error")}} 
+				cfg.apiConfig.httpClient = &http.Client{Transport: &errorTransport{Err: errors.New("network error")}} 
 			},
 			check: func(t *testing.T, err error) {
 				if err == nil {
@@ -286,7 +323,7 @@ func TestRequestWeatherFunctions(t *testing.T) {
 			name:           "requestDailyForecast - All providers fail",
 			functionToTest: "daily",
 			setupMocks: func(cfg *testAPIConfig) {
-				cfg.apiConfig.httpClient = &http.Client{Transport: &errorTransport{err: errors.New("network error")}} 
+				cfg.apiConfig.httpClient = &http.Client{Transport: &errorTransport{Err: errors.New("network error")}} 
 			},
 			check: func(t *testing.T, err error) {
 				if err == nil {
@@ -298,7 +335,7 @@ func TestRequestWeatherFunctions(t *testing.T) {
 			name:           "requestHourlyForecast - All providers fail",
 			functionToTest: "hourly",
 			setupMocks: func(cfg *testAPIConfig) {
-				cfg.apiConfig.httpClient = &http.Client{Transport: &errorTransport{err: errors.New("network error")}} 
+				cfg.apiConfig.httpClient = &http.Client{Transport: &errorTransport{Err: errors.New("network error")}} 
 			},
 			check: func(t *testing.T, err error) {
 				if err == nil {
@@ -356,15 +393,15 @@ func TestRequestWeatherFunctions(t *testing.T) {
 			testCfg.apiConfig.gmpWeatherURL = serverSuccess.URL + "/gmp"
 			testCfg.apiConfig.owmWeatherURL = serverSuccess.URL + "/owm"
 			testCfg.apiConfig.ometeoWeatherURL = serverSuccess.URL + "/ometeo"
-			testCfg.apiConfig.gmpKey = "dummy"
-			testCfg.apiConfig.owmKey = "dummy"
+			testCfg.apiConfig.gmpKey = newSecretValue("dummy")
+			testCfg.apiConfig.owmKey = newSecretValue("dummy")
 
 			tc.setupMocks(testCfg)
 
 			var err error
 			switch tc.functionToTest {
 			case "current":
-				_, err = testCfg.apiConfig.requestCurrentWeather(location)
+				_, err = testCfg.apiConfig.requestCurrentWeather(context.Background(), location, nil, nil)
 			case "daily":
 				// We need a different handler for daily/hourly to ensure parsers don't fail
 				dailyHandler := createWeatherAPIHandler(t, "daily_forecast")
@@ -372,7 +409,7 @@ func TestRequestWeatherFunctions(t *testing.T) {
 				testCfg.apiConfig.gmpWeatherURL = dailyServer.URL + "/gmp"
 				testCfg.apiConfig.owmWeatherURL = dailyServer.URL + "/owm"
 				testCfg.apiConfig.ometeoWeatherURL = dailyServer.URL + "/ometeo"
-				_, err = testCfg.apiConfig.requestDailyForecast(location)
+				_, err = testCfg.apiConfig.requestDailyForecast(context.Background(), location, nil, nil)
 				dailyServer.Close()
 			case "hourly":
 			hourlyHandler := createWeatherAPIHandler(t, "hourly_forecast")
@@ -380,7 +417,7 @@ func TestRequestWeatherFunctions(t *testing.T) {
 			testCfg.apiConfig.gmpWeatherURL = hourlyServer.URL + "/gmp"
 			testCfg.apiConfig.owmWeatherURL = hourlyServer.URL + "/owm"
 			testCfg.apiConfig.ometeoWeatherURL = hourlyServer.URL + "/ometeo"
-			_, err = testCfg.apiConfig.requestHourlyForecast(location)
+			_, err = testCfg.apiConfig.requestHourlyForecast(context.Background(), location, nil, nil)
 			hourlyServer.Close()
 			default:
 				t.Fatalf("unknown function to test: %s", tc.functionToTest)
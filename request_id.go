@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// This file implements request IDs. Every request gets an ID, either the one the caller sent in
+// the X-Request-ID header or a newly generated one. The ID is sent back in the X-Request-ID
+// response header and in error responses, so that users can quote it in bug reports, and it is
+// added to every log line emitted while serving the request, so that those reports can be
+// matched with the logs.
+
+// requestIDHeader is the header a request ID is read from and echoed in.
+const requestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength limits the length of request IDs accepted from callers.
+const maxRequestIDLength = 128
+
+type requestIDKey struct{}
+
+// withRequestID returns a copy of ctx carrying a request ID.
+func withRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// requestIDFrom returns the request ID carried by ctx, or "" if it has none.
+func requestIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// validRequestID reports whether a request ID sent by a caller can be used as is. IDs are
+// echoed in headers and written to the logs, so only short IDs of printable ASCII are accepted.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < '!' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// requestIDMiddleware assigns an ID to every request. The ID sent by the caller is kept if it is
+// valid, so that a request can be followed through a proxy that assigns IDs itself; otherwise a
+// new one is generated. The ID is set on the response header before the handler runs, which
+// lets respondWithError find it.
+func requestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if !validRequestID(id) {
+			id = uuid.NewString()
+		}
+		w.Header().Set(requestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(withRequestID(r.Context(), id)))
+	})
+}
+
+// responseRequestID returns the request ID assigned to the response by requestIDMiddleware, or
+// "" if it has none.
+func responseRequestID(w http.ResponseWriter) string {
+	return w.Header().Get(requestIDHeader)
+}
+
+// requestIDHandler is a slog.Handler that adds the request ID carried by the context to each
+// record. It only sees the context of the logger's Context methods, such as DebugContext, so
+// code serving requests logs with those.
+type requestIDHandler struct {
+	slog.Handler
+}
+
+// Handle adds the request ID, if any, to the record and passes it on.
+func (h requestIDHandler) Handle(ctx context.Context, record slog.Record) error {
+	if id := requestIDFrom(ctx); id != "" {
+		record = record.Clone()
+		record.AddAttrs(slog.String("request_id", id))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+// WithAttrs returns a requestIDHandler wrapping the handler with the attributes added.
+func (h requestIDHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return requestIDHandler{h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup returns a requestIDHandler wrapping the handler with the group opened.
+func (h requestIDHandler) WithGroup(name string) slog.Handler {
+	return requestIDHandler{h.Handler.WithGroup(name)}
+}
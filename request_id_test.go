@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestRequestIDMiddleware(t *testing.T) {
+	testCases := []struct {
+		name     string
+		sent     string
+		expected string
+	}{
+		{name: "ID sent by the caller", sent: "abc-123", expected: "abc-123"},
+		{name: "no ID sent"},
+		{name: "ID with spaces", sent: "abc 123"},
+		{name: "ID too long", sent: strings.Repeat("a", maxRequestIDLength+1)},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var seen string
+			handler := requestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				seen = requestIDFrom(r.Context())
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tc.sent != "" {
+				req.Header.Set(requestIDHeader, tc.sent)
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if got := rr.Header().Get(requestIDHeader); got != seen {
+				t.Errorf("response header %q does not match the context's request ID %q", got, seen)
+			}
+			if tc.expected != "" {
+				if seen != tc.expected {
+					t.Errorf("expected request ID %q, got %q", tc.expected, seen)
+				}
+			} else if _, err := uuid.Parse(seen); err != nil {
+				t.Errorf("expected a generated UUID, got %q", seen)
+			}
+		})
+	}
+}
+
+func TestRequestIDInErrorsAndLogs(t *testing.T) {
+	var logBuffer bytes.Buffer
+	cfg := &apiConfig{logger: slog.New(requestIDHandler{slog.NewTextHandler(&logBuffer, &slog.HandlerOptions{Level: slog.LevelDebug})})}
+
+	handler := requestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cfg.logger.DebugContext(r.Context(), "serving request")
+		cfg.respondWithError(w, http.StatusInternalServerError, "Something went wrong", errors.New("boom"))
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(requestIDHeader, "abc-123")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	if expected := `{"error":"Something went wrong","request_id":"abc-123"}`; rr.Body.String() != expected {
+		t.Errorf("expected body %s, got %s", expected, rr.Body.String())
+	}
+
+	logs := strings.Split(strings.TrimSpace(logBuffer.String()), "\n")
+	if len(logs) != 2 {
+		t.Fatalf("expected 2 log lines, got %d: %s", len(logs), logBuffer.String())
+	}
+	for _, line := range logs {
+		if !strings.Contains(line, "request_id=abc-123") {
+			t.Errorf("expected log line to contain the request ID: %s", line)
+		}
+	}
+}
@@ -0,0 +1,360 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cor0nius/willitrain/internal/database"
+	"github.com/google/uuid"
+)
+
+// This file implements persistent request statistics. Requests are counted in memory per
+// endpoint and per location, bucketed by hour, and periodically added to the
+// endpoint_request_stats and location_request_stats tables by a scheduler job. Unlike the
+// Prometheus counters, the history survives restarts and can be queried through /admin/stats.
+// The same job records the last access time of every requested location in location_access,
+// which the scheduler uses to decide how often to refresh it.
+
+const (
+	requestStatsJobName       = "request stats"
+	requestStatsFlushInterval = 5 * time.Minute
+
+	defaultStatsWindowHours = 7 * 24
+	maxStatsWindowHours     = 90 * 24
+	defaultStatsLocations   = 20
+	maxStatsLocations       = 100
+)
+
+// requestStatsRecorder accumulates request counts until they are flushed to the database.
+// A nil recorder is valid and records nothing.
+type requestStatsRecorder struct {
+	mu        sync.Mutex
+	endpoints map[endpointStatKey]int64
+	locations map[locationStatKey]int64
+	accessed  map[uuid.UUID]time.Time
+}
+
+type endpointStatKey struct {
+	hour     time.Time
+	endpoint string
+}
+
+type locationStatKey struct {
+	hour       time.Time
+	locationID uuid.UUID
+	endpoint   string
+}
+
+func newRequestStatsRecorder() *requestStatsRecorder {
+	return &requestStatsRecorder{
+		endpoints: make(map[endpointStatKey]int64),
+		locations: make(map[locationStatKey]int64),
+		accessed:  make(map[uuid.UUID]time.Time),
+	}
+}
+
+// recordEndpoint counts one request to an endpoint.
+func (s *requestStatsRecorder) recordEndpoint(endpoint string, at time.Time) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.endpoints[endpointStatKey{hour: statsHour(at), endpoint: endpoint}]++
+}
+
+// recordLocation counts one request to an endpoint for a location.
+func (s *requestStatsRecorder) recordLocation(endpoint string, locationID uuid.UUID, at time.Time) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.locations[locationStatKey{hour: statsHour(at), locationID: locationID, endpoint: endpoint}]++
+	if at.After(s.accessed[locationID]) {
+		s.accessed[locationID] = at
+	}
+}
+
+// drain returns the accumulated counts and resets the recorder.
+func (s *requestStatsRecorder) drain() (map[endpointStatKey]int64, map[locationStatKey]int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	endpoints, locations := s.endpoints, s.locations
+	s.endpoints = make(map[endpointStatKey]int64)
+	s.locations = make(map[locationStatKey]int64)
+	return endpoints, locations
+}
+
+// drainAccessed returns the last access time of every location requested since the last call
+// and resets them.
+func (s *requestStatsRecorder) drainAccessed() map[uuid.UUID]time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	accessed := s.accessed
+	s.accessed = make(map[uuid.UUID]time.Time)
+	return accessed
+}
+
+// restoreAccessed puts access times that could not be flushed back into the recorder.
+func (s *requestStatsRecorder) restoreAccessed(accessed map[uuid.UUID]time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, at := range accessed {
+		if at.After(s.accessed[id]) {
+			s.accessed[id] = at
+		}
+	}
+}
+
+// restore adds counts that could not be flushed back to the recorder, so they are retried
+// on the next flush.
+func (s *requestStatsRecorder) restore(endpoints map[endpointStatKey]int64, locations map[locationStatKey]int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for k, v := range endpoints {
+		s.endpoints[k] += v
+	}
+	for k, v := range locations {
+		s.locations[k] += v
+	}
+}
+
+// statsHour truncates a time to the start of its hour in UTC.
+func statsHour(t time.Time) time.Time {
+	return t.UTC().Truncate(time.Hour)
+}
+
+// flushRequestStats adds the accumulated counts to the database and records the access times of
+// the requested locations. Counts and access times that fail to be written are kept in memory for
+// the next flush.
+func (cfg *apiConfig) flushRequestStats(ctx context.Context) error {
+	if cfg.requestStats == nil {
+		return nil
+	}
+	accessErr := cfg.flushLocationAccess(ctx)
+	endpoints, locations := cfg.requestStats.drain()
+	if len(endpoints) == 0 && len(locations) == 0 {
+		return accessErr
+	}
+
+	var firstErr error
+	for k, count := range endpoints {
+		err := cfg.dbQueries.IncrementEndpointRequestStats(ctx, database.IncrementEndpointRequestStatsParams{
+			Hour:         k.hour,
+			Endpoint:     k.endpoint,
+			RequestCount: count,
+		})
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		delete(endpoints, k)
+	}
+	for k, count := range locations {
+		err := cfg.dbQueries.IncrementLocationRequestStats(ctx, database.IncrementLocationRequestStatsParams{
+			Hour:         k.hour,
+			LocationID:   k.locationID,
+			Endpoint:     k.endpoint,
+			RequestCount: count,
+		})
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		delete(locations, k)
+	}
+
+	if firstErr != nil {
+		cfg.requestStats.restore(endpoints, locations)
+		return errors.Join(fmt.Errorf("could not flush request stats: %w", firstErr), accessErr)
+	}
+	cfg.logger.Debug("request stats flushed")
+	return accessErr
+}
+
+// flushLocationAccess writes the access times of the locations requested since the last flush.
+func (cfg *apiConfig) flushLocationAccess(ctx context.Context) error {
+	accessed := cfg.requestStats.drainAccessed()
+	var firstErr error
+	for id, at := range accessed {
+		err := cfg.dbQueries.TouchLocationAccess(ctx, database.TouchLocationAccessParams{
+			LocationID:     id,
+			LastAccessedAt: at.UTC(),
+		})
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		delete(accessed, id)
+	}
+	if firstErr != nil {
+		cfg.requestStats.restoreAccessed(accessed)
+		return fmt.Errorf("could not record location access: %w", firstErr)
+	}
+	return nil
+}
+
+// requestStatsJob returns the scheduler job that periodically flushes the request statistics.
+func (cfg *apiConfig) requestStatsJob() SchedulerJob {
+	return SchedulerJob{
+		Name:     requestStatsJobName,
+		Interval: requestStatsFlushInterval,
+		Run: func(ctx context.Context) error {
+			return cfg.flushRequestStats(ctx)
+		},
+	}
+}
+
+// requestStatsMiddleware counts requests to the public API by the route pattern that served
+// them. Using the pattern instead of the raw path keeps the number of distinct endpoints bounded.
+func requestStatsMiddleware(stats *requestStatsRecorder, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r)
+		if strings.HasPrefix(r.Pattern, "/api/") {
+			stats.recordEndpoint(r.Pattern, time.Now())
+		}
+	})
+}
+
+// @Summary      Get request statistics per endpoint
+// @Description  Returns the number of requests per public API endpoint and per hour over the given window,
+// @Description  from the persisted request statistics. Counts from the last few minutes may not be flushed yet.
+// @Tags         admin
+// @Produce      json
+// @Param        hours  query     int  false  "Length of the window in hours (default 168, max 2160)"
+// @Success      200  {object}  EndpointStatsResponse
+// @Failure      400  {object}  ErrorResponse "Bad Request - Invalid window"
+// @Failure      500  {object}  ErrorResponse "Internal Server Error - Failed to get statistics"
+// @Security     ApiKeyAuth
+// @Failure      401  {object}  ErrorResponse "Unauthorized - Missing API key"
+// @Failure      403  {object}  ErrorResponse "Forbidden - Invalid API key"
+// @Router       /admin/stats/endpoints [get]
+func (cfg *apiConfig) handlerEndpointStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		cfg.respondWithError(w, http.StatusMethodNotAllowed, "Method Not Allowed", nil)
+		return
+	}
+
+	hours, err := parseStatsParam(r, "hours", defaultStatsWindowHours, maxStatsWindowHours)
+	if err != nil {
+		cfg.respondWithError(w, http.StatusBadRequest, "Invalid window", err)
+		return
+	}
+	since := statsHour(time.Now()).Add(-time.Duration(hours-1) * time.Hour)
+
+	rows, err := cfg.dbQueries.GetEndpointRequestStatsSince(r.Context(), since)
+	if err != nil {
+		cfg.respondWithError(w, http.StatusInternalServerError, "Failed to get request statistics", err)
+		return
+	}
+
+	totals := make(map[string]int64)
+	response := EndpointStatsResponse{
+		Since:     since.Format(time.RFC3339),
+		Endpoints: []EndpointStatJSON{},
+		Hourly:    []HourlyStatJSON{},
+	}
+	for _, row := range rows {
+		totals[row.Endpoint] += row.RequestCount
+		hour := row.Hour.UTC().Format(time.RFC3339)
+		if n := len(response.Hourly); n > 0 && response.Hourly[n-1].Hour == hour {
+			response.Hourly[n-1].Requests += row.RequestCount
+		} else {
+			response.Hourly = append(response.Hourly, HourlyStatJSON{Hour: hour, Requests: row.RequestCount})
+		}
+	}
+	for endpoint, requests := range totals {
+		response.Endpoints = append(response.Endpoints, EndpointStatJSON{Endpoint: endpoint, Requests: requests})
+		response.TotalRequests += requests
+	}
+	sort.Slice(response.Endpoints, func(i, j int) bool {
+		if response.Endpoints[i].Requests != response.Endpoints[j].Requests {
+			return response.Endpoints[i].Requests > response.Endpoints[j].Requests
+		}
+		return response.Endpoints[i].Endpoint < response.Endpoints[j].Endpoint
+	})
+
+	cfg.respondWithJSON(w, http.StatusOK, response)
+}
+
+// @Summary      Get request statistics per location
+// @Description  Returns the most requested locations over the given window, from the persisted request statistics.
+// @Tags         admin
+// @Produce      json
+// @Param        hours  query     int  false  "Length of the window in hours (default 168, max 2160)"
+// @Param        limit  query     int  false  "Maximum number of locations (default 20, max 100)"
+// @Success      200  {object}  LocationStatsResponse
+// @Failure      400  {object}  ErrorResponse "Bad Request - Invalid window or limit"
+// @Failure      500  {object}  ErrorResponse "Internal Server Error - Failed to get statistics"
+// @Security     ApiKeyAuth
+// @Failure      401  {object}  ErrorResponse "Unauthorized - Missing API key"
+// @Failure      403  {object}  ErrorResponse "Forbidden - Invalid API key"
+// @Router       /admin/stats/locations [get]
+func (cfg *apiConfig) handlerLocationStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		cfg.respondWithError(w, http.StatusMethodNotAllowed, "Method Not Allowed", nil)
+		return
+	}
+
+	hours, err := parseStatsParam(r, "hours", defaultStatsWindowHours, maxStatsWindowHours)
+	if err != nil {
+		cfg.respondWithError(w, http.StatusBadRequest, "Invalid window", err)
+		return
+	}
+	limit, err := parseStatsParam(r, "limit", defaultStatsLocations, maxStatsLocations)
+	if err != nil {
+		cfg.respondWithError(w, http.StatusBadRequest, "Invalid limit", err)
+		return
+	}
+	since := statsHour(time.Now()).Add(-time.Duration(hours-1) * time.Hour)
+
+	rows, err := cfg.dbQueries.GetTopLocationsByRequestsSince(r.Context(), database.GetTopLocationsByRequestsSinceParams{
+		Hour:  since,
+		Limit: int32(limit),
+	})
+	if err != nil {
+		cfg.respondWithError(w, http.StatusInternalServerError, "Failed to get request statistics", err)
+		return
+	}
+
+	response := LocationStatsResponse{
+		Since:     since.Format(time.RFC3339),
+		Locations: make([]LocationStatJSON, len(rows)),
+	}
+	for i, row := range rows {
+		response.Locations[i] = LocationStatJSON{
+			LocationID:  row.ID.String(),
+			CityName:    row.CityName,
+			CountryCode: row.CountryCode,
+			Requests:    row.RequestCount,
+		}
+	}
+	cfg.respondWithJSON(w, http.StatusOK, response)
+}
+
+// parseStatsParam reads a positive integer query parameter that must not exceed max.
+func parseStatsParam(r *http.Request, name string, fallback, max int) (int, error) {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		return fallback, nil
+	}
+	val, err := strconv.Atoi(raw)
+	if err != nil || val < 1 || val > max {
+		return 0, fmt.Errorf("%s must be an integer between 1 and %d, got %q", name, max, raw)
+	}
+	return val, nil
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cor0nius/willitrain/internal/database"
+	"github.com/google/uuid"
+)
+
+func TestFlushRequestStats(t *testing.T) {
+	at := time.Date(2025, 3, 1, 14, 35, 0, 0, time.UTC)
+	locationID := uuid.New()
+
+	testCases := []struct {
+		name          string
+		endpointErr   error
+		accessErr     error
+		wantErr       bool
+		wantRemaining int
+		wantAccessed  int
+	}{
+		{name: "Success", wantRemaining: 0},
+		{name: "Failed Rows Are Kept", endpointErr: errors.New("db error"), wantErr: true, wantRemaining: 1},
+		{name: "Failed Access Times Are Kept", accessErr: errors.New("db error"), wantErr: true, wantAccessed: 1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testCfg := newTestAPIConfig(t)
+			testCfg.requestStats = newRequestStatsRecorder()
+			testCfg.requestStats.recordEndpoint("/api/currentweather", at)
+			testCfg.requestStats.recordEndpoint("/api/currentweather", at.Add(10*time.Minute))
+			testCfg.requestStats.recordLocation("/api/currentweather", locationID, at)
+			testCfg.requestStats.recordLocation("/api/dailyforecast", locationID, at.Add(5*time.Minute))
+
+			var gotEndpoint database.IncrementEndpointRequestStatsParams
+			testCfg.mockDB.IncrementEndpointRequestStatsFunc = func(ctx context.Context, arg database.IncrementEndpointRequestStatsParams) error {
+				gotEndpoint = arg
+				return tc.endpointErr
+			}
+			var gotLocation database.IncrementLocationRequestStatsParams
+			testCfg.mockDB.IncrementLocationRequestStatsFunc = func(ctx context.Context, arg database.IncrementLocationRequestStatsParams) error {
+				gotLocation = arg
+				return nil
+			}
+			var gotAccess database.TouchLocationAccessParams
+			testCfg.mockDB.TouchLocationAccessFunc = func(ctx context.Context, arg database.TouchLocationAccessParams) error {
+				gotAccess = arg
+				return tc.accessErr
+			}
+
+			err := testCfg.flushRequestStats(context.Background())
+
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("expected error: %v, got: %v", tc.wantErr, err)
+			}
+			wantHour := time.Date(2025, 3, 1, 14, 0, 0, 0, time.UTC)
+			if !gotEndpoint.Hour.Equal(wantHour) || gotEndpoint.RequestCount != 2 {
+				t.Errorf("unexpected endpoint increment: %+v", gotEndpoint)
+			}
+			if gotLocation.LocationID != locationID || gotLocation.RequestCount != 1 {
+				t.Errorf("unexpected location increment: %+v", gotLocation)
+			}
+			if gotAccess.LocationID != locationID || !gotAccess.LastAccessedAt.Equal(at.Add(5*time.Minute)) {
+				t.Errorf("unexpected location access: %+v", gotAccess)
+			}
+			if accessed := testCfg.requestStats.drainAccessed(); len(accessed) != tc.wantAccessed {
+				t.Errorf("expected %d unflushed access times, got %d", tc.wantAccessed, len(accessed))
+			}
+			endpoints, locations := testCfg.requestStats.drain()
+			if len(endpoints) != tc.wantRemaining || len(locations) != 0 {
+				t.Errorf("expected %d unflushed endpoint rows and no location rows, got %d and %d", tc.wantRemaining, len(endpoints), len(locations))
+			}
+		})
+	}
+}
+
+func TestRequestStatsMiddleware(t *testing.T) {
+	stats := newRequestStatsRecorder()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/config", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {})
+	handler := requestStatsMiddleware(stats, mux)
+
+	for _, target := range []string{"/api/config", "/api/config?x=1", "/index.html", "/api/unknown"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, target, nil))
+	}
+
+	endpoints, _ := stats.drain()
+	if len(endpoints) != 1 {
+		t.Fatalf("expected one counted endpoint, got %v", endpoints)
+	}
+	for k, count := range endpoints {
+		if k.endpoint != "/api/config" || count != 2 {
+			t.Errorf("expected 2 requests to /api/config, got %d to %s", count, k.endpoint)
+		}
+	}
+}
+
+func TestHandlerEndpointStats(t *testing.T) {
+	hour := statsHour(time.Now())
+
+	testCases := []struct {
+		name       string
+		target     string
+		wantStatus int
+	}{
+		{name: "Success", target: "/admin/stats/endpoints", wantStatus: http.StatusOK},
+		{name: "Invalid Window", target: "/admin/stats/endpoints?hours=0", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testCfg := newTestAPIConfig(t)
+			testCfg.mockDB.GetEndpointRequestStatsSinceFunc = func(ctx context.Context, since time.Time) ([]database.EndpointRequestStat, error) {
+				return []database.EndpointRequestStat{
+					{Hour: hour.Add(-time.Hour), Endpoint: "/api/currentweather", RequestCount: 3},
+					{Hour: hour.Add(-time.Hour), Endpoint: "/api/dailyforecast", RequestCount: 5},
+					{Hour: hour, Endpoint: "/api/currentweather", RequestCount: 4},
+				}, nil
+			}
+
+			req := httptest.NewRequest(http.MethodGet, tc.target, nil)
+			rr := httptest.NewRecorder()
+
+			testCfg.apiConfig.handlerEndpointStats(rr, req)
+
+			if rr.Code != tc.wantStatus {
+				t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, tc.wantStatus)
+			}
+			if tc.wantStatus != http.StatusOK {
+				return
+			}
+
+			var resp EndpointStatsResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("could not decode response: %v", err)
+			}
+			if resp.TotalRequests != 12 {
+				t.Errorf("expected 12 requests, got %d", resp.TotalRequests)
+			}
+			if len(resp.Endpoints) != 2 || resp.Endpoints[0].Endpoint != "/api/currentweather" || resp.Endpoints[0].Requests != 7 {
+				t.Errorf("unexpected endpoint totals: %+v", resp.Endpoints)
+			}
+			if len(resp.Hourly) != 2 || resp.Hourly[0].Requests != 8 || resp.Hourly[1].Requests != 4 {
+				t.Errorf("unexpected hourly totals: %+v", resp.Hourly)
+			}
+		})
+	}
+}
+
+func TestHandlerLocationStats(t *testing.T) {
+	testCases := []struct {
+		name       string
+		target     string
+		wantStatus int
+		wantLimit  int32
+	}{
+		{name: "Default Limit", target: "/admin/stats/locations", wantStatus: http.StatusOK, wantLimit: defaultStatsLocations},
+		{name: "Custom Limit", target: "/admin/stats/locations?limit=5", wantStatus: http.StatusOK, wantLimit: 5},
+		{name: "Invalid Limit", target: "/admin/stats/locations?limit=1000", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testCfg := newTestAPIConfig(t)
+			var gotLimit int32
+			testCfg.mockDB.GetTopLocationsByRequestsSinceFunc = func(ctx context.Context, arg database.GetTopLocationsByRequestsSinceParams) ([]database.GetTopLocationsByRequestsSinceRow, error) {
+				gotLimit = arg.Limit
+				return []database.GetTopLocationsByRequestsSinceRow{
+					{ID: MockLocation.LocationID, CityName: "Wroclaw", CountryCode: "PL", RequestCount: 42},
+				}, nil
+			}
+
+			req := httptest.NewRequest(http.MethodGet, tc.target, nil)
+			rr := httptest.NewRecorder()
+
+			testCfg.apiConfig.handlerLocationStats(rr, req)
+
+			if rr.Code != tc.wantStatus {
+				t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, tc.wantStatus)
+			}
+			if tc.wantStatus == http.StatusOK && gotLimit != tc.wantLimit {
+				t.Errorf("expected limit %d, got %d", tc.wantLimit, gotLimit)
+			}
+		})
+	}
+}
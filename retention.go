@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// This file implements the data retention job. The scheduler replaces the forecasts of the
+// locations it refreshes, but forecasts of locations it skips are kept, and with ARCHIVE_HISTORY
+// enabled every replaced forecast is moved to the history tables, so the tables grow without
+// bound. An hourly job deletes the live and archived hourly forecasts for times more than
+// RETENTION_HOURLY_HOURS in the past, the daily forecasts for dates and the provider disagreement
+// reports computed more than RETENTION_DAILY_DAYS in the past, and evicts the locations nobody
+// requested for LOCATION_EVICT_DAYS. The pruned rows are counted by table in
+// willitrain_retention_pruned_rows_total.
+
+const (
+	defaultRetentionHourlyHours = 7 * 24
+	defaultRetentionDailyDays   = 90
+
+	retentionJobName  = "data retention"
+	retentionInterval = time.Hour
+)
+
+// Tables pruned by the retention job, used as metric labels.
+const (
+	retentionTableHourly        = "hourly_forecasts"
+	retentionTableDaily         = "daily_forecasts"
+	retentionTableHourlyHistory = "hourly_forecast_history"
+	retentionTableDailyHistory  = "daily_forecast_history"
+	retentionTableDisagreement  = "provider_disagreement"
+	retentionTableLocations     = "locations"
+)
+
+// getRetentionHourlyHours reads for how many hours past their time hourly forecasts are kept
+// from RETENTION_HOURLY_HOURS. A value of 0 keeps them indefinitely; negative values are ignored.
+func getRetentionHourlyHours(logger *slog.Logger) int {
+	n := getEnvAsInt("RETENTION_HOURLY_HOURS", defaultRetentionHourlyHours, logger)
+	if n < 0 {
+		logger.Warn("RETENTION_HOURLY_HOURS must not be negative, using default", "value", n)
+		return defaultRetentionHourlyHours
+	}
+	return n
+}
+
+// getRetentionDailyDays reads for how many days past their date daily forecasts are kept from
+// RETENTION_DAILY_DAYS. A value of 0 keeps them indefinitely; negative values are ignored.
+func getRetentionDailyDays(logger *slog.Logger) int {
+	n := getEnvAsInt("RETENTION_DAILY_DAYS", defaultRetentionDailyDays, logger)
+	if n < 0 {
+		logger.Warn("RETENTION_DAILY_DAYS must not be negative, using default", "value", n)
+		return defaultRetentionDailyDays
+	}
+	return n
+}
+
+// pruneForecasts deletes the live and archived forecasts past their retention period. Every
+// table is pruned even if another fails, and the errors are joined.
+func (cfg *apiConfig) pruneForecasts(ctx context.Context, now time.Time) error {
+	type prune struct {
+		table  string
+		cutoff time.Time
+		delete func(context.Context, time.Time) (int64, error)
+	}
+	var prunes []prune
+	if cfg.retentionHourlyHours > 0 {
+		cutoff := now.UTC().Add(-time.Duration(cfg.retentionHourlyHours) * time.Hour)
+		prunes = append(prunes,
+			prune{retentionTableHourly, cutoff, cfg.dbQueries.DeleteHourlyForecastsBefore},
+			prune{retentionTableHourlyHistory, cutoff, cfg.dbQueries.DeleteHourlyForecastHistoryBefore},
+		)
+	}
+	if cfg.retentionDailyDays > 0 {
+		y, m, d := now.UTC().AddDate(0, 0, -cfg.retentionDailyDays).Date()
+		cutoff := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+		prunes = append(prunes,
+			prune{retentionTableDaily, cutoff, cfg.dbQueries.DeleteDailyForecastsBefore},
+			prune{retentionTableDailyHistory, cutoff, cfg.dbQueries.DeleteDailyForecastHistoryBefore},
+			prune{retentionTableDisagreement, cutoff, cfg.dbQueries.DeleteProviderDisagreementBefore},
+		)
+	}
+
+	var errs []error
+	for _, p := range prunes {
+		deleted, err := p.delete(ctx, p.cutoff)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("could not prune %s: %w", p.table, err))
+			continue
+		}
+		if deleted > 0 {
+			cfg.logger.Info("pruned expired rows", "table", p.table, "deleted", deleted, "before", p.cutoff)
+		}
+		retentionPrunedRows.WithLabelValues(p.table).Add(float64(deleted))
+	}
+	return errors.Join(errs...)
+}
+
+// retentionJob returns the scheduler job that deletes the forecasts past their retention period
+// and the idle locations.
+func (cfg *apiConfig) retentionJob() SchedulerJob {
+	return SchedulerJob{
+		Name:     retentionJobName,
+		Interval: retentionInterval,
+		Run: func(ctx context.Context) error {
+			now := time.Now()
+			return errors.Join(cfg.pruneForecasts(ctx, now), cfg.evictIdleLocations(ctx, now))
+		},
+	}
+}
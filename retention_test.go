@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestPruneForecasts(t *testing.T) {
+	now := time.Date(2025, 6, 10, 15, 30, 0, 0, time.FixedZone("CEST", 2*60*60))
+	hourlyCutoff := time.Date(2025, 6, 9, 13, 30, 0, 0, time.UTC)
+	dailyCutoff := time.Date(2025, 6, 3, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name        string
+		hourlyHours int
+		dailyDays   int
+		historyErr  error
+		wantCalls   map[string]int
+		wantPruned  map[string]float64
+		wantErr     string
+	}{
+		{
+			name:        "Both Pruned",
+			hourlyHours: 24,
+			dailyDays:   7,
+			wantCalls:   map[string]int{"DeleteHourlyForecastsBefore": 1, "DeleteHourlyForecastHistoryBefore": 1, "DeleteDailyForecastsBefore": 1, "DeleteDailyForecastHistoryBefore": 1, "DeleteProviderDisagreementBefore": 1},
+			wantPruned:  map[string]float64{retentionTableHourly: 5, retentionTableHourlyHistory: 3, retentionTableDaily: 2, retentionTableDailyHistory: 3, retentionTableDisagreement: 4},
+		},
+		{
+			name:       "Retention Disabled",
+			wantCalls:  map[string]int{"DeleteHourlyForecastsBefore": 0, "DeleteHourlyForecastHistoryBefore": 0, "DeleteDailyForecastsBefore": 0, "DeleteDailyForecastHistoryBefore": 0, "DeleteProviderDisagreementBefore": 0},
+			wantPruned: map[string]float64{retentionTableHourly: 0, retentionTableDaily: 0},
+		},
+		{
+			name:        "History Error Does Not Stop Other Tables",
+			hourlyHours: 24,
+			dailyDays:   7,
+			historyErr:  errors.New("db down"),
+			wantCalls:   map[string]int{"DeleteHourlyForecastsBefore": 1, "DeleteHourlyForecastHistoryBefore": 1, "DeleteDailyForecastsBefore": 1, "DeleteDailyForecastHistoryBefore": 1, "DeleteProviderDisagreementBefore": 1},
+			wantPruned:  map[string]float64{retentionTableHourly: 5, retentionTableHourlyHistory: 0, retentionTableDaily: 2, retentionTableDailyHistory: 0, retentionTableDisagreement: 4},
+			wantErr:     "could not prune hourly_forecast_history",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testCfg := newTestAPIConfig(t)
+			testCfg.retentionHourlyHours = tc.hourlyHours
+			testCfg.retentionDailyDays = tc.dailyDays
+			checkCutoff := func(want time.Time, rows int64, err error) func(context.Context, time.Time) (int64, error) {
+				return func(ctx context.Context, cutoff time.Time) (int64, error) {
+					if !cutoff.Equal(want) {
+						t.Errorf("expected cutoff %v, got %v", want, cutoff)
+					}
+					if err != nil {
+						return 0, err
+					}
+					return rows, nil
+				}
+			}
+			testCfg.mockDB.DeleteHourlyForecastsBeforeFunc = checkCutoff(hourlyCutoff, 5, nil)
+			testCfg.mockDB.DeleteHourlyForecastHistoryBeforeFunc = checkCutoff(hourlyCutoff, 3, tc.historyErr)
+			testCfg.mockDB.DeleteDailyForecastsBeforeFunc = checkCutoff(dailyCutoff, 2, nil)
+			testCfg.mockDB.DeleteDailyForecastHistoryBeforeFunc = checkCutoff(dailyCutoff, 3, tc.historyErr)
+			testCfg.mockDB.DeleteProviderDisagreementBeforeFunc = checkCutoff(dailyCutoff, 4, nil)
+
+			before := make(map[string]float64)
+			for table := range tc.wantPruned {
+				before[table] = testutil.ToFloat64(retentionPrunedRows.WithLabelValues(table))
+			}
+
+			err := testCfg.pruneForecasts(context.Background(), now)
+
+			if tc.wantErr == "" && err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if tc.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tc.wantErr)) {
+				t.Fatalf("expected error containing %q, got %v", tc.wantErr, err)
+			}
+			for query, want := range tc.wantCalls {
+				if n := testCfg.mockDB.Calls(query); n != want {
+					t.Errorf("expected %d calls to %s, got %d", want, query, n)
+				}
+			}
+			for table, want := range tc.wantPruned {
+				if got := testutil.ToFloat64(retentionPrunedRows.WithLabelValues(table)) - before[table]; got != want {
+					t.Errorf("expected %v pruned rows in %s, got %v", want, table, got)
+				}
+			}
+		})
+	}
+}
+
+func TestGetRetention(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	if got := getRetentionHourlyHours(logger); got != defaultRetentionHourlyHours {
+		t.Errorf("expected default hourly retention %d, got %d", defaultRetentionHourlyHours, got)
+	}
+	if got := getRetentionDailyDays(logger); got != defaultRetentionDailyDays {
+		t.Errorf("expected default daily retention %d, got %d", defaultRetentionDailyDays, got)
+	}
+
+	t.Setenv("RETENTION_HOURLY_HOURS", "0")
+	t.Setenv("RETENTION_DAILY_DAYS", "-1")
+	if got := getRetentionHourlyHours(logger); got != 0 {
+		t.Errorf("expected hourly retention to be disabled, got %d", got)
+	}
+	if got := getRetentionDailyDays(logger); got != defaultRetentionDailyDays {
+		t.Errorf("expected a negative daily retention to fall back to %d, got %d", defaultRetentionDailyDays, got)
+	}
+}
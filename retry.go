@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// This file implements the retries of the fetch layer. A provider request that fails with a
+// timeout, a network error, a 429 or a 5xx response is retried up to FETCH_MAX_RETRIES times,
+// waiting FETCH_RETRY_BASE_MS, then twice as long, and so on, with jitter so that the requests
+// of a scheduler cycle do not retry in lockstep. A Retry-After header sent with the failed
+// response is honored; if it asks for a longer wait than maxFetchRetryDelay, the request is
+// given up instead. Every attempt waits for the provider's rate limit and counts against its
+// daily quota, no retry is made once the quota is used up, and the wait is cut short when the
+// request's context is cancelled.
+
+const (
+	defaultFetchMaxRetries  = 2
+	defaultFetchRetryBaseMs = 500
+
+	// maxFetchRetryDelay is the longest wait before a retry.
+	maxFetchRetryDelay = 10 * time.Second
+)
+
+// getFetchMaxRetries reads how many times a failed provider request is retried from
+// FETCH_MAX_RETRIES. A value of 0 disables retries; negative values are ignored.
+func getFetchMaxRetries(logger *slog.Logger) int {
+	n := getEnvAsInt("FETCH_MAX_RETRIES", defaultFetchMaxRetries, logger)
+	if n < 0 {
+		logger.Warn("FETCH_MAX_RETRIES must not be negative, using default", "value", n)
+		return defaultFetchMaxRetries
+	}
+	return n
+}
+
+// getFetchRetryBase reads the wait before the first retry from FETCH_RETRY_BASE_MS. Values
+// below 1 are ignored.
+func getFetchRetryBase(logger *slog.Logger) time.Duration {
+	ms := getEnvAsInt("FETCH_RETRY_BASE_MS", defaultFetchRetryBaseMs, logger)
+	if ms < 1 {
+		logger.Warn("FETCH_RETRY_BASE_MS must be at least 1, using default", "value", ms)
+		ms = defaultFetchRetryBaseMs
+	}
+	return time.Duration(ms) * time.Millisecond
+}
+
+// getWithRetries sends a GET request to a provider, retrying transient failures. It returns the
+// response of the first attempt answered with 200 OK, together with the time that attempt was
+// sent, or the error of the last attempt. providerID may be empty for an unknown provider, which
+// is neither rate-limited nor counted against a quota.
+func (cfg *apiConfig) getWithRetries(ctx context.Context, url, providerID string) (*http.Response, time.Time, error) {
+	for attempt := 0; ; attempt++ {
+		if err := cfg.rateLimits.wait(ctx, providerID); err != nil {
+			return nil, time.Time{}, err
+		}
+		if attempt > 0 {
+			cfg.quota.recordCall(providerID)
+		}
+
+		started := time.Now()
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, started, err
+		}
+		resp, err := cfg.httpClient.Do(req)
+		if err == nil && resp.StatusCode == http.StatusOK {
+			return resp, started, nil
+		}
+		var retryAfter time.Duration
+		if err == nil {
+			retryAfter = parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+			resp.Body.Close()
+			err = &fetchStatusError{Status: resp.Status, StatusCode: resp.StatusCode}
+		}
+
+		if attempt >= cfg.fetchMaxRetries || !isProviderOutage(err) || ctx.Err() != nil || cfg.quota.exhausted(providerID) {
+			return nil, started, err
+		}
+		delay := max(retryDelay(cfg.fetchRetryBase, attempt), retryAfter)
+		if delay > maxFetchRetryDelay {
+			cfg.logger.Debug("provider asked for a longer wait than allowed, not retrying", "provider", providerID, "retry_after", retryAfter.String())
+			return nil, started, err
+		}
+
+		class := fetchErrorClass(err)
+		cfg.logger.Debug("retrying provider request", "provider", providerID, "attempt", attempt+1, "delay", delay.String(), "error", class)
+		fetchRetries.WithLabelValues(providerID, class).Inc()
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, started, err
+		}
+	}
+}
+
+// retryDelay returns the backoff before the given retry, counted from 0: base doubled for every
+// earlier retry and capped at maxFetchRetryDelay, of which a random half is subtracted as jitter.
+func retryDelay(base time.Duration, retry int) time.Duration {
+	backoff := maxFetchRetryDelay
+	if retry < 30 && base<<retry < maxFetchRetryDelay {
+		backoff = base << retry
+	}
+	half := backoff / 2
+	if half <= 0 {
+		return backoff
+	}
+	return backoff - rand.N(half)
+}
+
+// parseRetryAfter returns the wait requested by a Retry-After header, given either in seconds
+// or as an HTTP date. It returns 0 for a missing or invalid header, or a date in the past.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if sec, err := strconv.Atoi(value); err == nil {
+		return time.Duration(max(sec, 0)) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+	return 0
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFetchForecast_Retries(t *testing.T) {
+	testCases := []struct {
+		name         string
+		maxRetries   int
+		statuses     []int
+		retryAfter   string
+		wantRequests int
+		wantErr      bool
+		wantCalls    int64
+	}{
+		{
+			name:         "Success: Retried After 503",
+			maxRetries:   2,
+			statuses:     []int{http.StatusServiceUnavailable, http.StatusOK},
+			wantRequests: 2,
+			wantCalls:    1,
+		},
+		{
+			name:         "Success: Retried After 429 With Retry-After",
+			maxRetries:   2,
+			statuses:     []int{http.StatusTooManyRequests, http.StatusOK},
+			retryAfter:   "0",
+			wantRequests: 2,
+			wantCalls:    1,
+		},
+		{
+			name:         "Failure: Retries Exhausted",
+			maxRetries:   2,
+			statuses:     []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway},
+			wantRequests: 3,
+			wantErr:      true,
+			wantCalls:    2,
+		},
+		{
+			name:         "Failure: Client Error Not Retried",
+			maxRetries:   2,
+			statuses:     []int{http.StatusNotFound},
+			wantRequests: 1,
+			wantErr:      true,
+		},
+		{
+			name:         "Failure: Retry-After Too Long",
+			maxRetries:   2,
+			statuses:     []int{http.StatusServiceUnavailable},
+			retryAfter:   "3600",
+			wantRequests: 1,
+			wantErr:      true,
+		},
+		{
+			name:         "Failure: Retries Disabled",
+			maxRetries:   0,
+			statuses:     []int{http.StatusServiceUnavailable},
+			wantRequests: 1,
+			wantErr:      true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var requests atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := int(requests.Add(1))
+				status := tc.statuses[min(n, len(tc.statuses))-1]
+				if status != http.StatusOK && tc.retryAfter != "" {
+					w.Header().Set("Retry-After", tc.retryAfter)
+				}
+				w.WriteHeader(status)
+				_, _ = w.Write([]byte(`{}`))
+			}))
+			defer server.Close()
+
+			cfg := newTestAPIConfig(t).apiConfig
+			cfg.fetchMaxRetries = tc.maxRetries
+			cfg.fetchRetryBase = time.Millisecond
+			cfg.quota = newProviderQuotaPolicy(map[string]int64{"ometeo": 100}, 0, cfg.logger)
+
+			parser := func(io.Reader, *slog.Logger) (CurrentWeather, string, error) {
+				return CurrentWeather{SourceAPI: "Open-Meteo API", Temperature: 21}, "", nil
+			}
+			got, _, err := fetchForecast(cfg, context.Background(), server.URL, parser, CurrentWeather{SourceAPI: "Open-Meteo API"})
+
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("expected error: %v, got %v", tc.wantErr, err)
+			}
+			if !tc.wantErr && got.Temperature != 21 {
+				t.Errorf("expected the parsed forecast, got %+v", got)
+			}
+			if n := int(requests.Load()); n != tc.wantRequests {
+				t.Errorf("expected %d requests, got %d", tc.wantRequests, n)
+			}
+			if calls := cfg.quota.calls["ometeo"]; calls != tc.wantCalls {
+				t.Errorf("expected %d retries counted against the quota, got %d", tc.wantCalls, calls)
+			}
+		})
+	}
+}
+
+func TestFetchForecast_RetryCancelled(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	cfg := newTestAPIConfig(t).apiConfig
+	cfg.fetchMaxRetries = 5
+	cfg.fetchRetryBase = 5 * time.Second
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	parser := func(io.Reader, *slog.Logger) (CurrentWeather, string, error) {
+		return CurrentWeather{SourceAPI: "Open-Meteo API"}, "", nil
+	}
+	start := time.Now()
+	_, _, err := fetchForecast(cfg, ctx, server.URL, parser, CurrentWeather{SourceAPI: "Open-Meteo API"})
+	if err == nil {
+		t.Fatal("expected an error, got nil")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected the backoff to end with the context, took %v", elapsed)
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("expected 1 request, got %d", n)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	base := 100 * time.Millisecond
+	for retry, want := range []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond} {
+		for range 20 {
+			got := retryDelay(base, retry)
+			if got <= want/2 || got > want {
+				t.Fatalf("retry %d: expected a delay in (%v, %v], got %v", retry, want/2, want, got)
+			}
+		}
+	}
+	if got := retryDelay(base, 40); got > maxFetchRetryDelay || got <= maxFetchRetryDelay/2 {
+		t.Errorf("expected the delay to be capped at %v, got %v", maxFetchRetryDelay, got)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 8, 4, 12, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"5", 5 * time.Second},
+		{" 120 ", 2 * time.Minute},
+		{"-3", 0},
+		{"Mon, 04 Aug 2025 12:00:30 GMT", 30 * time.Second},
+		{"Mon, 04 Aug 2025 11:59:00 GMT", 0},
+		{"soon", 0},
+	}
+
+	for _, tc := range testCases {
+		if got := parseRetryAfter(tc.value, now); got != tc.want {
+			t.Errorf("parseRetryAfter(%q) = %v, want %v", tc.value, got, tc.want)
+		}
+	}
+}
+
+func TestGetFetchRetrySettings(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	testCases := []struct {
+		name        string
+		retries     string
+		baseMs      string
+		wantRetries int
+		wantBase    time.Duration
+	}{
+		{name: "Defaults", wantRetries: defaultFetchMaxRetries, wantBase: defaultFetchRetryBaseMs * time.Millisecond},
+		{name: "Configured", retries: "0", baseMs: "250", wantRetries: 0, wantBase: 250 * time.Millisecond},
+		{name: "Invalid", retries: "-1", baseMs: "0", wantRetries: defaultFetchMaxRetries, wantBase: defaultFetchRetryBaseMs * time.Millisecond},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("FETCH_MAX_RETRIES", tc.retries)
+			t.Setenv("FETCH_RETRY_BASE_MS", tc.baseMs)
+			if got := getFetchMaxRetries(logger); got != tc.wantRetries {
+				t.Errorf("expected %d retries, got %d", tc.wantRetries, got)
+			}
+			if got := getFetchRetryBase(logger); got != tc.wantBase {
+				t.Errorf("expected a base delay of %v, got %v", tc.wantBase, got)
+			}
+		})
+	}
+}
@@ -2,74 +2,271 @@ package main
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"log/slog"
 	"sync"
 	"time"
+)
+
+// This file implements a scheduler that periodically runs background jobs, such as fetching
+// and updating weather data. Jobs are registered with a name, an interval and a run function.
+// Every job gets its own ticker and goroutine, and all jobs share the same lifecycle: start
+// and stop, manual triggering, pause and resume, and status reporting.
+//
+// Every job run is recorded with its outcome in the job_runs table. Jobs that update every tracked
+// location do not fire all provider requests at the tick: they queue one task per location, each
+// delayed by a random share of SCHEDULER_JITTER_SEC, and a pool of SCHEDULER_CONCURRENCY workers
+// runs the tasks, so that the upstream APIs see no bursts and their rate limits are not tripped.
+//
+// Every job run is bounded by SCHEDULER_JOB_TIMEOUT_SEC, or by its interval, and every location
+// update by SCHEDULER_LOCATION_TIMEOUT_SEC, so that a hung provider cannot stall a whole cycle:
+// the context of the run or update is cancelled, which aborts its outstanding provider fetches.
+//
+// On shutdown the scheduler stops ticking and waits up to SCHEDULER_DRAIN_TIMEOUT_SEC for the
+// running jobs to finish. Jobs still running after that are cancelled through the scheduler's
+// context, and jobs that do not return within schedulerCancelGrace are abandoned.
 
-	"github.com/cor0nius/willitrain/internal/database"
+// Names of the built-in weather update jobs. They are also used as the job_type metric label.
+const (
+	currentWeatherJobName = "current weather"
+	hourlyForecastJobName = "hourly forecast"
+	dailyForecastJobName  = "daily forecast"
 )
 
-// This file implements a scheduler that periodically fetches and updates weather data.
-// It manages separate tickers for current, hourly, and daily forecasts and runs the
-// update jobs for all stored locations concurrently.
+const (
+	// defaultSchedulerConcurrency is the number of locations updated at the same time unless
+	// overridden with SCHEDULER_CONCURRENCY.
+	defaultSchedulerConcurrency = 4
+	// defaultSchedulerJitterSec is the window, in seconds, over which the location updates of a
+	// cycle are spread unless overridden with SCHEDULER_JITTER_SEC.
+	defaultSchedulerJitterSec = 30
+	// defaultSchedulerDrainTimeoutSec is how long, in seconds, a shutdown waits for running jobs
+	// unless overridden with SCHEDULER_DRAIN_TIMEOUT_SEC.
+	defaultSchedulerDrainTimeoutSec = 20
+	// schedulerCancelGrace is how long a shutdown waits for jobs to return once cancelled.
+	schedulerCancelGrace = 5 * time.Second
+	// defaultSchedulerLocationTimeoutSec is how long, in seconds, the update of one location may
+	// take unless overridden with SCHEDULER_LOCATION_TIMEOUT_SEC.
+	defaultSchedulerLocationTimeoutSec = 60
+)
+
+// errSchedulerJobNotFound is returned when an operation refers to a job that is not registered.
+var errSchedulerJobNotFound = errors.New("scheduler job not found")
+
+// getSchedulerConcurrency reads the maximum number of concurrent location updates from
+// SCHEDULER_CONCURRENCY. Values below 1 are ignored.
+func getSchedulerConcurrency(logger *slog.Logger) int {
+	n := getEnvAsInt("SCHEDULER_CONCURRENCY", defaultSchedulerConcurrency, logger)
+	if n < 1 {
+		logger.Warn("SCHEDULER_CONCURRENCY must be at least 1, using default", "value", n)
+		return defaultSchedulerConcurrency
+	}
+	return n
+}
+
+// getSchedulerJitter reads the window over which location updates are spread from
+// SCHEDULER_JITTER_SEC. A value of 0 starts all updates at the tick; negative values are ignored.
+func getSchedulerJitter(logger *slog.Logger) time.Duration {
+	sec := getEnvAsInt("SCHEDULER_JITTER_SEC", defaultSchedulerJitterSec, logger)
+	if sec < 0 {
+		logger.Warn("SCHEDULER_JITTER_SEC must not be negative, using default", "value", sec)
+		sec = defaultSchedulerJitterSec
+	}
+	return time.Duration(sec) * time.Second
+}
+
+// getSchedulerDrainTimeout reads how long a shutdown waits for running jobs from
+// SCHEDULER_DRAIN_TIMEOUT_SEC. A value of 0 waits for as long as the jobs take; negative values
+// are ignored.
+func getSchedulerDrainTimeout(logger *slog.Logger) time.Duration {
+	sec := getEnvAsInt("SCHEDULER_DRAIN_TIMEOUT_SEC", defaultSchedulerDrainTimeoutSec, logger)
+	if sec < 0 {
+		logger.Warn("SCHEDULER_DRAIN_TIMEOUT_SEC must not be negative, using default", "value", sec)
+		sec = defaultSchedulerDrainTimeoutSec
+	}
+	return time.Duration(sec) * time.Second
+}
+
+// getSchedulerJobTimeout reads how long a job run may take from SCHEDULER_JOB_TIMEOUT_SEC. The
+// default of 0 limits every run to the job's interval; negative values are ignored.
+func getSchedulerJobTimeout(logger *slog.Logger) time.Duration {
+	sec := getEnvAsInt("SCHEDULER_JOB_TIMEOUT_SEC", 0, logger)
+	if sec < 0 {
+		logger.Warn("SCHEDULER_JOB_TIMEOUT_SEC must not be negative, using default", "value", sec)
+		sec = 0
+	}
+	return time.Duration(sec) * time.Second
+}
+
+// getSchedulerLocationTimeout reads how long the update of one location may take from
+// SCHEDULER_LOCATION_TIMEOUT_SEC. A value of 0 disables the limit; negative values are ignored.
+func getSchedulerLocationTimeout(logger *slog.Logger) time.Duration {
+	sec := getEnvAsInt("SCHEDULER_LOCATION_TIMEOUT_SEC", defaultSchedulerLocationTimeoutSec, logger)
+	if sec < 0 {
+		logger.Warn("SCHEDULER_LOCATION_TIMEOUT_SEC must not be negative, using default", "value", sec)
+		sec = defaultSchedulerLocationTimeoutSec
+	}
+	return time.Duration(sec) * time.Second
+}
 
-// Scheduler manages the periodic execution of weather data updates.
+// SchedulerJob defines a periodic job. Run is called every Interval; a nil error marks the
+// cycle as successful for status reporting and the drift metric. Its context ends when the job
+// exceeds its timeout or the scheduler's shutdown runs out of time.
+type SchedulerJob struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) error
+}
+
+// scheduledJob is a registered job together with its runtime state.
+// The embedded Interval can be changed with SetInterval; it and all other fields except the
+// rest of the embedded definition are guarded by Scheduler.mu. defaultInterval is the interval
+// the job was registered with.
+type scheduledJob struct {
+	SchedulerJob
+	defaultInterval time.Duration
+
+	tick    <-chan time.Time
+	ticker  *time.Ticker
+	paused  bool
+	running bool
+	lastRun time.Time
+	nextRun time.Time
+	lastErr error
+	// run is the recorded run in progress, or nil.
+	run *jobRun
+}
+
+// Scheduler manages the periodic execution of registered jobs.
 type Scheduler struct {
-	cfg                *apiConfig
-	currentChan        <-chan time.Time
-	hourlyChan         <-chan time.Time
-	dailyChan          <-chan time.Time
-	stop               chan struct{}
-	tickers            []*time.Ticker
-	currentWeatherJobs func()
-	hourlyForecastJobs func()
-	dailyForecastJobs  func()
-	jobWG              sync.WaitGroup
-}
-
-// NewScheduler creates and initializes a new Scheduler instance.
-func NewScheduler(cfg *apiConfig, currentInterval, hourlyInterval, dailyInterval time.Duration) *Scheduler {
-	currentTicker := time.NewTicker(currentInterval)
-	hourlyTicker := time.NewTicker(hourlyInterval)
-	dailyTicker := time.NewTicker(dailyInterval)
-	s := &Scheduler{
-		cfg:         cfg,
-		currentChan: currentTicker.C,
-		hourlyChan:  hourlyTicker.C,
-		dailyChan:   dailyTicker.C,
-		stop:        make(chan struct{}),
-		tickers:     []*time.Ticker{currentTicker, hourlyTicker, dailyTicker},
-	}
-	s.currentWeatherJobs = s.runCurrentWeatherJobs
-	s.hourlyForecastJobs = s.runHourlyForecastJobs
-	s.dailyForecastJobs = s.runDailyForecastJobs
-	return s
-}
-
-// Start begins the scheduler's main loop in a new goroutine.
-// It listens on the ticker channels and triggers the corresponding job functions.
+	cfg    *apiConfig
+	stop   chan struct{}
+	loopWG sync.WaitGroup
+	jobWG  sync.WaitGroup
+
+	// mu guards the registered jobs and the bookkeeping used by the scheduler health collector.
+	mu          sync.RWMutex
+	jobs        []*scheduledJob
+	started     bool
+	startedAt   time.Time
+	lastSuccess map[string]time.Time
+	// cycles counts the runs of every job that updates locations, for demand-driven scheduling.
+	cycles map[string]int
+
+	// events receives the lifecycle events of all job runs.
+	events *schedulerEventHub
+
+	// concurrency limits the number of locations updated at the same time; 0 means no limit.
+	// Each location update is delayed by a random duration below jitter.
+	concurrency int
+	jitter      time.Duration
+
+	// ctx is the parent of every job's context and is cancelled when a shutdown runs out of
+	// drainTimeout; a zero drainTimeout waits for the jobs indefinitely.
+	ctx          context.Context
+	cancel       context.CancelFunc
+	drainTimeout time.Duration
+
+	// jobTimeout bounds every job run, and locationTimeout every location update within a run.
+	// A zero jobTimeout uses the job's interval; a zero locationTimeout disables the limit.
+	jobTimeout      time.Duration
+	locationTimeout time.Duration
+}
+
+// schedulerStopSummary describes how the running jobs ended when the scheduler stopped.
+type schedulerStopSummary struct {
+	Running   []string // Jobs running when the shutdown started.
+	Cancelled []string // Jobs still running after the drain timeout, whose context was cancelled.
+	Abandoned []string // Jobs still running after the cancellation grace period.
+	Duration  time.Duration
+}
+
+// NewScheduler creates a Scheduler with no jobs. Jobs are added with RegisterJob.
+func NewScheduler(cfg *apiConfig) *Scheduler {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Scheduler{
+		cfg:          cfg,
+		stop:         make(chan struct{}),
+		startedAt:    time.Now(),
+		lastSuccess:  make(map[string]time.Time),
+		cycles:       make(map[string]int),
+		events:       newSchedulerEventHub(),
+		concurrency:  cfg.schedulerConcurrency,
+		jitter:       cfg.schedulerJitter,
+		ctx:          ctx,
+		cancel:       cancel,
+		drainTimeout: cfg.schedulerDrainTimeout,
+
+		jobTimeout:      cfg.schedulerJobTimeout,
+		locationTimeout: cfg.schedulerLocationTimeout,
+	}
+}
+
+// RegisterJob adds a job to the scheduler. Jobs registered after Start begin ticking immediately.
+func (s *Scheduler) RegisterJob(job SchedulerJob) error {
+	if job.Name == "" {
+		return errors.New("scheduler job name must not be empty")
+	}
+	if job.Interval <= 0 {
+		return fmt.Errorf("scheduler job %q must have a positive interval", job.Name)
+	}
+	if job.Run == nil {
+		return fmt.Errorf("scheduler job %q must have a run function", job.Name)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, j := range s.jobs {
+		if j.Name == job.Name {
+			return fmt.Errorf("scheduler job %q is already registered", job.Name)
+		}
+	}
+	j := &scheduledJob{SchedulerJob: job, defaultInterval: job.Interval}
+	s.jobs = append(s.jobs, j)
+	if s.started {
+		s.startJob(j)
+	}
+	return nil
+}
+
+// Start begins running all registered jobs, each in its own goroutine.
 func (s *Scheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.started = true
+	for _, j := range s.jobs {
+		s.startJob(j)
+	}
+}
+
+// startJob creates the job's ticker, unless a tick channel was provided, and launches its loop.
+// The caller must hold s.mu.
+func (s *Scheduler) startJob(j *scheduledJob) {
+	if j.tick == nil {
+		j.ticker = time.NewTicker(j.Interval)
+		j.tick = j.ticker.C
+	}
+	j.nextRun = time.Now().Add(j.Interval)
+
+	s.loopWG.Add(1)
 	go func() {
+		defer s.loopWG.Done()
 		for {
 			select {
-			case <-s.currentChan:
-				s.cfg.logger.Info("running scheduler jobs", "type", "current weather")
-				s.jobWG.Add(1)
-				s.currentWeatherJobs()
-				s.jobWG.Done()
-			case <-s.hourlyChan:
-				s.cfg.logger.Info("running scheduler jobs", "type", "hourly forecast")
-				s.jobWG.Add(1)
-				s.hourlyForecastJobs()
-				s.jobWG.Done()
-			case <-s.dailyChan:
-				s.cfg.logger.Info("running scheduler jobs", "type", "daily forecast")
-				s.jobWG.Add(1)
-				s.dailyForecastJobs()
-				s.jobWG.Done()
+			case <-j.tick:
+				s.mu.Lock()
+				j.nextRun = time.Now().Add(j.Interval)
+				paused := j.paused
+				s.mu.Unlock()
+				if paused {
+					s.cfg.logger.Debug("skipping paused scheduler job", "type", j.Name)
+					continue
+				}
+				s.runJob(j)
 			case <-s.stop:
-				s.cfg.logger.Info("stopping scheduler")
-				for _, ticker := range s.tickers {
-					ticker.Stop()
+				if j.ticker != nil {
+					j.ticker.Stop()
 				}
 				return
 			}
@@ -77,87 +274,403 @@ func (s *Scheduler) Start() {
 	}()
 }
 
+// runJob runs a job once, unless a previous run is still in progress, and records the outcome.
+func (s *Scheduler) runJob(j *scheduledJob) {
+	s.mu.Lock()
+	if j.running {
+		s.mu.Unlock()
+		s.cfg.logger.Warn("scheduler job still running, skipping this cycle", "type", j.Name)
+		return
+	}
+	j.running = true
+	j.lastRun = time.Now()
+	interval := j.Interval
+	s.jobWG.Add(1)
+	s.mu.Unlock()
+	defer s.jobWG.Done()
+
+	s.cfg.logger.Info("running scheduler jobs", "type", j.Name)
+	s.events.publish(SchedulerEventJSON{Type: schedulerEventJobStarted, Job: j.Name})
+	start := time.Now()
+	run := s.cfg.startJobRun(s.ctx, j.Name, start)
+	s.mu.Lock()
+	j.run = run
+	s.mu.Unlock()
+	timeout := s.jobTimeout
+	if timeout <= 0 {
+		timeout = interval
+	}
+	ctx, cancel := context.WithTimeout(s.ctx, timeout)
+	err := j.Run(ctx)
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		schedulerTimeouts.WithLabelValues(j.Name, "job").Inc()
+		s.cfg.logger.Warn("scheduler job timed out", "type", j.Name, "timeout", timeout.String())
+		if err == nil {
+			err = ctx.Err()
+		}
+	}
+	cancel()
+	schedulerJobDuration.WithLabelValues(j.Name).Set(time.Since(start).Seconds())
+	// The outcome is recorded even if the run was cancelled by a shutdown.
+	s.cfg.finishJobRun(context.WithoutCancel(s.ctx), run, err, time.Now())
+
+	s.mu.Lock()
+	j.running = false
+	j.run = nil
+	j.lastErr = err
+	s.mu.Unlock()
+
+	finished := SchedulerEventJSON{Type: schedulerEventJobFinished, Job: j.Name, DurationMs: time.Since(start).Milliseconds()}
+	if err != nil {
+		finished.Error = err.Error()
+	}
+	s.events.publish(finished)
+
+	if err != nil {
+		s.cfg.logger.Error("scheduler job failed", "type", j.Name, "error", err)
+		return
+	}
+	s.recordJobSuccess(j.Name, time.Now())
+}
+
 // Stop gracefully shuts down the scheduler.
-// It stops all tickers and waits for any running jobs to complete.
-func (s *Scheduler) Stop() {
+// It stops all tickers, waits for any running jobs to complete and disconnects event clients.
+// Jobs still running after the drain timeout are cancelled, and abandoned if they do not return
+// within schedulerCancelGrace. The outcome is logged and returned.
+func (s *Scheduler) Stop() schedulerStopSummary {
+	start := time.Now()
+	summary := schedulerStopSummary{Running: s.runningJobs()}
+	// The stop channel is closed under mu, so that runInBackground adds no work once the
+	// shutdown waits for it.
+	s.mu.Lock()
 	close(s.stop)
-	s.jobWG.Wait()
-	s.cfg.logger.Info("scheduler stopped")
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.loopWG.Wait()
+		s.jobWG.Wait()
+		close(done)
+	}()
+	if !waitUntilDone(done, s.drainTimeout) {
+		summary.Cancelled = s.runningJobs()
+		s.cfg.logger.Warn("scheduler jobs still running after drain timeout, cancelling", "jobs", summary.Cancelled, "timeout", s.drainTimeout.String())
+		s.cancel()
+		if !waitUntilDone(done, schedulerCancelGrace) {
+			summary.Abandoned = s.runningJobs()
+		}
+	}
+	s.cancel()
+	s.events.close()
+
+	summary.Duration = time.Since(start)
+	s.cfg.logger.Info("scheduler stopped",
+		"running", len(summary.Running),
+		"drained", len(summary.Running)-len(summary.Cancelled),
+		"cancelled", summary.Cancelled,
+		"abandoned", summary.Abandoned,
+		"duration", summary.Duration.String(),
+	)
+	return summary
 }
 
-// runUpdateForLocations retrieves all locations from the database and runs a given update
-// function for each one concurrently.
-func (s *Scheduler) runUpdateForLocations(jobType string, updateFunc func(context.Context, Location)) {
-	ctx := context.Background()
+// runInBackground runs fn in its own goroutine as part of the scheduler's work: a shutdown
+// waits for it like for a job run and cancels its context after the drain timeout. It reports
+// false without running fn if the scheduler is stopping.
+func (s *Scheduler) runInBackground(fn func(ctx context.Context)) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	select {
+	case <-s.stop:
+		return false
+	default:
+	}
+	s.jobWG.Add(1)
+	go func() {
+		defer s.jobWG.Done()
+		fn(s.ctx)
+	}()
+	return true
+}
+
+// runningJobs returns the names of the jobs that are currently running.
+func (s *Scheduler) runningJobs() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var names []string
+	for _, j := range s.jobs {
+		if j.running {
+			names = append(names, j.Name)
+		}
+	}
+	return names
+}
+
+// waitUntilDone waits until done is closed or the timeout passes, and reports whether done was
+// closed. A zero timeout waits indefinitely.
+func waitUntilDone(done <-chan struct{}, timeout time.Duration) bool {
+	if timeout <= 0 {
+		<-done
+		return true
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+// jobsForTrigger selects the jobs for a manual run: the named job, or every job that is not
+// paused if name is empty. The tickers of the selected jobs are reset so that the next
+// scheduled run happens a full interval after the manual one.
+func (s *Scheduler) jobsForTrigger(name string) ([]*scheduledJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var jobs []*scheduledJob
+	for _, j := range s.jobs {
+		if (name == "" && !j.paused) || j.Name == name {
+			jobs = append(jobs, j)
+		}
+	}
+	if name != "" && len(jobs) == 0 {
+		return nil, errSchedulerJobNotFound
+	}
+	for _, j := range jobs {
+		if j.ticker != nil {
+			j.ticker.Reset(j.Interval)
+			j.nextRun = time.Now().Add(j.Interval)
+		}
+	}
+	return jobs, nil
+}
+
+// Pause stops the named job from running on its ticker until Resume is called.
+// A paused job can still be triggered manually by name.
+func (s *Scheduler) Pause(name string) error {
+	return s.setPaused(name, true)
+}
+
+// Resume re-enables scheduled runs of a paused job.
+func (s *Scheduler) Resume(name string) error {
+	return s.setPaused(name, false)
+}
+
+func (s *Scheduler) setPaused(name string, paused bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, j := range s.jobs {
+		if j.Name == name {
+			j.paused = paused
+			return nil
+		}
+	}
+	return errSchedulerJobNotFound
+}
+
+// Status reports the state of every registered job in registration order.
+func (s *Scheduler) Status() []SchedulerJobStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	statuses := make([]SchedulerJobStatus, len(s.jobs))
+	for i, j := range s.jobs {
+		status := SchedulerJobStatus{
+			Name:     j.Name,
+			Interval: j.Interval.String(),
+			Paused:   j.paused,
+			Running:  j.running,
+		}
+		if !j.lastRun.IsZero() {
+			status.LastRun = j.lastRun.UTC().Format(time.RFC3339)
+		}
+		if last, ok := s.lastSuccess[j.Name]; ok {
+			status.LastSuccess = last.UTC().Format(time.RFC3339)
+		}
+		if !j.nextRun.IsZero() && !j.paused {
+			status.NextRun = j.nextRun.UTC().Format(time.RFC3339)
+		}
+		if j.lastErr != nil {
+			status.LastError = j.lastErr.Error()
+		}
+		statuses[i] = status
+	}
+	return statuses
+}
+
+// runUpdateForLocations retrieves all locations from the database, selects those due in this
+// cycle by their demand, and queues a given update function for each one on the job queue, which
+// runs the updates concurrently, staggered by the scheduler's jitter and limited to its concurrency.
+// The outcome of every update is recorded with the job's run and published as an event; update
+// functions log their own errors and return errUpdateSkipped if they did nothing. Updates that are
+// still waiting for their turn when the scheduler stops or the job's context ends are skipped. Each
+// update runs with the scheduler's location timeout; an update that exceeds it is cancelled,
+// together with its outstanding provider fetches, and counted in willitrain_scheduler_timeouts_total.
+func (s *Scheduler) runUpdateForLocations(ctx context.Context, jobType string, updateFunc func(context.Context, Location) error) error {
 	locations, err := s.cfg.dbQueries.ListLocations(ctx)
 	if err != nil {
 		s.cfg.logger.Error("scheduler failed to get locations", "error", err)
-		return
+		return fmt.Errorf("failed to list locations: %w", err)
 	}
+	trackedLocations.Set(float64(len(locations)))
+	locations = s.locationsForCycle(ctx, jobType, locations)
 
-	var wg sync.WaitGroup
-	for _, dbLocation := range locations {
-		wg.Add(1)
-		go func(loc database.Location) {
-			defer wg.Done()
-			location := databaseLocationToLocation(loc)
-			updateFunc(ctx, location)
-		}(dbLocation)
-	}
-	wg.Wait()
+	s.runLocationQueue(ctx, jobType, s.activeJobRun(jobType), locations, updateFunc)
+	s.cfg.pruneSchedulerRuns(ctx, time.Now())
 	s.cfg.logger.Info("scheduler jobs for this cycle completed", "type", jobType)
+	return nil
 }
 
-// The run...Jobs functions define the specific update logic for each forecast type.
-// They fetch all locations from the database and then, for each location, they delete
-// the old data and request new data from the external APIs.
-func (s *Scheduler) runCurrentWeatherJobs() {
-	updateFunc := func(ctx context.Context, location Location) {
-		if err := s.cfg.dbQueries.DeleteCurrentWeatherAtLocation(ctx, location.LocationID); err != nil {
-			s.cfg.logger.Error("failed to delete current weather", "location", location.CityName, "error", err)
-			return
+// runLocationUpdate runs the update of one location with the scheduler's location timeout.
+func (s *Scheduler) runLocationUpdate(ctx context.Context, jobType string, location Location, updateFunc func(context.Context, Location) error) error {
+	if s.locationTimeout <= 0 {
+		return updateFunc(ctx, location)
+	}
+	locationCtx, cancel := context.WithTimeout(ctx, s.locationTimeout)
+	defer cancel()
+	err := updateFunc(locationCtx, location)
+	if errors.Is(locationCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil {
+		schedulerTimeouts.WithLabelValues(jobType, "location").Inc()
+		s.cfg.logger.Warn("scheduler location update timed out", "type", jobType, "location", location.CityName, "timeout", s.locationTimeout.String())
+		if err == nil {
+			err = locationCtx.Err()
 		}
-		weather, err := s.cfg.requestCurrentWeather(location)
-		if err != nil {
-			s.cfg.logger.Error("failed to request current weather", "location", location.CityName, "error", err)
-			return
+	}
+	return err
+}
+
+// recordJobSuccess stores the completion time of a scheduler cycle and publishes it
+// as the scheduler_last_success_timestamp metric for the given job type.
+func (s *Scheduler) recordJobSuccess(jobType string, at time.Time) {
+	s.mu.Lock()
+	if s.lastSuccess == nil {
+		s.lastSuccess = make(map[string]time.Time)
+	}
+	s.lastSuccess[jobType] = at
+	s.mu.Unlock()
+	schedulerLastSuccessTimestamp.WithLabelValues(jobType).Set(float64(at.Unix()))
+}
+
+// weatherJobs returns the definitions of the built-in weather update jobs.
+func (s *Scheduler) weatherJobs(currentInterval, hourlyInterval, dailyInterval time.Duration) []SchedulerJob {
+	return []SchedulerJob{
+		{Name: currentWeatherJobName, Interval: currentInterval, Run: s.runCurrentWeatherJobs},
+		{Name: hourlyForecastJobName, Interval: hourlyInterval, Run: s.runHourlyForecastJobs},
+		{Name: dailyForecastJobName, Interval: dailyInterval, Run: s.runDailyForecastJobs},
+	}
+}
+
+// The run...Jobs functions define the specific update logic for each forecast type.
+// They fetch all locations from the database and then, for each location, request new data
+// from the external APIs and replace the old data with it. The old data is deleted, or moved to
+// the history tables when ARCHIVE_HISTORY is enabled. The outcome of every provider is saved as
+// a scheduler run report. Locations are left untouched while every provider is out of its daily
+// quota or has an open circuit. A refreshed hourly forecast is also checked against the
+// location's alert rules.
+func (s *Scheduler) runCurrentWeatherJobs(ctx context.Context) error {
+	return s.runUpdateForLocations(ctx, currentWeatherJobName, s.updateCurrentWeather)
+}
+
+func (s *Scheduler) runHourlyForecastJobs(ctx context.Context) error {
+	if err := s.cfg.refreshQuotaPriority(ctx, time.Now()); err != nil {
+		s.cfg.logger.Warn("could not refresh quota priority locations", "error", err)
+	}
+	return s.runUpdateForLocations(ctx, hourlyForecastJobName, s.updateHourlyForecast)
+}
+
+func (s *Scheduler) runDailyForecastJobs(ctx context.Context) error {
+	return s.runUpdateForLocations(ctx, dailyForecastJobName, s.updateDailyForecast)
+}
+
+// The update... functions replace one type of data of a single location with fresh data from the
+// providers. They are run by the scheduler jobs above and by the refresh endpoint. The old rows
+// are removed and the new ones saved in one transaction once the providers have answered, so
+// that a failed request or a crash mid-refresh leaves the location with its previous data.
+func (s *Scheduler) updateCurrentWeather(ctx context.Context, location Location) error {
+	if s.cfg.allQuotasExhausted() || s.cfg.allCircuitsOpen() {
+		s.cfg.logger.DebugContext(ctx, "skipping current weather, no provider available", "location", location.CityName)
+		return errUpdateSkipped
+	}
+	runs := newSchedulerRunRecorder(currentWeatherJobName, location)
+	defer s.cfg.saveSchedulerRuns(ctx, runs)
+	weather, err := s.cfg.requestCurrentWeather(ctx, location, nil, runs.observe)
+	if err != nil {
+		s.cfg.logger.ErrorContext(ctx, "failed to request current weather", "location", location.CityName, "error", err)
+		return err
+	}
+	err = s.cfg.runInTx(ctx, func(q dbQuerier) error {
+		if err := s.cfg.clearCurrentWeather(ctx, q, location.LocationID); err != nil {
+			s.cfg.logger.ErrorContext(ctx, "failed to delete current weather", "location", location.CityName, "error", err)
+			return err
 		}
-		s.cfg.persistCurrentWeather(ctx, weather)
-		s.cfg.logger.Debug("updated current weather", "location", location.CityName)
+		return s.cfg.storeCurrentWeather(ctx, q, weather)
+	})
+	if err != nil {
+		return err
 	}
-	s.runUpdateForLocations("current weather", updateFunc)
+	s.cfg.logger.DebugContext(ctx, "updated current weather", "location", location.CityName)
+	return nil
 }
 
-func (s *Scheduler) runHourlyForecastJobs() {
-	updateFunc := func(ctx context.Context, location Location) {
-		if err := s.cfg.dbQueries.DeleteHourlyForecastsAtLocation(ctx, location.LocationID); err != nil {
-			s.cfg.logger.Error("failed to delete hourly forecasts", "location", location.CityName, "error", err)
-			return
+func (s *Scheduler) updateHourlyForecast(ctx context.Context, location Location) error {
+	if skips, all := s.cfg.hourlyQuotaSkips(location); all {
+		for id := range skips {
+			quotaSkippedFetches.WithLabelValues(id).Inc()
 		}
-		forecast, err := s.cfg.requestHourlyForecast(location)
-		if err != nil {
-			s.cfg.logger.Error("failed to request hourly forecast", "location", location.CityName, "error", err)
-			return
+		s.cfg.logger.DebugContext(ctx, "skipping hourly forecast, all providers low on quota", "location", location.CityName)
+		return errUpdateSkipped
+	}
+	if s.cfg.allQuotasExhausted() || s.cfg.allCircuitsOpen() {
+		s.cfg.logger.DebugContext(ctx, "skipping hourly forecast, no provider available", "location", location.CityName)
+		return errUpdateSkipped
+	}
+	runs := newSchedulerRunRecorder(hourlyForecastJobName, location)
+	defer s.cfg.saveSchedulerRuns(ctx, runs)
+	forecast, err := s.cfg.requestHourlyForecast(ctx, location, nil, runs.observe)
+	if err != nil {
+		s.cfg.logger.ErrorContext(ctx, "failed to request hourly forecast", "location", location.CityName, "error", err)
+		return err
+	}
+	err = s.cfg.runInTx(ctx, func(q dbQuerier) error {
+		if err := s.cfg.clearHourlyForecasts(ctx, q, location.LocationID); err != nil {
+			s.cfg.logger.ErrorContext(ctx, "failed to delete hourly forecasts", "location", location.CityName, "error", err)
+			return err
 		}
-		s.cfg.persistHourlyForecast(ctx, forecast)
-		s.cfg.logger.Debug("updated hourly forecast", "location", location.CityName)
+		return s.cfg.storeHourlyForecast(ctx, q, forecast)
+	})
+	if err != nil {
+		return err
 	}
-	s.runUpdateForLocations("hourly forecast", updateFunc)
+	s.cfg.evaluateAlerts(ctx, location, forecast, time.Now())
+	s.cfg.logger.DebugContext(ctx, "updated hourly forecast", "location", location.CityName)
+	return nil
 }
 
-func (s *Scheduler) runDailyForecastJobs() {
-	updateFunc := func(ctx context.Context, location Location) {
-		if err := s.cfg.dbQueries.DeleteDailyForecastsAtLocation(ctx, location.LocationID); err != nil {
-			s.cfg.logger.Error("failed to delete daily forecasts", "location", location.CityName, "error", err)
-			return
-		}
-		forecast, err := s.cfg.requestDailyForecast(location)
-		if err != nil {
-			s.cfg.logger.Error("failed to request daily forecast", "location", location.CityName, "error", err)
-			return
+func (s *Scheduler) updateDailyForecast(ctx context.Context, location Location) error {
+	if s.cfg.allQuotasExhausted() || s.cfg.allCircuitsOpen() {
+		s.cfg.logger.DebugContext(ctx, "skipping daily forecast, no provider available", "location", location.CityName)
+		return errUpdateSkipped
+	}
+	runs := newSchedulerRunRecorder(dailyForecastJobName, location)
+	defer s.cfg.saveSchedulerRuns(ctx, runs)
+	forecast, err := s.cfg.requestDailyForecast(ctx, location, nil, runs.observe)
+	if err != nil {
+		s.cfg.logger.ErrorContext(ctx, "failed to request daily forecast", "location", location.CityName, "error", err)
+		return err
+	}
+	err = s.cfg.runInTx(ctx, func(q dbQuerier) error {
+		if err := s.cfg.clearDailyForecasts(ctx, q, location.LocationID); err != nil {
+			s.cfg.logger.ErrorContext(ctx, "failed to delete daily forecasts", "location", location.CityName, "error", err)
+			return err
 		}
-		s.cfg.persistDailyForecast(ctx, forecast)
-		s.cfg.logger.Debug("updated daily forecast", "location", location.CityName)
+		return s.cfg.storeDailyForecast(ctx, q, forecast)
+	})
+	if err != nil {
+		return err
 	}
-	s.runUpdateForLocations("daily forecast", updateFunc)
+	s.cfg.logger.DebugContext(ctx, "updated daily forecast", "location", location.CityName)
+	return nil
 }
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+// This file implements the scheduler event stream. The scheduler publishes the lifecycle of every
+// job run to an event hub: the start of a run, the outcome of every location a weather job
+// updates and the end of the run. The /ws endpoint streams these events to WebSocket clients as
+// JSON messages, so that dashboards can show live refresh status. Events are not stored, and a
+// client that cannot keep up loses events instead of slowing the scheduler down.
+
+// Types of scheduler events.
+const (
+	schedulerEventJobStarted        = "job_started"
+	schedulerEventLocationSucceeded = "location_succeeded"
+	schedulerEventLocationFailed    = "location_failed"
+	schedulerEventLocationSkipped   = "location_skipped"
+	schedulerEventJobFinished       = "job_finished"
+)
+
+const (
+	// schedulerEventBuffer is the number of events queued per client before events are dropped.
+	schedulerEventBuffer = 256
+	// maxSchedulerEventClients limits the number of concurrently connected clients.
+	maxSchedulerEventClients = 100
+)
+
+var (
+	// errUpdateSkipped is returned by a location update that deliberately did nothing.
+	errUpdateSkipped = errors.New("update skipped")
+	// errTooManyEventClients is returned when maxSchedulerEventClients are already connected.
+	errTooManyEventClients = errors.New("too many scheduler event clients")
+	// errEventStreamClosed is returned when subscribing to a stopped scheduler's events.
+	errEventStreamClosed = errors.New("scheduler event stream is closed")
+)
+
+// schedulerEventHub fans scheduler events out to the subscribed clients. A nil hub is valid and
+// discards all events.
+type schedulerEventHub struct {
+	mu      sync.Mutex
+	clients map[chan SchedulerEventJSON]struct{}
+	closed  bool
+}
+
+func newSchedulerEventHub() *schedulerEventHub {
+	return &schedulerEventHub{clients: make(map[chan SchedulerEventJSON]struct{})}
+}
+
+// subscribe registers a client. The returned channel is closed when the client unsubscribes or
+// the hub is closed.
+func (h *schedulerEventHub) subscribe() (<-chan SchedulerEventJSON, func(), error) {
+	if h == nil {
+		return nil, nil, errEventStreamClosed
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return nil, nil, errEventStreamClosed
+	}
+	if len(h.clients) >= maxSchedulerEventClients {
+		return nil, nil, errTooManyEventClients
+	}
+	ch := make(chan SchedulerEventJSON, schedulerEventBuffer)
+	h.clients[ch] = struct{}{}
+	schedulerEventClients.Set(float64(len(h.clients)))
+
+	unsubscribe := func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if _, ok := h.clients[ch]; ok {
+			delete(h.clients, ch)
+			close(ch)
+			schedulerEventClients.Set(float64(len(h.clients)))
+		}
+	}
+	return ch, unsubscribe, nil
+}
+
+// publish sends an event to every client without blocking. Clients whose buffer is full miss
+// the event.
+func (h *schedulerEventHub) publish(event SchedulerEventJSON) {
+	if h == nil {
+		return
+	}
+	if event.Time == "" {
+		event.Time = time.Now().UTC().Format(time.RFC3339Nano)
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.clients {
+		select {
+		case ch <- event:
+		default:
+			schedulerEventsDropped.Inc()
+		}
+	}
+}
+
+// close disconnects all clients and rejects new ones.
+func (h *schedulerEventHub) close() {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for ch := range h.clients {
+		delete(h.clients, ch)
+		close(ch)
+	}
+	schedulerEventClients.Set(0)
+}
+
+// publishLocationEvent publishes the outcome of a location update of a job.
+func (s *Scheduler) publishLocationEvent(jobType string, location Location, err error) {
+	event := SchedulerEventJSON{
+		Type:       schedulerEventLocationSucceeded,
+		Job:        jobType,
+		LocationID: location.LocationID.String(),
+		CityName:   location.CityName,
+	}
+	switch {
+	case errors.Is(err, errUpdateSkipped):
+		event.Type = schedulerEventLocationSkipped
+	case err != nil:
+		event.Type = schedulerEventLocationFailed
+		event.Error = err.Error()
+	}
+	s.events.publish(event)
+}
+
+// @Summary      Stream scheduler events
+// @Description  Upgrades the connection to a WebSocket and streams scheduler job events as JSON messages:
+// @Description  job_started, location_succeeded, location_failed or location_skipped for every location a
+// @Description  weather job updates, and job_finished with the duration and error of the run. Messages
+// @Description  sent by the client are ignored. Events are dropped for clients that cannot keep up.
+// @Tags         scheduler
+// @Produce      json
+// @Success      101  {object}  SchedulerEventJSON
+// @Failure      405  {object}  ErrorResponse "Method Not Allowed"
+// @Failure      503  {object}  ErrorResponse "Service Unavailable - Too many clients or scheduler stopped"
+// @Router       /ws [get]
+func (s *Scheduler) handlerSchedulerEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.cfg.respondWithError(w, http.StatusMethodNotAllowed, "Method Not Allowed", nil)
+		return
+	}
+
+	events, unsubscribe, err := s.events.subscribe()
+	if err != nil {
+		s.cfg.respondWithError(w, http.StatusServiceUnavailable, err.Error(), nil)
+		return
+	}
+	defer unsubscribe()
+
+	// The server is used without a handshake function, so that clients other than browsers do
+	// not have to send an Origin header. The stream is read-only and carries no subscriber data.
+	websocket.Server{Handler: func(ws *websocket.Conn) {
+		defer ws.Close()
+		s.streamSchedulerEvents(ws, events)
+	}}.ServeHTTP(w, r)
+}
+
+// streamSchedulerEvents writes events to the connection until the client disconnects, a write
+// fails or the event channel is closed.
+func (s *Scheduler) streamSchedulerEvents(ws *websocket.Conn, events <-chan SchedulerEventJSON) {
+	disconnected := make(chan struct{})
+	go func() {
+		defer close(disconnected)
+		_, _ = io.Copy(io.Discard, ws)
+	}()
+
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			if err := websocket.JSON.Send(ws, event); err != nil {
+				s.cfg.logger.Debug("scheduler event client write failed", "error", err)
+				return
+			}
+		case <-disconnected:
+			return
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cor0nius/willitrain/internal/database"
+	"github.com/google/uuid"
+	"golang.org/x/net/websocket"
+)
+
+func TestSchedulerEventHub(t *testing.T) {
+	t.Run("publish and unsubscribe", func(t *testing.T) {
+		hub := newSchedulerEventHub()
+		events, unsubscribe, err := hub.subscribe()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		hub.publish(SchedulerEventJSON{Type: schedulerEventJobStarted, Job: "test"})
+		event := <-events
+		if event.Type != schedulerEventJobStarted || event.Job != "test" || event.Time == "" {
+			t.Errorf("unexpected event: %+v", event)
+		}
+
+		unsubscribe()
+		unsubscribe()
+		if _, ok := <-events; ok {
+			t.Error("expected the channel to be closed after unsubscribing")
+		}
+	})
+
+	t.Run("slow client drops events", func(t *testing.T) {
+		hub := newSchedulerEventHub()
+		events, unsubscribe, _ := hub.subscribe()
+		defer unsubscribe()
+		for i := 0; i < schedulerEventBuffer+10; i++ {
+			hub.publish(SchedulerEventJSON{Type: schedulerEventJobStarted})
+		}
+		if len(events) != schedulerEventBuffer {
+			t.Errorf("buffered %d events, want %d", len(events), schedulerEventBuffer)
+		}
+	})
+
+	t.Run("client limit", func(t *testing.T) {
+		hub := newSchedulerEventHub()
+		for i := 0; i < maxSchedulerEventClients; i++ {
+			if _, _, err := hub.subscribe(); err != nil {
+				t.Fatalf("subscribe %d: unexpected error: %v", i, err)
+			}
+		}
+		if _, _, err := hub.subscribe(); !errors.Is(err, errTooManyEventClients) {
+			t.Errorf("expected errTooManyEventClients, got %v", err)
+		}
+	})
+
+	t.Run("close", func(t *testing.T) {
+		hub := newSchedulerEventHub()
+		events, _, _ := hub.subscribe()
+		hub.close()
+		if _, ok := <-events; ok {
+			t.Error("expected the channel to be closed")
+		}
+		if _, _, err := hub.subscribe(); !errors.Is(err, errEventStreamClosed) {
+			t.Errorf("expected errEventStreamClosed, got %v", err)
+		}
+	})
+
+	t.Run("nil hub", func(t *testing.T) {
+		var hub *schedulerEventHub
+		hub.publish(SchedulerEventJSON{})
+		hub.close()
+		if _, _, err := hub.subscribe(); err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
+
+func TestHandlerSchedulerEvents(t *testing.T) {
+	testCfg := newTestAPIConfig(t)
+	s := NewScheduler(testCfg.apiConfig)
+	// The handler is wrapped like in production, so the middleware must let it hijack the connection.
+	server := httptest.NewServer(metricsMiddleware(corsMiddleware(testCfg.apiConfig.namingMiddleware(http.HandlerFunc(s.handlerSchedulerEvents)))))
+	defer server.Close()
+
+	t.Run("method not allowed", func(t *testing.T) {
+		resp, err := http.Post(server.URL, "application/json", nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusMethodNotAllowed {
+			t.Errorf("status = %d, want %d", resp.StatusCode, http.StatusMethodNotAllowed)
+		}
+	})
+
+	t.Run("stream", func(t *testing.T) {
+		ws, err := websocket.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws", "", server.URL)
+		if err != nil {
+			t.Fatalf("could not connect: %v", err)
+		}
+		defer ws.Close()
+
+		// The subscription is registered before the handshake completes.
+		s.events.publish(SchedulerEventJSON{Type: schedulerEventLocationFailed, Job: hourlyForecastJobName, CityName: "Wroclaw", Error: "boom"})
+
+		_ = ws.SetReadDeadline(time.Now().Add(5 * time.Second))
+		var event SchedulerEventJSON
+		if err := websocket.JSON.Receive(ws, &event); err != nil {
+			t.Fatalf("could not receive event: %v", err)
+		}
+		if event.Type != schedulerEventLocationFailed || event.CityName != "Wroclaw" || event.Error != "boom" {
+			t.Errorf("unexpected event: %+v", event)
+		}
+
+		s.Stop()
+		if err := websocket.JSON.Receive(ws, &event); err == nil {
+			t.Error("expected the connection to be closed when the scheduler stops")
+		}
+	})
+}
+
+func TestSchedulerPublishesEvents(t *testing.T) {
+	testCfg := newTestAPIConfig(t)
+	locations := []database.Location{
+		{ID: uuid.New(), CityName: "Good"},
+		{ID: uuid.New(), CityName: "Bad"},
+		{ID: uuid.New(), CityName: "Skipped"},
+	}
+	testCfg.mockDB.ListLocationsFunc = func(ctx context.Context) ([]database.Location, error) {
+		return locations, nil
+	}
+	s := NewScheduler(testCfg.apiConfig)
+	events, unsubscribe, err := s.events.subscribe()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer unsubscribe()
+
+	updateFunc := func(ctx context.Context, location Location) error {
+		switch location.CityName {
+		case "Bad":
+			return errors.New("provider down")
+		case "Skipped":
+			return errUpdateSkipped
+		}
+		return nil
+	}
+	job := &scheduledJob{SchedulerJob: SchedulerJob{Name: "test job", Interval: time.Hour, Run: func(ctx context.Context) error {
+		return s.runUpdateForLocations(ctx, "test job", updateFunc)
+	}}}
+	s.runJob(job)
+
+	var got []SchedulerEventJSON
+	for len(events) > 0 {
+		got = append(got, <-events)
+	}
+	if len(got) != 5 {
+		t.Fatalf("expected 5 events, got %d: %+v", len(got), got)
+	}
+	if got[0].Type != schedulerEventJobStarted || got[4].Type != schedulerEventJobFinished || got[4].Error != "" {
+		t.Errorf("unexpected job events: %+v, %+v", got[0], got[4])
+	}
+	byCity := make(map[string]SchedulerEventJSON)
+	for _, e := range got[1:4] {
+		byCity[e.CityName] = e
+	}
+	if byCity["Good"].Type != schedulerEventLocationSucceeded || byCity["Good"].LocationID != locations[0].ID.String() {
+		t.Errorf("unexpected event for Good: %+v", byCity["Good"])
+	}
+	if byCity["Bad"].Type != schedulerEventLocationFailed || byCity["Bad"].Error != "provider down" {
+		t.Errorf("unexpected event for Bad: %+v", byCity["Bad"])
+	}
+	if byCity["Skipped"].Type != schedulerEventLocationSkipped {
+		t.Errorf("unexpected event for Skipped: %+v", byCity["Skipped"])
+	}
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/cor0nius/willitrain/internal/database"
+)
+
+// This file implements the runtime reconfiguration of the weather update intervals. Operators
+// approaching a provider's quota can slow the refreshes through PATCH /admin/scheduler without
+// a redeploy: the new intervals take effect immediately, as the job tickers are reset, and are
+// stored in the scheduler_intervals table, from where they are applied again on startup. An
+// interval of 0 resets a job to the interval configured in the environment.
+
+// maxSchedulerIntervalMin is the longest interval, in minutes, that can be set at runtime.
+const maxSchedulerIntervalMin = 7 * 24 * 60
+
+// SchedulerIntervalsRequest is the request body for PATCH /admin/scheduler. Omitted fields
+// leave their job unchanged, and 0 restores the interval configured in the environment.
+type SchedulerIntervalsRequest struct {
+	CurrentIntervalMin    *int `json:"current_interval_min" example:"30"`
+	HourlyIntervalMin     *int `json:"hourly_interval_min" example:"180"`
+	DailyIntervalMin      *int `json:"daily_interval_min" example:"720"`
+	AirQualityIntervalMin *int `json:"air_quality_interval_min" example:"120"`
+}
+
+// intervals returns the requested interval of every job present in the request, keyed by job name.
+func (req *SchedulerIntervalsRequest) intervals() map[string]int {
+	intervals := make(map[string]int)
+	for name, minutes := range map[string]*int{
+		currentWeatherJobName: req.CurrentIntervalMin,
+		hourlyForecastJobName: req.HourlyIntervalMin,
+		dailyForecastJobName:  req.DailyIntervalMin,
+		airQualityJobName:     req.AirQualityIntervalMin,
+	} {
+		if minutes != nil {
+			intervals[name] = *minutes
+		}
+	}
+	return intervals
+}
+
+// validate checks that at least one interval is given and that all are within range.
+func (req *SchedulerIntervalsRequest) validate() error {
+	intervals := req.intervals()
+	if len(intervals) == 0 {
+		return errors.New("at least one interval must be given")
+	}
+	for name, minutes := range intervals {
+		if minutes < 0 || minutes > maxSchedulerIntervalMin {
+			return fmt.Errorf("%s interval must be between 0 and %d minutes", name, maxSchedulerIntervalMin)
+		}
+	}
+	return nil
+}
+
+// SetInterval changes the interval of the named job. A zero interval restores the interval the
+// job was registered with. The ticker of a running job is reset, so that its next run happens a
+// full new interval from now.
+func (s *Scheduler) SetInterval(name string, interval time.Duration) error {
+	if interval < 0 {
+		return fmt.Errorf("scheduler job %q must have a positive interval", name)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, j := range s.jobs {
+		if j.Name != name {
+			continue
+		}
+		if interval == 0 {
+			interval = j.defaultInterval
+		}
+		j.Interval = interval
+		if j.ticker != nil {
+			j.ticker.Reset(interval)
+			j.nextRun = time.Now().Add(interval)
+		}
+		return nil
+	}
+	return errSchedulerJobNotFound
+}
+
+// applyStoredIntervals applies the interval overrides stored in the database. Failures are
+// logged, since the scheduler can still run with the configured intervals.
+func (s *Scheduler) applyStoredIntervals(ctx context.Context) {
+	overrides, err := s.cfg.dbQueries.ListSchedulerIntervals(ctx)
+	if err != nil {
+		s.cfg.logger.Warn("could not load scheduler interval overrides, using configured intervals", "error", err)
+		return
+	}
+	for _, o := range overrides {
+		interval := time.Duration(o.IntervalSeconds) * time.Second
+		if interval <= 0 {
+			s.cfg.logger.Warn("ignoring invalid scheduler interval override", "job", o.JobName, "seconds", o.IntervalSeconds)
+			continue
+		}
+		if err := s.SetInterval(o.JobName, interval); err != nil {
+			s.cfg.logger.Warn("ignoring scheduler interval override", "job", o.JobName, "error", err)
+			continue
+		}
+		s.cfg.logger.Info("applied scheduler interval override", "job", o.JobName, "interval", interval.String())
+	}
+}
+
+// @Summary      Change scheduler intervals
+// @Description  Changes the intervals of the weather update jobs at runtime, for example to slow the refreshes
+// @Description  when approaching a provider's quota. The tickers of the changed jobs are reset, so their next
+// @Description  run happens a full new interval from now. The intervals are stored and survive restarts; an
+// @Description  interval of 0 restores the one configured in the environment. Omitted jobs are unchanged.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        intervals  body      SchedulerIntervalsRequest  true  "New intervals in minutes (0 to 10080)"
+// @Success      200  {object}  SchedulerStatusResponse
+// @Failure      400  {object}  ErrorResponse "Bad Request - Invalid request body or interval"
+// @Failure      500  {object}  ErrorResponse "Internal Server Error - Failed to store scheduler intervals"
+// @Security     ApiKeyAuth
+// @Failure      401  {object}  ErrorResponse "Unauthorized - Missing API key"
+// @Failure      403  {object}  ErrorResponse "Forbidden - Invalid API key"
+// @Router       /admin/scheduler [patch]
+func (s *Scheduler) handlerUpdateSchedulerIntervals(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPatch {
+		s.cfg.respondWithError(w, http.StatusMethodNotAllowed, "Method Not Allowed", nil)
+		return
+	}
+
+	var req SchedulerIntervalsRequest
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4<<10))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		s.cfg.respondWithError(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	if err := req.validate(); err != nil {
+		s.cfg.respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	// The overrides are stored before they are applied, so that a running scheduler never uses
+	// intervals that would be lost on restart.
+	intervals := req.intervals()
+	now := time.Now().UTC()
+	for name, minutes := range intervals {
+		var err error
+		if minutes == 0 {
+			err = s.cfg.dbQueries.DeleteSchedulerInterval(r.Context(), name)
+		} else {
+			err = s.cfg.dbQueries.UpsertSchedulerInterval(r.Context(), database.UpsertSchedulerIntervalParams{
+				JobName:         name,
+				IntervalSeconds: int32(minutes * 60),
+				UpdatedAt:       now,
+			})
+		}
+		if err != nil {
+			s.cfg.respondWithError(w, http.StatusInternalServerError, "Failed to store scheduler intervals", err)
+			return
+		}
+	}
+
+	for name, minutes := range intervals {
+		if err := s.SetInterval(name, time.Duration(minutes)*time.Minute); err != nil {
+			s.cfg.logger.Warn("could not change scheduler interval", "job", name, "error", err)
+			continue
+		}
+		s.cfg.logger.Info("scheduler interval changed", "job", name, "interval_min", minutes)
+	}
+	s.cfg.respondWithJSON(w, http.StatusOK, SchedulerStatusResponse{Jobs: s.Status()})
+}
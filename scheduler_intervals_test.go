@@ -0,0 +1,220 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cor0nius/willitrain/internal/database"
+)
+
+// newIntervalTestScheduler returns a scheduler with the four weather update jobs registered
+// with an interval of one hour.
+func newIntervalTestScheduler(t *testing.T, testCfg *testAPIConfig) *Scheduler {
+	t.Helper()
+	s := NewScheduler(testCfg.apiConfig)
+	for _, name := range []string{currentWeatherJobName, hourlyForecastJobName, dailyForecastJobName, airQualityJobName} {
+		if err := s.RegisterJob(SchedulerJob{Name: name, Interval: time.Hour, Run: func(context.Context) error { return nil }}); err != nil {
+			t.Fatalf("failed to register job: %v", err)
+		}
+	}
+	return s
+}
+
+func TestScheduler_SetInterval(t *testing.T) {
+	s := newIntervalTestScheduler(t, newTestAPIConfig(t))
+	s.Start()
+	defer s.Stop()
+
+	if err := s.SetInterval(currentWeatherJobName, 5*time.Minute); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	s.mu.RLock()
+	j := s.jobs[0]
+	interval, nextRun := j.Interval, j.nextRun
+	s.mu.RUnlock()
+	if interval != 5*time.Minute {
+		t.Errorf("expected an interval of 5m, got %v", interval)
+	}
+	if until := time.Until(nextRun); until > 5*time.Minute || until < 4*time.Minute {
+		t.Errorf("expected the next run in about 5m, got %v", until)
+	}
+
+	if err := s.SetInterval(currentWeatherJobName, 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := s.Status()[0].Interval; got != "1h0m0s" {
+		t.Errorf("expected the registered interval to be restored, got %s", got)
+	}
+	if err := s.SetInterval("missing", time.Minute); !errors.Is(err, errSchedulerJobNotFound) {
+		t.Errorf("expected errSchedulerJobNotFound, got %v", err)
+	}
+}
+
+func TestApplyStoredIntervals(t *testing.T) {
+	testCfg := newTestAPIConfig(t)
+	s := newIntervalTestScheduler(t, testCfg)
+	testCfg.mockDB.ListSchedulerIntervalsFunc = func(ctx context.Context) ([]database.SchedulerInterval, error) {
+		return []database.SchedulerInterval{
+			{JobName: dailyForecastJobName, IntervalSeconds: 1800},
+			{JobName: "removed job", IntervalSeconds: 60},
+			{JobName: hourlyForecastJobName, IntervalSeconds: 0},
+		}, nil
+	}
+
+	s.applyStoredIntervals(context.Background())
+
+	want := map[string]string{
+		currentWeatherJobName: "1h0m0s",
+		hourlyForecastJobName: "1h0m0s",
+		dailyForecastJobName:  "30m0s",
+		airQualityJobName:     "1h0m0s",
+	}
+	for _, status := range s.Status() {
+		if status.Interval != want[status.Name] {
+			t.Errorf("expected %s to have an interval of %s, got %s", status.Name, want[status.Name], status.Interval)
+		}
+	}
+}
+
+func TestHandlerUpdateSchedulerIntervals(t *testing.T) {
+	testCases := []struct {
+		name           string
+		method         string
+		body           string
+		upsertErr      error
+		wantStatus     int
+		wantUpserts    map[string]int32
+		wantDeletes    []string
+		wantIntervals  map[string]string
+		wantBodySubstr string
+	}{
+		{
+			name:        "Success: Intervals Changed",
+			method:      http.MethodPatch,
+			body:        `{"current_interval_min": 30, "daily_interval_min": 720}`,
+			wantStatus:  http.StatusOK,
+			wantUpserts: map[string]int32{currentWeatherJobName: 1800, dailyForecastJobName: 43200},
+			wantIntervals: map[string]string{
+				currentWeatherJobName: "30m0s",
+				hourlyForecastJobName: "1h0m0s",
+				dailyForecastJobName:  "12h0m0s",
+				airQualityJobName:     "1h0m0s",
+			},
+		},
+		{
+			name:        "Success: Interval Reset",
+			method:      http.MethodPatch,
+			body:        `{"air_quality_interval_min": 0}`,
+			wantStatus:  http.StatusOK,
+			wantDeletes: []string{airQualityJobName},
+			wantIntervals: map[string]string{
+				airQualityJobName: "1h0m0s",
+			},
+		},
+		{
+			name:           "Failure: No Intervals",
+			method:         http.MethodPatch,
+			body:           `{}`,
+			wantStatus:     http.StatusBadRequest,
+			wantBodySubstr: "at least one interval",
+		},
+		{
+			name:           "Failure: Interval Out Of Range",
+			method:         http.MethodPatch,
+			body:           `{"hourly_interval_min": 20000}`,
+			wantStatus:     http.StatusBadRequest,
+			wantBodySubstr: "between 0 and 10080",
+		},
+		{
+			name:       "Failure: Unknown Field",
+			method:     http.MethodPatch,
+			body:       `{"weekly_interval_min": 5}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Failure: Database Error",
+			method:     http.MethodPatch,
+			body:       `{"current_interval_min": 30}`,
+			upsertErr:  errors.New("db down"),
+			wantStatus: http.StatusInternalServerError,
+			wantIntervals: map[string]string{
+				currentWeatherJobName: "1h0m0s",
+			},
+		},
+		{
+			name:       "Failure: Wrong Method",
+			method:     http.MethodPost,
+			body:       `{"current_interval_min": 30}`,
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testCfg := newTestAPIConfig(t)
+			s := newIntervalTestScheduler(t, testCfg)
+
+			var mu sync.Mutex
+			upserts := make(map[string]int32)
+			var deletes []string
+			testCfg.mockDB.UpsertSchedulerIntervalFunc = func(ctx context.Context, arg database.UpsertSchedulerIntervalParams) error {
+				mu.Lock()
+				defer mu.Unlock()
+				upserts[arg.JobName] = arg.IntervalSeconds
+				return tc.upsertErr
+			}
+			testCfg.mockDB.DeleteSchedulerIntervalFunc = func(ctx context.Context, jobName string) error {
+				mu.Lock()
+				defer mu.Unlock()
+				deletes = append(deletes, jobName)
+				return nil
+			}
+
+			req := httptest.NewRequest(tc.method, "/admin/scheduler", strings.NewReader(tc.body))
+			rr := httptest.NewRecorder()
+			s.handlerUpdateSchedulerIntervals(rr, req)
+
+			if rr.Code != tc.wantStatus {
+				t.Fatalf("expected status %d, got %d: %s", tc.wantStatus, rr.Code, rr.Body.String())
+			}
+			if tc.wantBodySubstr != "" && !strings.Contains(rr.Body.String(), tc.wantBodySubstr) {
+				t.Errorf("expected body to contain %q, got %s", tc.wantBodySubstr, rr.Body.String())
+			}
+			if tc.wantUpserts != nil {
+				if len(upserts) != len(tc.wantUpserts) {
+					t.Errorf("expected %d stored intervals, got %v", len(tc.wantUpserts), upserts)
+				}
+				for name, seconds := range tc.wantUpserts {
+					if upserts[name] != seconds {
+						t.Errorf("expected %s to be stored as %d seconds, got %d", name, seconds, upserts[name])
+					}
+				}
+			}
+			if len(deletes) != len(tc.wantDeletes) {
+				t.Errorf("expected deleted overrides %v, got %v", tc.wantDeletes, deletes)
+			}
+
+			if tc.wantStatus == http.StatusOK {
+				var response SchedulerStatusResponse
+				if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+					t.Fatalf("failed to decode response: %v", err)
+				}
+				if len(response.Jobs) != 4 {
+					t.Errorf("expected 4 jobs in the response, got %d", len(response.Jobs))
+				}
+			}
+			for _, status := range s.Status() {
+				if want, ok := tc.wantIntervals[status.Name]; ok && status.Interval != want {
+					t.Errorf("expected %s to have an interval of %s, got %s", status.Name, want, status.Interval)
+				}
+			}
+		})
+	}
+}
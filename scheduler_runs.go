@@ -0,0 +1,235 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/cor0nius/willitrain/internal/database"
+)
+
+// This file implements the persisted scheduler run reports. Every scheduled update records one
+// row per location and provider with the number of rows written, the forecast hours they cover,
+// the duration and, for failures, an error class. Support can then answer questions such as
+// "when did this city last update successfully from OWM?" through /admin/scheduler/runs instead
+// of searching the logs. Records older than schedulerRunRetention are pruned after every cycle.
+
+const (
+	schedulerRunRetention = 30 * 24 * time.Hour
+
+	defaultSchedulerRuns = 20
+	maxSchedulerRuns     = 500
+)
+
+// Error classes stored with failed scheduler runs.
+const (
+	errorClassUnauthorized    = "unauthorized"
+	errorClassRateLimited     = "rate_limited"
+	errorClassClientError     = "client_error"
+	errorClassServerError     = "server_error"
+	errorClassTimeout         = "timeout"
+	errorClassNetwork         = "network"
+	errorClassInvalidResponse = "invalid_response"
+)
+
+// providerFetchOutcome describes the result of fetching one forecast type from one provider.
+// Rows and HoursCovered are zero for failed fetches.
+type providerFetchOutcome struct {
+	ProviderID   string
+	Duration     time.Duration
+	Rows         int
+	HoursCovered int
+	Err          error
+}
+
+// fetchErrorClass maps a fetch error to a coarse class that can be filtered and aggregated.
+// It returns an empty string for a nil error.
+func fetchErrorClass(err error) string {
+	if err == nil {
+		return ""
+	}
+	var statusErr *fetchStatusError
+	if errors.As(err, &statusErr) {
+		switch {
+		case statusErr.StatusCode == http.StatusUnauthorized || statusErr.StatusCode == http.StatusForbidden:
+			return errorClassUnauthorized
+		case statusErr.StatusCode == http.StatusTooManyRequests:
+			return errorClassRateLimited
+		case statusErr.StatusCode >= 500:
+			return errorClassServerError
+		default:
+			return errorClassClientError
+		}
+	}
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return errorClassTimeout
+	}
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return errorClassNetwork
+	}
+	return errorClassInvalidResponse
+}
+
+// schedulerRunRecorder collects the provider outcomes of one scheduled update of a location.
+type schedulerRunRecorder struct {
+	jobType   string
+	location  Location
+	startedAt time.Time
+
+	mu       sync.Mutex
+	outcomes []providerFetchOutcome
+}
+
+func newSchedulerRunRecorder(jobType string, location Location) *schedulerRunRecorder {
+	return &schedulerRunRecorder{jobType: jobType, location: location, startedAt: time.Now()}
+}
+
+// observe records the outcome of a provider. It is passed to the request functions as onOutcome.
+func (rec *schedulerRunRecorder) observe(outcome providerFetchOutcome) {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	rec.outcomes = append(rec.outcomes, outcome)
+}
+
+// saveSchedulerRuns stores the recorded outcomes. Failures are logged, since a missing report
+// must not fail the update itself.
+func (cfg *apiConfig) saveSchedulerRuns(ctx context.Context, rec *schedulerRunRecorder) {
+	rec.mu.Lock()
+	outcomes := rec.outcomes
+	rec.mu.Unlock()
+
+	for _, o := range outcomes {
+		params := database.CreateSchedulerRunParams{
+			LocationID:   rec.location.LocationID,
+			JobType:      rec.jobType,
+			Provider:     o.ProviderID,
+			StartedAt:    rec.startedAt.UTC(),
+			DurationMs:   o.Duration.Milliseconds(),
+			RowsWritten:  int32(o.Rows),
+			HoursCovered: int32(o.HoursCovered),
+		}
+		if o.Err != nil {
+			params.ErrorClass = sql.NullString{String: fetchErrorClass(o.Err), Valid: true}
+			params.ErrorMessage = sql.NullString{String: o.Err.Error(), Valid: true}
+		}
+		if err := cfg.dbQueries.CreateSchedulerRun(ctx, params); err != nil {
+			cfg.logger.Warn("could not save scheduler run", "location", rec.location.CityName, "provider", o.ProviderID, "error", err)
+		}
+	}
+}
+
+// pruneSchedulerRuns deletes the scheduler run records that are older than schedulerRunRetention.
+func (cfg *apiConfig) pruneSchedulerRuns(ctx context.Context, now time.Time) {
+	deleted, err := cfg.dbQueries.DeleteSchedulerRunsBefore(ctx, now.Add(-schedulerRunRetention))
+	if err != nil {
+		cfg.logger.Warn("could not prune scheduler runs", "error", err)
+		return
+	}
+	if deleted > 0 {
+		cfg.logger.Debug("pruned scheduler runs", "deleted", deleted)
+	}
+}
+
+// findLocation looks up an existing location by a city name or one of its aliases. Unlike
+// getOrCreateLocation, it never geocodes or creates a location, and returns sql.ErrNoRows
+// if none matches.
+func (cfg *apiConfig) findLocation(ctx context.Context, cityName string) (database.Location, error) {
+	if alias, err := normalizeCityName(cityName); err == nil {
+		dbLocation, err := cfg.dbQueries.GetLocationByAlias(ctx, alias)
+		if err != sql.ErrNoRows {
+			return dbLocation, err
+		}
+	}
+	return cfg.dbQueries.GetLocationByName(ctx, cityName)
+}
+
+// @Summary      Get scheduler runs for a location
+// @Description  Returns the most recent scheduled updates of a location, one entry per job and provider,
+// @Description  with the rows written, the forecast hours covered and the error class of failed fetches.
+// @Description  Runs are kept for 30 days.
+// @Tags         admin
+// @Produce      json
+// @Param        city      query     string  true   "City name or alias of an existing location"
+// @Param        provider  query     string  false  "Provider ID to filter by (gmp, owm, ometeo, metno)"
+// @Param        limit     query     int     false  "Maximum number of runs (default 20, max 500)"
+// @Success      200  {object}  SchedulerRunsResponse
+// @Failure      400  {object}  ErrorResponse "Bad Request - Missing city, unknown provider or invalid limit"
+// @Failure      404  {object}  ErrorResponse "Not Found - Location does not exist"
+// @Failure      500  {object}  ErrorResponse "Internal Server Error - Failed to get scheduler runs"
+// @Security     ApiKeyAuth
+// @Failure      401  {object}  ErrorResponse "Unauthorized - Missing API key"
+// @Failure      403  {object}  ErrorResponse "Forbidden - Invalid API key"
+// @Router       /admin/scheduler/runs [get]
+func (cfg *apiConfig) handlerSchedulerRuns(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		cfg.respondWithError(w, http.StatusMethodNotAllowed, "Method Not Allowed", nil)
+		return
+	}
+
+	city := r.URL.Query().Get("city")
+	if city == "" {
+		cfg.respondWithError(w, http.StatusBadRequest, "city query parameter is required", nil)
+		return
+	}
+	var provider sql.NullString
+	if id := r.URL.Query().Get("provider"); id != "" {
+		if _, ok := providerByID(id); !ok {
+			cfg.respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Unknown provider %q", id), nil)
+			return
+		}
+		provider = sql.NullString{String: id, Valid: true}
+	}
+	limit, err := parseStatsParam(r, "limit", defaultSchedulerRuns, maxSchedulerRuns)
+	if err != nil {
+		cfg.respondWithError(w, http.StatusBadRequest, "Invalid limit", err)
+		return
+	}
+
+	dbLocation, err := cfg.findLocation(r.Context(), city)
+	if err == sql.ErrNoRows {
+		cfg.respondWithError(w, http.StatusNotFound, "Location not found", nil)
+		return
+	}
+	if err != nil {
+		cfg.respondWithError(w, http.StatusInternalServerError, "Failed to get location", err)
+		return
+	}
+
+	runs, err := cfg.dbQueries.ListSchedulerRunsForLocation(r.Context(), database.ListSchedulerRunsForLocationParams{
+		LocationID: dbLocation.ID,
+		Provider:   provider,
+		RowLimit:   int32(limit),
+	})
+	if err != nil {
+		cfg.respondWithError(w, http.StatusInternalServerError, "Failed to get scheduler runs", err)
+		return
+	}
+
+	response := SchedulerRunsResponse{
+		LocationID: dbLocation.ID.String(),
+		CityName:   dbLocation.CityName,
+		Runs:       make([]SchedulerRunJSON, 0, len(runs)),
+	}
+	for _, run := range runs {
+		response.Runs = append(response.Runs, SchedulerRunJSON{
+			JobType:      run.JobType,
+			Provider:     run.Provider,
+			StartedAt:    run.StartedAt.UTC().Format(time.RFC3339),
+			DurationMs:   run.DurationMs,
+			RowsWritten:  run.RowsWritten,
+			HoursCovered: run.HoursCovered,
+			Success:      !run.ErrorClass.Valid,
+			ErrorClass:   run.ErrorClass.String,
+			Error:        run.ErrorMessage.String,
+		})
+	}
+	cfg.respondWithJSON(w, http.StatusOK, response)
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cor0nius/willitrain/internal/database"
+	"github.com/google/uuid"
+)
+
+// timeoutError is a net.Error that reports a timeout.
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestFetchErrorClass(t *testing.T) {
+	testCases := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"no error", nil, ""},
+		{"unauthorized", &fetchStatusError{Status: "401 Unauthorized", StatusCode: 401}, errorClassUnauthorized},
+		{"forbidden", &fetchStatusError{Status: "403 Forbidden", StatusCode: 403}, errorClassUnauthorized},
+		{"rate limited", &fetchStatusError{Status: "429 Too Many Requests", StatusCode: 429}, errorClassRateLimited},
+		{"not found", &fetchStatusError{Status: "404 Not Found", StatusCode: 404}, errorClassClientError},
+		{"server error", fmt.Errorf("wrapped: %w", &fetchStatusError{Status: "503 Service Unavailable", StatusCode: 503}), errorClassServerError},
+		{"deadline exceeded", context.DeadlineExceeded, errorClassTimeout},
+		{"network timeout", &url.Error{Op: "Get", URL: "http://example.com", Err: timeoutError{}}, errorClassTimeout},
+		{"connection refused", &url.Error{Op: "Get", URL: "http://example.com", Err: errors.New("connection refused")}, errorClassNetwork},
+		{"parse error", errors.New("invalid character 'x'"), errorClassInvalidResponse},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := fetchErrorClass(tc.err); got != tc.want {
+				t.Errorf("fetchErrorClass() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRunHourlyForecastJobs_SavesSchedulerRuns(t *testing.T) {
+	gmpData, err := os.ReadFile("testdata/hourly_forecast_gmp.json")
+	if err != nil {
+		t.Fatalf("failed to read test data: %v", err)
+	}
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.Contains(r.URL.Path, "/gmp"):
+			_, _ = w.Write(gmpData)
+		case strings.Contains(r.URL.Path, "/owm"):
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer mockServer.Close()
+
+	testCfg := newTestAPIConfig(t)
+	cfg := testCfg.apiConfig
+	cfg.httpClient = mockServer.Client()
+	cfg.gmpWeatherURL = mockServer.URL + "/gmp"
+	cfg.owmWeatherURL = mockServer.URL + "/owm"
+	cfg.ometeoWeatherURL = mockServer.URL + "/ometeo"
+	cfg.metnoWeatherURL = mockServer.URL + "/metno?"
+
+	locationID := uuid.New()
+	testCfg.mockDB.ListLocationsFunc = func(ctx context.Context) ([]database.Location, error) {
+		return []database.Location{{ID: locationID, CityName: "Test City"}}, nil
+	}
+	var mu sync.Mutex
+	runs := make(map[string]database.CreateSchedulerRunParams)
+	testCfg.mockDB.CreateSchedulerRunFunc = func(ctx context.Context, arg database.CreateSchedulerRunParams) error {
+		mu.Lock()
+		defer mu.Unlock()
+		runs[arg.Provider] = arg
+		return nil
+	}
+	testCfg.mockDB.ListAlertSubscriptionsForLocationFunc = func(ctx context.Context, locationID uuid.UUID) ([]database.AlertSubscription, error) {
+		return nil, nil
+	}
+	var prunedBefore time.Time
+	testCfg.mockDB.DeleteSchedulerRunsBeforeFunc = func(ctx context.Context, startedAt time.Time) (int64, error) {
+		prunedBefore = startedAt
+		return 0, nil
+	}
+
+	if err := NewScheduler(cfg).runHourlyForecastJobs(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(runs) != 4 {
+		t.Fatalf("expected 4 scheduler runs, got %d: %+v", len(runs), runs)
+	}
+	for provider, run := range runs {
+		if run.LocationID != locationID || run.JobType != hourlyForecastJobName {
+			t.Errorf("%s: unexpected location or job type: %+v", provider, run)
+		}
+	}
+	if gmp := runs["gmp"]; gmp.ErrorClass.Valid || gmp.RowsWritten == 0 || gmp.HoursCovered != gmp.RowsWritten {
+		t.Errorf("gmp: expected a successful run with one hour per row, got %+v", gmp)
+	}
+	if owm := runs["owm"]; owm.ErrorClass.String != errorClassRateLimited || owm.RowsWritten != 0 || !strings.Contains(owm.ErrorMessage.String, "429") {
+		t.Errorf("owm: expected a rate limited run, got %+v", owm)
+	}
+	if ometeo := runs["ometeo"]; ometeo.ErrorClass.String != errorClassServerError {
+		t.Errorf("ometeo: expected a server error run, got %+v", ometeo)
+	}
+	if metno := runs["metno"]; metno.ErrorClass.String != errorClassServerError {
+		t.Errorf("metno: expected a server error run, got %+v", metno)
+	}
+	if prunedBefore.IsZero() || time.Since(prunedBefore) < schedulerRunRetention {
+		t.Errorf("expected runs older than the retention to be pruned, pruned before %v", prunedBefore)
+	}
+}
+
+func TestHandlerSchedulerRuns(t *testing.T) {
+	locationID := uuid.New()
+	startedAt := time.Date(2025, 8, 4, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name           string
+		method         string
+		query          string
+		setup          func(cfg *testAPIConfig)
+		wantStatus     int
+		wantProvider   sql.NullString
+		wantRuns       int
+		wantErrorClass string
+	}{
+		{name: "method not allowed", method: http.MethodPost, query: "?city=Wroclaw", wantStatus: http.StatusMethodNotAllowed},
+		{name: "missing city", method: http.MethodGet, wantStatus: http.StatusBadRequest},
+		{name: "unknown provider", method: http.MethodGet, query: "?city=Wroclaw&provider=accuweather", wantStatus: http.StatusBadRequest},
+		{name: "invalid limit", method: http.MethodGet, query: "?city=Wroclaw&limit=0", wantStatus: http.StatusBadRequest},
+		{
+			name:   "location not found",
+			method: http.MethodGet,
+			query:  "?city=Atlantis",
+			setup: func(cfg *testAPIConfig) {
+				cfg.mockDB.GetLocationByAliasFunc = func(ctx context.Context, alias string) (database.Location, error) {
+					return database.Location{}, sql.ErrNoRows
+				}
+				cfg.mockDB.GetLocationByNameFunc = func(ctx context.Context, cityName string) (database.Location, error) {
+					return database.Location{}, sql.ErrNoRows
+				}
+			},
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:   "runs filtered by provider",
+			method: http.MethodGet,
+			query:  "?city=Wroclaw&provider=owm&limit=5",
+			setup: func(cfg *testAPIConfig) {
+				cfg.mockDB.GetLocationByAliasFunc = func(ctx context.Context, alias string) (database.Location, error) {
+					return database.Location{ID: locationID, CityName: "Wrocław"}, nil
+				}
+				cfg.mockDB.ListSchedulerRunsForLocationFunc = func(ctx context.Context, arg database.ListSchedulerRunsForLocationParams) ([]database.SchedulerRun, error) {
+					if arg.LocationID != locationID || arg.RowLimit != 5 {
+						return nil, fmt.Errorf("unexpected params %+v", arg)
+					}
+					return []database.SchedulerRun{
+						{JobType: hourlyForecastJobName, Provider: "owm", StartedAt: startedAt, DurationMs: 1200, ErrorClass: sql.NullString{String: errorClassRateLimited, Valid: true}, ErrorMessage: sql.NullString{String: "failed to fetch forecast: 429 Too Many Requests", Valid: true}},
+						{JobType: hourlyForecastJobName, Provider: "owm", StartedAt: startedAt.Add(-time.Hour), DurationMs: 300, RowsWritten: 24, HoursCovered: 24},
+					}, nil
+				}
+			},
+			wantStatus:     http.StatusOK,
+			wantProvider:   sql.NullString{String: "owm", Valid: true},
+			wantRuns:       2,
+			wantErrorClass: errorClassRateLimited,
+		},
+		{
+			name:   "database error",
+			method: http.MethodGet,
+			query:  "?city=Wroclaw",
+			setup: func(cfg *testAPIConfig) {
+				cfg.mockDB.GetLocationByAliasFunc = func(ctx context.Context, alias string) (database.Location, error) {
+					return database.Location{ID: locationID, CityName: "Wrocław"}, nil
+				}
+				cfg.mockDB.ListSchedulerRunsForLocationFunc = func(ctx context.Context, arg database.ListSchedulerRunsForLocationParams) ([]database.SchedulerRun, error) {
+					return nil, errors.New("db down")
+				}
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testCfg := newTestAPIConfig(t)
+			var gotProvider sql.NullString
+			if tc.setup != nil {
+				tc.setup(testCfg)
+				if list := testCfg.mockDB.ListSchedulerRunsForLocationFunc; list != nil {
+					testCfg.mockDB.ListSchedulerRunsForLocationFunc = func(ctx context.Context, arg database.ListSchedulerRunsForLocationParams) ([]database.SchedulerRun, error) {
+						gotProvider = arg.Provider
+						return list(ctx, arg)
+					}
+				}
+			}
+
+			req := httptest.NewRequest(tc.method, "/admin/scheduler/runs"+tc.query, nil)
+			rr := httptest.NewRecorder()
+			testCfg.apiConfig.handlerSchedulerRuns(rr, req)
+
+			if rr.Code != tc.wantStatus {
+				t.Fatalf("status = %d, want %d; body: %s", rr.Code, tc.wantStatus, rr.Body.String())
+			}
+			if tc.wantStatus != http.StatusOK {
+				return
+			}
+
+			var response SchedulerRunsResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if gotProvider != tc.wantProvider {
+				t.Errorf("provider filter = %+v, want %+v", gotProvider, tc.wantProvider)
+			}
+			if response.LocationID != locationID.String() || len(response.Runs) != tc.wantRuns {
+				t.Fatalf("unexpected response: %+v", response)
+			}
+			first, second := response.Runs[0], response.Runs[1]
+			if first.Success || first.ErrorClass != tc.wantErrorClass || first.StartedAt != "2025-08-04T12:00:00Z" {
+				t.Errorf("unexpected failed run: %+v", first)
+			}
+			if !second.Success || second.RowsWritten != 24 || second.HoursCovered != 24 || second.ErrorClass != "" {
+				t.Errorf("unexpected successful run: %+v", second)
+			}
+		})
+	}
+}
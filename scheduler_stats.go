@@ -0,0 +1,67 @@
+package main
+
+import (
+	"runtime"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// This file implements a Prometheus collector that exposes runtime and scheduler health
+// statistics. Unlike the static metrics in metrics.go, these values are derived at scrape
+// time, so they stay accurate even when the scheduler has stalled and stopped updating
+// its own counters.
+
+var (
+	goroutinesDesc = prometheus.NewDesc(
+		"willitrain_goroutines",
+		"Number of goroutines currently running in the application.",
+		nil, nil,
+	)
+	schedulerDriftDesc = prometheus.NewDesc(
+		"willitrain_scheduler_drift_seconds",
+		"Time since the last completed scheduler cycle minus the configured interval, by job type. Positive values mean the job is overdue.",
+		[]string{"job_type"}, nil,
+	)
+)
+
+// schedulerStatsCollector is a prometheus.Collector that reports the goroutine count
+// and the ticker drift of every job type managed by a Scheduler.
+type schedulerStatsCollector struct {
+	scheduler *Scheduler
+	now       func() time.Time
+}
+
+// newSchedulerStatsCollector creates a collector bound to the given scheduler.
+func newSchedulerStatsCollector(s *Scheduler) *schedulerStatsCollector {
+	return &schedulerStatsCollector{
+		scheduler: s,
+		now:       time.Now,
+	}
+}
+
+// Describe sends the descriptors of all metrics produced by the collector.
+func (c *schedulerStatsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- goroutinesDesc
+	ch <- schedulerDriftDesc
+}
+
+// Collect computes the current statistics and sends them as constant metrics.
+// If a job type has never completed, drift is measured from the scheduler's start time.
+func (c *schedulerStatsCollector) Collect(ch chan<- prometheus.Metric) {
+	ch <- prometheus.MustNewConstMetric(goroutinesDesc, prometheus.GaugeValue, float64(runtime.NumGoroutine()))
+
+	s := c.scheduler
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := c.now()
+	for _, j := range s.jobs {
+		last, ok := s.lastSuccess[j.Name]
+		if !ok {
+			last = s.startedAt
+		}
+		drift := now.Sub(last) - j.Interval
+		ch <- prometheus.MustNewConstMetric(schedulerDriftDesc, prometheus.GaugeValue, drift.Seconds(), j.Name)
+	}
+}
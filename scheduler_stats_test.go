@@ -0,0 +1,54 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestSchedulerStatsCollector(t *testing.T) {
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	now := start.Add(30 * time.Minute)
+
+	s := &Scheduler{
+		startedAt: start,
+		jobs: []*scheduledJob{
+			{SchedulerJob: SchedulerJob{Name: "current weather", Interval: 10 * time.Minute}},
+			{SchedulerJob: SchedulerJob{Name: "hourly forecast", Interval: 60 * time.Minute}},
+		},
+	}
+	s.recordJobSuccess("current weather", now.Add(-5*time.Minute))
+
+	c := newSchedulerStatsCollector(s)
+	c.now = func() time.Time { return now }
+
+	expected := `
+# HELP willitrain_scheduler_drift_seconds Time since the last completed scheduler cycle minus the configured interval, by job type. Positive values mean the job is overdue.
+# TYPE willitrain_scheduler_drift_seconds gauge
+willitrain_scheduler_drift_seconds{job_type="current weather"} -300
+willitrain_scheduler_drift_seconds{job_type="hourly forecast"} -1800
+`
+	if err := testutil.CollectAndCompare(c, strings.NewReader(expected), "willitrain_scheduler_drift_seconds"); err != nil {
+		t.Errorf("unexpected drift metrics: %v", err)
+	}
+
+	if count := testutil.CollectAndCount(c, "willitrain_goroutines"); count != 1 {
+		t.Errorf("expected 1 goroutine metric, got %d", count)
+	}
+}
+
+func TestRecordJobSuccess(t *testing.T) {
+	s := &Scheduler{}
+	at := time.Unix(1700000000, 0)
+
+	s.recordJobSuccess("daily forecast", at)
+
+	if got := s.lastSuccess["daily forecast"]; !got.Equal(at) {
+		t.Errorf("expected last success %v, got %v", at, got)
+	}
+	if got := testutil.ToFloat64(schedulerLastSuccessTimestamp.WithLabelValues("daily forecast")); got != float64(at.Unix()) {
+		t.Errorf("expected last success metric %v, got %v", at.Unix(), got)
+	}
+}
@@ -5,17 +5,20 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"io"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/cor0nius/willitrain/internal/database"
 	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 )
 
 func TestRunCurrentWeatherJobs(t *testing.T) {
@@ -87,7 +90,7 @@ func TestRunCurrentWeatherJobs(t *testing.T) {
 					return []database.Location{{ID: uuid.New(), CityName: "Test City 1"}}, nil
 				}
 				cfg.apiConfig.httpClient = &http.Client{
-					Transport: &errorTransport{err: apiErr},
+					Transport: &errorTransport{Err: apiErr},
 				}
 			},
 			expectedCreateCalls: 0,
@@ -108,11 +111,11 @@ func TestRunCurrentWeatherJobs(t *testing.T) {
 			testCfg.apiConfig.owmWeatherURL = mockServer.URL + "/owm"
 			testCfg.apiConfig.ometeoWeatherURL = mockServer.URL + "/ometeo"
 
-			s := NewScheduler(testCfg.apiConfig, 1*time.Minute, 1*time.Minute, 1*time.Minute)
-			s.runCurrentWeatherJobs()
+			s := NewScheduler(testCfg.apiConfig)
+			s.runCurrentWeatherJobs(context.Background())
 
-			if testCfg.mockDB.createCurrentWeatherCalls != tt.expectedCreateCalls {
-				t.Errorf("expected %d calls to CreateCurrentWeather, got %d", tt.expectedCreateCalls, testCfg.mockDB.createCurrentWeatherCalls)
+			if testCfg.mockDB.Calls("CreateCurrentWeather") != tt.expectedCreateCalls {
+				t.Errorf("expected %d calls to CreateCurrentWeather, got %d", tt.expectedCreateCalls, testCfg.mockDB.Calls("CreateCurrentWeather"))
 			}
 
 			logOutput := logBuffer.String()
@@ -149,7 +152,7 @@ func TestRunDailyForecastJobs(t *testing.T) {
 	tests := []struct {
 		name                string
 		setup               func(t *testing.T, cfg *testAPIConfig)
-		expectedCreateCalls int
+		expectedUpsertCalls int
 		expectedLogContains string
 		expectErrorInLog    bool
 		expectSuccessInLog  bool
@@ -163,15 +166,9 @@ func TestRunDailyForecastJobs(t *testing.T) {
 						{ID: uuid.New(), CityName: "Test City 2"},
 					}, nil
 				}
-				cfg.mockDB.GetDailyForecastAtLocationAndDateFromAPIFunc = func(ctx context.Context, arg database.GetDailyForecastAtLocationAndDateFromAPIParams) (database.DailyForecast, error) {
-					return database.DailyForecast{}, sql.ErrNoRows
-				}
-				cfg.mockDB.CreateDailyForecastFunc = func(ctx context.Context, arg database.CreateDailyForecastParams) (database.DailyForecast, error) {
-					return database.DailyForecast{}, nil
-				}
 				cfg.apiConfig.httpClient = mockServer.Client()
 			},
-			expectedCreateCalls: 2 * 3 * 5, // 2 locations, 3 APIs, 5 days
+			expectedUpsertCalls: 2 * 3, // 2 locations, 3 APIs
 			expectSuccessInLog:  true,
 		},
 		{
@@ -184,7 +181,7 @@ func TestRunDailyForecastJobs(t *testing.T) {
 					return dbErr
 				}
 			},
-			expectedCreateCalls: 0,
+			expectedUpsertCalls: 0,
 			expectedLogContains: "failed to delete daily forecasts",
 			expectErrorInLog:    true,
 		},
@@ -195,10 +192,10 @@ func TestRunDailyForecastJobs(t *testing.T) {
 					return []database.Location{{ID: uuid.New(), CityName: "Test City 1"}}, nil
 				}
 				cfg.apiConfig.httpClient = &http.Client{
-					Transport: &errorTransport{err: apiErr},
+					Transport: &errorTransport{Err: apiErr},
 				}
 			},
-			expectedCreateCalls: 0,
+			expectedUpsertCalls: 0,
 			expectedLogContains: "failed to request daily forecast",
 			expectErrorInLog:    true,
 		},
@@ -216,11 +213,11 @@ func TestRunDailyForecastJobs(t *testing.T) {
 			testCfg.apiConfig.owmWeatherURL = mockServer.URL + "/owm"
 			testCfg.apiConfig.ometeoWeatherURL = mockServer.URL + "/ometeo"
 
-			s := NewScheduler(testCfg.apiConfig, 1*time.Minute, 1*time.Minute, 1*time.Minute)
-			s.runDailyForecastJobs()
+			s := NewScheduler(testCfg.apiConfig)
+			s.runDailyForecastJobs(context.Background())
 
-			if testCfg.mockDB.createDailyForecastCalls != tt.expectedCreateCalls {
-				t.Errorf("expected %d calls to CreateDailyForecast, got %d", tt.expectedCreateCalls, testCfg.mockDB.createDailyForecastCalls)
+			if testCfg.mockDB.Calls("UpsertDailyForecasts") != tt.expectedUpsertCalls {
+				t.Errorf("expected %d calls to UpsertDailyForecasts, got %d", tt.expectedUpsertCalls, testCfg.mockDB.Calls("UpsertDailyForecasts"))
 			}
 
 			logOutput := logBuffer.String()
@@ -257,7 +254,7 @@ func TestRunHourlyForecastJobs(t *testing.T) {
 	tests := []struct {
 		name                string
 		setup               func(t *testing.T, cfg *testAPIConfig)
-		expectedCreateCalls int
+		expectedUpsertCalls int
 		expectedLogContains string
 		expectErrorInLog    bool
 		expectSuccessInLog  bool
@@ -271,15 +268,12 @@ func TestRunHourlyForecastJobs(t *testing.T) {
 						{ID: uuid.New(), CityName: "Test City 2"},
 					}, nil
 				}
-				cfg.mockDB.GetHourlyForecastAtLocationAndTimeFromAPIFunc = func(ctx context.Context, arg database.GetHourlyForecastAtLocationAndTimeFromAPIParams) (database.HourlyForecast, error) {
-					return database.HourlyForecast{}, sql.ErrNoRows
-				}
-				cfg.mockDB.CreateHourlyForecastFunc = func(ctx context.Context, arg database.CreateHourlyForecastParams) (database.HourlyForecast, error) {
-					return database.HourlyForecast{}, nil
+				cfg.mockDB.ListAlertSubscriptionsForLocationFunc = func(ctx context.Context, locationID uuid.UUID) ([]database.AlertSubscription, error) {
+					return nil, nil
 				}
 				cfg.apiConfig.httpClient = mockServer.Client()
 			},
-			expectedCreateCalls: 2 * 3 * 24, // 2 locations, 3 APIs, 24 hours
+			expectedUpsertCalls: 2 * 3, // 2 locations, 3 APIs
 			expectSuccessInLog:  true,
 		},
 		{
@@ -292,7 +286,7 @@ func TestRunHourlyForecastJobs(t *testing.T) {
 					return dbErr
 				}
 			},
-			expectedCreateCalls: 0,
+			expectedUpsertCalls: 0,
 			expectedLogContains: "failed to delete hourly forecasts",
 			expectErrorInLog:    true,
 		},
@@ -303,10 +297,10 @@ func TestRunHourlyForecastJobs(t *testing.T) {
 					return []database.Location{{ID: uuid.New(), CityName: "Test City 1"}}, nil
 				}
 				cfg.apiConfig.httpClient = &http.Client{
-					Transport: &errorTransport{err: apiErr},
+					Transport: &errorTransport{Err: apiErr},
 				}
 			},
-			expectedCreateCalls: 0,
+			expectedUpsertCalls: 0,
 			expectedLogContains: "failed to request hourly forecast",
 			expectErrorInLog:    true,
 		},
@@ -324,11 +318,11 @@ func TestRunHourlyForecastJobs(t *testing.T) {
 			testCfg.apiConfig.owmWeatherURL = mockServer.URL + "/owm"
 			testCfg.apiConfig.ometeoWeatherURL = mockServer.URL + "/ometeo"
 
-			s := NewScheduler(testCfg.apiConfig, 1*time.Minute, 1*time.Minute, 1*time.Minute)
-			s.runHourlyForecastJobs()
+			s := NewScheduler(testCfg.apiConfig)
+			s.runHourlyForecastJobs(context.Background())
 
-			if testCfg.mockDB.createHourlyForecastCalls != tt.expectedCreateCalls {
-				t.Errorf("expected %d calls to CreateHourlyForecast, got %d", tt.expectedCreateCalls, testCfg.mockDB.createHourlyForecastCalls)
+			if testCfg.mockDB.Calls("UpsertHourlyForecasts") != tt.expectedUpsertCalls {
+				t.Errorf("expected %d calls to UpsertHourlyForecasts, got %d", tt.expectedUpsertCalls, testCfg.mockDB.Calls("UpsertHourlyForecasts"))
 			}
 
 			logOutput := logBuffer.String()
@@ -345,35 +339,37 @@ func TestRunHourlyForecastJobs(t *testing.T) {
 func TestScheduler_Ticks(t *testing.T) {
 	// --- Setup ---
 	testCfg := newTestAPIConfig(t)
-
-	currentChan := make(chan time.Time)
-	hourlyChan := make(chan time.Time)
-	dailyChan := make(chan time.Time)
-
-	s := &Scheduler{
-		cfg:         testCfg.apiConfig,
-		currentChan: currentChan,
-		hourlyChan:  hourlyChan,
-		dailyChan:   dailyChan,
-		stop:        make(chan struct{}),
-	}
+	s := NewScheduler(testCfg.apiConfig)
 
 	// --- Mock Job Functions ---
 	var wg sync.WaitGroup
 	var currentCalled, hourlyCalled, dailyCalled bool
-
-	s.currentWeatherJobs = func() {
-		currentCalled = true
-		wg.Done()
-	}
-	s.hourlyForecastJobs = func() {
-		hourlyCalled = true
-		wg.Done()
+	jobs := []SchedulerJob{
+		{Name: currentWeatherJobName, Interval: time.Hour, Run: func(context.Context) error {
+			currentCalled = true
+			wg.Done()
+			return nil
+		}},
+		{Name: hourlyForecastJobName, Interval: time.Hour, Run: func(context.Context) error {
+			hourlyCalled = true
+			wg.Done()
+			return nil
+		}},
+		{Name: dailyForecastJobName, Interval: time.Hour, Run: func(context.Context) error {
+			dailyCalled = true
+			wg.Done()
+			return nil
+		}},
 	}
-	s.dailyForecastJobs = func() {
-		dailyCalled = true
-		wg.Done()
+	ticks := make([]chan time.Time, len(jobs))
+	for i, job := range jobs {
+		if err := s.RegisterJob(job); err != nil {
+			t.Fatalf("failed to register job: %v", err)
+		}
+		ticks[i] = make(chan time.Time)
+		s.jobs[i].tick = ticks[i]
 	}
+	currentChan, hourlyChan, dailyChan := ticks[0], ticks[1], ticks[2]
 
 	// --- Action & Assertions ---
 	s.Start()
@@ -414,6 +410,90 @@ func TestScheduler_Ticks(t *testing.T) {
 			t.Error("expected hourly forecast job to be called, but it wasn't")
 		}
 	})
+
+	t.Run("PausedJobSkipsTick", func(t *testing.T) {
+		currentCalled = false
+		if err := s.Pause(currentWeatherJobName); err != nil {
+			t.Fatalf("failed to pause job: %v", err)
+		}
+		currentChan <- time.Now()
+		// A second send only returns once the loop has finished handling the first tick.
+		currentChan <- time.Now()
+
+		if currentCalled {
+			t.Error("expected paused job not to be called, but it was")
+		}
+
+		if err := s.Resume(currentWeatherJobName); err != nil {
+			t.Fatalf("failed to resume job: %v", err)
+		}
+		wg.Add(1)
+		currentChan <- time.Now()
+		wg.Wait()
+
+		if !currentCalled {
+			t.Error("expected resumed job to be called, but it wasn't")
+		}
+	})
+}
+
+func TestScheduler_RegisterJob(t *testing.T) {
+	noop := func(context.Context) error { return nil }
+
+	testCases := []struct {
+		name    string
+		job     SchedulerJob
+		wantErr bool
+	}{
+		{name: "Valid", job: SchedulerJob{Name: "prune", Interval: time.Hour, Run: noop}},
+		{name: "Duplicate Name", job: SchedulerJob{Name: currentWeatherJobName, Interval: time.Hour, Run: noop}, wantErr: true},
+		{name: "Empty Name", job: SchedulerJob{Interval: time.Hour, Run: noop}, wantErr: true},
+		{name: "Zero Interval", job: SchedulerJob{Name: "prune", Run: noop}, wantErr: true},
+		{name: "Missing Run", job: SchedulerJob{Name: "prune", Interval: time.Hour}, wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := NewScheduler(newTestAPIConfig(t).apiConfig)
+			if err := s.RegisterJob(SchedulerJob{Name: currentWeatherJobName, Interval: time.Hour, Run: noop}); err != nil {
+				t.Fatalf("failed to register initial job: %v", err)
+			}
+
+			err := s.RegisterJob(tc.job)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("expected error: %v, got: %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestScheduler_Status(t *testing.T) {
+	testCfg := newTestAPIConfig(t)
+	s := NewScheduler(testCfg.apiConfig)
+	jobErr := errors.New("job failed")
+	_ = s.RegisterJob(SchedulerJob{Name: "ok", Interval: time.Minute, Run: func(context.Context) error { return nil }})
+	_ = s.RegisterJob(SchedulerJob{Name: "failing", Interval: time.Hour, Run: func(context.Context) error { return jobErr }})
+
+	if err := s.Pause("failing"); err != nil {
+		t.Fatalf("failed to pause job: %v", err)
+	}
+	if err := s.Pause("missing"); !errors.Is(err, errSchedulerJobNotFound) {
+		t.Errorf("expected errSchedulerJobNotFound, got %v", err)
+	}
+	for _, j := range s.jobs {
+		s.runJob(j)
+	}
+
+	statuses := s.Status()
+	if len(statuses) != 2 {
+		t.Fatalf("expected 2 job statuses, got %d", len(statuses))
+	}
+	if statuses[0].Name != "ok" || statuses[0].Interval != "1m0s" || statuses[0].LastSuccess == "" || statuses[0].LastError != "" {
+		t.Errorf("unexpected status for successful job: %+v", statuses[0])
+	}
+	if !statuses[1].Paused || statuses[1].LastSuccess != "" || statuses[1].LastError != jobErr.Error() {
+		t.Errorf("unexpected status for failing job: %+v", statuses[1])
+	}
 }
 
 func TestRunUpdateForLocations_DBError(t *testing.T) {
@@ -427,14 +507,18 @@ func TestRunUpdateForLocations_DBError(t *testing.T) {
 	s := &Scheduler{cfg: testCfg.apiConfig}
 
 	var updateFuncCalled bool
-	mockUpdateFunc := func(ctx context.Context, location Location) {
+	mockUpdateFunc := func(ctx context.Context, location Location) error {
 		updateFuncCalled = true
+		return nil
 	}
 
 	// --- Action ---
-	s.runUpdateForLocations("test job", mockUpdateFunc)
+	err := s.runUpdateForLocations(context.Background(), "test job", mockUpdateFunc)
 
 	// --- Assertions ---
+	if !errors.Is(err, dbErr) {
+		t.Errorf("expected error wrapping %v, got %v", dbErr, err)
+	}
 	if updateFuncCalled {
 		t.Error("expected updateFunc not to be called when ListLocations fails, but it was")
 	}
@@ -497,42 +581,318 @@ func TestRunUpdateForLocations_PartialAPIFailure(t *testing.T) {
 	testCfg.apiConfig.owmWeatherURL = mockServer.URL + "/owm?"
 	testCfg.apiConfig.ometeoWeatherURL = mockServer.URL + "/ometeo?"
 	testCfg.apiConfig.httpClient = mockServer.Client()
-	testCfg.apiConfig.gmpKey = "dummy"
-	testCfg.apiConfig.owmKey = "dummy"
+	testCfg.apiConfig.gmpKey = newSecretValue("dummy")
+	testCfg.apiConfig.owmKey = newSecretValue("dummy")
 
-	s := NewScheduler(testCfg.apiConfig, 1*time.Minute, 1*time.Minute, 1*time.Minute)
+	s := NewScheduler(testCfg.apiConfig)
 
 	// --- Action ---
-	s.runCurrentWeatherJobs()
+	s.runCurrentWeatherJobs(context.Background())
 
 	// --- Assertions ---
 	expectedCalls := 3
-	if testCfg.mockDB.createCurrentWeatherCalls != expectedCalls {
-		t.Errorf("expected %d calls to CreateCurrentWeather for the successful location, but got %d", expectedCalls, testCfg.mockDB.createCurrentWeatherCalls)
+	if testCfg.mockDB.Calls("CreateCurrentWeather") != expectedCalls {
+		t.Errorf("expected %d calls to CreateCurrentWeather for the successful location, but got %d", expectedCalls, testCfg.mockDB.Calls("CreateCurrentWeather"))
+	}
+}
+
+func TestRunUpdateForLocations_Concurrency(t *testing.T) {
+	testCfg := newTestAPIConfig(t)
+	locations := make([]database.Location, 6)
+	for i := range locations {
+		locations[i] = database.Location{ID: uuid.New(), CityName: "City"}
+	}
+	testCfg.mockDB.ListLocationsFunc = func(ctx context.Context) ([]database.Location, error) {
+		return locations, nil
+	}
+
+	s := NewScheduler(testCfg.apiConfig)
+	s.concurrency = 2
+	s.jitter = 20 * time.Millisecond
+
+	var calls, inFlight, maxInFlight atomic.Int32
+	updateFunc := func(ctx context.Context, location Location) error {
+		calls.Add(1)
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			peak := maxInFlight.Load()
+			if n <= peak || maxInFlight.CompareAndSwap(peak, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		return nil
+	}
+
+	if err := s.runUpdateForLocations(context.Background(), "test job", updateFunc); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := calls.Load(); got != int32(len(locations)) {
+		t.Errorf("expected %d updates, got %d", len(locations), got)
+	}
+	if got := maxInFlight.Load(); got > 2 {
+		t.Errorf("expected at most 2 concurrent updates, got %d", got)
+	}
+}
+
+func TestRunUpdateForLocations_StoppedDuringJitter(t *testing.T) {
+	testCfg := newTestAPIConfig(t)
+	testCfg.mockDB.ListLocationsFunc = func(ctx context.Context) ([]database.Location, error) {
+		return []database.Location{{ID: uuid.New(), CityName: "City"}}, nil
+	}
+
+	s := NewScheduler(testCfg.apiConfig)
+	s.jitter = time.Hour
+	events, unsubscribe, err := s.events.subscribe()
+	if err != nil {
+		t.Fatalf("failed to subscribe to events: %v", err)
+	}
+	defer unsubscribe()
+	close(s.stop)
+
+	var called bool
+	done := make(chan error)
+	go func() {
+		done <- s.runUpdateForLocations(context.Background(), "test job", func(ctx context.Context, location Location) error {
+			called = true
+			return nil
+		})
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the cycle to end when the scheduler stops")
+	}
+	if called {
+		t.Error("expected the waiting update to be skipped")
+	}
+	if event := <-events; event.Type != schedulerEventLocationSkipped {
+		t.Errorf("expected a %s event, got %+v", schedulerEventLocationSkipped, event)
+	}
+}
+
+func TestRunUpdateForLocations_LocationTimeout(t *testing.T) {
+	testCfg := newTestAPIConfig(t)
+	testCfg.mockDB.ListLocationsFunc = func(ctx context.Context) ([]database.Location, error) {
+		return []database.Location{{ID: uuid.New(), CityName: "Hung"}, {ID: uuid.New(), CityName: "Fast"}}, nil
+	}
+
+	s := NewScheduler(testCfg.apiConfig)
+	s.locationTimeout = 20 * time.Millisecond
+	events, unsubscribe, err := s.events.subscribe()
+	if err != nil {
+		t.Fatalf("failed to subscribe to events: %v", err)
+	}
+	defer unsubscribe()
+	timeouts := schedulerTimeouts.WithLabelValues("timeout job", "location")
+	before := testutil.ToFloat64(timeouts)
+
+	err = s.runUpdateForLocations(context.Background(), "timeout job", func(ctx context.Context, location Location) error {
+		if location.CityName == "Fast" {
+			return nil
+		}
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	outcomes := make(map[string]string)
+	for range 2 {
+		event := <-events
+		outcomes[event.CityName] = event.Type
+	}
+	if outcomes["Hung"] != schedulerEventLocationFailed || outcomes["Fast"] != schedulerEventLocationSucceeded {
+		t.Errorf("expected the hung update to fail and the other to succeed, got %v", outcomes)
+	}
+	if got := testutil.ToFloat64(timeouts) - before; got != 1 {
+		t.Errorf("expected 1 location timeout to be counted, got %v", got)
+	}
+}
+
+func TestRunJob_Timeout(t *testing.T) {
+	testCfg := newTestAPIConfig(t)
+	s := NewScheduler(testCfg.apiConfig)
+	s.jobTimeout = 20 * time.Millisecond
+	job := &scheduledJob{SchedulerJob: SchedulerJob{Name: "hung job", Interval: time.Hour, Run: func(ctx context.Context) error {
+		<-ctx.Done()
+		return nil
+	}}}
+	s.jobs = append(s.jobs, job)
+	timeouts := schedulerTimeouts.WithLabelValues("hung job", "job")
+	before := testutil.ToFloat64(timeouts)
+
+	s.runJob(job)
+
+	if !errors.Is(job.lastErr, context.DeadlineExceeded) {
+		t.Errorf("expected the run to fail with a deadline error, got %v", job.lastErr)
+	}
+	if got := testutil.ToFloat64(timeouts) - before; got != 1 {
+		t.Errorf("expected 1 job timeout to be counted, got %v", got)
+	}
+}
+
+func TestGetSchedulerTimeouts(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	testCases := []struct {
+		job          string
+		location     string
+		wantJob      time.Duration
+		wantLocation time.Duration
+	}{
+		{"", "", 0, defaultSchedulerLocationTimeoutSec * time.Second},
+		{"300", "15", 5 * time.Minute, 15 * time.Second},
+		{"0", "0", 0, 0},
+		{"-1", "-1", 0, defaultSchedulerLocationTimeoutSec * time.Second},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.job+"/"+tc.location, func(t *testing.T) {
+			t.Setenv("SCHEDULER_JOB_TIMEOUT_SEC", tc.job)
+			t.Setenv("SCHEDULER_LOCATION_TIMEOUT_SEC", tc.location)
+			if got := getSchedulerJobTimeout(logger); got != tc.wantJob {
+				t.Errorf("getSchedulerJobTimeout() = %v, want %v", got, tc.wantJob)
+			}
+			if got := getSchedulerLocationTimeout(logger); got != tc.wantLocation {
+				t.Errorf("getSchedulerLocationTimeout() = %v, want %v", got, tc.wantLocation)
+			}
+		})
+	}
+}
+
+func TestGetSchedulerConcurrencyAndJitter(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	testCases := []struct {
+		concurrency     string
+		jitter          string
+		wantConcurrency int
+		wantJitter      time.Duration
+	}{
+		{"", "", defaultSchedulerConcurrency, defaultSchedulerJitterSec * time.Second},
+		{"10", "120", 10, 2 * time.Minute},
+		{"1", "0", 1, 0},
+		{"0", "-5", defaultSchedulerConcurrency, defaultSchedulerJitterSec * time.Second},
+		{"many", "soon", defaultSchedulerConcurrency, defaultSchedulerJitterSec * time.Second},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.concurrency+"/"+tc.jitter, func(t *testing.T) {
+			t.Setenv("SCHEDULER_CONCURRENCY", tc.concurrency)
+			t.Setenv("SCHEDULER_JITTER_SEC", tc.jitter)
+			if got := getSchedulerConcurrency(logger); got != tc.wantConcurrency {
+				t.Errorf("getSchedulerConcurrency() = %d, want %d", got, tc.wantConcurrency)
+			}
+			if got := getSchedulerJitter(logger); got != tc.wantJitter {
+				t.Errorf("getSchedulerJitter() = %v, want %v", got, tc.wantJitter)
+			}
+		})
 	}
 }
 
 func TestScheduler_Stop(t *testing.T) {
 	testCfg := newTestAPIConfig(t)
-	s := NewScheduler(testCfg.apiConfig, 1*time.Millisecond, 1*time.Millisecond, 1*time.Millisecond)
+	s := NewScheduler(testCfg.apiConfig)
 
-	s.currentWeatherJobs = func() {}
-	s.hourlyForecastJobs = func() {}
-	s.dailyForecastJobs = func() {}
+	for _, job := range s.weatherJobs(1*time.Millisecond, 1*time.Millisecond, 1*time.Millisecond) {
+		job.Run = func(context.Context) error { return nil }
+		if err := s.RegisterJob(job); err != nil {
+			t.Fatalf("failed to register job: %v", err)
+		}
+	}
 
 	s.Start()
 	s.Stop()
 
 	time.Sleep(5 * time.Millisecond)
 
-	select {
-	case tick := <-s.currentChan:
-		t.Errorf("received tick on currentChan after stop: %v", tick)
-	case tick := <-s.hourlyChan:
-		t.Errorf("received tick on hourlyChan after stop: %v", tick)
-	case tick := <-s.dailyChan:
-		t.Errorf("received tick on dailyChan after stop: %v", tick)
-	default:
-		// No ticks received, as expected.
+	for _, j := range s.jobs {
+		select {
+		case tick := <-j.tick:
+			t.Errorf("received tick on %s after stop: %v", j.Name, tick)
+		default:
+			// No ticks received, as expected.
+		}
+	}
+}
+
+func TestScheduler_StopDrain(t *testing.T) {
+	testCases := []struct {
+		name          string
+		drainTimeout  time.Duration
+		wantCancelled bool
+	}{
+		{name: "Running Job Drained", drainTimeout: time.Second},
+		{name: "Running Job Cancelled After Drain Timeout", drainTimeout: 20 * time.Millisecond, wantCancelled: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testCfg := newTestAPIConfig(t)
+			testCfg.schedulerDrainTimeout = tc.drainTimeout
+			testCfg.mockDB.ListLocationsFunc = func(ctx context.Context) ([]database.Location, error) {
+				return []database.Location{MockDBLocation}, nil
+			}
+			s := NewScheduler(testCfg.apiConfig)
+
+			started := make(chan struct{})
+			var cancelled atomic.Bool
+			job := SchedulerJob{Name: "slow job", Interval: time.Hour, Run: func(ctx context.Context) error {
+				return s.runUpdateForLocations(ctx, "slow job", func(ctx context.Context, location Location) error {
+					close(started)
+					select {
+					case <-time.After(100 * time.Millisecond):
+					case <-ctx.Done():
+						cancelled.Store(true)
+					}
+					return nil
+				})
+			}}
+			if err := s.RegisterJob(job); err != nil {
+				t.Fatalf("failed to register job: %v", err)
+			}
+			tick := make(chan time.Time)
+			s.jobs[0].tick = tick
+			s.Start()
+			tick <- time.Now()
+			<-started
+
+			summary := s.Stop()
+
+			if len(summary.Running) != 1 || summary.Running[0] != "slow job" {
+				t.Errorf("expected the slow job to be reported as running, got %v", summary.Running)
+			}
+			if got := len(summary.Cancelled) > 0; got != tc.wantCancelled {
+				t.Errorf("expected cancelled: %v, got %v", tc.wantCancelled, summary.Cancelled)
+			}
+			if cancelled.Load() != tc.wantCancelled {
+				t.Errorf("expected the update context to be cancelled: %v", tc.wantCancelled)
+			}
+			if len(summary.Abandoned) != 0 {
+				t.Errorf("expected no abandoned jobs, got %v", summary.Abandoned)
+			}
+		})
+	}
+}
+
+func TestGetSchedulerDrainTimeout(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	testCases := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", defaultSchedulerDrainTimeoutSec * time.Second},
+		{"45", 45 * time.Second},
+		{"0", 0},
+		{"-1", defaultSchedulerDrainTimeoutSec * time.Second},
+	}
+	for _, tc := range testCases {
+		t.Setenv("SCHEDULER_DRAIN_TIMEOUT_SEC", tc.value)
+		if got := getSchedulerDrainTimeout(logger); got != tc.want {
+			t.Errorf("getSchedulerDrainTimeout() with %q = %v, want %v", tc.value, got, tc.want)
+		}
 	}
 }
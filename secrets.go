@@ -0,0 +1,292 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	secretmanager "cloud.google.com/go/secretmanager/apiv1"
+	"cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+	"github.com/jackc/pgx/v5"
+	"github.com/redis/go-redis/v9"
+)
+
+// This file implements loading the provider API keys, the database and Redis URLs and the user
+// session signing key from secret files or Google Secret Manager, so that they never appear in the deployment manifest.
+// For each of these settings, X_FILE names a file holding the value, such as a mounted
+// Kubernetes or Docker secret, and X_SECRET names a Secret Manager secret, as
+// projects/PROJECT/secrets/NAME with an optional /versions/VERSION that defaults to latest.
+// They are read before the config file and the environment, and the value is exported as X.
+//
+// The secrets are read again every SECRETS_RELOAD_SEC. A rotated API key is used by the next
+// provider request, and rotated database or Redis credentials by the next connection opened, so
+// rotations need no restart as long as the old credentials stay valid until the pools have
+// recycled their connections. Changes of the host or database name in the URLs are ignored until
+// the next restart.
+
+const (
+	secretFileSuffix    = "_FILE"
+	secretManagerSuffix = "_SECRET"
+
+	defaultSecretsReloadSec = 60
+	secretReadTimeout       = 10 * time.Second
+)
+
+// secretSettings are the settings that can be loaded from secrets.
+var secretSettings = []string{"GMP_KEY", "OWM_KEY", "DB_URL", "REDIS_URL", "USER_JWT_SECRET"}
+
+// secretValue is a setting that can change at runtime when its secret is rotated. The zero
+// value and a nil pointer are empty.
+type secretValue struct {
+	v atomic.Pointer[string]
+}
+
+func newSecretValue(s string) *secretValue {
+	sv := &secretValue{}
+	sv.Set(s)
+	return sv
+}
+
+// Get returns the current value.
+func (s *secretValue) Get() string {
+	if s == nil {
+		return ""
+	}
+	if v := s.v.Load(); v != nil {
+		return *v
+	}
+	return ""
+}
+
+// Set replaces the value.
+func (s *secretValue) Set(v string) {
+	s.v.Store(&v)
+}
+
+// secretSource is where a setting is loaded from: a file or a Secret Manager secret version.
+type secretSource struct {
+	setting string
+	file    string
+	version string
+}
+
+func (src secretSource) String() string {
+	if src.file != "" {
+		return src.setting + secretFileSuffix
+	}
+	return src.setting + secretManagerSuffix
+}
+
+// secretAccessor reads the payload of a Secret Manager secret version.
+type secretAccessor interface {
+	AccessSecret(ctx context.Context, version string) (string, error)
+}
+
+// gcpSecretAccessor reads secrets from Google Secret Manager with the application default
+// credentials.
+type gcpSecretAccessor struct {
+	client *secretmanager.Client
+}
+
+func (a gcpSecretAccessor) AccessSecret(ctx context.Context, version string) (string, error) {
+	resp, err := a.client.AccessSecretVersion(ctx, &secretmanagerpb.AccessSecretVersionRequest{Name: version})
+	if err != nil {
+		return "", err
+	}
+	return string(resp.GetPayload().GetData()), nil
+}
+
+// secretStore holds the settings loaded from secrets and reads them again on reload.
+type secretStore struct {
+	sources  []secretSource
+	accessor secretAccessor
+	values   map[string]*secretValue
+	// mu serializes reloads.
+	mu sync.Mutex
+}
+
+// value returns the current value of a setting loaded from a secret, or nil if it was not.
+func (s *secretStore) value(setting string) *secretValue {
+	if s == nil {
+		return nil
+	}
+	return s.values[setting]
+}
+
+// valueOr returns the value of a setting loaded from a secret, so that it follows rotations, or
+// a fixed value if it was not.
+func (s *secretStore) valueOr(setting, fixed string) *secretValue {
+	if v := s.value(setting); v != nil {
+		return v
+	}
+	return newSecretValue(fixed)
+}
+
+// read returns the value of a source, with surrounding whitespace, such as the trailing newline
+// of most secret files, removed.
+func (s *secretStore) read(ctx context.Context, src secretSource) (string, error) {
+	var raw string
+	if src.file != "" {
+		data, err := os.ReadFile(src.file)
+		if err != nil {
+			return "", err
+		}
+		raw = string(data)
+	} else {
+		var err error
+		if raw, err = s.accessor.AccessSecret(ctx, src.version); err != nil {
+			return "", err
+		}
+	}
+	value := strings.TrimSpace(raw)
+	if value == "" {
+		return "", errors.New("secret is empty")
+	}
+	return value, nil
+}
+
+// loadSecrets reads the settings whose X_FILE or X_SECRET variable is set and exports their
+// values as X. It returns the store used to reload them, or nil if no setting uses a secret,
+// and the names of the variables it read. A Secret Manager client is only created if a secret
+// refers to it.
+func loadSecrets(ctx context.Context) (*secretStore, []string, error) {
+	store := &secretStore{values: make(map[string]*secretValue)}
+	var errs []error
+	for _, setting := range secretSettings {
+		file, version := os.Getenv(setting+secretFileSuffix), os.Getenv(setting+secretManagerSuffix)
+		if file == "" && version == "" {
+			continue
+		}
+		if (file != "" && version != "") || os.Getenv(setting) != "" {
+			errs = append(errs, fmt.Errorf("set only one of %s, %s%s and %s%s", setting, setting, secretFileSuffix, setting, secretManagerSuffix))
+			continue
+		}
+		if version != "" && !strings.Contains(version, "/versions/") {
+			version += "/versions/latest"
+		}
+		store.sources = append(store.sources, secretSource{setting: setting, file: file, version: version})
+	}
+	if len(errs) > 0 {
+		return nil, nil, errors.Join(errs...)
+	}
+	if len(store.sources) == 0 {
+		return nil, nil, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, secretReadTimeout)
+	defer cancel()
+	var loaded []string
+	for _, src := range store.sources {
+		if src.version != "" && store.accessor == nil {
+			client, err := secretmanager.NewClient(ctx)
+			if err != nil {
+				return nil, nil, fmt.Errorf("could not create Secret Manager client: %w", err)
+			}
+			store.accessor = gcpSecretAccessor{client: client}
+		}
+		value, err := store.read(ctx, src)
+		if err != nil {
+			return nil, nil, fmt.Errorf("could not read %s: %w", src, err)
+		}
+		if err := os.Setenv(src.setting, value); err != nil {
+			return nil, nil, fmt.Errorf("could not apply %s: %w", src, err)
+		}
+		store.values[src.setting] = newSecretValue(value)
+		loaded = append(loaded, src.String())
+	}
+	sort.Strings(loaded)
+	return store, loaded, nil
+}
+
+// reload reads every source again and updates the values that changed. A source that cannot be
+// read keeps its value. It returns the settings that changed.
+func (s *secretStore) reload(ctx context.Context) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var changed []string
+	var errs []error
+	for _, src := range s.sources {
+		value, err := s.read(ctx, src)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("could not read %s: %w", src, err))
+			continue
+		}
+		if current := s.values[src.setting]; current.Get() != value {
+			current.Set(value)
+			changed = append(changed, src.setting)
+		}
+	}
+	return changed, errors.Join(errs...)
+}
+
+// getSecretsReloadInterval reads how often secrets are read again from SECRETS_RELOAD_SEC.
+func getSecretsReloadInterval(logger *slog.Logger) time.Duration {
+	sec := getEnvAsInt("SECRETS_RELOAD_SEC", defaultSecretsReloadSec, logger)
+	if sec <= 0 {
+		logger.Warn("SECRETS_RELOAD_SEC must be positive, using default", "value", sec)
+		sec = defaultSecretsReloadSec
+	}
+	return time.Duration(sec) * time.Second
+}
+
+// watchSecrets reloads the secrets every interval until ctx is done. It does nothing if no
+// setting is loaded from a secret.
+func (cfg *apiConfig) watchSecrets(ctx context.Context, interval time.Duration) {
+	if cfg.secrets == nil {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			readCtx, cancel := context.WithTimeout(ctx, secretReadTimeout)
+			changed, err := cfg.secrets.reload(readCtx)
+			cancel()
+			if err != nil {
+				cfg.logger.Warn("could not reload secrets, keeping the previous values", "error", err)
+			}
+			for _, setting := range changed {
+				cfg.logger.Info("secret rotated", "setting", setting)
+				secretRotations.WithLabelValues(setting).Inc()
+			}
+		}
+	}
+}
+
+// setDBCredentials makes new database connections use the credentials of the current DB_URL,
+// if it is loaded from a secret.
+func (cfg *apiConfig) setDBCredentials(ctx context.Context, conn *pgx.ConnConfig) error {
+	dbURL := cfg.secrets.value("DB_URL")
+	if dbURL == nil {
+		return nil
+	}
+	current, err := pgx.ParseConfig(dbURL.Get())
+	if err != nil {
+		return fmt.Errorf("could not parse rotated DB_URL: %w", err)
+	}
+	conn.User, conn.Password = current.User, current.Password
+	return nil
+}
+
+// redisCredentials returns the credentials of the current REDIS_URL, for new Redis connections.
+func (cfg *apiConfig) redisCredentials(fallback *redis.Options) func() (string, string) {
+	return func() (string, string) {
+		current, err := redis.ParseURL(cfg.secrets.value("REDIS_URL").Get())
+		if err != nil {
+			cfg.logger.Warn("could not parse rotated REDIS_URL, using the previous credentials", "error", err)
+			return fallback.Username, fallback.Password
+		}
+		return current.Username, current.Password
+	}
+}
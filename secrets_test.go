@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type fakeSecretAccessor map[string]string
+
+func (f fakeSecretAccessor) AccessSecret(ctx context.Context, version string) (string, error) {
+	value, ok := f[version]
+	if !ok {
+		return "", errors.New("secret not found")
+	}
+	return value, nil
+}
+
+func writeSecretFile(t *testing.T, path, value string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(value), 0o600); err != nil {
+		t.Fatalf("could not write secret file: %v", err)
+	}
+}
+
+func TestLoadSecrets(t *testing.T) {
+	for _, setting := range secretSettings {
+		t.Setenv(setting, "")
+		t.Setenv(setting+secretFileSuffix, "")
+		t.Setenv(setting+secretManagerSuffix, "")
+	}
+
+	t.Run("None", func(t *testing.T) {
+		store, loaded, err := loadSecrets(context.Background())
+		if err != nil || store != nil || loaded != nil {
+			t.Errorf("loadSecrets() = %v, %v, %v, want nothing", store, loaded, err)
+		}
+		if v := store.valueOr("OWM_KEY", "plain"); v.Get() != "plain" {
+			t.Errorf("valueOr() = %q, want the plain value", v.Get())
+		}
+	})
+
+	t.Run("File", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "owm_key")
+		writeSecretFile(t, path, "owm-secret\n")
+		t.Setenv("OWM_KEY_FILE", path)
+		t.Setenv("OWM_KEY", "")
+
+		store, loaded, err := loadSecrets(context.Background())
+		if err != nil {
+			t.Fatalf("loadSecrets() error = %v", err)
+		}
+		if !slices.Equal(loaded, []string{"OWM_KEY_FILE"}) {
+			t.Errorf("loaded = %v, want [OWM_KEY_FILE]", loaded)
+		}
+		if got := os.Getenv("OWM_KEY"); got != "owm-secret" {
+			t.Errorf("OWM_KEY = %q, want the trimmed file contents", got)
+		}
+		if got := store.valueOr("OWM_KEY", "").Get(); got != "owm-secret" {
+			t.Errorf("valueOr() = %q, want the secret", got)
+		}
+	})
+
+	t.Run("Conflict", func(t *testing.T) {
+		t.Setenv("GMP_KEY", "plain")
+		t.Setenv("GMP_KEY_FILE", "/run/secrets/gmp_key")
+		t.Setenv("DB_URL_FILE", "/run/secrets/db_url")
+		t.Setenv("DB_URL_SECRET", "projects/p/secrets/db-url")
+
+		_, _, err := loadSecrets(context.Background())
+		if err == nil {
+			t.Fatal("loadSecrets() error = nil, want conflicts")
+		}
+		for _, want := range []string{"GMP_KEY, GMP_KEY_FILE", "DB_URL, DB_URL_FILE"} {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("error %q does not mention %q", err, want)
+			}
+		}
+	})
+
+	t.Run("MissingFile", func(t *testing.T) {
+		t.Setenv("OWM_KEY", "")
+		t.Setenv("OWM_KEY_FILE", filepath.Join(t.TempDir(), "missing"))
+		if _, _, err := loadSecrets(context.Background()); err == nil || !strings.Contains(err.Error(), "OWM_KEY_FILE") {
+			t.Errorf("loadSecrets() error = %v, want an error naming OWM_KEY_FILE", err)
+		}
+	})
+}
+
+func TestSecretStoreReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gmp_key")
+	writeSecretFile(t, path, "gmp-1")
+	accessor := fakeSecretAccessor{"projects/p/secrets/owm/versions/latest": "owm-1"}
+	store := &secretStore{
+		sources: []secretSource{
+			{setting: "GMP_KEY", file: path},
+			{setting: "OWM_KEY", version: "projects/p/secrets/owm/versions/latest"},
+		},
+		accessor: accessor,
+		values: map[string]*secretValue{
+			"GMP_KEY": newSecretValue("gmp-1"),
+			"OWM_KEY": newSecretValue("owm-1"),
+		},
+	}
+	gmpKey := store.valueOr("GMP_KEY", "")
+
+	changed, err := store.reload(context.Background())
+	if err != nil || changed != nil {
+		t.Errorf("reload() without rotation = %v, %v, want no changes", changed, err)
+	}
+
+	writeSecretFile(t, path, "gmp-2\n")
+	accessor["projects/p/secrets/owm/versions/latest"] = "owm-2"
+	changed, err = store.reload(context.Background())
+	if err != nil {
+		t.Fatalf("reload() error = %v", err)
+	}
+	if !slices.Equal(changed, []string{"GMP_KEY", "OWM_KEY"}) {
+		t.Errorf("changed = %v, want [GMP_KEY OWM_KEY]", changed)
+	}
+	if got := gmpKey.Get(); got != "gmp-2" {
+		t.Errorf("GMP_KEY = %q, want the rotated key", got)
+	}
+
+	// An unreadable or empty secret keeps the previous value.
+	writeSecretFile(t, path, "")
+	delete(accessor, "projects/p/secrets/owm/versions/latest")
+	changed, err = store.reload(context.Background())
+	if err == nil || changed != nil {
+		t.Errorf("reload() of broken secrets = %v, %v, want an error and no changes", changed, err)
+	}
+	if got := store.value("OWM_KEY").Get(); got != "owm-2" {
+		t.Errorf("OWM_KEY = %q, want the previous key", got)
+	}
+}
+
+func TestSetDBCredentials(t *testing.T) {
+	cfg := &apiConfig{
+		secrets: &secretStore{values: map[string]*secretValue{
+			"DB_URL": newSecretValue("postgres://app:old@db:5432/willitrain"),
+		}},
+	}
+	conn, err := pgx.ParseConfig("postgres://app:old@db:5432/willitrain")
+	if err != nil {
+		t.Fatalf("could not parse connection string: %v", err)
+	}
+
+	cfg.secrets.value("DB_URL").Set("postgres://app2:new@db:5432/willitrain")
+	if err := cfg.setDBCredentials(context.Background(), conn); err != nil {
+		t.Fatalf("setDBCredentials() error = %v", err)
+	}
+	if conn.User != "app2" || conn.Password != "new" {
+		t.Errorf("credentials = %s:%s, want the rotated ones", conn.User, conn.Password)
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// This file implements the "simple" endpoints, an ultra-light API family intended for
+// microcontrollers and other constrained clients. Instead of JSON, each endpoint answers
+// a single yes/no question about the forecast with a plain-text "1" or "0", and responses
+// carry long-lived caching headers so that devices and intermediaries can reuse them.
+
+// simpleCacheMaxAge is how long clients may cache a simple endpoint response.
+// Hourly forecasts are refreshed once an hour, so a quarter of that keeps answers fresh
+// while sparing battery-powered devices from frequent round-trips.
+const simpleCacheMaxAge = 15 * time.Minute
+
+// Default and maximum look-ahead windows, in hours, for the simple endpoints.
+const (
+	defaultRainHours  = 6
+	defaultFrostHours = 12
+	maxSimpleHours    = 24
+)
+
+// rainChanceThreshold is the precipitation probability, in percent, at or above which
+// an hour is considered rainy even if no precipitation amount is forecast.
+const rainChanceThreshold = 50
+
+// @Summary      Will it rain? (plain text)
+// @Description  Returns "1" if any source forecasts precipitation (or a precipitation chance of at least
+// @Description  50%) within the next `hours` hours, otherwise "0". Intended for microcontrollers.
+// @Tags         simple
+// @Produce      plain
+// @Param        city   query     string  false  "Location name to search for (e.g., 'London')"
+// @Param        lat    query     number  false  "Latitude for the location (e.g., 51.5074)"
+// @Param        lon    query     number  false  "Longitude for the location (e.g., -0.1278)"
+// @Param        hours  query     int     false  "Look-ahead window in hours (1-24, default 6)"
+// @Success      200  {string}  string  "1 or 0"
+// @Failure      400  {object}  ErrorResponse "Bad Request - Invalid location or hours parameter"
+// @Failure      500  {object}  ErrorResponse "Internal Server Error - Failed to retrieve forecast data"
+// @Router       /api/v1/simple/rain [get]
+func (cfg *apiConfig) handlerSimpleRain(w http.ResponseWriter, r *http.Request) {
+	cfg.handleSimpleHourlyQuestion(w, r, defaultRainHours, func(f HourlyForecast) bool {
+		return f.Precipitation > 0 || f.PrecipitationChance >= rainChanceThreshold
+	})
+}
+
+// @Summary      Will there be frost? (plain text)
+// @Description  Returns "1" if any source forecasts a temperature at or below 0°C within the next
+// @Description  `hours` hours, otherwise "0". Intended for microcontrollers.
+// @Tags         simple
+// @Produce      plain
+// @Param        city   query     string  false  "Location name to search for (e.g., 'London')"
+// @Param        lat    query     number  false  "Latitude for the location (e.g., 51.5074)"
+// @Param        lon    query     number  false  "Longitude for the location (e.g., -0.1278)"
+// @Param        hours  query     int     false  "Look-ahead window in hours (1-24, default 12)"
+// @Success      200  {string}  string  "1 or 0"
+// @Failure      400  {object}  ErrorResponse "Bad Request - Invalid location or hours parameter"
+// @Failure      500  {object}  ErrorResponse "Internal Server Error - Failed to retrieve forecast data"
+// @Router       /api/v1/simple/frost [get]
+func (cfg *apiConfig) handlerSimpleFrost(w http.ResponseWriter, r *http.Request) {
+	cfg.handleSimpleHourlyQuestion(w, r, defaultFrostHours, func(f HourlyForecast) bool {
+		return f.Temperature <= 0
+	})
+}
+
+// handleSimpleHourlyQuestion contains the logic shared by the simple endpoints. It resolves the
+// location, loads the hourly forecast through the regular cache layers and answers "1" if the
+// predicate holds for any forecast hour inside the requested window.
+func (cfg *apiConfig) handleSimpleHourlyQuestion(w http.ResponseWriter, r *http.Request, defaultHours int, predicate func(HourlyForecast) bool) {
+	if r.Method != http.MethodGet {
+		cfg.respondWithError(w, http.StatusMethodNotAllowed, "Method Not Allowed", nil)
+		return
+	}
+
+	hours, err := parseSimpleHours(r.URL.Query().Get("hours"), defaultHours)
+	if err != nil {
+		cfg.respondWithError(w, http.StatusBadRequest, "Invalid hours parameter", err)
+		return
+	}
+
+	location, err := cfg.getLocationFromRequest(r)
+	if err != nil {
+		cfg.respondWithError(w, http.StatusBadRequest, "Error getting location data", err)
+		return
+	}
+
+	forecast, err := cfg.getCachedOrFetchHourlyForecast(r.Context(), location)
+	if err != nil {
+		cfg.respondWithError(w, http.StatusInternalServerError, "Error getting hourly forecast data", err)
+		return
+	}
+
+	now := time.Now().UTC()
+	windowEnd := now.Add(time.Duration(hours) * time.Hour)
+	answer := false
+	for _, f := range forecast {
+		if f.ForecastDateTime.Before(now.Truncate(time.Hour)) || !f.ForecastDateTime.Before(windowEnd) {
+			continue
+		}
+		if predicate(f) {
+			answer = true
+			break
+		}
+	}
+
+	cfg.respondWithBool(w, answer)
+}
+
+// parseSimpleHours validates the optional hours query parameter.
+func parseSimpleHours(value string, fallback int) (int, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	hours, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, err
+	}
+	if hours < 1 || hours > maxSimpleHours {
+		return 0, fmt.Errorf("hours must be between 1 and %d, got %d", maxSimpleHours, hours)
+	}
+	return hours, nil
+}
+
+// respondWithBool writes a plain-text "1" or "0" response with caching headers.
+func (cfg *apiConfig) respondWithBool(w http.ResponseWriter, value bool) {
+	body := "0"
+	if value {
+		body = "1"
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(simpleCacheMaxAge.Seconds())))
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write([]byte(body)); err != nil {
+		cfg.logger.Error("error writing response", "error", err)
+	}
+}
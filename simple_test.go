@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cor0nius/willitrain/internal/database"
+)
+
+func TestSimpleEndpoints(t *testing.T) {
+	nextHour := time.Now().UTC().Truncate(time.Hour).Add(time.Hour)
+	dryWarm := []HourlyForecast{
+		{SourceAPI: "test1", ForecastDateTime: nextHour, Temperature: 5},
+		{SourceAPI: "test1", ForecastDateTime: nextHour.Add(10 * time.Hour), Temperature: -2, Precipitation: 1.2},
+	}
+	wetCold := []HourlyForecast{
+		{SourceAPI: "test1", ForecastDateTime: nextHour, Temperature: -1, PrecipitationChance: 70},
+	}
+
+	testCases := []struct {
+		name       string
+		handler    func(cfg *apiConfig) http.HandlerFunc
+		target     string
+		forecast   []HourlyForecast
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "Rain - Outside Default Window",
+			handler:    func(cfg *apiConfig) http.HandlerFunc { return cfg.handlerSimpleRain },
+			target:     "/api/simple/rain?city=Wroclaw",
+			forecast:   dryWarm,
+			wantStatus: http.StatusOK,
+			wantBody:   "0",
+		},
+		{
+			name:       "Rain - Inside Custom Window",
+			handler:    func(cfg *apiConfig) http.HandlerFunc { return cfg.handlerSimpleRain },
+			target:     "/api/simple/rain?city=Wroclaw&hours=12",
+			forecast:   dryWarm,
+			wantStatus: http.StatusOK,
+			wantBody:   "1",
+		},
+		{
+			name:       "Rain - By Chance",
+			handler:    func(cfg *apiConfig) http.HandlerFunc { return cfg.handlerSimpleRain },
+			target:     "/api/simple/rain?city=Wroclaw",
+			forecast:   wetCold,
+			wantStatus: http.StatusOK,
+			wantBody:   "1",
+		},
+		{
+			name:       "Frost",
+			handler:    func(cfg *apiConfig) http.HandlerFunc { return cfg.handlerSimpleFrost },
+			target:     "/api/simple/frost?city=Wroclaw",
+			forecast:   dryWarm,
+			wantStatus: http.StatusOK,
+			wantBody:   "1",
+		},
+		{
+			name:       "No Frost In Short Window",
+			handler:    func(cfg *apiConfig) http.HandlerFunc { return cfg.handlerSimpleFrost },
+			target:     "/api/simple/frost?city=Wroclaw&hours=2",
+			forecast:   dryWarm,
+			wantStatus: http.StatusOK,
+			wantBody:   "0",
+		},
+		{
+			name:       "Invalid Hours",
+			handler:    func(cfg *apiConfig) http.HandlerFunc { return cfg.handlerSimpleRain },
+			target:     "/api/simple/rain?city=Wroclaw&hours=48",
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"Invalid hours parameter"}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testCfg := newTestAPIConfig(t)
+			testCfg.mockDB.GetLocationByAliasFunc = func(ctx context.Context, alias string) (database.Location, error) {
+				return MockDBLocation, nil
+			}
+			testCfg.mockCache.GetFunc = func(ctx context.Context, key string) (string, error) {
+				data, err := json.Marshal(tc.forecast)
+				return string(data), err
+			}
+
+			req := httptest.NewRequest(http.MethodGet, tc.target, nil)
+			rr := httptest.NewRecorder()
+
+			tc.handler(testCfg.apiConfig)(rr, req)
+
+			if rr.Code != tc.wantStatus {
+				t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, tc.wantStatus)
+			}
+			if rr.Body.String() != tc.wantBody {
+				t.Errorf("handler returned unexpected body: got %q want %q", rr.Body.String(), tc.wantBody)
+			}
+			if tc.wantStatus == http.StatusOK && rr.Header().Get("Cache-Control") != "public, max-age=900" {
+				t.Errorf("unexpected Cache-Control header: %q", rr.Header().Get("Cache-Control"))
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+)
+
+// This file serves the embedded frontend as a single-page application. Files of the build are
+// served as they are, and any other path that looks like a client-side route, such as
+// /city/wroclaw, is answered with index.html so that the frontend can render it. The build's
+// assets directory only holds files whose names carry a hash of their content, so they are
+// cached by browsers and proxies for a year without revalidation; index.html and the other
+// files keep their names across builds and must be revalidated on every use.
+
+const (
+	// spaIndex is the entry point of the frontend.
+	spaIndex = "index.html"
+	// spaAssetsDir is the directory of the content-hashed build assets.
+	spaAssetsDir = "assets"
+	// spaImmutableCacheControl is the Cache-Control header of content-hashed assets.
+	spaImmutableCacheControl = "public, max-age=31536000, immutable"
+)
+
+// spaReservedPrefixes are the path prefixes of the backend and of the build assets. Unknown paths
+// below them are not client-side routes and are answered with 404 Not Found instead of
+// index.html.
+var spaReservedPrefixes = []string{legacyAPIPrefix + "/", "/admin/", "/dev/", "/swagger/", "/" + spaAssetsDir + "/"}
+
+// spaHandler serves the frontend in fsys with a fallback to its index.html for client-side
+// routes.
+func (cfg *apiConfig) spaHandler(fsys fs.FS) http.Handler {
+	fileServer := http.FileServer(http.FS(fsys))
+	index, err := fs.ReadFile(fsys, spaIndex)
+	if err != nil {
+		cfg.logger.Warn("frontend has no index.html, client-side routes will not be served", "error", err)
+	}
+	sum := sha256.Sum256(index)
+	indexETag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	serveIndex := func(w http.ResponseWriter, r *http.Request) {
+		if index == nil {
+			cfg.respondWithError(w, http.StatusNotFound, "Not Found", nil)
+			return
+		}
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			cfg.respondWithError(w, http.StatusMethodNotAllowed, "Method Not Allowed", nil)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("ETag", indexETag)
+		http.ServeContent(w, r, spaIndex, time.Time{}, bytes.NewReader(index))
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/")
+		if name == "" || name == spaIndex {
+			serveIndex(w, r)
+			return
+		}
+		if info, err := fs.Stat(fsys, name); err == nil && !info.IsDir() {
+			if strings.HasPrefix(name, spaAssetsDir+"/") {
+				w.Header().Set("Cache-Control", spaImmutableCacheControl)
+			} else {
+				w.Header().Set("Cache-Control", "no-cache")
+			}
+			fileServer.ServeHTTP(w, r)
+			return
+		}
+
+		if !isSPARoute(r.URL.Path) {
+			cfg.respondWithError(w, http.StatusNotFound, "Not Found", nil)
+			return
+		}
+		serveIndex(w, r)
+	})
+}
+
+// isSPARoute reports whether a path that matches no file may be a client-side route of the
+// frontend. Paths of the backend and of missing assets are not, nor are paths ending in the
+// extension of a known file type, such as /logo.png; other dots, as in /city/st.petersburg,
+// are allowed.
+func isSPARoute(p string) bool {
+	for _, prefix := range spaReservedPrefixes {
+		if strings.HasPrefix(p, prefix) || p+"/" == prefix {
+			return false
+		}
+	}
+	ext := path.Ext(p)
+	return ext == "" || mime.TypeByExtension(ext) == ""
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+)
+
+func TestIsSPARoute(t *testing.T) {
+	testCases := []struct {
+		path string
+		want bool
+	}{
+		{path: "/city/wroclaw", want: true},
+		{path: "/city/st.petersburg", want: true},
+		{path: "/settings/", want: true},
+		{path: "/logo.svg", want: false},
+		{path: "/manifest.json", want: false},
+		{path: "/assets/index-BZ7Ihx0n.js", want: false},
+		{path: "/assets/missing", want: false},
+		{path: "/api/v1/unknown", want: false},
+		{path: "/api", want: false},
+		{path: "/admin/unknown", want: false},
+		{path: "/swagger/", want: false},
+	}
+	for _, tc := range testCases {
+		if got := isSPARoute(tc.path); got != tc.want {
+			t.Errorf("isSPARoute(%q) = %v, want %v", tc.path, got, tc.want)
+		}
+	}
+}
+
+func TestSPAHandler(t *testing.T) {
+	const index = "<!doctype html><title>Will It Rain?</title>"
+	fsys := fstest.MapFS{
+		"index.html":                {Data: []byte(index)},
+		"assets/index-BZ7Ihx0n.js":  {Data: []byte("console.log('rain')")},
+		"assets/index-D8mQ2Lkx.css": {Data: []byte("body{}")},
+		"robots.txt":                {Data: []byte("User-agent: *")},
+	}
+	testCfg := newTestAPIConfig(t)
+	handler := testCfg.apiConfig.spaHandler(fsys)
+
+	testCases := []struct {
+		name             string
+		method           string
+		path             string
+		wantStatus       int
+		wantCacheControl string
+		wantBody         string
+	}{
+		{name: "Root", path: "/", wantStatus: http.StatusOK, wantCacheControl: "no-cache", wantBody: index},
+		{name: "Index", path: "/index.html", wantStatus: http.StatusOK, wantCacheControl: "no-cache", wantBody: index},
+		{name: "Client Route", path: "/city/wroclaw", wantStatus: http.StatusOK, wantCacheControl: "no-cache", wantBody: index},
+		{name: "Assets Directory", path: "/assets/", wantStatus: http.StatusNotFound, wantBody: `{"error":"Not Found"}`},
+		{name: "Hashed Asset", path: "/assets/index-BZ7Ihx0n.js", wantStatus: http.StatusOK, wantCacheControl: spaImmutableCacheControl, wantBody: "console.log('rain')"},
+		{name: "Other File", path: "/robots.txt", wantStatus: http.StatusOK, wantCacheControl: "no-cache", wantBody: "User-agent: *"},
+		{name: "Missing Asset", path: "/assets/index-old.js", wantStatus: http.StatusNotFound, wantBody: `{"error":"Not Found"}`},
+		{name: "Missing File", path: "/logo.png", wantStatus: http.StatusNotFound, wantBody: `{"error":"Not Found"}`},
+		{name: "Unknown API Path", path: "/api/v1/unknown", wantStatus: http.StatusNotFound, wantBody: `{"error":"Not Found"}`},
+		{name: "Wrong Method", method: http.MethodPost, path: "/city/wroclaw", wantStatus: http.StatusMethodNotAllowed, wantBody: `{"error":"Method Not Allowed"}`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			method := tc.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			req := httptest.NewRequest(method, tc.path, nil)
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != tc.wantStatus {
+				t.Fatalf("status = %d, want %d; body: %s", rr.Code, tc.wantStatus, rr.Body.String())
+			}
+			if rr.Body.String() != tc.wantBody {
+				t.Errorf("body = %q, want %q", rr.Body.String(), tc.wantBody)
+			}
+			if got := rr.Header().Get("Cache-Control"); got != tc.wantCacheControl {
+				t.Errorf("Cache-Control = %q, want %q", got, tc.wantCacheControl)
+			}
+		})
+	}
+
+	// index.html is revalidated with its ETag.
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/city/wroclaw", nil))
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("If-None-Match", rr.Header().Get("ETag"))
+	revalidated := httptest.NewRecorder()
+	handler.ServeHTTP(revalidated, req)
+	if revalidated.Code != http.StatusNotModified {
+		t.Errorf("status = %d, want 304", revalidated.Code)
+	}
+}
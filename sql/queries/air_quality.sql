@@ -0,0 +1,33 @@
+-- CreateAirQuality inserts a new air quality record into the database.
+-- name: CreateAirQuality :one
+INSERT INTO air_quality (
+    id,
+    location_id,
+    source_api,
+    updated_at,
+    aqi,
+    pm25_ugm3,
+    pm10_ugm3,
+    ozone_ugm3
+)
+VALUES (gen_random_uuid(), $1, $2, $3, $4, $5, $6, $7)
+RETURNING *;
+
+-- GetAirQualityAtLocation retrieves all air quality records for a specific location.
+-- name: GetAirQualityAtLocation :many
+SELECT * FROM air_quality WHERE location_id=$1;
+
+-- GetAirQualityAtLocationFromAPI retrieves the air quality record for a specific location and API source.
+-- name: GetAirQualityAtLocationFromAPI :one
+SELECT * FROM air_quality WHERE location_id=$1 AND source_api=$2;
+
+-- UpdateAirQuality updates an existing air quality record.
+-- name: UpdateAirQuality :one
+UPDATE air_quality
+SET updated_at=$2, aqi=$3, pm25_ugm3=$4, pm10_ugm3=$5, ozone_ugm3=$6
+WHERE id=$1
+RETURNING *;
+
+-- DeleteAirQualityAtLocation deletes all air quality records for a specific location.
+-- name: DeleteAirQualityAtLocation :exec
+DELETE FROM air_quality WHERE location_id=$1;
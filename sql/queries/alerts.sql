@@ -0,0 +1,62 @@
+-- CreateAlertSubscription stores a new alert rule of a subscriber.
+-- name: CreateAlertSubscription :one
+INSERT INTO alert_subscriptions (id, subscriber_id, location_id, metric, operator, threshold, window_hours, webhook_url, created_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING *;
+
+-- ListAlertSubscriptionsForSubscriber retrieves all alert rules of a subscriber, oldest first.
+-- name: ListAlertSubscriptionsForSubscriber :many
+SELECT * FROM alert_subscriptions WHERE subscriber_id=$1 ORDER BY created_at ASC;
+
+-- CountAlertSubscriptionsForSubscriber returns the number of alert rules of a subscriber.
+-- name: CountAlertSubscriptionsForSubscriber :one
+SELECT COUNT(*) FROM alert_subscriptions WHERE subscriber_id=$1;
+
+-- ListAlertSubscriptionsForLocation retrieves all alert rules of a location.
+-- name: ListAlertSubscriptionsForLocation :many
+SELECT * FROM alert_subscriptions WHERE location_id=$1;
+
+-- MoveAlertSubscriptions moves the alert rules of a location to another location and reports how many were moved.
+-- name: MoveAlertSubscriptions :execrows
+UPDATE alert_subscriptions SET location_id = sqlc.arg(to_location_id)
+WHERE location_id = sqlc.arg(from_location_id);
+
+-- DeleteAlertSubscription removes an alert rule of a subscriber and returns how many were deleted.
+-- name: DeleteAlertSubscription :execrows
+DELETE FROM alert_subscriptions WHERE id=$1 AND subscriber_id=$2;
+
+-- DeleteAlertSubscriptionsForSubscriber removes all alert rules of a subscriber, together with
+-- their deliveries, and returns how many rules were deleted.
+-- name: DeleteAlertSubscriptionsForSubscriber :execrows
+DELETE FROM alert_subscriptions WHERE subscriber_id=$1;
+
+-- UpdateAlertSubscriptionState records whether an alert rule matched at its last evaluation.
+-- name: UpdateAlertSubscriptionState :exec
+UPDATE alert_subscriptions SET triggered=$2, last_triggered_at=$3 WHERE id=$1;
+
+-- CreateAlertDelivery queues a webhook call for a triggered alert.
+-- name: CreateAlertDelivery :exec
+INSERT INTO alert_deliveries (id, subscription_id, webhook_url, payload, created_at, next_attempt_at)
+VALUES ($1, $2, $3, $4, $5, $5);
+
+-- ClaimDueAlertDeliveries returns up to row_limit deliveries whose next attempt is due and moves
+-- their next attempt to lease_until, so that concurrent workers do not send them twice.
+-- name: ClaimDueAlertDeliveries :many
+UPDATE alert_deliveries SET next_attempt_at = sqlc.arg(lease_until)::timestamptz
+WHERE id IN (
+    SELECT d.id FROM alert_deliveries d
+    WHERE d.next_attempt_at <= sqlc.arg(now)::timestamptz
+    ORDER BY d.next_attempt_at ASC
+    LIMIT sqlc.arg(row_limit)
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- RecordAlertDeliveryAttempt stores the outcome of a delivery attempt.
+-- name: RecordAlertDeliveryAttempt :exec
+UPDATE alert_deliveries SET attempts=$2, next_attempt_at=$3, delivered_at=$4, last_error=$5 WHERE id=$1;
+
+-- DeleteAlertDeliveriesBefore removes finished deliveries created before the given time and
+-- returns how many were deleted.
+-- name: DeleteAlertDeliveriesBefore :execrows
+DELETE FROM alert_deliveries WHERE next_attempt_at IS NULL AND created_at < $1;
@@ -0,0 +1,10 @@
+-- CreateAPIKey stores the hash of a new API key.
+-- name: CreateAPIKey :one
+INSERT INTO api_keys (id, name, key_hash, created_at, daily_quota)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- GetActiveAPIKeyByHash retrieves the API key with the given hash, unless it was revoked.
+-- name: GetActiveAPIKeyByHash :one
+SELECT * FROM api_keys
+WHERE key_hash = $1 AND revoked_at IS NULL;
@@ -9,9 +9,18 @@ INSERT INTO current_weather (
     humidity,
     wind_speed_kmh,
     precipitation_mm,
-    condition_text
+    condition_text,
+    uv_index,
+    sunrise,
+    sunset,
+    wind_direction_deg,
+    wind_gust_kmh,
+    apparent_temperature_c,
+    dew_point_c,
+    rain_mm,
+    snow_mm
 )
-VALUES (gen_random_uuid(), $1, $2, $3, $4, $5, $6, $7, $8)
+VALUES (gen_random_uuid(), $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
 RETURNING *;
 
 -- GetCurrentWeatherAtLocation retrieves all current weather records for a specific location.
@@ -25,7 +34,7 @@ SELECT * FROM current_weather WHERE location_id=$1 AND source_api=$2;
 -- UpdateCurrentWeather updates an existing current weather record.
 -- name: UpdateCurrentWeather :one
 UPDATE current_weather
-SET updated_at=$2, temperature_c=$3, humidity=$4, wind_speed_kmh=$5, precipitation_mm=$6, condition_text=$7
+SET updated_at=$2, temperature_c=$3, humidity=$4, wind_speed_kmh=$5, precipitation_mm=$6, condition_text=$7, uv_index=$8, sunrise=$9, sunset=$10, wind_direction_deg=$11, wind_gust_kmh=$12, apparent_temperature_c=$13, dew_point_c=$14, rain_mm=$15, snow_mm=$16
 WHERE id=$1
 RETURNING *;
 
@@ -11,9 +11,28 @@ INSERT INTO daily_forecasts (
     precipitation_mm,
     precipitation_chance_percent,
     wind_speed_kmh,
-    humidity 
+    humidity,
+    uv_index,
+    sunrise,
+    sunset,
+    wind_direction_deg,
+    wind_gust_kmh,
+    rain_mm,
+    snow_mm,
+    morning_temp_c,
+    morning_precipitation_chance_percent,
+    morning_condition_text,
+    afternoon_temp_c,
+    afternoon_precipitation_chance_percent,
+    afternoon_condition_text,
+    evening_temp_c,
+    evening_precipitation_chance_percent,
+    evening_condition_text,
+    night_temp_c,
+    night_precipitation_chance_percent,
+    night_condition_text
 )
-VALUES (gen_random_uuid(), $1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+VALUES (gen_random_uuid(), $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27, $28, $29)
 RETURNING *;
 
 -- GetDailyForecastAtLocationAndDate retrieves all daily forecasts for a specific location and date.
@@ -31,7 +50,7 @@ SELECT * FROM daily_forecasts WHERE location_id=$1 AND forecast_date=$2 AND sour
 -- UpdateDailyForecast updates an existing daily forecast record.
 -- name: UpdateDailyForecast :one
 UPDATE daily_forecasts
-SET updated_at=$2, forecast_date=$3, min_temp_c=$4, max_temp_c=$5, precipitation_mm=$6, precipitation_chance_percent=$7, wind_speed_kmh=$8, humidity=$9
+SET updated_at=$2, forecast_date=$3, min_temp_c=$4, max_temp_c=$5, precipitation_mm=$6, precipitation_chance_percent=$7, wind_speed_kmh=$8, humidity=$9, uv_index=$10, sunrise=$11, sunset=$12, wind_direction_deg=$13, wind_gust_kmh=$14, rain_mm=$15, snow_mm=$16, morning_temp_c=$17, morning_precipitation_chance_percent=$18, morning_condition_text=$19, afternoon_temp_c=$20, afternoon_precipitation_chance_percent=$21, afternoon_condition_text=$22, evening_temp_c=$23, evening_precipitation_chance_percent=$24, evening_condition_text=$25, night_temp_c=$26, night_precipitation_chance_percent=$27, night_condition_text=$28
 WHERE id=$1
 RETURNING *;
 
@@ -43,6 +62,10 @@ DELETE FROM daily_forecasts WHERE location_id=$1;
 -- name: DeleteDailyForecastsAtLocationFromAPI :exec
 DELETE FROM daily_forecasts WHERE location_id=$1 AND source_api=$2;
 
+-- DeleteDailyForecastsBefore deletes the daily forecasts for dates before the given date.
+-- name: DeleteDailyForecastsBefore :execrows
+DELETE FROM daily_forecasts WHERE forecast_date < $1;
+
 -- DeleteDailyForecastsFromApi deletes all daily forecasts from a specific API source.
 -- name: DeleteDailyForecastsFromApi :exec
 DELETE FROM daily_forecasts WHERE source_api=$1;
@@ -51,8 +74,77 @@ DELETE FROM daily_forecasts WHERE source_api=$1;
 -- name: DeleteAllDailyForecasts :exec
 DELETE FROM daily_forecasts;
 
--- GetUpcomingDailyForecastsAtLocation retrieves all upcoming daily forecasts for a specific location.
+-- GetUpcomingDailyForecastsAtLocation retrieves the daily forecasts for a specific location from from_date up to, but excluding, to_date.
 -- name: GetUpcomingDailyForecastsAtLocation :many
 SELECT * FROM daily_forecasts
-WHERE location_id = $1 AND forecast_date >= $2
+WHERE location_id = $1 AND forecast_date >= sqlc.arg(from_date) AND forecast_date < sqlc.arg(to_date)
 ORDER BY forecast_date ASC;
+
+-- UpsertDailyForecasts inserts the daily forecasts in a JSON array of rows, or updates those that already exist for the same location, API source, and date.
+-- name: UpsertDailyForecasts :execrows
+INSERT INTO daily_forecasts (
+    id,
+    location_id,
+    source_api,
+    forecast_date,
+    updated_at,
+    min_temp_c,
+    max_temp_c,
+    precipitation_mm,
+    precipitation_chance_percent,
+    wind_speed_kmh,
+    humidity,
+    uv_index,
+    sunrise,
+    sunset,
+    wind_direction_deg,
+    wind_gust_kmh,
+    rain_mm,
+    snow_mm,
+    morning_temp_c,
+    morning_precipitation_chance_percent,
+    morning_condition_text,
+    afternoon_temp_c,
+    afternoon_precipitation_chance_percent,
+    afternoon_condition_text,
+    evening_temp_c,
+    evening_precipitation_chance_percent,
+    evening_condition_text,
+    night_temp_c,
+    night_precipitation_chance_percent,
+    night_condition_text
+)
+SELECT gen_random_uuid(), f.*
+FROM jsonb_to_recordset(sqlc.arg(forecasts)::jsonb) AS f(
+    location_id UUID,
+    source_api TEXT,
+    forecast_date DATE,
+    updated_at TIMESTAMPTZ,
+    min_temp_c FLOAT,
+    max_temp_c FLOAT,
+    precipitation_mm FLOAT,
+    precipitation_chance_percent INT,
+    wind_speed_kmh FLOAT,
+    humidity INT,
+    uv_index FLOAT,
+    sunrise TIMESTAMPTZ,
+    sunset TIMESTAMPTZ,
+    wind_direction_deg FLOAT,
+    wind_gust_kmh FLOAT,
+    rain_mm FLOAT,
+    snow_mm FLOAT,
+    morning_temp_c FLOAT,
+    morning_precipitation_chance_percent INT,
+    morning_condition_text TEXT,
+    afternoon_temp_c FLOAT,
+    afternoon_precipitation_chance_percent INT,
+    afternoon_condition_text TEXT,
+    evening_temp_c FLOAT,
+    evening_precipitation_chance_percent INT,
+    evening_condition_text TEXT,
+    night_temp_c FLOAT,
+    night_precipitation_chance_percent INT,
+    night_condition_text TEXT
+)
+ON CONFLICT (location_id, source_api, forecast_date) DO UPDATE
+SET updated_at=EXCLUDED.updated_at, min_temp_c=EXCLUDED.min_temp_c, max_temp_c=EXCLUDED.max_temp_c, precipitation_mm=EXCLUDED.precipitation_mm, precipitation_chance_percent=EXCLUDED.precipitation_chance_percent, wind_speed_kmh=EXCLUDED.wind_speed_kmh, humidity=EXCLUDED.humidity, uv_index=EXCLUDED.uv_index, sunrise=EXCLUDED.sunrise, sunset=EXCLUDED.sunset, wind_direction_deg=EXCLUDED.wind_direction_deg, wind_gust_kmh=EXCLUDED.wind_gust_kmh, rain_mm=EXCLUDED.rain_mm, snow_mm=EXCLUDED.snow_mm, morning_temp_c=EXCLUDED.morning_temp_c, morning_precipitation_chance_percent=EXCLUDED.morning_precipitation_chance_percent, morning_condition_text=EXCLUDED.morning_condition_text, afternoon_temp_c=EXCLUDED.afternoon_temp_c, afternoon_precipitation_chance_percent=EXCLUDED.afternoon_precipitation_chance_percent, afternoon_condition_text=EXCLUDED.afternoon_condition_text, evening_temp_c=EXCLUDED.evening_temp_c, evening_precipitation_chance_percent=EXCLUDED.evening_precipitation_chance_percent, evening_condition_text=EXCLUDED.evening_condition_text, night_temp_c=EXCLUDED.night_temp_c, night_precipitation_chance_percent=EXCLUDED.night_precipitation_chance_percent, night_condition_text=EXCLUDED.night_condition_text;
@@ -11,9 +11,15 @@ INSERT INTO hourly_forecasts (
     wind_speed_kmh,
     precipitation_mm,
     precipitation_chance_percent,
-    condition_text 
+    condition_text,
+    wind_direction_deg,
+    wind_gust_kmh,
+    apparent_temperature_c,
+    dew_point_c,
+    rain_mm,
+    snow_mm
 )
-VALUES (gen_random_uuid(), $1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+VALUES (gen_random_uuid(), $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
 RETURNING *;
 
 -- GetHourlyForecastAtLocationAndTime retrieves all hourly forecasts for a specific location and time.
@@ -31,7 +37,7 @@ SELECT * FROM hourly_forecasts WHERE location_id=$1 AND forecast_datetime_utc=$2
 -- UpdateHourlyForecast updates an existing hourly forecast record.
 -- name: UpdateHourlyForecast :one
 UPDATE hourly_forecasts
-SET updated_at=$2, forecast_datetime_utc=$3, temperature_c=$4, humidity=$5, wind_speed_kmh=$6, precipitation_mm=$7, precipitation_chance_percent=$8, condition_text=$9
+SET updated_at=$2, forecast_datetime_utc=$3, temperature_c=$4, humidity=$5, wind_speed_kmh=$6, precipitation_mm=$7, precipitation_chance_percent=$8, condition_text=$9, wind_direction_deg=$10, wind_gust_kmh=$11, apparent_temperature_c=$12, dew_point_c=$13, rain_mm=$14, snow_mm=$15
 WHERE id=$1
 RETURNING *;
 
@@ -43,6 +49,10 @@ DELETE FROM hourly_forecasts WHERE location_id=$1;
 -- name: DeleteHourlyForecastsAtLocationFromAPI :exec
 DELETE FROM hourly_forecasts WHERE location_id=$1 AND source_api=$2;
 
+-- DeleteHourlyForecastsBefore deletes the hourly forecasts for times before the given time.
+-- name: DeleteHourlyForecastsBefore :execrows
+DELETE FROM hourly_forecasts WHERE forecast_datetime_utc < $1;
+
 -- DeleteHourlyForecastsFromAPI deletes all hourly forecasts from a specific API source.
 -- name: DeleteHourlyForecastsFromAPI :exec
 DELETE FROM hourly_forecasts WHERE source_api=$1;
@@ -51,8 +61,56 @@ DELETE FROM hourly_forecasts WHERE source_api=$1;
 -- name: DeleteAllHourlyForecasts :exec
 DELETE FROM hourly_forecasts;
 
--- GetUpcomingHourlyForecastsAtLocation retrieves all upcoming hourly forecasts for a specific location.
+-- GetUpcomingHourlyForecastsAtLocation retrieves the hourly forecasts for a specific location from from_time up to, but excluding, to_time, for the first max_hours forecast times with any forecast.
 -- name: GetUpcomingHourlyForecastsAtLocation :many
 SELECT * FROM hourly_forecasts
-WHERE location_id = $1 AND forecast_datetime_utc >= $2
+WHERE location_id = $1 AND forecast_datetime_utc IN (
+    SELECT DISTINCT forecast_datetime_utc FROM hourly_forecasts
+    WHERE location_id = $1 AND forecast_datetime_utc >= sqlc.arg(from_time) AND forecast_datetime_utc < sqlc.arg(to_time)
+    ORDER BY forecast_datetime_utc ASC
+    LIMIT sqlc.arg(max_hours)
+)
 ORDER BY forecast_datetime_utc ASC;
+
+-- UpsertHourlyForecasts inserts the hourly forecasts in a JSON array of rows, or updates those that already exist for the same location, API source, and time.
+-- name: UpsertHourlyForecasts :execrows
+INSERT INTO hourly_forecasts (
+    id,
+    location_id,
+    source_api,
+    forecast_datetime_utc,
+    updated_at,
+    temperature_c,
+    humidity,
+    wind_speed_kmh,
+    precipitation_mm,
+    precipitation_chance_percent,
+    condition_text,
+    wind_direction_deg,
+    wind_gust_kmh,
+    apparent_temperature_c,
+    dew_point_c,
+    rain_mm,
+    snow_mm
+)
+SELECT gen_random_uuid(), f.*
+FROM jsonb_to_recordset(sqlc.arg(forecasts)::jsonb) AS f(
+    location_id UUID,
+    source_api TEXT,
+    forecast_datetime_utc TIMESTAMP,
+    updated_at TIMESTAMPTZ,
+    temperature_c FLOAT,
+    humidity INT,
+    wind_speed_kmh FLOAT,
+    precipitation_mm FLOAT,
+    precipitation_chance_percent INT,
+    condition_text TEXT,
+    wind_direction_deg FLOAT,
+    wind_gust_kmh FLOAT,
+    apparent_temperature_c FLOAT,
+    dew_point_c FLOAT,
+    rain_mm FLOAT,
+    snow_mm FLOAT
+)
+ON CONFLICT (location_id, source_api, forecast_datetime_utc) DO UPDATE
+SET updated_at=EXCLUDED.updated_at, temperature_c=EXCLUDED.temperature_c, humidity=EXCLUDED.humidity, wind_speed_kmh=EXCLUDED.wind_speed_kmh, precipitation_mm=EXCLUDED.precipitation_mm, precipitation_chance_percent=EXCLUDED.precipitation_chance_percent, condition_text=EXCLUDED.condition_text, wind_direction_deg=EXCLUDED.wind_direction_deg, wind_gust_kmh=EXCLUDED.wind_gust_kmh, apparent_temperature_c=EXCLUDED.apparent_temperature_c, dew_point_c=EXCLUDED.dew_point_c, rain_mm=EXCLUDED.rain_mm, snow_mm=EXCLUDED.snow_mm;
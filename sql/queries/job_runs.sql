@@ -0,0 +1,85 @@
+-- CreateJobRun records the start of a scheduler job run.
+-- name: CreateJobRun :one
+INSERT INTO job_runs (id, job_name, status, started_at)
+VALUES (gen_random_uuid(), $1, 'running', $2)
+RETURNING *;
+
+-- FinishJobRun records the outcome of a scheduler job run.
+-- name: FinishJobRun :exec
+UPDATE job_runs
+SET status = $2,
+    finished_at = $3,
+    duration_ms = $4,
+    locations_total = $5,
+    locations_succeeded = $6,
+    locations_failed = $7,
+    locations_skipped = $8,
+    error = $9
+WHERE id = $1;
+
+-- CreateJobRunLocation records a location update queued by a job run.
+-- name: CreateJobRunLocation :exec
+INSERT INTO job_run_locations (job_run_id, location_id, status, queued_at)
+VALUES ($1, $2, 'queued', $3);
+
+-- StartJobRunLocation marks a queued location update as running.
+-- name: StartJobRunLocation :exec
+UPDATE job_run_locations
+SET status = 'running',
+    started_at = $3
+WHERE job_run_id = $1 AND location_id = $2;
+
+-- FinishJobRunLocation records the outcome of a location update.
+-- name: FinishJobRunLocation :exec
+UPDATE job_run_locations
+SET status = $3,
+    finished_at = $4,
+    duration_ms = $5,
+    error = $6
+WHERE job_run_id = $1 AND location_id = $2;
+
+-- InterruptUnfinishedJobRuns marks the job runs and location updates that a previous process left
+-- unfinished as interrupted.
+-- name: InterruptUnfinishedJobRuns :execrows
+WITH interrupted_locations AS (
+    UPDATE job_run_locations
+    SET status = 'interrupted', finished_at = $1
+    WHERE job_run_locations.finished_at IS NULL
+)
+UPDATE job_runs
+SET status = 'interrupted', finished_at = $1
+WHERE job_runs.finished_at IS NULL;
+
+-- GetJobRun retrieves a job run by its ID.
+-- name: GetJobRun :one
+SELECT * FROM job_runs WHERE id = $1;
+
+-- ListJobRuns retrieves the most recent job runs, optionally limited to one job.
+-- name: ListJobRuns :many
+SELECT * FROM job_runs
+WHERE (sqlc.narg(job_name)::text IS NULL OR job_name = sqlc.narg(job_name)::text)
+ORDER BY started_at DESC
+LIMIT sqlc.arg(row_limit);
+
+-- ListJobRunLocations retrieves the location updates of a job run with the city names.
+-- name: ListJobRunLocations :many
+SELECT job_run_locations.*, locations.city_name
+FROM job_run_locations
+JOIN locations ON locations.id = job_run_locations.location_id
+WHERE job_run_locations.job_run_id = $1
+ORDER BY job_run_locations.queued_at ASC, locations.city_name ASC;
+
+-- ListJobRunLocationsForLocation retrieves the most recent updates of a location with the job
+-- runs they belong to, optionally limited to one job.
+-- name: ListJobRunLocationsForLocation :many
+SELECT job_run_locations.*, job_runs.job_name
+FROM job_run_locations
+JOIN job_runs ON job_runs.id = job_run_locations.job_run_id
+WHERE job_run_locations.location_id = sqlc.arg(location_id)
+  AND (sqlc.narg(job_name)::text IS NULL OR job_runs.job_name = sqlc.narg(job_name)::text)
+ORDER BY job_run_locations.queued_at DESC
+LIMIT sqlc.arg(row_limit);
+
+-- DeleteJobRunsBefore removes job runs, with their location updates, started before the given time.
+-- name: DeleteJobRunsBefore :execrows
+DELETE FROM job_runs WHERE started_at < $1;
@@ -0,0 +1,31 @@
+-- TouchLocationAccess records a request for a location, keeping the latest access time.
+-- name: TouchLocationAccess :exec
+INSERT INTO location_access (location_id, last_accessed_at)
+VALUES ($1, $2)
+ON CONFLICT (location_id) DO UPDATE
+SET last_accessed_at = GREATEST(location_access.last_accessed_at, EXCLUDED.last_accessed_at);
+
+-- ListLocationDemand retrieves, for every location, when it was last requested, how many requests
+-- it had since the given hour and whether it is pinned by a watchlist entry or an alert rule.
+-- name: ListLocationDemand :many
+SELECT l.id AS location_id,
+       a.last_accessed_at,
+       COALESCE(SUM(s.request_count), 0)::bigint AS recent_requests,
+       (EXISTS (SELECT 1 FROM watchlist_entries w WHERE w.location_id = l.id)
+        OR EXISTS (SELECT 1 FROM alert_subscriptions r WHERE r.location_id = l.id))::boolean AS pinned
+FROM locations l
+LEFT JOIN location_access a ON a.location_id = l.id
+LEFT JOIN location_request_stats s ON s.location_id = l.id AND s.hour >= $1
+GROUP BY l.id, a.last_accessed_at;
+
+-- DeleteIdleLocations deletes the locations that have not been requested since the given time and
+-- are neither watched, used by an alert rule nor in a location group, together with all their data.
+-- name: DeleteIdleLocations :many
+DELETE FROM locations l
+USING location_access a
+WHERE a.location_id = l.id
+  AND a.last_accessed_at < $1
+  AND NOT EXISTS (SELECT 1 FROM watchlist_entries w WHERE w.location_id = l.id)
+  AND NOT EXISTS (SELECT 1 FROM alert_subscriptions r WHERE r.location_id = l.id)
+  AND NOT EXISTS (SELECT 1 FROM location_group_members g WHERE g.location_id = l.id)
+RETURNING l.id, l.city_name;
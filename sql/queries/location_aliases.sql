@@ -7,4 +7,26 @@ RETURNING *;
 -- GetLocationByAlias retrieves a location's details by its alias.
 -- name: GetLocationByAlias :one
 SELECT l.* FROM locations l JOIN location_aliases la ON l.id = la.location_id
-WHERE la.alias = $1;
\ No newline at end of file
+WHERE la.alias = $1;
+
+-- ListLocationAliases retrieves all aliases that resolve to a location, ordered alphabetically.
+-- name: ListLocationAliases :many
+SELECT * FROM location_aliases
+WHERE location_id = $1
+ORDER BY alias ASC;
+
+-- UpsertLocationAlias points an alias at a location, moving it away from any location it resolved to before.
+-- name: UpsertLocationAlias :one
+INSERT INTO location_aliases (alias, location_id)
+VALUES ($1, $2)
+ON CONFLICT (alias) DO UPDATE SET location_id = EXCLUDED.location_id
+RETURNING *;
+
+-- DeleteLocationAlias removes an alias from a location and reports how many rows were deleted.
+-- name: DeleteLocationAlias :execrows
+DELETE FROM location_aliases WHERE alias = $1 AND location_id = $2;
+
+-- MoveLocationAliases points all aliases of a location at another location and reports how many were moved.
+-- name: MoveLocationAliases :execrows
+UPDATE location_aliases SET location_id = sqlc.arg(to_location_id)
+WHERE location_id = sqlc.arg(from_location_id);
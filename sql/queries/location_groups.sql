@@ -0,0 +1,52 @@
+-- CreateLocationGroup creates a named group of locations for a subscriber.
+-- name: CreateLocationGroup :one
+INSERT INTO location_groups (id, subscriber_id, name, created_at)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- GetLocationGroup retrieves a location group of a subscriber.
+-- name: GetLocationGroup :one
+SELECT * FROM location_groups WHERE id=$1 AND subscriber_id=$2;
+
+-- ListLocationGroupsForSubscriber retrieves all location groups of a subscriber, ordered by name.
+-- name: ListLocationGroupsForSubscriber :many
+SELECT * FROM location_groups
+WHERE subscriber_id = $1
+ORDER BY name ASC;
+
+-- DeleteLocationGroup removes a location group of a subscriber, with its members, and reports how many rows were deleted.
+-- name: DeleteLocationGroup :execrows
+DELETE FROM location_groups WHERE id=$1 AND subscriber_id=$2;
+
+-- DeleteLocationGroupsForSubscriber removes all location groups of a subscriber, with their members, and returns how many were deleted.
+-- name: DeleteLocationGroupsForSubscriber :execrows
+DELETE FROM location_groups WHERE subscriber_id=$1;
+
+-- AddLocationGroupMember adds a location to a group. Adding a location that is already a member is a no-op.
+-- name: AddLocationGroupMember :exec
+INSERT INTO location_group_members (group_id, location_id, added_at)
+VALUES ($1, $2, $3)
+ON CONFLICT (group_id, location_id) DO NOTHING;
+
+-- RemoveLocationGroupMember removes a location from a group and reports how many rows were deleted.
+-- name: RemoveLocationGroupMember :execrows
+DELETE FROM location_group_members WHERE group_id=$1 AND location_id=$2;
+
+-- CountLocationGroupMembers returns the number of locations in a group.
+-- name: CountLocationGroupMembers :one
+SELECT COUNT(*) FROM location_group_members WHERE group_id=$1;
+
+-- ListLocationGroupMembers retrieves the locations of a group, in the order they were added.
+-- name: ListLocationGroupMembers :many
+SELECT l.* FROM locations l JOIN location_group_members m ON l.id = m.location_id
+WHERE m.group_id = $1
+ORDER BY m.added_at ASC, l.city_name ASC;
+
+-- MoveLocationGroupMembers moves the group memberships of a location to another location and reports how many
+-- were moved. Memberships of groups that already contain the other location are left in place.
+-- name: MoveLocationGroupMembers :execrows
+UPDATE location_group_members SET location_id = sqlc.arg(to_location_id)
+WHERE location_id = sqlc.arg(from_location_id)
+AND group_id NOT IN (
+    SELECT group_id FROM location_group_members WHERE location_id = sqlc.arg(to_location_id)
+);
@@ -0,0 +1,15 @@
+-- ListLocationProviderWeights retrieves the provider weights of a location, ordered by provider.
+-- name: ListLocationProviderWeights :many
+SELECT * FROM location_provider_weights
+WHERE location_id = $1
+ORDER BY provider ASC;
+
+-- UpsertLocationProviderWeight sets the weight of a provider at a location.
+-- name: UpsertLocationProviderWeight :exec
+INSERT INTO location_provider_weights (location_id, provider, weight)
+VALUES ($1, $2, $3)
+ON CONFLICT (location_id, provider) DO UPDATE SET weight = EXCLUDED.weight;
+
+-- DeleteLocationProviderWeights removes all provider weights of a location and reports how many were deleted.
+-- name: DeleteLocationProviderWeights :execrows
+DELETE FROM location_provider_weights WHERE location_id = $1;
@@ -12,6 +12,10 @@ SELECT * FROM locations ORDER BY city_name ASC;
 -- name: GetLocationByName :one
 SELECT * FROM locations WHERE city_name=$1;
 
+-- GetLocationByID retrieves a location by its ID.
+-- name: GetLocationByID :one
+SELECT * FROM locations WHERE id=$1;
+
 -- GetLocationByCoordinates retrieves a location by its latitude and longitude.
 -- name: GetLocationByCoordinates :one
 SELECT * FROM locations WHERE latitude=$1 AND longitude=$2;
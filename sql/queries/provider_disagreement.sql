@@ -0,0 +1,38 @@
+-- UpsertProviderDisagreement stores the disagreement of the providers on a forecast date, replacing the one computed earlier on the same day.
+-- name: UpsertProviderDisagreement :exec
+INSERT INTO provider_disagreement (
+    location_id,
+    computed_on,
+    forecast_date,
+    computed_at,
+    sources,
+    min_temp_spread_c,
+    max_temp_spread_c,
+    precipitation_spread_mm,
+    precipitation_chance_spread,
+    wind_speed_spread_kmh,
+    condition_agreement
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+ON CONFLICT (location_id, computed_on, forecast_date) DO UPDATE
+SET computed_at = EXCLUDED.computed_at,
+    sources = EXCLUDED.sources,
+    min_temp_spread_c = EXCLUDED.min_temp_spread_c,
+    max_temp_spread_c = EXCLUDED.max_temp_spread_c,
+    precipitation_spread_mm = EXCLUDED.precipitation_spread_mm,
+    precipitation_chance_spread = EXCLUDED.precipitation_chance_spread,
+    wind_speed_spread_kmh = EXCLUDED.wind_speed_spread_kmh,
+    condition_agreement = EXCLUDED.condition_agreement;
+
+-- ListLatestProviderDisagreement retrieves the latest disagreement report of a location, ordered by forecast date.
+-- name: ListLatestProviderDisagreement :many
+SELECT * FROM provider_disagreement
+WHERE location_id = $1 AND computed_on = (
+    SELECT MAX(computed_on) FROM provider_disagreement WHERE location_id = $1
+)
+ORDER BY forecast_date ASC;
+
+-- DeleteProviderDisagreementBefore deletes the disagreement reports computed before the given date.
+-- name: DeleteProviderDisagreementBefore :execrows
+DELETE FROM provider_disagreement
+WHERE computed_on < $1;
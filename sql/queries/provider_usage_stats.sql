@@ -0,0 +1,38 @@
+-- IncrementProviderUsageStats adds to the calls and failures of a provider for a location in the given hour.
+-- name: IncrementProviderUsageStats :exec
+INSERT INTO provider_usage_stats (hour, location_id, provider, call_count, failure_count)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (hour, location_id, provider) DO UPDATE SET
+    call_count = provider_usage_stats.call_count + EXCLUDED.call_count,
+    failure_count = provider_usage_stats.failure_count + EXCLUDED.failure_count;
+
+-- IncrementLocationFetchStats adds to the fetch operations of a location in the given hour.
+-- name: IncrementLocationFetchStats :exec
+INSERT INTO location_fetch_stats (hour, location_id, operation_count)
+VALUES ($1, $2, $3)
+ON CONFLICT (hour, location_id) DO UPDATE SET operation_count = location_fetch_stats.operation_count + EXCLUDED.operation_count;
+
+-- GetProviderUsageSince retrieves the calls and failures of every provider per location since the given hour.
+-- name: GetProviderUsageSince :many
+SELECT l.id, l.city_name, s.provider, SUM(s.call_count)::bigint AS call_count, SUM(s.failure_count)::bigint AS failure_count
+FROM provider_usage_stats s
+JOIN locations l ON l.id = s.location_id
+WHERE s.hour >= $1
+GROUP BY l.id, s.provider
+ORDER BY l.city_name ASC, s.provider ASC;
+
+-- GetLocationFetchesSince retrieves the fetch operations of every location since the given hour.
+-- name: GetLocationFetchesSince :many
+SELECT l.id, l.city_name, SUM(s.operation_count)::bigint AS operation_count
+FROM location_fetch_stats s
+JOIN locations l ON l.id = s.location_id
+WHERE s.hour >= $1
+GROUP BY l.id
+ORDER BY l.city_name ASC;
+
+-- GetFirstProviderUsageHourSince retrieves the first hour since the given one with recorded fetches.
+-- name: GetFirstProviderUsageHourSince :one
+SELECT hour FROM location_fetch_stats
+WHERE hour >= $1
+ORDER BY hour ASC
+LIMIT 1;
@@ -0,0 +1,27 @@
+-- IncrementEndpointRequestStats adds to the request count of an endpoint in the given hour.
+-- name: IncrementEndpointRequestStats :exec
+INSERT INTO endpoint_request_stats (hour, endpoint, request_count)
+VALUES ($1, $2, $3)
+ON CONFLICT (hour, endpoint) DO UPDATE SET request_count = endpoint_request_stats.request_count + EXCLUDED.request_count;
+
+-- IncrementLocationRequestStats adds to the request count of a location and endpoint in the given hour.
+-- name: IncrementLocationRequestStats :exec
+INSERT INTO location_request_stats (hour, location_id, endpoint, request_count)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (hour, location_id, endpoint) DO UPDATE SET request_count = location_request_stats.request_count + EXCLUDED.request_count;
+
+-- GetEndpointRequestStatsSince retrieves the hourly request counts of all endpoints since the given hour.
+-- name: GetEndpointRequestStatsSince :many
+SELECT * FROM endpoint_request_stats
+WHERE hour >= $1
+ORDER BY hour ASC, endpoint ASC;
+
+-- GetTopLocationsByRequestsSince retrieves the locations with the most requests since the given hour.
+-- name: GetTopLocationsByRequestsSince :many
+SELECT l.id, l.city_name, l.country_code, SUM(s.request_count)::bigint AS request_count
+FROM location_request_stats s
+JOIN locations l ON l.id = s.location_id
+WHERE s.hour >= $1
+GROUP BY l.id
+ORDER BY request_count DESC, l.city_name ASC
+LIMIT $2;
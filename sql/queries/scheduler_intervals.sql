@@ -0,0 +1,16 @@
+-- ListSchedulerIntervals retrieves every stored scheduler interval override.
+-- name: ListSchedulerIntervals :many
+SELECT * FROM scheduler_intervals
+ORDER BY job_name ASC;
+
+-- UpsertSchedulerInterval stores the interval override of a scheduler job, replacing any previous one.
+-- name: UpsertSchedulerInterval :exec
+INSERT INTO scheduler_intervals (job_name, interval_seconds, updated_at)
+VALUES ($1, $2, $3)
+ON CONFLICT (job_name) DO UPDATE
+SET interval_seconds = EXCLUDED.interval_seconds,
+    updated_at = EXCLUDED.updated_at;
+
+-- DeleteSchedulerInterval removes the interval override of a scheduler job.
+-- name: DeleteSchedulerInterval :exec
+DELETE FROM scheduler_intervals WHERE job_name = $1;
@@ -0,0 +1,28 @@
+-- CreateSchedulerRun records the outcome of a scheduled update for one location and provider.
+-- name: CreateSchedulerRun :exec
+INSERT INTO scheduler_runs (
+    id,
+    location_id,
+    job_type,
+    provider,
+    started_at,
+    duration_ms,
+    rows_written,
+    hours_covered,
+    error_class,
+    error_message
+)
+VALUES (gen_random_uuid(), $1, $2, $3, $4, $5, $6, $7, $8, $9);
+
+-- ListSchedulerRunsForLocation retrieves the most recent scheduled updates of a location, optionally
+-- limited to one provider.
+-- name: ListSchedulerRunsForLocation :many
+SELECT * FROM scheduler_runs
+WHERE location_id = sqlc.arg(location_id)
+  AND (sqlc.narg(provider)::text IS NULL OR provider = sqlc.narg(provider)::text)
+ORDER BY started_at DESC, job_type ASC, provider ASC
+LIMIT sqlc.arg(row_limit);
+
+-- DeleteSchedulerRunsBefore removes scheduler run records older than the given time.
+-- name: DeleteSchedulerRunsBefore :execrows
+DELETE FROM scheduler_runs WHERE started_at < $1;
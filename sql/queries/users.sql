@@ -0,0 +1,23 @@
+-- CreateUser creates a user account with default preferences.
+-- name: CreateUser :one
+INSERT INTO users (id, email, password_hash, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $4)
+RETURNING *;
+
+-- GetUserByEmail retrieves the user account with the given email address.
+-- name: GetUserByEmail :one
+SELECT * FROM users WHERE email=$1;
+
+-- GetUserByID retrieves a user account.
+-- name: GetUserByID :one
+SELECT * FROM users WHERE id=$1;
+
+-- UpdateUserPreferences replaces the preferences of a user account.
+-- name: UpdateUserPreferences :one
+UPDATE users SET units=$2, language=$3, default_city=$4, updated_at=$5
+WHERE id=$1
+RETURNING *;
+
+-- DeleteUser removes a user account and reports how many rows were deleted.
+-- name: DeleteUser :execrows
+DELETE FROM users WHERE id=$1;
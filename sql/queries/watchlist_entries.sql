@@ -0,0 +1,51 @@
+-- CreateWatchlistEntry adds a location to a subscriber's watchlist. Adding an already watched location is a no-op.
+-- name: CreateWatchlistEntry :one
+INSERT INTO watchlist_entries (subscriber_id, location_id, created_at)
+VALUES ($1, $2, $3)
+ON CONFLICT (subscriber_id, location_id) DO UPDATE SET subscriber_id = EXCLUDED.subscriber_id
+RETURNING *;
+
+-- DeleteWatchlistEntry removes a location from a subscriber's watchlist.
+-- name: DeleteWatchlistEntry :exec
+DELETE FROM watchlist_entries WHERE subscriber_id=$1 AND location_id=$2;
+
+-- DeleteWatchlistEntriesForSubscriber removes all entries of a subscriber's watchlist and returns how many were deleted.
+-- name: DeleteWatchlistEntriesForSubscriber :execrows
+DELETE FROM watchlist_entries WHERE subscriber_id=$1;
+
+-- ListWatchlistLocations retrieves all locations on a subscriber's watchlist, ordered by city name.
+-- name: ListWatchlistLocations :many
+SELECT l.* FROM locations l JOIN watchlist_entries w ON l.id = w.location_id
+WHERE w.subscriber_id = $1
+ORDER BY l.city_name ASC;
+
+-- ListWatchedLocationIDs retrieves the IDs of all locations that are on at least one watchlist.
+-- name: ListWatchedLocationIDs :many
+SELECT DISTINCT location_id FROM watchlist_entries;
+
+-- GetWatchlistUpdates retrieves the watched locations whose weather data was updated after the given time,
+-- together with the time of their most recent update.
+-- name: GetWatchlistUpdates :many
+SELECT l.id, l.city_name, l.latitude, l.longitude, l.country_code, l.timezone, MAX(u.updated_at)::timestamptz AS last_updated
+FROM watchlist_entries w
+JOIN locations l ON l.id = w.location_id
+JOIN (
+    SELECT location_id, updated_at FROM current_weather
+    UNION ALL
+    SELECT location_id, updated_at FROM hourly_forecasts
+    UNION ALL
+    SELECT location_id, updated_at FROM daily_forecasts
+) u ON u.location_id = l.id
+WHERE w.subscriber_id = $1
+GROUP BY l.id
+HAVING MAX(u.updated_at) > sqlc.arg(since)::timestamptz
+ORDER BY last_updated ASC;
+
+-- MoveWatchlistEntries moves the watchlist entries of a location to another location and reports how many
+-- were moved. Entries of subscribers who already watch the other location are left in place.
+-- name: MoveWatchlistEntries :execrows
+UPDATE watchlist_entries SET location_id = sqlc.arg(to_location_id)
+WHERE location_id = sqlc.arg(from_location_id)
+AND subscriber_id NOT IN (
+    SELECT subscriber_id FROM watchlist_entries WHERE location_id = sqlc.arg(to_location_id)
+);
@@ -0,0 +1,76 @@
+-- ArchiveCurrentWeatherAtLocation moves all current weather records of a location to the history table.
+-- name: ArchiveCurrentWeatherAtLocation :execrows
+WITH moved AS (
+    DELETE FROM current_weather WHERE location_id = sqlc.arg(location_id)
+    RETURNING *
+)
+INSERT INTO current_weather_history (
+    id, location_id, source_api, updated_at, temperature_c, humidity, wind_speed_kmh, precipitation_mm, condition_text, archived_at
+)
+SELECT id, location_id, source_api, updated_at, temperature_c, humidity, wind_speed_kmh, precipitation_mm, condition_text, sqlc.arg(archived_at)::timestamptz
+FROM moved;
+
+-- ArchiveDailyForecastsAtLocation moves all daily forecast records of a location to the history table.
+-- name: ArchiveDailyForecastsAtLocation :execrows
+WITH moved AS (
+    DELETE FROM daily_forecasts WHERE location_id = sqlc.arg(location_id)
+    RETURNING *
+)
+INSERT INTO daily_forecast_history (
+    id, location_id, source_api, forecast_date, updated_at, min_temp_c, max_temp_c, precipitation_mm, precipitation_chance_percent, wind_speed_kmh, humidity, archived_at
+)
+SELECT id, location_id, source_api, forecast_date, updated_at, min_temp_c, max_temp_c, precipitation_mm, precipitation_chance_percent, wind_speed_kmh, humidity, sqlc.arg(archived_at)::timestamptz
+FROM moved;
+
+-- ArchiveHourlyForecastsAtLocation moves all hourly forecast records of a location to the history table.
+-- name: ArchiveHourlyForecastsAtLocation :execrows
+WITH moved AS (
+    DELETE FROM hourly_forecasts WHERE location_id = sqlc.arg(location_id)
+    RETURNING *
+)
+INSERT INTO hourly_forecast_history (
+    id, location_id, source_api, forecast_datetime_utc, updated_at, temperature_c, humidity, wind_speed_kmh, precipitation_mm, precipitation_chance_percent, condition_text, archived_at
+)
+SELECT id, location_id, source_api, forecast_datetime_utc, updated_at, temperature_c, humidity, wind_speed_kmh, precipitation_mm, precipitation_chance_percent, condition_text, sqlc.arg(archived_at)::timestamptz
+FROM moved;
+
+-- DeleteDailyForecastHistoryBefore deletes the archived daily forecasts for dates before the given date.
+-- name: DeleteDailyForecastHistoryBefore :execrows
+DELETE FROM daily_forecast_history WHERE forecast_date < $1;
+
+-- DeleteHourlyForecastHistoryBefore deletes the archived hourly forecasts for times before the given time.
+-- name: DeleteHourlyForecastHistoryBefore :execrows
+DELETE FROM hourly_forecast_history WHERE forecast_datetime_utc < $1;
+
+-- ListCurrentWeatherHistory retrieves a page of archived current weather records of a location with an
+-- update time in [from, to), ordered by update time. A page continues after the given time and ID.
+-- name: ListCurrentWeatherHistory :many
+SELECT * FROM current_weather_history
+WHERE location_id = sqlc.arg(location_id)
+  AND updated_at >= sqlc.arg(from_time)::timestamptz
+  AND updated_at < sqlc.arg(to_time)::timestamptz
+  AND (sqlc.narg(after_time)::timestamptz IS NULL OR (updated_at, id) > (sqlc.narg(after_time)::timestamptz, sqlc.narg(after_id)::uuid))
+ORDER BY updated_at ASC, id ASC
+LIMIT sqlc.arg(row_limit);
+
+-- ListDailyForecastHistory retrieves a page of archived daily forecast records of a location with a
+-- forecast date in [from, to), ordered by forecast date. A page continues after the given date and ID.
+-- name: ListDailyForecastHistory :many
+SELECT * FROM daily_forecast_history
+WHERE location_id = sqlc.arg(location_id)
+  AND forecast_date >= sqlc.arg(from_time)::date
+  AND forecast_date < sqlc.arg(to_time)::date
+  AND (sqlc.narg(after_time)::date IS NULL OR (forecast_date, id) > (sqlc.narg(after_time)::date, sqlc.narg(after_id)::uuid))
+ORDER BY forecast_date ASC, id ASC
+LIMIT sqlc.arg(row_limit);
+
+-- ListHourlyForecastHistory retrieves a page of archived hourly forecast records of a location with a
+-- forecast time in [from, to), ordered by forecast time. A page continues after the given time and ID.
+-- name: ListHourlyForecastHistory :many
+SELECT * FROM hourly_forecast_history
+WHERE location_id = sqlc.arg(location_id)
+  AND forecast_datetime_utc >= sqlc.arg(from_time)::timestamp
+  AND forecast_datetime_utc < sqlc.arg(to_time)::timestamp
+  AND (sqlc.narg(after_time)::timestamp IS NULL OR (forecast_datetime_utc, id) > (sqlc.narg(after_time)::timestamp, sqlc.narg(after_id)::uuid))
+ORDER BY forecast_datetime_utc ASC, id ASC
+LIMIT sqlc.arg(row_limit);
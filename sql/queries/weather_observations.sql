@@ -0,0 +1,26 @@
+-- UpsertWeatherObservation inserts an observation, replacing an existing one for the same location, source and hour.
+-- name: UpsertWeatherObservation :exec
+INSERT INTO weather_observations (
+    location_id,
+    source_api,
+    observed_at,
+    temperature_c,
+    humidity,
+    wind_speed_kmh,
+    precipitation_mm,
+    condition_text
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+ON CONFLICT (location_id, source_api, observed_at) DO UPDATE
+SET temperature_c = EXCLUDED.temperature_c,
+    humidity = EXCLUDED.humidity,
+    wind_speed_kmh = EXCLUDED.wind_speed_kmh,
+    precipitation_mm = EXCLUDED.precipitation_mm,
+    condition_text = EXCLUDED.condition_text;
+
+-- GetLatestWeatherObservation retrieves the most recent observation of a location from a source made at or after the given time.
+-- name: GetLatestWeatherObservation :one
+SELECT * FROM weather_observations
+WHERE location_id = $1 AND source_api = $2 AND observed_at >= $3
+ORDER BY observed_at DESC
+LIMIT 1;
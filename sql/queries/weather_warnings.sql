@@ -0,0 +1,18 @@
+-- GetWeatherWarningsAtLocation retrieves the stored warnings of all sources for a location.
+-- name: GetWeatherWarningsAtLocation :many
+SELECT * FROM weather_warnings
+WHERE location_id = $1
+ORDER BY source_api;
+
+-- UpsertWeatherWarnings stores the warnings of a source for a location, replacing the previous ones.
+-- name: UpsertWeatherWarnings :exec
+INSERT INTO weather_warnings (
+    location_id,
+    source_api,
+    warnings,
+    updated_at
+)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (location_id, source_api) DO UPDATE
+SET warnings = EXCLUDED.warnings,
+    updated_at = EXCLUDED.updated_at;
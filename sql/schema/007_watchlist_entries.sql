@@ -0,0 +1,13 @@
+-- +goose Up
+-- watchlist_entries stores the locations a subscriber (identified by a device ID or a hashed API key)
+-- has asked to follow. Watched locations are persisted like any other location, so the scheduler
+-- keeps refreshing them on every cycle.
+CREATE TABLE watchlist_entries (
+    subscriber_id TEXT NOT NULL,
+    location_id UUID REFERENCES locations(id) ON DELETE CASCADE NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (subscriber_id, location_id)
+);
+
+-- +goose Down
+DROP TABLE watchlist_entries;
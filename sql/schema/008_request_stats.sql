@@ -0,0 +1,22 @@
+-- +goose Up
+-- endpoint_request_stats and location_request_stats hold request counts aggregated per hour.
+-- Unlike the Prometheus counters, they survive restarts and keep a usage history that can be
+-- queried through the /admin/stats endpoints.
+CREATE TABLE endpoint_request_stats (
+    hour TIMESTAMPTZ NOT NULL,
+    endpoint TEXT NOT NULL,
+    request_count BIGINT NOT NULL,
+    PRIMARY KEY (hour, endpoint)
+);
+
+CREATE TABLE location_request_stats (
+    hour TIMESTAMPTZ NOT NULL,
+    location_id UUID REFERENCES locations(id) ON DELETE CASCADE NOT NULL,
+    endpoint TEXT NOT NULL,
+    request_count BIGINT NOT NULL,
+    PRIMARY KEY (hour, location_id, endpoint)
+);
+
+-- +goose Down
+DROP TABLE location_request_stats;
+DROP TABLE endpoint_request_stats;
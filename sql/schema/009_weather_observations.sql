@@ -0,0 +1,18 @@
+-- +goose Up
+-- weather_observations stores hourly observed weather per location and data source. Unlike
+-- current_weather, which only keeps the latest reading, it accumulates a history that forecasts
+-- can be compared against. New locations are seeded from the Open-Meteo archive.
+CREATE TABLE weather_observations (
+    location_id UUID REFERENCES locations(id) ON DELETE CASCADE NOT NULL,
+    source_api TEXT NOT NULL,
+    observed_at TIMESTAMPTZ NOT NULL,
+    temperature_c FLOAT,
+    humidity INT,
+    wind_speed_kmh FLOAT,
+    precipitation_mm FLOAT,
+    condition_text TEXT,
+    PRIMARY KEY (location_id, source_api, observed_at)
+);
+
+-- +goose Down
+DROP TABLE weather_observations;
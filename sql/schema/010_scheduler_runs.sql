@@ -0,0 +1,22 @@
+-- +goose Up
+-- scheduler_runs records the outcome of every scheduled update per location and provider, so
+-- that the update history of a location can be queried without searching the logs.
+-- error_class is NULL for successful fetches.
+CREATE TABLE scheduler_runs (
+    id UUID PRIMARY KEY,
+    location_id UUID REFERENCES locations(id) ON DELETE CASCADE NOT NULL,
+    job_type TEXT NOT NULL,
+    provider TEXT NOT NULL,
+    started_at TIMESTAMPTZ NOT NULL,
+    duration_ms BIGINT NOT NULL,
+    rows_written INT NOT NULL,
+    hours_covered INT NOT NULL,
+    error_class TEXT,
+    error_message TEXT
+);
+
+CREATE INDEX scheduler_runs_location_started_at_idx ON scheduler_runs (location_id, started_at DESC);
+CREATE INDEX scheduler_runs_started_at_idx ON scheduler_runs (started_at);
+
+-- +goose Down
+DROP TABLE scheduler_runs;
@@ -0,0 +1,57 @@
+-- +goose Up
+-- The history tables receive current weather and forecast rows when the scheduler replaces them and
+-- archiving is enabled (ARCHIVE_HISTORY). Rows keep their original ID and columns; archived_at is
+-- the time they were moved.
+CREATE TABLE current_weather_history (
+    id UUID PRIMARY KEY,
+    location_id UUID REFERENCES locations(id) ON DELETE CASCADE NOT NULL,
+    source_api TEXT NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    temperature_c FLOAT,
+    humidity INT,
+    wind_speed_kmh FLOAT,
+    precipitation_mm FLOAT,
+    condition_text TEXT,
+    archived_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX current_weather_history_location_updated_at_idx ON current_weather_history (location_id, updated_at, id);
+
+CREATE TABLE daily_forecast_history (
+    id UUID PRIMARY KEY,
+    location_id UUID REFERENCES locations(id) ON DELETE CASCADE NOT NULL,
+    source_api TEXT NOT NULL,
+    forecast_date DATE NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    min_temp_c FLOAT,
+    max_temp_c FLOAT,
+    precipitation_mm FLOAT,
+    precipitation_chance_percent INT,
+    wind_speed_kmh FLOAT,
+    humidity INT,
+    archived_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX daily_forecast_history_location_date_idx ON daily_forecast_history (location_id, forecast_date, id);
+
+CREATE TABLE hourly_forecast_history (
+    id UUID PRIMARY KEY,
+    location_id UUID REFERENCES locations(id) ON DELETE CASCADE NOT NULL,
+    source_api TEXT NOT NULL,
+    forecast_datetime_utc TIMESTAMP NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    temperature_c FLOAT,
+    humidity INT,
+    wind_speed_kmh FLOAT,
+    precipitation_mm FLOAT,
+    precipitation_chance_percent INT,
+    condition_text TEXT,
+    archived_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX hourly_forecast_history_location_datetime_idx ON hourly_forecast_history (location_id, forecast_datetime_utc, id);
+
+-- +goose Down
+DROP TABLE hourly_forecast_history;
+DROP TABLE daily_forecast_history;
+DROP TABLE current_weather_history;
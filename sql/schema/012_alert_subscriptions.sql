@@ -0,0 +1,42 @@
+-- +goose Up
+-- alert_subscriptions stores the alert rules of subscribers, who are identified like watchlist
+-- subscribers. A rule compares one hourly forecast field with a threshold over the next
+-- window_hours hours and calls webhook_url when it starts to match. triggered records whether the
+-- rule matched at its last evaluation, so that the webhook is called again only after the rule
+-- stopped matching in between.
+CREATE TABLE alert_subscriptions (
+    id UUID PRIMARY KEY,
+    subscriber_id TEXT NOT NULL,
+    location_id UUID REFERENCES locations(id) ON DELETE CASCADE NOT NULL,
+    metric TEXT NOT NULL,
+    operator TEXT NOT NULL,
+    threshold DOUBLE PRECISION NOT NULL,
+    window_hours INT NOT NULL,
+    webhook_url TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    triggered BOOLEAN NOT NULL DEFAULT false,
+    last_triggered_at TIMESTAMPTZ
+);
+
+CREATE INDEX alert_subscriptions_subscriber_id_idx ON alert_subscriptions (subscriber_id, created_at);
+CREATE INDEX alert_subscriptions_location_id_idx ON alert_subscriptions (location_id);
+
+-- alert_deliveries queues the webhook calls of triggered alerts. next_attempt_at is the time of
+-- the next delivery attempt and is NULL once the call succeeded or was given up.
+CREATE TABLE alert_deliveries (
+    id UUID PRIMARY KEY,
+    subscription_id UUID REFERENCES alert_subscriptions(id) ON DELETE CASCADE NOT NULL,
+    webhook_url TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ,
+    delivered_at TIMESTAMPTZ,
+    last_error TEXT
+);
+
+CREATE INDEX alert_deliveries_next_attempt_at_idx ON alert_deliveries (next_attempt_at) WHERE next_attempt_at IS NOT NULL;
+
+-- +goose Down
+DROP TABLE alert_deliveries;
+DROP TABLE alert_subscriptions;
@@ -0,0 +1,14 @@
+-- +goose Up
+-- api_keys stores the API keys that authorize requests to the development and admin endpoints,
+-- in addition to the static keys in ADMIN_API_KEYS. Only the SHA-256 hash of a key is stored. A
+-- revoked key is kept with its revocation time so that the audit trail of its name remains.
+CREATE TABLE api_keys (
+    id UUID PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE,
+    key_hash TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL,
+    revoked_at TIMESTAMPTZ
+);
+
+-- +goose Down
+DROP TABLE api_keys;
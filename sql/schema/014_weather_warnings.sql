@@ -0,0 +1,14 @@
+-- +goose Up
+-- weather_warnings stores the severe weather warnings issued for a location, as reported by each
+-- data source. A row holds the complete list of a source's warnings at the time of the fetch, so
+-- that a fresh row without warnings is distinguishable from one that was never fetched.
+CREATE TABLE weather_warnings (
+    location_id UUID REFERENCES locations(id) ON DELETE CASCADE NOT NULL,
+    source_api TEXT NOT NULL,
+    warnings JSONB NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (location_id, source_api)
+);
+
+-- +goose Down
+DROP TABLE weather_warnings;
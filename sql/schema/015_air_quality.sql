@@ -0,0 +1,16 @@
+-- +goose Up
+-- air_quality stores the most recent air quality reading for a specific location from a particular source.
+-- The scale of aqi depends on the source: the European AQI for Open-Meteo, 1 (good) to 5 (very poor) for OWM.
+CREATE TABLE air_quality (
+    id UUID PRIMARY KEY,
+    location_id UUID REFERENCES locations(id) ON DELETE CASCADE NOT NULL,
+    source_api TEXT NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    aqi INT,
+    pm25_ugm3 FLOAT,
+    pm10_ugm3 FLOAT,
+    ozone_ugm3 FLOAT
+);
+
+-- +goose Down
+DROP TABLE air_quality;
@@ -0,0 +1,23 @@
+-- +goose Up
+-- uv_index is the current UV index for current weather and the daily maximum for daily forecasts.
+-- sunrise and sunset are NULL for sources that do not report them.
+ALTER TABLE current_weather
+    ADD COLUMN uv_index FLOAT,
+    ADD COLUMN sunrise TIMESTAMPTZ,
+    ADD COLUMN sunset TIMESTAMPTZ;
+
+ALTER TABLE daily_forecasts
+    ADD COLUMN uv_index FLOAT,
+    ADD COLUMN sunrise TIMESTAMPTZ,
+    ADD COLUMN sunset TIMESTAMPTZ;
+
+-- +goose Down
+ALTER TABLE daily_forecasts
+    DROP COLUMN sunset,
+    DROP COLUMN sunrise,
+    DROP COLUMN uv_index;
+
+ALTER TABLE current_weather
+    DROP COLUMN sunset,
+    DROP COLUMN sunrise,
+    DROP COLUMN uv_index;
@@ -0,0 +1,27 @@
+-- +goose Up
+-- wind_direction_deg is the direction the wind blows from, in degrees clockwise from north.
+-- wind_gust_kmh is the gust speed; for daily forecasts it is the day's strongest gust.
+ALTER TABLE current_weather
+    ADD COLUMN wind_direction_deg FLOAT,
+    ADD COLUMN wind_gust_kmh FLOAT;
+
+ALTER TABLE hourly_forecasts
+    ADD COLUMN wind_direction_deg FLOAT,
+    ADD COLUMN wind_gust_kmh FLOAT;
+
+ALTER TABLE daily_forecasts
+    ADD COLUMN wind_direction_deg FLOAT,
+    ADD COLUMN wind_gust_kmh FLOAT;
+
+-- +goose Down
+ALTER TABLE daily_forecasts
+    DROP COLUMN wind_gust_kmh,
+    DROP COLUMN wind_direction_deg;
+
+ALTER TABLE hourly_forecasts
+    DROP COLUMN wind_gust_kmh,
+    DROP COLUMN wind_direction_deg;
+
+ALTER TABLE current_weather
+    DROP COLUMN wind_gust_kmh,
+    DROP COLUMN wind_direction_deg;
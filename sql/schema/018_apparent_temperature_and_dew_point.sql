@@ -0,0 +1,19 @@
+-- +goose Up
+-- apparent_temperature_c is the feels-like temperature, dew_point_c the dew point. Both are
+-- computed from the temperature, humidity and wind speed when the source does not report them.
+ALTER TABLE current_weather
+    ADD COLUMN apparent_temperature_c FLOAT,
+    ADD COLUMN dew_point_c FLOAT;
+
+ALTER TABLE hourly_forecasts
+    ADD COLUMN apparent_temperature_c FLOAT,
+    ADD COLUMN dew_point_c FLOAT;
+
+-- +goose Down
+ALTER TABLE hourly_forecasts
+    DROP COLUMN dew_point_c,
+    DROP COLUMN apparent_temperature_c;
+
+ALTER TABLE current_weather
+    DROP COLUMN dew_point_c,
+    DROP COLUMN apparent_temperature_c;
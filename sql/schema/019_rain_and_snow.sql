@@ -0,0 +1,27 @@
+-- +goose Up
+-- rain_mm and snow_mm split precipitation_mm into rain and snow, both as liquid water
+-- equivalent. They are NULL where the source does not distinguish the two.
+ALTER TABLE current_weather
+    ADD COLUMN rain_mm FLOAT,
+    ADD COLUMN snow_mm FLOAT;
+
+ALTER TABLE hourly_forecasts
+    ADD COLUMN rain_mm FLOAT,
+    ADD COLUMN snow_mm FLOAT;
+
+ALTER TABLE daily_forecasts
+    ADD COLUMN rain_mm FLOAT,
+    ADD COLUMN snow_mm FLOAT;
+
+-- +goose Down
+ALTER TABLE daily_forecasts
+    DROP COLUMN snow_mm,
+    DROP COLUMN rain_mm;
+
+ALTER TABLE hourly_forecasts
+    DROP COLUMN snow_mm,
+    DROP COLUMN rain_mm;
+
+ALTER TABLE current_weather
+    DROP COLUMN snow_mm,
+    DROP COLUMN rain_mm;
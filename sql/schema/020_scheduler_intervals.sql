@@ -0,0 +1,12 @@
+-- +goose Up
+-- scheduler_intervals holds the job intervals changed at runtime through PATCH /admin/scheduler.
+-- An override replaces the interval configured in the environment and survives restarts until it
+-- is reset, which deletes its row.
+CREATE TABLE scheduler_intervals (
+    job_name TEXT PRIMARY KEY,
+    interval_seconds INT NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
+
+-- +goose Down
+DROP TABLE scheduler_intervals;
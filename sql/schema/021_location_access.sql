@@ -0,0 +1,20 @@
+-- +goose Up
+-- location_access records when each location was last requested, so that the scheduler can
+-- refresh locations nobody asks for less often and evict them after LOCATION_EVICT_DAYS. Rows are
+-- written when the request statistics are flushed. Existing locations start from their latest
+-- request statistics, or from the time of the migration if they have none.
+CREATE TABLE location_access (
+    location_id UUID PRIMARY KEY REFERENCES locations(id) ON DELETE CASCADE,
+    last_accessed_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX location_access_last_accessed_at_idx ON location_access (last_accessed_at);
+
+INSERT INTO location_access (location_id, last_accessed_at)
+SELECT l.id, COALESCE(MAX(s.hour), now())
+FROM locations l
+LEFT JOIN location_request_stats s ON s.location_id = l.id
+GROUP BY l.id;
+
+-- +goose Down
+DROP TABLE location_access;
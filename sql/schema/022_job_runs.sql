@@ -0,0 +1,40 @@
+-- +goose Up
+-- job_runs records every run of a scheduler job with its status, duration and error.
+-- finished_at, duration_ms and error are NULL while the run is in progress; error is also NULL
+-- for successful runs. Runs that update locations count the outcomes of their locations.
+CREATE TABLE job_runs (
+    id UUID PRIMARY KEY,
+    job_name TEXT NOT NULL,
+    status TEXT NOT NULL,
+    started_at TIMESTAMPTZ NOT NULL,
+    finished_at TIMESTAMPTZ,
+    duration_ms BIGINT,
+    locations_total INT NOT NULL DEFAULT 0,
+    locations_succeeded INT NOT NULL DEFAULT 0,
+    locations_failed INT NOT NULL DEFAULT 0,
+    locations_skipped INT NOT NULL DEFAULT 0,
+    error TEXT
+);
+
+CREATE INDEX job_runs_started_at_idx ON job_runs (started_at DESC);
+CREATE INDEX job_runs_job_name_started_at_idx ON job_runs (job_name, started_at DESC);
+
+-- job_run_locations records the queued update of every location within a job run, from queued
+-- through running to its outcome.
+CREATE TABLE job_run_locations (
+    job_run_id UUID REFERENCES job_runs(id) ON DELETE CASCADE NOT NULL,
+    location_id UUID REFERENCES locations(id) ON DELETE CASCADE NOT NULL,
+    status TEXT NOT NULL,
+    queued_at TIMESTAMPTZ NOT NULL,
+    started_at TIMESTAMPTZ,
+    finished_at TIMESTAMPTZ,
+    duration_ms BIGINT,
+    error TEXT,
+    PRIMARY KEY (job_run_id, location_id)
+);
+
+CREATE INDEX job_run_locations_location_id_idx ON job_run_locations (location_id, queued_at DESC);
+
+-- +goose Down
+DROP TABLE job_run_locations;
+DROP TABLE job_runs;
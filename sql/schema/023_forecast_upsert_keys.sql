@@ -0,0 +1,24 @@
+-- +goose Up
+-- Forecasts are persisted with INSERT ... ON CONFLICT, which needs a unique key per location,
+-- provider and forecast time. Duplicate forecasts, which the row-by-row upsert could leave behind
+-- when two refreshes raced, are removed first, keeping the most recently updated one.
+DELETE FROM hourly_forecasts a
+USING hourly_forecasts b
+WHERE a.location_id = b.location_id
+  AND a.source_api = b.source_api
+  AND a.forecast_datetime_utc = b.forecast_datetime_utc
+  AND (a.updated_at, a.id) < (b.updated_at, b.id);
+
+DELETE FROM daily_forecasts a
+USING daily_forecasts b
+WHERE a.location_id = b.location_id
+  AND a.source_api = b.source_api
+  AND a.forecast_date = b.forecast_date
+  AND (a.updated_at, a.id) < (b.updated_at, b.id);
+
+CREATE UNIQUE INDEX hourly_forecasts_location_source_datetime_key ON hourly_forecasts (location_id, source_api, forecast_datetime_utc);
+CREATE UNIQUE INDEX daily_forecasts_location_source_date_key ON daily_forecasts (location_id, source_api, forecast_date);
+
+-- +goose Down
+DROP INDEX daily_forecasts_location_source_date_key;
+DROP INDEX hourly_forecasts_location_source_datetime_key;
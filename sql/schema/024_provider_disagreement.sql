@@ -0,0 +1,24 @@
+-- +goose Up
+-- provider_disagreement stores the nightly reports of how far the daily forecasts of the
+-- providers for a location diverge. A report, identified by the date it was computed on, holds a
+-- row for every forecast date with at least two sources: the spread, the highest minus the lowest
+-- source value, of each field, and the share of the sources that report the most common condition.
+CREATE TABLE provider_disagreement (
+    location_id UUID REFERENCES locations(id) ON DELETE CASCADE NOT NULL,
+    computed_on DATE NOT NULL,
+    forecast_date DATE NOT NULL,
+    computed_at TIMESTAMPTZ NOT NULL,
+    sources INT NOT NULL,
+    min_temp_spread_c FLOAT NOT NULL,
+    max_temp_spread_c FLOAT NOT NULL,
+    precipitation_spread_mm FLOAT NOT NULL,
+    precipitation_chance_spread FLOAT NOT NULL,
+    wind_speed_spread_kmh FLOAT NOT NULL,
+    condition_agreement FLOAT NOT NULL,
+    PRIMARY KEY (location_id, computed_on, forecast_date)
+);
+
+CREATE INDEX provider_disagreement_computed_on_idx ON provider_disagreement (computed_on);
+
+-- +goose Down
+DROP TABLE provider_disagreement;
@@ -0,0 +1,13 @@
+-- +goose Up
+-- location_provider_weights overrides the weights of PROVIDER_WEIGHTS for single locations, for
+-- example to favour a provider known to do well in the mountains. The weight of a provider decides
+-- how much its forecast counts in the blended consensus of the location; 0 leaves it out.
+CREATE TABLE location_provider_weights (
+    location_id UUID REFERENCES locations(id) ON DELETE CASCADE NOT NULL,
+    provider TEXT NOT NULL,
+    weight FLOAT NOT NULL CHECK (weight >= 0),
+    PRIMARY KEY (location_id, provider)
+);
+
+-- +goose Down
+DROP TABLE location_provider_weights;
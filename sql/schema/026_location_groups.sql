@@ -0,0 +1,24 @@
+-- +goose Up
+-- location_groups are named lists of locations, such as "my cities", that a subscriber keeps
+-- server-side so that multi-city widgets can fetch the weather of all of them in one call.
+-- Subscribers are identified like watchlist subscribers, by a hashed API key or a device ID.
+CREATE TABLE location_groups (
+    id UUID PRIMARY KEY,
+    subscriber_id TEXT NOT NULL,
+    name TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    UNIQUE (subscriber_id, name)
+);
+
+CREATE TABLE location_group_members (
+    group_id UUID REFERENCES location_groups(id) ON DELETE CASCADE NOT NULL,
+    location_id UUID REFERENCES locations(id) ON DELETE CASCADE NOT NULL,
+    added_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (group_id, location_id)
+);
+
+CREATE INDEX location_group_members_location_id_idx ON location_group_members (location_id);
+
+-- +goose Down
+DROP TABLE location_group_members;
+DROP TABLE location_groups;
@@ -0,0 +1,18 @@
+-- +goose Up
+-- users are the optional user accounts. Only a bcrypt hash of the password is stored. The
+-- preferences apply to requests made with the user's session token that do not set them: units
+-- and language are empty for the server defaults, and default_city is used when a request names
+-- no location.
+CREATE TABLE users (
+    id UUID PRIMARY KEY,
+    email TEXT NOT NULL UNIQUE,
+    password_hash TEXT NOT NULL,
+    units TEXT NOT NULL DEFAULT '',
+    language TEXT NOT NULL DEFAULT '',
+    default_city TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
+
+-- +goose Down
+DROP TABLE users;
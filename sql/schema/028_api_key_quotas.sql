@@ -0,0 +1,10 @@
+-- +goose Up
+-- daily_quota marks a client key of the public API, handed to a third-party integrator, and is
+-- the number of requests the key may make per UTC day; 0 leaves it unlimited. Client keys are not
+-- accepted by the development and admin endpoints. Keys without a quota are admin keys.
+ALTER TABLE api_keys
+    ADD COLUMN daily_quota INTEGER CHECK (daily_quota >= 0);
+
+-- +goose Down
+ALTER TABLE api_keys
+    DROP COLUMN daily_quota;
@@ -0,0 +1,32 @@
+-- +goose Up
+-- The morning (06-12), afternoon (12-18), evening (18-24) and night (00-06) of a daily forecast,
+-- each with a temperature, a precipitation chance and a condition. They are NULL where the source
+-- does not break the day down.
+ALTER TABLE daily_forecasts
+    ADD COLUMN morning_temp_c FLOAT,
+    ADD COLUMN morning_precipitation_chance_percent INT,
+    ADD COLUMN morning_condition_text TEXT,
+    ADD COLUMN afternoon_temp_c FLOAT,
+    ADD COLUMN afternoon_precipitation_chance_percent INT,
+    ADD COLUMN afternoon_condition_text TEXT,
+    ADD COLUMN evening_temp_c FLOAT,
+    ADD COLUMN evening_precipitation_chance_percent INT,
+    ADD COLUMN evening_condition_text TEXT,
+    ADD COLUMN night_temp_c FLOAT,
+    ADD COLUMN night_precipitation_chance_percent INT,
+    ADD COLUMN night_condition_text TEXT;
+
+-- +goose Down
+ALTER TABLE daily_forecasts
+    DROP COLUMN night_condition_text,
+    DROP COLUMN night_precipitation_chance_percent,
+    DROP COLUMN night_temp_c,
+    DROP COLUMN evening_condition_text,
+    DROP COLUMN evening_precipitation_chance_percent,
+    DROP COLUMN evening_temp_c,
+    DROP COLUMN afternoon_condition_text,
+    DROP COLUMN afternoon_precipitation_chance_percent,
+    DROP COLUMN afternoon_temp_c,
+    DROP COLUMN morning_condition_text,
+    DROP COLUMN morning_precipitation_chance_percent,
+    DROP COLUMN morning_temp_c;
@@ -0,0 +1,24 @@
+-- +goose Up
+-- provider_usage_stats holds the calls to each provider made on behalf of a location, and
+-- location_fetch_stats the fetch operations of a location, aggregated per hour. Unlike the
+-- in-memory counters they are collected in, they survive restarts, so that /admin/costs can
+-- extrapolate the provider spend from a window of recorded usage.
+CREATE TABLE provider_usage_stats (
+    hour TIMESTAMPTZ NOT NULL,
+    location_id UUID REFERENCES locations(id) ON DELETE CASCADE NOT NULL,
+    provider TEXT NOT NULL,
+    call_count BIGINT NOT NULL,
+    failure_count BIGINT NOT NULL,
+    PRIMARY KEY (hour, location_id, provider)
+);
+
+CREATE TABLE location_fetch_stats (
+    hour TIMESTAMPTZ NOT NULL,
+    location_id UUID REFERENCES locations(id) ON DELETE CASCADE NOT NULL,
+    operation_count BIGINT NOT NULL,
+    PRIMARY KEY (hour, location_id)
+);
+
+-- +goose Down
+DROP TABLE location_fetch_stats;
+DROP TABLE provider_usage_stats;
@@ -0,0 +1,82 @@
+-- SQLite versions of the queries in sql/queries that use PostgreSQL-only syntax. Each replaces the
+-- generated query of the same name and must take the same parameters in the same order and return
+-- the same columns. The remaining queries run unchanged, apart from their type casts.
+
+-- name: ArchiveCurrentWeatherAtLocation :execrows
+INSERT INTO current_weather_history (
+    id, location_id, source_api, updated_at, temperature_c, humidity, wind_speed_kmh, precipitation_mm, condition_text, archived_at
+)
+SELECT id, location_id, source_api, updated_at, temperature_c, humidity, wind_speed_kmh, precipitation_mm, condition_text, $2
+FROM current_weather WHERE location_id = $1;
+DELETE FROM current_weather WHERE location_id = $1;
+
+-- name: ArchiveDailyForecastsAtLocation :execrows
+INSERT INTO daily_forecast_history (
+    id, location_id, source_api, forecast_date, updated_at, min_temp_c, max_temp_c, precipitation_mm, precipitation_chance_percent, wind_speed_kmh, humidity, archived_at
+)
+SELECT id, location_id, source_api, forecast_date, updated_at, min_temp_c, max_temp_c, precipitation_mm, precipitation_chance_percent, wind_speed_kmh, humidity, $2
+FROM daily_forecasts WHERE location_id = $1;
+DELETE FROM daily_forecasts WHERE location_id = $1;
+
+-- name: ArchiveHourlyForecastsAtLocation :execrows
+INSERT INTO hourly_forecast_history (
+    id, location_id, source_api, forecast_datetime_utc, updated_at, temperature_c, humidity, wind_speed_kmh, precipitation_mm, precipitation_chance_percent, condition_text, archived_at
+)
+SELECT id, location_id, source_api, forecast_datetime_utc, updated_at, temperature_c, humidity, wind_speed_kmh, precipitation_mm, precipitation_chance_percent, condition_text, $2
+FROM hourly_forecasts WHERE location_id = $1;
+DELETE FROM hourly_forecasts WHERE location_id = $1;
+
+-- name: DeleteIdleLocations :many
+DELETE FROM locations
+WHERE id IN (SELECT a.location_id FROM location_access a WHERE a.last_accessed_at < $1)
+  AND NOT EXISTS (SELECT 1 FROM watchlist_entries w WHERE w.location_id = locations.id)
+  AND NOT EXISTS (SELECT 1 FROM alert_subscriptions r WHERE r.location_id = locations.id)
+  AND NOT EXISTS (SELECT 1 FROM location_group_members g WHERE g.location_id = locations.id)
+RETURNING id, city_name;
+
+-- last_updated is a bare column, which SQLite takes from the row with the maximum of the single
+-- MAX() aggregate. Unlike MAX() itself, it keeps its declared type and is read back as a time.
+-- name: GetWatchlistUpdates :many
+SELECT l.id, l.city_name, l.latitude, l.longitude, l.country_code, l.timezone, u.updated_at AS last_updated
+FROM watchlist_entries w
+JOIN locations l ON l.id = w.location_id
+JOIN (
+    SELECT location_id, updated_at FROM current_weather
+    UNION ALL
+    SELECT location_id, updated_at FROM hourly_forecasts
+    UNION ALL
+    SELECT location_id, updated_at FROM daily_forecasts
+) u ON u.location_id = l.id
+WHERE w.subscriber_id = $1
+GROUP BY l.id
+HAVING MAX(u.updated_at) > $2
+ORDER BY last_updated ASC;
+
+-- The upserts read the rows with json_each. Times in the rows keep their offset and are converted
+-- by utc_time to the format the driver stores time parameters in. WHERE true is needed for SQLite
+-- to parse ON CONFLICT after a SELECT.
+-- name: UpsertDailyForecasts :execrows
+INSERT INTO daily_forecasts (
+    id, location_id, source_api, forecast_date, updated_at, min_temp_c, max_temp_c, precipitation_mm, precipitation_chance_percent, wind_speed_kmh, humidity, uv_index, sunrise, sunset, wind_direction_deg, wind_gust_kmh, rain_mm, snow_mm,
+    morning_temp_c, morning_precipitation_chance_percent, morning_condition_text, afternoon_temp_c, afternoon_precipitation_chance_percent, afternoon_condition_text, evening_temp_c, evening_precipitation_chance_percent, evening_condition_text, night_temp_c, night_precipitation_chance_percent, night_condition_text
+)
+SELECT gen_random_uuid(), value ->> 'location_id', value ->> 'source_api', utc_time(value ->> 'forecast_date'), utc_time(value ->> 'updated_at'),
+    value ->> 'min_temp_c', value ->> 'max_temp_c', value ->> 'precipitation_mm', value ->> 'precipitation_chance_percent', value ->> 'wind_speed_kmh', value ->> 'humidity', value ->> 'uv_index',
+    utc_time(value ->> 'sunrise'), utc_time(value ->> 'sunset'), value ->> 'wind_direction_deg', value ->> 'wind_gust_kmh', value ->> 'rain_mm', value ->> 'snow_mm',
+    value ->> 'morning_temp_c', value ->> 'morning_precipitation_chance_percent', value ->> 'morning_condition_text', value ->> 'afternoon_temp_c', value ->> 'afternoon_precipitation_chance_percent', value ->> 'afternoon_condition_text', value ->> 'evening_temp_c', value ->> 'evening_precipitation_chance_percent', value ->> 'evening_condition_text', value ->> 'night_temp_c', value ->> 'night_precipitation_chance_percent', value ->> 'night_condition_text'
+FROM json_each(CAST($1 AS TEXT))
+WHERE true
+ON CONFLICT (location_id, source_api, forecast_date) DO UPDATE
+SET updated_at=excluded.updated_at, min_temp_c=excluded.min_temp_c, max_temp_c=excluded.max_temp_c, precipitation_mm=excluded.precipitation_mm, precipitation_chance_percent=excluded.precipitation_chance_percent, wind_speed_kmh=excluded.wind_speed_kmh, humidity=excluded.humidity, uv_index=excluded.uv_index, sunrise=excluded.sunrise, sunset=excluded.sunset, wind_direction_deg=excluded.wind_direction_deg, wind_gust_kmh=excluded.wind_gust_kmh, rain_mm=excluded.rain_mm, snow_mm=excluded.snow_mm, morning_temp_c=excluded.morning_temp_c, morning_precipitation_chance_percent=excluded.morning_precipitation_chance_percent, morning_condition_text=excluded.morning_condition_text, afternoon_temp_c=excluded.afternoon_temp_c, afternoon_precipitation_chance_percent=excluded.afternoon_precipitation_chance_percent, afternoon_condition_text=excluded.afternoon_condition_text, evening_temp_c=excluded.evening_temp_c, evening_precipitation_chance_percent=excluded.evening_precipitation_chance_percent, evening_condition_text=excluded.evening_condition_text, night_temp_c=excluded.night_temp_c, night_precipitation_chance_percent=excluded.night_precipitation_chance_percent, night_condition_text=excluded.night_condition_text;
+
+-- name: UpsertHourlyForecasts :execrows
+INSERT INTO hourly_forecasts (
+    id, location_id, source_api, forecast_datetime_utc, updated_at, temperature_c, humidity, wind_speed_kmh, precipitation_mm, precipitation_chance_percent, condition_text, wind_direction_deg, wind_gust_kmh, apparent_temperature_c, dew_point_c, rain_mm, snow_mm
+)
+SELECT gen_random_uuid(), value ->> 'location_id', value ->> 'source_api', utc_time(value ->> 'forecast_datetime_utc'), utc_time(value ->> 'updated_at'),
+    value ->> 'temperature_c', value ->> 'humidity', value ->> 'wind_speed_kmh', value ->> 'precipitation_mm', value ->> 'precipitation_chance_percent', value ->> 'condition_text',
+    value ->> 'wind_direction_deg', value ->> 'wind_gust_kmh', value ->> 'apparent_temperature_c', value ->> 'dew_point_c', value ->> 'rain_mm', value ->> 'snow_mm'
+FROM json_each(CAST($1 AS TEXT))
+WHERE true
+ON CONFLICT (location_id, source_api, forecast_datetime_utc) DO UPDATE
+SET updated_at=excluded.updated_at, temperature_c=excluded.temperature_c, humidity=excluded.humidity, wind_speed_kmh=excluded.wind_speed_kmh, precipitation_mm=excluded.precipitation_mm, precipitation_chance_percent=excluded.precipitation_chance_percent, condition_text=excluded.condition_text, wind_direction_deg=excluded.wind_direction_deg, wind_gust_kmh=excluded.wind_gust_kmh, apparent_temperature_c=excluded.apparent_temperature_c, dew_point_c=excluded.dew_point_c, rain_mm=excluded.rain_mm, snow_mm=excluded.snow_mm;
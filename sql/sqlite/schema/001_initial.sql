@@ -0,0 +1,307 @@
+-- +goose Up
+-- The SQLite schema is equivalent to the PostgreSQL schema in sql/schema after all of its
+-- migrations, with column types mapped to SQLite: UUIDs and JSON are stored as TEXT and BLOB,
+-- and timestamps as TEXT in UTC, declared TIMESTAMP or DATE so that they are read back as times.
+-- Columns keep the order of the PostgreSQL tables, which the generated queries rely on. Further
+-- PostgreSQL migrations need an equivalent migration here.
+
+CREATE TABLE locations (
+    id TEXT PRIMARY KEY NOT NULL,
+    city_name TEXT UNIQUE NOT NULL,
+    latitude REAL NOT NULL,
+    longitude REAL NOT NULL,
+    country_code TEXT NOT NULL,
+    timezone TEXT
+);
+
+CREATE TABLE current_weather (
+    id TEXT PRIMARY KEY NOT NULL,
+    location_id TEXT REFERENCES locations(id) ON DELETE CASCADE NOT NULL,
+    source_api TEXT NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    temperature_c REAL,
+    humidity INTEGER,
+    wind_speed_kmh REAL,
+    precipitation_mm REAL,
+    condition_text TEXT,
+    uv_index REAL,
+    sunrise TIMESTAMP,
+    sunset TIMESTAMP,
+    wind_direction_deg REAL,
+    wind_gust_kmh REAL,
+    apparent_temperature_c REAL,
+    dew_point_c REAL,
+    rain_mm REAL,
+    snow_mm REAL
+);
+
+CREATE TABLE daily_forecasts (
+    id TEXT PRIMARY KEY NOT NULL,
+    location_id TEXT REFERENCES locations(id) ON DELETE CASCADE NOT NULL,
+    source_api TEXT NOT NULL,
+    forecast_date DATE NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    min_temp_c REAL,
+    max_temp_c REAL,
+    precipitation_mm REAL,
+    precipitation_chance_percent INTEGER,
+    wind_speed_kmh REAL,
+    humidity INTEGER,
+    uv_index REAL,
+    sunrise TIMESTAMP,
+    sunset TIMESTAMP,
+    wind_direction_deg REAL,
+    wind_gust_kmh REAL,
+    rain_mm REAL,
+    snow_mm REAL
+);
+
+CREATE TABLE hourly_forecasts (
+    id TEXT PRIMARY KEY NOT NULL,
+    location_id TEXT REFERENCES locations(id) ON DELETE CASCADE NOT NULL,
+    source_api TEXT NOT NULL,
+    forecast_datetime_utc TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    temperature_c REAL,
+    humidity INTEGER,
+    wind_speed_kmh REAL,
+    precipitation_mm REAL,
+    precipitation_chance_percent INTEGER,
+    condition_text TEXT,
+    wind_direction_deg REAL,
+    wind_gust_kmh REAL,
+    apparent_temperature_c REAL,
+    dew_point_c REAL,
+    rain_mm REAL,
+    snow_mm REAL
+);
+
+CREATE TABLE location_aliases (
+    alias TEXT PRIMARY KEY NOT NULL,
+    location_id TEXT REFERENCES locations(id) ON DELETE CASCADE NOT NULL
+);
+
+CREATE TABLE watchlist_entries (
+    subscriber_id TEXT NOT NULL,
+    location_id TEXT REFERENCES locations(id) ON DELETE CASCADE NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (subscriber_id, location_id)
+);
+
+CREATE TABLE endpoint_request_stats (
+    hour TIMESTAMP NOT NULL,
+    endpoint TEXT NOT NULL,
+    request_count INTEGER NOT NULL,
+    PRIMARY KEY (hour, endpoint)
+);
+
+CREATE TABLE location_request_stats (
+    hour TIMESTAMP NOT NULL,
+    location_id TEXT REFERENCES locations(id) ON DELETE CASCADE NOT NULL,
+    endpoint TEXT NOT NULL,
+    request_count INTEGER NOT NULL,
+    PRIMARY KEY (hour, location_id, endpoint)
+);
+
+CREATE TABLE weather_observations (
+    location_id TEXT REFERENCES locations(id) ON DELETE CASCADE NOT NULL,
+    source_api TEXT NOT NULL,
+    observed_at TIMESTAMP NOT NULL,
+    temperature_c REAL,
+    humidity INTEGER,
+    wind_speed_kmh REAL,
+    precipitation_mm REAL,
+    condition_text TEXT,
+    PRIMARY KEY (location_id, source_api, observed_at)
+);
+
+CREATE TABLE scheduler_runs (
+    id TEXT PRIMARY KEY NOT NULL,
+    location_id TEXT REFERENCES locations(id) ON DELETE CASCADE NOT NULL,
+    job_type TEXT NOT NULL,
+    provider TEXT NOT NULL,
+    started_at TIMESTAMP NOT NULL,
+    duration_ms INTEGER NOT NULL,
+    rows_written INTEGER NOT NULL,
+    hours_covered INTEGER NOT NULL,
+    error_class TEXT,
+    error_message TEXT
+);
+
+CREATE INDEX scheduler_runs_location_started_at_idx ON scheduler_runs (location_id, started_at DESC);
+CREATE INDEX scheduler_runs_started_at_idx ON scheduler_runs (started_at);
+
+CREATE TABLE current_weather_history (
+    id TEXT PRIMARY KEY NOT NULL,
+    location_id TEXT REFERENCES locations(id) ON DELETE CASCADE NOT NULL,
+    source_api TEXT NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    temperature_c REAL,
+    humidity INTEGER,
+    wind_speed_kmh REAL,
+    precipitation_mm REAL,
+    condition_text TEXT,
+    archived_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX current_weather_history_location_updated_at_idx ON current_weather_history (location_id, updated_at, id);
+
+CREATE TABLE daily_forecast_history (
+    id TEXT PRIMARY KEY NOT NULL,
+    location_id TEXT REFERENCES locations(id) ON DELETE CASCADE NOT NULL,
+    source_api TEXT NOT NULL,
+    forecast_date DATE NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    min_temp_c REAL,
+    max_temp_c REAL,
+    precipitation_mm REAL,
+    precipitation_chance_percent INTEGER,
+    wind_speed_kmh REAL,
+    humidity INTEGER,
+    archived_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX daily_forecast_history_location_date_idx ON daily_forecast_history (location_id, forecast_date, id);
+
+CREATE TABLE hourly_forecast_history (
+    id TEXT PRIMARY KEY NOT NULL,
+    location_id TEXT REFERENCES locations(id) ON DELETE CASCADE NOT NULL,
+    source_api TEXT NOT NULL,
+    forecast_datetime_utc TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    temperature_c REAL,
+    humidity INTEGER,
+    wind_speed_kmh REAL,
+    precipitation_mm REAL,
+    precipitation_chance_percent INTEGER,
+    condition_text TEXT,
+    archived_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX hourly_forecast_history_location_datetime_idx ON hourly_forecast_history (location_id, forecast_datetime_utc, id);
+
+CREATE TABLE alert_subscriptions (
+    id TEXT PRIMARY KEY NOT NULL,
+    subscriber_id TEXT NOT NULL,
+    location_id TEXT REFERENCES locations(id) ON DELETE CASCADE NOT NULL,
+    metric TEXT NOT NULL,
+    operator TEXT NOT NULL,
+    threshold REAL NOT NULL,
+    window_hours INTEGER NOT NULL,
+    webhook_url TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    triggered BOOLEAN NOT NULL DEFAULT false,
+    last_triggered_at TIMESTAMP
+);
+
+CREATE INDEX alert_subscriptions_subscriber_id_idx ON alert_subscriptions (subscriber_id, created_at);
+CREATE INDEX alert_subscriptions_location_id_idx ON alert_subscriptions (location_id);
+
+CREATE TABLE alert_deliveries (
+    id TEXT PRIMARY KEY NOT NULL,
+    subscription_id TEXT REFERENCES alert_subscriptions(id) ON DELETE CASCADE NOT NULL,
+    webhook_url TEXT NOT NULL,
+    payload BLOB NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP,
+    delivered_at TIMESTAMP,
+    last_error TEXT
+);
+
+CREATE INDEX alert_deliveries_next_attempt_at_idx ON alert_deliveries (next_attempt_at) WHERE next_attempt_at IS NOT NULL;
+
+CREATE TABLE api_keys (
+    id TEXT PRIMARY KEY NOT NULL,
+    name TEXT NOT NULL UNIQUE,
+    key_hash TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMP NOT NULL,
+    revoked_at TIMESTAMP
+);
+
+CREATE TABLE weather_warnings (
+    location_id TEXT REFERENCES locations(id) ON DELETE CASCADE NOT NULL,
+    source_api TEXT NOT NULL,
+    warnings BLOB NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (location_id, source_api)
+);
+
+CREATE TABLE air_quality (
+    id TEXT PRIMARY KEY NOT NULL,
+    location_id TEXT REFERENCES locations(id) ON DELETE CASCADE NOT NULL,
+    source_api TEXT NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    aqi INTEGER,
+    pm25_ugm3 REAL,
+    pm10_ugm3 REAL,
+    ozone_ugm3 REAL
+);
+
+CREATE TABLE scheduler_intervals (
+    job_name TEXT PRIMARY KEY NOT NULL,
+    interval_seconds INTEGER NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE location_access (
+    location_id TEXT PRIMARY KEY NOT NULL REFERENCES locations(id) ON DELETE CASCADE,
+    last_accessed_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX location_access_last_accessed_at_idx ON location_access (last_accessed_at);
+
+CREATE TABLE job_runs (
+    id TEXT PRIMARY KEY NOT NULL,
+    job_name TEXT NOT NULL,
+    status TEXT NOT NULL,
+    started_at TIMESTAMP NOT NULL,
+    finished_at TIMESTAMP,
+    duration_ms INTEGER,
+    locations_total INTEGER NOT NULL DEFAULT 0,
+    locations_succeeded INTEGER NOT NULL DEFAULT 0,
+    locations_failed INTEGER NOT NULL DEFAULT 0,
+    locations_skipped INTEGER NOT NULL DEFAULT 0,
+    error TEXT
+);
+
+CREATE INDEX job_runs_started_at_idx ON job_runs (started_at DESC);
+CREATE INDEX job_runs_job_name_started_at_idx ON job_runs (job_name, started_at DESC);
+
+CREATE TABLE job_run_locations (
+    job_run_id TEXT REFERENCES job_runs(id) ON DELETE CASCADE NOT NULL,
+    location_id TEXT REFERENCES locations(id) ON DELETE CASCADE NOT NULL,
+    status TEXT NOT NULL,
+    queued_at TIMESTAMP NOT NULL,
+    started_at TIMESTAMP,
+    finished_at TIMESTAMP,
+    duration_ms INTEGER,
+    error TEXT,
+    PRIMARY KEY (job_run_id, location_id)
+);
+
+CREATE INDEX job_run_locations_location_id_idx ON job_run_locations (location_id, queued_at DESC);
+
+-- +goose Down
+DROP TABLE job_run_locations;
+DROP TABLE job_runs;
+DROP TABLE location_access;
+DROP TABLE scheduler_intervals;
+DROP TABLE air_quality;
+DROP TABLE weather_warnings;
+DROP TABLE api_keys;
+DROP TABLE alert_deliveries;
+DROP TABLE alert_subscriptions;
+DROP TABLE hourly_forecast_history;
+DROP TABLE daily_forecast_history;
+DROP TABLE current_weather_history;
+DROP TABLE scheduler_runs;
+DROP TABLE weather_observations;
+DROP TABLE location_request_stats;
+DROP TABLE endpoint_request_stats;
+DROP TABLE watchlist_entries;
+DROP TABLE location_aliases;
+DROP TABLE hourly_forecasts;
+DROP TABLE daily_forecasts;
+DROP TABLE current_weather;
+DROP TABLE locations;
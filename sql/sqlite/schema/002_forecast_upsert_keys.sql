@@ -0,0 +1,26 @@
+-- +goose Up
+-- Equivalent of sql/schema/023_forecast_upsert_keys.sql.
+DELETE FROM hourly_forecasts
+WHERE EXISTS (
+    SELECT 1 FROM hourly_forecasts b
+    WHERE b.location_id = hourly_forecasts.location_id
+      AND b.source_api = hourly_forecasts.source_api
+      AND b.forecast_datetime_utc = hourly_forecasts.forecast_datetime_utc
+      AND (b.updated_at, b.id) > (hourly_forecasts.updated_at, hourly_forecasts.id)
+);
+
+DELETE FROM daily_forecasts
+WHERE EXISTS (
+    SELECT 1 FROM daily_forecasts b
+    WHERE b.location_id = daily_forecasts.location_id
+      AND b.source_api = daily_forecasts.source_api
+      AND b.forecast_date = daily_forecasts.forecast_date
+      AND (b.updated_at, b.id) > (daily_forecasts.updated_at, daily_forecasts.id)
+);
+
+CREATE UNIQUE INDEX hourly_forecasts_location_source_datetime_key ON hourly_forecasts (location_id, source_api, forecast_datetime_utc);
+CREATE UNIQUE INDEX daily_forecasts_location_source_date_key ON daily_forecasts (location_id, source_api, forecast_date);
+
+-- +goose Down
+DROP INDEX daily_forecasts_location_source_date_key;
+DROP INDEX hourly_forecasts_location_source_datetime_key;
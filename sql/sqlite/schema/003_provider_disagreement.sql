@@ -0,0 +1,21 @@
+-- +goose Up
+-- Equivalent of sql/schema/024_provider_disagreement.sql.
+CREATE TABLE provider_disagreement (
+    location_id TEXT REFERENCES locations(id) ON DELETE CASCADE NOT NULL,
+    computed_on DATE NOT NULL,
+    forecast_date DATE NOT NULL,
+    computed_at TIMESTAMP NOT NULL,
+    sources INTEGER NOT NULL,
+    min_temp_spread_c REAL NOT NULL,
+    max_temp_spread_c REAL NOT NULL,
+    precipitation_spread_mm REAL NOT NULL,
+    precipitation_chance_spread REAL NOT NULL,
+    wind_speed_spread_kmh REAL NOT NULL,
+    condition_agreement REAL NOT NULL,
+    PRIMARY KEY (location_id, computed_on, forecast_date)
+);
+
+CREATE INDEX provider_disagreement_computed_on_idx ON provider_disagreement (computed_on);
+
+-- +goose Down
+DROP TABLE provider_disagreement;
@@ -0,0 +1,11 @@
+-- +goose Up
+-- Equivalent of sql/schema/025_location_provider_weights.sql.
+CREATE TABLE location_provider_weights (
+    location_id TEXT REFERENCES locations(id) ON DELETE CASCADE NOT NULL,
+    provider TEXT NOT NULL,
+    weight REAL NOT NULL CHECK (weight >= 0),
+    PRIMARY KEY (location_id, provider)
+);
+
+-- +goose Down
+DROP TABLE location_provider_weights;
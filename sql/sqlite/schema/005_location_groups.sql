@@ -0,0 +1,22 @@
+-- +goose Up
+-- Equivalent of sql/schema/026_location_groups.sql.
+CREATE TABLE location_groups (
+    id TEXT PRIMARY KEY,
+    subscriber_id TEXT NOT NULL,
+    name TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    UNIQUE (subscriber_id, name)
+);
+
+CREATE TABLE location_group_members (
+    group_id TEXT REFERENCES location_groups(id) ON DELETE CASCADE NOT NULL,
+    location_id TEXT REFERENCES locations(id) ON DELETE CASCADE NOT NULL,
+    added_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (group_id, location_id)
+);
+
+CREATE INDEX location_group_members_location_id_idx ON location_group_members (location_id);
+
+-- +goose Down
+DROP TABLE location_group_members;
+DROP TABLE location_groups;
@@ -0,0 +1,15 @@
+-- +goose Up
+-- Equivalent of sql/schema/027_users.sql.
+CREATE TABLE users (
+    id TEXT PRIMARY KEY,
+    email TEXT NOT NULL UNIQUE,
+    password_hash TEXT NOT NULL,
+    units TEXT NOT NULL DEFAULT '',
+    language TEXT NOT NULL DEFAULT '',
+    default_city TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+-- +goose Down
+DROP TABLE users;
@@ -0,0 +1,6 @@
+-- +goose Up
+-- Equivalent of sql/schema/028_api_key_quotas.sql.
+ALTER TABLE api_keys ADD COLUMN daily_quota INTEGER CHECK (daily_quota >= 0);
+
+-- +goose Down
+ALTER TABLE api_keys DROP COLUMN daily_quota;
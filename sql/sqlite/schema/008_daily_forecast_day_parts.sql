@@ -0,0 +1,28 @@
+-- +goose Up
+-- Equivalent of sql/schema/029_daily_forecast_day_parts.sql.
+ALTER TABLE daily_forecasts ADD COLUMN morning_temp_c REAL;
+ALTER TABLE daily_forecasts ADD COLUMN morning_precipitation_chance_percent INTEGER;
+ALTER TABLE daily_forecasts ADD COLUMN morning_condition_text TEXT;
+ALTER TABLE daily_forecasts ADD COLUMN afternoon_temp_c REAL;
+ALTER TABLE daily_forecasts ADD COLUMN afternoon_precipitation_chance_percent INTEGER;
+ALTER TABLE daily_forecasts ADD COLUMN afternoon_condition_text TEXT;
+ALTER TABLE daily_forecasts ADD COLUMN evening_temp_c REAL;
+ALTER TABLE daily_forecasts ADD COLUMN evening_precipitation_chance_percent INTEGER;
+ALTER TABLE daily_forecasts ADD COLUMN evening_condition_text TEXT;
+ALTER TABLE daily_forecasts ADD COLUMN night_temp_c REAL;
+ALTER TABLE daily_forecasts ADD COLUMN night_precipitation_chance_percent INTEGER;
+ALTER TABLE daily_forecasts ADD COLUMN night_condition_text TEXT;
+
+-- +goose Down
+ALTER TABLE daily_forecasts DROP COLUMN night_condition_text;
+ALTER TABLE daily_forecasts DROP COLUMN night_precipitation_chance_percent;
+ALTER TABLE daily_forecasts DROP COLUMN night_temp_c;
+ALTER TABLE daily_forecasts DROP COLUMN evening_condition_text;
+ALTER TABLE daily_forecasts DROP COLUMN evening_precipitation_chance_percent;
+ALTER TABLE daily_forecasts DROP COLUMN evening_temp_c;
+ALTER TABLE daily_forecasts DROP COLUMN afternoon_condition_text;
+ALTER TABLE daily_forecasts DROP COLUMN afternoon_precipitation_chance_percent;
+ALTER TABLE daily_forecasts DROP COLUMN afternoon_temp_c;
+ALTER TABLE daily_forecasts DROP COLUMN morning_condition_text;
+ALTER TABLE daily_forecasts DROP COLUMN morning_precipitation_chance_percent;
+ALTER TABLE daily_forecasts DROP COLUMN morning_temp_c;
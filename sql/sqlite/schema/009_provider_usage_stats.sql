@@ -0,0 +1,21 @@
+-- +goose Up
+-- Equivalent of sql/schema/030_provider_usage_stats.sql.
+CREATE TABLE provider_usage_stats (
+    hour TIMESTAMP NOT NULL,
+    location_id TEXT REFERENCES locations(id) ON DELETE CASCADE NOT NULL,
+    provider TEXT NOT NULL,
+    call_count INTEGER NOT NULL,
+    failure_count INTEGER NOT NULL,
+    PRIMARY KEY (hour, location_id, provider)
+);
+
+CREATE TABLE location_fetch_stats (
+    hour TIMESTAMP NOT NULL,
+    location_id TEXT REFERENCES locations(id) ON DELETE CASCADE NOT NULL,
+    operation_count INTEGER NOT NULL,
+    PRIMARY KEY (hour, location_id)
+);
+
+-- +goose Down
+DROP TABLE location_fetch_stats;
+DROP TABLE provider_usage_stats;
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cor0nius/willitrain/internal/database"
+	"github.com/google/uuid"
+	"modernc.org/sqlite"
+)
+
+// This file implements the SQLite storage backend, selected with DB_DRIVER=sqlite, which runs the
+// application as a single binary without a PostgreSQL server, for example on a Raspberry Pi.
+// DB_URL is then the path of the database file.
+//
+// The backend runs the sqlc queries generated for PostgreSQL. Most of them are plain SQL that
+// SQLite understands once their type casts are removed; the few that are not have a SQLite
+// version in sql/sqlite/queries.sql. The schema is created from sql/sqlite/schema at startup.
+// Times are stored as text in UTC, so that they compare correctly as strings.
+
+// Database drivers selectable with DB_DRIVER.
+const (
+	dbDriverPostgres = "postgres"
+	dbDriverSQLite   = "sqlite"
+)
+
+//go:embed sql/sqlite
+var sqliteFiles embed.FS
+
+// getDBDriver reads the database driver from DB_DRIVER.
+func getDBDriver(logger *slog.Logger) string {
+	driver := strings.ToLower(strings.TrimSpace(getEnv("DB_DRIVER", dbDriverPostgres, logger)))
+	switch driver {
+	case dbDriverPostgres, dbDriverSQLite:
+		return driver
+	}
+	logger.Warn("unknown DB_DRIVER, using default", "value", driver, "default", dbDriverPostgres)
+	return dbDriverPostgres
+}
+
+// sqliteDSN returns the data source name of a SQLite database file. Foreign keys are enforced so
+// that deletions cascade as in PostgreSQL, times are written in a format SQLite can compare and
+// parse, and write transactions take the database lock when they begin, so that concurrent
+// transactions wait for each other instead of failing.
+func sqliteDSN(path string) string {
+	dsn := path
+	if !strings.HasPrefix(dsn, "file:") {
+		dsn = "file:" + dsn
+	}
+	sep := "?"
+	if strings.Contains(dsn, "?") {
+		sep = "&"
+	}
+	return dsn + sep + "_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_time_format=sqlite&_txlock=immediate"
+}
+
+var registerSQLiteFunctionsOnce sync.Once
+
+// registerSQLiteFunctions registers the PostgreSQL functions the queries use.
+func registerSQLiteFunctions() {
+	registerSQLiteFunctionsOnce.Do(func() {
+		sqlite.MustRegisterScalarFunction("gen_random_uuid", 0, func(ctx *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+			return uuid.NewString(), nil
+		})
+		// Values of the same column compare as strings, which is all GREATEST is used for.
+		sqlite.MustRegisterDeterministicScalarFunction("greatest", -1, func(ctx *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+			var greatest driver.Value
+			for _, arg := range args {
+				if greatest == nil || fmt.Sprint(arg) > fmt.Sprint(greatest) {
+					greatest = arg
+				}
+			}
+			return greatest, nil
+		})
+		// The times in the JSON rows of the bulk upserts are converted to UTC, in the format the
+		// driver writes time parameters in.
+		sqlite.MustRegisterDeterministicScalarFunction("utc_time", 1, func(ctx *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+			s, ok := args[0].(string)
+			if !ok {
+				return args[0], nil
+			}
+			t, err := time.Parse(dbTimeFormat, s)
+			if err != nil {
+				return nil, err
+			}
+			return t.UTC().Format(dbTimeFormat), nil
+		})
+	})
+}
+
+// migrateSQLite applies the migrations in sql/sqlite/schema that have not been applied yet. Like
+// goose, it runs the "-- +goose Up" section of each file and records its version.
+func migrateSQLite(ctx context.Context, db *sql.DB) error {
+	if _, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+    version TEXT PRIMARY KEY NOT NULL,
+    applied_at TIMESTAMP NOT NULL
+)`); err != nil {
+		return fmt.Errorf("could not create migrations table: %w", err)
+	}
+
+	files, err := fs.Glob(sqliteFiles, "sql/sqlite/schema/*.sql")
+	if err != nil {
+		return err
+	}
+	sort.Strings(files)
+	for _, file := range files {
+		version := strings.TrimSuffix(file[strings.LastIndex(file, "/")+1:], ".sql")
+		var applied int
+		if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM schema_migrations WHERE version = $1", version).Scan(&applied); err != nil {
+			return fmt.Errorf("could not read migrations table: %w", err)
+		}
+		if applied > 0 {
+			continue
+		}
+		content, err := sqliteFiles.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, gooseUpSection(string(content))); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("could not apply migration %s: %w", version, err)
+		}
+		if _, err := tx.ExecContext(ctx, "INSERT INTO schema_migrations (version, applied_at) VALUES ($1, $2)", version, time.Now().UTC()); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("could not record migration %s: %w", version, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("could not apply migration %s: %w", version, err)
+		}
+	}
+	return nil
+}
+
+// gooseUpSection returns the statements between the "-- +goose Up" and "-- +goose Down"
+// annotations of a migration.
+func gooseUpSection(migration string) string {
+	_, up, found := strings.Cut(migration, "-- +goose Up")
+	if !found {
+		return migration
+	}
+	up, _, _ = strings.Cut(up, "-- +goose Down")
+	return up
+}
+
+// sqliteQueryOverrides maps query names to the SQLite versions in sql/sqlite/queries.sql.
+var sqliteQueryOverrides = sync.OnceValue(func() map[string]string {
+	content, err := sqliteFiles.ReadFile("sql/sqlite/queries.sql")
+	if err != nil {
+		panic(err)
+	}
+	return parseSQLiteQueries(string(content))
+})
+
+// parseSQLiteQueries parses queries in the sqlc format. A query starts at its "-- name:" line and
+// ends at the next blank line; comment lines before the name line are skipped.
+func parseSQLiteQueries(content string) map[string]string {
+	queries := make(map[string]string)
+	var name string
+	var query strings.Builder
+	flush := func() {
+		if name != "" {
+			queries[name] = query.String()
+		}
+		name = ""
+		query.Reset()
+	}
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.TrimSpace(line) == "":
+			flush()
+		case strings.HasPrefix(line, "-- name: "):
+			name = queryName(line)
+			query.WriteString(line + "\n")
+		case name != "":
+			query.WriteString(line + "\n")
+		}
+	}
+	flush()
+	return queries
+}
+
+// postgresCast matches the PostgreSQL type casts in the generated queries, such as "::timestamptz".
+var postgresCast = regexp.MustCompile(`::[a-z]+(\[\])?`)
+
+// sqliteQueries caches the SQLite versions of the generated queries.
+var sqliteQueries sync.Map
+
+// sqliteQuery returns the SQLite version of a generated query: its override if it has one, and
+// otherwise the query without type casts and row locks, which SQLite does not need.
+func sqliteQuery(query string) string {
+	if q, ok := sqliteQueries.Load(query); ok {
+		return q.(string)
+	}
+	q, ok := sqliteQueryOverrides()[queryName(query)]
+	if !ok {
+		q = postgresCast.ReplaceAllString(query, "")
+		q = strings.ReplaceAll(q, "FOR UPDATE SKIP LOCKED", "")
+	}
+	sqliteQueries.Store(query, q)
+	return q
+}
+
+// sqliteArgs converts times to UTC, so that they are stored in the same format and compare
+// correctly as strings.
+func sqliteArgs(args []any) []any {
+	for i, arg := range args {
+		switch v := arg.(type) {
+		case time.Time:
+			args[i] = v.UTC()
+		case sql.NullTime:
+			if v.Valid {
+				args[i] = sql.NullTime{Time: v.Time.UTC(), Valid: true}
+			}
+		}
+	}
+	return args
+}
+
+// sqliteDB wraps a SQLite connection or transaction and translates the generated PostgreSQL
+// queries run through it.
+type sqliteDB struct {
+	db database.DBTX
+}
+
+func (s sqliteDB) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return s.db.ExecContext(ctx, sqliteQuery(query), sqliteArgs(args)...)
+}
+
+func (s sqliteDB) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return s.db.PrepareContext(ctx, sqliteQuery(query))
+}
+
+func (s sqliteDB) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return s.db.QueryContext(ctx, sqliteQuery(query), sqliteArgs(args)...)
+}
+
+func (s sqliteDB) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	return s.db.QueryRowContext(ctx, sqliteQuery(query), sqliteArgs(args)...)
+}
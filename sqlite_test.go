@@ -0,0 +1,261 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cor0nius/willitrain/internal/database"
+	"github.com/google/uuid"
+)
+
+func newSQLiteTestConfig(t *testing.T) *apiConfig {
+	t.Helper()
+	cfg := &apiConfig{
+		dbDriver:        dbDriverSQLite,
+		dbURL:           filepath.Join(t.TempDir(), "willitrain.db"),
+		newDBClientFunc: sql.Open,
+		logger:          slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	if err := cfg.ConnectDB(); err != nil {
+		t.Fatalf("ConnectDB: %v", err)
+	}
+	t.Cleanup(func() { cfg.db.Close() })
+	return cfg
+}
+
+// TestSQLiteQueries checks that every generated query is valid SQLite once translated.
+func TestSQLiteQueries(t *testing.T) {
+	cfg := newSQLiteTestConfig(t)
+
+	files, err := filepath.Glob("internal/database/*.sql.go")
+	if err != nil || len(files) == 0 {
+		t.Fatalf("could not list the generated queries: %v", err)
+	}
+	var checked int
+	for _, file := range files {
+		f, err := parser.ParseFile(token.NewFileSet(), file, nil, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ast.Inspect(f, func(n ast.Node) bool {
+			lit, ok := n.(*ast.BasicLit)
+			if !ok || lit.Kind != token.STRING {
+				return true
+			}
+			query, err := strconv.Unquote(lit.Value)
+			if err != nil || !strings.HasPrefix(query, "-- name: ") {
+				return true
+			}
+			checked++
+			stmt, err := cfg.db.Prepare(sqliteQuery(query))
+			if err != nil {
+				t.Errorf("%s: %v", queryName(query), err)
+				return true
+			}
+			stmt.Close()
+			return true
+		})
+	}
+	if checked < len(files) {
+		t.Errorf("found only %d queries in %d files", checked, len(files))
+	}
+}
+
+func TestSQLiteStorage(t *testing.T) {
+	ctx := context.Background()
+	cfg := newSQLiteTestConfig(t)
+	q := cfg.dbQueries
+
+	// Migrations that were already applied are skipped.
+	if err := migrateSQLite(ctx, cfg.db); err != nil {
+		t.Fatalf("second migration: %v", err)
+	}
+
+	location, err := q.CreateLocation(ctx, database.CreateLocationParams{CityName: "Wrocław", Latitude: 51.1, Longitude: 17.03, CountryCode: "PL"})
+	if err != nil {
+		t.Fatalf("CreateLocation: %v", err)
+	}
+	warsaw := time.FixedZone("CEST", 2*60*60)
+	updatedAt := time.Date(2025, 6, 1, 14, 0, 0, 0, warsaw)
+	if _, err := q.CreateCurrentWeather(ctx, database.CreateCurrentWeatherParams{
+		LocationID:   location.ID,
+		SourceApi:    "ometeo",
+		UpdatedAt:    updatedAt,
+		TemperatureC: sql.NullFloat64{Float64: 21.5, Valid: true},
+	}); err != nil {
+		t.Fatalf("CreateCurrentWeather: %v", err)
+	}
+	weather, err := q.GetCurrentWeatherAtLocation(ctx, location.ID)
+	if err != nil || len(weather) != 1 {
+		t.Fatalf("GetCurrentWeatherAtLocation = %v, %v", weather, err)
+	}
+	if !weather[0].UpdatedAt.Equal(updatedAt) || weather[0].TemperatureC.Float64 != 21.5 {
+		t.Errorf("unexpected current weather: %+v", weather[0])
+	}
+
+	// Times in other zones are stored in UTC, so they compare correctly.
+	if _, err := q.CreateWatchlistEntry(ctx, database.CreateWatchlistEntryParams{SubscriberID: "device", LocationID: location.ID, CreatedAt: updatedAt}); err != nil {
+		t.Fatalf("CreateWatchlistEntry: %v", err)
+	}
+	updates, err := q.GetWatchlistUpdates(ctx, database.GetWatchlistUpdatesParams{SubscriberID: "device", Since: updatedAt.UTC().Add(-time.Minute)})
+	if err != nil || len(updates) != 1 || !updates[0].LastUpdated.Equal(updatedAt) {
+		t.Errorf("GetWatchlistUpdates = %+v, %v", updates, err)
+	}
+
+	// Forecasts are upserted in bulk, and times in other zones are stored as other times are.
+	forecastLocation := Location{LocationID: location.ID, CityName: location.CityName}
+	forecastDate := time.Date(2025, 6, 2, 0, 0, 0, 0, warsaw)
+	eveningTemp, eveningChance, eveningCondition := 18.5, int32(70), "Rain"
+	evening := DayPart{Temperature: &eveningTemp, PrecipitationChance: &eveningChance, Condition: &eveningCondition}
+	for _, maxTemp := range []float64{20, 24} {
+		cfg.persistDailyForecast(ctx, []DailyForecast{
+			{Location: forecastLocation, SourceAPI: "ometeo", ForecastDate: forecastDate, Timestamp: updatedAt, MaxTemp: maxTemp, Sunrise: forecastDate.Add(5 * time.Hour), Evening: evening},
+		})
+	}
+	daily, err := q.GetDailyForecastAtLocationAndDateFromAPI(ctx, database.GetDailyForecastAtLocationAndDateFromAPIParams{LocationID: location.ID, ForecastDate: forecastDate, SourceApi: "ometeo"})
+	if err != nil || daily.MaxTempC.Float64 != 24 || !daily.Sunrise.Time.Equal(forecastDate.Add(5*time.Hour)) {
+		t.Errorf("GetDailyForecastAtLocationAndDateFromAPI = %+v, %v", daily, err)
+	}
+	if daily.EveningTempC.Float64 != 18.5 || daily.EveningPrecipitationChancePercent.Int32 != 70 || daily.EveningConditionText.String != "Rain" || daily.MorningTempC.Valid {
+		t.Errorf("unexpected day parts %+v", daily)
+	}
+	cfg.persistHourlyForecast(ctx, []HourlyForecast{
+		{Location: forecastLocation, SourceAPI: "ometeo", ForecastDateTime: updatedAt, Timestamp: updatedAt, Condition: "Clear"},
+		{Location: forecastLocation, SourceAPI: "ometeo", ForecastDateTime: updatedAt.Add(time.Hour), Timestamp: updatedAt},
+	})
+	hourly, err := q.GetAllHourlyForecastsAtLocation(ctx, location.ID)
+	if err != nil || len(hourly) != 2 || hourly[0].ConditionText.String != "Clear" || hourly[1].RainMm.Valid {
+		t.Errorf("GetAllHourlyForecastsAtLocation = %+v, %v", hourly, err)
+	}
+	hourly, err = q.GetUpcomingHourlyForecastsAtLocation(ctx, database.GetUpcomingHourlyForecastsAtLocationParams{
+		LocationID: location.ID,
+		FromTime:   updatedAt.Add(-time.Hour),
+		ToTime:     updatedAt.Add(24 * time.Hour),
+		MaxHours:   1,
+	})
+	if err != nil || len(hourly) != 1 || !hourly[0].ForecastDatetimeUtc.Equal(updatedAt) {
+		t.Errorf("GetUpcomingHourlyForecastsAtLocation = %+v, %v", hourly, err)
+	}
+
+	// Provider usage is added up per hour and summed over the window.
+	usageHour := statsHour(updatedAt)
+	for range 2 {
+		if err := q.IncrementProviderUsageStats(ctx, database.IncrementProviderUsageStatsParams{Hour: usageHour, LocationID: location.ID, Provider: "owm", CallCount: 2, FailureCount: 1}); err != nil {
+			t.Fatalf("IncrementProviderUsageStats: %v", err)
+		}
+		if err := q.IncrementLocationFetchStats(ctx, database.IncrementLocationFetchStatsParams{Hour: usageHour, LocationID: location.ID, OperationCount: 1}); err != nil {
+			t.Fatalf("IncrementLocationFetchStats: %v", err)
+		}
+	}
+	if first, err := q.GetFirstProviderUsageHourSince(ctx, usageHour.Add(-time.Hour)); err != nil || !first.Equal(usageHour) {
+		t.Errorf("GetFirstProviderUsageHourSince = %v, %v", first, err)
+	}
+	usage, err := q.GetProviderUsageSince(ctx, usageHour)
+	if err != nil || len(usage) != 1 || usage[0].CallCount != 4 || usage[0].FailureCount != 2 {
+		t.Errorf("GetProviderUsageSince = %+v, %v", usage, err)
+	}
+	fetches, err := q.GetLocationFetchesSince(ctx, usageHour)
+	if err != nil || len(fetches) != 1 || fetches[0].OperationCount != 2 {
+		t.Errorf("GetLocationFetchesSince = %+v, %v", fetches, err)
+	}
+
+	archived, err := q.ArchiveCurrentWeatherAtLocation(ctx, database.ArchiveCurrentWeatherAtLocationParams{LocationID: location.ID, ArchivedAt: updatedAt})
+	if err != nil || archived != 1 {
+		t.Errorf("ArchiveCurrentWeatherAtLocation = %d, %v", archived, err)
+	}
+	if weather, _ := q.GetCurrentWeatherAtLocation(ctx, location.ID); len(weather) != 0 {
+		t.Errorf("expected archived current weather to be deleted, got %d rows", len(weather))
+	}
+
+	// The latest access time is kept, and idle locations are deleted with their data.
+	for _, at := range []time.Time{updatedAt, updatedAt.Add(-time.Hour)} {
+		if err := q.TouchLocationAccess(ctx, database.TouchLocationAccessParams{LocationID: location.ID, LastAccessedAt: at}); err != nil {
+			t.Fatalf("TouchLocationAccess: %v", err)
+		}
+	}
+	if deleted, err := q.DeleteIdleLocations(ctx, updatedAt); err != nil || len(deleted) != 0 {
+		t.Errorf("expected no idle locations, got %v, %v", deleted, err)
+	}
+	if _, err := q.DeleteWatchlistEntriesForSubscriber(ctx, "device"); err != nil {
+		t.Fatal(err)
+	}
+	deleted, err := q.DeleteIdleLocations(ctx, updatedAt.Add(time.Minute))
+	if err != nil || len(deleted) != 1 || deleted[0].ID != location.ID {
+		t.Errorf("DeleteIdleLocations = %v, %v", deleted, err)
+	}
+	if _, err := q.GetLocationByID(ctx, location.ID); err != sql.ErrNoRows {
+		t.Errorf("expected the idle location to be deleted, got %v", err)
+	}
+}
+
+// TestSQLiteRunInTx checks that forecasts cleared in a failed refresh are restored.
+func TestSQLiteRunInTx(t *testing.T) {
+	ctx := context.Background()
+	cfg := newSQLiteTestConfig(t)
+
+	dbLocation, err := cfg.dbQueries.CreateLocation(ctx, database.CreateLocationParams{CityName: "Gdańsk", Latitude: 54.35, Longitude: 18.65, CountryCode: "PL"})
+	if err != nil {
+		t.Fatalf("CreateLocation: %v", err)
+	}
+	location := Location{LocationID: dbLocation.ID, CityName: dbLocation.CityName}
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	forecast := []HourlyForecast{{Location: location, SourceAPI: "ometeo", ForecastDateTime: now, Timestamp: now}}
+	if err := cfg.storeHourlyForecast(ctx, cfg.dbQueries, forecast); err != nil {
+		t.Fatalf("storeHourlyForecast: %v", err)
+	}
+
+	errRefresh := errors.New("refresh failed")
+	err = cfg.runInTx(ctx, func(q dbQuerier) error {
+		if err := cfg.clearHourlyForecasts(ctx, q, location.LocationID); err != nil {
+			return err
+		}
+		return errRefresh
+	})
+	if !errors.Is(err, errRefresh) {
+		t.Fatalf("expected the refresh error, got %v", err)
+	}
+	if hourly, err := cfg.dbQueries.GetAllHourlyForecastsAtLocation(ctx, location.LocationID); err != nil || len(hourly) != 1 {
+		t.Errorf("expected the forecast to be kept, got %d rows, %v", len(hourly), err)
+	}
+
+	err = cfg.runInTx(ctx, func(q dbQuerier) error {
+		if err := cfg.clearHourlyForecasts(ctx, q, location.LocationID); err != nil {
+			return err
+		}
+		return cfg.storeHourlyForecast(ctx, q, append(forecast, HourlyForecast{Location: location, SourceAPI: "ometeo", ForecastDateTime: now.Add(time.Hour), Timestamp: now}))
+	})
+	if err != nil {
+		t.Fatalf("runInTx: %v", err)
+	}
+	if hourly, err := cfg.dbQueries.GetAllHourlyForecastsAtLocation(ctx, location.LocationID); err != nil || len(hourly) != 2 {
+		t.Errorf("expected the forecasts to be replaced, got %d rows, %v", len(hourly), err)
+	}
+}
+
+func TestSQLiteUniqueViolation(t *testing.T) {
+	ctx := context.Background()
+	cfg := newSQLiteTestConfig(t)
+
+	arg := database.CreateUserParams{ID: uuid.New(), Email: "ala@example.com", PasswordHash: "hash", CreatedAt: time.Now().UTC()}
+	if _, err := cfg.dbQueries.CreateUser(ctx, arg); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	arg.ID = uuid.New()
+	if _, err := cfg.dbQueries.CreateUser(ctx, arg); !isUniqueViolation(err) {
+		t.Errorf("expected a unique violation for a duplicate email, got %v", err)
+	}
+	if isUniqueViolation(errors.New("other error")) {
+		t.Error("expected other errors not to be unique violations")
+	}
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// This file implements geocoding from a bundled city dataset. The StaticGeocodingService
+// answers lookups for the cities in geodata/cities.tsv itself and passes only misses on to a
+// fallback service, usually the Google Maps geocoder. This takes the external geocoder off the
+// critical path for the most common locations and keeps them resolvable when no geocoding key
+// is configured.
+
+// staticReverseGeocodeRadiusKm is the maximum distance between requested coordinates and a
+// bundled city for the city to be used as the reverse geocoding result.
+const staticReverseGeocodeRadiusKm = 15.0
+
+// earthRadiusKm is the mean radius of the Earth used for distance calculations.
+const earthRadiusKm = 6371.0
+
+//go:generate go run geodata/generate.go -o geodata/cities.tsv
+//go:embed geodata/cities.tsv
+var bundledCitiesTSV string
+
+// staticCity is a single entry of the bundled city dataset.
+type staticCity struct {
+	Location Location
+	alias    string
+}
+
+// StaticGeocodingService is an implementation of GeocodingService backed by the bundled city
+// dataset, with an optional fallback service for lookups the dataset cannot answer.
+type StaticGeocodingService struct {
+	cities   []staticCity
+	byAlias  map[string][]int
+	fallback GeocodingService
+}
+
+// NewStaticGeocodingService creates a StaticGeocodingService from the bundled dataset.
+// fallback may be nil, in which case misses are reported as ErrNoResultsFound.
+func NewStaticGeocodingService(fallback GeocodingService) (*StaticGeocodingService, error) {
+	cities, err := parseStaticCities(bundledCitiesTSV)
+	if err != nil {
+		return nil, fmt.Errorf("could not load bundled city dataset: %w", err)
+	}
+	return newStaticGeocodingService(cities, fallback), nil
+}
+
+func newStaticGeocodingService(cities []staticCity, fallback GeocodingService) *StaticGeocodingService {
+	s := &StaticGeocodingService{
+		cities:   cities,
+		byAlias:  make(map[string][]int),
+		fallback: fallback,
+	}
+	for i, c := range cities {
+		s.byAlias[c.alias] = append(s.byAlias[c.alias], i)
+	}
+	return s
+}
+
+// parseStaticCities parses the tab-separated city dataset. Empty lines and lines starting
+// with '#' are ignored.
+func parseStaticCities(data string) ([]staticCity, error) {
+	var cities []staticCity
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if len(fields) != 5 {
+			return nil, fmt.Errorf("line %d: expected 5 fields, got %d", lineNo, len(fields))
+		}
+		lat, err := strconv.ParseFloat(fields[2], 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid latitude: %w", lineNo, err)
+		}
+		lon, err := strconv.ParseFloat(fields[3], 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid longitude: %w", lineNo, err)
+		}
+		alias, err := normalizeCityName(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid name: %w", lineNo, err)
+		}
+		cities = append(cities, staticCity{
+			Location: Location{
+				CityName:    fields[0],
+				CountryCode: fields[1],
+				Latitude:    lat,
+				Longitude:   lon,
+				Timezone:    fields[4],
+			},
+			alias: alias,
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return cities, nil
+}
+
+// Geocode resolves a city name from the dataset. A trailing two-letter country code, as in
+// "Paris, FR", restricts the match to that country. Names that are not in the dataset are
+// passed on to the fallback service.
+func (s *StaticGeocodingService) Geocode(cityName string) (Location, error) {
+	if location, ok := s.lookup(cityName); ok {
+		staticGeocodeLookups.WithLabelValues("hit").Inc()
+		return location, nil
+	}
+	staticGeocodeLookups.WithLabelValues("miss").Inc()
+	if s.fallback == nil {
+		return Location{}, ErrNoResultsFound
+	}
+	return s.fallback.Geocode(cityName)
+}
+
+// ReverseGeocode returns the nearest bundled city within staticReverseGeocodeRadiusKm of the
+// coordinates, or the fallback service's answer if there is none.
+func (s *StaticGeocodingService) ReverseGeocode(lat, lng float64) (Location, error) {
+	best, bestDist := -1, staticReverseGeocodeRadiusKm
+	for i, c := range s.cities {
+		if d := haversineKm(lat, lng, c.Location.Latitude, c.Location.Longitude); d <= bestDist {
+			best, bestDist = i, d
+		}
+	}
+	if best >= 0 {
+		staticGeocodeLookups.WithLabelValues("hit").Inc()
+		return s.cities[best].Location, nil
+	}
+	staticGeocodeLookups.WithLabelValues("miss").Inc()
+	if s.fallback == nil {
+		return Location{}, ErrNoResultsFound
+	}
+	return s.fallback.ReverseGeocode(lat, lng)
+}
+
+// lookup finds a city by its normalized name and optional country code.
+func (s *StaticGeocodingService) lookup(query string) (Location, bool) {
+	name, country := strings.TrimSpace(query), ""
+	if i := strings.LastIndex(name, ","); i >= 0 {
+		if cc := strings.TrimSpace(name[i+1:]); len(cc) == 2 {
+			name, country = strings.TrimSpace(name[:i]), strings.ToUpper(cc)
+		}
+	}
+	alias, err := normalizeCityName(name)
+	if err != nil {
+		return Location{}, false
+	}
+	for _, i := range s.byAlias[alias] {
+		if country == "" || s.cities[i].Location.CountryCode == country {
+			return s.cities[i].Location, true
+		}
+	}
+	return Location{}, false
+}
+
+// haversineKm returns the great-circle distance between two coordinates in kilometers.
+func haversineKm(lat1, lon1, lat2, lon2 float64) float64 {
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+	dLat := toRad(lat2 - lat1)
+	dLon := toRad(lon2 - lon1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(a))
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+const testCitiesTSV = `# name	country	lat	lon	timezone
+London	GB	51.5083	-0.1253	Europe/London
+Paris	FR	48.8667	2.3333	Europe/Paris
+Paris	US	33.6609	-95.5555	America/Chicago
+Kraków	PL	50.0614	19.9372	Europe/Warsaw
+`
+
+func TestParseStaticCities(t *testing.T) {
+	cities, err := parseStaticCities(testCitiesTSV)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cities) != 4 {
+		t.Fatalf("expected 4 cities, got %d", len(cities))
+	}
+	if cities[3].alias != "krakow" {
+		t.Errorf("expected normalized alias krakow, got %q", cities[3].alias)
+	}
+
+	if _, err := parseStaticCities("London\tGB\t51.5\n"); err == nil {
+		t.Error("expected an error for a line with missing fields")
+	}
+	if _, err := parseStaticCities("London\tGB\tnorth\t-0.1\tEurope/London\n"); err == nil {
+		t.Error("expected an error for an invalid latitude")
+	}
+}
+
+func TestBundledCitiesDataset(t *testing.T) {
+	geocoder, err := NewStaticGeocodingService(nil)
+	if err != nil {
+		t.Fatalf("could not load bundled dataset: %v", err)
+	}
+	if len(geocoder.cities) == 0 {
+		t.Fatal("bundled dataset is empty")
+	}
+	location, err := geocoder.Geocode("Warsaw")
+	if err != nil {
+		t.Fatalf("expected Warsaw in the bundled dataset: %v", err)
+	}
+	if location.CountryCode != "PL" || location.Timezone != "Europe/Warsaw" {
+		t.Errorf("unexpected location for Warsaw: %+v", location)
+	}
+
+	// The suggested cities resolve without the fallback geocoder.
+	for country, cities := range defaultCitySuggestions {
+		for _, city := range cities {
+			location, err := geocoder.Geocode(city)
+			if err != nil {
+				t.Errorf("expected suggested city %s in the bundled dataset: %v", city, err)
+				continue
+			}
+			if country != defaultSuggestionsKey && location.CountryCode != country {
+				t.Errorf("expected %s to resolve to %s, got %+v", city, country, location)
+			}
+		}
+	}
+
+	// Of cities sharing a name, the larger one wins.
+	for city, country := range map[string]string{"London": "GB", "Barcelona": "ES", "Hyderabad": "IN", "Cambridge": "GB", "Valencia": "ES"} {
+		if location, err := geocoder.Geocode(city); err != nil || location.CountryCode != country {
+			t.Errorf("expected %s to resolve to %s, got %+v (%v)", city, country, location, err)
+		}
+	}
+}
+
+func TestStaticGeocodingService(t *testing.T) {
+	cities, err := parseStaticCities(testCitiesTSV)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	fallbackLocation := Location{CityName: "Wrocław", CountryCode: "PL", Latitude: 51.11, Longitude: 17.03}
+	fallback := &mockGeocodingService{
+		GeocodeFunc: func(cityName string) (Location, error) {
+			return fallbackLocation, nil
+		},
+		ReverseGeocodeFunc: func(lat, lng float64) (Location, error) {
+			return fallbackLocation, nil
+		},
+	}
+
+	testCases := []struct {
+		name      string
+		fallback  GeocodingService
+		query     string
+		reverse   bool
+		lat, lon  float64
+		wantCity  string
+		wantCC    string
+		wantTZ    string
+		wantError error
+	}{
+		{name: "Hit", fallback: fallback, query: "london", wantCity: "London", wantCC: "GB", wantTZ: "Europe/London"},
+		{name: "Hit Without Diacritics", fallback: fallback, query: "Krakow", wantCity: "Kraków", wantCC: "PL", wantTZ: "Europe/Warsaw"},
+		{name: "First Row Wins", fallback: fallback, query: "Paris", wantCity: "Paris", wantCC: "FR", wantTZ: "Europe/Paris"},
+		{name: "Country Qualifier", fallback: fallback, query: "Paris, us", wantCity: "Paris", wantCC: "US", wantTZ: "America/Chicago"},
+		{name: "Miss Uses Fallback", fallback: fallback, query: "Wroclaw", wantCity: "Wrocław", wantCC: "PL"},
+		{name: "Miss Without Fallback", query: "Wroclaw", wantError: ErrNoResultsFound},
+		{name: "Reverse Hit", fallback: fallback, reverse: true, lat: 51.51, lon: -0.12, wantCity: "London", wantCC: "GB", wantTZ: "Europe/London"},
+		{name: "Reverse Miss Uses Fallback", fallback: fallback, reverse: true, lat: 51.11, lon: 17.03, wantCity: "Wrocław", wantCC: "PL"},
+		{name: "Reverse Miss Without Fallback", reverse: true, lat: 51.11, lon: 17.03, wantError: ErrNoResultsFound},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			geocoder := newStaticGeocodingService(cities, tc.fallback)
+
+			var location Location
+			var err error
+			if tc.reverse {
+				location, err = geocoder.ReverseGeocode(tc.lat, tc.lon)
+			} else {
+				location, err = geocoder.Geocode(tc.query)
+			}
+
+			if tc.wantError != nil {
+				if !errors.Is(err, tc.wantError) {
+					t.Fatalf("expected error %v, got %v", tc.wantError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if location.CityName != tc.wantCity || location.CountryCode != tc.wantCC || location.Timezone != tc.wantTZ {
+				t.Errorf("unexpected location: %+v", location)
+			}
+		})
+	}
+}
+
+func TestHaversineKm(t *testing.T) {
+	// London to Paris is roughly 340 km.
+	d := haversineKm(51.5083, -0.1253, 48.8667, 2.3333)
+	if d < 330 || d > 350 {
+		t.Errorf("expected about 340 km, got %.1f", d)
+	}
+}
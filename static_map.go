@@ -0,0 +1,376 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/jpeg"
+	"image/png"
+	"log/slog"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// This file implements /api/map, which renders a small static map of a location for the location
+// cards of the frontend. The map is stitched from the tiles of a slippy map tile server,
+// OpenStreetMap unless MAP_TILE_URL names another, centred on the location and marked with a pin.
+// Rendered maps are cached in the shared cache and, if MAP_CACHE_DIR is set, on disk, where they
+// survive restarts without taking up Redis memory. The disk cache is kept under
+// MAP_CACHE_MAX_MB by removing the least recently used maps, and the size and zoom of a map are
+// limited so that a request never needs more than a few tiles.
+
+const (
+	// defaultMapTileURL is the tile server of OpenStreetMap. {z}, {x} and {y} are replaced by the
+	// zoom level and the position of a tile.
+	defaultMapTileURL = "https://tile.openstreetmap.org/{z}/{x}/{y}.png"
+	// defaultMapAttribution is the attribution the OpenStreetMap tiles require.
+	defaultMapAttribution = "© OpenStreetMap contributors"
+	// mapTileSize is the width and height of a tile in pixels.
+	mapTileSize = 256
+
+	mapDefaultWidth  = 320
+	mapDefaultHeight = 160
+	mapMinSize       = 64
+	mapMaxSize       = 640
+	mapDefaultZoom   = 12
+	mapMaxZoom       = 17
+	// mapMaxLatitude is the latitude at which the Web Mercator projection ends.
+	mapMaxLatitude = 85.05112878
+
+	// mapFetchConcurrency limits the number of tiles fetched in parallel for one map.
+	mapFetchConcurrency = 4
+	// staticMapCacheKeyPrefix is the cache key prefix of rendered maps.
+	staticMapCacheKeyPrefix = "staticmap"
+	// staticMapCacheTTL is how long a rendered map is kept in the shared cache. Maps change
+	// rarely, so they are also served with a long max-age.
+	staticMapCacheTTL = 7 * 24 * time.Hour
+	// defaultMapCacheMaxMB is the default size limit of the disk cache.
+	defaultMapCacheMaxMB = 100
+)
+
+// staticMapRequest describes a rendered map.
+type staticMapRequest struct {
+	lat, lon      float64
+	zoom          int
+	width, height int
+}
+
+// cacheKey returns the cache key of the map. Coordinates are rounded to about 10 m, which is
+// less than a pixel at the highest zoom level.
+func (req staticMapRequest) cacheKey() string {
+	return fmt.Sprintf("%s:%.4f:%.4f:%d:%dx%d", staticMapCacheKeyPrefix, req.lat, req.lon, req.zoom, req.width, req.height)
+}
+
+// parseMapSize parses the query parameter name of r as a map size between mapMinSize and
+// mapMaxSize pixels. A missing parameter selects fallback.
+func parseMapSize(r *http.Request, name string, fallback int) (int, error) {
+	n, err := parseStatsParam(r, name, fallback, mapMaxSize)
+	if err == nil && n < mapMinSize {
+		err = fmt.Errorf("%s must be at least %d", name, mapMinSize)
+	}
+	return n, err
+}
+
+// mapPixel returns the position of a coordinate in pixels of the Web Mercator world map at a
+// zoom level. Latitudes beyond the edges of the projection are clamped to them.
+func mapPixel(lat, lon float64, zoom int) (x, y float64) {
+	lat = math.Max(-mapMaxLatitude, math.Min(mapMaxLatitude, lat))
+	scale := float64(mapTileSize) * math.Exp2(float64(zoom))
+	sin := math.Sin(lat * math.Pi / 180)
+	x = (lon + 180) / 360 * scale
+	y = (0.5 - math.Log((1+sin)/(1-sin))/(4*math.Pi)) * scale
+	return x, y
+}
+
+// mapTileURL returns the URL of a tile from a tile URL template.
+func mapTileURL(template string, zoom, x, y int) string {
+	return strings.NewReplacer("{z}", strconv.Itoa(zoom), "{x}", strconv.Itoa(x), "{y}", strconv.Itoa(y)).Replace(template)
+}
+
+// fetchMapTile fetches and decodes a tile from the tile server.
+func (cfg *apiConfig) fetchMapTile(ctx context.Context, zoom, x, y int) (image.Image, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, mapTileURL(cfg.mapTileURL, zoom, x, y), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := cfg.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("map tile request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("map tile request returned non-200 status: %s", resp.Status)
+	}
+	tile, _, err := image.Decode(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode map tile: %w", err)
+	}
+	return tile, nil
+}
+
+// renderStaticMap stitches the tiles covering a map and draws a pin at its centre. Tiles wrap
+// around the antimeridian; the area beyond the poles is left blank.
+func (cfg *apiConfig) renderStaticMap(ctx context.Context, req staticMapRequest) ([]byte, error) {
+	centerX, centerY := mapPixel(req.lat, req.lon, req.zoom)
+	left := int(math.Floor(centerX)) - req.width/2
+	top := int(math.Floor(centerY)) - req.height/2
+	tiles := 1 << req.zoom
+
+	img := image.NewRGBA(image.Rect(0, 0, req.width, req.height))
+	draw.Draw(img, img.Bounds(), &image.Uniform{color.RGBA{0xe5, 0xe3, 0xdf, 0xff}}, image.Point{}, draw.Src)
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	sem := make(chan struct{}, mapFetchConcurrency)
+	for ty := floorDiv(top, mapTileSize); ty <= floorDiv(top+req.height-1, mapTileSize); ty++ {
+		if ty < 0 || ty >= tiles {
+			continue
+		}
+		for tx := floorDiv(left, mapTileSize); tx <= floorDiv(left+req.width-1, mapTileSize); tx++ {
+			wg.Add(1)
+			go func(tx, ty int) {
+				defer wg.Done()
+				sem <- struct{}{}
+				defer func() { <-sem }()
+
+				tile, err := cfg.fetchMapTile(ctx, req.zoom, ((tx%tiles)+tiles)%tiles, ty)
+				mu.Lock()
+				defer mu.Unlock()
+				if err != nil {
+					errs = append(errs, err)
+					return
+				}
+				offset := image.Pt(tx*mapTileSize-left, ty*mapTileSize-top)
+				draw.Draw(img, image.Rectangle{Min: offset, Max: offset.Add(tile.Bounds().Size())}, tile, tile.Bounds().Min, draw.Src)
+			}(tx, ty)
+		}
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	drawMapPin(img, req.width/2, req.height/2)
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("failed to encode map: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// drawMapPin draws a red dot with a white outline centred on (cx, cy).
+func drawMapPin(img *image.RGBA, cx, cy int) {
+	const radius, outline = 7, 2
+	for y := -radius; y <= radius; y++ {
+		for x := -radius; x <= radius; x++ {
+			switch d := x*x + y*y; {
+			case d <= (radius-outline)*(radius-outline):
+				img.Set(cx+x, cy+y, color.RGBA{0xd9, 0x30, 0x25, 0xff})
+			case d <= radius*radius:
+				img.Set(cx+x, cy+y, color.White)
+			}
+		}
+	}
+}
+
+// getStaticMap returns a rendered map from the disk cache or the shared cache, or renders and
+// caches it.
+func (cfg *apiConfig) getStaticMap(ctx context.Context, req staticMapRequest) ([]byte, error) {
+	key := req.cacheKey()
+	if data, ok := cfg.mapCache.get(key); ok {
+		return data, nil
+	}
+	if cached, err := cfg.cache.Get(ctx, key); err == nil {
+		var data []byte
+		if err := json.Unmarshal([]byte(cached), &data); err == nil {
+			cfg.mapCache.put(key, data)
+			return data, nil
+		}
+	}
+
+	data, err := cfg.renderStaticMap(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.cache.Set(ctx, key, data, staticMapCacheTTL); err != nil {
+		cfg.logger.Warn("failed to cache map", "key", key, "error", err)
+	}
+	cfg.mapCache.put(key, data)
+	return data, nil
+}
+
+// @Summary      Get a static map
+// @Description  Renders a small PNG map centred on a location and marked with a pin, for location cards. The
+// @Description  location can be identified by its name, or by latitude and longitude. The tiles are served by
+// @Description  OpenStreetMap unless MAP_TILE_URL names another tile server; the attribution they require is
+// @Description  returned in the X-Attribution header.
+// @Tags         locations
+// @Produce      png
+// @Param        city   query    string  false  "Location name to search for (e.g., 'London')"
+// @Param        lat    query    number  false  "Latitude for the location (e.g., 51.5074)"
+// @Param        lon    query    number  false  "Longitude for the location (e.g., -0.1278)"
+// @Param        width  query    int     false  "Width in pixels, between 64 and 640 (defaults to 320)"
+// @Param        height query    int     false  "Height in pixels, between 64 and 640 (defaults to 160)"
+// @Param        zoom   query    int     false  "Zoom level, between 1 and 17 (defaults to 12)"
+// @Success      200  {file}    binary
+// @Failure      400  {object}  ErrorResponse "Bad Request - Invalid location, size or zoom"
+// @Failure      502  {object}  ErrorResponse "Bad Gateway - Failed to fetch map tiles"
+// @Router       /api/v1/map [get]
+func (cfg *apiConfig) handlerStaticMap(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		cfg.respondWithError(w, http.StatusMethodNotAllowed, "Method Not Allowed", nil)
+		return
+	}
+	width, err := parseMapSize(r, "width", mapDefaultWidth)
+	if err != nil {
+		cfg.respondWithError(w, http.StatusBadRequest, "Invalid map size", err)
+		return
+	}
+	height, err := parseMapSize(r, "height", mapDefaultHeight)
+	if err != nil {
+		cfg.respondWithError(w, http.StatusBadRequest, "Invalid map size", err)
+		return
+	}
+	zoom, err := parseStatsParam(r, "zoom", mapDefaultZoom, mapMaxZoom)
+	if err != nil {
+		cfg.respondWithError(w, http.StatusBadRequest, "Invalid zoom", err)
+		return
+	}
+	location, err := cfg.getLocationFromRequest(r)
+	if err != nil {
+		cfg.respondWithError(w, http.StatusBadRequest, "Error getting location data", err)
+		return
+	}
+
+	req := staticMapRequest{lat: location.Latitude, lon: location.Longitude, zoom: zoom, width: width, height: height}
+	data, err := cfg.getStaticMap(r.Context(), req)
+	if err != nil {
+		cfg.respondWithError(w, http.StatusBadGateway, "Error rendering map", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(staticMapCacheTTL.Seconds())))
+	if cfg.mapAttribution != "" {
+		w.Header().Set("X-Attribution", cfg.mapAttribution)
+	}
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(data); err != nil {
+		cfg.logger.Error("error writing map", "error", err)
+	}
+}
+
+// mapDiskCache keeps rendered maps in a directory, up to maxBytes in total. A nil mapDiskCache
+// caches nothing.
+type mapDiskCache struct {
+	dir      string
+	maxBytes int64
+	logger   *slog.Logger
+	mu       sync.Mutex
+}
+
+// newMapDiskCache returns a disk cache in dir, or nil if dir is empty.
+func newMapDiskCache(dir string, maxBytes int64, logger *slog.Logger) *mapDiskCache {
+	if dir == "" {
+		return nil
+	}
+	return &mapDiskCache{dir: dir, maxBytes: maxBytes, logger: logger}
+}
+
+// getMapCacheMaxBytes reads MAP_CACHE_MAX_MB, the size limit of the disk cache in megabytes.
+func getMapCacheMaxBytes(logger *slog.Logger) int64 {
+	mb := getEnvAsInt("MAP_CACHE_MAX_MB", defaultMapCacheMaxMB, logger)
+	if mb < 1 {
+		logger.Warn("MAP_CACHE_MAX_MB must be positive, using default", "value", mb)
+		mb = defaultMapCacheMaxMB
+	}
+	return int64(mb) << 20
+}
+
+// path returns the file of a cache key.
+func (c *mapDiskCache) path(key string) string {
+	return filepath.Join(c.dir, strings.ReplaceAll(key, ":", "_")+".png")
+}
+
+// get returns a cached map and marks it as recently used.
+func (c *mapDiskCache) get(key string) ([]byte, bool) {
+	if c == nil {
+		return nil, false
+	}
+	path := c.path(key)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	now := time.Now()
+	if err := os.Chtimes(path, now, now); err != nil {
+		c.logger.Debug("could not touch cached map", "path", path, "error", err)
+	}
+	return data, true
+}
+
+// put stores a map and removes the least recently used maps while the cache exceeds its limit.
+func (c *mapDiskCache) put(key string, data []byte) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := os.MkdirAll(c.dir, 0o755); err != nil {
+		c.logger.Warn("could not create map cache directory", "dir", c.dir, "error", err)
+		return
+	}
+	if err := os.WriteFile(c.path(key), data, 0o644); err != nil {
+		c.logger.Warn("could not cache map on disk", "key", key, "error", err)
+		return
+	}
+	c.evict()
+}
+
+// evict removes the least recently used maps until the cache is within its limit.
+func (c *mapDiskCache) evict() {
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		c.logger.Warn("could not list map cache directory", "dir", c.dir, "error", err)
+		return
+	}
+	var files []os.FileInfo
+	var total int64
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".png") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, info)
+		total += info.Size()
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].ModTime().Before(files[j].ModTime()) })
+	for _, info := range files {
+		if total <= c.maxBytes {
+			return
+		}
+		if err := os.Remove(filepath.Join(c.dir, info.Name())); err != nil {
+			c.logger.Warn("could not remove cached map", "file", info.Name(), "error", err)
+			continue
+		}
+		total -= info.Size()
+	}
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cor0nius/willitrain/internal/database"
+	"github.com/cor0nius/willitrain/internal/testkit"
+)
+
+func TestMapPixel(t *testing.T) {
+	testCases := []struct {
+		lat, lon float64
+		zoom     int
+		wantX    float64
+		wantY    float64
+	}{
+		{lat: 0, lon: 0, zoom: 0, wantX: 128, wantY: 128},
+		{lat: 0, lon: -180, zoom: 1, wantX: 0, wantY: 256},
+		{lat: 90, lon: 180, zoom: 0, wantX: 256, wantY: 0},
+		{lat: -90, lon: 0, zoom: 0, wantX: 128, wantY: 256},
+	}
+	for _, tc := range testCases {
+		x, y := mapPixel(tc.lat, tc.lon, tc.zoom)
+		if math.Abs(x-tc.wantX) > 1e-6 || math.Abs(y-tc.wantY) > 1e-6 {
+			t.Errorf("mapPixel(%v, %v, %d) = (%v, %v), want (%v, %v)", tc.lat, tc.lon, tc.zoom, x, y, tc.wantX, tc.wantY)
+		}
+	}
+}
+
+func TestMapTileURL(t *testing.T) {
+	got := mapTileURL("https://tiles.example.com/{z}/{x}/{y}.png?key=abc", 12, 2234, 1370)
+	if want := "https://tiles.example.com/12/2234/1370.png?key=abc"; got != want {
+		t.Errorf("mapTileURL() = %q, want %q", got, want)
+	}
+}
+
+func TestGetMapCacheMaxBytes(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	t.Setenv("MAP_CACHE_MAX_MB", "5")
+	if got := getMapCacheMaxBytes(logger); got != 5<<20 {
+		t.Errorf("getMapCacheMaxBytes() = %d, want %d", got, 5<<20)
+	}
+	t.Setenv("MAP_CACHE_MAX_MB", "0")
+	if got := getMapCacheMaxBytes(logger); got != defaultMapCacheMaxMB<<20 {
+		t.Errorf("expected a non-positive limit to be ignored, got %d", got)
+	}
+}
+
+// newTileServer serves blue tiles and counts the requests. Tiles are served with status.
+func newTileServer(t *testing.T, status int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	tile := image.NewRGBA(image.Rect(0, 0, mapTileSize, mapTileSize))
+	for y := range mapTileSize {
+		for x := range mapTileSize {
+			tile.Set(x, y, color.RGBA{0, 0, 0xff, 0xff})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, tile); err != nil {
+		t.Fatal(err)
+	}
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(status)
+		w.Write(buf.Bytes())
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestHandlerStaticMap(t *testing.T) {
+	server, requests := newTileServer(t, http.StatusOK)
+	testCfg := newTestAPIConfig(t)
+	testCfg.cache = testkit.NewMemoryCache()
+	testCfg.mapTileURL = server.URL + "/{z}/{x}/{y}.png"
+	testCfg.mapAttribution = defaultMapAttribution
+	cacheDir := t.TempDir()
+	testCfg.mapCache = newMapDiskCache(cacheDir, 1<<20, testCfg.logger)
+	testCfg.mockDB.GetLocationByAliasFunc = func(ctx context.Context, alias string) (database.Location, error) {
+		return MockDBLocation, nil
+	}
+	send := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/map?city=wroclaw"+query, nil)
+		rr := httptest.NewRecorder()
+		testCfg.apiConfig.handlerStaticMap(rr, req)
+		return rr
+	}
+
+	rr := send("&width=100&height=80")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200; body: %s", rr.Code, rr.Body.String())
+	}
+	if rr.Header().Get("Content-Type") != "image/png" || rr.Header().Get("X-Attribution") != defaultMapAttribution {
+		t.Errorf("unexpected headers %v", rr.Header())
+	}
+	img, err := png.Decode(rr.Body)
+	if err != nil {
+		t.Fatalf("could not decode map: %v", err)
+	}
+	if size := img.Bounds().Size(); size != image.Pt(100, 80) {
+		t.Errorf("map size = %v, want 100x80", size)
+	}
+	if r, _, b, _ := img.At(0, 0).RGBA(); r != 0 || b != 0xffff {
+		t.Errorf("expected the corner to show the tile, got %v", img.At(0, 0))
+	}
+	if r, _, b, _ := img.At(50, 40).RGBA(); r == 0 || b == 0xffff {
+		t.Errorf("expected the centre to show the pin, got %v", img.At(50, 40))
+	}
+	fetched := requests.Load()
+	if fetched == 0 || fetched > 4 {
+		t.Errorf("expected 1 to 4 tile requests, got %d", fetched)
+	}
+
+	// Rendered maps are served from the disk cache, and from the shared cache once evicted from disk.
+	for range 2 {
+		if rr := send("&width=100&height=80"); rr.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200", rr.Code)
+		}
+		files, _ := filepath.Glob(filepath.Join(cacheDir, "*.png"))
+		if len(files) != 1 {
+			t.Fatalf("expected 1 map on disk, got %d", len(files))
+		}
+		os.Remove(files[0])
+	}
+	if requests.Load() != fetched {
+		t.Errorf("expected cached maps to be served without fetching tiles, got %d more requests", requests.Load()-fetched)
+	}
+
+	for _, query := range []string{"&width=32", "&height=641", "&zoom=0", "&zoom=18"} {
+		if rr := send(query); rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", query, rr.Code)
+		}
+	}
+}
+
+func TestHandlerStaticMapTileServerError(t *testing.T) {
+	server, _ := newTileServer(t, http.StatusInternalServerError)
+	testCfg := newTestAPIConfig(t)
+	testCfg.cache = testkit.NewMemoryCache()
+	testCfg.mapTileURL = server.URL + "/{z}/{x}/{y}.png"
+	testCfg.mockDB.GetLocationByAliasFunc = func(ctx context.Context, alias string) (database.Location, error) {
+		return MockDBLocation, nil
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/map?city=wroclaw", nil)
+	rr := httptest.NewRecorder()
+	testCfg.apiConfig.handlerStaticMap(rr, req)
+	if rr.Code != http.StatusBadGateway || rr.Body.String() != `{"error":"Error rendering map"}` {
+		t.Errorf("expected a bad gateway, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestMapDiskCacheEviction(t *testing.T) {
+	dir := t.TempDir()
+	cache := newMapDiskCache(dir, 10, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	cache.put("a", []byte("aaaa"))
+	cache.put("b", []byte("bbbb"))
+	old := time.Now().Add(-time.Hour)
+	os.Chtimes(cache.path("a"), old, old)
+	os.Chtimes(cache.path("b"), old.Add(time.Minute), old.Add(time.Minute))
+
+	// Reading a map marks it as recently used, so b is the least recently used.
+	if data, ok := cache.get("a"); !ok || string(data) != "aaaa" {
+		t.Fatalf("get(a) = %q, %v", data, ok)
+	}
+	cache.put("c", []byte("cccc"))
+	if _, ok := cache.get("b"); ok {
+		t.Error("expected b to be evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok := cache.get(key); !ok {
+			t.Errorf("expected %s to be kept", key)
+		}
+	}
+
+	var disabled *mapDiskCache
+	disabled.put("a", []byte("aaaa"))
+	if _, ok := disabled.get("a"); ok || newMapDiskCache("", 10, nil) != nil {
+		t.Error("expected a cache without a directory to cache nothing")
+	}
+}
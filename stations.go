@@ -0,0 +1,308 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/cor0nius/willitrain/internal/database"
+)
+
+// This file implements the ingestion of uploads from personal weather stations. Ecowitt
+// gateways post their readings to a custom server in the Ecowitt protocol, and WeatherFlow
+// Tempest hubs broadcast theirs over UDP, which a bridge forwards as JSON. Readings are stored
+// as observations of the station's location under localStationSourceAPI, and the latest one is
+// listed with the providers' current weather, so that forecasts can be checked against what is
+// measured on site.
+
+const (
+	// localStationSourceAPI is the SourceAPI of readings uploaded by personal weather stations.
+	localStationSourceAPI = "local-station"
+
+	// stationWeatherMaxAge is how old the latest station reading may be to be listed with the
+	// current weather. Stations upload every minute or so, so an older reading means the station
+	// is offline.
+	stationWeatherMaxAge = 30 * time.Minute
+	// stationClockSkew is how far ahead of the server's clock a station's clock may run.
+	stationClockSkew = time.Minute
+
+	maxStationUploadBytes = 64 << 10
+)
+
+// errUnknownStation is returned for uploads from a station not listed in WEATHER_STATIONS.
+var errUnknownStation = errors.New("station is not listed in WEATHER_STATIONS")
+
+// getWeatherStations reads WEATHER_STATIONS, a comma-separated list of id=city pairs that map the
+// personal weather stations allowed to upload to the city they stand in. The id is the PASSKEY
+// of an Ecowitt gateway or the serial number of a WeatherFlow station.
+func getWeatherStations(logger *slog.Logger) map[string]string {
+	stations := make(map[string]string)
+	val := os.Getenv("WEATHER_STATIONS")
+	if val == "" {
+		return stations
+	}
+	for _, entry := range strings.Split(val, ",") {
+		id, city, found := strings.Cut(strings.TrimSpace(entry), "=")
+		id, city = strings.TrimSpace(id), strings.TrimSpace(city)
+		if !found || id == "" || city == "" {
+			logger.Warn("invalid weather stations entry, ignoring", "entry", entry)
+			continue
+		}
+		stations[id] = city
+	}
+	if len(stations) > 0 {
+		logger.Info("personal weather station uploads enabled", "count", len(stations))
+	}
+	return stations
+}
+
+// @Summary      Upload an Ecowitt station reading
+// @Description  Accepts a reading posted by an Ecowitt gateway configured to upload to a customized server in the
+// @Description  Ecowitt protocol. The station is identified by its PASSKEY, which must be listed in WEATHER_STATIONS.
+// @Description  The reading is stored as a local-station observation of the station's city and listed with its current weather.
+// @Tags         stations
+// @Accept       x-www-form-urlencoded
+// @Produce      json
+// @Param        PASSKEY      formData  string  true   "Station passkey"
+// @Param        dateutc      formData  string  false  "Observation time in UTC as 'YYYY-MM-DD HH:MM:SS', or 'now'"
+// @Param        tempf        formData  number  false  "Outdoor temperature in °F"
+// @Param        humidity     formData  number  false  "Outdoor relative humidity in %"
+// @Param        windspeedmph formData  number  false  "Wind speed in mph"
+// @Param        rainratein   formData  number  false  "Rain rate in in/h"
+// @Success      200  {object}  StationUploadResponse
+// @Failure      400  {object}  ErrorResponse "Bad Request - Invalid reading"
+// @Failure      403  {object}  ErrorResponse "Forbidden - Unknown station"
+// @Failure      500  {object}  ErrorResponse "Internal Server Error - Failed to store the reading"
+// @Router       /api/v1/stations/ecowitt [post]
+func (cfg *apiConfig) handlerStationEcowitt(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		cfg.respondWithError(w, http.StatusMethodNotAllowed, "Method Not Allowed", nil)
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxStationUploadBytes)
+	if err := r.ParseForm(); err != nil {
+		cfg.respondWithError(w, http.StatusBadRequest, "Invalid station upload", err)
+		return
+	}
+	reading, err := parseEcowittReading(r.PostForm, time.Now())
+	if err != nil {
+		cfg.respondWithError(w, http.StatusBadRequest, "Invalid station upload", err)
+		return
+	}
+	cfg.storeStationReadings(w, r, "ecowitt", r.PostForm.Get("PASSKEY"), []ImportObservationJSON{reading})
+}
+
+// @Summary      Upload a WeatherFlow station reading
+// @Description  Accepts a UDP broadcast message of a WeatherFlow hub, forwarded as JSON by a UDP-to-HTTP bridge. The
+// @Description  station is identified by its serial_number, which must be listed in WEATHER_STATIONS. Tempest
+// @Description  observations (type obs_st) are stored as local-station observations of the station's city and listed with
+// @Description  its current weather; other message types are accepted and ignored.
+// @Tags         stations
+// @Accept       json
+// @Produce      json
+// @Param        message  body  WeatherFlowMessage  true  "WeatherFlow UDP message"
+// @Success      200  {object}  StationUploadResponse
+// @Failure      400  {object}  ErrorResponse "Bad Request - Invalid message"
+// @Failure      403  {object}  ErrorResponse "Forbidden - Unknown station"
+// @Failure      500  {object}  ErrorResponse "Internal Server Error - Failed to store the reading"
+// @Router       /api/v1/stations/weatherflow [post]
+func (cfg *apiConfig) handlerStationWeatherFlow(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		cfg.respondWithError(w, http.StatusMethodNotAllowed, "Method Not Allowed", nil)
+		return
+	}
+	var message WeatherFlowMessage
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxStationUploadBytes)).Decode(&message); err != nil {
+		cfg.respondWithError(w, http.StatusBadRequest, "Invalid station upload", err)
+		return
+	}
+	readings, err := parseWeatherFlowReadings(message)
+	if err != nil {
+		cfg.respondWithError(w, http.StatusBadRequest, "Invalid station upload", err)
+		return
+	}
+	cfg.storeStationReadings(w, r, "weatherflow", message.SerialNumber, readings)
+}
+
+// storeStationReadings stores the readings of a station as observations of its location and
+// responds with the number stored. The readings are validated like imported observations.
+func (cfg *apiConfig) storeStationReadings(w http.ResponseWriter, r *http.Request, protocol, stationID string, readings []ImportObservationJSON) {
+	ctx := r.Context()
+	city, ok := cfg.weatherStations[stationID]
+	if !ok {
+		cfg.respondWithError(w, http.StatusForbidden, "Unknown station", errUnknownStation)
+		return
+	}
+	location, err := cfg.getOrCreateLocation(ctx, city)
+	if err != nil {
+		cfg.respondWithError(w, http.StatusInternalServerError, "Error getting station location", err)
+		return
+	}
+
+	observations, rowErrors := validateImportObservations(readings, location.LocationID, localStationSourceAPI, time.Now().Add(stationClockSkew))
+	if len(rowErrors) > 0 {
+		cfg.respondWithError(w, http.StatusBadRequest, "Invalid station reading: "+strings.Join(rowErrors[0].Errors, "; "), nil)
+		return
+	}
+	err = cfg.runInTx(ctx, func(q dbQuerier) error {
+		for _, o := range observations {
+			if err := q.UpsertWeatherObservation(ctx, o); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		cfg.respondWithError(w, http.StatusInternalServerError, "Failed to store the station reading", err)
+		return
+	}
+	stationReadings.WithLabelValues(protocol).Add(float64(len(observations)))
+
+	cfg.respondWithJSON(w, http.StatusOK, StationUploadResponse{
+		Location: location,
+		Source:   localStationSourceAPI,
+		Stored:   len(observations),
+	})
+}
+
+// parseEcowittReading converts the form fields of an Ecowitt upload, which are in imperial
+// units, to an observation. The time is truncated to the minute, so that a station uploading more
+// often replaces its reading instead of piling them up.
+func parseEcowittReading(form map[string][]string, now time.Time) (ImportObservationJSON, error) {
+	get := func(key string) string {
+		if v := form[key]; len(v) > 0 {
+			return strings.TrimSpace(v[0])
+		}
+		return ""
+	}
+
+	observedAt := now
+	if date := get("dateutc"); date != "" && date != "now" {
+		t, err := time.Parse(time.DateTime, date)
+		if err != nil {
+			return ImportObservationJSON{}, fmt.Errorf("dateutc %q is not a 'YYYY-MM-DD HH:MM:SS' time", date)
+		}
+		observedAt = t
+	}
+	reading := ImportObservationJSON{Timestamp: observedAt.UTC().Truncate(time.Minute).Format(time.RFC3339)}
+
+	if v := get("tempf"); v != "" {
+		if f := parseImportFloat(v, "tempf", &reading); f != nil {
+			c := Round((*f-32)*5/9, 1)
+			reading.Temperature = &c
+		}
+	}
+	if v := get("humidity"); v != "" {
+		if f := parseImportFloat(v, "humidity", &reading); f != nil {
+			humidity := int32(*f)
+			reading.Humidity = &humidity
+		}
+	}
+	if v := get("windspeedmph"); v != "" {
+		if f := parseImportFloat(v, "windspeedmph", &reading); f != nil {
+			kmh := Round(*f*1.609344, 1)
+			reading.WindSpeed = &kmh
+		}
+	}
+	if v := get("rainratein"); v != "" {
+		if f := parseImportFloat(v, "rainratein", &reading); f != nil {
+			mm := Round(*f*25.4, 2)
+			reading.Precipitation = &mm
+			reading.Condition = stationCondition(mm)
+		}
+	}
+	return reading, nil
+}
+
+// Indexes of the values of a WeatherFlow obs_st observation that are stored.
+const (
+	weatherFlowObsTime           = 0
+	weatherFlowObsWindAvg        = 2
+	weatherFlowObsTemperature    = 7
+	weatherFlowObsHumidity       = 8
+	weatherFlowObsRain           = 12
+	weatherFlowObsReportInterval = 17
+)
+
+// parseWeatherFlowReadings converts the observations of a WeatherFlow obs_st message to
+// observations. Messages of other types, such as rapid_wind or hub_status, have no readings.
+func parseWeatherFlowReadings(message WeatherFlowMessage) ([]ImportObservationJSON, error) {
+	if message.Type != "obs_st" {
+		return nil, nil
+	}
+	readings := make([]ImportObservationJSON, 0, len(message.Obs))
+	for _, obs := range message.Obs {
+		if len(obs) <= weatherFlowObsRain || obs[weatherFlowObsTime] == nil {
+			return nil, fmt.Errorf("obs_st observation has %d values, want at least %d", len(obs), weatherFlowObsRain+1)
+		}
+		observedAt := time.Unix(int64(*obs[weatherFlowObsTime]), 0)
+		reading := ImportObservationJSON{Timestamp: observedAt.UTC().Truncate(time.Minute).Format(time.RFC3339)}
+		if v := obs[weatherFlowObsTemperature]; v != nil {
+			c := Round(*v, 1)
+			reading.Temperature = &c
+		}
+		if v := obs[weatherFlowObsHumidity]; v != nil {
+			humidity := int32(*v)
+			reading.Humidity = &humidity
+		}
+		if v := obs[weatherFlowObsWindAvg]; v != nil {
+			kmh := Round(*v*3.6, 1)
+			reading.WindSpeed = &kmh
+		}
+		if v := obs[weatherFlowObsRain]; v != nil {
+			// The rain amount covers the report interval, one minute unless the message says otherwise.
+			interval := 1.0
+			if len(obs) > weatherFlowObsReportInterval && obs[weatherFlowObsReportInterval] != nil && *obs[weatherFlowObsReportInterval] > 0 {
+				interval = *obs[weatherFlowObsReportInterval]
+			}
+			mm := Round(*v*60/interval, 2)
+			reading.Precipitation = &mm
+			reading.Condition = stationCondition(mm)
+		}
+		readings = append(readings, reading)
+	}
+	return readings, nil
+}
+
+// stationCondition describes the weather from a station's rain rate in mm/h. Stations cannot
+// tell a clear sky from an overcast one, so only rain is reported.
+func stationCondition(rainRate float64) *string {
+	if rainRate <= 0 {
+		return nil
+	}
+	condition := "Rain"
+	return &condition
+}
+
+// localStationWeather returns the latest reading of a personal weather station at the location,
+// if one was uploaded within stationWeatherMaxAge.
+func (cfg *apiConfig) localStationWeather(ctx context.Context, location Location) (CurrentWeather, bool, error) {
+	observation, err := cfg.dbQueries.GetLatestWeatherObservation(ctx, database.GetLatestWeatherObservationParams{
+		LocationID: location.LocationID,
+		SourceApi:  localStationSourceAPI,
+		ObservedAt: time.Now().Add(-stationWeatherMaxAge),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return CurrentWeather{}, false, nil
+	}
+	if err != nil {
+		return CurrentWeather{}, false, err
+	}
+	return CurrentWeather{
+		Location:      location,
+		SourceAPI:     observation.SourceApi,
+		Timestamp:     observation.ObservedAt,
+		Temperature:   observation.TemperatureC.Float64,
+		Humidity:      observation.Humidity.Int32,
+		WindSpeed:     observation.WindSpeedKmh.Float64,
+		Precipitation: observation.PrecipitationMm.Float64,
+		Condition:     observation.ConditionText.String,
+	}.withDerivedValues(), true, nil
+}
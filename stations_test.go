@@ -0,0 +1,251 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cor0nius/willitrain/internal/database"
+	"github.com/google/uuid"
+)
+
+func TestGetWeatherStations(t *testing.T) {
+	t.Setenv("WEATHER_STATIONS", "ABC123=Wroclaw, ST-00000512 = Berlin,invalid,=Prague")
+	stations := getWeatherStations(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if len(stations) != 2 || stations["ABC123"] != "Wroclaw" || stations["ST-00000512"] != "Berlin" {
+		t.Errorf("unexpected stations: %v", stations)
+	}
+}
+
+func TestParseEcowittReading(t *testing.T) {
+	now := time.Date(2025, 6, 1, 10, 30, 45, 0, time.UTC)
+	form := url.Values{
+		"PASSKEY":      {"ABC123"},
+		"dateutc":      {"2025-06-01 10:29:16"},
+		"tempf":        {"68.0"},
+		"humidity":     {"55"},
+		"windspeedmph": {"10"},
+		"rainratein":   {"0.1"},
+	}
+	reading, err := parseEcowittReading(form, now)
+	if err != nil {
+		t.Fatalf("parseEcowittReading() error = %v", err)
+	}
+	if reading.Timestamp != "2025-06-01T10:29:00Z" || *reading.Temperature != 20 || *reading.Humidity != 55 || *reading.WindSpeed != 16.1 || *reading.Precipitation != 2.54 || *reading.Condition != "Rain" {
+		t.Errorf("unexpected reading: %+v", reading)
+	}
+
+	form = url.Values{"dateutc": {"now"}, "tempf": {"warm"}, "rainratein": {"0"}}
+	if reading, err = parseEcowittReading(form, now); err != nil {
+		t.Fatalf("parseEcowittReading() error = %v", err)
+	}
+	if reading.Timestamp != "2025-06-01T10:30:00Z" || len(reading.invalid) != 1 || reading.Condition != nil {
+		t.Errorf("unexpected reading: %+v", reading)
+	}
+
+	if _, err := parseEcowittReading(url.Values{"dateutc": {"yesterday"}}, now); err == nil {
+		t.Error("expected an error for an invalid dateutc")
+	}
+}
+
+func TestParseWeatherFlowReadings(t *testing.T) {
+	var message WeatherFlowMessage
+	body := `{"serial_number": "ST-00000512", "type": "obs_st", "hub_sn": "HB-00013030",
+		"obs": [[1748773800, 0.18, 2.5, 0.27, 144, 6, 1017.57, 22.37, 50.26, 328, 0.03, 3, 0.05, 1, 0, 0, 2.41, 1]]}`
+	if err := json.Unmarshal([]byte(body), &message); err != nil {
+		t.Fatalf("failed to decode message: %v", err)
+	}
+	readings, err := parseWeatherFlowReadings(message)
+	if err != nil {
+		t.Fatalf("parseWeatherFlowReadings() error = %v", err)
+	}
+	if len(readings) != 1 {
+		t.Fatalf("got %d readings, want 1", len(readings))
+	}
+	r := readings[0]
+	if r.Timestamp != "2025-06-01T10:30:00Z" || *r.Temperature != 22.4 || *r.Humidity != 50 || *r.WindSpeed != 9 || *r.Precipitation != 3 || *r.Condition != "Rain" {
+		t.Errorf("unexpected reading: %+v", r)
+	}
+
+	if readings, err := parseWeatherFlowReadings(WeatherFlowMessage{Type: "rapid_wind"}); err != nil || len(readings) != 0 {
+		t.Errorf("expected no readings for rapid_wind, got %+v, %v", readings, err)
+	}
+	ts := 1748773800.0
+	if _, err := parseWeatherFlowReadings(WeatherFlowMessage{Type: "obs_st", Obs: [][]*float64{{&ts}}}); err == nil {
+		t.Error("expected an error for a short observation")
+	}
+}
+
+func TestHandlerStationUploads(t *testing.T) {
+	now := time.Now().UTC()
+	testCases := []struct {
+		name       string
+		handler    func(cfg *apiConfig) http.HandlerFunc
+		method     string
+		body       string
+		wantStatus int
+		wantStored int
+	}{
+		{
+			name:       "method not allowed",
+			handler:    func(cfg *apiConfig) http.HandlerFunc { return cfg.handlerStationEcowitt },
+			method:     http.MethodGet,
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:       "ecowitt",
+			handler:    func(cfg *apiConfig) http.HandlerFunc { return cfg.handlerStationEcowitt },
+			method:     http.MethodPost,
+			body:       url.Values{"PASSKEY": {"ABC123"}, "dateutc": {"now"}, "tempf": {"68"}, "humidity": {"55"}}.Encode(),
+			wantStatus: http.StatusOK,
+			wantStored: 1,
+		},
+		{
+			name:       "ecowitt unknown station",
+			handler:    func(cfg *apiConfig) http.HandlerFunc { return cfg.handlerStationEcowitt },
+			method:     http.MethodPost,
+			body:       url.Values{"PASSKEY": {"XYZ"}, "dateutc": {"now"}, "tempf": {"68"}}.Encode(),
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "ecowitt implausible reading",
+			handler:    func(cfg *apiConfig) http.HandlerFunc { return cfg.handlerStationEcowitt },
+			method:     http.MethodPost,
+			body:       url.Values{"PASSKEY": {"ABC123"}, "dateutc": {"now"}, "tempf": {"212"}}.Encode(),
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "weatherflow",
+			handler:    func(cfg *apiConfig) http.HandlerFunc { return cfg.handlerStationWeatherFlow },
+			method:     http.MethodPost,
+			body:       `{"serial_number": "ST-00000512", "type": "obs_st", "obs": [[` + strconv.FormatInt(now.Unix(), 10) + `, 0, 2.5, 0, 0, 3, 1000, 20, 60, 0, 0, 0, 0, 0, 0, 0, 2.4, 1]]}`,
+			wantStatus: http.StatusOK,
+			wantStored: 1,
+		},
+		{
+			name:       "weatherflow other message",
+			handler:    func(cfg *apiConfig) http.HandlerFunc { return cfg.handlerStationWeatherFlow },
+			method:     http.MethodPost,
+			body:       `{"serial_number": "ST-00000512", "type": "rapid_wind", "ob": [1748773800, 2.3, 128]}`,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "weatherflow malformed",
+			handler:    func(cfg *apiConfig) http.HandlerFunc { return cfg.handlerStationWeatherFlow },
+			method:     http.MethodPost,
+			body:       `{"serial_number": `,
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testCfg := newTestAPIConfig(t)
+			testCfg.weatherStations = map[string]string{"ABC123": "Wroclaw", "ST-00000512": "Wroclaw"}
+			testCfg.mockDB.GetLocationByAliasFunc = func(ctx context.Context, alias string) (database.Location, error) {
+				return MockDBLocation, nil
+			}
+			var stored []database.UpsertWeatherObservationParams
+			testCfg.mockDB.UpsertWeatherObservationFunc = func(ctx context.Context, arg database.UpsertWeatherObservationParams) error {
+				stored = append(stored, arg)
+				return nil
+			}
+
+			req := httptest.NewRequest(tc.method, "/api/v1/stations", strings.NewReader(tc.body))
+			if strings.HasPrefix(tc.body, "{") {
+				req.Header.Set("Content-Type", "application/json")
+			} else {
+				req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			}
+			rr := httptest.NewRecorder()
+			tc.handler(testCfg.apiConfig)(rr, req)
+
+			if rr.Code != tc.wantStatus {
+				t.Fatalf("status = %d, want %d; body: %s", rr.Code, tc.wantStatus, rr.Body.String())
+			}
+			if len(stored) != tc.wantStored {
+				t.Fatalf("stored %d readings, want %d", len(stored), tc.wantStored)
+			}
+			for _, o := range stored {
+				if o.SourceApi != localStationSourceAPI || o.LocationID != MockDBLocation.ID || o.ObservedAt.Second() != 0 {
+					t.Errorf("unexpected observation: %+v", o)
+				}
+			}
+		})
+	}
+}
+
+func TestCurrentWeatherResponse_LocalStation(t *testing.T) {
+	location := Location{LocationID: uuid.New(), CityName: "Wroclaw", Timezone: "UTC"}
+	observedAt := time.Now().UTC().Truncate(time.Minute)
+
+	setup := func(t *testing.T) *testAPIConfig {
+		testCfg := newTestAPIConfig(t)
+		testCfg.enabledSources = map[string]bool{"ometeo": true}
+		testCfg.weatherStations = map[string]string{"ABC123": "Wroclaw"}
+		cached, _ := json.Marshal([]CurrentWeather{{SourceAPI: "Open-Meteo API", Timestamp: observedAt.Add(-10 * time.Minute), Temperature: 19}})
+		testCfg.mockCache.GetFunc = func(ctx context.Context, key string) (string, error) {
+			return string(cached), nil
+		}
+		return testCfg
+	}
+
+	t.Run("recent reading is listed", func(t *testing.T) {
+		testCfg := setup(t)
+		testCfg.mockDB.GetLatestWeatherObservationFunc = func(ctx context.Context, arg database.GetLatestWeatherObservationParams) (database.WeatherObservation, error) {
+			if arg.SourceApi != localStationSourceAPI || arg.LocationID != location.LocationID || time.Since(arg.ObservedAt) < stationWeatherMaxAge {
+				t.Errorf("unexpected query: %+v", arg)
+			}
+			return database.WeatherObservation{
+				LocationID:   location.LocationID,
+				SourceApi:    localStationSourceAPI,
+				ObservedAt:   observedAt,
+				TemperatureC: sql.NullFloat64{Float64: 20.5, Valid: true},
+				Humidity:     sql.NullInt32{Int32: 60, Valid: true},
+			}, nil
+		}
+
+		response, _, err := testCfg.apiConfig.currentWeatherResponse(context.Background(), location, unitsMetric, false)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(response.Weather) != 2 || response.Weather[1].SourceAPI != localStationSourceAPI || response.Weather[1].Temperature != 20.5 {
+			t.Errorf("expected the station reading last, got %+v", response.Weather)
+		}
+		if len(response.Providers) != 1 || response.Partial {
+			t.Errorf("expected the station to be left out of the provider statuses, got %+v", response.Providers)
+		}
+	})
+
+	t.Run("no recent reading", func(t *testing.T) {
+		testCfg := setup(t)
+		testCfg.mockDB.GetLatestWeatherObservationFunc = func(ctx context.Context, arg database.GetLatestWeatherObservationParams) (database.WeatherObservation, error) {
+			return database.WeatherObservation{}, sql.ErrNoRows
+		}
+		response, _, err := testCfg.apiConfig.currentWeatherResponse(context.Background(), location, unitsMetric, false)
+		if err != nil || len(response.Weather) != 1 {
+			t.Errorf("expected only the provider, got %+v, %v", response.Weather, err)
+		}
+	})
+
+	t.Run("lookup failure is ignored", func(t *testing.T) {
+		testCfg := setup(t)
+		testCfg.mockDB.GetLatestWeatherObservationFunc = func(ctx context.Context, arg database.GetLatestWeatherObservationParams) (database.WeatherObservation, error) {
+			return database.WeatherObservation{}, errors.New("db down")
+		}
+		response, _, err := testCfg.apiConfig.currentWeatherResponse(context.Background(), location, unitsMetric, false)
+		if err != nil || len(response.Weather) != 1 {
+			t.Errorf("expected only the provider, got %+v, %v", response.Weather, err)
+		}
+	})
+}
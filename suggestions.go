@@ -0,0 +1,131 @@
+package main
+
+import (
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// This file implements the default city suggestions returned by /api/config. Before a user grants
+// geolocation, clients can offer a few nearby cities instead of an empty search box. The country
+// is taken from the "country" query parameter if present, and otherwise guessed from the
+// Accept-Language header, e.g. "pl-PL" or just "pl" both select Poland. The cities per country
+// come from a built-in mapping that can be extended or overridden with DEFAULT_CITIES.
+
+// defaultSuggestionsKey is the mapping entry used when no country could be determined or the
+// country has no entry of its own.
+const defaultSuggestionsKey = "default"
+
+// defaultCitySuggestions is the built-in mapping of ISO 3166-1 alpha-2 country codes to the
+// cities suggested for that country.
+var defaultCitySuggestions = map[string][]string{
+	"PL":                  {"Warsaw", "Kraków", "Wrocław"},
+	"DE":                  {"Berlin", "Munich", "Hamburg"},
+	"CZ":                  {"Prague", "Brno", "Ostrava"},
+	"FR":                  {"Paris", "Lyon", "Marseille"},
+	"ES":                  {"Madrid", "Barcelona", "Valencia"},
+	"IT":                  {"Rome", "Milan", "Naples"},
+	"GB":                  {"London", "Manchester", "Edinburgh"},
+	"US":                  {"New York", "Los Angeles", "Chicago"},
+	"UA":                  {"Kyiv", "Lviv", "Odesa"},
+	"NL":                  {"Amsterdam", "Rotterdam", "Utrecht"},
+	defaultSuggestionsKey: {"London", "New York", "Tokyo"},
+}
+
+// getCitySuggestions returns the built-in city suggestions merged with DEFAULT_CITIES, a
+// comma-separated list of country=city|city|... entries (e.g. "PL=Gdańsk|Poznań,default=Paris").
+// An entry replaces the built-in cities for its country. Invalid entries are logged and ignored.
+func getCitySuggestions(logger *slog.Logger) map[string][]string {
+	suggestions := make(map[string][]string, len(defaultCitySuggestions))
+	for country, cities := range defaultCitySuggestions {
+		suggestions[country] = cities
+	}
+
+	val := os.Getenv("DEFAULT_CITIES")
+	if val == "" {
+		return suggestions
+	}
+	for _, entry := range strings.Split(val, ",") {
+		key, list, found := strings.Cut(strings.TrimSpace(entry), "=")
+		country, ok := normalizeSuggestionsKey(key)
+		if !found || !ok {
+			logger.Warn("invalid default cities entry, ignoring", "entry", entry)
+			continue
+		}
+		var cities []string
+		for _, city := range strings.Split(list, "|") {
+			if city = strings.TrimSpace(city); city != "" {
+				cities = append(cities, city)
+			}
+		}
+		if len(cities) == 0 {
+			logger.Warn("default cities entry lists no cities, ignoring", "entry", entry)
+			continue
+		}
+		suggestions[country] = cities
+	}
+	return suggestions
+}
+
+// normalizeSuggestionsKey validates a mapping key, which is either a two-letter country code or
+// defaultSuggestionsKey, and returns it in canonical form.
+func normalizeSuggestionsKey(key string) (string, bool) {
+	key = strings.TrimSpace(key)
+	if strings.EqualFold(key, defaultSuggestionsKey) {
+		return defaultSuggestionsKey, true
+	}
+	if len(key) != 2 || !isASCIILetters(key) {
+		return "", false
+	}
+	return strings.ToUpper(key), true
+}
+
+// isASCIILetters reports whether s consists of ASCII letters only.
+func isASCIILetters(s string) bool {
+	for _, c := range s {
+		if (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') {
+			return false
+		}
+	}
+	return true
+}
+
+// suggestCities returns the suggested cities for a request and the country they were chosen for.
+// The country is empty when the default suggestions are returned.
+func (cfg *apiConfig) suggestCities(r *http.Request) (string, []string) {
+	if len(cfg.citySuggestions) == 0 {
+		return "", nil
+	}
+	for _, country := range requestCountryHints(r) {
+		if cities, ok := cfg.citySuggestions[country]; ok {
+			return country, cities
+		}
+	}
+	return "", cfg.citySuggestions[defaultSuggestionsKey]
+}
+
+// requestCountryHints returns the candidate countries of a request in order of preference: the
+// country query parameter, followed by the regions of the Accept-Language tags by quality.
+// Tags without a region contribute the region their language is most likely used in.
+func requestCountryHints(r *http.Request) []string {
+	var hints []string
+	if country, ok := normalizeSuggestionsKey(r.URL.Query().Get("country")); ok && country != defaultSuggestionsKey {
+		hints = append(hints, country)
+	}
+
+	tags, _, err := language.ParseAcceptLanguage(r.Header.Get("Accept-Language"))
+	if err != nil {
+		return hints
+	}
+	for _, tag := range tags {
+		region, confidence := tag.Region()
+		if confidence == language.No {
+			continue
+		}
+		hints = append(hints, region.String())
+	}
+	return hints
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"io"
+	"log/slog"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGetCitySuggestions(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	t.Run("Built-in Mapping", func(t *testing.T) {
+		t.Setenv("DEFAULT_CITIES", "")
+		suggestions := getCitySuggestions(logger)
+		if got := strings.Join(suggestions["PL"], ","); got != "Warsaw,Kraków,Wrocław" {
+			t.Errorf("PL suggestions = %q", got)
+		}
+		if len(suggestions[defaultSuggestionsKey]) == 0 {
+			t.Error("expected default suggestions")
+		}
+	})
+
+	t.Run("Overrides and Invalid Entries", func(t *testing.T) {
+		t.Setenv("DEFAULT_CITIES", "pl=Gdańsk| Poznań ,SE=Stockholm,Default=Paris,Sweden=Malmö,DE=|,bogus")
+		suggestions := getCitySuggestions(logger)
+
+		want := map[string]string{
+			"PL":                  "Gdańsk,Poznań",
+			"SE":                  "Stockholm",
+			defaultSuggestionsKey: "Paris",
+			"DE":                  "Berlin,Munich,Hamburg",
+		}
+		for country, cities := range want {
+			if got := strings.Join(suggestions[country], ","); got != cities {
+				t.Errorf("%s suggestions = %q, want %q", country, got, cities)
+			}
+		}
+		if defaultCitySuggestions["PL"][0] != "Warsaw" {
+			t.Error("overrides must not modify the built-in mapping")
+		}
+	})
+}
+
+func TestSuggestCities(t *testing.T) {
+	cfg := &apiConfig{citySuggestions: map[string][]string{
+		"PL":                  {"Warsaw"},
+		"DE":                  {"Berlin"},
+		"US":                  {"New York"},
+		defaultSuggestionsKey: {"London"},
+	}}
+
+	testCases := []struct {
+		name           string
+		target         string
+		acceptLanguage string
+		wantCountry    string
+		wantCity       string
+	}{
+		{name: "Region in Tag", target: "/api/config", acceptLanguage: "de-DE,de;q=0.9", wantCountry: "DE", wantCity: "Berlin"},
+		{name: "Language Only", target: "/api/config", acceptLanguage: "pl", wantCountry: "PL", wantCity: "Warsaw"},
+		{name: "Quality Order", target: "/api/config", acceptLanguage: "fr-FR;q=0.9,pl-PL;q=0.5,en-US;q=0.7", wantCountry: "US", wantCity: "New York"},
+		{name: "Query Hint Wins", target: "/api/config?country=pl", acceptLanguage: "de-DE", wantCountry: "PL", wantCity: "Warsaw"},
+		{name: "Unknown Query Hint", target: "/api/config?country=SE", acceptLanguage: "de-DE", wantCountry: "DE", wantCity: "Berlin"},
+		{name: "No Match", target: "/api/config", acceptLanguage: "ja-JP", wantCity: "London"},
+		{name: "No Header", target: "/api/config", wantCity: "London"},
+		{name: "Malformed Header", target: "/api/config", acceptLanguage: ";;;q=x", wantCity: "London"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tc.target, nil)
+			if tc.acceptLanguage != "" {
+				req.Header.Set("Accept-Language", tc.acceptLanguage)
+			}
+
+			country, cities := cfg.suggestCities(req)
+
+			if country != tc.wantCountry {
+				t.Errorf("country = %q, want %q", country, tc.wantCountry)
+			}
+			if len(cities) != 1 || cities[0] != tc.wantCity {
+				t.Errorf("cities = %v, want [%s]", cities, tc.wantCity)
+			}
+		})
+	}
+
+	t.Run("No Mapping", func(t *testing.T) {
+		country, cities := (&apiConfig{}).suggestCities(httptest.NewRequest("GET", "/api/config", nil))
+		if country != "" || cities != nil {
+			t.Errorf("expected no suggestions, got %q %v", country, cities)
+		}
+	})
+}
@@ -0,0 +1,357 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// This file implements the natural-language summary of a location's forecast for the rest of the
+// day, such as "Cloudy morning, 60% chance of rain after 15:00, high 21°C". The summary is built
+// from the hourly and daily consensus of all sources with a template per language, which fills in
+// the prevailing condition of the current part of the day, the first hour that is likely to be
+// wet and the day's high.
+
+// summaryLanguages are the summary templates by locale.
+var summaryLanguages = map[string]summaryLanguage{
+	"en": englishSummary,
+	"pl": polishSummary,
+	"de": germanSummary,
+}
+
+// Parts of the day described by summaries. The night until 6:00 is described as the coming
+// morning.
+const (
+	partMorning   = "morning"
+	partAfternoon = "afternoon"
+	partEvening   = "evening"
+)
+
+// summaryLanguage holds the templates of a summary language.
+type summaryLanguage struct {
+	// conditionPart describes the prevailing condition of a part of the day, e.g. "cloudy
+	// morning". It returns an empty string for conditions it has no words for.
+	conditionPart func(code, part string) string
+	// precipitation describes the first likely precipitation of the day: "rain" or "snow", the
+	// chance in percent, if known, and the local time it starts after, if not right away.
+	precipitation func(kind string, chance int, after string) string
+	// noPrecipitation is said if no precipitation is likely for the rest of the day.
+	noPrecipitation string
+	// high describes the day's highest temperature, formatted with its unit.
+	high func(temperature string) string
+}
+
+var englishConditions = map[string]string{
+	conditionClear:        "clear",
+	conditionPartlyCloudy: "partly cloudy",
+	conditionCloudy:       "cloudy",
+	conditionFog:          "foggy",
+	conditionDrizzle:      "drizzly",
+	conditionShowers:      "showery",
+	conditionRain:         "rainy",
+	conditionHeavyRain:    "very rainy",
+	conditionThunderstorm: "stormy",
+	conditionSleet:        "sleety",
+	conditionSnow:         "snowy",
+	conditionDry:          "dry",
+}
+
+var englishSummary = summaryLanguage{
+	conditionPart: func(code, part string) string {
+		adjective, ok := englishConditions[code]
+		if !ok {
+			return ""
+		}
+		return adjective + " " + part
+	},
+	precipitation: func(kind string, chance int, after string) string {
+		s := kind
+		if chance > 0 {
+			s = fmt.Sprintf("%d%% chance of %s", chance, kind)
+		}
+		if after != "" {
+			s += " after " + after
+		}
+		return s
+	},
+	noPrecipitation: "no rain expected",
+	high:            func(temperature string) string { return "high " + temperature },
+}
+
+// polishConditions holds the masculine and neuter forms of the Polish condition adjectives, which
+// agree with the gender of the part of the day.
+var polishConditions = map[string][2]string{
+	conditionClear:        {"bezchmurny", "bezchmurne"},
+	conditionPartlyCloudy: {"częściowo pochmurny", "częściowo pochmurne"},
+	conditionCloudy:       {"pochmurny", "pochmurne"},
+	conditionFog:          {"mglisty", "mgliste"},
+	conditionDrizzle:      {"mżysty", "mżyste"},
+	conditionShowers:      {"przelotnie deszczowy", "przelotnie deszczowe"},
+	conditionRain:         {"deszczowy", "deszczowe"},
+	conditionHeavyRain:    {"bardzo deszczowy", "bardzo deszczowe"},
+	conditionThunderstorm: {"burzowy", "burzowe"},
+	conditionSleet:        {"śnieżny", "śnieżne"},
+	conditionSnow:         {"śnieżny", "śnieżne"},
+	conditionDry:          {"suchy", "suche"},
+}
+
+// polishParts holds the Polish parts of the day and whether they are neuter.
+var polishParts = map[string]struct {
+	noun   string
+	neuter bool
+}{
+	partMorning:   {"poranek", false},
+	partAfternoon: {"popołudnie", true},
+	partEvening:   {"wieczór", false},
+}
+
+var polishPrecipitation = map[string]string{"rain": "deszcz", "snow": "śnieg"}
+
+var polishSummary = summaryLanguage{
+	conditionPart: func(code, part string) string {
+		forms, ok := polishConditions[code]
+		if !ok {
+			return ""
+		}
+		p := polishParts[part]
+		if p.neuter {
+			return forms[1] + " " + p.noun
+		}
+		return forms[0] + " " + p.noun
+	},
+	precipitation: func(kind string, chance int, after string) string {
+		s := polishPrecipitation[kind]
+		if chance > 0 {
+			s = fmt.Sprintf("%d%% szans na %s", chance, s)
+		}
+		if after != "" {
+			s += " po " + after
+		}
+		return s
+	},
+	noPrecipitation: "bez opadów",
+	high:            func(temperature string) string { return "maks. " + temperature },
+}
+
+// germanConditions holds the German condition adjectives in the masculine form, which agrees
+// with all parts of the day.
+var germanConditions = map[string]string{
+	conditionClear:        "klarer",
+	conditionPartlyCloudy: "teilweise bewölkter",
+	conditionCloudy:       "bewölkter",
+	conditionFog:          "nebliger",
+	conditionDrizzle:      "nieseliger",
+	conditionShowers:      "wechselhafter",
+	conditionRain:         "regnerischer",
+	conditionHeavyRain:    "sehr regnerischer",
+	conditionThunderstorm: "gewittriger",
+	conditionSleet:        "nasskalter",
+	conditionSnow:         "verschneiter",
+	conditionDry:          "trockener",
+}
+
+var germanParts = map[string]string{partMorning: "Morgen", partAfternoon: "Nachmittag", partEvening: "Abend"}
+
+var germanPrecipitation = map[string]string{"rain": "Regen", "snow": "Schnee"}
+
+var germanSummary = summaryLanguage{
+	conditionPart: func(code, part string) string {
+		adjective, ok := germanConditions[code]
+		if !ok {
+			return ""
+		}
+		return adjective + " " + germanParts[part]
+	},
+	precipitation: func(kind string, chance int, after string) string {
+		s := germanPrecipitation[kind]
+		if chance > 0 {
+			s = fmt.Sprintf("%d%% %swahrscheinlichkeit", chance, s)
+		}
+		if after != "" {
+			s += " ab " + after
+		}
+		return s
+	},
+	noPrecipitation: "kein Regen erwartet",
+	high:            func(temperature string) string { return "max. " + temperature },
+}
+
+// @Summary      Get natural-language forecast summary
+// @Description  Summarizes the consensus forecast of all sources for the rest of the day in a short sentence, such as
+// @Description  "Cloudy morning, 60% chance of rain after 15:00, high 21°C": the prevailing condition of the current part
+// @Description  of the day, the first hour with a precipitation chance of at least 50% and the day's high. The language
+// @Description  is selected with lang, or otherwise from the Accept-Language header.
+// @Tags         weather
+// @Produce      json
+// @Param        city   query     string  false  "Location name to search for (e.g., 'London')"
+// @Param        lat    query     number  false  "Latitude for the location (e.g., 51.5074)"
+// @Param        lon    query     number  false  "Longitude for the location (e.g., -0.1278)"
+// @Param        lang   query     string  false  "Language of the summary: 'en', 'pl' or 'de'"
+// @Param        units  query     string  false  "Units of the summary: 'metric' (default) or 'imperial'"
+// @Param        Accept-Language  header  string  false  "Preferred languages, used without lang"
+// @Success      200  {object}  SummaryResponse
+// @Failure      400  {object}  ErrorResponse "Bad Request - Invalid location, language or units parameter"
+// @Failure      500  {object}  ErrorResponse "Internal Server Error - Failed to retrieve forecast data"
+// @Router       /api/v1/summary [get]
+func (cfg *apiConfig) handlerSummary(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if r.Method != http.MethodGet {
+		cfg.respondWithError(w, http.StatusMethodNotAllowed, "Method Not Allowed", nil)
+		return
+	}
+
+	lang, ok := requestLocale(r)
+	if !ok {
+		cfg.respondWithError(w, http.StatusBadRequest, errInvalidLocale.Error(), nil)
+		return
+	}
+	units, err := cfg.requestUnits(r)
+	if err != nil {
+		cfg.respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	location, err := cfg.getLocationFromRequest(r)
+	if err != nil {
+		cfg.respondWithError(w, http.StatusBadRequest, "Error getting location data", err)
+		return
+	}
+
+	hourly, err := cfg.getCachedOrFetchHourlyForecast(ctx, location)
+	if err != nil {
+		cfg.respondWithError(w, http.StatusInternalServerError, "Error getting hourly forecast data", err)
+		return
+	}
+	daily, err := cfg.getCachedOrFetchDailyForecast(ctx, location)
+	if err != nil {
+		cfg.respondWithError(w, http.StatusInternalServerError, "Error getting daily forecast data", err)
+		return
+	}
+
+	loc, err := time.LoadLocation(location.Timezone)
+	if err != nil {
+		cfg.logger.WarnContext(ctx, "could not load location timezone, falling back to UTC", "timezone", location.Timezone, "error", err)
+		loc = time.UTC
+	}
+	now := time.Now().In(loc)
+
+	var sources []string
+	for _, f := range hourly {
+		sources = append(sources, f.SourceAPI)
+	}
+	response := SummaryResponse{
+		Location:    location,
+		Date:        now.Format("2006-01-02"),
+		Language:    lang,
+		Summary:     summarizeForecast(consensusHours(hourly, loc, nil), consensusDays(daily, loc, nil), now, summaryLanguages[lang], units),
+		Attribution: attributionForSources(sources),
+	}
+
+	updatedAt := latestTimestamp(hourly)
+	if dailyUpdatedAt := latestTimestamp(daily); dailyUpdatedAt.After(updatedAt) {
+		updatedAt = dailyUpdatedAt
+	}
+	cfg.respondWithConditionalJSON(w, r, response, updatedAt, hourlyForecastCacheTTL)
+}
+
+// summarizeForecast describes the rest of the day of now, in the location's timezone, from the
+// hourly and daily consensus forecasts.
+func summarizeForecast(hours []ConsensusHourJSON, days []ConsensusDayJSON, now time.Time, lang summaryLanguage, units unitSystem) string {
+	// The remaining hours of the day, starting with the current one.
+	type hour struct {
+		t time.Time
+		ConsensusHourJSON
+	}
+	var remaining []hour
+	start := time.Date(now.Year(), now.Month(), now.Day(), now.Hour(), 0, 0, 0, now.Location())
+	for _, h := range hours {
+		t, err := time.ParseInLocation("2006-01-02 15:04", h.ForecastDateTime, now.Location())
+		if err != nil || t.Before(start) || t.YearDay() != now.YearDay() || t.Year() != now.Year() {
+			continue
+		}
+		remaining = append(remaining, hour{t, h})
+	}
+
+	var clauses []string
+
+	// The prevailing condition of the current part of the day.
+	part := partOfDay(now.Hour())
+	var codes []string
+	for _, h := range remaining {
+		if partOfDay(h.t.Hour()) == part {
+			codes = append(codes, h.Condition.Code)
+		}
+	}
+	if len(codes) > 0 {
+		if s := lang.conditionPart(consensusCondition(codes).Code, part); s != "" {
+			clauses = append(clauses, s)
+		}
+	}
+
+	// The first hour that is likely to be wet, with the highest chance from then on.
+	clause := lang.noPrecipitation
+	for i, h := range remaining {
+		chance := h.PrecipitationChance.Value
+		if chance < rainChanceThreshold && h.Precipitation.Value <= 0 {
+			continue
+		}
+		for _, later := range remaining[i+1:] {
+			chance = max(chance, later.PrecipitationChance.Value)
+		}
+		kind := "rain"
+		if h.Condition.Code == conditionSnow || h.Condition.Code == conditionSleet || h.Temperature.Value <= 0 {
+			kind = "snow"
+		}
+		after := ""
+		if i > 0 {
+			after = h.t.Format("15:04")
+		}
+		clause = lang.precipitation(kind, int(Round(chance, 0)), after)
+		break
+	}
+	clauses = append(clauses, clause)
+
+	// The day's high, from the daily consensus or, without it, the remaining hours.
+	today := now.Format("2006-01-02")
+	high, haveHigh := 0.0, false
+	for _, d := range days {
+		if d.ForecastDate == today {
+			high, haveHigh = d.MaxTemp.Value, true
+		}
+	}
+	if !haveHigh {
+		for _, h := range remaining {
+			if !haveHigh || h.Temperature.Value > high {
+				high, haveHigh = h.Temperature.Value, true
+			}
+		}
+	}
+	if haveHigh {
+		clauses = append(clauses, lang.high(formatSummaryNumber(units.temperature(high), 0)+units.temperatureSymbol()))
+	}
+
+	return capitalizeFirst(strings.Join(clauses, ", "))
+}
+
+// partOfDay returns the part of the day described for a local hour.
+func partOfDay(hour int) string {
+	switch {
+	case hour >= 18:
+		return partEvening
+	case hour >= 12:
+		return partAfternoon
+	default:
+		return partMorning
+	}
+}
+
+// capitalizeFirst returns s with its first letter in upper case.
+func capitalizeFirst(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	if r == utf8.RuneError {
+		return s
+	}
+	return string(unicode.ToUpper(r)) + s[size:]
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cor0nius/willitrain/internal/database"
+)
+
+// summaryTestHours returns a consensus hour for every hour of 2025-06-02 from the given hour on,
+// cloudy and dry unless changed by modify.
+func summaryTestHours(from int, modify func(hour int, h *ConsensusHourJSON)) []ConsensusHourJSON {
+	var hours []ConsensusHourJSON
+	for hour := from; hour < 24; hour++ {
+		h := ConsensusHourJSON{
+			ForecastDateTime: fmt.Sprintf("2025-06-02 %02d:00", hour),
+			Temperature:      ConsensusValueJSON{Value: 15 + float64(hour)/4},
+			Condition:        ConsensusConditionJSON{Code: conditionCloudy},
+		}
+		if modify != nil {
+			modify(hour, &h)
+		}
+		hours = append(hours, h)
+	}
+	return hours
+}
+
+func TestSummarizeForecast(t *testing.T) {
+	rainAfter15 := func(hour int, h *ConsensusHourJSON) {
+		switch {
+		case hour == 15:
+			h.PrecipitationChance.Value = 55
+		case hour > 15:
+			h.PrecipitationChance.Value = 60
+			h.Condition.Code = conditionRain
+		}
+	}
+	days := []ConsensusDayJSON{{ForecastDate: "2025-06-02", MaxTemp: ConsensusValueJSON{Value: 21.3}}}
+
+	testCases := []struct {
+		name  string
+		now   time.Time
+		hours []ConsensusHourJSON
+		days  []ConsensusDayJSON
+		lang  summaryLanguage
+		units unitSystem
+		want  string
+	}{
+		{
+			name:  "morning rain later",
+			now:   time.Date(2025, 6, 2, 8, 20, 0, 0, time.UTC),
+			hours: summaryTestHours(0, rainAfter15),
+			days:  days,
+			lang:  englishSummary,
+			want:  "Cloudy morning, 60% chance of rain after 15:00, high 21°C",
+		},
+		{
+			name:  "polish",
+			now:   time.Date(2025, 6, 2, 8, 20, 0, 0, time.UTC),
+			hours: summaryTestHours(0, rainAfter15),
+			days:  days,
+			lang:  polishSummary,
+			want:  "Pochmurny poranek, 60% szans na deszcz po 15:00, maks. 21°C",
+		},
+		{
+			name:  "polish neuter afternoon",
+			now:   time.Date(2025, 6, 2, 13, 0, 0, 0, time.UTC),
+			hours: summaryTestHours(0, func(hour int, h *ConsensusHourJSON) { h.Condition.Code = conditionClear }),
+			days:  days,
+			lang:  polishSummary,
+			want:  "Bezchmurne popołudnie, bez opadów, maks. 21°C",
+		},
+		{
+			name:  "german",
+			now:   time.Date(2025, 6, 2, 8, 20, 0, 0, time.UTC),
+			hours: summaryTestHours(0, rainAfter15),
+			days:  days,
+			lang:  germanSummary,
+			want:  "Bewölkter Morgen, 60% Regenwahrscheinlichkeit ab 15:00, max. 21°C",
+		},
+		{
+			name:  "raining now",
+			now:   time.Date(2025, 6, 2, 16, 30, 0, 0, time.UTC),
+			hours: summaryTestHours(0, rainAfter15),
+			lang:  englishSummary,
+			units: unitsImperial,
+			// Without a daily forecast, the high is that of the remaining hours.
+			want: "Rainy afternoon, 60% chance of rain, high 69°F",
+		},
+		{
+			name: "snow without a chance",
+			now:  time.Date(2025, 6, 2, 19, 0, 0, 0, time.UTC),
+			hours: summaryTestHours(19, func(hour int, h *ConsensusHourJSON) {
+				h.Condition.Code = conditionUnknown
+				if hour >= 21 {
+					h.Precipitation.Value = 0.4
+					h.Temperature.Value = -1
+				}
+			}),
+			days: days,
+			lang: englishSummary,
+			want: "Snow after 21:00, high 21°C",
+		},
+		{
+			name:  "past hours are ignored",
+			now:   time.Date(2025, 6, 2, 18, 0, 0, 0, time.UTC),
+			hours: summaryTestHours(0, func(hour int, h *ConsensusHourJSON) { h.Precipitation.Value = float64(max(0, 12-hour)) }),
+			days:  days,
+			lang:  englishSummary,
+			want:  "Cloudy evening, no rain expected, high 21°C",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := summarizeForecast(tc.hours, tc.days, tc.now, tc.lang, tc.units); got != tc.want {
+				t.Errorf("summarizeForecast() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestHandlerSummary(t *testing.T) {
+	now := time.Now().UTC()
+	testCfg := newTestAPIConfig(t)
+	testCfg.mockDB.GetLocationByAliasFunc = func(ctx context.Context, alias string) (database.Location, error) {
+		return MockDBLocation, nil
+	}
+	hourly, _ := json.Marshal([]HourlyForecast{{SourceAPI: "Open-Meteo API", Timestamp: now, ForecastDateTime: now.Truncate(time.Hour), Temperature: 18, Condition: "Overcast"}})
+	daily, _ := json.Marshal([]DailyForecast{{SourceAPI: "Open-Meteo API", Timestamp: now, ForecastDate: now.Truncate(24 * time.Hour), MaxTemp: 21}})
+	testCfg.mockCache.GetFunc = func(ctx context.Context, key string) (string, error) {
+		if key == testCfg.weatherCacheKey(hourlyForecastCacheKeyPrefix, MockDBLocation.ID) {
+			return string(hourly), nil
+		}
+		return string(daily), nil
+	}
+
+	rr := httptest.NewRecorder()
+	testCfg.apiConfig.handlerSummary(rr, httptest.NewRequest(http.MethodGet, "/api/v1/summary?city=wroclaw&lang=pl", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200; body: %s", rr.Code, rr.Body.String())
+	}
+	var response SummaryResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if response.Language != "pl" || response.Date != now.Format("2006-01-02") || !strings.HasSuffix(response.Summary, "bez opadów, maks. 21°C") {
+		t.Errorf("unexpected response: %+v", response)
+	}
+
+	rr = httptest.NewRecorder()
+	testCfg.apiConfig.handlerSummary(rr, httptest.NewRequest(http.MethodGet, "/api/v1/summary?city=wroclaw&lang=xx", nil))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want 400 for an unsupported language", rr.Code)
+	}
+}
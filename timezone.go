@@ -0,0 +1,243 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/cor0nius/willitrain/internal/database"
+	"github.com/google/uuid"
+)
+
+// This file implements timezone resolution for locations. Weather providers report a
+// timezone with every response, but for locations near a border they do not always agree,
+// and writing whichever answer arrived first made the stored value flap between fetches.
+// Provider answers are therefore only used to detect that something may be wrong; the
+// value that is stored comes from a TimezoneService that derives it from the coordinates.
+
+// timezoneCacheKeyPrefix is the cache key prefix for authoritative location timezones.
+const timezoneCacheKeyPrefix = "timezone"
+
+// timezoneCacheTTL is how long an authoritative timezone is cached. Timezone boundaries
+// practically never move, so the lookup only needs to be repeated occasionally.
+const timezoneCacheTTL = 30 * 24 * time.Hour
+
+// TimezoneService resolves the IANA timezone for a pair of coordinates.
+type TimezoneService interface {
+	Timezone(lat, lng float64) (string, error)
+}
+
+// GmpTimezoneService is an implementation of TimezoneService that uses the Google Time Zone API.
+type GmpTimezoneService struct {
+	gmpKey         *secretValue
+	gmpTimezoneURL string
+	httpClient     *http.Client
+}
+
+// NewGmpTimezoneService creates a new GmpTimezoneService.
+func NewGmpTimezoneService(gmpKey *secretValue, gmpTimezoneURL string, httpClient *http.Client) *GmpTimezoneService {
+	return &GmpTimezoneService{
+		gmpKey:         gmpKey,
+		gmpTimezoneURL: gmpTimezoneURL,
+		httpClient:     httpClient,
+	}
+}
+
+// Timezone queries the Google Time Zone API for the timezone at the given coordinates.
+func (s *GmpTimezoneService) Timezone(lat, lng float64) (string, error) {
+	baseURL, err := url.Parse(s.gmpTimezoneURL + "json")
+	if err != nil {
+		return "", fmt.Errorf("failed to parse base timezone URL: %w", err)
+	}
+
+	q := baseURL.Query()
+	q.Set("key", s.gmpKey.Get())
+	q.Set("location", fmt.Sprintf("%.4f,%.4f", lat, lng))
+	q.Set("timestamp", strconv.FormatInt(time.Now().Unix(), 10))
+	baseURL.RawQuery = q.Encode()
+
+	resp, err := s.httpClient.Get(baseURL.String())
+	if err != nil {
+		return "", fmt.Errorf("timezone API request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("timezone API request returned non-200 status: %s", resp.Status)
+	}
+
+	var responseJSON TimezoneAPIResponse
+	if err := json.NewDecoder(resp.Body).Decode(&responseJSON); err != nil {
+		return "", fmt.Errorf("failed to decode timezone response: %w", err)
+	}
+	if responseJSON.Status != "OK" {
+		return "", fmt.Errorf("timezone API returned status: %s", responseJSON.Status)
+	}
+	if responseJSON.TimeZoneID == "" {
+		return "", errors.New("timezone API returned an empty timezone")
+	}
+	return responseJSON.TimeZoneID, nil
+}
+
+// TimezoneAPIResponse represents the relevant part of the Google Time Zone API JSON response.
+type TimezoneAPIResponse struct {
+	Status     string `json:"status"`
+	TimeZoneID string `json:"timeZoneId"`
+}
+
+// consensusTimezone picks the timezone reported by the most providers from a map of
+// SourceAPI to reported timezone. Ties are broken alphabetically so that the choice does
+// not depend on which provider answered first. It also reports whether the providers disagreed.
+func consensusTimezone(reported map[string]string) (string, bool) {
+	votes := make(map[string]int)
+	for _, tz := range reported {
+		if tz != "" {
+			votes[tz]++
+		}
+	}
+
+	candidates := make([]string, 0, len(votes))
+	for tz := range votes {
+		candidates = append(candidates, tz)
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if votes[candidates[i]] != votes[candidates[j]] {
+			return votes[candidates[i]] > votes[candidates[j]]
+		}
+		return candidates[i] < candidates[j]
+	})
+
+	if len(candidates) == 0 {
+		return "", false
+	}
+	return candidates[0], len(candidates) > 1
+}
+
+// lookupTimezone returns the authoritative timezone for a location, computed from its
+// coordinates by the timezone service and cached for timezoneCacheTTL.
+func (cfg *apiConfig) lookupTimezone(ctx context.Context, location Location) (string, error) {
+	key := timezoneCacheKey(location.LocationID)
+	if tz, err := cfg.cache.Get(ctx, key); err == nil && tz != "" {
+		return tz, nil
+	}
+
+	tz, err := cfg.timezoner.Timezone(location.Latitude, location.Longitude)
+	if err != nil {
+		return "", err
+	}
+	if _, err := time.LoadLocation(tz); err != nil {
+		return "", fmt.Errorf("timezone service returned unknown timezone %q: %w", tz, err)
+	}
+
+	if err := cfg.cache.Set(ctx, key, tz, timezoneCacheTTL); err != nil {
+		cfg.logger.Warn("failed to cache timezone", "location", location.CityName, "error", err)
+	}
+	return tz, nil
+}
+
+// reconcileTimezone updates the stored timezone of a location after a fetch, if needed.
+// A reported timezone that matches the stored one needs no action. Otherwise the
+// authoritative timezone is looked up and written only if it differs from the stored value.
+// If the lookup fails, the reported timezone is used to fill in a missing value but never
+// overwrites an existing one.
+func (cfg *apiConfig) reconcileTimezone(ctx context.Context, location Location, reported string) {
+	if reported == "" || reported == location.Timezone {
+		return
+	}
+
+	tz, err := cfg.lookupTimezone(ctx, location)
+	if err != nil {
+		cfg.logger.Warn("failed to look up authoritative timezone", "location", location.CityName, "error", err)
+		if location.Timezone != "" {
+			return
+		}
+		tz = reported
+	}
+	if tz == location.Timezone {
+		cfg.logger.Debug("reported timezone differs from authoritative one, keeping stored value",
+			"location", location.CityName, "reported", reported, "timezone", tz)
+		return
+	}
+
+	if err := cfg.updateTimezone(ctx, location.LocationID, tz); err != nil {
+		cfg.logger.Warn("failed to update timezone", "location", location.CityName, "error", err)
+		return
+	}
+	cfg.logger.Info("updated location timezone", "location", location.CityName, "from", location.Timezone, "to", tz)
+}
+
+// repairTimezones checks the stored timezone of every location against the authoritative one
+// and corrects the mismatches. It is intended as a one-off repair for rows whose timezone
+// flapped before reconciliation was introduced, and returns the number of locations checked
+// and repaired.
+func (cfg *apiConfig) repairTimezones(ctx context.Context) (int, int, error) {
+	locations, err := cfg.dbQueries.ListLocations(ctx)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to list locations: %w", err)
+	}
+
+	repaired := 0
+	for _, dbLocation := range locations {
+		location := databaseLocationToLocation(dbLocation)
+		tz, err := cfg.lookupTimezone(ctx, location)
+		if err != nil {
+			cfg.logger.Warn("failed to look up authoritative timezone", "location", location.CityName, "error", err)
+			continue
+		}
+		if tz == location.Timezone {
+			continue
+		}
+		if err := cfg.updateTimezone(ctx, location.LocationID, tz); err != nil {
+			cfg.logger.Warn("failed to update timezone", "location", location.CityName, "error", err)
+			continue
+		}
+		cfg.logger.Info("repaired location timezone", "location", location.CityName, "from", location.Timezone, "to", tz)
+		repaired++
+	}
+	return len(locations), repaired, nil
+}
+
+// @Summary      Repair location timezones (development only)
+// @Description  Compares the stored timezone of every location with the one computed from its coordinates
+// @Description  and corrects any mismatches. Intended as a one-off repair for timezones that flapped between providers.
+// @Tags         admin
+// @Produce      json
+// @Success      200  {object}  TimezoneRepairResponse
+// @Failure      500  {object}  ErrorResponse "Internal Server Error - Failed to repair timezones"
+// @Security     ApiKeyAuth
+// @Failure      401  {object}  ErrorResponse "Unauthorized - Missing API key"
+// @Failure      403  {object}  ErrorResponse "Forbidden - Invalid API key"
+// @Router       /admin/timezones/repair [post]
+func (cfg *apiConfig) handlerRepairTimezones(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		cfg.respondWithError(w, http.StatusMethodNotAllowed, "Method Not Allowed", nil)
+		return
+	}
+
+	checked, repaired, err := cfg.repairTimezones(r.Context())
+	if err != nil {
+		cfg.respondWithError(w, http.StatusInternalServerError, "Failed to repair timezones", err)
+		return
+	}
+	cfg.respondWithJSON(w, http.StatusOK, TimezoneRepairResponse{Checked: checked, Repaired: repaired})
+}
+
+// updateTimezone stores a timezone for a location in the database.
+func (cfg *apiConfig) updateTimezone(ctx context.Context, locationID uuid.UUID, tz string) error {
+	return cfg.dbQueries.UpdateTimezone(ctx, database.UpdateTimezoneParams{
+		ID:       locationID,
+		Timezone: sql.NullString{String: tz, Valid: true},
+	})
+}
+
+// timezoneCacheKey returns the cache key of the authoritative timezone of a location.
+func timezoneCacheKey(locationID uuid.UUID) string {
+	return locationCacheKey(timezoneCacheKeyPrefix, locationID)
+}
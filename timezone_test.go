@@ -0,0 +1,212 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cor0nius/willitrain/internal/database"
+)
+
+func TestGmpTimezoneService(t *testing.T) {
+	testCases := []struct {
+		name      string
+		handler   http.HandlerFunc
+		want      string
+		expectErr bool
+	}{
+		{
+			name: "Success",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Query().Get("location") != "51.1000,17.0300" {
+					t.Errorf("unexpected location parameter: %s", r.URL.Query().Get("location"))
+				}
+				_, _ = w.Write([]byte(`{"status":"OK","timeZoneId":"Europe/Warsaw"}`))
+			},
+			want: "Europe/Warsaw",
+		},
+		{
+			name: "API Status Error",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`{"status":"ZERO_RESULTS"}`))
+			},
+			expectErr: true,
+		},
+		{
+			name: "HTTP Error",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusInternalServerError)
+			},
+			expectErr: true,
+		},
+		{
+			name: "Invalid JSON",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`{`))
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(tc.handler)
+			defer server.Close()
+
+			service := NewGmpTimezoneService(newSecretValue("key"), server.URL+"/", server.Client())
+			got, err := service.Timezone(51.1, 17.03)
+
+			if (err != nil) != tc.expectErr {
+				t.Fatalf("expected error: %v, got: %v", tc.expectErr, err)
+			}
+			if got != tc.want {
+				t.Errorf("expected %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestConsensusTimezone(t *testing.T) {
+	testCases := []struct {
+		name         string
+		reported     map[string]string
+		want         string
+		wantDisagree bool
+	}{
+		{name: "None Reported", reported: map[string]string{}},
+		{name: "Agreement", reported: map[string]string{"a": "Europe/Warsaw", "b": "Europe/Warsaw"}, want: "Europe/Warsaw"},
+		{
+			name:         "Majority Wins",
+			reported:     map[string]string{"a": "Europe/Berlin", "b": "Europe/Warsaw", "c": "Europe/Warsaw"},
+			want:         "Europe/Warsaw",
+			wantDisagree: true,
+		},
+		{
+			name:         "Tie Broken Alphabetically",
+			reported:     map[string]string{"a": "Europe/Warsaw", "b": "Europe/Berlin"},
+			want:         "Europe/Berlin",
+			wantDisagree: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, disagree := consensusTimezone(tc.reported)
+			if got != tc.want || disagree != tc.wantDisagree {
+				t.Errorf("expected (%q, %v), got (%q, %v)", tc.want, tc.wantDisagree, got, disagree)
+			}
+		})
+	}
+}
+
+func TestReconcileTimezone(t *testing.T) {
+	lookupErr := errors.New("lookup failed")
+
+	testCases := []struct {
+		name         string
+		stored       string
+		reported     string
+		authority    string
+		authorityErr error
+		wantUpdate   string
+	}{
+		{name: "Matches Stored", stored: "Europe/Warsaw", reported: "Europe/Warsaw"},
+		{name: "Nothing Reported", stored: "Europe/Warsaw"},
+		{name: "Fill Missing From Authority", reported: "Europe/Berlin", authority: "Europe/Warsaw", wantUpdate: "Europe/Warsaw"},
+		{name: "Disagreeing Provider Does Not Flap", stored: "Europe/Warsaw", reported: "Europe/Berlin", authority: "Europe/Warsaw"},
+		{name: "Authority Changed", stored: "Europe/Berlin", reported: "Europe/Warsaw", authority: "Europe/Warsaw", wantUpdate: "Europe/Warsaw"},
+		{name: "Lookup Fails - Fill Missing", reported: "Europe/Berlin", authorityErr: lookupErr, wantUpdate: "Europe/Berlin"},
+		{name: "Lookup Fails - Keep Stored", stored: "Europe/Warsaw", reported: "Europe/Berlin", authorityErr: lookupErr},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testCfg := newTestAPIConfig(t)
+			testCfg.mockTZ.TimezoneFunc = func(lat, lng float64) (string, error) {
+				return tc.authority, tc.authorityErr
+			}
+			var updated string
+			testCfg.mockDB.UpdateTimezoneFunc = func(ctx context.Context, arg database.UpdateTimezoneParams) error {
+				updated = arg.Timezone.String
+				return nil
+			}
+
+			location := MockLocation
+			location.Timezone = tc.stored
+			testCfg.reconcileTimezone(context.Background(), location, tc.reported)
+
+			if updated != tc.wantUpdate {
+				t.Errorf("expected timezone update to %q, got %q", tc.wantUpdate, updated)
+			}
+		})
+	}
+}
+
+func TestHandlerRepairTimezones(t *testing.T) {
+	testCases := []struct {
+		name       string
+		method     string
+		setupMocks func(cfg *testAPIConfig)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:   "Success",
+			method: http.MethodPost,
+			setupMocks: func(cfg *testAPIConfig) {
+				cfg.mockDB.ListLocationsFunc = func(ctx context.Context) ([]database.Location, error) {
+					flapped := MockDBLocation
+					flapped.Timezone.String, flapped.Timezone.Valid = "Europe/Berlin", true
+					return []database.Location{MockDBLocation, flapped}, nil
+				}
+				cfg.mockTZ.TimezoneFunc = func(lat, lng float64) (string, error) {
+					return "Europe/Warsaw", nil
+				}
+				cfg.mockDB.UpdateTimezoneFunc = func(ctx context.Context, arg database.UpdateTimezoneParams) error {
+					return nil
+				}
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `{"checked":2,"repaired":2}`,
+		},
+		{
+			name:   "DB Error",
+			method: http.MethodPost,
+			setupMocks: func(cfg *testAPIConfig) {
+				cfg.mockDB.ListLocationsFunc = func(ctx context.Context) ([]database.Location, error) {
+					return nil, errors.New("db error")
+				}
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   `{"error":"Failed to repair timezones"}`,
+		},
+		{
+			name:       "Wrong Method",
+			method:     http.MethodGet,
+			setupMocks: func(cfg *testAPIConfig) {},
+			wantStatus: http.StatusMethodNotAllowed,
+			wantBody:   `{"error":"Method Not Allowed"}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testCfg := newTestAPIConfig(t)
+			tc.setupMocks(testCfg)
+
+			req := httptest.NewRequest(tc.method, "/admin/timezones/repair", nil)
+			rr := httptest.NewRecorder()
+
+			testCfg.apiConfig.handlerRepairTimezones(rr, req)
+
+			if rr.Code != tc.wantStatus {
+				t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, tc.wantStatus)
+			}
+			if rr.Body.String() != tc.wantBody {
+				t.Errorf("handler returned unexpected body: got %v want %v", rr.Body.String(), tc.wantBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/cor0nius/willitrain/internal/database"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// This file sets up OpenTelemetry tracing. Every request is traced with child spans for the
+// location lookup, cache reads and writes, database queries and provider fetches, so that a
+// trace shows where a slow request spent its time. Spans are exported over OTLP/HTTP when
+// OTEL_EXPORTER_OTLP_ENDPOINT or OTEL_EXPORTER_OTLP_TRACES_ENDPOINT is set; the exporter reads
+// the other standard OTEL_EXPORTER_OTLP_* variables itself. Without an endpoint, tracing is
+// disabled and the spans cost next to nothing.
+
+// tracer creates the application's spans. It uses the global tracer provider, so spans are
+// exported once setupTracing has installed one.
+var tracer = otel.Tracer("github.com/cor0nius/willitrain")
+
+// setupTracing installs a tracer provider exporting over OTLP/HTTP if an endpoint is configured.
+// The returned function flushes and stops the exporter; it does nothing if tracing is disabled.
+func setupTracing(ctx context.Context, logger *slog.Logger) (func(context.Context) error, error) {
+	if os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") == "" && os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") == "" {
+		logger.Debug("tracing disabled, no OTLP endpoint configured")
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not create OTLP trace exporter: %w", err)
+	}
+
+	// OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES take precedence over the default name.
+	res, err := resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceName("willitrain")),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("could not create trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	logger.Info("tracing enabled")
+	return provider.Shutdown, nil
+}
+
+// tracingMiddleware starts a server span for every request, continuing the trace of the caller
+// if the request carries a W3C traceparent header. The span is named after the matched route.
+func tracingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracer.Start(ctx, r.Method+" "+r.URL.Path,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(r.Method),
+				semconv.URLPath(r.URL.Path),
+			),
+		)
+		defer span.End()
+		if id := requestIDFrom(ctx); id != "" {
+			span.SetAttributes(attribute.String("http.request.id", id))
+		}
+
+		rw := newResponseWriter(w)
+		r = r.WithContext(ctx)
+		next.ServeHTTP(rw, r)
+
+		// The mux records the matched pattern on the request it was given.
+		if r.Pattern != "" {
+			span.SetName(r.Pattern)
+			span.SetAttributes(semconv.HTTPRoute(r.Pattern))
+		}
+		span.SetAttributes(semconv.HTTPResponseStatusCode(rw.statusCode))
+		if rw.statusCode >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(rw.statusCode))
+		}
+	})
+}
+
+// endSpan records err, if any, on a span and ends it.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// tracedDB wraps the connection used by the sqlc queries and traces every query. Spans are
+// named after the query, taken from the "-- name:" comment sqlc puts at the top of each.
+type tracedDB struct {
+	db database.DBTX
+}
+
+func (t tracedDB) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	ctx, span := startQuerySpan(ctx, query)
+	res, err := t.db.ExecContext(ctx, query, args...)
+	endSpan(span, err)
+	return res, err
+}
+
+func (t tracedDB) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	ctx, span := startQuerySpan(ctx, query)
+	stmt, err := t.db.PrepareContext(ctx, query)
+	endSpan(span, err)
+	return stmt, err
+}
+
+func (t tracedDB) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	ctx, span := startQuerySpan(ctx, query)
+	rows, err := t.db.QueryContext(ctx, query, args...)
+	endSpan(span, err)
+	return rows, err
+}
+
+func (t tracedDB) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	ctx, span := startQuerySpan(ctx, query)
+	row := t.db.QueryRowContext(ctx, query, args...)
+	endSpan(span, row.Err())
+	return row
+}
+
+// startQuerySpan starts a client span for a database query.
+func startQuerySpan(ctx context.Context, query string) (context.Context, trace.Span) {
+	name := queryName(query)
+	return tracer.Start(ctx, "db "+name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemPostgreSQL,
+			semconv.DBOperationName(name),
+		),
+	)
+}
+
+// queryName returns the name of a sqlc query, or "query" if it has none.
+func queryName(query string) string {
+	const prefix = "-- name: "
+	if !strings.HasPrefix(query, prefix) {
+		return "query"
+	}
+	fields := strings.Fields(query[len(prefix):])
+	if len(fields) == 0 {
+		return "query"
+	}
+	return fields[0]
+}
+
+// cacheSpanAttributes returns the attributes of a cache operation span.
+func cacheSpanAttributes(operation, key string) trace.SpanStartOption {
+	return trace.WithAttributes(
+		attribute.String("db.system", "redis"),
+		semconv.DBOperationName(operation),
+		attribute.String("cache.key", key),
+	)
+}
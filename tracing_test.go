@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestQueryName(t *testing.T) {
+	testCases := []struct {
+		query    string
+		expected string
+	}{
+		{"-- name: GetLocationByName :one\nSELECT * FROM locations WHERE city_name = $1", "GetLocationByName"},
+		{"-- name: DeleteAllLocations :exec\nDELETE FROM locations", "DeleteAllLocations"},
+		{"SELECT 1", "query"},
+		{"-- name: ", "query"},
+	}
+
+	for _, tc := range testCases {
+		if got := queryName(tc.query); got != tc.expected {
+			t.Errorf("queryName(%q) = %q, want %q", tc.query, got, tc.expected)
+		}
+	}
+}
+
+// fakeDBTX is a database connection whose statements fail with err.
+type fakeDBTX struct {
+	err error
+}
+
+func (f fakeDBTX) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return nil, f.err
+}
+
+func (f fakeDBTX) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return nil, f.err
+}
+
+func (f fakeDBTX) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return nil, f.err
+}
+
+func (f fakeDBTX) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	return nil
+}
+
+// testSpanProcessor passes the spans of the tracer provider installed for tests on to the
+// recorder of the running test.
+type testSpanProcessor struct {
+	mu       sync.Mutex
+	recorder *tracetest.SpanRecorder
+}
+
+func (p *testSpanProcessor) current() *tracetest.SpanRecorder {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.recorder
+}
+
+func (p *testSpanProcessor) OnStart(ctx context.Context, s sdktrace.ReadWriteSpan) {
+	p.current().OnStart(ctx, s)
+}
+
+func (p *testSpanProcessor) OnEnd(s sdktrace.ReadOnlySpan) { p.current().OnEnd(s) }
+
+func (p *testSpanProcessor) Shutdown(ctx context.Context) error { return nil }
+
+func (p *testSpanProcessor) ForceFlush(ctx context.Context) error { return nil }
+
+var (
+	installTestTracerProvider sync.Once
+	testSpans                 = &testSpanProcessor{recorder: tracetest.NewSpanRecorder()}
+)
+
+// newSpanRecorder returns a recorder for the spans started from now on. The global tracer only
+// delegates to the first tracer provider installed, so a single provider is installed for all
+// tests, and runs repeated with -count share it.
+func newSpanRecorder(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	installTestTracerProvider.Do(func() {
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(testSpans)))
+		otel.SetTextMapPropagator(propagation.TraceContext{})
+	})
+	recorder := tracetest.NewSpanRecorder()
+	testSpans.mu.Lock()
+	testSpans.recorder = recorder
+	testSpans.mu.Unlock()
+	return recorder
+}
+
+func TestTracing(t *testing.T) {
+	recorder := newSpanRecorder(t)
+
+	errQuery := errors.New("connection refused")
+	db := tracedDB{db: fakeDBTX{err: errQuery}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/things/{id}", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = db.ExecContext(r.Context(), "-- name: DeleteThing :exec\nDELETE FROM things WHERE id = $1", r.PathValue("id"))
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/things/42", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	rr := httptest.NewRecorder()
+	tracingMiddleware(mux).ServeHTTP(rr, req)
+
+	// Spans of other tests' background work may be recorded too.
+	var spans []sdktrace.ReadOnlySpan
+	for _, span := range recorder.Ended() {
+		if span.SpanContext().TraceID().String() == "4bf92f3577b34da6a3ce929d0e0e4736" {
+			spans = append(spans, span)
+		}
+	}
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans in the caller's trace, got %d", len(spans))
+	}
+	dbSpan, serverSpan := spans[0], spans[1]
+
+	if dbSpan.Name() != "db DeleteThing" {
+		t.Errorf("unexpected database span name %q", dbSpan.Name())
+	}
+	if dbSpan.Status().Code != codes.Error {
+		t.Errorf("expected the failed query to set an error status, got %v", dbSpan.Status().Code)
+	}
+	if dbSpan.Parent().SpanID() != serverSpan.SpanContext().SpanID() {
+		t.Error("expected the database span to be a child of the server span")
+	}
+
+	if serverSpan.Name() != "GET /api/v1/things/{id}" {
+		t.Errorf("unexpected server span name %q", serverSpan.Name())
+	}
+	if got := serverSpan.Parent().SpanID().String(); got != "00f067aa0ba902b7" {
+		t.Errorf("expected the server span to continue the caller's span, got parent %s", got)
+	}
+	if serverSpan.Status().Code != codes.Error {
+		t.Errorf("expected a 500 response to set an error status, got %v", serverSpan.Status().Code)
+	}
+}
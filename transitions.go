@@ -0,0 +1,126 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+// This file derives condition transitions from hourly forecasts, so that clients can show
+// banners such as "rain starts at 14:00" without comparing the hourly rows themselves.
+// Transitions are computed for every source on its own and for the consensus, which is the
+// condition reported by most sources for each hour.
+
+// consensusSource is the source name used for transitions of the consensus condition.
+const consensusSource = "consensus"
+
+// conditionSeverity ranks condition codes from mildest to most severe. It breaks ties when
+// sources are evenly split, in favor of the more severe condition.
+var conditionSeverity = map[string]int{
+	conditionClear:        1,
+	conditionDry:          1,
+	conditionPartlyCloudy: 2,
+	conditionCloudy:       3,
+	conditionFog:          4,
+	conditionDrizzle:      5,
+	conditionShowers:      6,
+	conditionRain:         7,
+	conditionHeavyRain:    8,
+	conditionSleet:        9,
+	conditionSnow:         10,
+	conditionThunderstorm: 11,
+}
+
+// hourlyTransitions returns the condition transitions in forecasts, which must be sorted by
+// time. Consensus transitions come first, followed by those of each source in alphabetical
+// order. Hours with an unknown condition are skipped rather than treated as a change.
+func hourlyTransitions(forecasts []HourlyForecastJSON) []ConditionTransitionJSON {
+	transitions := conditionTransitions(consensusSource, consensusSeries(forecasts))
+
+	bySource := make(map[string][]HourlyForecastJSON)
+	for _, f := range forecasts {
+		bySource[f.SourceAPI] = append(bySource[f.SourceAPI], f)
+	}
+	sources := make([]string, 0, len(bySource))
+	for source := range bySource {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+	for _, source := range sources {
+		transitions = append(transitions, conditionTransitions(source, bySource[source])...)
+	}
+	return transitions
+}
+
+// consensusSeries collapses the forecasts of all sources into one entry per hour carrying
+// the most reported condition code.
+func consensusSeries(forecasts []HourlyForecastJSON) []HourlyForecastJSON {
+	var series []HourlyForecastJSON
+	for start := 0; start < len(forecasts); {
+		end := start
+		var codes []string
+		for ; end < len(forecasts) && forecasts[end].ForecastDateTime == forecasts[start].ForecastDateTime; end++ {
+			codes = append(codes, forecasts[end].ConditionCode)
+		}
+
+		best, _, _ := majorityCondition(codes)
+		series = append(series, HourlyForecastJSON{
+			SourceAPI:        consensusSource,
+			ForecastDateTime: forecasts[start].ForecastDateTime,
+			ConditionCode:    best,
+		})
+		start = end
+	}
+	return series
+}
+
+// majorityCondition returns the condition code reported most often in codes, the number of
+// codes that reported it and the number of known codes. Ties go to the more severe condition,
+// and unknown codes are not counted.
+func majorityCondition(codes []string) (code string, votes, known int) {
+	counts := make(map[string]int)
+	for _, c := range codes {
+		if c != conditionUnknown {
+			counts[c]++
+			known++
+		}
+	}
+
+	code = conditionUnknown
+	for c, n := range counts {
+		if n > counts[code] || n == counts[code] && conditionSeverity[c] > conditionSeverity[code] {
+			code = c
+		}
+	}
+	return code, counts[code], known
+}
+
+// conditionTransitions returns a transition for every hour in series whose condition differs
+// from the last known condition before it.
+func conditionTransitions(source string, series []HourlyForecastJSON) []ConditionTransitionJSON {
+	var transitions []ConditionTransitionJSON
+	previous := conditionUnknown
+	for _, f := range series {
+		if f.ConditionCode == conditionUnknown {
+			continue
+		}
+		if previous != conditionUnknown && f.ConditionCode != previous {
+			transitions = append(transitions, ConditionTransitionJSON{
+				Source:           source,
+				At:               transitionClock(f.ForecastDateTime),
+				ForecastDateTime: f.ForecastDateTime,
+				From:             previous,
+				To:               f.ConditionCode,
+			})
+		}
+		previous = f.ConditionCode
+	}
+	return transitions
+}
+
+// transitionClock extracts the "15:04" part of a "2006-01-02 15:04" forecast time.
+func transitionClock(forecastDateTime string) string {
+	if i := strings.LastIndex(forecastDateTime, " "); i >= 0 {
+		return forecastDateTime[i+1:]
+	}
+	return forecastDateTime
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHourlyTransitions(t *testing.T) {
+	hour := func(source, dateTime, code string) HourlyForecastJSON {
+		return HourlyForecastJSON{SourceAPI: source, ForecastDateTime: dateTime, ConditionCode: code}
+	}
+
+	testCases := []struct {
+		name      string
+		forecasts []HourlyForecastJSON
+		want      []ConditionTransitionJSON
+	}{
+		{
+			name: "No Change",
+			forecasts: []HourlyForecastJSON{
+				hour("a", "2025-06-01 13:00", conditionCloudy),
+				hour("a", "2025-06-01 14:00", conditionCloudy),
+			},
+		},
+		{
+			name: "Per Source And Consensus",
+			forecasts: []HourlyForecastJSON{
+				hour("a", "2025-06-01 13:00", conditionCloudy),
+				hour("b", "2025-06-01 13:00", conditionCloudy),
+				hour("a", "2025-06-01 14:00", conditionRain),
+				hour("b", "2025-06-01 14:00", conditionCloudy),
+				hour("a", "2025-06-01 15:00", conditionRain),
+				hour("b", "2025-06-01 15:00", conditionRain),
+			},
+			want: []ConditionTransitionJSON{
+				{Source: "consensus", At: "14:00", ForecastDateTime: "2025-06-01 14:00", From: conditionCloudy, To: conditionRain},
+				{Source: "a", At: "14:00", ForecastDateTime: "2025-06-01 14:00", From: conditionCloudy, To: conditionRain},
+				{Source: "b", At: "15:00", ForecastDateTime: "2025-06-01 15:00", From: conditionCloudy, To: conditionRain},
+			},
+		},
+		{
+			name: "Consensus Majority",
+			forecasts: []HourlyForecastJSON{
+				hour("a", "2025-06-01 13:00", conditionClear),
+				hour("b", "2025-06-01 13:00", conditionClear),
+				hour("c", "2025-06-01 13:00", conditionClear),
+				hour("a", "2025-06-01 14:00", conditionClear),
+				hour("b", "2025-06-01 14:00", conditionClear),
+				hour("c", "2025-06-01 14:00", conditionThunderstorm),
+			},
+			want: []ConditionTransitionJSON{
+				{Source: "c", At: "14:00", ForecastDateTime: "2025-06-01 14:00", From: conditionClear, To: conditionThunderstorm},
+			},
+		},
+		{
+			name: "Unknown Hours Are Skipped",
+			forecasts: []HourlyForecastJSON{
+				hour("a", "2025-06-01 23:00", conditionCloudy),
+				hour("a", "2025-06-02 00:00", conditionUnknown),
+				hour("a", "2025-06-02 01:00", conditionCloudy),
+				hour("a", "2025-06-02 02:00", conditionSnow),
+			},
+			want: []ConditionTransitionJSON{
+				{Source: "consensus", At: "02:00", ForecastDateTime: "2025-06-02 02:00", From: conditionCloudy, To: conditionSnow},
+				{Source: "a", At: "02:00", ForecastDateTime: "2025-06-02 02:00", From: conditionCloudy, To: conditionSnow},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := hourlyTransitions(tc.forecasts)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("unexpected transitions:\ngot  %+v\nwant %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestConsensusSeriesTieBreak(t *testing.T) {
+	series := consensusSeries([]HourlyForecastJSON{
+		{SourceAPI: "a", ForecastDateTime: "2025-06-01 13:00", ConditionCode: conditionPartlyCloudy},
+		{SourceAPI: "b", ForecastDateTime: "2025-06-01 13:00", ConditionCode: conditionShowers},
+	})
+	if len(series) != 1 || series[0].ConditionCode != conditionShowers {
+		t.Errorf("expected the more severe condition to win a tie, got %+v", series)
+	}
+}
@@ -32,14 +32,23 @@ type Location struct {
 
 // CurrentWeather is the internal model for weather conditions at a specific moment.
 type CurrentWeather struct {
-	Location      Location
-	SourceAPI     string
-	Timestamp     time.Time
-	Temperature   float64
-	Humidity      int32
-	WindSpeed     float64
-	Precipitation float64
-	Condition     string
+	Location            Location
+	SourceAPI           string
+	Timestamp           time.Time
+	Temperature         float64
+	ApparentTemperature *float64 // Computed if the source does not report it.
+	DewPoint            *float64 // Computed if the source does not report it; nil without humidity.
+	Humidity            int32
+	WindSpeed           float64
+	WindDirection       *float64 // Degrees the wind blows from; nil if the source does not report it.
+	WindGust            *float64 // Nil if the source does not report it.
+	Precipitation       float64
+	Rain                *float64 // Liquid water equivalent; nil if the source does not split the precipitation.
+	Snow                *float64 // Liquid water equivalent; nil if the source does not split the precipitation.
+	Condition           string
+	UVIndex             *float64  // Nil if the source does not report it.
+	Sunrise             time.Time // Zero if the source does not report it.
+	Sunset              time.Time // Zero if the source does not report it.
 }
 
 // DailyForecast is the internal model for predicted weather conditions for a full day.
@@ -51,9 +60,28 @@ type DailyForecast struct {
 	MinTemp             float64
 	MaxTemp             float64
 	Precipitation       float64
+	Rain                *float64 // Liquid water equivalent; nil if the source does not split the precipitation.
+	Snow                *float64 // Liquid water equivalent; nil if the source does not split the precipitation.
 	PrecipitationChance int32
 	WindSpeed           float64
+	WindDirection       *float64 // Degrees the wind blows from; nil if the source does not report it.
+	WindGust            *float64 // Nil if the source does not report it.
 	Humidity            int32
+	UVIndex             *float64  // Nil if the source does not report it.
+	Sunrise             time.Time // Zero if the source does not report it.
+	Sunset              time.Time // Zero if the source does not report it.
+	Morning             DayPart   // 06:00 to 12:00 local time.
+	Afternoon           DayPart   // 12:00 to 18:00 local time.
+	Evening             DayPart   // 18:00 to 24:00 local time.
+	Night               DayPart   // 00:00 to 06:00 local time.
+}
+
+// DayPart is the internal model for the predicted weather of a part of a day. Its fields are nil
+// if the source does not break the day down.
+type DayPart struct {
+	Temperature         *float64
+	PrecipitationChance *int32
+	Condition           *string
 }
 
 // HourlyForecast is the internal model for predicted weather conditions for a specific hour.
@@ -63,93 +91,952 @@ type HourlyForecast struct {
 	Timestamp           time.Time
 	ForecastDateTime    time.Time
 	Temperature         float64
+	ApparentTemperature *float64 // Computed if the source does not report it.
+	DewPoint            *float64 // Computed if the source does not report it; nil without humidity.
 	Humidity            int32
 	WindSpeed           float64
+	WindDirection       *float64 // Degrees the wind blows from; nil if the source does not report it.
+	WindGust            *float64 // Nil if the source does not report it.
 	Precipitation       float64
+	Rain                *float64 // Liquid water equivalent; nil if the source does not split the precipitation.
+	Snow                *float64 // Liquid water equivalent; nil if the source does not split the precipitation.
 	PrecipitationChance int32
 	Condition           string
 }
 
+// WeatherWarning is the internal model for a severe weather warning issued for a location.
+type WeatherWarning struct {
+	SourceAPI   string
+	Sender      string
+	Event       string
+	Start       time.Time
+	End         time.Time
+	Description string
+	Tags        []string
+}
+
+// AirQuality is the internal model for the air quality at a location. The scale of AQI depends
+// on the source: the European AQI for Open-Meteo, 1 (good) to 5 (very poor) for OpenWeatherMap.
+// Concentrations are in µg/m³.
+type AirQuality struct {
+	Location  Location
+	SourceAPI string
+	Timestamp time.Time
+	AQI       int32
+	PM25      float64
+	PM10      float64
+	Ozone     float64
+}
+
 // --- API Response DTOs (JSON Models) ---
 
 // CurrentWeatherJSON defines the JSON structure for current weather data in API responses.
+// ObservedAtLocal is the observation time of day in the location's timezone, for display.
+// MinutesSinceObservation is only set in the age comparison mode.
 type CurrentWeatherJSON struct {
-	SourceAPI     string  `json:"source_api"`
-	Timestamp     string  `json:"timestamp"`
-	Temperature   float64 `json:"temperature_c"`
-	Humidity      int32   `json:"humidity"`
-	WindSpeed     float64 `json:"wind_speed_kmh"`
-	Precipitation float64 `json:"precipitation_mm"`
-	Condition     string  `json:"condition_text"`
+	SourceAPI               string      `json:"source_api"`
+	Timestamp               string      `json:"timestamp"`
+	ObservedAtLocal         string      `json:"observed_at_local"`
+	MinutesSinceObservation *int        `json:"minutes_since_observation,omitempty"`
+	Temperature             float64     `json:"temperature_c"`
+	ApparentTemperature     *float64    `json:"apparent_temperature_c,omitempty"`
+	DewPoint                *float64    `json:"dew_point_c,omitempty"`
+	Humidity                int32       `json:"humidity"`
+	WindSpeed               float64     `json:"wind_speed_kmh"`
+	WindDirection           *float64    `json:"wind_direction_deg,omitempty"`
+	WindCompass             string      `json:"wind_direction,omitempty"`
+	WindGust                *float64    `json:"wind_gust_kmh,omitempty"`
+	Precipitation           float64     `json:"precipitation_mm"`
+	Rain                    *float64    `json:"rain_mm,omitempty"`
+	Snow                    *float64    `json:"snow_mm,omitempty"`
+	Condition               string      `json:"condition_text"`
+	ConditionCode           string      `json:"condition_code"`
+	UVIndex                 *float64    `json:"uv_index,omitempty"`
+	Sunrise                 string      `json:"sunrise,omitempty"`
+	Sunset                  string      `json:"sunset,omitempty"`
+	Compact                 CompactJSON `json:"compact"`
 }
 
 // DailyForecastJSON defines the JSON structure for daily forecast data in API responses.
 type DailyForecastJSON struct {
-	SourceAPI           string  `json:"source_api"`
-	ForecastDate        string  `json:"forecast_date"`
-	MinTemp             float64 `json:"min_temp_c"`
-	MaxTemp             float64 `json:"max_temp_c"`
-	Precipitation       float64 `json:"precipitation_mm"`
-	PrecipitationChance int32   `json:"precipitation_chance"`
-	WindSpeed           float64 `json:"wind_speed_kmh"`
-	Humidity            int32   `json:"humidity"`
+	SourceAPI           string       `json:"source_api"`
+	ForecastDate        string       `json:"forecast_date"`
+	MinTemp             float64      `json:"min_temp_c"`
+	MaxTemp             float64      `json:"max_temp_c"`
+	Precipitation       float64      `json:"precipitation_mm"`
+	Rain                *float64     `json:"rain_mm,omitempty"`
+	Snow                *float64     `json:"snow_mm,omitempty"`
+	PrecipitationChance int32        `json:"precipitation_chance"`
+	WindSpeed           float64      `json:"wind_speed_kmh"`
+	WindDirection       *float64     `json:"wind_direction_deg,omitempty"`
+	WindCompass         string       `json:"wind_direction,omitempty"`
+	WindGust            *float64     `json:"wind_gust_kmh,omitempty"`
+	Humidity            int32        `json:"humidity"`
+	ConditionCode       string       `json:"condition_code"`
+	UVIndex             *float64     `json:"uv_index,omitempty"`
+	Sunrise             string       `json:"sunrise,omitempty"`
+	Sunset              string       `json:"sunset,omitempty"`
+	Parts               DayPartsJSON `json:"parts"`
+	Compact             CompactJSON  `json:"compact"`
+}
+
+// DayPartsJSON defines the JSON structure for the parts of the day of a daily forecast.
+type DayPartsJSON struct {
+	Morning   DayPartJSON `json:"morning"`
+	Afternoon DayPartJSON `json:"afternoon"`
+	Evening   DayPartJSON `json:"evening"`
+	Night     DayPartJSON `json:"night"`
+}
+
+// DayPartJSON defines the JSON structure for a part of the day. Fields the source does not report
+// are null.
+type DayPartJSON struct {
+	Temperature         *float64 `json:"temperature_c"`
+	PrecipitationChance *int32   `json:"precipitation_chance"`
+	Condition           *string  `json:"condition"`
 }
 
 // HourlyForecastJSON defines the JSON structure for hourly forecast data in API responses.
 type HourlyForecastJSON struct {
-	SourceAPI           string  `json:"source_api"`
-	ForecastDateTime    string  `json:"forecast_datetime"`
-	Temperature         float64 `json:"temperature_c"`
-	Humidity            int32   `json:"humidity"`
-	WindSpeed           float64 `json:"wind_speed_kmh"`
-	Precipitation       float64 `json:"precipitation_mm"`
-	PrecipitationChance int32   `json:"precipitation_chance"`
-	Condition           string  `json:"condition_text"`
+	SourceAPI           string      `json:"source_api"`
+	ForecastDateTime    string      `json:"forecast_datetime"`
+	Temperature         float64     `json:"temperature_c"`
+	ApparentTemperature *float64    `json:"apparent_temperature_c,omitempty"`
+	DewPoint            *float64    `json:"dew_point_c,omitempty"`
+	Humidity            int32       `json:"humidity"`
+	WindSpeed           float64     `json:"wind_speed_kmh"`
+	WindDirection       *float64    `json:"wind_direction_deg,omitempty"`
+	WindCompass         string      `json:"wind_direction,omitempty"`
+	WindGust            *float64    `json:"wind_gust_kmh,omitempty"`
+	Precipitation       float64     `json:"precipitation_mm"`
+	Rain                *float64    `json:"rain_mm,omitempty"`
+	Snow                *float64    `json:"snow_mm,omitempty"`
+	PrecipitationChance int32       `json:"precipitation_chance"`
+	Condition           string      `json:"condition_text"`
+	ConditionCode       string      `json:"condition_code"`
+	Compact             CompactJSON `json:"compact"`
+}
+
+// CompactJSON is a glanceable rendering of a forecast entry for chat, terminal and smartwatch
+// clients: an emoji and a summary of at most 20 characters, e.g. "🌧️" and "Rain 12°C".
+type CompactJSON struct {
+	Emoji   string `json:"emoji"`
+	Summary string `json:"summary"`
 }
 
 // CurrentWeatherResponse is the top-level JSON structure for the /api/currentweather endpoint.
 type CurrentWeatherResponse struct {
-	Location Location             `json:"location"`
-	Weather  []CurrentWeatherJSON `json:"weather"`
+	Location    Location             `json:"location"`
+	Weather     []CurrentWeatherJSON `json:"weather"`
+	Partial     bool                 `json:"partial"`
+	Providers   []ProviderStatusJSON `json:"providers"`
+	Attribution []AttributionJSON    `json:"attribution,omitempty"`
+}
+
+// RefreshResponse is the JSON structure for the /api/refresh endpoint. Results holds the outcome
+// of each forecast type by scheduler job name.
+type RefreshResponse struct {
+	Location Location          `json:"location"`
+	Results  map[string]string `json:"results"`
+}
+
+// CurrentWeatherBatchResponse is the top-level JSON structure for the /api/currentweather/batch
+// endpoint. Results and Errors are keyed by the requested city names.
+type CurrentWeatherBatchResponse struct {
+	Results map[string]CurrentWeatherResponse `json:"results"`
+	Errors  map[string]string                 `json:"errors,omitempty"`
 }
 
 // DailyForecastsResponse is the top-level JSON structure for the /api/dailyforecast endpoint.
 type DailyForecastsResponse struct {
-	Location  Location            `json:"location"`
-	Forecasts []DailyForecastJSON `json:"forecasts"`
+	Location    Location             `json:"location"`
+	Forecasts   []DailyForecastJSON  `json:"forecasts"`
+	Partial     bool                 `json:"partial"`
+	Providers   []ProviderStatusJSON `json:"providers"`
+	Attribution []AttributionJSON    `json:"attribution,omitempty"`
 }
 
 // HourlyForecastsResponse is the top-level JSON structure for the /api/hourlyforecast endpoint.
 type HourlyForecastsResponse struct {
-	Location  Location             `json:"location"`
-	Forecasts []HourlyForecastJSON `json:"forecasts"`
+	Location    Location                  `json:"location"`
+	Forecasts   []HourlyForecastJSON      `json:"forecasts"`
+	Transitions []ConditionTransitionJSON `json:"transitions"`
+	Partial     bool                      `json:"partial"`
+	Providers   []ProviderStatusJSON      `json:"providers"`
+	Attribution []AttributionJSON         `json:"attribution,omitempty"`
+}
+
+// WeatherWarningsResponse is the top-level JSON structure for the /api/warnings endpoint.
+type WeatherWarningsResponse struct {
+	Location    Location             `json:"location"`
+	Warnings    []WeatherWarningJSON `json:"warnings"`
+	Attribution []AttributionJSON    `json:"attribution,omitempty"`
+}
+
+// WeatherWarningJSON defines the JSON structure for a severe weather warning in API responses.
+// Sender is the issuing agency and Tags the categories of the warning, e.g. "Wind" or "Flood".
+type WeatherWarningJSON struct {
+	SourceAPI   string   `json:"source_api"`
+	Sender      string   `json:"sender"`
+	Event       string   `json:"event"`
+	Start       string   `json:"start"`
+	End         string   `json:"end"`
+	Description string   `json:"description"`
+	Tags        []string `json:"tags"`
+}
+
+// AirQualityResponse is the top-level JSON structure for the /api/airquality endpoint.
+type AirQualityResponse struct {
+	Location    Location          `json:"location"`
+	AirQuality  []AirQualityJSON  `json:"air_quality"`
+	Attribution []AttributionJSON `json:"attribution,omitempty"`
+}
+
+// AirQualityJSON defines the JSON structure for air quality data in API responses. AQIScale names
+// the index AQI is given in, "european_aqi" or "owm"; Category is the band of the index mapped to
+// a common vocabulary, so that the sources can be compared.
+type AirQualityJSON struct {
+	SourceAPI string  `json:"source_api"`
+	Timestamp string  `json:"timestamp"`
+	AQI       int32   `json:"aqi"`
+	AQIScale  string  `json:"aqi_scale"`
+	Category  string  `json:"category"`
+	PM25      float64 `json:"pm2_5_ugm3"`
+	PM10      float64 `json:"pm10_ugm3"`
+	Ozone     float64 `json:"ozone_ugm3"`
+}
+
+// ConditionTransitionJSON describes a change of the condition code between two consecutive
+// forecast hours, e.g. from "cloudy" to "rain" at "14:00". Source is a source API or "consensus".
+type ConditionTransitionJSON struct {
+	Source           string `json:"source"`
+	At               string `json:"at"`
+	ForecastDateTime string `json:"forecast_datetime"`
+	From             string `json:"from"`
+	To               string `json:"to"`
+}
+
+// ConsensusResponse is the top-level JSON structure for the /api/consensus endpoint. Only the
+// entries of the requested period are set. Blend is "median" or "weighted"; Weights are the
+// provider weights of the location, keyed by provider ID, if it has any.
+type ConsensusResponse struct {
+	Location    Location            `json:"location"`
+	Period      string              `json:"period"`
+	Blend       string              `json:"blend"`
+	Weights     map[string]float64  `json:"weights,omitempty"`
+	Hours       []ConsensusHourJSON `json:"hours,omitempty"`
+	Days        []ConsensusDayJSON  `json:"days,omitempty"`
+	Attribution []AttributionJSON   `json:"attribution,omitempty"`
+}
+
+// ConsensusHourJSON is the forecast for one hour merged from the listed sources.
+type ConsensusHourJSON struct {
+	ForecastDateTime    string                 `json:"forecast_datetime"`
+	Sources             []string               `json:"sources"`
+	Temperature         ConsensusValueJSON     `json:"temperature_c"`
+	Humidity            ConsensusValueJSON     `json:"humidity"`
+	WindSpeed           ConsensusValueJSON     `json:"wind_speed_kmh"`
+	Precipitation       ConsensusValueJSON     `json:"precipitation_mm"`
+	PrecipitationChance ConsensusValueJSON     `json:"precipitation_chance"`
+	Condition           ConsensusConditionJSON `json:"condition"`
+}
+
+// ConsensusDayJSON is the forecast for one day merged from the listed sources.
+type ConsensusDayJSON struct {
+	ForecastDate        string                 `json:"forecast_date"`
+	Sources             []string               `json:"sources"`
+	MinTemp             ConsensusValueJSON     `json:"min_temp_c"`
+	MaxTemp             ConsensusValueJSON     `json:"max_temp_c"`
+	Precipitation       ConsensusValueJSON     `json:"precipitation_mm"`
+	PrecipitationChance ConsensusValueJSON     `json:"precipitation_chance"`
+	WindSpeed           ConsensusValueJSON     `json:"wind_speed_kmh"`
+	Humidity            ConsensusValueJSON     `json:"humidity"`
+	Condition           ConsensusConditionJSON `json:"condition"`
+}
+
+// ConsensusValueJSON is a numeric field aggregated over the sources, with the lowest and highest
+// source value and a confidence of "high", "medium" or "low".
+type ConsensusValueJSON struct {
+	Value      float64 `json:"value"`
+	Min        float64 `json:"min"`
+	Max        float64 `json:"max"`
+	Confidence string  `json:"confidence"`
+}
+
+// ConsensusConditionJSON is the condition code reported by most sources. Label names it in the
+// locale of the request. Agreement is the share of the sources with a known condition that
+// reported it.
+type ConsensusConditionJSON struct {
+	Code       string  `json:"code"`
+	Label      string  `json:"label"`
+	Agreement  float64 `json:"agreement"`
+	Confidence string  `json:"confidence"`
+}
+
+// DisagreementResponse defines the JSON structure for the /api/disagreement endpoint. ComputedAt
+// is when the latest report of the location was computed and is empty if there is none yet.
+type DisagreementResponse struct {
+	Location   Location              `json:"location"`
+	ComputedAt string                `json:"computed_at,omitempty"`
+	Days       []DisagreementDayJSON `json:"days"`
+}
+
+// DisagreementDayJSON is how far apart the daily forecasts of the sources are for one date. The
+// spreads are the differences between the highest and the lowest source value.
+type DisagreementDayJSON struct {
+	ForecastDate              string  `json:"forecast_date"`
+	Sources                   int     `json:"sources"`
+	MinTempSpread             float64 `json:"min_temp_spread_c"`
+	MaxTempSpread             float64 `json:"max_temp_spread_c"`
+	PrecipitationSpread       float64 `json:"precipitation_spread_mm"`
+	PrecipitationChanceSpread float64 `json:"precipitation_chance_spread"`
+	WindSpeedSpread           float64 `json:"wind_speed_spread_kmh"`
+	ConditionAgreement        float64 `json:"condition_agreement"`
+	Confidence                string  `json:"confidence"`
+}
+
+// SummaryResponse defines the JSON structure for the /api/summary endpoint. Summary describes the
+// rest of Date, the current day at the location, in Language.
+type SummaryResponse struct {
+	Location    Location          `json:"location"`
+	Date        string            `json:"date"`
+	Language    string            `json:"language"`
+	Summary     string            `json:"summary"`
+	Attribution []AttributionJSON `json:"attribution,omitempty"`
+}
+
+// HistoryResponse is the top-level JSON structure for the /api/history endpoint. Only the entries
+// of the requested type are set. NextCursor is set if there are more entries in the time range.
+type HistoryResponse struct {
+	Location    Location                    `json:"location"`
+	Type        string                      `json:"type"`
+	From        string                      `json:"from"`
+	To          string                      `json:"to"`
+	Weather     []CurrentWeatherJSON        `json:"weather,omitempty"`
+	Hourly      []HistoryHourlyForecastJSON `json:"hourly,omitempty"`
+	Daily       []HistoryDailyForecastJSON  `json:"daily,omitempty"`
+	NextCursor  string                      `json:"next_cursor,omitempty"`
+	Attribution []AttributionJSON           `json:"attribution,omitempty"`
+}
+
+// ImportObservationJSON is an observation in the body of an /admin/import request. Missing
+// measurements are null or omitted.
+type ImportObservationJSON struct {
+	Timestamp     string   `json:"timestamp"`
+	Temperature   *float64 `json:"temperature_c"`
+	Humidity      *int32   `json:"humidity"`
+	WindSpeed     *float64 `json:"wind_speed_kmh"`
+	Precipitation *float64 `json:"precipitation_mm"`
+	Condition     *string  `json:"condition_text"`
+
+	// invalid holds the errors found while decoding a CSV row.
+	invalid []string
+}
+
+// WeatherFlowMessage defines the JSON structure of a WeatherFlow UDP broadcast message forwarded
+// to /api/v1/stations/weatherflow. Obs holds the observations of obs_st messages as arrays of
+// values in the order of the WeatherFlow UDP API; missing values are null.
+type WeatherFlowMessage struct {
+	SerialNumber string       `json:"serial_number"`
+	Type         string       `json:"type"`
+	HubSN        string       `json:"hub_sn,omitempty"`
+	Obs          [][]*float64 `json:"obs,omitempty"`
+}
+
+// StationUploadResponse defines the JSON structure of an accepted personal weather station
+// upload. Stored is zero for messages without readings.
+type StationUploadResponse struct {
+	Location Location `json:"location"`
+	Source   string   `json:"source"`
+	Stored   int      `json:"stored"`
+}
+
+// ImportResponse defines the JSON structure of a successful /admin/import request. From and To
+// are the earliest and latest imported observation times.
+type ImportResponse struct {
+	Location Location `json:"location"`
+	Source   string   `json:"source"`
+	Imported int      `json:"imported"`
+	From     string   `json:"from"`
+	To       string   `json:"to"`
+}
+
+// ImportErrorResponse defines the JSON structure of an /admin/import request that was rejected
+// because of invalid observations. Rows lists the first of them.
+type ImportErrorResponse struct {
+	Error     string           `json:"error"`
+	RequestID string           `json:"request_id,omitempty"`
+	Rows      []ImportRowError `json:"rows"`
+}
+
+// ImportRowError lists the problems of an invalid imported observation. Row counts the
+// observations from 1, without the CSV header.
+type ImportRowError struct {
+	Row    int      `json:"row"`
+	Errors []string `json:"errors"`
+}
+
+// ExportResponse describes the JSON document of the /api/export endpoint. The document is
+// streamed entry by entry rather than encoded from this type, and Entries holds entries of the
+// requested type.
+type ExportResponse struct {
+	Location Location `json:"location"`
+	Type     string   `json:"type"`
+	Entries  []any    `json:"entries"`
+}
+
+// ExportCurrentWeatherJSON is a current weather entry of the /api/export endpoint. Values the
+// provider did not report are null. Archived is false for the observation that is still current.
+type ExportCurrentWeatherJSON struct {
+	SourceAPI     string   `json:"source_api"`
+	ObservedAt    string   `json:"observed_at"`
+	Temperature   *float64 `json:"temperature_c"`
+	Humidity      *int32   `json:"humidity"`
+	WindSpeed     *float64 `json:"wind_speed_kmh"`
+	Precipitation *float64 `json:"precipitation_mm"`
+	Condition     *string  `json:"condition_text"`
+	Archived      bool     `json:"archived"`
+}
+
+// ExportHourlyForecastJSON is an hourly forecast entry of the /api/export endpoint, with the
+// time it was issued at. Archived is false for forecasts that have not been replaced yet.
+type ExportHourlyForecastJSON struct {
+	SourceAPI           string   `json:"source_api"`
+	ForecastDateTime    string   `json:"forecast_datetime"`
+	IssuedAt            string   `json:"issued_at"`
+	Temperature         *float64 `json:"temperature_c"`
+	Humidity            *int32   `json:"humidity"`
+	WindSpeed           *float64 `json:"wind_speed_kmh"`
+	Precipitation       *float64 `json:"precipitation_mm"`
+	PrecipitationChance *int32   `json:"precipitation_chance"`
+	Condition           *string  `json:"condition_text"`
+	Archived            bool     `json:"archived"`
+}
+
+// ExportDailyForecastJSON is a daily forecast entry of the /api/export endpoint, with the time
+// it was issued at. Archived is false for forecasts that have not been replaced yet.
+type ExportDailyForecastJSON struct {
+	SourceAPI           string   `json:"source_api"`
+	ForecastDate        string   `json:"forecast_date"`
+	IssuedAt            string   `json:"issued_at"`
+	MinTemp             *float64 `json:"min_temp_c"`
+	MaxTemp             *float64 `json:"max_temp_c"`
+	Precipitation       *float64 `json:"precipitation_mm"`
+	PrecipitationChance *int32   `json:"precipitation_chance"`
+	WindSpeed           *float64 `json:"wind_speed_kmh"`
+	Humidity            *int32   `json:"humidity"`
+	Archived            bool     `json:"archived"`
+}
+
+// HistoryHourlyForecastJSON is an archived hourly forecast with the time it was issued at.
+type HistoryHourlyForecastJSON struct {
+	HourlyForecastJSON
+	IssuedAt string `json:"issued_at"`
+}
+
+// HistoryDailyForecastJSON is an archived daily forecast with the time it was issued at.
+type HistoryDailyForecastJSON struct {
+	DailyForecastJSON
+	IssuedAt string `json:"issued_at"`
+}
+
+// GridResponse is the top-level JSON structure for the /api/grid endpoint.
+type GridResponse struct {
+	Resolution  float64           `json:"resolution"`
+	Points      []GridPointJSON   `json:"points"`
+	Attribution []AttributionJSON `json:"attribution,omitempty"`
+}
+
+// GridPointJSON holds the current conditions at a single grid point.
+type GridPointJSON struct {
+	Latitude      float64 `json:"latitude"`
+	Longitude     float64 `json:"longitude"`
+	Temperature   float64 `json:"temperature_c"`
+	Precipitation float64 `json:"precipitation_mm"`
+	ObservedAt    string  `json:"observed_at"`
+}
+
+// ProviderStatusJSON reports whether an enabled provider contributed data to a forecast response.
+// Message explains an error status.
+type ProviderStatusJSON struct {
+	Provider    string `json:"provider"`
+	DisplayName string `json:"display_name"`
+	Status      string `json:"status"`
+	Message     string `json:"message,omitempty"`
+}
+
+// AttributionJSON describes the licensing and attribution requirements of a data provider.
+// Clients displaying the provider's data must show Notice and link to the license.
+type AttributionJSON struct {
+	Provider    string `json:"provider"`
+	DisplayName string `json:"display_name"`
+	HomepageURL string `json:"homepage_url"`
+	LicenseName string `json:"license_name"`
+	LicenseURL  string `json:"license_url"`
+	Notice      string `json:"notice"`
+}
+
+// AttributionResponse is the top-level JSON structure for the /api/attribution endpoint.
+type AttributionResponse struct {
+	Providers []AttributionJSON `json:"providers"`
+	Geocoder  *AttributionJSON  `json:"geocoder,omitempty"`
+}
+
+// WatchlistResponse is the top-level JSON structure for listing a subscriber's watchlist.
+type WatchlistResponse struct {
+	Locations []Location `json:"locations"`
+}
+
+// WatchlistUpdateJSON describes a watched location whose weather data changed since the last poll.
+type WatchlistUpdateJSON struct {
+	Location  Location `json:"location"`
+	UpdatedAt string   `json:"updated_at"`
+}
+
+// WatchlistUpdatesResponse is the top-level JSON structure for the /api/watchlist/updates endpoint.
+// Cursor should be passed back on the next poll to receive only newer changes.
+type WatchlistUpdatesResponse struct {
+	Cursor  string                `json:"cursor,omitempty"`
+	Updates []WatchlistUpdateJSON `json:"updates"`
+}
+
+// LocationGroupJSON describes a named group of locations of a subscriber, with its locations in
+// the order they were added.
+type LocationGroupJSON struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	CreatedAt string     `json:"created_at"`
+	Locations []Location `json:"locations"`
+}
+
+// LocationGroupsResponse is the top-level JSON structure for listing a subscriber's location groups.
+type LocationGroupsResponse struct {
+	Groups []LocationGroupJSON `json:"groups"`
+}
+
+// LocationGroupWeatherResponse is the top-level JSON structure for the /api/groups/currentweather
+// endpoint. Errors is keyed by the IDs of the locations whose weather could not be fetched.
+type LocationGroupWeatherResponse struct {
+	Group   LocationGroupJSON        `json:"group"`
+	Results []CurrentWeatherResponse `json:"results"`
+	Errors  map[string]string        `json:"errors,omitempty"`
+}
+
+// UserPreferencesJSON holds the preferences of a user account. Empty values select the server
+// defaults.
+type UserPreferencesJSON struct {
+	Units       string `json:"units"`
+	Language    string `json:"language"`
+	DefaultCity string `json:"default_city"`
+}
+
+// UserJSON describes a user account.
+type UserJSON struct {
+	ID          string              `json:"id"`
+	Email       string              `json:"email"`
+	Preferences UserPreferencesJSON `json:"preferences"`
+	CreatedAt   string              `json:"created_at"`
+}
+
+// UserSessionResponse is the JSON structure returned on registration and login. Token is sent as
+// "Authorization: Bearer <token>" until ExpiresAt.
+type UserSessionResponse struct {
+	Token     string   `json:"token"`
+	ExpiresAt string   `json:"expires_at"`
+	User      UserJSON `json:"user"`
+}
+
+// APIKeyUsageResponse defines the JSON structure for the /api/usage endpoint. Limit and
+// Remaining are null for a key without a daily quota.
+type APIKeyUsageResponse struct {
+	Date      string `json:"date"`
+	Used      int64  `json:"used"`
+	Limit     *int64 `json:"limit"`
+	Remaining *int64 `json:"remaining"`
+	ResetAt   string `json:"reset_at"`
+}
+
+// LocationsResponse is the top-level JSON structure for listing tracked locations.
+type LocationsResponse struct {
+	Locations []Location `json:"locations"`
+}
+
+// LocationMergeResponse reports the location a duplicate was merged into and how many of the
+// duplicate's aliases, watchlist entries, alert rules and group memberships were moved to it.
+type LocationMergeResponse struct {
+	Location                Location `json:"location"`
+	MergedLocationID        string   `json:"merged_location_id"`
+	AliasesMoved            int64    `json:"aliases_moved"`
+	WatchlistEntriesMoved   int64    `json:"watchlist_entries_moved"`
+	AlertSubscriptionsMoved int64    `json:"alert_subscriptions_moved"`
+	GroupMembersMoved       int64    `json:"group_members_moved"`
+}
+
+// LocationAliasesResponse is the top-level JSON structure for listing a location's aliases.
+type LocationAliasesResponse struct {
+	Location Location `json:"location"`
+	Aliases  []string `json:"aliases"`
+}
+
+// LocationProviderWeightsResponse is the top-level JSON structure for a location's provider
+// weights. Weights holds the effective weight of every enabled provider, keyed by provider ID,
+// and Overrides those set for the location.
+type LocationProviderWeightsResponse struct {
+	Location  Location           `json:"location"`
+	Blend     string             `json:"blend"`
+	Weights   map[string]float64 `json:"weights"`
+	Overrides map[string]float64 `json:"overrides"`
+}
+
+// LocationAliasJSON describes a single alias and the location it resolves to.
+type LocationAliasJSON struct {
+	Alias      string `json:"alias"`
+	LocationID string `json:"location_id"`
+}
+
+// AlertSubscriptionJSON describes an alert rule of a subscriber. Triggered reports whether the rule
+// matched at its last evaluation.
+type AlertSubscriptionJSON struct {
+	ID              string  `json:"id"`
+	LocationID      string  `json:"location_id"`
+	Metric          string  `json:"metric"`
+	Operator        string  `json:"operator"`
+	Threshold       float64 `json:"threshold"`
+	Unit            string  `json:"unit"`
+	WindowHours     int     `json:"window_hours"`
+	WebhookURL      string  `json:"webhook_url"`
+	CreatedAt       string  `json:"created_at"`
+	Triggered       bool    `json:"triggered"`
+	LastTriggeredAt string  `json:"last_triggered_at,omitempty"`
+}
+
+// AlertsResponse is the top-level JSON structure for listing a subscriber's alerts.
+type AlertsResponse struct {
+	Alerts []AlertSubscriptionJSON `json:"alerts"`
+}
+
+// AlertMatchJSON is a forecast hour in which an alert rule matched, with the consensus value of
+// the rule's metric.
+type AlertMatchJSON struct {
+	ForecastDateTime string  `json:"forecast_datetime"`
+	Value            float64 `json:"value"`
+}
+
+// AlertWebhookPayload is the body of the POST sent to an alert's webhook URL when the alert triggers.
+type AlertWebhookPayload struct {
+	Event       string                `json:"event"`
+	DeliveryID  string                `json:"delivery_id"`
+	Alert       AlertSubscriptionJSON `json:"alert"`
+	Location    Location              `json:"location"`
+	TriggeredAt string                `json:"triggered_at"`
+	Matches     []AlertMatchJSON      `json:"matches"`
+}
+
+// OpsNotificationPayload is the body of the POST sent to NOTIFY_WEBHOOK_URL when a scheduler job
+// keeps failing for a location or a provider's circuit opens. Summary describes the problem in
+// one line, as sent to Slack.
+type OpsNotificationPayload struct {
+	Event               string `json:"event"`
+	Summary             string `json:"summary"`
+	Time                string `json:"time"`
+	Job                 string `json:"job,omitempty"`
+	LocationID          string `json:"location_id,omitempty"`
+	CityName            string `json:"city_name,omitempty"`
+	Provider            string `json:"provider,omitempty"`
+	ConsecutiveFailures int    `json:"consecutive_failures"`
+	RetryAfter          string `json:"retry_after,omitempty"`
+	Error               string `json:"error,omitempty"`
+}
+
+// SlackMessage is the body of the POST sent to a Slack incoming webhook.
+type SlackMessage struct {
+	Text string `json:"text"`
+}
+
+// DeletionReceiptJSON confirms the deletion of a subscriber's data. Deleted holds the number of
+// deleted records per data category.
+type DeletionReceiptJSON struct {
+	ReceiptID    string           `json:"receipt_id"`
+	SubscriberID string           `json:"subscriber_id"`
+	DeletedAt    string           `json:"deleted_at"`
+	Deleted      map[string]int64 `json:"deleted"`
+	TotalDeleted int64            `json:"total_deleted"`
+}
+
+// EndpointStatsResponse is the top-level JSON structure for the /admin/stats/endpoints endpoint.
+type EndpointStatsResponse struct {
+	Since         string             `json:"since"`
+	TotalRequests int64              `json:"total_requests"`
+	Endpoints     []EndpointStatJSON `json:"endpoints"`
+	Hourly        []HourlyStatJSON   `json:"hourly"`
+}
+
+// EndpointStatJSON holds the number of requests to a single endpoint.
+type EndpointStatJSON struct {
+	Endpoint string `json:"endpoint"`
+	Requests int64  `json:"requests"`
+}
+
+// HourlyStatJSON holds the number of requests to all endpoints in a single hour.
+type HourlyStatJSON struct {
+	Hour     string `json:"hour"`
+	Requests int64  `json:"requests"`
+}
+
+// LocationStatsResponse is the top-level JSON structure for the /admin/stats/locations endpoint.
+type LocationStatsResponse struct {
+	Since     string             `json:"since"`
+	Locations []LocationStatJSON `json:"locations"`
+}
+
+// LocationStatJSON holds the number of requests for a single location.
+type LocationStatJSON struct {
+	LocationID  string `json:"location_id"`
+	CityName    string `json:"city_name"`
+	CountryCode string `json:"country_code"`
+	Requests    int64  `json:"requests"`
+}
+
+// CacheKeysResponse is the top-level JSON structure for the /admin/cache/keys endpoint.
+type CacheKeysResponse struct {
+	Prefixes []CacheKeyPrefixJSON `json:"prefixes"`
+}
+
+// CacheKeyPrefixJSON holds the number of cache keys under a prefix and how many of them are
+// in an outdated format.
+type CacheKeyPrefixJSON struct {
+	Prefix   string `json:"prefix"`
+	Keys     int    `json:"keys"`
+	Outdated int    `json:"outdated"`
+}
+
+// CachePurgeResponse is the top-level JSON structure for the /admin/cache/purge endpoint.
+// LocationID is empty if the entries of all locations were purged.
+type CachePurgeResponse struct {
+	LocationID string                 `json:"location_id,omitempty"`
+	Prefixes   []CachePurgePrefixJSON `json:"prefixes"`
+}
+
+// CachePurgePrefixJSON holds the number of cache keys deleted under a prefix.
+type CachePurgePrefixJSON struct {
+	Prefix  string `json:"prefix"`
+	Deleted int    `json:"deleted"`
+}
+
+// SchedulerRunsResponse is the top-level JSON structure for the /admin/scheduler/runs endpoint.
+type SchedulerRunsResponse struct {
+	LocationID string             `json:"location_id"`
+	CityName   string             `json:"city_name"`
+	Runs       []SchedulerRunJSON `json:"runs"`
+}
+
+// SchedulerRunJSON describes the outcome of one scheduled update of a location from one provider.
+type SchedulerRunJSON struct {
+	JobType      string `json:"job_type"`
+	Provider     string `json:"provider"`
+	StartedAt    string `json:"started_at"`
+	DurationMs   int64  `json:"duration_ms"`
+	RowsWritten  int32  `json:"rows_written"`
+	HoursCovered int32  `json:"hours_covered"`
+	Success      bool   `json:"success"`
+	ErrorClass   string `json:"error_class,omitempty"`
+	Error        string `json:"error,omitempty"`
+}
+
+// JobRunsResponse is the top-level JSON structure for the /admin/jobs endpoint. Runs lists the
+// recent job runs; with a city, LocationID, CityName and Updates describe that location's recent
+// updates instead.
+type JobRunsResponse struct {
+	Runs       []JobRunJSON         `json:"runs,omitempty"`
+	LocationID string               `json:"location_id,omitempty"`
+	CityName   string               `json:"city_name,omitempty"`
+	Updates    []JobRunLocationJSON `json:"updates,omitempty"`
+}
+
+// JobRunJSON describes one run of a scheduler job. FinishedAt and DurationMs are omitted while
+// the run is in progress. Locations is set for the /admin/jobs/{id} endpoint.
+type JobRunJSON struct {
+	ID                 string               `json:"id"`
+	Job                string               `json:"job"`
+	Status             string               `json:"status"`
+	StartedAt          string               `json:"started_at"`
+	FinishedAt         string               `json:"finished_at,omitempty"`
+	DurationMs         int64                `json:"duration_ms,omitempty"`
+	LocationsTotal     int32                `json:"locations_total"`
+	LocationsSucceeded int32                `json:"locations_succeeded"`
+	LocationsFailed    int32                `json:"locations_failed"`
+	LocationsSkipped   int32                `json:"locations_skipped"`
+	Error              string               `json:"error,omitempty"`
+	Locations          []JobRunLocationJSON `json:"locations,omitempty"`
+}
+
+// JobRunLocationJSON describes the queued update of one location within a job run. RunID and Job
+// are set when updates of one location are listed, and CityName when the updates of one run are.
+type JobRunLocationJSON struct {
+	RunID      string `json:"run_id,omitempty"`
+	Job        string `json:"job,omitempty"`
+	LocationID string `json:"location_id"`
+	CityName   string `json:"city_name,omitempty"`
+	Status     string `json:"status"`
+	QueuedAt   string `json:"queued_at"`
+	StartedAt  string `json:"started_at,omitempty"`
+	FinishedAt string `json:"finished_at,omitempty"`
+	DurationMs int64  `json:"duration_ms,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// MigrationsResponse is the top-level JSON structure for the /admin/migrations endpoint. Version
+// is the latest applied migration, and Pending the number of migrations not applied yet.
+type MigrationsResponse struct {
+	Driver     string          `json:"driver"`
+	Version    int64           `json:"version"`
+	Pending    int             `json:"pending"`
+	Migrations []MigrationJSON `json:"migrations"`
+}
+
+// MigrationJSON describes one migration embedded in the binary. AppliedAt is omitted while the
+// migration is pending.
+type MigrationJSON struct {
+	Version   int64  `json:"version"`
+	Name      string `json:"name"`
+	State     string `json:"state"`
+	AppliedAt string `json:"applied_at,omitempty"`
+}
+
+// CostReportResponse is the top-level JSON structure for the /admin/costs endpoint.
+// All monetary values are in USD and all monthly figures extrapolate the observed window to 30 days.
+type CostReportResponse struct {
+	ObservedSince        string             `json:"observed_since"`
+	ObservedHours        float64            `json:"observed_hours"`
+	Operations           int64              `json:"operations"`
+	Providers            []ProviderCostJSON `json:"providers"`
+	Locations            []LocationCostJSON `json:"locations"`
+	TotalObservedCost    float64            `json:"total_observed_cost"`
+	TotalMonthlyEstimate float64            `json:"total_monthly_estimate"`
+	FallbackWhatIf       FallbackWhatIfJSON `json:"fallback_what_if"`
+}
+
+// ProviderCostJSON describes the usage and estimated spend of a single provider. APIVersion is set for
+// providers that negotiate the upstream API version, such as OpenWeatherMap.
+type ProviderCostJSON struct {
+	Provider        string  `json:"provider"`
+	DisplayName     string  `json:"display_name"`
+	Calls           int64   `json:"calls"`
+	Failures        int64   `json:"failures"`
+	CostPerCall     float64 `json:"cost_per_call"`
+	ObservedCost    float64 `json:"observed_cost"`
+	MonthlyEstimate float64 `json:"monthly_estimate"`
+	APIVersion      string  `json:"api_version,omitempty"`
+}
+
+// LocationCostJSON describes the estimated spend attributable to a single location.
+type LocationCostJSON struct {
+	LocationID      uuid.UUID `json:"location_id"`
+	CityName        string    `json:"city_name"`
+	Operations      int64     `json:"operations"`
+	ObservedCost    float64   `json:"observed_cost"`
+	MonthlyEstimate float64   `json:"monthly_estimate"`
+}
+
+// FallbackWhatIfJSON estimates spend if providers were queried one at a time in Order,
+// moving to the next provider only when the previous one failed.
+type FallbackWhatIfJSON struct {
+	Order           []string                   `json:"order"`
+	Providers       []FallbackProviderCostJSON `json:"providers"`
+	MonthlyEstimate float64                    `json:"monthly_estimate"`
+	MonthlySavings  float64                    `json:"monthly_savings"`
+}
+
+// FallbackProviderCostJSON is the projected usage of one provider in the fallback what-if scenario.
+type FallbackProviderCostJSON struct {
+	Provider        string  `json:"provider"`
+	ExpectedCalls   float64 `json:"expected_calls"`
+	MonthlyEstimate float64 `json:"monthly_estimate"`
+}
+
+// SchedulerJobStatus describes the state of a single scheduler job in the /dev/scheduler/jobs response.
+// Timestamps are RFC 3339 in UTC and are omitted if the event has not happened yet.
+type SchedulerJobStatus struct {
+	Name        string `json:"name"`
+	Interval    string `json:"interval"`
+	Paused      bool   `json:"paused"`
+	Running     bool   `json:"running"`
+	LastRun     string `json:"last_run,omitempty"`
+	LastSuccess string `json:"last_success,omitempty"`
+	NextRun     string `json:"next_run,omitempty"`
+	LastError   string `json:"last_error,omitempty"`
+}
+
+// SchedulerEventJSON is a scheduler job event streamed on /ws. Location fields are set for
+// location events, and DurationMs for job_finished. Error is set for failed locations and runs.
+type SchedulerEventJSON struct {
+	Type       string `json:"type"`
+	Job        string `json:"job"`
+	Time       string `json:"time"`
+	LocationID string `json:"location_id,omitempty"`
+	CityName   string `json:"city_name,omitempty"`
+	DurationMs int64  `json:"duration_ms,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// SchedulerStatusResponse is the top-level JSON structure for the /dev/scheduler/jobs endpoint.
+type SchedulerStatusResponse struct {
+	Jobs []SchedulerJobStatus `json:"jobs"`
+}
+
+// TimezoneRepairResponse is the top-level JSON structure for the /admin/timezones/repair endpoint.
+type TimezoneRepairResponse struct {
+	Checked  int `json:"checked"`
+	Repaired int `json:"repaired"`
+}
+
+// ProviderHealthResponse is the top-level JSON structure for the /api/health/providers endpoint.
+// Status is "ok", "degraded" or "down".
+type ProviderHealthResponse struct {
+	Status    string               `json:"status"`
+	Providers []ProviderHealthJSON `json:"providers"`
+}
+
+// ProviderHealthJSON is the circuit breaker state of one provider. OpenedAt and RetryAt are
+// RFC 3339 in UTC and are omitted while the circuit is closed.
+type ProviderHealthJSON struct {
+	Provider            string `json:"provider"`
+	State               string `json:"state"`
+	ConsecutiveFailures int    `json:"consecutive_failures"`
+	OpenedAt            string `json:"opened_at,omitempty"`
+	RetryAt             string `json:"retry_at,omitempty"`
 }
 
 // ErrorResponse standardizes the JSON structure for error messages returned by the API.
 type ErrorResponse struct {
-	Error string `json:"error"`
+	Error     string `json:"error"`
+	RequestID string `json:"request_id,omitempty"`
 }
 
 // ConfigResponse defines the JSON structure for the /api/config endpoint.
+// SuggestedCities are default cities to offer before the user's location is known;
+// SuggestedCountry is the country they were chosen for, or empty for the global defaults.
 type ConfigResponse struct {
-	DevMode         bool   `json:"dev_mode"`
-	CurrentInterval string `json:"current_interval"`
-	HourlyInterval  string `json:"hourly_interval"`
-	DailyInterval   string `json:"daily_interval"`
+	DevMode          bool     `json:"dev_mode"`
+	CurrentInterval  string   `json:"current_interval"`
+	HourlyInterval   string   `json:"hourly_interval"`
+	DailyInterval    string   `json:"daily_interval"`
+	SuggestedCountry string   `json:"suggested_country,omitempty"`
+	SuggestedCities  []string `json:"suggested_cities,omitempty"`
 }
 
 // --- Generic Type Constraints ---
 
 // Forecast is a generic type constraint that allows functions to work with any of the forecast types.
 type Forecast interface {
-	CurrentWeather | []DailyForecast | []HourlyForecast
+	CurrentWeather | []DailyForecast | []HourlyForecast | AirQuality
 }
 
 // apiModel and dbModel are generic type constraints used in the caching and persistence helpers.
 type apiModel interface {
-	CurrentWeather | DailyForecast | HourlyForecast
+	CurrentWeather | DailyForecast | HourlyForecast | AirQuality
 }
 
 type dbModel interface {
-	database.CurrentWeather | database.DailyForecast | database.HourlyForecast
+	database.CurrentWeather | database.DailyForecast | database.HourlyForecast | database.AirQuality
 }
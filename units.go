@@ -0,0 +1,193 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+)
+
+// This file implements imperial units for the current weather and forecast endpoints. Data is
+// stored and cached in metric units; when a request asks for ?units=imperial, or DEFAULT_UNITS
+// makes imperial the default, temperatures are converted to degrees Fahrenheit, wind speeds to
+// miles per hour and precipitation to inches. The unit suffixes of the affected JSON fields are
+// renamed accordingly (temperature_c becomes temperature_f), so a response never carries a
+// value under the name of another unit.
+
+// unitSystem selects the units of measurement in responses.
+type unitSystem int
+
+const (
+	unitsMetric unitSystem = iota
+	unitsImperial
+)
+
+// errInvalidUnits is returned for a units value other than metric or imperial.
+var errInvalidUnits = errors.New("units must be either metric or imperial")
+
+// imperialFieldNames maps the JSON fields holding metric values to their imperial names.
+var imperialFieldNames = map[string]string{
+	"temperature_c":          "temperature_f",
+	"apparent_temperature_c": "apparent_temperature_f",
+	"dew_point_c":            "dew_point_f",
+	"min_temp_c":             "min_temp_f",
+	"max_temp_c":             "max_temp_f",
+	"wind_speed_kmh":         "wind_speed_mph",
+	"wind_gust_kmh":          "wind_gust_mph",
+	"precipitation_mm":       "precipitation_in",
+	"rain_mm":                "rain_in",
+	"snow_mm":                "snow_in",
+}
+
+// parseUnits parses a units value. An empty value selects metric units.
+func parseUnits(raw string) (unitSystem, error) {
+	switch raw {
+	case "", "metric":
+		return unitsMetric, nil
+	case "imperial":
+		return unitsImperial, nil
+	default:
+		return unitsMetric, errInvalidUnits
+	}
+}
+
+func (u unitSystem) String() string {
+	if u == unitsImperial {
+		return "imperial"
+	}
+	return "metric"
+}
+
+// getDefaultUnits reads DEFAULT_UNITS, the units of responses that do not ask for any.
+func getDefaultUnits(logger *slog.Logger) unitSystem {
+	raw := os.Getenv("DEFAULT_UNITS")
+	units, err := parseUnits(raw)
+	if err != nil {
+		logger.Warn("invalid DEFAULT_UNITS value, using metric units", "value", raw)
+		return unitsMetric
+	}
+	return units
+}
+
+// requestUnits returns the units requested by r with the units query parameter, or the
+// configured default.
+func (cfg *apiConfig) requestUnits(r *http.Request) (unitSystem, error) {
+	if !r.URL.Query().Has("units") {
+		if user, ok := userFrom(r.Context()); ok && user.Units != "" {
+			return parseUnits(user.Units)
+		}
+		return cfg.defaultUnits, nil
+	}
+	return parseUnits(r.URL.Query().Get("units"))
+}
+
+// temperature converts a temperature in degrees Celsius, rounded to one decimal in Fahrenheit.
+func (u unitSystem) temperature(c float64) float64 {
+	if u != unitsImperial {
+		return c
+	}
+	return Round(c*9/5+32, 1)
+}
+
+// optionalTemperature converts a temperature in °C that may be missing, like temperature.
+func (u unitSystem) optionalTemperature(c *float64) *float64 {
+	if c == nil {
+		return nil
+	}
+	converted := u.temperature(*c)
+	return &converted
+}
+
+// windSpeed converts a wind speed in km/h, rounded to one decimal in mph.
+func (u unitSystem) windSpeed(kmh float64) float64 {
+	if u != unitsImperial {
+		return kmh
+	}
+	return Round(kmh/1.609344, 1)
+}
+
+// windGust converts a gust speed in km/h that may be missing, like windSpeed.
+func (u unitSystem) windGust(kmh *float64) *float64 {
+	if kmh == nil {
+		return nil
+	}
+	converted := u.windSpeed(*kmh)
+	return &converted
+}
+
+// precipitation converts a precipitation amount in millimeters, rounded to two decimals in
+// inches.
+func (u unitSystem) precipitation(mm float64) float64 {
+	if u != unitsImperial {
+		return mm
+	}
+	return Round(mm/25.4, 2)
+}
+
+// optionalPrecipitation converts a precipitation amount in millimeters that may be missing, like
+// precipitation.
+func (u unitSystem) optionalPrecipitation(mm *float64) *float64 {
+	if mm == nil {
+		return nil
+	}
+	converted := u.precipitation(*mm)
+	return &converted
+}
+
+// temperatureSymbol returns the unit symbol of temperatures, e.g. for compact summaries.
+func (u unitSystem) temperatureSymbol() string {
+	if u == unitsImperial {
+		return "°F"
+	}
+	return "°C"
+}
+
+// windSpeedSymbol returns the unit symbol of wind speeds.
+func (u unitSystem) windSpeedSymbol() string {
+	if u == unitsImperial {
+		return "mph"
+	}
+	return "km/h"
+}
+
+// precipitationSymbol returns the unit symbol of precipitation amounts.
+func (u unitSystem) precipitationSymbol() string {
+	if u == unitsImperial {
+		return "in"
+	}
+	return "mm"
+}
+
+// withUnits prepares a response for respondWithJSON. Metric responses are returned unchanged;
+// imperial responses are wrapped so that their unit-suffixed field names are renamed.
+func withUnits(payload any, units unitSystem) any {
+	if units != unitsImperial {
+		return payload
+	}
+	return imperialResponse{payload: payload}
+}
+
+// imperialResponse marshals a response whose values were converted to imperial units with the
+// imperial field names.
+type imperialResponse struct {
+	payload any
+}
+
+func (ir imperialResponse) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(ir.payload)
+	if err != nil {
+		return nil, err
+	}
+	data, err = rewriteKeys(data, func(key string) string {
+		if name, ok := imperialFieldNames[key]; ok {
+			return name
+		}
+		return key
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not rename unit fields: %w", err)
+	}
+	return data, nil
+}
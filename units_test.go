@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cor0nius/willitrain/internal/database"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestParseUnits(t *testing.T) {
+	testCases := []struct {
+		raw     string
+		want    unitSystem
+		wantErr bool
+	}{
+		{raw: "", want: unitsMetric},
+		{raw: "metric", want: unitsMetric},
+		{raw: "imperial", want: unitsImperial},
+		{raw: "Imperial", wantErr: true},
+		{raw: "us", wantErr: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.raw, func(t *testing.T) {
+			got, err := parseUnits(tc.raw)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("expected error: %v, got: %v", tc.wantErr, err)
+			}
+			if !tc.wantErr && got != tc.want {
+				t.Errorf("parseUnits(%q) = %v, want %v", tc.raw, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestGetDefaultUnits(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	testCases := map[string]unitSystem{"": unitsMetric, "metric": unitsMetric, "imperial": unitsImperial, "kelvin": unitsMetric}
+	for value, want := range testCases {
+		t.Run(value, func(t *testing.T) {
+			t.Setenv("DEFAULT_UNITS", value)
+			if got := getDefaultUnits(logger); got != want {
+				t.Errorf("getDefaultUnits() = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestUnitConversions(t *testing.T) {
+	testCases := []struct {
+		name    string
+		convert func(unitSystem, float64) float64
+		in      float64
+		want    float64
+	}{
+		{name: "freezing", convert: unitSystem.temperature, in: 0, want: 32},
+		{name: "negative temperature", convert: unitSystem.temperature, in: -40, want: -40},
+		{name: "temperature rounded to one decimal", convert: unitSystem.temperature, in: 12.3, want: 54.1},
+		{name: "wind speed", convert: unitSystem.windSpeed, in: 100, want: 62.1},
+		{name: "slow wind speed", convert: unitSystem.windSpeed, in: 5, want: 3.1},
+		{name: "precipitation rounded to two decimals", convert: unitSystem.precipitation, in: 0.2, want: 0.01},
+		{name: "heavy precipitation", convert: unitSystem.precipitation, in: 25.4, want: 1},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.convert(unitsImperial, tc.in); got != tc.want {
+				t.Errorf("imperial: got %v, want %v", got, tc.want)
+			}
+			if got := tc.convert(unitsMetric, tc.in); got != tc.in {
+				t.Errorf("metric: got %v, want the unchanged value %v", got, tc.in)
+			}
+		})
+	}
+}
+
+func TestWithUnits(t *testing.T) {
+	gust, evening := 11.8, 50.0
+	response := DailyForecastsResponse{
+		Location: Location{CityName: "Wroclaw"},
+		Forecasts: []DailyForecastJSON{{SourceAPI: "test1", MinTemp: 41, MaxTemp: 59, Precipitation: 0.04, WindSpeed: 6.2, WindGust: &gust,
+			Parts: DayPartsJSON{Evening: DayPartJSON{Temperature: &evening}}}},
+	}
+
+	metric, err := json.Marshal(withUnits(response, unitsMetric))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(string(metric), `"min_temp_c":41`) {
+		t.Errorf("expected metric field names, got %s", metric)
+	}
+
+	imperial, err := json.Marshal(withUnits(response, unitsImperial))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `"min_temp_f":41,"max_temp_f":59,"precipitation_in":0.04,"precipitation_chance":0,"wind_speed_mph":6.2,"wind_gust_mph":11.8`
+	if !strings.Contains(string(imperial), want) || !strings.Contains(string(imperial), `"city_name":"Wroclaw"`) {
+		t.Errorf("got %s, want it to contain %s", imperial, want)
+	}
+	if !strings.Contains(string(imperial), `"evening":{"temperature_f":50,`) {
+		t.Errorf("expected the day parts to be renamed, got %s", imperial)
+	}
+}
+
+func TestHandlersUnits(t *testing.T) {
+	handlers := []struct {
+		name    string
+		handler func(cfg *apiConfig) http.HandlerFunc
+		want    []string
+	}{
+		{
+			name:    "current weather",
+			handler: func(cfg *apiConfig) http.HandlerFunc { return cfg.handlerCurrentWeather },
+			want:    []string{`"temperature_f":50,"humidity":50,"wind_speed_mph":3.1,"precipitation_in":0,`, `"temperature_f":53.6,"humidity":52,"wind_speed_mph":4.3,"precipitation_in":0.01,`, `"summary":"Clear 50°F"`},
+		},
+		{
+			name:    "daily forecast",
+			handler: func(cfg *apiConfig) http.HandlerFunc { return cfg.handlerDailyForecast },
+			want:    []string{`"min_temp_f":41,"max_temp_f":59,"precipitation_in":0.04,"precipitation_chance":50,"wind_speed_mph":6.2`, `"summary":"Rain 41/59°F"`},
+		},
+		{
+			name:    "hourly forecast",
+			handler: func(cfg *apiConfig) http.HandlerFunc { return cfg.handlerHourlyForecast },
+			want:    []string{`"temperature_f":50,"humidity":50,"wind_speed_mph":3.1,`},
+		},
+	}
+
+	for _, h := range handlers {
+		for _, tc := range []struct {
+			name         string
+			query        string
+			defaultUnits unitSystem
+			wantStatus   int
+			wantImperial bool
+		}{
+			{name: "query parameter", query: "&units=imperial", wantStatus: http.StatusOK, wantImperial: true},
+			{name: "configured default", defaultUnits: unitsImperial, wantStatus: http.StatusOK, wantImperial: true},
+			{name: "query overrides default", query: "&units=metric", defaultUnits: unitsImperial, wantStatus: http.StatusOK},
+			{name: "invalid", query: "&units=kelvin", wantStatus: http.StatusBadRequest},
+		} {
+			t.Run(h.name+" "+tc.name, func(t *testing.T) {
+				testCfg := newTestAPIConfig(t)
+				testCfg.apiConfig.enabledSources = map[string]bool{"gmp": true, "owm": true, "ometeo": true}
+				testCfg.apiConfig.defaultUnits = tc.defaultUnits
+				testCfg.mockDB.GetLocationByAliasFunc = func(ctx context.Context, alias string) (database.Location, error) {
+					return MockDBLocation, nil
+				}
+				testCfg.mockCache.GetFunc = func(ctx context.Context, key string) (string, error) {
+					return "", redis.Nil
+				}
+				testCfg.mockCache.SetFunc = func(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+					return nil
+				}
+				testCfg.mockDB.GetCurrentWeatherAtLocationFunc = func(ctx context.Context, locationID uuid.UUID) ([]database.CurrentWeather, error) {
+					return []database.CurrentWeather{MockDBCurrentWeather1, MockDBCurrentWeather2, MockDBCurrentWeather3}, nil
+				}
+				testCfg.mockDB.GetUpcomingDailyForecastsAtLocationFunc = func(ctx context.Context, arg database.GetUpcomingDailyForecastsAtLocationParams) ([]database.DailyForecast, error) {
+					return []database.DailyForecast{MockDBDailyForecast1, MockDBDailyForecast2, MockDBDailyForecast3}, nil
+				}
+				testCfg.mockDB.GetUpcomingHourlyForecastsAtLocationFunc = func(ctx context.Context, arg database.GetUpcomingHourlyForecastsAtLocationParams) ([]database.HourlyForecast, error) {
+					return []database.HourlyForecast{MockDBHourlyForecast1, MockDBHourlyForecast2, MockDBHourlyForecast3}, nil
+				}
+
+				req := httptest.NewRequest(http.MethodGet, "/?city=wroclaw"+tc.query, nil)
+				rr := httptest.NewRecorder()
+				h.handler(testCfg.apiConfig)(rr, req)
+
+				if rr.Code != tc.wantStatus {
+					t.Fatalf("status = %d, want %d; body: %s", rr.Code, tc.wantStatus, rr.Body.String())
+				}
+				body := rr.Body.String()
+				if tc.wantStatus != http.StatusOK {
+					if body != `{"error":"units must be either metric or imperial"}` {
+						t.Errorf("unexpected body: %s", body)
+					}
+					return
+				}
+				for _, want := range h.want {
+					if strings.Contains(body, want) != tc.wantImperial {
+						t.Errorf("body = %s, want it to contain %s: %v", body, want, tc.wantImperial)
+					}
+				}
+				if strings.Contains(body, "_kmh") == tc.wantImperial {
+					t.Errorf("unexpected field names in %s", body)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/cor0nius/willitrain/internal/database"
+	"github.com/google/uuid"
+)
+
+// This file implements the tracking of calls made to the weather providers. Calls, failures and
+// fetch operations are counted in memory per location, bucketed by hour, and periodically added
+// to the provider_usage_stats and location_fetch_stats tables by a scheduler job. The persisted
+// counts are the input for the cost report served by /admin/costs, so the report extrapolates
+// from usage that survives restarts rather than from the lifetime of the process.
+
+const (
+	providerUsageJobName       = "provider usage"
+	providerUsageFlushInterval = 5 * time.Minute
+)
+
+// providerUsageTracker accumulates provider calls, failures and fetch operations until they are
+// flushed to the database. A nil tracker is valid and records nothing. Locations without an ID
+// have not been stored and are not tracked.
+type providerUsageTracker struct {
+	mu         sync.Mutex
+	providers  map[providerUsageKey]providerUsage
+	operations map[locationFetchKey]int64
+}
+
+type providerUsageKey struct {
+	hour       time.Time
+	locationID uuid.UUID
+	provider   string
+}
+
+type locationFetchKey struct {
+	hour       time.Time
+	locationID uuid.UUID
+}
+
+// providerUsage holds the counters for a single provider.
+type providerUsage struct {
+	Calls    int64
+	Failures int64
+}
+
+// locationUsage holds the counters for a single location.
+type locationUsage struct {
+	CityName   string
+	Operations int64
+	Calls      map[string]int64 // Keyed by provider ID.
+}
+
+// usageSnapshot holds the provider usage recorded from StartedAt to TakenAt.
+type usageSnapshot struct {
+	StartedAt  time.Time
+	TakenAt    time.Time
+	Operations int64
+	Providers  map[string]providerUsage
+	Locations  map[uuid.UUID]locationUsage
+}
+
+func newProviderUsageTracker() *providerUsageTracker {
+	return &providerUsageTracker{
+		providers:  make(map[providerUsageKey]providerUsage),
+		operations: make(map[locationFetchKey]int64),
+	}
+}
+
+// recordOperation counts one fan-out fetch for a location, i.e. one call to processForecastRequests.
+func (u *providerUsageTracker) recordOperation(location Location) {
+	if u == nil || location.LocationID == uuid.Nil {
+		return
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.operations[locationFetchKey{hour: statsHour(time.Now()), locationID: location.LocationID}]++
+}
+
+// recordCall counts one request sent to a provider on behalf of a location.
+func (u *providerUsageTracker) recordCall(location Location, providerID string) {
+	u.add(location, providerID, providerUsage{Calls: 1})
+}
+
+// recordFailure counts one failed request to a provider on behalf of a location.
+func (u *providerUsageTracker) recordFailure(location Location, providerID string) {
+	u.add(location, providerID, providerUsage{Failures: 1})
+}
+
+func (u *providerUsageTracker) add(location Location, providerID string, usage providerUsage) {
+	if u == nil || location.LocationID == uuid.Nil {
+		return
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	key := providerUsageKey{hour: statsHour(time.Now()), locationID: location.LocationID, provider: providerID}
+	p := u.providers[key]
+	p.Calls += usage.Calls
+	p.Failures += usage.Failures
+	u.providers[key] = p
+}
+
+// drain returns the accumulated counts and resets the tracker.
+func (u *providerUsageTracker) drain() (map[providerUsageKey]providerUsage, map[locationFetchKey]int64) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	providers, operations := u.providers, u.operations
+	u.providers = make(map[providerUsageKey]providerUsage)
+	u.operations = make(map[locationFetchKey]int64)
+	return providers, operations
+}
+
+// restore adds counts that could not be flushed back to the tracker, so they are retried on the
+// next flush.
+func (u *providerUsageTracker) restore(providers map[providerUsageKey]providerUsage, operations map[locationFetchKey]int64) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	for k, v := range providers {
+		p := u.providers[k]
+		p.Calls += v.Calls
+		p.Failures += v.Failures
+		u.providers[k] = p
+	}
+	for k, v := range operations {
+		u.operations[k] += v
+	}
+}
+
+// flushProviderUsage adds the accumulated counts to the database. Counts that fail to be written
+// are kept in memory for the next flush.
+func (cfg *apiConfig) flushProviderUsage(ctx context.Context) error {
+	if cfg.usage == nil {
+		return nil
+	}
+	providers, operations := cfg.usage.drain()
+	if len(providers) == 0 && len(operations) == 0 {
+		return nil
+	}
+
+	var firstErr error
+	for k, usage := range providers {
+		err := cfg.dbQueries.IncrementProviderUsageStats(ctx, database.IncrementProviderUsageStatsParams{
+			Hour:         k.hour,
+			LocationID:   k.locationID,
+			Provider:     k.provider,
+			CallCount:    usage.Calls,
+			FailureCount: usage.Failures,
+		})
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		delete(providers, k)
+	}
+	for k, count := range operations {
+		err := cfg.dbQueries.IncrementLocationFetchStats(ctx, database.IncrementLocationFetchStatsParams{
+			Hour:           k.hour,
+			LocationID:     k.locationID,
+			OperationCount: count,
+		})
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		delete(operations, k)
+	}
+
+	if firstErr != nil {
+		cfg.usage.restore(providers, operations)
+		return fmt.Errorf("could not flush provider usage: %w", firstErr)
+	}
+	cfg.logger.Debug("provider usage flushed")
+	return nil
+}
+
+// providerUsageJob returns the scheduler job that periodically flushes the provider usage.
+func (cfg *apiConfig) providerUsageJob() SchedulerJob {
+	return SchedulerJob{
+		Name:     providerUsageJobName,
+		Interval: providerUsageFlushInterval,
+		Run: func(ctx context.Context) error {
+			return cfg.flushProviderUsage(ctx)
+		},
+	}
+}
+
+// loadUsageSnapshot reads the provider usage recorded since the given hour. The snapshot starts
+// at the first hour with recorded usage, so that a window reaching back before the first
+// recorded fetch is not extrapolated as if it had been idle.
+func (cfg *apiConfig) loadUsageSnapshot(ctx context.Context, since, now time.Time) (usageSnapshot, error) {
+	snap := usageSnapshot{
+		StartedAt: now,
+		TakenAt:   now,
+		Providers: make(map[string]providerUsage),
+		Locations: make(map[uuid.UUID]locationUsage),
+	}
+	first, err := cfg.dbQueries.GetFirstProviderUsageHourSince(ctx, since)
+	if errors.Is(err, sql.ErrNoRows) {
+		return snap, nil
+	}
+	if err != nil {
+		return snap, err
+	}
+	snap.StartedAt = first
+
+	fetches, err := cfg.dbQueries.GetLocationFetchesSince(ctx, since)
+	if err != nil {
+		return snap, err
+	}
+	for _, row := range fetches {
+		snap.Operations += row.OperationCount
+		snap.Locations[row.ID] = locationUsage{CityName: row.CityName, Operations: row.OperationCount, Calls: make(map[string]int64)}
+	}
+
+	usage, err := cfg.dbQueries.GetProviderUsageSince(ctx, since)
+	if err != nil {
+		return snap, err
+	}
+	for _, row := range usage {
+		p := snap.Providers[row.Provider]
+		p.Calls += row.CallCount
+		p.Failures += row.FailureCount
+		snap.Providers[row.Provider] = p
+
+		l, ok := snap.Locations[row.ID]
+		if !ok {
+			l = locationUsage{CityName: row.CityName, Calls: make(map[string]int64)}
+		}
+		l.Calls[row.Provider] += row.CallCount
+		snap.Locations[row.ID] = l
+	}
+	return snap, nil
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cor0nius/willitrain/internal/database"
+	"github.com/google/uuid"
+)
+
+func TestFlushProviderUsage(t *testing.T) {
+	testCases := []struct {
+		name          string
+		usageErr      error
+		wantErr       bool
+		wantRemaining int
+	}{
+		{name: "Success", wantRemaining: 0},
+		{name: "Failed Rows Are Kept", usageErr: errors.New("db error"), wantErr: true, wantRemaining: 1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testCfg := newTestAPIConfig(t)
+			testCfg.usage = newProviderUsageTracker()
+			testCfg.usage.recordOperation(MockLocation)
+			testCfg.usage.recordCall(MockLocation, "owm")
+			testCfg.usage.recordCall(MockLocation, "owm")
+			testCfg.usage.recordFailure(MockLocation, "owm")
+			// Locations that have not been stored are not tracked.
+			testCfg.usage.recordCall(Location{CityName: "Atlantis"}, "owm")
+
+			var gotUsage []database.IncrementProviderUsageStatsParams
+			testCfg.mockDB.IncrementProviderUsageStatsFunc = func(ctx context.Context, arg database.IncrementProviderUsageStatsParams) error {
+				gotUsage = append(gotUsage, arg)
+				return tc.usageErr
+			}
+			var gotFetches database.IncrementLocationFetchStatsParams
+			testCfg.mockDB.IncrementLocationFetchStatsFunc = func(ctx context.Context, arg database.IncrementLocationFetchStatsParams) error {
+				gotFetches = arg
+				return nil
+			}
+
+			err := testCfg.flushProviderUsage(context.Background())
+
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("expected error: %v, got: %v", tc.wantErr, err)
+			}
+			if len(gotUsage) != 1 || gotUsage[0].LocationID != MockLocation.LocationID || gotUsage[0].CallCount != 2 || gotUsage[0].FailureCount != 1 {
+				t.Errorf("unexpected provider usage increments: %+v", gotUsage)
+			}
+			if !gotUsage[0].Hour.Equal(statsHour(gotUsage[0].Hour)) {
+				t.Errorf("expected usage bucketed by hour, got %v", gotUsage[0].Hour)
+			}
+			if gotFetches.LocationID != MockLocation.LocationID || gotFetches.OperationCount != 1 {
+				t.Errorf("unexpected fetch increment: %+v", gotFetches)
+			}
+			providers, operations := testCfg.usage.drain()
+			if len(providers) != tc.wantRemaining || len(operations) != 0 {
+				t.Errorf("expected %d unflushed provider rows and no fetch rows, got %d and %d", tc.wantRemaining, len(providers), len(operations))
+			}
+		})
+	}
+}
+
+func TestLoadUsageSnapshot(t *testing.T) {
+	now := time.Date(2025, 6, 8, 12, 30, 0, 0, time.UTC)
+	first := time.Date(2025, 6, 7, 12, 0, 0, 0, time.UTC)
+	other := uuid.New()
+
+	testCfg := newTestAPIConfig(t)
+	testCfg.mockDB.GetFirstProviderUsageHourSinceFunc = func(ctx context.Context, hour time.Time) (time.Time, error) {
+		return first, nil
+	}
+	testCfg.mockDB.GetLocationFetchesSinceFunc = func(ctx context.Context, hour time.Time) ([]database.GetLocationFetchesSinceRow, error) {
+		return []database.GetLocationFetchesSinceRow{{ID: MockLocation.LocationID, CityName: "Wroclaw", OperationCount: 4}}, nil
+	}
+	testCfg.mockDB.GetProviderUsageSinceFunc = func(ctx context.Context, hour time.Time) ([]database.GetProviderUsageSinceRow, error) {
+		return []database.GetProviderUsageSinceRow{
+			{ID: MockLocation.LocationID, CityName: "Wroclaw", Provider: "gmp", CallCount: 4},
+			{ID: MockLocation.LocationID, CityName: "Wroclaw", Provider: "owm", CallCount: 4, FailureCount: 1},
+			{ID: other, CityName: "Warsaw", Provider: "owm", CallCount: 1},
+		}, nil
+	}
+
+	snap, err := testCfg.loadUsageSnapshot(context.Background(), now.Add(-7*24*time.Hour), now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !snap.StartedAt.Equal(first) || !snap.TakenAt.Equal(now) || snap.Operations != 4 {
+		t.Errorf("unexpected snapshot window: %+v", snap)
+	}
+	if got := snap.Providers["owm"]; got.Calls != 5 || got.Failures != 1 {
+		t.Errorf("unexpected owm usage: %+v", got)
+	}
+	if got := snap.Locations[other]; got.CityName != "Warsaw" || got.Operations != 0 || got.Calls["owm"] != 1 {
+		t.Errorf("unexpected usage of a location without fetches: %+v", got)
+	}
+
+	// Without recorded usage, the snapshot is empty.
+	testCfg.mockDB.GetFirstProviderUsageHourSinceFunc = func(ctx context.Context, hour time.Time) (time.Time, error) {
+		return time.Time{}, sql.ErrNoRows
+	}
+	snap, err = testCfg.loadUsageSnapshot(context.Background(), now.Add(-time.Hour), now)
+	if err != nil || snap.Operations != 0 || !snap.StartedAt.Equal(now) {
+		t.Errorf("expected an empty snapshot, got %+v, %v", snap, err)
+	}
+}
@@ -0,0 +1,459 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/mail"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/cor0nius/willitrain/internal/database"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// This file implements the optional user accounts. With USER_JWT_SECRET set, clients register
+// and log in with an email address and a password, of which only a bcrypt hash is stored, and
+// receive a session token: a JWT signed with HS256 that expires after USER_SESSION_HOURS.
+// Requests sending the token as "Authorization: Bearer <token>" are made on behalf of the user.
+// The alerts, groups and watchlist endpoints are then scoped to the user instead of the
+// X-API-Key or X-Device-ID subscriber, and the user's preferred units, language and default city
+// apply to requests that do not set them. Without the secret, the user endpoints respond with
+// 404 and the Authorization header is ignored.
+
+const (
+	defaultUserSessionHours = 7 * 24
+	// minPasswordLength and maxPasswordLength bound passwords; bcrypt ignores bytes beyond 72.
+	minPasswordLength = 8
+	maxPasswordLength = 72
+	// maxDefaultCityLength is the maximum length of a default city, in characters.
+	maxDefaultCityLength = 100
+)
+
+var (
+	errUsersDisabled     = errors.New("user accounts are not enabled")
+	errInvalidSession    = errors.New("invalid or expired session")
+	errSessionRequired   = errors.New("a session token is required")
+	errInvalidCredential = errors.New("invalid email or password")
+)
+
+// dummyPasswordHash is compared against when a login names an unknown email address, so that
+// the response takes as long as for a wrong password and does not reveal which addresses are
+// registered.
+var dummyPasswordHash = sync.OnceValue(func() []byte {
+	hash, _ := bcrypt.GenerateFromPassword([]byte(uuid.NewString()), bcrypt.DefaultCost)
+	return hash
+})
+
+// getUserSessionTTL reads USER_SESSION_HOURS, the lifetime of session tokens.
+func getUserSessionTTL(logger *slog.Logger) time.Duration {
+	hours := getEnvAsInt("USER_SESSION_HOURS", defaultUserSessionHours, logger)
+	if hours <= 0 {
+		logger.Warn("USER_SESSION_HOURS must be positive, using default", "value", hours)
+		hours = defaultUserSessionHours
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+// userSubscriberID returns the subscriber ID of a user account.
+func userSubscriberID(id uuid.UUID) string {
+	return "user:" + id.String()
+}
+
+type userKey struct{}
+
+// requestUser holds the user a request is made on behalf of. userContextMiddleware places an
+// empty one in the context of every request and userSessionMiddleware fills it in, so that the
+// request is not replaced below the middlewares that read the pattern the mux records on it.
+type requestUser struct {
+	user database.User
+	ok   bool
+}
+
+// withUser returns a copy of ctx carrying the user a request is made on behalf of.
+func withUser(ctx context.Context, user database.User) context.Context {
+	return context.WithValue(ctx, userKey{}, &requestUser{user: user, ok: true})
+}
+
+// userFrom returns the user carried by ctx, if any.
+func userFrom(ctx context.Context) (database.User, bool) {
+	holder, ok := ctx.Value(userKey{}).(*requestUser)
+	if !ok {
+		return database.User{}, false
+	}
+	return holder.user, holder.ok
+}
+
+// userContextMiddleware places an empty user holder in the context of every request. It wraps
+// the metrics, request statistics and tracing middlewares.
+func userContextMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), userKey{}, &requestUser{})))
+	})
+}
+
+// sessionClaims are the claims of a session token.
+type sessionClaims struct {
+	Subject   string `json:"sub"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// sessionTokenHeader is the encoded JOSE header of every session token.
+var sessionTokenHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// signSessionToken returns a session token for a user, issued at now and valid for ttl.
+func signSessionToken(secret string, userID uuid.UUID, now time.Time, ttl time.Duration) (string, error) {
+	claims, err := json.Marshal(sessionClaims{
+		Subject:   userID.String(),
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(ttl).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+	signingInput := sessionTokenHeader + "." + base64.RawURLEncoding.EncodeToString(claims)
+	return signingInput + "." + sessionTokenSignature(secret, signingInput), nil
+}
+
+// parseSessionToken verifies a session token and returns the ID of its user. Tokens with another
+// header, a wrong signature or that expired before now are rejected.
+func parseSessionToken(secret, token string, now time.Time) (uuid.UUID, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] != sessionTokenHeader {
+		return uuid.Nil, errInvalidSession
+	}
+	want := sessionTokenSignature(secret, parts[0]+"."+parts[1])
+	if !hmac.Equal([]byte(parts[2]), []byte(want)) {
+		return uuid.Nil, errInvalidSession
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return uuid.Nil, errInvalidSession
+	}
+	var claims sessionClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return uuid.Nil, errInvalidSession
+	}
+	if now.Unix() >= claims.ExpiresAt {
+		return uuid.Nil, errInvalidSession
+	}
+	userID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return uuid.Nil, errInvalidSession
+	}
+	return userID, nil
+}
+
+// sessionTokenSignature returns the encoded HMAC-SHA256 signature of a token.
+func sessionTokenSignature(secret, signingInput string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(signingInput))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// userSessionMiddleware attaches the user of the session token in the Authorization header to
+// the request context, filling in the holder placed there by userContextMiddleware. Requests
+// with an invalid or expired token are rejected, and requests without one are passed on
+// unchanged. It does nothing while user accounts are disabled.
+func (cfg *apiConfig) userSessionMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		secret := cfg.userJWTSecret.Get()
+		if secret == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Add("Vary", "Authorization")
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		userID, err := parseSessionToken(secret, strings.TrimSpace(token), time.Now())
+		if err != nil {
+			cfg.respondWithError(w, http.StatusUnauthorized, err.Error(), nil)
+			return
+		}
+		user, err := cfg.dbQueries.GetUserByID(r.Context(), userID)
+		if err == sql.ErrNoRows {
+			cfg.respondWithError(w, http.StatusUnauthorized, errInvalidSession.Error(), nil)
+			return
+		}
+		if err != nil {
+			cfg.respondWithError(w, http.StatusInternalServerError, "Failed to verify session", err)
+			return
+		}
+		if holder, ok := r.Context().Value(userKey{}).(*requestUser); ok {
+			holder.user, holder.ok = user, true
+			next.ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(withUser(r.Context(), user)))
+	})
+}
+
+// UserCredentialsRequest is the body of a registration or login request.
+type UserCredentialsRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// decodeCredentials decodes the credentials in the body of a request and normalizes the email
+// address.
+func decodeCredentials(w http.ResponseWriter, r *http.Request) (UserCredentialsRequest, error) {
+	var req UserCredentialsRequest
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4<<10))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		return req, err
+	}
+	req.Email = strings.ToLower(strings.TrimSpace(req.Email))
+	return req, nil
+}
+
+// validate checks the email address and the password of a registration.
+func (req UserCredentialsRequest) validate() error {
+	if addr, err := mail.ParseAddress(req.Email); err != nil || addr.Address != req.Email {
+		return fmt.Errorf("email must be a valid email address")
+	}
+	if len(req.Password) < minPasswordLength || len(req.Password) > maxPasswordLength {
+		return fmt.Errorf("password must be between %d and %d bytes long", minPasswordLength, maxPasswordLength)
+	}
+	return nil
+}
+
+// @Summary      Register a user account
+// @Description  Creates a user account with the email address and password in the body and returns a session token.
+// @Description  Only available with USER_JWT_SECRET set.
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Param        request  body      UserCredentialsRequest  true  "Email address and password (8 to 72 bytes)"
+// @Success      201  {object}  UserSessionResponse
+// @Failure      400  {object}  ErrorResponse "Bad Request - Invalid email address or password"
+// @Failure      404  {object}  ErrorResponse "Not Found - User accounts are not enabled"
+// @Failure      409  {object}  ErrorResponse "Conflict - Email address already registered"
+// @Failure      500  {object}  ErrorResponse "Internal Server Error - Failed to create the account"
+// @Router       /api/v1/users/register [post]
+func (cfg *apiConfig) handlerUserRegister(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		cfg.respondWithError(w, http.StatusMethodNotAllowed, "Method Not Allowed", nil)
+		return
+	}
+	if cfg.userJWTSecret.Get() == "" {
+		cfg.respondWithError(w, http.StatusNotFound, errUsersDisabled.Error(), nil)
+		return
+	}
+
+	req, err := decodeCredentials(w, r)
+	if err != nil {
+		cfg.respondWithError(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	if err := req.validate(); err != nil {
+		cfg.respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	ctx := r.Context()
+	_, err = cfg.dbQueries.GetUserByEmail(ctx, req.Email)
+	if err == nil {
+		cfg.respondWithError(w, http.StatusConflict, "email address is already registered", nil)
+		return
+	}
+	if err != sql.ErrNoRows {
+		cfg.respondWithError(w, http.StatusInternalServerError, "Failed to create account", err)
+		return
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		cfg.respondWithError(w, http.StatusInternalServerError, "Failed to create account", err)
+		return
+	}
+	user, err := cfg.dbQueries.CreateUser(ctx, database.CreateUserParams{
+		ID:           uuid.New(),
+		Email:        req.Email,
+		PasswordHash: string(hash),
+		CreatedAt:    time.Now().UTC(),
+	})
+	if isUniqueViolation(err) {
+		// A concurrent registration of the same address won the race.
+		cfg.respondWithError(w, http.StatusConflict, "email address is already registered", nil)
+		return
+	}
+	if err != nil {
+		cfg.respondWithError(w, http.StatusInternalServerError, "Failed to create account", err)
+		return
+	}
+	cfg.logger.Info("user registered", "user_id", user.ID)
+	cfg.respondWithSession(w, http.StatusCreated, user)
+}
+
+// @Summary      Log in to a user account
+// @Description  Verifies the email address and password in the body and returns a new session token, to be sent
+// @Description  as "Authorization: Bearer <token>". Only available with USER_JWT_SECRET set.
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Param        request  body      UserCredentialsRequest  true  "Email address and password"
+// @Success      200  {object}  UserSessionResponse
+// @Failure      400  {object}  ErrorResponse "Bad Request - Invalid request body"
+// @Failure      401  {object}  ErrorResponse "Unauthorized - Invalid email address or password"
+// @Failure      404  {object}  ErrorResponse "Not Found - User accounts are not enabled"
+// @Failure      500  {object}  ErrorResponse "Internal Server Error - Failed to log in"
+// @Router       /api/v1/users/login [post]
+func (cfg *apiConfig) handlerUserLogin(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		cfg.respondWithError(w, http.StatusMethodNotAllowed, "Method Not Allowed", nil)
+		return
+	}
+	if cfg.userJWTSecret.Get() == "" {
+		cfg.respondWithError(w, http.StatusNotFound, errUsersDisabled.Error(), nil)
+		return
+	}
+
+	req, err := decodeCredentials(w, r)
+	if err != nil {
+		cfg.respondWithError(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	user, err := cfg.dbQueries.GetUserByEmail(r.Context(), req.Email)
+	if err == sql.ErrNoRows {
+		_ = bcrypt.CompareHashAndPassword(dummyPasswordHash(), []byte(req.Password))
+		cfg.respondWithError(w, http.StatusUnauthorized, errInvalidCredential.Error(), nil)
+		return
+	}
+	if err != nil {
+		cfg.respondWithError(w, http.StatusInternalServerError, "Failed to log in", err)
+		return
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
+		cfg.logger.Warn("failed login", "user_id", user.ID, "remote_addr", r.RemoteAddr)
+		cfg.respondWithError(w, http.StatusUnauthorized, errInvalidCredential.Error(), nil)
+		return
+	}
+	cfg.respondWithSession(w, http.StatusOK, user)
+}
+
+// respondWithSession issues a session token for a user and responds with it.
+func (cfg *apiConfig) respondWithSession(w http.ResponseWriter, code int, user database.User) {
+	now := time.Now()
+	token, err := signSessionToken(cfg.userJWTSecret.Get(), user.ID, now, cfg.userSessionTTL)
+	if err != nil {
+		cfg.respondWithError(w, http.StatusInternalServerError, "Failed to create session", err)
+		return
+	}
+	cfg.respondWithJSON(w, code, UserSessionResponse{
+		Token:     token,
+		ExpiresAt: now.Add(cfg.userSessionTTL).UTC().Format(time.RFC3339),
+		User:      userToJSON(user),
+	})
+}
+
+// validate checks the preferences of a user.
+func (p UserPreferencesJSON) validate() error {
+	if p.Units != "" {
+		if _, err := parseUnits(p.Units); err != nil {
+			return err
+		}
+	}
+	if _, ok := localeBundles[p.Language]; p.Language != "" && !ok {
+		return fmt.Errorf("language must be one of en, pl or de")
+	}
+	if utf8.RuneCountInString(p.DefaultCity) > maxDefaultCityLength {
+		return fmt.Errorf("default_city must be at most %d characters long", maxDefaultCityLength)
+	}
+	return nil
+}
+
+// handlerUserMe dispatches requests for the account of the session's user by method: GET
+// returns the account and PUT replaces its preferences.
+
+// @Summary      Get or update the user account
+// @Description  GET returns the account of the user of the session token, with its preferences. PUT replaces the
+// @Description  preferences with those in the body: units ('metric' or 'imperial') and language ('en', 'pl' or
+// @Description  'de') apply to requests that do not set them, and default_city to requests that name no location.
+// @Description  Empty values fall back to the server defaults.
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Param        Authorization  header    string               true   "Bearer session token"
+// @Param        request        body      UserPreferencesJSON  false  "Preferences (PUT)"
+// @Success      200  {object}  UserJSON
+// @Failure      400  {object}  ErrorResponse "Bad Request - Invalid preferences"
+// @Failure      401  {object}  ErrorResponse "Unauthorized - Missing, invalid or expired session"
+// @Failure      404  {object}  ErrorResponse "Not Found - User accounts are not enabled"
+// @Failure      500  {object}  ErrorResponse "Internal Server Error - Failed to update the account"
+// @Router       /api/v1/users/me [get]
+// @Router       /api/v1/users/me [put]
+func (cfg *apiConfig) handlerUserMe(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPut {
+		cfg.respondWithError(w, http.StatusMethodNotAllowed, "Method Not Allowed", nil)
+		return
+	}
+	if cfg.userJWTSecret.Get() == "" {
+		cfg.respondWithError(w, http.StatusNotFound, errUsersDisabled.Error(), nil)
+		return
+	}
+	user, ok := userFrom(r.Context())
+	if !ok {
+		cfg.respondWithError(w, http.StatusUnauthorized, errSessionRequired.Error(), nil)
+		return
+	}
+
+	if r.Method == http.MethodPut {
+		var prefs UserPreferencesJSON
+		dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4<<10))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&prefs); err != nil {
+			cfg.respondWithError(w, http.StatusBadRequest, "Invalid request body", err)
+			return
+		}
+		prefs.Language = strings.ToLower(prefs.Language)
+		prefs.DefaultCity = strings.TrimSpace(prefs.DefaultCity)
+		if err := prefs.validate(); err != nil {
+			cfg.respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+			return
+		}
+		var err error
+		user, err = cfg.dbQueries.UpdateUserPreferences(r.Context(), database.UpdateUserPreferencesParams{
+			ID:          user.ID,
+			Units:       prefs.Units,
+			Language:    prefs.Language,
+			DefaultCity: prefs.DefaultCity,
+			UpdatedAt:   time.Now().UTC(),
+		})
+		if err != nil {
+			cfg.respondWithError(w, http.StatusInternalServerError, "Failed to update account", err)
+			return
+		}
+	}
+	cfg.respondWithJSON(w, http.StatusOK, userToJSON(user))
+}
+
+// userToJSON converts a stored user account to its API representation.
+func userToJSON(user database.User) UserJSON {
+	return UserJSON{
+		ID:    user.ID.String(),
+		Email: user.Email,
+		Preferences: UserPreferencesJSON{
+			Units:       user.Units,
+			Language:    user.Language,
+			DefaultCity: user.DefaultCity,
+		},
+		CreatedAt: user.CreatedAt.UTC().Format(time.RFC3339),
+	}
+}
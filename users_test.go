@@ -0,0 +1,339 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cor0nius/willitrain/internal/database"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestSessionToken(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	userID := uuid.New()
+	token, err := signSessionToken("secret", userID, now, time.Hour)
+	if err != nil {
+		t.Fatalf("signSessionToken() error = %v", err)
+	}
+
+	if got, err := parseSessionToken("secret", token, now.Add(59*time.Minute)); err != nil || got != userID {
+		t.Errorf("parseSessionToken() = %v, %v; want %v", got, err, userID)
+	}
+	if _, err := parseSessionToken("secret", token, now.Add(time.Hour)); err != errInvalidSession {
+		t.Errorf("expected an expired token to be rejected, got %v", err)
+	}
+	if _, err := parseSessionToken("other", token, now); err != errInvalidSession {
+		t.Errorf("expected a token signed with another secret to be rejected, got %v", err)
+	}
+
+	// A token whose claims were changed no longer matches its signature.
+	parts := strings.Split(token, ".")
+	forged, _ := signSessionToken("secret", uuid.New(), now, time.Hour)
+	parts[1] = strings.Split(forged, ".")[1]
+	if _, err := parseSessionToken("secret", strings.Join(parts, "."), now); err != errInvalidSession {
+		t.Errorf("expected a tampered token to be rejected, got %v", err)
+	}
+	if _, err := parseSessionToken("secret", "not-a-token", now); err != errInvalidSession {
+		t.Errorf("expected a malformed token to be rejected, got %v", err)
+	}
+}
+
+func TestUserSessionMiddleware(t *testing.T) {
+	user := database.User{ID: uuid.New(), Email: "ala@example.com", Units: "imperial", Language: "pl", DefaultCity: "Wroclaw"}
+	token, err := signSessionToken("secret", user.ID, time.Now(), time.Hour)
+	if err != nil {
+		t.Fatalf("signSessionToken() error = %v", err)
+	}
+	unknownToken, _ := signSessionToken("secret", uuid.New(), time.Now(), time.Hour)
+
+	testCases := []struct {
+		name           string
+		secret         string
+		authorization  string
+		wantStatus     int
+		wantSubscriber string
+		wantUnits      unitSystem
+		wantLocale     string
+		wantCity       string
+	}{
+		{name: "Disabled", authorization: "Bearer " + token, wantStatus: http.StatusOK, wantSubscriber: "device:abc", wantUnits: unitsMetric, wantLocale: defaultLocale},
+		{name: "No Token", secret: "secret", wantStatus: http.StatusOK, wantSubscriber: "device:abc", wantUnits: unitsMetric, wantLocale: defaultLocale},
+		{name: "Session", secret: "secret", authorization: "Bearer " + token, wantStatus: http.StatusOK, wantSubscriber: userSubscriberID(user.ID), wantUnits: unitsImperial, wantLocale: "pl", wantCity: "Wroclaw"},
+		{name: "Invalid Token", secret: "secret", authorization: "Bearer " + token + "x", wantStatus: http.StatusUnauthorized},
+		{name: "Deleted User", secret: "secret", authorization: "Bearer " + unknownToken, wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testCfg := newTestAPIConfig(t)
+			testCfg.userJWTSecret = newSecretValue(tc.secret)
+			testCfg.defaultUnits = unitsMetric
+			testCfg.mockDB.GetUserByIDFunc = func(ctx context.Context, id uuid.UUID) (database.User, error) {
+				if id == user.ID {
+					return user, nil
+				}
+				return database.User{}, sql.ErrNoRows
+			}
+			testCfg.mockDB.GetLocationByAliasFunc = func(ctx context.Context, alias string) (database.Location, error) {
+				if alias != "wroclaw" {
+					t.Errorf("unexpected alias %q", alias)
+				}
+				return MockDBLocation, nil
+			}
+
+			called := false
+			handler := testCfg.userSessionMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				called = true
+				if got, err := getSubscriberID(r); err != nil || got != tc.wantSubscriber {
+					t.Errorf("getSubscriberID() = %q, %v; want %q", got, err, tc.wantSubscriber)
+				}
+				if got, err := testCfg.requestUnits(r); err != nil || got != tc.wantUnits {
+					t.Errorf("requestUnits() = %v, %v; want %v", got, err, tc.wantUnits)
+				}
+				if got, _ := requestLocale(r); got != tc.wantLocale {
+					t.Errorf("requestLocale() = %q, want %q", got, tc.wantLocale)
+				}
+				location, err := testCfg.getLocationFromRequest(r)
+				if tc.wantCity == "" && err == nil || tc.wantCity != "" && location.CityName != tc.wantCity {
+					t.Errorf("getLocationFromRequest() = %+v, %v; want %q", location, err, tc.wantCity)
+				}
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/currentweather", nil)
+			req.Header.Set("X-Device-ID", "abc")
+			if tc.authorization != "" {
+				req.Header.Set("Authorization", tc.authorization)
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != tc.wantStatus {
+				t.Fatalf("status = %d, want %d; body: %s", rr.Code, tc.wantStatus, rr.Body.String())
+			}
+			if called != (tc.wantStatus == http.StatusOK) {
+				t.Errorf("next handler called = %v", called)
+			}
+			if tc.wantStatus == http.StatusUnauthorized && rr.Body.String() != `{"error":"invalid or expired session"}` {
+				t.Errorf("unexpected body: %s", rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestUserSessionMiddlewareKeepsRoute(t *testing.T) {
+	user := database.User{ID: uuid.New(), Email: "ala@example.com"}
+	token, _ := signSessionToken("secret", user.ID, time.Now(), time.Hour)
+
+	testCfg := newTestAPIConfig(t)
+	testCfg.userJWTSecret = newSecretValue("secret")
+	testCfg.mockDB.GetUserByIDFunc = func(ctx context.Context, id uuid.UUID) (database.User, error) {
+		return user, nil
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/currentweather", func(w http.ResponseWriter, r *http.Request) {
+		if got, ok := userFrom(r.Context()); !ok || got.ID != user.ID {
+			t.Errorf("userFrom() = %v, %v; want the session's user", got.ID, ok)
+		}
+	})
+	stats := newRequestStatsRecorder()
+	httpRequestDuration.Reset()
+	handler := userContextMiddleware(metricsMiddleware(requestStatsMiddleware(stats, testCfg.userSessionMiddleware(mux))))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/currentweather?city=Wroclaw", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d; body: %s", rr.Code, http.StatusOK, rr.Body.String())
+	}
+
+	// The middlewares outside the session middleware still see the pattern the mux matched.
+	if got := testutil.CollectAndCount(httpRequestDuration); got != 1 {
+		t.Fatalf("expected one duration series, got %d", got)
+	}
+	var m dto.Metric
+	if err := httpRequestDuration.WithLabelValues("/api/v1/currentweather", "GET", "2xx").(prometheus.Metric).Write(&m); err != nil {
+		t.Fatalf("could not read histogram: %v", err)
+	}
+	if got := m.GetHistogram().GetSampleCount(); got != 1 {
+		t.Errorf("expected the request to be recorded under its route, got %d samples", got)
+	}
+	endpoints, _ := stats.drain()
+	if len(endpoints) != 1 {
+		t.Fatalf("expected one counted endpoint, got %v", endpoints)
+	}
+	for k := range endpoints {
+		if k.endpoint != "/api/v1/currentweather" {
+			t.Errorf("expected the request to be counted under its route, got %s", k.endpoint)
+		}
+	}
+}
+
+func TestHandlerUserRegister(t *testing.T) {
+	testCases := []struct {
+		name       string
+		secret     string
+		body       string
+		existing   bool
+		createErr  error
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "Register", secret: "secret", body: `{"email":" Ala@Example.com ","password":"correct horse"}`, wantStatus: http.StatusCreated, wantBody: `"email":"ala@example.com"`},
+		{name: "Already Registered", secret: "secret", body: `{"email":"ala@example.com","password":"correct horse"}`, existing: true, wantStatus: http.StatusConflict, wantBody: `{"error":"email address is already registered"}`},
+		{name: "Registered Concurrently", secret: "secret", body: `{"email":"ala@example.com","password":"correct horse"}`, createErr: &pgconn.PgError{Code: pgUniqueViolation}, wantStatus: http.StatusConflict, wantBody: `{"error":"email address is already registered"}`},
+		{name: "Invalid Email", secret: "secret", body: `{"email":"ala","password":"correct horse"}`, wantStatus: http.StatusBadRequest, wantBody: `{"error":"email must be a valid email address"}`},
+		{name: "Short Password", secret: "secret", body: `{"email":"ala@example.com","password":"short"}`, wantStatus: http.StatusBadRequest, wantBody: `{"error":"password must be between 8 and 72 bytes long"}`},
+		{name: "Unknown Field", secret: "secret", body: `{"email":"ala@example.com","password":"correct horse","admin":true}`, wantStatus: http.StatusBadRequest, wantBody: `{"error":"Invalid request body"}`},
+		{name: "Disabled", body: `{"email":"ala@example.com","password":"correct horse"}`, wantStatus: http.StatusNotFound, wantBody: `{"error":"user accounts are not enabled"}`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testCfg := newTestAPIConfig(t)
+			testCfg.userJWTSecret = newSecretValue(tc.secret)
+			testCfg.userSessionTTL = time.Hour
+			testCfg.mockDB.GetUserByEmailFunc = func(ctx context.Context, email string) (database.User, error) {
+				if tc.existing {
+					return database.User{ID: uuid.New(), Email: email}, nil
+				}
+				return database.User{}, sql.ErrNoRows
+			}
+			testCfg.mockDB.CreateUserFunc = func(ctx context.Context, arg database.CreateUserParams) (database.User, error) {
+				if bcrypt.CompareHashAndPassword([]byte(arg.PasswordHash), []byte("correct horse")) != nil {
+					t.Errorf("expected the password to be stored as a bcrypt hash, got %q", arg.PasswordHash)
+				}
+				if tc.createErr != nil {
+					return database.User{}, tc.createErr
+				}
+				return database.User{ID: arg.ID, Email: arg.Email, PasswordHash: arg.PasswordHash, CreatedAt: arg.CreatedAt, UpdatedAt: arg.CreatedAt}, nil
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/users/register", strings.NewReader(tc.body))
+			rr := httptest.NewRecorder()
+			testCfg.apiConfig.handlerUserRegister(rr, req)
+
+			if rr.Code != tc.wantStatus {
+				t.Fatalf("status = %d, want %d; body: %s", rr.Code, tc.wantStatus, rr.Body.String())
+			}
+			if !strings.Contains(rr.Body.String(), tc.wantBody) {
+				t.Errorf("body = %s, want it to contain %s", rr.Body.String(), tc.wantBody)
+			}
+			if rr.Code != http.StatusCreated {
+				return
+			}
+			var response UserSessionResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if id, err := parseSessionToken("secret", response.Token, time.Now()); err != nil || id.String() != response.User.ID {
+				t.Errorf("expected a session token of the new user, got %v, %v", id, err)
+			}
+		})
+	}
+}
+
+func TestHandlerUserLogin(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("correct horse"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("could not hash password: %v", err)
+	}
+	user := database.User{ID: uuid.New(), Email: "ala@example.com", PasswordHash: string(hash)}
+
+	testCases := []struct {
+		name       string
+		body       string
+		wantStatus int
+	}{
+		{name: "Login", body: `{"email":"ALA@example.com","password":"correct horse"}`, wantStatus: http.StatusOK},
+		{name: "Wrong Password", body: `{"email":"ala@example.com","password":"battery staple"}`, wantStatus: http.StatusUnauthorized},
+		{name: "Unknown Email", body: `{"email":"ola@example.com","password":"correct horse"}`, wantStatus: http.StatusUnauthorized},
+		{name: "Invalid Body", body: `["ala@example.com"]`, wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testCfg := newTestAPIConfig(t)
+			testCfg.userJWTSecret = newSecretValue("secret")
+			testCfg.userSessionTTL = time.Hour
+			testCfg.mockDB.GetUserByEmailFunc = func(ctx context.Context, email string) (database.User, error) {
+				if email == user.Email {
+					return user, nil
+				}
+				return database.User{}, sql.ErrNoRows
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/users/login", strings.NewReader(tc.body))
+			rr := httptest.NewRecorder()
+			testCfg.apiConfig.handlerUserLogin(rr, req)
+
+			if rr.Code != tc.wantStatus {
+				t.Fatalf("status = %d, want %d; body: %s", rr.Code, tc.wantStatus, rr.Body.String())
+			}
+			if tc.wantStatus == http.StatusUnauthorized && rr.Body.String() != `{"error":"invalid email or password"}` {
+				t.Errorf("expected the same error for unknown emails and wrong passwords, got %s", rr.Body.String())
+			}
+			if tc.wantStatus == http.StatusOK && !strings.Contains(rr.Body.String(), `"token":"`) {
+				t.Errorf("expected a session token, got %s", rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandlerUserMe(t *testing.T) {
+	user := database.User{ID: uuid.New(), Email: "ala@example.com", CreatedAt: time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)}
+
+	testCases := []struct {
+		name       string
+		method     string
+		body       string
+		noSession  bool
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "Get", method: http.MethodGet, wantStatus: http.StatusOK, wantBody: `{"id":"` + user.ID.String() + `","email":"ala@example.com","preferences":{"units":"","language":"","default_city":""},"created_at":"2025-06-01T12:00:00Z"}`},
+		{name: "Update", method: http.MethodPut, body: `{"units":"imperial","language":"PL","default_city":" Wroclaw "}`, wantStatus: http.StatusOK, wantBody: `"preferences":{"units":"imperial","language":"pl","default_city":"Wroclaw"}`},
+		{name: "Invalid Units", method: http.MethodPut, body: `{"units":"kelvin"}`, wantStatus: http.StatusBadRequest, wantBody: `{"error":"units must be either metric or imperial"}`},
+		{name: "Invalid Language", method: http.MethodPut, body: `{"language":"fr"}`, wantStatus: http.StatusBadRequest, wantBody: `{"error":"language must be one of en, pl or de"}`},
+		{name: "No Session", method: http.MethodGet, noSession: true, wantStatus: http.StatusUnauthorized, wantBody: `{"error":"a session token is required"}`},
+		{name: "Method Not Allowed", method: http.MethodDelete, wantStatus: http.StatusMethodNotAllowed},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testCfg := newTestAPIConfig(t)
+			testCfg.userJWTSecret = newSecretValue("secret")
+			testCfg.mockDB.UpdateUserPreferencesFunc = func(ctx context.Context, arg database.UpdateUserPreferencesParams) (database.User, error) {
+				updated := user
+				updated.Units, updated.Language, updated.DefaultCity = arg.Units, arg.Language, arg.DefaultCity
+				return updated, nil
+			}
+
+			req := httptest.NewRequest(tc.method, "/api/v1/users/me", strings.NewReader(tc.body))
+			if !tc.noSession {
+				req = req.WithContext(withUser(req.Context(), user))
+			}
+			rr := httptest.NewRecorder()
+			testCfg.apiConfig.handlerUserMe(rr, req)
+
+			if rr.Code != tc.wantStatus {
+				t.Fatalf("status = %d, want %d; body: %s", rr.Code, tc.wantStatus, rr.Body.String())
+			}
+			if !strings.Contains(rr.Body.String(), tc.wantBody) {
+				t.Errorf("body = %s, want it to contain %s", rr.Body.String(), tc.wantBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// This file implements the versioning of the public API. Every version is served under its own
+// prefix, e.g. /api/v1/currentweather, so that a breaking change of a response shape can be
+// shipped as a new version while existing clients keep the old one. The unversioned /api paths
+// predate versioning; they remain as aliases of /api/v1 and announce their deprecation in the
+// response headers.
+
+const (
+	// legacyAPIPrefix is the prefix of the unversioned API paths.
+	legacyAPIPrefix = "/api"
+	// apiV1Prefix is the prefix of version 1 of the API.
+	apiV1Prefix = "/api/v1"
+)
+
+// apiRoute is an endpoint of the public API. Its path is relative to the version prefix.
+type apiRoute struct {
+	path    string
+	handler http.HandlerFunc
+}
+
+// registerAPIRoutes registers the routes of an API version under its prefix.
+func registerAPIRoutes(mux *http.ServeMux, prefix string, routes []apiRoute) {
+	for _, route := range routes {
+		mux.HandleFunc(prefix+route.path, route.handler)
+	}
+}
+
+// registerLegacyAPIRoutes registers the routes under the unversioned prefix as deprecated
+// aliases of the version with the given prefix.
+func registerLegacyAPIRoutes(mux *http.ServeMux, successorPrefix string, routes []apiRoute) {
+	for _, route := range routes {
+		mux.Handle(legacyAPIPrefix+route.path, deprecatedAlias(successorPrefix+route.path, route.handler))
+	}
+}
+
+// deprecatedAlias serves a deprecated path with the handler of its successor. Responses carry a
+// Deprecation header and a Link header pointing to the successor path, both readable by
+// cross-origin clients.
+func deprecatedAlias(successor string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Deprecation", "true")
+		w.Header().Set("Link", fmt.Sprintf("<%s>; rel=\"successor-version\"", successor))
+		w.Header().Set("Access-Control-Expose-Headers", "Deprecation, Link")
+		next.ServeHTTP(w, r)
+	})
+}
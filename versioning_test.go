@@ -0,0 +1,52 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAPIVersionRouting(t *testing.T) {
+	mux := http.NewServeMux()
+	routes := []apiRoute{
+		{"/currentweather", func(w http.ResponseWriter, r *http.Request) { _, _ = w.Write([]byte("current")) }},
+		{"/currentweather/batch", func(w http.ResponseWriter, r *http.Request) { _, _ = w.Write([]byte("batch")) }},
+	}
+	registerAPIRoutes(mux, apiV1Prefix, routes)
+	registerLegacyAPIRoutes(mux, apiV1Prefix, routes)
+
+	testCases := []struct {
+		path           string
+		wantStatus     int
+		wantBody       string
+		wantDeprecated bool
+		wantLink       string
+	}{
+		{path: "/api/v1/currentweather", wantStatus: http.StatusOK, wantBody: "current"},
+		{path: "/api/v1/currentweather/batch", wantStatus: http.StatusOK, wantBody: "batch"},
+		{path: "/api/currentweather", wantStatus: http.StatusOK, wantBody: "current", wantDeprecated: true, wantLink: `</api/v1/currentweather>; rel="successor-version"`},
+		{path: "/api/currentweather/batch", wantStatus: http.StatusOK, wantBody: "batch", wantDeprecated: true, wantLink: `</api/v1/currentweather/batch>; rel="successor-version"`},
+		{path: "/api/v2/currentweather", wantStatus: http.StatusNotFound},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			rr := httptest.NewRecorder()
+			mux.ServeHTTP(rr, req)
+
+			if rr.Code != tc.wantStatus {
+				t.Fatalf("status = %d, want %d", rr.Code, tc.wantStatus)
+			}
+			if tc.wantBody != "" && rr.Body.String() != tc.wantBody {
+				t.Errorf("body = %q, want %q", rr.Body.String(), tc.wantBody)
+			}
+			if deprecated := rr.Header().Get("Deprecation") == "true"; deprecated != tc.wantDeprecated {
+				t.Errorf("Deprecation header = %q, want deprecated: %v", rr.Header().Get("Deprecation"), tc.wantDeprecated)
+			}
+			if link := rr.Header().Get("Link"); link != tc.wantLink {
+				t.Errorf("Link header = %q, want %q", link, tc.wantLink)
+			}
+		})
+	}
+}
@@ -0,0 +1,303 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/cor0nius/willitrain/internal/database"
+	"github.com/redis/go-redis/v9"
+)
+
+// This file implements /api/warnings, which returns the severe weather warnings issued by
+// national weather services for a location, e.g. storm, flood or heat warnings. The warnings
+// are taken from the alerts section of the OpenWeatherMap One Call 3.0 API, the only configured
+// provider that relays them; Open-Meteo does not publish warnings. While the OWM source is
+// disabled or only the 2.5 API is available, the list is empty.
+//
+// Warnings follow the same caching strategy as the forecasts: Redis first, then the database,
+// then the provider. The database keeps the complete list of a source's warnings per location,
+// so that a location without warnings is not requested again on every call.
+
+const (
+	// warningsCacheKeyPrefix is the cache key prefix for the warnings of a location.
+	warningsCacheKeyPrefix = "warnings"
+
+	// warningsCacheTTL is how long stored warnings are considered fresh. Warnings are issued at
+	// short notice, so they are refreshed more often than forecasts.
+	warningsCacheTTL      = 15 * time.Minute
+	redisWarningsCacheTTL = 14 * time.Minute
+)
+
+// @Summary      Get severe weather warnings
+// @Description  Retrieves the current and upcoming severe weather warnings issued by national weather services
+// @Description  for a specified location, such as storm, flood or heat warnings. The location can be identified
+// @Description  by its name, or by latitude and longitude. Warnings are relayed from OpenWeatherMap One Call 3.0;
+// @Description  the list is empty while that API is unavailable.
+// @Tags         weather
+// @Produce      json
+// @Param        city    query     string  false  "Location name to search for (e.g., 'London')"
+// @Param        lat     query     number  false  "Latitude for the location (e.g., 51.5074)"
+// @Param        lon     query     number  false  "Longitude for the location (e.g., -0.1278)"
+// @Success      200  {object}  WeatherWarningsResponse
+// @Failure      400  {object}  ErrorResponse "Bad Request - Invalid location parameters"
+// @Failure      500  {object}  ErrorResponse "Internal Server Error - Failed to retrieve warnings"
+// @Router       /api/v1/warnings [get]
+func (cfg *apiConfig) handlerWeatherWarnings(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		cfg.respondWithError(w, http.StatusMethodNotAllowed, "Method Not Allowed", nil)
+		return
+	}
+
+	location, err := cfg.getLocationFromRequest(r)
+	if err != nil {
+		cfg.respondWithError(w, http.StatusBadRequest, "Error getting location data", err)
+		return
+	}
+	cfg.logger.Debug("weather warnings request", "city", location.CityName)
+
+	warnings, err := cfg.getCachedOrFetchWeatherWarnings(r.Context(), location)
+	if err != nil {
+		cfg.respondWithError(w, http.StatusInternalServerError, "Error getting weather warnings", err)
+		return
+	}
+
+	cfg.respondWithJSON(w, http.StatusOK, weatherWarningsResponse(location, activeWeatherWarnings(warnings, time.Now()), cfg.logger))
+}
+
+// weatherWarningsResponse formats warnings for the API response, ordered by start time, with
+// times in the location's timezone.
+func weatherWarningsResponse(location Location, warnings []WeatherWarning, logger *slog.Logger) WeatherWarningsResponse {
+	loc, err := time.LoadLocation(location.Timezone)
+	if err != nil {
+		logger.Warn("could not load location timezone, falling back to UTC", "timezone", location.Timezone, "error", err)
+		loc = time.UTC
+	}
+
+	sort.SliceStable(warnings, func(i, j int) bool {
+		return warnings[i].Start.Before(warnings[j].Start)
+	})
+
+	warningsJSON := make([]WeatherWarningJSON, len(warnings))
+	sources := make([]string, len(warnings))
+	for i, w := range warnings {
+		tags := w.Tags
+		if tags == nil {
+			tags = []string{}
+		}
+		warningsJSON[i] = WeatherWarningJSON{
+			SourceAPI:   w.SourceAPI,
+			Sender:      w.Sender,
+			Event:       w.Event,
+			Start:       w.Start.In(loc).Format(time.RFC3339),
+			End:         w.End.In(loc).Format(time.RFC3339),
+			Description: w.Description,
+			Tags:        tags,
+		}
+		sources[i] = w.SourceAPI
+	}
+
+	return WeatherWarningsResponse{
+		Location:    location,
+		Warnings:    warningsJSON,
+		Attribution: attributionForSources(sources),
+	}
+}
+
+// activeWeatherWarnings returns the warnings that have not ended at now. Stored warnings may
+// have expired since they were fetched.
+func activeWeatherWarnings(warnings []WeatherWarning, now time.Time) []WeatherWarning {
+	var active []WeatherWarning
+	for _, w := range warnings {
+		if w.End.After(now) {
+			active = append(active, w)
+		}
+	}
+	return active
+}
+
+// getCachedOrFetchWeatherWarnings returns the warnings for a location from Redis, from the
+// database if they are fresh, or from OWM, in which case they are stored in both.
+func (cfg *apiConfig) getCachedOrFetchWeatherWarnings(ctx context.Context, location Location) ([]WeatherWarning, error) {
+	if !cfg.sourceEnabled("owm") || cfg.owmVersion.active() == owmVersionLegacy {
+		return nil, nil
+	}
+
+	cacheKey := locationCacheKey(warningsCacheKeyPrefix, location.LocationID)
+	cached, err := cfg.cache.Get(ctx, cacheKey)
+	if err == nil {
+		var warnings []WeatherWarning
+		jsonErr := json.Unmarshal([]byte(cached), &warnings)
+		if jsonErr == nil {
+			cfg.logger.Debug("cache hit", "key", cacheKey)
+			return warnings, nil
+		}
+		cfg.logger.Warn("invalid cache entry: unmarshal error", "key", cacheKey, "error", jsonErr)
+	} else if errors.Is(err, errCacheUnavailable) {
+		cfg.logger.Debug("cache bypassed", "key", cacheKey)
+	} else if err != redis.Nil {
+		cfg.logger.Warn("error getting from redis", "key", cacheKey, "error", err)
+	}
+
+	rows, err := cfg.dbQueries.GetWeatherWarningsAtLocation(ctx, location.LocationID)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("database error when fetching %s: %w", warningsCacheKeyPrefix, err)
+	}
+	if warnings, ok := cfg.freshWeatherWarnings(rows); ok {
+		cfg.logger.Debug("db cache hit", "key", cacheKey)
+		cfg.cacheWeatherWarnings(ctx, cacheKey, warnings)
+		return warnings, nil
+	}
+
+	warnings, err := cfg.requestWeatherWarningsOWM(ctx, location)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch %s: %w", warningsCacheKeyPrefix, err)
+	}
+	cfg.logger.Debug("api fetch successful", "key", cacheKey)
+
+	cfg.persistWeatherWarnings(ctx, location, "OpenWeatherMap API", warnings)
+	cfg.cacheWeatherWarnings(ctx, cacheKey, warnings)
+	return warnings, nil
+}
+
+// freshWeatherWarnings decodes the stored warnings of the OWM source. It reports false if
+// they are missing, stale or invalid.
+func (cfg *apiConfig) freshWeatherWarnings(rows []database.WeatherWarning) ([]WeatherWarning, bool) {
+	for _, row := range rows {
+		if row.SourceApi != "OpenWeatherMap API" {
+			continue
+		}
+		if !row.UpdatedAt.After(time.Now().UTC().Add(-warningsCacheTTL)) {
+			return nil, false
+		}
+		var warnings []WeatherWarning
+		if err := json.Unmarshal(row.Warnings, &warnings); err != nil {
+			cfg.logger.Warn("invalid stored weather warnings", "location_id", row.LocationID, "error", err)
+			return nil, false
+		}
+		return warnings, true
+	}
+	return nil, false
+}
+
+// cacheWeatherWarnings writes the warnings of a location to Redis.
+func (cfg *apiConfig) cacheWeatherWarnings(ctx context.Context, cacheKey string, warnings []WeatherWarning) {
+	if warnings == nil {
+		warnings = []WeatherWarning{}
+	}
+	if err := cfg.cache.Set(ctx, cacheKey, warnings, redisWarningsCacheTTL); errors.Is(err, errCacheUnavailable) {
+		cfg.logger.Debug("cache bypassed", "key", cacheKey)
+	} else if err != nil {
+		cfg.logger.Warn("error setting to redis", "key", cacheKey, "error", err)
+	}
+}
+
+// persistWeatherWarnings replaces the stored warnings of a source for a location. Errors are
+// logged, as the warnings can still be served.
+func (cfg *apiConfig) persistWeatherWarnings(ctx context.Context, location Location, sourceAPI string, warnings []WeatherWarning) {
+	if warnings == nil {
+		warnings = []WeatherWarning{}
+	}
+	data, err := json.Marshal(warnings)
+	if err != nil {
+		cfg.logger.Error("failed to encode weather warnings", "location", location.CityName, "error", err)
+		return
+	}
+	if err := cfg.dbQueries.UpsertWeatherWarnings(ctx, database.UpsertWeatherWarningsParams{
+		LocationID: location.LocationID,
+		SourceApi:  sourceAPI,
+		Warnings:   data,
+		UpdatedAt:  time.Now().UTC(),
+	}); err != nil {
+		cfg.logger.Error("failed to persist weather warnings", "location", location.CityName, "error", err)
+	}
+}
+
+// requestWeatherWarningsOWM fetches the warnings for a location from the One Call 3.0 API,
+// within OWM's rate limit and daily quota, unless its circuit is open.
+func (cfg *apiConfig) requestWeatherWarningsOWM(ctx context.Context, location Location) ([]WeatherWarning, error) {
+	if cfg.quota.exhausted("owm") {
+		rateLimitedFetches.WithLabelValues("owm", "skipped").Inc()
+		return nil, errQuotaExhausted
+	}
+	if !cfg.breakers.allow("owm") {
+		return nil, errCircuitOpen
+	}
+	if err := cfg.rateLimits.wait(ctx, "owm"); err != nil {
+		cfg.breakers.record("owm", err)
+		return nil, err
+	}
+	cfg.usage.recordCall(location, "owm")
+	cfg.quota.recordCall("owm")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, cfg.owmOneCallURL(location, owmWarnings), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := cfg.httpClient.Do(req)
+	if err == nil && resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		err = &fetchStatusError{Status: resp.Status, StatusCode: resp.StatusCode}
+	}
+	cfg.breakers.record("owm", err)
+	if err != nil {
+		cfg.usage.recordFailure(location, "owm")
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	warnings, err := ParseWeatherWarningsOWM(resp.Body)
+	if err != nil {
+		cfg.usage.recordFailure(location, "owm")
+		return nil, err
+	}
+	return warnings, nil
+}
+
+// ParseWeatherWarningsOWM decodes the alerts section of a One Call 3.0 response. A response
+// without alerts yields no warnings.
+func ParseWeatherWarningsOWM(body io.Reader) ([]WeatherWarning, error) {
+	var response ResponseWarningsOWM
+	if err := json.NewDecoder(body).Decode(&response); err != nil {
+		return nil, err
+	}
+	if response.Timezone == "" {
+		return nil, errors.New("empty or invalid response from API")
+	}
+
+	warnings := make([]WeatherWarning, len(response.Alerts))
+	for i, alert := range response.Alerts {
+		warnings[i] = WeatherWarning{
+			SourceAPI:   "OpenWeatherMap API",
+			Sender:      alert.SenderName,
+			Event:       alert.Event,
+			Start:       time.Unix(alert.Start, 0).UTC(),
+			End:         time.Unix(alert.End, 0).UTC(),
+			Description: alert.Description,
+			Tags:        alert.Tags,
+		}
+	}
+	return warnings, nil
+}
+
+// The following structs are used to unmarshal the alerts section of the One Call 3.0 response.
+type ResponseWarningsOWM struct {
+	Alerts   []AlertOWM `json:"alerts"`
+	Timezone string     `json:"timezone"`
+}
+
+type AlertOWM struct {
+	SenderName  string   `json:"sender_name"`
+	Event       string   `json:"event"`
+	Start       int64    `json:"start"`
+	End         int64    `json:"end"`
+	Description string   `json:"description"`
+	Tags        []string `json:"tags"`
+}
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cor0nius/willitrain/internal/database"
+	"github.com/google/uuid"
+)
+
+func TestParseWeatherWarningsOWM(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		sampleJSON, err := testData.Open("testdata/warnings_owm.json")
+		if err != nil {
+			t.Fatalf("failed to open test data: %v", err)
+		}
+		defer sampleJSON.Close()
+
+		warnings, err := ParseWeatherWarningsOWM(sampleJSON)
+		if err != nil {
+			t.Fatalf("ParseWeatherWarningsOWM failed with error: %v", err)
+		}
+		if len(warnings) != 2 {
+			t.Fatalf("expected 2 warnings, got %d", len(warnings))
+		}
+		want := WeatherWarning{
+			SourceAPI:   "OpenWeatherMap API",
+			Sender:      "Institute of Meteorology and Water Management",
+			Event:       "Thunderstorms",
+			Start:       time.Unix(1754301600, 0).UTC(),
+			End:         time.Unix(1754337600, 0).UTC(),
+			Description: "Thunderstorms with heavy rain and hail are expected.",
+			Tags:        []string{"Thunderstorm", "Rain"},
+		}
+		got := warnings[0]
+		if got.SourceAPI != want.SourceAPI || got.Sender != want.Sender || got.Event != want.Event || got.Description != want.Description {
+			t.Errorf("got %+v, want %+v", got, want)
+		}
+		if !got.Start.Equal(want.Start) || !got.End.Equal(want.End) {
+			t.Errorf("got period %v - %v, want %v - %v", got.Start, got.End, want.Start, want.End)
+		}
+		if strings.Join(got.Tags, ",") != strings.Join(want.Tags, ",") {
+			t.Errorf("got tags %v, want %v", got.Tags, want.Tags)
+		}
+	})
+
+	t.Run("No Alerts", func(t *testing.T) {
+		warnings, err := ParseWeatherWarningsOWM(strings.NewReader(`{"lat":51.11,"lon":17.04,"timezone":"Europe/Warsaw"}`))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(warnings) != 0 {
+			t.Errorf("expected no warnings, got %d", len(warnings))
+		}
+	})
+
+	t.Run("Invalid Response", func(t *testing.T) {
+		if _, err := ParseWeatherWarningsOWM(strings.NewReader(`{}`)); err == nil {
+			t.Error("expected an error for an empty response, got nil")
+		}
+		if _, err := ParseWeatherWarningsOWM(strings.NewReader(`{"alerts":`)); err == nil {
+			t.Error("expected an error for malformed JSON, got nil")
+		}
+	})
+}
+
+func TestHandlerWeatherWarnings(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	active := WeatherWarning{
+		SourceAPI:   "OpenWeatherMap API",
+		Sender:      "IMGW-PIB",
+		Event:       "Strong wind",
+		Start:       now.Add(-time.Hour),
+		End:         now.Add(6 * time.Hour),
+		Description: "Gusts up to 90 km/h.",
+		Tags:        []string{"Wind"},
+	}
+	expired := active
+	expired.Event = "Heat"
+	expired.End = now.Add(-time.Minute)
+
+	owmAlerts := fmt.Sprintf(`{"timezone":"Europe/Warsaw","alerts":[`+
+		`{"sender_name":"IMGW-PIB","event":"Strong wind","start":%d,"end":%d,"description":"Gusts up to 90 km/h.","tags":["Wind"]},`+
+		`{"sender_name":"IMGW-PIB","event":"Heat","start":%d,"end":%d,"description":"","tags":[]}]}`,
+		active.Start.Unix(), active.End.Unix(), expired.Start.Unix(), expired.End.Unix())
+	storedWarnings, _ := json.Marshal([]WeatherWarning{active, expired})
+	warningJSON := `{"source_api":"OpenWeatherMap API","sender":"IMGW-PIB","event":"Strong wind",` +
+		`"start":"` + active.Start.Format(time.RFC3339) + `","end":"` + active.End.Format(time.RFC3339) + `",` +
+		`"description":"Gusts up to 90 km/h.","tags":["Wind"]}`
+	locationJSON := `{"location_id":"` + MockLocation.LocationID.String() + `","city_name":"Wroclaw","latitude":51.1,"longitude":17.03,"country_code":"PL"}`
+	attributionJSON := `"attribution":[{"provider":"owm","display_name":"OpenWeatherMap API","homepage_url":"https://openweathermap.org","license_name":"CC BY-SA 4.0","license_url":"https://creativecommons.org/licenses/by-sa/4.0/","notice":"Weather data provided by OpenWeather"}]`
+
+	testCases := []struct {
+		name           string
+		method         string
+		setupMocks     func(cfg *testAPIConfig)
+		enabledSources map[string]bool
+		upstreamStatus int
+		wantStatus     int
+		wantBody       string
+		wantUpstream   bool
+		wantPersisted  bool
+	}{
+		{
+			name: "Fetched From OWM",
+			setupMocks: func(cfg *testAPIConfig) {
+				cfg.mockDB.GetWeatherWarningsAtLocationFunc = func(ctx context.Context, locationID uuid.UUID) ([]database.WeatherWarning, error) {
+					return nil, nil
+				}
+			},
+			wantStatus:    http.StatusOK,
+			wantBody:      `{"location":` + locationJSON + `,"warnings":[` + warningJSON + `],` + attributionJSON + `}`,
+			wantUpstream:  true,
+			wantPersisted: true,
+		},
+		{
+			name: "Fresh In Database",
+			setupMocks: func(cfg *testAPIConfig) {
+				cfg.mockDB.GetWeatherWarningsAtLocationFunc = func(ctx context.Context, locationID uuid.UUID) ([]database.WeatherWarning, error) {
+					return []database.WeatherWarning{{LocationID: locationID, SourceApi: "OpenWeatherMap API", Warnings: storedWarnings, UpdatedAt: now}}, nil
+				}
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `{"location":` + locationJSON + `,"warnings":[` + warningJSON + `],` + attributionJSON + `}`,
+		},
+		{
+			name: "Stale In Database",
+			setupMocks: func(cfg *testAPIConfig) {
+				cfg.mockDB.GetWeatherWarningsAtLocationFunc = func(ctx context.Context, locationID uuid.UUID) ([]database.WeatherWarning, error) {
+					return []database.WeatherWarning{{LocationID: locationID, SourceApi: "OpenWeatherMap API", Warnings: []byte(`[]`), UpdatedAt: now.Add(-time.Hour)}}, nil
+				}
+			},
+			wantStatus:    http.StatusOK,
+			wantBody:      `{"location":` + locationJSON + `,"warnings":[` + warningJSON + `],` + attributionJSON + `}`,
+			wantUpstream:  true,
+			wantPersisted: true,
+		},
+		{
+			name: "Cached In Redis",
+			setupMocks: func(cfg *testAPIConfig) {
+				cfg.mockCache.GetFunc = func(ctx context.Context, key string) (string, error) {
+					if key != "warnings:"+MockLocation.LocationID.String() {
+						t.Errorf("unexpected cache key %q", key)
+					}
+					return string(storedWarnings), nil
+				}
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `{"location":` + locationJSON + `,"warnings":[` + warningJSON + `],` + attributionJSON + `}`,
+		},
+		{
+			name:           "OWM Disabled",
+			setupMocks:     func(cfg *testAPIConfig) {},
+			enabledSources: map[string]bool{"ometeo": true},
+			wantStatus:     http.StatusOK,
+			wantBody:       `{"location":` + locationJSON + `,"warnings":[]}`,
+		},
+		{
+			name: "Upstream Error",
+			setupMocks: func(cfg *testAPIConfig) {
+				cfg.mockDB.GetWeatherWarningsAtLocationFunc = func(ctx context.Context, locationID uuid.UUID) ([]database.WeatherWarning, error) {
+					return nil, nil
+				}
+			},
+			upstreamStatus: http.StatusUnauthorized,
+			wantStatus:     http.StatusInternalServerError,
+			wantBody:       `{"error":"Error getting weather warnings"}`,
+			wantUpstream:   true,
+		},
+		{
+			name: "Database Error",
+			setupMocks: func(cfg *testAPIConfig) {
+				cfg.mockDB.GetWeatherWarningsAtLocationFunc = func(ctx context.Context, locationID uuid.UUID) ([]database.WeatherWarning, error) {
+					return nil, errors.New("db down")
+				}
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   `{"error":"Error getting weather warnings"}`,
+		},
+		{
+			name:       "Wrong Method",
+			method:     http.MethodPost,
+			setupMocks: func(cfg *testAPIConfig) {},
+			wantStatus: http.StatusMethodNotAllowed,
+			wantBody:   `{"error":"Method Not Allowed"}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var upstreamCalled bool
+			server := setupMockServer(func(w http.ResponseWriter, r *http.Request) {
+				upstreamCalled = true
+				if got := r.URL.Query().Get("exclude"); got != "current,minutely,hourly,daily" {
+					t.Errorf("expected only alerts to be requested, got exclude=%q", got)
+				}
+				if tc.upstreamStatus != 0 {
+					w.WriteHeader(tc.upstreamStatus)
+					return
+				}
+				_, _ = w.Write([]byte(owmAlerts))
+			})
+			defer server.Close()
+
+			testCfg := newTestAPIConfig(t)
+			testCfg.owmWeatherURL = server.URL + "/?"
+			testCfg.enabledSources = tc.enabledSources
+			testCfg.mockDB.GetLocationByAliasFunc = func(ctx context.Context, alias string) (database.Location, error) {
+				return MockDBLocation, nil
+			}
+			var persisted bool
+			testCfg.mockDB.UpsertWeatherWarningsFunc = func(ctx context.Context, arg database.UpsertWeatherWarningsParams) error {
+				persisted = true
+				if arg.LocationID != MockLocation.LocationID || arg.SourceApi != "OpenWeatherMap API" {
+					t.Errorf("unexpected upsert: %+v", arg)
+				}
+				return nil
+			}
+			tc.setupMocks(testCfg)
+
+			method := tc.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			req := httptest.NewRequest(method, "/api/v1/warnings?city=Wroclaw", nil)
+			rr := httptest.NewRecorder()
+
+			testCfg.apiConfig.handlerWeatherWarnings(rr, req)
+
+			if status := rr.Code; status != tc.wantStatus {
+				t.Errorf("handler returned wrong status code: got %v want %v", status, tc.wantStatus)
+			}
+			if rr.Body.String() != tc.wantBody {
+				t.Errorf("handler returned unexpected body:\ngot  %v\nwant %v", rr.Body.String(), tc.wantBody)
+			}
+			if upstreamCalled != tc.wantUpstream {
+				t.Errorf("upstream called = %v, want %v", upstreamCalled, tc.wantUpstream)
+			}
+			if persisted != tc.wantPersisted {
+				t.Errorf("persisted = %v, want %v", persisted, tc.wantPersisted)
+			}
+		})
+	}
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/cor0nius/willitrain/internal/database"
+	"github.com/google/uuid"
+)
+
+// This file implements the watchlist API, which lets clients follow a set of locations.
+// A watchlist is scoped to a subscriber, identified either by an API key or by a device ID
+// sent in the request headers. Every watched location is stored as a regular location, so
+// the scheduler refreshes it on every cycle regardless of how often it is requested.
+// Clients poll /api/watchlist/updates with the cursor from their previous response to
+// receive only the locations whose data changed in the meantime.
+
+// errMissingSubscriber is returned when a watchlist request carries no subscriber identity.
+var errMissingSubscriber = errors.New("either X-API-Key or X-Device-ID header is required")
+
+// getSubscriberID derives the watchlist owner from the request headers. The user of a session
+// token takes precedence over API keys, and API keys over device IDs. API keys are hashed so
+// that raw keys are never stored.
+func getSubscriberID(r *http.Request) (string, error) {
+	if user, ok := userFrom(r.Context()); ok {
+		return userSubscriberID(user.ID), nil
+	}
+	if apiKey := r.Header.Get("X-API-Key"); apiKey != "" {
+		return apiKeySubscriberID(apiKey), nil
+	}
+	if deviceID := r.Header.Get("X-Device-ID"); deviceID != "" {
+		return "device:" + deviceID, nil
+	}
+	return "", errMissingSubscriber
+}
+
+// apiKeySubscriberID returns the subscriber ID of an API key.
+func apiKeySubscriberID(apiKey string) string {
+	return "key:" + apiKeyHash(apiKey)
+}
+
+// apiKeyHash returns the hex-encoded SHA-256 hash of an API key.
+func apiKeyHash(apiKey string) string {
+	sum := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(sum[:])
+}
+
+// handlerWatchlist dispatches watchlist requests by method: GET lists the watched
+// locations, POST adds a location and DELETE removes one.
+
+// @Summary      Manage a watchlist
+// @Description  GET lists the subscriber's watched locations. POST adds the location given by city
+// @Description  or lat/lon. DELETE removes the location given by location_id. The subscriber is
+// @Description  identified by the X-API-Key or X-Device-ID header.
+// @Tags         watchlist
+// @Produce      json
+// @Param        X-API-Key    header    string  false  "API key identifying the subscriber"
+// @Param        X-Device-ID  header    string  false  "Device ID identifying the subscriber"
+// @Param        city         query     string  false  "Location name to watch (POST)"
+// @Param        lat          query     number  false  "Latitude of the location to watch (POST)"
+// @Param        lon          query     number  false  "Longitude of the location to watch (POST)"
+// @Param        location_id  query     string  false  "ID of the location to stop watching (DELETE)"
+// @Success      200  {object}  WatchlistResponse
+// @Success      201  {object}  Location
+// @Failure      400  {object}  ErrorResponse "Bad Request - Missing subscriber or invalid location"
+// @Failure      500  {object}  ErrorResponse "Internal Server Error - Failed to access the watchlist"
+// @Router       /api/v1/watchlist [get]
+// @Router       /api/v1/watchlist [post]
+// @Router       /api/v1/watchlist [delete]
+func (cfg *apiConfig) handlerWatchlist(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost && r.Method != http.MethodDelete {
+		cfg.respondWithError(w, http.StatusMethodNotAllowed, "Method Not Allowed", nil)
+		return
+	}
+
+	subscriberID, err := getSubscriberID(r)
+	if err != nil {
+		cfg.respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		cfg.listWatchlist(w, r, subscriberID)
+	case http.MethodPost:
+		cfg.addToWatchlist(w, r, subscriberID)
+	case http.MethodDelete:
+		cfg.removeFromWatchlist(w, r, subscriberID)
+	}
+}
+
+func (cfg *apiConfig) listWatchlist(w http.ResponseWriter, r *http.Request, subscriberID string) {
+	dbLocations, err := cfg.dbQueries.ListWatchlistLocations(r.Context(), subscriberID)
+	if err != nil {
+		cfg.respondWithError(w, http.StatusInternalServerError, "Failed to get watchlist", err)
+		return
+	}
+
+	locations := make([]Location, len(dbLocations))
+	for i, l := range dbLocations {
+		locations[i] = databaseLocationToLocation(l)
+	}
+	cfg.respondWithJSON(w, http.StatusOK, WatchlistResponse{Locations: locations})
+}
+
+func (cfg *apiConfig) addToWatchlist(w http.ResponseWriter, r *http.Request, subscriberID string) {
+	location, err := cfg.getLocationFromRequest(r)
+	if err != nil {
+		cfg.respondWithError(w, http.StatusBadRequest, "Error getting location data", err)
+		return
+	}
+
+	_, err = cfg.dbQueries.CreateWatchlistEntry(r.Context(), database.CreateWatchlistEntryParams{
+		SubscriberID: subscriberID,
+		LocationID:   location.LocationID,
+		CreatedAt:    time.Now().UTC(),
+	})
+	if err != nil {
+		cfg.respondWithError(w, http.StatusInternalServerError, "Failed to update watchlist", err)
+		return
+	}
+	cfg.logger.Debug("location added to watchlist", "subscriber", subscriberID, "city", location.CityName)
+	cfg.respondWithJSON(w, http.StatusCreated, location)
+}
+
+func (cfg *apiConfig) removeFromWatchlist(w http.ResponseWriter, r *http.Request, subscriberID string) {
+	locationID, err := uuid.Parse(r.URL.Query().Get("location_id"))
+	if err != nil {
+		cfg.respondWithError(w, http.StatusBadRequest, "Invalid location ID", err)
+		return
+	}
+
+	err = cfg.dbQueries.DeleteWatchlistEntry(r.Context(), database.DeleteWatchlistEntryParams{
+		SubscriberID: subscriberID,
+		LocationID:   locationID,
+	})
+	if err != nil {
+		cfg.respondWithError(w, http.StatusInternalServerError, "Failed to update watchlist", err)
+		return
+	}
+	cfg.respondWithJSON(w, http.StatusOK, map[string]string{"status": "location removed from watchlist"})
+}
+
+// @Summary      Poll watchlist updates
+// @Description  Returns the watched locations whose weather data changed after the supplied cursor,
+// @Description  together with a new cursor to pass on the next poll. Omitting the cursor returns
+// @Description  every watched location that has any data.
+// @Tags         watchlist
+// @Produce      json
+// @Param        X-API-Key    header    string  false  "API key identifying the subscriber"
+// @Param        X-Device-ID  header    string  false  "Device ID identifying the subscriber"
+// @Param        cursor       query     string  false  "Cursor returned by the previous poll (RFC 3339 timestamp)"
+// @Success      200  {object}  WatchlistUpdatesResponse
+// @Failure      400  {object}  ErrorResponse "Bad Request - Missing subscriber or invalid cursor"
+// @Failure      500  {object}  ErrorResponse "Internal Server Error - Failed to get updates"
+// @Router       /api/v1/watchlist/updates [get]
+func (cfg *apiConfig) handlerWatchlistUpdates(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		cfg.respondWithError(w, http.StatusMethodNotAllowed, "Method Not Allowed", nil)
+		return
+	}
+
+	subscriberID, err := getSubscriberID(r)
+	if err != nil {
+		cfg.respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	var since time.Time
+	if cursor := r.URL.Query().Get("cursor"); cursor != "" {
+		since, err = time.Parse(time.RFC3339Nano, cursor)
+		if err != nil {
+			cfg.respondWithError(w, http.StatusBadRequest, "Invalid cursor", err)
+			return
+		}
+	}
+
+	rows, err := cfg.dbQueries.GetWatchlistUpdates(r.Context(), database.GetWatchlistUpdatesParams{
+		SubscriberID: subscriberID,
+		Since:        since,
+	})
+	if err != nil {
+		cfg.respondWithError(w, http.StatusInternalServerError, "Failed to get watchlist updates", err)
+		return
+	}
+
+	next := since
+	updates := make([]WatchlistUpdateJSON, len(rows))
+	for i, row := range rows {
+		updates[i] = WatchlistUpdateJSON{
+			Location: databaseLocationToLocation(database.Location{
+				ID:          row.ID,
+				CityName:    row.CityName,
+				Latitude:    row.Latitude,
+				Longitude:   row.Longitude,
+				CountryCode: row.CountryCode,
+				Timezone:    row.Timezone,
+			}),
+			UpdatedAt: row.LastUpdated.UTC().Format(time.RFC3339Nano),
+		}
+		if row.LastUpdated.After(next) {
+			next = row.LastUpdated
+		}
+	}
+
+	response := WatchlistUpdatesResponse{
+		Updates: updates,
+	}
+	if !next.IsZero() {
+		response.Cursor = next.UTC().Format(time.RFC3339Nano)
+	}
+	cfg.respondWithJSON(w, http.StatusOK, response)
+}
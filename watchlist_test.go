@@ -0,0 +1,268 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cor0nius/willitrain/internal/database"
+	"github.com/google/uuid"
+)
+
+func TestGetSubscriberID(t *testing.T) {
+	testCases := []struct {
+		name     string
+		headers  map[string]string
+		want     string
+		wantErr  bool
+		wantHash bool
+	}{
+		{
+			name:     "API key takes precedence",
+			headers:  map[string]string{"X-API-Key": "secret", "X-Device-ID": "esp-1"},
+			wantHash: true,
+		},
+		{
+			name:    "Device ID",
+			headers: map[string]string{"X-Device-ID": "esp-1"},
+			want:    "device:esp-1",
+		},
+		{
+			name:    "Missing",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/watchlist", nil)
+			for k, v := range tc.headers {
+				req.Header.Set(k, v)
+			}
+
+			got, err := getSubscriberID(req)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("expected error: %v, got: %v", tc.wantErr, err)
+			}
+			if tc.wantHash {
+				if !strings.HasPrefix(got, "key:") || strings.Contains(got, "secret") {
+					t.Errorf("expected hashed API key subscriber, got %q", got)
+				}
+				return
+			}
+			if got != tc.want {
+				t.Errorf("expected %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestHandlerWatchlist(t *testing.T) {
+	testCases := []struct {
+		name       string
+		method     string
+		target     string
+		deviceID   string
+		setupMocks func(cfg *testAPIConfig)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:     "List",
+			method:   http.MethodGet,
+			target:   "/api/watchlist",
+			deviceID: "esp-1",
+			setupMocks: func(cfg *testAPIConfig) {
+				cfg.mockDB.ListWatchlistLocationsFunc = func(ctx context.Context, subscriberID string) ([]database.Location, error) {
+					if subscriberID != "device:esp-1" {
+						t.Errorf("unexpected subscriber: %s", subscriberID)
+					}
+					return []database.Location{MockDBLocation}, nil
+				}
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `{"locations":[{"location_id":"` + MockLocation.LocationID.String() + `","city_name":"Wroclaw","latitude":51.1,"longitude":17.03,"country_code":"PL"}]}`,
+		},
+		{
+			name:     "Add",
+			method:   http.MethodPost,
+			target:   "/api/watchlist?city=Wroclaw",
+			deviceID: "esp-1",
+			setupMocks: func(cfg *testAPIConfig) {
+				cfg.mockDB.GetLocationByAliasFunc = func(ctx context.Context, alias string) (database.Location, error) {
+					return MockDBLocation, nil
+				}
+				cfg.mockDB.CreateWatchlistEntryFunc = func(ctx context.Context, arg database.CreateWatchlistEntryParams) (database.WatchlistEntry, error) {
+					if arg.LocationID != MockLocation.LocationID {
+						t.Errorf("unexpected location ID: %s", arg.LocationID)
+					}
+					return database.WatchlistEntry{}, nil
+				}
+			},
+			wantStatus: http.StatusCreated,
+			wantBody:   `{"location_id":"` + MockLocation.LocationID.String() + `","city_name":"Wroclaw","latitude":51.1,"longitude":17.03,"country_code":"PL"}`,
+		},
+		{
+			name:     "Add - DB Error",
+			method:   http.MethodPost,
+			target:   "/api/watchlist?city=Wroclaw",
+			deviceID: "esp-1",
+			setupMocks: func(cfg *testAPIConfig) {
+				cfg.mockDB.GetLocationByAliasFunc = func(ctx context.Context, alias string) (database.Location, error) {
+					return MockDBLocation, nil
+				}
+				cfg.mockDB.CreateWatchlistEntryFunc = func(ctx context.Context, arg database.CreateWatchlistEntryParams) (database.WatchlistEntry, error) {
+					return database.WatchlistEntry{}, errors.New("db error")
+				}
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   `{"error":"Failed to update watchlist"}`,
+		},
+		{
+			name:     "Remove",
+			method:   http.MethodDelete,
+			target:   "/api/watchlist?location_id=" + MockLocation.LocationID.String(),
+			deviceID: "esp-1",
+			setupMocks: func(cfg *testAPIConfig) {
+				cfg.mockDB.DeleteWatchlistEntryFunc = func(ctx context.Context, arg database.DeleteWatchlistEntryParams) error {
+					return nil
+				}
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `{"status":"location removed from watchlist"}`,
+		},
+		{
+			name:       "Remove - Invalid Location ID",
+			method:     http.MethodDelete,
+			target:     "/api/watchlist?location_id=abc",
+			deviceID:   "esp-1",
+			setupMocks: func(cfg *testAPIConfig) {},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"Invalid location ID"}`,
+		},
+		{
+			name:       "Missing Subscriber",
+			method:     http.MethodGet,
+			target:     "/api/watchlist",
+			setupMocks: func(cfg *testAPIConfig) {},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"either X-API-Key or X-Device-ID header is required"}`,
+		},
+		{
+			name:       "Wrong Method",
+			method:     http.MethodPut,
+			target:     "/api/watchlist",
+			deviceID:   "esp-1",
+			setupMocks: func(cfg *testAPIConfig) {},
+			wantStatus: http.StatusMethodNotAllowed,
+			wantBody:   `{"error":"Method Not Allowed"}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testCfg := newTestAPIConfig(t)
+			tc.setupMocks(testCfg)
+
+			req := httptest.NewRequest(tc.method, tc.target, nil)
+			if tc.deviceID != "" {
+				req.Header.Set("X-Device-ID", tc.deviceID)
+			}
+			rr := httptest.NewRecorder()
+
+			testCfg.apiConfig.handlerWatchlist(rr, req)
+
+			if rr.Code != tc.wantStatus {
+				t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, tc.wantStatus)
+			}
+			if rr.Body.String() != tc.wantBody {
+				t.Errorf("handler returned unexpected body: got %v want %v", rr.Body.String(), tc.wantBody)
+			}
+		})
+	}
+}
+
+func TestHandlerWatchlistUpdates(t *testing.T) {
+	cursor := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	updatedAt := cursor.Add(10 * time.Minute)
+	otherID := uuid.New()
+
+	testCases := []struct {
+		name       string
+		target     string
+		setupMocks func(cfg *testAPIConfig)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:   "Changes Since Cursor",
+			target: "/api/watchlist/updates?cursor=" + cursor.Format(time.RFC3339Nano),
+			setupMocks: func(cfg *testAPIConfig) {
+				cfg.mockDB.GetWatchlistUpdatesFunc = func(ctx context.Context, arg database.GetWatchlistUpdatesParams) ([]database.GetWatchlistUpdatesRow, error) {
+					if !arg.Since.Equal(cursor) {
+						t.Errorf("expected since %v, got %v", cursor, arg.Since)
+					}
+					return []database.GetWatchlistUpdatesRow{
+						{ID: otherID, CityName: "Berlin", CountryCode: "DE", LastUpdated: updatedAt},
+					}, nil
+				}
+			},
+			wantStatus: http.StatusOK,
+			wantBody: `{"cursor":"2025-06-01T12:10:00Z","updates":[{"location":{"location_id":"` + otherID.String() +
+				`","city_name":"Berlin","latitude":0,"longitude":0,"country_code":"DE"},"updated_at":"2025-06-01T12:10:00Z"}]}`,
+		},
+		{
+			name:   "No Changes Keeps Cursor",
+			target: "/api/watchlist/updates?cursor=" + cursor.Format(time.RFC3339Nano),
+			setupMocks: func(cfg *testAPIConfig) {
+				cfg.mockDB.GetWatchlistUpdatesFunc = func(ctx context.Context, arg database.GetWatchlistUpdatesParams) ([]database.GetWatchlistUpdatesRow, error) {
+					return nil, nil
+				}
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `{"cursor":"2025-06-01T12:00:00Z","updates":[]}`,
+		},
+		{
+			name:       "Invalid Cursor",
+			target:     "/api/watchlist/updates?cursor=yesterday",
+			setupMocks: func(cfg *testAPIConfig) {},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"Invalid cursor"}`,
+		},
+		{
+			name:   "DB Error",
+			target: "/api/watchlist/updates",
+			setupMocks: func(cfg *testAPIConfig) {
+				cfg.mockDB.GetWatchlistUpdatesFunc = func(ctx context.Context, arg database.GetWatchlistUpdatesParams) ([]database.GetWatchlistUpdatesRow, error) {
+					return nil, errors.New("db error")
+				}
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   `{"error":"Failed to get watchlist updates"}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testCfg := newTestAPIConfig(t)
+			tc.setupMocks(testCfg)
+
+			req := httptest.NewRequest(http.MethodGet, tc.target, nil)
+			req.Header.Set("X-Device-ID", "esp-1")
+			rr := httptest.NewRecorder()
+
+			testCfg.apiConfig.handlerWatchlistUpdates(rr, req)
+
+			if rr.Code != tc.wantStatus {
+				t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, tc.wantStatus)
+			}
+			if rr.Body.String() != tc.wantBody {
+				t.Errorf("handler returned unexpected body: got %v want %v", rr.Body.String(), tc.wantBody)
+			}
+		})
+	}
+}
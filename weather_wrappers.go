@@ -5,54 +5,85 @@ import (
 )
 
 // The WrapFor... functions are responsible for constructing the full request URLs
-// for the various external weather APIs (Google Weather, OpenWeatherMap, and Open-Meteo).
+// for the various external weather APIs (Google Weather, OpenWeatherMap, Open-Meteo and Met.no).
 // Each function takes a Location and prepares a map of API-specific URLs
 // for a particular type of forecast (current, daily, or hourly).
 
 func (cfg *apiConfig) WrapForCurrentWeather(location Location) map[string]string {
 
-	gmpWrappedURL := fmt.Sprintf("%scurrentConditions:lookup?key=%s&location.latitude=%.2f&location.longitude=%.2f", cfg.gmpWeatherURL, cfg.gmpKey, location.Latitude, location.Longitude)
+	gmpWrappedURL := fmt.Sprintf("%scurrentConditions:lookup?key=%s&location.latitude=%.2f&location.longitude=%.2f", cfg.gmpWeatherURL, cfg.gmpKey.Get(), location.Latitude, location.Longitude)
 
-	owmWrappedURL := fmt.Sprintf("%slat=%.2f&lon=%.2f&exclude=minutely,hourly,daily,alerts&units=metric&appid=%s", cfg.owmWeatherURL, location.Latitude, location.Longitude, cfg.owmKey)
+	owmWrappedURL := cfg.owmURL(location, owmCurrent)
 
-	ometeoParameters := "temperature_2m,relative_humidity_2m,wind_speed_10m,precipitation,weather_code"
-	ometeoWrappedURL := fmt.Sprintf("%slatitude=%.2f&longitude=%.2f&current=%s&timezone=auto&timeformat=unixtime", cfg.ometeoWeatherURL, location.Latitude, location.Longitude, ometeoParameters)
+	ometeoParameters := "temperature_2m,apparent_temperature,dew_point_2m,relative_humidity_2m,wind_speed_10m,wind_direction_10m,wind_gusts_10m,precipitation,rain,showers,weather_code,uv_index"
+	ometeoWrappedURL := fmt.Sprintf("%slatitude=%.2f&longitude=%.2f&current=%s&daily=sunrise,sunset&forecast_days=1&timezone=auto&timeformat=unixtime", cfg.ometeoWeatherURL, location.Latitude, location.Longitude, ometeoParameters)
 
 	return map[string]string{
 		"gmpWrappedURL":    gmpWrappedURL,
 		"owmWrappedURL":    owmWrappedURL,
 		"ometeoWrappedURL": ometeoWrappedURL,
+		"metnoWrappedURL":  cfg.metnoURL(location),
 	}
 }
 
 func (cfg *apiConfig) WrapForDailyForecast(location Location) map[string]string {
 
-	gmpWrappedURL := fmt.Sprintf("%sforecast/days:lookup?key=%s&location.latitude=%.2f&location.longitude=%.2f", cfg.gmpWeatherURL, cfg.gmpKey, location.Latitude, location.Longitude)
+	days := cfg.dailyForecastDays()
+	gmpDays := min(days, gmpMaxForecastDays)
+	gmpWrappedURL := fmt.Sprintf("%sforecast/days:lookup?key=%s&location.latitude=%.2f&location.longitude=%.2f&days=%d&pageSize=%d", cfg.gmpWeatherURL, cfg.gmpKey.Get(), location.Latitude, location.Longitude, gmpDays, gmpDays)
 
-	owmWrappedURL := fmt.Sprintf("%slat=%.2f&lon=%.2f&exclude=current,minutely,hourly,alerts&units=metric&appid=%s", cfg.owmWeatherURL, location.Latitude, location.Longitude, cfg.owmKey)
+	owmWrappedURL := cfg.owmURL(location, owmDaily)
 
-	ometeoParameters := "temperature_2m_max,temperature_2m_min,precipitation_sum,precipitation_probability_max,wind_speed_10m_max,weather_code,relative_humidity_2m_max"
-	ometeoWrappedURL := fmt.Sprintf("%slatitude=%.2f&longitude=%.2f&daily=%s&timezone=auto&timeformat=unixtime", cfg.ometeoWeatherURL, location.Latitude, location.Longitude, ometeoParameters)
+	ometeoParameters := "temperature_2m_max,temperature_2m_min,precipitation_sum,rain_sum,showers_sum,precipitation_probability_max,wind_speed_10m_max,wind_direction_10m_dominant,wind_gusts_10m_max,weather_code,relative_humidity_2m_max,uv_index_max,sunrise,sunset"
+	ometeoWrappedURL := fmt.Sprintf("%slatitude=%.2f&longitude=%.2f&daily=%s&forecast_days=%d&timezone=auto&timeformat=unixtime", cfg.ometeoWeatherURL, location.Latitude, location.Longitude, ometeoParameters, days)
 
 	return map[string]string{
 		"gmpWrappedURL":    gmpWrappedURL,
 		"owmWrappedURL":    owmWrappedURL,
 		"ometeoWrappedURL": ometeoWrappedURL,
+		"metnoWrappedURL":  cfg.metnoURL(location),
 	}
 }
 
 func (cfg *apiConfig) WrapForHourlyForecast(location Location) map[string]string {
 
-	gmpWrappedURL := fmt.Sprintf("%sforecast/hours:lookup?key=%s&location.latitude=%.2f&location.longitude=%.2f", cfg.gmpWeatherURL, cfg.gmpKey, location.Latitude, location.Longitude)
+	hours := cfg.hourlyForecastHours()
+	gmpHours := min(hours, gmpMaxForecastHours)
+	gmpWrappedURL := fmt.Sprintf("%sforecast/hours:lookup?key=%s&location.latitude=%.2f&location.longitude=%.2f&hours=%d&pageSize=%d", cfg.gmpWeatherURL, cfg.gmpKey.Get(), location.Latitude, location.Longitude, gmpHours, gmpHours)
 
-	owmWrappedURL := fmt.Sprintf("%slat=%.2f&lon=%.2f&exclude=current,minutely,daily,alerts&units=metric&appid=%s", cfg.owmWeatherURL, location.Latitude, location.Longitude, cfg.owmKey)
+	owmWrappedURL := cfg.owmURL(location, owmHourly)
 
-	ometeoParameters := "temperature_2m,relative_humidity_2m,wind_speed_10m,precipitation,precipitation_probability,weather_code&forecast_days=2"
-	ometeoWrappedURL := fmt.Sprintf("%slatitude=%.2f&longitude=%.2f&hourly=%s&timezone=auto&timeformat=unixtime", cfg.ometeoWeatherURL, location.Latitude, location.Longitude, ometeoParameters)
+	// Open-Meteo returns whole days from local midnight, so one more day than the hours span is
+	// requested.
+	ometeoDays := min((hours+23)/24+1, maxForecastDays)
+	ometeoParameters := "temperature_2m,apparent_temperature,dew_point_2m,relative_humidity_2m,wind_speed_10m,wind_direction_10m,wind_gusts_10m,precipitation,rain,showers,precipitation_probability,weather_code"
+	ometeoWrappedURL := fmt.Sprintf("%slatitude=%.2f&longitude=%.2f&hourly=%s&forecast_days=%d&timezone=auto&timeformat=unixtime", cfg.ometeoWeatherURL, location.Latitude, location.Longitude, ometeoParameters, ometeoDays)
 
 	return map[string]string{
 		"gmpWrappedURL":    gmpWrappedURL,
 		"owmWrappedURL":    owmWrappedURL,
 		"ometeoWrappedURL": ometeoWrappedURL,
+		"metnoWrappedURL":  cfg.metnoURL(location),
 	}
 }
+
+// WrapForAirQuality prepares the air quality URLs. Only OpenWeatherMap and Open-Meteo publish air
+// quality data, through their Air Pollution and Air Quality APIs.
+func (cfg *apiConfig) WrapForAirQuality(location Location) map[string]string {
+
+	owmWrappedURL := fmt.Sprintf("%slat=%.2f&lon=%.2f&appid=%s", cfg.owmAirPollutionURL, location.Latitude, location.Longitude, cfg.owmKey.Get())
+
+	ometeoParameters := "european_aqi,pm2_5,pm10,ozone"
+	ometeoWrappedURL := fmt.Sprintf("%slatitude=%.2f&longitude=%.2f&current=%s&timezone=auto&timeformat=unixtime", cfg.ometeoAirQualityURL, location.Latitude, location.Longitude, ometeoParameters)
+
+	return map[string]string{
+		"owmWrappedURL":    owmWrappedURL,
+		"ometeoWrappedURL": ometeoWrappedURL,
+	}
+}
+
+// metnoURL returns the Met.no Locationforecast URL for a location. Met.no serves all forecast
+// types in one response, so the same URL is used for each of them.
+func (cfg *apiConfig) metnoURL(location Location) string {
+	return fmt.Sprintf("%slat=%.2f&lon=%.2f", cfg.metnoWeatherURL, location.Latitude, location.Longitude)
+}
@@ -1,16 +1,18 @@
 package main
 
 import (
+	"strings"
 	"testing"
 )
 
 func TestURLWrappers(t *testing.T) {
 	cfg := apiConfig{
 		gmpWeatherURL:    "https://weather.googleapis.com/v1/",
-		gmpKey:           "gmpKey",
+		gmpKey:           newSecretValue("gmpKey"),
 		owmWeatherURL:    "https://api.openweathermap.org/data/3.0/onecall?",
-		owmKey:           "owmKey",
+		owmKey:           newSecretValue("owmKey"),
 		ometeoWeatherURL: "https://api.open-meteo.com/v1/forecast?",
+		metnoWeatherURL:  "https://api.met.no/weatherapi/locationforecast/2.0/complete?",
 	}
 
 	location := Location{Latitude: 51.1093, Longitude: 17.0386} // Example coordinates for Wrocław
@@ -24,27 +26,30 @@ func TestURLWrappers(t *testing.T) {
 			name:        "CurrentWeather",
 			wrapperFunc: cfg.WrapForCurrentWeather,
 			expectedURLs: map[string]string{
-				"gmpWrappedURL":    "https://weather.googleapis.com/v1/currentConditions:lookup?key=" + cfg.gmpKey + "&location.latitude=51.11&location.longitude=17.04",
-				"owmWrappedURL":    "https://api.openweathermap.org/data/3.0/onecall?lat=51.11&lon=17.04&exclude=minutely,hourly,daily,alerts&units=metric&appid=" + cfg.owmKey,
-				"ometeoWrappedURL": "https://api.open-meteo.com/v1/forecast?latitude=51.11&longitude=17.04&current=temperature_2m,relative_humidity_2m,wind_speed_10m,precipitation,weather_code&timezone=auto&timeformat=unixtime",
+				"gmpWrappedURL":    "https://weather.googleapis.com/v1/currentConditions:lookup?key=" + cfg.gmpKey.Get() + "&location.latitude=51.11&location.longitude=17.04",
+				"owmWrappedURL":    "https://api.openweathermap.org/data/3.0/onecall?lat=51.11&lon=17.04&exclude=minutely,hourly,daily,alerts&units=metric&appid=" + cfg.owmKey.Get(),
+				"ometeoWrappedURL": "https://api.open-meteo.com/v1/forecast?latitude=51.11&longitude=17.04&current=temperature_2m,apparent_temperature,dew_point_2m,relative_humidity_2m,wind_speed_10m,wind_direction_10m,wind_gusts_10m,precipitation,rain,showers,weather_code,uv_index&daily=sunrise,sunset&forecast_days=1&timezone=auto&timeformat=unixtime",
+				"metnoWrappedURL":  "https://api.met.no/weatherapi/locationforecast/2.0/complete?lat=51.11&lon=17.04",
 			},
 		},
 		{
 			name:        "DailyForecast",
 			wrapperFunc: cfg.WrapForDailyForecast,
 			expectedURLs: map[string]string{
-				"gmpWrappedURL":    "https://weather.googleapis.com/v1/forecast/days:lookup?key=" + cfg.gmpKey + "&location.latitude=51.11&location.longitude=17.04",
-				"owmWrappedURL":    "https://api.openweathermap.org/data/3.0/onecall?lat=51.11&lon=17.04&exclude=current,minutely,hourly,alerts&units=metric&appid=" + cfg.owmKey,
-				"ometeoWrappedURL": "https://api.open-meteo.com/v1/forecast?latitude=51.11&longitude=17.04&daily=temperature_2m_max,temperature_2m_min,precipitation_sum,precipitation_probability_max,wind_speed_10m_max,weather_code,relative_humidity_2m_max&timezone=auto&timeformat=unixtime",
+				"gmpWrappedURL":    "https://weather.googleapis.com/v1/forecast/days:lookup?key=" + cfg.gmpKey.Get() + "&location.latitude=51.11&location.longitude=17.04&days=5&pageSize=5",
+				"owmWrappedURL":    "https://api.openweathermap.org/data/3.0/onecall?lat=51.11&lon=17.04&exclude=current,minutely,hourly,alerts&units=metric&appid=" + cfg.owmKey.Get(),
+				"ometeoWrappedURL": "https://api.open-meteo.com/v1/forecast?latitude=51.11&longitude=17.04&daily=temperature_2m_max,temperature_2m_min,precipitation_sum,rain_sum,showers_sum,precipitation_probability_max,wind_speed_10m_max,wind_direction_10m_dominant,wind_gusts_10m_max,weather_code,relative_humidity_2m_max,uv_index_max,sunrise,sunset&forecast_days=5&timezone=auto&timeformat=unixtime",
+				"metnoWrappedURL":  "https://api.met.no/weatherapi/locationforecast/2.0/complete?lat=51.11&lon=17.04",
 			},
 		},
 		{
 			name:        "HourlyForecast",
 			wrapperFunc: cfg.WrapForHourlyForecast,
 			expectedURLs: map[string]string{
-				"gmpWrappedURL":    "https://weather.googleapis.com/v1/forecast/hours:lookup?key=" + cfg.gmpKey + "&location.latitude=51.11&location.longitude=17.04",
-				"owmWrappedURL":    "https://api.openweathermap.org/data/3.0/onecall?lat=51.11&lon=17.04&exclude=current,minutely,daily,alerts&units=metric&appid=" + cfg.owmKey,
-				"ometeoWrappedURL": "https://api.open-meteo.com/v1/forecast?latitude=51.11&longitude=17.04&hourly=temperature_2m,relative_humidity_2m,wind_speed_10m,precipitation,precipitation_probability,weather_code&forecast_days=2&timezone=auto&timeformat=unixtime",
+				"gmpWrappedURL":    "https://weather.googleapis.com/v1/forecast/hours:lookup?key=" + cfg.gmpKey.Get() + "&location.latitude=51.11&location.longitude=17.04&hours=24&pageSize=24",
+				"owmWrappedURL":    "https://api.openweathermap.org/data/3.0/onecall?lat=51.11&lon=17.04&exclude=current,minutely,daily,alerts&units=metric&appid=" + cfg.owmKey.Get(),
+				"ometeoWrappedURL": "https://api.open-meteo.com/v1/forecast?latitude=51.11&longitude=17.04&hourly=temperature_2m,apparent_temperature,dew_point_2m,relative_humidity_2m,wind_speed_10m,wind_direction_10m,wind_gusts_10m,precipitation,rain,showers,precipitation_probability,weather_code&forecast_days=2&timezone=auto&timeformat=unixtime",
+				"metnoWrappedURL":  "https://api.met.no/weatherapi/locationforecast/2.0/complete?lat=51.11&lon=17.04",
 			},
 		},
 	}
@@ -68,3 +73,29 @@ func TestURLWrappers(t *testing.T) {
 		})
 	}
 }
+
+func TestURLWrappersForecastHorizon(t *testing.T) {
+	cfg := apiConfig{
+		gmpWeatherURL:    "https://weather.googleapis.com/v1/",
+		ometeoWeatherURL: "https://api.open-meteo.com/v1/forecast?",
+		forecastDays:     14,
+		forecastHours:    72,
+	}
+	location := Location{Latitude: 51.1093, Longitude: 17.0386}
+
+	daily := cfg.WrapForDailyForecast(location)
+	if !strings.HasSuffix(daily["gmpWrappedURL"], "&days=10&pageSize=10") {
+		t.Errorf("expected the Google request to be capped at 10 days, got %s", daily["gmpWrappedURL"])
+	}
+	if !strings.Contains(daily["ometeoWrappedURL"], "&forecast_days=14&") {
+		t.Errorf("expected 14 forecast days, got %s", daily["ometeoWrappedURL"])
+	}
+
+	hourly := cfg.WrapForHourlyForecast(location)
+	if !strings.HasSuffix(hourly["gmpWrappedURL"], "&hours=24&pageSize=24") {
+		t.Errorf("expected the Google request to be capped at 24 hours, got %s", hourly["gmpWrappedURL"])
+	}
+	if !strings.Contains(hourly["ometeoWrappedURL"], "&forecast_days=4&") {
+		t.Errorf("expected 4 forecast days to cover 72 hours, got %s", hourly["ometeoWrappedURL"])
+	}
+}
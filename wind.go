@@ -0,0 +1,23 @@
+package main
+
+import "math"
+
+// This file formats the wind direction for API responses. Providers report the direction the
+// wind blows from in degrees clockwise from north; responses carry the degrees together with the
+// 16-point compass direction, e.g. 225 and "SW", for clients that display one or the other.
+
+// compassPoints are the 16 compass directions, clockwise from north.
+var compassPoints = []string{"N", "NNE", "NE", "ENE", "E", "ESE", "SE", "SSE", "S", "SSW", "SW", "WSW", "W", "WNW", "NW", "NNW"}
+
+// compassDirection returns the 16-point compass direction nearest to a direction in degrees, or
+// an empty string if the direction is missing.
+func compassDirection(degrees *float64) string {
+	if degrees == nil {
+		return ""
+	}
+	sector := int(math.Round(math.Mod(*degrees, 360) / 22.5))
+	if sector < 0 {
+		sector += len(compassPoints)
+	}
+	return compassPoints[sector%len(compassPoints)]
+}
@@ -0,0 +1,26 @@
+package main
+
+import "testing"
+
+func TestCompassDirection(t *testing.T) {
+	testCases := []struct {
+		degrees *float64
+		want    string
+	}{
+		{nil, ""},
+		{optional(0), "N"},
+		{optional(11.24), "N"},
+		{optional(11.25), "NNE"},
+		{optional(90), "E"},
+		{optional(225), "SW"},
+		{optional(350), "N"},
+		{optional(360), "N"},
+		{optional(-45), "NW"},
+	}
+
+	for _, tc := range testCases {
+		if got := compassDirection(tc.degrees); got != tc.want {
+			t.Errorf("compassDirection(%s) = %q, want %q", formatOptional(tc.degrees), got, tc.want)
+		}
+	}
+}